@@ -8,11 +8,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/apikeys"
 	"maglev.onebusaway.org/internal/app"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/clock"
@@ -20,6 +23,7 @@ import (
 	"maglev.onebusaway.org/internal/logging"
 	"maglev.onebusaway.org/internal/metrics"
 	"maglev.onebusaway.org/internal/restapi"
+	"maglev.onebusaway.org/internal/utils"
 	"maglev.onebusaway.org/internal/webui"
 )
 
@@ -32,9 +36,32 @@ func gtfsConfigFromData(gtfsCfgData appconf.GtfsConfigData) gtfs.Config {
 		Env:                   gtfsCfgData.Env,
 		Verbose:               gtfsCfgData.Verbose,
 		EnableGTFSTidy:        gtfsCfgData.EnableGTFSTidy,
+		StaticRefreshInterval: gtfsCfgData.StaticRefreshInterval,
+		StaticDownloadTimeout: gtfsCfgData.StaticDownloadTimeout,
+		ReferentialIntegrity:  gtfsdb.IntegrityMode(gtfsCfgData.ReferentialIntegrity),
+		DBReadConnections:     gtfsCfgData.DBReadConnections,
+
+		VehicleHistoryRetention:   gtfsCfgData.VehicleHistoryRetention,
+		DeviationSmoothingSamples: gtfsCfgData.DeviationSmoothingSamples,
+		DetourThresholdMeters:     gtfsCfgData.DetourThresholdMeters,
+		DetourConsecutiveUpdates:  gtfsCfgData.DetourConsecutiveUpdates,
+		ReplayDir:                 gtfsCfgData.ReplayDir,
+		ReplaySpeed:               gtfsCfgData.ReplaySpeed,
+		ReplayLoop:                gtfsCfgData.ReplayLoop,
+		VehicleCapacityFile:       gtfsCfgData.VehicleCapacityFile,
+		VehicleCapacities:         gtfsCfgData.VehicleCapacities,
 	}
 
 	for _, feedData := range gtfsCfgData.RTFeeds {
+		// The patterns were validated when the JSON config was loaded.
+		var rewrites []gtfs.VehicleIDRewrite
+		for _, rewrite := range feedData.VehicleIDRewrites {
+			rewrites = append(rewrites, gtfs.VehicleIDRewrite{
+				Pattern:     regexp.MustCompile(rewrite.Pattern),
+				Replacement: rewrite.Replacement,
+			})
+		}
+
 		gtfsCfg.RTFeeds = append(gtfsCfg.RTFeeds, gtfs.RTFeedConfig{
 			ID:                  feedData.ID,
 			AgencyIDs:           feedData.AgencyIDs,
@@ -43,7 +70,20 @@ func gtfsConfigFromData(gtfsCfgData appconf.GtfsConfigData) gtfs.Config {
 			ServiceAlertsURL:    feedData.ServiceAlertsURL,
 			Headers:             feedData.Headers,
 			RefreshInterval:     feedData.RefreshInterval,
-			Enabled:             feedData.Enabled,
+
+			TripUpdatesInterval:      feedData.TripUpdatesInterval,
+			VehiclePositionsInterval: feedData.VehiclePositionsInterval,
+			ServiceAlertsInterval:    feedData.ServiceAlertsInterval,
+			Enabled:                  feedData.Enabled,
+			VehicleIDRewrites:        rewrites,
+			Priority:                 feedData.Priority,
+		})
+	}
+
+	for _, region := range gtfsCfgData.Regions {
+		gtfsCfg.Regions = append(gtfsCfg.Regions, gtfs.RegionConfig{
+			ID:     region.ID,
+			Config: gtfsConfigFromData(region.GtfsConfigData),
 		})
 	}
 
@@ -70,18 +110,59 @@ func ParseAPIKeys(apiKeysFlag string) []string {
 func BuildApplication(cfg appconf.Config, gtfsCfg gtfs.Config) (*app.Application, error) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
+	// Raw IDs carry no agency, so they are read as the main feed's agency,
+	// which would misread the IDs of every region.
+	if cfg.RawIDs && len(gtfsCfg.Regions) > 0 {
+		return nil, fmt.Errorf("raw IDs cannot be combined with regions")
+	}
+
 	gtfsManager, err := gtfs.InitGTFSManager(gtfsCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize GTFS manager: %w", err)
 	}
 
+	if err := configureIDCodec(cfg, gtfsManager); err != nil {
+		if gtfsManager != nil {
+			gtfsManager.Shutdown()
+		}
+		return nil, err
+	}
+
+	regionManagers, err := initRegionManagers(gtfsCfg.Regions)
+	if err != nil {
+		if gtfsManager != nil {
+			gtfsManager.Shutdown()
+		}
+		return nil, err
+	}
+
+	utils.SetGeodesicProjection(cfg.GeodesicProjection)
+
 	var directionCalculator *gtfs.AdvancedDirectionCalculator
 	if gtfsManager != nil {
 		directionCalculator = gtfs.NewAdvancedDirectionCalculator(gtfsManager.GtfsDB.Queries)
 	}
 
-	// Select clock implementation based on environment
+	// Select clock implementation based on environment; a realtime replay
+	// brings its own clock, running at the time of the replayed data.
 	appClock := createClock(cfg.Env)
+	if gtfsManager != nil && gtfsManager.ReplayClock() != nil {
+		appClock = gtfsManager.ReplayClock()
+	}
+
+	var apiKeyStore *apikeys.Store
+	if cfg.ApiKeyDBPath != "" {
+		apiKeyStore, err = apikeys.Open(cfg.ApiKeyDBPath, appClock)
+		if err != nil {
+			if gtfsManager != nil {
+				gtfsManager.Shutdown()
+			}
+			for _, manager := range regionManagers {
+				manager.Shutdown()
+			}
+			return nil, fmt.Errorf("failed to open API key store: %w", err)
+		}
+	}
 
 	// Initialize metrics with logger for error reporting
 	appMetrics := metrics.NewWithLogger(logger)
@@ -94,6 +175,25 @@ func BuildApplication(cfg appconf.Config, gtfsCfg gtfs.Config) (*app.Application
 		DirectionCalculator: directionCalculator,
 		Clock:               appClock,
 		Metrics:             appMetrics,
+		APIKeys:             apiKeyStore,
+	}
+
+	// Regions share everything but their dataset with the main application.
+	for i, region := range gtfsCfg.Regions {
+		manager := regionManagers[i]
+		coreApp.Regions = append(coreApp.Regions, app.Region{
+			ID: region.ID,
+			Application: &app.Application{
+				Config:              cfg,
+				GtfsConfig:          region.Config,
+				Logger:              logger.With("region", region.ID),
+				GtfsManager:         manager,
+				DirectionCalculator: gtfs.NewAdvancedDirectionCalculator(manager.GtfsDB.Queries),
+				Clock:               appClock,
+				Metrics:             appMetrics,
+				APIKeys:             apiKeyStore,
+			},
+		})
 	}
 
 	// Start DB stats collector if database is available
@@ -101,9 +201,57 @@ func BuildApplication(cfg appconf.Config, gtfsCfg gtfs.Config) (*app.Application
 		appMetrics.StartDBStatsCollector(gtfsManager.GtfsDB.DB, 15*time.Second)
 	}
 
+	if gtfsManager != nil {
+		appMetrics.RegisterEntityCacheStats(func() map[string]metrics.CacheStats {
+			stats := make(map[string]metrics.CacheStats)
+			for entity, s := range gtfsManager.EntityCacheStats() {
+				stats[entity] = metrics.CacheStats{Hits: s.Hits, Misses: s.Misses}
+			}
+			return stats
+		})
+	}
+
 	return coreApp, nil
 }
 
+// initRegionManagers loads the dataset of every region. If one fails, those
+// already loaded are shut down.
+func initRegionManagers(regions []gtfs.RegionConfig) ([]*gtfs.Manager, error) {
+	managers := make([]*gtfs.Manager, 0, len(regions))
+	for _, region := range regions {
+		manager, err := gtfs.InitGTFSManager(region.Config)
+		if err != nil {
+			for _, loaded := range managers {
+				loaded.Shutdown()
+			}
+			return nil, fmt.Errorf("failed to initialize GTFS manager of region %q: %w", region.ID, err)
+		}
+		managers = append(managers, manager)
+	}
+	return managers, nil
+}
+
+// configureIDCodec sets the format of the combined IDs in responses. Raw IDs
+// without a configured agency belong to the feed's only agency.
+func configureIDCodec(cfg appconf.Config, manager *gtfs.Manager) error {
+	codec := utils.IDCodec{Separator: cfg.IDSeparator, Raw: cfg.RawIDs, RawAgencyID: cfg.RawIDAgency}
+	if codec.Raw && codec.RawAgencyID == "" && manager != nil {
+		manager.RLock()
+		agencies := manager.GetAgencies()
+		if len(agencies) == 1 {
+			codec.RawAgencyID = agencies[0].Id
+		}
+		manager.RUnlock()
+		if len(agencies) != 1 {
+			return fmt.Errorf("raw IDs need the agency they belong to, as the feed has %d agencies", len(agencies))
+		}
+	}
+	if err := utils.SetIDCodec(codec); err != nil {
+		return fmt.Errorf("invalid ID format: %w", err)
+	}
+	return nil
+}
+
 // createClock returns the appropriate Clock implementation based on environment.
 // - Production/Development: RealClock (uses actual system time)
 // - Test: EnvironmentClock (reads from FAKETIME env var or file, fallback to system time)
@@ -128,6 +276,7 @@ func CreateServer(coreApp *app.Application, cfg appconf.Config) (*http.Server, *
 	mux := http.NewServeMux()
 
 	api.SetRoutes(mux)
+	api.SetRegionRoutes(mux)
 	webUI.SetWebUIRoutes(mux)
 
 	// Add metrics endpoint (no auth required) - uses custom registry with structured error logging
@@ -135,17 +284,28 @@ func CreateServer(coreApp *app.Application, cfg appconf.Config) (*http.Server, *
 		ErrorLog: slog.NewLogLogger(coreApp.Logger.Handler(), slog.LevelError),
 	}))
 
+	// Bound how long each request may run
+	timeoutHandler := api.RequestTimeoutMiddleware(restapi.WithRoutePattern(mux))
+
 	// Wrap with security middleware
-	secureHandler := api.WithSecurityHeaders(mux)
+	secureHandler := api.WithSecurityHeaders(timeoutHandler)
 
 	// Add metrics middleware
 	metricsHandler := restapi.MetricsHandler(coreApp.Metrics)(secureHandler)
 
 	// Add request logging middleware (outermost)
 	requestLogger := logging.NewStructuredLogger(os.Stdout, slog.LevelInfo)
-	requestLogMiddleware := restapi.NewRequestLoggingMiddleware(requestLogger)
+	sampleRate := cfg.RequestLogSampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+	requestLogMiddleware := restapi.NewRequestLoggingMiddlewareWithConfig(requestLogger, restapi.RequestLogConfig{
+		SampleRate:      sampleRate,
+		SlowDBThreshold: cfg.SlowDBThreshold,
+	})
 
-	handler := restapi.RequestIDMiddleware(requestLogMiddleware(metricsHandler))
+	// Count in-flight requests so shutdown can drain them
+	handler := restapi.RequestIDMiddleware(requestLogMiddleware(api.TrackInFlight(metricsHandler)))
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -155,13 +315,16 @@ func CreateServer(coreApp *app.Application, cfg appconf.Config) (*http.Server, *
 		WriteTimeout: 10 * time.Second,
 		ErrorLog:     slog.NewLogLogger(coreApp.Logger.Handler(), slog.LevelError),
 	}
+	srv.RegisterOnShutdown(api.CloseStreams)
 
 	return srv, api
 }
 
 // Run manages the server lifecycle with graceful shutdown.
 // Starts the server in a goroutine, waits for shutdown signals (SIGINT, SIGTERM) or context cancellation,
-// and performs graceful shutdown with a 30-second timeout.
+// and performs graceful shutdown with a 30-second timeout shared by every step: the server stops
+// accepting connections, in-flight requests drain, and realtime pollers stop with their database
+// writes finished before the database is closed.
 // Returns an error if the server fails to start or shutdown fails.
 func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *restapi.RestAPI, logger *slog.Logger) error {
 	logger.Info("starting server", "addr", srv.Addr)
@@ -192,15 +355,19 @@ func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *r
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Shutdown server
+	// Shutdown server. Keep going when it times out, so that the remaining
+	// requests are canceled and the database is still closed cleanly.
+	var shutdownErr error
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("server forced to shutdown", "error", err)
-		return fmt.Errorf("server forced to shutdown: %w", err)
+		shutdownErr = fmt.Errorf("server forced to shutdown: %w", err)
 	}
 
-	// Shutdown API rate limiter first (stops background goroutines for request handling)
+	// Drain in-flight requests and stop the API's background goroutines
 	if api != nil {
-		api.Shutdown()
+		if err := api.ShutdownContext(shutdownCtx); err != nil {
+			logger.Error("failed to drain in-flight requests", "error", err)
+		}
 	}
 
 	// Shutdown metrics collector (blocks until goroutine exits)
@@ -208,54 +375,63 @@ func Run(ctx context.Context, srv *http.Server, coreApp *app.Application, api *r
 		coreApp.Metrics.Shutdown()
 	}
 
+	// Close the API key store after the API so buffered usage counts are written
+	if coreApp.APIKeys != nil {
+		if err := coreApp.APIKeys.Close(); err != nil {
+			logger.Error("failed to close API key store", "error", err)
+		}
+	}
+
 	// Then shutdown GTFS manager (stops data fetching - the lowest-level dependency)
 	if coreApp.GtfsManager != nil {
-		coreApp.GtfsManager.Shutdown()
+		if err := coreApp.GtfsManager.ShutdownContext(shutdownCtx); err != nil {
+			logger.Error("realtime pollers did not stop in time", "error", err)
+		}
+	}
+	for _, region := range coreApp.Regions {
+		if err := region.GtfsManager.ShutdownContext(shutdownCtx); err != nil {
+			logger.Error("realtime pollers did not stop in time", "region", region.ID, "error", err)
+		}
 	}
 
+	if shutdownErr != nil {
+		return shutdownErr
+	}
 	logger.Info("server exited")
 	return nil
 }
 
-// dumpConfigJSON converts current configuration to JSON and prints it to stdout
-func dumpConfigJSON(cfg appconf.Config, gtfsCfg gtfs.Config) {
-	// Convert environment enum to string
-	envStr := "development"
-	switch cfg.Env {
-	case appconf.Development:
-		envStr = "development"
-	case appconf.Test:
-		envStr = "test"
-	case appconf.Production:
-		envStr = "production"
-	}
-
-	// Build gtfs-static-feed object
+// dumpStaticFeed returns the gtfs-static-feed object of a dumped config, with
+// the auth header value redacted.
+func dumpStaticFeed(gtfsCfg gtfs.Config) map[string]interface{} {
 	staticAuthValue := gtfsCfg.StaticAuthHeaderValue
 	if staticAuthValue != "" {
 		staticAuthValue = "***REDACTED***"
 	}
-	staticFeed := map[string]string{
+	staticFeed := map[string]interface{}{
 		"url": gtfsCfg.GtfsURL,
 	}
 	if gtfsCfg.StaticAuthHeaderKey != "" {
 		staticFeed["auth-header-name"] = gtfsCfg.StaticAuthHeaderKey
 		staticFeed["auth-header-value"] = staticAuthValue
 	}
-
-	// Build JSON config structure
-	jsonConfig := map[string]interface{}{
-		"port":             cfg.Port,
-		"env":              envStr,
-		"api-keys":         cfg.ApiKeys,
-		"exempt-api-keys":  cfg.ExemptApiKeys,
-		"rate-limit":       cfg.RateLimit,
-		"gtfs-static-feed": staticFeed,
-		"data-path":        gtfsCfg.GTFSDataPath,
+	if gtfsCfg.StaticRefreshInterval > 0 {
+		staticFeed["refresh-interval-minutes"] = int(gtfsCfg.StaticRefreshInterval / time.Minute)
+	}
+	if gtfsCfg.StaticDownloadTimeout > 0 {
+		staticFeed["download-timeout-seconds"] = int(gtfsCfg.StaticDownloadTimeout / time.Second)
 	}
+	if gtfsCfg.ReferentialIntegrity != "" {
+		staticFeed["referential-integrity"] = string(gtfsCfg.ReferentialIntegrity)
+	}
+	return staticFeed
+}
 
+// dumpRTFeeds returns the gtfs-rt-feeds array of a dumped config, with header
+// values redacted.
+func dumpRTFeeds(rtFeeds []gtfs.RTFeedConfig) []map[string]interface{} {
 	var feeds []map[string]interface{}
-	for _, feedCfg := range gtfsCfg.RTFeeds {
+	for _, feedCfg := range rtFeeds {
 		redactedHeaders := make(map[string]string)
 		for k := range feedCfg.Headers {
 			redactedHeaders[k] = "***REDACTED***"
@@ -269,15 +445,233 @@ func dumpConfigJSON(cfg appconf.Config, gtfsCfg gtfs.Config) {
 			"refresh-interval":      feedCfg.RefreshInterval,
 			"enabled":               feedCfg.Enabled,
 		}
+		if feedCfg.TripUpdatesInterval > 0 {
+			feed["trip-updates-interval"] = feedCfg.TripUpdatesInterval
+		}
+		if feedCfg.VehiclePositionsInterval > 0 {
+			feed["vehicle-positions-interval"] = feedCfg.VehiclePositionsInterval
+		}
+		if feedCfg.ServiceAlertsInterval > 0 {
+			feed["service-alerts-interval"] = feedCfg.ServiceAlertsInterval
+		}
 		if len(feedCfg.AgencyIDs) > 0 {
 			feed["agency-ids"] = feedCfg.AgencyIDs
 		}
+		if feedCfg.Priority != 0 {
+			feed["priority"] = feedCfg.Priority
+		}
 		if len(redactedHeaders) > 0 {
 			feed["headers"] = redactedHeaders
 		}
+		if len(feedCfg.VehicleIDRewrites) > 0 {
+			rewrites := make([]map[string]string, 0, len(feedCfg.VehicleIDRewrites))
+			for _, rewrite := range feedCfg.VehicleIDRewrites {
+				rewrites = append(rewrites, map[string]string{
+					"pattern":     rewrite.Pattern.String(),
+					"replacement": rewrite.Replacement,
+				})
+			}
+			feed["vehicle-id-rewrites"] = rewrites
+		}
 		feeds = append(feeds, feed)
 	}
-	jsonConfig["gtfs-rt-feeds"] = feeds
+	return feeds
+}
+
+// dumpConfigJSON converts current configuration to JSON and prints it to stdout
+func dumpConfigJSON(cfg appconf.Config, gtfsCfg gtfs.Config) {
+	// Convert environment enum to string
+	envStr := "development"
+	switch cfg.Env {
+	case appconf.Development:
+		envStr = "development"
+	case appconf.Test:
+		envStr = "test"
+	case appconf.Production:
+		envStr = "production"
+	}
+
+	// Build JSON config structure
+	jsonConfig := map[string]interface{}{
+		"port":             cfg.Port,
+		"env":              envStr,
+		"api-keys":         cfg.ApiKeys,
+		"exempt-api-keys":  cfg.ExemptApiKeys,
+		"rate-limit":       cfg.RateLimit,
+		"gtfs-static-feed": dumpStaticFeed(gtfsCfg),
+		"data-path":        gtfsCfg.GTFSDataPath,
+	}
+
+	jsonConfig["gtfs-rt-feeds"] = dumpRTFeeds(gtfsCfg.RTFeeds)
+	if gtfsCfg.DBReadConnections > 0 {
+		jsonConfig["db-read-connections"] = gtfsCfg.DBReadConnections
+	}
+
+	if len(gtfsCfg.Regions) > 0 {
+		regions := make([]map[string]interface{}, 0, len(gtfsCfg.Regions))
+		for _, region := range gtfsCfg.Regions {
+			regions = append(regions, map[string]interface{}{
+				"id":               region.ID,
+				"gtfs-static-feed": dumpStaticFeed(region.Config),
+				"gtfs-rt-feeds":    dumpRTFeeds(region.RTFeeds),
+				"data-path":        region.GTFSDataPath,
+			})
+		}
+		jsonConfig["regions"] = regions
+	}
+
+	if cfg.ApiKeyDBPath != "" {
+		jsonConfig["api-key-db-path"] = cfg.ApiKeyDBPath
+	}
+	if len(cfg.AdminApiKeys) > 0 {
+		redactedAdminKeys := make([]string, len(cfg.AdminApiKeys))
+		for i := range redactedAdminKeys {
+			redactedAdminKeys[i] = "***REDACTED***"
+		}
+		jsonConfig["admin-api-keys"] = redactedAdminKeys
+	}
+	if cfg.IPRateLimit > 0 {
+		ipRateLimit := map[string]interface{}{
+			"requests-per-second": cfg.IPRateLimit,
+		}
+		if cfg.IPRateLimitBurst > 0 {
+			ipRateLimit["burst"] = cfg.IPRateLimitBurst
+		}
+		if cfg.IPRateLimitTrustForwardedFor {
+			ipRateLimit["trust-forwarded-for"] = true
+		}
+		jsonConfig["ip-rate-limit"] = ipRateLimit
+	}
+	if cfg.EnableJSONP {
+		jsonConfig["enable-jsonp"] = true
+	}
+	if cfg.EnableArrivalWebhooks {
+		jsonConfig["enable-arrival-webhooks"] = true
+	}
+	if len(cfg.ArrivalWebhookAllowedHosts) > 0 {
+		jsonConfig["arrival-webhook-allowed-hosts"] = cfg.ArrivalWebhookAllowedHosts
+	}
+	if cfg.GeodesicProjection {
+		jsonConfig["geodesic-projection"] = true
+	}
+	if cfg.StopDistanceEarlyExitMeters > 0 {
+		jsonConfig["stop-distance-early-exit-meters"] = cfg.StopDistanceEarlyExitMeters
+	}
+
+	if cfg.RequestTimeout > 0 {
+		jsonConfig["request-timeout-seconds"] = int(cfg.RequestTimeout / time.Second)
+	}
+	if cfg.PredictionHorizon > 0 {
+		jsonConfig["prediction-horizon-minutes"] = int(cfg.PredictionHorizon / time.Minute)
+	}
+
+	requestLog := map[string]interface{}{}
+	if cfg.RequestLogSampleRate > 0 {
+		requestLog["sample-rate"] = cfg.RequestLogSampleRate
+	}
+	if cfg.SlowDBThreshold > 0 {
+		requestLog["slow-db-threshold-ms"] = int(cfg.SlowDBThreshold / time.Millisecond)
+	}
+	if len(requestLog) > 0 {
+		jsonConfig["request-log"] = requestLog
+	}
+
+	stopSearch := map[string]interface{}{}
+	if cfg.StopSearchRadius > 0 {
+		stopSearch["radius-meters"] = cfg.StopSearchRadius
+	}
+	if cfg.StopSearchMaxCount > 0 {
+		stopSearch["max-count"] = cfg.StopSearchMaxCount
+	}
+	if cfg.NearbyStopsRadius > 0 {
+		stopSearch["nearby-radius-meters"] = cfg.NearbyStopsRadius
+	}
+	if cfg.NearbyStopsMaxCount > 0 {
+		stopSearch["nearby-max-count"] = cfg.NearbyStopsMaxCount
+	}
+	if len(stopSearch) > 0 {
+		jsonConfig["stop-search"] = stopSearch
+	}
+
+	responseLimits := map[string]interface{}{}
+	if cfg.MaxShapePoints > 0 {
+		responseLimits["max-shape-points"] = cfg.MaxShapePoints
+	}
+	if cfg.MaxTripStops > 0 {
+		responseLimits["max-trip-stops"] = cfg.MaxTripStops
+	}
+	if len(responseLimits) > 0 {
+		jsonConfig["response-limits"] = responseLimits
+	}
+
+	idFormat := map[string]interface{}{}
+	if cfg.IDSeparator != "" {
+		idFormat["separator"] = cfg.IDSeparator
+	}
+	if cfg.RawIDs {
+		idFormat["raw"] = true
+	}
+	if cfg.RawIDAgency != "" {
+		idFormat["agency-id"] = cfg.RawIDAgency
+	}
+	if len(idFormat) > 0 {
+		jsonConfig["id-format"] = idFormat
+	}
+
+	if gtfsCfg.VehicleHistoryRetention > 0 {
+		jsonConfig["vehicle-position-history"] = map[string]int{
+			"retention-minutes": int(gtfsCfg.VehicleHistoryRetention / time.Minute),
+			"smoothing-samples": gtfsCfg.DeviationSmoothingSamples,
+		}
+	}
+
+	detourDetection := map[string]interface{}{}
+	if gtfsCfg.DetourThresholdMeters > 0 {
+		detourDetection["threshold-meters"] = gtfsCfg.DetourThresholdMeters
+	}
+	if gtfsCfg.DetourConsecutiveUpdates > 0 {
+		detourDetection["consecutive-updates"] = gtfsCfg.DetourConsecutiveUpdates
+	}
+	if len(detourDetection) > 0 {
+		jsonConfig["detour-detection"] = detourDetection
+	}
+
+	if gtfsCfg.ReplayDir != "" {
+		realtimeReplay := map[string]interface{}{"dir": gtfsCfg.ReplayDir}
+		if gtfsCfg.ReplaySpeed > 0 {
+			realtimeReplay["speed"] = gtfsCfg.ReplaySpeed
+		}
+		if gtfsCfg.ReplayLoop {
+			realtimeReplay["loop"] = true
+		}
+		jsonConfig["realtime-replay"] = realtimeReplay
+	}
+
+	if gtfsCfg.VehicleCapacityFile != "" || len(gtfsCfg.VehicleCapacities) > 0 {
+		vehicleCapacity := map[string]interface{}{}
+		if gtfsCfg.VehicleCapacityFile != "" {
+			vehicleCapacity["file"] = gtfsCfg.VehicleCapacityFile
+		}
+		if len(gtfsCfg.VehicleCapacities) > 0 {
+			vehicleCapacity["vehicles"] = gtfsCfg.VehicleCapacities
+		}
+		jsonConfig["vehicle-capacity"] = vehicleCapacity
+	}
+
+	if cfg.StaleVehicleThreshold > 0 || len(cfg.AgencyStaleVehicleThresholds) > 0 {
+		staleVehicle := map[string]interface{}{}
+		if cfg.StaleVehicleThreshold > 0 {
+			staleVehicle["threshold-seconds"] = int(cfg.StaleVehicleThreshold / time.Second)
+		}
+		if len(cfg.AgencyStaleVehicleThresholds) > 0 {
+			agencyThresholds := make(map[string]int, len(cfg.AgencyStaleVehicleThresholds))
+			for agencyID, threshold := range cfg.AgencyStaleVehicleThresholds {
+				agencyThresholds[agencyID] = int(threshold / time.Second)
+			}
+			staleVehicle["agency-threshold-seconds"] = agencyThresholds
+		}
+		jsonConfig["stale-vehicle"] = staleVehicle
+	}
 
 	// Marshal to JSON with indentation
 	output, err := json.MarshalIndent(jsonConfig, "", "  ")
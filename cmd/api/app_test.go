@@ -208,6 +208,69 @@ func TestCreateServerHandlerResponds(t *testing.T) {
 	assert.NotEqual(t, http.StatusNotFound, w.Code, "Handler should be configured and respond to requests")
 }
 
+func TestBuildApplicationWithRegions(t *testing.T) {
+	testDataPath := filepath.Join("..", "..", "testdata", "raba.zip")
+	if _, err := os.Stat(testDataPath); os.IsNotExist(err) {
+		t.Skip("Test data not available, skipping test")
+	}
+
+	cfg := appconf.Config{
+		Port:      8080,
+		Env:       appconf.Test,
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+	}
+	gtfsCfg := gtfs.Config{
+		GTFSDataPath: ":memory:",
+		GtfsURL:      testDataPath,
+		Regions: []gtfs.RegionConfig{{
+			ID:     "north",
+			Config: gtfs.Config{GTFSDataPath: ":memory:", GtfsURL: testDataPath},
+		}},
+	}
+
+	coreApp, err := BuildApplication(cfg, gtfsCfg)
+	require.NoError(t, err, "BuildApplication should not fail")
+	defer coreApp.GtfsManager.Shutdown()
+	require.Len(t, coreApp.Regions, 1)
+	region := coreApp.Regions[0]
+	defer region.GtfsManager.Shutdown()
+	assert.Equal(t, "north", region.ID)
+	assert.NotSame(t, coreApp.GtfsManager, region.GtfsManager, "each region has a dataset of its own")
+	assert.Same(t, coreApp.APIKeys, region.APIKeys)
+
+	srv, api := CreateServer(coreApp, cfg)
+	defer api.Shutdown()
+
+	for path, want := range map[string]int{
+		"/api/where/agency/25.json?key=test":               http.StatusOK,
+		"/regions/north/api/where/agency/25.json?key=test": http.StatusOK,
+		"/regions/south/api/where/agency/25.json?key=test": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, w.Code, path)
+	}
+}
+
+func TestBuildApplicationRejectsRawIDsWithRegions(t *testing.T) {
+	testDataPath := filepath.Join("..", "..", "testdata", "raba.zip")
+	cfg := appconf.Config{Env: appconf.Test, ApiKeys: []string{"test"}, RateLimit: 100, RawIDs: true}
+	gtfsCfg := gtfs.Config{
+		GTFSDataPath: ":memory:",
+		GtfsURL:      testDataPath,
+		Regions: []gtfs.RegionConfig{{
+			ID:     "north",
+			Config: gtfs.Config{GTFSDataPath: ":memory:", GtfsURL: testDataPath},
+		}},
+	}
+
+	coreApp, err := BuildApplication(cfg, gtfsCfg)
+	require.Error(t, err)
+	assert.Nil(t, coreApp)
+	assert.Contains(t, err.Error(), "raw IDs cannot be combined with regions")
+}
+
 func TestRunServerStartsAndStopsCleanly(t *testing.T) {
 	// This is a lightweight integration test to verify the Run function can start and stop
 	// We use a test HTTP server to avoid binding to real ports
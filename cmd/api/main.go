@@ -5,7 +5,9 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"time"
 
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/gtfs"
 )
@@ -15,6 +17,8 @@ func main() {
 	var gtfsCfg gtfs.Config
 	var apiKeysFlag string
 	var exemptApiKeysFlag string
+	var adminApiKeysFlag string
+	var arrivalWebhookAllowedHostsFlag string
 	var envFlag string
 	var configFile string
 	var dumpConfig bool
@@ -26,6 +30,15 @@ func main() {
 	var cliFeedAuthHeaderName string
 	var cliFeedAuthHeaderValue string
 
+	var staticRefreshMinutes int
+	var staticDownloadTimeoutSeconds int
+	var referentialIntegrity string
+	var vehicleHistoryRetentionMinutes int
+	var staleVehicleThresholdSeconds int
+	var requestTimeoutSeconds int
+	var predictionHorizonMinutes int
+	var slowDBThresholdMs int
+
 	// Parse command-line flags
 	flag.StringVar(&configFile, "f", "", "Path to JSON configuration file (mutually exclusive with other flags)")
 	flag.BoolVar(&dumpConfig, "dump-config", false, "Dump current configuration as JSON and exit")
@@ -33,7 +46,30 @@ func main() {
 	flag.StringVar(&envFlag, "env", "development", "Environment (development|test|production)")
 	flag.StringVar(&apiKeysFlag, "api-keys", "test", "Comma Separated API Keys (test, etc)")
 	flag.StringVar(&exemptApiKeysFlag, "exempt-api-keys", "org.onebusaway.iphone", "Comma separated list of API keys exempt from rate limiting")
+	flag.StringVar(&adminApiKeysFlag, "admin-api-keys", "", "Comma separated list of API keys allowed to manage stored API keys")
+	flag.StringVar(&cfg.ApiKeyDBPath, "api-key-db", "", "Path to the SQLite database of API keys managed at runtime (empty disables the key store)")
+	flag.BoolVar(&cfg.EnableJSONP, "enable-jsonp", false, "Wrap responses in the function named by the callback parameter (JSONP)")
+	flag.BoolVar(&cfg.EnableArrivalWebhooks, "enable-arrival-webhooks", false, "Let clients subscribe to arrivals at a stop and be notified at a callback URL")
+	flag.StringVar(&arrivalWebhookAllowedHostsFlag, "arrival-webhook-allowed-hosts", "", "Comma separated list of the only hosts arrival webhook callbacks may name (empty allows any public host)")
+	flag.BoolVar(&cfg.GeodesicProjection, "geodesic-projection", false, "Project positions onto shapes with per-segment latitude scaling instead of planar degrees")
+	flag.Float64Var(&cfg.StopDistanceEarlyExitMeters, "stop-distance-early-exit", 0, "Meters past a stop's closest shape segment the distance-along-trip search looks before giving up (0 uses 100)")
+	flag.Float64Var(&cfg.StopSearchRadius, "stop-search-radius", 0, "Default radius in meters for stops-for-location (0 uses 500)")
+	flag.IntVar(&cfg.StopSearchMaxCount, "stop-search-max-count", 0, "Default maxCount for stops-for-location (0 uses 100)")
+	flag.Float64Var(&cfg.NearbyStopsRadius, "nearby-stops-radius", 0, "Default radius in meters searched for the nearby stops listed with arrivals (0 uses 10000)")
+	flag.IntVar(&cfg.NearbyStopsMaxCount, "nearby-stops-max-count", 0, "Default number of nearby stops listed with arrivals (0 uses 5)")
+	flag.IntVar(&cfg.MaxShapePoints, "max-shape-points", 0, "Most points returned for a shape, spread evenly along it (0 uses 10000)")
+	flag.IntVar(&cfg.MaxTripStops, "max-trip-stops", 0, "Most stop times returned in a trip schedule (0 uses 1000)")
+	flag.StringVar(&cfg.IDSeparator, "id-separator", "", "Separator between the agency ID and GTFS ID of combined IDs (empty uses _)")
+	flag.BoolVar(&cfg.RawIDs, "raw-ids", false, "Use plain GTFS IDs without an agency prefix, for single-agency installs")
+	flag.StringVar(&cfg.RawIDAgency, "raw-id-agency", "", "Agency that raw IDs belong to (empty uses the feed's only agency)")
+	flag.IntVar(&requestTimeoutSeconds, "request-timeout", 8, "Seconds an API request may run before it is answered with a 503 timeout error")
+	flag.IntVar(&predictionHorizonMinutes, "prediction-horizon", 0, "Minutes ahead beyond which arrivals report scheduled times only (0 predicts every arrival)")
+	flag.Float64Var(&cfg.RequestLogSampleRate, "request-log-sample-rate", 1, "Fraction of successful requests written to the access log (server errors are always logged)")
+	flag.IntVar(&slowDBThresholdMs, "slow-db-threshold", 0, "Milliseconds of database time after which a request is logged as slow (0 disables)")
 	flag.IntVar(&cfg.RateLimit, "rate-limit", 100, "Requests per second per API key for rate limiting")
+	flag.IntVar(&cfg.IPRateLimit, "ip-rate-limit", 0, "Requests per second per client IP address, across API keys (0 disables)")
+	flag.IntVar(&cfg.IPRateLimitBurst, "ip-rate-limit-burst", 0, "Requests a client IP address may make at once (0 uses -ip-rate-limit)")
+	flag.BoolVar(&cfg.IPRateLimitTrustForwardedFor, "ip-rate-limit-trust-forwarded-for", false, "Take the client IP address from the last X-Forwarded-For entry, behind a reverse proxy")
 	flag.StringVar(&gtfsCfg.GtfsURL, "gtfs-url", "https://www.soundtransit.org/GTFS-rail/40_gtfs.zip", "URL for a static GTFS zip file")
 	flag.StringVar(&gtfsCfg.StaticAuthHeaderKey, "gtfs-static-auth-header-name", "", "Optional header name for static GTFS feed auth")
 	flag.StringVar(&gtfsCfg.StaticAuthHeaderValue, "gtfs-static-auth-header-value", "", "Optional header value for static GTFS feed auth")
@@ -43,6 +79,19 @@ func main() {
 	flag.StringVar(&cliFeedAuthHeaderValue, "realtime-auth-header-value", "", "Optional header value for GTFS-RT auth")
 	flag.StringVar(&cliFeedServiceAlertsURL, "service-alerts-url", "", "URL for a GTFS-RT service alerts feed")
 	flag.StringVar(&gtfsCfg.GTFSDataPath, "data-path", "./gtfs.db", "Path to the SQLite database containing GTFS data")
+	flag.IntVar(&gtfsCfg.DBReadConnections, "db-read-connections", 0, "Size of a separate read-only pool of GTFS database connections for API queries, with the database in WAL mode (0 shares one pool with imports)")
+	flag.IntVar(&staticRefreshMinutes, "gtfs-refresh-interval", 1440, "Minutes between static GTFS feed refreshes")
+	flag.IntVar(&staticDownloadTimeoutSeconds, "gtfs-download-timeout", 300, "Seconds a static GTFS feed download may take")
+	flag.StringVar(&referentialIntegrity, "referential-integrity", "report", "What a static import does with rows referencing missing rows: report, fail or quarantine")
+	flag.IntVar(&vehicleHistoryRetentionMinutes, "vehicle-history-retention", 0, "Minutes of GTFS-RT vehicle position history to keep (0 disables recording)")
+	flag.IntVar(&gtfsCfg.DeviationSmoothingSamples, "deviation-smoothing-samples", 5, "Number of recent vehicle observations averaged for schedule deviation")
+	flag.Float64Var(&gtfsCfg.DetourThresholdMeters, "detour-threshold", 150, "Meters a vehicle may be from its trip's shape before its position counts as off-route")
+	flag.IntVar(&gtfsCfg.DetourConsecutiveUpdates, "detour-consecutive-updates", 3, "Consecutive off-route vehicle positions after which a trip is flagged as deviated")
+	flag.StringVar(&gtfsCfg.ReplayDir, "replay-dir", "", "Directory of recorded GTFS-RT snapshots to replay instead of polling the realtime feeds")
+	flag.Float64Var(&gtfsCfg.ReplaySpeed, "replay-speed", 1, "How many times faster than real time recorded snapshots are replayed")
+	flag.BoolVar(&gtfsCfg.ReplayLoop, "replay-loop", false, "Start the replay over after its last snapshot")
+	flag.StringVar(&gtfsCfg.VehicleCapacityFile, "vehicle-capacity-file", "", "CSV file of vehicle_id,capacity rows giving the passenger capacity of vehicles")
+	flag.IntVar(&staleVehicleThresholdSeconds, "stale-vehicle-threshold", 900, "Seconds after which a vehicle that has not reported is treated as absent")
 	flag.Parse()
 
 	// Enforce mutual exclusivity between -f and other flags (except --dump-config)
@@ -90,6 +139,12 @@ func main() {
 		if exemptApiKeysFlag != "" {
 			cfg.ExemptApiKeys = ParseAPIKeys(exemptApiKeysFlag)
 		}
+		if adminApiKeysFlag != "" {
+			cfg.AdminApiKeys = ParseAPIKeys(adminApiKeysFlag)
+		}
+		if arrivalWebhookAllowedHostsFlag != "" {
+			cfg.ArrivalWebhookAllowedHosts = ParseAPIKeys(arrivalWebhookAllowedHostsFlag)
+		}
 
 		// Convert environment flag to enum
 		cfg.Env = appconf.EnvFlagToEnvironment(envFlag)
@@ -97,6 +152,20 @@ func main() {
 		// Set GTFS config environment
 		gtfsCfg.Env = cfg.Env
 
+		gtfsCfg.StaticRefreshInterval = time.Duration(staticRefreshMinutes) * time.Minute
+		gtfsCfg.StaticDownloadTimeout = time.Duration(staticDownloadTimeoutSeconds) * time.Second
+		if err := appconf.ValidateReferentialIntegrity(referentialIntegrity); err != nil {
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			logger.Error("invalid -referential-integrity", "error", err)
+			os.Exit(1)
+		}
+		gtfsCfg.ReferentialIntegrity = gtfsdb.IntegrityMode(referentialIntegrity)
+		gtfsCfg.VehicleHistoryRetention = time.Duration(vehicleHistoryRetentionMinutes) * time.Minute
+		cfg.StaleVehicleThreshold = time.Duration(staleVehicleThresholdSeconds) * time.Second
+		cfg.RequestTimeout = time.Duration(requestTimeoutSeconds) * time.Second
+		cfg.PredictionHorizon = time.Duration(predictionHorizonMinutes) * time.Minute
+		cfg.SlowDBThreshold = time.Duration(slowDBThresholdMs) * time.Millisecond
+
 		// Build single-feed RTFeeds slice from CLI flags
 		headers := make(map[string]string)
 		if cliFeedAuthHeaderName != "" && cliFeedAuthHeaderValue != "" {
@@ -0,0 +1,213 @@
+package gtfsdb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// maxBatchVariables bounds the values bound by one multi-row INSERT, below
+// SQLite's default SQLITE_MAX_VARIABLE_NUMBER of 32766. Tables with more than
+// ten columns get fewer rows per batch than the configured size.
+const maxBatchVariables = 30000
+
+// batchInsert describes the multi-row INSERT of a table: the statement up to
+// and including VALUES, and the number of columns each row binds.
+type batchInsert struct {
+	table   string
+	prefix  string
+	columns int
+}
+
+var (
+	stopsBatchInsert = batchInsert{
+		table: "stops",
+		prefix: `INSERT OR REPLACE INTO stops (
+		id, code, name, desc, lat, lon, zone_id, url, location_type,
+		timezone, wheelchair_boarding, platform_code, direction
+	) VALUES `,
+		columns: 13,
+	}
+	tripsBatchInsert = batchInsert{
+		table: "trips",
+		prefix: `INSERT OR REPLACE INTO trips (
+		id, route_id, service_id, trip_headsign, trip_short_name, direction_id,
+		block_id, shape_id, wheelchair_accessible, bikes_allowed
+	) VALUES `,
+		columns: 10,
+	}
+	calendarBatchInsert = batchInsert{
+		table: "calendar",
+		prefix: `INSERT OR REPLACE INTO calendar (
+		id, monday, tuesday, wednesday, thursday, friday, saturday, sunday,
+		start_date, end_date
+	) VALUES `,
+		columns: 10,
+	}
+	calendarDatesBatchInsert = batchInsert{
+		table:   "calendar_dates",
+		prefix:  `INSERT OR REPLACE INTO calendar_dates (service_id, date, exception_type) VALUES `,
+		columns: 3,
+	}
+	shapeGeometriesBatchInsert = batchInsert{
+		table:   "shape_geometries",
+		prefix:  `INSERT OR REPLACE INTO shape_geometries (shape_id, geometry_id) VALUES `,
+		columns: 2,
+	}
+	quarantinedRowsBatchInsert = batchInsert{
+		table:   "quarantined_rows",
+		prefix:  `INSERT INTO quarantined_rows (file, row_num, kind, row_content) VALUES `,
+		columns: 4,
+	}
+)
+
+// preparedBatch holds a built multi-row INSERT with its arguments.
+type preparedBatch struct {
+	query string
+	args  []interface{}
+	index int // Original index for ordering
+}
+
+// insertBatched inserts rows in one transaction using multi-row INSERTs of up
+// to the configured batch size. As for stop_times, the statements are built by
+// a pool of workers and then executed in the rows' order, so that a later
+// duplicate still replaces an earlier one.
+func insertBatched[T any](ctx context.Context, c *Client, insert batchInsert, rows []T, values func(T) []interface{}) error {
+	logger := slog.Default().With(slog.String("component", "bulk_insert"))
+
+	logging.LogOperation(logger, "inserting_"+insert.table,
+		slog.Int("count", len(rows)))
+
+	batchSize := min(c.config.GetBulkInsertBatchSize(), maxBatchVariables/insert.columns)
+	numBatches := (len(rows) + batchSize - 1) / batchSize
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", insert.columns), ", ") + ")"
+
+	numWorkers := runtime.NumCPU()
+	batchChan := make(chan int, numWorkers)
+	resultsChan := make(chan preparedBatch, numWorkers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batchIndex := range batchChan {
+				if ctx.Err() != nil {
+					return
+				}
+
+				start := batchIndex * batchSize
+				end := min(start+batchSize, len(rows))
+				batch := rows[start:end]
+
+				// SECURITY: Only use placeholders (?) for values. Never concatenate user input directly
+				// into the query string to prevent SQL injection attacks.
+				var query strings.Builder
+				query.WriteString(insert.prefix)
+				args := make([]interface{}, 0, len(batch)*insert.columns)
+				for j, row := range batch {
+					if j > 0 {
+						query.WriteString(", ")
+					}
+					query.WriteString(placeholders)
+					args = append(args, values(row)...)
+				}
+
+				resultsChan <- preparedBatch{query: query.String(), args: args, index: batchIndex}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(batchChan)
+		for i := 0; i < numBatches; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case batchChan <- i:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	preparedBatches := make([]preparedBatch, 0, numBatches)
+	for batch := range resultsChan {
+		preparedBatches = append(preparedBatches, batch)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	sort.Slice(preparedBatches, func(i, j int) bool {
+		return preparedBatches[i].index < preparedBatches[j].index
+	})
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_"+insert.table)
+
+	for _, batch := range preparedBatches {
+		if _, err := tx.ExecContext(ctx, batch.query, batch.args...); err != nil {
+			return fmt.Errorf("failed to insert %s batch: %w", insert.table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logging.LogOperation(logger, insert.table+"_inserted",
+		slog.Int("count", len(rows)))
+
+	return nil
+}
+
+func (c *Client) bulkInsertStops(ctx context.Context, stops []CreateStopParams) error {
+	return insertBatched(ctx, c, stopsBatchInsert, stops, func(p CreateStopParams) []interface{} {
+		return []interface{}{
+			p.ID, p.Code, p.Name, p.Desc, p.Lat, p.Lon, p.ZoneID, p.Url, p.LocationType,
+			p.Timezone, p.WheelchairBoarding, p.PlatformCode, p.Direction,
+		}
+	})
+}
+
+func (c *Client) bulkInsertTrips(ctx context.Context, trips []CreateTripParams) error {
+	return insertBatched(ctx, c, tripsBatchInsert, trips, func(p CreateTripParams) []interface{} {
+		return []interface{}{
+			p.ID, p.RouteID, p.ServiceID, p.TripHeadsign, p.TripShortName, p.DirectionID,
+			p.BlockID, p.ShapeID, p.WheelchairAccessible, p.BikesAllowed,
+		}
+	})
+}
+
+func (c *Client) bulkInsertCalendars(ctx context.Context, calendars []CreateCalendarParams) error {
+	return insertBatched(ctx, c, calendarBatchInsert, calendars, func(p CreateCalendarParams) []interface{} {
+		return []interface{}{
+			p.ID, p.Monday, p.Tuesday, p.Wednesday, p.Thursday, p.Friday, p.Saturday, p.Sunday,
+			p.StartDate, p.EndDate,
+		}
+	})
+}
+
+func (c *Client) bulkInsertCalendarDates(ctx context.Context, calendarDates []CreateCalendarDateParams) error {
+	return insertBatched(ctx, c, calendarDatesBatchInsert, calendarDates, func(p CreateCalendarDateParams) []interface{} {
+		return []interface{}{p.ServiceID, p.Date, p.ExceptionType}
+	})
+}
+
+func (c *Client) bulkInsertShapeGeometries(ctx context.Context, geometries []CreateShapeGeometryParams) error {
+	return insertBatched(ctx, c, shapeGeometriesBatchInsert, geometries, func(p CreateShapeGeometryParams) []interface{} {
+		return []interface{}{p.ShapeID, p.GeometryID}
+	})
+}
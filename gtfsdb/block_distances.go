@@ -0,0 +1,70 @@
+package gtfsdb
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/geo"
+)
+
+// shapeLength returns the length of a trip's shape in meters, measured point
+// to point with geo.Distance, or NULL when the trip has no shape of at least
+// two points.
+func shapeLength(shape *gtfs.Shape) sql.NullFloat64 {
+	if shape == nil || len(shape.Points) < 2 {
+		return sql.NullFloat64{}
+	}
+	length := 0.0
+	for i := 1; i < len(shape.Points); i++ {
+		a, b := shape.Points[i-1], shape.Points[i]
+		length += geo.Distance(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+	}
+	return sql.NullFloat64{Float64: length, Valid: true}
+}
+
+// blockTripDistance is what buildBlockTripIndex needs of a trip to accumulate
+// its block's distances.
+type blockTripDistance struct {
+	tripID         string
+	blockID        string
+	serviceID      string
+	firstDeparture int64
+	shapeLength    sql.NullFloat64
+}
+
+// blockDistances returns, by trip ID, the total shape length of the trips
+// that run before each trip in its block on the same service ID, ordered by
+// first departure as GetTripsByBlockIDOrdered orders them. A trip after one
+// without a shape gets NULL, as do trips without a block.
+func blockDistances(trips []blockTripDistance) map[string]sql.NullFloat64 {
+	type blockKey struct{ blockID, serviceID string }
+	blocks := make(map[blockKey][]blockTripDistance)
+	for _, trip := range trips {
+		if trip.blockID == "" {
+			continue
+		}
+		key := blockKey{trip.blockID, trip.serviceID}
+		blocks[key] = append(blocks[key], trip)
+	}
+
+	distances := make(map[string]sql.NullFloat64, len(trips))
+	for _, block := range blocks {
+		sort.Slice(block, func(i, j int) bool {
+			if block[i].firstDeparture != block[j].firstDeparture {
+				return block[i].firstDeparture < block[j].firstDeparture
+			}
+			return block[i].tripID < block[j].tripID
+		})
+		accumulated := sql.NullFloat64{Float64: 0, Valid: true}
+		for _, trip := range block {
+			distances[trip.tripID] = accumulated
+			if !trip.shapeLength.Valid {
+				accumulated = sql.NullFloat64{}
+			} else if accumulated.Valid {
+				accumulated.Float64 += trip.shapeLength.Float64
+			}
+		}
+	}
+	return distances
+}
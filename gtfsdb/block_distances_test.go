@@ -0,0 +1,77 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/geo"
+)
+
+func TestShapeLength(t *testing.T) {
+	assert.False(t, shapeLength(nil).Valid)
+	assert.False(t, shapeLength(&gtfs.Shape{Points: []gtfs.ShapePoint{{Latitude: 40, Longitude: -75}}}).Valid)
+
+	length := shapeLength(&gtfs.Shape{Points: []gtfs.ShapePoint{
+		{Latitude: 40.00, Longitude: -75.00},
+		{Latitude: 40.01, Longitude: -75.00},
+		{Latitude: 40.02, Longitude: -75.00},
+	}})
+	require.True(t, length.Valid)
+	assert.InDelta(t, 2223.9, length.Float64, 0.5, "0.02 degrees of latitude")
+}
+
+func TestBlockDistances(t *testing.T) {
+	known := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	distances := blockDistances([]blockTripDistance{
+		{tripID: "third", blockID: "b1", serviceID: "weekday", firstDeparture: 300, shapeLength: known(30)},
+		{tripID: "first", blockID: "b1", serviceID: "weekday", firstDeparture: 100, shapeLength: known(10)},
+		{tripID: "second", blockID: "b1", serviceID: "weekday", firstDeparture: 200},
+		{tripID: "saturday", blockID: "b1", serviceID: "saturday", firstDeparture: 150, shapeLength: known(99)},
+		{tripID: "other", blockID: "b2", serviceID: "weekday", firstDeparture: 50, shapeLength: known(5)},
+		{tripID: "unblocked", serviceID: "weekday", firstDeparture: 0, shapeLength: known(1)},
+	})
+
+	assert.Equal(t, known(0), distances["first"])
+	assert.Equal(t, known(10), distances["second"])
+	assert.False(t, distances["third"].Valid, "the trip before it has no shape")
+	assert.Equal(t, known(0), distances["saturday"], "service IDs accumulate separately")
+	assert.Equal(t, known(0), distances["other"])
+	assert.NotContains(t, distances, "unblocked")
+}
+
+func TestImportPrecomputesBlockDistances(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign,block_id,shape_id
+ROUTE1,WEEKDAY,TRIP1,Downtown,BLOCK1,SHAPE1
+ROUTE1,WEEKDAY,TRIP2,Uptown,BLOCK1,SHAPE2
+`,
+		"shapes.txt": `shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence
+SHAPE1,40.7128,-74.0060,1
+SHAPE1,40.7580,-73.9855,2
+SHAPE2,40.7580,-73.9855,1
+SHAPE2,40.7128,-74.0060,2
+`,
+	}))
+	ctx := context.Background()
+
+	rows, err := client.Queries.GetBlockStopTimesWithShapeLengths(ctx, GetBlockStopTimesWithShapeLengthsParams{
+		BlockID:    sql.NullString{String: "BLOCK1", Valid: true},
+		ServiceIds: []string{"WEEKDAY"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, []string{"TRIP1", "TRIP1", "TRIP2", "TRIP2"},
+		[]string{rows[0].TripID, rows[1].TripID, rows[2].TripID, rows[3].TripID}, "trips in block order")
+	assert.Equal(t, int64(2), rows[1].StopSequence)
+
+	length := rows[0].ShapeLength
+	require.True(t, length.Valid)
+	assert.InDelta(t, geo.Distance(40.7128, -74.0060, 40.7580, -73.9855), length.Float64, 0.001)
+	assert.Equal(t, sql.NullFloat64{Float64: 0, Valid: true}, rows[0].BlockDistance)
+	assert.Equal(t, length, rows[2].BlockDistance, "the second trip starts after the first one's shape")
+}
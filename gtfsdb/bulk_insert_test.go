@@ -3,6 +3,7 @@ package gtfsdb
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
@@ -352,3 +353,95 @@ func TestBulkInsertPerformance(t *testing.T) {
 	t.Logf("Bulk inserted %d stop_times in %v (~%.0f inserts/sec)",
 		recordCount, duration, float64(recordCount)/duration.Seconds())
 }
+
+func TestBulkInsertStopsTripsAndCalendars(t *testing.T) {
+	// A batch size of 2 splits every table into several statements.
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test, BulkInsertBatchSize: 2})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+
+	_, err = client.Queries.CreateAgency(ctx, CreateAgencyParams{
+		ID: "agency", Name: "Agency", Url: "http://test.com", Timezone: "America/New_York",
+	})
+	require.NoError(t, err)
+	_, err = client.Queries.CreateRoute(ctx, CreateRouteParams{ID: "route", AgencyID: "agency", Type: 3})
+	require.NoError(t, err)
+
+	calendars := []CreateCalendarParams{
+		{ID: "weekday", Monday: 1, Tuesday: 1, Wednesday: 1, Thursday: 1, Friday: 1, StartDate: "20240101", EndDate: "20241231"},
+		{ID: "saturday", Saturday: 1, StartDate: "20240101", EndDate: "20241231"},
+		{ID: "sunday", Sunday: 1, StartDate: "20240101", EndDate: "20241231"},
+	}
+	require.NoError(t, client.bulkInsertCalendars(ctx, calendars))
+
+	calendarDates := []CreateCalendarDateParams{
+		{ServiceID: "weekday", Date: "20240704", ExceptionType: 2},
+		{ServiceID: "sunday", Date: "20240704", ExceptionType: 1},
+		{ServiceID: "weekday", Date: "20241225", ExceptionType: 2},
+	}
+	require.NoError(t, client.bulkInsertCalendarDates(ctx, calendarDates))
+
+	stops := []CreateStopParams{
+		{ID: "s1", Name: sql.NullString{String: "First", Valid: true}, Lat: 40.1, Lon: -74.1},
+		{ID: "s2", Lat: 40.2, Lon: -74.2},
+		{ID: "s3", Code: sql.NullString{String: "303", Valid: true}, Lat: 40.3, Lon: -74.3},
+		// A later duplicate replaces the earlier row, as row-by-row inserts did.
+		{ID: "s1", Name: sql.NullString{String: "First (moved)", Valid: true}, Lat: 40.15, Lon: -74.15},
+	}
+	require.NoError(t, client.bulkInsertStops(ctx, stops))
+
+	trips := []CreateTripParams{
+		{ID: "t1", RouteID: "route", ServiceID: "weekday", TripHeadsign: sql.NullString{String: "Downtown", Valid: true}},
+		{ID: "t2", RouteID: "route", ServiceID: "saturday"},
+		{ID: "t3", RouteID: "route", ServiceID: "sunday", DirectionID: sql.NullInt64{Int64: 1, Valid: true}},
+	}
+	require.NoError(t, client.bulkInsertTrips(ctx, trips))
+
+	count := func(table string) int {
+		var n int
+		require.NoError(t, client.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+	assert.Equal(t, 3, count("calendar"))
+	assert.Equal(t, 3, count("calendar_dates"))
+	assert.Equal(t, 3, count("stops"))
+	assert.Equal(t, 3, count("trips"))
+
+	stop, err := client.Queries.GetStop(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "First (moved)", stop.Name.String)
+	assert.InDelta(t, 40.15, stop.Lat, 1e-9)
+	assert.False(t, stop.Code.Valid)
+
+	trip, err := client.Queries.GetTrip(ctx, "t3")
+	require.NoError(t, err)
+	assert.Equal(t, "sunday", trip.ServiceID)
+	assert.Equal(t, int64(1), trip.DirectionID.Int64)
+	assert.False(t, trip.TripHeadsign.Valid)
+
+	calendar, err := client.Queries.GetCalendarByServiceID(ctx, "saturday")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), calendar.Saturday)
+	assert.Equal(t, int64(0), calendar.Monday)
+}
+
+func TestBulkInsertStops_WideRowsStayUnderVariableLimit(t *testing.T) {
+	// At the default batch size stops would bind more variables than SQLite
+	// allows in one statement, so their batches are made smaller.
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+	stops := make([]CreateStopParams, 3*DefaultBulkInsertBatchSize)
+	for i := range stops {
+		stops[i] = CreateStopParams{ID: fmt.Sprintf("stop_%d", i), Lat: 40, Lon: -74}
+	}
+	require.NoError(t, client.bulkInsertStops(ctx, stops))
+
+	var count int
+	require.NoError(t, client.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM stops").Scan(&count))
+	assert.Equal(t, len(stops), count)
+}
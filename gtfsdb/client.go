@@ -3,6 +3,7 @@ package gtfsdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,10 +16,16 @@ import (
 
 // Client is the main entry point for the library
 type Client struct {
-	config        Config
-	DB            *sql.DB
+	config Config
+	// DB is the connection pool that writes and transactions use.
+	DB *sql.DB
+	// ReadDB is the read-only pool of Config.ReadConnections, or DB when
+	// there is none.
+	ReadDB *sql.DB
+	// Queries runs reads on ReadDB and everything else on DB.
 	Queries       *Queries
 	importRuntime time.Duration
+	entities      *entityCaches
 }
 
 // NewClient creates a new Client with the provided configuration
@@ -30,24 +37,77 @@ func NewClient(config Config) (*Client, error) {
 		log.Println("Successfully created tables")
 	}
 
-	queries := New(db)
+	readDB := db
+	var queries *Queries
+	if config.splitReads() {
+		readDB, err = openReadPool(context.Background(), config.DBPath, config.ReadConnections)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		queries = New(splitDB{writer: db, reader: readDB})
+	} else {
+		queries = New(db)
+	}
 
 	client := &Client{
-		config:  config,
-		DB:      db,
-		Queries: queries,
+		config:   config,
+		DB:       db,
+		ReadDB:   readDB,
+		Queries:  queries,
+		entities: newEntityCaches(config.GetEntityCacheSize()),
 	}
 	return client, nil
 }
 
+// Close closes the read pool, if any, and then the writer, whose last
+// connection checkpoints the WAL back into the database file.
 func (c *Client) Close() error {
-	return c.DB.Close()
+	var readErr error
+	if c.ReadDB != nil && c.ReadDB != c.DB {
+		readErr = c.ReadDB.Close()
+	}
+	return errors.Join(readErr, c.DB.Close())
 }
 
 func (c *Client) GetDBPath() string {
 	return c.config.DBPath
 }
 
+// FeedValidators are the HTTP validators a static feed was served with. Sent
+// back on the next download, they let the server answer 304 Not Modified
+// instead of sending an unchanged feed again.
+type FeedValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether the feed was served without validators.
+func (v FeedValidators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ImportValidators returns the validators the imported feed was served with,
+// provided it was imported from source. Feeds read from files, served without
+// validators or imported from another source have none.
+func (c *Client) ImportValidators(ctx context.Context, source string) (FeedValidators, error) {
+	metadata, err := c.Queries.GetImportMetadata(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return FeedValidators{}, nil
+	}
+	if err != nil {
+		return FeedValidators{}, err
+	}
+	if metadata.FileSource != source {
+		return FeedValidators{}, nil
+	}
+	return importValidators(metadata), nil
+}
+
+func importValidators(metadata ImportMetadatum) FeedValidators {
+	return FeedValidators{ETag: metadata.Etag.String, LastModified: metadata.LastModified.String}
+}
+
 // DownloadAndStore downloads GTFS data from the given URL and stores it in the database
 func (c *Client) DownloadAndStore(ctx context.Context, url, authHeaderKey, authHeaderValue string) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -83,11 +143,24 @@ func (c *Client) DownloadAndStore(ctx context.Context, url, authHeaderKey, authH
 		return fmt.Errorf("static GTFS response exceeds size limit of %d bytes", maxBodySize)
 	}
 
-	err = c.processAndStoreGTFSDataWithSource(body, url)
+	err = c.processAndStoreGTFSData(body, url, FeedValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	})
 
 	return err
 }
 
+// ImportFromData imports a static GTFS zip that was read from source, such as
+// a feed the caller downloaded itself, recording the validators it was served
+// with.
+func (c *Client) ImportFromData(ctx context.Context, data []byte, source string, validators FeedValidators) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.processAndStoreGTFSData(data, source, validators)
+}
+
 // ImportFromFile imports GTFS data from a local zip file into the database
 func (c *Client) ImportFromFile(ctx context.Context, path string) error {
 	data, err := os.ReadFile(path)
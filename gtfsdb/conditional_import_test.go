@@ -249,6 +249,44 @@ func TestConditionalImport_FileImport(t *testing.T) {
 	assert.Less(t, duration, 100*time.Millisecond, "Second import should be very fast when skipped")
 }
 
+func TestConditionalImport_StoresValidators(t *testing.T) {
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err, "Failed to create client")
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+	originalData, _ := createTestData(t)
+
+	validators, err := client.ImportValidators(ctx, "https://example.com/gtfs.zip")
+	require.NoError(t, err)
+	assert.True(t, validators.IsZero(), "nothing is imported yet")
+
+	served := FeedValidators{ETag: `"v1"`, LastModified: "Sun, 01 Jun 2025 00:00:00 GMT"}
+	require.NoError(t, client.ImportFromData(ctx, originalData, "https://example.com/gtfs.zip", served))
+	imported, err := client.Queries.GetImportMetadata(ctx)
+	require.NoError(t, err)
+
+	validators, err = client.ImportValidators(ctx, "https://example.com/gtfs.zip")
+	require.NoError(t, err)
+	assert.Equal(t, served, validators)
+
+	validators, err = client.ImportValidators(ctx, "https://example.com/other.zip")
+	require.NoError(t, err)
+	assert.True(t, validators.IsZero(), "validators only apply to the source they were served by")
+
+	// The same feed served under new validators is not imported again, but
+	// its new validators are kept.
+	reserved := FeedValidators{ETag: `"v2"`}
+	require.NoError(t, client.ImportFromData(ctx, originalData, "https://example.com/gtfs.zip", reserved))
+	metadata, err := client.Queries.GetImportMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, imported.ImportTime, metadata.ImportTime)
+	assert.Equal(t, imported.FileHash, metadata.FileHash)
+	validators, err = client.ImportValidators(ctx, "https://example.com/gtfs.zip")
+	require.NoError(t, err)
+	assert.Equal(t, reserved, validators)
+}
+
 func TestClearAllGTFSData(t *testing.T) {
 	// Create in-memory database
 	config := Config{
@@ -23,6 +23,22 @@ type Config struct {
 	// SQLITE_MAX_VARIABLE_NUMBER limit (default 999).
 	// Set to 0 to use the default value.
 	BulkInsertBatchSize int
+
+	// ReferentialIntegrity is what an import does with rows that reference
+	// missing rows. Empty means IntegrityModeReport.
+	ReferentialIntegrity IntegrityMode
+
+	// EntityCacheSize is how many trips, routes and stops, each, Client.GetTrip,
+	// GetRoute and GetStop keep in memory. Zero uses DefaultEntityCacheSize; a
+	// negative value disables the caches.
+	EntityCacheSize int
+
+	// ReadConnections is the size of a separate pool of read-only connections
+	// that queries run on, so that handlers are not queued behind imports and
+	// other writes. The database is switched to WAL mode, in which readers and
+	// the writer do not block each other. Zero, or an in-memory database, keeps
+	// a single pool for everything.
+	ReadConnections int
 }
 
 func NewConfig(dbPath string, env appconf.Environment, verbose bool) Config {
@@ -41,3 +57,24 @@ func (c Config) GetBulkInsertBatchSize() int {
 	}
 	return c.BulkInsertBatchSize
 }
+
+// referentialIntegrity returns the configured integrity mode, or the default if not set
+func (c Config) referentialIntegrity() IntegrityMode {
+	if c.ReferentialIntegrity == "" {
+		return IntegrityModeReport
+	}
+	return c.ReferentialIntegrity
+}
+
+// GetEntityCacheSize returns the configured entity cache size, or the default if not set
+func (c Config) GetEntityCacheSize() int {
+	if c.EntityCacheSize == 0 {
+		return DefaultEntityCacheSize
+	}
+	return c.EntityCacheSize
+}
+
+// splitReads reports whether queries get a read-only pool of their own
+func (c Config) splitReads() bool {
+	return c.ReadConnections > 0 && c.DBPath != ":memory:"
+}
@@ -33,15 +33,54 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.clearBlockTripIndicesStmt, err = db.PrepareContext(ctx, clearBlockTripIndices); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearBlockTripIndices: %w", err)
 	}
+	if q.clearBookingRulesStmt, err = db.PrepareContext(ctx, clearBookingRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearBookingRules: %w", err)
+	}
 	if q.clearCalendarStmt, err = db.PrepareContext(ctx, clearCalendar); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearCalendar: %w", err)
 	}
 	if q.clearCalendarDatesStmt, err = db.PrepareContext(ctx, clearCalendarDates); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearCalendarDates: %w", err)
 	}
+	if q.clearFareAttributesStmt, err = db.PrepareContext(ctx, clearFareAttributes); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearFareAttributes: %w", err)
+	}
+	if q.clearFareRulesStmt, err = db.PrepareContext(ctx, clearFareRules); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearFareRules: %w", err)
+	}
+	if q.clearFeedInfoStmt, err = db.PrepareContext(ctx, clearFeedInfo); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearFeedInfo: %w", err)
+	}
+	if q.clearFlexStopTimesStmt, err = db.PrepareContext(ctx, clearFlexStopTimes); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearFlexStopTimes: %w", err)
+	}
+	if q.clearFrequenciesStmt, err = db.PrepareContext(ctx, clearFrequencies); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearFrequencies: %w", err)
+	}
+	if q.clearImportWarningsStmt, err = db.PrepareContext(ctx, clearImportWarnings); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearImportWarnings: %w", err)
+	}
+	if q.clearLocationGroupStopsStmt, err = db.PrepareContext(ctx, clearLocationGroupStops); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearLocationGroupStops: %w", err)
+	}
+	if q.clearLocationGroupsStmt, err = db.PrepareContext(ctx, clearLocationGroups); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearLocationGroups: %w", err)
+	}
+	if q.clearLocationsStmt, err = db.PrepareContext(ctx, clearLocations); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearLocations: %w", err)
+	}
+	if q.clearNetworksStmt, err = db.PrepareContext(ctx, clearNetworks); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearNetworks: %w", err)
+	}
+	if q.clearQuarantinedRowsStmt, err = db.PrepareContext(ctx, clearQuarantinedRows); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearQuarantinedRows: %w", err)
+	}
 	if q.clearRoutesStmt, err = db.PrepareContext(ctx, clearRoutes); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearRoutes: %w", err)
 	}
+	if q.clearShapeGeometriesStmt, err = db.PrepareContext(ctx, clearShapeGeometries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearShapeGeometries: %w", err)
+	}
 	if q.clearShapesStmt, err = db.PrepareContext(ctx, clearShapes); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearShapes: %w", err)
 	}
@@ -51,9 +90,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.clearStopsStmt, err = db.PrepareContext(ctx, clearStops); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearStops: %w", err)
 	}
+	if q.clearTransfersStmt, err = db.PrepareContext(ctx, clearTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearTransfers: %w", err)
+	}
+	if q.clearTranslationsStmt, err = db.PrepareContext(ctx, clearTranslations); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearTranslations: %w", err)
+	}
 	if q.clearTripsStmt, err = db.PrepareContext(ctx, clearTrips); err != nil {
 		return nil, fmt.Errorf("error preparing query ClearTrips: %w", err)
 	}
+	if q.countImportWarningsStmt, err = db.PrepareContext(ctx, countImportWarnings); err != nil {
+		return nil, fmt.Errorf("error preparing query CountImportWarnings: %w", err)
+	}
 	if q.createAgencyStmt, err = db.PrepareContext(ctx, createAgency); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateAgency: %w", err)
 	}
@@ -63,12 +111,42 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createBlockTripIndexStmt, err = db.PrepareContext(ctx, createBlockTripIndex); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateBlockTripIndex: %w", err)
 	}
+	if q.createBookingRuleStmt, err = db.PrepareContext(ctx, createBookingRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBookingRule: %w", err)
+	}
 	if q.createCalendarStmt, err = db.PrepareContext(ctx, createCalendar); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateCalendar: %w", err)
 	}
 	if q.createCalendarDateStmt, err = db.PrepareContext(ctx, createCalendarDate); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateCalendarDate: %w", err)
 	}
+	if q.createFareAttributeStmt, err = db.PrepareContext(ctx, createFareAttribute); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFareAttribute: %w", err)
+	}
+	if q.createFareRuleStmt, err = db.PrepareContext(ctx, createFareRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFareRule: %w", err)
+	}
+	if q.createFlexStopTimeStmt, err = db.PrepareContext(ctx, createFlexStopTime); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFlexStopTime: %w", err)
+	}
+	if q.createFrequencyStmt, err = db.PrepareContext(ctx, createFrequency); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFrequency: %w", err)
+	}
+	if q.createImportWarningStmt, err = db.PrepareContext(ctx, createImportWarning); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateImportWarning: %w", err)
+	}
+	if q.createLocationStmt, err = db.PrepareContext(ctx, createLocation); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocation: %w", err)
+	}
+	if q.createLocationGroupStmt, err = db.PrepareContext(ctx, createLocationGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocationGroup: %w", err)
+	}
+	if q.createLocationGroupStopStmt, err = db.PrepareContext(ctx, createLocationGroupStop); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocationGroupStop: %w", err)
+	}
+	if q.createNetworkStmt, err = db.PrepareContext(ctx, createNetwork); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNetwork: %w", err)
+	}
 	if q.createProblemReportStopStmt, err = db.PrepareContext(ctx, createProblemReportStop); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateProblemReportStop: %w", err)
 	}
@@ -81,15 +159,33 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createShapeStmt, err = db.PrepareContext(ctx, createShape); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateShape: %w", err)
 	}
+	if q.createShapeGeometryStmt, err = db.PrepareContext(ctx, createShapeGeometry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateShapeGeometry: %w", err)
+	}
 	if q.createStopStmt, err = db.PrepareContext(ctx, createStop); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateStop: %w", err)
 	}
 	if q.createStopTimeStmt, err = db.PrepareContext(ctx, createStopTime); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateStopTime: %w", err)
 	}
+	if q.createTransferStmt, err = db.PrepareContext(ctx, createTransfer); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransfer: %w", err)
+	}
+	if q.createTranslationStmt, err = db.PrepareContext(ctx, createTranslation); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTranslation: %w", err)
+	}
 	if q.createTripStmt, err = db.PrepareContext(ctx, createTrip); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTrip: %w", err)
 	}
+	if q.createVehiclePositionHistoryStmt, err = db.PrepareContext(ctx, createVehiclePositionHistory); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVehiclePositionHistory: %w", err)
+	}
+	if q.deleteScheduleDeviationSamplesBeforeStmt, err = db.PrepareContext(ctx, deleteScheduleDeviationSamplesBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteScheduleDeviationSamplesBefore: %w", err)
+	}
+	if q.deleteVehiclePositionsHistoryBeforeStmt, err = db.PrepareContext(ctx, deleteVehiclePositionsHistoryBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteVehiclePositionsHistoryBefore: %w", err)
+	}
 	if q.getActiveRouteIDsForStopsOnDateStmt, err = db.PrepareContext(ctx, getActiveRouteIDsForStopsOnDate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetActiveRouteIDsForStopsOnDate: %w", err)
 	}
@@ -126,9 +222,15 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getBlockDetailsStmt, err = db.PrepareContext(ctx, getBlockDetails); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBlockDetails: %w", err)
 	}
+	if q.getBlockDetailsForBlocksStmt, err = db.PrepareContext(ctx, getBlockDetailsForBlocks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBlockDetailsForBlocks: %w", err)
+	}
 	if q.getBlockIDByTripIDStmt, err = db.PrepareContext(ctx, getBlockIDByTripID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBlockIDByTripID: %w", err)
 	}
+	if q.getBlockIDsForAgencyStmt, err = db.PrepareContext(ctx, getBlockIDsForAgency); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBlockIDsForAgency: %w", err)
+	}
 	if q.getBlockTripIndexIDsForBlocksStmt, err = db.PrepareContext(ctx, getBlockTripIndexIDsForBlocks); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBlockTripIndexIDsForBlocks: %w", err)
 	}
@@ -138,18 +240,60 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getBlocksForBlockTripIndexIDsStmt, err = db.PrepareContext(ctx, getBlocksForBlockTripIndexIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetBlocksForBlockTripIndexIDs: %w", err)
 	}
+	if q.getBookingRuleStmt, err = db.PrepareContext(ctx, getBookingRule); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBookingRule: %w", err)
+	}
 	if q.getCalendarByServiceIDStmt, err = db.PrepareContext(ctx, getCalendarByServiceID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetCalendarByServiceID: %w", err)
 	}
 	if q.getCalendarDateExceptionsForServiceIDStmt, err = db.PrepareContext(ctx, getCalendarDateExceptionsForServiceID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetCalendarDateExceptionsForServiceID: %w", err)
 	}
+	if q.getFaresForRouteStmt, err = db.PrepareContext(ctx, getFaresForRoute); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFaresForRoute: %w", err)
+	}
+	if q.getFeedInfoStmt, err = db.PrepareContext(ctx, getFeedInfo); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFeedInfo: %w", err)
+	}
+	if q.getFlexStopTimesForTripStmt, err = db.PrepareContext(ctx, getFlexStopTimesForTrip); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFlexStopTimesForTrip: %w", err)
+	}
+	if q.getFrequenciesForTripStmt, err = db.PrepareContext(ctx, getFrequenciesForTrip); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFrequenciesForTrip: %w", err)
+	}
+	if q.getFrequencyStopTimesForStopStmt, err = db.PrepareContext(ctx, getFrequencyStopTimesForStop); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFrequencyStopTimesForStop: %w", err)
+	}
+	if q.getHistoricalOccupancyForTripStmt, err = db.PrepareContext(ctx, getHistoricalOccupancyForTrip); err != nil {
+		return nil, fmt.Errorf("error preparing query GetHistoricalOccupancyForTrip: %w", err)
+	}
 	if q.getImportMetadataStmt, err = db.PrepareContext(ctx, getImportMetadata); err != nil {
 		return nil, fmt.Errorf("error preparing query GetImportMetadata: %w", err)
 	}
+	if q.getLocationStmt, err = db.PrepareContext(ctx, getLocation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocation: %w", err)
+	}
+	if q.getLocationGroupStmt, err = db.PrepareContext(ctx, getLocationGroup); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationGroup: %w", err)
+	}
+	if q.getLocationGroupStopIDsStmt, err = db.PrepareContext(ctx, getLocationGroupStopIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationGroupStopIDs: %w", err)
+	}
+	if q.getLocationGroupsForStopStmt, err = db.PrepareContext(ctx, getLocationGroupsForStop); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationGroupsForStop: %w", err)
+	}
+	if q.getLocationsContainingPointStmt, err = db.PrepareContext(ctx, getLocationsContainingPoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationsContainingPoint: %w", err)
+	}
+	if q.getMaxStopTimeStmt, err = db.PrepareContext(ctx, getMaxStopTime); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMaxStopTime: %w", err)
+	}
 	if q.getNextStopInTripStmt, err = db.PrepareContext(ctx, getNextStopInTrip); err != nil {
 		return nil, fmt.Errorf("error preparing query GetNextStopInTrip: %w", err)
 	}
+	if q.getOnTimePerformanceByRouteStmt, err = db.PrepareContext(ctx, getOnTimePerformanceByRoute); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOnTimePerformanceByRoute: %w", err)
+	}
 	if q.getOrderedStopIDsForTripStmt, err = db.PrepareContext(ctx, getOrderedStopIDsForTrip); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderedStopIDsForTrip: %w", err)
 	}
@@ -159,6 +303,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getProblemReportsByTripStmt, err = db.PrepareContext(ctx, getProblemReportsByTrip); err != nil {
 		return nil, fmt.Errorf("error preparing query GetProblemReportsByTrip: %w", err)
 	}
+	if q.getRecentVehiclePositionsForTripStmt, err = db.PrepareContext(ctx, getRecentVehiclePositionsForTrip); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRecentVehiclePositionsForTrip: %w", err)
+	}
 	if q.getRouteStmt, err = db.PrepareContext(ctx, getRoute); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRoute: %w", err)
 	}
@@ -180,6 +327,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getRoutesForStopsStmt, err = db.PrepareContext(ctx, getRoutesForStops); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRoutesForStops: %w", err)
 	}
+	if q.getRoutesForStopsWithRouteTypesStmt, err = db.PrepareContext(ctx, getRoutesForStopsWithRouteTypes); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRoutesForStopsWithRouteTypes: %w", err)
+	}
 	if q.getRoutesInBlockTripIndicesStmt, err = db.PrepareContext(ctx, getRoutesInBlockTripIndices); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRoutesInBlockTripIndices: %w", err)
 	}
@@ -192,9 +342,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getShapeByIDStmt, err = db.PrepareContext(ctx, getShapeByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetShapeByID: %w", err)
 	}
+	if q.getShapeContextForStopsWithoutDirectionStmt, err = db.PrepareContext(ctx, getShapeContextForStopsWithoutDirection); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShapeContextForStopsWithoutDirection: %w", err)
+	}
+	if q.getShapeGeometriesStmt, err = db.PrepareContext(ctx, getShapeGeometries); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShapeGeometries: %w", err)
+	}
 	if q.getShapePointWindowStmt, err = db.PrepareContext(ctx, getShapePointWindow); err != nil {
 		return nil, fmt.Errorf("error preparing query GetShapePointWindow: %w", err)
 	}
+	if q.getShapePointsStmt, err = db.PrepareContext(ctx, getShapePoints); err != nil {
+		return nil, fmt.Errorf("error preparing query GetShapePoints: %w", err)
+	}
 	if q.getShapePointsByIDsStmt, err = db.PrepareContext(ctx, getShapePointsByIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetShapePointsByIDs: %w", err)
 	}
@@ -237,6 +396,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getStopTimesForTripIDsStmt, err = db.PrepareContext(ctx, getStopTimesForTripIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStopTimesForTripIDs: %w", err)
 	}
+	if q.getStopsByCodeStmt, err = db.PrepareContext(ctx, getStopsByCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStopsByCode: %w", err)
+	}
 	if q.getStopsByIDsStmt, err = db.PrepareContext(ctx, getStopsByIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStopsByIDs: %w", err)
 	}
@@ -255,9 +417,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getStopsWithTripContextStmt, err = db.PrepareContext(ctx, getStopsWithTripContext); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStopsWithTripContext: %w", err)
 	}
+	if q.getTransfersFromStopStmt, err = db.PrepareContext(ctx, getTransfersFromStop); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransfersFromStop: %w", err)
+	}
+	if q.getTranslationsStmt, err = db.PrepareContext(ctx, getTranslations); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTranslations: %w", err)
+	}
 	if q.getTripStmt, err = db.PrepareContext(ctx, getTrip); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTrip: %w", err)
 	}
+	if q.getTripServiceSpanStmt, err = db.PrepareContext(ctx, getTripServiceSpan); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTripServiceSpan: %w", err)
+	}
 	if q.getTripsByBlockIDStmt, err = db.PrepareContext(ctx, getTripsByBlockID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTripsByBlockID: %w", err)
 	}
@@ -282,24 +453,51 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getTripsInBlockStmt, err = db.PrepareContext(ctx, getTripsInBlock); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTripsInBlock: %w", err)
 	}
+	if q.getTripsServingStopsInOrderStmt, err = db.PrepareContext(ctx, getTripsServingStopsInOrder); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTripsServingStopsInOrder: %w", err)
+	}
+	if q.getVehiclePositionsInWindowStmt, err = db.PrepareContext(ctx, getVehiclePositionsInWindow); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVehiclePositionsInWindow: %w", err)
+	}
+	if q.incrementHistoricalOccupancyStmt, err = db.PrepareContext(ctx, incrementHistoricalOccupancy); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementHistoricalOccupancy: %w", err)
+	}
 	if q.listAgenciesStmt, err = db.PrepareContext(ctx, listAgencies); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAgencies: %w", err)
 	}
+	if q.listImportWarningsStmt, err = db.PrepareContext(ctx, listImportWarnings); err != nil {
+		return nil, fmt.Errorf("error preparing query ListImportWarnings: %w", err)
+	}
+	if q.listNetworksStmt, err = db.PrepareContext(ctx, listNetworks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNetworks: %w", err)
+	}
+	if q.listQuarantinedRowsStmt, err = db.PrepareContext(ctx, listQuarantinedRows); err != nil {
+		return nil, fmt.Errorf("error preparing query ListQuarantinedRows: %w", err)
+	}
 	if q.listRoutesStmt, err = db.PrepareContext(ctx, listRoutes); err != nil {
 		return nil, fmt.Errorf("error preparing query ListRoutes: %w", err)
 	}
 	if q.listStopsStmt, err = db.PrepareContext(ctx, listStops); err != nil {
 		return nil, fmt.Errorf("error preparing query ListStops: %w", err)
 	}
+	if q.listStopsWithoutDirectionStmt, err = db.PrepareContext(ctx, listStopsWithoutDirection); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStopsWithoutDirection: %w", err)
+	}
 	if q.listTripsStmt, err = db.PrepareContext(ctx, listTrips); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTrips: %w", err)
 	}
 	if q.updateStopDirectionStmt, err = db.PrepareContext(ctx, updateStopDirection); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateStopDirection: %w", err)
 	}
+	if q.upsertFeedInfoStmt, err = db.PrepareContext(ctx, upsertFeedInfo); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertFeedInfo: %w", err)
+	}
 	if q.upsertImportMetadataStmt, err = db.PrepareContext(ctx, upsertImportMetadata); err != nil {
 		return nil, fmt.Errorf("error preparing query UpsertImportMetadata: %w", err)
 	}
+	if q.upsertScheduleDeviationSampleStmt, err = db.PrepareContext(ctx, upsertScheduleDeviationSample); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertScheduleDeviationSample: %w", err)
+	}
 	return &q, nil
 }
 
@@ -320,6 +518,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing clearBlockTripIndicesStmt: %w", cerr)
 		}
 	}
+	if q.clearBookingRulesStmt != nil {
+		if cerr := q.clearBookingRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearBookingRulesStmt: %w", cerr)
+		}
+	}
 	if q.clearCalendarStmt != nil {
 		if cerr := q.clearCalendarStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing clearCalendarStmt: %w", cerr)
@@ -330,11 +533,71 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing clearCalendarDatesStmt: %w", cerr)
 		}
 	}
+	if q.clearFareAttributesStmt != nil {
+		if cerr := q.clearFareAttributesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearFareAttributesStmt: %w", cerr)
+		}
+	}
+	if q.clearFareRulesStmt != nil {
+		if cerr := q.clearFareRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearFareRulesStmt: %w", cerr)
+		}
+	}
+	if q.clearFeedInfoStmt != nil {
+		if cerr := q.clearFeedInfoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearFeedInfoStmt: %w", cerr)
+		}
+	}
+	if q.clearFlexStopTimesStmt != nil {
+		if cerr := q.clearFlexStopTimesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearFlexStopTimesStmt: %w", cerr)
+		}
+	}
+	if q.clearFrequenciesStmt != nil {
+		if cerr := q.clearFrequenciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearFrequenciesStmt: %w", cerr)
+		}
+	}
+	if q.clearImportWarningsStmt != nil {
+		if cerr := q.clearImportWarningsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearImportWarningsStmt: %w", cerr)
+		}
+	}
+	if q.clearLocationGroupStopsStmt != nil {
+		if cerr := q.clearLocationGroupStopsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearLocationGroupStopsStmt: %w", cerr)
+		}
+	}
+	if q.clearLocationGroupsStmt != nil {
+		if cerr := q.clearLocationGroupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearLocationGroupsStmt: %w", cerr)
+		}
+	}
+	if q.clearLocationsStmt != nil {
+		if cerr := q.clearLocationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearLocationsStmt: %w", cerr)
+		}
+	}
+	if q.clearNetworksStmt != nil {
+		if cerr := q.clearNetworksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearNetworksStmt: %w", cerr)
+		}
+	}
+	if q.clearQuarantinedRowsStmt != nil {
+		if cerr := q.clearQuarantinedRowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearQuarantinedRowsStmt: %w", cerr)
+		}
+	}
 	if q.clearRoutesStmt != nil {
 		if cerr := q.clearRoutesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing clearRoutesStmt: %w", cerr)
 		}
 	}
+	if q.clearShapeGeometriesStmt != nil {
+		if cerr := q.clearShapeGeometriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearShapeGeometriesStmt: %w", cerr)
+		}
+	}
 	if q.clearShapesStmt != nil {
 		if cerr := q.clearShapesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing clearShapesStmt: %w", cerr)
@@ -350,11 +613,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing clearStopsStmt: %w", cerr)
 		}
 	}
+	if q.clearTransfersStmt != nil {
+		if cerr := q.clearTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearTransfersStmt: %w", cerr)
+		}
+	}
+	if q.clearTranslationsStmt != nil {
+		if cerr := q.clearTranslationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearTranslationsStmt: %w", cerr)
+		}
+	}
 	if q.clearTripsStmt != nil {
 		if cerr := q.clearTripsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing clearTripsStmt: %w", cerr)
 		}
 	}
+	if q.countImportWarningsStmt != nil {
+		if cerr := q.countImportWarningsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countImportWarningsStmt: %w", cerr)
+		}
+	}
 	if q.createAgencyStmt != nil {
 		if cerr := q.createAgencyStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createAgencyStmt: %w", cerr)
@@ -370,6 +648,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createBlockTripIndexStmt: %w", cerr)
 		}
 	}
+	if q.createBookingRuleStmt != nil {
+		if cerr := q.createBookingRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBookingRuleStmt: %w", cerr)
+		}
+	}
 	if q.createCalendarStmt != nil {
 		if cerr := q.createCalendarStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createCalendarStmt: %w", cerr)
@@ -380,6 +663,51 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createCalendarDateStmt: %w", cerr)
 		}
 	}
+	if q.createFareAttributeStmt != nil {
+		if cerr := q.createFareAttributeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFareAttributeStmt: %w", cerr)
+		}
+	}
+	if q.createFareRuleStmt != nil {
+		if cerr := q.createFareRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFareRuleStmt: %w", cerr)
+		}
+	}
+	if q.createFlexStopTimeStmt != nil {
+		if cerr := q.createFlexStopTimeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFlexStopTimeStmt: %w", cerr)
+		}
+	}
+	if q.createFrequencyStmt != nil {
+		if cerr := q.createFrequencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFrequencyStmt: %w", cerr)
+		}
+	}
+	if q.createImportWarningStmt != nil {
+		if cerr := q.createImportWarningStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createImportWarningStmt: %w", cerr)
+		}
+	}
+	if q.createLocationStmt != nil {
+		if cerr := q.createLocationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationStmt: %w", cerr)
+		}
+	}
+	if q.createLocationGroupStmt != nil {
+		if cerr := q.createLocationGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationGroupStmt: %w", cerr)
+		}
+	}
+	if q.createLocationGroupStopStmt != nil {
+		if cerr := q.createLocationGroupStopStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationGroupStopStmt: %w", cerr)
+		}
+	}
+	if q.createNetworkStmt != nil {
+		if cerr := q.createNetworkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNetworkStmt: %w", cerr)
+		}
+	}
 	if q.createProblemReportStopStmt != nil {
 		if cerr := q.createProblemReportStopStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createProblemReportStopStmt: %w", cerr)
@@ -400,6 +728,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createShapeStmt: %w", cerr)
 		}
 	}
+	if q.createShapeGeometryStmt != nil {
+		if cerr := q.createShapeGeometryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createShapeGeometryStmt: %w", cerr)
+		}
+	}
 	if q.createStopStmt != nil {
 		if cerr := q.createStopStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createStopStmt: %w", cerr)
@@ -410,11 +743,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createStopTimeStmt: %w", cerr)
 		}
 	}
+	if q.createTransferStmt != nil {
+		if cerr := q.createTransferStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransferStmt: %w", cerr)
+		}
+	}
+	if q.createTranslationStmt != nil {
+		if cerr := q.createTranslationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTranslationStmt: %w", cerr)
+		}
+	}
 	if q.createTripStmt != nil {
 		if cerr := q.createTripStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTripStmt: %w", cerr)
 		}
 	}
+	if q.createVehiclePositionHistoryStmt != nil {
+		if cerr := q.createVehiclePositionHistoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVehiclePositionHistoryStmt: %w", cerr)
+		}
+	}
+	if q.deleteScheduleDeviationSamplesBeforeStmt != nil {
+		if cerr := q.deleteScheduleDeviationSamplesBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteScheduleDeviationSamplesBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteVehiclePositionsHistoryBeforeStmt != nil {
+		if cerr := q.deleteVehiclePositionsHistoryBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteVehiclePositionsHistoryBeforeStmt: %w", cerr)
+		}
+	}
 	if q.getActiveRouteIDsForStopsOnDateStmt != nil {
 		if cerr := q.getActiveRouteIDsForStopsOnDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getActiveRouteIDsForStopsOnDateStmt: %w", cerr)
@@ -475,11 +833,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getBlockDetailsStmt: %w", cerr)
 		}
 	}
+	if q.getBlockDetailsForBlocksStmt != nil {
+		if cerr := q.getBlockDetailsForBlocksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBlockDetailsForBlocksStmt: %w", cerr)
+		}
+	}
 	if q.getBlockIDByTripIDStmt != nil {
 		if cerr := q.getBlockIDByTripIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getBlockIDByTripIDStmt: %w", cerr)
 		}
 	}
+	if q.getBlockIDsForAgencyStmt != nil {
+		if cerr := q.getBlockIDsForAgencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBlockIDsForAgencyStmt: %w", cerr)
+		}
+	}
 	if q.getBlockTripIndexIDsForBlocksStmt != nil {
 		if cerr := q.getBlockTripIndexIDsForBlocksStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getBlockTripIndexIDsForBlocksStmt: %w", cerr)
@@ -495,6 +863,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getBlocksForBlockTripIndexIDsStmt: %w", cerr)
 		}
 	}
+	if q.getBookingRuleStmt != nil {
+		if cerr := q.getBookingRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBookingRuleStmt: %w", cerr)
+		}
+	}
 	if q.getCalendarByServiceIDStmt != nil {
 		if cerr := q.getCalendarByServiceIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getCalendarByServiceIDStmt: %w", cerr)
@@ -505,16 +878,81 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getCalendarDateExceptionsForServiceIDStmt: %w", cerr)
 		}
 	}
+	if q.getFaresForRouteStmt != nil {
+		if cerr := q.getFaresForRouteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFaresForRouteStmt: %w", cerr)
+		}
+	}
+	if q.getFeedInfoStmt != nil {
+		if cerr := q.getFeedInfoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFeedInfoStmt: %w", cerr)
+		}
+	}
+	if q.getFlexStopTimesForTripStmt != nil {
+		if cerr := q.getFlexStopTimesForTripStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFlexStopTimesForTripStmt: %w", cerr)
+		}
+	}
+	if q.getFrequenciesForTripStmt != nil {
+		if cerr := q.getFrequenciesForTripStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFrequenciesForTripStmt: %w", cerr)
+		}
+	}
+	if q.getFrequencyStopTimesForStopStmt != nil {
+		if cerr := q.getFrequencyStopTimesForStopStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFrequencyStopTimesForStopStmt: %w", cerr)
+		}
+	}
+	if q.getHistoricalOccupancyForTripStmt != nil {
+		if cerr := q.getHistoricalOccupancyForTripStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getHistoricalOccupancyForTripStmt: %w", cerr)
+		}
+	}
 	if q.getImportMetadataStmt != nil {
 		if cerr := q.getImportMetadataStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getImportMetadataStmt: %w", cerr)
 		}
 	}
+	if q.getLocationStmt != nil {
+		if cerr := q.getLocationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationStmt: %w", cerr)
+		}
+	}
+	if q.getLocationGroupStmt != nil {
+		if cerr := q.getLocationGroupStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationGroupStmt: %w", cerr)
+		}
+	}
+	if q.getLocationGroupStopIDsStmt != nil {
+		if cerr := q.getLocationGroupStopIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationGroupStopIDsStmt: %w", cerr)
+		}
+	}
+	if q.getLocationGroupsForStopStmt != nil {
+		if cerr := q.getLocationGroupsForStopStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationGroupsForStopStmt: %w", cerr)
+		}
+	}
+	if q.getLocationsContainingPointStmt != nil {
+		if cerr := q.getLocationsContainingPointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationsContainingPointStmt: %w", cerr)
+		}
+	}
+	if q.getMaxStopTimeStmt != nil {
+		if cerr := q.getMaxStopTimeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMaxStopTimeStmt: %w", cerr)
+		}
+	}
 	if q.getNextStopInTripStmt != nil {
 		if cerr := q.getNextStopInTripStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getNextStopInTripStmt: %w", cerr)
 		}
 	}
+	if q.getOnTimePerformanceByRouteStmt != nil {
+		if cerr := q.getOnTimePerformanceByRouteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOnTimePerformanceByRouteStmt: %w", cerr)
+		}
+	}
 	if q.getOrderedStopIDsForTripStmt != nil {
 		if cerr := q.getOrderedStopIDsForTripStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderedStopIDsForTripStmt: %w", cerr)
@@ -530,6 +968,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getProblemReportsByTripStmt: %w", cerr)
 		}
 	}
+	if q.getRecentVehiclePositionsForTripStmt != nil {
+		if cerr := q.getRecentVehiclePositionsForTripStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRecentVehiclePositionsForTripStmt: %w", cerr)
+		}
+	}
 	if q.getRouteStmt != nil {
 		if cerr := q.getRouteStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRouteStmt: %w", cerr)
@@ -565,6 +1008,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getRoutesForStopsStmt: %w", cerr)
 		}
 	}
+	if q.getRoutesForStopsWithRouteTypesStmt != nil {
+		if cerr := q.getRoutesForStopsWithRouteTypesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRoutesForStopsWithRouteTypesStmt: %w", cerr)
+		}
+	}
 	if q.getRoutesInBlockTripIndicesStmt != nil {
 		if cerr := q.getRoutesInBlockTripIndicesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRoutesInBlockTripIndicesStmt: %w", cerr)
@@ -585,11 +1033,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getShapeByIDStmt: %w", cerr)
 		}
 	}
+	if q.getShapeContextForStopsWithoutDirectionStmt != nil {
+		if cerr := q.getShapeContextForStopsWithoutDirectionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShapeContextForStopsWithoutDirectionStmt: %w", cerr)
+		}
+	}
+	if q.getShapeGeometriesStmt != nil {
+		if cerr := q.getShapeGeometriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShapeGeometriesStmt: %w", cerr)
+		}
+	}
 	if q.getShapePointWindowStmt != nil {
 		if cerr := q.getShapePointWindowStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getShapePointWindowStmt: %w", cerr)
 		}
 	}
+	if q.getShapePointsStmt != nil {
+		if cerr := q.getShapePointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getShapePointsStmt: %w", cerr)
+		}
+	}
 	if q.getShapePointsByIDsStmt != nil {
 		if cerr := q.getShapePointsByIDsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getShapePointsByIDsStmt: %w", cerr)
@@ -660,6 +1123,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getStopTimesForTripIDsStmt: %w", cerr)
 		}
 	}
+	if q.getStopsByCodeStmt != nil {
+		if cerr := q.getStopsByCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStopsByCodeStmt: %w", cerr)
+		}
+	}
 	if q.getStopsByIDsStmt != nil {
 		if cerr := q.getStopsByIDsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getStopsByIDsStmt: %w", cerr)
@@ -690,11 +1158,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getStopsWithTripContextStmt: %w", cerr)
 		}
 	}
+	if q.getTransfersFromStopStmt != nil {
+		if cerr := q.getTransfersFromStopStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransfersFromStopStmt: %w", cerr)
+		}
+	}
+	if q.getTranslationsStmt != nil {
+		if cerr := q.getTranslationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTranslationsStmt: %w", cerr)
+		}
+	}
 	if q.getTripStmt != nil {
 		if cerr := q.getTripStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTripStmt: %w", cerr)
 		}
 	}
+	if q.getTripServiceSpanStmt != nil {
+		if cerr := q.getTripServiceSpanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTripServiceSpanStmt: %w", cerr)
+		}
+	}
 	if q.getTripsByBlockIDStmt != nil {
 		if cerr := q.getTripsByBlockIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTripsByBlockIDStmt: %w", cerr)
@@ -735,11 +1218,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getTripsInBlockStmt: %w", cerr)
 		}
 	}
+	if q.getTripsServingStopsInOrderStmt != nil {
+		if cerr := q.getTripsServingStopsInOrderStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTripsServingStopsInOrderStmt: %w", cerr)
+		}
+	}
+	if q.getVehiclePositionsInWindowStmt != nil {
+		if cerr := q.getVehiclePositionsInWindowStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVehiclePositionsInWindowStmt: %w", cerr)
+		}
+	}
+	if q.incrementHistoricalOccupancyStmt != nil {
+		if cerr := q.incrementHistoricalOccupancyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementHistoricalOccupancyStmt: %w", cerr)
+		}
+	}
 	if q.listAgenciesStmt != nil {
 		if cerr := q.listAgenciesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAgenciesStmt: %w", cerr)
 		}
 	}
+	if q.listImportWarningsStmt != nil {
+		if cerr := q.listImportWarningsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listImportWarningsStmt: %w", cerr)
+		}
+	}
+	if q.listNetworksStmt != nil {
+		if cerr := q.listNetworksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNetworksStmt: %w", cerr)
+		}
+	}
+	if q.listQuarantinedRowsStmt != nil {
+		if cerr := q.listQuarantinedRowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listQuarantinedRowsStmt: %w", cerr)
+		}
+	}
 	if q.listRoutesStmt != nil {
 		if cerr := q.listRoutesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listRoutesStmt: %w", cerr)
@@ -750,6 +1263,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listStopsStmt: %w", cerr)
 		}
 	}
+	if q.listStopsWithoutDirectionStmt != nil {
+		if cerr := q.listStopsWithoutDirectionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStopsWithoutDirectionStmt: %w", cerr)
+		}
+	}
 	if q.listTripsStmt != nil {
 		if cerr := q.listTripsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTripsStmt: %w", cerr)
@@ -760,11 +1278,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateStopDirectionStmt: %w", cerr)
 		}
 	}
+	if q.upsertFeedInfoStmt != nil {
+		if cerr := q.upsertFeedInfoStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertFeedInfoStmt: %w", cerr)
+		}
+	}
 	if q.upsertImportMetadataStmt != nil {
 		if cerr := q.upsertImportMetadataStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing upsertImportMetadataStmt: %w", cerr)
 		}
 	}
+	if q.upsertScheduleDeviationSampleStmt != nil {
+		if cerr := q.upsertScheduleDeviationSampleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertScheduleDeviationSampleStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -802,197 +1330,329 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                                        DBTX
-	tx                                        *sql.Tx
-	clearAgenciesStmt                         *sql.Stmt
-	clearBlockTripEntriesStmt                 *sql.Stmt
-	clearBlockTripIndicesStmt                 *sql.Stmt
-	clearCalendarStmt                         *sql.Stmt
-	clearCalendarDatesStmt                    *sql.Stmt
-	clearRoutesStmt                           *sql.Stmt
-	clearShapesStmt                           *sql.Stmt
-	clearStopTimesStmt                        *sql.Stmt
-	clearStopsStmt                            *sql.Stmt
-	clearTripsStmt                            *sql.Stmt
-	createAgencyStmt                          *sql.Stmt
-	createBlockTripEntryStmt                  *sql.Stmt
-	createBlockTripIndexStmt                  *sql.Stmt
-	createCalendarStmt                        *sql.Stmt
-	createCalendarDateStmt                    *sql.Stmt
-	createProblemReportStopStmt               *sql.Stmt
-	createProblemReportTripStmt               *sql.Stmt
-	createRouteStmt                           *sql.Stmt
-	createShapeStmt                           *sql.Stmt
-	createStopStmt                            *sql.Stmt
-	createStopTimeStmt                        *sql.Stmt
-	createTripStmt                            *sql.Stmt
-	getActiveRouteIDsForStopsOnDateStmt       *sql.Stmt
-	getActiveServiceIDsForDateStmt            *sql.Stmt
-	getActiveStopsStmt                        *sql.Stmt
-	getActiveTripForRouteAtTimeStmt           *sql.Stmt
-	getActiveTripInBlockAtTimeStmt            *sql.Stmt
-	getAgenciesForStopsStmt                   *sql.Stmt
-	getAgencyStmt                             *sql.Stmt
-	getAgencyForStopStmt                      *sql.Stmt
-	getAllShapesStmt                          *sql.Stmt
-	getAllTripsForRouteStmt                   *sql.Stmt
-	getArrivalsAndDeparturesForStopStmt       *sql.Stmt
-	getBlockDetailsStmt                       *sql.Stmt
-	getBlockIDByTripIDStmt                    *sql.Stmt
-	getBlockTripIndexIDsForBlocksStmt         *sql.Stmt
-	getBlockTripIndexIDsForRouteStmt          *sql.Stmt
-	getBlocksForBlockTripIndexIDsStmt         *sql.Stmt
-	getCalendarByServiceIDStmt                *sql.Stmt
-	getCalendarDateExceptionsForServiceIDStmt *sql.Stmt
-	getImportMetadataStmt                     *sql.Stmt
-	getNextStopInTripStmt                     *sql.Stmt
-	getOrderedStopIDsForTripStmt              *sql.Stmt
-	getProblemReportsByStopStmt               *sql.Stmt
-	getProblemReportsByTripStmt               *sql.Stmt
-	getRouteStmt                              *sql.Stmt
-	getRouteIDsForAgencyStmt                  *sql.Stmt
-	getRouteIDsForStopStmt                    *sql.Stmt
-	getRouteIDsForStopsStmt                   *sql.Stmt
-	getRoutesByIDsStmt                        *sql.Stmt
-	getRoutesForStopStmt                      *sql.Stmt
-	getRoutesForStopsStmt                     *sql.Stmt
-	getRoutesInBlockTripIndicesStmt           *sql.Stmt
-	getScheduleForStopStmt                    *sql.Stmt
-	getScheduleForStopOnDateStmt              *sql.Stmt
-	getShapeByIDStmt                          *sql.Stmt
-	getShapePointWindowStmt                   *sql.Stmt
-	getShapePointsByIDsStmt                   *sql.Stmt
-	getShapePointsByTripIDStmt                *sql.Stmt
-	getShapePointsForTripStmt                 *sql.Stmt
-	getShapePointsWithDistanceStmt            *sql.Stmt
-	getShapesGroupedByTripHeadSignStmt        *sql.Stmt
-	getStopStmt                               *sql.Stmt
-	getStopForAgencyStmt                      *sql.Stmt
-	getStopIDsForAgencyStmt                   *sql.Stmt
-	getStopIDsForRouteStmt                    *sql.Stmt
-	getStopIDsForTripStmt                     *sql.Stmt
-	getStopTimesByStopIDsStmt                 *sql.Stmt
-	getStopTimesForStopInWindowStmt           *sql.Stmt
-	getStopTimesForTripStmt                   *sql.Stmt
-	getStopTimesForTripIDsStmt                *sql.Stmt
-	getStopsByIDsStmt                         *sql.Stmt
-	getStopsForRouteStmt                      *sql.Stmt
-	getStopsWithActiveServiceOnDateStmt       *sql.Stmt
-	getStopsWithShapeContextStmt              *sql.Stmt
-	getStopsWithShapeContextByIDsStmt         *sql.Stmt
-	getStopsWithTripContextStmt               *sql.Stmt
-	getTripStmt                               *sql.Stmt
-	getTripsByBlockIDStmt                     *sql.Stmt
-	getTripsByBlockIDOrderedStmt              *sql.Stmt
-	getTripsByBlockIDsStmt                    *sql.Stmt
-	getTripsByBlockTripIndexIDsStmt           *sql.Stmt
-	getTripsByIDsStmt                         *sql.Stmt
-	getTripsByServiceIDStmt                   *sql.Stmt
-	getTripsForRouteInActiveServiceIDsStmt    *sql.Stmt
-	getTripsInBlockStmt                       *sql.Stmt
-	listAgenciesStmt                          *sql.Stmt
-	listRoutesStmt                            *sql.Stmt
-	listStopsStmt                             *sql.Stmt
-	listTripsStmt                             *sql.Stmt
-	updateStopDirectionStmt                   *sql.Stmt
-	upsertImportMetadataStmt                  *sql.Stmt
+	db                                          DBTX
+	tx                                          *sql.Tx
+	clearAgenciesStmt                           *sql.Stmt
+	clearBlockTripEntriesStmt                   *sql.Stmt
+	clearBlockTripIndicesStmt                   *sql.Stmt
+	clearBookingRulesStmt                       *sql.Stmt
+	clearCalendarStmt                           *sql.Stmt
+	clearCalendarDatesStmt                      *sql.Stmt
+	clearFareAttributesStmt                     *sql.Stmt
+	clearFareRulesStmt                          *sql.Stmt
+	clearFeedInfoStmt                           *sql.Stmt
+	clearFlexStopTimesStmt                      *sql.Stmt
+	clearFrequenciesStmt                        *sql.Stmt
+	clearImportWarningsStmt                     *sql.Stmt
+	clearLocationGroupStopsStmt                 *sql.Stmt
+	clearLocationGroupsStmt                     *sql.Stmt
+	clearLocationsStmt                          *sql.Stmt
+	clearNetworksStmt                           *sql.Stmt
+	clearQuarantinedRowsStmt                    *sql.Stmt
+	clearRoutesStmt                             *sql.Stmt
+	clearShapeGeometriesStmt                    *sql.Stmt
+	clearShapesStmt                             *sql.Stmt
+	clearStopTimesStmt                          *sql.Stmt
+	clearStopsStmt                              *sql.Stmt
+	clearTransfersStmt                          *sql.Stmt
+	clearTranslationsStmt                       *sql.Stmt
+	clearTripsStmt                              *sql.Stmt
+	countImportWarningsStmt                     *sql.Stmt
+	createAgencyStmt                            *sql.Stmt
+	createBlockTripEntryStmt                    *sql.Stmt
+	createBlockTripIndexStmt                    *sql.Stmt
+	createBookingRuleStmt                       *sql.Stmt
+	createCalendarStmt                          *sql.Stmt
+	createCalendarDateStmt                      *sql.Stmt
+	createFareAttributeStmt                     *sql.Stmt
+	createFareRuleStmt                          *sql.Stmt
+	createFlexStopTimeStmt                      *sql.Stmt
+	createFrequencyStmt                         *sql.Stmt
+	createImportWarningStmt                     *sql.Stmt
+	createLocationStmt                          *sql.Stmt
+	createLocationGroupStmt                     *sql.Stmt
+	createLocationGroupStopStmt                 *sql.Stmt
+	createNetworkStmt                           *sql.Stmt
+	createProblemReportStopStmt                 *sql.Stmt
+	createProblemReportTripStmt                 *sql.Stmt
+	createRouteStmt                             *sql.Stmt
+	createShapeStmt                             *sql.Stmt
+	createShapeGeometryStmt                     *sql.Stmt
+	createStopStmt                              *sql.Stmt
+	createStopTimeStmt                          *sql.Stmt
+	createTransferStmt                          *sql.Stmt
+	createTranslationStmt                       *sql.Stmt
+	createTripStmt                              *sql.Stmt
+	createVehiclePositionHistoryStmt            *sql.Stmt
+	deleteScheduleDeviationSamplesBeforeStmt    *sql.Stmt
+	deleteVehiclePositionsHistoryBeforeStmt     *sql.Stmt
+	getActiveRouteIDsForStopsOnDateStmt         *sql.Stmt
+	getActiveServiceIDsForDateStmt              *sql.Stmt
+	getActiveStopsStmt                          *sql.Stmt
+	getActiveTripForRouteAtTimeStmt             *sql.Stmt
+	getActiveTripInBlockAtTimeStmt              *sql.Stmt
+	getAgenciesForStopsStmt                     *sql.Stmt
+	getAgencyStmt                               *sql.Stmt
+	getAgencyForStopStmt                        *sql.Stmt
+	getAllShapesStmt                            *sql.Stmt
+	getAllTripsForRouteStmt                     *sql.Stmt
+	getArrivalsAndDeparturesForStopStmt         *sql.Stmt
+	getBlockDetailsStmt                         *sql.Stmt
+	getBlockDetailsForBlocksStmt                *sql.Stmt
+	getBlockIDByTripIDStmt                      *sql.Stmt
+	getBlockIDsForAgencyStmt                    *sql.Stmt
+	getBlockTripIndexIDsForBlocksStmt           *sql.Stmt
+	getBlockTripIndexIDsForRouteStmt            *sql.Stmt
+	getBlocksForBlockTripIndexIDsStmt           *sql.Stmt
+	getBookingRuleStmt                          *sql.Stmt
+	getCalendarByServiceIDStmt                  *sql.Stmt
+	getCalendarDateExceptionsForServiceIDStmt   *sql.Stmt
+	getFaresForRouteStmt                        *sql.Stmt
+	getFeedInfoStmt                             *sql.Stmt
+	getFlexStopTimesForTripStmt                 *sql.Stmt
+	getFrequenciesForTripStmt                   *sql.Stmt
+	getFrequencyStopTimesForStopStmt            *sql.Stmt
+	getHistoricalOccupancyForTripStmt           *sql.Stmt
+	getImportMetadataStmt                       *sql.Stmt
+	getLocationStmt                             *sql.Stmt
+	getLocationGroupStmt                        *sql.Stmt
+	getLocationGroupStopIDsStmt                 *sql.Stmt
+	getLocationGroupsForStopStmt                *sql.Stmt
+	getLocationsContainingPointStmt             *sql.Stmt
+	getMaxStopTimeStmt                          *sql.Stmt
+	getNextStopInTripStmt                       *sql.Stmt
+	getOnTimePerformanceByRouteStmt             *sql.Stmt
+	getOrderedStopIDsForTripStmt                *sql.Stmt
+	getProblemReportsByStopStmt                 *sql.Stmt
+	getProblemReportsByTripStmt                 *sql.Stmt
+	getRecentVehiclePositionsForTripStmt        *sql.Stmt
+	getRouteStmt                                *sql.Stmt
+	getRouteIDsForAgencyStmt                    *sql.Stmt
+	getRouteIDsForStopStmt                      *sql.Stmt
+	getRouteIDsForStopsStmt                     *sql.Stmt
+	getRoutesByIDsStmt                          *sql.Stmt
+	getRoutesForStopStmt                        *sql.Stmt
+	getRoutesForStopsStmt                       *sql.Stmt
+	getRoutesForStopsWithRouteTypesStmt         *sql.Stmt
+	getRoutesInBlockTripIndicesStmt             *sql.Stmt
+	getScheduleForStopStmt                      *sql.Stmt
+	getScheduleForStopOnDateStmt                *sql.Stmt
+	getShapeByIDStmt                            *sql.Stmt
+	getShapeContextForStopsWithoutDirectionStmt *sql.Stmt
+	getShapeGeometriesStmt                      *sql.Stmt
+	getShapePointWindowStmt                     *sql.Stmt
+	getShapePointsStmt                          *sql.Stmt
+	getShapePointsByIDsStmt                     *sql.Stmt
+	getShapePointsByTripIDStmt                  *sql.Stmt
+	getShapePointsForTripStmt                   *sql.Stmt
+	getShapePointsWithDistanceStmt              *sql.Stmt
+	getShapesGroupedByTripHeadSignStmt          *sql.Stmt
+	getStopStmt                                 *sql.Stmt
+	getStopForAgencyStmt                        *sql.Stmt
+	getStopIDsForAgencyStmt                     *sql.Stmt
+	getStopIDsForRouteStmt                      *sql.Stmt
+	getStopIDsForTripStmt                       *sql.Stmt
+	getStopTimesByStopIDsStmt                   *sql.Stmt
+	getStopTimesForStopInWindowStmt             *sql.Stmt
+	getStopTimesForTripStmt                     *sql.Stmt
+	getStopTimesForTripIDsStmt                  *sql.Stmt
+	getStopsByCodeStmt                          *sql.Stmt
+	getStopsByIDsStmt                           *sql.Stmt
+	getStopsForRouteStmt                        *sql.Stmt
+	getStopsWithActiveServiceOnDateStmt         *sql.Stmt
+	getStopsWithShapeContextStmt                *sql.Stmt
+	getStopsWithShapeContextByIDsStmt           *sql.Stmt
+	getStopsWithTripContextStmt                 *sql.Stmt
+	getTransfersFromStopStmt                    *sql.Stmt
+	getTranslationsStmt                         *sql.Stmt
+	getTripStmt                                 *sql.Stmt
+	getTripServiceSpanStmt                      *sql.Stmt
+	getTripsByBlockIDStmt                       *sql.Stmt
+	getTripsByBlockIDOrderedStmt                *sql.Stmt
+	getTripsByBlockIDsStmt                      *sql.Stmt
+	getTripsByBlockTripIndexIDsStmt             *sql.Stmt
+	getTripsByIDsStmt                           *sql.Stmt
+	getTripsByServiceIDStmt                     *sql.Stmt
+	getTripsForRouteInActiveServiceIDsStmt      *sql.Stmt
+	getTripsInBlockStmt                         *sql.Stmt
+	getTripsServingStopsInOrderStmt             *sql.Stmt
+	getVehiclePositionsInWindowStmt             *sql.Stmt
+	incrementHistoricalOccupancyStmt            *sql.Stmt
+	listAgenciesStmt                            *sql.Stmt
+	listImportWarningsStmt                      *sql.Stmt
+	listNetworksStmt                            *sql.Stmt
+	listQuarantinedRowsStmt                     *sql.Stmt
+	listRoutesStmt                              *sql.Stmt
+	listStopsStmt                               *sql.Stmt
+	listStopsWithoutDirectionStmt               *sql.Stmt
+	listTripsStmt                               *sql.Stmt
+	updateStopDirectionStmt                     *sql.Stmt
+	upsertFeedInfoStmt                          *sql.Stmt
+	upsertImportMetadataStmt                    *sql.Stmt
+	upsertScheduleDeviationSampleStmt           *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                                        tx,
-		tx:                                        tx,
-		clearAgenciesStmt:                         q.clearAgenciesStmt,
-		clearBlockTripEntriesStmt:                 q.clearBlockTripEntriesStmt,
-		clearBlockTripIndicesStmt:                 q.clearBlockTripIndicesStmt,
-		clearCalendarStmt:                         q.clearCalendarStmt,
-		clearCalendarDatesStmt:                    q.clearCalendarDatesStmt,
-		clearRoutesStmt:                           q.clearRoutesStmt,
-		clearShapesStmt:                           q.clearShapesStmt,
-		clearStopTimesStmt:                        q.clearStopTimesStmt,
-		clearStopsStmt:                            q.clearStopsStmt,
-		clearTripsStmt:                            q.clearTripsStmt,
-		createAgencyStmt:                          q.createAgencyStmt,
-		createBlockTripEntryStmt:                  q.createBlockTripEntryStmt,
-		createBlockTripIndexStmt:                  q.createBlockTripIndexStmt,
-		createCalendarStmt:                        q.createCalendarStmt,
-		createCalendarDateStmt:                    q.createCalendarDateStmt,
-		createProblemReportStopStmt:               q.createProblemReportStopStmt,
-		createProblemReportTripStmt:               q.createProblemReportTripStmt,
-		createRouteStmt:                           q.createRouteStmt,
-		createShapeStmt:                           q.createShapeStmt,
-		createStopStmt:                            q.createStopStmt,
-		createStopTimeStmt:                        q.createStopTimeStmt,
-		createTripStmt:                            q.createTripStmt,
-		getActiveRouteIDsForStopsOnDateStmt:       q.getActiveRouteIDsForStopsOnDateStmt,
-		getActiveServiceIDsForDateStmt:            q.getActiveServiceIDsForDateStmt,
-		getActiveStopsStmt:                        q.getActiveStopsStmt,
-		getActiveTripForRouteAtTimeStmt:           q.getActiveTripForRouteAtTimeStmt,
-		getActiveTripInBlockAtTimeStmt:            q.getActiveTripInBlockAtTimeStmt,
-		getAgenciesForStopsStmt:                   q.getAgenciesForStopsStmt,
-		getAgencyStmt:                             q.getAgencyStmt,
-		getAgencyForStopStmt:                      q.getAgencyForStopStmt,
-		getAllShapesStmt:                          q.getAllShapesStmt,
-		getAllTripsForRouteStmt:                   q.getAllTripsForRouteStmt,
-		getArrivalsAndDeparturesForStopStmt:       q.getArrivalsAndDeparturesForStopStmt,
-		getBlockDetailsStmt:                       q.getBlockDetailsStmt,
-		getBlockIDByTripIDStmt:                    q.getBlockIDByTripIDStmt,
-		getBlockTripIndexIDsForBlocksStmt:         q.getBlockTripIndexIDsForBlocksStmt,
-		getBlockTripIndexIDsForRouteStmt:          q.getBlockTripIndexIDsForRouteStmt,
-		getBlocksForBlockTripIndexIDsStmt:         q.getBlocksForBlockTripIndexIDsStmt,
-		getCalendarByServiceIDStmt:                q.getCalendarByServiceIDStmt,
-		getCalendarDateExceptionsForServiceIDStmt: q.getCalendarDateExceptionsForServiceIDStmt,
-		getImportMetadataStmt:                     q.getImportMetadataStmt,
-		getNextStopInTripStmt:                     q.getNextStopInTripStmt,
-		getOrderedStopIDsForTripStmt:              q.getOrderedStopIDsForTripStmt,
-		getProblemReportsByStopStmt:               q.getProblemReportsByStopStmt,
-		getProblemReportsByTripStmt:               q.getProblemReportsByTripStmt,
-		getRouteStmt:                              q.getRouteStmt,
-		getRouteIDsForAgencyStmt:                  q.getRouteIDsForAgencyStmt,
-		getRouteIDsForStopStmt:                    q.getRouteIDsForStopStmt,
-		getRouteIDsForStopsStmt:                   q.getRouteIDsForStopsStmt,
-		getRoutesByIDsStmt:                        q.getRoutesByIDsStmt,
-		getRoutesForStopStmt:                      q.getRoutesForStopStmt,
-		getRoutesForStopsStmt:                     q.getRoutesForStopsStmt,
-		getRoutesInBlockTripIndicesStmt:           q.getRoutesInBlockTripIndicesStmt,
-		getScheduleForStopStmt:                    q.getScheduleForStopStmt,
-		getScheduleForStopOnDateStmt:              q.getScheduleForStopOnDateStmt,
-		getShapeByIDStmt:                          q.getShapeByIDStmt,
-		getShapePointWindowStmt:                   q.getShapePointWindowStmt,
-		getShapePointsByIDsStmt:                   q.getShapePointsByIDsStmt,
-		getShapePointsByTripIDStmt:                q.getShapePointsByTripIDStmt,
-		getShapePointsForTripStmt:                 q.getShapePointsForTripStmt,
-		getShapePointsWithDistanceStmt:            q.getShapePointsWithDistanceStmt,
-		getShapesGroupedByTripHeadSignStmt:        q.getShapesGroupedByTripHeadSignStmt,
-		getStopStmt:                               q.getStopStmt,
-		getStopForAgencyStmt:                      q.getStopForAgencyStmt,
-		getStopIDsForAgencyStmt:                   q.getStopIDsForAgencyStmt,
-		getStopIDsForRouteStmt:                    q.getStopIDsForRouteStmt,
-		getStopIDsForTripStmt:                     q.getStopIDsForTripStmt,
-		getStopTimesByStopIDsStmt:                 q.getStopTimesByStopIDsStmt,
-		getStopTimesForStopInWindowStmt:           q.getStopTimesForStopInWindowStmt,
-		getStopTimesForTripStmt:                   q.getStopTimesForTripStmt,
-		getStopTimesForTripIDsStmt:                q.getStopTimesForTripIDsStmt,
-		getStopsByIDsStmt:                         q.getStopsByIDsStmt,
-		getStopsForRouteStmt:                      q.getStopsForRouteStmt,
-		getStopsWithActiveServiceOnDateStmt:       q.getStopsWithActiveServiceOnDateStmt,
-		getStopsWithShapeContextStmt:              q.getStopsWithShapeContextStmt,
-		getStopsWithShapeContextByIDsStmt:         q.getStopsWithShapeContextByIDsStmt,
-		getStopsWithTripContextStmt:               q.getStopsWithTripContextStmt,
-		getTripStmt:                               q.getTripStmt,
-		getTripsByBlockIDStmt:                     q.getTripsByBlockIDStmt,
-		getTripsByBlockIDOrderedStmt:              q.getTripsByBlockIDOrderedStmt,
-		getTripsByBlockIDsStmt:                    q.getTripsByBlockIDsStmt,
-		getTripsByBlockTripIndexIDsStmt:           q.getTripsByBlockTripIndexIDsStmt,
-		getTripsByIDsStmt:                         q.getTripsByIDsStmt,
-		getTripsByServiceIDStmt:                   q.getTripsByServiceIDStmt,
-		getTripsForRouteInActiveServiceIDsStmt:    q.getTripsForRouteInActiveServiceIDsStmt,
-		getTripsInBlockStmt:                       q.getTripsInBlockStmt,
-		listAgenciesStmt:                          q.listAgenciesStmt,
-		listRoutesStmt:                            q.listRoutesStmt,
-		listStopsStmt:                             q.listStopsStmt,
-		listTripsStmt:                             q.listTripsStmt,
-		updateStopDirectionStmt:                   q.updateStopDirectionStmt,
-		upsertImportMetadataStmt:                  q.upsertImportMetadataStmt,
+		db:                                          tx,
+		tx:                                          tx,
+		clearAgenciesStmt:                           q.clearAgenciesStmt,
+		clearBlockTripEntriesStmt:                   q.clearBlockTripEntriesStmt,
+		clearBlockTripIndicesStmt:                   q.clearBlockTripIndicesStmt,
+		clearBookingRulesStmt:                       q.clearBookingRulesStmt,
+		clearCalendarStmt:                           q.clearCalendarStmt,
+		clearCalendarDatesStmt:                      q.clearCalendarDatesStmt,
+		clearFareAttributesStmt:                     q.clearFareAttributesStmt,
+		clearFareRulesStmt:                          q.clearFareRulesStmt,
+		clearFeedInfoStmt:                           q.clearFeedInfoStmt,
+		clearFlexStopTimesStmt:                      q.clearFlexStopTimesStmt,
+		clearFrequenciesStmt:                        q.clearFrequenciesStmt,
+		clearImportWarningsStmt:                     q.clearImportWarningsStmt,
+		clearLocationGroupStopsStmt:                 q.clearLocationGroupStopsStmt,
+		clearLocationGroupsStmt:                     q.clearLocationGroupsStmt,
+		clearLocationsStmt:                          q.clearLocationsStmt,
+		clearNetworksStmt:                           q.clearNetworksStmt,
+		clearQuarantinedRowsStmt:                    q.clearQuarantinedRowsStmt,
+		clearRoutesStmt:                             q.clearRoutesStmt,
+		clearShapeGeometriesStmt:                    q.clearShapeGeometriesStmt,
+		clearShapesStmt:                             q.clearShapesStmt,
+		clearStopTimesStmt:                          q.clearStopTimesStmt,
+		clearStopsStmt:                              q.clearStopsStmt,
+		clearTransfersStmt:                          q.clearTransfersStmt,
+		clearTranslationsStmt:                       q.clearTranslationsStmt,
+		clearTripsStmt:                              q.clearTripsStmt,
+		countImportWarningsStmt:                     q.countImportWarningsStmt,
+		createAgencyStmt:                            q.createAgencyStmt,
+		createBlockTripEntryStmt:                    q.createBlockTripEntryStmt,
+		createBlockTripIndexStmt:                    q.createBlockTripIndexStmt,
+		createBookingRuleStmt:                       q.createBookingRuleStmt,
+		createCalendarStmt:                          q.createCalendarStmt,
+		createCalendarDateStmt:                      q.createCalendarDateStmt,
+		createFareAttributeStmt:                     q.createFareAttributeStmt,
+		createFareRuleStmt:                          q.createFareRuleStmt,
+		createFlexStopTimeStmt:                      q.createFlexStopTimeStmt,
+		createFrequencyStmt:                         q.createFrequencyStmt,
+		createImportWarningStmt:                     q.createImportWarningStmt,
+		createLocationStmt:                          q.createLocationStmt,
+		createLocationGroupStmt:                     q.createLocationGroupStmt,
+		createLocationGroupStopStmt:                 q.createLocationGroupStopStmt,
+		createNetworkStmt:                           q.createNetworkStmt,
+		createProblemReportStopStmt:                 q.createProblemReportStopStmt,
+		createProblemReportTripStmt:                 q.createProblemReportTripStmt,
+		createRouteStmt:                             q.createRouteStmt,
+		createShapeStmt:                             q.createShapeStmt,
+		createShapeGeometryStmt:                     q.createShapeGeometryStmt,
+		createStopStmt:                              q.createStopStmt,
+		createStopTimeStmt:                          q.createStopTimeStmt,
+		createTransferStmt:                          q.createTransferStmt,
+		createTranslationStmt:                       q.createTranslationStmt,
+		createTripStmt:                              q.createTripStmt,
+		createVehiclePositionHistoryStmt:            q.createVehiclePositionHistoryStmt,
+		deleteScheduleDeviationSamplesBeforeStmt:    q.deleteScheduleDeviationSamplesBeforeStmt,
+		deleteVehiclePositionsHistoryBeforeStmt:     q.deleteVehiclePositionsHistoryBeforeStmt,
+		getActiveRouteIDsForStopsOnDateStmt:         q.getActiveRouteIDsForStopsOnDateStmt,
+		getActiveServiceIDsForDateStmt:              q.getActiveServiceIDsForDateStmt,
+		getActiveStopsStmt:                          q.getActiveStopsStmt,
+		getActiveTripForRouteAtTimeStmt:             q.getActiveTripForRouteAtTimeStmt,
+		getActiveTripInBlockAtTimeStmt:              q.getActiveTripInBlockAtTimeStmt,
+		getAgenciesForStopsStmt:                     q.getAgenciesForStopsStmt,
+		getAgencyStmt:                               q.getAgencyStmt,
+		getAgencyForStopStmt:                        q.getAgencyForStopStmt,
+		getAllShapesStmt:                            q.getAllShapesStmt,
+		getAllTripsForRouteStmt:                     q.getAllTripsForRouteStmt,
+		getArrivalsAndDeparturesForStopStmt:         q.getArrivalsAndDeparturesForStopStmt,
+		getBlockDetailsStmt:                         q.getBlockDetailsStmt,
+		getBlockDetailsForBlocksStmt:                q.getBlockDetailsForBlocksStmt,
+		getBlockIDByTripIDStmt:                      q.getBlockIDByTripIDStmt,
+		getBlockIDsForAgencyStmt:                    q.getBlockIDsForAgencyStmt,
+		getBlockTripIndexIDsForBlocksStmt:           q.getBlockTripIndexIDsForBlocksStmt,
+		getBlockTripIndexIDsForRouteStmt:            q.getBlockTripIndexIDsForRouteStmt,
+		getBlocksForBlockTripIndexIDsStmt:           q.getBlocksForBlockTripIndexIDsStmt,
+		getBookingRuleStmt:                          q.getBookingRuleStmt,
+		getCalendarByServiceIDStmt:                  q.getCalendarByServiceIDStmt,
+		getCalendarDateExceptionsForServiceIDStmt:   q.getCalendarDateExceptionsForServiceIDStmt,
+		getFaresForRouteStmt:                        q.getFaresForRouteStmt,
+		getFeedInfoStmt:                             q.getFeedInfoStmt,
+		getFlexStopTimesForTripStmt:                 q.getFlexStopTimesForTripStmt,
+		getFrequenciesForTripStmt:                   q.getFrequenciesForTripStmt,
+		getFrequencyStopTimesForStopStmt:            q.getFrequencyStopTimesForStopStmt,
+		getHistoricalOccupancyForTripStmt:           q.getHistoricalOccupancyForTripStmt,
+		getImportMetadataStmt:                       q.getImportMetadataStmt,
+		getLocationStmt:                             q.getLocationStmt,
+		getLocationGroupStmt:                        q.getLocationGroupStmt,
+		getLocationGroupStopIDsStmt:                 q.getLocationGroupStopIDsStmt,
+		getLocationGroupsForStopStmt:                q.getLocationGroupsForStopStmt,
+		getLocationsContainingPointStmt:             q.getLocationsContainingPointStmt,
+		getMaxStopTimeStmt:                          q.getMaxStopTimeStmt,
+		getNextStopInTripStmt:                       q.getNextStopInTripStmt,
+		getOnTimePerformanceByRouteStmt:             q.getOnTimePerformanceByRouteStmt,
+		getOrderedStopIDsForTripStmt:                q.getOrderedStopIDsForTripStmt,
+		getProblemReportsByStopStmt:                 q.getProblemReportsByStopStmt,
+		getProblemReportsByTripStmt:                 q.getProblemReportsByTripStmt,
+		getRecentVehiclePositionsForTripStmt:        q.getRecentVehiclePositionsForTripStmt,
+		getRouteStmt:                                q.getRouteStmt,
+		getRouteIDsForAgencyStmt:                    q.getRouteIDsForAgencyStmt,
+		getRouteIDsForStopStmt:                      q.getRouteIDsForStopStmt,
+		getRouteIDsForStopsStmt:                     q.getRouteIDsForStopsStmt,
+		getRoutesByIDsStmt:                          q.getRoutesByIDsStmt,
+		getRoutesForStopStmt:                        q.getRoutesForStopStmt,
+		getRoutesForStopsStmt:                       q.getRoutesForStopsStmt,
+		getRoutesForStopsWithRouteTypesStmt:         q.getRoutesForStopsWithRouteTypesStmt,
+		getRoutesInBlockTripIndicesStmt:             q.getRoutesInBlockTripIndicesStmt,
+		getScheduleForStopStmt:                      q.getScheduleForStopStmt,
+		getScheduleForStopOnDateStmt:                q.getScheduleForStopOnDateStmt,
+		getShapeByIDStmt:                            q.getShapeByIDStmt,
+		getShapeContextForStopsWithoutDirectionStmt: q.getShapeContextForStopsWithoutDirectionStmt,
+		getShapeGeometriesStmt:                      q.getShapeGeometriesStmt,
+		getShapePointWindowStmt:                     q.getShapePointWindowStmt,
+		getShapePointsStmt:                          q.getShapePointsStmt,
+		getShapePointsByIDsStmt:                     q.getShapePointsByIDsStmt,
+		getShapePointsByTripIDStmt:                  q.getShapePointsByTripIDStmt,
+		getShapePointsForTripStmt:                   q.getShapePointsForTripStmt,
+		getShapePointsWithDistanceStmt:              q.getShapePointsWithDistanceStmt,
+		getShapesGroupedByTripHeadSignStmt:          q.getShapesGroupedByTripHeadSignStmt,
+		getStopStmt:                                 q.getStopStmt,
+		getStopForAgencyStmt:                        q.getStopForAgencyStmt,
+		getStopIDsForAgencyStmt:                     q.getStopIDsForAgencyStmt,
+		getStopIDsForRouteStmt:                      q.getStopIDsForRouteStmt,
+		getStopIDsForTripStmt:                       q.getStopIDsForTripStmt,
+		getStopTimesByStopIDsStmt:                   q.getStopTimesByStopIDsStmt,
+		getStopTimesForStopInWindowStmt:             q.getStopTimesForStopInWindowStmt,
+		getStopTimesForTripStmt:                     q.getStopTimesForTripStmt,
+		getStopTimesForTripIDsStmt:                  q.getStopTimesForTripIDsStmt,
+		getStopsByCodeStmt:                          q.getStopsByCodeStmt,
+		getStopsByIDsStmt:                           q.getStopsByIDsStmt,
+		getStopsForRouteStmt:                        q.getStopsForRouteStmt,
+		getStopsWithActiveServiceOnDateStmt:         q.getStopsWithActiveServiceOnDateStmt,
+		getStopsWithShapeContextStmt:                q.getStopsWithShapeContextStmt,
+		getStopsWithShapeContextByIDsStmt:           q.getStopsWithShapeContextByIDsStmt,
+		getStopsWithTripContextStmt:                 q.getStopsWithTripContextStmt,
+		getTransfersFromStopStmt:                    q.getTransfersFromStopStmt,
+		getTranslationsStmt:                         q.getTranslationsStmt,
+		getTripStmt:                                 q.getTripStmt,
+		getTripServiceSpanStmt:                      q.getTripServiceSpanStmt,
+		getTripsByBlockIDStmt:                       q.getTripsByBlockIDStmt,
+		getTripsByBlockIDOrderedStmt:                q.getTripsByBlockIDOrderedStmt,
+		getTripsByBlockIDsStmt:                      q.getTripsByBlockIDsStmt,
+		getTripsByBlockTripIndexIDsStmt:             q.getTripsByBlockTripIndexIDsStmt,
+		getTripsByIDsStmt:                           q.getTripsByIDsStmt,
+		getTripsByServiceIDStmt:                     q.getTripsByServiceIDStmt,
+		getTripsForRouteInActiveServiceIDsStmt:      q.getTripsForRouteInActiveServiceIDsStmt,
+		getTripsInBlockStmt:                         q.getTripsInBlockStmt,
+		getTripsServingStopsInOrderStmt:             q.getTripsServingStopsInOrderStmt,
+		getVehiclePositionsInWindowStmt:             q.getVehiclePositionsInWindowStmt,
+		incrementHistoricalOccupancyStmt:            q.incrementHistoricalOccupancyStmt,
+		listAgenciesStmt:                            q.listAgenciesStmt,
+		listImportWarningsStmt:                      q.listImportWarningsStmt,
+		listNetworksStmt:                            q.listNetworksStmt,
+		listQuarantinedRowsStmt:                     q.listQuarantinedRowsStmt,
+		listRoutesStmt:                              q.listRoutesStmt,
+		listStopsStmt:                               q.listStopsStmt,
+		listStopsWithoutDirectionStmt:               q.listStopsWithoutDirectionStmt,
+		listTripsStmt:                               q.listTripsStmt,
+		updateStopDirectionStmt:                     q.updateStopDirectionStmt,
+		upsertFeedInfoStmt:                          q.upsertFeedInfoStmt,
+		upsertImportMetadataStmt:                    q.upsertImportMetadataStmt,
+		upsertScheduleDeviationSampleStmt:           q.upsertScheduleDeviationSampleStmt,
 	}
 }
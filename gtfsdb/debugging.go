@@ -78,17 +78,29 @@ func (c *Client) TableCounts() (map[string]int, error) {
 	tableCountQueries := map[string]string{
 		"agencies":         "SELECT COUNT(*) FROM agencies",
 		"routes":           "SELECT COUNT(*) FROM routes",
+		"networks":         "SELECT COUNT(*) FROM networks",
 		"stops":            "SELECT COUNT(*) FROM stops",
 		"trips":            "SELECT COUNT(*) FROM trips",
 		"stop_times":       "SELECT COUNT(*) FROM stop_times",
 		"calendar":         "SELECT COUNT(*) FROM calendar",
 		"calendar_dates":   "SELECT COUNT(*) FROM calendar_dates",
 		"shapes":           "SELECT COUNT(*) FROM shapes",
+		"shape_geometries": "SELECT COUNT(*) FROM shape_geometries",
+		"frequencies":      "SELECT COUNT(*) FROM frequencies",
 		"transfers":        "SELECT COUNT(*) FROM transfers",
+		"fare_attributes":  "SELECT COUNT(*) FROM fare_attributes",
+		"fare_rules":       "SELECT COUNT(*) FROM fare_rules",
 		"feed_info":        "SELECT COUNT(*) FROM feed_info",
+		"translations":     "SELECT COUNT(*) FROM translations",
+		"locations":        "SELECT COUNT(*) FROM locations",
+		"location_groups":  "SELECT COUNT(*) FROM location_groups",
+		"booking_rules":    "SELECT COUNT(*) FROM booking_rules",
+		"flex_stop_times":  "SELECT COUNT(*) FROM flex_stop_times",
 		"block_trip_index": "SELECT COUNT(*) FROM block_trip_index",
 		"block_trip_entry": "SELECT COUNT(*) FROM block_trip_entry",
 		"import_metadata":  "SELECT COUNT(*) FROM import_metadata",
+		"import_warnings":  "SELECT COUNT(*) FROM import_warnings",
+		"quarantined_rows": "SELECT COUNT(*) FROM quarantined_rows",
 	}
 
 	for _, table := range tables {
@@ -0,0 +1,152 @@
+package gtfsdb
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEntityCacheSize is how many trips, routes and stops, each, the Client
+// keeps in memory by default.
+const DefaultEntityCacheSize = 2000
+
+// EntityCacheStats counts the lookups answered by one of the Client's entity
+// caches and the ones that went to the database.
+type EntityCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// lruCache is a fixed-size, least-recently-used cache safe for concurrent use.
+// A capacity of zero or less disables it.
+type lruCache[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	items    map[string]*list.Element
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRUCache[V any](capacity int) *lruCache[V] {
+	return &lruCache[V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		c.hits.Add(1)
+		return elem.Value.(*lruEntry[V]).value, true
+	}
+	c.misses.Add(1)
+	var zero V
+	return zero, false
+}
+
+func (c *lruCache[V]) add(key string, value V) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (c *lruCache[V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+func (c *lruCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache[V]) stats() EntityCacheStats {
+	return EntityCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// cached returns the value for key from cache, calling fetch on a miss. Only
+// successful fetches are cached, so a missing row or a cancelled query is
+// looked up again next time.
+func cached[V any](cache *lruCache[V], key string, fetch func() (V, error)) (V, error) {
+	if value, ok := cache.get(key); ok {
+		return value, nil
+	}
+	value, err := fetch()
+	if err == nil {
+		cache.add(key, value)
+	}
+	return value, err
+}
+
+// entityCaches holds the Client's caches of the rows handlers look up most.
+type entityCaches struct {
+	trips  *lruCache[Trip]
+	routes *lruCache[Route]
+	stops  *lruCache[GetStopRow]
+}
+
+func newEntityCaches(capacity int) *entityCaches {
+	return &entityCaches{
+		trips:  newLRUCache[Trip](capacity),
+		routes: newLRUCache[Route](capacity),
+		stops:  newLRUCache[GetStopRow](capacity),
+	}
+}
+
+func (e *entityCaches) purge() {
+	e.trips.purge()
+	e.routes.purge()
+	e.stops.purge()
+}
+
+// GetTrip is Queries.GetTrip answered from the Client's trip cache when it can.
+func (c *Client) GetTrip(ctx context.Context, id string) (Trip, error) {
+	return cached(c.entities.trips, id, func() (Trip, error) { return c.Queries.GetTrip(ctx, id) })
+}
+
+// GetRoute is Queries.GetRoute answered from the Client's route cache when it can.
+func (c *Client) GetRoute(ctx context.Context, id string) (Route, error) {
+	return cached(c.entities.routes, id, func() (Route, error) { return c.Queries.GetRoute(ctx, id) })
+}
+
+// GetStop is Queries.GetStop answered from the Client's stop cache when it can.
+func (c *Client) GetStop(ctx context.Context, id string) (GetStopRow, error) {
+	return cached(c.entities.stops, id, func() (GetStopRow, error) { return c.Queries.GetStop(ctx, id) })
+}
+
+// EntityCacheStats reports the lookups of each entity cache since the Client
+// was opened, keyed by "trip", "route" and "stop".
+func (c *Client) EntityCacheStats() map[string]EntityCacheStats {
+	return map[string]EntityCacheStats{
+		"trip":  c.entities.trips.stats(),
+		"route": c.entities.routes.stats(),
+		"stop":  c.entities.stops.stats(),
+	}
+}
@@ -0,0 +1,74 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newLRUCache[int](2)
+	cache.add("a", 1)
+	cache.add("b", 2)
+
+	_, ok := cache.get("a") // a is now more recent than b
+	require.True(t, ok)
+	cache.add("c", 3)
+
+	_, ok = cache.get("b")
+	assert.False(t, ok, "b was least recently used")
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.len())
+	assert.Equal(t, EntityCacheStats{Hits: 2, Misses: 1}, cache.stats())
+
+	cache.purge()
+	assert.Zero(t, cache.len())
+}
+
+func TestLRUCacheDisabled(t *testing.T) {
+	cache := newLRUCache[int](-1)
+	cache.add("a", 1)
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	assert.Zero(t, cache.len())
+}
+
+func TestClientEntityCache(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, nil))
+	ctx := context.Background()
+
+	trip, err := client.GetTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, "ROUTE1", trip.RouteID)
+	_, err = client.GetTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, EntityCacheStats{Hits: 1, Misses: 1}, client.EntityCacheStats()["trip"])
+
+	route, err := client.GetRoute(ctx, "ROUTE1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", route.ShortName.String)
+	stop, err := client.GetStop(ctx, "STOP2")
+	require.NoError(t, err)
+	assert.Equal(t, "Second Stop", stop.Name.String)
+
+	// Missing rows are not cached, so a later import can add them.
+	_, err = client.GetTrip(ctx, "TRIP9")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, 1, client.entities.trips.len())
+
+	// A re-import drops what was cached from the previous data.
+	require.NoError(t, client.processAndStoreGTFSDataWithSource(createGTFSZip(t, map[string]string{
+		"routes.txt": `route_id,agency_id,route_short_name,route_long_name,route_type
+ROUTE1,TEST_AGENCY,1X,Test Route,3
+`,
+	}), "test-source"))
+	assert.Zero(t, client.entities.routes.len())
+	route, err = client.GetRoute(ctx, "ROUTE1")
+	require.NoError(t, err)
+	assert.Equal(t, "1X", route.ShortName.String)
+}
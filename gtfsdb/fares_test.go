@@ -0,0 +1,67 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFares(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"fare_attributes.txt": `fare_id,price,currency_type,payment_method,transfers,agency_id,transfer_duration
+CHEAP,1.50,USD,0,,TEST_AGENCY,
+ZONED,2.75,USD,1,1,,5400
+CHEAP,9.00,USD,0,,,
+BROKEN,,USD,0,,,
+OTHER,4.00,USD,0,0,,
+`,
+		"fare_rules.txt": `fare_id,route_id,origin_id,destination_id,contains_id
+CHEAP,ROUTE1,,,
+ZONED,,A,B,
+OTHER,ROUTE2,,,
+UNKNOWN,ROUTE1,,,
+BROKEN,ROUTE1,,,
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	fares, err := client.Queries.GetFaresForRoute(ctx, sql.NullString{String: "ROUTE1", Valid: true})
+	require.NoError(t, err)
+	require.Len(t, fares, 2, "OTHER is limited to another route; rules of unread fares are dropped")
+
+	// Cheapest first, and the first CHEAP row wins
+	assert.Equal(t, "CHEAP", fares[0].FareID)
+	assert.Equal(t, 1.50, fares[0].Price)
+	assert.Equal(t, "TEST_AGENCY", fares[0].AgencyID.String)
+	assert.False(t, fares[0].Transfers.Valid, "an empty transfers field means unlimited transfers")
+
+	assert.Equal(t, "ZONED", fares[1].FareID)
+	assert.Equal(t, int64(1), fares[1].PaymentMethod)
+	assert.Equal(t, int64(1), fares[1].Transfers.Int64)
+	assert.Equal(t, int64(5400), fares[1].TransferDuration.Int64)
+	assert.Equal(t, "A", fares[1].OriginID.String)
+	assert.Equal(t, "B", fares[1].DestinationID.String)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	fares, err = client.Queries.GetFaresForRoute(ctx, sql.NullString{String: "ROUTE1", Valid: true})
+	require.NoError(t, err)
+	assert.Empty(t, fares)
+}
+
+func TestImportFaresWithoutRules(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"fare_attributes.txt": `fare_id,price,currency_type,payment_method,transfers
+FLAT,2.00,EUR,1,2
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+
+	fares, err := client.Queries.GetFaresForRoute(context.Background(), sql.NullString{String: "ROUTE1", Valid: true})
+	require.NoError(t, err)
+	require.Len(t, fares, 1, "a fare without rules applies to every route")
+	assert.Equal(t, "EUR", fares[0].CurrencyType)
+}
@@ -0,0 +1,310 @@
+package gtfsdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs/constants"
+	"github.com/OneBusAway/go-gtfs/csv"
+)
+
+// go-gtfs skips some optional GTFS files entirely and parses others lossily (for
+// example it drops same-stop transfers). The readers in this file go straight to
+// the feed archive for those files.
+
+// feedArchive provides access to the raw files of a GTFS zip.
+type feedArchive struct {
+	files map[string]*zip.File
+}
+
+func newFeedArchive(b []byte) (*feedArchive, error) {
+	reader, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, f := range reader.File {
+		files[f.Name] = f
+	}
+	return &feedArchive{files: files}, nil
+}
+
+// open returns a CSV reader for the named file, or nil when the feed does not
+// contain it or it has no rows. The caller must Close a non-nil file.
+func (a *feedArchive) open(name string) (*csv.File, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", name, err)
+	}
+	file, err := csv.New(constants.StaticFile(name), rc)
+	if err != nil {
+		// csv.New closes the reader on error; an empty file is the same as a missing one.
+		return nil, nil
+	}
+	return file, nil
+}
+
+// readTransfers reads every stop-level rule from transfers.txt, including the
+// same-stop transfers that go-gtfs discards.
+func (a *feedArchive) readTransfers() ([]CreateTransferParams, error) {
+	file, err := a.open("transfers.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	fromStopID := file.OptionalColumn("from_stop_id")
+	toStopID := file.OptionalColumn("to_stop_id")
+	fromRouteID := file.OptionalColumn("from_route_id")
+	toRouteID := file.OptionalColumn("to_route_id")
+	fromTripID := file.OptionalColumn("from_trip_id")
+	toTripID := file.OptionalColumn("to_trip_id")
+	transferType := file.OptionalColumn("transfer_type")
+	minTransferTime := file.OptionalColumn("min_transfer_time")
+
+	var transfers []CreateTransferParams
+	for file.NextRow() {
+		// Trip-to-trip (in-seat) transfers have no stops and no stop-level meaning here.
+		if fromStopID.Read() == "" || toStopID.Read() == "" {
+			continue
+		}
+		params := CreateTransferParams{
+			FromStopID:  fromStopID.Read(),
+			ToStopID:    toStopID.Read(),
+			FromRouteID: toNullString(fromRouteID.Read()),
+			ToRouteID:   toNullString(toRouteID.Read()),
+			FromTripID:  toNullString(fromTripID.Read()),
+			ToTripID:    toNullString(toTripID.Read()),
+		}
+		if v, err := strconv.ParseInt(transferType.Read(), 10, 64); err == nil {
+			params.TransferType = v
+		}
+		if v, err := strconv.ParseInt(minTransferTime.Read(), 10, 64); err == nil {
+			params.MinTransferTime = sql.NullInt64{Int64: v, Valid: true}
+		}
+		transfers = append(transfers, params)
+	}
+	return transfers, nil
+}
+
+// readFares reads fare_attributes.txt and fare_rules.txt. Fares without a valid
+// price or currency are skipped, as are repeated fare IDs and rules that refer
+// to a fare that was not read.
+func (a *feedArchive) readFares() ([]CreateFareAttributeParams, []CreateFareRuleParams, error) {
+	attributes, err := a.readFareAttributes()
+	if err != nil || len(attributes) == 0 {
+		return nil, nil, err
+	}
+
+	fareIDs := make(map[string]bool, len(attributes))
+	for _, fare := range attributes {
+		fareIDs[fare.FareID] = true
+	}
+
+	file, err := a.open("fare_rules.txt")
+	if err != nil || file == nil {
+		return attributes, nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	fareID := file.OptionalColumn("fare_id")
+	routeID := file.OptionalColumn("route_id")
+	originID := file.OptionalColumn("origin_id")
+	destinationID := file.OptionalColumn("destination_id")
+	containsID := file.OptionalColumn("contains_id")
+
+	var rules []CreateFareRuleParams
+	for file.NextRow() {
+		if !fareIDs[fareID.Read()] {
+			continue
+		}
+		rules = append(rules, CreateFareRuleParams{
+			FareID:        fareID.Read(),
+			RouteID:       toNullString(routeID.Read()),
+			OriginID:      toNullString(originID.Read()),
+			DestinationID: toNullString(destinationID.Read()),
+			ContainsID:    toNullString(containsID.Read()),
+		})
+	}
+	return attributes, rules, nil
+}
+
+func (a *feedArchive) readFareAttributes() ([]CreateFareAttributeParams, error) {
+	file, err := a.open("fare_attributes.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	fareID := file.OptionalColumn("fare_id")
+	agencyID := file.OptionalColumn("agency_id")
+	price := file.OptionalColumn("price")
+	currencyType := file.OptionalColumn("currency_type")
+	paymentMethod := file.OptionalColumn("payment_method")
+	transfers := file.OptionalColumn("transfers")
+	transferDuration := file.OptionalColumn("transfer_duration")
+
+	seen := make(map[string]bool)
+	var fares []CreateFareAttributeParams
+	for file.NextRow() {
+		id := fareID.Read()
+		amount, err := strconv.ParseFloat(price.Read(), 64)
+		if id == "" || seen[id] || err != nil || currencyType.Read() == "" {
+			continue
+		}
+		seen[id] = true
+
+		params := CreateFareAttributeParams{
+			FareID:       id,
+			AgencyID:     toNullString(agencyID.Read()),
+			Price:        amount,
+			CurrencyType: currencyType.Read(),
+		}
+		if v, err := strconv.ParseInt(paymentMethod.Read(), 10, 64); err == nil {
+			params.PaymentMethod = v
+		}
+		// An empty transfers field means unlimited transfers.
+		if v, err := strconv.ParseInt(transfers.Read(), 10, 64); err == nil {
+			params.Transfers = sql.NullInt64{Int64: v, Valid: true}
+		}
+		if v, err := strconv.ParseInt(transferDuration.Read(), 10, 64); err == nil {
+			params.TransferDuration = sql.NullInt64{Int64: v, Valid: true}
+		}
+		fares = append(fares, params)
+	}
+	return fares, nil
+}
+
+// readFeedInfo reads the single record of feed_info.txt. It returns nil when the
+// feed has no feed_info.txt. Start and end dates that are not valid YYYYMMDD
+// dates are dropped rather than failing the import.
+func (a *feedArchive) readFeedInfo() (*UpsertFeedInfoParams, error) {
+	file, err := a.open("feed_info.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	publisherName := file.OptionalColumn("feed_publisher_name")
+	publisherURL := file.OptionalColumn("feed_publisher_url")
+	lang := file.OptionalColumn("feed_lang")
+	defaultLang := file.OptionalColumn("default_lang")
+	startDate := file.OptionalColumn("feed_start_date")
+	endDate := file.OptionalColumn("feed_end_date")
+	version := file.OptionalColumn("feed_version")
+	contactEmail := file.OptionalColumn("feed_contact_email")
+	contactURL := file.OptionalColumn("feed_contact_url")
+
+	if !file.NextRow() {
+		return nil, nil
+	}
+	return &UpsertFeedInfoParams{
+		FeedPublisherName: publisherName.Read(),
+		FeedPublisherUrl:  publisherURL.Read(),
+		FeedLang:          lang.Read(),
+		DefaultLang:       toNullString(defaultLang.Read()),
+		FeedStartDate:     toNullString(feedDate(startDate.Read())),
+		FeedEndDate:       toNullString(feedDate(endDate.Read())),
+		FeedVersion:       toNullString(version.Read()),
+		FeedContactEmail:  toNullString(contactEmail.Read()),
+		FeedContactUrl:    toNullString(contactURL.Read()),
+	}, nil
+}
+
+// readNetworks reads networks.txt and the network of each route, which feeds
+// give either in the network_id column of routes.txt or in route_networks.txt.
+// When both are present, route_networks.txt wins. go-gtfs reads neither.
+func (a *feedArchive) readNetworks() ([]CreateNetworkParams, map[string]string, error) {
+	var networks []CreateNetworkParams
+	file, err := a.open("networks.txt")
+	if err != nil {
+		return nil, nil, err
+	}
+	if file != nil {
+		defer file.Close() //nolint:errcheck
+
+		networkID := file.OptionalColumn("network_id")
+		networkName := file.OptionalColumn("network_name")
+		for file.NextRow() {
+			if networkID.Read() == "" {
+				continue
+			}
+			networks = append(networks, CreateNetworkParams{
+				ID:   networkID.Read(),
+				Name: toNullString(networkName.Read()),
+			})
+		}
+	}
+
+	routeNetworks := make(map[string]string)
+	for _, name := range []string{"routes.txt", "route_networks.txt"} {
+		file, err := a.open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if file == nil {
+			continue
+		}
+		routeID := file.OptionalColumn("route_id")
+		networkID := file.OptionalColumn("network_id")
+		for file.NextRow() {
+			if routeID.Read() != "" && networkID.Read() != "" {
+				routeNetworks[routeID.Read()] = networkID.Read()
+			}
+		}
+		_ = file.Close()
+	}
+	return networks, routeNetworks, nil
+}
+
+// readTranslations reads translations.txt, which go-gtfs does not parse. Rows
+// missing the table, field, language or translation are skipped.
+func (a *feedArchive) readTranslations() ([]CreateTranslationParams, error) {
+	file, err := a.open("translations.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	tableName := file.OptionalColumn("table_name")
+	fieldName := file.OptionalColumn("field_name")
+	language := file.OptionalColumn("language")
+	translation := file.OptionalColumn("translation")
+	recordID := file.OptionalColumn("record_id")
+	recordSubID := file.OptionalColumn("record_sub_id")
+	fieldValue := file.OptionalColumn("field_value")
+
+	var translations []CreateTranslationParams
+	for file.NextRow() {
+		params := CreateTranslationParams{
+			TableName:   tableName.Read(),
+			FieldName:   fieldName.Read(),
+			Language:    language.Read(),
+			Translation: translation.Read(),
+			RecordID:    toNullString(recordID.Read()),
+			RecordSubID: toNullString(recordSubID.Read()),
+			FieldValue:  toNullString(fieldValue.Read()),
+		}
+		if params.TableName == "" || params.FieldName == "" || params.Language == "" || params.Translation == "" {
+			continue
+		}
+		translations = append(translations, params)
+	}
+	return translations, nil
+}
+
+// feedDate returns value if it is a valid GTFS date (YYYYMMDD), or "" otherwise.
+func feedDate(value string) string {
+	if _, err := time.Parse("20060102", value); err != nil {
+		return ""
+	}
+	return value
+}
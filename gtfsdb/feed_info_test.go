@@ -0,0 +1,57 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFeedInfo(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"feed_info.txt": `feed_publisher_name,feed_publisher_url,feed_lang,feed_start_date,feed_end_date,feed_version,feed_contact_email
+Test Transit,https://example.com,en,20250101,20251231,v42,ops@example.com
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	info, err := client.Queries.GetFeedInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Transit", info.FeedPublisherName)
+	assert.Equal(t, "https://example.com", info.FeedPublisherUrl)
+	assert.Equal(t, "en", info.FeedLang)
+	assert.False(t, info.DefaultLang.Valid)
+	assert.Equal(t, "20250101", info.FeedStartDate.String)
+	assert.Equal(t, "20251231", info.FeedEndDate.String)
+	assert.Equal(t, "v42", info.FeedVersion.String)
+	assert.Equal(t, "ops@example.com", info.FeedContactEmail.String)
+	assert.False(t, info.FeedContactUrl.Valid)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	_, err = client.Queries.GetFeedInfo(ctx)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestImportFeedInfoDropsInvalidDates(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"feed_info.txt": `feed_publisher_name,feed_publisher_url,feed_lang,feed_start_date,feed_end_date
+Test Transit,https://example.com,en,2025-01-01,20251231
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+
+	info, err := client.Queries.GetFeedInfo(context.Background())
+	require.NoError(t, err)
+	assert.False(t, info.FeedStartDate.Valid)
+	assert.Equal(t, "20251231", info.FeedEndDate.String)
+}
+
+func TestImportWithoutFeedInfo(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, nil))
+
+	_, err := client.Queries.GetFeedInfo(context.Background())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,390 @@
+package gtfsdb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// GTFS-Flex files. go-gtfs ignores all of them, and it drops stop_times rows
+// that serve a zone or a location group because they have no stop_id, so the
+// readers below parse the feed archive directly.
+
+// flexFeed holds the GTFS-Flex records of a feed, ready to insert.
+type flexFeed struct {
+	locations          []CreateLocationParams
+	locationGroups     []CreateLocationGroupParams
+	locationGroupStops []CreateLocationGroupStopParams
+	bookingRules       []CreateBookingRuleParams
+	stopTimes          []CreateFlexStopTimeParams
+}
+
+func (f *flexFeed) empty() bool {
+	return len(f.locations) == 0 && len(f.locationGroups) == 0 && len(f.locationGroupStops) == 0 &&
+		len(f.bookingRules) == 0 && len(f.stopTimes) == 0
+}
+
+// readFlex reads locations.geojson, location_groups.txt,
+// location_group_stops.txt, booking_rules.txt and the GTFS-Flex columns of
+// stop_times.txt.
+func (a *feedArchive) readFlex() (*flexFeed, error) {
+	var feed flexFeed
+	var err error
+	if feed.locations, err = a.readLocations(); err != nil {
+		return nil, err
+	}
+	if feed.locationGroups, err = a.readLocationGroups(); err != nil {
+		return nil, err
+	}
+	if feed.locationGroupStops, err = a.readLocationGroupStops(); err != nil {
+		return nil, err
+	}
+	if feed.bookingRules, err = a.readBookingRules(); err != nil {
+		return nil, err
+	}
+	if feed.stopTimes, err = a.readFlexStopTimes(); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// geoJSONFeature is the subset of a locations.geojson feature that is stored.
+type geoJSONFeature struct {
+	ID         json.RawMessage `json:"id"`
+	Properties struct {
+		StopName string `json:"stop_name"`
+		StopDesc string `json:"stop_desc"`
+	} `json:"properties"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// readLocations reads the zones of locations.geojson. GTFS-Flex only allows
+// Polygon and MultiPolygon geometries; features with any other geometry, or
+// without an id, are skipped.
+func (a *feedArchive) readLocations() ([]CreateLocationParams, error) {
+	f, ok := a.files["locations.geojson"]
+	if !ok {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening locations.geojson: %w", err)
+	}
+	defer rc.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("error reading locations.geojson: %w", err)
+	}
+	var collection struct {
+		Features []geoJSONFeature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("error parsing locations.geojson: %w", err)
+	}
+
+	locations := make([]CreateLocationParams, 0, len(collection.Features))
+	for _, feature := range collection.Features {
+		id := geoJSONFeatureID(feature.ID)
+		if id == "" {
+			continue
+		}
+		bounds, ok := geoJSONBounds(feature.Geometry)
+		if !ok {
+			continue
+		}
+		var geometry bytes.Buffer
+		if err := json.Compact(&geometry, feature.Geometry); err != nil {
+			continue
+		}
+		locations = append(locations, CreateLocationParams{
+			ID:       id,
+			StopName: toNullString(feature.Properties.StopName),
+			StopDesc: toNullString(feature.Properties.StopDesc),
+			Geometry: geometry.String(),
+			MinLat:   bounds[0],
+			MinLon:   bounds[1],
+			MaxLat:   bounds[2],
+			MaxLon:   bounds[3],
+		})
+	}
+	return locations, nil
+}
+
+// geoJSONFeatureID returns a feature id, which GeoJSON allows to be a string
+// or a number.
+func geoJSONFeatureID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number.String()
+	}
+	return ""
+}
+
+// geoJSONBounds returns the bounding box (min lat, min lon, max lat, max lon)
+// of a Polygon or MultiPolygon geometry. ok is false for other geometries and
+// for polygons without coordinates.
+func geoJSONBounds(raw json.RawMessage) (bounds [4]float64, ok bool) {
+	var geometry geoJSONGeometry
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return bounds, false
+	}
+	var polygons [][][][2]float64
+	switch geometry.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return bounds, false
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+			return bounds, false
+		}
+	default:
+		return bounds, false
+	}
+
+	bounds = [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, polygon := range polygons {
+		for _, ring := range polygon {
+			for _, position := range ring {
+				// GeoJSON positions are [longitude, latitude].
+				lon, lat := position[0], position[1]
+				bounds[0] = min(bounds[0], lat)
+				bounds[1] = min(bounds[1], lon)
+				bounds[2] = max(bounds[2], lat)
+				bounds[3] = max(bounds[3], lon)
+				ok = true
+			}
+		}
+	}
+	return bounds, ok
+}
+
+func (a *feedArchive) readLocationGroups() ([]CreateLocationGroupParams, error) {
+	file, err := a.open("location_groups.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	id := file.OptionalColumn("location_group_id")
+	name := file.OptionalColumn("location_group_name")
+
+	var groups []CreateLocationGroupParams
+	for file.NextRow() {
+		if id.Read() == "" {
+			continue
+		}
+		groups = append(groups, CreateLocationGroupParams{
+			ID:                id.Read(),
+			LocationGroupName: toNullString(name.Read()),
+		})
+	}
+	return groups, nil
+}
+
+func (a *feedArchive) readLocationGroupStops() ([]CreateLocationGroupStopParams, error) {
+	file, err := a.open("location_group_stops.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	groupID := file.OptionalColumn("location_group_id")
+	stopID := file.OptionalColumn("stop_id")
+
+	var members []CreateLocationGroupStopParams
+	for file.NextRow() {
+		if groupID.Read() == "" || stopID.Read() == "" {
+			continue
+		}
+		members = append(members, CreateLocationGroupStopParams{
+			LocationGroupID: groupID.Read(),
+			StopID:          stopID.Read(),
+		})
+	}
+	return members, nil
+}
+
+func (a *feedArchive) readBookingRules() ([]CreateBookingRuleParams, error) {
+	file, err := a.open("booking_rules.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	id := file.OptionalColumn("booking_rule_id")
+	bookingType := file.OptionalColumn("booking_type")
+	durationMin := file.OptionalColumn("prior_notice_duration_min")
+	durationMax := file.OptionalColumn("prior_notice_duration_max")
+	lastDay := file.OptionalColumn("prior_notice_last_day")
+	lastTime := file.OptionalColumn("prior_notice_last_time")
+	startDay := file.OptionalColumn("prior_notice_start_day")
+	startTime := file.OptionalColumn("prior_notice_start_time")
+	serviceID := file.OptionalColumn("prior_notice_service_id")
+	message := file.OptionalColumn("message")
+	pickupMessage := file.OptionalColumn("pickup_message")
+	dropOffMessage := file.OptionalColumn("drop_off_message")
+	phoneNumber := file.OptionalColumn("phone_number")
+	infoURL := file.OptionalColumn("info_url")
+	bookingURL := file.OptionalColumn("booking_url")
+
+	var rules []CreateBookingRuleParams
+	for file.NextRow() {
+		if id.Read() == "" {
+			continue
+		}
+		params := CreateBookingRuleParams{
+			ID:                     id.Read(),
+			PriorNoticeDurationMin: parseNullInt64(durationMin.Read()),
+			PriorNoticeDurationMax: parseNullInt64(durationMax.Read()),
+			PriorNoticeLastDay:     parseNullInt64(lastDay.Read()),
+			PriorNoticeStartDay:    parseNullInt64(startDay.Read()),
+			PriorNoticeServiceID:   toNullString(serviceID.Read()),
+			Message:                toNullString(message.Read()),
+			PickupMessage:          toNullString(pickupMessage.Read()),
+			DropOffMessage:         toNullString(dropOffMessage.Read()),
+			PhoneNumber:            toNullString(phoneNumber.Read()),
+			InfoUrl:                toNullString(infoURL.Read()),
+			BookingUrl:             toNullString(bookingURL.Read()),
+		}
+		if v, err := strconv.ParseInt(bookingType.Read(), 10, 64); err == nil {
+			params.BookingType = v
+		}
+		if d, ok := parseGTFSTime(lastTime.Read()); ok {
+			params.PriorNoticeLastTime = sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+		}
+		if d, ok := parseGTFSTime(startTime.Read()); ok {
+			params.PriorNoticeStartTime = sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+		}
+		rules = append(rules, params)
+	}
+	return rules, nil
+}
+
+// flexStopTimeColumns are the stop_times.txt columns added by GTFS-Flex. Feeds
+// without any of them have no flexible service, and stop_times.txt, usually
+// the largest file, is not read a second time.
+var flexStopTimeColumns = []string{
+	"location_id",
+	"location_group_id",
+	"start_pickup_drop_off_window",
+	"end_pickup_drop_off_window",
+	"pickup_booking_rule_id",
+	"drop_off_booking_rule_id",
+	"continuous_pickup",
+	"continuous_drop_off",
+}
+
+// readFlexStopTimes returns the stop_times.txt rows that serve a zone or a
+// location group, plus rows at regular stops that carry a pickup/drop-off
+// window, a booking rule or continuous stopping behaviour.
+func (a *feedArchive) readFlexStopTimes() ([]CreateFlexStopTimeParams, error) {
+	file, err := a.open("stop_times.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	if !slices.ContainsFunc(file.HeaderContent(), func(column string) bool {
+		return slices.Contains(flexStopTimeColumns, column)
+	}) {
+		return nil, nil
+	}
+
+	tripID := file.OptionalColumn("trip_id")
+	stopSequence := file.OptionalColumn("stop_sequence")
+	stopID := file.OptionalColumn("stop_id")
+	locationID := file.OptionalColumn("location_id")
+	locationGroupID := file.OptionalColumn("location_group_id")
+	windowStart := file.OptionalColumn("start_pickup_drop_off_window")
+	windowEnd := file.OptionalColumn("end_pickup_drop_off_window")
+	pickupType := file.OptionalColumn("pickup_type")
+	dropOffType := file.OptionalColumn("drop_off_type")
+	continuousPickup := file.OptionalColumn("continuous_pickup")
+	continuousDropOff := file.OptionalColumn("continuous_drop_off")
+	pickupRule := file.OptionalColumn("pickup_booking_rule_id")
+	dropOffRule := file.OptionalColumn("drop_off_booking_rule_id")
+
+	var stopTimes []CreateFlexStopTimeParams
+	for file.NextRow() {
+		sequence, err := strconv.ParseInt(stopSequence.Read(), 10, 64)
+		if tripID.Read() == "" || err != nil {
+			continue
+		}
+		params := CreateFlexStopTimeParams{
+			TripID:               tripID.Read(),
+			StopSequence:         sequence,
+			StopID:               toNullString(stopID.Read()),
+			LocationID:           toNullString(locationID.Read()),
+			LocationGroupID:      toNullString(locationGroupID.Read()),
+			PickupType:           parseInt64Or(pickupType.Read(), 0),
+			DropOffType:          parseInt64Or(dropOffType.Read(), 0),
+			ContinuousPickup:     parseInt64Or(continuousPickup.Read(), 1),
+			ContinuousDropOff:    parseInt64Or(continuousDropOff.Read(), 1),
+			PickupBookingRuleID:  toNullString(pickupRule.Read()),
+			DropOffBookingRuleID: toNullString(dropOffRule.Read()),
+		}
+		if d, ok := parseGTFSTime(windowStart.Read()); ok {
+			params.StartPickupDropOffWindow = sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+		}
+		if d, ok := parseGTFSTime(windowEnd.Read()); ok {
+			params.EndPickupDropOffWindow = sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+		}
+
+		isFlexible := params.LocationID.Valid || params.LocationGroupID.Valid ||
+			params.StartPickupDropOffWindow.Valid || params.EndPickupDropOffWindow.Valid ||
+			params.PickupBookingRuleID.Valid || params.DropOffBookingRuleID.Valid ||
+			params.ContinuousPickup != 1 || params.ContinuousDropOff != 1
+		if isFlexible {
+			stopTimes = append(stopTimes, params)
+		}
+	}
+	return stopTimes, nil
+}
+
+// parseGTFSTime parses an HH:MM:SS time, which may exceed 24:00:00, into the
+// duration since service-day midnight.
+func parseGTFSTime(value string) (time.Duration, bool) {
+	var h, m, s int
+	if n, err := fmt.Sscanf(value, "%d:%d:%d", &h, &m, &s); err != nil || n != 3 {
+		return 0, false
+	}
+	if h < 0 || m < 0 || m > 59 || s < 0 || s > 59 {
+		return 0, false
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second, true
+}
+
+func parseNullInt64(value string) sql.NullInt64 {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: v, Valid: true}
+}
+
+func parseInt64Or(value string, fallback int64) int64 {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
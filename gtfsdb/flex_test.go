@@ -0,0 +1,123 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flexGTFSFiles() map[string]string {
+	return map[string]string{
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign
+ROUTE1,WEEKDAY,TRIP1,Downtown
+ROUTE1,WEEKDAY,TRIP2,Uptown
+ROUTE1,WEEKDAY,FLEX1,Dial-a-Ride
+`,
+		"stop_times.txt": `trip_id,arrival_time,departure_time,stop_id,location_group_id,location_id,stop_sequence,start_pickup_drop_off_window,end_pickup_drop_off_window,pickup_type,drop_off_type,continuous_pickup,pickup_booking_rule_id,drop_off_booking_rule_id
+TRIP1,08:00:00,08:00:00,STOP1,,,1,,,,,,,
+TRIP1,08:15:00,08:15:00,STOP2,,,2,,,,,0,,
+TRIP2,09:00:00,09:00:00,STOP2,,,1,,,,,,,
+TRIP2,09:15:00,09:15:00,STOP1,,,2,,,,,,,
+FLEX1,,,,GROUP1,,1,07:00:00,19:00:00,2,1,,RULE1,
+FLEX1,,,,,ZONE1,2,07:00:00,25:30:00,1,2,,,RULE1
+`,
+		"locations.geojson": `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "id": "ZONE1",
+      "type": "Feature",
+      "properties": {"stop_name": "Midtown", "stop_desc": "Flex zone"},
+      "geometry": {"type": "Polygon", "coordinates": [[[-74.01, 40.70], [-73.98, 40.70], [-73.98, 40.76], [-74.01, 40.76], [-74.01, 40.70]]]}
+    },
+    {
+      "id": "POINT",
+      "type": "Feature",
+      "properties": {},
+      "geometry": {"type": "Point", "coordinates": [-74.0, 40.7]}
+    }
+  ]
+}`,
+		"location_groups.txt": `location_group_id,location_group_name
+GROUP1,Hospitals
+`,
+		"location_group_stops.txt": `location_group_id,stop_id
+GROUP1,STOP1
+GROUP1,STOP2
+`,
+		"booking_rules.txt": `booking_rule_id,booking_type,prior_notice_duration_min,prior_notice_last_day,prior_notice_last_time,message,phone_number,booking_url
+RULE1,1,60,,,Call ahead,555-0100,https://book.example.com
+`,
+	}
+}
+
+func TestImportFlex(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, flexGTFSFiles()))
+	ctx := context.Background()
+
+	zone, err := client.Queries.GetLocation(ctx, "ZONE1")
+	require.NoError(t, err)
+	assert.Equal(t, "Midtown", zone.StopName.String)
+	assert.InDelta(t, 40.70, zone.MinLat, 1e-9)
+	assert.InDelta(t, -74.01, zone.MinLon, 1e-9)
+	assert.InDelta(t, 40.76, zone.MaxLat, 1e-9)
+	assert.InDelta(t, -73.98, zone.MaxLon, 1e-9)
+	assert.JSONEq(t, `{"type":"Polygon","coordinates":[[[-74.01,40.70],[-73.98,40.70],[-73.98,40.76],[-74.01,40.76],[-74.01,40.70]]]}`, zone.Geometry)
+
+	_, err = client.Queries.GetLocation(ctx, "POINT")
+	assert.Error(t, err, "GTFS-Flex zones must be polygons")
+
+	groups, err := client.Queries.GetLocationGroupsForStop(ctx, "STOP2")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Hospitals", groups[0].LocationGroupName.String)
+	stopIDs, err := client.Queries.GetLocationGroupStopIDs(ctx, "GROUP1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"STOP1", "STOP2"}, stopIDs)
+
+	rule, err := client.Queries.GetBookingRule(ctx, "RULE1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rule.BookingType)
+	assert.Equal(t, int64(60), rule.PriorNoticeDurationMin.Int64)
+	assert.False(t, rule.PriorNoticeLastTime.Valid)
+	assert.Equal(t, "https://book.example.com", rule.BookingUrl.String)
+
+	flex, err := client.Queries.GetFlexStopTimesForTrip(ctx, "FLEX1")
+	require.NoError(t, err)
+	require.Len(t, flex, 2)
+	assert.Equal(t, "GROUP1", flex[0].LocationGroupID.String)
+	assert.Equal(t, int64(7*time.Hour/time.Second), flex[0].StartPickupDropOffWindow.Int64)
+	assert.Equal(t, int64(2), flex[0].PickupType)
+	assert.Equal(t, int64(1), flex[0].ContinuousPickup, "continuous stopping defaults to none")
+	assert.Equal(t, "RULE1", flex[0].PickupBookingRuleID.String)
+	assert.Equal(t, "ZONE1", flex[1].LocationID.String)
+	assert.Equal(t, int64((25*time.Hour+30*time.Minute)/time.Second), flex[1].EndPickupDropOffWindow.Int64)
+	assert.Equal(t, "RULE1", flex[1].DropOffBookingRuleID.String)
+
+	// Regular stop times are only kept when they carry GTFS-Flex attributes.
+	flex, err = client.Queries.GetFlexStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	require.Len(t, flex, 1)
+	assert.Equal(t, "STOP2", flex[0].StopID.String)
+	assert.Equal(t, int64(0), flex[0].ContinuousPickup)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	flex, err = client.Queries.GetFlexStopTimesForTrip(ctx, "FLEX1")
+	require.NoError(t, err)
+	assert.Empty(t, flex)
+	groups, err = client.Queries.GetLocationGroupsForStop(ctx, "STOP2")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestImportWithoutFlexColumnsStoresNoFlexStopTimes(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, nil))
+
+	counts, err := client.TableCounts()
+	require.NoError(t, err)
+	assert.Zero(t, counts["flex_stop_times"])
+	assert.Zero(t, counts["locations"])
+}
@@ -0,0 +1,58 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFrequencies(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"frequencies.txt": `trip_id,start_time,end_time,headway_secs,exact_times
+TRIP1,06:00:00,09:00:00,600,0
+TRIP1,09:00:00,12:00:00,900,0
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	frequencies, err := client.Queries.GetFrequenciesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	require.Len(t, frequencies, 2)
+
+	assert.Equal(t, int64(6*time.Hour/time.Second), frequencies[0].StartTime)
+	assert.Equal(t, int64(9*time.Hour/time.Second), frequencies[0].EndTime)
+	assert.Equal(t, int64(600), frequencies[0].HeadwaySecs)
+	assert.Equal(t, int64(0), frequencies[0].ExactTimes)
+	assert.Equal(t, int64(900), frequencies[1].HeadwaySecs)
+
+	none, err := client.Queries.GetFrequenciesForTrip(ctx, "TRIP2")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	rows, err := client.Queries.GetFrequencyStopTimesForStop(ctx, "STOP2")
+	require.NoError(t, err)
+	require.Len(t, rows, 2, "one row per frequency window of TRIP1")
+	assert.Equal(t, "TRIP1", rows[0].TripID)
+	assert.Equal(t, "ROUTE1", rows[0].RouteID)
+	assert.Equal(t, "WEEKDAY", rows[0].ServiceID)
+}
+
+func TestClearAllGTFSDataRemovesFrequencies(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"frequencies.txt": `trip_id,start_time,end_time,headway_secs
+TRIP1,06:00:00,09:00:00,600
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+
+	frequencies, err := client.Queries.GetFrequenciesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Empty(t, frequencies)
+}
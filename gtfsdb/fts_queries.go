@@ -25,7 +25,9 @@ SELECT
     r.color,
     r.text_color,
     r.continuous_pickup,
-    r.continuous_drop_off
+    r.continuous_drop_off,
+    r.sort_order,
+    r.network_id
 FROM
     routes_fts
     JOIN routes r ON r.rowid = routes_fts.rowid
@@ -66,6 +68,8 @@ func (q *Queries) SearchRoutesByFullText(ctx context.Context, arg SearchRoutesBy
 			&i.TextColor,
 			&i.ContinuousPickup,
 			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
 		); err != nil {
 			return nil, err
 		}
@@ -80,6 +84,9 @@ func (q *Queries) SearchRoutesByFullText(ctx context.Context, arg SearchRoutesBy
 	return items, nil
 }
 
+// searchStopsByName matches stop names and codes. Results are ordered by bm25
+// relevance, weighting code matches above name matches so that typing a stop's
+// code finds that stop first, and then by name for stable ordering of ties.
 const searchStopsByName = `
 SELECT
     s.id,
@@ -90,12 +97,13 @@ SELECT
     s.location_type,
     s.wheelchair_boarding,
     s.direction,
-    s.parent_station
-FROM stops s
-JOIN stops_fts fts
-  ON s.rowid = fts.rowid
-WHERE fts.stop_name MATCH ?
-ORDER BY s.name
+    s.parent_station,
+    bm25(stops_fts, 0.0, 1.0, 5.0) AS rank
+FROM stops_fts
+JOIN stops s
+  ON s.rowid = stops_fts.rowid
+WHERE stops_fts MATCH ?
+ORDER BY rank, s.name
 LIMIT ?
 `
 
@@ -114,6 +122,8 @@ type SearchStopsByNameRow struct {
 	WheelchairBoarding sql.NullInt64
 	Direction          sql.NullString
 	ParentStation      sql.NullString
+	// Rank is the bm25 relevance of the match; lower values are better.
+	Rank float64
 }
 
 func (q *Queries) SearchStopsByName(ctx context.Context, arg SearchStopsByNameParams) ([]SearchStopsByNameRow, error) {
@@ -136,6 +146,7 @@ func (q *Queries) SearchStopsByName(ctx context.Context, arg SearchStopsByNamePa
 			&i.WheelchairBoarding,
 			&i.Direction,
 			&i.ParentStation,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -333,3 +333,44 @@ func TestSearchStopsByNameEmptyDB(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, results)
 }
+
+func TestSearchStopsByNameMatchesCodeAndSurvivesMigration(t *testing.T) {
+	client := createFTSTestClient(t)
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+
+	for _, s := range []CreateStopParams{
+		{ID: "s1", Name: toNullString("Transit Center Bay 4"), Code: toNullString("4410"), Lat: 40.0, Lon: -74.0},
+		{ID: "s2", Name: toNullString("4410 Elm Street"), Code: toNullString("7001"), Lat: 40.1, Lon: -74.1},
+	} {
+		_, err := client.Queries.CreateStop(ctx, s)
+		require.NoError(t, err)
+	}
+
+	search := func() []SearchStopsByNameRow {
+		results, err := client.Queries.SearchStopsByName(ctx, SearchStopsByNameParams{SearchQuery: "4410", Limit: 10})
+		require.NoError(t, err)
+		return results
+	}
+
+	results := search()
+	require.Len(t, results, 2)
+	assert.Equal(t, "s1", results[0].ID, "code matches outrank name matches")
+	assert.Less(t, results[0].Rank, results[1].Rank)
+
+	// Starting up again keeps the index.
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	require.Len(t, search(), 2)
+
+	// Upgrading from the names-only index rebuilds it from the stops table.
+	require.NoError(t, client.MigrateDown(ctx, 6))
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	require.Len(t, search(), 2)
+
+	_, err := client.DB.ExecContext(ctx, "UPDATE stops SET code = '9999' WHERE id = 's1'")
+	require.NoError(t, err)
+	results = search()
+	require.Len(t, results, 1)
+	assert.Equal(t, "s2", results[0].ID)
+}
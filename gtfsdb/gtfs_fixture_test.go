@@ -0,0 +1,80 @@
+package gtfsdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"maps"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// minimalGTFSFiles returns the contents of a small but complete GTFS feed, keyed
+// by file name. Tests add or override entries to exercise optional files.
+func minimalGTFSFiles() map[string]string {
+	return map[string]string{
+		"agency.txt": `agency_id,agency_name,agency_url,agency_timezone
+TEST_AGENCY,Test Transit,https://test.com,America/Los_Angeles
+`,
+		"routes.txt": `route_id,agency_id,route_short_name,route_long_name,route_type
+ROUTE1,TEST_AGENCY,1,Test Route,3
+`,
+		"stops.txt": `stop_id,stop_name,stop_lat,stop_lon
+STOP1,First Stop,40.7128,-74.0060
+STOP2,Second Stop,40.7580,-73.9855
+`,
+		"calendar.txt": `service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date
+WEEKDAY,1,1,1,1,1,0,0,20250101,20251231
+`,
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign
+ROUTE1,WEEKDAY,TRIP1,Downtown
+ROUTE1,WEEKDAY,TRIP2,Uptown
+`,
+		"stop_times.txt": `trip_id,arrival_time,departure_time,stop_id,stop_sequence
+TRIP1,08:00:00,08:00:00,STOP1,1
+TRIP1,08:15:00,08:15:00,STOP2,2
+TRIP2,09:00:00,09:00:00,STOP2,1
+TRIP2,09:15:00,09:15:00,STOP1,2
+`,
+	}
+}
+
+// createGTFSZip zips the minimal feed with the given files added or replaced.
+func createGTFSZip(t *testing.T, overrides map[string]string) []byte {
+	t.Helper()
+
+	files := minimalGTFSFiles()
+	maps.Copy(files, overrides)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+
+	return buf.Bytes()
+}
+
+// newImportedTestClient creates an in-memory client and imports the given feed.
+func newImportedTestClient(t *testing.T, gtfsData []byte) *Client {
+	t.Helper()
+
+	client, err := NewClient(NewConfig(":memory:", appconf.Test, false))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	require.NoError(t, client.processAndStoreGTFSDataWithSource(gtfsData, "test-source"))
+	return client
+}
@@ -30,18 +30,21 @@ func createDB(config Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("test database must use in-memory storage, got path: %s", config.DBPath)
 	}
 
-	db, err := sql.Open("sqlite3", config.DBPath)
-	if err != nil {
-		return nil, err
-	}
+	db := openTimedDB(config.DBPath)
 
 	// Configure SQLite performance settings immediately after opening
 	ctx := context.Background()
-	err = configureSQLitePerformance(ctx, db)
+	err := configureSQLitePerformance(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("error configuring SQLite performance: %w", err)
 	}
 
+	if config.splitReads() {
+		if _, err := db.ExecContext(ctx, "PRAGMA journal_mode=WAL"); err != nil {
+			return nil, fmt.Errorf("error enabling WAL mode: %w", err)
+		}
+	}
+
 	err = performDatabaseMigration(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("error performing database migration: %w", err)
@@ -53,7 +56,14 @@ func createDB(config Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// performDatabaseMigration creates any missing tables from schema.sql, then
+// applies the versioned migrations that bring an existing database up to it.
 func performDatabaseMigration(ctx context.Context, db *sql.DB) error {
+	newDatabase, err := isNewDatabase(ctx, db)
+	if err != nil {
+		return fmt.Errorf("error inspecting database: %w", err)
+	}
+
 	statements := strings.Split(ddl, "-- migrate") // Split DDL into individual statements
 	for _, stmt := range statements {
 		trimmedStmt := strings.TrimSpace(stmt)
@@ -64,10 +74,16 @@ func performDatabaseMigration(ctx context.Context, db *sql.DB) error {
 			return fmt.Errorf("error executing DDL statement [%s]: %w", trimmedStmt, err)
 		}
 	}
-	return nil
+	return migrateUp(ctx, db, newDatabase)
 }
 
 func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) error {
+	return c.processAndStoreGTFSData(b, source, FeedValidators{})
+}
+
+// processAndStoreGTFSData imports the feed b read from source, recording the
+// HTTP validators it was served with in the import metadata.
+func (c *Client) processAndStoreGTFSData(b []byte, source string, validators FeedValidators) error {
 	logger := slog.Default().With(slog.String("component", "gtfs_importer"))
 
 	startTime := time.Now()
@@ -75,6 +91,8 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 		endTime := time.Now()
 
 		c.importRuntime = endTime.Sub(startTime)
+		// Rows cached before the import may have changed or gone.
+		c.entities.purge()
 
 		logging.LogOperation(logger, "gtfs_data_import_completed",
 			slog.Duration("duration", c.importRuntime),
@@ -94,11 +112,25 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 		if existingMetadata.FileHash == hashStr && existingMetadata.FileSource == source {
 			logging.LogOperation(logger, "gtfs_data_unchanged_skipping_import",
 				slog.String("hash", hashStr[:8]))
+			if validators == importValidators(existingMetadata) {
+				return nil
+			}
+			// The server revalidated the same feed under new validators.
+			_, err = c.Queries.UpsertImportMetadata(ctx, UpsertImportMetadataParams{
+				FileHash:     existingMetadata.FileHash,
+				ImportTime:   existingMetadata.ImportTime,
+				FileSource:   source,
+				Etag:         toNullString(validators.ETag),
+				LastModified: toNullString(validators.LastModified),
+			})
+			if err != nil {
+				return fmt.Errorf("error updating import metadata: %w", err)
+			}
 			return nil
 		}
 		// Hash differs, we need to clear existing data and reimport
 		logging.LogOperation(logger, "gtfs_data_changed_reimporting",
-			slog.String("old_hash", existingMetadata.FileHash[:8]),
+			slog.String("old_hash", shortHash(existingMetadata.FileHash)),
 			slog.String("new_hash", hashStr[:8]))
 		err = c.clearAllGTFSData(ctx)
 		if err != nil {
@@ -112,12 +144,23 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 
 	var staticCounts map[string]int
 
-	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{InheritWheelchairBoarding: true})
 	if err != nil {
 		return err
 	}
 
 	logging.LogOperation(logger, "retrieved_static_data", slog.Int("warnings", len(staticData.Warnings)))
+	if err := c.storeImportWarnings(ctx, logger, staticData.Warnings); err != nil {
+		return fmt.Errorf("unable to store parse warnings: %w", err)
+	}
+
+	archive, err := newFeedArchive(b)
+	if err != nil {
+		return fmt.Errorf("error reading GTFS archive: %w", err)
+	}
+	if _, err := c.checkReferentialIntegrity(ctx, logger, archive, staticData); err != nil {
+		return err
+	}
 
 	staticCounts = c.staticDataCounts(staticData)
 	for k, v := range staticCounts {
@@ -153,6 +196,16 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 		singleAgencyID = staticData.Agencies[0].Id
 	}
 
+	networks, routeNetworks, err := archive.readNetworks()
+	if err != nil {
+		return fmt.Errorf("unable to read networks: %w", err)
+	}
+	for _, n := range networks {
+		if err := c.Queries.CreateNetwork(ctx, n); err != nil {
+			return fmt.Errorf("unable to create network: %w", err)
+		}
+	}
+
 	for _, r := range staticData.Routes {
 		route := CreateRouteParams{
 			ID:                r.Id,
@@ -166,6 +219,8 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			TextColor:         toNullString(r.TextColor),
 			ContinuousPickup:  toNullInt64(int64(r.ContinuousPickup)),
 			ContinuousDropOff: toNullInt64(int64(r.ContinuousDropOff)),
+			SortOrder:         int32PtrToNullInt64(r.SortOrder),
+			NetworkID:         toNullString(routeNetworks[r.Id]),
 		}
 
 		_, err := c.Queries.CreateRoute(ctx, route)
@@ -216,11 +271,10 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 	logging.LogOperation(logger, "agencies_and_routes_inserted",
 		slog.Int("agencies", len(staticData.Agencies)),
 		slog.Int("routes", len(staticData.Routes)))
-	logging.LogOperation(logger, "inserting_calendar",
-		slog.Int("count", len(staticData.Services)))
 
+	calendars := make([]CreateCalendarParams, 0, len(staticData.Services))
 	for _, s := range staticData.Services {
-		params := CreateCalendarParams{
+		calendars = append(calendars, CreateCalendarParams{
 			ID:        s.Id,
 			Monday:    boolToInt(s.Monday),
 			Tuesday:   boolToInt(s.Tuesday),
@@ -231,16 +285,11 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			Sunday:    boolToInt(s.Sunday),
 			StartDate: s.StartDate.Format("20060102"),
 			EndDate:   s.EndDate.Format("20060102"),
-		}
-
-		_, err := c.Queries.CreateCalendar(ctx, params)
-		if err != nil {
-			return fmt.Errorf("unable to create calendar: %w", err)
-		}
+		})
+	}
+	if err := c.bulkInsertCalendars(ctx, calendars); err != nil {
+		return fmt.Errorf("unable to create calendar: %w", err)
 	}
-
-	logging.LogOperation(logger, "calendar_inserted",
-		slog.Int("count", len(staticData.Services)))
 
 	var allTripParams []CreateTripParams
 	for _, t := range staticData.Trips {
@@ -270,24 +319,28 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 	}
 
 	var allStopTimeParams []CreateStopTimeParams
+	geometries := dedupeShapes(staticData.Shapes)
+	placer := newStopDistancePlacer(geometries.geometryOf)
 	for _, t := range staticData.Trips {
-		for _, st := range t.StopTimes {
+		distances := placer.place(&t)
+		for i, st := range t.StopTimes {
 			var shapeDistTraveled float64
 			if st.ShapeDistanceTraveled != nil {
 				shapeDistTraveled = *st.ShapeDistanceTraveled
 			}
 
 			params := CreateStopTimeParams{
-				TripID:            t.ID,
-				ArrivalTime:       int64(st.ArrivalTime),
-				DepartureTime:     int64(st.DepartureTime),
-				StopID:            st.Stop.Id,
-				StopSequence:      int64(st.StopSequence),
-				StopHeadsign:      toNullString(st.Headsign),
-				PickupType:        toNullInt64(int64(st.PickupType)),
-				DropOffType:       toNullInt64(int64(st.DropOffType)),
-				ShapeDistTraveled: toNullFloat64(shapeDistTraveled),
-				Timepoint:         toNullInt64(boolToInt(st.ExactTimes)),
+				TripID:             t.ID,
+				ArrivalTime:        int64(st.ArrivalTime / time.Second),
+				DepartureTime:      int64(st.DepartureTime / time.Second),
+				StopID:             st.Stop.Id,
+				StopSequence:       int64(st.StopSequence),
+				StopHeadsign:       toNullString(st.Headsign),
+				PickupType:         toNullInt64(int64(st.PickupType)),
+				DropOffType:        toNullInt64(int64(st.DropOffType)),
+				ShapeDistTraveled:  toNullFloat64(shapeDistTraveled),
+				Timepoint:          toNullInt64(boolToInt(st.ExactTimes)),
+				DistanceAlongShape: distances[i],
 			}
 
 			allStopTimeParams = append(allStopTimeParams, params)
@@ -298,8 +351,82 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 		return fmt.Errorf("unable to create stop times: %w", err)
 	}
 
+	var allFrequencyParams []CreateFrequencyParams
+	for _, t := range staticData.Trips {
+		for _, f := range t.Frequencies {
+			allFrequencyParams = append(allFrequencyParams, CreateFrequencyParams{
+				TripID:      t.ID,
+				StartTime:   int64(f.StartTime / time.Second),
+				EndTime:     int64(f.EndTime / time.Second),
+				HeadwaySecs: int64(f.Headway / time.Second),
+				ExactTimes:  int64(f.ExactTimes),
+			})
+		}
+	}
+	if len(allFrequencyParams) > 0 {
+		err = c.bulkInsertFrequencies(ctx, allFrequencyParams)
+		if err != nil {
+			return fmt.Errorf("unable to create frequencies: %w", err)
+		}
+	}
+
+	allTransferParams, err := archive.readTransfers()
+	if err != nil {
+		return fmt.Errorf("unable to read transfers: %w", err)
+	}
+	if len(allTransferParams) > 0 {
+		err = c.bulkInsertTransfers(ctx, allTransferParams)
+		if err != nil {
+			return fmt.Errorf("unable to create transfers: %w", err)
+		}
+	}
+
+	fareAttributes, fareRules, err := archive.readFares()
+	if err != nil {
+		return fmt.Errorf("unable to read fares: %w", err)
+	}
+	if len(fareAttributes) > 0 {
+		err = c.bulkInsertFares(ctx, fareAttributes, fareRules)
+		if err != nil {
+			return fmt.Errorf("unable to create fares: %w", err)
+		}
+	}
+
+	flex, err := archive.readFlex()
+	if err != nil {
+		return fmt.Errorf("unable to read GTFS-Flex files: %w", err)
+	}
+	if !flex.empty() {
+		err = c.bulkInsertFlex(ctx, flex)
+		if err != nil {
+			return fmt.Errorf("unable to create GTFS-Flex records: %w", err)
+		}
+	}
+
+	feedInfo, err := archive.readFeedInfo()
+	if err != nil {
+		return fmt.Errorf("unable to read feed info: %w", err)
+	}
+	if feedInfo != nil {
+		if err := c.Queries.UpsertFeedInfo(ctx, *feedInfo); err != nil {
+			return fmt.Errorf("unable to store feed info: %w", err)
+		}
+	}
+
+	translations, err := archive.readTranslations()
+	if err != nil {
+		return fmt.Errorf("unable to read translations: %w", err)
+	}
+	if len(translations) > 0 {
+		err = c.bulkInsertTranslations(ctx, translations)
+		if err != nil {
+			return fmt.Errorf("unable to create translations: %w", err)
+		}
+	}
+
 	var allShapeParams []CreateShapeParams
-	for _, s := range staticData.Shapes {
+	for _, s := range geometries.stored {
+		cumulative := placer.shapeDistances(s)
 		for idx, pt := range s.Points {
 			var distance float64
 			if pt.Distance != nil {
@@ -307,11 +434,12 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 			}
 
 			params := CreateShapeParams{
-				ShapeID:           s.ID,
-				Lat:               pt.Latitude,
-				Lon:               pt.Longitude,
-				ShapePtSequence:   int64(idx),
-				ShapeDistTraveled: toNullFloat64(distance),
+				ShapeID:            s.ID,
+				Lat:                pt.Latitude,
+				Lon:                pt.Longitude,
+				ShapePtSequence:    int64(idx),
+				ShapeDistTraveled:  toNullFloat64(distance),
+				DistanceAlongShape: sql.NullFloat64{Float64: cumulative[idx], Valid: true},
 			}
 			allShapeParams = append(allShapeParams, params)
 		}
@@ -320,6 +448,13 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 	if err != nil {
 		return fmt.Errorf("unable to create shapes: %w", err)
 	}
+	logging.LogOperation(logger, "shapes_deduplicated",
+		slog.Int("shapes", len(staticData.Shapes)),
+		slog.Int("geometries", len(geometries.stored)))
+	err = c.bulkInsertShapeGeometries(ctx, geometries.params())
+	if err != nil {
+		return fmt.Errorf("unable to create shape geometries: %w", err)
+	}
 
 	counts, err := c.TableCounts()
 	if err != nil {
@@ -335,9 +470,11 @@ func (c *Client) processAndStoreGTFSDataWithSource(b []byte, source string) erro
 		slog.String("source", source))
 
 	_, err = c.Queries.UpsertImportMetadata(ctx, UpsertImportMetadataParams{
-		FileHash:   hashStr,
-		ImportTime: time.Now().Unix(),
-		FileSource: source,
+		FileHash:     hashStr,
+		ImportTime:   time.Now().Unix(),
+		FileSource:   source,
+		Etag:         toNullString(validators.ETag),
+		LastModified: toNullString(validators.LastModified),
 	})
 	if err != nil {
 		logging.LogError(logger, "Error updating import metadata", err)
@@ -400,12 +537,54 @@ func (c *Client) clearAllGTFSData(ctx context.Context) error {
 	if err := c.Queries.ClearBlockTripIndices(ctx); err != nil {
 		return fmt.Errorf("error clearing block_trip_index: %w", err)
 	}
+	if err := c.Queries.ClearFrequencies(ctx); err != nil {
+		return fmt.Errorf("error clearing frequencies: %w", err)
+	}
+	if err := c.Queries.ClearTransfers(ctx); err != nil {
+		return fmt.Errorf("error clearing transfers: %w", err)
+	}
+	if err := c.Queries.ClearFareRules(ctx); err != nil {
+		return fmt.Errorf("error clearing fare_rules: %w", err)
+	}
+	if err := c.Queries.ClearFareAttributes(ctx); err != nil {
+		return fmt.Errorf("error clearing fare_attributes: %w", err)
+	}
+	if err := c.Queries.ClearFlexStopTimes(ctx); err != nil {
+		return fmt.Errorf("error clearing flex_stop_times: %w", err)
+	}
+	if err := c.Queries.ClearBookingRules(ctx); err != nil {
+		return fmt.Errorf("error clearing booking_rules: %w", err)
+	}
+	if err := c.Queries.ClearLocationGroupStops(ctx); err != nil {
+		return fmt.Errorf("error clearing location_group_stops: %w", err)
+	}
+	if err := c.Queries.ClearLocationGroups(ctx); err != nil {
+		return fmt.Errorf("error clearing location_groups: %w", err)
+	}
+	if err := c.Queries.ClearLocations(ctx); err != nil {
+		return fmt.Errorf("error clearing locations: %w", err)
+	}
+	if err := c.Queries.ClearImportWarnings(ctx); err != nil {
+		return fmt.Errorf("error clearing import_warnings: %w", err)
+	}
+	if err := c.Queries.ClearQuarantinedRows(ctx); err != nil {
+		return fmt.Errorf("error clearing quarantined_rows: %w", err)
+	}
+	if err := c.Queries.ClearTranslations(ctx); err != nil {
+		return fmt.Errorf("error clearing translations: %w", err)
+	}
+	if err := c.Queries.ClearFeedInfo(ctx); err != nil {
+		return fmt.Errorf("error clearing feed_info: %w", err)
+	}
 	if err := c.Queries.ClearStopTimes(ctx); err != nil {
 		return fmt.Errorf("error clearing stop_times: %w", err)
 	}
 	if err := c.Queries.ClearShapes(ctx); err != nil {
 		return fmt.Errorf("error clearing shapes: %w", err)
 	}
+	if err := c.Queries.ClearShapeGeometries(ctx); err != nil {
+		return fmt.Errorf("error clearing shape_geometries: %w", err)
+	}
 	if err := c.Queries.ClearTrips(ctx); err != nil {
 		return fmt.Errorf("error clearing trips: %w", err)
 	}
@@ -421,6 +600,9 @@ func (c *Client) clearAllGTFSData(ctx context.Context) error {
 	if err := c.Queries.ClearRoutes(ctx); err != nil {
 		return fmt.Errorf("error clearing routes: %w", err)
 	}
+	if err := c.Queries.ClearNetworks(ctx); err != nil {
+		return fmt.Errorf("error clearing networks: %w", err)
+	}
 	if err := c.Queries.ClearAgencies(ctx); err != nil {
 		return fmt.Errorf("error clearing agencies: %w", err)
 	}
@@ -444,6 +626,15 @@ func toNullInt64(i int64) sql.NullInt64 {
 	return sql.NullInt64{}
 }
 
+// int32PtrToNullInt64 converts an optional value, where zero is a valid value
+// rather than a missing one.
+func int32PtrToNullInt64(i *int32) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
+
 func toNullFloat64(f float64) sql.NullFloat64 {
 	if f != 0 {
 		return sql.NullFloat64{
@@ -503,70 +694,6 @@ func pickFirstAvailable(a, b string) string {
 	return b
 }
 
-func (c *Client) bulkInsertStops(ctx context.Context, stops []CreateStopParams) error {
-	db := c.DB
-	queries := c.Queries
-	logger := slog.Default().With(slog.String("component", "bulk_insert"))
-
-	logging.LogOperation(logger, "inserting_stops",
-		slog.Int("count", len(stops)))
-
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_stops")
-
-	qtx := queries.WithTx(tx)
-	for _, params := range stops {
-		_, err := qtx.CreateStop(ctx, params)
-		if err != nil {
-			return err
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-
-	logging.LogOperation(logger, "stops_inserted",
-		slog.Int("count", len(stops)))
-
-	return nil
-}
-
-func (c *Client) bulkInsertTrips(ctx context.Context, trips []CreateTripParams) error {
-	db := c.DB
-	queries := c.Queries
-	logger := slog.Default().With(slog.String("component", "bulk_insert"))
-
-	logging.LogOperation(logger, "inserting_trips",
-		slog.Int("count", len(trips)))
-
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_trips")
-
-	qtx := queries.WithTx(tx)
-	for _, params := range trips {
-		_, err := qtx.CreateTrip(ctx, params)
-		if err != nil {
-			return err
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-
-	logging.LogOperation(logger, "trips_inserted",
-		slog.Int("count", len(trips)))
-
-	return nil
-}
-
 // preparedStopTimeBatch holds a prepared SQL statement with its arguments
 type preparedStopTimeBatch struct {
 	query string
@@ -583,10 +710,12 @@ func (c *Client) bulkInsertStopTimes(ctx context.Context, stopTimes []CreateStop
 		slog.Int("count", len(stopTimes)))
 
 	// ===== PIPELINE: PARALLEL PREPARATION + SEQUENTIAL EXECUTION =====
-	batchSize := c.config.GetBulkInsertBatchSize()
+	const columns = 11
+	batchSize := min(c.config.GetBulkInsertBatchSize(), maxBatchVariables/columns)
 	const baseQuery = `INSERT INTO stop_times (
 		trip_id, arrival_time, departure_time, stop_id, stop_sequence,
-		stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint
+		stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint,
+		distance_along_shape
 	) VALUES `
 
 	// Calculate number of batches
@@ -631,13 +760,13 @@ func (c *Client) bulkInsertStopTimes(ctx context.Context, stopTimes []CreateStop
 				// into the query string to prevent SQL injection attacks.
 				var query strings.Builder
 				query.WriteString(baseQuery)
-				args := make([]interface{}, 0, len(batch)*10)
+				args := make([]interface{}, 0, len(batch)*columns)
 
 				for j, params := range batch {
 					if j > 0 {
 						query.WriteString(", ")
 					}
-					query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+					query.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 
 					args = append(args,
 						params.TripID,
@@ -650,6 +779,7 @@ func (c *Client) bulkInsertStopTimes(ctx context.Context, stopTimes []CreateStop
 						params.DropOffType,
 						params.ShapeDistTraveled,
 						params.Timepoint,
+						params.DistanceAlongShape,
 					)
 				}
 
@@ -755,7 +885,7 @@ func (c *Client) bulkInsertShapes(ctx context.Context, shapes []CreateShapeParam
 	// ===== PHASE 1: PARALLEL STATEMENT PREPARATION =====
 	batchSize := c.config.GetBulkInsertBatchSize()
 	const baseQuery = `INSERT INTO shapes (
-		shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
+		shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape
 	) VALUES `
 
 	// Calculate number of batches
@@ -793,13 +923,13 @@ func (c *Client) bulkInsertShapes(ctx context.Context, shapes []CreateShapeParam
 				// into the query string to prevent SQL injection attacks.
 				var query strings.Builder
 				query.WriteString(baseQuery)
-				args := make([]interface{}, 0, len(batch)*5)
+				args := make([]interface{}, 0, len(batch)*6)
 
 				for j, params := range batch {
 					if j > 0 {
 						query.WriteString(", ")
 					}
-					query.WriteString("(?, ?, ?, ?, ?)")
+					query.WriteString("(?, ?, ?, ?, ?, ?)")
 
 					args = append(args,
 						params.ShapeID,
@@ -807,6 +937,7 @@ func (c *Client) bulkInsertShapes(ctx context.Context, shapes []CreateShapeParam
 						params.Lon,
 						params.ShapePtSequence,
 						params.ShapeDistTraveled,
+						params.DistanceAlongShape,
 					)
 				}
 
@@ -906,21 +1037,140 @@ func (c *Client) bulkInsertShapes(ctx context.Context, shapes []CreateShapeParam
 	return nil
 }
 
-func (c *Client) bulkInsertCalendarDates(ctx context.Context, calendarDates []CreateCalendarDateParams) error {
+func (c *Client) bulkInsertFrequencies(ctx context.Context, frequencies []CreateFrequencyParams) error {
 	db := c.DB
 	queries := c.Queries
 	logger := slog.Default().With(slog.String("component", "bulk_insert"))
 
+	logging.LogOperation(logger, "inserting_frequencies",
+		slog.Int("count", len(frequencies)))
+
 	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_calendar_dates")
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_frequencies")
 
 	qtx := queries.WithTx(tx)
-	for _, params := range calendarDates {
-		_, err := qtx.CreateCalendarDate(ctx, params)
-		if err != nil {
+	for _, params := range frequencies {
+		if err := qtx.CreateFrequency(ctx, params); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *Client) bulkInsertTransfers(ctx context.Context, transfers []CreateTransferParams) error {
+	db := c.DB
+	queries := c.Queries
+	logger := slog.Default().With(slog.String("component", "bulk_insert"))
+
+	logging.LogOperation(logger, "inserting_transfers",
+		slog.Int("count", len(transfers)))
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_transfers")
+
+	qtx := queries.WithTx(tx)
+	for _, params := range transfers {
+		if err := qtx.CreateTransfer(ctx, params); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *Client) bulkInsertTranslations(ctx context.Context, translations []CreateTranslationParams) error {
+	logger := slog.Default().With(slog.String("component", "bulk_insert"))
+
+	logging.LogOperation(logger, "inserting_translations",
+		slog.Int("count", len(translations)))
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_translations")
+
+	qtx := c.Queries.WithTx(tx)
+	for _, params := range translations {
+		if err := qtx.CreateTranslation(ctx, params); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// bulkInsertFares stores the fares of a feed and their rules in one transaction.
+func (c *Client) bulkInsertFares(ctx context.Context, fares []CreateFareAttributeParams, rules []CreateFareRuleParams) error {
+	logger := slog.Default().With(slog.String("component", "bulk_insert"))
+
+	logging.LogOperation(logger, "inserting_fares",
+		slog.Int("fare_attributes", len(fares)),
+		slog.Int("fare_rules", len(rules)))
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_fares")
+
+	qtx := c.Queries.WithTx(tx)
+	for _, params := range fares {
+		if err := qtx.CreateFareAttribute(ctx, params); err != nil {
+			return err
+		}
+	}
+	for _, params := range rules {
+		if err := qtx.CreateFareRule(ctx, params); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// bulkInsertFlex stores all GTFS-Flex records of a feed in one transaction.
+func (c *Client) bulkInsertFlex(ctx context.Context, flex *flexFeed) error {
+	logger := slog.Default().With(slog.String("component", "bulk_insert"))
+
+	logging.LogOperation(logger, "inserting_flex",
+		slog.Int("locations", len(flex.locations)),
+		slog.Int("location_groups", len(flex.locationGroups)),
+		slog.Int("booking_rules", len(flex.bookingRules)),
+		slog.Int("stop_times", len(flex.stopTimes)))
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_flex")
+
+	qtx := c.Queries.WithTx(tx)
+	for _, params := range flex.locations {
+		if err := qtx.CreateLocation(ctx, params); err != nil {
+			return err
+		}
+	}
+	for _, params := range flex.locationGroups {
+		if err := qtx.CreateLocationGroup(ctx, params); err != nil {
+			return err
+		}
+	}
+	for _, params := range flex.locationGroupStops {
+		if err := qtx.CreateLocationGroupStop(ctx, params); err != nil {
+			return err
+		}
+	}
+	for _, params := range flex.bookingRules {
+		if err := qtx.CreateBookingRule(ctx, params); err != nil {
+			return err
+		}
+	}
+	for _, params := range flex.stopTimes {
+		if err := qtx.CreateFlexStopTime(ctx, params); err != nil {
 			return err
 		}
 	}
@@ -968,8 +1218,10 @@ func configureSQLitePerformance(ctx context.Context, db *sql.DB) error {
 //     connection to a :memory: database creates a separate database instance, so we
 //     must limit to 1 connection to maintain data integrity.
 //
-//   - File databases: MaxOpenConns=25 to allow concurrent access. SQLite with WAL mode
-//     supports concurrent readers and a single writer.
+//   - File databases: MaxOpenConns=25 to allow concurrent access. SQLite still
+//     serializes writers, and in its default rollback journal mode a writer also
+//     blocks readers; Config.ReadConnections switches to WAL mode and moves
+//     queries to a read-only pool of their own.
 //
 // For production deployments with high concurrency requirements, consider using a
 // file-based database instead of :memory: to take advantage of concurrent connections.
@@ -1009,9 +1261,11 @@ func (c *Client) buildBlockTripIndex(ctx context.Context, staticData *gtfs.Stati
 		serviceID     string
 		blockID       string
 		layoverStopID string
+		shapeLength   sql.NullFloat64
 	}
 
 	tripMap := make(map[string]*tripInfo)
+	distanceTrips := make([]blockTripDistance, 0, len(staticData.Trips))
 
 	for _, trip := range staticData.Trips {
 		if len(trip.StopTimes) == 0 {
@@ -1021,14 +1275,26 @@ func (c *Client) buildBlockTripIndex(ctx context.Context, staticData *gtfs.Stati
 		// Get the FIRST stop - this is the layover location where the trip starts
 		firstStop := trip.StopTimes[0].Stop.Id
 
-		tripMap[trip.ID] = &tripInfo{
+		info := &tripInfo{
 			tripID:        trip.ID,
 			routeID:       trip.Route.Id,
 			serviceID:     trip.Service.Id,
 			blockID:       trip.BlockID,
 			layoverStopID: firstStop,
+			shapeLength:   shapeLength(trip.Shape),
 		}
+		tripMap[trip.ID] = info
+		distanceTrips = append(distanceTrips, blockTripDistance{
+			tripID:         trip.ID,
+			blockID:        trip.BlockID,
+			serviceID:      trip.Service.Id,
+			firstDeparture: int64(trip.StopTimes[0].DepartureTime / time.Second),
+			shapeLength:    info.shapeLength,
+		})
 	}
+	// Block distances are precomputed so that measuring along a block takes
+	// one query at request time instead of placing every trip in between.
+	accumulatedDistances := blockDistances(distanceTrips)
 
 	// Group trips by (serviceID, layoverStopID)
 	indexGroups := make(map[blockTripIndexKey][]*tripInfo)
@@ -1084,6 +1350,8 @@ func (c *Client) buildBlockTripIndex(ctx context.Context, staticData *gtfs.Stati
 				BlockID:           toNullString(trip.blockID),
 				ServiceID:         trip.serviceID,
 				BlockTripSequence: int64(sequence),
+				ShapeLength:       trip.shapeLength,
+				BlockDistance:     accumulatedDistances[trip.tripID],
 			})
 			if err != nil {
 				return fmt.Errorf("failed to create block trip entry: %w", err)
@@ -1106,3 +1374,10 @@ func (c *Client) buildBlockTripIndex(ctx context.Context, staticData *gtfs.Stati
 
 	return nil
 }
+
+// shortHash returns the first characters of a feed hash for logging. The hash
+// of the imported feed is empty once a migration has cleared it to force a
+// reimport.
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 8)]
+}
@@ -0,0 +1,100 @@
+package gtfsdb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs/warnings"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// maxStoredImportWarningsPerKind bounds how many warnings of one kind are kept
+// for each file. A systematic problem, such as a missing column, repeats on
+// every row of a file and a few examples are enough to fix it.
+const maxStoredImportWarningsPerKind = 1000
+
+type importWarningGroup struct {
+	file, kind string
+}
+
+// importWarningSummary is how many warnings of a group the feed raised, with
+// the message of the first.
+type importWarningSummary struct {
+	count   int
+	example string
+}
+
+// importWarningParams converts parse warnings into import_warnings rows, in
+// the order go-gtfs raised them, keeping at most maxStoredImportWarningsPerKind
+// of each kind per file. It also summarizes every group, dropped rows included.
+func importWarningParams(ws []warnings.StaticWarning) ([]CreateImportWarningParams, map[importWarningGroup]*importWarningSummary) {
+	var params []CreateImportWarningParams
+	summaries := make(map[importWarningGroup]*importWarningSummary)
+	for _, w := range ws {
+		group := importWarningGroup{file: string(w.File), kind: importWarningKind(w.Kind)}
+		summary := summaries[group]
+		if summary == nil {
+			summary = &importWarningSummary{example: w.Kind.Error()}
+			summaries[group] = summary
+		}
+		summary.count++
+		if summary.count > maxStoredImportWarningsPerKind {
+			continue
+		}
+		params = append(params, CreateImportWarningParams{
+			File:       group.file,
+			RowNum:     int64(w.RowNumber),
+			Kind:       group.kind,
+			Message:    w.Kind.Error(),
+			RowContent: csvRow(w.RowContent),
+		})
+	}
+	return params, summaries
+}
+
+// importWarningKind names a warning by its go-gtfs type, e.g. "AgencyMissingValues".
+func importWarningKind(kind warnings.StaticWarningKind) string {
+	name := fmt.Sprintf("%T", kind)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func csvRow(fields []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write(fields) // Writing to a strings.Builder cannot fail
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// storeImportWarnings records the feed's parse warnings and logs a summary of
+// each kind per file, so publishers can find the rows to fix.
+func (c *Client) storeImportWarnings(ctx context.Context, logger *slog.Logger, ws []warnings.StaticWarning) error {
+	params, summaries := importWarningParams(ws)
+	for group, summary := range summaries {
+		logger.Warn("gtfs_parse_warnings",
+			slog.String("file", group.file),
+			slog.String("kind", group.kind),
+			slog.Int("count", summary.count),
+			slog.String("example", summary.example))
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "bulk_insert_import_warnings")
+
+	qtx := c.Queries.WithTx(tx)
+	for _, p := range params {
+		if err := qtx.CreateImportWarning(ctx, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
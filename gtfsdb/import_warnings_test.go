@@ -0,0 +1,69 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs/warnings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportStoresParseWarnings(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"agency.txt": `agency_id,agency_name,agency_url,agency_timezone
+TEST_AGENCY,Test Transit,https://test.com,America/Los_Angeles
+NAMELESS,,https://nameless.example.com,America/Los_Angeles
+`,
+	}))
+	ctx := context.Background()
+
+	all, err := client.Queries.ListImportWarnings(ctx, ListImportWarningsParams{PageLimit: -1})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "agency.txt", all[0].File)
+	assert.Equal(t, "AgencyMissingValues", all[0].Kind)
+	assert.Contains(t, all[0].Message, "NAMELESS")
+	assert.Equal(t, "NAMELESS,,https://nameless.example.com,America/Los_Angeles", all[0].RowContent)
+
+	filtered, err := client.Queries.ListImportWarnings(ctx, ListImportWarningsParams{
+		File:      sql.NullString{String: "stops.txt", Valid: true},
+		PageLimit: -1,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, filtered)
+
+	counts, err := client.Queries.CountImportWarnings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []CountImportWarningsRow{{File: "agency.txt", Kind: "AgencyMissingValues", Count: 1}}, counts)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	all, err = client.Queries.ListImportWarnings(ctx, ListImportWarningsParams{PageLimit: -1})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestImportWarningParamsCapsEachKind(t *testing.T) {
+	ws := make([]warnings.StaticWarning, maxStoredImportWarningsPerKind+2)
+	for i := range ws {
+		ws[i] = warnings.StaticWarning{
+			Kind:       warnings.MissingColumns{Columns: []string{"stop_lat"}},
+			File:       "stops.txt",
+			RowNumber:  i + 1,
+			RowContent: []string{"STOP", "Main St, North"},
+		}
+	}
+	ws = append(ws, warnings.StaticWarning{Kind: warnings.AgencyMissingValues{AgencyID: "A"}, File: "agency.txt"})
+
+	params, summaries := importWarningParams(ws)
+	assert.Len(t, params, maxStoredImportWarningsPerKind+1)
+	assert.Equal(t, `STOP,"Main St, North"`, params[0].RowContent)
+	assert.Equal(t, "MissingColumns", params[0].Kind)
+
+	stops := summaries[importWarningGroup{file: "stops.txt", kind: "MissingColumns"}]
+	require.NotNil(t, stops)
+	assert.Equal(t, maxStoredImportWarningsPerKind+2, stops.count)
+	assert.Equal(t, "csv file is missing columns [stop_lat]", stops.example)
+	assert.Equal(t, 1, summaries[importWarningGroup{file: "agency.txt", kind: "AgencyMissingValues"}].count)
+}
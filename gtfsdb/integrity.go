@@ -0,0 +1,308 @@
+package gtfsdb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// go-gtfs silently leaves out trips whose route or service is missing and stop
+// times whose trip or stop is missing, and imports a trip without its shape when
+// the shape is missing. The check in this file goes back to trips.txt and
+// stop_times.txt to find those rows, so that a broken feed does not quietly lose
+// service.
+
+// IntegrityMode is what an import does with rows that reference rows the feed
+// does not have.
+type IntegrityMode string
+
+const (
+	// IntegrityModeReport logs the orphaned rows and lists a sample of each kind
+	// in the import warnings. It is the default.
+	IntegrityModeReport IntegrityMode = "report"
+	// IntegrityModeFail fails the import when any row is orphaned.
+	IntegrityModeFail IntegrityMode = "fail"
+	// IntegrityModeQuarantine also keeps every orphaned row in quarantined_rows,
+	// so a publisher can be sent exactly what was left out.
+	IntegrityModeQuarantine IntegrityMode = "quarantine"
+)
+
+// maxIntegrityExamples is how many missing values of each check the report
+// names.
+const maxIntegrityExamples = 10
+
+// integrityCheck is one foreign key of trips.txt or stop_times.txt.
+type integrityCheck struct {
+	file       string
+	column     string
+	references string
+	kind       string
+}
+
+// The checks of a row run in order and the first that fails is the row's only
+// violation, the same order in which go-gtfs gives up on the row.
+var (
+	tripRouteCheck      = integrityCheck{"trips.txt", "route_id", "routes.txt", "UnknownRouteReference"}
+	tripServiceCheck    = integrityCheck{"trips.txt", "service_id", "calendar.txt or calendar_dates.txt", "UnknownServiceReference"}
+	tripShapeCheck      = integrityCheck{"trips.txt", "shape_id", "shapes.txt", "UnknownShapeReference"}
+	stopTimeTripCheck   = integrityCheck{"stop_times.txt", "trip_id", "trips.txt", "UnknownTripReference"}
+	stopTimeStopCheck   = integrityCheck{"stop_times.txt", "stop_id", "stops.txt", "UnknownStopReference"}
+	integrityCheckOrder = []integrityCheck{tripRouteCheck, tripServiceCheck, tripShapeCheck, stopTimeTripCheck, stopTimeStopCheck}
+)
+
+// orphan is a row whose reference failed a check.
+type orphan struct {
+	check   integrityCheck
+	missing string
+	rowNum  int
+	row     []string
+}
+
+// IntegrityViolation counts the rows of a file whose column references a row
+// missing from another file.
+type IntegrityViolation struct {
+	File       string
+	Column     string
+	References string
+	Count      int
+	// Examples holds the missing values of the first few orphaned rows.
+	Examples []string
+}
+
+// IntegrityReport lists the foreign keys the imported feed violates, in check
+// order.
+type IntegrityReport struct {
+	Violations []IntegrityViolation
+}
+
+// OrphanCount is the number of orphaned rows across all checks.
+func (r IntegrityReport) OrphanCount() int {
+	total := 0
+	for _, v := range r.Violations {
+		total += v.Count
+	}
+	return total
+}
+
+func (r IntegrityReport) String() string {
+	parts := make([]string, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		parts = append(parts, fmt.Sprintf("%d %s rows with unknown %s", v.Count, v.File, v.Column))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// feedReferences holds the IDs the parsed feed has for trips and stop times to
+// reference.
+type feedReferences struct {
+	routes, services, shapes, trips, stops map[string]bool
+}
+
+func newFeedReferences(staticData *gtfs.Static) feedReferences {
+	refs := feedReferences{
+		routes:   make(map[string]bool, len(staticData.Routes)),
+		services: make(map[string]bool, len(staticData.Services)),
+		shapes:   make(map[string]bool, len(staticData.Shapes)),
+		trips:    make(map[string]bool, len(staticData.Trips)),
+		stops:    make(map[string]bool, len(staticData.Stops)),
+	}
+	for _, r := range staticData.Routes {
+		refs.routes[r.Id] = true
+	}
+	for _, s := range staticData.Services {
+		refs.services[s.Id] = true
+	}
+	for _, s := range staticData.Shapes {
+		refs.shapes[s.ID] = true
+	}
+	for _, t := range staticData.Trips {
+		refs.trips[t.ID] = true
+	}
+	for _, s := range staticData.Stops {
+		refs.stops[s.Id] = true
+	}
+	return refs
+}
+
+// checkReferentialIntegrity cross-checks the references of trips.txt and
+// stop_times.txt against the parsed feed. Orphaned rows are logged in summary
+// and stored as import warnings; the configured mode then decides whether they
+// are also quarantined or fail the import.
+func (c *Client) checkReferentialIntegrity(ctx context.Context, logger *slog.Logger, archive *feedArchive, staticData *gtfs.Static) (IntegrityReport, error) {
+	mode := c.config.referentialIntegrity()
+	refs := newFeedReferences(staticData)
+
+	orphans, err := archive.findOrphanedTrips(refs)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	stopTimeOrphans, err := archive.findOrphanedStopTimes(refs)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	orphans = append(orphans, stopTimeOrphans...)
+
+	report := integrityReport(orphans)
+	for _, v := range report.Violations {
+		logger.Warn("gtfs_referential_integrity",
+			slog.String("file", v.File),
+			slog.String("column", v.Column),
+			slog.String("references", v.References),
+			slog.Int("count", v.Count),
+			slog.Any("examples", v.Examples),
+			slog.String("mode", string(mode)))
+	}
+	if len(orphans) == 0 {
+		return report, nil
+	}
+
+	if mode == IntegrityModeFail {
+		return report, fmt.Errorf("feed fails referential integrity: %s", report)
+	}
+	if err := c.storeIntegrityWarnings(ctx, logger, orphans); err != nil {
+		return report, fmt.Errorf("unable to store integrity warnings: %w", err)
+	}
+	if mode == IntegrityModeQuarantine {
+		if err := c.bulkInsertQuarantinedRows(ctx, orphans); err != nil {
+			return report, fmt.Errorf("unable to quarantine rows: %w", err)
+		}
+	}
+
+	logging.LogOperation(logger, "referential_integrity_checked",
+		slog.Int("orphans", report.OrphanCount()),
+		slog.String("mode", string(mode)))
+	return report, nil
+}
+
+// findOrphanedTrips checks the route, service and shape of every trip.
+func (a *feedArchive) findOrphanedTrips(refs feedReferences) ([]orphan, error) {
+	file, err := a.open("trips.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	tripID := file.RequiredColumn("trip_id")
+	routeID := file.RequiredColumn("route_id")
+	serviceID := file.RequiredColumn("service_id")
+	shapeID := file.OptionalColumn("shape_id")
+
+	var orphans []orphan
+	for file.NextRow() {
+		_ = tripID.Read()
+		route, service, shape := routeID.Read(), serviceID.Read(), shapeID.Read()
+		// Rows missing required values are dropped for that reason, not a reference.
+		if len(file.MissingRowKeys()) > 0 {
+			continue
+		}
+		switch {
+		case !refs.routes[route]:
+			orphans = append(orphans, newOrphan(tripRouteCheck, route, file.RowNumber(), file.RowContent()))
+		case !refs.services[service]:
+			orphans = append(orphans, newOrphan(tripServiceCheck, service, file.RowNumber(), file.RowContent()))
+		case shape != "" && !refs.shapes[shape]:
+			orphans = append(orphans, newOrphan(tripShapeCheck, shape, file.RowNumber(), file.RowContent()))
+		}
+	}
+	return orphans, nil
+}
+
+// findOrphanedStopTimes checks the trip and stop of every stop time. The stop
+// times of trips go-gtfs left out are orphaned too.
+func (a *feedArchive) findOrphanedStopTimes(refs feedReferences) ([]orphan, error) {
+	file, err := a.open("stop_times.txt")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+
+	tripID := file.RequiredColumn("trip_id")
+	stopID := file.RequiredColumn("stop_id")
+
+	var orphans []orphan
+	for file.NextRow() {
+		trip, stop := tripID.Read(), stopID.Read()
+		if len(file.MissingRowKeys()) > 0 {
+			continue
+		}
+		switch {
+		case !refs.trips[trip]:
+			orphans = append(orphans, newOrphan(stopTimeTripCheck, trip, file.RowNumber(), file.RowContent()))
+		case !refs.stops[stop]:
+			orphans = append(orphans, newOrphan(stopTimeStopCheck, stop, file.RowNumber(), file.RowContent()))
+		}
+	}
+	return orphans, nil
+}
+
+func newOrphan(check integrityCheck, missing string, rowNum int, row []string) orphan {
+	return orphan{check: check, missing: missing, rowNum: rowNum, row: slices.Clone(row)}
+}
+
+// integrityReport groups orphans by check.
+func integrityReport(orphans []orphan) IntegrityReport {
+	byKind := make(map[string]*IntegrityViolation)
+	for _, o := range orphans {
+		v := byKind[o.check.kind]
+		if v == nil {
+			v = &IntegrityViolation{File: o.check.file, Column: o.check.column, References: o.check.references}
+			byKind[o.check.kind] = v
+		}
+		v.Count++
+		if len(v.Examples) < maxIntegrityExamples {
+			v.Examples = append(v.Examples, o.missing)
+		}
+	}
+
+	var report IntegrityReport
+	for _, check := range integrityCheckOrder {
+		if v := byKind[check.kind]; v != nil {
+			report.Violations = append(report.Violations, *v)
+		}
+	}
+	return report
+}
+
+func (o orphan) message() string {
+	return fmt.Sprintf("%s %q is not in %s", o.check.column, o.missing, o.check.references)
+}
+
+// storeIntegrityWarnings records up to maxStoredImportWarningsPerKind orphans
+// of each check as import warnings.
+func (c *Client) storeIntegrityWarnings(ctx context.Context, logger *slog.Logger, orphans []orphan) error {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "store_integrity_warnings")
+
+	qtx := c.Queries.WithTx(tx)
+	stored := make(map[string]int)
+	for _, o := range orphans {
+		if stored[o.check.kind] >= maxStoredImportWarningsPerKind {
+			continue
+		}
+		stored[o.check.kind]++
+		if err := qtx.CreateImportWarning(ctx, CreateImportWarningParams{
+			File:       o.check.file,
+			RowNum:     int64(o.rowNum),
+			Kind:       o.check.kind,
+			Message:    o.message(),
+			RowContent: csvRow(o.row),
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *Client) bulkInsertQuarantinedRows(ctx context.Context, orphans []orphan) error {
+	return insertBatched(ctx, c, quarantinedRowsBatchInsert, orphans, func(o orphan) []interface{} {
+		return []interface{}{o.check.file, o.rowNum, o.check.kind, csvRow(o.row)}
+	})
+}
@@ -0,0 +1,137 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// orphanedFeed breaks the references of the minimal feed: TRIP3 runs on an
+// unknown route, TRIP2 points at a missing shape, and TRIP1 calls at a stop
+// that does not exist. TRIP3's stop time comes first because go-gtfs v1.1.1
+// panics on stop times of an unknown trip that follow another trip's.
+func orphanedFeed(t *testing.T) []byte {
+	t.Helper()
+	return createGTFSZip(t, map[string]string{
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign,shape_id
+ROUTE1,WEEKDAY,TRIP1,Downtown,
+ROUTE1,WEEKDAY,TRIP2,Uptown,NO_SHAPE
+NO_ROUTE,WEEKDAY,TRIP3,Nowhere,
+`,
+		"stop_times.txt": `trip_id,arrival_time,departure_time,stop_id,stop_sequence
+TRIP3,10:00:00,10:00:00,STOP1,1
+TRIP1,08:00:00,08:00:00,STOP1,1
+TRIP1,08:15:00,08:15:00,STOP2,2
+TRIP1,08:20:00,08:20:00,NO_STOP,3
+TRIP2,09:00:00,09:00:00,STOP2,1
+TRIP2,09:15:00,09:15:00,STOP1,2
+`,
+	})
+}
+
+func importWithIntegrityMode(t *testing.T, mode IntegrityMode, feed []byte) (*Client, error) {
+	t.Helper()
+
+	config := NewConfig(":memory:", appconf.Test, false)
+	config.ReferentialIntegrity = mode
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, client.processAndStoreGTFSDataWithSource(feed, "test-source")
+}
+
+func TestReferentialIntegrity_Report(t *testing.T) {
+	client, err := importWithIntegrityMode(t, "", orphanedFeed(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// The orphans are left out as before; TRIP2 runs without its shape.
+	trips, err := client.Queries.ListTrips(ctx)
+	require.NoError(t, err)
+	assert.Len(t, trips, 2)
+	var stopTimes int
+	require.NoError(t, client.DB.QueryRow(`SELECT COUNT(*) FROM stop_times`).Scan(&stopTimes))
+	assert.Equal(t, 4, stopTimes)
+
+	counts, err := client.Queries.CountImportWarnings(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []CountImportWarningsRow{
+		{File: "trips.txt", Kind: "UnknownRouteReference", Count: 1},
+		{File: "trips.txt", Kind: "UnknownShapeReference", Count: 1},
+		{File: "stop_times.txt", Kind: "UnknownTripReference", Count: 1},
+		{File: "stop_times.txt", Kind: "UnknownStopReference", Count: 1},
+	}, counts)
+
+	stored, err := client.Queries.ListImportWarnings(ctx, ListImportWarningsParams{
+		Kind:      sql.NullString{String: "UnknownStopReference", Valid: true},
+		PageLimit: -1,
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "stop_times.txt", stored[0].File)
+	assert.Equal(t, int64(4), stored[0].RowNum)
+	assert.Equal(t, `stop_id "NO_STOP" is not in stops.txt`, stored[0].Message)
+	assert.Equal(t, "TRIP1,08:20:00,08:20:00,NO_STOP,3", stored[0].RowContent)
+
+	quarantined, err := client.Queries.ListQuarantinedRows(ctx, sql.NullString{})
+	require.NoError(t, err)
+	assert.Empty(t, quarantined)
+}
+
+func TestReferentialIntegrity_Fail(t *testing.T) {
+	_, err := importWithIntegrityMode(t, IntegrityModeFail, orphanedFeed(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 trips.txt rows with unknown route_id")
+	assert.Contains(t, err.Error(), "1 stop_times.txt rows with unknown stop_id")
+
+	_, err = importWithIntegrityMode(t, IntegrityModeFail, createGTFSZip(t, nil))
+	assert.NoError(t, err, "a clean feed imports")
+}
+
+func TestReferentialIntegrity_Quarantine(t *testing.T) {
+	client, err := importWithIntegrityMode(t, IntegrityModeQuarantine, orphanedFeed(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	quarantined, err := client.Queries.ListQuarantinedRows(ctx, sql.NullString{})
+	require.NoError(t, err)
+	require.Len(t, quarantined, 4)
+	assert.Equal(t, "trips.txt", quarantined[0].File)
+	assert.Equal(t, "UnknownShapeReference", quarantined[0].Kind)
+	assert.Equal(t, int64(2), quarantined[0].RowNum)
+	assert.Equal(t, "ROUTE1,WEEKDAY,TRIP2,Uptown,NO_SHAPE", quarantined[0].RowContent)
+	assert.Equal(t, "UnknownRouteReference", quarantined[1].Kind)
+
+	stopTimes, err := client.Queries.ListQuarantinedRows(ctx, sql.NullString{String: "stop_times.txt", Valid: true})
+	require.NoError(t, err)
+	require.Len(t, stopTimes, 2)
+	assert.Equal(t, "TRIP3,10:00:00,10:00:00,STOP1,1", stopTimes[0].RowContent)
+	assert.Equal(t, "TRIP1,08:20:00,08:20:00,NO_STOP,3", stopTimes[1].RowContent)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	quarantined, err = client.Queries.ListQuarantinedRows(ctx, sql.NullString{})
+	require.NoError(t, err)
+	assert.Empty(t, quarantined)
+}
+
+func TestIntegrityReport(t *testing.T) {
+	orphans := []orphan{
+		newOrphan(stopTimeStopCheck, "S1", 4, nil),
+		newOrphan(tripRouteCheck, "R1", 1, nil),
+		newOrphan(stopTimeStopCheck, "S2", 9, nil),
+	}
+
+	report := integrityReport(orphans)
+	require.Len(t, report.Violations, 2)
+	assert.Equal(t, IntegrityViolation{
+		File: "trips.txt", Column: "route_id", References: "routes.txt", Count: 1, Examples: []string{"R1"},
+	}, report.Violations[0])
+	assert.Equal(t, []string{"S1", "S2"}, report.Violations[1].Examples)
+	assert.Equal(t, 3, report.OrphanCount())
+	assert.Equal(t, "1 trips.txt rows with unknown route_id, 2 stop_times.txt rows with unknown stop_id", report.String())
+}
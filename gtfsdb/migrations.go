@@ -0,0 +1,226 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"maglev.onebusaway.org/migrations"
+)
+
+// migration is one versioned change to the database, with the SQL that
+// applies it and the SQL that reverts it.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the migrations embedded from the migrations directory,
+// ordered by version.
+func loadMigrations(files fs.FS) ([]migration, error) {
+	paths, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, path := range paths {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(path, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", path)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s does not start with a positive version number", path)
+		}
+		body, err := fs.ReadFile(files, path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		} else if m.name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.name, name)
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	result := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up step", m.version, m.name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].version < result[j].version })
+	return result, nil
+}
+
+// isNewDatabase reports whether the database has no GTFS tables yet, before
+// the schema is created.
+func isNewDatabase(ctx context.Context, db *sql.DB) (bool, error) {
+	exists, err := hasTable(ctx, db, "stop_times")
+	return !exists, err
+}
+
+func hasTable(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	return count > 0, err
+}
+
+// migrateUp applies the migrations the database has not had yet, each in its
+// own transaction together with the schema_migrations row that records it.
+// schema.sql always describes the latest schema, so a new database only
+// records every migration as applied.
+func migrateUp(ctx context.Context, db *sql.DB, newDatabase bool) error {
+	all, err := loadMigrations(migrations.Files)
+	if err != nil {
+		return err
+	}
+	tracked, err := hasTable(ctx, db, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if !tracked {
+		// Databases from before schema_migrations existed recorded their data
+		// migrations in user_version.
+		var baseline int
+		if !newDatabase {
+			if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&baseline); err != nil {
+				return fmt.Errorf("error reading schema version: %w", err)
+			}
+		}
+		for _, m := range all {
+			if newDatabase || m.version <= baseline {
+				if err := recordMigration(ctx, db, m); err != nil {
+					return err
+				}
+				applied[m.version] = true
+			}
+		}
+	}
+
+	for _, m := range all {
+		if applied[m.version] {
+			continue
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, m.up); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("error applying migration %d_%s: %w", m.version, m.name, err)
+		}
+		if err := recordMigration(ctx, tx, m); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts applied migrations, latest first, until the database is
+// at targetVersion. It fails without changing anything further when a
+// migration has no down step.
+func (c *Client) MigrateDown(ctx context.Context, targetVersion int) error {
+	all, err := loadMigrations(migrations.Files)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(ctx, c.DB)
+	if err != nil {
+		return err
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		if m.version <= targetVersion || !applied[m.version] {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("migration %d_%s cannot be reverted", m.version, m.name)
+		}
+		tx, err := c.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, m.down); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("error reverting migration %d_%s: %w", m.version, m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the latest migration applied to the database, or 0
+// when none has been.
+func (c *Client) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := c.DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int]bool, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func recordMigration(ctx context.Context, db DBTX, m migration) error {
+	if _, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return fmt.Errorf("error recording migration %d_%s: %w", m.version, m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := loadMigrations(fstest.MapFS{
+		"0002_add_column.up.sql":     {Data: []byte("ALTER TABLE t ADD COLUMN c TEXT;")},
+		"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (id TEXT);")},
+		"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+	})
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, migration{version: 1, name: "create_table", up: "CREATE TABLE t (id TEXT);", down: "DROP TABLE t;"}, loaded[0])
+	assert.Equal(t, 2, loaded[1].version)
+	assert.Empty(t, loaded[1].down)
+
+	invalid := map[string]fstest.MapFS{
+		"no direction":   {"0001_create_table.sql": {}},
+		"no version":     {"create_table.up.sql": {}},
+		"no up step":     {"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")}},
+		"version reused": {"0001_a.up.sql": {Data: []byte("SELECT 1;")}, "0001_b.up.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, files := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadMigrations(files)
+			assert.Error(t, err)
+		})
+	}
+
+	embedded, err := loadMigrations(migrations.Files)
+	require.NoError(t, err)
+	assert.NotEmpty(t, embedded)
+}
+
+func latestMigrationVersion(t *testing.T) int {
+	t.Helper()
+	all, err := loadMigrations(migrations.Files)
+	require.NoError(t, err)
+	return all[len(all)-1].version
+}
+
+func TestNewDatabaseRecordsEveryMigration(t *testing.T) {
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	version, err := client.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, latestMigrationVersion(t), version)
+}
+
+func TestMigrationsUpgradeAndRevertExistingDatabase(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"frequencies.txt": `trip_id,start_time,end_time,headway_secs
+TRIP1,06:00:00,09:00:00,600
+`,
+	}))
+	ctx := context.Background()
+
+	current, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	require.NotEmpty(t, current)
+
+	// Revert to the layout of a database written before stop times were stored
+	// in seconds.
+	require.NoError(t, client.MigrateDown(ctx, 0))
+	version, err := client.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	// The reverted layout also predates distance_along_shape, which the
+	// generated queries select.
+	var revertedArrival int64
+	require.NoError(t, client.DB.QueryRowContext(ctx,
+		"SELECT arrival_time FROM stop_times WHERE trip_id = 'TRIP1' ORDER BY stop_sequence LIMIT 1").Scan(&revertedArrival))
+	assert.Equal(t, current[0].ArrivalTime*1_000_000_000, revertedArrival)
+
+	// Starting up again applies the pending migration, whatever user_version
+	// an earlier release left behind.
+	_, err = client.DB.ExecContext(ctx, "PRAGMA user_version = 1")
+	require.NoError(t, err)
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	upgraded, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, current, upgraded)
+
+	route, err := client.Queries.GetRoute(ctx, "ROUTE1")
+	require.NoError(t, err)
+	assert.False(t, route.SortOrder.Valid, "sort orders are filled in by the next import")
+
+	frequencies, err := client.Queries.GetFrequenciesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	require.Len(t, frequencies, 1)
+	assert.Equal(t, int64(6*3600), frequencies[0].StartTime)
+
+	// Applied migrations are not run again.
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	again, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, current, again)
+}
+
+func TestMigrationsAdoptLegacyUserVersion(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, nil))
+	ctx := context.Background()
+
+	current, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+
+	// A database that recorded the seconds conversion in user_version before
+	// schema_migrations existed must not be converted a second time. Such a
+	// database also predates the block and shape distance columns, the route
+	// sort order and network columns, shape geometries and the feed's HTTP
+	// validators.
+	_, err = client.DB.ExecContext(ctx, `DROP TABLE schema_migrations; PRAGMA user_version = 1;
+		ALTER TABLE block_trip_entry DROP COLUMN block_distance;
+		ALTER TABLE block_trip_entry DROP COLUMN shape_length;
+		ALTER TABLE shapes DROP COLUMN distance_along_shape;
+		ALTER TABLE stop_times DROP COLUMN distance_along_shape;
+		ALTER TABLE routes DROP COLUMN network_id;
+		ALTER TABLE routes DROP COLUMN sort_order;
+		DROP TABLE shape_geometries;
+		ALTER TABLE import_metadata DROP COLUMN etag;
+		ALTER TABLE import_metadata DROP COLUMN last_modified;`)
+	require.NoError(t, err)
+
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+
+	version, err := client.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latestMigrationVersion(t), version)
+
+	after, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, current, after)
+}
+
+func TestShapeGeometriesMigration(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"shapes.txt": `shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence
+SHAPE1,40.7128,-74.0060,1
+SHAPE1,40.7580,-73.9855,2
+SHAPE2,40.7128,-74.0060,1
+SHAPE2,40.7580,-73.9855,2
+`,
+	}))
+	ctx := context.Background()
+	countShapes := func() int {
+		var count int
+		require.NoError(t, client.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM shapes").Scan(&count))
+		return count
+	}
+	require.Equal(t, 2, countShapes())
+
+	// Reverting gives SHAPE2 its own copy of the shared points.
+	require.NoError(t, client.MigrateDown(ctx, 4))
+	assert.Equal(t, 4, countShapes())
+	geometries, err := client.Queries.GetShapeGeometries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, geometries)
+
+	// Upgrading maps every shape to its own points until the next import.
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	geometries, err = client.Queries.GetShapeGeometries(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ShapeGeometry{
+		{ShapeID: "SHAPE1", GeometryID: "SHAPE1"},
+		{ShapeID: "SHAPE2", GeometryID: "SHAPE2"},
+	}, geometries)
+	points, err := client.Queries.GetShapePoints(ctx, "SHAPE2")
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+}
+
+func TestUpgradedDatabaseIsReimported(t *testing.T) {
+	feed := createGTFSZip(t, map[string]string{
+		"routes.txt": `route_id,agency_id,route_short_name,route_long_name,route_type,route_sort_order
+ROUTE1,TEST_AGENCY,1,Test Route,3,5
+`,
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign,block_id,shape_id
+ROUTE1,WEEKDAY,TRIP1,Downtown,BLOCK1,SHAPE1
+ROUTE1,WEEKDAY,TRIP2,Uptown,BLOCK1,SHAPE1
+`,
+		"shapes.txt": `shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence
+SHAPE1,40.7128,-74.0060,1
+SHAPE1,40.7580,-73.9855,2
+`,
+	})
+	client := newImportedTestClient(t, feed)
+	ctx := context.Background()
+
+	// Upgrading from before the distance and sort order columns leaves them
+	// NULL.
+	require.NoError(t, client.MigrateDown(ctx, 1))
+	require.NoError(t, performDatabaseMigration(ctx, client.DB))
+	countNulls := func() int {
+		var count int
+		require.NoError(t, client.DB.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM block_trip_entry WHERE shape_length IS NULL OR block_distance IS NULL) +
+			(SELECT COUNT(*) FROM shapes WHERE distance_along_shape IS NULL) +
+			(SELECT COUNT(*) FROM stop_times WHERE distance_along_shape IS NULL) +
+			(SELECT COUNT(*) FROM routes WHERE sort_order IS NULL)`).Scan(&count))
+		return count
+	}
+	require.NotZero(t, countNulls())
+
+	// The next start imports the same feed again, filling them in.
+	require.NoError(t, client.processAndStoreGTFSDataWithSource(feed, "test-source"))
+	assert.Zero(t, countNulls())
+	route, err := client.Queries.GetRoute(ctx, "ROUTE1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), route.SortOrder.Int64)
+}
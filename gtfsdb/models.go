@@ -26,6 +26,8 @@ type BlockTripEntry struct {
 	BlockID           sql.NullString
 	ServiceID         string
 	BlockTripSequence int64
+	ShapeLength       sql.NullFloat64
+	BlockDistance     sql.NullFloat64
 }
 
 type BlockTripIndex struct {
@@ -36,6 +38,24 @@ type BlockTripIndex struct {
 	CreatedAt       int64
 }
 
+type BookingRule struct {
+	ID                     string
+	BookingType            int64
+	PriorNoticeDurationMin sql.NullInt64
+	PriorNoticeDurationMax sql.NullInt64
+	PriorNoticeLastDay     sql.NullInt64
+	PriorNoticeLastTime    sql.NullInt64
+	PriorNoticeStartDay    sql.NullInt64
+	PriorNoticeStartTime   sql.NullInt64
+	PriorNoticeServiceID   sql.NullString
+	Message                sql.NullString
+	PickupMessage          sql.NullString
+	DropOffMessage         sql.NullString
+	PhoneNumber            sql.NullString
+	InfoUrl                sql.NullString
+	BookingUrl             sql.NullString
+}
+
 type Calendar struct {
 	ID        string
 	Monday    int64
@@ -55,11 +75,112 @@ type CalendarDate struct {
 	ExceptionType int64
 }
 
+type FareAttribute struct {
+	FareID           string
+	AgencyID         sql.NullString
+	Price            float64
+	CurrencyType     string
+	PaymentMethod    int64
+	Transfers        sql.NullInt64
+	TransferDuration sql.NullInt64
+}
+
+type FareRule struct {
+	ID            int64
+	FareID        string
+	RouteID       sql.NullString
+	OriginID      sql.NullString
+	DestinationID sql.NullString
+	ContainsID    sql.NullString
+}
+
+type FeedInfo struct {
+	ID                int64
+	FeedPublisherName string
+	FeedPublisherUrl  string
+	FeedLang          string
+	DefaultLang       sql.NullString
+	FeedStartDate     sql.NullString
+	FeedEndDate       sql.NullString
+	FeedVersion       sql.NullString
+	FeedContactEmail  sql.NullString
+	FeedContactUrl    sql.NullString
+}
+
+type FlexStopTime struct {
+	TripID                   string
+	StopSequence             int64
+	StopID                   sql.NullString
+	LocationID               sql.NullString
+	LocationGroupID          sql.NullString
+	StartPickupDropOffWindow sql.NullInt64
+	EndPickupDropOffWindow   sql.NullInt64
+	PickupType               int64
+	DropOffType              int64
+	ContinuousPickup         int64
+	ContinuousDropOff        int64
+	PickupBookingRuleID      sql.NullString
+	DropOffBookingRuleID     sql.NullString
+}
+
+type Frequency struct {
+	TripID      string
+	StartTime   int64
+	EndTime     int64
+	HeadwaySecs int64
+	ExactTimes  int64
+}
+
+type HistoricalOccupancy struct {
+	TripID          string
+	StopID          string
+	DayOfWeek       int64
+	OccupancyStatus int64
+	SampleCount     int64
+}
+
 type ImportMetadatum struct {
+	ID           int64
+	FileHash     string
+	ImportTime   int64
+	FileSource   string
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+type ImportWarning struct {
 	ID         int64
-	FileHash   string
-	ImportTime int64
-	FileSource string
+	File       string
+	RowNum     int64
+	Kind       string
+	Message    string
+	RowContent string
+}
+
+type Location struct {
+	ID       string
+	StopName sql.NullString
+	StopDesc sql.NullString
+	Geometry string
+	MinLat   float64
+	MinLon   float64
+	MaxLat   float64
+	MaxLon   float64
+}
+
+type LocationGroup struct {
+	ID                string
+	LocationGroupName sql.NullString
+}
+
+type LocationGroupStop struct {
+	LocationGroupID string
+	StopID          string
+}
+
+type Network struct {
+	ID   string
+	Name sql.NullString
 }
 
 type ProblemReportsStop struct {
@@ -91,6 +212,14 @@ type ProblemReportsTrip struct {
 	SubmittedAt          int64
 }
 
+type QuarantinedRow struct {
+	ID         int64
+	File       string
+	RowNum     int64
+	Kind       string
+	RowContent string
+}
+
 type Route struct {
 	ID                string
 	AgencyID          string
@@ -103,6 +232,8 @@ type Route struct {
 	TextColor         sql.NullString
 	ContinuousPickup  sql.NullInt64
 	ContinuousDropOff sql.NullInt64
+	SortOrder         sql.NullInt64
+	NetworkID         sql.NullString
 }
 
 type RoutesFt struct {
@@ -113,13 +244,27 @@ type RoutesFt struct {
 	Desc      string
 }
 
+type ScheduleDeviationSample struct {
+	TripID      string
+	StopID      string
+	ServiceDate string
+	Deviation   int64
+	ObservedAt  int64
+}
+
 type Shape struct {
-	ID                int64
-	ShapeID           string
-	Lat               float64
-	Lon               float64
-	ShapePtSequence   int64
-	ShapeDistTraveled sql.NullFloat64
+	ID                 int64
+	ShapeID            string
+	Lat                float64
+	Lon                float64
+	ShapePtSequence    int64
+	ShapeDistTraveled  sql.NullFloat64
+	DistanceAlongShape sql.NullFloat64
+}
+
+type ShapeGeometry struct {
+	ShapeID    string
+	GeometryID string
 }
 
 type Stop struct {
@@ -140,21 +285,23 @@ type Stop struct {
 }
 
 type StopTime struct {
-	TripID            string
-	ArrivalTime       int64
-	DepartureTime     int64
-	StopID            string
-	StopSequence      int64
-	StopHeadsign      sql.NullString
-	PickupType        sql.NullInt64
-	DropOffType       sql.NullInt64
-	ShapeDistTraveled sql.NullFloat64
-	Timepoint         sql.NullInt64
+	TripID             string
+	ArrivalTime        int64
+	DepartureTime      int64
+	StopID             string
+	StopSequence       int64
+	StopHeadsign       sql.NullString
+	PickupType         sql.NullInt64
+	DropOffType        sql.NullInt64
+	ShapeDistTraveled  sql.NullFloat64
+	Timepoint          sql.NullInt64
+	DistanceAlongShape sql.NullFloat64
 }
 
 type StopsFt struct {
-	ID       string
-	StopName string
+	ID   string
+	Name string
+	Code string
 }
 
 type StopsRtreeNode struct {
@@ -172,6 +319,29 @@ type StopsRtreeRowid struct {
 	Nodeno interface{}
 }
 
+type Transfer struct {
+	ID              int64
+	FromStopID      string
+	ToStopID        string
+	FromRouteID     sql.NullString
+	ToRouteID       sql.NullString
+	FromTripID      sql.NullString
+	ToTripID        sql.NullString
+	TransferType    int64
+	MinTransferTime sql.NullInt64
+}
+
+type Translation struct {
+	ID          int64
+	TableName   string
+	FieldName   string
+	Language    string
+	Translation string
+	RecordID    sql.NullString
+	RecordSubID sql.NullString
+	FieldValue  sql.NullString
+}
+
 type Trip struct {
 	ID                   string
 	RouteID              string
@@ -184,3 +354,19 @@ type Trip struct {
 	WheelchairAccessible sql.NullInt64
 	BikesAllowed         sql.NullInt64
 }
+
+type VehiclePositionsHistory struct {
+	ID                int64
+	FeedID            string
+	VehicleID         string
+	TripID            sql.NullString
+	RouteID           sql.NullString
+	Lat               sql.NullFloat64
+	Lon               sql.NullFloat64
+	Bearing           sql.NullFloat64
+	Speed             sql.NullFloat64
+	ScheduleDeviation sql.NullInt64
+	StopID            sql.NullString
+	OccupancyStatus   sql.NullInt64
+	ObservedAt        int64
+}
@@ -0,0 +1,69 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportRouteSortOrderAndNetworks(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"routes.txt": `route_id,agency_id,route_short_name,route_long_name,route_type,route_sort_order,network_id
+ROUTE1,TEST_AGENCY,1,Test Route,3,20,local
+ROUTE2,TEST_AGENCY,2,Express,3,0,express
+ROUTE3,TEST_AGENCY,3,Shuttle,3,,
+`,
+		"networks.txt": `network_id,network_name
+local,Local Buses
+express,
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	routes, err := client.Queries.GetRoutesByIDs(ctx, []string{"ROUTE1", "ROUTE2", "ROUTE3"})
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Equal(t, sql.NullInt64{Int64: 20, Valid: true}, routes[0].SortOrder)
+	assert.Equal(t, "local", routes[0].NetworkID.String)
+	assert.Equal(t, sql.NullInt64{Int64: 0, Valid: true}, routes[1].SortOrder, "zero is a sort order")
+	assert.Equal(t, "express", routes[1].NetworkID.String)
+	assert.False(t, routes[2].SortOrder.Valid)
+	assert.False(t, routes[2].NetworkID.Valid)
+
+	networks, err := client.Queries.ListNetworks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Network{
+		{ID: "express"},
+		{ID: "local", Name: sql.NullString{String: "Local Buses", Valid: true}},
+	}, networks)
+
+	ids, err := client.Queries.GetRouteIDsForAgency(ctx, "TEST_AGENCY")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ROUTE2", "ROUTE1", "ROUTE3"}, ids)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	networks, err = client.Queries.ListNetworks(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, networks)
+}
+
+func TestImportRouteNetworksFile(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"networks.txt": `network_id,network_name
+rapid,Rapid Ride
+`,
+		"route_networks.txt": `network_id,route_id
+rapid,ROUTE1
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+
+	route, err := client.Queries.GetRoute(context.Background(), "ROUTE1")
+	require.NoError(t, err)
+	assert.Equal(t, "rapid", route.NetworkID.String)
+	assert.False(t, route.SortOrder.Valid)
+}
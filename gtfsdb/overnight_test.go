@@ -0,0 +1,48 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOvernightStopTimes(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"stop_times.txt": `trip_id,arrival_time,departure_time,stop_id,stop_sequence
+TRIP1,23:50:00,23:52:00,STOP1,1
+TRIP1,24:40:00,24:41:00,STOP2,2
+TRIP2,09:00:00,09:00:00,STOP2,1
+TRIP2,09:15:00,09:15:00,STOP1,2
+`,
+	}))
+	ctx := context.Background()
+
+	span, err := client.Queries.GetTripServiceSpan(ctx, "TRIP1")
+	require.NoError(t, err)
+	assert.Equal(t, int64((23*time.Hour+52*time.Minute)/time.Second), span.FirstDepartureTime)
+	assert.Equal(t, int64((24*time.Hour+40*time.Minute)/time.Second), span.LastArrivalTime)
+
+	span, err = client.Queries.GetTripServiceSpan(ctx, "NO_SUCH_TRIP")
+	require.NoError(t, err)
+	assert.Zero(t, span.LastArrivalTime)
+
+	maxStopTime, err := client.Queries.GetMaxStopTime(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64((24*time.Hour+41*time.Minute)/time.Second), maxStopTime)
+}
+
+func TestMaxStopTimeCountsLastFrequencyRun(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"frequencies.txt": `trip_id,start_time,end_time,headway_secs
+TRIP2,09:00:00,25:00:00,600
+`,
+	}))
+
+	// The last run leaves at 25:00:00 and takes the template's 15 minutes.
+	maxStopTime, err := client.Queries.GetMaxStopTime(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64((25*time.Hour+15*time.Minute)/time.Second), maxStopTime)
+}
@@ -44,10 +44,18 @@ OR REPLACE INTO routes (
     color,
     text_color,
     continuous_pickup,
-    continuous_drop_off
+    continuous_drop_off,
+    sort_order,
+    network_id
 )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateNetwork :exec
+INSERT
+OR REPLACE INTO networks (id, name)
+VALUES
+    (?, ?);
 
 -- name: CreateStop :one
 INSERT
@@ -88,9 +96,15 @@ VALUES
 
 -- name: CreateShape :one
 INSERT
-OR REPLACE INTO shapes (shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled)
+OR REPLACE INTO shapes (shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape)
+VALUES
+    (?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateShapeGeometry :exec
+INSERT
+OR REPLACE INTO shape_geometries (shape_id, geometry_id)
 VALUES
-    (?, ?, ?, ?, ?) RETURNING *;
+    (?, ?);
 
 -- name: CreateStopTime :one
 INSERT
@@ -104,10 +118,11 @@ OR REPLACE INTO stop_times (
     pickup_type,
     drop_off_type,
     shape_dist_traveled,
-    timepoint
+    timepoint,
+    distance_along_shape
 )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: CreateTrip :one
 INSERT
@@ -127,6 +142,122 @@ VALUES
     (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 
+-- name: CreateFrequency :exec
+INSERT
+OR REPLACE INTO frequencies (trip_id, start_time, end_time, headway_secs, exact_times)
+VALUES
+    (?, ?, ?, ?, ?);
+
+-- name: CreateTransfer :exec
+INSERT INTO
+    transfers (
+        from_stop_id,
+        to_stop_id,
+        from_route_id,
+        to_route_id,
+        from_trip_id,
+        to_trip_id,
+        transfer_type,
+        min_transfer_time
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateFareAttribute :exec
+INSERT INTO
+    fare_attributes (
+        fare_id,
+        agency_id,
+        price,
+        currency_type,
+        payment_method,
+        transfers,
+        transfer_duration
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateFareRule :exec
+INSERT INTO
+    fare_rules (
+        fare_id,
+        route_id,
+        origin_id,
+        destination_id,
+        contains_id
+    )
+VALUES
+    (?, ?, ?, ?, ?);
+
+-- name: CreateLocation :exec
+INSERT INTO
+    locations (
+        id,
+        stop_name,
+        stop_desc,
+        geometry,
+        min_lat,
+        min_lon,
+        max_lat,
+        max_lon
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateLocationGroup :exec
+INSERT INTO
+    location_groups (id, location_group_name)
+VALUES
+    (?, ?);
+
+-- name: CreateLocationGroupStop :exec
+INSERT
+OR IGNORE INTO location_group_stops (location_group_id, stop_id)
+VALUES
+    (?, ?);
+
+-- name: CreateBookingRule :exec
+INSERT INTO
+    booking_rules (
+        id,
+        booking_type,
+        prior_notice_duration_min,
+        prior_notice_duration_max,
+        prior_notice_last_day,
+        prior_notice_last_time,
+        prior_notice_start_day,
+        prior_notice_start_time,
+        prior_notice_service_id,
+        message,
+        pickup_message,
+        drop_off_message,
+        phone_number,
+        info_url,
+        booking_url
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateFlexStopTime :exec
+INSERT
+OR REPLACE INTO flex_stop_times (
+    trip_id,
+    stop_sequence,
+    stop_id,
+    location_id,
+    location_group_id,
+    start_pickup_drop_off_window,
+    end_pickup_drop_off_window,
+    pickup_type,
+    drop_off_type,
+    continuous_pickup,
+    continuous_drop_off,
+    pickup_booking_rule_id,
+    drop_off_booking_rule_id
+)
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
 -- name: CreateCalendarDate :one
 INSERT
 OR REPLACE INTO calendar_dates (service_id, date, exception_type)
@@ -145,13 +276,23 @@ SELECT
     color,
     text_color,
     continuous_pickup,
-    continuous_drop_off
+    continuous_drop_off,
+    sort_order,
+    network_id
 FROM
     routes
 ORDER BY
     agency_id,
     id;
 
+-- name: ListNetworks :many
+SELECT
+    *
+FROM
+    networks
+ORDER BY
+    id;
+
 
 -- name: GetRouteIDsForAgency :many
 SELECT
@@ -160,7 +301,11 @@ FROM
     routes r
     JOIN agencies a ON r.agency_id = a.id
 WHERE
-    a.id = ?;
+    a.id = ?
+ORDER BY
+    r.sort_order IS NULL,
+    r.sort_order,
+    r.id;
 
 -- name: GetRouteIDsForStop :many
 SELECT DISTINCT
@@ -265,6 +410,17 @@ FROM
 ORDER BY
     id;
 
+-- name: ListStopsWithoutDirection :many
+-- Returns the stops whose direction has not been computed yet
+SELECT
+    *
+FROM
+    stops
+WHERE
+    direction IS NULL
+ORDER BY
+    id;
+
 -- name: GetRoutesForStop :many
 SELECT DISTINCT
     routes.*
@@ -286,13 +442,31 @@ SELECT
 FROM
     shapes;
 
+-- name: GetShapeGeometries :many
+SELECT
+    *
+FROM
+    shape_geometries;
+
 -- name: GetShapeByID :many
+-- A shape with the same points as another returns the rows stored for that one.
 SELECT
     *
 FROM
     shapes
 WHERE
-    shape_id = ?
+    shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = @shape_id), @shape_id)
+ORDER BY
+    shape_pt_sequence;
+
+-- name: GetShapePoints :many
+SELECT
+    lat,
+    lon
+FROM
+    shapes
+WHERE
+    shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = @shape_id), @shape_id)
 ORDER BY
     shape_pt_sequence;
 
@@ -323,13 +497,14 @@ WHERE
 SELECT DISTINCT s.lat, s.lon, s.shape_pt_sequence
 FROM shapes s
          JOIN (
-    SELECT shape_id
+    SELECT COALESCE(g.geometry_id, trips.shape_id) AS geometry_id
     FROM trips
+             LEFT JOIN shape_geometries g ON g.shape_id = trips.shape_id
     WHERE route_id = @route_id
       AND trip_headsign = @trip_headsign
-      AND shape_id IS NOT NULL
+      AND trips.shape_id IS NOT NULL
     LIMIT 1
-) t ON s.shape_id = t.shape_id
+) t ON s.shape_id = t.geometry_id
 ORDER BY s.shape_pt_sequence;
 
 -- name: GetActiveServiceIDsForDate :many
@@ -449,6 +624,8 @@ WHERE
         OR
         added.service_id IS NOT NULL
     )
+    AND st.arrival_time >= @window_start
+    AND st.arrival_time < @window_end
     AND r.id IN (sqlc.slice('route_ids'))
 ORDER BY
     r.id, st.arrival_time;
@@ -468,17 +645,165 @@ OR REPLACE INTO import_metadata (
     id,
     file_hash,
     import_time,
-    file_source
+    file_source,
+    etag,
+    last_modified
 )
 VALUES
-    (1, ?, ?, ?) RETURNING *;
+    (1, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: CreateImportWarning :exec
+INSERT INTO
+    import_warnings (file, row_num, kind, message, row_content)
+VALUES
+    (?, ?, ?, ?, ?);
+
+-- name: ListImportWarnings :many
+-- Lists parse warnings in feed order, optionally only those of one file or kind.
+-- A negative page_limit returns all of them.
+SELECT
+    *
+FROM
+    import_warnings
+WHERE
+    (sqlc.narg('file') IS NULL OR file = sqlc.narg('file'))
+    AND (sqlc.narg('kind') IS NULL OR kind = sqlc.narg('kind'))
+ORDER BY
+    id
+LIMIT
+    sqlc.arg('page_limit')
+OFFSET
+    sqlc.arg('page_offset');
+
+-- name: CountImportWarnings :many
+SELECT
+    file,
+    kind,
+    COUNT(*) AS count
+FROM
+    import_warnings
+GROUP BY
+    file,
+    kind
+ORDER BY
+    file,
+    kind;
+
+-- name: ClearImportWarnings :exec
+DELETE FROM import_warnings;
+
+-- name: ListQuarantinedRows :many
+-- Lists the rows set aside for referencing rows the feed does not have, in feed order.
+SELECT
+    *
+FROM
+    quarantined_rows
+WHERE
+    (sqlc.narg('file') IS NULL OR file = sqlc.narg('file'))
+ORDER BY
+    id;
+
+-- name: ClearQuarantinedRows :exec
+DELETE FROM quarantined_rows;
+
+-- name: GetFeedInfo :one
+SELECT
+    *
+FROM
+    feed_info
+WHERE
+    id = 1;
+
+-- name: UpsertFeedInfo :exec
+INSERT
+OR REPLACE INTO feed_info (
+    id,
+    feed_publisher_name,
+    feed_publisher_url,
+    feed_lang,
+    default_lang,
+    feed_start_date,
+    feed_end_date,
+    feed_version,
+    feed_contact_email,
+    feed_contact_url
+)
+VALUES
+    (1, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateTranslation :exec
+INSERT INTO
+    translations (
+        table_name,
+        field_name,
+        language,
+        translation,
+        record_id,
+        record_sub_id,
+        field_value
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetTranslations :many
+-- The translations of a table into any of the languages, both those of the
+-- records and those matching a field value
+SELECT
+    *
+FROM
+    translations
+WHERE
+    table_name = ?
+    AND language IN (sqlc.slice('languages'))
+    AND (
+        record_id IN (sqlc.slice('record_ids'))
+        OR field_value IS NOT NULL
+    )
+ORDER BY
+    id;
 
 -- name: ClearStopTimes :exec
 DELETE FROM stop_times;
 
+-- name: ClearFrequencies :exec
+DELETE FROM frequencies;
+
+-- name: ClearTransfers :exec
+DELETE FROM transfers;
+
+-- name: ClearTranslations :exec
+DELETE FROM translations;
+
+-- name: ClearFareRules :exec
+DELETE FROM fare_rules;
+
+-- name: ClearFareAttributes :exec
+DELETE FROM fare_attributes;
+
+-- name: ClearFlexStopTimes :exec
+DELETE FROM flex_stop_times;
+
+-- name: ClearBookingRules :exec
+DELETE FROM booking_rules;
+
+-- name: ClearLocationGroupStops :exec
+DELETE FROM location_group_stops;
+
+-- name: ClearLocationGroups :exec
+DELETE FROM location_groups;
+
+-- name: ClearLocations :exec
+DELETE FROM locations;
+
+-- name: ClearFeedInfo :exec
+DELETE FROM feed_info;
+
 -- name: ClearShapes :exec
 DELETE FROM shapes;
 
+-- name: ClearShapeGeometries :exec
+DELETE FROM shape_geometries;
+
 -- name: ClearTrips :exec
 DELETE FROM trips;
 
@@ -494,6 +819,9 @@ DELETE FROM stops;
 -- name: ClearRoutes :exec
 DELETE FROM routes;
 
+-- name: ClearNetworks :exec
+DELETE FROM networks;
+
 -- name: ClearAgencies :exec
 DELETE FROM agencies;
 
@@ -510,6 +838,19 @@ FROM
 WHERE
     stop_times.stop_id IN (sqlc.slice('stop_ids'));
 
+-- name: GetRoutesForStopsWithRouteTypes :many
+-- Like GetRoutesForStops, limited to routes of the given GTFS route types
+SELECT DISTINCT
+    routes.*,
+    stop_times.stop_id
+FROM
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stop_times.stop_id IN (sqlc.slice('stop_ids'))
+    AND routes.type IN (sqlc.slice('route_types'));
+
 -- name: GetRouteIDsForStops :many
 SELECT DISTINCT
     routes.agency_id || '_' || routes.id AS route_id,
@@ -619,10 +960,12 @@ SELECT
     s.lat,
     s.lon,
     s.shape_pt_sequence,
-    s.shape_dist_traveled
+    s.shape_dist_traveled,
+    s.distance_along_shape
 FROM
-    shapes s
-    JOIN trips t ON t.shape_id = s.shape_id
+    trips t
+    LEFT JOIN shape_geometries g ON g.shape_id = t.shape_id
+    JOIN shapes s ON s.shape_id = COALESCE(g.geometry_id, t.shape_id)
 WHERE
     t.id = ?
 ORDER BY
@@ -640,6 +983,20 @@ JOIN trips t ON st.trip_id = t.id
 JOIN stops s ON st.stop_id = s.id
 WHERE st.stop_id IN (sqlc.slice('stop_ids'));
 
+-- name: GetShapeContextForStopsWithoutDirection :many
+-- Bulk form of GetStopsWithShapeContextByIDs for every stop whose direction
+-- has not been computed yet, with repeated trip patterns collapsed
+SELECT DISTINCT
+    st.stop_id,
+    t.shape_id,
+    s.lat,
+    s.lon,
+    st.shape_dist_traveled
+FROM stop_times st
+JOIN trips t ON st.trip_id = t.id
+JOIN stops s ON st.stop_id = s.id
+WHERE s.direction IS NULL;
+
 -- name: GetTripsByBlockIDOrdered :many
 SELECT
     t.id,
@@ -668,6 +1025,17 @@ FROM
 WHERE
     id = ?;
 
+-- name: GetStopsByCode :many
+-- Stop codes are only unique within an agency, if at all, so several stops may match
+SELECT
+    *
+FROM
+    stops
+WHERE
+    code = ?
+ORDER BY
+    id;
+
 -- name: GetStopsByIDs :many
 SELECT
     *
@@ -698,6 +1066,22 @@ WHERE
 ORDER BY
     id;
 
+-- name: GetBlockStopTimesWithShapeLengths :many
+-- Every stop time of a block's trips on the given service IDs, the trips in
+-- order of first departure, with each trip's shape length and block distance
+-- from block_trip_entry.
+SELECT
+    st.*,
+    t.service_id,
+    bte.shape_length,
+    bte.block_distance
+FROM stop_times st
+         JOIN trips t ON st.trip_id = t.id
+         LEFT JOIN block_trip_entry bte ON bte.trip_id = t.id
+WHERE t.block_id = ?
+  AND t.service_id IN (sqlc.slice('service_ids'))
+ORDER BY MIN(st.departure_time) OVER (PARTITION BY st.trip_id), st.trip_id, st.stop_sequence;
+
 -- name: GetBlockDetails :many
 SELECT
     t.service_id,
@@ -722,6 +1106,45 @@ WHERE
 ORDER BY
     t.id, st.stop_sequence;
 
+-- name: GetBlockDetailsForBlocks :many
+-- Lists the stop times of the trips of several blocks, grouped by block.
+SELECT
+    t.block_id,
+    t.service_id,
+    t.id as trip_id,
+    t.route_id,
+    st.arrival_time,
+    st.departure_time,
+    st.stop_id,
+    st.stop_sequence,
+    st.pickup_type,
+    st.drop_off_type,
+    s.lat,
+    s.lon
+FROM
+    trips t
+        JOIN
+    stop_times st ON t.id = st.trip_id
+        JOIN
+    stops s ON st.stop_id = s.id
+WHERE
+    t.block_id IN (sqlc.slice('block_ids'))
+ORDER BY
+    t.block_id, t.id, st.stop_sequence;
+
+-- name: GetBlockIDsForAgency :many
+SELECT DISTINCT
+    t.block_id
+FROM
+    trips t
+    JOIN routes r ON t.route_id = r.id
+WHERE
+    r.agency_id = ?
+    AND t.block_id IS NOT NULL
+    AND t.block_id != ''
+ORDER BY
+    t.block_id;
+
 -- name: GetStopTimesByStopIDs :many
 SELECT
     *
@@ -766,8 +1189,9 @@ WHERE service_id IN (sqlc.slice('service_ids'));
 
 -- name: GetShapePointsForTrip :many
 SELECT DISTINCT shapes.lat, shapes.lon, shapes.shape_pt_sequence
-FROM shapes
-JOIN trips ON trips.shape_id = shapes.shape_id
+FROM trips
+LEFT JOIN shape_geometries ON shape_geometries.shape_id = trips.shape_id
+JOIN shapes ON shapes.shape_id = COALESCE(shape_geometries.geometry_id, trips.shape_id)
 WHERE trips.id = ?
 ORDER BY shapes.shape_pt_sequence;
 
@@ -801,9 +1225,9 @@ FROM stop_times st
          JOIN trips t ON st.trip_id = t.id
 WHERE st.stop_id = @stop_id
   AND (
-    (st.arrival_time BETWEEN @window_start_nanos AND @window_end_nanos)
+    (st.arrival_time BETWEEN @window_start AND @window_end)
         OR
-    (st.departure_time BETWEEN @window_start_nanos AND @window_end_nanos)
+    (st.departure_time BETWEEN @window_start AND @window_end)
     )
 ORDER BY st.arrival_time;
 
@@ -825,14 +1249,14 @@ WHERE s.id = ?;
 -- name: GetShapePointWindow :many
 SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
 FROM shapes
-WHERE shape_id = ?
+WHERE shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = @shape_id), @shape_id)
   AND shape_pt_sequence BETWEEN ? AND ?
 ORDER BY shape_pt_sequence;
 
 -- name: GetShapePointsWithDistance :many
 SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
 FROM shapes
-WHERE shape_id = ?
+WHERE shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = @shape_id), @shape_id)
 ORDER BY shape_pt_sequence;
 
 
@@ -856,10 +1280,12 @@ INSERT INTO block_trip_entry (
     trip_id,
     block_id,
     service_id,
-    block_trip_sequence
+    block_trip_sequence,
+    shape_length,
+    block_distance
 )
 VALUES
-    (?, ?, ?, ?, ?);
+    (?, ?, ?, ?, ?, ?, ?);
 
 -- name: ClearBlockTripEntries :exec
 DELETE FROM block_trip_entry;
@@ -938,17 +1364,52 @@ WHERE bte.block_trip_index_id IN (sqlc.slice('index_ids'))
 -- Find the currently active trip in a specific block at the given time
 -- Returns the trip whose stop times contain the current time (with late/early windows)
 -- Orders by departure time ASC to get the EARLIEST matching trip (the one currently in progress)
+-- current_time must appear before the service_ids slice: expanding the slice shifts
+-- the position of any numbered parameter that follows it.
 SELECT t.id
 FROM trips t
-JOIN stop_times st ON t.id = st.trip_id
 WHERE t.block_id = sqlc.arg('block_id')
+  AND (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) <= sqlc.arg('current_time')
+  AND (SELECT MAX(st.arrival_time) FROM stop_times st WHERE st.trip_id = t.id) >= sqlc.arg('current_time')
   AND t.service_id IN (sqlc.slice('service_ids'))
-GROUP BY t.id
-HAVING MIN(st.departure_time) <= sqlc.arg('current_time')
-   AND MAX(st.arrival_time) >= sqlc.arg('current_time')
-ORDER BY MIN(st.departure_time) ASC
+ORDER BY (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) ASC
 LIMIT 1;
 
+-- name: GetTripsUnderwayAtTime :many
+-- The trips of the given services that are under way at current_time: past
+-- their first departure and not yet at their last arrival. current_time must
+-- appear before the service_ids slice, as in GetActiveTripInBlockAtTime.
+SELECT t.id, t.route_id
+FROM trips t
+WHERE (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) <= sqlc.arg('current_time')
+  AND (SELECT MAX(st.arrival_time) FROM stop_times st WHERE st.trip_id = t.id) >= sqlc.arg('current_time')
+  AND t.service_id IN (sqlc.slice('service_ids'))
+ORDER BY t.route_id, t.id;
+
+-- name: GetTripServiceSpan :one
+-- The first departure and last arrival of a trip, in seconds since the
+-- start of its service day. Both exceed 24 hours for trips running past midnight.
+SELECT
+    CAST(COALESCE(MIN(departure_time), 0) AS INTEGER) AS first_departure_time,
+    CAST(COALESCE(MAX(arrival_time), 0) AS INTEGER) AS last_arrival_time
+FROM stop_times
+WHERE trip_id = ?;
+
+-- name: GetMaxStopTime :one
+-- The latest arrival or departure time in the feed, counting the last run of
+-- frequency-based trips. It bounds how many earlier service days can still
+-- have trips running at a given moment.
+SELECT CAST(COALESCE(MAX(latest), 0) AS INTEGER) AS max_stop_time
+FROM (
+    SELECT MAX(arrival_time, departure_time) AS latest
+    FROM stop_times
+    UNION ALL
+    SELECT f.end_time + MAX(st.arrival_time) - MIN(st.departure_time)
+    FROM frequencies f
+    JOIN stop_times st ON st.trip_id = f.trip_id
+    GROUP BY f.trip_id, f.start_time
+);
+
 -- name: GetTripsInBlock :many
 -- Get all trip IDs in a specific block for the given service IDs
 SELECT id
@@ -966,16 +1427,103 @@ WHERE bte.block_trip_index_id IN (sqlc.slice('index_ids'))
 
 
 -- name: GetShapePointsByIDs :many
-SELECT shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
-FROM shapes
-WHERE shape_id IN (sqlc.slice('shape_ids'))
-ORDER BY shape_id, shape_pt_sequence;
+-- Only finds shapes mapped in shape_geometries, as every import maps them.
+SELECT g.shape_id, s.lat, s.lon, s.shape_pt_sequence, s.shape_dist_traveled
+FROM shape_geometries g
+JOIN shapes s ON s.shape_id = g.geometry_id
+WHERE g.shape_id IN (sqlc.slice('shape_ids'))
+ORDER BY g.shape_id, s.shape_pt_sequence;
 
 -- name: GetStopTimesForTripIDs :many
 SELECT * FROM stop_times
 WHERE trip_id IN (sqlc.slice('trip_ids'))
 ORDER BY trip_id, stop_sequence;
 
+-- name: GetFrequenciesForTrip :many
+SELECT * FROM frequencies
+WHERE trip_id = ?
+ORDER BY start_time;
+
+-- name: GetFrequencyStopTimesForStop :many
+SELECT
+    st.*,
+    t.route_id,
+    t.service_id,
+    t.trip_headsign,
+    t.block_id,
+    f.start_time,
+    f.end_time,
+    f.headway_secs,
+    f.exact_times
+FROM stop_times st
+         JOIN trips t ON st.trip_id = t.id
+         JOIN frequencies f ON f.trip_id = st.trip_id
+WHERE st.stop_id = ?
+ORDER BY st.trip_id, f.start_time;
+
+-- name: GetTransfersFromStop :many
+SELECT * FROM transfers
+WHERE from_stop_id = ?
+ORDER BY to_stop_id, id;
+
+-- name: GetFaresForRoute :many
+-- Returns each fare that can apply to the route with its rules for the route,
+-- cheapest first. Rules without a route apply to every route, and so does a
+-- fare without rules.
+SELECT
+    fa.fare_id,
+    fa.agency_id,
+    fa.price,
+    fa.currency_type,
+    fa.payment_method,
+    fa.transfers,
+    fa.transfer_duration,
+    fr.origin_id,
+    fr.destination_id,
+    fr.contains_id
+FROM fare_attributes fa
+LEFT JOIN fare_rules fr ON fr.fare_id = fa.fare_id
+WHERE fr.id IS NULL
+   OR fr.route_id IS NULL
+   OR fr.route_id = ?
+ORDER BY fa.price, fa.fare_id, fr.id;
+
+-- name: GetFlexStopTimesForTrip :many
+SELECT * FROM flex_stop_times
+WHERE trip_id = ?
+ORDER BY stop_sequence;
+
+-- name: GetLocation :one
+SELECT * FROM locations
+WHERE id = ?;
+
+-- name: GetLocationsContainingPoint :many
+-- Bounding-box candidates only; callers test the geometry itself.
+SELECT * FROM locations
+WHERE min_lat <= sqlc.arg('lat') AND max_lat >= sqlc.arg('lat')
+  AND min_lon <= sqlc.arg('lon') AND max_lon >= sqlc.arg('lon')
+ORDER BY id;
+
+-- name: GetLocationGroup :one
+SELECT * FROM location_groups
+WHERE id = ?;
+
+-- name: GetLocationGroupsForStop :many
+SELECT location_groups.id, location_groups.location_group_name
+FROM location_groups
+JOIN location_group_stops ON location_group_stops.location_group_id = location_groups.id
+WHERE location_group_stops.stop_id = ?
+ORDER BY location_groups.id;
+
+-- name: GetLocationGroupStopIDs :many
+SELECT stop_id FROM location_group_stops
+WHERE location_group_id = ?
+ORDER BY stop_id;
+
+-- name: GetBookingRule :one
+SELECT * FROM booking_rules
+WHERE id = ?;
+
 -- name: GetTripsByBlockIDs :many
 SELECT t.*
 FROM trips t
@@ -1025,3 +1573,118 @@ SELECT * FROM problem_reports_stop
 WHERE stop_id = ?
 ORDER BY created_at DESC;
 
+
+-- Vehicle Position History Queries
+
+-- name: CreateVehiclePositionHistory :execrows
+INSERT
+OR IGNORE INTO vehicle_positions_history (
+    feed_id,
+    vehicle_id,
+    trip_id,
+    route_id,
+    lat,
+    lon,
+    bearing,
+    speed,
+    schedule_deviation,
+    stop_id,
+    occupancy_status,
+    observed_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetRecentVehiclePositionsForTrip :many
+SELECT * FROM vehicle_positions_history
+WHERE trip_id = ?
+ORDER BY observed_at DESC
+LIMIT ?;
+
+-- name: GetVehiclePositionsInWindow :many
+-- Lists the located observations of a vehicle between two times, oldest first.
+SELECT * FROM vehicle_positions_history
+WHERE vehicle_id = sqlc.arg('vehicle_id')
+    AND observed_at BETWEEN sqlc.arg('from_time') AND sqlc.arg('to_time')
+    AND lat IS NOT NULL
+    AND lon IS NOT NULL
+ORDER BY observed_at;
+
+-- name: DeleteVehiclePositionsHistoryBefore :execrows
+DELETE FROM vehicle_positions_history
+WHERE observed_at < ?;
+
+-- name: IncrementHistoricalOccupancy :exec
+INSERT INTO historical_occupancy (
+    trip_id,
+    stop_id,
+    day_of_week,
+    occupancy_status,
+    sample_count
+) VALUES (?, ?, ?, ?, 1)
+ON CONFLICT (trip_id, stop_id, day_of_week, occupancy_status)
+DO UPDATE SET sample_count = sample_count + 1;
+
+-- name: GetHistoricalOccupancyForTrip :many
+SELECT stop_id, occupancy_status, sample_count
+FROM historical_occupancy
+WHERE trip_id = ?
+  AND day_of_week = ?;
+
+-- name: UpsertScheduleDeviationSample :exec
+INSERT INTO schedule_deviation_samples (
+    trip_id,
+    stop_id,
+    service_date,
+    deviation,
+    observed_at
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (trip_id, stop_id, service_date)
+DO UPDATE SET deviation = excluded.deviation, observed_at = excluded.observed_at;
+
+-- name: DeleteScheduleDeviationSamplesBefore :execrows
+DELETE FROM schedule_deviation_samples
+WHERE observed_at < ?;
+
+-- name: GetOnTimePerformanceByRoute :many
+-- Counts the deviation samples of an agency's routes observed between two times.
+-- Samples more than early_threshold seconds early or late_threshold seconds late
+-- are counted as early or late.
+SELECT
+    t.route_id,
+    COUNT(*) AS sample_count,
+    CAST(SUM(s.deviation < -sqlc.arg('early_threshold')) AS INTEGER) AS early_count,
+    CAST(SUM(s.deviation > sqlc.arg('late_threshold')) AS INTEGER) AS late_count,
+    CAST(AVG(s.deviation) AS REAL) AS mean_deviation
+FROM schedule_deviation_samples s
+JOIN trips t ON t.id = s.trip_id
+JOIN routes r ON r.id = t.route_id
+WHERE r.agency_id = sqlc.arg('agency_id')
+    AND s.observed_at BETWEEN sqlc.arg('from_time') AND sqlc.arg('to_time')
+GROUP BY t.route_id
+ORDER BY t.route_id;
+
+-- name: GetTripsServingStopsInOrder :many
+-- Lists the trips that depart the origin stop between two times and later
+-- call at the destination stop, letting riders board at the origin and
+-- alight at the destination. Frequency-based trips are left out: their stop
+-- times only describe a template.
+SELECT
+    o.trip_id,
+    o.stop_sequence AS origin_stop_sequence,
+    o.arrival_time AS origin_arrival_time,
+    o.departure_time AS origin_departure_time,
+    d.stop_sequence AS destination_stop_sequence,
+    d.arrival_time AS destination_arrival_time,
+    d.departure_time AS destination_departure_time,
+    t.route_id,
+    t.service_id,
+    t.trip_headsign
+FROM stop_times o
+JOIN stop_times d ON d.trip_id = o.trip_id AND d.stop_sequence > o.stop_sequence
+JOIN trips t ON t.id = o.trip_id
+WHERE o.stop_id = sqlc.arg('origin_stop_id')
+    AND d.stop_id = sqlc.arg('destination_stop_id')
+    AND o.departure_time BETWEEN sqlc.arg('window_start') AND sqlc.arg('window_end')
+    AND COALESCE(o.pickup_type, 0) != 1
+    AND COALESCE(d.drop_off_type, 0) != 1
+    AND NOT EXISTS (SELECT 1 FROM frequencies f WHERE f.trip_id = o.trip_id)
+ORDER BY o.departure_time, o.trip_id, d.stop_sequence;
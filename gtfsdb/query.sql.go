@@ -38,6 +38,15 @@ func (q *Queries) ClearBlockTripIndices(ctx context.Context) error {
 	return err
 }
 
+const clearBookingRules = `-- name: ClearBookingRules :exec
+DELETE FROM booking_rules
+`
+
+func (q *Queries) ClearBookingRules(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearBookingRulesStmt, clearBookingRules)
+	return err
+}
+
 const clearCalendar = `-- name: ClearCalendar :exec
 DELETE FROM calendar
 `
@@ -56,6 +65,105 @@ func (q *Queries) ClearCalendarDates(ctx context.Context) error {
 	return err
 }
 
+const clearFareAttributes = `-- name: ClearFareAttributes :exec
+DELETE FROM fare_attributes
+`
+
+func (q *Queries) ClearFareAttributes(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearFareAttributesStmt, clearFareAttributes)
+	return err
+}
+
+const clearFareRules = `-- name: ClearFareRules :exec
+DELETE FROM fare_rules
+`
+
+func (q *Queries) ClearFareRules(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearFareRulesStmt, clearFareRules)
+	return err
+}
+
+const clearFeedInfo = `-- name: ClearFeedInfo :exec
+DELETE FROM feed_info
+`
+
+func (q *Queries) ClearFeedInfo(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearFeedInfoStmt, clearFeedInfo)
+	return err
+}
+
+const clearFlexStopTimes = `-- name: ClearFlexStopTimes :exec
+DELETE FROM flex_stop_times
+`
+
+func (q *Queries) ClearFlexStopTimes(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearFlexStopTimesStmt, clearFlexStopTimes)
+	return err
+}
+
+const clearFrequencies = `-- name: ClearFrequencies :exec
+DELETE FROM frequencies
+`
+
+func (q *Queries) ClearFrequencies(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearFrequenciesStmt, clearFrequencies)
+	return err
+}
+
+const clearImportWarnings = `-- name: ClearImportWarnings :exec
+DELETE FROM import_warnings
+`
+
+func (q *Queries) ClearImportWarnings(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearImportWarningsStmt, clearImportWarnings)
+	return err
+}
+
+const clearLocationGroupStops = `-- name: ClearLocationGroupStops :exec
+DELETE FROM location_group_stops
+`
+
+func (q *Queries) ClearLocationGroupStops(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearLocationGroupStopsStmt, clearLocationGroupStops)
+	return err
+}
+
+const clearLocationGroups = `-- name: ClearLocationGroups :exec
+DELETE FROM location_groups
+`
+
+func (q *Queries) ClearLocationGroups(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearLocationGroupsStmt, clearLocationGroups)
+	return err
+}
+
+const clearLocations = `-- name: ClearLocations :exec
+DELETE FROM locations
+`
+
+func (q *Queries) ClearLocations(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearLocationsStmt, clearLocations)
+	return err
+}
+
+const clearNetworks = `-- name: ClearNetworks :exec
+DELETE FROM networks
+`
+
+func (q *Queries) ClearNetworks(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearNetworksStmt, clearNetworks)
+	return err
+}
+
+const clearQuarantinedRows = `-- name: ClearQuarantinedRows :exec
+DELETE FROM quarantined_rows
+`
+
+func (q *Queries) ClearQuarantinedRows(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearQuarantinedRowsStmt, clearQuarantinedRows)
+	return err
+}
+
 const clearRoutes = `-- name: ClearRoutes :exec
 DELETE FROM routes
 `
@@ -65,6 +173,15 @@ func (q *Queries) ClearRoutes(ctx context.Context) error {
 	return err
 }
 
+const clearShapeGeometries = `-- name: ClearShapeGeometries :exec
+DELETE FROM shape_geometries
+`
+
+func (q *Queries) ClearShapeGeometries(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearShapeGeometriesStmt, clearShapeGeometries)
+	return err
+}
+
 const clearShapes = `-- name: ClearShapes :exec
 DELETE FROM shapes
 `
@@ -92,6 +209,24 @@ func (q *Queries) ClearStops(ctx context.Context) error {
 	return err
 }
 
+const clearTransfers = `-- name: ClearTransfers :exec
+DELETE FROM transfers
+`
+
+func (q *Queries) ClearTransfers(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearTransfersStmt, clearTransfers)
+	return err
+}
+
+const clearTranslations = `-- name: ClearTranslations :exec
+DELETE FROM translations
+`
+
+func (q *Queries) ClearTranslations(ctx context.Context) error {
+	_, err := q.exec(ctx, q.clearTranslationsStmt, clearTranslations)
+	return err
+}
+
 const clearTrips = `-- name: ClearTrips :exec
 DELETE FROM trips
 `
@@ -101,6 +236,50 @@ func (q *Queries) ClearTrips(ctx context.Context) error {
 	return err
 }
 
+const countImportWarnings = `-- name: CountImportWarnings :many
+SELECT
+    file,
+    kind,
+    COUNT(*) AS count
+FROM
+    import_warnings
+GROUP BY
+    file,
+    kind
+ORDER BY
+    file,
+    kind
+`
+
+type CountImportWarningsRow struct {
+	File  string
+	Kind  string
+	Count int64
+}
+
+func (q *Queries) CountImportWarnings(ctx context.Context) ([]CountImportWarningsRow, error) {
+	rows, err := q.query(ctx, q.countImportWarningsStmt, countImportWarnings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountImportWarningsRow
+	for rows.Next() {
+		var i CountImportWarningsRow
+		if err := rows.Scan(&i.File, &i.Kind, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createAgency = `-- name: CreateAgency :one
 INSERT
 OR REPLACE INTO agencies (
@@ -159,10 +338,12 @@ INSERT INTO block_trip_entry (
     trip_id,
     block_id,
     service_id,
-    block_trip_sequence
+    block_trip_sequence,
+    shape_length,
+    block_distance
 )
 VALUES
-    (?, ?, ?, ?, ?)
+    (?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateBlockTripEntryParams struct {
@@ -171,6 +352,8 @@ type CreateBlockTripEntryParams struct {
 	BlockID           sql.NullString
 	ServiceID         string
 	BlockTripSequence int64
+	ShapeLength       sql.NullFloat64
+	BlockDistance     sql.NullFloat64
 }
 
 func (q *Queries) CreateBlockTripEntry(ctx context.Context, arg CreateBlockTripEntryParams) error {
@@ -180,6 +363,8 @@ func (q *Queries) CreateBlockTripEntry(ctx context.Context, arg CreateBlockTripE
 		arg.BlockID,
 		arg.ServiceID,
 		arg.BlockTripSequence,
+		arg.ShapeLength,
+		arg.BlockDistance,
 	)
 	return err
 }
@@ -217,6 +402,68 @@ func (q *Queries) CreateBlockTripIndex(ctx context.Context, arg CreateBlockTripI
 	return id, err
 }
 
+const createBookingRule = `-- name: CreateBookingRule :exec
+INSERT INTO
+    booking_rules (
+        id,
+        booking_type,
+        prior_notice_duration_min,
+        prior_notice_duration_max,
+        prior_notice_last_day,
+        prior_notice_last_time,
+        prior_notice_start_day,
+        prior_notice_start_time,
+        prior_notice_service_id,
+        message,
+        pickup_message,
+        drop_off_message,
+        phone_number,
+        info_url,
+        booking_url
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateBookingRuleParams struct {
+	ID                     string
+	BookingType            int64
+	PriorNoticeDurationMin sql.NullInt64
+	PriorNoticeDurationMax sql.NullInt64
+	PriorNoticeLastDay     sql.NullInt64
+	PriorNoticeLastTime    sql.NullInt64
+	PriorNoticeStartDay    sql.NullInt64
+	PriorNoticeStartTime   sql.NullInt64
+	PriorNoticeServiceID   sql.NullString
+	Message                sql.NullString
+	PickupMessage          sql.NullString
+	DropOffMessage         sql.NullString
+	PhoneNumber            sql.NullString
+	InfoUrl                sql.NullString
+	BookingUrl             sql.NullString
+}
+
+func (q *Queries) CreateBookingRule(ctx context.Context, arg CreateBookingRuleParams) error {
+	_, err := q.exec(ctx, q.createBookingRuleStmt, createBookingRule,
+		arg.ID,
+		arg.BookingType,
+		arg.PriorNoticeDurationMin,
+		arg.PriorNoticeDurationMax,
+		arg.PriorNoticeLastDay,
+		arg.PriorNoticeLastTime,
+		arg.PriorNoticeStartDay,
+		arg.PriorNoticeStartTime,
+		arg.PriorNoticeServiceID,
+		arg.Message,
+		arg.PickupMessage,
+		arg.DropOffMessage,
+		arg.PhoneNumber,
+		arg.InfoUrl,
+		arg.BookingUrl,
+	)
+	return err
+}
+
 const createCalendar = `-- name: CreateCalendar :one
 INSERT
 OR REPLACE INTO calendar (
@@ -297,6 +544,276 @@ func (q *Queries) CreateCalendarDate(ctx context.Context, arg CreateCalendarDate
 	return i, err
 }
 
+const createFareAttribute = `-- name: CreateFareAttribute :exec
+INSERT INTO
+    fare_attributes (
+        fare_id,
+        agency_id,
+        price,
+        currency_type,
+        payment_method,
+        transfers,
+        transfer_duration
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateFareAttributeParams struct {
+	FareID           string
+	AgencyID         sql.NullString
+	Price            float64
+	CurrencyType     string
+	PaymentMethod    int64
+	Transfers        sql.NullInt64
+	TransferDuration sql.NullInt64
+}
+
+func (q *Queries) CreateFareAttribute(ctx context.Context, arg CreateFareAttributeParams) error {
+	_, err := q.exec(ctx, q.createFareAttributeStmt, createFareAttribute,
+		arg.FareID,
+		arg.AgencyID,
+		arg.Price,
+		arg.CurrencyType,
+		arg.PaymentMethod,
+		arg.Transfers,
+		arg.TransferDuration,
+	)
+	return err
+}
+
+const createFareRule = `-- name: CreateFareRule :exec
+INSERT INTO
+    fare_rules (
+        fare_id,
+        route_id,
+        origin_id,
+        destination_id,
+        contains_id
+    )
+VALUES
+    (?, ?, ?, ?, ?)
+`
+
+type CreateFareRuleParams struct {
+	FareID        string
+	RouteID       sql.NullString
+	OriginID      sql.NullString
+	DestinationID sql.NullString
+	ContainsID    sql.NullString
+}
+
+func (q *Queries) CreateFareRule(ctx context.Context, arg CreateFareRuleParams) error {
+	_, err := q.exec(ctx, q.createFareRuleStmt, createFareRule,
+		arg.FareID,
+		arg.RouteID,
+		arg.OriginID,
+		arg.DestinationID,
+		arg.ContainsID,
+	)
+	return err
+}
+
+const createFlexStopTime = `-- name: CreateFlexStopTime :exec
+INSERT
+OR REPLACE INTO flex_stop_times (
+    trip_id,
+    stop_sequence,
+    stop_id,
+    location_id,
+    location_group_id,
+    start_pickup_drop_off_window,
+    end_pickup_drop_off_window,
+    pickup_type,
+    drop_off_type,
+    continuous_pickup,
+    continuous_drop_off,
+    pickup_booking_rule_id,
+    drop_off_booking_rule_id
+)
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateFlexStopTimeParams struct {
+	TripID                   string
+	StopSequence             int64
+	StopID                   sql.NullString
+	LocationID               sql.NullString
+	LocationGroupID          sql.NullString
+	StartPickupDropOffWindow sql.NullInt64
+	EndPickupDropOffWindow   sql.NullInt64
+	PickupType               int64
+	DropOffType              int64
+	ContinuousPickup         int64
+	ContinuousDropOff        int64
+	PickupBookingRuleID      sql.NullString
+	DropOffBookingRuleID     sql.NullString
+}
+
+func (q *Queries) CreateFlexStopTime(ctx context.Context, arg CreateFlexStopTimeParams) error {
+	_, err := q.exec(ctx, q.createFlexStopTimeStmt, createFlexStopTime,
+		arg.TripID,
+		arg.StopSequence,
+		arg.StopID,
+		arg.LocationID,
+		arg.LocationGroupID,
+		arg.StartPickupDropOffWindow,
+		arg.EndPickupDropOffWindow,
+		arg.PickupType,
+		arg.DropOffType,
+		arg.ContinuousPickup,
+		arg.ContinuousDropOff,
+		arg.PickupBookingRuleID,
+		arg.DropOffBookingRuleID,
+	)
+	return err
+}
+
+const createFrequency = `-- name: CreateFrequency :exec
+INSERT
+OR REPLACE INTO frequencies (trip_id, start_time, end_time, headway_secs, exact_times)
+VALUES
+    (?, ?, ?, ?, ?)
+`
+
+type CreateFrequencyParams struct {
+	TripID      string
+	StartTime   int64
+	EndTime     int64
+	HeadwaySecs int64
+	ExactTimes  int64
+}
+
+func (q *Queries) CreateFrequency(ctx context.Context, arg CreateFrequencyParams) error {
+	_, err := q.exec(ctx, q.createFrequencyStmt, createFrequency,
+		arg.TripID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.HeadwaySecs,
+		arg.ExactTimes,
+	)
+	return err
+}
+
+const createImportWarning = `-- name: CreateImportWarning :exec
+INSERT INTO
+    import_warnings (file, row_num, kind, message, row_content)
+VALUES
+    (?, ?, ?, ?, ?)
+`
+
+type CreateImportWarningParams struct {
+	File       string
+	RowNum     int64
+	Kind       string
+	Message    string
+	RowContent string
+}
+
+func (q *Queries) CreateImportWarning(ctx context.Context, arg CreateImportWarningParams) error {
+	_, err := q.exec(ctx, q.createImportWarningStmt, createImportWarning,
+		arg.File,
+		arg.RowNum,
+		arg.Kind,
+		arg.Message,
+		arg.RowContent,
+	)
+	return err
+}
+
+const createLocation = `-- name: CreateLocation :exec
+INSERT INTO
+    locations (
+        id,
+        stop_name,
+        stop_desc,
+        geometry,
+        min_lat,
+        min_lon,
+        max_lat,
+        max_lon
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateLocationParams struct {
+	ID       string
+	StopName sql.NullString
+	StopDesc sql.NullString
+	Geometry string
+	MinLat   float64
+	MinLon   float64
+	MaxLat   float64
+	MaxLon   float64
+}
+
+func (q *Queries) CreateLocation(ctx context.Context, arg CreateLocationParams) error {
+	_, err := q.exec(ctx, q.createLocationStmt, createLocation,
+		arg.ID,
+		arg.StopName,
+		arg.StopDesc,
+		arg.Geometry,
+		arg.MinLat,
+		arg.MinLon,
+		arg.MaxLat,
+		arg.MaxLon,
+	)
+	return err
+}
+
+const createLocationGroup = `-- name: CreateLocationGroup :exec
+INSERT INTO
+    location_groups (id, location_group_name)
+VALUES
+    (?, ?)
+`
+
+type CreateLocationGroupParams struct {
+	ID                string
+	LocationGroupName sql.NullString
+}
+
+func (q *Queries) CreateLocationGroup(ctx context.Context, arg CreateLocationGroupParams) error {
+	_, err := q.exec(ctx, q.createLocationGroupStmt, createLocationGroup, arg.ID, arg.LocationGroupName)
+	return err
+}
+
+const createLocationGroupStop = `-- name: CreateLocationGroupStop :exec
+INSERT
+OR IGNORE INTO location_group_stops (location_group_id, stop_id)
+VALUES
+    (?, ?)
+`
+
+type CreateLocationGroupStopParams struct {
+	LocationGroupID string
+	StopID          string
+}
+
+func (q *Queries) CreateLocationGroupStop(ctx context.Context, arg CreateLocationGroupStopParams) error {
+	_, err := q.exec(ctx, q.createLocationGroupStopStmt, createLocationGroupStop, arg.LocationGroupID, arg.StopID)
+	return err
+}
+
+const createNetwork = `-- name: CreateNetwork :exec
+INSERT
+OR REPLACE INTO networks (id, name)
+VALUES
+    (?, ?)
+`
+
+type CreateNetworkParams struct {
+	ID   string
+	Name sql.NullString
+}
+
+func (q *Queries) CreateNetwork(ctx context.Context, arg CreateNetworkParams) error {
+	_, err := q.exec(ctx, q.createNetworkStmt, createNetwork, arg.ID, arg.Name)
+	return err
+}
+
 const createProblemReportStop = `-- name: CreateProblemReportStop :exec
 INSERT INTO problem_reports_stop (
     stop_id,
@@ -403,10 +920,12 @@ OR REPLACE INTO routes (
     color,
     text_color,
     continuous_pickup,
-    continuous_drop_off
+    continuous_drop_off,
+    sort_order,
+    network_id
 )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off, sort_order, network_id
 `
 
 type CreateRouteParams struct {
@@ -421,6 +940,8 @@ type CreateRouteParams struct {
 	TextColor         sql.NullString
 	ContinuousPickup  sql.NullInt64
 	ContinuousDropOff sql.NullInt64
+	SortOrder         sql.NullInt64
+	NetworkID         sql.NullString
 }
 
 func (q *Queries) CreateRoute(ctx context.Context, arg CreateRouteParams) (Route, error) {
@@ -436,6 +957,8 @@ func (q *Queries) CreateRoute(ctx context.Context, arg CreateRouteParams) (Route
 		arg.TextColor,
 		arg.ContinuousPickup,
 		arg.ContinuousDropOff,
+		arg.SortOrder,
+		arg.NetworkID,
 	)
 	var i Route
 	err := row.Scan(
@@ -450,23 +973,26 @@ func (q *Queries) CreateRoute(ctx context.Context, arg CreateRouteParams) (Route
 		&i.TextColor,
 		&i.ContinuousPickup,
 		&i.ContinuousDropOff,
+		&i.SortOrder,
+		&i.NetworkID,
 	)
 	return i, err
 }
 
 const createShape = `-- name: CreateShape :one
 INSERT
-OR REPLACE INTO shapes (shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled)
+OR REPLACE INTO shapes (shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape)
 VALUES
-    (?, ?, ?, ?, ?) RETURNING id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
+    (?, ?, ?, ?, ?, ?) RETURNING id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape
 `
 
 type CreateShapeParams struct {
-	ShapeID           string
-	Lat               float64
-	Lon               float64
-	ShapePtSequence   int64
-	ShapeDistTraveled sql.NullFloat64
+	ShapeID            string
+	Lat                float64
+	Lon                float64
+	ShapePtSequence    int64
+	ShapeDistTraveled  sql.NullFloat64
+	DistanceAlongShape sql.NullFloat64
 }
 
 func (q *Queries) CreateShape(ctx context.Context, arg CreateShapeParams) (Shape, error) {
@@ -476,6 +1002,7 @@ func (q *Queries) CreateShape(ctx context.Context, arg CreateShapeParams) (Shape
 		arg.Lon,
 		arg.ShapePtSequence,
 		arg.ShapeDistTraveled,
+		arg.DistanceAlongShape,
 	)
 	var i Shape
 	err := row.Scan(
@@ -485,10 +1012,28 @@ func (q *Queries) CreateShape(ctx context.Context, arg CreateShapeParams) (Shape
 		&i.Lon,
 		&i.ShapePtSequence,
 		&i.ShapeDistTraveled,
+		&i.DistanceAlongShape,
 	)
 	return i, err
 }
 
+const createShapeGeometry = `-- name: CreateShapeGeometry :exec
+INSERT
+OR REPLACE INTO shape_geometries (shape_id, geometry_id)
+VALUES
+    (?, ?)
+`
+
+type CreateShapeGeometryParams struct {
+	ShapeID    string
+	GeometryID string
+}
+
+func (q *Queries) CreateShapeGeometry(ctx context.Context, arg CreateShapeGeometryParams) error {
+	_, err := q.exec(ctx, q.createShapeGeometryStmt, createShapeGeometry, arg.ShapeID, arg.GeometryID)
+	return err
+}
+
 const createStop = `-- name: CreateStop :one
 INSERT
 OR REPLACE INTO stops (
@@ -574,23 +1119,25 @@ OR REPLACE INTO stop_times (
     pickup_type,
     drop_off_type,
     shape_dist_traveled,
-    timepoint
+    timepoint,
+    distance_along_shape
 )
 VALUES
-    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint
+    (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint, distance_along_shape
 `
 
 type CreateStopTimeParams struct {
-	TripID            string
-	ArrivalTime       int64
-	DepartureTime     int64
-	StopID            string
-	StopSequence      int64
-	StopHeadsign      sql.NullString
-	PickupType        sql.NullInt64
-	DropOffType       sql.NullInt64
-	ShapeDistTraveled sql.NullFloat64
-	Timepoint         sql.NullInt64
+	TripID             string
+	ArrivalTime        int64
+	DepartureTime      int64
+	StopID             string
+	StopSequence       int64
+	StopHeadsign       sql.NullString
+	PickupType         sql.NullInt64
+	DropOffType        sql.NullInt64
+	ShapeDistTraveled  sql.NullFloat64
+	Timepoint          sql.NullInt64
+	DistanceAlongShape sql.NullFloat64
 }
 
 func (q *Queries) CreateStopTime(ctx context.Context, arg CreateStopTimeParams) (StopTime, error) {
@@ -605,6 +1152,7 @@ func (q *Queries) CreateStopTime(ctx context.Context, arg CreateStopTimeParams)
 		arg.DropOffType,
 		arg.ShapeDistTraveled,
 		arg.Timepoint,
+		arg.DistanceAlongShape,
 	)
 	var i StopTime
 	err := row.Scan(
@@ -618,10 +1166,90 @@ func (q *Queries) CreateStopTime(ctx context.Context, arg CreateStopTimeParams)
 		&i.DropOffType,
 		&i.ShapeDistTraveled,
 		&i.Timepoint,
+		&i.DistanceAlongShape,
 	)
 	return i, err
 }
 
+const createTransfer = `-- name: CreateTransfer :exec
+INSERT INTO
+    transfers (
+        from_stop_id,
+        to_stop_id,
+        from_route_id,
+        to_route_id,
+        from_trip_id,
+        to_trip_id,
+        transfer_type,
+        min_transfer_time
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateTransferParams struct {
+	FromStopID      string
+	ToStopID        string
+	FromRouteID     sql.NullString
+	ToRouteID       sql.NullString
+	FromTripID      sql.NullString
+	ToTripID        sql.NullString
+	TransferType    int64
+	MinTransferTime sql.NullInt64
+}
+
+func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) error {
+	_, err := q.exec(ctx, q.createTransferStmt, createTransfer,
+		arg.FromStopID,
+		arg.ToStopID,
+		arg.FromRouteID,
+		arg.ToRouteID,
+		arg.FromTripID,
+		arg.ToTripID,
+		arg.TransferType,
+		arg.MinTransferTime,
+	)
+	return err
+}
+
+const createTranslation = `-- name: CreateTranslation :exec
+INSERT INTO
+    translations (
+        table_name,
+        field_name,
+        language,
+        translation,
+        record_id,
+        record_sub_id,
+        field_value
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateTranslationParams struct {
+	TableName   string
+	FieldName   string
+	Language    string
+	Translation string
+	RecordID    sql.NullString
+	RecordSubID sql.NullString
+	FieldValue  sql.NullString
+}
+
+func (q *Queries) CreateTranslation(ctx context.Context, arg CreateTranslationParams) error {
+	_, err := q.exec(ctx, q.createTranslationStmt, createTranslation,
+		arg.TableName,
+		arg.FieldName,
+		arg.Language,
+		arg.Translation,
+		arg.RecordID,
+		arg.RecordSubID,
+		arg.FieldValue,
+	)
+	return err
+}
+
 const createTrip = `-- name: CreateTrip :one
 INSERT
 OR REPLACE INTO trips (
@@ -682,11 +1310,91 @@ func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (Trip, e
 	return i, err
 }
 
-const getActiveRouteIDsForStopsOnDate = `-- name: GetActiveRouteIDsForStopsOnDate :many
-SELECT DISTINCT
-    routes.agency_id || '_' || routes.id AS route_id,
-    stop_times.stop_id
-FROM
+const createVehiclePositionHistory = `-- name: CreateVehiclePositionHistory :execrows
+INSERT
+OR IGNORE INTO vehicle_positions_history (
+    feed_id,
+    vehicle_id,
+    trip_id,
+    route_id,
+    lat,
+    lon,
+    bearing,
+    speed,
+    schedule_deviation,
+    stop_id,
+    occupancy_status,
+    observed_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateVehiclePositionHistoryParams struct {
+	FeedID            string
+	VehicleID         string
+	TripID            sql.NullString
+	RouteID           sql.NullString
+	Lat               sql.NullFloat64
+	Lon               sql.NullFloat64
+	Bearing           sql.NullFloat64
+	Speed             sql.NullFloat64
+	ScheduleDeviation sql.NullInt64
+	StopID            sql.NullString
+	OccupancyStatus   sql.NullInt64
+	ObservedAt        int64
+}
+
+func (q *Queries) CreateVehiclePositionHistory(ctx context.Context, arg CreateVehiclePositionHistoryParams) (int64, error) {
+	result, err := q.exec(ctx, q.createVehiclePositionHistoryStmt, createVehiclePositionHistory,
+		arg.FeedID,
+		arg.VehicleID,
+		arg.TripID,
+		arg.RouteID,
+		arg.Lat,
+		arg.Lon,
+		arg.Bearing,
+		arg.Speed,
+		arg.ScheduleDeviation,
+		arg.StopID,
+		arg.OccupancyStatus,
+		arg.ObservedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteScheduleDeviationSamplesBefore = `-- name: DeleteScheduleDeviationSamplesBefore :execrows
+DELETE FROM schedule_deviation_samples
+WHERE observed_at < ?
+`
+
+func (q *Queries) DeleteScheduleDeviationSamplesBefore(ctx context.Context, observedAt int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteScheduleDeviationSamplesBeforeStmt, deleteScheduleDeviationSamplesBefore, observedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVehiclePositionsHistoryBefore = `-- name: DeleteVehiclePositionsHistoryBefore :execrows
+DELETE FROM vehicle_positions_history
+WHERE observed_at < ?
+`
+
+func (q *Queries) DeleteVehiclePositionsHistoryBefore(ctx context.Context, observedAt int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteVehiclePositionsHistoryBeforeStmt, deleteVehiclePositionsHistoryBefore, observedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getActiveRouteIDsForStopsOnDate = `-- name: GetActiveRouteIDsForStopsOnDate :many
+SELECT DISTINCT
+    routes.agency_id || '_' || routes.id AS route_id,
+    stop_times.stop_id
+FROM
     stop_times
     JOIN trips ON stop_times.trip_id = trips.id
     JOIN routes ON trips.route_id = routes.id
@@ -920,29 +1628,30 @@ func (q *Queries) GetActiveTripForRouteAtTime(ctx context.Context, arg GetActive
 const getActiveTripInBlockAtTime = `-- name: GetActiveTripInBlockAtTime :one
 SELECT t.id
 FROM trips t
-JOIN stop_times st ON t.id = st.trip_id
 WHERE t.block_id = ?1
+  AND (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) <= ?2
+  AND (SELECT MAX(st.arrival_time) FROM stop_times st WHERE st.trip_id = t.id) >= ?2
   AND t.service_id IN (/*SLICE:service_ids*/?)
-GROUP BY t.id
-HAVING MIN(st.departure_time) <= ?3
-   AND MAX(st.arrival_time) >= ?3
-ORDER BY MIN(st.departure_time) ASC
+ORDER BY (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) ASC
 LIMIT 1
 `
 
 type GetActiveTripInBlockAtTimeParams struct {
 	BlockID     sql.NullString
-	ServiceIds  []string
 	CurrentTime int64
+	ServiceIds  []string
 }
 
 // Find the currently active trip in a specific block at the given time
 // Returns the trip whose stop times contain the current time (with late/early windows)
 // Orders by departure time ASC to get the EARLIEST matching trip (the one currently in progress)
+// current_time must appear before the service_ids slice: expanding the slice shifts
+// the position of any numbered parameter that follows it.
 func (q *Queries) GetActiveTripInBlockAtTime(ctx context.Context, arg GetActiveTripInBlockAtTimeParams) (string, error) {
 	query := getActiveTripInBlockAtTime
 	var queryParams []interface{}
 	queryParams = append(queryParams, arg.BlockID)
+	queryParams = append(queryParams, arg.CurrentTime)
 	if len(arg.ServiceIds) > 0 {
 		for _, v := range arg.ServiceIds {
 			queryParams = append(queryParams, v)
@@ -951,7 +1660,6 @@ func (q *Queries) GetActiveTripInBlockAtTime(ctx context.Context, arg GetActiveT
 	} else {
 		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
 	}
-	queryParams = append(queryParams, arg.CurrentTime)
 	row := q.queryRow(ctx, nil, query, queryParams...)
 	var id string
 	err := row.Scan(&id)
@@ -1101,7 +1809,7 @@ func (q *Queries) GetAgencyForStop(ctx context.Context, stopID string) (Agency,
 
 const getAllShapes = `-- name: GetAllShapes :many
 SELECT
-    id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
+    id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape
 FROM
     shapes
 `
@@ -1122,6 +1830,7 @@ func (q *Queries) GetAllShapes(ctx context.Context) ([]Shape, error) {
 			&i.Lon,
 			&i.ShapePtSequence,
 			&i.ShapeDistTraveled,
+			&i.DistanceAlongShape,
 		); err != nil {
 			return nil, err
 		}
@@ -1329,6 +2038,94 @@ func (q *Queries) GetBlockDetails(ctx context.Context, blockID sql.NullString) (
 	return items, nil
 }
 
+const getBlockDetailsForBlocks = `-- name: GetBlockDetailsForBlocks :many
+SELECT
+    t.block_id,
+    t.service_id,
+    t.id as trip_id,
+    t.route_id,
+    st.arrival_time,
+    st.departure_time,
+    st.stop_id,
+    st.stop_sequence,
+    st.pickup_type,
+    st.drop_off_type,
+    s.lat,
+    s.lon
+FROM
+    trips t
+        JOIN
+    stop_times st ON t.id = st.trip_id
+        JOIN
+    stops s ON st.stop_id = s.id
+WHERE
+    t.block_id IN (/*SLICE:block_ids*/?)
+ORDER BY
+    t.block_id, t.id, st.stop_sequence
+`
+
+type GetBlockDetailsForBlocksRow struct {
+	BlockID       sql.NullString
+	ServiceID     string
+	TripID        string
+	RouteID       string
+	ArrivalTime   int64
+	DepartureTime int64
+	StopID        string
+	StopSequence  int64
+	PickupType    sql.NullInt64
+	DropOffType   sql.NullInt64
+	Lat           float64
+	Lon           float64
+}
+
+// Lists the stop times of the trips of several blocks, grouped by block.
+func (q *Queries) GetBlockDetailsForBlocks(ctx context.Context, blockIds []sql.NullString) ([]GetBlockDetailsForBlocksRow, error) {
+	query := getBlockDetailsForBlocks
+	var queryParams []interface{}
+	if len(blockIds) > 0 {
+		for _, v := range blockIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:block_ids*/?", strings.Repeat(",?", len(blockIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:block_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBlockDetailsForBlocksRow
+	for rows.Next() {
+		var i GetBlockDetailsForBlocksRow
+		if err := rows.Scan(
+			&i.BlockID,
+			&i.ServiceID,
+			&i.TripID,
+			&i.RouteID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.Lat,
+			&i.Lon,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBlockIDByTripID = `-- name: GetBlockIDByTripID :one
 SELECT
     block_id
@@ -1345,6 +2142,131 @@ func (q *Queries) GetBlockIDByTripID(ctx context.Context, id string) (sql.NullSt
 	return block_id, err
 }
 
+const getBlockIDsForAgency = `-- name: GetBlockIDsForAgency :many
+SELECT DISTINCT
+    t.block_id
+FROM
+    trips t
+    JOIN routes r ON t.route_id = r.id
+WHERE
+    r.agency_id = ?
+    AND t.block_id IS NOT NULL
+    AND t.block_id != ''
+ORDER BY
+    t.block_id
+`
+
+func (q *Queries) GetBlockIDsForAgency(ctx context.Context, agencyID string) ([]sql.NullString, error) {
+	rows, err := q.query(ctx, q.getBlockIDsForAgencyStmt, getBlockIDsForAgency, agencyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var block_id sql.NullString
+		if err := rows.Scan(&block_id); err != nil {
+			return nil, err
+		}
+		items = append(items, block_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBlockStopTimesWithShapeLengths = `-- name: GetBlockStopTimesWithShapeLengths :many
+SELECT
+    st.trip_id, st.arrival_time, st.departure_time, st.stop_id, st.stop_sequence, st.stop_headsign, st.pickup_type, st.drop_off_type, st.shape_dist_traveled, st.timepoint, st.distance_along_shape,
+    t.service_id,
+    bte.shape_length,
+    bte.block_distance
+FROM stop_times st
+         JOIN trips t ON st.trip_id = t.id
+         LEFT JOIN block_trip_entry bte ON bte.trip_id = t.id
+WHERE t.block_id = ?
+  AND t.service_id IN (/*SLICE:service_ids*/?)
+ORDER BY MIN(st.departure_time) OVER (PARTITION BY st.trip_id), st.trip_id, st.stop_sequence
+`
+
+type GetBlockStopTimesWithShapeLengthsParams struct {
+	BlockID    sql.NullString
+	ServiceIds []string
+}
+
+type GetBlockStopTimesWithShapeLengthsRow struct {
+	TripID             string
+	ArrivalTime        int64
+	DepartureTime      int64
+	StopID             string
+	StopSequence       int64
+	StopHeadsign       sql.NullString
+	PickupType         sql.NullInt64
+	DropOffType        sql.NullInt64
+	ShapeDistTraveled  sql.NullFloat64
+	Timepoint          sql.NullInt64
+	DistanceAlongShape sql.NullFloat64
+	ServiceID          string
+	ShapeLength        sql.NullFloat64
+	BlockDistance      sql.NullFloat64
+}
+
+// Every stop time of a block's trips on the given service IDs, the trips in
+// order of first departure, with each trip's shape length and block distance
+// from block_trip_entry.
+func (q *Queries) GetBlockStopTimesWithShapeLengths(ctx context.Context, arg GetBlockStopTimesWithShapeLengthsParams) ([]GetBlockStopTimesWithShapeLengthsRow, error) {
+	query := getBlockStopTimesWithShapeLengths
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.BlockID)
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBlockStopTimesWithShapeLengthsRow
+	for rows.Next() {
+		var i GetBlockStopTimesWithShapeLengthsRow
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+			&i.ServiceID,
+			&i.ShapeLength,
+			&i.BlockDistance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBlockTripIndexIDsForBlocks = `-- name: GetBlockTripIndexIDsForBlocks :many
 SELECT DISTINCT bte.block_trip_index_id
 FROM block_trip_entry bte
@@ -1505,6 +2427,34 @@ func (q *Queries) GetBlocksForBlockTripIndexIDs(ctx context.Context, arg GetBloc
 	return items, nil
 }
 
+const getBookingRule = `-- name: GetBookingRule :one
+SELECT id, booking_type, prior_notice_duration_min, prior_notice_duration_max, prior_notice_last_day, prior_notice_last_time, prior_notice_start_day, prior_notice_start_time, prior_notice_service_id, message, pickup_message, drop_off_message, phone_number, info_url, booking_url FROM booking_rules
+WHERE id = ?
+`
+
+func (q *Queries) GetBookingRule(ctx context.Context, id string) (BookingRule, error) {
+	row := q.queryRow(ctx, q.getBookingRuleStmt, getBookingRule, id)
+	var i BookingRule
+	err := row.Scan(
+		&i.ID,
+		&i.BookingType,
+		&i.PriorNoticeDurationMin,
+		&i.PriorNoticeDurationMax,
+		&i.PriorNoticeLastDay,
+		&i.PriorNoticeLastTime,
+		&i.PriorNoticeStartDay,
+		&i.PriorNoticeStartTime,
+		&i.PriorNoticeServiceID,
+		&i.Message,
+		&i.PickupMessage,
+		&i.DropOffMessage,
+		&i.PhoneNumber,
+		&i.InfoUrl,
+		&i.BookingUrl,
+	)
+	return i, err
+}
+
 const getCalendarByServiceID = `-- name: GetCalendarByServiceID :one
 SELECT
     id, monday, tuesday, wednesday, thursday, friday, saturday, sunday, start_date, end_date
@@ -1564,75 +2514,171 @@ func (q *Queries) GetCalendarDateExceptionsForServiceID(ctx context.Context, ser
 	return items, nil
 }
 
-const getImportMetadata = `-- name: GetImportMetadata :one
+const getFaresForRoute = `-- name: GetFaresForRoute :many
+SELECT
+    fa.fare_id,
+    fa.agency_id,
+    fa.price,
+    fa.currency_type,
+    fa.payment_method,
+    fa.transfers,
+    fa.transfer_duration,
+    fr.origin_id,
+    fr.destination_id,
+    fr.contains_id
+FROM fare_attributes fa
+LEFT JOIN fare_rules fr ON fr.fare_id = fa.fare_id
+WHERE fr.id IS NULL
+   OR fr.route_id IS NULL
+   OR fr.route_id = ?
+ORDER BY fa.price, fa.fare_id, fr.id
+`
+
+type GetFaresForRouteRow struct {
+	FareID           string
+	AgencyID         sql.NullString
+	Price            float64
+	CurrencyType     string
+	PaymentMethod    int64
+	Transfers        sql.NullInt64
+	TransferDuration sql.NullInt64
+	OriginID         sql.NullString
+	DestinationID    sql.NullString
+	ContainsID       sql.NullString
+}
+
+// Returns each fare that can apply to the route with its rules for the route,
+// cheapest first. Rules without a route apply to every route, and so does a
+// fare without rules.
+func (q *Queries) GetFaresForRoute(ctx context.Context, routeID sql.NullString) ([]GetFaresForRouteRow, error) {
+	rows, err := q.query(ctx, q.getFaresForRouteStmt, getFaresForRoute, routeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFaresForRouteRow
+	for rows.Next() {
+		var i GetFaresForRouteRow
+		if err := rows.Scan(
+			&i.FareID,
+			&i.AgencyID,
+			&i.Price,
+			&i.CurrencyType,
+			&i.PaymentMethod,
+			&i.Transfers,
+			&i.TransferDuration,
+			&i.OriginID,
+			&i.DestinationID,
+			&i.ContainsID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedInfo = `-- name: GetFeedInfo :one
 SELECT
-    id, file_hash, import_time, file_source
+    id, feed_publisher_name, feed_publisher_url, feed_lang, default_lang, feed_start_date, feed_end_date, feed_version, feed_contact_email, feed_contact_url
 FROM
-    import_metadata
+    feed_info
 WHERE
     id = 1
 `
 
-func (q *Queries) GetImportMetadata(ctx context.Context) (ImportMetadatum, error) {
-	row := q.queryRow(ctx, q.getImportMetadataStmt, getImportMetadata)
-	var i ImportMetadatum
+func (q *Queries) GetFeedInfo(ctx context.Context) (FeedInfo, error) {
+	row := q.queryRow(ctx, q.getFeedInfoStmt, getFeedInfo)
+	var i FeedInfo
 	err := row.Scan(
 		&i.ID,
-		&i.FileHash,
-		&i.ImportTime,
-		&i.FileSource,
+		&i.FeedPublisherName,
+		&i.FeedPublisherUrl,
+		&i.FeedLang,
+		&i.DefaultLang,
+		&i.FeedStartDate,
+		&i.FeedEndDate,
+		&i.FeedVersion,
+		&i.FeedContactEmail,
+		&i.FeedContactUrl,
 	)
 	return i, err
 }
 
-const getNextStopInTrip = `-- name: GetNextStopInTrip :one
-SELECT stops.lat, stops.lon, stops.id
-FROM stop_times
-JOIN stops ON stops.id = stop_times.stop_id
-WHERE stop_times.trip_id = ?
-  AND stop_times.stop_sequence > ?
-ORDER BY stop_times.stop_sequence ASC
-LIMIT 1
+const getFlexStopTimesForTrip = `-- name: GetFlexStopTimesForTrip :many
+SELECT trip_id, stop_sequence, stop_id, location_id, location_group_id, start_pickup_drop_off_window, end_pickup_drop_off_window, pickup_type, drop_off_type, continuous_pickup, continuous_drop_off, pickup_booking_rule_id, drop_off_booking_rule_id FROM flex_stop_times
+WHERE trip_id = ?
+ORDER BY stop_sequence
 `
 
-type GetNextStopInTripParams struct {
-	TripID       string
-	StopSequence int64
-}
-
-type GetNextStopInTripRow struct {
-	Lat float64
-	Lon float64
-	ID  string
-}
-
-func (q *Queries) GetNextStopInTrip(ctx context.Context, arg GetNextStopInTripParams) (GetNextStopInTripRow, error) {
-	row := q.queryRow(ctx, q.getNextStopInTripStmt, getNextStopInTrip, arg.TripID, arg.StopSequence)
-	var i GetNextStopInTripRow
-	err := row.Scan(&i.Lat, &i.Lon, &i.ID)
-	return i, err
+func (q *Queries) GetFlexStopTimesForTrip(ctx context.Context, tripID string) ([]FlexStopTime, error) {
+	rows, err := q.query(ctx, q.getFlexStopTimesForTripStmt, getFlexStopTimesForTrip, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FlexStopTime
+	for rows.Next() {
+		var i FlexStopTime
+		if err := rows.Scan(
+			&i.TripID,
+			&i.StopSequence,
+			&i.StopID,
+			&i.LocationID,
+			&i.LocationGroupID,
+			&i.StartPickupDropOffWindow,
+			&i.EndPickupDropOffWindow,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ContinuousPickup,
+			&i.ContinuousDropOff,
+			&i.PickupBookingRuleID,
+			&i.DropOffBookingRuleID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getOrderedStopIDsForTrip = `-- name: GetOrderedStopIDsForTrip :many
-SELECT stop_id
-FROM stop_times
+const getFrequenciesForTrip = `-- name: GetFrequenciesForTrip :many
+SELECT trip_id, start_time, end_time, headway_secs, exact_times FROM frequencies
 WHERE trip_id = ?
-ORDER BY stop_sequence
+ORDER BY start_time
 `
 
-func (q *Queries) GetOrderedStopIDsForTrip(ctx context.Context, tripID string) ([]string, error) {
-	rows, err := q.query(ctx, q.getOrderedStopIDsForTripStmt, getOrderedStopIDsForTrip, tripID)
+func (q *Queries) GetFrequenciesForTrip(ctx context.Context, tripID string) ([]Frequency, error) {
+	rows, err := q.query(ctx, q.getFrequenciesForTripStmt, getFrequenciesForTrip, tripID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []Frequency
 	for rows.Next() {
-		var stop_id string
-		if err := rows.Scan(&stop_id); err != nil {
+		var i Frequency
+		if err := rows.Scan(
+			&i.TripID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.HeadwaySecs,
+			&i.ExactTimes,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, stop_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -1643,31 +2689,75 @@ func (q *Queries) GetOrderedStopIDsForTrip(ctx context.Context, tripID string) (
 	return items, nil
 }
 
-const getProblemReportsByStop = `-- name: GetProblemReportsByStop :many
-SELECT id, stop_id, code, user_comment, user_lat, user_lon, user_location_accuracy, created_at, submitted_at FROM problem_reports_stop
-WHERE stop_id = ?
-ORDER BY created_at DESC
+const getFrequencyStopTimesForStop = `-- name: GetFrequencyStopTimesForStop :many
+SELECT
+    st.trip_id, st.arrival_time, st.departure_time, st.stop_id, st.stop_sequence, st.stop_headsign, st.pickup_type, st.drop_off_type, st.shape_dist_traveled, st.timepoint, st.distance_along_shape,
+    t.route_id,
+    t.service_id,
+    t.trip_headsign,
+    t.block_id,
+    f.start_time,
+    f.end_time,
+    f.headway_secs,
+    f.exact_times
+FROM stop_times st
+         JOIN trips t ON st.trip_id = t.id
+         JOIN frequencies f ON f.trip_id = st.trip_id
+WHERE st.stop_id = ?
+ORDER BY st.trip_id, f.start_time
 `
 
-func (q *Queries) GetProblemReportsByStop(ctx context.Context, stopID string) ([]ProblemReportsStop, error) {
-	rows, err := q.query(ctx, q.getProblemReportsByStopStmt, getProblemReportsByStop, stopID)
+type GetFrequencyStopTimesForStopRow struct {
+	TripID             string
+	ArrivalTime        int64
+	DepartureTime      int64
+	StopID             string
+	StopSequence       int64
+	StopHeadsign       sql.NullString
+	PickupType         sql.NullInt64
+	DropOffType        sql.NullInt64
+	ShapeDistTraveled  sql.NullFloat64
+	Timepoint          sql.NullInt64
+	DistanceAlongShape sql.NullFloat64
+	RouteID            string
+	ServiceID          string
+	TripHeadsign       sql.NullString
+	BlockID            sql.NullString
+	StartTime          int64
+	EndTime            int64
+	HeadwaySecs        int64
+	ExactTimes         int64
+}
+
+func (q *Queries) GetFrequencyStopTimesForStop(ctx context.Context, stopID string) ([]GetFrequencyStopTimesForStopRow, error) {
+	rows, err := q.query(ctx, q.getFrequencyStopTimesForStopStmt, getFrequencyStopTimesForStop, stopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ProblemReportsStop
+	var items []GetFrequencyStopTimesForStopRow
 	for rows.Next() {
-		var i ProblemReportsStop
+		var i GetFrequencyStopTimesForStopRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
 			&i.StopID,
-			&i.Code,
-			&i.UserComment,
-			&i.UserLat,
-			&i.UserLon,
-			&i.UserLocationAccuracy,
-			&i.CreatedAt,
-			&i.SubmittedAt,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+			&i.RouteID,
+			&i.ServiceID,
+			&i.TripHeadsign,
+			&i.BlockID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.HeadwaySecs,
+			&i.ExactTimes,
 		); err != nil {
 			return nil, err
 		}
@@ -1682,37 +2772,34 @@ func (q *Queries) GetProblemReportsByStop(ctx context.Context, stopID string) ([
 	return items, nil
 }
 
-const getProblemReportsByTrip = `-- name: GetProblemReportsByTrip :many
-SELECT id, trip_id, service_date, vehicle_id, stop_id, code, user_comment, user_lat, user_lon, user_location_accuracy, user_on_vehicle, user_vehicle_number, created_at, submitted_at FROM problem_reports_trip
+const getHistoricalOccupancyForTrip = `-- name: GetHistoricalOccupancyForTrip :many
+SELECT stop_id, occupancy_status, sample_count
+FROM historical_occupancy
 WHERE trip_id = ?
-ORDER BY created_at DESC
+  AND day_of_week = ?
 `
 
-func (q *Queries) GetProblemReportsByTrip(ctx context.Context, tripID string) ([]ProblemReportsTrip, error) {
-	rows, err := q.query(ctx, q.getProblemReportsByTripStmt, getProblemReportsByTrip, tripID)
+type GetHistoricalOccupancyForTripParams struct {
+	TripID    string
+	DayOfWeek int64
+}
+
+type GetHistoricalOccupancyForTripRow struct {
+	StopID          string
+	OccupancyStatus int64
+	SampleCount     int64
+}
+
+func (q *Queries) GetHistoricalOccupancyForTrip(ctx context.Context, arg GetHistoricalOccupancyForTripParams) ([]GetHistoricalOccupancyForTripRow, error) {
+	rows, err := q.query(ctx, q.getHistoricalOccupancyForTripStmt, getHistoricalOccupancyForTrip, arg.TripID, arg.DayOfWeek)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ProblemReportsTrip
+	var items []GetHistoricalOccupancyForTripRow
 	for rows.Next() {
-		var i ProblemReportsTrip
-		if err := rows.Scan(
-			&i.ID,
-			&i.TripID,
-			&i.ServiceDate,
-			&i.VehicleID,
-			&i.StopID,
-			&i.Code,
-			&i.UserComment,
-			&i.UserLat,
-			&i.UserLon,
-			&i.UserLocationAccuracy,
-			&i.UserOnVehicle,
-			&i.UserVehicleNumber,
-			&i.CreatedAt,
-			&i.SubmittedAt,
-		); err != nil {
+		var i GetHistoricalOccupancyForTripRow
+		if err := rows.Scan(&i.StopID, &i.OccupancyStatus, &i.SampleCount); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1726,57 +2813,81 @@ func (q *Queries) GetProblemReportsByTrip(ctx context.Context, tripID string) ([
 	return items, nil
 }
 
-const getRoute = `-- name: GetRoute :one
+const getImportMetadata = `-- name: GetImportMetadata :one
 SELECT
-    id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off
+    id, file_hash, import_time, file_source, etag, last_modified
 FROM
-    routes
+    import_metadata
 WHERE
-    id = ?
+    id = 1
 `
 
-func (q *Queries) GetRoute(ctx context.Context, id string) (Route, error) {
-	row := q.queryRow(ctx, q.getRouteStmt, getRoute, id)
-	var i Route
+func (q *Queries) GetImportMetadata(ctx context.Context) (ImportMetadatum, error) {
+	row := q.queryRow(ctx, q.getImportMetadataStmt, getImportMetadata)
+	var i ImportMetadatum
 	err := row.Scan(
 		&i.ID,
-		&i.AgencyID,
-		&i.ShortName,
-		&i.LongName,
-		&i.Desc,
-		&i.Type,
-		&i.Url,
-		&i.Color,
-		&i.TextColor,
-		&i.ContinuousPickup,
-		&i.ContinuousDropOff,
+		&i.FileHash,
+		&i.ImportTime,
+		&i.FileSource,
+		&i.Etag,
+		&i.LastModified,
 	)
 	return i, err
 }
 
-const getRouteIDsForAgency = `-- name: GetRouteIDsForAgency :many
-SELECT
-    r.id
-FROM
-    routes r
-    JOIN agencies a ON r.agency_id = a.id
-WHERE
-    a.id = ?
+const getLocation = `-- name: GetLocation :one
+SELECT id, stop_name, stop_desc, geometry, min_lat, min_lon, max_lat, max_lon FROM locations
+WHERE id = ?
 `
 
-func (q *Queries) GetRouteIDsForAgency(ctx context.Context, id string) ([]string, error) {
-	rows, err := q.query(ctx, q.getRouteIDsForAgencyStmt, getRouteIDsForAgency, id)
+func (q *Queries) GetLocation(ctx context.Context, id string) (Location, error) {
+	row := q.queryRow(ctx, q.getLocationStmt, getLocation, id)
+	var i Location
+	err := row.Scan(
+		&i.ID,
+		&i.StopName,
+		&i.StopDesc,
+		&i.Geometry,
+		&i.MinLat,
+		&i.MinLon,
+		&i.MaxLat,
+		&i.MaxLon,
+	)
+	return i, err
+}
+
+const getLocationGroup = `-- name: GetLocationGroup :one
+SELECT id, location_group_name FROM location_groups
+WHERE id = ?
+`
+
+func (q *Queries) GetLocationGroup(ctx context.Context, id string) (LocationGroup, error) {
+	row := q.queryRow(ctx, q.getLocationGroupStmt, getLocationGroup, id)
+	var i LocationGroup
+	err := row.Scan(&i.ID, &i.LocationGroupName)
+	return i, err
+}
+
+const getLocationGroupStopIDs = `-- name: GetLocationGroupStopIDs :many
+SELECT stop_id FROM location_group_stops
+WHERE location_group_id = ?
+ORDER BY stop_id
+`
+
+func (q *Queries) GetLocationGroupStopIDs(ctx context.Context, locationGroupID string) ([]string, error) {
+	rows, err := q.query(ctx, q.getLocationGroupStopIDsStmt, getLocationGroupStopIDs, locationGroupID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var items []string
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var stop_id string
+		if err := rows.Scan(&stop_id); err != nil {
 			return nil, err
 		}
-		items = append(items, id)
+		items = append(items, stop_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -1787,30 +2898,27 @@ func (q *Queries) GetRouteIDsForAgency(ctx context.Context, id string) ([]string
 	return items, nil
 }
 
-const getRouteIDsForStop = `-- name: GetRouteIDsForStop :many
-SELECT DISTINCT
-    (routes.agency_id || '_' || routes.id) AS route_id
-FROM
-    stop_times
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
-WHERE
-    stop_times.stop_id = ?
+const getLocationGroupsForStop = `-- name: GetLocationGroupsForStop :many
+SELECT location_groups.id, location_groups.location_group_name
+FROM location_groups
+JOIN location_group_stops ON location_group_stops.location_group_id = location_groups.id
+WHERE location_group_stops.stop_id = ?
+ORDER BY location_groups.id
 `
 
-func (q *Queries) GetRouteIDsForStop(ctx context.Context, stopID string) ([]interface{}, error) {
-	rows, err := q.query(ctx, q.getRouteIDsForStopStmt, getRouteIDsForStop, stopID)
+func (q *Queries) GetLocationGroupsForStop(ctx context.Context, stopID string) ([]LocationGroup, error) {
+	rows, err := q.query(ctx, q.getLocationGroupsForStopStmt, getLocationGroupsForStop, stopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []interface{}
+	var items []LocationGroup
 	for rows.Next() {
-		var route_id interface{}
-		if err := rows.Scan(&route_id); err != nil {
+		var i LocationGroup
+		if err := rows.Scan(&i.ID, &i.LocationGroupName); err != nil {
 			return nil, err
 		}
-		items = append(items, route_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -1821,43 +2929,38 @@ func (q *Queries) GetRouteIDsForStop(ctx context.Context, stopID string) ([]inte
 	return items, nil
 }
 
-const getRouteIDsForStops = `-- name: GetRouteIDsForStops :many
-SELECT DISTINCT
-    routes.agency_id || '_' || routes.id AS route_id,
-    stop_times.stop_id
-FROM
-    stop_times
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
-WHERE
-    stop_times.stop_id IN (/*SLICE:stop_ids*/?)
+const getLocationsContainingPoint = `-- name: GetLocationsContainingPoint :many
+SELECT id, stop_name, stop_desc, geometry, min_lat, min_lon, max_lat, max_lon FROM locations
+WHERE min_lat <= ?1 AND max_lat >= ?1
+  AND min_lon <= ?2 AND max_lon >= ?2
+ORDER BY id
 `
 
-type GetRouteIDsForStopsRow struct {
-	RouteID interface{}
-	StopID  string
+type GetLocationsContainingPointParams struct {
+	Lat float64
+	Lon float64
 }
 
-func (q *Queries) GetRouteIDsForStops(ctx context.Context, stopIds []string) ([]GetRouteIDsForStopsRow, error) {
-	query := getRouteIDsForStops
-	var queryParams []interface{}
-	if len(stopIds) > 0 {
-		for _, v := range stopIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+// Bounding-box candidates only; callers test the geometry itself.
+func (q *Queries) GetLocationsContainingPoint(ctx context.Context, arg GetLocationsContainingPointParams) ([]Location, error) {
+	rows, err := q.query(ctx, q.getLocationsContainingPointStmt, getLocationsContainingPoint, arg.Lat, arg.Lon)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetRouteIDsForStopsRow
+	var items []Location
 	for rows.Next() {
-		var i GetRouteIDsForStopsRow
-		if err := rows.Scan(&i.RouteID, &i.StopID); err != nil {
+		var i Location
+		if err := rows.Scan(
+			&i.ID,
+			&i.StopName,
+			&i.StopDesc,
+			&i.Geometry,
+			&i.MinLat,
+			&i.MinLon,
+			&i.MaxLat,
+			&i.MaxLon,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1871,48 +2974,113 @@ func (q *Queries) GetRouteIDsForStops(ctx context.Context, stopIds []string) ([]
 	return items, nil
 }
 
-const getRoutesByIDs = `-- name: GetRoutesByIDs :many
-SELECT
-    id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off
-FROM
-    routes
-WHERE
-    id IN (/*SLICE:route_ids*/?)
-ORDER BY
-    id
+const getMaxStopTime = `-- name: GetMaxStopTime :one
+SELECT CAST(COALESCE(MAX(latest), 0) AS INTEGER) AS max_stop_time
+FROM (
+    SELECT MAX(arrival_time, departure_time) AS latest
+    FROM stop_times
+    UNION ALL
+    SELECT f.end_time + MAX(st.arrival_time) - MIN(st.departure_time)
+    FROM frequencies f
+    JOIN stop_times st ON st.trip_id = f.trip_id
+    GROUP BY f.trip_id, f.start_time
+)
 `
 
-func (q *Queries) GetRoutesByIDs(ctx context.Context, routeIds []string) ([]Route, error) {
-	query := getRoutesByIDs
-	var queryParams []interface{}
-	if len(routeIds) > 0 {
-		for _, v := range routeIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:route_ids*/?", strings.Repeat(",?", len(routeIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:route_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Route
-	for rows.Next() {
-		var i Route
+// The latest arrival or departure time in the feed, counting the last run of
+// frequency-based trips. It bounds how many earlier service days can still
+// have trips running at a given moment.
+func (q *Queries) GetMaxStopTime(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.getMaxStopTimeStmt, getMaxStopTime)
+	var max_stop_time int64
+	err := row.Scan(&max_stop_time)
+	return max_stop_time, err
+}
+
+const getNextStopInTrip = `-- name: GetNextStopInTrip :one
+SELECT stops.lat, stops.lon, stops.id
+FROM stop_times
+JOIN stops ON stops.id = stop_times.stop_id
+WHERE stop_times.trip_id = ?
+  AND stop_times.stop_sequence > ?
+ORDER BY stop_times.stop_sequence ASC
+LIMIT 1
+`
+
+type GetNextStopInTripParams struct {
+	TripID       string
+	StopSequence int64
+}
+
+type GetNextStopInTripRow struct {
+	Lat float64
+	Lon float64
+	ID  string
+}
+
+func (q *Queries) GetNextStopInTrip(ctx context.Context, arg GetNextStopInTripParams) (GetNextStopInTripRow, error) {
+	row := q.queryRow(ctx, q.getNextStopInTripStmt, getNextStopInTrip, arg.TripID, arg.StopSequence)
+	var i GetNextStopInTripRow
+	err := row.Scan(&i.Lat, &i.Lon, &i.ID)
+	return i, err
+}
+
+const getOnTimePerformanceByRoute = `-- name: GetOnTimePerformanceByRoute :many
+SELECT
+    t.route_id,
+    COUNT(*) AS sample_count,
+    CAST(SUM(s.deviation < -?1) AS INTEGER) AS early_count,
+    CAST(SUM(s.deviation > ?2) AS INTEGER) AS late_count,
+    CAST(AVG(s.deviation) AS REAL) AS mean_deviation
+FROM schedule_deviation_samples s
+JOIN trips t ON t.id = s.trip_id
+JOIN routes r ON r.id = t.route_id
+WHERE r.agency_id = ?3
+    AND s.observed_at BETWEEN ?4 AND ?5
+GROUP BY t.route_id
+ORDER BY t.route_id
+`
+
+type GetOnTimePerformanceByRouteParams struct {
+	EarlyThreshold interface{}
+	LateThreshold  interface{}
+	AgencyID       string
+	FromTime       int64
+	ToTime         int64
+}
+
+type GetOnTimePerformanceByRouteRow struct {
+	RouteID       string
+	SampleCount   int64
+	EarlyCount    int64
+	LateCount     int64
+	MeanDeviation float64
+}
+
+// Counts the deviation samples of an agency's routes observed between two times.
+// Samples more than early_threshold seconds early or late_threshold seconds late
+// are counted as early or late.
+func (q *Queries) GetOnTimePerformanceByRoute(ctx context.Context, arg GetOnTimePerformanceByRouteParams) ([]GetOnTimePerformanceByRouteRow, error) {
+	rows, err := q.query(ctx, q.getOnTimePerformanceByRouteStmt, getOnTimePerformanceByRoute,
+		arg.EarlyThreshold,
+		arg.LateThreshold,
+		arg.AgencyID,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOnTimePerformanceByRouteRow
+	for rows.Next() {
+		var i GetOnTimePerformanceByRouteRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.AgencyID,
-			&i.ShortName,
-			&i.LongName,
-			&i.Desc,
-			&i.Type,
-			&i.Url,
-			&i.Color,
-			&i.TextColor,
-			&i.ContinuousPickup,
-			&i.ContinuousDropOff,
+			&i.RouteID,
+			&i.SampleCount,
+			&i.EarlyCount,
+			&i.LateCount,
+			&i.MeanDeviation,
 		); err != nil {
 			return nil, err
 		}
@@ -1927,42 +3095,26 @@ func (q *Queries) GetRoutesByIDs(ctx context.Context, routeIds []string) ([]Rout
 	return items, nil
 }
 
-const getRoutesForStop = `-- name: GetRoutesForStop :many
-SELECT DISTINCT
-    routes.id, routes.agency_id, routes.short_name, routes.long_name, routes."desc", routes.type, routes.url, routes.color, routes.text_color, routes.continuous_pickup, routes.continuous_drop_off
-FROM
-    stop_times
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
-WHERE
-    stop_times.stop_id = ?
+const getOrderedStopIDsForTrip = `-- name: GetOrderedStopIDsForTrip :many
+SELECT stop_id
+FROM stop_times
+WHERE trip_id = ?
+ORDER BY stop_sequence
 `
 
-func (q *Queries) GetRoutesForStop(ctx context.Context, stopID string) ([]Route, error) {
-	rows, err := q.query(ctx, q.getRoutesForStopStmt, getRoutesForStop, stopID)
+func (q *Queries) GetOrderedStopIDsForTrip(ctx context.Context, tripID string) ([]string, error) {
+	rows, err := q.query(ctx, q.getOrderedStopIDsForTripStmt, getOrderedStopIDsForTrip, tripID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Route
+	var items []string
 	for rows.Next() {
-		var i Route
-		if err := rows.Scan(
-			&i.ID,
-			&i.AgencyID,
-			&i.ShortName,
-			&i.LongName,
-			&i.Desc,
-			&i.Type,
-			&i.Url,
-			&i.Color,
-			&i.TextColor,
-			&i.ContinuousPickup,
-			&i.ContinuousDropOff,
-		); err != nil {
+		var stop_id string
+		if err := rows.Scan(&stop_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, stop_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -1973,67 +3125,31 @@ func (q *Queries) GetRoutesForStop(ctx context.Context, stopID string) ([]Route,
 	return items, nil
 }
 
-const getRoutesForStops = `-- name: GetRoutesForStops :many
-
-SELECT DISTINCT
-    routes.id, routes.agency_id, routes.short_name, routes.long_name, routes."desc", routes.type, routes.url, routes.color, routes.text_color, routes.continuous_pickup, routes.continuous_drop_off,
-    stop_times.stop_id
-FROM
-    stop_times
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
-WHERE
-    stop_times.stop_id IN (/*SLICE:stop_ids*/?)
+const getProblemReportsByStop = `-- name: GetProblemReportsByStop :many
+SELECT id, stop_id, code, user_comment, user_lat, user_lon, user_location_accuracy, created_at, submitted_at FROM problem_reports_stop
+WHERE stop_id = ?
+ORDER BY created_at DESC
 `
 
-type GetRoutesForStopsRow struct {
-	ID                string
-	AgencyID          string
-	ShortName         sql.NullString
-	LongName          sql.NullString
-	Desc              sql.NullString
-	Type              int64
-	Url               sql.NullString
-	Color             sql.NullString
-	TextColor         sql.NullString
-	ContinuousPickup  sql.NullInt64
-	ContinuousDropOff sql.NullInt64
-	StopID            string
-}
-
-// Batch queries to solve N+1 problems
-func (q *Queries) GetRoutesForStops(ctx context.Context, stopIds []string) ([]GetRoutesForStopsRow, error) {
-	query := getRoutesForStops
-	var queryParams []interface{}
-	if len(stopIds) > 0 {
-		for _, v := range stopIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) GetProblemReportsByStop(ctx context.Context, stopID string) ([]ProblemReportsStop, error) {
+	rows, err := q.query(ctx, q.getProblemReportsByStopStmt, getProblemReportsByStop, stopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetRoutesForStopsRow
+	var items []ProblemReportsStop
 	for rows.Next() {
-		var i GetRoutesForStopsRow
+		var i ProblemReportsStop
 		if err := rows.Scan(
 			&i.ID,
-			&i.AgencyID,
-			&i.ShortName,
-			&i.LongName,
-			&i.Desc,
-			&i.Type,
-			&i.Url,
-			&i.Color,
-			&i.TextColor,
-			&i.ContinuousPickup,
-			&i.ContinuousDropOff,
 			&i.StopID,
+			&i.Code,
+			&i.UserComment,
+			&i.UserLat,
+			&i.UserLon,
+			&i.UserLocationAccuracy,
+			&i.CreatedAt,
+			&i.SubmittedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -2048,51 +3164,40 @@ func (q *Queries) GetRoutesForStops(ctx context.Context, stopIds []string) ([]Ge
 	return items, nil
 }
 
-const getRoutesInBlockTripIndices = `-- name: GetRoutesInBlockTripIndices :many
-SELECT DISTINCT t.route_id
-FROM trips t
-JOIN block_trip_entry bte ON t.id = bte.trip_id
-WHERE bte.block_trip_index_id IN (/*SLICE:index_ids*/?)
-  AND bte.service_id IN (/*SLICE:service_ids*/?)
+const getProblemReportsByTrip = `-- name: GetProblemReportsByTrip :many
+SELECT id, trip_id, service_date, vehicle_id, stop_id, code, user_comment, user_lat, user_lon, user_location_accuracy, user_on_vehicle, user_vehicle_number, created_at, submitted_at FROM problem_reports_trip
+WHERE trip_id = ?
+ORDER BY created_at DESC
 `
 
-type GetRoutesInBlockTripIndicesParams struct {
-	IndexIds   []int64
-	ServiceIds []string
-}
-
-// Get all unique route IDs that have trips in the specified block_trip_index IDs
-func (q *Queries) GetRoutesInBlockTripIndices(ctx context.Context, arg GetRoutesInBlockTripIndicesParams) ([]string, error) {
-	query := getRoutesInBlockTripIndices
-	var queryParams []interface{}
-	if len(arg.IndexIds) > 0 {
-		for _, v := range arg.IndexIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:index_ids*/?", strings.Repeat(",?", len(arg.IndexIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:index_ids*/?", "NULL", 1)
-	}
-	if len(arg.ServiceIds) > 0 {
-		for _, v := range arg.ServiceIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) GetProblemReportsByTrip(ctx context.Context, tripID string) ([]ProblemReportsTrip, error) {
+	rows, err := q.query(ctx, q.getProblemReportsByTripStmt, getProblemReportsByTrip, tripID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []ProblemReportsTrip
 	for rows.Next() {
-		var route_id string
-		if err := rows.Scan(&route_id); err != nil {
+		var i ProblemReportsTrip
+		if err := rows.Scan(
+			&i.ID,
+			&i.TripID,
+			&i.ServiceDate,
+			&i.VehicleID,
+			&i.StopID,
+			&i.Code,
+			&i.UserComment,
+			&i.UserLat,
+			&i.UserLon,
+			&i.UserLocationAccuracy,
+			&i.UserOnVehicle,
+			&i.UserVehicleNumber,
+			&i.CreatedAt,
+			&i.SubmittedAt,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, route_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2103,58 +3208,41 @@ func (q *Queries) GetRoutesInBlockTripIndices(ctx context.Context, arg GetRoutes
 	return items, nil
 }
 
-const getScheduleForStop = `-- name: GetScheduleForStop :many
-SELECT
-    st.trip_id,
-    st.arrival_time,
-    st.departure_time,
-    st.stop_headsign,
-    t.service_id,
-    t.route_id,
-    t.trip_headsign,
-    r.id as route_id,
-    r.agency_id
-FROM
-    stop_times st
-    JOIN trips t ON st.trip_id = t.id
-    JOIN routes r ON t.route_id = r.id
-WHERE
-    st.stop_id = ?
-ORDER BY
-    r.id, st.arrival_time
+const getRecentVehiclePositionsForTrip = `-- name: GetRecentVehiclePositionsForTrip :many
+SELECT id, feed_id, vehicle_id, trip_id, route_id, lat, lon, bearing, speed, schedule_deviation, stop_id, occupancy_status, observed_at FROM vehicle_positions_history
+WHERE trip_id = ?
+ORDER BY observed_at DESC
+LIMIT ?
 `
 
-type GetScheduleForStopRow struct {
-	TripID        string
-	ArrivalTime   int64
-	DepartureTime int64
-	StopHeadsign  sql.NullString
-	ServiceID     string
-	RouteID       string
-	TripHeadsign  sql.NullString
-	RouteID_2     string
-	AgencyID      string
+type GetRecentVehiclePositionsForTripParams struct {
+	TripID sql.NullString
+	Limit  int64
 }
 
-func (q *Queries) GetScheduleForStop(ctx context.Context, stopID string) ([]GetScheduleForStopRow, error) {
-	rows, err := q.query(ctx, q.getScheduleForStopStmt, getScheduleForStop, stopID)
+func (q *Queries) GetRecentVehiclePositionsForTrip(ctx context.Context, arg GetRecentVehiclePositionsForTripParams) ([]VehiclePositionsHistory, error) {
+	rows, err := q.query(ctx, q.getRecentVehiclePositionsForTripStmt, getRecentVehiclePositionsForTrip, arg.TripID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetScheduleForStopRow
+	var items []VehiclePositionsHistory
 	for rows.Next() {
-		var i GetScheduleForStopRow
+		var i VehiclePositionsHistory
 		if err := rows.Scan(
-			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
-			&i.StopHeadsign,
-			&i.ServiceID,
+			&i.ID,
+			&i.FeedID,
+			&i.VehicleID,
+			&i.TripID,
 			&i.RouteID,
-			&i.TripHeadsign,
-			&i.RouteID_2,
-			&i.AgencyID,
+			&i.Lat,
+			&i.Lon,
+			&i.Bearing,
+			&i.Speed,
+			&i.ScheduleDeviation,
+			&i.StopID,
+			&i.OccupancyStatus,
+			&i.ObservedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -2169,113 +3257,63 @@ func (q *Queries) GetScheduleForStop(ctx context.Context, stopID string) ([]GetS
 	return items, nil
 }
 
-const getScheduleForStopOnDate = `-- name: GetScheduleForStopOnDate :many
+const getRoute = `-- name: GetRoute :one
 SELECT
-    st.trip_id,
-    st.arrival_time,
-    st.departure_time,
-    st.stop_headsign,
-    t.service_id,
-    t.route_id,
-    t.trip_headsign,
-    r.id as route_id,
-    r.agency_id
+    id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off, sort_order, network_id
 FROM
-    stop_times st
-    JOIN trips t ON st.trip_id = t.id
-    JOIN routes r ON t.route_id = r.id
-    LEFT JOIN (
-        SELECT c.id AS service_id
-        FROM calendar c
-        WHERE c.start_date <= ?1
-          AND c.end_date >= ?1
-          AND (
-            (?2 = 'sunday' AND c.sunday = 1) OR
-            (?2 = 'monday' AND c.monday = 1) OR
-            (?2 = 'tuesday' AND c.tuesday = 1) OR
-            (?2 = 'wednesday' AND c.wednesday = 1) OR
-            (?2 = 'thursday' AND c.thursday = 1) OR
-            (?2 = 'friday' AND c.friday = 1) OR
-            (?2 = 'saturday' AND c.saturday = 1)
-          )
-    ) base ON t.service_id = base.service_id
-    LEFT JOIN (
-        SELECT cd.service_id
-        FROM calendar_dates cd
-        WHERE cd.date = ?1 AND cd.exception_type = 2
-    ) removed ON t.service_id = removed.service_id
-    LEFT JOIN (
-        SELECT cd.service_id
-        FROM calendar_dates cd
-        WHERE cd.date = ?1 AND cd.exception_type = 1
-    ) added ON t.service_id = added.service_id
+    routes
 WHERE
-    st.stop_id = ?3
-    AND (
-        (base.service_id IS NOT NULL AND removed.service_id IS NULL)
-        OR
-        added.service_id IS NOT NULL
-    )
-    AND r.id IN (/*SLICE:route_ids*/?)
-ORDER BY
-    r.id, st.arrival_time
+    id = ?
 `
 
-type GetScheduleForStopOnDateParams struct {
-	TargetDate string
-	Weekday    interface{}
-	StopID     string
-	RouteIds   []string
+func (q *Queries) GetRoute(ctx context.Context, id string) (Route, error) {
+	row := q.queryRow(ctx, q.getRouteStmt, getRoute, id)
+	var i Route
+	err := row.Scan(
+		&i.ID,
+		&i.AgencyID,
+		&i.ShortName,
+		&i.LongName,
+		&i.Desc,
+		&i.Type,
+		&i.Url,
+		&i.Color,
+		&i.TextColor,
+		&i.ContinuousPickup,
+		&i.ContinuousDropOff,
+		&i.SortOrder,
+		&i.NetworkID,
+	)
+	return i, err
 }
 
-type GetScheduleForStopOnDateRow struct {
-	TripID        string
-	ArrivalTime   int64
-	DepartureTime int64
-	StopHeadsign  sql.NullString
-	ServiceID     string
-	RouteID       string
-	TripHeadsign  sql.NullString
-	RouteID_2     string
-	AgencyID      string
-}
+const getRouteIDsForAgency = `-- name: GetRouteIDsForAgency :many
+SELECT
+    r.id
+FROM
+    routes r
+    JOIN agencies a ON r.agency_id = a.id
+WHERE
+    a.id = ?
+ORDER BY
+    r.sort_order IS NULL,
+    r.sort_order,
+    r.id
+`
 
-func (q *Queries) GetScheduleForStopOnDate(ctx context.Context, arg GetScheduleForStopOnDateParams) ([]GetScheduleForStopOnDateRow, error) {
-	query := getScheduleForStopOnDate
-	var queryParams []interface{}
-	queryParams = append(queryParams, arg.TargetDate)
-	queryParams = append(queryParams, arg.Weekday)
-	queryParams = append(queryParams, arg.StopID)
-	if len(arg.RouteIds) > 0 {
-		for _, v := range arg.RouteIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:route_ids*/?", strings.Repeat(",?", len(arg.RouteIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:route_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) GetRouteIDsForAgency(ctx context.Context, id string) ([]string, error) {
+	rows, err := q.query(ctx, q.getRouteIDsForAgencyStmt, getRouteIDsForAgency, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetScheduleForStopOnDateRow
+	var items []string
 	for rows.Next() {
-		var i GetScheduleForStopOnDateRow
-		if err := rows.Scan(
-			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
-			&i.StopHeadsign,
-			&i.ServiceID,
-			&i.RouteID,
-			&i.TripHeadsign,
-			&i.RouteID_2,
-			&i.AgencyID,
-		); err != nil {
+		var id string
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2286,37 +3324,30 @@ func (q *Queries) GetScheduleForStopOnDate(ctx context.Context, arg GetScheduleF
 	return items, nil
 }
 
-const getShapeByID = `-- name: GetShapeByID :many
-SELECT
-    id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
+const getRouteIDsForStop = `-- name: GetRouteIDsForStop :many
+SELECT DISTINCT
+    (routes.agency_id || '_' || routes.id) AS route_id
 FROM
-    shapes
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
 WHERE
-    shape_id = ?
-ORDER BY
-    shape_pt_sequence
+    stop_times.stop_id = ?
 `
 
-func (q *Queries) GetShapeByID(ctx context.Context, shapeID string) ([]Shape, error) {
-	rows, err := q.query(ctx, q.getShapeByIDStmt, getShapeByID, shapeID)
+func (q *Queries) GetRouteIDsForStop(ctx context.Context, stopID string) ([]interface{}, error) {
+	rows, err := q.query(ctx, q.getRouteIDsForStopStmt, getRouteIDsForStop, stopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Shape
+	var items []interface{}
 	for rows.Next() {
-		var i Shape
-		if err := rows.Scan(
-			&i.ID,
-			&i.ShapeID,
-			&i.Lat,
-			&i.Lon,
-			&i.ShapePtSequence,
-			&i.ShapeDistTraveled,
-		); err != nil {
+		var route_id interface{}
+		if err := rows.Scan(&route_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, route_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2327,36 +3358,43 @@ func (q *Queries) GetShapeByID(ctx context.Context, shapeID string) ([]Shape, er
 	return items, nil
 }
 
-const getShapePointWindow = `-- name: GetShapePointWindow :many
-SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
-FROM shapes
-WHERE shape_id = ?
-  AND shape_pt_sequence BETWEEN ? AND ?
-ORDER BY shape_pt_sequence
+const getRouteIDsForStops = `-- name: GetRouteIDsForStops :many
+SELECT DISTINCT
+    routes.agency_id || '_' || routes.id AS route_id,
+    stop_times.stop_id
+FROM
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stop_times.stop_id IN (/*SLICE:stop_ids*/?)
 `
 
-type GetShapePointWindowRow struct {
-	Lat               float64
-	Lon               float64
-	ShapePtSequence   int64
-	ShapeDistTraveled sql.NullFloat64
+type GetRouteIDsForStopsRow struct {
+	RouteID interface{}
+	StopID  string
 }
 
-func (q *Queries) GetShapePointWindow(ctx context.Context, shapeID string) ([]GetShapePointWindowRow, error) {
-	rows, err := q.query(ctx, q.getShapePointWindowStmt, getShapePointWindow, shapeID)
+func (q *Queries) GetRouteIDsForStops(ctx context.Context, stopIds []string) ([]GetRouteIDsForStopsRow, error) {
+	query := getRouteIDsForStops
+	var queryParams []interface{}
+	if len(stopIds) > 0 {
+		for _, v := range stopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetShapePointWindowRow
+	var items []GetRouteIDsForStopsRow
 	for rows.Next() {
-		var i GetShapePointWindowRow
-		if err := rows.Scan(
-			&i.Lat,
-			&i.Lon,
-			&i.ShapePtSequence,
-			&i.ShapeDistTraveled,
-		); err != nil {
+		var i GetRouteIDsForStopsRow
+		if err := rows.Scan(&i.RouteID, &i.StopID); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -2370,46 +3408,50 @@ func (q *Queries) GetShapePointWindow(ctx context.Context, shapeID string) ([]Ge
 	return items, nil
 }
 
-const getShapePointsByIDs = `-- name: GetShapePointsByIDs :many
-SELECT shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled
-FROM shapes
-WHERE shape_id IN (/*SLICE:shape_ids*/?)
-ORDER BY shape_id, shape_pt_sequence
+const getRoutesByIDs = `-- name: GetRoutesByIDs :many
+SELECT
+    id, agency_id, short_name, long_name, "desc", type, url, color, text_color, continuous_pickup, continuous_drop_off, sort_order, network_id
+FROM
+    routes
+WHERE
+    id IN (/*SLICE:route_ids*/?)
+ORDER BY
+    id
 `
 
-type GetShapePointsByIDsRow struct {
-	ShapeID           string
-	Lat               float64
-	Lon               float64
-	ShapePtSequence   int64
-	ShapeDistTraveled sql.NullFloat64
-}
-
-func (q *Queries) GetShapePointsByIDs(ctx context.Context, shapeIds []string) ([]GetShapePointsByIDsRow, error) {
-	query := getShapePointsByIDs
+func (q *Queries) GetRoutesByIDs(ctx context.Context, routeIds []string) ([]Route, error) {
+	query := getRoutesByIDs
 	var queryParams []interface{}
-	if len(shapeIds) > 0 {
-		for _, v := range shapeIds {
+	if len(routeIds) > 0 {
+		for _, v := range routeIds {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:shape_ids*/?", strings.Repeat(",?", len(shapeIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:route_ids*/?", strings.Repeat(",?", len(routeIds))[1:], 1)
 	} else {
-		query = strings.Replace(query, "/*SLICE:shape_ids*/?", "NULL", 1)
+		query = strings.Replace(query, "/*SLICE:route_ids*/?", "NULL", 1)
 	}
 	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetShapePointsByIDsRow
+	var items []Route
 	for rows.Next() {
-		var i GetShapePointsByIDsRow
+		var i Route
 		if err := rows.Scan(
-			&i.ShapeID,
-			&i.Lat,
-			&i.Lon,
-			&i.ShapePtSequence,
-			&i.ShapeDistTraveled,
+			&i.ID,
+			&i.AgencyID,
+			&i.ShortName,
+			&i.LongName,
+			&i.Desc,
+			&i.Type,
+			&i.Url,
+			&i.Color,
+			&i.TextColor,
+			&i.ContinuousPickup,
+			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
 		); err != nil {
 			return nil, err
 		}
@@ -2424,39 +3466,40 @@ func (q *Queries) GetShapePointsByIDs(ctx context.Context, shapeIds []string) ([
 	return items, nil
 }
 
-const getShapePointsByTripID = `-- name: GetShapePointsByTripID :many
-SELECT
-    s.id,
-    s.shape_id,
-    s.lat,
-    s.lon,
-    s.shape_pt_sequence,
-    s.shape_dist_traveled
+const getRoutesForStop = `-- name: GetRoutesForStop :many
+SELECT DISTINCT
+    routes.id, routes.agency_id, routes.short_name, routes.long_name, routes."desc", routes.type, routes.url, routes.color, routes.text_color, routes.continuous_pickup, routes.continuous_drop_off, routes.sort_order, routes.network_id
 FROM
-    shapes s
-    JOIN trips t ON t.shape_id = s.shape_id
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
 WHERE
-    t.id = ?
-ORDER BY
-    s.shape_pt_sequence ASC
+    stop_times.stop_id = ?
 `
 
-func (q *Queries) GetShapePointsByTripID(ctx context.Context, id string) ([]Shape, error) {
-	rows, err := q.query(ctx, q.getShapePointsByTripIDStmt, getShapePointsByTripID, id)
+func (q *Queries) GetRoutesForStop(ctx context.Context, stopID string) ([]Route, error) {
+	rows, err := q.query(ctx, q.getRoutesForStopStmt, getRoutesForStop, stopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Shape
+	var items []Route
 	for rows.Next() {
-		var i Shape
+		var i Route
 		if err := rows.Scan(
 			&i.ID,
-			&i.ShapeID,
-			&i.Lat,
-			&i.Lon,
-			&i.ShapePtSequence,
-			&i.ShapeDistTraveled,
+			&i.AgencyID,
+			&i.ShortName,
+			&i.LongName,
+			&i.Desc,
+			&i.Type,
+			&i.Url,
+			&i.Color,
+			&i.TextColor,
+			&i.ContinuousPickup,
+			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
 		); err != nil {
 			return nil, err
 		}
@@ -2471,30 +3514,72 @@ func (q *Queries) GetShapePointsByTripID(ctx context.Context, id string) ([]Shap
 	return items, nil
 }
 
-const getShapePointsForTrip = `-- name: GetShapePointsForTrip :many
-SELECT DISTINCT shapes.lat, shapes.lon, shapes.shape_pt_sequence
-FROM shapes
-JOIN trips ON trips.shape_id = shapes.shape_id
-WHERE trips.id = ?
-ORDER BY shapes.shape_pt_sequence
+const getRoutesForStops = `-- name: GetRoutesForStops :many
+
+SELECT DISTINCT
+    routes.id, routes.agency_id, routes.short_name, routes.long_name, routes."desc", routes.type, routes.url, routes.color, routes.text_color, routes.continuous_pickup, routes.continuous_drop_off, routes.sort_order, routes.network_id,
+    stop_times.stop_id
+FROM
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stop_times.stop_id IN (/*SLICE:stop_ids*/?)
 `
 
-type GetShapePointsForTripRow struct {
-	Lat             float64
-	Lon             float64
-	ShapePtSequence int64
+type GetRoutesForStopsRow struct {
+	ID                string
+	AgencyID          string
+	ShortName         sql.NullString
+	LongName          sql.NullString
+	Desc              sql.NullString
+	Type              int64
+	Url               sql.NullString
+	Color             sql.NullString
+	TextColor         sql.NullString
+	ContinuousPickup  sql.NullInt64
+	ContinuousDropOff sql.NullInt64
+	SortOrder         sql.NullInt64
+	NetworkID         sql.NullString
+	StopID            string
 }
 
-func (q *Queries) GetShapePointsForTrip(ctx context.Context, id string) ([]GetShapePointsForTripRow, error) {
-	rows, err := q.query(ctx, q.getShapePointsForTripStmt, getShapePointsForTrip, id)
+// Batch queries to solve N+1 problems
+func (q *Queries) GetRoutesForStops(ctx context.Context, stopIds []string) ([]GetRoutesForStopsRow, error) {
+	query := getRoutesForStops
+	var queryParams []interface{}
+	if len(stopIds) > 0 {
+		for _, v := range stopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetShapePointsForTripRow
+	var items []GetRoutesForStopsRow
 	for rows.Next() {
-		var i GetShapePointsForTripRow
-		if err := rows.Scan(&i.Lat, &i.Lon, &i.ShapePtSequence); err != nil {
+		var i GetRoutesForStopsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AgencyID,
+			&i.ShortName,
+			&i.LongName,
+			&i.Desc,
+			&i.Type,
+			&i.Url,
+			&i.Color,
+			&i.TextColor,
+			&i.ContinuousPickup,
+			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
+			&i.StopID,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -2508,34 +3593,84 @@ func (q *Queries) GetShapePointsForTrip(ctx context.Context, id string) ([]GetSh
 	return items, nil
 }
 
-const getShapePointsWithDistance = `-- name: GetShapePointsWithDistance :many
-SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
-FROM shapes
-WHERE shape_id = ?
-ORDER BY shape_pt_sequence
+const getRoutesForStopsWithRouteTypes = `-- name: GetRoutesForStopsWithRouteTypes :many
+SELECT DISTINCT
+    routes.id, routes.agency_id, routes.short_name, routes.long_name, routes."desc", routes.type, routes.url, routes.color, routes.text_color, routes.continuous_pickup, routes.continuous_drop_off, routes.sort_order, routes.network_id,
+    stop_times.stop_id
+FROM
+    stop_times
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stop_times.stop_id IN (/*SLICE:stop_ids*/?)
+    AND routes.type IN (/*SLICE:route_types*/?)
 `
 
-type GetShapePointsWithDistanceRow struct {
-	Lat               float64
-	Lon               float64
-	ShapePtSequence   int64
-	ShapeDistTraveled sql.NullFloat64
+type GetRoutesForStopsWithRouteTypesParams struct {
+	StopIds    []string
+	RouteTypes []int64
 }
 
-func (q *Queries) GetShapePointsWithDistance(ctx context.Context, shapeID string) ([]GetShapePointsWithDistanceRow, error) {
-	rows, err := q.query(ctx, q.getShapePointsWithDistanceStmt, getShapePointsWithDistance, shapeID)
+type GetRoutesForStopsWithRouteTypesRow struct {
+	ID                string
+	AgencyID          string
+	ShortName         sql.NullString
+	LongName          sql.NullString
+	Desc              sql.NullString
+	Type              int64
+	Url               sql.NullString
+	Color             sql.NullString
+	TextColor         sql.NullString
+	ContinuousPickup  sql.NullInt64
+	ContinuousDropOff sql.NullInt64
+	SortOrder         sql.NullInt64
+	NetworkID         sql.NullString
+	StopID            string
+}
+
+// Like GetRoutesForStops, limited to routes of the given GTFS route types
+func (q *Queries) GetRoutesForStopsWithRouteTypes(ctx context.Context, arg GetRoutesForStopsWithRouteTypesParams) ([]GetRoutesForStopsWithRouteTypesRow, error) {
+	query := getRoutesForStopsWithRouteTypes
+	var queryParams []interface{}
+	if len(arg.StopIds) > 0 {
+		for _, v := range arg.StopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(arg.StopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	if len(arg.RouteTypes) > 0 {
+		for _, v := range arg.RouteTypes {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:route_types*/?", strings.Repeat(",?", len(arg.RouteTypes))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:route_types*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetShapePointsWithDistanceRow
+	var items []GetRoutesForStopsWithRouteTypesRow
 	for rows.Next() {
-		var i GetShapePointsWithDistanceRow
+		var i GetRoutesForStopsWithRouteTypesRow
 		if err := rows.Scan(
-			&i.Lat,
-			&i.Lon,
-			&i.ShapePtSequence,
-			&i.ShapeDistTraveled,
+			&i.ID,
+			&i.AgencyID,
+			&i.ShortName,
+			&i.LongName,
+			&i.Desc,
+			&i.Type,
+			&i.Url,
+			&i.Color,
+			&i.TextColor,
+			&i.ContinuousPickup,
+			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
+			&i.StopID,
 		); err != nil {
 			return nil, err
 		}
@@ -2550,44 +3685,51 @@ func (q *Queries) GetShapePointsWithDistance(ctx context.Context, shapeID string
 	return items, nil
 }
 
-const getShapesGroupedByTripHeadSign = `-- name: GetShapesGroupedByTripHeadSign :many
-SELECT DISTINCT s.lat, s.lon, s.shape_pt_sequence
-FROM shapes s
-         JOIN (
-    SELECT shape_id
-    FROM trips
-    WHERE route_id = ?1
-      AND trip_headsign = ?2
-      AND shape_id IS NOT NULL
-    LIMIT 1
-) t ON s.shape_id = t.shape_id
-ORDER BY s.shape_pt_sequence
+const getRoutesInBlockTripIndices = `-- name: GetRoutesInBlockTripIndices :many
+SELECT DISTINCT t.route_id
+FROM trips t
+JOIN block_trip_entry bte ON t.id = bte.trip_id
+WHERE bte.block_trip_index_id IN (/*SLICE:index_ids*/?)
+  AND bte.service_id IN (/*SLICE:service_ids*/?)
 `
 
-type GetShapesGroupedByTripHeadSignParams struct {
-	RouteID      string
-	TripHeadsign sql.NullString
-}
-
-type GetShapesGroupedByTripHeadSignRow struct {
-	Lat             float64
-	Lon             float64
-	ShapePtSequence int64
+type GetRoutesInBlockTripIndicesParams struct {
+	IndexIds   []int64
+	ServiceIds []string
 }
 
-func (q *Queries) GetShapesGroupedByTripHeadSign(ctx context.Context, arg GetShapesGroupedByTripHeadSignParams) ([]GetShapesGroupedByTripHeadSignRow, error) {
-	rows, err := q.query(ctx, q.getShapesGroupedByTripHeadSignStmt, getShapesGroupedByTripHeadSign, arg.RouteID, arg.TripHeadsign)
+// Get all unique route IDs that have trips in the specified block_trip_index IDs
+func (q *Queries) GetRoutesInBlockTripIndices(ctx context.Context, arg GetRoutesInBlockTripIndicesParams) ([]string, error) {
+	query := getRoutesInBlockTripIndices
+	var queryParams []interface{}
+	if len(arg.IndexIds) > 0 {
+		for _, v := range arg.IndexIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:index_ids*/?", strings.Repeat(",?", len(arg.IndexIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:index_ids*/?", "NULL", 1)
+	}
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetShapesGroupedByTripHeadSignRow
+	var items []string
 	for rows.Next() {
-		var i GetShapesGroupedByTripHeadSignRow
-		if err := rows.Scan(&i.Lat, &i.Lon, &i.ShapePtSequence); err != nil {
+		var route_id string
+		if err := rows.Scan(&route_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, route_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2598,133 +3740,1171 @@ func (q *Queries) GetShapesGroupedByTripHeadSign(ctx context.Context, arg GetSha
 	return items, nil
 }
 
-const getStop = `-- name: GetStop :one
+const getScheduleForStop = `-- name: GetScheduleForStop :many
 SELECT
-    id,
-    code,
-    name,
-    desc,
-    lat,
-    lon,
-    zone_id,
-    url,
-    location_type,
-    timezone,
-    wheelchair_boarding,
-    platform_code,
-    direction
-FROM
-    stops
+    st.trip_id,
+    st.arrival_time,
+    st.departure_time,
+    st.stop_headsign,
+    t.service_id,
+    t.route_id,
+    t.trip_headsign,
+    r.id as route_id,
+    r.agency_id
+FROM
+    stop_times st
+    JOIN trips t ON st.trip_id = t.id
+    JOIN routes r ON t.route_id = r.id
 WHERE
-    id = ?
-LIMIT
-    1
+    st.stop_id = ?
+ORDER BY
+    r.id, st.arrival_time
 `
 
-type GetStopRow struct {
-	ID                 string
-	Code               sql.NullString
-	Name               sql.NullString
-	Desc               sql.NullString
-	Lat                float64
-	Lon                float64
-	ZoneID             sql.NullString
-	Url                sql.NullString
-	LocationType       sql.NullInt64
-	Timezone           sql.NullString
-	WheelchairBoarding sql.NullInt64
-	PlatformCode       sql.NullString
-	Direction          sql.NullString
+type GetScheduleForStopRow struct {
+	TripID        string
+	ArrivalTime   int64
+	DepartureTime int64
+	StopHeadsign  sql.NullString
+	ServiceID     string
+	RouteID       string
+	TripHeadsign  sql.NullString
+	RouteID_2     string
+	AgencyID      string
 }
 
-func (q *Queries) GetStop(ctx context.Context, id string) (GetStopRow, error) {
-	row := q.queryRow(ctx, q.getStopStmt, getStop, id)
-	var i GetStopRow
-	err := row.Scan(
-		&i.ID,
-		&i.Code,
-		&i.Name,
-		&i.Desc,
-		&i.Lat,
-		&i.Lon,
-		&i.ZoneID,
-		&i.Url,
-		&i.LocationType,
-		&i.Timezone,
-		&i.WheelchairBoarding,
-		&i.PlatformCode,
-		&i.Direction,
-	)
-	return i, err
+func (q *Queries) GetScheduleForStop(ctx context.Context, stopID string) ([]GetScheduleForStopRow, error) {
+	rows, err := q.query(ctx, q.getScheduleForStopStmt, getScheduleForStop, stopID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScheduleForStopRow
+	for rows.Next() {
+		var i GetScheduleForStopRow
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopHeadsign,
+			&i.ServiceID,
+			&i.RouteID,
+			&i.TripHeadsign,
+			&i.RouteID_2,
+			&i.AgencyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getStopForAgency = `-- name: GetStopForAgency :one
-SELECT DISTINCT
-    stops.id, stops.code, stops.name, stops."desc", stops.lat, stops.lon, stops.zone_id, stops.url, stops.location_type, stops.timezone, stops.wheelchair_boarding, stops.platform_code, stops.direction, stops.parent_station
+const getScheduleForStopOnDate = `-- name: GetScheduleForStopOnDate :many
+SELECT
+    st.trip_id,
+    st.arrival_time,
+    st.departure_time,
+    st.stop_headsign,
+    t.service_id,
+    t.route_id,
+    t.trip_headsign,
+    r.id as route_id,
+    r.agency_id
 FROM
-    stops
-    JOIN stop_times ON stops.id = stop_times.stop_id
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
+    stop_times st
+    JOIN trips t ON st.trip_id = t.id
+    JOIN routes r ON t.route_id = r.id
+    LEFT JOIN (
+        SELECT c.id AS service_id
+        FROM calendar c
+        WHERE c.start_date <= ?1
+          AND c.end_date >= ?1
+          AND (
+            (?2 = 'sunday' AND c.sunday = 1) OR
+            (?2 = 'monday' AND c.monday = 1) OR
+            (?2 = 'tuesday' AND c.tuesday = 1) OR
+            (?2 = 'wednesday' AND c.wednesday = 1) OR
+            (?2 = 'thursday' AND c.thursday = 1) OR
+            (?2 = 'friday' AND c.friday = 1) OR
+            (?2 = 'saturday' AND c.saturday = 1)
+          )
+    ) base ON t.service_id = base.service_id
+    LEFT JOIN (
+        SELECT cd.service_id
+        FROM calendar_dates cd
+        WHERE cd.date = ?1 AND cd.exception_type = 2
+    ) removed ON t.service_id = removed.service_id
+    LEFT JOIN (
+        SELECT cd.service_id
+        FROM calendar_dates cd
+        WHERE cd.date = ?1 AND cd.exception_type = 1
+    ) added ON t.service_id = added.service_id
 WHERE
-    stops.id = ?
-    AND routes.agency_id = ?
+    st.stop_id = ?3
+    AND (
+        (base.service_id IS NOT NULL AND removed.service_id IS NULL)
+        OR
+        added.service_id IS NOT NULL
+    )
+    AND st.arrival_time >= ?4
+    AND st.arrival_time < ?5
+    AND r.id IN (/*SLICE:route_ids*/?)
+ORDER BY
+    r.id, st.arrival_time
 `
 
-type GetStopForAgencyParams struct {
-	ID       string
-	AgencyID string
+type GetScheduleForStopOnDateParams struct {
+	TargetDate  string
+	Weekday     interface{}
+	StopID      string
+	WindowStart int64
+	WindowEnd   int64
+	RouteIds    []string
 }
 
-// Return the stop only if it is served by any route that belongs to the specified agency.
-// We join stop_times -> trips -> routes and filter by routes.agency_id to enforce agency ownership.
-func (q *Queries) GetStopForAgency(ctx context.Context, arg GetStopForAgencyParams) (Stop, error) {
-	row := q.queryRow(ctx, q.getStopForAgencyStmt, getStopForAgency, arg.ID, arg.AgencyID)
-	var i Stop
-	err := row.Scan(
-		&i.ID,
-		&i.Code,
-		&i.Name,
-		&i.Desc,
-		&i.Lat,
-		&i.Lon,
-		&i.ZoneID,
-		&i.Url,
-		&i.LocationType,
-		&i.Timezone,
-		&i.WheelchairBoarding,
-		&i.PlatformCode,
-		&i.Direction,
-		&i.ParentStation,
-	)
-	return i, err
+type GetScheduleForStopOnDateRow struct {
+	TripID        string
+	ArrivalTime   int64
+	DepartureTime int64
+	StopHeadsign  sql.NullString
+	ServiceID     string
+	RouteID       string
+	TripHeadsign  sql.NullString
+	RouteID_2     string
+	AgencyID      string
 }
 
-const getStopIDsForAgency = `-- name: GetStopIDsForAgency :many
-SELECT DISTINCT
-    s.id
+func (q *Queries) GetScheduleForStopOnDate(ctx context.Context, arg GetScheduleForStopOnDateParams) ([]GetScheduleForStopOnDateRow, error) {
+	query := getScheduleForStopOnDate
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.TargetDate)
+	queryParams = append(queryParams, arg.Weekday)
+	queryParams = append(queryParams, arg.StopID)
+	queryParams = append(queryParams, arg.WindowStart)
+	queryParams = append(queryParams, arg.WindowEnd)
+	if len(arg.RouteIds) > 0 {
+		for _, v := range arg.RouteIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:route_ids*/?", strings.Repeat(",?", len(arg.RouteIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:route_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetScheduleForStopOnDateRow
+	for rows.Next() {
+		var i GetScheduleForStopOnDateRow
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopHeadsign,
+			&i.ServiceID,
+			&i.RouteID,
+			&i.TripHeadsign,
+			&i.RouteID_2,
+			&i.AgencyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapeByID = `-- name: GetShapeByID :many
+SELECT
+    id, shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape
 FROM
-    stops s
-    JOIN stop_times st ON s.id = st.stop_id
-    JOIN trips t ON st.trip_id = t.id
-    JOIN routes r ON t.route_id = r.id
+    shapes
 WHERE
-    r.agency_id = ?
+    shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = ?1), ?1)
+ORDER BY
+    shape_pt_sequence
 `
 
-func (q *Queries) GetStopIDsForAgency(ctx context.Context, agencyID string) ([]string, error) {
-	rows, err := q.query(ctx, q.getStopIDsForAgencyStmt, getStopIDsForAgency, agencyID)
+// A shape with the same points as another returns the rows stored for that one
+func (q *Queries) GetShapeByID(ctx context.Context, shapeID string) ([]Shape, error) {
+	rows, err := q.query(ctx, q.getShapeByIDStmt, getShapeByID, shapeID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []Shape
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var i Shape
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShapeID,
+			&i.Lat,
+			&i.Lon,
+			&i.ShapePtSequence,
+			&i.ShapeDistTraveled,
+			&i.DistanceAlongShape,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapeContextForStopsWithoutDirection = `-- name: GetShapeContextForStopsWithoutDirection :many
+SELECT DISTINCT
+    st.stop_id,
+    t.shape_id,
+    s.lat,
+    s.lon,
+    st.shape_dist_traveled
+FROM stop_times st
+JOIN trips t ON st.trip_id = t.id
+JOIN stops s ON st.stop_id = s.id
+WHERE s.direction IS NULL
+`
+
+type GetShapeContextForStopsWithoutDirectionRow struct {
+	StopID            string
+	ShapeID           sql.NullString
+	Lat               float64
+	Lon               float64
+	ShapeDistTraveled sql.NullFloat64
+}
+
+// Bulk form of GetStopsWithShapeContextByIDs for every stop whose direction
+// has not been computed yet, with repeated trip patterns collapsed
+func (q *Queries) GetShapeContextForStopsWithoutDirection(ctx context.Context) ([]GetShapeContextForStopsWithoutDirectionRow, error) {
+	rows, err := q.query(ctx, q.getShapeContextForStopsWithoutDirectionStmt, getShapeContextForStopsWithoutDirection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapeContextForStopsWithoutDirectionRow
+	for rows.Next() {
+		var i GetShapeContextForStopsWithoutDirectionRow
+		if err := rows.Scan(
+			&i.StopID,
+			&i.ShapeID,
+			&i.Lat,
+			&i.Lon,
+			&i.ShapeDistTraveled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapeGeometries = `-- name: GetShapeGeometries :many
+SELECT
+    shape_id, geometry_id
+FROM
+    shape_geometries
+`
+
+func (q *Queries) GetShapeGeometries(ctx context.Context) ([]ShapeGeometry, error) {
+	rows, err := q.query(ctx, q.getShapeGeometriesStmt, getShapeGeometries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShapeGeometry
+	for rows.Next() {
+		var i ShapeGeometry
+		if err := rows.Scan(&i.ShapeID, &i.GeometryID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePointWindow = `-- name: GetShapePointWindow :many
+SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
+FROM shapes
+WHERE shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = ?1), ?1)
+  AND shape_pt_sequence BETWEEN ? AND ?
+ORDER BY shape_pt_sequence
+`
+
+type GetShapePointWindowRow struct {
+	Lat               float64
+	Lon               float64
+	ShapePtSequence   int64
+	ShapeDistTraveled sql.NullFloat64
+}
+
+func (q *Queries) GetShapePointWindow(ctx context.Context, shapeID string) ([]GetShapePointWindowRow, error) {
+	rows, err := q.query(ctx, q.getShapePointWindowStmt, getShapePointWindow, shapeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapePointWindowRow
+	for rows.Next() {
+		var i GetShapePointWindowRow
+		if err := rows.Scan(
+			&i.Lat,
+			&i.Lon,
+			&i.ShapePtSequence,
+			&i.ShapeDistTraveled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePoints = `-- name: GetShapePoints :many
+SELECT
+    lat,
+    lon
+FROM
+    shapes
+WHERE
+    shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = ?1), ?1)
+ORDER BY
+    shape_pt_sequence
+`
+
+type GetShapePointsRow struct {
+	Lat float64
+	Lon float64
+}
+
+func (q *Queries) GetShapePoints(ctx context.Context, shapeID string) ([]GetShapePointsRow, error) {
+	rows, err := q.query(ctx, q.getShapePointsStmt, getShapePoints, shapeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapePointsRow
+	for rows.Next() {
+		var i GetShapePointsRow
+		if err := rows.Scan(&i.Lat, &i.Lon); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePointsByIDs = `-- name: GetShapePointsByIDs :many
+SELECT g.shape_id, s.lat, s.lon, s.shape_pt_sequence, s.shape_dist_traveled
+FROM shape_geometries g
+JOIN shapes s ON s.shape_id = g.geometry_id
+WHERE g.shape_id IN (/*SLICE:shape_ids*/?)
+ORDER BY g.shape_id, s.shape_pt_sequence
+`
+
+type GetShapePointsByIDsRow struct {
+	ShapeID           string
+	Lat               float64
+	Lon               float64
+	ShapePtSequence   int64
+	ShapeDistTraveled sql.NullFloat64
+}
+
+// Only finds shapes mapped in shape_geometries, as every import maps them
+func (q *Queries) GetShapePointsByIDs(ctx context.Context, shapeIds []string) ([]GetShapePointsByIDsRow, error) {
+	query := getShapePointsByIDs
+	var queryParams []interface{}
+	if len(shapeIds) > 0 {
+		for _, v := range shapeIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:shape_ids*/?", strings.Repeat(",?", len(shapeIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:shape_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapePointsByIDsRow
+	for rows.Next() {
+		var i GetShapePointsByIDsRow
+		if err := rows.Scan(
+			&i.ShapeID,
+			&i.Lat,
+			&i.Lon,
+			&i.ShapePtSequence,
+			&i.ShapeDistTraveled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePointsByTripID = `-- name: GetShapePointsByTripID :many
+SELECT
+    s.id,
+    s.shape_id,
+    s.lat,
+    s.lon,
+    s.shape_pt_sequence,
+    s.shape_dist_traveled,
+    s.distance_along_shape
+FROM
+    trips t
+    LEFT JOIN shape_geometries g ON g.shape_id = t.shape_id
+    JOIN shapes s ON s.shape_id = COALESCE(g.geometry_id, t.shape_id)
+WHERE
+    t.id = ?
+ORDER BY
+    s.shape_pt_sequence ASC
+`
+
+func (q *Queries) GetShapePointsByTripID(ctx context.Context, id string) ([]Shape, error) {
+	rows, err := q.query(ctx, q.getShapePointsByTripIDStmt, getShapePointsByTripID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Shape
+	for rows.Next() {
+		var i Shape
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShapeID,
+			&i.Lat,
+			&i.Lon,
+			&i.ShapePtSequence,
+			&i.ShapeDistTraveled,
+			&i.DistanceAlongShape,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePointsForTrip = `-- name: GetShapePointsForTrip :many
+SELECT DISTINCT shapes.lat, shapes.lon, shapes.shape_pt_sequence
+FROM trips
+LEFT JOIN shape_geometries ON shape_geometries.shape_id = trips.shape_id
+JOIN shapes ON shapes.shape_id = COALESCE(shape_geometries.geometry_id, trips.shape_id)
+WHERE trips.id = ?
+ORDER BY shapes.shape_pt_sequence
+`
+
+type GetShapePointsForTripRow struct {
+	Lat             float64
+	Lon             float64
+	ShapePtSequence int64
+}
+
+func (q *Queries) GetShapePointsForTrip(ctx context.Context, id string) ([]GetShapePointsForTripRow, error) {
+	rows, err := q.query(ctx, q.getShapePointsForTripStmt, getShapePointsForTrip, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapePointsForTripRow
+	for rows.Next() {
+		var i GetShapePointsForTripRow
+		if err := rows.Scan(&i.Lat, &i.Lon, &i.ShapePtSequence); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapePointsWithDistance = `-- name: GetShapePointsWithDistance :many
+SELECT lat, lon, shape_pt_sequence, shape_dist_traveled
+FROM shapes
+WHERE shape_id = COALESCE((SELECT geometry_id FROM shape_geometries WHERE shape_geometries.shape_id = ?1), ?1)
+ORDER BY shape_pt_sequence
+`
+
+type GetShapePointsWithDistanceRow struct {
+	Lat               float64
+	Lon               float64
+	ShapePtSequence   int64
+	ShapeDistTraveled sql.NullFloat64
+}
+
+func (q *Queries) GetShapePointsWithDistance(ctx context.Context, shapeID string) ([]GetShapePointsWithDistanceRow, error) {
+	rows, err := q.query(ctx, q.getShapePointsWithDistanceStmt, getShapePointsWithDistance, shapeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapePointsWithDistanceRow
+	for rows.Next() {
+		var i GetShapePointsWithDistanceRow
+		if err := rows.Scan(
+			&i.Lat,
+			&i.Lon,
+			&i.ShapePtSequence,
+			&i.ShapeDistTraveled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShapesGroupedByTripHeadSign = `-- name: GetShapesGroupedByTripHeadSign :many
+SELECT DISTINCT s.lat, s.lon, s.shape_pt_sequence
+FROM shapes s
+         JOIN (
+    SELECT COALESCE(g.geometry_id, trips.shape_id) AS geometry_id
+    FROM trips
+             LEFT JOIN shape_geometries g ON g.shape_id = trips.shape_id
+    WHERE route_id = ?1
+      AND trip_headsign = ?2
+      AND trips.shape_id IS NOT NULL
+    LIMIT 1
+) t ON s.shape_id = t.geometry_id
+ORDER BY s.shape_pt_sequence
+`
+
+type GetShapesGroupedByTripHeadSignParams struct {
+	RouteID      string
+	TripHeadsign sql.NullString
+}
+
+type GetShapesGroupedByTripHeadSignRow struct {
+	Lat             float64
+	Lon             float64
+	ShapePtSequence int64
+}
+
+func (q *Queries) GetShapesGroupedByTripHeadSign(ctx context.Context, arg GetShapesGroupedByTripHeadSignParams) ([]GetShapesGroupedByTripHeadSignRow, error) {
+	rows, err := q.query(ctx, q.getShapesGroupedByTripHeadSignStmt, getShapesGroupedByTripHeadSign, arg.RouteID, arg.TripHeadsign)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShapesGroupedByTripHeadSignRow
+	for rows.Next() {
+		var i GetShapesGroupedByTripHeadSignRow
+		if err := rows.Scan(&i.Lat, &i.Lon, &i.ShapePtSequence); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStop = `-- name: GetStop :one
+SELECT
+    id,
+    code,
+    name,
+    desc,
+    lat,
+    lon,
+    zone_id,
+    url,
+    location_type,
+    timezone,
+    wheelchair_boarding,
+    platform_code,
+    direction
+FROM
+    stops
+WHERE
+    id = ?
+LIMIT
+    1
+`
+
+type GetStopRow struct {
+	ID                 string
+	Code               sql.NullString
+	Name               sql.NullString
+	Desc               sql.NullString
+	Lat                float64
+	Lon                float64
+	ZoneID             sql.NullString
+	Url                sql.NullString
+	LocationType       sql.NullInt64
+	Timezone           sql.NullString
+	WheelchairBoarding sql.NullInt64
+	PlatformCode       sql.NullString
+	Direction          sql.NullString
+}
+
+func (q *Queries) GetStop(ctx context.Context, id string) (GetStopRow, error) {
+	row := q.queryRow(ctx, q.getStopStmt, getStop, id)
+	var i GetStopRow
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Desc,
+		&i.Lat,
+		&i.Lon,
+		&i.ZoneID,
+		&i.Url,
+		&i.LocationType,
+		&i.Timezone,
+		&i.WheelchairBoarding,
+		&i.PlatformCode,
+		&i.Direction,
+	)
+	return i, err
+}
+
+const getStopForAgency = `-- name: GetStopForAgency :one
+SELECT DISTINCT
+    stops.id, stops.code, stops.name, stops."desc", stops.lat, stops.lon, stops.zone_id, stops.url, stops.location_type, stops.timezone, stops.wheelchair_boarding, stops.platform_code, stops.direction, stops.parent_station
+FROM
+    stops
+    JOIN stop_times ON stops.id = stop_times.stop_id
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+WHERE
+    stops.id = ?
+    AND routes.agency_id = ?
+`
+
+type GetStopForAgencyParams struct {
+	ID       string
+	AgencyID string
+}
+
+// Return the stop only if it is served by any route that belongs to the specified agency.
+// We join stop_times -> trips -> routes and filter by routes.agency_id to enforce agency ownership.
+func (q *Queries) GetStopForAgency(ctx context.Context, arg GetStopForAgencyParams) (Stop, error) {
+	row := q.queryRow(ctx, q.getStopForAgencyStmt, getStopForAgency, arg.ID, arg.AgencyID)
+	var i Stop
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Desc,
+		&i.Lat,
+		&i.Lon,
+		&i.ZoneID,
+		&i.Url,
+		&i.LocationType,
+		&i.Timezone,
+		&i.WheelchairBoarding,
+		&i.PlatformCode,
+		&i.Direction,
+		&i.ParentStation,
+	)
+	return i, err
+}
+
+const getStopIDsForAgency = `-- name: GetStopIDsForAgency :many
+SELECT DISTINCT
+    s.id
+FROM
+    stops s
+    JOIN stop_times st ON s.id = st.stop_id
+    JOIN trips t ON st.trip_id = t.id
+    JOIN routes r ON t.route_id = r.id
+WHERE
+    r.agency_id = ?
+`
+
+func (q *Queries) GetStopIDsForAgency(ctx context.Context, agencyID string) ([]string, error) {
+	rows, err := q.query(ctx, q.getStopIDsForAgencyStmt, getStopIDsForAgency, agencyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopIDsForRoute = `-- name: GetStopIDsForRoute :many
+SELECT DISTINCT
+    stop_times.stop_id
+FROM
+    stop_times
+        JOIN trips ON stop_times.trip_id = trips.id
+WHERE
+    trips.route_id = ?
+`
+
+func (q *Queries) GetStopIDsForRoute(ctx context.Context, routeID string) ([]string, error) {
+	rows, err := q.query(ctx, q.getStopIDsForRouteStmt, getStopIDsForRoute, routeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var stop_id string
+		if err := rows.Scan(&stop_id); err != nil {
+			return nil, err
+		}
+		items = append(items, stop_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopIDsForTrip = `-- name: GetStopIDsForTrip :many
+SELECT DISTINCT
+    stop_times.stop_id
+FROM
+    stop_times
+WHERE
+    stop_times.trip_id = ?
+`
+
+func (q *Queries) GetStopIDsForTrip(ctx context.Context, tripID string) ([]string, error) {
+	rows, err := q.query(ctx, q.getStopIDsForTripStmt, getStopIDsForTrip, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var stop_id string
+		if err := rows.Scan(&stop_id); err != nil {
+			return nil, err
+		}
+		items = append(items, stop_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopTimesByStopIDs = `-- name: GetStopTimesByStopIDs :many
+SELECT
+    trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint, distance_along_shape
+FROM
+    stop_times
+WHERE
+    stop_id IN (/*SLICE:stop_ids*/?)
+`
+
+func (q *Queries) GetStopTimesByStopIDs(ctx context.Context, stopIds []string) ([]StopTime, error) {
+	query := getStopTimesByStopIDs
+	var queryParams []interface{}
+	if len(stopIds) > 0 {
+		for _, v := range stopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StopTime
+	for rows.Next() {
+		var i StopTime
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopTimesForStopInWindow = `-- name: GetStopTimesForStopInWindow :many
+SELECT
+    st.trip_id, st.arrival_time, st.departure_time, st.stop_id, st.stop_sequence, st.stop_headsign, st.pickup_type, st.drop_off_type, st.shape_dist_traveled, st.timepoint, st.distance_along_shape,
+    t.route_id,
+    t.service_id,
+    t.trip_headsign,
+    t.block_id
+FROM stop_times st
+         JOIN trips t ON st.trip_id = t.id
+WHERE st.stop_id = ?1
+  AND (
+    (st.arrival_time BETWEEN ?2 AND ?3)
+        OR
+    (st.departure_time BETWEEN ?2 AND ?3)
+    )
+ORDER BY st.arrival_time
+`
+
+type GetStopTimesForStopInWindowParams struct {
+	StopID      string
+	WindowStart int64
+	WindowEnd   int64
+}
+
+type GetStopTimesForStopInWindowRow struct {
+	TripID             string
+	ArrivalTime        int64
+	DepartureTime      int64
+	StopID             string
+	StopSequence       int64
+	StopHeadsign       sql.NullString
+	PickupType         sql.NullInt64
+	DropOffType        sql.NullInt64
+	ShapeDistTraveled  sql.NullFloat64
+	Timepoint          sql.NullInt64
+	DistanceAlongShape sql.NullFloat64
+	RouteID            string
+	ServiceID          string
+	TripHeadsign       sql.NullString
+	BlockID            sql.NullString
+}
+
+func (q *Queries) GetStopTimesForStopInWindow(ctx context.Context, arg GetStopTimesForStopInWindowParams) ([]GetStopTimesForStopInWindowRow, error) {
+	rows, err := q.query(ctx, q.getStopTimesForStopInWindowStmt, getStopTimesForStopInWindow, arg.StopID, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStopTimesForStopInWindowRow
+	for rows.Next() {
+		var i GetStopTimesForStopInWindowRow
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+			&i.RouteID,
+			&i.ServiceID,
+			&i.TripHeadsign,
+			&i.BlockID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopTimesForTrip = `-- name: GetStopTimesForTrip :many
+SELECT
+    trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint, distance_along_shape
+FROM
+    stop_times
+WHERE
+    trip_id = ?
+ORDER BY
+    stop_sequence
+`
+
+func (q *Queries) GetStopTimesForTrip(ctx context.Context, tripID string) ([]StopTime, error) {
+	rows, err := q.query(ctx, q.getStopTimesForTripStmt, getStopTimesForTrip, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StopTime
+	for rows.Next() {
+		var i StopTime
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopTimesForTripIDs = `-- name: GetStopTimesForTripIDs :many
+SELECT trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint, distance_along_shape FROM stop_times
+WHERE trip_id IN (/*SLICE:trip_ids*/?)
+ORDER BY trip_id, stop_sequence
+`
+
+func (q *Queries) GetStopTimesForTripIDs(ctx context.Context, tripIds []string) ([]StopTime, error) {
+	query := getStopTimesForTripIDs
+	var queryParams []interface{}
+	if len(tripIds) > 0 {
+		for _, v := range tripIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:trip_ids*/?", strings.Repeat(",?", len(tripIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:trip_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StopTime
+	for rows.Next() {
+		var i StopTime
+		if err := rows.Scan(
+			&i.TripID,
+			&i.ArrivalTime,
+			&i.DepartureTime,
+			&i.StopID,
+			&i.StopSequence,
+			&i.StopHeadsign,
+			&i.PickupType,
+			&i.DropOffType,
+			&i.ShapeDistTraveled,
+			&i.Timepoint,
+			&i.DistanceAlongShape,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopsByCode = `-- name: GetStopsByCode :many
+SELECT
+    id, code, name, "desc", lat, lon, zone_id, url, location_type, timezone, wheelchair_boarding, platform_code, direction, parent_station
+FROM
+    stops
+WHERE
+    code = ?
+ORDER BY
+    id
+`
+
+// Stop codes are only unique within an agency, if at all, so several stops may match
+func (q *Queries) GetStopsByCode(ctx context.Context, code sql.NullString) ([]Stop, error) {
+	rows, err := q.query(ctx, q.getStopsByCodeStmt, getStopsByCode, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Stop
+	for rows.Next() {
+		var i Stop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Desc,
+			&i.Lat,
+			&i.Lon,
+			&i.ZoneID,
+			&i.Url,
+			&i.LocationType,
+			&i.Timezone,
+			&i.WheelchairBoarding,
+			&i.PlatformCode,
+			&i.Direction,
+			&i.ParentStation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStopsByIDs = `-- name: GetStopsByIDs :many
+SELECT
+    id, code, name, "desc", lat, lon, zone_id, url, location_type, timezone, wheelchair_boarding, platform_code, direction, parent_station
+FROM
+    stops
+WHERE
+    id IN (/*SLICE:stop_ids*/?)
+ORDER BY
+    id
+`
+
+func (q *Queries) GetStopsByIDs(ctx context.Context, stopIds []string) ([]Stop, error) {
+	query := getStopsByIDs
+	var queryParams []interface{}
+	if len(stopIds) > 0 {
+		for _, v := range stopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Stop
+	for rows.Next() {
+		var i Stop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Desc,
+			&i.Lat,
+			&i.Lon,
+			&i.ZoneID,
+			&i.Url,
+			&i.LocationType,
+			&i.Timezone,
+			&i.WheelchairBoarding,
+			&i.PlatformCode,
+			&i.Direction,
+			&i.ParentStation,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2735,29 +4915,46 @@ func (q *Queries) GetStopIDsForAgency(ctx context.Context, agencyID string) ([]s
 	return items, nil
 }
 
-const getStopIDsForRoute = `-- name: GetStopIDsForRoute :many
+const getStopsForRoute = `-- name: GetStopsForRoute :many
 SELECT DISTINCT
-    stop_times.stop_id
+    stops.id, stops.code, stops.name, stops."desc", stops.lat, stops.lon, stops.zone_id, stops.url, stops.location_type, stops.timezone, stops.wheelchair_boarding, stops.platform_code, stops.direction, stops.parent_station
 FROM
     stop_times
-        JOIN trips ON stop_times.trip_id = trips.id
+    JOIN trips ON stop_times.trip_id = trips.id
+    JOIN routes ON trips.route_id = routes.id
+    JOIN stops ON stop_times.stop_id = stops.id
 WHERE
-    trips.route_id = ?
+    routes.id = ?
 `
 
-func (q *Queries) GetStopIDsForRoute(ctx context.Context, routeID string) ([]string, error) {
-	rows, err := q.query(ctx, q.getStopIDsForRouteStmt, getStopIDsForRoute, routeID)
+func (q *Queries) GetStopsForRoute(ctx context.Context, id string) ([]Stop, error) {
+	rows, err := q.query(ctx, q.getStopsForRouteStmt, getStopsForRoute, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []Stop
 	for rows.Next() {
-		var stop_id string
-		if err := rows.Scan(&stop_id); err != nil {
+		var i Stop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Desc,
+			&i.Lat,
+			&i.Lon,
+			&i.ZoneID,
+			&i.Url,
+			&i.LocationType,
+			&i.Timezone,
+			&i.WheelchairBoarding,
+			&i.PlatformCode,
+			&i.Direction,
+			&i.ParentStation,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, stop_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2768,17 +4965,40 @@ func (q *Queries) GetStopIDsForRoute(ctx context.Context, routeID string) ([]str
 	return items, nil
 }
 
-const getStopIDsForTrip = `-- name: GetStopIDsForTrip :many
-SELECT DISTINCT
-    stop_times.stop_id
-FROM
-    stop_times
-WHERE
-    stop_times.trip_id = ?
+const getStopsWithActiveServiceOnDate = `-- name: GetStopsWithActiveServiceOnDate :many
+SELECT DISTINCT st.stop_id
+FROM stop_times st
+JOIN trips t ON st.trip_id = t.id
+WHERE st.stop_id IN (/*SLICE:stop_ids*/?)
+  AND t.service_id IN (/*SLICE:service_ids*/?)
 `
 
-func (q *Queries) GetStopIDsForTrip(ctx context.Context, tripID string) ([]string, error) {
-	rows, err := q.query(ctx, q.getStopIDsForTripStmt, getStopIDsForTrip, tripID)
+type GetStopsWithActiveServiceOnDateParams struct {
+	StopIds    []string
+	ServiceIds []string
+}
+
+// Returns stop IDs that have at least one trip with active service on the given date
+func (q *Queries) GetStopsWithActiveServiceOnDate(ctx context.Context, arg GetStopsWithActiveServiceOnDateParams) ([]string, error) {
+	query := getStopsWithActiveServiceOnDate
+	var queryParams []interface{}
+	if len(arg.StopIds) > 0 {
+		for _, v := range arg.StopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(arg.StopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -2800,45 +5020,50 @@ func (q *Queries) GetStopIDsForTrip(ctx context.Context, tripID string) ([]strin
 	return items, nil
 }
 
-const getStopTimesByStopIDs = `-- name: GetStopTimesByStopIDs :many
+const getStopsWithShapeContext = `-- name: GetStopsWithShapeContext :many
 SELECT
-    trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint
-FROM
-    stop_times
-WHERE
-    stop_id IN (/*SLICE:stop_ids*/?)
+    s.id, s.lat, s.lon, s.name, s.code, s.direction,
+    st.trip_id, st.stop_sequence, st.shape_dist_traveled,
+    t.shape_id
+FROM stops s
+JOIN stop_times st ON s.id = st.stop_id
+JOIN trips t ON st.trip_id = t.id
+WHERE s.id = ?
 `
 
-func (q *Queries) GetStopTimesByStopIDs(ctx context.Context, stopIds []string) ([]StopTime, error) {
-	query := getStopTimesByStopIDs
-	var queryParams []interface{}
-	if len(stopIds) > 0 {
-		for _, v := range stopIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+type GetStopsWithShapeContextRow struct {
+	ID                string
+	Lat               float64
+	Lon               float64
+	Name              sql.NullString
+	Code              sql.NullString
+	Direction         sql.NullString
+	TripID            string
+	StopSequence      int64
+	ShapeDistTraveled sql.NullFloat64
+	ShapeID           sql.NullString
+}
+
+func (q *Queries) GetStopsWithShapeContext(ctx context.Context, id string) ([]GetStopsWithShapeContextRow, error) {
+	rows, err := q.query(ctx, q.getStopsWithShapeContextStmt, getStopsWithShapeContext, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []StopTime
+	var items []GetStopsWithShapeContextRow
 	for rows.Next() {
-		var i StopTime
+		var i GetStopsWithShapeContextRow
 		if err := rows.Scan(
+			&i.ID,
+			&i.Lat,
+			&i.Lon,
+			&i.Name,
+			&i.Code,
+			&i.Direction,
 			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
-			&i.StopID,
 			&i.StopSequence,
-			&i.StopHeadsign,
-			&i.PickupType,
-			&i.DropOffType,
 			&i.ShapeDistTraveled,
-			&i.Timepoint,
+			&i.ShapeID,
 		); err != nil {
 			return nil, err
 		}
@@ -2853,71 +5078,52 @@ func (q *Queries) GetStopTimesByStopIDs(ctx context.Context, stopIds []string) (
 	return items, nil
 }
 
-const getStopTimesForStopInWindow = `-- name: GetStopTimesForStopInWindow :many
+const getStopsWithShapeContextByIDs = `-- name: GetStopsWithShapeContextByIDs :many
 SELECT
-    st.trip_id, st.arrival_time, st.departure_time, st.stop_id, st.stop_sequence, st.stop_headsign, st.pickup_type, st.drop_off_type, st.shape_dist_traveled, st.timepoint,
-    t.route_id,
-    t.service_id,
-    t.trip_headsign,
-    t.block_id
+    st.stop_id,
+    t.shape_id,
+    s.lat,
+    s.lon,
+    st.shape_dist_traveled
 FROM stop_times st
-         JOIN trips t ON st.trip_id = t.id
-WHERE st.stop_id = ?1
-  AND (
-    (st.arrival_time BETWEEN ?2 AND ?3)
-        OR
-    (st.departure_time BETWEEN ?2 AND ?3)
-    )
-ORDER BY st.arrival_time
+JOIN trips t ON st.trip_id = t.id
+JOIN stops s ON st.stop_id = s.id
+WHERE st.stop_id IN (/*SLICE:stop_ids*/?)
 `
 
-type GetStopTimesForStopInWindowParams struct {
-	StopID           string
-	WindowStartNanos int64
-	WindowEndNanos   int64
-}
-
-type GetStopTimesForStopInWindowRow struct {
-	TripID            string
-	ArrivalTime       int64
-	DepartureTime     int64
+type GetStopsWithShapeContextByIDsRow struct {
 	StopID            string
-	StopSequence      int64
-	StopHeadsign      sql.NullString
-	PickupType        sql.NullInt64
-	DropOffType       sql.NullInt64
+	ShapeID           sql.NullString
+	Lat               float64
+	Lon               float64
 	ShapeDistTraveled sql.NullFloat64
-	Timepoint         sql.NullInt64
-	RouteID           string
-	ServiceID         string
-	TripHeadsign      sql.NullString
-	BlockID           sql.NullString
 }
 
-func (q *Queries) GetStopTimesForStopInWindow(ctx context.Context, arg GetStopTimesForStopInWindowParams) ([]GetStopTimesForStopInWindowRow, error) {
-	rows, err := q.query(ctx, q.getStopTimesForStopInWindowStmt, getStopTimesForStopInWindow, arg.StopID, arg.WindowStartNanos, arg.WindowEndNanos)
+func (q *Queries) GetStopsWithShapeContextByIDs(ctx context.Context, stopIds []string) ([]GetStopsWithShapeContextByIDsRow, error) {
+	query := getStopsWithShapeContextByIDs
+	var queryParams []interface{}
+	if len(stopIds) > 0 {
+		for _, v := range stopIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetStopTimesForStopInWindowRow
+	var items []GetStopsWithShapeContextByIDsRow
 	for rows.Next() {
-		var i GetStopTimesForStopInWindowRow
+		var i GetStopsWithShapeContextByIDsRow
 		if err := rows.Scan(
-			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
 			&i.StopID,
-			&i.StopSequence,
-			&i.StopHeadsign,
-			&i.PickupType,
-			&i.DropOffType,
+			&i.ShapeID,
+			&i.Lat,
+			&i.Lon,
 			&i.ShapeDistTraveled,
-			&i.Timepoint,
-			&i.RouteID,
-			&i.ServiceID,
-			&i.TripHeadsign,
-			&i.BlockID,
 		); err != nil {
 			return nil, err
 		}
@@ -2932,37 +5138,46 @@ func (q *Queries) GetStopTimesForStopInWindow(ctx context.Context, arg GetStopTi
 	return items, nil
 }
 
-const getStopTimesForTrip = `-- name: GetStopTimesForTrip :many
+const getStopsWithTripContext = `-- name: GetStopsWithTripContext :many
 SELECT
-    trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint
-FROM
-    stop_times
-WHERE
-    trip_id = ?
-ORDER BY
-    stop_sequence
+    s.id, s.lat, s.lon, s.name, s.code,
+    st.trip_id, st.stop_sequence,
+    t.shape_id
+FROM stops s
+JOIN stop_times st ON s.id = st.stop_id
+JOIN trips t ON st.trip_id = t.id
+WHERE s.id = ?
 `
 
-func (q *Queries) GetStopTimesForTrip(ctx context.Context, tripID string) ([]StopTime, error) {
-	rows, err := q.query(ctx, q.getStopTimesForTripStmt, getStopTimesForTrip, tripID)
+type GetStopsWithTripContextRow struct {
+	ID           string
+	Lat          float64
+	Lon          float64
+	Name         sql.NullString
+	Code         sql.NullString
+	TripID       string
+	StopSequence int64
+	ShapeID      sql.NullString
+}
+
+func (q *Queries) GetStopsWithTripContext(ctx context.Context, id string) ([]GetStopsWithTripContextRow, error) {
+	rows, err := q.query(ctx, q.getStopsWithTripContextStmt, getStopsWithTripContext, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []StopTime
+	var items []GetStopsWithTripContextRow
 	for rows.Next() {
-		var i StopTime
+		var i GetStopsWithTripContextRow
 		if err := rows.Scan(
-			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
-			&i.StopID,
-			&i.StopSequence,
-			&i.StopHeadsign,
-			&i.PickupType,
-			&i.DropOffType,
-			&i.ShapeDistTraveled,
-			&i.Timepoint,
+			&i.ID,
+			&i.Lat,
+			&i.Lon,
+			&i.Name,
+			&i.Code,
+			&i.TripID,
+			&i.StopSequence,
+			&i.ShapeID,
 		); err != nil {
 			return nil, err
 		}
@@ -2977,42 +5192,31 @@ func (q *Queries) GetStopTimesForTrip(ctx context.Context, tripID string) ([]Sto
 	return items, nil
 }
 
-const getStopTimesForTripIDs = `-- name: GetStopTimesForTripIDs :many
-SELECT trip_id, arrival_time, departure_time, stop_id, stop_sequence, stop_headsign, pickup_type, drop_off_type, shape_dist_traveled, timepoint FROM stop_times
-WHERE trip_id IN (/*SLICE:trip_ids*/?)
-ORDER BY trip_id, stop_sequence
+const getTransfersFromStop = `-- name: GetTransfersFromStop :many
+SELECT id, from_stop_id, to_stop_id, from_route_id, to_route_id, from_trip_id, to_trip_id, transfer_type, min_transfer_time FROM transfers
+WHERE from_stop_id = ?
+ORDER BY to_stop_id, id
 `
 
-func (q *Queries) GetStopTimesForTripIDs(ctx context.Context, tripIds []string) ([]StopTime, error) {
-	query := getStopTimesForTripIDs
-	var queryParams []interface{}
-	if len(tripIds) > 0 {
-		for _, v := range tripIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:trip_ids*/?", strings.Repeat(",?", len(tripIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:trip_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) GetTransfersFromStop(ctx context.Context, fromStopID string) ([]Transfer, error) {
+	rows, err := q.query(ctx, q.getTransfersFromStopStmt, getTransfersFromStop, fromStopID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []StopTime
+	var items []Transfer
 	for rows.Next() {
-		var i StopTime
+		var i Transfer
 		if err := rows.Scan(
-			&i.TripID,
-			&i.ArrivalTime,
-			&i.DepartureTime,
-			&i.StopID,
-			&i.StopSequence,
-			&i.StopHeadsign,
-			&i.PickupType,
-			&i.DropOffType,
-			&i.ShapeDistTraveled,
-			&i.Timepoint,
+			&i.ID,
+			&i.FromStopID,
+			&i.ToStopID,
+			&i.FromRouteID,
+			&i.ToRouteID,
+			&i.FromTripID,
+			&i.ToTripID,
+			&i.TransferType,
+			&i.MinTransferTime,
 		); err != nil {
 			return nil, err
 		}
@@ -3027,51 +5231,67 @@ func (q *Queries) GetStopTimesForTripIDs(ctx context.Context, tripIds []string)
 	return items, nil
 }
 
-const getStopsByIDs = `-- name: GetStopsByIDs :many
+const getTranslations = `-- name: GetTranslations :many
 SELECT
-    id, code, name, "desc", lat, lon, zone_id, url, location_type, timezone, wheelchair_boarding, platform_code, direction, parent_station
+    id, table_name, field_name, language, translation, record_id, record_sub_id, field_value
 FROM
-    stops
+    translations
 WHERE
-    id IN (/*SLICE:stop_ids*/?)
+    table_name = ?
+    AND language IN (/*SLICE:languages*/?)
+    AND (
+        record_id IN (/*SLICE:record_ids*/?)
+        OR field_value IS NOT NULL
+    )
 ORDER BY
     id
 `
 
-func (q *Queries) GetStopsByIDs(ctx context.Context, stopIds []string) ([]Stop, error) {
-	query := getStopsByIDs
+type GetTranslationsParams struct {
+	TableName string
+	Languages []string
+	RecordIds []string
+}
+
+// The translations of a table into any of the languages, both those of the
+// records and those matching a field value
+func (q *Queries) GetTranslations(ctx context.Context, arg GetTranslationsParams) ([]Translation, error) {
+	query := getTranslations
 	var queryParams []interface{}
-	if len(stopIds) > 0 {
-		for _, v := range stopIds {
+	queryParams = append(queryParams, arg.TableName)
+	if len(arg.Languages) > 0 {
+		for _, v := range arg.Languages {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:languages*/?", strings.Repeat(",?", len(arg.Languages))[1:], 1)
 	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+		query = strings.Replace(query, "/*SLICE:languages*/?", "NULL", 1)
+	}
+	if len(arg.RecordIds) > 0 {
+		for _, v := range arg.RecordIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:record_ids*/?", strings.Repeat(",?", len(arg.RecordIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:record_ids*/?", "NULL", 1)
 	}
 	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Stop
+	var items []Translation
 	for rows.Next() {
-		var i Stop
+		var i Translation
 		if err := rows.Scan(
 			&i.ID,
-			&i.Code,
-			&i.Name,
-			&i.Desc,
-			&i.Lat,
-			&i.Lon,
-			&i.ZoneID,
-			&i.Url,
-			&i.LocationType,
-			&i.Timezone,
-			&i.WheelchairBoarding,
-			&i.PlatformCode,
-			&i.Direction,
-			&i.ParentStation,
+			&i.TableName,
+			&i.FieldName,
+			&i.Language,
+			&i.Translation,
+			&i.RecordID,
+			&i.RecordSubID,
+			&i.FieldValue,
 		); err != nil {
 			return nil, err
 		}
@@ -3086,101 +5306,104 @@ func (q *Queries) GetStopsByIDs(ctx context.Context, stopIds []string) ([]Stop,
 	return items, nil
 }
 
-const getStopsForRoute = `-- name: GetStopsForRoute :many
-SELECT DISTINCT
-    stops.id, stops.code, stops.name, stops."desc", stops.lat, stops.lon, stops.zone_id, stops.url, stops.location_type, stops.timezone, stops.wheelchair_boarding, stops.platform_code, stops.direction, stops.parent_station
+const getTrip = `-- name: GetTrip :one
+SELECT
+    id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
 FROM
-    stop_times
-    JOIN trips ON stop_times.trip_id = trips.id
-    JOIN routes ON trips.route_id = routes.id
-    JOIN stops ON stop_times.stop_id = stops.id
+    trips
 WHERE
-    routes.id = ?
+    id = ?
 `
 
-func (q *Queries) GetStopsForRoute(ctx context.Context, id string) ([]Stop, error) {
-	rows, err := q.query(ctx, q.getStopsForRouteStmt, getStopsForRoute, id)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Stop
-	for rows.Next() {
-		var i Stop
-		if err := rows.Scan(
-			&i.ID,
-			&i.Code,
-			&i.Name,
-			&i.Desc,
-			&i.Lat,
-			&i.Lon,
-			&i.ZoneID,
-			&i.Url,
-			&i.LocationType,
-			&i.Timezone,
-			&i.WheelchairBoarding,
-			&i.PlatformCode,
-			&i.Direction,
-			&i.ParentStation,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetTrip(ctx context.Context, id string) (Trip, error) {
+	row := q.queryRow(ctx, q.getTripStmt, getTrip, id)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.RouteID,
+		&i.ServiceID,
+		&i.TripHeadsign,
+		&i.TripShortName,
+		&i.DirectionID,
+		&i.BlockID,
+		&i.ShapeID,
+		&i.WheelchairAccessible,
+		&i.BikesAllowed,
+	)
+	return i, err
 }
 
-const getStopsWithActiveServiceOnDate = `-- name: GetStopsWithActiveServiceOnDate :many
-SELECT DISTINCT st.stop_id
-FROM stop_times st
-JOIN trips t ON st.trip_id = t.id
-WHERE st.stop_id IN (/*SLICE:stop_ids*/?)
-  AND t.service_id IN (/*SLICE:service_ids*/?)
+const getTripServiceSpan = `-- name: GetTripServiceSpan :one
+SELECT
+    CAST(COALESCE(MIN(departure_time), 0) AS INTEGER) AS first_departure_time,
+    CAST(COALESCE(MAX(arrival_time), 0) AS INTEGER) AS last_arrival_time
+FROM stop_times
+WHERE trip_id = ?
 `
 
-type GetStopsWithActiveServiceOnDateParams struct {
-	StopIds    []string
-	ServiceIds []string
+type GetTripServiceSpanRow struct {
+	FirstDepartureTime int64
+	LastArrivalTime    int64
 }
 
-// Returns stop IDs that have at least one trip with active service on the given date
-func (q *Queries) GetStopsWithActiveServiceOnDate(ctx context.Context, arg GetStopsWithActiveServiceOnDateParams) ([]string, error) {
-	query := getStopsWithActiveServiceOnDate
-	var queryParams []interface{}
-	if len(arg.StopIds) > 0 {
-		for _, v := range arg.StopIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(arg.StopIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
-	}
-	if len(arg.ServiceIds) > 0 {
-		for _, v := range arg.ServiceIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+// The first departure and last arrival of a trip, in seconds since the
+// start of its service day. Both exceed 24 hours for trips running past midnight.
+func (q *Queries) GetTripServiceSpan(ctx context.Context, tripID string) (GetTripServiceSpanRow, error) {
+	row := q.queryRow(ctx, q.getTripServiceSpanStmt, getTripServiceSpan, tripID)
+	var i GetTripServiceSpanRow
+	err := row.Scan(&i.FirstDepartureTime, &i.LastArrivalTime)
+	return i, err
+}
+
+const getTripsByBlockID = `-- name: GetTripsByBlockID :many
+SELECT
+    id,
+    route_id,
+    service_id,
+    trip_headsign,
+    trip_short_name,
+    direction_id,
+    block_id,
+    shape_id
+FROM
+    trips
+WHERE
+    block_id = ?
+`
+
+type GetTripsByBlockIDRow struct {
+	ID            string
+	RouteID       string
+	ServiceID     string
+	TripHeadsign  sql.NullString
+	TripShortName sql.NullString
+	DirectionID   sql.NullInt64
+	BlockID       sql.NullString
+	ShapeID       sql.NullString
+}
+
+func (q *Queries) GetTripsByBlockID(ctx context.Context, blockID sql.NullString) ([]GetTripsByBlockIDRow, error) {
+	rows, err := q.query(ctx, q.getTripsByBlockIDStmt, getTripsByBlockID, blockID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetTripsByBlockIDRow
 	for rows.Next() {
-		var stop_id string
-		if err := rows.Scan(&stop_id); err != nil {
+		var i GetTripsByBlockIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RouteID,
+			&i.ServiceID,
+			&i.TripHeadsign,
+			&i.TripShortName,
+			&i.DirectionID,
+			&i.BlockID,
+			&i.ShapeID,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, stop_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -3191,50 +5414,66 @@ func (q *Queries) GetStopsWithActiveServiceOnDate(ctx context.Context, arg GetSt
 	return items, nil
 }
 
-const getStopsWithShapeContext = `-- name: GetStopsWithShapeContext :many
+const getTripsByBlockIDOrdered = `-- name: GetTripsByBlockIDOrdered :many
 SELECT
-    s.id, s.lat, s.lon, s.name, s.code, s.direction,
-    st.trip_id, st.stop_sequence, st.shape_dist_traveled,
-    t.shape_id
-FROM stops s
-JOIN stop_times st ON s.id = st.stop_id
-JOIN trips t ON st.trip_id = t.id
-WHERE s.id = ?
+    t.id,
+    t.block_id,
+    t.service_id,
+    MIN(st.departure_time) AS first_departure_time,
+    MAX(st.arrival_time) AS last_arrival_time
+FROM
+    trips t
+    JOIN stop_times st ON st.trip_id = t.id
+WHERE
+    t.block_id = ?
+    AND t.service_id IN (/*SLICE:service_ids*/?)
+GROUP BY
+    t.id,
+    t.block_id,
+    t.service_id
+ORDER BY
+    MIN(st.departure_time)
 `
 
-type GetStopsWithShapeContextRow struct {
-	ID                string
-	Lat               float64
-	Lon               float64
-	Name              sql.NullString
-	Code              sql.NullString
-	Direction         sql.NullString
-	TripID            string
-	StopSequence      int64
-	ShapeDistTraveled sql.NullFloat64
-	ShapeID           sql.NullString
+type GetTripsByBlockIDOrderedParams struct {
+	BlockID    sql.NullString
+	ServiceIds []string
+}
+
+type GetTripsByBlockIDOrderedRow struct {
+	ID                 string
+	BlockID            sql.NullString
+	ServiceID          string
+	FirstDepartureTime interface{}
+	LastArrivalTime    interface{}
 }
 
-func (q *Queries) GetStopsWithShapeContext(ctx context.Context, id string) ([]GetStopsWithShapeContextRow, error) {
-	rows, err := q.query(ctx, q.getStopsWithShapeContextStmt, getStopsWithShapeContext, id)
+func (q *Queries) GetTripsByBlockIDOrdered(ctx context.Context, arg GetTripsByBlockIDOrderedParams) ([]GetTripsByBlockIDOrderedRow, error) {
+	query := getTripsByBlockIDOrdered
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.BlockID)
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetStopsWithShapeContextRow
+	var items []GetTripsByBlockIDOrderedRow
 	for rows.Next() {
-		var i GetStopsWithShapeContextRow
+		var i GetTripsByBlockIDOrderedRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Lat,
-			&i.Lon,
-			&i.Name,
-			&i.Code,
-			&i.Direction,
-			&i.TripID,
-			&i.StopSequence,
-			&i.ShapeDistTraveled,
-			&i.ShapeID,
+			&i.BlockID,
+			&i.ServiceID,
+			&i.FirstDepartureTime,
+			&i.LastArrivalTime,
 		); err != nil {
 			return nil, err
 		}
@@ -3249,52 +5488,59 @@ func (q *Queries) GetStopsWithShapeContext(ctx context.Context, id string) ([]Ge
 	return items, nil
 }
 
-const getStopsWithShapeContextByIDs = `-- name: GetStopsWithShapeContextByIDs :many
-SELECT
-    st.stop_id,
-    t.shape_id,
-    s.lat,
-    s.lon,
-    st.shape_dist_traveled
-FROM stop_times st
-JOIN trips t ON st.trip_id = t.id
-JOIN stops s ON st.stop_id = s.id
-WHERE st.stop_id IN (/*SLICE:stop_ids*/?)
+const getTripsByBlockIDs = `-- name: GetTripsByBlockIDs :many
+SELECT t.id, t.route_id, t.service_id, t.trip_headsign, t.trip_short_name, t.direction_id, t.block_id, t.shape_id, t.wheelchair_accessible, t.bikes_allowed
+FROM trips t
+JOIN stop_times st ON t.id = st.trip_id
+WHERE t.block_id IN (/*SLICE:block_ids*/?)
+  AND t.service_id IN (/*SLICE:service_ids*/?)
+GROUP BY t.id
+ORDER BY t.block_id, MIN(st.departure_time), t.id
 `
 
-type GetStopsWithShapeContextByIDsRow struct {
-	StopID            string
-	ShapeID           sql.NullString
-	Lat               float64
-	Lon               float64
-	ShapeDistTraveled sql.NullFloat64
+type GetTripsByBlockIDsParams struct {
+	BlockIds   []sql.NullString
+	ServiceIds []string
 }
 
-func (q *Queries) GetStopsWithShapeContextByIDs(ctx context.Context, stopIds []string) ([]GetStopsWithShapeContextByIDsRow, error) {
-	query := getStopsWithShapeContextByIDs
+func (q *Queries) GetTripsByBlockIDs(ctx context.Context, arg GetTripsByBlockIDsParams) ([]Trip, error) {
+	query := getTripsByBlockIDs
 	var queryParams []interface{}
-	if len(stopIds) > 0 {
-		for _, v := range stopIds {
+	if len(arg.BlockIds) > 0 {
+		for _, v := range arg.BlockIds {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", strings.Repeat(",?", len(stopIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:block_ids*/?", strings.Repeat(",?", len(arg.BlockIds))[1:], 1)
 	} else {
-		query = strings.Replace(query, "/*SLICE:stop_ids*/?", "NULL", 1)
+		query = strings.Replace(query, "/*SLICE:block_ids*/?", "NULL", 1)
+	}
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
 	}
 	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetStopsWithShapeContextByIDsRow
+	var items []Trip
 	for rows.Next() {
-		var i GetStopsWithShapeContextByIDsRow
+		var i Trip
 		if err := rows.Scan(
-			&i.StopID,
+			&i.ID,
+			&i.RouteID,
+			&i.ServiceID,
+			&i.TripHeadsign,
+			&i.TripShortName,
+			&i.DirectionID,
+			&i.BlockID,
 			&i.ShapeID,
-			&i.Lat,
-			&i.Lon,
-			&i.ShapeDistTraveled,
+			&i.WheelchairAccessible,
+			&i.BikesAllowed,
 		); err != nil {
 			return nil, err
 		}
@@ -3309,46 +5555,92 @@ func (q *Queries) GetStopsWithShapeContextByIDs(ctx context.Context, stopIds []s
 	return items, nil
 }
 
-const getStopsWithTripContext = `-- name: GetStopsWithTripContext :many
-SELECT
-    s.id, s.lat, s.lon, s.name, s.code,
-    st.trip_id, st.stop_sequence,
-    t.shape_id
-FROM stops s
-JOIN stop_times st ON s.id = st.stop_id
-JOIN trips t ON st.trip_id = t.id
-WHERE s.id = ?
+const getTripsByBlockTripIndexIDs = `-- name: GetTripsByBlockTripIndexIDs :many
+SELECT DISTINCT
+    t.id, t.route_id, t.service_id, t.trip_headsign, t.trip_short_name,
+    t.direction_id, t.block_id, t.shape_id, t.wheelchair_accessible, t.bikes_allowed,
+    bte.block_trip_sequence
+FROM trips t
+JOIN block_trip_entry bte ON t.id = bte.trip_id
+WHERE bte.block_trip_index_id IN (/*SLICE:index_ids*/?)
+  AND bte.service_id IN (/*SLICE:service_ids*/?)
+  AND EXISTS (
+    -- Check if trip could be active: maxDeparture >= timeFrom AND minArrival <= timeTo
+    SELECT 1
+    FROM stop_times st
+    WHERE st.trip_id = t.id
+    GROUP BY st.trip_id
+    HAVING MAX(st.departure_time) >= ?3
+       AND MIN(st.arrival_time) <= ?4
+  )
+ORDER BY t.route_id, bte.block_trip_sequence, t.id
 `
 
-type GetStopsWithTripContextRow struct {
-	ID           string
-	Lat          float64
-	Lon          float64
-	Name         sql.NullString
-	Code         sql.NullString
-	TripID       string
-	StopSequence int64
-	ShapeID      sql.NullString
+type GetTripsByBlockTripIndexIDsParams struct {
+	IndexIds   []int64
+	ServiceIds []string
+	FromTime   int64
+	ToTime     int64
 }
 
-func (q *Queries) GetStopsWithTripContext(ctx context.Context, id string) ([]GetStopsWithTripContextRow, error) {
-	rows, err := q.query(ctx, q.getStopsWithTripContextStmt, getStopsWithTripContext, id)
+type GetTripsByBlockTripIndexIDsRow struct {
+	ID                   string
+	RouteID              string
+	ServiceID            string
+	TripHeadsign         sql.NullString
+	TripShortName        sql.NullString
+	DirectionID          sql.NullInt64
+	BlockID              sql.NullString
+	ShapeID              sql.NullString
+	WheelchairAccessible sql.NullInt64
+	BikesAllowed         sql.NullInt64
+	BlockTripSequence    int64
+}
+
+// Get all trips that belong to the specified block_trip_index IDs within a time window
+// Matches Java's findBlockTripsInRange logic using binary search on maxDepartures and minArrivals
+// A trip is active if: maxDeparture >= timeFrom (could have started) AND minArrival <= timeTo (could still be active)
+func (q *Queries) GetTripsByBlockTripIndexIDs(ctx context.Context, arg GetTripsByBlockTripIndexIDsParams) ([]GetTripsByBlockTripIndexIDsRow, error) {
+	query := getTripsByBlockTripIndexIDs
+	var queryParams []interface{}
+	if len(arg.IndexIds) > 0 {
+		for _, v := range arg.IndexIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:index_ids*/?", strings.Repeat(",?", len(arg.IndexIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:index_ids*/?", "NULL", 1)
+	}
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.FromTime)
+	queryParams = append(queryParams, arg.ToTime)
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetStopsWithTripContextRow
+	var items []GetTripsByBlockTripIndexIDsRow
 	for rows.Next() {
-		var i GetStopsWithTripContextRow
+		var i GetTripsByBlockTripIndexIDsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Lat,
-			&i.Lon,
-			&i.Name,
-			&i.Code,
-			&i.TripID,
-			&i.StopSequence,
+			&i.RouteID,
+			&i.ServiceID,
+			&i.TripHeadsign,
+			&i.TripShortName,
+			&i.DirectionID,
+			&i.BlockID,
 			&i.ShapeID,
+			&i.WheelchairAccessible,
+			&i.BikesAllowed,
+			&i.BlockTripSequence,
 		); err != nil {
 			return nil, err
 		}
@@ -3363,69 +5655,36 @@ func (q *Queries) GetStopsWithTripContext(ctx context.Context, id string) ([]Get
 	return items, nil
 }
 
-const getTrip = `-- name: GetTrip :one
-SELECT
-    id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
-FROM
-    trips
-WHERE
-    id = ?
-`
-
-func (q *Queries) GetTrip(ctx context.Context, id string) (Trip, error) {
-	row := q.queryRow(ctx, q.getTripStmt, getTrip, id)
-	var i Trip
-	err := row.Scan(
-		&i.ID,
-		&i.RouteID,
-		&i.ServiceID,
-		&i.TripHeadsign,
-		&i.TripShortName,
-		&i.DirectionID,
-		&i.BlockID,
-		&i.ShapeID,
-		&i.WheelchairAccessible,
-		&i.BikesAllowed,
-	)
-	return i, err
-}
-
-const getTripsByBlockID = `-- name: GetTripsByBlockID :many
+const getTripsByIDs = `-- name: GetTripsByIDs :many
 SELECT
-    id,
-    route_id,
-    service_id,
-    trip_headsign,
-    trip_short_name,
-    direction_id,
-    block_id,
-    shape_id
-FROM
-    trips
-WHERE
-    block_id = ?
-`
-
-type GetTripsByBlockIDRow struct {
-	ID            string
-	RouteID       string
-	ServiceID     string
-	TripHeadsign  sql.NullString
-	TripShortName sql.NullString
-	DirectionID   sql.NullInt64
-	BlockID       sql.NullString
-	ShapeID       sql.NullString
-}
+    id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
+FROM
+    trips
+WHERE
+    id IN (/*SLICE:trip_ids*/?)
+ORDER BY
+    id
+`
 
-func (q *Queries) GetTripsByBlockID(ctx context.Context, blockID sql.NullString) ([]GetTripsByBlockIDRow, error) {
-	rows, err := q.query(ctx, q.getTripsByBlockIDStmt, getTripsByBlockID, blockID)
+func (q *Queries) GetTripsByIDs(ctx context.Context, tripIds []string) ([]Trip, error) {
+	query := getTripsByIDs
+	var queryParams []interface{}
+	if len(tripIds) > 0 {
+		for _, v := range tripIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:trip_ids*/?", strings.Repeat(",?", len(tripIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:trip_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTripsByBlockIDRow
+	var items []Trip
 	for rows.Next() {
-		var i GetTripsByBlockIDRow
+		var i Trip
 		if err := rows.Scan(
 			&i.ID,
 			&i.RouteID,
@@ -3435,6 +5694,8 @@ func (q *Queries) GetTripsByBlockID(ctx context.Context, blockID sql.NullString)
 			&i.DirectionID,
 			&i.BlockID,
 			&i.ShapeID,
+			&i.WheelchairAccessible,
+			&i.BikesAllowed,
 		); err != nil {
 			return nil, err
 		}
@@ -3449,49 +5710,27 @@ func (q *Queries) GetTripsByBlockID(ctx context.Context, blockID sql.NullString)
 	return items, nil
 }
 
-const getTripsByBlockIDOrdered = `-- name: GetTripsByBlockIDOrdered :many
-SELECT
-    t.id,
-    t.block_id,
-    t.service_id,
-    MIN(st.departure_time) AS first_departure_time,
-    MAX(st.arrival_time) AS last_arrival_time
-FROM
-    trips t
-    JOIN stop_times st ON st.trip_id = t.id
-WHERE
-    t.block_id = ?
-    AND t.service_id IN (/*SLICE:service_ids*/?)
-GROUP BY
-    t.id,
-    t.block_id,
-    t.service_id
-ORDER BY
-    MIN(st.departure_time)
+const getTripsByServiceID = `-- name: GetTripsByServiceID :many
+SELECT id, route_id, service_id, trip_headsign
+FROM trips
+WHERE service_id IN (/*SLICE:service_ids*/?)
 `
 
-type GetTripsByBlockIDOrderedParams struct {
-	BlockID    sql.NullString
-	ServiceIds []string
-}
-
-type GetTripsByBlockIDOrderedRow struct {
-	ID                 string
-	BlockID            sql.NullString
-	ServiceID          string
-	FirstDepartureTime interface{}
-	LastArrivalTime    interface{}
+type GetTripsByServiceIDRow struct {
+	ID           string
+	RouteID      string
+	ServiceID    string
+	TripHeadsign sql.NullString
 }
 
-func (q *Queries) GetTripsByBlockIDOrdered(ctx context.Context, arg GetTripsByBlockIDOrderedParams) ([]GetTripsByBlockIDOrderedRow, error) {
-	query := getTripsByBlockIDOrdered
+func (q *Queries) GetTripsByServiceID(ctx context.Context, serviceIds []string) ([]GetTripsByServiceIDRow, error) {
+	query := getTripsByServiceID
 	var queryParams []interface{}
-	queryParams = append(queryParams, arg.BlockID)
-	if len(arg.ServiceIds) > 0 {
-		for _, v := range arg.ServiceIds {
+	if len(serviceIds) > 0 {
+		for _, v := range serviceIds {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(serviceIds))[1:], 1)
 	} else {
 		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
 	}
@@ -3500,15 +5739,14 @@ func (q *Queries) GetTripsByBlockIDOrdered(ctx context.Context, arg GetTripsByBl
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTripsByBlockIDOrderedRow
+	var items []GetTripsByServiceIDRow
 	for rows.Next() {
-		var i GetTripsByBlockIDOrderedRow
+		var i GetTripsByServiceIDRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.BlockID,
+			&i.RouteID,
 			&i.ServiceID,
-			&i.FirstDepartureTime,
-			&i.LastArrivalTime,
+			&i.TripHeadsign,
 		); err != nil {
 			return nil, err
 		}
@@ -3523,39 +5761,30 @@ func (q *Queries) GetTripsByBlockIDOrdered(ctx context.Context, arg GetTripsByBl
 	return items, nil
 }
 
-const getTripsByBlockIDs = `-- name: GetTripsByBlockIDs :many
-SELECT t.id, t.route_id, t.service_id, t.trip_headsign, t.trip_short_name, t.direction_id, t.block_id, t.shape_id, t.wheelchair_accessible, t.bikes_allowed
+const getTripsForRouteInActiveServiceIDs = `-- name: GetTripsForRouteInActiveServiceIDs :many
+SELECT DISTINCT id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
 FROM trips t
-JOIN stop_times st ON t.id = st.trip_id
-WHERE t.block_id IN (/*SLICE:block_ids*/?)
-  AND t.service_id IN (/*SLICE:service_ids*/?)
-GROUP BY t.id
-ORDER BY t.block_id, MIN(st.departure_time), t.id
+WHERE t.route_id = ?1
+  AND t.service_id IN (/*SLICE:('service_ids')*/?)
+ORDER BY t.direction_id, t.trip_headsign
 `
 
-type GetTripsByBlockIDsParams struct {
-	BlockIds   []sql.NullString
+type GetTripsForRouteInActiveServiceIDsParams struct {
+	RouteID    string
 	ServiceIds []string
 }
 
-func (q *Queries) GetTripsByBlockIDs(ctx context.Context, arg GetTripsByBlockIDsParams) ([]Trip, error) {
-	query := getTripsByBlockIDs
+func (q *Queries) GetTripsForRouteInActiveServiceIDs(ctx context.Context, arg GetTripsForRouteInActiveServiceIDsParams) ([]Trip, error) {
+	query := getTripsForRouteInActiveServiceIDs
 	var queryParams []interface{}
-	if len(arg.BlockIds) > 0 {
-		for _, v := range arg.BlockIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:block_ids*/?", strings.Repeat(",?", len(arg.BlockIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:block_ids*/?", "NULL", 1)
-	}
+	queryParams = append(queryParams, arg.RouteID)
 	if len(arg.ServiceIds) > 0 {
 		for _, v := range arg.ServiceIds {
 			queryParams = append(queryParams, v)
 		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+		query = strings.Replace(query, "/*SLICE:('service_ids')*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
 	} else {
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+		query = strings.Replace(query, "/*SLICE:('service_ids')*/?", "NULL", 1)
 	}
 	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
@@ -3590,62 +5819,23 @@ func (q *Queries) GetTripsByBlockIDs(ctx context.Context, arg GetTripsByBlockIDs
 	return items, nil
 }
 
-const getTripsByBlockTripIndexIDs = `-- name: GetTripsByBlockTripIndexIDs :many
-SELECT DISTINCT
-    t.id, t.route_id, t.service_id, t.trip_headsign, t.trip_short_name,
-    t.direction_id, t.block_id, t.shape_id, t.wheelchair_accessible, t.bikes_allowed,
-    bte.block_trip_sequence
-FROM trips t
-JOIN block_trip_entry bte ON t.id = bte.trip_id
-WHERE bte.block_trip_index_id IN (/*SLICE:index_ids*/?)
-  AND bte.service_id IN (/*SLICE:service_ids*/?)
-  AND EXISTS (
-    -- Check if trip could be active: maxDeparture >= timeFrom AND minArrival <= timeTo
-    SELECT 1
-    FROM stop_times st
-    WHERE st.trip_id = t.id
-    GROUP BY st.trip_id
-    HAVING MAX(st.departure_time) >= ?3
-       AND MIN(st.arrival_time) <= ?4
-  )
-ORDER BY t.route_id, bte.block_trip_sequence, t.id
+const getTripsInBlock = `-- name: GetTripsInBlock :many
+SELECT id
+FROM trips
+WHERE block_id = ?1
+  AND service_id IN (/*SLICE:service_ids*/?)
 `
 
-type GetTripsByBlockTripIndexIDsParams struct {
-	IndexIds   []int64
+type GetTripsInBlockParams struct {
+	BlockID    sql.NullString
 	ServiceIds []string
-	FromTime   int64
-	ToTime     int64
-}
-
-type GetTripsByBlockTripIndexIDsRow struct {
-	ID                   string
-	RouteID              string
-	ServiceID            string
-	TripHeadsign         sql.NullString
-	TripShortName        sql.NullString
-	DirectionID          sql.NullInt64
-	BlockID              sql.NullString
-	ShapeID              sql.NullString
-	WheelchairAccessible sql.NullInt64
-	BikesAllowed         sql.NullInt64
-	BlockTripSequence    int64
 }
 
-// Get all trips that belong to the specified block_trip_index IDs within a time window
-// Matches Java's findBlockTripsInRange logic using binary search on maxDepartures and minArrivals
-// A trip is active if: maxDeparture >= timeFrom (could have started) AND minArrival <= timeTo (could still be active)
-func (q *Queries) GetTripsByBlockTripIndexIDs(ctx context.Context, arg GetTripsByBlockTripIndexIDsParams) ([]GetTripsByBlockTripIndexIDsRow, error) {
-	query := getTripsByBlockTripIndexIDs
+// Get all trip IDs in a specific block for the given service IDs
+func (q *Queries) GetTripsInBlock(ctx context.Context, arg GetTripsInBlockParams) ([]string, error) {
+	query := getTripsInBlock
 	var queryParams []interface{}
-	if len(arg.IndexIds) > 0 {
-		for _, v := range arg.IndexIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:index_ids*/?", strings.Repeat(",?", len(arg.IndexIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:index_ids*/?", "NULL", 1)
-	}
+	queryParams = append(queryParams, arg.BlockID)
 	if len(arg.ServiceIds) > 0 {
 		for _, v := range arg.ServiceIds {
 			queryParams = append(queryParams, v)
@@ -3654,28 +5844,101 @@ func (q *Queries) GetTripsByBlockTripIndexIDs(ctx context.Context, arg GetTripsB
 	} else {
 		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
 	}
-	queryParams = append(queryParams, arg.FromTime)
-	queryParams = append(queryParams, arg.ToTime)
 	rows, err := q.query(ctx, nil, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTripsByBlockTripIndexIDsRow
+	var items []string
 	for rows.Next() {
-		var i GetTripsByBlockTripIndexIDsRow
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTripsServingStopsInOrder = `-- name: GetTripsServingStopsInOrder :many
+SELECT
+    o.trip_id,
+    o.stop_sequence AS origin_stop_sequence,
+    o.arrival_time AS origin_arrival_time,
+    o.departure_time AS origin_departure_time,
+    d.stop_sequence AS destination_stop_sequence,
+    d.arrival_time AS destination_arrival_time,
+    d.departure_time AS destination_departure_time,
+    t.route_id,
+    t.service_id,
+    t.trip_headsign
+FROM stop_times o
+JOIN stop_times d ON d.trip_id = o.trip_id AND d.stop_sequence > o.stop_sequence
+JOIN trips t ON t.id = o.trip_id
+WHERE o.stop_id = ?1
+    AND d.stop_id = ?2
+    AND o.departure_time BETWEEN ?3 AND ?4
+    AND COALESCE(o.pickup_type, 0) != 1
+    AND COALESCE(d.drop_off_type, 0) != 1
+    AND NOT EXISTS (SELECT 1 FROM frequencies f WHERE f.trip_id = o.trip_id)
+ORDER BY o.departure_time, o.trip_id, d.stop_sequence
+`
+
+type GetTripsServingStopsInOrderParams struct {
+	OriginStopID      string
+	DestinationStopID string
+	WindowStart       int64
+	WindowEnd         int64
+}
+
+type GetTripsServingStopsInOrderRow struct {
+	TripID                   string
+	OriginStopSequence       int64
+	OriginArrivalTime        int64
+	OriginDepartureTime      int64
+	DestinationStopSequence  int64
+	DestinationArrivalTime   int64
+	DestinationDepartureTime int64
+	RouteID                  string
+	ServiceID                string
+	TripHeadsign             sql.NullString
+}
+
+// Lists the trips that depart the origin stop between two times and later
+// call at the destination stop, letting riders board at the origin and
+// alight at the destination. Frequency-based trips are left out: their stop
+// times only describe a template.
+func (q *Queries) GetTripsServingStopsInOrder(ctx context.Context, arg GetTripsServingStopsInOrderParams) ([]GetTripsServingStopsInOrderRow, error) {
+	rows, err := q.query(ctx, q.getTripsServingStopsInOrderStmt, getTripsServingStopsInOrder,
+		arg.OriginStopID,
+		arg.DestinationStopID,
+		arg.WindowStart,
+		arg.WindowEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTripsServingStopsInOrderRow
+	for rows.Next() {
+		var i GetTripsServingStopsInOrderRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.TripID,
+			&i.OriginStopSequence,
+			&i.OriginArrivalTime,
+			&i.OriginDepartureTime,
+			&i.DestinationStopSequence,
+			&i.DestinationArrivalTime,
+			&i.DestinationDepartureTime,
 			&i.RouteID,
 			&i.ServiceID,
 			&i.TripHeadsign,
-			&i.TripShortName,
-			&i.DirectionID,
-			&i.BlockID,
-			&i.ShapeID,
-			&i.WheelchairAccessible,
-			&i.BikesAllowed,
-			&i.BlockTripSequence,
 		); err != nil {
 			return nil, err
 		}
@@ -3690,47 +5953,101 @@ func (q *Queries) GetTripsByBlockTripIndexIDs(ctx context.Context, arg GetTripsB
 	return items, nil
 }
 
-const getTripsByIDs = `-- name: GetTripsByIDs :many
-SELECT
-    id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
-FROM
-    trips
-WHERE
-    id IN (/*SLICE:trip_ids*/?)
-ORDER BY
-    id
+const getTripsUnderwayAtTime = `-- name: GetTripsUnderwayAtTime :many
+SELECT t.id, t.route_id
+FROM trips t
+WHERE (SELECT MIN(st.departure_time) FROM stop_times st WHERE st.trip_id = t.id) <= ?1
+  AND (SELECT MAX(st.arrival_time) FROM stop_times st WHERE st.trip_id = t.id) >= ?1
+  AND t.service_id IN (/*SLICE:service_ids*/?)
+ORDER BY t.route_id, t.id
+`
+
+type GetTripsUnderwayAtTimeParams struct {
+	CurrentTime int64
+	ServiceIds  []string
+}
+
+type GetTripsUnderwayAtTimeRow struct {
+	ID      string
+	RouteID string
+}
+
+// The trips of the given services that are under way at current_time: past
+// their first departure and not yet at their last arrival. current_time must
+// appear before the service_ids slice, as in GetActiveTripInBlockAtTime.
+func (q *Queries) GetTripsUnderwayAtTime(ctx context.Context, arg GetTripsUnderwayAtTimeParams) ([]GetTripsUnderwayAtTimeRow, error) {
+	query := getTripsUnderwayAtTime
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.CurrentTime)
+	if len(arg.ServiceIds) > 0 {
+		for _, v := range arg.ServiceIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
+	}
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTripsUnderwayAtTimeRow
+	for rows.Next() {
+		var i GetTripsUnderwayAtTimeRow
+		if err := rows.Scan(&i.ID, &i.RouteID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVehiclePositionsInWindow = `-- name: GetVehiclePositionsInWindow :many
+SELECT id, feed_id, vehicle_id, trip_id, route_id, lat, lon, bearing, speed, schedule_deviation, stop_id, occupancy_status, observed_at FROM vehicle_positions_history
+WHERE vehicle_id = ?1
+    AND observed_at BETWEEN ?2 AND ?3
+    AND lat IS NOT NULL
+    AND lon IS NOT NULL
+ORDER BY observed_at
 `
 
-func (q *Queries) GetTripsByIDs(ctx context.Context, tripIds []string) ([]Trip, error) {
-	query := getTripsByIDs
-	var queryParams []interface{}
-	if len(tripIds) > 0 {
-		for _, v := range tripIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:trip_ids*/?", strings.Repeat(",?", len(tripIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:trip_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+type GetVehiclePositionsInWindowParams struct {
+	VehicleID string
+	FromTime  int64
+	ToTime    int64
+}
+
+// Lists the located observations of a vehicle between two times, oldest first.
+func (q *Queries) GetVehiclePositionsInWindow(ctx context.Context, arg GetVehiclePositionsInWindowParams) ([]VehiclePositionsHistory, error) {
+	rows, err := q.query(ctx, q.getVehiclePositionsInWindowStmt, getVehiclePositionsInWindow, arg.VehicleID, arg.FromTime, arg.ToTime)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Trip
+	var items []VehiclePositionsHistory
 	for rows.Next() {
-		var i Trip
+		var i VehiclePositionsHistory
 		if err := rows.Scan(
 			&i.ID,
+			&i.FeedID,
+			&i.VehicleID,
+			&i.TripID,
 			&i.RouteID,
-			&i.ServiceID,
-			&i.TripHeadsign,
-			&i.TripShortName,
-			&i.DirectionID,
-			&i.BlockID,
-			&i.ShapeID,
-			&i.WheelchairAccessible,
-			&i.BikesAllowed,
+			&i.Lat,
+			&i.Lon,
+			&i.Bearing,
+			&i.Speed,
+			&i.ScheduleDeviation,
+			&i.StopID,
+			&i.OccupancyStatus,
+			&i.ObservedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -3745,43 +6062,62 @@ func (q *Queries) GetTripsByIDs(ctx context.Context, tripIds []string) ([]Trip,
 	return items, nil
 }
 
-const getTripsByServiceID = `-- name: GetTripsByServiceID :many
-SELECT id, route_id, service_id, trip_headsign
-FROM trips
-WHERE service_id IN (/*SLICE:service_ids*/?)
+const incrementHistoricalOccupancy = `-- name: IncrementHistoricalOccupancy :exec
+INSERT INTO historical_occupancy (
+    trip_id,
+    stop_id,
+    day_of_week,
+    occupancy_status,
+    sample_count
+) VALUES (?, ?, ?, ?, 1)
+ON CONFLICT (trip_id, stop_id, day_of_week, occupancy_status)
+DO UPDATE SET sample_count = sample_count + 1
 `
 
-type GetTripsByServiceIDRow struct {
-	ID           string
-	RouteID      string
-	ServiceID    string
-	TripHeadsign sql.NullString
+type IncrementHistoricalOccupancyParams struct {
+	TripID          string
+	StopID          string
+	DayOfWeek       int64
+	OccupancyStatus int64
 }
 
-func (q *Queries) GetTripsByServiceID(ctx context.Context, serviceIds []string) ([]GetTripsByServiceIDRow, error) {
-	query := getTripsByServiceID
-	var queryParams []interface{}
-	if len(serviceIds) > 0 {
-		for _, v := range serviceIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(serviceIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) IncrementHistoricalOccupancy(ctx context.Context, arg IncrementHistoricalOccupancyParams) error {
+	_, err := q.exec(ctx, q.incrementHistoricalOccupancyStmt, incrementHistoricalOccupancy,
+		arg.TripID,
+		arg.StopID,
+		arg.DayOfWeek,
+		arg.OccupancyStatus,
+	)
+	return err
+}
+
+const listAgencies = `-- name: ListAgencies :many
+SELECT
+    id, name, url, timezone, lang, phone, fare_url, email
+FROM
+    agencies
+ORDER BY
+    id
+`
+
+func (q *Queries) ListAgencies(ctx context.Context) ([]Agency, error) {
+	rows, err := q.query(ctx, q.listAgenciesStmt, listAgencies)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTripsByServiceIDRow
+	var items []Agency
 	for rows.Next() {
-		var i GetTripsByServiceIDRow
+		var i Agency
 		if err := rows.Scan(
 			&i.ID,
-			&i.RouteID,
-			&i.ServiceID,
-			&i.TripHeadsign,
+			&i.Name,
+			&i.Url,
+			&i.Timezone,
+			&i.Lang,
+			&i.Phone,
+			&i.FareUrl,
+			&i.Email,
 		); err != nil {
 			return nil, err
 		}
@@ -3796,50 +6132,52 @@ func (q *Queries) GetTripsByServiceID(ctx context.Context, serviceIds []string)
 	return items, nil
 }
 
-const getTripsForRouteInActiveServiceIDs = `-- name: GetTripsForRouteInActiveServiceIDs :many
-SELECT DISTINCT id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
-FROM trips t
-WHERE t.route_id = ?1
-  AND t.service_id IN (/*SLICE:('service_ids')*/?)
-ORDER BY t.direction_id, t.trip_headsign
+const listImportWarnings = `-- name: ListImportWarnings :many
+SELECT
+    id, file, row_num, kind, message, row_content
+FROM
+    import_warnings
+WHERE
+    (?1 IS NULL OR file = ?1)
+    AND (?2 IS NULL OR kind = ?2)
+ORDER BY
+    id
+LIMIT
+    ?3
+OFFSET
+    ?4
 `
 
-type GetTripsForRouteInActiveServiceIDsParams struct {
-	RouteID    string
-	ServiceIds []string
-}
-
-func (q *Queries) GetTripsForRouteInActiveServiceIDs(ctx context.Context, arg GetTripsForRouteInActiveServiceIDsParams) ([]Trip, error) {
-	query := getTripsForRouteInActiveServiceIDs
-	var queryParams []interface{}
-	queryParams = append(queryParams, arg.RouteID)
-	if len(arg.ServiceIds) > 0 {
-		for _, v := range arg.ServiceIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:('service_ids')*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:('service_ids')*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+type ListImportWarningsParams struct {
+	File       sql.NullString
+	Kind       sql.NullString
+	PageLimit  int64
+	PageOffset int64
+}
+
+// Lists parse warnings in feed order, optionally only those of one file or kind.
+// A negative page_limit returns all of them.
+func (q *Queries) ListImportWarnings(ctx context.Context, arg ListImportWarningsParams) ([]ImportWarning, error) {
+	rows, err := q.query(ctx, q.listImportWarningsStmt, listImportWarnings,
+		arg.File,
+		arg.Kind,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Trip
+	var items []ImportWarning
 	for rows.Next() {
-		var i Trip
+		var i ImportWarning
 		if err := rows.Scan(
 			&i.ID,
-			&i.RouteID,
-			&i.ServiceID,
-			&i.TripHeadsign,
-			&i.TripShortName,
-			&i.DirectionID,
-			&i.BlockID,
-			&i.ShapeID,
-			&i.WheelchairAccessible,
-			&i.BikesAllowed,
+			&i.File,
+			&i.RowNum,
+			&i.Kind,
+			&i.Message,
+			&i.RowContent,
 		); err != nil {
 			return nil, err
 		}
@@ -3854,43 +6192,28 @@ func (q *Queries) GetTripsForRouteInActiveServiceIDs(ctx context.Context, arg Ge
 	return items, nil
 }
 
-const getTripsInBlock = `-- name: GetTripsInBlock :many
-SELECT id
-FROM trips
-WHERE block_id = ?1
-  AND service_id IN (/*SLICE:service_ids*/?)
+const listNetworks = `-- name: ListNetworks :many
+SELECT
+    id, name
+FROM
+    networks
+ORDER BY
+    id
 `
 
-type GetTripsInBlockParams struct {
-	BlockID    sql.NullString
-	ServiceIds []string
-}
-
-// Get all trip IDs in a specific block for the given service IDs
-func (q *Queries) GetTripsInBlock(ctx context.Context, arg GetTripsInBlockParams) ([]string, error) {
-	query := getTripsInBlock
-	var queryParams []interface{}
-	queryParams = append(queryParams, arg.BlockID)
-	if len(arg.ServiceIds) > 0 {
-		for _, v := range arg.ServiceIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", strings.Repeat(",?", len(arg.ServiceIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:service_ids*/?", "NULL", 1)
-	}
-	rows, err := q.query(ctx, nil, query, queryParams...)
+func (q *Queries) ListNetworks(ctx context.Context) ([]Network, error) {
+	rows, err := q.query(ctx, q.listNetworksStmt, listNetworks)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []Network
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var i Network
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
 			return nil, err
 		}
-		items = append(items, id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -3901,33 +6224,33 @@ func (q *Queries) GetTripsInBlock(ctx context.Context, arg GetTripsInBlockParams
 	return items, nil
 }
 
-const listAgencies = `-- name: ListAgencies :many
+const listQuarantinedRows = `-- name: ListQuarantinedRows :many
 SELECT
-    id, name, url, timezone, lang, phone, fare_url, email
+    id, file, row_num, kind, row_content
 FROM
-    agencies
+    quarantined_rows
+WHERE
+    (?1 IS NULL OR file = ?1)
 ORDER BY
     id
 `
 
-func (q *Queries) ListAgencies(ctx context.Context) ([]Agency, error) {
-	rows, err := q.query(ctx, q.listAgenciesStmt, listAgencies)
+// Lists the rows set aside for referencing rows the feed does not have, in feed order.
+func (q *Queries) ListQuarantinedRows(ctx context.Context, file sql.NullString) ([]QuarantinedRow, error) {
+	rows, err := q.query(ctx, q.listQuarantinedRowsStmt, listQuarantinedRows, file)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Agency
+	var items []QuarantinedRow
 	for rows.Next() {
-		var i Agency
+		var i QuarantinedRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Url,
-			&i.Timezone,
-			&i.Lang,
-			&i.Phone,
-			&i.FareUrl,
-			&i.Email,
+			&i.File,
+			&i.RowNum,
+			&i.Kind,
+			&i.RowContent,
 		); err != nil {
 			return nil, err
 		}
@@ -3954,7 +6277,9 @@ SELECT
     color,
     text_color,
     continuous_pickup,
-    continuous_drop_off
+    continuous_drop_off,
+    sort_order,
+    network_id
 FROM
     routes
 ORDER BY
@@ -3983,6 +6308,8 @@ func (q *Queries) ListRoutes(ctx context.Context) ([]Route, error) {
 			&i.TextColor,
 			&i.ContinuousPickup,
 			&i.ContinuousDropOff,
+			&i.SortOrder,
+			&i.NetworkID,
 		); err != nil {
 			return nil, err
 		}
@@ -4044,6 +6371,56 @@ func (q *Queries) ListStops(ctx context.Context) ([]Stop, error) {
 	return items, nil
 }
 
+const listStopsWithoutDirection = `-- name: ListStopsWithoutDirection :many
+SELECT
+    id, code, name, "desc", lat, lon, zone_id, url, location_type, timezone, wheelchair_boarding, platform_code, direction, parent_station
+FROM
+    stops
+WHERE
+    direction IS NULL
+ORDER BY
+    id
+`
+
+// Returns the stops whose direction has not been computed yet
+func (q *Queries) ListStopsWithoutDirection(ctx context.Context) ([]Stop, error) {
+	rows, err := q.query(ctx, q.listStopsWithoutDirectionStmt, listStopsWithoutDirection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Stop
+	for rows.Next() {
+		var i Stop
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Desc,
+			&i.Lat,
+			&i.Lon,
+			&i.ZoneID,
+			&i.Url,
+			&i.LocationType,
+			&i.Timezone,
+			&i.WheelchairBoarding,
+			&i.PlatformCode,
+			&i.Direction,
+			&i.ParentStation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTrips = `-- name: ListTrips :many
 SELECT
     id, route_id, service_id, trip_headsign, trip_short_name, direction_id, block_id, shape_id, wheelchair_accessible, bikes_allowed
@@ -4101,32 +6478,120 @@ func (q *Queries) UpdateStopDirection(ctx context.Context, arg UpdateStopDirecti
 	return err
 }
 
+const upsertFeedInfo = `-- name: UpsertFeedInfo :exec
+INSERT
+OR REPLACE INTO feed_info (
+    id,
+    feed_publisher_name,
+    feed_publisher_url,
+    feed_lang,
+    default_lang,
+    feed_start_date,
+    feed_end_date,
+    feed_version,
+    feed_contact_email,
+    feed_contact_url
+)
+VALUES
+    (1, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type UpsertFeedInfoParams struct {
+	FeedPublisherName string
+	FeedPublisherUrl  string
+	FeedLang          string
+	DefaultLang       sql.NullString
+	FeedStartDate     sql.NullString
+	FeedEndDate       sql.NullString
+	FeedVersion       sql.NullString
+	FeedContactEmail  sql.NullString
+	FeedContactUrl    sql.NullString
+}
+
+func (q *Queries) UpsertFeedInfo(ctx context.Context, arg UpsertFeedInfoParams) error {
+	_, err := q.exec(ctx, q.upsertFeedInfoStmt, upsertFeedInfo,
+		arg.FeedPublisherName,
+		arg.FeedPublisherUrl,
+		arg.FeedLang,
+		arg.DefaultLang,
+		arg.FeedStartDate,
+		arg.FeedEndDate,
+		arg.FeedVersion,
+		arg.FeedContactEmail,
+		arg.FeedContactUrl,
+	)
+	return err
+}
+
 const upsertImportMetadata = `-- name: UpsertImportMetadata :one
 INSERT
 OR REPLACE INTO import_metadata (
     id,
     file_hash,
     import_time,
-    file_source
+    file_source,
+    etag,
+    last_modified
 )
 VALUES
-    (1, ?, ?, ?) RETURNING id, file_hash, import_time, file_source
+    (1, ?, ?, ?, ?, ?) RETURNING id, file_hash, import_time, file_source, etag, last_modified
 `
 
 type UpsertImportMetadataParams struct {
-	FileHash   string
-	ImportTime int64
-	FileSource string
+	FileHash     string
+	ImportTime   int64
+	FileSource   string
+	Etag         sql.NullString
+	LastModified sql.NullString
 }
 
 func (q *Queries) UpsertImportMetadata(ctx context.Context, arg UpsertImportMetadataParams) (ImportMetadatum, error) {
-	row := q.queryRow(ctx, q.upsertImportMetadataStmt, upsertImportMetadata, arg.FileHash, arg.ImportTime, arg.FileSource)
+	row := q.queryRow(ctx, q.upsertImportMetadataStmt, upsertImportMetadata,
+		arg.FileHash,
+		arg.ImportTime,
+		arg.FileSource,
+		arg.Etag,
+		arg.LastModified,
+	)
 	var i ImportMetadatum
 	err := row.Scan(
 		&i.ID,
 		&i.FileHash,
 		&i.ImportTime,
 		&i.FileSource,
+		&i.Etag,
+		&i.LastModified,
 	)
 	return i, err
 }
+
+const upsertScheduleDeviationSample = `-- name: UpsertScheduleDeviationSample :exec
+INSERT INTO schedule_deviation_samples (
+    trip_id,
+    stop_id,
+    service_date,
+    deviation,
+    observed_at
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (trip_id, stop_id, service_date)
+DO UPDATE SET deviation = excluded.deviation, observed_at = excluded.observed_at
+`
+
+type UpsertScheduleDeviationSampleParams struct {
+	TripID      string
+	StopID      string
+	ServiceDate string
+	Deviation   int64
+	ObservedAt  int64
+}
+
+func (q *Queries) UpsertScheduleDeviationSample(ctx context.Context, arg UpsertScheduleDeviationSampleParams) error {
+	_, err := q.exec(ctx, q.upsertScheduleDeviationSampleStmt, upsertScheduleDeviationSample,
+		arg.TripID,
+		arg.StopID,
+		arg.ServiceDate,
+		arg.Deviation,
+		arg.ObservedAt,
+	)
+	return err
+}
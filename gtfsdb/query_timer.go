@@ -0,0 +1,157 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// QueryTimer accumulates the time spent in SQLite by the statements run with a
+// context carrying it, including stepping through their result rows. It is safe
+// for concurrent use.
+type QueryTimer struct {
+	total   atomic.Int64
+	queries atomic.Int64
+}
+
+type queryTimerKey struct{}
+
+// WithQueryTimer returns a context whose database calls are timed by timer.
+func WithQueryTimer(ctx context.Context, timer *QueryTimer) context.Context {
+	return context.WithValue(ctx, queryTimerKey{}, timer)
+}
+
+func queryTimerFromContext(ctx context.Context) *QueryTimer {
+	timer, _ := ctx.Value(queryTimerKey{}).(*QueryTimer)
+	return timer
+}
+
+// Total is the time spent in the database so far.
+func (t *QueryTimer) Total() time.Duration {
+	return time.Duration(t.total.Load())
+}
+
+// Queries is the number of statements run so far.
+func (t *QueryTimer) Queries() int64 {
+	return t.queries.Load()
+}
+
+func (t *QueryTimer) add(d time.Duration) {
+	t.total.Add(int64(d))
+}
+
+// openTimedDB opens a SQLite database whose statements report to the
+// QueryTimer of their context. Statements run without one are not wrapped.
+func openTimedDB(dsn string) *sql.DB {
+	return sql.OpenDB(timedConnector{dsn: dsn, driver: &sqlite3.SQLiteDriver{}})
+}
+
+type timedConnector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c timedConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{SQLiteConn: conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+func (c timedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// timedConn times the statements run directly on a connection and wraps
+// prepared statements so that they are timed too.
+type timedConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	timer := queryTimerFromContext(ctx)
+	if timer == nil {
+		return c.SQLiteConn.QueryContext(ctx, query, args)
+	}
+	start := time.Now()
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	return timeRows(timer, start, rows, err)
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	timer := queryTimerFromContext(ctx)
+	if timer == nil {
+		return c.SQLiteConn.ExecContext(ctx, query, args)
+	}
+	start := time.Now()
+	result, err := c.SQLiteConn.ExecContext(ctx, query, args)
+	timer.queries.Add(1)
+	timer.add(time.Since(start))
+	return result, err
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{SQLiteStmt: stmt.(*sqlite3.SQLiteStmt)}, nil
+}
+
+type timedStmt struct {
+	*sqlite3.SQLiteStmt
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	timer := queryTimerFromContext(ctx)
+	if timer == nil {
+		return s.SQLiteStmt.QueryContext(ctx, args)
+	}
+	start := time.Now()
+	rows, err := s.SQLiteStmt.QueryContext(ctx, args)
+	return timeRows(timer, start, rows, err)
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	timer := queryTimerFromContext(ctx)
+	if timer == nil {
+		return s.SQLiteStmt.ExecContext(ctx, args)
+	}
+	start := time.Now()
+	result, err := s.SQLiteStmt.ExecContext(ctx, args)
+	timer.queries.Add(1)
+	timer.add(time.Since(start))
+	return result, err
+}
+
+// timeRows records a query started at start. SQLite runs a query as its rows
+// are read, so the returned rows keep adding the time spent in Next.
+func timeRows(timer *QueryTimer, start time.Time, rows driver.Rows, err error) (driver.Rows, error) {
+	timer.queries.Add(1)
+	timer.add(time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	sqliteRows, ok := rows.(*sqlite3.SQLiteRows)
+	if !ok {
+		return rows, nil
+	}
+	return &timedRows{SQLiteRows: sqliteRows, timer: timer}, nil
+}
+
+type timedRows struct {
+	*sqlite3.SQLiteRows
+	timer *QueryTimer
+}
+
+func (r *timedRows) Next(dest []driver.Value) error {
+	start := time.Now()
+	err := r.SQLiteRows.Next(dest)
+	r.timer.add(time.Since(start))
+	return err
+}
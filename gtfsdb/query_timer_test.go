@@ -0,0 +1,43 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestQueryTimer(t *testing.T) {
+	client, err := NewClient(Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.Queries.CreateAgency(context.Background(), CreateAgencyParams{
+		ID:       "agency",
+		Name:     "Agency",
+		Url:      "http://example.com",
+		Timezone: "America/Los_Angeles",
+	})
+	require.NoError(t, err)
+
+	var timer QueryTimer
+	ctx := WithQueryTimer(context.Background(), &timer)
+
+	agencies, err := client.Queries.ListAgencies(ctx)
+	require.NoError(t, err)
+	require.Len(t, agencies, 1)
+
+	var count int
+	require.NoError(t, client.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM agencies").Scan(&count))
+
+	assert.Equal(t, int64(2), timer.Queries())
+	assert.Positive(t, timer.Total())
+
+	t.Run("queries without a timer are not counted", func(t *testing.T) {
+		_, err := client.Queries.ListAgencies(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), timer.Queries())
+	})
+}
@@ -0,0 +1,78 @@
+package gtfsdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// openReadPool opens a pool of read-only connections to the database at
+// dbPath. The connections set PRAGMA query_only, so a statement sent to the
+// wrong pool fails instead of writing.
+func openReadPool(ctx context.Context, dbPath string, size int) (*sql.DB, error) {
+	db := openTimedDB(dbPath + "?_query_only=1")
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error opening read pool: %w", err)
+	}
+	db.SetMaxOpenConns(size)
+	db.SetMaxIdleConns(size)
+	db.SetConnMaxLifetime(5 * time.Minute)
+	return db, nil
+}
+
+// splitDB sends the statements that only read to the read pool and every
+// other statement, including INSERT ... RETURNING, to the writer. Statements
+// in a transaction are not affected: Queries.WithTx binds them to the
+// writer's transaction.
+type splitDB struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+func (s splitDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.writer.ExecContext(ctx, query, args...)
+}
+
+func (s splitDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.pool(query).PrepareContext(ctx, query)
+}
+
+func (s splitDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.pool(query).QueryContext(ctx, query, args...)
+}
+
+func (s splitDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.pool(query).QueryRowContext(ctx, query, args...)
+}
+
+func (s splitDB) pool(query string) *sql.DB {
+	if isReadStatement(query) {
+		return s.reader
+	}
+	return s.writer
+}
+
+// isReadStatement reports whether query is a SELECT, possibly with a WITH
+// clause, once the leading comments sqlc adds are skipped.
+func isReadStatement(query string) bool {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+		end := strings.IndexByte(query, '\n')
+		if end < 0 {
+			return false
+		}
+		query = query[end+1:]
+	}
+	keyword := query
+	if end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		keyword = query[:end]
+	}
+	return strings.EqualFold(keyword, "SELECT") || strings.EqualFold(keyword, "WITH")
+}
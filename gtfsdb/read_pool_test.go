@@ -0,0 +1,90 @@
+package gtfsdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func TestReadPool(t *testing.T) {
+	config := NewConfig(filepath.Join(t.TempDir(), "gtfs.db"), appconf.Development, false)
+	config.ReadConnections = 4
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NotSame(t, client.DB, client.ReadDB)
+	assert.Equal(t, 4, client.ReadDB.Stats().MaxOpenConnections)
+
+	var journalMode string
+	require.NoError(t, client.DB.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+
+	// INSERT ... RETURNING goes through QueryRow but must reach the writer.
+	agency, err := client.Queries.CreateAgency(ctx, CreateAgencyParams{
+		ID: "1", Name: "Metro", Url: "https://metro.example", Timezone: "America/Los_Angeles",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Metro", agency.Name)
+
+	got, err := client.Queries.GetAgency(ctx, "1")
+	require.NoError(t, err, "the read pool sees committed writes")
+	assert.Equal(t, "Metro", got.Name)
+
+	_, err = client.ReadDB.ExecContext(ctx, "DELETE FROM agencies")
+	assert.Error(t, err, "the read pool cannot write")
+
+	require.NoError(t, client.Close())
+	assert.NoFileExists(t, config.DBPath+"-wal", "closing checkpoints the WAL into the database file")
+}
+
+func TestImportWithReadPool(t *testing.T) {
+	config := NewConfig(filepath.Join(t.TempDir(), "gtfs.db"), appconf.Development, false)
+	config.ReadConnections = 2
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	require.NoError(t, client.ImportFromFile(ctx, filepath.Join("..", "testdata", "raba.zip")))
+
+	stop, err := client.Queries.GetStop(ctx, "1505")
+	require.NoError(t, err)
+	assert.Equal(t, "1505", stop.ID)
+	routes, err := client.Queries.ListRoutes(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, routes)
+}
+
+func TestReadPoolNotUsedInMemory(t *testing.T) {
+	config := NewConfig(":memory:", appconf.Test, false)
+	config.ReadConnections = 4
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	assert.Same(t, client.DB, client.ReadDB, "each in-memory connection would see its own empty database")
+}
+
+func TestIsReadStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		read  bool
+	}{
+		{getAgency, true},
+		{listAgencies, true},
+		{createAgency, false},
+		{clearAgencies, false},
+		{"\nSELECT 1", true},
+		{"with recent AS (SELECT 1) SELECT * FROM recent", true},
+		{"PRAGMA journal_mode", false},
+		{"-- only a comment", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.read, isReadStatement(tt.query), tt.query)
+	}
+}
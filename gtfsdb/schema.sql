@@ -27,9 +27,18 @@ CREATE TABLE
         text_color TEXT,
         continuous_pickup INTEGER,
         continuous_drop_off INTEGER,
+        sort_order INTEGER, -- route_sort_order; routes without one sort after those with one
+        network_id TEXT, -- from routes.txt or route_networks.txt
         FOREIGN KEY (agency_id) REFERENCES agencies (id)
     );
 
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS networks (
+        id TEXT PRIMARY KEY,
+        name TEXT
+    );
+
 -- migrate
 -- FTS5 external content table for full-text route search.
 -- Data lives in 'routes' table; only the search index is stored here.
@@ -178,41 +187,43 @@ WHERE
 
 END;
 
--- FTS5 external content table for full-text stop search.
+-- FTS5 external content table for full-text stop search over names and codes.
 -- Data lives in 'stops' table; only the search index is stored here.
 -- migrate
 CREATE VIRTUAL TABLE IF NOT EXISTS stops_fts USING fts5(
     id UNINDEXED,
-    stop_name,
+    name,
+    code,
+    content = 'stops',
+    content_rowid = 'rowid',
     tokenize = 'porter'
 );
 
 -- The triggers below keep the index synchronized with the content table.
 -- migrate
-DROP TRIGGER IF EXISTS stops_fts_insert_trigger;
 CREATE TRIGGER IF NOT EXISTS stops_fts_insert_trigger
 AFTER INSERT ON stops
 BEGIN
-    INSERT INTO stops_fts (rowid, id, stop_name)
-    VALUES (new.rowid, new.id, new.name);
+    INSERT INTO stops_fts (rowid, id, name, code)
+    VALUES (new.rowid, new.id, coalesce(new.name, ''), coalesce(new.code, ''));
 END;
 
 -- migrate
-DROP TRIGGER IF EXISTS stops_fts_update_trigger;
 CREATE TRIGGER IF NOT EXISTS stops_fts_update_trigger
 AFTER UPDATE ON stops
 BEGIN
-    DELETE FROM stops_fts WHERE rowid = old.rowid;
-    INSERT INTO stops_fts (rowid, id, stop_name)
-    VALUES (new.rowid, new.id, new.name);
+    INSERT INTO stops_fts (stops_fts, rowid, id, name, code)
+    VALUES ('delete', old.rowid, old.id, coalesce(old.name, ''), coalesce(old.code, ''));
+    INSERT INTO stops_fts (rowid, id, name, code)
+    VALUES (new.rowid, new.id, coalesce(new.name, ''), coalesce(new.code, ''));
 END;
 
 -- migrate
-DROP TRIGGER IF EXISTS stops_fts_delete_trigger;
 CREATE TRIGGER IF NOT EXISTS stops_fts_delete_trigger
 AFTER DELETE ON stops
 BEGIN
-    DELETE FROM stops_fts WHERE rowid = old.rowid;
+    INSERT INTO stops_fts (stops_fts, rowid, id, name, code)
+    VALUES ('delete', old.rowid, old.id, coalesce(old.name, ''), coalesce(old.code, ''));
 END;
 
 -- migrate
@@ -255,15 +266,25 @@ CREATE TABLE
         lat REAL NOT NULL,
         lon REAL NOT NULL,
         shape_pt_sequence INTEGER NOT NULL,
-        shape_dist_traveled REAL
+        shape_dist_traveled REAL,
+        distance_along_shape REAL -- meters from the shape's first point; the last point's is the shape's length
+    );
+
+-- migrate
+-- Shapes with the same points share one geometry: their points are stored in
+-- shapes once, under the geometry_id of the first of them.
+CREATE TABLE
+    IF NOT EXISTS shape_geometries (
+        shape_id TEXT PRIMARY KEY,
+        geometry_id TEXT NOT NULL
     );
 
 -- migrate
 CREATE TABLE
     IF NOT EXISTS stop_times (
         trip_id TEXT NOT NULL,
-        arrival_time INTEGER NOT NULL,
-        departure_time INTEGER NOT NULL,
+        arrival_time INTEGER NOT NULL, -- seconds since service-day midnight
+        departure_time INTEGER NOT NULL, -- seconds since service-day midnight
         stop_id TEXT NOT NULL,
         stop_sequence INTEGER NOT NULL,
         stop_headsign TEXT,
@@ -271,11 +292,141 @@ CREATE TABLE
         drop_off_type INTEGER DEFAULT 0,
         shape_dist_traveled REAL,
         timepoint INTEGER DEFAULT 1,
+        distance_along_shape REAL, -- meters along the trip's shape, computed at import; NULL without a shape
         FOREIGN KEY (trip_id) REFERENCES trips (id),
         FOREIGN KEY (stop_id) REFERENCES stops (id),
         PRIMARY KEY (trip_id, stop_sequence)
     );
 
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS frequencies (
+        trip_id TEXT NOT NULL,
+        start_time INTEGER NOT NULL, -- seconds since service-day midnight, like stop_times
+        end_time INTEGER NOT NULL,
+        headway_secs INTEGER NOT NULL,
+        exact_times INTEGER NOT NULL DEFAULT 0,
+        FOREIGN KEY (trip_id) REFERENCES trips (id),
+        PRIMARY KEY (trip_id, start_time)
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS transfers (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        from_stop_id TEXT NOT NULL,
+        to_stop_id TEXT NOT NULL,
+        from_route_id TEXT,
+        to_route_id TEXT,
+        from_trip_id TEXT,
+        to_trip_id TEXT,
+        transfer_type INTEGER NOT NULL DEFAULT 0,
+        min_transfer_time INTEGER,
+        FOREIGN KEY (from_stop_id) REFERENCES stops (id),
+        FOREIGN KEY (to_stop_id) REFERENCES stops (id)
+    );
+
+-- GTFS fares v1. A NULL transfers means unlimited transfers.
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS fare_attributes (
+        fare_id TEXT PRIMARY KEY,
+        agency_id TEXT,
+        price REAL NOT NULL,
+        currency_type TEXT NOT NULL,
+        payment_method INTEGER NOT NULL DEFAULT 0,
+        transfers INTEGER,
+        transfer_duration INTEGER
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS fare_rules (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        fare_id TEXT NOT NULL,
+        route_id TEXT,
+        origin_id TEXT,
+        destination_id TEXT,
+        contains_id TEXT,
+        FOREIGN KEY (fare_id) REFERENCES fare_attributes (fare_id)
+    );
+
+-- GTFS-Flex zones from locations.geojson. geometry holds the GeoJSON geometry
+-- object as-is; the bounding box lets spatial lookups skip most zones cheaply.
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS locations (
+        id TEXT PRIMARY KEY,
+        stop_name TEXT,
+        stop_desc TEXT,
+        geometry TEXT NOT NULL,
+        min_lat REAL NOT NULL,
+        min_lon REAL NOT NULL,
+        max_lat REAL NOT NULL,
+        max_lon REAL NOT NULL
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS location_groups (
+        id TEXT PRIMARY KEY,
+        location_group_name TEXT
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS location_group_stops (
+        location_group_id TEXT NOT NULL,
+        stop_id TEXT NOT NULL,
+        FOREIGN KEY (location_group_id) REFERENCES location_groups (id),
+        FOREIGN KEY (stop_id) REFERENCES stops (id),
+        PRIMARY KEY (location_group_id, stop_id)
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS booking_rules (
+        id TEXT PRIMARY KEY,
+        booking_type INTEGER NOT NULL,
+        prior_notice_duration_min INTEGER,
+        prior_notice_duration_max INTEGER,
+        prior_notice_last_day INTEGER,
+        prior_notice_last_time INTEGER, -- seconds since midnight
+        prior_notice_start_day INTEGER,
+        prior_notice_start_time INTEGER, -- seconds since midnight
+        prior_notice_service_id TEXT,
+        message TEXT,
+        pickup_message TEXT,
+        drop_off_message TEXT,
+        phone_number TEXT,
+        info_url TEXT,
+        booking_url TEXT
+    );
+
+-- GTFS-Flex attributes of stop_times.txt rows. Rows that serve a zone or a
+-- location group exist only here, since stop_times requires a stop; rows at a
+-- regular stop are kept here when they carry a booking rule, a pickup/drop-off
+-- window or continuous stopping behaviour.
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS flex_stop_times (
+        trip_id TEXT NOT NULL,
+        stop_sequence INTEGER NOT NULL,
+        stop_id TEXT,
+        location_id TEXT,
+        location_group_id TEXT,
+        start_pickup_drop_off_window INTEGER, -- seconds since service-day midnight, like stop_times
+        end_pickup_drop_off_window INTEGER,
+        pickup_type INTEGER NOT NULL DEFAULT 0,
+        drop_off_type INTEGER NOT NULL DEFAULT 0,
+        continuous_pickup INTEGER NOT NULL DEFAULT 1,
+        continuous_drop_off INTEGER NOT NULL DEFAULT 1,
+        pickup_booking_rule_id TEXT,
+        drop_off_booking_rule_id TEXT,
+        FOREIGN KEY (trip_id) REFERENCES trips (id),
+        PRIMARY KEY (trip_id, stop_sequence)
+    );
+
 -- migrate
 CREATE TABLE
     IF NOT EXISTS calendar_dates (
@@ -285,15 +436,75 @@ CREATE TABLE
         PRIMARY KEY (service_id, date)
     );
 
+-- etag and last_modified are the HTTP validators the feed was served with,
+-- sent back on the next download
 -- migrate
 CREATE TABLE
     IF NOT EXISTS import_metadata (
         id INTEGER PRIMARY KEY CHECK (id = 1), -- Only allow one row
         file_hash TEXT NOT NULL,
         import_time INTEGER NOT NULL,
-        file_source TEXT NOT NULL
+        file_source TEXT NOT NULL,
+        etag TEXT,
+        last_modified TEXT
+    );
+
+-- Warnings go-gtfs raised while parsing the imported feed, replaced on each import
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS import_warnings (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        file TEXT NOT NULL, -- e.g. stop_times.txt
+        row_num INTEGER NOT NULL, -- data row within the file, not counting blank lines
+        kind TEXT NOT NULL, -- go-gtfs warning type, e.g. AgencyMissingValues
+        message TEXT NOT NULL,
+        row_content TEXT NOT NULL -- the offending row as CSV
+    );
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_import_warnings_file_kind ON import_warnings (file, kind);
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS quarantined_rows (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        file TEXT NOT NULL, -- e.g. stop_times.txt
+        row_num INTEGER NOT NULL, -- data row within the file, not counting blank lines
+        kind TEXT NOT NULL, -- the broken reference, e.g. UnknownStopReference
+        row_content TEXT NOT NULL -- the row as CSV
+    );
+
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS feed_info (
+        id INTEGER PRIMARY KEY CHECK (id = 1), -- feed_info.txt holds a single record
+        feed_publisher_name TEXT NOT NULL,
+        feed_publisher_url TEXT NOT NULL,
+        feed_lang TEXT NOT NULL,
+        default_lang TEXT,
+        feed_start_date TEXT, -- YYYYMMDD
+        feed_end_date TEXT, -- YYYYMMDD
+        feed_version TEXT,
+        feed_contact_email TEXT,
+        feed_contact_url TEXT
     );
 
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS translations (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        table_name TEXT NOT NULL, -- e.g. stops
+        field_name TEXT NOT NULL, -- e.g. stop_name
+        language TEXT NOT NULL, -- BCP-47 code
+        translation TEXT NOT NULL,
+        record_id TEXT, -- the translated record; NULL when field_value is set
+        record_sub_id TEXT, -- stop_sequence of a translated stop_times record
+        field_value TEXT -- translates every record whose field holds this value
+    );
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_translations_table_language ON translations (table_name, language);
+
 -- migrate
 CREATE TABLE
     IF NOT EXISTS block_trip_index (
@@ -313,6 +524,8 @@ CREATE TABLE
         block_id TEXT,
         service_id TEXT NOT NULL,
         block_trip_sequence INTEGER NOT NULL, -- Order of trip within the block
+        shape_length REAL, -- Length of the trip's shape in meters; NULL without a shape
+        block_distance REAL, -- Total shape_length of the block's earlier trips on the same service ID; NULL when one has no shape
         FOREIGN KEY (block_trip_index_id) REFERENCES block_trip_index (id),
         FOREIGN KEY (trip_id) REFERENCES trips (id)
     );
@@ -338,6 +551,10 @@ CREATE INDEX IF NOT EXISTS idx_stop_times_stop_arrival ON stop_times (stop_id, a
 -- migrate
 CREATE INDEX IF NOT EXISTS idx_stop_times_stop_id_trip_id ON stop_times (stop_id, trip_id);
 
+-- migrate
+-- Riders look stops up by the code printed on signage
+CREATE INDEX IF NOT EXISTS idx_stops_code ON stops (code);
+
 -- migrate
 CREATE INDEX IF NOT EXISTS idx_calendar_dates_service_id ON calendar_dates (service_id);
 
@@ -359,6 +576,21 @@ CREATE INDEX IF NOT EXISTS idx_trips_block_id ON trips (block_id);
 -- migrate
 CREATE INDEX IF NOT EXISTS idx_shapes_shape_id ON shapes (shape_id);
 
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_transfers_from_stop_id ON transfers (from_stop_id);
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_fare_rules_route_id ON fare_rules (route_id);
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_location_group_stops_stop_id ON location_group_stops (stop_id);
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_flex_stop_times_location_id ON flex_stop_times (location_id);
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_flex_stop_times_location_group_id ON flex_stop_times (location_group_id);
+
 -- Problem reports for trips
 -- migrate
 CREATE TABLE
@@ -417,3 +649,64 @@ CREATE INDEX IF NOT EXISTS idx_problem_reports_stop_created
 -- migrate
 CREATE INDEX IF NOT EXISTS idx_problem_reports_stop_code
     ON problem_reports_stop (code);
+
+-- Vehicle position history recorded from GTFS-RT VehiclePositions
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS vehicle_positions_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        feed_id TEXT NOT NULL,
+        vehicle_id TEXT NOT NULL,
+        trip_id TEXT,
+        route_id TEXT,
+        lat REAL,
+        lon REAL,
+        bearing REAL,
+        speed REAL,
+        schedule_deviation INTEGER, -- seconds, from the trip update observed alongside the position
+        stop_id TEXT, -- current or next stop reported by the vehicle
+        occupancy_status INTEGER, -- GTFS-RT OccupancyStatus enum value
+        observed_at INTEGER NOT NULL, -- Unix milliseconds (vehicle timestamp when reported)
+        UNIQUE (vehicle_id, observed_at)
+    );
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_vehicle_positions_history_trip
+    ON vehicle_positions_history (trip_id, observed_at);
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_vehicle_positions_history_observed
+    ON vehicle_positions_history (observed_at);
+
+-- Occupancy observations aggregated by trip, stop and service day of week.
+-- Unlike the raw history it is not pruned, so it accumulates over weeks of service.
+-- It is copied into the database that replaces this one on a static feed update.
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS historical_occupancy (
+        trip_id TEXT NOT NULL,
+        stop_id TEXT NOT NULL,
+        day_of_week INTEGER NOT NULL, -- 0 = Sunday, of the trip's service date
+        occupancy_status INTEGER NOT NULL,
+        sample_count INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (trip_id, stop_id, day_of_week, occupancy_status)
+    );
+
+-- Schedule deviation at each stop of a trip's service day, for on-time
+-- performance. Only the latest observation per stop is kept: a vehicle reports
+-- the stop it is approaching, so its last report is the closest to the actual
+-- arrival. Like historical_occupancy, it is copied across static feed updates.
+-- migrate
+CREATE TABLE
+    IF NOT EXISTS schedule_deviation_samples (
+        trip_id TEXT NOT NULL,
+        stop_id TEXT NOT NULL,
+        service_date TEXT NOT NULL, -- YYYYMMDD
+        deviation INTEGER NOT NULL, -- seconds, positive when late
+        observed_at INTEGER NOT NULL, -- Unix milliseconds
+        PRIMARY KEY (trip_id, stop_id, service_date)
+    );
+
+-- migrate
+CREATE INDEX IF NOT EXISTS idx_schedule_deviation_samples_observed
+    ON schedule_deviation_samples (observed_at);
@@ -0,0 +1,50 @@
+package gtfsdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// serviceHistoryTables are the tables built up from realtime observations
+// rather than imported from the static feed, with the columns copied from
+// each. They outlive the feed they were observed against.
+var serviceHistoryTables = []struct {
+	name    string
+	columns string
+}{
+	{"historical_occupancy", "trip_id, stop_id, day_of_week, occupancy_status, sample_count"},
+	{"schedule_deviation_samples", "trip_id, stop_id, service_date, deviation, observed_at"},
+}
+
+// CopyServiceHistory copies the service history of the database at path, the
+// one this database replaces, so that a static feed update does not reset the
+// occupancy history and on-time performance gathered over weeks of service.
+// Rows already in this database are kept.
+func (c *Client) CopyServiceHistory(ctx context.Context, path string) error {
+	// ATTACH applies to a single connection, so the copy holds on to one.
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS previous", path); err != nil {
+		return fmt.Errorf("failed to attach previous database: %w", err)
+	}
+	defer func() { _, _ = conn.ExecContext(context.Background(), "DETACH DATABASE previous") }()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range serviceHistoryTables {
+		query := fmt.Sprintf("INSERT OR IGNORE INTO main.%[1]s (%[2]s) SELECT %[2]s FROM previous.%[1]s",
+			table.name, table.columns)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", table.name, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,150 @@
+package gtfsdb
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/geo"
+)
+
+// cumulativeShapeDistances returns how far, in meters, each point of the shape
+// lies from its first point.
+func cumulativeShapeDistances(points []gtfs.ShapePoint) []float64 {
+	distances := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		distances[i] = distances[i-1] + geo.Distance(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+	}
+	return distances
+}
+
+// stopDistancePlacer places the stop times of an imported feed's trips along
+// their shapes. Trips commonly share a shape and a stop pattern, so the
+// distances of each shape geometry and of each pattern on it are worked out
+// once.
+type stopDistancePlacer struct {
+	geometries map[string]string
+	shapes     map[string][]float64
+	patterns   map[string][]float64
+}
+
+// newStopDistancePlacer returns a placer for shapes grouped by geometries,
+// which map shape IDs to the ID of the shape with the same points, if any.
+func newStopDistancePlacer(geometries map[string]string) *stopDistancePlacer {
+	return &stopDistancePlacer{
+		geometries: geometries,
+		shapes:     make(map[string][]float64),
+		patterns:   make(map[string][]float64),
+	}
+}
+
+// geometryID returns the ID the shape's points are stored under.
+func (p *stopDistancePlacer) geometryID(shape *gtfs.Shape) string {
+	if id, ok := p.geometries[shape.ID]; ok {
+		return id
+	}
+	return shape.ID
+}
+
+// shapeDistances returns the cumulative distances of the shape's points.
+func (p *stopDistancePlacer) shapeDistances(shape *gtfs.Shape) []float64 {
+	id := p.geometryID(shape)
+	distances, ok := p.shapes[id]
+	if !ok {
+		distances = cumulativeShapeDistances(shape.Points)
+		p.shapes[id] = distances
+	}
+	return distances
+}
+
+// place returns how far along its shape, in meters, each of the trip's stop
+// times lies. A feed's shape_dist_traveled is converted to meters when the
+// stop times and the shape both carry it; otherwise each stop is matched to
+// the shape the way the REST API matches it. All distances are NULL when the
+// trip has no shape of at least two points or a stop has no location.
+func (p *stopDistancePlacer) place(trip *gtfs.ScheduledTrip) []sql.NullFloat64 {
+	distances := make([]sql.NullFloat64, len(trip.StopTimes))
+	shape := trip.Shape
+	if shape == nil || len(shape.Points) < 2 {
+		return distances
+	}
+	cumulative := p.shapeDistances(shape)
+
+	placed, ok := feedStopDistances(shape, cumulative, trip.StopTimes)
+	if !ok {
+		placed, ok = p.matchedStopDistances(shape, cumulative, trip.StopTimes)
+	}
+	if !ok {
+		return distances
+	}
+	for i, distance := range placed {
+		distances[i] = sql.NullFloat64{Float64: distance, Valid: true}
+	}
+	return distances
+}
+
+// feedStopDistances converts the stop times' shape_dist_traveled, which is in
+// whatever unit the feed chose, to meters by locating each value between the
+// shape points' own shape_dist_traveled. It returns false when a value is
+// missing, the shape's values decrease, or a stop's value lies off the shape.
+func feedStopDistances(shape *gtfs.Shape, cumulative []float64, stopTimes []gtfs.ScheduledStopTime) ([]float64, bool) {
+	traveled := make([]float64, len(shape.Points))
+	for i, point := range shape.Points {
+		if point.Distance == nil || (i > 0 && *point.Distance < traveled[i-1]) {
+			return nil, false
+		}
+		traveled[i] = *point.Distance
+	}
+	first, last := traveled[0], traveled[len(traveled)-1]
+	if last <= first {
+		return nil, false
+	}
+
+	distances := make([]float64, len(stopTimes))
+	for i, st := range stopTimes {
+		if st.ShapeDistanceTraveled == nil {
+			return nil, false
+		}
+		value := *st.ShapeDistanceTraveled
+		if value < first || value > last {
+			return nil, false
+		}
+		j := sort.SearchFloat64s(traveled, value)
+		if j == 0 {
+			distances[i] = cumulative[0]
+			continue
+		}
+		ratio := 0.0
+		if span := traveled[j] - traveled[j-1]; span > 0 {
+			ratio = (value - traveled[j-1]) / span
+		}
+		distances[i] = cumulative[j-1] + ratio*(cumulative[j]-cumulative[j-1])
+	}
+	return distances, true
+}
+
+// matchedStopDistances places the stops geometrically, remembering the result
+// for the shape and stop pattern.
+func (p *stopDistancePlacer) matchedStopDistances(shape *gtfs.Shape, cumulative []float64, stopTimes []gtfs.ScheduledStopTime) ([]float64, bool) {
+	var key strings.Builder
+	key.WriteString(p.geometryID(shape))
+	stops := make([]geo.Point, len(stopTimes))
+	for i, st := range stopTimes {
+		if st.Stop == nil || st.Stop.Latitude == nil || st.Stop.Longitude == nil {
+			return nil, false
+		}
+		key.WriteByte(0)
+		key.WriteString(st.Stop.Id)
+		stops[i] = geo.Point{Lat: *st.Stop.Latitude, Lon: *st.Stop.Longitude}
+	}
+
+	if distances, ok := p.patterns[key.String()]; ok {
+		return distances, true
+	}
+	// Stored distances are always placed with the planar projection.
+	distances := geo.PlaceStops(shape.Points, cumulative, stops, false)
+	p.patterns[key.String()] = distances
+	return distances, true
+}
@@ -0,0 +1,105 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scheduledStop(id string, lat, lon float64) *gtfs.Stop {
+	return &gtfs.Stop{Id: id, Latitude: &lat, Longitude: &lon}
+}
+
+func TestStopDistancePlacerConvertsShapeDistTraveled(t *testing.T) {
+	// shape_dist_traveled in kilometers, a unit the placer has to convert.
+	km := func(v float64) *float64 { return &v }
+	shape := &gtfs.Shape{ID: "km", Points: []gtfs.ShapePoint{
+		{Latitude: 40.00, Longitude: -75.00, Distance: km(0)},
+		{Latitude: 40.01, Longitude: -75.00, Distance: km(1.1)},
+		{Latitude: 40.02, Longitude: -75.00, Distance: km(2.2)},
+	}}
+	stop := scheduledStop("S", 40.00, -75.00) // not where the distances put it
+	trip := &gtfs.ScheduledTrip{ID: "T", Shape: shape, StopTimes: []gtfs.ScheduledStopTime{
+		{Stop: stop, ShapeDistanceTraveled: km(0)},
+		{Stop: stop, ShapeDistanceTraveled: km(1.65)},
+		{Stop: stop, ShapeDistanceTraveled: km(2.2)},
+	}}
+
+	cumulative := cumulativeShapeDistances(shape.Points)
+	distances := newStopDistancePlacer(nil).place(trip)
+	require.Len(t, distances, 3)
+	assert.InDelta(t, 0, distances[0].Float64, 1e-9)
+	assert.InDelta(t, (cumulative[1]+cumulative[2])/2, distances[1].Float64, 1e-9)
+	assert.InDelta(t, cumulative[2], distances[2].Float64, 1e-9)
+}
+
+func TestStopDistancePlacerMatchesStopsWithoutShapeDistTraveled(t *testing.T) {
+	// Out and back: the terminal is passed at both ends of the trip.
+	shape := &gtfs.Shape{ID: "loop", Points: []gtfs.ShapePoint{
+		{Latitude: 40.00, Longitude: -75.00},
+		{Latitude: 40.02, Longitude: -75.00},
+		{Latitude: 40.00, Longitude: -75.00},
+	}}
+	terminal := scheduledStop("TERMINAL", 40.00, -75.00)
+	trip := &gtfs.ScheduledTrip{ID: "T", Shape: shape, StopTimes: []gtfs.ScheduledStopTime{
+		{Stop: terminal},
+		{Stop: scheduledStop("MIDDLE", 40.01, -75.00)},
+		{Stop: scheduledStop("TURN", 40.02, -75.00)},
+		{Stop: terminal},
+	}}
+
+	placer := newStopDistancePlacer(nil)
+	distances := placer.place(trip)
+	half := cumulativeShapeDistances(shape.Points)[1]
+	require.Len(t, distances, 4)
+	assert.InDelta(t, 0, distances[0].Float64, 1e-6)
+	assert.InDelta(t, half/2, distances[1].Float64, 1e-6)
+	assert.InDelta(t, half, distances[2].Float64, 1e-6)
+	assert.InDelta(t, 2*half, distances[3].Float64, 1e-6, "the second call at the terminal is the return pass")
+	assert.Len(t, placer.patterns, 1, "the stop pattern is remembered for other trips")
+
+	// A trip without a shape gets no distances.
+	for _, distance := range placer.place(&gtfs.ScheduledTrip{ID: "U", StopTimes: trip.StopTimes}) {
+		assert.False(t, distance.Valid)
+	}
+}
+
+func TestImportStoresShapeDistances(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign,shape_id
+ROUTE1,WEEKDAY,TRIP1,Downtown,SHAPE1
+ROUTE1,WEEKDAY,TRIP2,Uptown,
+`,
+		"shapes.txt": `shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence
+SHAPE1,40.7128,-74.0060,1
+SHAPE1,40.7354,-73.9957,2
+SHAPE1,40.7580,-73.9855,3
+`,
+	}))
+	ctx := context.Background()
+
+	points, err := client.Queries.GetShapeByID(ctx, "SHAPE1")
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	length := cumulativeShapeDistances([]gtfs.ShapePoint{
+		{Latitude: 40.7128, Longitude: -74.0060},
+		{Latitude: 40.7354, Longitude: -73.9957},
+		{Latitude: 40.7580, Longitude: -73.9855},
+	})[2]
+	assert.Zero(t, points[0].DistanceAlongShape.Float64)
+	assert.True(t, points[0].DistanceAlongShape.Valid)
+	assert.InDelta(t, length, points[2].DistanceAlongShape.Float64, 1e-6, "the last point's distance is the shape's length")
+
+	stopTimes, err := client.Queries.GetStopTimesForTrip(ctx, "TRIP1")
+	require.NoError(t, err)
+	require.Len(t, stopTimes, 2)
+	assert.InDelta(t, 0, stopTimes[0].DistanceAlongShape.Float64, 1e-6)
+	assert.InDelta(t, length, stopTimes[1].DistanceAlongShape.Float64, 1e-6)
+
+	stopTimes, err = client.Queries.GetStopTimesForTrip(ctx, "TRIP2")
+	require.NoError(t, err)
+	assert.False(t, stopTimes[0].DistanceAlongShape.Valid, "the trip has no shape")
+}
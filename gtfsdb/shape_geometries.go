@@ -0,0 +1,93 @@
+package gtfsdb
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// shapeGeometries is the result of deduplicating a feed's shapes: the shapes
+// whose points are stored, one per distinct point sequence, and the geometry
+// every shape ID maps to.
+type shapeGeometries struct {
+	stored     []*gtfs.Shape
+	geometryOf map[string]string // Shape ID to the ID of the stored shape with the same points
+}
+
+// dedupeShapes groups shapes by their point sequences. Large feeds often draw
+// the same path under many shape IDs; each distinct sequence, including any
+// shape_dist_traveled, is stored once under the ID of its first shape.
+func dedupeShapes(shapes []gtfs.Shape) shapeGeometries {
+	result := shapeGeometries{geometryOf: make(map[string]string, len(shapes))}
+	byHash := make(map[[sha256.Size]byte][]*gtfs.Shape)
+	for i := range shapes {
+		shape := &shapes[i]
+		if _, seen := result.geometryOf[shape.ID]; seen {
+			continue
+		}
+
+		hash := hashShapePoints(shape.Points)
+		geometryID := ""
+		for _, stored := range byHash[hash] {
+			if sameShapePoints(stored.Points, shape.Points) {
+				geometryID = stored.ID
+				break
+			}
+		}
+		if geometryID == "" {
+			geometryID = shape.ID
+			byHash[hash] = append(byHash[hash], shape)
+			result.stored = append(result.stored, shape)
+		}
+		result.geometryOf[shape.ID] = geometryID
+	}
+	return result
+}
+
+// params returns the shape_geometries rows mapping every shape to its geometry.
+func (g shapeGeometries) params() []CreateShapeGeometryParams {
+	params := make([]CreateShapeGeometryParams, 0, len(g.geometryOf))
+	for shapeID, geometryID := range g.geometryOf {
+		params = append(params, CreateShapeGeometryParams{ShapeID: shapeID, GeometryID: geometryID})
+	}
+	return params
+}
+
+func hashShapePoints(points []gtfs.ShapePoint) [sha256.Size]byte {
+	h := sha256.New()
+	var buf [25]byte
+	for _, pt := range points {
+		binary.LittleEndian.PutUint64(buf[0:], math.Float64bits(pt.Latitude))
+		binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(pt.Longitude))
+		buf[16] = 0
+		binary.LittleEndian.PutUint64(buf[17:], 0)
+		if pt.Distance != nil {
+			buf[16] = 1
+			binary.LittleEndian.PutUint64(buf[17:], math.Float64bits(*pt.Distance))
+		}
+		h.Write(buf[:])
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func sameShapePoints(a, b []gtfs.ShapePoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Latitude != b[i].Latitude || a[i].Longitude != b[i].Longitude {
+			return false
+		}
+		if (a[i].Distance == nil) != (b[i].Distance == nil) {
+			return false
+		}
+		if a[i].Distance != nil && *a[i].Distance != *b[i].Distance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeShapes(t *testing.T) {
+	distance := 1.5
+	otherDistance := 2.5
+	points := func(d *float64) []gtfs.ShapePoint {
+		return []gtfs.ShapePoint{
+			{Latitude: 40.71, Longitude: -74.00},
+			{Latitude: 40.75, Longitude: -73.98, Distance: d},
+		}
+	}
+
+	geometries := dedupeShapes([]gtfs.Shape{
+		{ID: "A", Points: points(nil)},
+		{ID: "B", Points: points(nil)},
+		{ID: "C", Points: points(&distance)},
+		{ID: "D", Points: points(&otherDistance)},
+		{ID: "E", Points: points(&distance)},
+		{ID: "F", Points: points(nil)[:1]},
+	})
+
+	assert.Equal(t, map[string]string{
+		"A": "A", "B": "A",
+		"C": "C", "E": "C",
+		"D": "D",
+		"F": "F",
+	}, geometries.geometryOf)
+
+	stored := make([]string, len(geometries.stored))
+	for i, shape := range geometries.stored {
+		stored[i] = shape.ID
+	}
+	assert.Equal(t, []string{"A", "C", "D", "F"}, stored)
+	assert.Len(t, geometries.params(), 6)
+}
+
+func TestImportDeduplicatesShapes(t *testing.T) {
+	client := newImportedTestClient(t, createGTFSZip(t, map[string]string{
+		"trips.txt": `route_id,service_id,trip_id,trip_headsign,shape_id
+ROUTE1,WEEKDAY,TRIP1,Downtown,SHAPE1
+ROUTE1,WEEKDAY,TRIP2,Uptown,SHAPE2
+`,
+		"shapes.txt": `shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence
+SHAPE1,40.7128,-74.0060,1
+SHAPE1,40.7354,-73.9957,2
+SHAPE1,40.7580,-73.9855,3
+SHAPE2,40.7128,-74.0060,1
+SHAPE2,40.7354,-73.9957,2
+SHAPE2,40.7580,-73.9855,3
+SHAPE3,40.7580,-73.9855,1
+SHAPE3,40.7128,-74.0060,2
+`,
+	}))
+	ctx := context.Background()
+
+	var stored int
+	require.NoError(t, client.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM shapes").Scan(&stored))
+	assert.Equal(t, 5, stored, "the points shared by SHAPE1 and SHAPE2 are stored once")
+
+	geometries, err := client.Queries.GetShapeGeometries(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []ShapeGeometry{
+		{ShapeID: "SHAPE1", GeometryID: "SHAPE1"},
+		{ShapeID: "SHAPE2", GeometryID: "SHAPE1"},
+		{ShapeID: "SHAPE3", GeometryID: "SHAPE3"},
+	}, geometries)
+
+	shared, err := client.Queries.GetShapePoints(ctx, "SHAPE2")
+	require.NoError(t, err)
+	original, err := client.Queries.GetShapePoints(ctx, "SHAPE1")
+	require.NoError(t, err)
+	require.Len(t, shared, 3)
+	assert.Equal(t, original, shared)
+
+	tripPoints, err := client.Queries.GetShapePointsByTripID(ctx, "TRIP2")
+	require.NoError(t, err)
+	assert.Len(t, tripPoints, 3)
+
+	byID, err := client.Queries.GetShapePointsByIDs(ctx, []string{"SHAPE2", "SHAPE3"})
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, point := range byID {
+		counts[point.ShapeID]++
+	}
+	assert.Equal(t, map[string]int{"SHAPE2": 3, "SHAPE3": 2}, counts)
+
+	// Both trips are placed along the shared geometry.
+	for _, tripID := range []string{"TRIP1", "TRIP2"} {
+		stopTimes, err := client.Queries.GetStopTimesForTrip(ctx, tripID)
+		require.NoError(t, err)
+		require.Len(t, stopTimes, 2)
+		assert.True(t, stopTimes[1].DistanceAlongShape.Valid, tripID)
+	}
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	geometries, err = client.Queries.GetShapeGeometries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, geometries)
+}
@@ -0,0 +1,41 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportTransfers(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"transfers.txt": `from_stop_id,to_stop_id,from_route_id,to_route_id,transfer_type,min_transfer_time
+STOP1,STOP2,,,2,180
+STOP1,STOP1,ROUTE1,ROUTE1,1,
+STOP2,STOP1,,,3,
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	transfers, err := client.Queries.GetTransfersFromStop(ctx, "STOP1")
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	// Ordered by to_stop_id
+	assert.Equal(t, "STOP1", transfers[0].ToStopID)
+	assert.Equal(t, int64(1), transfers[0].TransferType)
+	assert.Equal(t, "ROUTE1", transfers[0].FromRouteID.String)
+	assert.False(t, transfers[0].MinTransferTime.Valid)
+
+	assert.Equal(t, "STOP2", transfers[1].ToStopID)
+	assert.Equal(t, int64(2), transfers[1].TransferType)
+	assert.True(t, transfers[1].MinTransferTime.Valid)
+	assert.Equal(t, int64(180), transfers[1].MinTransferTime.Int64)
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	transfers, err = client.Queries.GetTransfersFromStop(ctx, "STOP1")
+	require.NoError(t, err)
+	assert.Empty(t, transfers)
+}
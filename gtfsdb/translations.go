@@ -0,0 +1,78 @@
+package gtfsdb
+
+import (
+	"context"
+	"strings"
+)
+
+// Translator translates fields of the records of one table into a language,
+// from the rows of translations.txt. A nil Translator translates nothing.
+type Translator struct {
+	byRecord map[translationKey]string // Keyed by record ID
+	byValue  map[translationKey]string // Keyed by untranslated field value
+}
+
+type translationKey struct {
+	field string
+	key   string
+}
+
+// LoadTranslator loads the translations into language of the fields of the
+// given records of table, e.g. "stops". A language with a region or script,
+// such as "fr-CA", falls back to its primary language, "fr", field by field.
+// It returns nil when there are no translations to apply.
+func (c *Client) LoadTranslator(ctx context.Context, table, language string, recordIDs []string) (*Translator, error) {
+	languages := []string{language}
+	primary, _, hasSubtag := strings.Cut(language, "-")
+	if hasSubtag {
+		languages = append(languages, primary)
+	}
+
+	rows, err := c.Queries.GetTranslations(ctx, GetTranslationsParams{
+		TableName: table,
+		Languages: languages,
+		RecordIds: recordIDs,
+	})
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	t := &Translator{
+		byRecord: make(map[translationKey]string),
+		byValue:  make(map[translationKey]string),
+	}
+	// Apply the primary language first so the exact language overwrites it.
+	for _, exact := range []bool{false, true} {
+		for _, row := range rows {
+			if (row.Language == language) != exact {
+				continue
+			}
+			switch {
+			case row.RecordID.Valid:
+				t.byRecord[translationKey{row.FieldName, row.RecordID.String}] = row.Translation
+			case row.FieldValue.Valid:
+				t.byValue[translationKey{row.FieldName, row.FieldValue.String}] = row.Translation
+			}
+		}
+	}
+	return t, nil
+}
+
+// Translate returns the translation of a field, e.g. "stop_name", of a record
+// whose untranslated value is value. A translation of the record wins over one
+// of the value; without either, value is returned unchanged.
+func (t *Translator) Translate(field, recordID, value string) string {
+	if t == nil {
+		return value
+	}
+	if translation, ok := t.byRecord[translationKey{field, recordID}]; ok {
+		return translation
+	}
+	if value == "" {
+		return value
+	}
+	if translation, ok := t.byValue[translationKey{field, value}]; ok {
+		return translation
+	}
+	return value
+}
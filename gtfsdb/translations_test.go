@@ -0,0 +1,49 @@
+package gtfsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportTranslations(t *testing.T) {
+	gtfsData := createGTFSZip(t, map[string]string{
+		"translations.txt": `table_name,field_name,language,translation,record_id,record_sub_id,field_value
+stops,stop_name,fr,Premier Arrêt,STOP1,,
+stops,stop_name,fr-CA,Premier arrêt (Québec),STOP1,,
+stops,stop_name,fr,Deuxième Arrêt,,,Second Stop
+routes,route_long_name,fr,Route d'essai,ROUTE1,,
+stops,stop_name,,Missing language,STOP2,,
+`,
+	})
+	client := newImportedTestClient(t, gtfsData)
+	ctx := context.Background()
+
+	stops, err := client.LoadTranslator(ctx, "stops", "fr", []string{"STOP1", "STOP2"})
+	require.NoError(t, err)
+	assert.Equal(t, "Premier Arrêt", stops.Translate("stop_name", "STOP1", "First Stop"))
+	assert.Equal(t, "Deuxième Arrêt", stops.Translate("stop_name", "STOP2", "Second Stop"), "translated by field value")
+	assert.Equal(t, "Third Stop", stops.Translate("stop_name", "STOP3", "Third Stop"))
+
+	regional, err := client.LoadTranslator(ctx, "stops", "fr-CA", []string{"STOP1", "STOP2"})
+	require.NoError(t, err)
+	assert.Equal(t, "Premier arrêt (Québec)", regional.Translate("stop_name", "STOP1", "First Stop"))
+	assert.Equal(t, "Deuxième Arrêt", regional.Translate("stop_name", "STOP2", "Second Stop"), "falls back to the primary language")
+
+	routes, err := client.LoadTranslator(ctx, "routes", "fr", []string{"ROUTE1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Route d'essai", routes.Translate("route_long_name", "ROUTE1", "Test Route"))
+	assert.Equal(t, "1", routes.Translate("route_short_name", "ROUTE1", "1"))
+
+	none, err := client.LoadTranslator(ctx, "stops", "de", []string{"STOP1"})
+	require.NoError(t, err)
+	assert.Nil(t, none)
+	assert.Equal(t, "First Stop", none.Translate("stop_name", "STOP1", "First Stop"))
+
+	require.NoError(t, client.clearAllGTFSData(ctx))
+	cleared, err := client.LoadTranslator(ctx, "stops", "fr", []string{"STOP1"})
+	require.NoError(t, err)
+	assert.Nil(t, cleared)
+}
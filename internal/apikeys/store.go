@@ -0,0 +1,476 @@
+// Package apikeys stores API keys that are managed at runtime, alongside the
+// keys listed in the server configuration. Each stored key may carry its own
+// rate limit and expiration time, and the store counts the requests made with it.
+//
+// Keys live in a small SQLite database of their own rather than in the GTFS
+// database, which is rebuilt from scratch whenever the static feed is reloaded.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // CGo-based SQLite driver
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// usageFlushInterval is how often buffered usage counters are written to the database.
+const usageFlushInterval = time.Minute
+
+// maxKeyLength bounds the length of a key supplied by an administrator.
+const maxKeyLength = 128
+
+var (
+	// ErrKeyNotFound is returned when a key is not in the store.
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrKeyExists is returned when creating a key that is already stored.
+	ErrKeyExists = errors.New("api key already exists")
+)
+
+// ValidationError reports an unacceptable value for one field of a key.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+    key TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    rate_limit INTEGER,
+    expires_at INTEGER,
+    created_at INTEGER NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at INTEGER
+)`
+
+// Key is a stored API key.
+type Key struct {
+	Key  string
+	Name string
+	// RateLimit is the requests per second allowed for this key; nil uses the
+	// server-wide limit.
+	RateLimit *int
+	// ExpiresAt is when the key stops being accepted; nil means never.
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
+	RequestCount int64
+	LastUsedAt   *time.Time
+}
+
+// Expired reports whether the key is no longer accepted at now.
+func (k Key) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// NewKey describes a key to create. An empty Key generates a random one.
+type NewKey struct {
+	Key       string
+	Name      string
+	RateLimit *int
+	ExpiresAt *time.Time
+}
+
+// KeyUpdate lists the fields of a key to change; nil fields are left alone.
+// ClearRateLimit and ClearExpiresAt remove the per-key limit and expiry.
+type KeyUpdate struct {
+	Name           *string
+	RateLimit      *int
+	ClearRateLimit bool
+	ExpiresAt      *time.Time
+	ClearExpiresAt bool
+}
+
+// Store holds the stored keys in memory for request-time lookups and persists
+// changes to SQLite. Usage counters are buffered and written periodically.
+type Store struct {
+	db    *sql.DB
+	clock clock.Clock
+
+	mu       sync.RWMutex
+	keys     map[string]Key
+	pending  map[string]int64 // requests not yet written to the database
+	lastUsed map[string]time.Time
+
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// Open opens (creating if needed) the key database at path and loads its keys.
+func Open(path string, c clock.Clock) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection keeps ":memory:" databases intact and serializes writes.
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error creating api_keys table: %w", err)
+	}
+
+	s := &Store{
+		db:       db,
+		clock:    c,
+		keys:     make(map[string]Key),
+		pending:  make(map[string]int64),
+		lastUsed: make(map[string]time.Time),
+		stopChan: make(chan struct{}),
+	}
+	if err := s.load(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+	return s, nil
+}
+
+func (s *Store) load(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT key, name, rate_limit, expires_at, created_at, request_count, last_used_at
+FROM api_keys`)
+	if err != nil {
+		return fmt.Errorf("error loading api keys: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			k         Key
+			rateLimit sql.NullInt64
+			expiresAt sql.NullInt64
+			createdAt int64
+			lastUsed  sql.NullInt64
+		)
+		if err := rows.Scan(&k.Key, &k.Name, &rateLimit, &expiresAt, &createdAt, &k.RequestCount, &lastUsed); err != nil {
+			return fmt.Errorf("error loading api keys: %w", err)
+		}
+		if rateLimit.Valid {
+			limit := int(rateLimit.Int64)
+			k.RateLimit = &limit
+		}
+		k.ExpiresAt = timeFromNullMillis(expiresAt)
+		k.CreatedAt = time.UnixMilli(createdAt)
+		k.LastUsedAt = timeFromNullMillis(lastUsed)
+		s.keys[k.Key] = k
+	}
+	return rows.Err()
+}
+
+// Lookup returns the key if it is stored and has not expired.
+func (s *Store) Lookup(key string) (Key, bool) {
+	s.mu.RLock()
+	k, ok := s.keys[key]
+	s.mu.RUnlock()
+	if !ok || k.Expired(s.clock.Now()) {
+		return Key{}, false
+	}
+	return k, true
+}
+
+// RateLimit returns the per-key rate limit of a stored key, if it has one.
+func (s *Store) RateLimit(key string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[key]
+	if !ok || k.RateLimit == nil {
+		return 0, false
+	}
+	return *k.RateLimit, true
+}
+
+// RecordUse counts one request made with key. Unknown keys are ignored.
+func (s *Store) RecordUse(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key]; !ok {
+		return
+	}
+	s.pending[key]++
+	s.lastUsed[key] = s.clock.Now()
+}
+
+// Get returns a stored key, expired or not, with its up-to-date usage counters.
+func (s *Store) Get(key string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[key]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return s.withUsageLocked(k), nil
+}
+
+// List returns every stored key ordered by key.
+func (s *Store) List() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, s.withUsageLocked(k))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+func (s *Store) withUsageLocked(k Key) Key {
+	k.RequestCount += s.pending[k.Key]
+	if last, ok := s.lastUsed[k.Key]; ok {
+		k.LastUsedAt = &last
+	}
+	return k
+}
+
+// Create stores a new key.
+func (s *Store) Create(ctx context.Context, nk NewKey) (Key, error) {
+	if nk.Key == "" {
+		generated, err := generateKey()
+		if err != nil {
+			return Key{}, err
+		}
+		nk.Key = generated
+	}
+	if err := validateKey(nk.Key); err != nil {
+		return Key{}, err
+	}
+	if err := validateRateLimit(nk.RateLimit); err != nil {
+		return Key{}, err
+	}
+
+	k := Key{
+		Key:       nk.Key,
+		Name:      nk.Name,
+		RateLimit: nk.RateLimit,
+		ExpiresAt: nk.ExpiresAt,
+		CreatedAt: s.clock.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.keys[k.Key]; exists {
+		return Key{}, ErrKeyExists
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO api_keys (key, name, rate_limit, expires_at, created_at)
+VALUES (?, ?, ?, ?, ?)`,
+		k.Key, k.Name, nullInt(k.RateLimit), nullMillis(k.ExpiresAt), k.CreatedAt.UnixMilli())
+	if err != nil {
+		return Key{}, fmt.Errorf("error storing api key: %w", err)
+	}
+	s.keys[k.Key] = k
+	return k, nil
+}
+
+// Update changes the name, rate limit or expiration of a stored key.
+func (s *Store) Update(ctx context.Context, key string, u KeyUpdate) (Key, error) {
+	if err := validateRateLimit(u.RateLimit); err != nil {
+		return Key{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[key]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	if u.Name != nil {
+		k.Name = *u.Name
+	}
+	if u.ClearRateLimit {
+		k.RateLimit = nil
+	} else if u.RateLimit != nil {
+		k.RateLimit = u.RateLimit
+	}
+	if u.ClearExpiresAt {
+		k.ExpiresAt = nil
+	} else if u.ExpiresAt != nil {
+		k.ExpiresAt = u.ExpiresAt
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+UPDATE api_keys SET name = ?, rate_limit = ?, expires_at = ? WHERE key = ?`,
+		k.Name, nullInt(k.RateLimit), nullMillis(k.ExpiresAt), k.Key)
+	if err != nil {
+		return Key{}, fmt.Errorf("error updating api key: %w", err)
+	}
+	s.keys[key] = k
+	return s.withUsageLocked(k), nil
+}
+
+// Delete removes a stored key; requests using it are rejected from then on.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key]; !ok {
+		return ErrKeyNotFound
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("error deleting api key: %w", err)
+	}
+	delete(s.keys, key)
+	delete(s.pending, key)
+	delete(s.lastUsed, key)
+	return nil
+}
+
+// Flush writes buffered usage counters to the database. Counters that fail to
+// be written are kept and retried on the next flush.
+func (s *Store) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending, lastUsed := s.pending, s.lastUsed
+	s.pending = make(map[string]int64)
+	s.lastUsed = make(map[string]time.Time)
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := s.writeUsage(ctx, pending, lastUsed)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, count := range pending {
+		k, ok := s.keys[key]
+		if !ok {
+			continue // deleted while flushing
+		}
+		if err != nil {
+			s.pending[key] += count
+			if _, newer := s.lastUsed[key]; !newer {
+				s.lastUsed[key] = lastUsed[key]
+			}
+			continue
+		}
+		k.RequestCount += count
+		last := lastUsed[key]
+		k.LastUsedAt = &last
+		s.keys[key] = k
+	}
+	return err
+}
+
+func (s *Store) writeUsage(ctx context.Context, pending map[string]int64, lastUsed map[string]time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error flushing api key usage: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+UPDATE api_keys SET request_count = request_count + ?, last_used_at = ? WHERE key = ?`)
+	if err != nil {
+		return fmt.Errorf("error flushing api key usage: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for key, count := range pending {
+		if _, err := stmt.ExecContext(ctx, count, lastUsed[key].UnixMilli(), key); err != nil {
+			return fmt.Errorf("error flushing api key usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error flushing api key usage: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) flushLoop() {
+	defer s.wg.Done()
+	logger := slog.Default().With(slog.String("component", "api_key_store"))
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := s.Flush(ctx); err != nil {
+				logging.LogError(logger, "failed to flush api key usage", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Close stops the background flusher, writes any buffered usage and closes the
+// database. It is safe to call more than once.
+func (s *Store) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stopChan)
+		s.wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err = errors.Join(s.Flush(ctx), s.db.Close())
+	})
+	return err
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating api key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func validateKey(key string) error {
+	if len(key) > maxKeyLength {
+		return &ValidationError{Field: "key", Message: fmt.Sprintf("must be at most %d characters", maxKeyLength)}
+	}
+	if strings.ContainsFunc(key, func(r rune) bool { return r <= ' ' || r == 0x7f }) {
+		return &ValidationError{Field: "key", Message: "must not contain whitespace or control characters"}
+	}
+	return nil
+}
+
+func validateRateLimit(limit *int) error {
+	if limit != nil && *limit <= 0 {
+		return &ValidationError{Field: "rateLimit", Message: "must be positive"}
+	}
+	return nil
+}
+
+func nullInt(v *int) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*v), Valid: true}
+}
+
+func nullMillis(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
+
+func timeFromNullMillis(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMilli(v.Int64)
+	return &t
+}
@@ -0,0 +1,124 @@
+package apikeys
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func openTestStore(t *testing.T, path string, c clock.Clock) *Store {
+	t.Helper()
+	store, err := Open(path, c)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestStoreCreateAndLookup(t *testing.T) {
+	c := clock.NewMockClock(time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC))
+	store := openTestStore(t, ":memory:", c)
+	ctx := context.Background()
+
+	limit := 2
+	expiresAt := c.Now().Add(24 * time.Hour)
+	created, err := store.Create(ctx, NewKey{Key: "partner", Name: "Partner app", RateLimit: &limit, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	assert.Equal(t, c.Now(), created.CreatedAt)
+
+	found, ok := store.Lookup("partner")
+	require.True(t, ok)
+	assert.Equal(t, "Partner app", found.Name)
+
+	rateLimit, ok := store.RateLimit("partner")
+	require.True(t, ok)
+	assert.Equal(t, 2, rateLimit)
+
+	_, err = store.Create(ctx, NewKey{Key: "partner"})
+	assert.ErrorIs(t, err, ErrKeyExists)
+
+	generated, err := store.Create(ctx, NewKey{Name: "generated"})
+	require.NoError(t, err)
+	assert.Len(t, generated.Key, 32)
+	_, hasLimit := store.RateLimit(generated.Key)
+	assert.False(t, hasLimit)
+
+	c.Advance(24 * time.Hour)
+	_, ok = store.Lookup("partner")
+	assert.False(t, ok, "keys stop being accepted once they expire")
+	_, err = store.Get("partner")
+	assert.NoError(t, err, "expired keys remain visible to administrators")
+}
+
+func TestStoreValidation(t *testing.T) {
+	store := openTestStore(t, ":memory:", clock.RealClock{})
+	ctx := context.Background()
+
+	var validationErr *ValidationError
+	_, err := store.Create(ctx, NewKey{Key: "has space"})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "key", validationErr.Field)
+
+	zero := 0
+	_, err = store.Create(ctx, NewKey{Key: "ok", RateLimit: &zero})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "rateLimit", validationErr.Field)
+}
+
+func TestStoreUpdateAndDelete(t *testing.T) {
+	store := openTestStore(t, ":memory:", clock.RealClock{})
+	ctx := context.Background()
+
+	limit := 5
+	_, err := store.Create(ctx, NewKey{Key: "k1", RateLimit: &limit})
+	require.NoError(t, err)
+
+	name := "renamed"
+	updated, err := store.Update(ctx, "k1", KeyUpdate{Name: &name, ClearRateLimit: true})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Nil(t, updated.RateLimit)
+
+	_, err = store.Update(ctx, "missing", KeyUpdate{Name: &name})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, store.Delete(ctx, "k1"))
+	_, ok := store.Lookup("k1")
+	assert.False(t, ok)
+	assert.ErrorIs(t, store.Delete(ctx, "k1"), ErrKeyNotFound)
+}
+
+func TestStoreUsageCountersPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.db")
+	c := clock.NewMockClock(time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+
+	store, err := Open(path, c)
+	require.NoError(t, err)
+	_, err = store.Create(ctx, NewKey{Key: "counted"})
+	require.NoError(t, err)
+
+	store.RecordUse("counted")
+	store.RecordUse("counted")
+	store.RecordUse("not-stored")
+
+	k, err := store.Get("counted")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), k.RequestCount, "buffered uses are included before a flush")
+	require.NotNil(t, k.LastUsedAt)
+
+	require.NoError(t, store.Flush(ctx))
+	store.RecordUse("counted")
+	require.NoError(t, store.Close(), "closing flushes the remaining uses")
+
+	reopened := openTestStore(t, path, c)
+	k, err = reopened.Get("counted")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), k.RequestCount)
+	require.NotNil(t, k.LastUsedAt)
+	assert.Equal(t, c.Now().UnixMilli(), k.LastUsedAt.UnixMilli())
+}
@@ -10,18 +10,37 @@ func (app *Application) RequestHasInvalidAPIKey(r *http.Request) bool {
 	return app.IsInvalidAPIKey(key)
 }
 
+// IsInvalidAPIKey reports whether key is neither configured in ApiKeys nor an
+// unexpired key in the API key store.
 func (app *Application) IsInvalidAPIKey(key string) bool {
 	if key == "" {
 		return true
 	}
 
-	validKeys := app.Config.ApiKeys
-	for _, validKey := range validKeys {
-		// Use constant-time comparison to prevent timing attacks
-		if subtle.ConstantTimeCompare([]byte(key), []byte(validKey)) == 1 {
+	if containsKey(app.Config.ApiKeys, key) {
+		return false
+	}
+
+	if app.APIKeys != nil {
+		if _, ok := app.APIKeys.Lookup(key); ok {
 			return false
 		}
 	}
 
 	return true
 }
+
+// IsAdminAPIKey reports whether key is one of the configured AdminApiKeys.
+func (app *Application) IsAdminAPIKey(key string) bool {
+	return key != "" && containsKey(app.Config.AdminApiKeys, key)
+}
+
+func containsKey(validKeys []string, key string) bool {
+	for _, validKey := range validKeys {
+		// Use constant-time comparison to prevent timing attacks
+		if subtle.ConstantTimeCompare([]byte(key), []byte(validKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
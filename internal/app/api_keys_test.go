@@ -1,11 +1,17 @@
 package app
 
 import (
-	"github.com/stretchr/testify/assert"
-	"maglev.onebusaway.org/internal/appconf"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/apikeys"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/clock"
 )
 
 func TestBlankKeyIsInvalid(t *testing.T) {
@@ -152,3 +158,37 @@ func TestRequestHasInvalidAPIKeyWithNoQueryParam(t *testing.T) {
 	result := app.RequestHasInvalidAPIKey(req)
 	assert.True(t, result, "Request without API key should be invalid")
 }
+
+func TestIsInvalidAPIKeyConsultsKeyStore(t *testing.T) {
+	c := clock.NewMockClock(time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC))
+	store, err := apikeys.Open(":memory:", c)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	expiresAt := c.Now().Add(time.Hour)
+	_, err = store.Create(context.Background(), apikeys.NewKey{Key: "stored-key", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	app := &Application{
+		Config:  appconf.Config{ApiKeys: []string{"config-key"}},
+		APIKeys: store,
+	}
+	assert.False(t, app.IsInvalidAPIKey("config-key"), "configured keys stay valid alongside the store")
+	assert.False(t, app.IsInvalidAPIKey("stored-key"))
+	assert.True(t, app.IsInvalidAPIKey("unknown-key"))
+
+	c.Advance(time.Hour)
+	assert.True(t, app.IsInvalidAPIKey("stored-key"), "expired keys are rejected")
+}
+
+func TestIsAdminAPIKey(t *testing.T) {
+	app := &Application{
+		Config: appconf.Config{
+			ApiKeys:      []string{"regular"},
+			AdminApiKeys: []string{"admin"},
+		},
+	}
+	assert.True(t, app.IsAdminAPIKey("admin"))
+	assert.False(t, app.IsAdminAPIKey("regular"))
+	assert.False(t, app.IsAdminAPIKey(""))
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"log/slog"
 
+	"maglev.onebusaway.org/internal/apikeys"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/clock"
 	"maglev.onebusaway.org/internal/gtfs"
@@ -20,4 +21,13 @@ type Application struct {
 	DirectionCalculator *gtfs.AdvancedDirectionCalculator
 	Clock               clock.Clock
 	Metrics             *metrics.Metrics
+	APIKeys             *apikeys.Store // Nil when the API key store is disabled
+	Regions             []Region       // Datasets served under /regions/{id}/ next to this one
+}
+
+// Region is a dataset served under /regions/{ID}/. Its Application has the
+// region's own GTFS manager and shares everything else with the main one.
+type Region struct {
+	ID string
+	*Application
 }
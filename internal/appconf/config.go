@@ -1,5 +1,7 @@
 package appconf
 
+import "time"
+
 // Config holds all the configuration settings for our Application.
 // For now, the only configuration settings will be the network port that we want the
 // server to listen on, and the name of the current operating environment for the
@@ -12,6 +14,90 @@ type Config struct {
 	ExemptApiKeys []string
 	Verbose       bool
 	RateLimit     int // Requests per second per API key for rate limiting
+
+	// IPRateLimit is the number of requests per second each client IP address
+	// may make, across all API keys; zero disables per-IP limiting.
+	IPRateLimit int
+	// IPRateLimitBurst is how many requests a client IP address may make at
+	// once; zero uses IPRateLimit.
+	IPRateLimitBurst int
+	// IPRateLimitTrustForwardedFor takes the client address from the last entry
+	// of X-Forwarded-For, for deployments behind a reverse proxy.
+	IPRateLimitTrustForwardedFor bool
+
+	// ApiKeyDBPath is the SQLite database holding API keys managed through the
+	// admin endpoints; empty disables the key store.
+	ApiKeyDBPath string
+	// AdminApiKeys may call the admin endpoints. They are not valid for the
+	// regular API unless also listed in ApiKeys.
+	AdminApiKeys []string
+
+	// EnableJSONP lets clients wrap responses in a JavaScript function named by
+	// the callback query parameter, as the classic OneBusAway API does.
+	EnableJSONP bool
+
+	// EnableArrivalWebhooks lets clients subscribe to arrivals at a stop and
+	// have the server POST a notification to a callback URL of their choice
+	// when one is due. It is off by default since the server then makes
+	// requests to client-supplied addresses.
+	EnableArrivalWebhooks bool
+	// ArrivalWebhookAllowedHosts, when not empty, are the only hosts arrival
+	// webhook callbacks may name. Loopback, private and link-local addresses
+	// are refused either way.
+	ArrivalWebhookAllowedHosts []string
+
+	// GeodesicProjection projects points onto shape segments on a plane scaled
+	// to each segment's latitude instead of treating degrees as planar.
+	GeodesicProjection bool
+	// StopDistanceEarlyExitMeters is how much farther from a stop than its
+	// closest segment so far the shape may run before the search for the stop's
+	// distance along a trip gives up; zero uses 100 meters.
+	StopDistanceEarlyExitMeters float64
+
+	// StopSearchRadius is the radius in meters stops-for-location searches when
+	// the request sets no radius, span or query; zero uses the 500 meter default.
+	StopSearchRadius float64
+	// StopSearchMaxCount is the default maxCount of stops-for-location; zero uses 100.
+	StopSearchMaxCount int
+	// NearbyStopsRadius and NearbyStopsMaxCount bound the nearbyStopIds listed
+	// with a stop's arrivals when the request does not; zero uses 10 km and 5 stops.
+	NearbyStopsRadius   float64
+	NearbyStopsMaxCount int
+
+	// RequestTimeout bounds how long an API request may run before it is
+	// answered with a 503; zero uses the 8 second default.
+	RequestTimeout time.Duration
+
+	// RequestLogSampleRate is the fraction of successful requests written to the
+	// access log; zero logs every request. Server errors are always logged.
+	RequestLogSampleRate float64
+	// SlowDBThreshold is the database time above which a request is logged as a
+	// warning; zero disables the warning.
+	SlowDBThreshold time.Duration
+
+	// PredictionHorizon is how far ahead of now arrivals may be predicted;
+	// later ones report their scheduled times only, so that stale trip updates
+	// do not mislead riders about distant arrivals. Zero predicts every arrival.
+	PredictionHorizon time.Duration
+
+	// StaleVehicleThreshold is how old a vehicle's last report may be before it is
+	// treated as absent; zero uses the 15 minute default.
+	StaleVehicleThreshold time.Duration
+	// AgencyStaleVehicleThresholds overrides StaleVehicleThreshold for individual agencies.
+	AgencyStaleVehicleThresholds map[string]time.Duration
+	// MaxShapePoints and MaxTripStops cap the points of a shape and the stop
+	// times of a trip schedule in a response; longer ones are cut down and
+	// flagged as truncated. Zero uses 10000 points and 1000 stops.
+	MaxShapePoints int
+	MaxTripStops   int
+
+	// IDSeparator joins the agency ID and GTFS ID of the combined IDs in
+	// responses; empty uses "_". RawIDs drops the agency prefix altogether for
+	// single-agency installs, with every ID belonging to RawIDAgency, or to the
+	// feed's only agency when that is empty.
+	IDSeparator string
+	RawIDs      bool
+	RawIDAgency string
 }
 
 // Environment is an enumerated type representing various stages or configurations in the system's lifecycle.
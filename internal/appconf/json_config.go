@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // GtfsStaticFeed represents the static GTFS feed configuration
@@ -15,6 +17,12 @@ type GtfsStaticFeed struct {
 	AuthHeaderName  string `json:"auth-header-name"`
 	AuthHeaderValue string `json:"auth-header-value"`
 	EnableGTFSTidy  bool   `json:"enable-gtfs-tidy"`
+	// RefreshIntervalMinutes controls how often the feed is re-downloaded; 0 uses the 24h default
+	RefreshIntervalMinutes int `json:"refresh-interval-minutes"`
+	// DownloadTimeoutSeconds bounds each download of the feed; 0 uses the 5 minute default
+	DownloadTimeoutSeconds int `json:"download-timeout-seconds"`
+	// ReferentialIntegrity is "report" (default), "fail" or "quarantine"; see ValidateReferentialIntegrity
+	ReferentialIntegrity string `json:"referential-integrity"`
 }
 
 // GtfsRtFeed represents a single GTFS-RT feed configuration
@@ -28,21 +36,154 @@ type GtfsRtFeed struct {
 	RealTimeAuthHeaderValue string            `json:"realtime-auth-header-value"`
 	Headers                 map[string]string `json:"headers"`
 	RefreshInterval         int               `json:"refresh-interval"`
-	Enabled                 *bool             `json:"enabled"`
+	// Per-source polling intervals in seconds; 0 uses RefreshInterval
+	TripUpdatesInterval      int   `json:"trip-updates-interval"`
+	VehiclePositionsInterval int   `json:"vehicle-positions-interval"`
+	ServiceAlertsInterval    int   `json:"service-alerts-interval"`
+	Enabled                  *bool `json:"enabled"`
+	// VehicleIDRewrites normalize the feed's vehicle IDs, applied in order
+	VehicleIDRewrites []VehicleIDRewrite `json:"vehicle-id-rewrites"`
+	// Priority ranks the feed against others publishing the same trip or vehicle; higher wins
+	Priority int `json:"priority"`
 }
 
-// JSONConfig represents the JSON configuration file structure
-type JSONConfig struct {
-	Port           int            `json:"port"`
-	Env            string         `json:"env"`
-	ApiKeys        []string       `json:"api-keys"`
-	ExemptApiKeys  []string       `json:"exempt-api-keys"`
-	RateLimit      int            `json:"rate-limit"`
+// VehicleIDRewrite replaces the matches of a regular expression in a GTFS-RT
+// vehicle ID. Replacement may refer to capture groups as $1 or ${name}.
+type VehicleIDRewrite struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// VehiclePositionHistory configures recording of GTFS-RT vehicle positions.
+// Recording is disabled when RetentionMinutes is zero.
+type VehiclePositionHistory struct {
+	RetentionMinutes int `json:"retention-minutes"`
+	SmoothingSamples int `json:"smoothing-samples"`
+}
+
+// DetourDetection configures when a vehicle is considered off its trip's
+// shape. Zero values use the built-in defaults.
+type DetourDetection struct {
+	ThresholdMeters    float64 `json:"threshold-meters"`
+	ConsecutiveUpdates int     `json:"consecutive-updates"`
+}
+
+// RealtimeReplay configures a replay of recorded GTFS-RT snapshots in place
+// of the live feeds, for development. It is disabled when Dir is empty.
+type RealtimeReplay struct {
+	Dir string `json:"dir"`
+	// Speed is how many times faster than real time snapshots are replayed; zero replays in real time.
+	Speed float64 `json:"speed"`
+	Loop  bool    `json:"loop"`
+}
+
+// VehicleCapacity gives the passenger capacity of vehicles, by the vehicle IDs
+// GTFS-RT feeds publish, from a CSV file of vehicle_id,capacity rows and from
+// Vehicles, which overrides the file.
+type VehicleCapacity struct {
+	File     string         `json:"file"`
+	Vehicles map[string]int `json:"vehicles"`
+}
+
+// StaleVehicle configures how long a vehicle may go without reporting before its
+// realtime data is ignored. Zero values use the 15 minute default.
+type StaleVehicle struct {
+	ThresholdSeconds       int            `json:"threshold-seconds"`
+	AgencyThresholdSeconds map[string]int `json:"agency-threshold-seconds"`
+}
+
+// StopSearch configures the defaults of stop searches by location. Zero values
+// use the built-in defaults.
+type StopSearch struct {
+	RadiusMeters       float64 `json:"radius-meters"`
+	MaxCount           int     `json:"max-count"`
+	NearbyRadiusMeters float64 `json:"nearby-radius-meters"`
+	NearbyMaxCount     int     `json:"nearby-max-count"`
+}
+
+// RequestLog configures the per-request access log. Zero values use the
+// built-in defaults.
+type RequestLog struct {
+	// SampleRate is the fraction of successful requests logged; zero logs all.
+	SampleRate float64 `json:"sample-rate"`
+	// SlowDBThresholdMs logs a warning for requests that spend at least this
+	// long in the database; zero disables the warning.
+	SlowDBThresholdMs int `json:"slow-db-threshold-ms"`
+}
+
+// IPRateLimit limits how fast each client IP address may make requests,
+// whichever API key it uses.
+type IPRateLimit struct {
+	// RequestsPerSecond is the sustained rate allowed per address; zero
+	// disables per-IP limiting.
+	RequestsPerSecond int `json:"requests-per-second"`
+	// Burst is how many requests an address may make at once; zero uses
+	// RequestsPerSecond.
+	Burst int `json:"burst"`
+	// TrustForwardedFor takes the client address from the last entry of
+	// X-Forwarded-For instead of the connection's remote address.
+	TrustForwardedFor bool `json:"trust-forwarded-for"`
+}
+
+// ResponseLimits caps the size of responses about very long shapes and trips.
+// Zero values use the built-in defaults.
+type ResponseLimits struct {
+	MaxShapePoints int `json:"max-shape-points"`
+	MaxTripStops   int `json:"max-trip-stops"`
+}
+
+// IDFormat configures the combined `{agency_id}_{code_id}` IDs of responses.
+type IDFormat struct {
+	Separator string `json:"separator"`
+	// Raw leaves IDs unprefixed; AgencyID names the agency they belong to and
+	// may be left out when the feed has a single agency.
+	Raw      bool   `json:"raw"`
+	AgencyID string `json:"agency-id"`
+}
+
+// Region is a GTFS dataset served under /regions/{id}/ next to the main one,
+// with its own database and realtime feeds. Every other setting is shared
+// with the main dataset.
+type Region struct {
+	ID             string         `json:"id"`
 	GtfsStaticFeed GtfsStaticFeed `json:"gtfs-static-feed"`
 	GtfsRtFeeds    []GtfsRtFeed   `json:"gtfs-rt-feeds"`
 	DataPath       string         `json:"data-path"`
 }
 
+// JSONConfig represents the JSON configuration file structure
+type JSONConfig struct {
+	Port                       int                    `json:"port"`
+	Env                        string                 `json:"env"`
+	ApiKeys                    []string               `json:"api-keys"`
+	ExemptApiKeys              []string               `json:"exempt-api-keys"`
+	RateLimit                  int                    `json:"rate-limit"`
+	IPRateLimit                IPRateLimit            `json:"ip-rate-limit"`
+	GtfsStaticFeed             GtfsStaticFeed         `json:"gtfs-static-feed"`
+	GtfsRtFeeds                []GtfsRtFeed           `json:"gtfs-rt-feeds"`
+	DataPath                   string                 `json:"data-path"`
+	DBReadConnections          int                    `json:"db-read-connections"`
+	VehiclePositionHistory     VehiclePositionHistory `json:"vehicle-position-history"`
+	StaleVehicle               StaleVehicle           `json:"stale-vehicle"`
+	DetourDetection            DetourDetection        `json:"detour-detection"`
+	RealtimeReplay             RealtimeReplay         `json:"realtime-replay"`
+	VehicleCapacity            VehicleCapacity        `json:"vehicle-capacity"`
+	ApiKeyDBPath               string                 `json:"api-key-db-path"`
+	AdminApiKeys               []string               `json:"admin-api-keys"`
+	EnableJSONP                bool                   `json:"enable-jsonp"`
+	EnableArrivalWebhooks      bool                   `json:"enable-arrival-webhooks"`
+	ArrivalWebhookAllowedHosts []string               `json:"arrival-webhook-allowed-hosts"`
+	GeodesicProjection         bool                   `json:"geodesic-projection"`
+	StopDistanceEarlyExit      float64                `json:"stop-distance-early-exit-meters"`
+	StopSearch                 StopSearch             `json:"stop-search"`
+	RequestTimeoutSeconds      int                    `json:"request-timeout-seconds"`
+	PredictionHorizonMinutes   int                    `json:"prediction-horizon-minutes"`
+	RequestLog                 RequestLog             `json:"request-log"`
+	ResponseLimits             ResponseLimits         `json:"response-limits"`
+	IDFormat                   IDFormat               `json:"id-format"`
+	Regions                    []Region               `json:"regions"`
+}
+
 // setDefaults applies default values to the JSON config if fields are missing or zero
 func (j *JSONConfig) setDefaults() {
 	if j.Port == 0 {
@@ -94,6 +235,12 @@ func (j *JSONConfig) validate() error {
 	if j.RateLimit < 1 {
 		return fmt.Errorf("rate-limit must be at least 1, got %d", j.RateLimit)
 	}
+	if j.IPRateLimit.RequestsPerSecond < 0 {
+		return fmt.Errorf("ip-rate-limit.requests-per-second cannot be negative, got %d", j.IPRateLimit.RequestsPerSecond)
+	}
+	if j.IPRateLimit.Burst < 0 {
+		return fmt.Errorf("ip-rate-limit.burst cannot be negative, got %d", j.IPRateLimit.Burst)
+	}
 
 	if len(j.ApiKeys) == 0 {
 		return fmt.Errorf("api-keys cannot be empty")
@@ -110,33 +257,174 @@ func (j *JSONConfig) validate() error {
 		}
 		seen[key] = true
 	}
+	for _, key := range j.AdminApiKeys {
+		if key == "" {
+			return fmt.Errorf("admin-api-keys cannot contain empty strings")
+		}
+	}
+	for _, host := range j.ArrivalWebhookAllowedHosts {
+		if host == "" {
+			return fmt.Errorf("arrival-webhook-allowed-hosts cannot contain empty strings")
+		}
+	}
+
+	if err := j.GtfsStaticFeed.validate(); err != nil {
+		return err
+	}
+
+	if j.DBReadConnections < 0 {
+		return fmt.Errorf("db-read-connections cannot be negative, got %d", j.DBReadConnections)
+	}
+
+	if j.VehiclePositionHistory.RetentionMinutes < 0 {
+		return fmt.Errorf("vehicle-position-history.retention-minutes cannot be negative, got %d", j.VehiclePositionHistory.RetentionMinutes)
+	}
+	if j.VehiclePositionHistory.SmoothingSamples < 0 {
+		return fmt.Errorf("vehicle-position-history.smoothing-samples cannot be negative, got %d", j.VehiclePositionHistory.SmoothingSamples)
+	}
+
+	if j.RequestTimeoutSeconds < 0 {
+		return fmt.Errorf("request-timeout-seconds cannot be negative, got %d", j.RequestTimeoutSeconds)
+	}
+	if j.PredictionHorizonMinutes < 0 {
+		return fmt.Errorf("prediction-horizon-minutes cannot be negative, got %d", j.PredictionHorizonMinutes)
+	}
+	if j.DetourDetection.ThresholdMeters < 0 {
+		return fmt.Errorf("detour-detection.threshold-meters cannot be negative, got %g", j.DetourDetection.ThresholdMeters)
+	}
+	if j.DetourDetection.ConsecutiveUpdates < 0 {
+		return fmt.Errorf("detour-detection.consecutive-updates cannot be negative, got %d", j.DetourDetection.ConsecutiveUpdates)
+	}
+	if j.RealtimeReplay.Speed < 0 {
+		return fmt.Errorf("realtime-replay.speed cannot be negative, got %g", j.RealtimeReplay.Speed)
+	}
+	if j.RealtimeReplay.Dir == "" && (j.RealtimeReplay.Speed != 0 || j.RealtimeReplay.Loop) {
+		return fmt.Errorf("realtime-replay.speed and realtime-replay.loop need realtime-replay.dir")
+	}
+	for vehicleID, capacity := range j.VehicleCapacity.Vehicles {
+		if capacity <= 0 {
+			return fmt.Errorf("vehicle-capacity.vehicles capacity of %s must be positive, got %d", vehicleID, capacity)
+		}
+	}
+	if j.StopDistanceEarlyExit < 0 {
+		return fmt.Errorf("stop-distance-early-exit-meters cannot be negative, got %g", j.StopDistanceEarlyExit)
+	}
+	if j.RequestLog.SampleRate < 0 || j.RequestLog.SampleRate > 1 {
+		return fmt.Errorf("request-log.sample-rate must be between 0 and 1, got %g", j.RequestLog.SampleRate)
+	}
+	if j.RequestLog.SlowDBThresholdMs < 0 {
+		return fmt.Errorf("request-log.slow-db-threshold-ms cannot be negative, got %d", j.RequestLog.SlowDBThresholdMs)
+	}
+
+	if err := j.StopSearch.validate(); err != nil {
+		return err
+	}
+	if j.ResponseLimits.MaxShapePoints < 0 {
+		return fmt.Errorf("response-limits.max-shape-points cannot be negative, got %d", j.ResponseLimits.MaxShapePoints)
+	}
+	if j.ResponseLimits.MaxTripStops < 0 {
+		return fmt.Errorf("response-limits.max-trip-stops cannot be negative, got %d", j.ResponseLimits.MaxTripStops)
+	}
+	if j.IDFormat.Separator != "" && !idSeparatorPattern.MatchString(j.IDFormat.Separator) {
+		return fmt.Errorf("id-format.separator must be made of the characters _ . : -, got %q", j.IDFormat.Separator)
+	}
+	if j.IDFormat.AgencyID != "" && !j.IDFormat.Raw {
+		return fmt.Errorf("id-format.agency-id is only used with id-format.raw")
+	}
+	if j.IDFormat.Raw && len(j.Regions) > 0 {
+		return fmt.Errorf("id-format.raw cannot be combined with regions, as raw IDs only name the main feed's agency")
+	}
+
+	for i, feed := range j.GtfsRtFeeds {
+		if err := feed.validate(i); err != nil {
+			return err
+		}
+	}
+
+	dataPaths := map[string]bool{j.DataPath: true}
+	regionIDs := make(map[string]bool)
+	for i, region := range j.Regions {
+		if !regionIDPattern.MatchString(region.ID) {
+			return fmt.Errorf("regions[%d].id must be lowercase letters, digits and dashes, got %q", i, region.ID)
+		}
+		if regionIDs[region.ID] {
+			return fmt.Errorf("duplicate region ID found: %q", region.ID)
+		}
+		regionIDs[region.ID] = true
+		if region.GtfsStaticFeed.URL == "" {
+			return fmt.Errorf("regions[%d].gtfs-static-feed.url is required", i)
+		}
+		// Each region imports its feed into a database of its own.
+		if region.DataPath == "" {
+			return fmt.Errorf("regions[%d].data-path is required", i)
+		}
+		if err := validatePath(region.DataPath, fmt.Sprintf("regions[%d].data-path", i)); err != nil {
+			return err
+		}
+		if dataPaths[region.DataPath] && region.DataPath != ":memory:" {
+			return fmt.Errorf("regions[%d].data-path %q is already used by another dataset", i, region.DataPath)
+		}
+		dataPaths[region.DataPath] = true
+		if err := region.GtfsStaticFeed.validate(); err != nil {
+			return fmt.Errorf("regions[%d]: %w", i, err)
+		}
+		if err := region.GtfsStaticFeed.validateSource(); err != nil {
+			return fmt.Errorf("regions[%d]: %w", i, err)
+		}
+		for k, feed := range region.GtfsRtFeeds {
+			if err := feed.validate(k); err != nil {
+				return fmt.Errorf("regions[%d]: %w", i, err)
+			}
+		}
+	}
+
+	if j.StaleVehicle.ThresholdSeconds < 0 {
+		return fmt.Errorf("stale-vehicle.threshold-seconds cannot be negative, got %d", j.StaleVehicle.ThresholdSeconds)
+	}
+	for agencyID, seconds := range j.StaleVehicle.AgencyThresholdSeconds {
+		if agencyID == "" {
+			return fmt.Errorf("stale-vehicle.agency-threshold-seconds cannot contain an empty agency ID")
+		}
+		if seconds <= 0 {
+			return fmt.Errorf("stale-vehicle.agency-threshold-seconds for agency %q must be positive, got %d", agencyID, seconds)
+		}
+	}
 
 	// Validate DataPath for path traversal attempts
 	if err := validatePath(j.DataPath, "data-path"); err != nil {
 		return err
 	}
 
+	if err := validatePath(j.ApiKeyDBPath, "api-key-db-path"); err != nil {
+		return err
+	}
+
+	return j.GtfsStaticFeed.validateSource()
+}
+
+// validateSource checks the feed's URL and authentication header.
+func (f GtfsStaticFeed) validateSource() error {
 	// Validate that both auth header fields are provided together or neither
-	if (j.GtfsStaticFeed.AuthHeaderName != "" && j.GtfsStaticFeed.AuthHeaderValue == "") ||
-		(j.GtfsStaticFeed.AuthHeaderName == "" && j.GtfsStaticFeed.AuthHeaderValue != "") {
+	if (f.AuthHeaderName != "" && f.AuthHeaderValue == "") ||
+		(f.AuthHeaderName == "" && f.AuthHeaderValue != "") {
 		return fmt.Errorf("both auth-header-name and auth-header-value must be provided together for gtfs-static-feed")
 	}
 
-	// Validate GtfsStaticFeed.URL to prevent file:// URLs and other security issues
-	if j.GtfsStaticFeed.URL != "" {
+	// Validate the URL to prevent file:// URLs and other security issues
+	if f.URL != "" {
 		// Block file:// URLs (case-insensitive)
-		if strings.HasPrefix(strings.ToLower(j.GtfsStaticFeed.URL), "file://") {
+		if strings.HasPrefix(strings.ToLower(f.URL), "file://") {
 			return fmt.Errorf("file:// URLs are not allowed for gtfs-static-feed.url for security reasons")
 		}
 
 		// For HTTP(S) URLs, no path checks needed
-		if strings.HasPrefix(j.GtfsStaticFeed.URL, "http://") ||
-			strings.HasPrefix(j.GtfsStaticFeed.URL, "https://") {
+		if strings.HasPrefix(f.URL, "http://") ||
+			strings.HasPrefix(f.URL, "https://") {
 			return nil
 		}
 
 		// For file paths, validate for path traversal
-		if err := validatePath(j.GtfsStaticFeed.URL, "gtfs-static-feed.url"); err != nil {
+		if err := validatePath(f.URL, "gtfs-static-feed.url"); err != nil {
 			return err
 		}
 	}
@@ -144,6 +432,87 @@ func (j *JSONConfig) validate() error {
 	return nil
 }
 
+func (f GtfsStaticFeed) validate() error {
+	if err := ValidateReferentialIntegrity(f.ReferentialIntegrity); err != nil {
+		return fmt.Errorf("gtfs-static-feed.referential-integrity %w", err)
+	}
+	if f.RefreshIntervalMinutes < 0 {
+		return fmt.Errorf("gtfs-static-feed.refresh-interval-minutes cannot be negative, got %d", f.RefreshIntervalMinutes)
+	}
+	if f.DownloadTimeoutSeconds < 0 {
+		return fmt.Errorf("gtfs-static-feed.download-timeout-seconds cannot be negative, got %d", f.DownloadTimeoutSeconds)
+	}
+	return nil
+}
+
+func (f GtfsRtFeed) validate(index int) error {
+	intervals := []struct {
+		name  string
+		value int
+	}{
+		{"refresh-interval", f.RefreshInterval},
+		{"trip-updates-interval", f.TripUpdatesInterval},
+		{"vehicle-positions-interval", f.VehiclePositionsInterval},
+		{"service-alerts-interval", f.ServiceAlertsInterval},
+	}
+	for _, interval := range intervals {
+		if interval.value < 0 {
+			return fmt.Errorf("gtfs-rt-feeds[%d].%s cannot be negative, got %d", index, interval.name, interval.value)
+		}
+	}
+	for i, rewrite := range f.VehicleIDRewrites {
+		if rewrite.Pattern == "" {
+			return fmt.Errorf("gtfs-rt-feeds[%d].vehicle-id-rewrites[%d].pattern cannot be empty", index, i)
+		}
+		if _, err := regexp.Compile(rewrite.Pattern); err != nil {
+			return fmt.Errorf("gtfs-rt-feeds[%d].vehicle-id-rewrites[%d].pattern is not a valid regular expression: %w", index, i, err)
+		}
+	}
+	return nil
+}
+
+// maxStopSearchRadiusMeters and maxStopSearchCount match the limits the API
+// enforces on the radius and maxCount parameters.
+const (
+	maxStopSearchRadiusMeters = 10000
+	maxStopSearchCount        = 250
+)
+
+// idSeparatorPattern matches the separators whose IDs pass the API's ID
+// validation.
+var idSeparatorPattern = regexp.MustCompile(`^[_.:-]+$`)
+
+// regionIDPattern matches region IDs, which appear in URL paths.
+var regionIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+func (s StopSearch) validate() error {
+	radii := []struct {
+		name  string
+		value float64
+	}{
+		{"stop-search.radius-meters", s.RadiusMeters},
+		{"stop-search.nearby-radius-meters", s.NearbyRadiusMeters},
+	}
+	for _, r := range radii {
+		if r.value < 0 || r.value > maxStopSearchRadiusMeters {
+			return fmt.Errorf("%s must be between 0 and %d, got %g", r.name, maxStopSearchRadiusMeters, r.value)
+		}
+	}
+	counts := []struct {
+		name  string
+		value int
+	}{
+		{"stop-search.max-count", s.MaxCount},
+		{"stop-search.nearby-max-count", s.NearbyMaxCount},
+	}
+	for _, c := range counts {
+		if c.value < 0 || c.value > maxStopSearchCount {
+			return fmt.Errorf("%s must be between 0 and %d, got %d", c.name, maxStopSearchCount, c.value)
+		}
+	}
+	return nil
+}
+
 // validatePath checks a file path for security issues
 func validatePath(path, fieldName string) error {
 	if path == "" {
@@ -174,14 +543,53 @@ func validatePath(path, fieldName string) error {
 
 // ToAppConfig converts JSONConfig to appconf.Config
 func (j *JSONConfig) ToAppConfig() Config {
-	return Config{
+	cfg := Config{
 		Port:          j.Port,
 		Env:           EnvFlagToEnvironment(j.Env),
 		ApiKeys:       j.ApiKeys,
 		ExemptApiKeys: j.ExemptApiKeys,
 		Verbose:       true, // Always set to true like in main.go
 		RateLimit:     j.RateLimit,
+		ApiKeyDBPath:  j.ApiKeyDBPath,
+		AdminApiKeys:  j.AdminApiKeys,
+		EnableJSONP:   j.EnableJSONP,
+
+		EnableArrivalWebhooks:      j.EnableArrivalWebhooks,
+		ArrivalWebhookAllowedHosts: j.ArrivalWebhookAllowedHosts,
+
+		IPRateLimit:                  j.IPRateLimit.RequestsPerSecond,
+		IPRateLimitBurst:             j.IPRateLimit.Burst,
+		IPRateLimitTrustForwardedFor: j.IPRateLimit.TrustForwardedFor,
+
+		GeodesicProjection:          j.GeodesicProjection,
+		StopDistanceEarlyExitMeters: j.StopDistanceEarlyExit,
+
+		StopSearchRadius:    j.StopSearch.RadiusMeters,
+		StopSearchMaxCount:  j.StopSearch.MaxCount,
+		NearbyStopsRadius:   j.StopSearch.NearbyRadiusMeters,
+		NearbyStopsMaxCount: j.StopSearch.NearbyMaxCount,
+
+		RequestTimeout:        time.Duration(j.RequestTimeoutSeconds) * time.Second,
+		PredictionHorizon:     time.Duration(j.PredictionHorizonMinutes) * time.Minute,
+		StaleVehicleThreshold: time.Duration(j.StaleVehicle.ThresholdSeconds) * time.Second,
+
+		RequestLogSampleRate: j.RequestLog.SampleRate,
+		SlowDBThreshold:      time.Duration(j.RequestLog.SlowDBThresholdMs) * time.Millisecond,
+
+		MaxShapePoints: j.ResponseLimits.MaxShapePoints,
+		MaxTripStops:   j.ResponseLimits.MaxTripStops,
+
+		IDSeparator: j.IDFormat.Separator,
+		RawIDs:      j.IDFormat.Raw,
+		RawIDAgency: j.IDFormat.AgencyID,
 	}
+	if len(j.StaleVehicle.AgencyThresholdSeconds) > 0 {
+		cfg.AgencyStaleVehicleThresholds = make(map[string]time.Duration, len(j.StaleVehicle.AgencyThresholdSeconds))
+		for agencyID, seconds := range j.StaleVehicle.AgencyThresholdSeconds {
+			cfg.AgencyStaleVehicleThresholds[agencyID] = time.Duration(seconds) * time.Second
+		}
+	}
+	return cfg
 }
 
 // RTFeedConfigData holds per-feed GTFS-RT configuration
@@ -192,8 +600,15 @@ type RTFeedConfigData struct {
 	VehiclePositionsURL string
 	ServiceAlertsURL    string
 	Headers             map[string]string
-	RefreshInterval     int  // seconds, default 30
-	Enabled             bool // default true
+	RefreshInterval     int // seconds, default 30
+	// Per-source polling intervals in seconds; 0 uses RefreshInterval
+	TripUpdatesInterval      int
+	VehiclePositionsInterval int
+	ServiceAlertsInterval    int
+	Enabled                  bool // default true
+	// VehicleIDRewrites have been validated to hold compilable patterns
+	VehicleIDRewrites []VehicleIDRewrite
+	Priority          int
 }
 
 // GtfsConfigData holds GTFS configuration data without importing gtfs package
@@ -207,6 +622,29 @@ type GtfsConfigData struct {
 	Env                   Environment
 	Verbose               bool
 	EnableGTFSTidy        bool
+	StaticRefreshInterval time.Duration
+	StaticDownloadTimeout time.Duration
+	ReferentialIntegrity  string
+	// DBReadConnections is the size of a separate read-only database pool; zero shares one pool with writes
+	DBReadConnections int
+	// VehicleHistoryRetention is how long recorded vehicle positions are kept; zero disables recording
+	VehicleHistoryRetention   time.Duration
+	DeviationSmoothingSamples int
+	DetourThresholdMeters     float64
+	DetourConsecutiveUpdates  int
+	ReplayDir                 string
+	ReplaySpeed               float64
+	ReplayLoop                bool
+	VehicleCapacityFile       string
+	VehicleCapacities         map[string]int
+	Regions                   []RegionConfigData
+}
+
+// RegionConfigData is the GTFS configuration of a region, served under
+// /regions/{ID}/.
+type RegionConfigData struct {
+	ID string
+	GtfsConfigData
 }
 
 // ToGtfsConfigData converts JSONConfig to GtfsConfigData
@@ -219,6 +657,20 @@ func (j *JSONConfig) ToGtfsConfigData() (GtfsConfigData, error) {
 		Env:                   EnvFlagToEnvironment(j.Env),
 		Verbose:               true, // Always set to true like in main.go
 		EnableGTFSTidy:        j.GtfsStaticFeed.EnableGTFSTidy,
+		StaticRefreshInterval: time.Duration(j.GtfsStaticFeed.RefreshIntervalMinutes) * time.Minute,
+		StaticDownloadTimeout: time.Duration(j.GtfsStaticFeed.DownloadTimeoutSeconds) * time.Second,
+		ReferentialIntegrity:  j.GtfsStaticFeed.ReferentialIntegrity,
+		DBReadConnections:     j.DBReadConnections,
+
+		VehicleHistoryRetention:   time.Duration(j.VehiclePositionHistory.RetentionMinutes) * time.Minute,
+		DeviationSmoothingSamples: j.VehiclePositionHistory.SmoothingSamples,
+		DetourThresholdMeters:     j.DetourDetection.ThresholdMeters,
+		DetourConsecutiveUpdates:  j.DetourDetection.ConsecutiveUpdates,
+		ReplayDir:                 j.RealtimeReplay.Dir,
+		ReplaySpeed:               j.RealtimeReplay.Speed,
+		ReplayLoop:                j.RealtimeReplay.Loop,
+		VehicleCapacityFile:       j.VehicleCapacity.File,
+		VehicleCapacities:         j.VehicleCapacity.Vehicles,
 	}
 
 	for i, feed := range j.GtfsRtFeeds {
@@ -272,10 +724,31 @@ func (j *JSONConfig) ToGtfsConfigData() (GtfsConfigData, error) {
 			ServiceAlertsURL:    feed.ServiceAlertsURL,
 			Headers:             headers,
 			RefreshInterval:     refreshInterval,
-			Enabled:             enabled,
+
+			TripUpdatesInterval:      feed.TripUpdatesInterval,
+			VehiclePositionsInterval: feed.VehiclePositionsInterval,
+			ServiceAlertsInterval:    feed.ServiceAlertsInterval,
+			Enabled:                  enabled,
+			VehicleIDRewrites:        feed.VehicleIDRewrites,
+			Priority:                 feed.Priority,
 		})
 	}
 
+	for _, region := range j.Regions {
+		regionJSON := *j
+		regionJSON.GtfsStaticFeed = region.GtfsStaticFeed
+		regionJSON.GtfsRtFeeds = region.GtfsRtFeeds
+		regionJSON.DataPath = region.DataPath
+		// Replays stand in for the main dataset's feeds only.
+		regionJSON.RealtimeReplay = RealtimeReplay{}
+		regionJSON.Regions = nil
+		regionCfg, err := regionJSON.ToGtfsConfigData()
+		if err != nil {
+			return GtfsConfigData{}, fmt.Errorf("region %q: %w", region.ID, err)
+		}
+		cfg.Regions = append(cfg.Regions, RegionConfigData{ID: region.ID, GtfsConfigData: regionCfg})
+	}
+
 	return cfg, nil
 }
 
@@ -370,3 +843,14 @@ func LoadFromFile(path string) (*JSONConfig, error) {
 
 	return &config, nil
 }
+
+// ValidateReferentialIntegrity checks what an import is told to do with rows
+// that reference rows missing from the feed: keep and "report" them, "fail"
+// the import, or "quarantine" them. Empty uses the default, "report".
+func ValidateReferentialIntegrity(mode string) error {
+	switch mode {
+	case "", "report", "fail", "quarantine":
+		return nil
+	}
+	return fmt.Errorf("must be one of report, fail or quarantine, got %q", mode)
+}
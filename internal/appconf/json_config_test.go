@@ -3,6 +3,7 @@ package appconf
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -163,6 +164,13 @@ func TestToAppConfig(t *testing.T) {
 		ApiKeys:       []string{"key1", "key2"},
 		RateLimit:     50,
 		ExemptApiKeys: []string{"exempt-key-1"},
+		EnableJSONP:   true,
+
+		EnableArrivalWebhooks:      true,
+		ArrivalWebhookAllowedHosts: []string{"hooks.example"},
+		GeodesicProjection:         true,
+		StopDistanceEarlyExit:      60,
+		RequestTimeoutSeconds:      5,
 	}
 
 	appConfig := jsonConfig.ToAppConfig()
@@ -173,6 +181,12 @@ func TestToAppConfig(t *testing.T) {
 	assert.Equal(t, 50, appConfig.RateLimit)
 	assert.True(t, appConfig.Verbose)
 	assert.Equal(t, []string{"exempt-key-1"}, appConfig.ExemptApiKeys)
+	assert.True(t, appConfig.EnableJSONP)
+	assert.True(t, appConfig.EnableArrivalWebhooks)
+	assert.Equal(t, []string{"hooks.example"}, appConfig.ArrivalWebhookAllowedHosts)
+	assert.True(t, appConfig.GeodesicProjection)
+	assert.Equal(t, 60.0, appConfig.StopDistanceEarlyExitMeters)
+	assert.Equal(t, 5*time.Second, appConfig.RequestTimeout)
 }
 
 func TestToAppConfig_EnvironmentConversion(t *testing.T) {
@@ -227,6 +241,439 @@ func TestToGtfsConfigData_NoFeeds(t *testing.T) {
 	assert.Empty(t, gtfsConfig.RTFeeds)
 }
 
+func TestToGtfsConfigData_VehiclePositionHistory(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		VehiclePositionHistory: VehiclePositionHistory{
+			RetentionMinutes: 90,
+			SmoothingSamples: 4,
+		},
+	}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, gtfsConfig.VehicleHistoryRetention)
+	assert.Equal(t, 4, gtfsConfig.DeviationSmoothingSamples)
+}
+
+func TestToGtfsConfigData_StaticRefreshInterval(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		GtfsStaticFeed: GtfsStaticFeed{
+			URL:                    "https://example.com/gtfs.zip",
+			RefreshIntervalMinutes: 60,
+		},
+	}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, gtfsConfig.StaticRefreshInterval)
+}
+
+func TestValidate_ReferentialIntegrity(t *testing.T) {
+	config := &JSONConfig{
+		Port:           4000,
+		Env:            "development",
+		ApiKeys:        []string{"test"},
+		RateLimit:      100,
+		GtfsStaticFeed: GtfsStaticFeed{ReferentialIntegrity: "quarantine"},
+	}
+	require.NoError(t, config.validate())
+
+	gtfsConfig, err := config.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, "quarantine", gtfsConfig.ReferentialIntegrity)
+
+	config.GtfsStaticFeed.ReferentialIntegrity = "ignore"
+	err = config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-static-feed.referential-integrity must be one of report, fail or quarantine")
+}
+
+func TestValidate_NegativeVehiclePositionHistory(t *testing.T) {
+	config := &JSONConfig{
+		Port:      4000,
+		Env:       "development",
+		ApiKeys:   []string{"test"},
+		RateLimit: 100,
+		VehiclePositionHistory: VehiclePositionHistory{
+			RetentionMinutes: -1,
+		},
+	}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "retention-minutes cannot be negative")
+}
+
+func TestToAppConfig_StaleVehicle(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		StaleVehicle: StaleVehicle{
+			ThresholdSeconds:       600,
+			AgencyThresholdSeconds: map[string]int{"1": 120},
+		},
+	}
+
+	appConfig := jsonConfig.ToAppConfig()
+
+	assert.Equal(t, 10*time.Minute, appConfig.StaleVehicleThreshold)
+	assert.Equal(t, map[string]time.Duration{"1": 2 * time.Minute}, appConfig.AgencyStaleVehicleThresholds)
+}
+
+func TestValidate_InvalidStaleVehicle(t *testing.T) {
+	base := func() *JSONConfig {
+		return &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100}
+	}
+
+	config := base()
+	config.StaleVehicle.ThresholdSeconds = -1
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "threshold-seconds cannot be negative")
+
+	config = base()
+	config.StaleVehicle.AgencyThresholdSeconds = map[string]int{"1": 0}
+	err = config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be positive")
+}
+
+func TestValidate_NegativeRequestTimeout(t *testing.T) {
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, RequestTimeoutSeconds: -1}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "request-timeout-seconds cannot be negative")
+}
+
+func TestPredictionHorizon(t *testing.T) {
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, PredictionHorizonMinutes: 45}
+	require.NoError(t, config.validate())
+	assert.Equal(t, 45*time.Minute, config.ToAppConfig().PredictionHorizon)
+
+	config.PredictionHorizonMinutes = -1
+	err := config.validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prediction-horizon-minutes cannot be negative")
+}
+
+func TestDBReadConnections(t *testing.T) {
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, DBReadConnections: 8,
+		Regions: []Region{{ID: "north", GtfsStaticFeed: GtfsStaticFeed{URL: "https://north.example/gtfs.zip"}, DataPath: "./north.db"}}}
+	require.NoError(t, config.validate())
+	gtfsConfig, err := config.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, 8, gtfsConfig.DBReadConnections)
+	require.Len(t, gtfsConfig.Regions, 1)
+	assert.Equal(t, 8, gtfsConfig.Regions[0].DBReadConnections, "regions share the setting")
+
+	config.DBReadConnections = -1
+	err = config.validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db-read-connections cannot be negative")
+}
+
+func TestValidate_NegativeStopDistanceEarlyExit(t *testing.T) {
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, StopDistanceEarlyExit: -5}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "stop-distance-early-exit-meters cannot be negative")
+}
+
+func TestIPRateLimit(t *testing.T) {
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+		IPRateLimit: IPRateLimit{RequestsPerSecond: -1}}
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ip-rate-limit.requests-per-second cannot be negative")
+
+	config.IPRateLimit = IPRateLimit{RequestsPerSecond: 20, Burst: -1}
+	err = config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ip-rate-limit.burst cannot be negative")
+
+	config.IPRateLimit = IPRateLimit{RequestsPerSecond: 20, Burst: 40, TrustForwardedFor: true}
+	assert.NoError(t, config.validate())
+	appConfig := config.ToAppConfig()
+	assert.Equal(t, 20, appConfig.IPRateLimit)
+	assert.Equal(t, 40, appConfig.IPRateLimitBurst)
+	assert.True(t, appConfig.IPRateLimitTrustForwardedFor)
+}
+
+func TestValidate_RequestLog(t *testing.T) {
+	base := func() *JSONConfig {
+		return &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100}
+	}
+
+	config := base()
+	config.RequestLog = RequestLog{SampleRate: 0.25, SlowDBThresholdMs: 200}
+	assert.NoError(t, config.validate())
+
+	config = base()
+	config.RequestLog.SampleRate = 1.5
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "request-log.sample-rate must be between 0 and 1")
+
+	config = base()
+	config.RequestLog.SlowDBThresholdMs = -1
+	err = config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "request-log.slow-db-threshold-ms cannot be negative")
+}
+
+func TestValidate_DetourDetection(t *testing.T) {
+	base := func() *JSONConfig {
+		return &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100}
+	}
+
+	config := base()
+	config.DetourDetection = DetourDetection{ThresholdMeters: 200, ConsecutiveUpdates: 4}
+	assert.NoError(t, config.validate())
+
+	config = base()
+	config.DetourDetection.ThresholdMeters = -1
+	err := config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "detour-detection.threshold-meters cannot be negative")
+
+	config = base()
+	config.DetourDetection.ConsecutiveUpdates = -1
+	err = config.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "detour-detection.consecutive-updates cannot be negative")
+}
+
+func TestToGtfsConfigData_DetourDetection(t *testing.T) {
+	jsonConfig := &JSONConfig{DetourDetection: DetourDetection{ThresholdMeters: 200, ConsecutiveUpdates: 4}}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, gtfsConfig.DetourThresholdMeters)
+	assert.Equal(t, 4, gtfsConfig.DetourConsecutiveUpdates)
+}
+
+func TestToAppConfig_RequestLog(t *testing.T) {
+	jsonConfig := &JSONConfig{RequestLog: RequestLog{SampleRate: 0.1, SlowDBThresholdMs: 250}}
+
+	appConfig := jsonConfig.ToAppConfig()
+
+	assert.Equal(t, 0.1, appConfig.RequestLogSampleRate)
+	assert.Equal(t, 250*time.Millisecond, appConfig.SlowDBThreshold)
+}
+
+func TestToAppConfig_StopSearch(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		StopSearch: StopSearch{RadiusMeters: 800, MaxCount: 50, NearbyRadiusMeters: 400, NearbyMaxCount: 8},
+	}
+
+	appConfig := jsonConfig.ToAppConfig()
+
+	assert.Equal(t, 800.0, appConfig.StopSearchRadius)
+	assert.Equal(t, 50, appConfig.StopSearchMaxCount)
+	assert.Equal(t, 400.0, appConfig.NearbyStopsRadius)
+	assert.Equal(t, 8, appConfig.NearbyStopsMaxCount)
+}
+
+func TestValidate_InvalidStopSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		stopSearch StopSearch
+		field      string
+	}{
+		{"negative radius", StopSearch{RadiusMeters: -1}, "stop-search.radius-meters"},
+		{"nearby radius too large", StopSearch{NearbyRadiusMeters: 10001}, "stop-search.nearby-radius-meters"},
+		{"negative max count", StopSearch{MaxCount: -1}, "stop-search.max-count"},
+		{"nearby max count too large", StopSearch{NearbyMaxCount: 251}, "stop-search.nearby-max-count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100, StopSearch: tt.stopSearch}
+			err := config.validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.field)
+		})
+	}
+}
+
+func TestResponseLimits(t *testing.T) {
+	jsonConfig := &JSONConfig{ResponseLimits: ResponseLimits{MaxShapePoints: 5000, MaxTripStops: 300}}
+	appConfig := jsonConfig.ToAppConfig()
+	assert.Equal(t, 5000, appConfig.MaxShapePoints)
+	assert.Equal(t, 300, appConfig.MaxTripStops)
+
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+		ResponseLimits: ResponseLimits{MaxTripStops: -1}}
+	err := config.validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "response-limits.max-trip-stops")
+}
+
+func TestIDFormat(t *testing.T) {
+	jsonConfig := &JSONConfig{IDFormat: IDFormat{Raw: true, AgencyID: "25"}}
+	appConfig := jsonConfig.ToAppConfig()
+	assert.True(t, appConfig.RawIDs)
+	assert.Equal(t, "25", appConfig.RawIDAgency)
+
+	tests := []struct {
+		name     string
+		idFormat IDFormat
+		wantErr  string
+	}{
+		{"separator", IDFormat{Separator: ":"}, ""},
+		{"unsafe separator", IDFormat{Separator: "/"}, "id-format.separator"},
+		{"alphanumeric separator", IDFormat{Separator: "x"}, "id-format.separator"},
+		{"agency without raw", IDFormat{AgencyID: "25"}, "id-format.agency-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+				IDFormat: tt.idFormat}
+			err := config.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRealtimeReplay(t *testing.T) {
+	jsonConfig := &JSONConfig{RealtimeReplay: RealtimeReplay{Dir: "recordings", Speed: 10, Loop: true}}
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, "recordings", gtfsConfig.ReplayDir)
+	assert.Equal(t, 10.0, gtfsConfig.ReplaySpeed)
+	assert.True(t, gtfsConfig.ReplayLoop)
+
+	tests := []struct {
+		name    string
+		replay  RealtimeReplay
+		wantErr string
+	}{
+		{"disabled", RealtimeReplay{}, ""},
+		{"real time", RealtimeReplay{Dir: "recordings"}, ""},
+		{"negative speed", RealtimeReplay{Dir: "recordings", Speed: -2}, "realtime-replay.speed"},
+		{"loop without dir", RealtimeReplay{Loop: true}, "realtime-replay.dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+				RealtimeReplay: tt.replay}
+			err := config.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestVehicleCapacity(t *testing.T) {
+	jsonConfig := &JSONConfig{VehicleCapacity: VehicleCapacity{File: "capacities.csv", Vehicles: map[string]int{"1201": 80}}}
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, "capacities.csv", gtfsConfig.VehicleCapacityFile)
+	assert.Equal(t, map[string]int{"1201": 80}, gtfsConfig.VehicleCapacities)
+
+	config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+		VehicleCapacity: VehicleCapacity{Vehicles: map[string]int{"1201": 0}}}
+	err = config.validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vehicle-capacity.vehicles")
+}
+
+func TestValidate_NegativeFeedInterval(t *testing.T) {
+	config := &JSONConfig{
+		Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+		GtfsRtFeeds: []GtfsRtFeed{
+			{VehiclePositionsURL: "https://api.example.com/vehicle-positions.pb"},
+			{ServiceAlertsURL: "https://api.example.com/service-alerts.pb", ServiceAlertsInterval: -5},
+		},
+	}
+	err := config.validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-rt-feeds[1].service-alerts-interval")
+}
+
+func TestToGtfsConfigData_SourceIntervals(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		GtfsRtFeeds: []GtfsRtFeed{
+			{
+				VehiclePositionsURL:      "https://api.example.com/vehicle-positions.pb",
+				ServiceAlertsURL:         "https://api.example.com/service-alerts.pb",
+				VehiclePositionsInterval: 10,
+				ServiceAlertsInterval:    300,
+			},
+		},
+	}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	require.Len(t, gtfsConfig.RTFeeds, 1)
+
+	feed := gtfsConfig.RTFeeds[0]
+	assert.Equal(t, 30, feed.RefreshInterval)
+	assert.Equal(t, 0, feed.TripUpdatesInterval)
+	assert.Equal(t, 10, feed.VehiclePositionsInterval)
+	assert.Equal(t, 300, feed.ServiceAlertsInterval)
+}
+
+func TestValidate_VehicleIDRewrites(t *testing.T) {
+	base := func(rewrites ...VehicleIDRewrite) *JSONConfig {
+		return &JSONConfig{
+			Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+			GtfsRtFeeds: []GtfsRtFeed{{
+				VehiclePositionsURL: "https://api.example.com/vehicle-positions.pb",
+				VehicleIDRewrites:   rewrites,
+			}},
+		}
+	}
+
+	assert.NoError(t, base(VehicleIDRewrite{Pattern: "^KCM_(\\d+)$", Replacement: "$1"}).validate())
+
+	err := base(VehicleIDRewrite{Pattern: "", Replacement: "x"}).validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-rt-feeds[0].vehicle-id-rewrites[0].pattern cannot be empty")
+
+	err = base(VehicleIDRewrite{Pattern: "^1", Replacement: ""}, VehicleIDRewrite{Pattern: "([", Replacement: ""}).validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gtfs-rt-feeds[0].vehicle-id-rewrites[1].pattern is not a valid regular expression")
+}
+
+func TestToGtfsConfigData_VehicleIDRewrites(t *testing.T) {
+	rewrites := []VehicleIDRewrite{{Pattern: "^1_", Replacement: ""}}
+	jsonConfig := &JSONConfig{
+		GtfsRtFeeds: []GtfsRtFeed{{
+			VehiclePositionsURL: "https://api.example.com/vehicle-positions.pb",
+			VehicleIDRewrites:   rewrites,
+		}},
+	}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	require.Len(t, gtfsConfig.RTFeeds, 1)
+	assert.Equal(t, rewrites, gtfsConfig.RTFeeds[0].VehicleIDRewrites)
+}
+
+func TestToGtfsConfigData_FeedPriority(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		GtfsRtFeeds: []GtfsRtFeed{
+			{ID: "avl", VehiclePositionsURL: "https://avl.example.com/vehicle-positions.pb", Priority: 2},
+			{ID: "predictions", TripUpdatesURL: "https://predictions.example.com/trip-updates.pb"},
+		},
+	}
+
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	require.Len(t, gtfsConfig.RTFeeds, 2)
+	assert.Equal(t, 2, gtfsConfig.RTFeeds[0].Priority)
+	assert.Equal(t, 0, gtfsConfig.RTFeeds[1].Priority)
+}
+
 func TestToGtfsConfigData_WithMultipleFeeds(t *testing.T) {
 	jsonConfig := &JSONConfig{
 		Port: 4000,
@@ -581,3 +1028,83 @@ func TestLoadFromFile_EnvVarOverrides(t *testing.T) {
 		assert.Equal(t, "Env-Value", config.GtfsStaticFeed.AuthHeaderValue)
 	})
 }
+
+func TestRegions(t *testing.T) {
+	jsonConfig := &JSONConfig{
+		GtfsStaticFeed:  GtfsStaticFeed{URL: "https://example.com/main.zip"},
+		GtfsRtFeeds:     []GtfsRtFeed{{ID: "main", TripUpdatesURL: "https://example.com/main-tu.pb"}},
+		DataPath:        "./gtfs.db",
+		DetourDetection: DetourDetection{ThresholdMeters: 200},
+		RealtimeReplay:  RealtimeReplay{Dir: "recordings"},
+		Regions: []Region{{
+			ID:             "north",
+			GtfsStaticFeed: GtfsStaticFeed{URL: "https://example.com/north.zip", RefreshIntervalMinutes: 60},
+			GtfsRtFeeds:    []GtfsRtFeed{{TripUpdatesURL: "https://example.com/north-tu.pb"}},
+			DataPath:       "./north.db",
+		}},
+	}
+	gtfsConfig, err := jsonConfig.ToGtfsConfigData()
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/main.zip", gtfsConfig.GtfsURL)
+	require.Len(t, gtfsConfig.Regions, 1)
+
+	region := gtfsConfig.Regions[0]
+	assert.Equal(t, "north", region.ID)
+	assert.Equal(t, "https://example.com/north.zip", region.GtfsURL)
+	assert.Equal(t, time.Hour, region.StaticRefreshInterval)
+	assert.Equal(t, "./north.db", region.GTFSDataPath)
+	require.Len(t, region.RTFeeds, 1)
+	assert.Equal(t, "https://example.com/north-tu.pb", region.RTFeeds[0].TripUpdatesURL)
+	assert.Equal(t, 200.0, region.DetourThresholdMeters, "other settings are shared")
+	assert.Empty(t, region.ReplayDir, "replays only stand in for the main feeds")
+	assert.Empty(t, region.Regions)
+
+	feed := GtfsStaticFeed{URL: "https://example.com/region.zip"}
+	tests := []struct {
+		name    string
+		regions []Region
+		wantErr string
+	}{
+		{"valid", []Region{{ID: "north-2", GtfsStaticFeed: feed, DataPath: "./north.db"}}, ""},
+		{"in memory", []Region{
+			{ID: "a", GtfsStaticFeed: feed, DataPath: ":memory:"},
+			{ID: "b", GtfsStaticFeed: feed, DataPath: ":memory:"},
+		}, ""},
+		{"missing id", []Region{{GtfsStaticFeed: feed, DataPath: "./north.db"}}, "regions[0].id"},
+		{"uppercase id", []Region{{ID: "North", GtfsStaticFeed: feed, DataPath: "./north.db"}}, "regions[0].id"},
+		{"slash in id", []Region{{ID: "north/south", GtfsStaticFeed: feed, DataPath: "./north.db"}}, "regions[0].id"},
+		{"duplicate id", []Region{
+			{ID: "north", GtfsStaticFeed: feed, DataPath: "./a.db"},
+			{ID: "north", GtfsStaticFeed: feed, DataPath: "./b.db"},
+		}, "duplicate region ID"},
+		{"missing url", []Region{{ID: "north", DataPath: "./north.db"}}, "regions[0].gtfs-static-feed.url"},
+		{"file url", []Region{{ID: "north", GtfsStaticFeed: GtfsStaticFeed{URL: "file:///etc/passwd"}, DataPath: "./north.db"}}, "file://"},
+		{"missing data path", []Region{{ID: "north", GtfsStaticFeed: feed}}, "regions[0].data-path is required"},
+		{"shared data path", []Region{{ID: "north", GtfsStaticFeed: feed, DataPath: "./gtfs.db"}}, "already used"},
+		{"negative refresh", []Region{{ID: "north", GtfsStaticFeed: GtfsStaticFeed{URL: feed.URL, RefreshIntervalMinutes: -1}, DataPath: "./north.db"}}, "regions[0]: gtfs-static-feed.refresh-interval-minutes"},
+		{"negative feed interval", []Region{{ID: "north", GtfsStaticFeed: feed, DataPath: "./north.db",
+			GtfsRtFeeds: []GtfsRtFeed{{TripUpdatesInterval: -1}}}}, "regions[0]: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+				DataPath: "./gtfs.db", Regions: tt.regions}
+			err := config.validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("raw ids", func(t *testing.T) {
+		config := &JSONConfig{Port: 4000, Env: "development", ApiKeys: []string{"test"}, RateLimit: 100,
+			DataPath: "./gtfs.db", IDFormat: IDFormat{Raw: true},
+			Regions: []Region{{ID: "north", GtfsStaticFeed: feed, DataPath: "./north.db"}}}
+		err := config.validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "id-format.raw cannot be combined with regions")
+	})
+}
@@ -180,3 +180,56 @@ func (e *EnvironmentClock) parseTime(s string) (time.Time, error) {
 
 	return time.Time{}, fmt.Errorf("unable to parse time %q: expected RFC3339 (2006-01-02T15:04:05Z07:00), or YYYY-MM-DD HH:MM:SS, YYYY-MM-DDTHH:MM:SS, or YYYY-MM-DD", s)
 }
+
+// ReplayClock implements Clock for replays of recorded data. It starts at a
+// recorded instant and then runs a fixed number of times faster than real time.
+// Use NewReplayClock to create instances.
+type ReplayClock struct {
+	mu      sync.Mutex
+	origin  time.Time
+	started time.Time
+	speed   float64
+	wallNow func() time.Time
+}
+
+// NewReplayClock creates a ReplayClock that reads origin now and advances speed
+// seconds per real second. A speed of zero or less runs at real time.
+func NewReplayClock(origin time.Time, speed float64) *ReplayClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	c := &ReplayClock{speed: speed, wallNow: time.Now}
+	c.Reset(origin)
+	return c
+}
+
+// Now returns the replayed time.
+func (c *ReplayClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elapsed := c.wallNow().Sub(c.started)
+	return c.origin.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+// NowUnixMilli returns the replayed time as Unix milliseconds.
+func (c *ReplayClock) NowUnixMilli() int64 {
+	return c.Now().UnixMilli()
+}
+
+// Speed returns how many times faster than real time the clock runs.
+func (c *ReplayClock) Speed() float64 {
+	return c.speed
+}
+
+// Reset moves the clock back, or forward, to origin and keeps it running from there.
+func (c *ReplayClock) Reset(origin time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.origin = origin
+	c.started = c.wallNow()
+}
+
+// WallDuration returns how much real time passes while the clock advances by d.
+func (c *ReplayClock) WallDuration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
@@ -409,3 +409,25 @@ func TestMockClock_ConcurrentAccess(t *testing.T) {
 	// Just verify the clock still works
 	_ = c.Now()
 }
+
+func TestReplayClock_RunsAtSpeed(t *testing.T) {
+	wall := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	origin := time.Date(2025, 6, 8, 21, 8, 5, 0, time.UTC)
+	c := NewReplayClock(origin, 10)
+	c.wallNow = func() time.Time { return wall }
+	c.Reset(origin)
+
+	assert.Equal(t, origin, c.Now())
+	wall = wall.Add(3 * time.Second)
+	assert.Equal(t, origin.Add(30*time.Second), c.Now())
+	assert.Equal(t, origin.Add(30*time.Second).UnixMilli(), c.NowUnixMilli())
+	assert.Equal(t, 2*time.Second, c.WallDuration(20*time.Second))
+
+	c.Reset(origin)
+	assert.Equal(t, origin, c.Now(), "Reset restarts the clock at the origin")
+}
+
+func TestReplayClock_DefaultsToRealTime(t *testing.T) {
+	c := NewReplayClock(time.Unix(0, 0), 0)
+	assert.Equal(t, 1.0, c.Speed())
+}
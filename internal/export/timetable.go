@@ -0,0 +1,258 @@
+// Package export renders schedule data for publishing rather than for API
+// clients: a route's timetable for one service day as CSV or as a printable
+// HTML page.
+package export
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+//go:embed timetable.html
+var templateFS embed.FS
+
+var timetableTemplate = template.Must(template.New("timetable.html").Funcs(template.FuncMap{
+	"clock": func(t *int) string {
+		if t == nil {
+			return "—"
+		}
+		return formatClock(*t)
+	},
+}).ParseFS(templateFS, "timetable.html"))
+
+// Format selects how a timetable is rendered.
+type Format int
+
+const (
+	FormatCSV Format = iota
+	FormatHTML
+)
+
+// ParseFormat maps the "format" request parameter to a Format. CSV is the
+// default.
+func ParseFormat(value string) (Format, error) {
+	switch value {
+	case "", "csv":
+		return FormatCSV, nil
+	case "html":
+		return FormatHTML, nil
+	default:
+		return FormatCSV, fmt.Errorf("unsupported format %q: must be csv or html", value)
+	}
+}
+
+// ContentType returns the HTTP Content-Type for the format.
+func (f Format) ContentType() string {
+	if f == FormatHTML {
+		return "text/html; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Timetable is a route's schedule for one service day laid out for print: a
+// table per direction with a column per stop and a row per trip.
+type Timetable struct {
+	AgencyName     string
+	RouteShortName string
+	RouteLongName  string
+	ServiceDate    time.Time
+	Directions     []DirectionTimetable
+}
+
+// RouteName joins the route's short and long names, whichever it has.
+func (t Timetable) RouteName() string {
+	switch {
+	case t.RouteShortName != "" && t.RouteLongName != "":
+		return t.RouteShortName + " " + t.RouteLongName
+	case t.RouteShortName != "":
+		return t.RouteShortName
+	default:
+		return t.RouteLongName
+	}
+}
+
+// DirectionTimetable holds the trips of one direction of a route.
+type DirectionTimetable struct {
+	DirectionID string
+	Headsigns   []string
+	Stops       []TimetableStop
+	Trips       []TimetableTrip
+}
+
+// TimetableStop is a column of a DirectionTimetable. A stop a trip visits
+// twice, such as the ends of a loop, has a column for each visit.
+type TimetableStop struct {
+	ID   string
+	Name string
+}
+
+// TimetableTrip is a row of a DirectionTimetable. Times holds, for each of the
+// direction's stops, the trip's departure from it in seconds since the service
+// date's midnight, or nil where the trip does not stop. A trip's last stop
+// shows its arrival instead.
+type TimetableTrip struct {
+	ID       string
+	Headsign string
+	Times    []*int
+}
+
+// NewDirectionTimetable lays out the trips of a schedule-for-route grouping,
+// in the grouping's order. stopNames and tripHeadsigns are keyed by the
+// grouping's IDs; a stop without a name is shown by its ID.
+func NewDirectionTimetable(grouping models.StopTripGrouping, stopNames, tripHeadsigns map[string]string) DirectionTimetable {
+	visits := make([][]string, len(grouping.TripsWithStopTimes))
+	for i, trip := range grouping.TripsWithStopTimes {
+		for _, st := range trip.StopTimes {
+			visits[i] = append(visits[i], st.StopID)
+		}
+	}
+	columns := mergeStopColumns(visits)
+
+	direction := DirectionTimetable{
+		DirectionID: grouping.DirectionID,
+		Headsigns:   grouping.TripHeadsigns,
+		Stops:       make([]TimetableStop, len(columns)),
+		Trips:       make([]TimetableTrip, 0, len(grouping.TripsWithStopTimes)),
+	}
+	for i, stopID := range columns {
+		name := stopNames[stopID]
+		if name == "" {
+			name = stopID
+		}
+		direction.Stops[i] = TimetableStop{ID: stopID, Name: name}
+	}
+
+	for _, trip := range grouping.TripsWithStopTimes {
+		row := TimetableTrip{
+			ID:       trip.TripID,
+			Headsign: tripHeadsigns[trip.TripID],
+			Times:    make([]*int, len(columns)),
+		}
+		column := 0
+		for i, st := range trip.StopTimes {
+			column = nextColumn(columns, st.StopID, column)
+			t := st.DepartureTime
+			if i == len(trip.StopTimes)-1 {
+				t = st.ArrivalTime
+			}
+			row.Times[column] = &t
+			column++
+		}
+		direction.Trips = append(direction.Trips, row)
+	}
+	return direction
+}
+
+// mergeStopColumns returns a sequence of stops holding every trip's stops in
+// the trip's order, so that each trip reads left to right. A stop missing from
+// the columns so far is inserted right after the trip's previous stop.
+func mergeStopColumns(trips [][]string) []string {
+	var columns []string
+	for _, stops := range trips {
+		column := 0
+		for _, stopID := range stops {
+			if next := nextColumn(columns, stopID, column); next >= 0 {
+				column = next + 1
+				continue
+			}
+			columns = slices.Insert(columns, column, stopID)
+			column++
+		}
+	}
+	return columns
+}
+
+// nextColumn returns the first column at or after from that is stopID, or -1.
+func nextColumn(columns []string, stopID string, from int) int {
+	for i := from; i < len(columns); i++ {
+		if columns[i] == stopID {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatClock formats seconds since midnight as a time of day, e.g. "07:05".
+// Service times past midnight wrap around to the next day's clock.
+func formatClock(seconds int) string {
+	minutes := seconds / 60 % (24 * 60)
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Write renders the timetable in the given format.
+func Write(w io.Writer, t Timetable, format Format) error {
+	if format == FormatHTML {
+		return WriteHTML(w, t)
+	}
+	return WriteCSV(w, t)
+}
+
+// WriteCSV writes a block per direction: a header naming the stops, then a
+// row per trip. Blocks are separated by an empty record.
+func WriteCSV(w io.Writer, t Timetable) error {
+	out := csv.NewWriter(w)
+	for i, direction := range t.Directions {
+		if i > 0 {
+			if err := out.Write([]string{""}); err != nil {
+				return err
+			}
+		}
+
+		header := []string{"direction_id", "trip_id", "trip_headsign"}
+		for _, stop := range direction.Stops {
+			header = append(header, stop.Name)
+		}
+		if err := out.Write(header); err != nil {
+			return err
+		}
+
+		for _, trip := range direction.Trips {
+			record := []string{direction.DirectionID, trip.ID, trip.Headsign}
+			for _, at := range trip.Times {
+				if at == nil {
+					record = append(record, "")
+				} else {
+					record = append(record, formatClock(*at))
+				}
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteHTML writes a standalone page with a table per direction, styled to
+// print.
+func WriteHTML(w io.Writer, t Timetable) error {
+	return timetableTemplate.Execute(w, struct {
+		Timetable
+		Date string
+	}{t, t.ServiceDate.Format("Monday, January 2, 2006")})
+}
+
+// Filename suggests a download name for the timetable, e.g.
+// "route-24-2025-06-13.csv".
+func Filename(routeID string, serviceDate time.Time, format Format) string {
+	ext := "csv"
+	if format == FormatHTML {
+		ext = "html"
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, routeID)
+	return fmt.Sprintf("route-%s-%s.%s", safe, serviceDate.Format("2006-01-02"), ext)
+}
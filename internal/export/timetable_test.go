@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+	assert.Equal(t, "text/csv; charset=utf-8", f.ContentType())
+
+	f, err = ParseFormat("html")
+	require.NoError(t, err)
+	assert.Equal(t, FormatHTML, f)
+	assert.Equal(t, "text/html; charset=utf-8", f.ContentType())
+
+	_, err = ParseFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestMergeStopColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		trips [][]string
+		want  []string
+	}{
+		{"single trip", [][]string{{"A", "B", "C"}}, []string{"A", "B", "C"}},
+		{"short turn", [][]string{{"A", "B", "C"}, {"B", "C"}}, []string{"A", "B", "C"}},
+		{"branch", [][]string{{"A", "B", "D"}, {"A", "C", "D"}}, []string{"A", "C", "B", "D"}},
+		{"loop", [][]string{{"A", "B", "A"}}, []string{"A", "B", "A"}},
+		{"extension", [][]string{{"A", "B"}, {"A", "B", "C"}}, []string{"A", "B", "C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mergeStopColumns(tt.trips))
+		})
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	assert.Equal(t, "07:05", formatClock(7*3600+5*60))
+	assert.Equal(t, "23:59", formatClock(23*3600+59*60+59))
+	assert.Equal(t, "00:15", formatClock(24*3600+15*60))
+}
+
+func stopTime(tripID, stopID string, arrival, departure int) models.RouteStopTime {
+	return models.RouteStopTime{TripID: tripID, StopID: stopID, ArrivalTime: arrival, DepartureTime: departure}
+}
+
+func testTimetable() Timetable {
+	grouping := models.StopTripGrouping{
+		DirectionID:   "0",
+		TripHeadsigns: []string{"Downtown"},
+		TripsWithStopTimes: []models.TripStopTimes{
+			{TripID: "1_T1", StopTimes: []models.RouteStopTime{
+				stopTime("1_T1", "1_A", 7*3600, 7*3600),
+				stopTime("1_T1", "1_B", 7*3600+600, 7*3600+660),
+				stopTime("1_T1", "1_C", 7*3600+1200, 7*3600+1260),
+			}},
+			{TripID: "1_T2", StopTimes: []models.RouteStopTime{
+				stopTime("1_T2", "1_B", 8*3600, 8*3600),
+				stopTime("1_T2", "1_C", 8*3600+600, 8*3600+600),
+			}},
+		},
+	}
+	stopNames := map[string]string{"1_A": "Main & 1st", "1_B": "Central Station"}
+	headsigns := map[string]string{"1_T1": "Downtown", "1_T2": "Downtown"}
+
+	return Timetable{
+		AgencyName:     "Test Transit",
+		RouteShortName: "24",
+		RouteLongName:  "Crosstown",
+		ServiceDate:    time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC),
+		Directions:     []DirectionTimetable{NewDirectionTimetable(grouping, stopNames, headsigns)},
+	}
+}
+
+func TestNewDirectionTimetable(t *testing.T) {
+	direction := testTimetable().Directions[0]
+
+	require.Len(t, direction.Stops, 3)
+	assert.Equal(t, "Main & 1st", direction.Stops[0].Name)
+	assert.Equal(t, "1_C", direction.Stops[2].Name, "a stop without a name is shown by its ID")
+
+	require.Len(t, direction.Trips, 2)
+	first := direction.Trips[0]
+	assert.Equal(t, 7*3600, *first.Times[0])
+	assert.Equal(t, 7*3600+660, *first.Times[1], "intermediate stops show the departure")
+	assert.Equal(t, 7*3600+1200, *first.Times[2], "the last stop shows the arrival")
+
+	second := direction.Trips[1]
+	assert.Nil(t, second.Times[0], "a trip starting mid-route leaves the first stop blank")
+	assert.Equal(t, 8*3600, *second.Times[1])
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, testTimetable()))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"direction_id", "trip_id", "trip_headsign", "Main & 1st", "Central Station", "1_C"}, records[0])
+	assert.Equal(t, []string{"0", "1_T1", "Downtown", "07:00", "07:11", "07:20"}, records[1])
+	assert.Equal(t, []string{"0", "1_T2", "Downtown", "", "08:00", "08:10"}, records[2])
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, testTimetable()))
+	page := buf.String()
+
+	assert.Contains(t, page, "<h1>24 Crosstown</h1>")
+	assert.Contains(t, page, "Friday, June 13, 2025")
+	assert.Contains(t, page, "To Downtown")
+	assert.Contains(t, page, "Main &amp; 1st", "stop names are escaped")
+	assert.Contains(t, page, "<td>07:11</td>")
+	assert.Contains(t, page, "<td>—</td>")
+	assert.NotContains(t, page, "No service on this date.")
+
+	buf.Reset()
+	require.NoError(t, WriteHTML(&buf, Timetable{RouteShortName: "24"}))
+	assert.Contains(t, buf.String(), "No service on this date.")
+}
+
+func TestFilename(t *testing.T) {
+	date := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "route-24-2025-06-13.csv", Filename("24", date, FormatCSV))
+	assert.Equal(t, "route-A-B-2025-06-13.html", Filename("A/B", date, FormatHTML))
+}
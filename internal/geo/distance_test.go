@@ -0,0 +1,13 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0.0, Distance(40.7128, -74.0060, 40.7128, -74.0060))
+	assert.InDelta(t, 3935746, Distance(40.7128, -74.0060, 34.0522, -118.2437), 1000, "great circle")
+	assert.InDelta(t, 5315, Distance(40.7128, -74.0060, 40.7580, -73.9855), 1, "equirectangular fast path")
+}
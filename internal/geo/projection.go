@@ -0,0 +1,35 @@
+package geo
+
+import "math"
+
+// ProjectOntoSegment finds the point of the segment from (lat1, lon1) to
+// (lat2, lon2) closest to (lat, lon). It returns the distance in meters to
+// that point, how far along the segment it lies as a ratio in [0, 1], and its
+// coordinates. A segment of zero length projects everything onto its start.
+//
+// Unless geodesic, latitude and longitude are projected as if they were
+// planar. Geodesic, each segment is projected on a local equirectangular plane
+// scaled at its mean latitude.
+func ProjectOntoSegment(lat, lon, lat1, lon1, lat2, lon2 float64, geodesic bool) (distance, ratio, closestLat, closestLon float64) {
+	lonScale := 1.0
+	if geodesic {
+		lonScale = math.Cos((lat1 + lat2) / 2 * (math.Pi / 180))
+	}
+
+	dLat := lat2 - lat1
+	dLon := (lon2 - lon1) * lonScale
+	if dLat == 0 && dLon == 0 {
+		return Distance(lat, lon, lat1, lon1), 0, lat1, lon1
+	}
+
+	t := ((lat-lat1)*dLat + (lon-lon1)*lonScale*dLon) / (dLat*dLat + dLon*dLon)
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+
+	closestLat = lat1 + t*(lat2-lat1)
+	closestLon = lon1 + t*(lon2-lon1)
+	return Distance(lat, lon, closestLat, closestLon), t, closestLat, closestLon
+}
@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// StopMatchToleranceMeters is how much farther from a stop than the shape's
+// closest approach a pass of the shape may run and still be taken for the
+// stop. It lets the first call at a stop a trip passes twice, such as the
+// start and end of a loop, match the first pass even when the second one runs
+// a few meters closer.
+const StopMatchToleranceMeters = 25.0
+
+// Point is a stop's location.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// PlaceStops returns how far along the shape, in meters, each stop lies,
+// given the stops in stop sequence order and the cumulative distances of the
+// shape's points. Each stop is matched to the first pass of the shape after
+// the previous stop that comes within StopMatchToleranceMeters of the shape's
+// closest approach to it, so the distances never decrease and a stop served
+// twice gets both of its passes.
+func PlaceStops(shape []gtfs.ShapePoint, cumulative []float64, stops []Point, geodesic bool) []float64 {
+	distances := make([]float64, len(stops))
+	if len(shape) < 2 {
+		return distances
+	}
+
+	fromSegment, fromRatio := 0, 0.0
+	previous := 0.0
+	for i, stop := range stops {
+		segment, _, ratio := MatchStopToPass(shape, stop, fromSegment, fromRatio, geodesic)
+		along := cumulative[segment] + ratio*(cumulative[segment+1]-cumulative[segment])
+		if along < previous {
+			along = previous
+		}
+
+		distances[i] = along
+		previous = along
+		fromSegment, fromRatio = segment, ratio
+	}
+	return distances
+}
+
+// MatchStopToPass scans the shape from fromSegment on for the first pass that
+// comes within StopMatchToleranceMeters of the shape's closest approach to the
+// stop, and follows that pass to where it runs closest to the stop. The part
+// of fromSegment behind fromRatio is left out. It returns the segment, the
+// stop's distance from it and the ratio along it.
+func MatchStopToPass(shape []gtfs.ShapePoint, stop Point, fromSegment int, fromRatio float64, geodesic bool) (segment int, distance, ratio float64) {
+	// segmentDistance projects the stop onto a segment, leaving out the part of
+	// the previous stop's segment that lies behind that stop.
+	segmentDistance := func(i int) (float64, float64) {
+		a, b := shape[i], shape[i+1]
+		distance, ratio, _, _ := ProjectOntoSegment(stop.Lat, stop.Lon, a.Latitude, a.Longitude, b.Latitude, b.Longitude, geodesic)
+		if i == fromSegment && ratio < fromRatio {
+			lat := a.Latitude + fromRatio*(b.Latitude-a.Latitude)
+			lon := a.Longitude + fromRatio*(b.Longitude-a.Longitude)
+			distance, ratio = Distance(stop.Lat, stop.Lon, lat, lon), fromRatio
+		}
+		return distance, ratio
+	}
+
+	closest := math.Inf(1)
+	for s := fromSegment; s < len(shape)-1; s++ {
+		if d, _ := segmentDistance(s); d < closest {
+			closest = d
+		}
+	}
+
+	segment = fromSegment
+	for ; segment < len(shape)-2; segment++ {
+		if d, _ := segmentDistance(segment); d <= closest+StopMatchToleranceMeters {
+			break
+		}
+	}
+	// Follow the pass to where it runs closest to the stop.
+	distance, ratio = segmentDistance(segment)
+	for segment+1 < len(shape)-1 {
+		next, nextRatio := segmentDistance(segment + 1)
+		if next >= distance {
+			break
+		}
+		segment, distance, ratio = segment+1, next, nextRatio
+	}
+	return segment, distance, ratio
+}
@@ -0,0 +1,32 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceStopsOnLoop(t *testing.T) {
+	// A square loop that starts and ends at the terminal.
+	shape := []gtfs.ShapePoint{
+		{Latitude: 47.600, Longitude: -122.330},
+		{Latitude: 47.600, Longitude: -122.320},
+		{Latitude: 47.610, Longitude: -122.320},
+		{Latitude: 47.610, Longitude: -122.330},
+		{Latitude: 47.600, Longitude: -122.330},
+	}
+	cumulative := make([]float64, len(shape))
+	for i := 1; i < len(shape); i++ {
+		cumulative[i] = cumulative[i-1] + Distance(shape[i-1].Latitude, shape[i-1].Longitude, shape[i].Latitude, shape[i].Longitude)
+	}
+	terminal := Point{Lat: 47.600, Lon: -122.330}
+	corner := Point{Lat: 47.610, Lon: -122.320}
+
+	distances := PlaceStops(shape, cumulative, []Point{terminal, corner, terminal}, false)
+	assert.InDelta(t, 0, distances[0], 1, "the first call at the terminal is the start of the loop")
+	assert.InDelta(t, cumulative[2], distances[1], 1)
+	assert.InDelta(t, cumulative[4], distances[2], 1, "the last call is the end of the loop")
+
+	assert.Equal(t, []float64{0}, PlaceStops(shape[:1], cumulative[:1], []Point{terminal}, false), "a shape of one point places nothing")
+}
@@ -76,9 +76,16 @@ func (adc *AdvancedDirectionCalculator) SetContextCache(cache map[string][]gtfsd
 	adc.contextCache = cache
 }
 
-// CalculateStopDirection computes the direction for a stop using the Java algorithm
+// CalculateStopDirection returns the direction of a stop. gtfsDirection is the
+// stop's direction column: once DirectionPrecomputer has filled it in, the
+// stored value is returned as is, and an empty value means the direction could
+// not be determined. Only stops that were never precomputed are computed from
+// their shapes, using the Java algorithm.
 func (adc *AdvancedDirectionCalculator) CalculateStopDirection(ctx context.Context, stopID string, gtfsDirection ...sql.NullString) string {
-	if len(gtfsDirection) > 0 && gtfsDirection[0].Valid && gtfsDirection[0].String != "" {
+	if len(gtfsDirection) > 0 && gtfsDirection[0].Valid {
+		if gtfsDirection[0].String == "" {
+			return ""
+		}
 		if direction := adc.translateGtfsDirection(gtfsDirection[0].String); direction != "" {
 			return direction
 		}
@@ -94,23 +101,23 @@ func (adc *AdvancedDirectionCalculator) CalculateStopDirection(ctx context.Conte
 func (adc *AdvancedDirectionCalculator) translateGtfsDirection(direction string) string {
 	direction = strings.TrimSpace(strings.ToLower(direction))
 
-	// Try text-based directions
+	// Try text-based directions, including the abbreviations stored by precomputation
 	switch direction {
-	case "north":
+	case "north", "n":
 		return "N"
-	case "northeast":
+	case "northeast", "ne":
 		return "NE"
-	case "east":
+	case "east", "e":
 		return "E"
-	case "southeast":
+	case "southeast", "se":
 		return "SE"
-	case "south":
+	case "south", "s":
 		return "S"
-	case "southwest":
+	case "southwest", "sw":
 		return "SW"
-	case "west":
+	case "west", "w":
 		return "W"
-	case "northwest":
+	case "northwest", "nw":
 		return "NW"
 	}
 
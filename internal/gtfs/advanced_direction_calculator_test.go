@@ -31,6 +31,11 @@ func TestTranslateGtfsDirection(t *testing.T) {
 		{"west", "west", "W"},
 		{"northwest", "northwest", "NW"},
 
+		// Abbreviations, as stored by precomputation
+		{"N abbreviation", "N", "N"},
+		{"SW abbreviation", "SW", "SW"},
+		{"lowercase abbreviation", "ne", "NE"},
+
 		// Numeric directions (degrees) - GTFS uses geographic bearings
 		// 0°=North, 90°=East, 180°=South, 270°=West
 		{"0 degrees", "0", "N"},
@@ -456,6 +461,16 @@ func TestCalculateStopDirection_VariadicSignature(t *testing.T) {
 	assert.Equal(t, "", dirOmitted, "Should fall back gracefully when argument is omitted")
 }
 
+func TestCalculateStopDirection_PrecomputedEmptyDirection(t *testing.T) {
+	// No queries: an empty precomputed direction must be returned without
+	// falling back to the shapes.
+	calc := NewAdvancedDirectionCalculator(nil)
+
+	direction := calc.CalculateStopDirection(context.Background(), "any_stop", sql.NullString{String: "", Valid: true})
+	assert.Equal(t, "", direction)
+	assert.False(t, calc.initialized.Load(), "shapes should not have been consulted")
+}
+
 func TestSetContextCache_ConcurrentAccess(t *testing.T) {
 	// Setup
 	gtfsConfig := Config{
@@ -0,0 +1,131 @@
+package gtfs
+
+import (
+	"sort"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// AgencyCoverage is the area and time span served by one agency: the bounding
+// box and centroid of the stops its trips serve, and the first and last dates
+// any of its trips run.
+type AgencyCoverage struct {
+	AgencyID    string
+	StopCount   int
+	MinLat      float64
+	MaxLat      float64
+	MinLon      float64
+	MaxLon      float64
+	CentroidLat float64
+	CentroidLon float64
+	// ServiceStart and ServiceEnd are zero when the agency's services list no dates.
+	ServiceStart time.Time
+	ServiceEnd   time.Time
+}
+
+// agencyCoverageBuilder accumulates the stops and services of one agency.
+type agencyCoverageBuilder struct {
+	stops    map[string]*gtfs.Stop
+	services map[string]*gtfs.Service
+}
+
+// computeAgencyCoverage builds the coverage of every agency with trips in
+// staticData. Agencies without stops that have coordinates are left out.
+func computeAgencyCoverage(staticData *gtfs.Static) map[string]AgencyCoverage {
+	if staticData == nil {
+		return nil
+	}
+
+	builders := make(map[string]*agencyCoverageBuilder)
+	for i := range staticData.Trips {
+		trip := &staticData.Trips[i]
+		if trip.Route == nil || trip.Route.Agency == nil {
+			continue
+		}
+		builder, ok := builders[trip.Route.Agency.Id]
+		if !ok {
+			builder = &agencyCoverageBuilder{
+				stops:    make(map[string]*gtfs.Stop),
+				services: make(map[string]*gtfs.Service),
+			}
+			builders[trip.Route.Agency.Id] = builder
+		}
+		if trip.Service != nil {
+			builder.services[trip.Service.Id] = trip.Service
+		}
+		for _, st := range trip.StopTimes {
+			if st.Stop != nil && st.Stop.Latitude != nil && st.Stop.Longitude != nil {
+				builder.stops[st.Stop.Id] = st.Stop
+			}
+		}
+	}
+
+	coverage := make(map[string]AgencyCoverage, len(builders))
+	for agencyID, builder := range builders {
+		if len(builder.stops) == 0 {
+			continue
+		}
+		c := AgencyCoverage{AgencyID: agencyID, StopCount: len(builder.stops)}
+		first := true
+		var sumLat, sumLon float64
+		for _, stop := range builder.stops {
+			lat, lon := *stop.Latitude, *stop.Longitude
+			sumLat += lat
+			sumLon += lon
+			if first {
+				c.MinLat, c.MaxLat, c.MinLon, c.MaxLon = lat, lat, lon, lon
+				first = false
+				continue
+			}
+			c.MinLat = min(c.MinLat, lat)
+			c.MaxLat = max(c.MaxLat, lat)
+			c.MinLon = min(c.MinLon, lon)
+			c.MaxLon = max(c.MaxLon, lon)
+		}
+		c.CentroidLat = sumLat / float64(len(builder.stops))
+		c.CentroidLon = sumLon / float64(len(builder.stops))
+
+		for _, service := range builder.services {
+			start, end := serviceDateRange(service)
+			if !start.IsZero() && (c.ServiceStart.IsZero() || start.Before(c.ServiceStart)) {
+				c.ServiceStart = start
+			}
+			if end.After(c.ServiceEnd) {
+				c.ServiceEnd = end
+			}
+		}
+		coverage[agencyID] = c
+	}
+	return coverage
+}
+
+// serviceDateRange returns the first and last dates a service may run, taking
+// both its calendar.txt range and its calendar_dates.txt additions into account.
+func serviceDateRange(service *gtfs.Service) (start, end time.Time) {
+	dates := append([]time.Time{service.StartDate, service.EndDate}, service.AddedDates...)
+	for _, date := range dates {
+		if date.IsZero() {
+			continue
+		}
+		if start.IsZero() || date.Before(start) {
+			start = date
+		}
+		if date.After(end) {
+			end = date
+		}
+	}
+	return start, end
+}
+
+// GetAgencyCoverage returns the coverage of every agency, ordered by agency ID.
+// The coverage is computed once per static data load.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetAgencyCoverage() []AgencyCoverage {
+	coverage := make([]AgencyCoverage, 0, len(manager.agencyCoverage))
+	for _, c := range manager.agencyCoverage {
+		coverage = append(coverage, c)
+	}
+	sort.Slice(coverage, func(i, j int) bool { return coverage[i].AgencyID < coverage[j].AgencyID })
+	return coverage
+}
@@ -0,0 +1,65 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeAgencyCoverage(t *testing.T) {
+	coord := func(v float64) *float64 { return &v }
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	bus := &gtfs.Agency{Id: "bus"}
+	ferry := &gtfs.Agency{Id: "ferry"}
+	busRoute := &gtfs.Route{Id: "r1", Agency: bus}
+	ferryRoute := &gtfs.Route{Id: "r2", Agency: ferry}
+
+	stopA := &gtfs.Stop{Id: "A", Latitude: coord(47.0), Longitude: coord(-122.0)}
+	stopB := &gtfs.Stop{Id: "B", Latitude: coord(47.2), Longitude: coord(-122.4)}
+	stopC := &gtfs.Stop{Id: "C", Latitude: coord(47.1), Longitude: coord(-122.2)}
+	station := &gtfs.Stop{Id: "station"}
+
+	weekdays := &gtfs.Service{Id: "weekdays", StartDate: date(2025, 1, 1), EndDate: date(2025, 6, 30)}
+	holiday := &gtfs.Service{Id: "holiday", AddedDates: []time.Time{date(2025, 7, 4)}}
+	summer := &gtfs.Service{Id: "summer", StartDate: date(2025, 6, 1), EndDate: date(2025, 8, 31)}
+
+	staticData := &gtfs.Static{
+		Trips: []gtfs.ScheduledTrip{
+			{ID: "t1", Route: busRoute, Service: weekdays, StopTimes: []gtfs.ScheduledStopTime{{Stop: stopA}, {Stop: stopB}, {Stop: station}}},
+			{ID: "t2", Route: busRoute, Service: holiday, StopTimes: []gtfs.ScheduledStopTime{{Stop: stopB}, {Stop: stopC}}},
+			{ID: "t3", Route: ferryRoute, Service: summer, StopTimes: []gtfs.ScheduledStopTime{{Stop: station}}},
+		},
+	}
+
+	coverage := computeAgencyCoverage(staticData)
+
+	require.Contains(t, coverage, "bus")
+	c := coverage["bus"]
+	assert.Equal(t, 3, c.StopCount)
+	assert.Equal(t, 47.0, c.MinLat)
+	assert.Equal(t, 47.2, c.MaxLat)
+	assert.Equal(t, -122.4, c.MinLon)
+	assert.Equal(t, -122.0, c.MaxLon)
+	assert.InDelta(t, 47.1, c.CentroidLat, 1e-9)
+	assert.InDelta(t, -122.2, c.CentroidLon, 1e-9)
+	assert.Equal(t, date(2025, 1, 1), c.ServiceStart)
+	assert.Equal(t, date(2025, 7, 4), c.ServiceEnd, "dates added by calendar_dates extend the range")
+
+	assert.NotContains(t, coverage, "ferry", "an agency without located stops has no coverage")
+}
+
+func TestGetAgencyCoverage_OrderedByAgency(t *testing.T) {
+	manager := &Manager{agencyCoverage: map[string]AgencyCoverage{
+		"b": {AgencyID: "b"},
+		"a": {AgencyID: "a"},
+	}}
+
+	coverage := manager.GetAgencyCoverage()
+	require.Len(t, coverage, 2)
+	assert.Equal(t, "a", coverage[0].AgencyID)
+	assert.Equal(t, "b", coverage[1].AgencyID)
+}
@@ -1,6 +1,9 @@
 package gtfs
 
 import (
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/appconf"
 )
 
@@ -13,7 +16,103 @@ type RTFeedConfig struct {
 	ServiceAlertsURL    string
 	Headers             map[string]string
 	RefreshInterval     int // seconds, default 30
-	Enabled             bool
+	// Per-source polling intervals in seconds; 0 uses RefreshInterval
+	TripUpdatesInterval      int
+	VehiclePositionsInterval int
+	ServiceAlertsInterval    int
+	Enabled                  bool
+	// VehicleIDRewrites are applied, in order, to every vehicle ID the feed publishes
+	VehicleIDRewrites []VehicleIDRewrite
+	// Priority ranks the feed against others publishing the same trip or vehicle; higher wins, default 0
+	Priority int
+}
+
+// feedSource identifies one of the three GTFS-RT endpoints a feed may publish.
+type feedSource int
+
+const (
+	sourceTripUpdates feedSource = iota
+	sourceVehiclePositions
+	sourceServiceAlerts
+	numFeedSources
+)
+
+func (source feedSource) String() string {
+	switch source {
+	case sourceTripUpdates:
+		return "trip_updates"
+	case sourceVehiclePositions:
+		return "vehicle_positions"
+	case sourceServiceAlerts:
+		return "service_alerts"
+	}
+	return "unknown"
+}
+
+// refreshInterval returns the feed's polling interval, defaulting to 30 seconds.
+func (feed RTFeedConfig) refreshInterval() time.Duration {
+	if feed.RefreshInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(feed.RefreshInterval) * time.Second
+}
+
+// sourceURL returns the URL the feed publishes a source at, or "" if it does not.
+func (feed RTFeedConfig) sourceURL(source feedSource) string {
+	switch source {
+	case sourceTripUpdates:
+		return feed.TripUpdatesURL
+	case sourceVehiclePositions:
+		return feed.VehiclePositionsURL
+	case sourceServiceAlerts:
+		return feed.ServiceAlertsURL
+	}
+	return ""
+}
+
+// sourceInterval returns the polling interval of one source of the feed,
+// falling back to the feed's refresh interval.
+func (feed RTFeedConfig) sourceInterval(source feedSource) time.Duration {
+	var seconds int
+	switch source {
+	case sourceTripUpdates:
+		seconds = feed.TripUpdatesInterval
+	case sourceVehiclePositions:
+		seconds = feed.VehiclePositionsInterval
+	case sourceServiceAlerts:
+		seconds = feed.ServiceAlertsInterval
+	}
+	if seconds <= 0 {
+		return feed.refreshInterval()
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sources returns the sources the feed has a URL for.
+func (feed RTFeedConfig) sources() []feedSource {
+	var sources []feedSource
+	for source := range numFeedSources {
+		if feed.sourceURL(source) != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// healthInterval is the interval feed health is judged against: the longest
+// polling interval of the feed's sources, so that a slowly polled source does
+// not make the feed look stale between its polls.
+func (feed RTFeedConfig) healthInterval() time.Duration {
+	interval := feed.refreshInterval()
+	if sources := feed.sources(); len(sources) > 0 {
+		interval = 0
+		for _, source := range sources {
+			if sourceInterval := feed.sourceInterval(source); sourceInterval > interval {
+				interval = sourceInterval
+			}
+		}
+	}
+	return interval
 }
 
 // Config holds GTFS configuration for the manager.
@@ -26,6 +125,62 @@ type Config struct {
 	Env                   appconf.Environment
 	Verbose               bool
 	EnableGTFSTidy        bool
+	StaticRefreshInterval time.Duration // how often the static feed is re-downloaded, default 24h
+	StaticDownloadTimeout time.Duration // how long a static feed download may take, default 5m
+	// ReferentialIntegrity is what imports do with rows referencing missing rows, default report
+	ReferentialIntegrity gtfsdb.IntegrityMode
+	// DBReadConnections is the size of a separate read-only pool for queries; zero shares one pool with writes
+	DBReadConnections int
+	// VehicleHistoryRetention is how long recorded vehicle positions are kept; zero disables recording
+	VehicleHistoryRetention   time.Duration
+	DeviationSmoothingSamples int // observations averaged for smoothed schedule deviation, default 5
+	// DetourThresholdMeters is how far a vehicle may be from its trip's shape before it is off-route, default 150
+	DetourThresholdMeters float64
+	// DetourConsecutiveUpdates is how many consecutive off-route positions flag a detour, default 3
+	DetourConsecutiveUpdates int
+	// ReplayDir, when set, holds recorded GTFS-RT snapshots that are replayed instead of polling RTFeeds
+	ReplayDir string
+	// ReplaySpeed is how many times faster than real time snapshots are replayed, default 1
+	ReplaySpeed float64
+	// ReplayLoop starts the replay over once its last snapshot has been played
+	ReplayLoop bool
+	// VehicleCapacityFile is a CSV file of vehicle_id,capacity rows giving the passenger capacity of vehicles
+	VehicleCapacityFile string
+	// VehicleCapacities gives the passenger capacity of vehicles by ID, overriding VehicleCapacityFile
+	VehicleCapacities map[string]int
+	// Regions are further datasets the application serves under /regions/{id}/,
+	// each loaded by a Manager of its own; a Manager ignores them
+	Regions []RegionConfig
+}
+
+// RegionConfig is the configuration of a region's dataset.
+type RegionConfig struct {
+	ID string
+	Config
+}
+
+// defaultStaticRefreshInterval is used when no static refresh interval is configured.
+const defaultStaticRefreshInterval = 24 * time.Hour
+
+// staticRefreshInterval returns the configured static refresh interval, falling back
+// to the default when unset.
+func (config Config) staticRefreshInterval() time.Duration {
+	if config.StaticRefreshInterval > 0 {
+		return config.StaticRefreshInterval
+	}
+	return defaultStaticRefreshInterval
+}
+
+// defaultStaticDownloadTimeout is used when no static download timeout is configured.
+const defaultStaticDownloadTimeout = 5 * time.Minute
+
+// staticDownloadTimeout returns the configured static download timeout, falling
+// back to the default when unset.
+func (config Config) staticDownloadTimeout() time.Duration {
+	if config.StaticDownloadTimeout > 0 {
+		return config.StaticDownloadTimeout
+	}
+	return defaultStaticDownloadTimeout
 }
 
 // enabledFeeds returns only the enabled feeds that have at least one URL configured.
@@ -0,0 +1,225 @@
+package gtfs
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// dataQualityInterval is how often the realtime data quality of every route
+// is recomputed.
+const dataQualityInterval = time.Minute
+
+// RouteDataQuality measures how well the realtime feeds cover one route at a
+// moment: how many of its trips scheduled to be under way a vehicle reports
+// serving, how many have predicted arrivals, and how recent the positions of
+// its vehicles are.
+type RouteDataQuality struct {
+	RouteID string
+	// ScheduledTrips is the number of the route's trips under way by the schedule
+	ScheduledTrips int
+	// TripsWithVehicles is how many of those a realtime vehicle is serving
+	TripsWithVehicles int
+	// PredictedTrips is how many of those have a trip update predicting their arrivals
+	PredictedTrips int
+	// Vehicles is the number of realtime vehicles serving a trip of the route
+	Vehicles int
+	// MedianPositionAge is the median age of those vehicles' positions; it is
+	// negative when none reports when its position was taken
+	MedianPositionAge time.Duration
+}
+
+// DataQuality is the realtime data quality of every route with trips under
+// way or vehicles reporting, ordered by route ID, as computed at ComputedAt.
+type DataQuality struct {
+	ComputedAt time.Time
+	Routes     []RouteDataQuality
+}
+
+// GetDataQuality returns the latest data quality computed, and false before
+// the first computation or when no realtime data is configured.
+func (manager *Manager) GetDataQuality() (DataQuality, bool) {
+	quality := manager.dataQuality.Load()
+	if quality == nil {
+		return DataQuality{}, false
+	}
+	return *quality, true
+}
+
+// runDataQuality recomputes the data quality of every route until the
+// manager shuts down.
+func (manager *Manager) runDataQuality() {
+	defer manager.wg.Done()
+	logger := slog.Default().With(slog.String("component", "data_quality"))
+
+	ticker := time.NewTicker(dataQualityInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := manager.withShutdown(context.Background(), dataQualityInterval)
+		if err := manager.updateDataQuality(ctx, manager.realtimeNow()); err != nil {
+			logging.LogError(logger, "could not compute realtime data quality", err)
+		}
+		cancel()
+
+		select {
+		case <-ticker.C:
+		case <-manager.shutdownChan:
+			return
+		}
+	}
+}
+
+// realtimeNow returns the current time of the realtime data: the replay
+// clock's while replaying, otherwise the wall clock's.
+func (manager *Manager) realtimeNow() time.Time {
+	if clock := manager.ReplayClock(); clock != nil {
+		return clock.Now()
+	}
+	return time.Now()
+}
+
+// updateDataQuality computes the data quality of every route at now and
+// stores it for GetDataQuality.
+func (manager *Manager) updateDataQuality(ctx context.Context, now time.Time) error {
+	manager.staticMutex.RLock()
+	underway, err := manager.tripsUnderway(ctx, now)
+	manager.staticMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	routes := make(map[string]*RouteDataQuality)
+	route := func(routeID string) *RouteDataQuality {
+		quality := routes[routeID]
+		if quality == nil {
+			quality = &RouteDataQuality{RouteID: routeID}
+			routes[routeID] = quality
+		}
+		return quality
+	}
+	tripRoutes := make(map[string]string, len(underway))
+	positionAges := make(map[string][]time.Duration)
+
+	manager.realTimeMutex.RLock()
+	for _, trip := range underway {
+		tripRoutes[trip.ID] = trip.RouteID
+		quality := route(trip.RouteID)
+		quality.ScheduledTrips++
+		if _, ok := manager.realTimeVehicleLookupByTrip[trip.ID]; ok {
+			quality.TripsWithVehicles++
+		}
+		if i, ok := manager.realTimeTripLookup[trip.ID]; ok && len(manager.realTimeTrips[i].StopTimeUpdates) > 0 {
+			quality.PredictedTrips++
+		}
+	}
+	var unknownRoutes []gtfs.Vehicle
+	for _, vehicle := range manager.realTimeVehicles {
+		if vehicle.Trip == nil || vehicle.Trip.ID.ID == "" {
+			continue
+		}
+		routeID := tripRoutes[vehicle.Trip.ID.ID]
+		if routeID == "" {
+			routeID = vehicle.Trip.ID.RouteID
+		}
+		if routeID == "" {
+			unknownRoutes = append(unknownRoutes, vehicle)
+			continue
+		}
+		countVehicle(route(routeID), positionAges, vehicle, now)
+	}
+	manager.realTimeMutex.RUnlock()
+
+	// Vehicles on trips outside the schedule window that do not name their
+	// route are placed by looking their trip up.
+	if len(unknownRoutes) > 0 {
+		manager.staticMutex.RLock()
+		for _, vehicle := range unknownRoutes {
+			if trip, err := manager.GtfsDB.GetTrip(ctx, vehicle.Trip.ID.ID); err == nil {
+				countVehicle(route(trip.RouteID), positionAges, vehicle, now)
+			}
+		}
+		manager.staticMutex.RUnlock()
+	}
+
+	quality := &DataQuality{ComputedAt: now, Routes: make([]RouteDataQuality, 0, len(routes))}
+	for routeID, routeQuality := range routes {
+		routeQuality.MedianPositionAge = medianDuration(positionAges[routeID])
+		quality.Routes = append(quality.Routes, *routeQuality)
+	}
+	sort.Slice(quality.Routes, func(i, j int) bool {
+		return quality.Routes[i].RouteID < quality.Routes[j].RouteID
+	})
+	manager.dataQuality.Store(quality)
+	return nil
+}
+
+// countVehicle adds a vehicle to the route's count and its position age, when
+// known, to the route's ages.
+func countVehicle(route *RouteDataQuality, positionAges map[string][]time.Duration, vehicle gtfs.Vehicle, now time.Time) {
+	route.Vehicles++
+	if vehicle.Timestamp == nil {
+		return
+	}
+	age := now.Sub(*vehicle.Timestamp)
+	if age < 0 {
+		age = 0
+	}
+	positionAges[route.RouteID] = append(positionAges[route.RouteID], age)
+}
+
+// tripsUnderway returns the trips of every service day that are under way at
+// now. Caller must hold staticMutex.
+func (manager *Manager) tripsUnderway(ctx context.Context, now time.Time) ([]gtfsdb.GetTripsUnderwayAtTimeRow, error) {
+	if manager.GtfsDB == nil {
+		return nil, nil
+	}
+	local := now.In(manager.agencyLocation())
+
+	var trips []gtfsdb.GetTripsUnderwayAtTimeRow
+	seen := make(map[string]bool)
+	for _, date := range utils.ServiceDatesAt(local, manager.maxServiceTime) {
+		serviceIDs, err := manager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, date.Format("20060102"))
+		if err != nil {
+			return nil, err
+		}
+		if len(serviceIDs) == 0 {
+			continue
+		}
+		rows, err := manager.GtfsDB.Queries.GetTripsUnderwayAtTime(ctx, gtfsdb.GetTripsUnderwayAtTimeParams{
+			CurrentTime: utils.ServiceTimeAt(date, local).Seconds(),
+			ServiceIds:  serviceIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// A trip under way on two service days at once is counted once.
+		for _, row := range rows {
+			if !seen[row.ID] {
+				seen[row.ID] = true
+				trips = append(trips, row)
+			}
+		}
+	}
+	return trips, nil
+}
+
+// medianDuration returns the median of durations, or -1 when there are none.
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return -1
+	}
+	slices.Sort(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 1 {
+		return durations[mid]
+	}
+	return (durations[mid-1] + durations[mid]) / 2
+}
@@ -0,0 +1,67 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDataQuality(t *testing.T) {
+	manager, fixture := newInferenceFixture(t)
+	_, computed := manager.GetDataQuality()
+	assert.False(t, computed, "nothing is computed without realtime feeds")
+
+	require.NoError(t, manager.MockUpdateDataQuality(fixture.now))
+	quality, computed := manager.GetDataQuality()
+	require.True(t, computed)
+	assert.Equal(t, fixture.now, quality.ComputedAt)
+	route := findRouteDataQuality(t, quality, fixture.trip.RouteID)
+	assert.Positive(t, route.ScheduledTrips)
+	assert.Zero(t, route.TripsWithVehicles)
+	assert.Zero(t, route.PredictedTrips)
+	assert.Zero(t, route.Vehicles)
+	assert.Negative(t, route.MedianPositionAge, "no vehicle reports a position")
+	scheduled := route.ScheduledTrips
+
+	observed := fixture.now.Add(-30 * time.Second)
+	manager.MockAddVehicleWithOptions("v1", fixture.trip.ID, "", MockVehicleOptions{Timestamp: &observed})
+	stopSequence := uint32(1)
+	manager.MockAddTripUpdate(fixture.trip.ID, nil, []gtfs.StopTimeUpdate{{StopSequence: &stopSequence}})
+	// A trip update without stop time updates predicts no arrivals.
+	manager.MockAddTripUpdate("not-underway", nil, nil)
+	stale := fixture.now.Add(-90 * time.Second)
+	manager.MockAddVehicleWithOptions("v2", "off-schedule", fixture.trip.RouteID, MockVehicleOptions{Timestamp: &stale})
+
+	require.NoError(t, manager.MockUpdateDataQuality(fixture.now))
+	quality, _ = manager.GetDataQuality()
+	route = findRouteDataQuality(t, quality, fixture.trip.RouteID)
+	assert.Equal(t, scheduled, route.ScheduledTrips)
+	assert.Equal(t, 1, route.TripsWithVehicles)
+	assert.Equal(t, 1, route.PredictedTrips)
+	assert.Equal(t, 2, route.Vehicles, "vehicles off the schedule still report on the route")
+	assert.Equal(t, time.Minute, route.MedianPositionAge)
+
+	for i := 1; i < len(quality.Routes); i++ {
+		assert.Less(t, quality.Routes[i-1].RouteID, quality.Routes[i].RouteID)
+	}
+}
+
+func findRouteDataQuality(t *testing.T, quality DataQuality, routeID string) RouteDataQuality {
+	t.Helper()
+	for _, route := range quality.Routes {
+		if route.RouteID == routeID {
+			return route
+		}
+	}
+	require.Failf(t, "route missing", "no data quality for route %s", routeID)
+	return RouteDataQuality{}
+}
+
+func TestMedianDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(-1), medianDuration(nil))
+	assert.Equal(t, 2*time.Second, medianDuration([]time.Duration{3 * time.Second, time.Second, 2 * time.Second}))
+	assert.Equal(t, 15*time.Second, medianDuration([]time.Duration{20 * time.Second, 10 * time.Second}))
+}
@@ -0,0 +1,246 @@
+package gtfs
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+const (
+	// defaultDetourThresholdMeters is how far a position may lie from the trip's
+	// shape before it counts as off-route, when no threshold is configured. It
+	// leaves room for GPS error and shapes drawn down the road centerline.
+	defaultDetourThresholdMeters = 150.0
+
+	// defaultDetourConsecutiveUpdates is the number of consecutive off-route
+	// positions after which a trip is flagged, when none is configured.
+	defaultDetourConsecutiveUpdates = 3
+)
+
+// detourThreshold returns the configured off-route distance, falling back to
+// the default when unset.
+func (config Config) detourThreshold() float64 {
+	if config.DetourThresholdMeters > 0 {
+		return config.DetourThresholdMeters
+	}
+	return defaultDetourThresholdMeters
+}
+
+// detourConsecutiveUpdates returns the configured number of off-route updates
+// that flag a trip, falling back to the default when unset.
+func (config Config) detourConsecutiveUpdates() int {
+	if config.DetourConsecutiveUpdates > 0 {
+		return config.DetourConsecutiveUpdates
+	}
+	return defaultDetourConsecutiveUpdates
+}
+
+// Detour describes a vehicle whose recent positions lie away from its trip's
+// scheduled shape.
+type Detour struct {
+	VehicleID string
+	TripID    string
+	RouteID   string
+	// DistanceFromShape is the distance in meters of the latest position from the shape
+	DistanceFromShape float64
+	// ConsecutiveUpdates is the number of consecutive positions off the shape
+	ConsecutiveUpdates int
+	// Since is when the first of those positions was observed
+	Since    time.Time
+	Lat, Lon float64
+}
+
+// vehicleDetour is the off-route state of one vehicle, along with the time of
+// the position it was last updated from so a repeated report is not counted twice.
+type vehicleDetour struct {
+	Detour
+	observedAt time.Time
+}
+
+// detourTracker counts, per feed and vehicle, the consecutive positions that
+// lie off the vehicle's trip shape. Only vehicles currently off the shape have
+// an entry. It is safe for concurrent use.
+type detourTracker struct {
+	mu    sync.RWMutex
+	feeds map[string]map[string]*vehicleDetour // feedID -> vehicleID -> state
+}
+
+func newDetourTracker() *detourTracker {
+	return &detourTracker{feeds: make(map[string]map[string]*vehicleDetour)}
+}
+
+// trackDetours compares a feed's vehicle positions with the shapes of their
+// trips. A vehicle missing from the update, on a new trip, or back on its
+// shape starts counting from zero again.
+func (manager *Manager) trackDetours(ctx context.Context, feedID string, vehicles []gtfs.Vehicle, now time.Time) {
+	tracker := manager.detours
+	if tracker == nil {
+		return
+	}
+
+	tracker.mu.RLock()
+	previous := tracker.feeds[feedID]
+	tracker.mu.RUnlock()
+
+	threshold := manager.config.detourThreshold()
+	current := make(map[string]*vehicleDetour)
+
+	manager.staticMutex.RLock()
+	if manager.GtfsDB != nil {
+		for _, v := range vehicles {
+			if v.ID == nil || v.ID.ID == "" || v.Trip == nil || v.Trip.ID.ID == "" ||
+				v.Position == nil || v.Position.Latitude == nil || v.Position.Longitude == nil {
+				continue
+			}
+			vehicleID, tripID := v.ID.ID, v.Trip.ID.ID
+
+			observedAt := now
+			if v.Timestamp != nil {
+				observedAt = *v.Timestamp
+			}
+			prev := previous[vehicleID]
+			if prev != nil && prev.TripID != tripID {
+				prev = nil
+			}
+			if prev != nil && !observedAt.After(prev.observedAt) {
+				current[vehicleID] = prev
+				continue
+			}
+
+			geometry, err := manager.GetShapeGeometryForTrip(ctx, tripID)
+			if err != nil {
+				slog.Warn("failed to load trip shape for detour detection",
+					slog.String("trip_id", tripID),
+					slog.Any("error", err))
+				continue
+			}
+			if geometry == nil {
+				continue
+			}
+
+			lat, lon := float64(*v.Position.Latitude), float64(*v.Position.Longitude)
+			distance := geometry.DistanceFromShape(lat, lon)
+			if distance <= threshold {
+				continue
+			}
+
+			state := &vehicleDetour{
+				Detour: Detour{
+					VehicleID:          vehicleID,
+					TripID:             tripID,
+					RouteID:            v.Trip.ID.RouteID,
+					DistanceFromShape:  distance,
+					ConsecutiveUpdates: 1,
+					Since:              observedAt,
+					Lat:                lat,
+					Lon:                lon,
+				},
+				observedAt: observedAt,
+			}
+			if prev != nil {
+				state.ConsecutiveUpdates = prev.ConsecutiveUpdates + 1
+				state.Since = prev.Since
+				if state.RouteID == "" {
+					state.RouteID = prev.RouteID
+				}
+			}
+			if state.RouteID == "" {
+				if trip, err := manager.GtfsDB.GetTrip(ctx, tripID); err == nil {
+					state.RouteID = trip.RouteID
+				}
+			}
+			current[vehicleID] = state
+		}
+	}
+	manager.staticMutex.RUnlock()
+
+	tracker.mu.Lock()
+	tracker.feeds[feedID] = current
+	tracker.mu.Unlock()
+}
+
+// GetTripDetour returns the detour of the vehicle serving a trip once it has
+// been off the trip's shape for the configured number of consecutive updates.
+func (manager *Manager) GetTripDetour(tripID string) (Detour, bool) {
+	tracker := manager.detours
+	if tracker == nil {
+		return Detour{}, false
+	}
+	minUpdates := manager.config.detourConsecutiveUpdates()
+
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+	for _, vehicles := range tracker.feeds {
+		for _, state := range vehicles {
+			if state.TripID == tripID && state.ConsecutiveUpdates >= minUpdates {
+				return state.Detour, true
+			}
+		}
+	}
+	return Detour{}, false
+}
+
+// GetDetoursForRoute returns the detours of the trips of a route, ordered by
+// trip ID.
+func (manager *Manager) GetDetoursForRoute(routeID string) []Detour {
+	tracker := manager.detours
+	if tracker == nil {
+		return nil
+	}
+	minUpdates := manager.config.detourConsecutiveUpdates()
+
+	var detours []Detour
+	tracker.mu.RLock()
+	for _, vehicles := range tracker.feeds {
+		for _, state := range vehicles {
+			if state.RouteID == routeID && state.ConsecutiveUpdates >= minUpdates {
+				detours = append(detours, state.Detour)
+			}
+		}
+	}
+	tracker.mu.RUnlock()
+
+	sort.Slice(detours, func(i, j int) bool {
+		if detours[i].TripID != detours[j].TripID {
+			return detours[i].TripID < detours[j].TripID
+		}
+		return detours[i].VehicleID < detours[j].VehicleID
+	})
+	return detours
+}
+
+// DistanceFromShape returns the distance in meters from a point to the nearest
+// segment of the shape.
+func (g *ShapeGeometry) DistanceFromShape(lat, lon float64) float64 {
+	if g == nil || len(g.Points) == 0 {
+		return math.Inf(1)
+	}
+
+	// The same local planar projection simplifyShape uses, centered on the point.
+	const metersPerDegree = 111_320.0
+	lonScale := math.Cos(lat*math.Pi/180) * metersPerDegree
+	project := func(p gtfs.ShapePoint) (float64, float64) {
+		return (p.Longitude - lon) * lonScale, (p.Latitude - lat) * metersPerDegree
+	}
+
+	if len(g.Points) == 1 {
+		x, y := project(g.Points[0])
+		return math.Hypot(x, y)
+	}
+
+	nearest := math.Inf(1)
+	x1, y1 := project(g.Points[0])
+	for _, p := range g.Points[1:] {
+		x2, y2 := project(p)
+		if d := pointSegmentDistance(0, 0, x1, y1, x2, y2); d < nearest {
+			nearest = d
+		}
+		x1, y1 = x2, y2
+	}
+	return nearest
+}
@@ -0,0 +1,93 @@
+package gtfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShapeGeometry_DistanceFromShape(t *testing.T) {
+	// A shape running north along a meridian, then east.
+	geometry := newShapeGeometry([]gtfs.ShapePoint{
+		{Latitude: 47.60, Longitude: -122.30},
+		{Latitude: 47.61, Longitude: -122.30},
+		{Latitude: 47.61, Longitude: -122.29},
+	})
+
+	assert.InDelta(t, 0, geometry.DistanceFromShape(47.605, -122.30), 0.5, "on the first segment")
+	// 0.002 degrees of longitude at this latitude is about 150 m.
+	assert.InDelta(t, 150, geometry.DistanceFromShape(47.605, -122.302), 5, "beside the first segment")
+	assert.InDelta(t, 111.3, geometry.DistanceFromShape(47.611, -122.295), 1, "north of the second segment")
+
+	var missing *ShapeGeometry
+	assert.Greater(t, missing.DistanceFromShape(47.6, -122.3), 1e9)
+}
+
+func detourTestVehicle(lat, lon float32, observedAt time.Time) gtfs.Vehicle {
+	return gtfs.Vehicle{
+		ID:        &gtfs.VehicleID{ID: "v1"},
+		Trip:      &gtfs.Trip{ID: gtfs.TripID{ID: "t1", RouteID: "r1"}},
+		Position:  &gtfs.Position{Latitude: &lat, Longitude: &lon},
+		Timestamp: &observedAt,
+	}
+}
+
+func TestTrackDetours(t *testing.T) {
+	manager := newShapeGeometryTestManager(t)
+	manager.detours = newDetourTracker()
+	manager.config.DetourConsecutiveUpdates = 2
+	manager.shapeGeometries.tripShapes["t1"] = "s1"
+	manager.shapeGeometries.geometries["s1"] = newShapeGeometry([]gtfs.ShapePoint{
+		{Latitude: 47.60, Longitude: -122.30},
+		{Latitude: 47.62, Longitude: -122.30},
+	})
+	ctx := context.Background()
+	start := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+
+	// About 750 m west of the shape.
+	offLat, offLon := float32(47.605), float32(-122.31)
+
+	manager.trackDetours(ctx, "feed", []gtfs.Vehicle{detourTestVehicle(47.605, -122.30, start)}, start)
+	_, deviated := manager.GetTripDetour("t1")
+	assert.False(t, deviated, "on the shape")
+
+	manager.trackDetours(ctx, "feed", []gtfs.Vehicle{detourTestVehicle(offLat, offLon, start.Add(30*time.Second))}, start)
+	_, deviated = manager.GetTripDetour("t1")
+	assert.False(t, deviated, "one off-route position is not yet a detour")
+
+	// The same report polled again does not count as another update.
+	manager.trackDetours(ctx, "feed", []gtfs.Vehicle{detourTestVehicle(offLat, offLon, start.Add(30*time.Second))}, start)
+	_, deviated = manager.GetTripDetour("t1")
+	assert.False(t, deviated, "a repeated report is counted once")
+
+	manager.trackDetours(ctx, "feed", []gtfs.Vehicle{detourTestVehicle(offLat, offLon, start.Add(time.Minute))}, start)
+	detour, deviated := manager.GetTripDetour("t1")
+	require.True(t, deviated)
+	assert.Equal(t, "v1", detour.VehicleID)
+	assert.Equal(t, "r1", detour.RouteID)
+	assert.Equal(t, 2, detour.ConsecutiveUpdates)
+	assert.Equal(t, start.Add(30*time.Second), detour.Since)
+	assert.Greater(t, detour.DistanceFromShape, 700.0)
+
+	detours := manager.GetDetoursForRoute("r1")
+	require.Len(t, detours, 1)
+	assert.Equal(t, "t1", detours[0].TripID)
+	assert.Empty(t, manager.GetDetoursForRoute("r2"))
+
+	// Back on the shape ends the detour.
+	manager.trackDetours(ctx, "feed", []gtfs.Vehicle{detourTestVehicle(47.61, -122.30, start.Add(90*time.Second))}, start)
+	_, deviated = manager.GetTripDetour("t1")
+	assert.False(t, deviated)
+}
+
+func TestTrackDetours_DisabledWithoutTracker(t *testing.T) {
+	manager := newTestManager()
+	manager.trackDetours(context.Background(), "feed", []gtfs.Vehicle{detourTestVehicle(47.6, -122.3, time.Now())}, time.Now())
+	_, deviated := manager.GetTripDetour("t1")
+	assert.False(t, deviated)
+	assert.Nil(t, manager.GetDetoursForRoute("r1"))
+}
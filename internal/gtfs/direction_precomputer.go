@@ -44,23 +44,34 @@ func (dp *DirectionPrecomputer) SetVarianceThreshold(threshold float64) {
 	dp.calculator.SetVarianceThreshold(threshold)
 }
 
-// PrecomputeAllDirections computes and stores directions for all stops using parallel processing
+// PrecomputeAllDirections computes and stores directions, using parallel
+// processing, for every stop whose direction has not been computed yet. After
+// an import that is every stop; when the database already holds the feed there
+// is nothing to do. Stops whose direction cannot be determined are stored with
+// an empty direction, so requests read the column instead of recomputing it.
 func (dp *DirectionPrecomputer) PrecomputeAllDirections(ctx context.Context) error {
 	startTime := time.Now()
 
 	logging.LogOperation(dp.logger, "precomputing_stop_directions_started")
 
-	// Get all stops
-	stops, err := dp.queries.ListStops(ctx)
+	stops, err := dp.queries.ListStopsWithoutDirection(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list stops: %w", err)
 	}
 
 	if len(stops) == 0 {
-		logging.LogOperation(dp.logger, "no_stops_found_skipping_precomputation")
+		logging.LogOperation(dp.logger, "no_stops_without_direction_skipping_precomputation")
 		return nil
 	}
 
+	contextCache, err := dp.loadContextCache(ctx)
+	if err != nil {
+		logging.LogError(dp.logger, "Failed to load stop shape context cache", err)
+		// Don't fail precomputation - the calculator will query each stop instead
+	} else {
+		dp.calculator.SetContextCache(contextCache)
+	}
+
 	// ===== PHASE 0: PRE-LOAD SHAPE DATA INTO CACHE =====
 	logging.LogOperation(dp.logger, "loading_shape_cache_started")
 	shapeCache, err := dp.loadShapeCache(ctx)
@@ -167,7 +178,7 @@ func (dp *DirectionPrecomputer) PrecomputeAllDirections(ctx context.Context) err
 	// ===== PHASE 3: BATCH DATABASE WRITES (sequential, avoids lock contention) =====
 	batchSize := 500
 	successCount := 0
-	skippedCount := 0
+	undeterminedCount := 0
 	errorCount := 0
 
 	// Helper function to process a single batch with proper transaction cleanup
@@ -190,17 +201,17 @@ func (dp *DirectionPrecomputer) PrecomputeAllDirections(ctx context.Context) err
 		batchSuccess := 0
 		const updateSQL = "UPDATE stops SET direction = ? WHERE id = ?"
 		for _, result := range batch {
-			if result.direction != "" {
-				_, err := tx.ExecContext(ctx, updateSQL, result.direction, result.stopID)
-				if err != nil {
-					logging.LogError(dp.logger, fmt.Sprintf("Failed to update direction for stop %s", result.stopID), err)
-					errorCount++
-					continue
-				}
-				batchSuccess++
-			} else {
-				skippedCount++
+			// An empty direction is stored too, marking the stop as computed.
+			_, err := tx.ExecContext(ctx, updateSQL, result.direction, result.stopID)
+			if err != nil {
+				logging.LogError(dp.logger, fmt.Sprintf("Failed to update direction for stop %s", result.stopID), err)
+				errorCount++
+				continue
 			}
+			if result.direction == "" {
+				undeterminedCount++
+			}
+			batchSuccess++
 		}
 
 		// Commit batch transaction
@@ -245,12 +256,33 @@ func (dp *DirectionPrecomputer) PrecomputeAllDirections(ctx context.Context) err
 		slog.Duration("duration", duration),
 		slog.Int("total_stops", len(stops)),
 		slog.Int("successful", successCount),
-		slog.Int("skipped", skippedCount),
+		slog.Int("undetermined", undeterminedCount),
 		slog.Int("errors", errorCount))
 
 	return nil
 }
 
+// loadContextCache loads the shape context of every stop without a direction
+// in one query, keyed by stop ID, in the form GetStopsWithShapeContext returns.
+func (dp *DirectionPrecomputer) loadContextCache(ctx context.Context) (map[string][]gtfsdb.GetStopsWithShapeContextRow, error) {
+	rows, err := dp.queries.GetShapeContextForStopsWithoutDirection(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stop shape context: %w", err)
+	}
+
+	contextCache := make(map[string][]gtfsdb.GetStopsWithShapeContextRow)
+	for _, row := range rows {
+		contextCache[row.StopID] = append(contextCache[row.StopID], gtfsdb.GetStopsWithShapeContextRow{
+			ID:                row.StopID,
+			Lat:               row.Lat,
+			Lon:               row.Lon,
+			ShapeDistTraveled: row.ShapeDistTraveled,
+			ShapeID:           row.ShapeID,
+		})
+	}
+	return contextCache, nil
+}
+
 // loadShapeCache loads all shape data from the database and organizes it by shape_id
 // for efficient lookup during direction precomputation
 func (dp *DirectionPrecomputer) loadShapeCache(ctx context.Context) (map[string][]gtfsdb.GetShapePointsWithDistanceRow, error) {
@@ -275,6 +307,17 @@ func (dp *DirectionPrecomputer) loadShapeCache(ctx context.Context) (map[string]
 		shapeCache[shape.ShapeID] = append(shapeCache[shape.ShapeID], shapePoint)
 	}
 
+	// Shapes with the same points as another share its stored points
+	geometries, err := dp.queries.GetShapeGeometries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shape geometries: %w", err)
+	}
+	for _, geometry := range geometries {
+		if geometry.ShapeID != geometry.GeometryID {
+			shapeCache[geometry.ShapeID] = shapeCache[geometry.GeometryID]
+		}
+	}
+
 	return shapeCache, nil
 }
 
@@ -0,0 +1,55 @@
+package gtfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestPrecomputeAllDirectionsStoresEveryStop(t *testing.T) {
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath: ":memory:",
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	ctx := context.Background()
+	queries := manager.GtfsDB.Queries
+
+	// Loading the feed precomputed every stop, including undetermined ones.
+	remaining, err := queries.ListStopsWithoutDirection(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	stops, err := queries.ListStops(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, stops)
+
+	withDirection := 0
+	for _, stop := range stops {
+		assert.True(t, stop.Direction.Valid, "stop %s", stop.ID)
+		if stop.Direction.String != "" {
+			withDirection++
+		}
+	}
+	assert.NotZero(t, withDirection)
+
+	// Stored directions are what requests get, and match computing from shapes.
+	fresh := NewAdvancedDirectionCalculator(queries)
+	for _, stop := range stops[:10] {
+		assert.Equal(t, fresh.CalculateStopDirection(ctx, stop.ID), fresh.CalculateStopDirection(ctx, stop.ID, stop.Direction), "stop %s", stop.ID)
+	}
+
+	// A new stop is picked up by the next pass; the others are left alone.
+	_, err = manager.GtfsDB.DB.ExecContext(ctx, "UPDATE stops SET direction = NULL WHERE id = ?", stops[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, NewDirectionPrecomputer(queries, manager.GtfsDB.DB).PrecomputeAllDirections(ctx))
+
+	stop, err := queries.GetStop(ctx, stops[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, stops[0].Direction, stop.Direction)
+}
@@ -0,0 +1,280 @@
+package gtfs
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// feedStaleIntervals is the number of refresh intervals a feed may go without a
+	// successful fetch before its realtime data is considered stale.
+	feedStaleIntervals = 3
+
+	// maxFeedBackoff caps the delay between retries of a failing feed.
+	maxFeedBackoff = 5 * time.Minute
+
+	// feedBackoffJitter is the fraction by which each retry delay is randomly
+	// shortened or lengthened, so that feeds hosted together do not retry in lockstep.
+	feedBackoffJitter = 0.2
+)
+
+// FeedHealth describes the fetch state of a single GTFS-RT feed.
+type FeedHealth struct {
+	FeedID              string    `json:"feedId"`
+	LastAttempt         time.Time `json:"lastAttempt"`
+	LastSuccess         time.Time `json:"lastSuccess"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	NextAttempt         time.Time `json:"nextAttempt"`
+	Stale               bool      `json:"stale"`
+}
+
+type feedHealthState struct {
+	health   FeedHealth
+	interval time.Duration
+	// sources holds the fetch state of each source the feed has been polled
+	// from, so that one broken source does not fail a feed whose other
+	// sources work.
+	sources [numFeedSources]*sourceHealth
+}
+
+// sourceHealth is the fetch state of one source of a feed.
+type sourceHealth struct {
+	failures  int
+	lastError string
+}
+
+// feedHealthTracker records fetch outcomes per feed and derives retry delays and
+// staleness from them. A nil tracker records nothing and reports every feed healthy.
+type feedHealthTracker struct {
+	mu    sync.RWMutex
+	feeds map[string]*feedHealthState
+}
+
+func newFeedHealthTracker() *feedHealthTracker {
+	return &feedHealthTracker{feeds: make(map[string]*feedHealthState)}
+}
+
+func (t *feedHealthTracker) state(feedID string, interval time.Duration) *feedHealthState {
+	s, ok := t.feeds[feedID]
+	if !ok {
+		s = &feedHealthState{health: FeedHealth{FeedID: feedID}}
+		t.feeds[feedID] = s
+	}
+	s.interval = interval
+	return s
+}
+
+// recordSuccess marks a successful fetch and returns the delay until the next poll.
+func (t *feedHealthTracker) recordSuccess(feedID string, interval time.Duration, now time.Time) time.Duration {
+	if t == nil {
+		return interval
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state(feedID, interval).succeed(now)
+}
+
+// recordFailure marks a failed fetch and returns the backoff delay until the next attempt.
+func (t *feedHealthTracker) recordFailure(feedID string, interval time.Duration, err error, now time.Time) time.Duration {
+	if t == nil {
+		return interval
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	return t.state(feedID, interval).fail(message, now)
+}
+
+// recordFetch records the outcome of every source a poll fetched and returns
+// the delay until the feed's next poll, along with the consecutive failures of
+// each fetched source. The feed only counts as failing while none of the
+// sources it has been polled from succeeds, so a permanently broken source
+// does not flap the health of a feed whose other sources work. A poll that
+// fetched no source is a failure.
+func (t *feedHealthTracker) recordFetch(feedID string, interval time.Duration, fetch *feedFetch, now time.Time) (time.Duration, [numFeedSources]int) {
+	var sourceFailures [numFeedSources]int
+	if t == nil {
+		return interval, sourceFailures
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.state(feedID, interval)
+	polled, succeeded := false, false
+	for source := range numFeedSources {
+		if fetch.data[source] == nil && fetch.errs[source] == nil {
+			continue
+		}
+		polled = true
+		if s.sources[source] == nil {
+			s.sources[source] = &sourceHealth{}
+		}
+		sh := s.sources[source]
+		if err := fetch.errs[source]; err != nil {
+			sh.failures++
+			sh.lastError = err.Error()
+		} else {
+			sh.failures = 0
+			sh.lastError = ""
+			succeeded = true
+		}
+		sourceFailures[source] = sh.failures
+	}
+
+	switch {
+	case succeeded:
+		return s.succeed(now), sourceFailures
+	case !polled || s.allSourcesFailing():
+		return s.fail(s.sourceErrors(), now), sourceFailures
+	default:
+		// The source that failed is not the feed's only working one.
+		s.health.LastAttempt = now
+		return retryDelay(interval, s.health.ConsecutiveFailures), sourceFailures
+	}
+}
+
+func (s *feedHealthState) succeed(now time.Time) time.Duration {
+	s.health.LastAttempt = now
+	s.health.LastSuccess = now
+	s.health.ConsecutiveFailures = 0
+	s.health.LastError = ""
+	s.health.NextAttempt = now.Add(s.interval)
+	return s.interval
+}
+
+func (s *feedHealthState) fail(message string, now time.Time) time.Duration {
+	s.health.LastAttempt = now
+	s.health.ConsecutiveFailures++
+	if message != "" {
+		s.health.LastError = message
+	}
+
+	delay := retryDelay(s.interval, s.health.ConsecutiveFailures)
+	s.health.NextAttempt = now.Add(delay)
+	return delay
+}
+
+// allSourcesFailing reports whether every source polled so far failed on its
+// last poll.
+func (s *feedHealthState) allSourcesFailing() bool {
+	for _, sh := range s.sources {
+		if sh != nil && sh.failures == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceErrors joins the last errors of the failing sources, in source order.
+func (s *feedHealthState) sourceErrors() string {
+	var messages []string
+	for source, sh := range s.sources {
+		if sh != nil && sh.failures > 0 && sh.lastError != "" {
+			messages = append(messages, feedSource(source).String()+": "+sh.lastError)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// isStale reports whether a feed has failed for longer than feedStaleIntervals
+// refresh intervals. Feeds that have never been attempted are not stale.
+func (s *feedHealthState) isStale(now time.Time) bool {
+	if s.health.ConsecutiveFailures == 0 {
+		return false
+	}
+	if s.health.LastSuccess.IsZero() {
+		return true
+	}
+	return now.Sub(s.health.LastSuccess) > feedStaleIntervals*s.interval
+}
+
+func (t *feedHealthTracker) staleFeeds(now time.Time) map[string]bool {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var stale map[string]bool
+	for id, s := range t.feeds {
+		if s.isStale(now) {
+			if stale == nil {
+				stale = make(map[string]bool)
+			}
+			stale[id] = true
+		}
+	}
+	return stale
+}
+
+func (t *feedHealthTracker) snapshot(now time.Time) []FeedHealth {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]FeedHealth, 0, len(t.feeds))
+	for _, s := range t.feeds {
+		h := s.health
+		h.Stale = s.isStale(now)
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].FeedID < result[j].FeedID })
+	return result
+}
+
+// feedBackoff doubles the refresh interval for every consecutive failure after the
+// first, capped at maxFeedBackoff. The cap never shortens the regular interval.
+func feedBackoff(interval time.Duration, failures int) time.Duration {
+	limit := maxFeedBackoff
+	if interval > limit {
+		limit = interval
+	}
+	delay := interval
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
+
+// retryDelay returns how long to wait before the next poll after the given
+// number of consecutive failures: the interval itself while there are none,
+// otherwise the jittered backoff.
+func retryDelay(interval time.Duration, failures int) time.Duration {
+	if failures == 0 {
+		return interval
+	}
+	return jitterDelay(feedBackoff(interval, failures), rand.Float64())
+}
+
+// jitterDelay spreads delay by up to ±feedBackoffJitter; r is a uniform sample in [0, 1).
+func jitterDelay(delay time.Duration, r float64) time.Duration {
+	factor := 1 + feedBackoffJitter*(2*r-1)
+	return time.Duration(float64(delay) * factor)
+}
+
+// FeedHealthStatus returns the fetch state of every realtime feed that has been
+// polled at least once, ordered by feed ID.
+func (manager *Manager) FeedHealthStatus() []FeedHealth {
+	return manager.feedHealth.snapshot(time.Now())
+}
+
+// IsRealtimeDegraded reports whether any realtime feed is currently stale. While a
+// feed is stale its trip updates and vehicle positions are withheld, so affected
+// arrivals fall back to scheduled times.
+func (manager *Manager) IsRealtimeDegraded() bool {
+	return len(manager.feedHealth.staleFeeds(time.Now())) > 0
+}
@@ -0,0 +1,262 @@
+package gtfs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedBackoff(t *testing.T) {
+	interval := 30 * time.Second
+
+	assert.Equal(t, interval, feedBackoff(interval, 1))
+	assert.Equal(t, 60*time.Second, feedBackoff(interval, 2))
+	assert.Equal(t, 240*time.Second, feedBackoff(interval, 4))
+	assert.Equal(t, maxFeedBackoff, feedBackoff(interval, 10))
+
+	// Feeds polled less often than the cap keep their own interval.
+	assert.Equal(t, 10*time.Minute, feedBackoff(10*time.Minute, 5))
+}
+
+func TestJitterDelay(t *testing.T) {
+	assert.Equal(t, 80*time.Second, jitterDelay(100*time.Second, 0))
+	assert.Equal(t, 100*time.Second, jitterDelay(100*time.Second, 0.5))
+	assert.InDelta(t, float64(120*time.Second), float64(jitterDelay(100*time.Second, 0.999999)), float64(time.Millisecond))
+}
+
+func TestFeedHealthTracker_Staleness(t *testing.T) {
+	tracker := newFeedHealthTracker()
+	interval := 30 * time.Second
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tracker.recordSuccess("feed-a", interval, now)
+	assert.Empty(t, tracker.staleFeeds(now))
+
+	// A failure inside the staleness window keeps serving the last good data.
+	delay := tracker.recordFailure("feed-a", interval, errors.New("boom"), now.Add(time.Minute))
+	assert.GreaterOrEqual(t, delay, time.Duration(float64(interval)*(1-feedBackoffJitter)))
+	assert.Empty(t, tracker.staleFeeds(now.Add(time.Minute)))
+
+	tracker.recordFailure("feed-a", interval, errors.New("boom"), now.Add(2*time.Minute))
+	assert.Equal(t, map[string]bool{"feed-a": true}, tracker.staleFeeds(now.Add(2*time.Minute)))
+
+	// A feed that has never succeeded is stale after its first failure.
+	tracker.recordFailure("feed-b", interval, nil, now)
+
+	health := tracker.snapshot(now.Add(2 * time.Minute))
+	require.Len(t, health, 2)
+	assert.Equal(t, "feed-a", health[0].FeedID)
+	assert.Equal(t, 2, health[0].ConsecutiveFailures)
+	assert.Equal(t, "boom", health[0].LastError)
+	assert.Equal(t, now, health[0].LastSuccess)
+	assert.True(t, health[0].Stale)
+	assert.True(t, health[1].Stale)
+
+	tracker.recordSuccess("feed-a", interval, now.Add(3*time.Minute))
+	assert.Equal(t, map[string]bool{"feed-b": true}, tracker.staleFeeds(now.Add(3*time.Minute)))
+}
+
+func TestFeedHealthTracker_NilIsHealthy(t *testing.T) {
+	var tracker *feedHealthTracker
+	assert.Equal(t, time.Minute, tracker.recordFailure("feed", time.Minute, errors.New("boom"), time.Now()))
+	assert.Nil(t, tracker.staleFeeds(time.Now()))
+	assert.Nil(t, tracker.snapshot(time.Now()))
+}
+
+func TestUpdateFeedRealtime_WithholdsStaleFeedData(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	manager := newTestManager()
+	manager.feedHealth = newFeedHealthTracker()
+	feed := RTFeedConfig{ID: "feed-a", VehiclePositionsURL: server.URL, RefreshInterval: 30, Enabled: true}
+	ctx := context.Background()
+
+	next := manager.updateFeedRealtime(ctx, feed)
+	assert.Equal(t, 30*time.Second, next)
+	require.NotEmpty(t, manager.GetRealTimeVehicles())
+
+	// Pretend the last success was long ago, then fail.
+	manager.feedHealth.feeds["feed-a"].health.LastSuccess = time.Now().Add(-10 * time.Minute)
+	failing.Store(true)
+	manager.updateFeedRealtime(ctx, feed)
+
+	assert.True(t, manager.IsRealtimeDegraded())
+	assert.Empty(t, manager.GetRealTimeVehicles(), "stale vehicles should not be served")
+	assert.NotEmpty(t, manager.feedVehicles["feed-a"], "per-feed data is retained for recovery")
+
+	health := manager.FeedHealthStatus()
+	require.Len(t, health, 1)
+	assert.True(t, health[0].Stale)
+	assert.Contains(t, health[0].LastError, "503")
+
+	failing.Store(false)
+	manager.updateFeedRealtime(ctx, feed)
+	assert.False(t, manager.IsRealtimeDegraded())
+	assert.NotEmpty(t, manager.GetRealTimeVehicles())
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(30*time.Second, 0))
+
+	delay := retryDelay(30*time.Second, 3)
+	assert.GreaterOrEqual(t, delay, 96*time.Second)
+	assert.LessOrEqual(t, delay, 144*time.Second)
+}
+
+func TestUpdateFeedSourceRealtime_LeavesOtherSourcesUntouched(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trip-updates", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("../../testdata", "raba-trip-updates.pb"))
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/vehicle-positions", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/service-alerts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := newTestManager()
+	manager.feedHealth = newFeedHealthTracker()
+	feed := RTFeedConfig{
+		ID:                  "feed-a",
+		TripUpdatesURL:      server.URL + "/trip-updates",
+		VehiclePositionsURL: server.URL + "/vehicle-positions",
+		ServiceAlertsURL:    server.URL + "/service-alerts",
+		Enabled:             true,
+	}
+	ctx := context.Background()
+
+	_, err := manager.updateFeedSourceRealtime(ctx, feed, sourceVehiclePositions)
+	require.NoError(t, err)
+	assert.NotEmpty(t, manager.GetRealTimeVehicles())
+	assert.Empty(t, manager.GetRealTimeTrips(), "trip updates are polled by their own poller")
+
+	_, err = manager.updateFeedSourceRealtime(ctx, feed, sourceTripUpdates)
+	require.NoError(t, err)
+	assert.NotEmpty(t, manager.GetRealTimeTrips())
+
+	// A failing alerts source keeps the data of the other sources.
+	_, err = manager.updateFeedSourceRealtime(ctx, feed, sourceServiceAlerts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.NotEmpty(t, manager.GetRealTimeVehicles())
+	assert.NotEmpty(t, manager.GetRealTimeTrips())
+	assert.False(t, manager.IsRealtimeDegraded())
+}
+
+func TestUpdateFeedSourceRealtime_BrokenSourceDoesNotFlapFeedHealth(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vehicle-positions", func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/service-alerts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := newTestManager()
+	manager.feedHealth = newFeedHealthTracker()
+	feed := RTFeedConfig{
+		ID:                       "feed-a",
+		VehiclePositionsURL:      server.URL + "/vehicle-positions",
+		ServiceAlertsURL:         server.URL + "/service-alerts",
+		VehiclePositionsInterval: 30,
+		ServiceAlertsInterval:    60,
+		Enabled:                  true,
+	}
+	ctx := context.Background()
+
+	var alertDelays []time.Duration
+	for range 3 {
+		_, err := manager.updateFeedSourceRealtime(ctx, feed, sourceVehiclePositions)
+		require.NoError(t, err)
+		delay, err := manager.updateFeedSourceRealtime(ctx, feed, sourceServiceAlerts)
+		require.Error(t, err)
+		alertDelays = append(alertDelays, delay)
+
+		health := manager.FeedHealthStatus()
+		require.Len(t, health, 1)
+		assert.Zero(t, health[0].ConsecutiveFailures, "the feed's vehicle positions still work")
+		assert.Empty(t, health[0].LastError)
+		assert.False(t, health[0].Stale)
+	}
+
+	// The broken source backs off on its own.
+	assert.GreaterOrEqual(t, alertDelays[2], time.Duration(float64(4*time.Minute)*(1-feedBackoffJitter)))
+
+	// Once every source fails, so does the feed.
+	server.Close()
+	_, err := manager.updateFeedSourceRealtime(ctx, feed, sourceVehiclePositions)
+	require.Error(t, err)
+	health := manager.FeedHealthStatus()
+	assert.Equal(t, 1, health[0].ConsecutiveFailures)
+	assert.Contains(t, health[0].LastError, "service_alerts: ")
+	assert.Contains(t, health[0].LastError, "vehicle_positions: ")
+}
+
+func TestPollFeedSource_PollsSourcesIndependently(t *testing.T) {
+	var vehicleHits, alertHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vehicle-positions", func(w http.ResponseWriter, r *http.Request) {
+		vehicleHits.Add(1)
+		data, err := os.ReadFile(filepath.Join("../../testdata", "raba-vehicle-positions.pb"))
+		require.NoError(t, err)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/service-alerts", func(w http.ResponseWriter, r *http.Request) {
+		alertHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := newTestManager()
+	manager.shutdownChan = make(chan struct{})
+	feed := RTFeedConfig{
+		ID:                       "feed-a",
+		VehiclePositionsURL:      server.URL + "/vehicle-positions",
+		ServiceAlertsURL:         server.URL + "/service-alerts",
+		VehiclePositionsInterval: 1,
+		ServiceAlertsInterval:    60,
+		Enabled:                  true,
+	}
+
+	for _, source := range feed.sources() {
+		manager.wg.Add(1)
+		go manager.pollFeedSource(feed, source)
+	}
+
+	assert.Eventually(t, func() bool { return vehicleHits.Load() >= 2 }, 5*time.Second, 50*time.Millisecond)
+	assert.Zero(t, alertHits.Load(), "alerts are polled on their own, slower schedule")
+
+	close(manager.shutdownChan)
+	manager.wg.Wait()
+}
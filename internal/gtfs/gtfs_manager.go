@@ -43,6 +43,7 @@ type Manager struct {
 	realTimeTripLookup             map[string]int
 	realTimeVehicleLookupByTrip    map[string]int
 	realTimeVehicleLookupByVehicle map[string]int
+	realTimeInferredTrips          map[string]string // Trips inferred for vehicles reporting none, by vehicle ID
 	agenciesMap                    map[string]*gtfs.Agency
 	routesMap                      map[string]*gtfs.Route
 	staticUpdateMutex              sync.Mutex   // Protects against concurrent ForceUpdate calls
@@ -54,15 +55,30 @@ type Manager struct {
 	stopSpatialIndex               *rtree.RTree
 	blockLayoverIndices            map[string][]*BlockLayoverIndex
 	regionBounds                   *RegionBounds
+	agencyCoverage                 map[string]AgencyCoverage
+	maxServiceTime                 utils.ServiceTime   // Latest stop time in the feed
+	shapeGeometries                *shapeGeometryCache // Lazily filled; replaced on hot-swap
+	feedHealth                     *feedHealthTracker  // Nil disables backoff and staleness tracking
+	detours                        *detourTracker      // Nil disables detour detection
 	isHealthy                      bool
 	systemETag                     string      // systemETag stores the SHA-256 hash of the currently loaded GTFS static dataset.
 	isReady                        atomic.Bool // Tracks whether initial data loading is complete
+	realtimeNotifier               realtimeNotifier
+	dataGeneration                 atomic.Uint64               // Bumped on static reloads and assignment changes; see DataGeneration
+	vehicleAssignments             vehicleAssignmentStore      // Dispatcher overrides of GTFS-RT vehicle-to-trip matching
+	replay                         *realtimeReplay             // Nil unless recorded snapshots replace the live feeds
+	vehicleCapacities              map[string]int              // Passenger capacity by vehicle ID; read-only after init
+	dataQuality                    atomic.Pointer[DataQuality] // Latest realtime data quality; nil until computed
 
 	feedTrips    map[string][]gtfs.Trip
 	feedVehicles map[string][]gtfs.Vehicle
 	feedAlerts   map[string][]gtfs.Alert
 	// Per-feed, per-vehicle last-seen timestamps for stale vehicle expiry
 	feedVehicleLastSeen map[string]map[string]time.Time // feedID -> vehicleID -> lastSeen
+	// Per-feed trips inferred for vehicles that report none
+	feedInferredTrips map[string]map[string]string // feedID -> vehicleID -> tripID
+	// Per-feed generation time of each source, ranking feeds that publish the same trip or vehicle
+	feedTimestamps map[string]*[numFeedSources]time.Time
 }
 
 // IsReady returns true if the GTFS data is fully initialized and indexed.
@@ -80,7 +96,16 @@ func (manager *Manager) MarkReady() {
 func InitGTFSManager(config Config) (*Manager, error) {
 	isLocalFile := !strings.HasPrefix(config.GtfsURL, "http://") && !strings.HasPrefix(config.GtfsURL, "https://")
 
-	staticData, err := loadGTFSData(config.GtfsURL, isLocalFile, config)
+	vehicleCapacities, err := loadVehicleCapacities(config)
+	if err != nil {
+		return nil, fmt.Errorf("error loading vehicle capacities: %w", err)
+	}
+
+	feed, err := fetchStaticGTFS(context.Background(), config.GtfsURL, isLocalFile, config, gtfsdb.FeedValidators{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading GTFS data: %w", err)
+	}
+	staticData, err := parseGTFSData(feed, config)
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +121,14 @@ func InitGTFSManager(config Config) (*Manager, error) {
 		feedVehicles:                   make(map[string][]gtfs.Vehicle),
 		feedAlerts:                     make(map[string][]gtfs.Alert),
 		feedVehicleLastSeen:            make(map[string]map[string]time.Time),
+		shapeGeometries:                newShapeGeometryCache(),
+		feedHealth:                     newFeedHealthTracker(),
+		detours:                        newDetourTracker(),
+		vehicleCapacities:              vehicleCapacities,
 	}
 	manager.setStaticGTFS(staticData)
 
-	gtfsDB, err := buildGtfsDB(config, isLocalFile, "")
+	gtfsDB, err := buildGtfsDB(config, feed, "")
 	if err != nil {
 		return nil, fmt.Errorf("error building GTFS database: %w", err)
 	}
@@ -122,8 +151,20 @@ func InitGTFSManager(config Config) (*Manager, error) {
 
 	// STARTUP SEQUENCING:
 	// If realtime is enabled, perform the first fetch synchronously for each feed
-	// to "warm" the cache before marking the manager as ready.
+	// to "warm" the cache before marking the manager as ready. A replay takes
+	// the place of the live feeds and starts with its first snapshots.
 	enabledFeeds := config.enabledFeeds()
+	replayNext := 0
+	if config.ReplayDir != "" {
+		replay, err := newRealtimeReplay(config)
+		if err != nil {
+			_ = gtfsDB.Close()
+			return nil, fmt.Errorf("error loading realtime replay: %w", err)
+		}
+		manager.replay = replay
+		enabledFeeds = nil
+		replayNext = manager.applyReplaySnapshots(ctx, 0)
+	}
 	for _, feedCfg := range enabledFeeds {
 		initCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		manager.updateFeedRealtime(initCtx, feedCfg)
@@ -139,10 +180,22 @@ func InitGTFSManager(config Config) (*Manager, error) {
 		go manager.updateStaticGTFS()
 	}
 
-	// Start one poller goroutine per enabled feed
+	if manager.replay != nil {
+		manager.wg.Add(1)
+		go manager.runReplay(replayNext)
+	}
+
+	// Start one poller goroutine per source of every enabled feed
 	for _, feedCfg := range enabledFeeds {
+		for _, source := range feedCfg.sources() {
+			manager.wg.Add(1)
+			go manager.pollFeedSource(feedCfg, source)
+		}
+	}
+
+	if len(enabledFeeds) > 0 || manager.replay != nil {
 		manager.wg.Add(1)
-		go manager.pollFeed(feedCfg)
+		go manager.runDataQuality()
 	}
 
 	return manager, nil
@@ -159,16 +212,38 @@ func (manager *Manager) SetGtfsURL(url string) {
 
 // Shutdown gracefully shuts down the manager and its background goroutines
 func (manager *Manager) Shutdown() {
+	_ = manager.ShutdownContext(context.Background())
+}
+
+// ShutdownContext shuts down the manager like Shutdown: it cancels realtime
+// fetches in progress, lets the position history writes already under way
+// finish, and waits for the background goroutines until ctx is done before
+// closing the database. It returns an error if ctx ended the wait.
+func (manager *Manager) ShutdownContext(ctx context.Context) error {
+	var err error
 	manager.shutdownOnce.Do(func() {
 		close(manager.shutdownChan)
-		manager.wg.Wait()
+
+		stopped := make(chan struct{})
+		go func() {
+			manager.wg.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			err = fmt.Errorf("background goroutines still running at shutdown: %w", ctx.Err())
+		}
+
+		manager.realtimeNotifier.close()
 		if manager.GtfsDB != nil {
-			if err := manager.GtfsDB.Close(); err != nil {
+			if closeErr := manager.GtfsDB.Close(); closeErr != nil {
 				logger := slog.Default().With(slog.String("component", "gtfs_manager"))
-				logging.LogError(logger, "failed to close GTFS database", err)
+				logging.LogError(logger, "failed to close GTFS database", closeErr)
 			}
 		}
 	})
+	return err
 }
 
 // RLock acquires the static data read lock.
@@ -206,6 +281,13 @@ func (manager *Manager) GetBlockLayoverIndicesForRoute(routeID string) []*BlockL
 	return getBlockLayoverIndicesForRoute(manager.blockLayoverIndices, routeID)
 }
 
+// MaxServiceTime returns the latest arrival or departure time in the feed,
+// which exceeds 24 hours when trips run past midnight.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) MaxServiceTime() utils.ServiceTime {
+	return manager.maxServiceTime
+}
+
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (manager *Manager) FindAgency(id string) *gtfs.Agency {
 	if agency, ok := manager.agenciesMap[id]; ok {
@@ -237,13 +319,8 @@ func (manager *Manager) RoutesForAgencyID(agencyID string) []*gtfs.Route {
 	return []*gtfs.Route{}
 }
 
-type stopWithDistance struct {
-	stop     gtfsdb.Stop
-	distance float64
-}
-
 // GetStopsForLocation retrieves stops near a given location using the spatial index.
-// It supports filtering by route types and querying for specific stop codes.
+// It supports filtering by route types and querying stops by code or name.
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (manager *Manager) GetStopsForLocation(
 	ctx context.Context,
@@ -254,7 +331,32 @@ func (manager *Manager) GetStopsForLocation(
 	routeTypes []int,
 	queryTime time.Time,
 ) []gtfsdb.Stop {
-	var candidates []stopWithDistance
+	ranked := manager.GetRankedStopsForLocation(ctx, lat, lon, radius, latSpan, lonSpan, query, maxCount, isForRoutes, routeTypes, false, queryTime)
+	var stops []gtfsdb.Stop
+	for _, candidate := range ranked {
+		stops = append(stops, candidate.Stop)
+	}
+	return stops
+}
+
+// GetRankedStopsForLocation is GetStopsForLocation with each stop's distance
+// and, when query is set, its score. A query matches stops by code or name;
+// they are ordered by a score combining how well they match, how near they are
+// and how many routes serve them. Without a query stops are ordered by
+// distance. wheelchairAccessible keeps only the stops whose wheelchair_boarding,
+// or their station's, says a wheelchair can board.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetRankedStopsForLocation(
+	ctx context.Context,
+	lat, lon, radius, latSpan, lonSpan float64,
+	query string,
+	maxCount int,
+	isForRoutes bool,
+	routeTypes []int,
+	wheelchairAccessible bool,
+	queryTime time.Time,
+) []RankedStop {
+	var candidates []RankedStop
 
 	var bounds utils.CoordinateBounds
 
@@ -273,24 +375,20 @@ func (manager *Manager) GetStopsForLocation(
 
 	// Check if context is already cancelled
 	if ctx.Err() != nil {
-		return []gtfsdb.Stop{}
+		return []RankedStop{}
 	}
 
 	dbStops := queryStopsInBounds(manager.stopSpatialIndex, bounds)
 
 	for _, dbStop := range dbStops {
 		if ctx.Err() != nil {
-			return []gtfsdb.Stop{}
+			return []RankedStop{}
 		}
-
-		if query != "" && !isForRoutes {
-			if dbStop.Code.Valid && dbStop.Code.String == query {
-				return []gtfsdb.Stop{dbStop}
-			}
+		if wheelchairAccessible && utils.NullWheelchairBoardingOrUnknown(dbStop.WheelchairBoarding) != gtfs.WheelchairBoarding_Possible {
 			continue
 		}
 		distance := utils.Distance(lat, lon, dbStop.Lat, dbStop.Lon)
-		candidates = append(candidates, stopWithDistance{dbStop, distance})
+		candidates = append(candidates, RankedStop{Stop: dbStop, Distance: distance})
 	}
 
 	// If the stop does not have any routes actively serving it, don't include it in the results
@@ -299,36 +397,27 @@ func (manager *Manager) GetStopsForLocation(
 		if len(routeTypes) > 0 {
 			stopIDs := make([]string, 0, len(candidates))
 			for _, candidate := range candidates {
-				stopIDs = append(stopIDs, candidate.stop.ID)
+				stopIDs = append(stopIDs, candidate.Stop.ID)
+			}
+
+			types := make([]int64, len(routeTypes))
+			for i, rt := range routeTypes {
+				types[i] = int64(rt)
 			}
 
-			routesForStops, err := manager.GtfsDB.Queries.GetRoutesForStops(ctx, stopIDs)
+			routesForStops, err := manager.GtfsDB.Queries.GetRoutesForStopsWithRouteTypes(ctx, gtfsdb.GetRoutesForStopsWithRouteTypesParams{
+				StopIds:    stopIDs,
+				RouteTypes: types,
+			})
 			if err == nil {
-				stopRouteTypes := make(map[string][]int)
+				servedStops := make(map[string]bool)
 				for _, r := range routesForStops {
-					stopRouteTypes[r.StopID] = append(stopRouteTypes[r.StopID], int(r.Type))
+					servedStops[r.StopID] = true
 				}
 
-				filteredCandidates := make([]stopWithDistance, 0, len(candidates))
+				filteredCandidates := make([]RankedStop, 0, len(candidates))
 				for _, candidate := range candidates {
-					if ctx.Err() != nil {
-						return []gtfsdb.Stop{}
-					}
-
-					types := stopRouteTypes[candidate.stop.ID]
-					hasMatchingType := false
-					for _, rt := range types {
-						for _, targetType := range routeTypes {
-							if rt == targetType {
-								hasMatchingType = true
-								break
-							}
-						}
-						if hasMatchingType {
-							break
-						}
-					}
-					if hasMatchingType {
+					if servedStops[candidate.Stop.ID] {
 						filteredCandidates = append(filteredCandidates, candidate)
 					}
 				}
@@ -351,7 +440,7 @@ func (manager *Manager) GetStopsForLocation(
 			if err == nil && len(activeServiceIDs) > 0 {
 				stopIDs := make([]string, 0, len(candidates))
 				for _, candidate := range candidates {
-					stopIDs = append(stopIDs, candidate.stop.ID)
+					stopIDs = append(stopIDs, candidate.Stop.ID)
 				}
 
 				stopsWithActiveService, err := manager.GtfsDB.Queries.GetStopsWithActiveServiceOnDate(ctx, gtfsdb.GetStopsWithActiveServiceOnDateParams{
@@ -365,13 +454,13 @@ func (manager *Manager) GetStopsForLocation(
 						stopsWithService[stopID] = true
 					}
 
-					filteredCandidates := make([]stopWithDistance, 0, len(candidates))
+					filteredCandidates := make([]RankedStop, 0, len(candidates))
 					for _, candidate := range candidates {
 						if ctx.Err() != nil {
-							return []gtfsdb.Stop{}
+							return []RankedStop{}
 						}
 
-						if stopsWithService[candidate.stop.ID] {
+						if stopsWithService[candidate.Stop.ID] {
 							filteredCandidates = append(filteredCandidates, candidate)
 						}
 					}
@@ -381,19 +470,21 @@ func (manager *Manager) GetStopsForLocation(
 		}
 	}
 
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].distance < candidates[j].distance
-	})
+	if query != "" && !isForRoutes {
+		candidates = manager.rankStops(ctx, candidates, query, searchRadius(lat, lon, radius, latSpan, lonSpan))
+	} else {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Distance < candidates[j].Distance
+		})
+	}
 
 	// When isForRoutes is true, return all matching stops without applying maxCount limit.
 	// This prevents artificially limiting route results when the stop count would truncate
 	// routes that exist at stops beyond the maxCount threshold.
-	var stops []gtfsdb.Stop
-	for i := 0; i < len(candidates) && (i < maxCount || isForRoutes); i++ {
-		stops = append(stops, candidates[i].stop)
+	if !isForRoutes && len(candidates) > maxCount {
+		candidates = candidates[:maxCount]
 	}
-
-	return stops
+	return candidates
 }
 
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
@@ -418,7 +509,8 @@ func (manager *Manager) VehiclesForAgencyID(agencyID string) []gtfs.Vehicle {
 
 // GetVehicleForTrip retrieves a vehicle for a specific trip ID or finds the first vehicle that is part of the block
 // for that trip. Note we depend on getting the vehicle that may not match the trip ID exactly,
-// but is part of the same block.
+// but is part of the same block. A dispatcher's vehicle assignment covering the trip takes
+// precedence, and vehicles assigned to other trips are skipped.
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (manager *Manager) GetVehicleForTrip(ctx context.Context, tripID string) *gtfs.Vehicle {
 
@@ -427,31 +519,39 @@ func (manager *Manager) GetVehicleForTrip(ctx context.Context, tripID string) *g
 
 	logger := slog.Default().With(slog.String("component", "gtfs_manager"))
 
-	requestedTrip, err := manager.GtfsDB.Queries.GetTrip(ctx, tripID)
+	requestedTrip, err := manager.GtfsDB.GetTrip(ctx, tripID)
 	if err != nil {
 		logging.LogError(logger, "could not get trip", err,
 			slog.String("trip_id", tripID))
 		return nil
 	}
 
-	if !requestedTrip.BlockID.Valid {
-		logger.Debug("trip has no block ID, cannot find vehicle by block",
-			slog.String("trip_id", tripID))
-		return nil
-	}
-
+	now := time.Now()
 	requestedBlockID := requestedTrip.BlockID.String
 
-	blockTrips, err := manager.GtfsDB.Queries.GetTripsByBlockID(ctx, requestedTrip.BlockID)
-	if err != nil {
-		logging.LogError(logger, "could not get trips for block", err,
-			slog.String("block_id", requestedBlockID))
-		return nil
+	blockTripIDs := make(map[string]bool)
+	if requestedTrip.BlockID.Valid {
+		blockTrips, err := manager.GtfsDB.Queries.GetTripsByBlockID(ctx, requestedTrip.BlockID)
+		if err != nil {
+			logging.LogError(logger, "could not get trips for block", err,
+				slog.String("block_id", requestedBlockID))
+			return nil
+		}
+		for _, trip := range blockTrips {
+			blockTripIDs[trip.ID] = true
+		}
 	}
 
-	blockTripIDs := make(map[string]bool)
-	for _, trip := range blockTrips {
-		blockTripIDs[trip.ID] = true
+	if assignment, ok := manager.assignmentForTrip(tripID, requestedBlockID, now); ok {
+		manager.realTimeMutex.RLock()
+		defer manager.realTimeMutex.RUnlock()
+		return manager.assignedVehicle(assignment, tripID, blockTripIDs)
+	}
+
+	if !requestedTrip.BlockID.Valid {
+		logger.Debug("trip has no block ID, cannot find vehicle by block",
+			slog.String("trip_id", tripID))
+		return nil
 	}
 
 	manager.realTimeMutex.RLock()
@@ -462,6 +562,9 @@ func (manager *Manager) GetVehicleForTrip(ctx context.Context, tripID string) *g
 	// match against any trip in the block, not a specific trip ID.
 	for _, v := range manager.realTimeVehicles {
 		if v.Trip != nil && v.Trip.ID.ID != "" && blockTripIDs[v.Trip.ID.ID] {
+			if v.ID != nil && manager.assignedElsewhere(v.ID.ID, tripID, requestedBlockID, now) {
+				continue
+			}
 			vehicle := v
 			return &vehicle
 		}
@@ -585,6 +688,14 @@ func (manager *Manager) GetSystemETag() string {
 	return manager.systemETag
 }
 
+// LastStaticUpdate returns when the static GTFS data in use was loaded, or the
+// zero time if none has been.
+func (manager *Manager) LastStaticUpdate() time.Time {
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+	return manager.lastUpdated
+}
+
 // IsHealthy returns true if the GTFS data is loaded and valid.
 func (manager *Manager) IsHealthy() bool {
 	manager.staticMutex.RLock()
@@ -592,6 +703,17 @@ func (manager *Manager) IsHealthy() bool {
 	return manager.isHealthy
 }
 
+// EntityCacheStats reports the lookups of the static database's trip, route
+// and stop caches since the database was opened, or nil without one.
+func (manager *Manager) EntityCacheStats() map[string]gtfsdb.EntityCacheStats {
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+	if manager.GtfsDB == nil {
+		return nil
+	}
+	return manager.GtfsDB.EntityCacheStats()
+}
+
 // MarkHealthy sets the manager status to healthy.
 func (manager *Manager) MarkHealthy() {
 	manager.staticMutex.Lock()
@@ -1,9 +1,11 @@
 package gtfs
 
 import (
+	"context"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/utils"
 )
 
 func (m *Manager) MockAddAgency(id, name string) {
@@ -56,6 +58,7 @@ func (m *Manager) MockAddVehicle(vehicleID, tripID, routeID string) {
 	if tripID != "" {
 		m.realTimeVehicleLookupByTrip[tripID] = idx
 	}
+	m.realtimeNotifier.notify()
 }
 
 type MockVehicleOptions struct {
@@ -63,6 +66,12 @@ type MockVehicleOptions struct {
 	CurrentStopSequence *uint32
 	StopID              *string
 	CurrentStatus       *gtfs.CurrentStatus
+	// Timestamp is when the vehicle reported; it defaults to now.
+	Timestamp           *time.Time
+	OccupancyStatus     *gtfs.OccupancyStatus
+	OccupancyPercentage *uint32
+	// TripAssignmentInferred marks the trip as inferred rather than reported.
+	TripAssignmentInferred bool
 }
 
 func (m *Manager) MockAddVehicleWithOptions(vehicleID, tripID, routeID string, opts MockVehicleOptions) {
@@ -74,10 +83,14 @@ func (m *Manager) MockAddVehicleWithOptions(vehicleID, tripID, routeID string, o
 			return
 		}
 	}
-	now := time.Now()
+	timestamp := opts.Timestamp
+	if timestamp == nil {
+		now := time.Now()
+		timestamp = &now
+	}
 	v := gtfs.Vehicle{
 		ID:        &gtfs.VehicleID{ID: vehicleID},
-		Timestamp: &now,
+		Timestamp: timestamp,
 		Trip: &gtfs.Trip{
 			ID: gtfs.TripID{
 				ID:      tripID,
@@ -88,6 +101,8 @@ func (m *Manager) MockAddVehicleWithOptions(vehicleID, tripID, routeID string, o
 		CurrentStopSequence: opts.CurrentStopSequence,
 		StopID:              opts.StopID,
 		CurrentStatus:       opts.CurrentStatus,
+		OccupancyStatus:     opts.OccupancyStatus,
+		OccupancyPercentage: opts.OccupancyPercentage,
 	}
 	m.realTimeVehicles = append(m.realTimeVehicles, v)
 
@@ -96,6 +111,13 @@ func (m *Manager) MockAddVehicleWithOptions(vehicleID, tripID, routeID string, o
 	if tripID != "" {
 		m.realTimeVehicleLookupByTrip[tripID] = idx
 	}
+	if opts.TripAssignmentInferred {
+		if m.realTimeInferredTrips == nil {
+			m.realTimeInferredTrips = make(map[string]string)
+		}
+		m.realTimeInferredTrips[vehicleID] = tripID
+	}
+	m.realtimeNotifier.notify()
 }
 
 func (m *Manager) MockAddTrip(tripID, agencyID, routeID string) {
@@ -111,22 +133,37 @@ func (m *Manager) MockAddTrip(tripID, agencyID, routeID string) {
 }
 
 func (m *Manager) MockAddTripUpdate(tripID string, delay *time.Duration, stopTimeUpdates []gtfs.StopTimeUpdate) {
-	m.realTimeMutex.Lock()
-	defer m.realTimeMutex.Unlock()
-
-	trip := gtfs.Trip{
+	m.MockAddRealtimeTrip(gtfs.Trip{
 		ID:              gtfs.TripID{ID: tripID},
 		Delay:           delay,
 		StopTimeUpdates: stopTimeUpdates,
-	}
+	})
+}
+
+// MockAddRealtimeTrip adds a complete trip update, for tests that need fields
+// MockAddTripUpdate does not set, such as the schedule relationship.
+func (m *Manager) MockAddRealtimeTrip(trip gtfs.Trip) {
+	m.realTimeMutex.Lock()
+	defer m.realTimeMutex.Unlock()
+
 	m.realTimeTrips = append(m.realTimeTrips, trip)
 	if m.realTimeTripLookup == nil {
 		m.realTimeTripLookup = make(map[string]int)
 	}
-	m.realTimeTripLookup[tripID] = len(m.realTimeTrips) - 1
+	m.realTimeTripLookup[trip.ID.ID] = len(m.realTimeTrips) - 1
+	m.realtimeNotifier.notify()
 }
 
-// MockResetRealTimeData clears all mock real-time vehicles and trip updates.
+func (m *Manager) MockAddAlert(alert gtfs.Alert) {
+	m.realTimeMutex.Lock()
+	defer m.realTimeMutex.Unlock()
+
+	m.realTimeAlerts = append(m.realTimeAlerts, alert)
+	m.realtimeNotifier.notify()
+}
+
+// MockResetRealTimeData clears all mock real-time vehicles, trip updates and
+// alerts, and the data quality computed from them.
 func (m *Manager) MockResetRealTimeData() {
 	m.realTimeMutex.Lock()
 	defer m.realTimeMutex.Unlock()
@@ -134,6 +171,51 @@ func (m *Manager) MockResetRealTimeData() {
 	m.realTimeVehicles = nil
 	m.realTimeVehicleLookupByVehicle = make(map[string]int)
 	m.realTimeVehicleLookupByTrip = make(map[string]int)
+	m.realTimeInferredTrips = nil
 	m.realTimeTrips = nil
 	m.realTimeTripLookup = make(map[string]int)
+	m.realTimeAlerts = nil
+	if m.detours != nil {
+		m.detours = newDetourTracker()
+	}
+	m.dataQuality.Store(nil)
+	m.realtimeNotifier.notify()
+}
+
+// MockAddDetour records a vehicle as off its trip's shape, as detour detection
+// would after the detour's ConsecutiveUpdates off-route positions.
+func (m *Manager) MockAddDetour(detour Detour) {
+	if m.detours == nil {
+		m.detours = newDetourTracker()
+	}
+	m.detours.mu.Lock()
+	defer m.detours.mu.Unlock()
+
+	if m.detours.feeds["mock"] == nil {
+		m.detours.feeds["mock"] = make(map[string]*vehicleDetour)
+	}
+	m.detours.feeds["mock"][detour.VehicleID] = &vehicleDetour{Detour: detour, observedAt: detour.Since}
+	m.dataGeneration.Add(1)
+}
+
+// MockSetMaxServiceTime overrides the latest stop time of the feed, for tests
+// that add stop times past it. It returns the previous value.
+func (m *Manager) MockSetMaxServiceTime(maxServiceTime utils.ServiceTime) utils.ServiceTime {
+	previous := m.maxServiceTime
+	m.maxServiceTime = maxServiceTime
+	return previous
+}
+
+// MockSetVehicleCapacity configures the passenger capacity of a vehicle.
+func (m *Manager) MockSetVehicleCapacity(vehicleID string, capacity int) {
+	if m.vehicleCapacities == nil {
+		m.vehicleCapacities = make(map[string]int)
+	}
+	m.vehicleCapacities[vehicleID] = capacity
+}
+
+// MockUpdateDataQuality computes the realtime data quality of every route at
+// now, as the manager does every minute while realtime feeds are configured.
+func (m *Manager) MockUpdateDataQuality(now time.Time) error {
+	return m.updateDataQuality(context.Background(), now)
 }
@@ -0,0 +1,139 @@
+package gtfs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+// minHistoricalOccupancySamples is the number of observations a trip/stop/weekday
+// needs before its historical occupancy is reported.
+const minHistoricalOccupancySamples = 3
+
+// agencyLocation returns the timezone of the feed's first agency, or UTC.
+// Caller must hold staticMutex.
+func (manager *Manager) agencyLocation() *time.Location {
+	if manager.gtfsData == nil || len(manager.gtfsData.Agencies) == 0 {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(manager.gtfsData.Agencies[0].Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// isOccupancyLevel reports whether status describes how full a vehicle is, as
+// opposed to NO_DATA_AVAILABLE or NOT_BOARDABLE, which are not aggregated.
+func isOccupancyLevel(status gtfs.OccupancyStatus) bool {
+	return status >= gtfsrt.VehiclePosition_EMPTY && status <= gtfsrt.VehiclePosition_NOT_ACCEPTING_PASSENGERS
+}
+
+// occupancyServiceDate returns the service date an observation belongs to: the
+// trip's start date when the feed provides one, otherwise the local date of the
+// observation. Using the service date keeps after-midnight trips on the weekday
+// they were scheduled for.
+func occupancyServiceDate(v gtfs.Vehicle, observedAt time.Time, loc *time.Location) time.Time {
+	if v.Trip != nil && !v.Trip.ID.StartDate.IsZero() {
+		return v.Trip.ID.StartDate
+	}
+	return observedAt.In(loc)
+}
+
+// OccupancyHistory holds the typical occupancy at each stop of a trip on one
+// weekday, keyed by stop ID. Stops with fewer than minHistoricalOccupancySamples
+// observations are left out.
+type OccupancyHistory map[string]gtfs.OccupancyStatus
+
+// StatusAt returns the typical occupancy at a stop as a GTFS-RT OccupancyStatus
+// name, or "" when the stop has no history.
+func (history OccupancyHistory) StatusAt(stopID string) string {
+	if status, ok := history[stopID]; ok {
+		return status.String()
+	}
+	return ""
+}
+
+// GetHistoricalOccupancyForTrip returns the typical occupancy at each stop of a
+// trip on the weekday of serviceDate, keyed by stop ID. The value is the GTFS-RT
+// OccupancyStatus name (e.g. "MANY_SEATS_AVAILABLE") observed most often; stops
+// with fewer than minHistoricalOccupancySamples observations are omitted.
+// Returns nil when history recording is disabled.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetHistoricalOccupancyForTrip(ctx context.Context, tripID string, serviceDate time.Time) map[string]string {
+	history := manager.GetOccupancyHistoryForTrip(ctx, tripID, serviceDate)
+	if history == nil {
+		return nil
+	}
+	return history.names()
+}
+
+// GetOccupancyHistoryForTrip returns the most often observed occupancy at each
+// stop of a trip on the weekday of serviceDate. Returns nil when history
+// recording is disabled.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetOccupancyHistoryForTrip(ctx context.Context, tripID string, serviceDate time.Time) OccupancyHistory {
+	if !manager.config.historyEnabled() || manager.GtfsDB == nil {
+		return nil
+	}
+
+	rows, err := manager.GtfsDB.Queries.GetHistoricalOccupancyForTrip(ctx, gtfsdb.GetHistoricalOccupancyForTripParams{
+		TripID:    tripID,
+		DayOfWeek: int64(serviceDate.Weekday()),
+	})
+	if err != nil {
+		slog.Warn("failed to load historical occupancy",
+			slog.String("trip_id", tripID),
+			slog.Any("error", err))
+		return nil
+	}
+
+	return modalOccupancy(rows)
+}
+
+// summarizeHistoricalOccupancy names the most frequent status per stop.
+func summarizeHistoricalOccupancy(rows []gtfsdb.GetHistoricalOccupancyForTripRow) map[string]string {
+	return modalOccupancy(rows).names()
+}
+
+func (history OccupancyHistory) names() map[string]string {
+	result := make(map[string]string, len(history))
+	for stopID, status := range history {
+		result[stopID] = status.String()
+	}
+	return result
+}
+
+// modalOccupancy picks the most frequent status per stop. Ties go to the fuller
+// status so riders are not promised seats that often are not there.
+func modalOccupancy(rows []gtfsdb.GetHistoricalOccupancyForTripRow) OccupancyHistory {
+	type modal struct {
+		status, count, total int64
+	}
+
+	byStop := make(map[string]*modal)
+	for _, row := range rows {
+		m, ok := byStop[row.StopID]
+		if !ok {
+			m = &modal{status: row.OccupancyStatus}
+			byStop[row.StopID] = m
+		}
+		m.total += row.SampleCount
+		if row.SampleCount > m.count || (row.SampleCount == m.count && row.OccupancyStatus > m.status) {
+			m.status, m.count = row.OccupancyStatus, row.SampleCount
+		}
+	}
+
+	history := make(OccupancyHistory, len(byStop))
+	for stopID, m := range byStop {
+		if m.total < minHistoricalOccupancySamples {
+			continue
+		}
+		history[stopID] = gtfs.OccupancyStatus(m.status)
+	}
+	return history
+}
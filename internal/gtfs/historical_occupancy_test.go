@@ -0,0 +1,85 @@
+package gtfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+func occupancyVehicle(vehicleID, tripID, stopID string, status gtfs.OccupancyStatus, observedAt time.Time) gtfs.Vehicle {
+	v := historyVehicle(vehicleID, tripID, observedAt)
+	v.StopID = &stopID
+	v.OccupancyStatus = &status
+	return v
+}
+
+func TestGetHistoricalOccupancyForTrip(t *testing.T) {
+	manager := newHistoryTestManager(t, time.Hour, 0)
+	ctx := context.Background()
+
+	// Mondays, one week apart. Raw history is pruned, the aggregate is not.
+	monday := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	statuses := []gtfs.OccupancyStatus{
+		gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE,
+		gtfsrt.VehiclePosition_STANDING_ROOM_ONLY,
+		gtfsrt.VehiclePosition_STANDING_ROOM_ONLY,
+		gtfsrt.VehiclePosition_NO_DATA_AVAILABLE,
+	}
+	for week, status := range statuses {
+		observedAt := monday.AddDate(0, 0, 7*week)
+		vehicles := []gtfs.Vehicle{occupancyVehicle("bus1", "trip1", "stopA", status, observedAt)}
+		manager.recordVehiclePositions(ctx, "feed-0", vehicles, nil, observedAt)
+		// A repeated report with the same timestamp is not counted again.
+		manager.recordVehiclePositions(ctx, "feed-0", vehicles, nil, observedAt)
+	}
+
+	// A single Tuesday observation at another stop is below the sample threshold.
+	tuesday := monday.AddDate(0, 0, 1)
+	manager.recordVehiclePositions(ctx, "feed-0",
+		[]gtfs.Vehicle{occupancyVehicle("bus1", "trip1", "stopB", gtfsrt.VehiclePosition_FULL, tuesday)}, nil, tuesday)
+
+	occupancy := manager.GetHistoricalOccupancyForTrip(ctx, "trip1", monday.AddDate(0, 0, 35))
+	assert.Equal(t, map[string]string{"stopA": "STANDING_ROOM_ONLY"}, occupancy)
+
+	assert.Empty(t, manager.GetHistoricalOccupancyForTrip(ctx, "trip1", tuesday))
+	assert.Empty(t, manager.GetHistoricalOccupancyForTrip(ctx, "other-trip", monday))
+}
+
+func TestGetHistoricalOccupancyForTrip_Disabled(t *testing.T) {
+	manager := newHistoryTestManager(t, 0, 0)
+	assert.Nil(t, manager.GetHistoricalOccupancyForTrip(context.Background(), "trip1", time.Now()))
+}
+
+func TestSummarizeHistoricalOccupancy(t *testing.T) {
+	rows := []gtfsdb.GetHistoricalOccupancyForTripRow{
+		{StopID: "a", OccupancyStatus: int64(gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE), SampleCount: 2},
+		{StopID: "a", OccupancyStatus: int64(gtfsrt.VehiclePosition_FEW_SEATS_AVAILABLE), SampleCount: 2},
+		{StopID: "b", OccupancyStatus: int64(gtfsrt.VehiclePosition_EMPTY), SampleCount: 5},
+		{StopID: "c", OccupancyStatus: int64(gtfsrt.VehiclePosition_FULL), SampleCount: 2},
+	}
+
+	summary := summarizeHistoricalOccupancy(rows)
+	require.Len(t, summary, 2)
+	assert.Equal(t, "FEW_SEATS_AVAILABLE", summary["a"], "ties resolve to the fuller status")
+	assert.Equal(t, "EMPTY", summary["b"])
+	assert.NotContains(t, summary, "c")
+}
+
+func TestOccupancyServiceDate(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	// 00:30 Tuesday local, on a trip that started Monday.
+	observedAt := time.Date(2026, 3, 3, 8, 30, 0, 0, time.UTC)
+	v := historyVehicle("bus1", "trip1", observedAt)
+	assert.Equal(t, time.Tuesday, occupancyServiceDate(v, observedAt, loc).Weekday())
+
+	v.Trip.ID.StartDate = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Monday, occupancyServiceDate(v, observedAt, loc).Weekday())
+}
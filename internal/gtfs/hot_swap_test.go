@@ -3,15 +3,21 @@ package gtfs
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 )
@@ -308,3 +314,171 @@ func TestHotSwap_ConcurrentForceUpdate(t *testing.T) {
 		t.Error("Agencies should not be empty after update")
 	}
 }
+
+func TestStaticRefresh_SwapsOnInterval(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows: SQLite file I/O is too slow for CI timeout")
+	}
+
+	// Served without validators, the feed is downloaded again on every refresh.
+	feed, err := os.ReadFile(models.GetFixturePath(t, "raba.zip"))
+	require.NoError(t, err)
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		_, _ = w.Write(feed)
+	}))
+	defer server.Close()
+
+	gtfsConfig := Config{
+		GtfsURL:               server.URL + "/gtfs.zip",
+		GTFSDataPath:          t.TempDir() + "/gtfs.db",
+		Env:                   appconf.Development,
+		StaticRefreshInterval: 500 * time.Millisecond,
+	}
+
+	manager, err := InitGTFSManager(gtfsConfig)
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	lastUpdated := func() time.Time {
+		manager.RLock()
+		defer manager.RUnlock()
+		return manager.lastUpdated
+	}
+	initial := lastUpdated()
+	initialDownloads := downloads.Load()
+
+	assert.Eventually(t, func() bool { return lastUpdated().After(initial) }, 30*time.Second, 50*time.Millisecond,
+		"background refresh should hot-swap the static data")
+	assert.Greater(t, downloads.Load(), initialDownloads)
+
+	manager.RLock()
+	defer manager.RUnlock()
+	agencies, err := manager.GtfsDB.Queries.ListAgencies(context.Background())
+	require.NoError(t, err)
+	require.Len(t, agencies, 1)
+	assert.Equal(t, "25", agencies[0].ID)
+}
+
+func TestForceUpdate_SkipsUnchangedFeed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows: SQLite file I/O is too slow for CI timeout")
+	}
+
+	var downloads, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"raba-1"`)
+		if r.Header.Get("If-None-Match") == `"raba-1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		http.ServeFile(w, r, models.GetFixturePath(t, "raba.zip"))
+	}))
+	defer server.Close()
+
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      server.URL + "/gtfs.zip",
+		GTFSDataPath: t.TempDir() + "/gtfs.db",
+		Env:          appconf.Development,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	validators, err := manager.GtfsDB.ImportValidators(context.Background(), server.URL+"/gtfs.zip")
+	require.NoError(t, err)
+	assert.Equal(t, `"raba-1"`, validators.ETag)
+	assert.NotEmpty(t, validators.LastModified)
+
+	manager.RLock()
+	lastUpdated, generation := manager.lastUpdated, manager.dataGeneration.Load()
+	manager.RUnlock()
+
+	require.NoError(t, manager.ForceUpdate(context.Background()))
+
+	assert.Equal(t, int32(1), downloads.Load(), "the unchanged feed is not downloaded again")
+	assert.Equal(t, int32(1), notModified.Load())
+	manager.RLock()
+	defer manager.RUnlock()
+	assert.Equal(t, lastUpdated, manager.lastUpdated)
+	assert.Equal(t, generation, manager.dataGeneration.Load())
+	assert.NotEmpty(t, manager.GetAgencies())
+}
+
+func TestForceUpdate_KeepsScheduleDeviationSamples(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows: SQLite file I/O is too slow for CI timeout")
+	}
+
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:                 models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath:            t.TempDir() + "/gtfs.db",
+		Env:                     appconf.Development,
+		VehicleHistoryRetention: time.Hour,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	ctx := context.Background()
+	require.NoError(t, manager.GtfsDB.Queries.UpsertScheduleDeviationSample(ctx, gtfsdb.UpsertScheduleDeviationSampleParams{
+		TripID:      "trip1",
+		StopID:      "stopA",
+		ServiceDate: "20260302",
+		Deviation:   90,
+		ObservedAt:  time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC).UnixMilli(),
+	}))
+
+	manager.SetGtfsURL(models.GetFixturePath(t, "gtfs.zip"))
+	require.NoError(t, manager.ForceUpdate(ctx))
+
+	manager.RLock()
+	defer manager.RUnlock()
+	assert.Equal(t, "40", manager.GetAgencies()[0].Id, "the new feed was swapped in")
+	var deviation int64
+	require.NoError(t, manager.GtfsDB.DB.QueryRowContext(ctx,
+		"SELECT deviation FROM schedule_deviation_samples WHERE trip_id = 'trip1' AND stop_id = 'stopA'").Scan(&deviation))
+	assert.Equal(t, int64(90), deviation)
+}
+
+func TestForceUpdate_KeepsHistoricalOccupancy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping on Windows: SQLite file I/O is too slow for CI timeout")
+	}
+
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:                 models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath:            t.TempDir() + "/gtfs.db",
+		Env:                     appconf.Development,
+		VehicleHistoryRetention: time.Hour,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	ctx := context.Background()
+	monday := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	for week := 0; week < minHistoricalOccupancySamples; week++ {
+		observedAt := monday.AddDate(0, 0, 7*week)
+		manager.recordVehiclePositions(ctx, "feed-0", []gtfs.Vehicle{
+			occupancyVehicle("bus1", "trip1", "stopA", gtfsrt.VehiclePosition_FULL, observedAt),
+		}, nil, observedAt)
+	}
+
+	manager.SetGtfsURL(models.GetFixturePath(t, "gtfs.zip"))
+	require.NoError(t, manager.ForceUpdate(ctx))
+
+	assert.Equal(t, "40", manager.GetAgencies()[0].Id, "the new feed was swapped in")
+	occupancy := manager.GetHistoricalOccupancyForTrip(ctx, "trip1", monday)
+	assert.Equal(t, map[string]string{"stopA": "FULL"}, occupancy)
+}
+
+func TestConfigStaticRefreshInterval(t *testing.T) {
+	assert.Equal(t, 24*time.Hour, Config{}.staticRefreshInterval())
+	assert.Equal(t, time.Hour, Config{StaticRefreshInterval: time.Hour}.staticRefreshInterval())
+}
+
+func TestConfigStaticDownloadTimeout(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, Config{}.staticDownloadTimeout())
+	assert.Equal(t, time.Minute, Config{StaticDownloadTimeout: time.Minute}.staticDownloadTimeout())
+}
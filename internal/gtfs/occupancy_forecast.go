@@ -0,0 +1,50 @@
+package gtfs
+
+import (
+	"math"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+)
+
+// occupancyForecastDecay is the share of a vehicle's deviation from its usual
+// occupancy that is expected to remain one stop further on. Passengers board
+// and alight at every stop, so a bus that is unusually full now is only
+// somewhat fuller than usual a few stops later.
+const occupancyForecastDecay = 0.8
+
+// occupancyRealtimeOnlyStops is how many stops ahead a vehicle's current
+// occupancy stands in for a stop that has no history.
+const occupancyRealtimeOnlyStops = 3
+
+// ForecastOccupancy predicts how full a trip's vehicle will be at stopID,
+// stopsAway stops ahead of the vehicle, as a GTFS-RT OccupancyStatus name.
+//
+// The usual occupancy at the stop is shifted by how much fuller or emptier than
+// usual the vehicle is at currentStopID, and that shift fades by
+// occupancyForecastDecay per stop. Without a current occupancy level, or once
+// the vehicle has passed the stop (stopsAway < 0), the usual occupancy is the
+// forecast; without history the current occupancy is used for the next
+// occupancyRealtimeOnlyStops stops. Returns "" when neither is known.
+func (history OccupancyHistory) ForecastOccupancy(current *gtfs.OccupancyStatus, currentStopID, stopID string, stopsAway int) string {
+	usual, hasUsual := history[stopID]
+	if current == nil || !isOccupancyLevel(*current) || stopsAway < 0 {
+		return history.StatusAt(stopID)
+	}
+	if !hasUsual {
+		if stopsAway <= occupancyRealtimeOnlyStops {
+			return current.String()
+		}
+		return ""
+	}
+
+	baseline, ok := history[currentStopID]
+	if !ok {
+		baseline = usual
+	}
+	shift := float64(*current-baseline) * math.Pow(occupancyForecastDecay, float64(stopsAway))
+	level := math.Round(float64(usual) + shift)
+	level = math.Max(level, float64(gtfsrt.VehiclePosition_EMPTY))
+	level = math.Min(level, float64(gtfsrt.VehiclePosition_NOT_ACCEPTING_PASSENGERS))
+	return gtfs.OccupancyStatus(level).String()
+}
@@ -0,0 +1,108 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecastOccupancy(t *testing.T) {
+	status := func(s gtfs.OccupancyStatus) *gtfs.OccupancyStatus { return &s }
+
+	history := OccupancyHistory{
+		"current": gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE,
+		"target":  gtfsrt.VehiclePosition_FEW_SEATS_AVAILABLE,
+	}
+
+	tests := []struct {
+		name          string
+		history       OccupancyHistory
+		current       *gtfs.OccupancyStatus
+		currentStopID string
+		stopsAway     int
+		expected      string
+	}{
+		{
+			name:     "history alone without a realtime status",
+			history:  history,
+			expected: "FEW_SEATS_AVAILABLE",
+		},
+		{
+			name:     "no data is not an occupancy level",
+			history:  history,
+			current:  status(gtfsrt.VehiclePosition_NO_DATA_AVAILABLE),
+			expected: "FEW_SEATS_AVAILABLE",
+		},
+		{
+			name:      "vehicle already past the stop",
+			history:   history,
+			current:   status(gtfsrt.VehiclePosition_FULL),
+			stopsAway: -1,
+			expected:  "FEW_SEATS_AVAILABLE",
+		},
+		{
+			name:          "as usual now stays as usual",
+			history:       history,
+			current:       status(gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE),
+			currentStopID: "current",
+			stopsAway:     2,
+			expected:      "FEW_SEATS_AVAILABLE",
+		},
+		{
+			name:          "fuller than usual nearby",
+			history:       history,
+			current:       status(gtfsrt.VehiclePosition_STANDING_ROOM_ONLY),
+			currentStopID: "current",
+			stopsAway:     1,
+			expected:      "CRUSHED_STANDING_ROOM_ONLY",
+		},
+		{
+			name:          "fuller than usual fades with distance",
+			history:       history,
+			current:       status(gtfsrt.VehiclePosition_STANDING_ROOM_ONLY),
+			currentStopID: "current",
+			stopsAway:     8,
+			expected:      "FEW_SEATS_AVAILABLE",
+		},
+		{
+			name:          "current stop without history compares with the target",
+			history:       history,
+			current:       status(gtfsrt.VehiclePosition_EMPTY),
+			currentStopID: "elsewhere",
+			stopsAway:     0,
+			expected:      "EMPTY",
+		},
+		{
+			name:          "forecast stays within the occupancy levels",
+			history:       OccupancyHistory{"current": gtfsrt.VehiclePosition_EMPTY, "target": gtfsrt.VehiclePosition_FULL},
+			current:       status(gtfsrt.VehiclePosition_NOT_ACCEPTING_PASSENGERS),
+			currentStopID: "current",
+			stopsAway:     0,
+			expected:      "NOT_ACCEPTING_PASSENGERS",
+		},
+		{
+			name:      "realtime alone for nearby stops",
+			current:   status(gtfsrt.VehiclePosition_STANDING_ROOM_ONLY),
+			stopsAway: 3,
+			expected:  "STANDING_ROOM_ONLY",
+		},
+		{
+			name:      "realtime alone is not used far ahead",
+			current:   status(gtfsrt.VehiclePosition_STANDING_ROOM_ONLY),
+			stopsAway: 4,
+			expected:  "",
+		},
+		{
+			name:     "nothing known",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.history.ForecastOccupancy(tt.current, tt.currentStopID, "target", tt.stopsAway))
+		})
+	}
+}
@@ -38,7 +38,7 @@ func newRealtimeHTTPClient() *http.Client {
 
 	return &http.Client{
 		// Timeout acts as an absolute safety net per request. The caller in
-		// pollFeed also sets a 15s context timeout; the stricter of the two
+		// pollFeedSource also sets a 15s context timeout; the stricter of the two
 		// wins. Keep this <= the context timeout so the client enforces the
 		// bound even if a caller forgets a context.
 		Timeout:   10 * time.Second,
@@ -61,6 +61,13 @@ func (manager *Manager) GetRealTimeVehicles() []gtfs.Vehicle {
 	return manager.realTimeVehicles
 }
 
+// GetRealTimeAlerts returns the merged service alerts from all realtime feeds.
+func (manager *Manager) GetRealTimeAlerts() []gtfs.Alert {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+	return manager.realTimeAlerts
+}
+
 func (manager *Manager) GetAlertsForRoute(routeID string) []gtfs.Alert {
 	manager.realTimeMutex.RLock()
 	defer manager.realTimeMutex.RUnlock()
@@ -114,10 +121,10 @@ func (manager *Manager) GetAlertsForTrip(ctx context.Context, tripID string) []g
 	var agencyID string
 
 	if manager.GtfsDB != nil {
-		trip, err := manager.GtfsDB.Queries.GetTrip(ctx, tripID)
+		trip, err := manager.GtfsDB.GetTrip(ctx, tripID)
 		if err == nil {
 			routeID = trip.RouteID
-			route, err := manager.GtfsDB.Queries.GetRoute(ctx, routeID)
+			route, err := manager.GtfsDB.GetRoute(ctx, routeID)
 			if err == nil {
 				agencyID = route.AgencyID
 			} else if !errors.Is(err, sql.ErrNoRows) {
@@ -156,6 +163,98 @@ func (manager *Manager) GetAlertsForStop(stopID string) []gtfs.Alert {
 	return alerts
 }
 
+// GetAlertByID returns the realtime alert with the given feed entity ID.
+// It acquires the realTimeMutex internally; callers must NOT hold it.
+func (manager *Manager) GetAlertByID(alertID string) (gtfs.Alert, bool) {
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+
+	for _, alert := range manager.realTimeAlerts {
+		if alert.ID == alertID {
+			return alert, true
+		}
+	}
+	return gtfs.Alert{}, false
+}
+
+// GetAlertsForAgency returns the alerts affecting an agency: those naming it,
+// or one of its routes, trips or the stops its routes serve.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetAlertsForAgency(ctx context.Context, agencyID string) []gtfs.Alert {
+	agencyRoutes := make(map[string]bool)
+	for _, route := range manager.RoutesForAgencyID(agencyID) {
+		agencyRoutes[route.Id] = true
+	}
+
+	candidates := manager.GetRealTimeAlerts()
+
+	// Trips and stops are resolved to agencies through the static data, in
+	// one query per kind rather than one per entity.
+	tripRoutes := make(map[string]string)
+	stopAgencies := make(map[string]map[string]bool)
+	if manager.GtfsDB != nil {
+		var tripIDs, stopIDs []string
+		for _, alert := range candidates {
+			for _, entity := range alert.InformedEntities {
+				if entity.TripID != nil && entity.TripID.ID != "" && entity.TripID.RouteID == "" {
+					tripIDs = append(tripIDs, entity.TripID.ID)
+				}
+				if entity.StopID != nil && *entity.StopID != "" {
+					stopIDs = append(stopIDs, *entity.StopID)
+				}
+			}
+		}
+		if len(tripIDs) > 0 {
+			trips, err := manager.GtfsDB.Queries.GetTripsByIDs(ctx, tripIDs)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to fetch trips for alerts", slog.Any("error", err))
+			}
+			for _, trip := range trips {
+				tripRoutes[trip.ID] = trip.RouteID
+			}
+		}
+		if len(stopIDs) > 0 {
+			rows, err := manager.GtfsDB.Queries.GetAgenciesForStops(ctx, stopIDs)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to fetch stop agencies for alerts", slog.Any("error", err))
+			}
+			for _, row := range rows {
+				if stopAgencies[row.StopID] == nil {
+					stopAgencies[row.StopID] = make(map[string]bool)
+				}
+				stopAgencies[row.StopID][row.ID] = true
+			}
+		}
+	}
+
+	affectsAgency := func(entity gtfs.AlertInformedEntity) bool {
+		switch {
+		case entity.AgencyID != nil && *entity.AgencyID == agencyID:
+			return true
+		case entity.RouteID != nil && agencyRoutes[*entity.RouteID]:
+			return true
+		case entity.TripID != nil && entity.TripID.RouteID != "":
+			return agencyRoutes[entity.TripID.RouteID]
+		case entity.TripID != nil && agencyRoutes[tripRoutes[entity.TripID.ID]]:
+			return true
+		case entity.StopID != nil:
+			return stopAgencies[*entity.StopID][agencyID]
+		}
+		return false
+	}
+
+	var alerts []gtfs.Alert
+	for _, alert := range candidates {
+		for _, entity := range alert.InformedEntities {
+			if affectsAgency(entity) {
+				alerts = append(alerts, alert)
+				break
+			}
+		}
+	}
+	return alerts
+}
+
 // Fetches GTFS-RT data from a URL with per-feed headers.
 func loadRealtimeData(ctx context.Context, source string, headers map[string]string) (*gtfs.Realtime, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
@@ -193,79 +292,132 @@ func loadRealtimeData(ctx context.Context, source string, headers map[string]str
 	return gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
 }
 
-// updateFeedRealtime fetches and processes realtime data for a single feed.
-// It updates the per-feed sub-maps and then calls rebuildMergedRealtimeLocked.
-func (manager *Manager) updateFeedRealtime(ctx context.Context, feedCfg RTFeedConfig) {
+// feedFetch holds what one poll fetched from the sources of a feed, indexed by
+// source. A source that was not polled has neither data nor an error.
+type feedFetch struct {
+	data [numFeedSources]*gtfs.Realtime
+	errs [numFeedSources]error
+}
+
+// updated reports whether the poll fetched new data for the source.
+func (fetch *feedFetch) updated(source feedSource) bool {
+	return fetch.data[source] != nil && fetch.errs[source] == nil
+}
+
+// fetchFeedSources fetches the given sources of a feed in parallel.
+func fetchFeedSources(ctx context.Context, feedCfg RTFeedConfig, sources []feedSource) *feedFetch {
 	logger := logging.FromContext(ctx).With(slog.String("component", "gtfs_realtime"))
-	feedID := feedCfg.ID
+	fetch := &feedFetch{}
 
 	var wg sync.WaitGroup
-	var tripData, vehicleData, alertData *gtfs.Realtime
-	var tripErr, vehicleErr, alertErr error
-
-	// Fetch trip updates, vehicle positions, and alerts in parallel
-	if feedCfg.TripUpdatesURL != "" {
+	for _, source := range sources {
+		url := feedCfg.sourceURL(source)
+		if url == "" {
+			continue
+		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			tripData, tripErr = loadRealtimeData(ctx, feedCfg.TripUpdatesURL, feedCfg.Headers)
-			if tripErr != nil {
-				logging.LogError(logger, "Error loading GTFS-RT trip updates data", tripErr,
-					slog.String("feed", feedID),
-					slog.String("url", feedCfg.TripUpdatesURL))
+			fetch.data[source], fetch.errs[source] = loadRealtimeData(ctx, url, feedCfg.Headers)
+			if fetch.errs[source] != nil {
+				logging.LogError(logger, "Error loading GTFS-RT data", fetch.errs[source],
+					slog.String("feed", feedCfg.ID),
+					slog.String("source", source.String()),
+					slog.String("url", url))
 			}
 		}()
 	}
+	wg.Wait()
+	return fetch
+}
 
-	if feedCfg.VehiclePositionsURL != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			vehicleData, vehicleErr = loadRealtimeData(ctx, feedCfg.VehiclePositionsURL, feedCfg.Headers)
-			if vehicleErr != nil {
-				logging.LogError(logger, "Error loading GTFS-RT vehicle positions data", vehicleErr,
-					slog.String("feed", feedID),
-					slog.String("url", feedCfg.VehiclePositionsURL))
-			}
-		}()
-	}
+// updateFeedRealtime fetches and processes all sources of a single feed at once,
+// as done to warm the cache at startup. The returned delay is how long to wait
+// before polling the feed again: its refresh interval after a success, or a
+// jittered backoff after a failure.
+func (manager *Manager) updateFeedRealtime(ctx context.Context, feedCfg RTFeedConfig) time.Duration {
+	nextPoll, _ := manager.applyFeedFetch(ctx, feedCfg, fetchFeedSources(ctx, feedCfg, feedCfg.sources()))
+	return nextPoll
+}
 
-	if feedCfg.ServiceAlertsURL != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			alertData, alertErr = loadRealtimeData(ctx, feedCfg.ServiceAlertsURL, feedCfg.Headers)
-			if alertErr != nil {
-				logging.LogError(logger, "Error loading GTFS-RT service alerts data", alertErr,
-					slog.String("feed", feedID),
-					slog.String("url", feedCfg.ServiceAlertsURL))
-			}
-		}()
-	}
+// updateFeedSourceRealtime fetches and processes one source of a feed. It
+// returns how long the source's poller waits before polling it again, backing
+// off while the source fails, and the fetch error, if any.
+func (manager *Manager) updateFeedSourceRealtime(ctx context.Context, feedCfg RTFeedConfig, source feedSource) (time.Duration, error) {
+	fetch := fetchFeedSources(ctx, feedCfg, []feedSource{source})
+	_, sourceFailures := manager.applyFeedFetch(ctx, feedCfg, fetch)
+	return retryDelay(feedCfg.sourceInterval(source), sourceFailures[source]), fetch.errs[source]
+}
 
-	wg.Wait()
+// applyFeedFetch stores the fetched sources in the per-feed sub-maps, leaving
+// the data of sources that were not fetched untouched, and then calls
+// rebuildMergedRealtimeLocked. It records the outcome of each source in the
+// feed's health and returns the feed-level delay until the next poll, along
+// with the consecutive failures of each fetched source.
+func (manager *Manager) applyFeedFetch(ctx context.Context, feedCfg RTFeedConfig, fetch *feedFetch) (time.Duration, [numFeedSources]int) {
+	logger := logging.FromContext(ctx).With(slog.String("component", "gtfs_realtime"))
+	feedID := feedCfg.ID
+
+	interval := feedCfg.healthInterval()
+	now := time.Now()
 
 	// Check for context cancellation
 	if ctx.Err() != nil {
-		return
+		return interval, [numFeedSources]int{}
+	}
+
+	feedCfg.normalizeVehicleIDs(fetch)
+
+	tripsUpdated := fetch.updated(sourceTripUpdates)
+	vehiclesUpdated := fetch.updated(sourceVehiclePositions)
+	alertsUpdated := fetch.updated(sourceServiceAlerts)
+
+	// Infer the trips of vehicles that report none before taking the realtime
+	// lock, as it takes schedule lookups.
+	var inferredTrips map[string]string
+	if vehiclesUpdated {
+		manager.realTimeMutex.RLock()
+		previous := manager.feedInferredTrips[feedID]
+		manager.realTimeMutex.RUnlock()
+		inferredTrips = manager.inferVehicleTrips(ctx, fetch.data[sourceVehiclePositions].Vehicles, previous, now)
+	}
+
+	// Record history before taking the realtime lock so readers are not blocked on DB writes.
+	// The writes run to completion even if shutdown cancels ctx meanwhile, so
+	// that a poll's history is stored whole or, on error, rolled back.
+	if vehiclesUpdated {
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), historyWriteTimeout)
+		defer cancel()
+		var trips []gtfs.Trip
+		if tripsUpdated {
+			trips = fetch.data[sourceTripUpdates].Trips
+		} else {
+			// Trip updates are polled on their own; use the feed's latest ones.
+			manager.realTimeMutex.RLock()
+			trips = manager.feedTrips[feedID]
+			manager.realTimeMutex.RUnlock()
+		}
+		manager.recordVehiclePositions(writeCtx, feedID, fetch.data[sourceVehiclePositions].Vehicles, trips, now)
+		manager.trackDetours(writeCtx, feedID, fetch.data[sourceVehiclePositions].Vehicles, now)
 	}
 
 	manager.realTimeMutex.Lock()
 	defer manager.realTimeMutex.Unlock()
 
-	if tripData != nil && tripErr == nil {
-		manager.feedTrips[feedID] = tripData.Trips
+	if tripsUpdated {
+		manager.feedTrips[feedID] = fetch.data[sourceTripUpdates].Trips
+		manager.recordFeedTimestamp(feedID, sourceTripUpdates, fetch.data[sourceTripUpdates], now)
 	}
 
-	if vehicleData != nil && vehicleErr == nil {
-		validVehicles := make([]gtfs.Vehicle, 0, len(vehicleData.Vehicles))
-		for _, v := range vehicleData.Vehicles {
+	if vehiclesUpdated {
+		vehicles := fetch.data[sourceVehiclePositions].Vehicles
+		validVehicles := make([]gtfs.Vehicle, 0, len(vehicles))
+		for _, v := range vehicles {
 			if v.ID != nil {
 				validVehicles = append(validVehicles, v)
 			}
 		}
 
-		now := time.Now()
 		if manager.feedVehicleLastSeen[feedID] == nil {
 			manager.feedVehicleLastSeen[feedID] = make(map[string]time.Time)
 		}
@@ -300,29 +452,35 @@ func (manager *Manager) updateFeedRealtime(ctx context.Context, feedCfg RTFeedCo
 		}
 
 		manager.feedVehicles[feedID] = validVehicles
+		if manager.feedInferredTrips == nil {
+			manager.feedInferredTrips = make(map[string]map[string]string)
+		}
+		manager.feedInferredTrips[feedID] = keptInferredTrips(validVehicles, currentVehicleIDs, inferredTrips, manager.feedInferredTrips[feedID])
+		manager.recordFeedTimestamp(feedID, sourceVehiclePositions, fetch.data[sourceVehiclePositions], now)
 	}
 
-	if alertData != nil && alertErr == nil {
-		manager.feedAlerts[feedID] = alertData.Alerts
+	if alertsUpdated {
+		manager.feedAlerts[feedID] = fetch.data[sourceServiceAlerts].Alerts
 	}
 
-	tripsUpdated := tripData != nil && tripErr == nil
-	vehiclesUpdated := vehicleData != nil && vehicleErr == nil
-	alertsUpdated := alertData != nil && alertErr == nil
+	tripErr := fetch.errs[sourceTripUpdates]
+	vehicleErr := fetch.errs[sourceVehiclePositions]
+	alertErr := fetch.errs[sourceServiceAlerts]
 
 	hadDataBefore := len(manager.feedTrips[feedID]) > 0 || len(manager.feedVehicles[feedID]) > 0 || len(manager.feedAlerts[feedID]) > 0
 	hasNewData := tripsUpdated || vehiclesUpdated || alertsUpdated
 
+	nextPoll, sourceFailures := manager.feedHealth.recordFetch(feedID, interval, fetch, now)
 	if !hasNewData {
 		if hadDataBefore {
-			logger.Warn("all realtime feed sources failed - retaining stale data",
+			logger.Warn("polled realtime feed sources failed - retaining stale data",
 				slog.String("feed", feedID),
 				slog.Bool("trip_updates_error", tripErr != nil),
 				slog.Bool("vehicle_positions_error", vehicleErr != nil),
 				slog.Bool("service_alerts_error", alertErr != nil),
 			)
 		} else {
-			logger.Error("all realtime feed sources failed - no data available",
+			logger.Error("polled realtime feed sources failed - no data available",
 				slog.String("feed", feedID),
 				slog.Bool("trip_updates_error", tripErr != nil),
 				slog.Bool("vehicle_positions_error", vehicleErr != nil),
@@ -339,30 +497,35 @@ func (manager *Manager) updateFeedRealtime(ctx context.Context, feedCfg RTFeedCo
 	}
 
 	manager.rebuildMergedRealtimeLocked()
+	return nextPoll, sourceFailures
 }
 
+// rebuildMergedRealtimeLocked merges the per-feed data into the combined views,
+// resolving trips and vehicles published by more than one feed as described in
+// realtime_merge.go. Trip updates and vehicles of stale feeds are left out so
+// that consumers fall back to the schedule rather than serving outdated
+// predictions; their alerts are kept. Caller must hold realTimeMutex for writing.
 func (manager *Manager) rebuildMergedRealtimeLocked() {
+	stale := manager.feedHealth.staleFeeds(time.Now())
+	priorities := manager.config.feedPriorities()
+
 	feedIDs := make([]string, 0, len(manager.feedTrips))
 	for id := range manager.feedTrips {
-		feedIDs = append(feedIDs, id)
+		if !stale[id] {
+			feedIDs = append(feedIDs, id)
+		}
 	}
 	sort.Strings(feedIDs)
-
-	var allTrips []gtfs.Trip
-	for _, id := range feedIDs {
-		allTrips = append(allTrips, manager.feedTrips[id]...)
-	}
+	allTrips := manager.mergeRealtimeTrips(feedIDs, priorities)
 
 	vehicleFeedIDs := make([]string, 0, len(manager.feedVehicles))
 	for id := range manager.feedVehicles {
-		vehicleFeedIDs = append(vehicleFeedIDs, id)
+		if !stale[id] {
+			vehicleFeedIDs = append(vehicleFeedIDs, id)
+		}
 	}
 	sort.Strings(vehicleFeedIDs)
-
-	var allVehicles []gtfs.Vehicle
-	for _, id := range vehicleFeedIDs {
-		allVehicles = append(allVehicles, manager.feedVehicles[id]...)
-	}
+	rankedVehicles := manager.mergeRealtimeVehicles(vehicleFeedIDs, priorities)
 
 	alertFeedIDs := make([]string, 0, len(manager.feedAlerts))
 	for id := range manager.feedAlerts {
@@ -382,11 +545,23 @@ func (manager *Manager) rebuildMergedRealtimeLocked() {
 		}
 	}
 
-	vehicleLookupByTrip := make(map[string]int, len(allVehicles))
-	vehicleLookupByVehicle := make(map[string]int, len(allVehicles))
-	for i, vehicle := range allVehicles {
+	var allVehicles []gtfs.Vehicle
+	vehicleLookupByTrip := make(map[string]int, len(rankedVehicles))
+	vehicleLookupByVehicle := make(map[string]int, len(rankedVehicles))
+	inferredTrips := make(map[string]string)
+	for i, r := range rankedVehicles {
+		vehicle := r.item
+		allVehicles = append(allVehicles, vehicle)
+		if vehicle.ID != nil && vehicle.Trip != nil {
+			if tripID, ok := manager.feedInferredTrips[r.rank.feedID][vehicle.ID.ID]; ok && tripID == vehicle.Trip.ID.ID {
+				inferredTrips[vehicle.ID.ID] = tripID
+			}
+		}
+		// Vehicles of different feeds may claim the same trip; the best-ranked one serves it.
 		if vehicle.Trip != nil && vehicle.Trip.ID.ID != "" {
-			vehicleLookupByTrip[vehicle.Trip.ID.ID] = i
+			if j, claimed := vehicleLookupByTrip[vehicle.Trip.ID.ID]; !claimed || !rankedVehicles[j].rank.outranks(r.rank) {
+				vehicleLookupByTrip[vehicle.Trip.ID.ID] = i
+			}
 		}
 		if vehicle.ID != nil && vehicle.ID.ID != "" {
 			vehicleLookupByVehicle[vehicle.ID.ID] = i
@@ -399,46 +574,71 @@ func (manager *Manager) rebuildMergedRealtimeLocked() {
 	manager.realTimeTripLookup = tripLookup
 	manager.realTimeVehicleLookupByTrip = vehicleLookupByTrip
 	manager.realTimeVehicleLookupByVehicle = vehicleLookupByVehicle
+	manager.realTimeInferredTrips = inferredTrips
+	manager.realtimeNotifier.notify()
 }
 
-// pollFeed runs the polling loop for a single feed. Each feed gets its own
-// goroutine with its own ticker at the feed's configured refresh interval.
-func (manager *Manager) pollFeed(feedCfg RTFeedConfig) {
-	defer manager.wg.Done()
+// historyWriteTimeout bounds the position history and detour writes of one poll.
+const historyWriteTimeout = 15 * time.Second
 
-	if feedCfg.RefreshInterval <= 0 {
-		feedCfg.RefreshInterval = 30
-	}
+// withShutdown returns a context with the timeout that is also canceled when
+// the manager shuts down.
+func (manager *Manager) withShutdown(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	go func() {
+		select {
+		case <-manager.shutdownChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
 
-	logger := slog.Default().With(slog.String("component", "gtfs_realtime_updater"))
-	interval := time.Duration(feedCfg.RefreshInterval) * time.Second
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// pollFeedSource runs the polling loop for one source of a feed. Every source
+// gets its own goroutine that waits the source's polling interval between
+// successful polls and backs off exponentially, with jitter, while the source
+// keeps failing, so a failing alerts endpoint neither delays nor slows down
+// the vehicle positions of the same feed.
+func (manager *Manager) pollFeedSource(feedCfg RTFeedConfig, source feedSource) {
+	defer manager.wg.Done()
 
-	logging.LogOperation(logger, "started_realtime_feed_poller",
+	logger := slog.Default().With(
+		slog.String("component", "gtfs_realtime_updater"),
 		slog.String("feed", feedCfg.ID),
+		slog.String("source", source.String()),
+	)
+	interval := feedCfg.sourceInterval(source)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	logging.LogOperation(logger, "started_realtime_feed_poller",
 		slog.Duration("interval", interval),
-		slog.String("tripUpdatesURL", feedCfg.TripUpdatesURL),
-		slog.String("vehiclePositionsURL", feedCfg.VehiclePositionsURL),
-		slog.String("serviceAlertsURL", feedCfg.ServiceAlertsURL),
+		slog.String("url", feedCfg.sourceURL(source)),
 	)
 
 	for {
 		select {
 		case <-manager.shutdownChan:
-			logging.LogOperation(logger, "shutting_down_realtime_feed_poller",
-				slog.String("feed", feedCfg.ID))
+			logging.LogOperation(logger, "shutting_down_realtime_feed_poller")
 			return
-		case <-ticker.C:
-			func() {
-				ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		case <-timer.C:
+			next, err := func() (time.Duration, error) {
+				// Shutdown cancels the fetch instead of waiting out its timeout
+				ctx, cancel := manager.withShutdown(context.Background(), 15*time.Second)
 				defer cancel()
 				ctx = logging.WithLogger(ctx, logger)
 
-				logging.LogOperation(logger, "updating_gtfs_realtime_data",
-					slog.String("feed", feedCfg.ID))
-				manager.updateFeedRealtime(ctx, feedCfg)
+				logging.LogOperation(logger, "updating_gtfs_realtime_data")
+				return manager.updateFeedSourceRealtime(ctx, feedCfg, source)
 			}()
+
+			if err != nil {
+				logger.Warn("realtime feed source failing, backing off",
+					slog.String("error", err.Error()),
+					slog.Duration("retry_in", next))
+			}
+			timer.Reset(next)
 		}
 	}
 }
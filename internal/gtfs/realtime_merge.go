@@ -0,0 +1,156 @@
+package gtfs
+
+import (
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// When several feeds publish the same trip or vehicle, for instance one vendor's
+// trip updates and another's vehicle positions both describing a trip, the
+// merged view keeps one feed's version of it rather than whichever was merged
+// last. The feed with the highest Priority wins; among equal priorities the
+// newest data wins, and a tie goes to the feed whose ID sorts first.
+
+// mergeRank orders the versions of a trip or vehicle published by different
+// feeds.
+type mergeRank struct {
+	feedID    string
+	priority  int
+	timestamp time.Time
+}
+
+func (r mergeRank) outranks(other mergeRank) bool {
+	if r.priority != other.priority {
+		return r.priority > other.priority
+	}
+	if !r.timestamp.Equal(other.timestamp) {
+		return r.timestamp.After(other.timestamp)
+	}
+	return r.feedID < other.feedID
+}
+
+// ranked is an entity of the merged view with the rank of the feed version it
+// came from.
+type ranked[T any] struct {
+	item T
+	rank mergeRank
+}
+
+// mergeFeedEntities merges the entities of the feeds, visited in the given
+// order. Entities sharing a key across feeds are kept only from the feed with
+// the highest-ranked version; entities without a key, and repeats of a key
+// within the winning feed, are all kept.
+func mergeFeedEntities[T any](feedIDs []string, byFeed map[string][]T, key func(T) (string, bool), rank func(feedID string, item T) mergeRank) []ranked[T] {
+	winners := make(map[string]mergeRank)
+	for _, feedID := range feedIDs {
+		for _, item := range byFeed[feedID] {
+			k, ok := key(item)
+			if !ok {
+				continue
+			}
+			r := rank(feedID, item)
+			if best, seen := winners[k]; !seen || r.outranks(best) {
+				winners[k] = r
+			}
+		}
+	}
+
+	var merged []ranked[T]
+	for _, feedID := range feedIDs {
+		for _, item := range byFeed[feedID] {
+			k, ok := key(item)
+			if ok && winners[k].feedID != feedID {
+				continue
+			}
+			merged = append(merged, ranked[T]{item: item, rank: rank(feedID, item)})
+		}
+	}
+	return merged
+}
+
+// tripMergeKey identifies a trip across feeds by its ID and, when published,
+// its start date, so the same trip on consecutive service days is not merged.
+func tripMergeKey(trip gtfs.Trip) (string, bool) {
+	if trip.ID.ID == "" {
+		return "", false
+	}
+	if trip.ID.HasStartDate {
+		return trip.ID.ID + "|" + trip.ID.StartDate.Format("20060102"), true
+	}
+	return trip.ID.ID, true
+}
+
+func vehicleMergeKey(vehicle gtfs.Vehicle) (string, bool) {
+	if vehicle.ID == nil || vehicle.ID.ID == "" {
+		return "", false
+	}
+	return vehicle.ID.ID, true
+}
+
+// feedPriorities maps the configured feeds to their Priority.
+func (config Config) feedPriorities() map[string]int {
+	priorities := make(map[string]int, len(config.RTFeeds))
+	for _, feed := range config.RTFeeds {
+		priorities[feed.ID] = feed.Priority
+	}
+	return priorities
+}
+
+// recordFeedTimestamp remembers when a feed's source was generated, from the
+// feed header or, without one, the time it was fetched. Caller must hold
+// realTimeMutex for writing.
+func (manager *Manager) recordFeedTimestamp(feedID string, source feedSource, realtime *gtfs.Realtime, fetchedAt time.Time) {
+	if manager.feedTimestamps == nil {
+		manager.feedTimestamps = make(map[string]*[numFeedSources]time.Time)
+	}
+	timestamps := manager.feedTimestamps[feedID]
+	if timestamps == nil {
+		timestamps = &[numFeedSources]time.Time{}
+		manager.feedTimestamps[feedID] = timestamps
+	}
+	timestamps[source] = fetchedAt
+	if realtime != nil && !realtime.CreatedAt.IsZero() {
+		timestamps[source] = realtime.CreatedAt
+	}
+}
+
+// feedTimestamp returns when the feed's source was generated, or the zero time
+// if it never was. Caller must hold realTimeMutex.
+func (manager *Manager) feedTimestamp(feedID string, source feedSource) time.Time {
+	if timestamps := manager.feedTimestamps[feedID]; timestamps != nil {
+		return timestamps[source]
+	}
+	return time.Time{}
+}
+
+// mergeRealtimeTrips merges the trip updates of the given feeds. A trip update
+// carries no timestamp of its own, so it is as new as its feed's header.
+// Caller must hold realTimeMutex.
+func (manager *Manager) mergeRealtimeTrips(feedIDs []string, priorities map[string]int) []gtfs.Trip {
+	merged := mergeFeedEntities(feedIDs, manager.feedTrips, tripMergeKey, func(feedID string, _ gtfs.Trip) mergeRank {
+		return mergeRank{
+			feedID:    feedID,
+			priority:  priorities[feedID],
+			timestamp: manager.feedTimestamp(feedID, sourceTripUpdates),
+		}
+	})
+	var trips []gtfs.Trip
+	for _, r := range merged {
+		trips = append(trips, r.item)
+	}
+	return trips
+}
+
+// mergeRealtimeVehicles merges the vehicle positions of the given feeds, each
+// as new as its own timestamp or else its feed's header. Caller must hold
+// realTimeMutex.
+func (manager *Manager) mergeRealtimeVehicles(feedIDs []string, priorities map[string]int) []ranked[gtfs.Vehicle] {
+	return mergeFeedEntities(feedIDs, manager.feedVehicles, vehicleMergeKey, func(feedID string, vehicle gtfs.Vehicle) mergeRank {
+		timestamp := manager.feedTimestamp(feedID, sourceVehiclePositions)
+		if vehicle.Timestamp != nil {
+			timestamp = *vehicle.Timestamp
+		}
+		return mergeRank{feedID: feedID, priority: priorities[feedID], timestamp: timestamp}
+	})
+}
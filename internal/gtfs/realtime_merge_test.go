@@ -0,0 +1,127 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRankOutranks(t *testing.T) {
+	now := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	older := mergeRank{feedID: "a", timestamp: now.Add(-time.Minute)}
+	newer := mergeRank{feedID: "b", timestamp: now}
+
+	assert.True(t, newer.outranks(older), "newer data wins among equal priorities")
+	assert.False(t, older.outranks(newer))
+
+	older.priority = 1
+	assert.True(t, older.outranks(newer), "priority wins over recency")
+
+	tie := mergeRank{feedID: "c", timestamp: now}
+	assert.True(t, newer.outranks(tie), "a full tie goes to the feed ID that sorts first")
+	assert.False(t, tie.outranks(newer))
+}
+
+func mergeTestTrip(id string, delay time.Duration) gtfs.Trip {
+	return gtfs.Trip{ID: gtfs.TripID{ID: id}, Delay: &delay}
+}
+
+func TestRebuildMergedRealtime_ResolvesTripConflicts(t *testing.T) {
+	manager := newTestManager()
+	manager.config.RTFeeds = []RTFeedConfig{{ID: "vendor-a"}, {ID: "vendor-b"}}
+	now := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+
+	manager.feedTrips["vendor-a"] = []gtfs.Trip{mergeTestTrip("shared", time.Minute), mergeTestTrip("only-a", 0)}
+	manager.feedTrips["vendor-b"] = []gtfs.Trip{mergeTestTrip("shared", 2*time.Minute)}
+	manager.recordFeedTimestamp("vendor-a", sourceTripUpdates, &gtfs.Realtime{CreatedAt: now.Add(-30 * time.Second)}, now)
+	manager.recordFeedTimestamp("vendor-b", sourceTripUpdates, &gtfs.Realtime{CreatedAt: now}, now)
+
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	trips := manager.GetAllTripUpdates()
+	require.Len(t, trips, 2, "the shared trip is served once")
+	shared, err := manager.GetTripUpdateByID("shared")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, *shared.Delay, "vendor-b published it more recently")
+
+	// Precedence overrides recency.
+	manager.config.RTFeeds[0].Priority = 10
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	shared, err = manager.GetTripUpdateByID("shared")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, *shared.Delay)
+	assert.Len(t, manager.GetAllTripUpdates(), 2)
+}
+
+func TestRebuildMergedRealtime_KeepsTripsOfDifferentStartDates(t *testing.T) {
+	manager := newTestManager()
+	day := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+
+	first := gtfs.Trip{ID: gtfs.TripID{ID: "overnight", HasStartDate: true, StartDate: day}}
+	second := gtfs.Trip{ID: gtfs.TripID{ID: "overnight", HasStartDate: true, StartDate: day.AddDate(0, 0, 1)}}
+	manager.feedTrips["vendor-a"] = []gtfs.Trip{first}
+	manager.feedTrips["vendor-b"] = []gtfs.Trip{second}
+
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	assert.Len(t, manager.GetAllTripUpdates(), 2)
+}
+
+func TestRebuildMergedRealtime_ResolvesVehicleConflicts(t *testing.T) {
+	manager := newTestManager()
+	manager.config.RTFeeds = []RTFeedConfig{{ID: "avl"}, {ID: "predictions"}}
+	now := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Minute)
+
+	vehicle := func(id, tripID string, lat float32, observedAt time.Time) gtfs.Vehicle {
+		return gtfs.Vehicle{
+			ID:        &gtfs.VehicleID{ID: id},
+			Trip:      &gtfs.Trip{ID: gtfs.TripID{ID: tripID}},
+			Position:  &gtfs.Position{Latitude: &lat},
+			Timestamp: &observedAt,
+		}
+	}
+	// Both feeds track bus-1; the predictions vendor also reports a different
+	// vehicle, with an older position, on bus-1's trip.
+	manager.feedVehicles["avl"] = []gtfs.Vehicle{vehicle("bus-1", "t1", 47.6, now)}
+	manager.feedVehicles["predictions"] = []gtfs.Vehicle{
+		vehicle("bus-1", "t1", 47.5, earlier),
+		vehicle("bus-9", "t1", 47.4, earlier),
+	}
+
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	vehicles := manager.GetRealTimeVehicles()
+	require.Len(t, vehicles, 2)
+	manager.realTimeMutex.RLock()
+	byVehicle := manager.realTimeVehicles[manager.realTimeVehicleLookupByVehicle["bus-1"]]
+	byTrip := manager.realTimeVehicles[manager.realTimeVehicleLookupByTrip["t1"]]
+	manager.realTimeMutex.RUnlock()
+	assert.Equal(t, float32(47.6), *byVehicle.Position.Latitude, "the newer position of bus-1 is served")
+	assert.Equal(t, "bus-1", byTrip.ID.ID, "the best-ranked vehicle serves the trip")
+
+	// With precedence, the predictions vendor serves both the vehicle and the trip.
+	manager.config.RTFeeds[1].Priority = 1
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	manager.realTimeMutex.RLock()
+	byVehicle = manager.realTimeVehicles[manager.realTimeVehicleLookupByVehicle["bus-1"]]
+	byTrip = manager.realTimeVehicles[manager.realTimeVehicleLookupByTrip["t1"]]
+	manager.realTimeMutex.RUnlock()
+	assert.Equal(t, float32(47.5), *byVehicle.Position.Latitude)
+	assert.Equal(t, "bus-9", byTrip.ID.ID, "a tie within one feed goes to its later vehicle, as before")
+}
@@ -0,0 +1,92 @@
+package gtfs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// realtimeNotifier fans out "realtime data changed" signals to subscribers such
+// as streaming clients. Each subscriber gets a channel with a buffer of one and
+// signals are coalesced: a subscriber that is still busy with the previous change
+// sees a single pending signal rather than a backlog, and reads the current
+// merged data when it catches up. The zero value is ready to use.
+type realtimeNotifier struct {
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]chan struct{}
+	closed bool
+	// generation counts the changes signalled so far.
+	generation atomic.Uint64
+}
+
+func (n *realtimeNotifier) subscribe() (<-chan struct{}, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if n.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if n.subs == nil {
+		n.subs = make(map[int]chan struct{})
+	}
+	id := n.nextID
+	n.nextID++
+	n.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if sub, ok := n.subs[id]; ok {
+				delete(n.subs, id)
+				close(sub)
+			}
+		})
+	}
+}
+
+func (n *realtimeNotifier) notify() {
+	n.generation.Add(1)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, ch := range n.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// close closes every subscriber channel; later subscriptions receive an
+// already-closed channel.
+func (n *realtimeNotifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed = true
+	for id, ch := range n.subs {
+		delete(n.subs, id)
+		close(ch)
+	}
+}
+
+// SubscribeRealtime returns a channel that receives a value whenever the merged
+// realtime trips, vehicles or alerts are rebuilt, and a function that cancels the
+// subscription. The channel is closed when the subscription is cancelled or the
+// manager shuts down. Signals carry no data; read the current state through the
+// GetRealTime* accessors.
+func (manager *Manager) SubscribeRealtime() (<-chan struct{}, func()) {
+	return manager.realtimeNotifier.subscribe()
+}
+
+// DataGeneration returns a number that changes whenever the static data is
+// reloaded, the merged realtime data is rebuilt or a vehicle assignment
+// changes. Results derived from that data can be cached under it and dropped
+// once it moves on.
+func (manager *Manager) DataGeneration() uint64 {
+	return manager.dataGeneration.Load() + manager.realtimeNotifier.generation.Load()
+}
@@ -0,0 +1,80 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeRealtimeCoalescesSignals(t *testing.T) {
+	manager := &Manager{
+		feedVehicles: map[string][]gtfs.Vehicle{
+			"feed-0": {{ID: &gtfs.VehicleID{ID: "vehicle1"}}},
+		},
+	}
+	updates, cancel := manager.SubscribeRealtime()
+	defer cancel()
+
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	select {
+	case <-updates:
+	default:
+		t.Fatal("expected a signal after the realtime data was rebuilt")
+	}
+	select {
+	case <-updates:
+		t.Fatal("consecutive rebuilds should coalesce into one pending signal")
+	default:
+	}
+}
+
+func TestSubscribeRealtimeCancelClosesChannel(t *testing.T) {
+	manager := &Manager{}
+	updates, cancel := manager.SubscribeRealtime()
+	cancel()
+	cancel() // cancelling twice is harmless
+
+	_, ok := <-updates
+	assert.False(t, ok)
+
+	// Notifying after the only subscriber left must not panic.
+	manager.realtimeNotifier.notify()
+}
+
+func TestSubscribeRealtimeClosedOnShutdown(t *testing.T) {
+	manager := &Manager{shutdownChan: make(chan struct{})}
+	updates, cancel := manager.SubscribeRealtime()
+	defer cancel()
+
+	manager.Shutdown()
+
+	_, ok := <-updates
+	assert.False(t, ok, "shutdown should close subscriber channels")
+
+	late, lateCancel := manager.SubscribeRealtime()
+	defer lateCancel()
+	_, ok = <-late
+	assert.False(t, ok, "subscriptions after shutdown should be closed immediately")
+}
+
+func TestDataGenerationChangesWithData(t *testing.T) {
+	manager := &Manager{}
+	generation := manager.DataGeneration()
+
+	manager.realTimeMutex.Lock()
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+	assert.NotEqual(t, generation, manager.DataGeneration(), "a realtime rebuild moves the generation on")
+
+	generation = manager.DataGeneration()
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-1", BlockID: "block-1"})
+	assert.NotEqual(t, generation, manager.DataGeneration(), "a vehicle assignment moves the generation on")
+
+	generation = manager.DataGeneration()
+	assert.Equal(t, generation, manager.DataGeneration())
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/OneBusAway/go-gtfs"
 	"github.com/stretchr/testify/assert"
@@ -286,3 +287,24 @@ func TestEnabledFeeds(t *testing.T) {
 		})
 	}
 }
+
+func TestRTFeedConfig_SourceIntervals(t *testing.T) {
+	feed := RTFeedConfig{
+		TripUpdatesURL:           "http://example.com/tu",
+		VehiclePositionsURL:      "http://example.com/vp",
+		RefreshInterval:          20,
+		VehiclePositionsInterval: 10,
+		ServiceAlertsInterval:    300,
+	}
+
+	assert.Equal(t, []feedSource{sourceTripUpdates, sourceVehiclePositions}, feed.sources())
+	assert.Equal(t, 20*time.Second, feed.sourceInterval(sourceTripUpdates), "falls back to the refresh interval")
+	assert.Equal(t, 10*time.Second, feed.sourceInterval(sourceVehiclePositions))
+	assert.Equal(t, 20*time.Second, feed.healthInterval(), "sources without a URL are ignored")
+
+	feed.ServiceAlertsURL = "http://example.com/sa"
+	assert.Equal(t, 300*time.Second, feed.healthInterval())
+
+	assert.Equal(t, 30*time.Second, RTFeedConfig{}.sourceInterval(sourceServiceAlerts))
+	assert.Equal(t, 30*time.Second, RTFeedConfig{}.healthInterval())
+}
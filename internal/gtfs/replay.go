@@ -0,0 +1,209 @@
+package gtfs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// ReplayFeedID is the feed ID replayed snapshots are stored under.
+const ReplayFeedID = "replay"
+
+// replayLoopPause is how much replayed time passes between the last snapshot
+// and the restart of a looping replay.
+const replayLoopPause = 30 * time.Second
+
+// replaySnapshot is one recorded GTFS-RT response and when it was generated.
+type replaySnapshot struct {
+	name   string
+	source feedSource
+	at     time.Time
+	data   *gtfs.Realtime
+}
+
+// loadReplaySnapshots reads the GTFS-RT snapshots recorded in dir, in the order
+// they were generated. Snapshots are .pb files named after the source they
+// were recorded from, e.g. "vehicle_positions-0001.pb"; the prefix is one of
+// trip_updates, vehicle_positions or service_alerts. A snapshot was generated
+// at its header timestamp or, when that is missing, its file's modification time.
+func loadReplaySnapshots(dir string) ([]replaySnapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay directory: %w", err)
+	}
+
+	var snapshots []replaySnapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pb" {
+			continue
+		}
+		source, ok := replaySnapshotSource(entry.Name())
+		if !ok {
+			return nil, fmt.Errorf("replay snapshot %q must be named after its source: trip_updates, vehicle_positions or service_alerts", entry.Name())
+		}
+		path := filepath.Join(dir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read replay snapshot %q: %w", entry.Name(), err)
+		}
+		data, err := gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse replay snapshot %q: %w", entry.Name(), err)
+		}
+		at := data.CreatedAt
+		if at.IsZero() {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat replay snapshot %q: %w", entry.Name(), err)
+			}
+			at = info.ModTime()
+		}
+		snapshots = append(snapshots, replaySnapshot{name: entry.Name(), source: source, at: at, data: data})
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no GTFS-RT snapshots found in replay directory %q", dir)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if !snapshots[i].at.Equal(snapshots[j].at) {
+			return snapshots[i].at.Before(snapshots[j].at)
+		}
+		return snapshots[i].name < snapshots[j].name
+	})
+	return snapshots, nil
+}
+
+// replaySnapshotSource returns the source a snapshot file was recorded from.
+func replaySnapshotSource(name string) (feedSource, bool) {
+	for source := range numFeedSources {
+		if strings.HasPrefix(name, source.String()) {
+			return source, true
+		}
+	}
+	return 0, false
+}
+
+// realtimeReplay plays recorded snapshots back on a clock that starts at the
+// first snapshot and runs speed times faster than real time.
+type realtimeReplay struct {
+	feed      RTFeedConfig
+	snapshots []replaySnapshot
+	clock     *clock.ReplayClock
+	loop      bool
+}
+
+func newRealtimeReplay(config Config) (*realtimeReplay, error) {
+	snapshots, err := loadReplaySnapshots(config.ReplayDir)
+	if err != nil {
+		return nil, err
+	}
+	replay := &realtimeReplay{
+		snapshots: snapshots,
+		clock:     clock.NewReplayClock(snapshots[0].at, config.ReplaySpeed),
+		loop:      config.ReplayLoop,
+	}
+
+	// The replayed feed must not turn stale between two snapshots, so it is
+	// judged against the longest real-time gap between them.
+	var longestGap time.Duration
+	for i := 1; i < len(snapshots); i++ {
+		if gap := replay.clock.WallDuration(snapshots[i].at.Sub(snapshots[i-1].at)); gap > longestGap {
+			longestGap = gap
+		}
+	}
+	refreshSeconds := int(math.Ceil(longestGap.Seconds()))
+	replay.feed = RTFeedConfig{
+		ID:              ReplayFeedID,
+		Enabled:         true,
+		RefreshInterval: refreshSeconds, // zero uses the 30 second default
+	}
+	return replay, nil
+}
+
+// untilSnapshot returns the real time to wait before the snapshot at index next
+// is due, or before a looping replay restarts once all have been played.
+func (replay *realtimeReplay) untilSnapshot(next int) time.Duration {
+	if next >= len(replay.snapshots) {
+		return replay.clock.WallDuration(replayLoopPause)
+	}
+	wait := replay.clock.WallDuration(replay.snapshots[next].at.Sub(replay.clock.Now()))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// ReplayClock returns the clock of the running replay, or nil when realtime
+// data is polled from live feeds. Responses should be timed by it so that they
+// line up with the replayed data.
+func (manager *Manager) ReplayClock() *clock.ReplayClock {
+	if manager.replay == nil {
+		return nil
+	}
+	return manager.replay.clock
+}
+
+// applyReplaySnapshots applies the snapshot at index next together with the
+// ones generated at the same instant, as if they had been fetched in one poll,
+// and returns the index of the next snapshot to play.
+func (manager *Manager) applyReplaySnapshots(ctx context.Context, next int) int {
+	replay := manager.replay
+	fetch := &feedFetch{}
+	at := replay.snapshots[next].at
+	for ; next < len(replay.snapshots) && replay.snapshots[next].at.Equal(at); next++ {
+		fetch.data[replay.snapshots[next].source] = replay.snapshots[next].data
+	}
+	manager.applyFeedFetch(ctx, replay.feed, fetch)
+	return next
+}
+
+// runReplay plays the remaining snapshots, starting at index next, each once
+// the replay clock reaches the time it was generated at. A looping replay
+// starts over with the clock reset to the first snapshot.
+func (manager *Manager) runReplay(next int) {
+	defer manager.wg.Done()
+
+	replay := manager.replay
+	logger := slog.Default().With(slog.String("component", "gtfs_realtime_replay"))
+	logging.LogOperation(logger, "started_realtime_replay",
+		slog.Int("snapshots", len(replay.snapshots)),
+		slog.Float64("speed", replay.clock.Speed()),
+		slog.Bool("loop", replay.loop),
+	)
+	if next >= len(replay.snapshots) && !replay.loop {
+		logging.LogOperation(logger, "finished_realtime_replay")
+		return
+	}
+
+	timer := time.NewTimer(replay.untilSnapshot(next))
+	defer timer.Stop()
+	for {
+		select {
+		case <-manager.shutdownChan:
+			logging.LogOperation(logger, "shutting_down_realtime_replay")
+			return
+		case <-timer.C:
+			if next >= len(replay.snapshots) {
+				replay.clock.Reset(replay.snapshots[0].at)
+				next = 0
+				logging.LogOperation(logger, "restarted_realtime_replay")
+			}
+			next = manager.applyReplaySnapshots(logging.WithLogger(context.Background(), logger), next)
+			if next >= len(replay.snapshots) && !replay.loop {
+				logging.LogOperation(logger, "finished_realtime_replay")
+				return
+			}
+			timer.Reset(replay.untilSnapshot(next))
+		}
+	}
+}
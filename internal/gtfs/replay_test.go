@@ -0,0 +1,137 @@
+package gtfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+// recordReplay copies recorded test feeds into a replay directory under the
+// given snapshot names.
+func recordReplay(t *testing.T, snapshots map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, recording := range snapshots {
+		body, err := os.ReadFile(filepath.Join("..", "..", "testdata", recording))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), body, 0o644))
+	}
+	return dir
+}
+
+func TestLoadReplaySnapshots(t *testing.T) {
+	dir := recordReplay(t, map[string]string{
+		"vehicle_positions-0001.pb": "raba-vehicle-positions.pb",
+		"trip_updates-0001.pb":      "raba-trip-updates.pb",
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a snapshot"), 0o644))
+
+	snapshots, err := loadReplaySnapshots(dir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	// Ordered by header timestamp, not by name.
+	assert.Equal(t, sourceTripUpdates, snapshots[0].source)
+	assert.Equal(t, time.Date(2025, 6, 8, 21, 8, 5, 0, time.UTC), snapshots[0].at.UTC())
+	assert.Equal(t, sourceVehiclePositions, snapshots[1].source)
+	assert.Equal(t, time.Date(2025, 6, 8, 21, 8, 26, 0, time.UTC), snapshots[1].at.UTC())
+	assert.NotEmpty(t, snapshots[1].data.Vehicles)
+}
+
+func TestLoadReplaySnapshotsErrors(t *testing.T) {
+	_, err := loadReplaySnapshots(t.TempDir())
+	assert.ErrorContains(t, err, "no GTFS-RT snapshots")
+
+	dir := recordReplay(t, map[string]string{"positions.pb": "raba-vehicle-positions.pb"})
+	_, err = loadReplaySnapshots(dir)
+	assert.ErrorContains(t, err, "must be named after its source")
+
+	_, err = loadReplaySnapshots(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to read replay directory")
+}
+
+func TestManagerReplaysSnapshots(t *testing.T) {
+	testDataPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	// The snapshots were generated 21 seconds apart, which a 1000x replay plays in 21ms.
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      testDataPath,
+		GTFSDataPath: ":memory:",
+		Env:          appconf.Test,
+		ReplayDir: recordReplay(t, map[string]string{
+			"trip_updates-0001.pb":      "raba-trip-updates.pb",
+			"vehicle_positions-0001.pb": "raba-vehicle-positions.pb",
+		}),
+		ReplaySpeed: 1000,
+		RTFeeds: []RTFeedConfig{{
+			ID:                  "live",
+			VehiclePositionsURL: "http://127.0.0.1:1/unreachable.pb",
+			Enabled:             true,
+		}},
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	replayClock := manager.ReplayClock()
+	require.NotNil(t, replayClock)
+	assert.False(t, replayClock.Now().Before(time.Date(2025, 6, 8, 21, 8, 5, 0, time.UTC)),
+		"the replay clock starts at the first snapshot")
+
+	// The first snapshot is applied before the manager is ready.
+	assert.Len(t, manager.GetAllTripUpdates(), 9)
+
+	assert.Eventually(t, func() bool {
+		return len(manager.GetRealTimeVehicles()) == 22
+	}, 5*time.Second, 5*time.Millisecond, "the second snapshot is played once the replay clock reaches it")
+
+	health := manager.FeedHealthStatus()
+	require.Len(t, health, 1, "live feeds are not polled during a replay")
+	assert.Equal(t, ReplayFeedID, health[0].FeedID)
+}
+
+func TestManagerReplayLoops(t *testing.T) {
+	testDataPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      testDataPath,
+		GTFSDataPath: ":memory:",
+		Env:          appconf.Test,
+		ReplayDir: recordReplay(t, map[string]string{
+			"trip_updates-0001.pb":      "raba-trip-updates.pb",
+			"vehicle_positions-0001.pb": "raba-vehicle-positions.pb",
+		}),
+		ReplaySpeed: 1000,
+		ReplayLoop:  true,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	start := time.Date(2025, 6, 8, 21, 8, 5, 0, time.UTC)
+	last := time.Date(2025, 6, 8, 21, 8, 26, 0, time.UTC)
+	require.Eventually(t, func() bool {
+		return manager.ReplayClock().Now().After(last)
+	}, 5*time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return manager.ReplayClock().Now().Before(last)
+	}, 5*time.Second, time.Millisecond, "the clock is reset to %s when the replay starts over", start)
+}
+
+func TestInitGTFSManagerRejectsBadReplay(t *testing.T) {
+	testDataPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+
+	_, err = InitGTFSManager(Config{
+		GtfsURL:      testDataPath,
+		GTFSDataPath: ":memory:",
+		Env:          appconf.Test,
+		ReplayDir:    t.TempDir(),
+	})
+	assert.ErrorContains(t, err, "error loading realtime replay")
+}
@@ -0,0 +1,265 @@
+package gtfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+)
+
+// Scenario builds the realtime state of a test in a few chained calls instead
+// of one Mock* call per trip update, vehicle and alert:
+//
+//	err := gtfs.NewScenario().
+//		WithTrip("Route15-Southbound-MonSat-4").Delayed(2*time.Minute).
+//		WithVehicleAtStop("bus-1", "1505").
+//		WithTrip("Route15-Southbound-MonSat-3").Canceled().
+//		Apply(ctx, manager)
+//
+// Calls after WithTrip describe that trip until the next WithTrip. Mistakes
+// such as a vehicle without a trip are reported by Apply.
+type Scenario struct {
+	at     time.Time
+	trips  []*scenarioTrip
+	alerts []gtfs.Alert
+	err    error
+}
+
+type scenarioTrip struct {
+	trip       gtfs.Trip
+	hasUpdate  bool
+	stopDelays []scenarioStopDelay
+	vehicles   []scenarioVehicle
+}
+
+type scenarioStopDelay struct {
+	stopID string
+	delay  time.Duration
+}
+
+type scenarioVehicle struct {
+	id       string
+	position *gtfs.Position
+	stopID   string // the vehicle is stopped at this stop of its trip
+}
+
+// NewScenario starts an empty scenario whose vehicles report at the current time.
+func NewScenario() *Scenario {
+	return &Scenario{at: time.Now()}
+}
+
+// At sets when the scenario's vehicles reported their positions.
+func (s *Scenario) At(t time.Time) *Scenario {
+	s.at = t
+	return s
+}
+
+// WithTrip adds a trip that the following calls describe. Its route is looked
+// up in the static data when the scenario is applied.
+func (s *Scenario) WithTrip(tripID string) *Scenario {
+	s.trips = append(s.trips, &scenarioTrip{trip: gtfs.Trip{
+		ID:                gtfs.TripID{ID: tripID, ScheduleRelationship: gtfsrt.TripDescriptor_SCHEDULED},
+		IsEntityInMessage: true,
+	}})
+	return s
+}
+
+// Delayed gives the current trip a trip-level delay.
+func (s *Scenario) Delayed(delay time.Duration) *Scenario {
+	if trip := s.currentTrip("Delayed"); trip != nil {
+		trip.trip.Delay = &delay
+		trip.hasUpdate = true
+	}
+	return s
+}
+
+// WithStopDelay predicts the current trip's arrival at and departure from a
+// stop the given delay behind schedule.
+func (s *Scenario) WithStopDelay(stopID string, delay time.Duration) *Scenario {
+	if trip := s.currentTrip("WithStopDelay"); trip != nil {
+		trip.stopDelays = append(trip.stopDelays, scenarioStopDelay{stopID: stopID, delay: delay})
+		trip.hasUpdate = true
+	}
+	return s
+}
+
+// Canceled marks the current trip as canceled.
+func (s *Scenario) Canceled() *Scenario {
+	if trip := s.currentTrip("Canceled"); trip != nil {
+		trip.trip.ID.ScheduleRelationship = gtfsrt.TripDescriptor_CANCELED
+		trip.hasUpdate = true
+	}
+	return s
+}
+
+// WithVehicle assigns a vehicle without a position to the current trip.
+func (s *Scenario) WithVehicle(vehicleID string) *Scenario {
+	if trip := s.currentTrip("WithVehicle"); trip != nil {
+		trip.vehicles = append(trip.vehicles, scenarioVehicle{id: vehicleID})
+	}
+	return s
+}
+
+// WithVehicleAt assigns a vehicle at the given position to the current trip.
+func (s *Scenario) WithVehicleAt(vehicleID string, lat, lon float64) *Scenario {
+	if trip := s.currentTrip("WithVehicleAt"); trip != nil {
+		latitude, longitude := float32(lat), float32(lon)
+		trip.vehicles = append(trip.vehicles, scenarioVehicle{
+			id:       vehicleID,
+			position: &gtfs.Position{Latitude: &latitude, Longitude: &longitude},
+		})
+	}
+	return s
+}
+
+// WithVehicleAtStop assigns a vehicle stopped at one of the current trip's
+// stops to the trip, positioned at the stop.
+func (s *Scenario) WithVehicleAtStop(vehicleID, stopID string) *Scenario {
+	if trip := s.currentTrip("WithVehicleAtStop"); trip != nil {
+		trip.vehicles = append(trip.vehicles, scenarioVehicle{id: vehicleID, stopID: stopID})
+	}
+	return s
+}
+
+// WithAlert adds a service alert.
+func (s *Scenario) WithAlert(alert gtfs.Alert) *Scenario {
+	s.alerts = append(s.alerts, alert)
+	return s
+}
+
+func (s *Scenario) currentTrip(call string) *scenarioTrip {
+	if len(s.trips) == 0 {
+		s.err = errors.Join(s.err, fmt.Errorf("scenario: %s needs a trip; call WithTrip first", call))
+		return nil
+	}
+	return s.trips[len(s.trips)-1]
+}
+
+// Apply replaces the manager's realtime data with the scenario. Routes, stop
+// positions and stop sequences are resolved from the static data; trips
+// missing from it keep an empty route ID. Call MockResetRealTimeData to
+// clear the scenario again.
+func (s *Scenario) Apply(ctx context.Context, manager *Manager) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	var trips []gtfs.Trip
+	var vehicles []gtfs.Vehicle
+	for _, st := range s.trips {
+		trip := st.trip
+		resolver := scenarioResolver{ctx: ctx, manager: manager, tripID: trip.ID.ID}
+		trip.ID.RouteID = resolver.routeID()
+
+		for _, stopDelay := range st.stopDelays {
+			delay := stopDelay.delay
+			stu := gtfs.StopTimeUpdate{
+				StopID:    &stopDelay.stopID,
+				Arrival:   &gtfs.StopTimeEvent{Delay: &delay},
+				Departure: &gtfs.StopTimeEvent{Delay: &delay},
+			}
+			if sequence, err := resolver.stopSequence(stopDelay.stopID); err == nil {
+				stu.StopSequence = &sequence
+			}
+			trip.StopTimeUpdates = append(trip.StopTimeUpdates, stu)
+		}
+
+		for _, sv := range st.vehicles {
+			timestamp := s.at
+			vehicle := gtfs.Vehicle{
+				ID:        &gtfs.VehicleID{ID: sv.id},
+				Timestamp: &timestamp,
+				Trip:      &gtfs.Trip{ID: trip.ID},
+				Position:  sv.position,
+			}
+			if sv.stopID != "" {
+				if err := resolver.placeAtStop(&vehicle, sv.stopID); err != nil {
+					return err
+				}
+			}
+			vehicles = append(vehicles, vehicle)
+			if trip.Vehicle == nil {
+				trip.Vehicle = &gtfs.Vehicle{ID: vehicle.ID}
+			}
+		}
+
+		if st.hasUpdate {
+			trips = append(trips, trip)
+		}
+	}
+
+	manager.MockResetRealTimeData()
+	manager.realTimeMutex.Lock()
+	defer manager.realTimeMutex.Unlock()
+
+	manager.realTimeTrips = trips
+	manager.realTimeVehicles = vehicles
+	manager.realTimeAlerts = s.alerts
+	for i, trip := range trips {
+		manager.realTimeTripLookup[trip.ID.ID] = i
+	}
+	for i, vehicle := range vehicles {
+		manager.realTimeVehicleLookupByVehicle[vehicle.ID.ID] = i
+		if _, claimed := manager.realTimeVehicleLookupByTrip[vehicle.Trip.ID.ID]; !claimed {
+			manager.realTimeVehicleLookupByTrip[vehicle.Trip.ID.ID] = i
+		}
+	}
+	manager.realtimeNotifier.notify()
+	return nil
+}
+
+// scenarioResolver looks up what a scenario's trip needs from the static data.
+type scenarioResolver struct {
+	ctx     context.Context
+	manager *Manager
+	tripID  string
+}
+
+func (r scenarioResolver) routeID() string {
+	if r.manager.GtfsDB == nil {
+		return ""
+	}
+	trip, err := r.manager.GtfsDB.GetTrip(r.ctx, r.tripID)
+	if err != nil {
+		return ""
+	}
+	return trip.RouteID
+}
+
+func (r scenarioResolver) stopSequence(stopID string) (uint32, error) {
+	if r.manager.GtfsDB == nil {
+		return 0, fmt.Errorf("scenario: no static data to find stop %s of trip %s in", stopID, r.tripID)
+	}
+	stopTimes, err := r.manager.GtfsDB.Queries.GetStopTimesForTrip(r.ctx, r.tripID)
+	if err != nil {
+		return 0, fmt.Errorf("scenario: stop times of trip %s: %w", r.tripID, err)
+	}
+	for _, stopTime := range stopTimes {
+		if stopTime.StopID == stopID {
+			return uint32(stopTime.StopSequence), nil
+		}
+	}
+	return 0, fmt.Errorf("scenario: stop %s is not on trip %s", stopID, r.tripID)
+}
+
+// placeAtStop positions the vehicle at the stop and marks it stopped there.
+func (r scenarioResolver) placeAtStop(vehicle *gtfs.Vehicle, stopID string) error {
+	sequence, err := r.stopSequence(stopID)
+	if err != nil {
+		return err
+	}
+	stop, err := r.manager.GtfsDB.GetStop(r.ctx, stopID)
+	if err != nil {
+		return fmt.Errorf("scenario: stop %s: %w", stopID, err)
+	}
+	lat, lon := float32(stop.Lat), float32(stop.Lon)
+	status := gtfsrt.VehiclePosition_STOPPED_AT
+	vehicle.Position = &gtfs.Position{Latitude: &lat, Longitude: &lon}
+	vehicle.StopID = &stopID
+	vehicle.CurrentStopSequence = &sequence
+	vehicle.CurrentStatus = &status
+	return nil
+}
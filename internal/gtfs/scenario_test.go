@@ -0,0 +1,97 @@
+package gtfs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func newScenarioTestManager(t *testing.T) *Manager {
+	t.Helper()
+	testDataPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err)
+	manager, err := InitGTFSManager(Config{GtfsURL: testDataPath, GTFSDataPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	t.Cleanup(manager.Shutdown)
+	return manager
+}
+
+func TestScenarioApply(t *testing.T) {
+	manager := newScenarioTestManager(t)
+	ctx := context.Background()
+	reportedAt := time.Date(2025, 6, 9, 16, 7, 0, 0, time.UTC)
+
+	manager.MockAddVehicle("left-over", "trip1", "15")
+	err := NewScenario().At(reportedAt).
+		WithTrip("Route15-Southbound-MonSat-4").Delayed(2*time.Minute).
+		WithVehicleAtStop("bus-1", "1505").
+		WithTrip("Route15-Southbound-MonSat-3").Canceled().
+		WithTrip("Route15-Southbound-MonSat-2").WithStopDelay("1505", 90*time.Second).
+		WithTrip("Route15-Northbound-MonSat-5").WithVehicleAt("bus-2", 40.58, -122.39).
+		WithAlert(gtfs.Alert{ID: "detour"}).
+		Apply(ctx, manager)
+	require.NoError(t, err)
+
+	_, err = manager.GetVehicleByID("left-over")
+	assert.Error(t, err, "applying a scenario replaces the realtime data")
+
+	delayed, err := manager.GetTripUpdateByID("Route15-Southbound-MonSat-4")
+	require.NoError(t, err)
+	assert.Equal(t, "15", delayed.ID.RouteID, "the route comes from the static data")
+	assert.Equal(t, 2*time.Minute, *delayed.Delay)
+	require.NotNil(t, delayed.Vehicle)
+	assert.Equal(t, "bus-1", delayed.Vehicle.ID.ID)
+
+	bus := manager.GetVehicleForTrip(ctx, "Route15-Southbound-MonSat-4")
+	require.NotNil(t, bus)
+	assert.Equal(t, "bus-1", bus.ID.ID)
+	assert.Equal(t, reportedAt, *bus.Timestamp)
+	assert.Equal(t, "1505", *bus.StopID)
+	assert.Equal(t, uint32(18), *bus.CurrentStopSequence)
+	assert.Equal(t, gtfsrt.VehiclePosition_STOPPED_AT, *bus.CurrentStatus)
+	assert.InDelta(t, 40.505951, *bus.Position.Latitude, 1e-5)
+
+	canceled, err := manager.GetTripUpdateByID("Route15-Southbound-MonSat-3")
+	require.NoError(t, err)
+	assert.Equal(t, gtfsrt.TripDescriptor_CANCELED, canceled.ID.ScheduleRelationship)
+
+	stopDelayed, err := manager.GetTripUpdateByID("Route15-Southbound-MonSat-2")
+	require.NoError(t, err)
+	require.Len(t, stopDelayed.StopTimeUpdates, 1)
+	stu := stopDelayed.StopTimeUpdates[0]
+	assert.Equal(t, "1505", *stu.StopID)
+	require.NotNil(t, stu.StopSequence, "the stop sequence comes from the static data")
+	assert.Equal(t, 90*time.Second, *stu.Arrival.Delay)
+
+	_, err = manager.GetTripUpdateByID("Route15-Northbound-MonSat-5")
+	assert.Error(t, err, "a trip with only a vehicle has no trip update")
+	bus2, err := manager.GetVehicleByID("bus-2")
+	require.NoError(t, err)
+	assert.InDelta(t, -122.39, *bus2.Position.Longitude, 1e-5)
+
+	require.Len(t, manager.GetRealTimeAlerts(), 1)
+
+	manager.MockResetRealTimeData()
+	assert.Empty(t, manager.GetRealTimeVehicles())
+}
+
+func TestScenarioApplyErrors(t *testing.T) {
+	manager := newScenarioTestManager(t)
+	ctx := context.Background()
+
+	err := NewScenario().WithVehicle("bus-1").Apply(ctx, manager)
+	assert.ErrorContains(t, err, "WithVehicle needs a trip")
+
+	err = NewScenario().WithTrip("Route15-Southbound-MonSat-4").WithVehicleAtStop("bus-1", "no-such-stop").Apply(ctx, manager)
+	assert.ErrorContains(t, err, "stop no-such-stop is not on trip")
+
+	manager.MockAddVehicle("kept", "trip1", "15")
+	assert.Len(t, manager.GetRealTimeVehicles(), 1, "a scenario that fails leaves the realtime data alone")
+}
@@ -0,0 +1,278 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// shapeSimplificationThreshold is the point count above which a shape is simplified
+	// before caching. Typical route shapes stay well below it; only very dense traces
+	// (e.g. raw GPS recordings) are reduced.
+	shapeSimplificationThreshold = 5000
+
+	// shapeSimplificationToleranceMeters bounds how far a simplified shape may deviate
+	// from the original polyline.
+	shapeSimplificationToleranceMeters = 1.0
+)
+
+// ShapeGeometry is a shape prepared for distance-along-trip computations.
+// Instances are shared between requests and must not be modified.
+type ShapeGeometry struct {
+	Points []gtfs.ShapePoint
+	// CumulativeDistances[i] is the distance in meters from the first point to Points[i].
+	CumulativeDistances []float64
+}
+
+// Length returns the total length of the shape in meters.
+func (g *ShapeGeometry) Length() float64 {
+	if g == nil || len(g.CumulativeDistances) == 0 {
+		return 0
+	}
+	return g.CumulativeDistances[len(g.CumulativeDistances)-1]
+}
+
+// PointAtDistance returns the point the given number of meters along the
+// shape, clamped to its ends.
+func (g *ShapeGeometry) PointAtDistance(distance float64) (lat, lon float64) {
+	if g == nil || len(g.Points) == 0 {
+		return 0, 0
+	}
+	if distance <= 0 {
+		return g.Points[0].Latitude, g.Points[0].Longitude
+	}
+	i := sort.SearchFloat64s(g.CumulativeDistances, distance)
+	if i >= len(g.Points) {
+		last := g.Points[len(g.Points)-1]
+		return last.Latitude, last.Longitude
+	}
+	from, to := g.Points[i-1], g.Points[i]
+	span := g.CumulativeDistances[i] - g.CumulativeDistances[i-1]
+	if span == 0 {
+		return to.Latitude, to.Longitude
+	}
+	ratio := (distance - g.CumulativeDistances[i-1]) / span
+	return from.Latitude + ratio*(to.Latitude-from.Latitude), from.Longitude + ratio*(to.Longitude-from.Longitude)
+}
+
+// newShapeGeometry simplifies dense shapes and precomputes cumulative distances.
+func newShapeGeometry(points []gtfs.ShapePoint) *ShapeGeometry {
+	return newShapeGeometryWithDistances(points, nil)
+}
+
+// newShapeGeometryWithDistances is newShapeGeometry for a shape whose
+// cumulative distances were stored at import. A simplified shape keeps the
+// stored distances of the points it keeps, so that it stays as long as the
+// stop distances stored along it expect.
+func newShapeGeometryWithDistances(points []gtfs.ShapePoint, distances []float64) *ShapeGeometry {
+	if len(points) > shapeSimplificationThreshold {
+		kept := simplifiedShapeIndices(points, shapeSimplificationToleranceMeters)
+		simplified := make([]gtfs.ShapePoint, len(kept))
+		for i, index := range kept {
+			simplified[i] = points[index]
+		}
+		if distances != nil {
+			keptDistances := make([]float64, len(kept))
+			for i, index := range kept {
+				keptDistances[i] = distances[index]
+			}
+			distances = keptDistances
+		}
+		points = simplified
+	}
+	if distances != nil {
+		return &ShapeGeometry{Points: points, CumulativeDistances: distances}
+	}
+
+	distances = make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		distances[i] = distances[i-1] + utils.Distance(
+			points[i-1].Latitude, points[i-1].Longitude,
+			points[i].Latitude, points[i].Longitude,
+		)
+	}
+
+	return &ShapeGeometry{Points: points, CumulativeDistances: distances}
+}
+
+// shapeGeometryCache memoizes shape geometry per shape ID and the shape ID of each
+// trip. It is replaced wholesale when static data is hot-swapped.
+type shapeGeometryCache struct {
+	mu         sync.RWMutex
+	geometries map[string]*ShapeGeometry
+	tripShapes map[string]string
+}
+
+func newShapeGeometryCache() *shapeGeometryCache {
+	return &shapeGeometryCache{
+		geometries: make(map[string]*ShapeGeometry),
+		tripShapes: make(map[string]string),
+	}
+}
+
+// GetShapeGeometry returns the cached geometry of a shape, loading it from the
+// database on first use. Returns nil if the shape has fewer than two points.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetShapeGeometry(ctx context.Context, shapeID string) (*ShapeGeometry, error) {
+	cache := manager.shapeGeometries
+	if cache != nil {
+		cache.mu.RLock()
+		geometry, ok := cache.geometries[shapeID]
+		cache.mu.RUnlock()
+		if ok {
+			return geometry, nil
+		}
+	}
+
+	rows, err := manager.GtfsDB.Queries.GetShapeByID(ctx, shapeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var geometry *ShapeGeometry
+	if len(rows) > 1 {
+		points := make([]gtfs.ShapePoint, len(rows))
+		distances := make([]float64, len(rows))
+		for i, row := range rows {
+			points[i] = gtfs.ShapePoint{Latitude: row.Lat, Longitude: row.Lon}
+			// Databases imported before distances were stored have none.
+			if distances != nil && row.DistanceAlongShape.Valid {
+				distances[i] = row.DistanceAlongShape.Float64
+			} else {
+				distances = nil
+			}
+		}
+		geometry = newShapeGeometryWithDistances(points, distances)
+	}
+
+	if cache != nil {
+		cache.mu.Lock()
+		cache.geometries[shapeID] = geometry
+		cache.mu.Unlock()
+	}
+	return geometry, nil
+}
+
+// GetShapeGeometryForTrip returns the cached geometry of a trip's shape, or nil if
+// the trip has no usable shape.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetShapeGeometryForTrip(ctx context.Context, tripID string) (*ShapeGeometry, error) {
+	cache := manager.shapeGeometries
+
+	var shapeID string
+	var known bool
+	if cache != nil {
+		cache.mu.RLock()
+		shapeID, known = cache.tripShapes[tripID]
+		cache.mu.RUnlock()
+	}
+
+	if !known {
+		trip, err := manager.GtfsDB.GetTrip(ctx, tripID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		shapeID = trip.ShapeID.String
+		if cache != nil {
+			cache.mu.Lock()
+			cache.tripShapes[tripID] = shapeID
+			cache.mu.Unlock()
+		}
+	}
+
+	if shapeID == "" {
+		return nil, nil
+	}
+	return manager.GetShapeGeometry(ctx, shapeID)
+}
+
+// simplifyShape reduces a polyline with the Douglas-Peucker algorithm, keeping every
+// point that lies further than toleranceMeters from the simplified line. The first
+// and last points are always kept.
+func simplifyShape(points []gtfs.ShapePoint, toleranceMeters float64) []gtfs.ShapePoint {
+	if len(points) < 3 {
+		return points
+	}
+	kept := simplifiedShapeIndices(points, toleranceMeters)
+	simplified := make([]gtfs.ShapePoint, len(kept))
+	for i, index := range kept {
+		simplified[i] = points[index]
+	}
+	return simplified
+}
+
+// simplifiedShapeIndices returns the indices of the points simplifyShape keeps.
+func simplifiedShapeIndices(points []gtfs.ShapePoint, toleranceMeters float64) []int {
+	if len(points) < 3 {
+		indices := make([]int, len(points))
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	// Project onto a local equirectangular plane in meters; accurate enough over the
+	// extent of a single shape and far cheaper than great-circle math per point.
+	const metersPerDegree = 111_320.0
+	lonScale := math.Cos(points[0].Latitude*math.Pi/180) * metersPerDegree
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		xs[i] = p.Longitude * lonScale
+		ys[i] = p.Latitude * metersPerDegree
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	type span struct{ first, last int }
+	stack := []span{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		maxDistance, index := 0.0, -1
+		for i := s.first + 1; i < s.last; i++ {
+			if d := pointSegmentDistance(xs[i], ys[i], xs[s.first], ys[s.first], xs[s.last], ys[s.last]); d > maxDistance {
+				maxDistance, index = d, i
+			}
+		}
+
+		if index >= 0 && maxDistance > toleranceMeters {
+			keep[index] = true
+			stack = append(stack, span{s.first, index}, span{index, s.last})
+		}
+	}
+
+	indices := make([]int, 0, len(points)/4)
+	for i := range points {
+		if keep[i] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// pointSegmentDistance returns the planar distance from (px, py) to the segment
+// (x1, y1)-(x2, y2).
+func pointSegmentDistance(px, py, x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(px-x1, py-y1)
+	}
+
+	t := ((px-x1)*dx + (py-y1)*dy) / lengthSquared
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(px-(x1+t*dx), py-(y1+t*dy))
+}
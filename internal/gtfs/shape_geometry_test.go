@@ -0,0 +1,181 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func newShapeGeometryTestManager(t *testing.T) *Manager {
+	t.Helper()
+	client, err := gtfsdb.NewClient(gtfsdb.Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	manager := newTestManager()
+	manager.GtfsDB = client
+	manager.shapeGeometries = newShapeGeometryCache()
+	return manager
+}
+
+func TestGetShapeGeometry_CachesCumulativeDistances(t *testing.T) {
+	manager := newShapeGeometryTestManager(t)
+	ctx := context.Background()
+
+	points := []gtfsdb.CreateShapeParams{
+		{ShapeID: "s1", Lat: 47.60, Lon: -122.30, ShapePtSequence: 0},
+		{ShapeID: "s1", Lat: 47.61, Lon: -122.30, ShapePtSequence: 1},
+		{ShapeID: "s1", Lat: 47.61, Lon: -122.31, ShapePtSequence: 2},
+	}
+	for _, p := range points {
+		_, err := manager.GtfsDB.Queries.CreateShape(ctx, p)
+		require.NoError(t, err)
+	}
+
+	geometry, err := manager.GetShapeGeometry(ctx, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, geometry)
+	require.Len(t, geometry.Points, 3)
+
+	first := utils.Distance(47.60, -122.30, 47.61, -122.30)
+	second := utils.Distance(47.61, -122.30, 47.61, -122.31)
+	assert.Equal(t, []float64{0, first, first + second}, geometry.CumulativeDistances)
+	assert.InDelta(t, first+second, geometry.Length(), 1e-9)
+
+	// Subsequent lookups are served from the cache, even if the table changes.
+	require.NoError(t, manager.GtfsDB.Queries.ClearShapes(ctx))
+	cached, err := manager.GetShapeGeometry(ctx, "s1")
+	require.NoError(t, err)
+	assert.Same(t, geometry, cached)
+}
+
+func TestGetShapeGeometry_UsesStoredDistances(t *testing.T) {
+	manager := newShapeGeometryTestManager(t)
+	ctx := context.Background()
+
+	stored := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	points := []gtfsdb.CreateShapeParams{
+		{ShapeID: "stored", Lat: 47.60, Lon: -122.30, ShapePtSequence: 0, DistanceAlongShape: stored(0)},
+		{ShapeID: "stored", Lat: 47.61, Lon: -122.30, ShapePtSequence: 1, DistanceAlongShape: stored(1000)},
+		{ShapeID: "stored", Lat: 47.61, Lon: -122.31, ShapePtSequence: 2, DistanceAlongShape: stored(1500)},
+		// A point without a stored distance makes the whole shape fall back
+		// to measuring it.
+		{ShapeID: "partial", Lat: 47.60, Lon: -122.30, ShapePtSequence: 0, DistanceAlongShape: stored(0)},
+		{ShapeID: "partial", Lat: 47.61, Lon: -122.30, ShapePtSequence: 1},
+	}
+	for _, p := range points {
+		_, err := manager.GtfsDB.Queries.CreateShape(ctx, p)
+		require.NoError(t, err)
+	}
+
+	geometry, err := manager.GetShapeGeometry(ctx, "stored")
+	require.NoError(t, err)
+	require.NotNil(t, geometry)
+	assert.Equal(t, []float64{0, 1000, 1500}, geometry.CumulativeDistances)
+
+	geometry, err = manager.GetShapeGeometry(ctx, "partial")
+	require.NoError(t, err)
+	require.NotNil(t, geometry)
+	assert.InDelta(t, utils.Distance(47.60, -122.30, 47.61, -122.30), geometry.Length(), 1e-9)
+}
+
+func TestGetShapeGeometry_MissingShape(t *testing.T) {
+	manager := newShapeGeometryTestManager(t)
+
+	geometry, err := manager.GetShapeGeometry(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Nil(t, geometry)
+	assert.Zero(t, geometry.Length())
+
+	geometry, err = manager.GetShapeGeometryForTrip(context.Background(), "missing-trip")
+	require.NoError(t, err)
+	assert.Nil(t, geometry)
+}
+
+func TestSimplifyShape(t *testing.T) {
+	t.Run("collinear points collapse to endpoints", func(t *testing.T) {
+		var points []gtfs.ShapePoint
+		for i := 0; i <= 100; i++ {
+			points = append(points, gtfs.ShapePoint{Latitude: 47.6 + float64(i)*0.0001, Longitude: -122.3})
+		}
+
+		simplified := simplifyShape(points, 1.0)
+		require.Len(t, simplified, 2)
+		assert.Equal(t, points[0], simplified[0])
+		assert.Equal(t, points[100], simplified[1])
+	})
+
+	t.Run("corners are preserved", func(t *testing.T) {
+		points := []gtfs.ShapePoint{
+			{Latitude: 47.600, Longitude: -122.300},
+			{Latitude: 47.605, Longitude: -122.300},
+			{Latitude: 47.610, Longitude: -122.300},
+			{Latitude: 47.610, Longitude: -122.305},
+			{Latitude: 47.610, Longitude: -122.310},
+		}
+
+		simplified := simplifyShape(points, 1.0)
+		assert.Equal(t, []gtfs.ShapePoint{points[0], points[2], points[4]}, simplified)
+	})
+
+	t.Run("short shapes are returned unchanged", func(t *testing.T) {
+		points := []gtfs.ShapePoint{{Latitude: 1, Longitude: 1}, {Latitude: 2, Longitude: 2}}
+		assert.Equal(t, points, simplifyShape(points, 1.0))
+	})
+}
+
+func TestNewShapeGeometry_SimplifiesDenseShapes(t *testing.T) {
+	points := make([]gtfs.ShapePoint, shapeSimplificationThreshold+1)
+	for i := range points {
+		points[i] = gtfs.ShapePoint{Latitude: 47.6 + float64(i)*0.000001, Longitude: -122.3}
+	}
+
+	geometry := newShapeGeometry(points)
+	assert.Len(t, geometry.Points, 2)
+	assert.InDelta(t, utils.Distance(points[0].Latitude, points[0].Longitude, points[len(points)-1].Latitude, points[len(points)-1].Longitude), geometry.Length(), 1e-6)
+}
+
+func TestNewShapeGeometryWithDistances_KeepsStoredDistancesWhenSimplifying(t *testing.T) {
+	points := make([]gtfs.ShapePoint, shapeSimplificationThreshold+1)
+	distances := make([]float64, len(points))
+	for i := range points {
+		points[i] = gtfs.ShapePoint{Latitude: 47.6 + float64(i)*0.000001, Longitude: -122.3}
+		distances[i] = float64(i) * 0.2
+	}
+
+	geometry := newShapeGeometryWithDistances(points, distances)
+	assert.Len(t, geometry.Points, 2)
+	assert.Equal(t, []float64{0, distances[len(distances)-1]}, geometry.CumulativeDistances)
+}
+
+func TestShapeGeometryPointAtDistance(t *testing.T) {
+	geometry := newShapeGeometry([]gtfs.ShapePoint{
+		{Latitude: 47.60, Longitude: -122.30},
+		{Latitude: 47.61, Longitude: -122.30},
+		{Latitude: 47.61, Longitude: -122.29},
+	})
+	first := geometry.CumulativeDistances[1]
+
+	lat, lon := geometry.PointAtDistance(-10)
+	assert.Equal(t, 47.60, lat)
+	assert.Equal(t, -122.30, lon)
+
+	lat, lon = geometry.PointAtDistance(first / 2)
+	assert.InDelta(t, 47.605, lat, 1e-9)
+	assert.InDelta(t, -122.30, lon, 1e-9)
+
+	lat, lon = geometry.PointAtDistance(first)
+	assert.InDelta(t, 47.61, lat, 1e-9)
+	assert.InDelta(t, -122.30, lon, 1e-9)
+
+	lat, lon = geometry.PointAtDistance(geometry.Length() + 10)
+	assert.Equal(t, 47.61, lat)
+	assert.Equal(t, -122.29, lon)
+}
@@ -1,7 +1,11 @@
 package gtfs
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -112,3 +116,81 @@ func TestManagerShutdownIdempotent(t *testing.T) {
 	manager.Shutdown()
 	manager.Shutdown() // Second call should be safe
 }
+
+func TestManagerShutdownCancelsInFlightFetch(t *testing.T) {
+	testDataPath, err := filepath.Abs(filepath.Join("..", "..", "testdata", "raba.zip"))
+	require.NoError(t, err, "Failed to get test data path")
+
+	// The warm-up fetch is answered; every later poll hangs until canceled.
+	var requests atomic.Int32
+	polling := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		select {
+		case polling <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      testDataPath,
+		GTFSDataPath: ":memory:",
+		RTFeeds: []RTFeedConfig{{
+			ID:                  "test-feed",
+			VehiclePositionsURL: server.URL,
+			RefreshInterval:     1,
+			Enabled:             true,
+		}},
+		Env: appconf.Test,
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-polling:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller never fetched")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, manager.ShutdownContext(ctx))
+	assert.Less(t, time.Since(start), 5*time.Second, "shutdown should cancel the fetch rather than wait out its 15 second timeout")
+}
+
+func TestManagerShutdownContextDeadline(t *testing.T) {
+	manager := &Manager{shutdownChan: make(chan struct{})}
+
+	release := make(chan struct{})
+	defer close(release)
+	manager.wg.Add(1)
+	go func() {
+		defer manager.wg.Done()
+		<-release
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := manager.ShutdownContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithShutdown(t *testing.T) {
+	manager := &Manager{shutdownChan: make(chan struct{})}
+
+	ctx, cancel := manager.withShutdown(context.Background(), time.Minute)
+	defer cancel()
+	assert.NoError(t, ctx.Err())
+
+	close(manager.shutdownChan)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled on shutdown")
+	}
+}
@@ -1,91 +1,50 @@
 package gtfs
 
 import (
+	"cmp"
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
 )
 
-func rawGtfsData(source string, isLocalFile bool, config Config) ([]byte, error) {
-	var b []byte
-	var err error
-
-	logger := slog.Default().With(slog.String("component", "gtfs_loader"))
-
-	if isLocalFile {
-		b, err = os.ReadFile(source)
-		if err != nil {
-			return nil, fmt.Errorf("error reading local GTFS file: %w", err)
-		}
-	} else {
-		req, err := http.NewRequest("GET", source, nil)
-		if err != nil {
-			return nil, fmt.Errorf("error creating GTFS request: %w", err)
-		}
-
-		// Add auth header if provided
-		if config.StaticAuthHeaderKey != "" && config.StaticAuthHeaderValue != "" {
-			req.Header.Set(config.StaticAuthHeaderKey, config.StaticAuthHeaderValue)
-		}
-
-		client := &http.Client{
-			Timeout: 5 * time.Minute,
-			Transport: &http.Transport{
-				TLSHandshakeTimeout:   10 * time.Second,
-				ResponseHeaderTimeout: 30 * time.Second,
-				IdleConnTimeout:       90 * time.Second,
-			}}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("error downloading GTFS data: %w", err)
-		}
-		defer logging.SafeCloseWithLogging(resp.Body,
-			slog.Default().With(slog.String("component", "gtfs_downloader")),
-			"http_response_body")
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("failed to download GTFS data: received HTTP status %s", resp.Status)
-		}
-		const maxStaticSize = 200 * 1024 * 1024
-		b, err = io.ReadAll(io.LimitReader(resp.Body, maxStaticSize+1))
-		if err != nil {
-			return nil, fmt.Errorf("error reading GTFS data: %w", err)
-		}
-		if int64(len(b)) > maxStaticSize {
-			return nil, fmt.Errorf("static GTFS response exceeds size limit of %d bytes", maxStaticSize)
-		}
+// fetchStaticGTFS reads the static feed from a local file or downloads it.
+// known holds the validators of the feed already imported from source, if any,
+// making the download conditional.
+func fetchStaticGTFS(ctx context.Context, source string, isLocalFile bool, config Config, known gtfsdb.FeedValidators) (*staticFeed, error) {
+	if !isLocalFile {
+		return downloadStaticGTFS(ctx, source, config, known)
 	}
-
-	// Process through gtfstidy if enabled
-	if config.EnableGTFSTidy {
-		logging.LogOperation(logger, "gtfstidy_enabled_processing_gtfs_data")
-		tidiedData, err := tidyGTFSData(b, logger)
-		if err != nil {
-			logging.LogError(logger, "Failed to tidy GTFS data, using original data", err)
-		} else {
-			b = tidiedData
-		}
+	b, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("error reading local GTFS file: %w", err)
 	}
+	return &staticFeed{data: b}, nil
+}
 
-	return b, nil
+// newDBConfig configures the client of the database at dbPath.
+func newDBConfig(config Config, dbPath string) gtfsdb.Config {
+	dbConfig := gtfsdb.NewConfig(dbPath, config.Env, config.Verbose)
+	dbConfig.ReadConnections = config.DBReadConnections
+	return dbConfig
 }
 
-func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client, error) {
+func buildGtfsDB(config Config, feed *staticFeed, dbPath string) (*gtfsdb.Client, error) {
 	// If no specific path is provided, use the one from config
 	if dbPath == "" {
 		dbPath = config.GTFSDataPath
 	}
-	dbConfig := gtfsdb.NewConfig(dbPath, config.Env, config.Verbose)
+	dbConfig := newDBConfig(config, dbPath)
+	dbConfig.ReferentialIntegrity = config.ReferentialIntegrity
 	client, err := gtfsdb.NewClient(dbConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GTFS database client: %w", err)
@@ -93,13 +52,7 @@ func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client
 
 	ctx := context.Background()
 
-	if isLocalFile {
-		err = client.ImportFromFile(ctx, config.GtfsURL)
-	} else {
-		err = client.DownloadAndStore(ctx, config.GtfsURL, config.StaticAuthHeaderKey, config.StaticAuthHeaderValue)
-	}
-
-	if err != nil {
+	if err := client.ImportFromData(ctx, feed.data, config.GtfsURL, feed.validators); err != nil {
 		return nil, err
 	}
 
@@ -114,14 +67,23 @@ func buildGtfsDB(config Config, isLocalFile bool, dbPath string) (*gtfsdb.Client
 	return client, nil
 }
 
-// loadGTFSData loads and parses GTFS data from either a URL or a local file
-func loadGTFSData(source string, isLocalFile bool, config Config) (*gtfs.Static, error) {
-	b, err := rawGtfsData(source, isLocalFile, config)
-	if err != nil {
-		return nil, fmt.Errorf("error reading GTFS data: %w", err)
+// parseGTFSData parses a fetched static feed, tidying it first when enabled.
+func parseGTFSData(feed *staticFeed, config Config) (*gtfs.Static, error) {
+	b := feed.data
+
+	// Process through gtfstidy if enabled
+	if config.EnableGTFSTidy {
+		logger := slog.Default().With(slog.String("component", "gtfs_loader"))
+		logging.LogOperation(logger, "gtfstidy_enabled_processing_gtfs_data")
+		tidiedData, err := tidyGTFSData(b, logger)
+		if err != nil {
+			logging.LogError(logger, "Failed to tidy GTFS data, using original data", err)
+		} else {
+			b = tidiedData
+		}
 	}
 
-	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+	staticData, err := gtfs.ParseStatic(b, gtfs.ParseStaticOptions{InheritWheelchairBoarding: true})
 	if err != nil {
 		return nil, fmt.Errorf("error parsing GTFS data: %w", err)
 	}
@@ -129,7 +91,9 @@ func loadGTFSData(source string, isLocalFile bool, config Config) (*gtfs.Static,
 	return staticData, nil
 }
 
-// UpdateGTFSPeriodically updates the GTFS data on a regular schedule
+// updateStaticGTFS re-downloads the static feed every StaticRefreshInterval and
+// hot-swaps it in via ForceUpdate, so a long-running server picks up new schedules
+// without a restart.
 func (manager *Manager) updateStaticGTFS() { // nolint
 	defer manager.wg.Done()
 
@@ -143,15 +107,21 @@ func (manager *Manager) updateStaticGTFS() { // nolint
 		return
 	}
 
-	// Update every 24 hours
-	ticker := time.NewTicker(24 * time.Hour)
+	interval := manager.config.staticRefreshInterval()
+	logging.LogOperation(logger, "static_gtfs_refresh_scheduled",
+		slog.String("source", manager.config.GtfsURL),
+		slog.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for { // nolint
 		select {
 		case <-ticker.C:
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			// The download may take its whole timeout; the import and swap
+			// keep the five minutes they always had.
+			ctx, cancel := context.WithTimeout(context.Background(), manager.config.staticDownloadTimeout()+5*time.Minute)
 
 			err := manager.ForceUpdate(ctx)
 			cancel()
@@ -172,7 +142,8 @@ func (manager *Manager) updateStaticGTFS() { // nolint
 // ForceUpdate performs a thread-safe, mutex protected hot-swap of the GTFS static data and database.
 //
 // This process involves several critical steps to ensure data integrity and minimal downtime:
-//  1. Fetching Data: Downloads or reads the latest GTFS data from the configured source.
+//  1. Fetching Data: Downloads or reads the latest GTFS data from the configured source. A download is
+//     conditional on the validators of the imported feed, and an unchanged feed ends the update here.
 //  2. Staging: Creates a temporary SQLite database ("*.temp.db") and populates it with the new data.
 //  3. Precomputation: Builds necessary indices (e.g., stop spatial index, block layover indices) using the temporary database to ensure the new data is ready for query immediately upon swapping.
 //  4. Mutex Protected Swap:
@@ -190,7 +161,33 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 
 	logger := slog.Default().With(slog.String("component", "gtfs_updater"))
 
-	newStaticData, err := loadGTFSData(manager.config.GtfsURL, manager.isLocalFile, manager.config)
+	// Only a feed that differs from the imported one is downloaded.
+	var known gtfsdb.FeedValidators
+	manager.staticMutex.RLock()
+	currentDB := manager.GtfsDB
+	manager.staticMutex.RUnlock()
+	if currentDB != nil {
+		validators, err := currentDB.ImportValidators(ctx, manager.config.GtfsURL)
+		if err != nil {
+			logging.LogError(logger, "Failed to read import validators, downloading unconditionally", err)
+		} else {
+			known = validators
+		}
+	}
+
+	feed, err := fetchStaticGTFS(ctx, manager.config.GtfsURL, manager.isLocalFile, manager.config, known)
+	if err != nil {
+		logging.LogError(logger, "Error updating GTFS data", err,
+			slog.String("source", manager.config.GtfsURL))
+		return err
+	}
+	if feed.notModified {
+		logging.LogOperation(logger, "gtfs_static_data_not_modified_skipping_update",
+			slog.String("source", manager.config.GtfsURL))
+		return nil
+	}
+
+	newStaticData, err := parseGTFSData(feed, manager.config)
 	if err != nil {
 		logging.LogError(logger, "Error updating GTFS data", err,
 			slog.String("source", manager.config.GtfsURL))
@@ -208,7 +205,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		logging.LogError(logger, "Failed to remove existing temp DB", err)
 	}
 
-	newGtfsDB, err := buildGtfsDB(manager.config, manager.isLocalFile, tempDBPath)
+	newGtfsDB, err := buildGtfsDB(manager.config, feed, tempDBPath)
 	if err != nil {
 		logging.LogError(logger, "Error building new GTFS DB", err)
 		return err
@@ -238,6 +235,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 	}
 
 	newRegionBounds := ComputeRegionBounds(newStaticData.Shapes, newStaticData.Stops)
+	newAgencyCoverage := computeAgencyCoverage(newStaticData)
 
 	if err := ctx.Err(); err != nil {
 		if closeErr := newGtfsDB.Close(); closeErr != nil {
@@ -249,15 +247,24 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		return err
 	}
 
-	if err := newGtfsDB.Close(); err != nil {
-		logging.LogError(logger, "Error closing new GTFS DB", err)
-		return err
-	}
+	// The swap lock is taken before the service history is carried over, so
+	// that no observation recorded in the old database is left behind.
 	manager.staticMutex.Lock()
 	defer manager.staticMutex.Unlock()
 
 	oldGtfsDB := manager.GtfsDB
 
+	if oldGtfsDB != nil && finalDBPath != ":memory:" {
+		if err := newGtfsDB.CopyServiceHistory(ctx, finalDBPath); err != nil {
+			logging.LogError(logger, "Failed to carry service history over to new GTFS DB", err)
+		}
+	}
+
+	if err := newGtfsDB.Close(); err != nil {
+		logging.LogError(logger, "Error closing new GTFS DB", err)
+		return err
+	}
+
 	if oldGtfsDB != nil {
 		if err := oldGtfsDB.Close(); err != nil {
 			logging.LogError(logger, "Error closing old GTFS DB, did not swap DB", err)
@@ -275,7 +282,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 
 		logging.LogOperation(logger, "attempting_recovery_reopening_old_db")
 
-		dbConfig := gtfsdb.NewConfig(finalDBPath, manager.config.Env, manager.config.Verbose)
+		dbConfig := newDBConfig(manager.config, finalDBPath)
 		if reopenedClient, reopenErr := gtfsdb.NewClient(dbConfig); reopenErr == nil {
 			manager.GtfsDB = reopenedClient
 			logging.LogOperation(logger, "recovery_successful_old_db_reopened")
@@ -290,7 +297,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 		return err
 	}
 
-	dbConfig := gtfsdb.NewConfig(finalDBPath, manager.config.Env, manager.config.Verbose)
+	dbConfig := newDBConfig(manager.config, finalDBPath)
 	client, err := gtfsdb.NewClient(dbConfig)
 
 	if err != nil {
@@ -309,6 +316,9 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 	manager.blockLayoverIndices = newBlockLayoverIndices
 	manager.stopSpatialIndex = newStopSpatialIndex
 	manager.regionBounds = newRegionBounds
+	manager.agencyCoverage = newAgencyCoverage
+	manager.shapeGeometries = newShapeGeometryCache()
+	manager.maxServiceTime = loadMaxServiceTime(ctx, client.Queries)
 
 	manager.routesByAgencyID = buildRouteIndex(newStaticData)
 
@@ -327,6 +337,7 @@ func (manager *Manager) ForceUpdate(ctx context.Context) error {
 	}
 
 	manager.isHealthy = true
+	manager.dataGeneration.Add(1)
 
 	logging.LogOperation(logger, "gtfs_static_data_updated_hot_swap",
 		slog.String("source", manager.config.GtfsURL),
@@ -344,6 +355,7 @@ func (manager *Manager) setStaticGTFS(staticData *gtfs.Static) {
 	manager.gtfsData = staticData
 	manager.lastUpdated = time.Now()
 	manager.isHealthy = true
+	manager.dataGeneration.Add(1)
 
 	manager.agenciesMap, manager.routesMap = buildLookupMaps(staticData)
 
@@ -351,10 +363,13 @@ func (manager *Manager) setStaticGTFS(staticData *gtfs.Static) {
 
 	manager.blockLayoverIndices = buildBlockLayoverIndices(staticData)
 	manager.regionBounds = ComputeRegionBounds(staticData.Shapes, staticData.Stops)
+	manager.agencyCoverage = computeAgencyCoverage(staticData)
 
 	// Rebuild spatial index with updated data
 	ctx := context.Background()
 	if manager.GtfsDB != nil && manager.GtfsDB.Queries != nil {
+		manager.maxServiceTime = loadMaxServiceTime(ctx, manager.GtfsDB.Queries)
+
 		spatialIndex, err := buildStopSpatialIndex(ctx, manager.GtfsDB.Queries)
 		if err == nil {
 			manager.stopSpatialIndex = spatialIndex
@@ -409,5 +424,27 @@ func buildRouteIndex(staticData *gtfs.Static) map[string][]*gtfs.Route {
 		index[agencyID] = append(index[agencyID], route)
 	}
 
+	// List each agency's routes in its preferred order
+	for _, routes := range index {
+		slices.SortStableFunc(routes, func(a, b *gtfs.Route) int {
+			return cmp.Or(models.CompareSortOrder(a.SortOrder, b.SortOrder), strings.Compare(a.Id, b.Id))
+		})
+	}
+
 	return index
 }
+
+// fallbackMaxServiceTime is assumed when the latest stop time cannot be read:
+// long enough for overnight trips to be found on the previous service day.
+const fallbackMaxServiceTime = utils.ServiceTime(48 * time.Hour)
+
+// loadMaxServiceTime reads the latest stop time in the feed.
+func loadMaxServiceTime(ctx context.Context, queries *gtfsdb.Queries) utils.ServiceTime {
+	maxStopTime, err := queries.GetMaxStopTime(ctx)
+	if err != nil {
+		logger := slog.Default().With(slog.String("component", "gtfs_manager"))
+		logging.LogError(logger, "Failed to read latest stop time", err)
+		return fallbackMaxServiceTime
+	}
+	return utils.NewServiceTime(maxStopTime)
+}
@@ -0,0 +1,201 @@
+package gtfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+const (
+	// maxStaticSize caps a static feed download.
+	maxStaticSize = 200 * 1024 * 1024
+	// maxStaticDownloadAttempts is how many requests one download may take
+	// when its connection drops partway, resuming from the bytes received.
+	maxStaticDownloadAttempts = 3
+)
+
+// errStaticDownloadInterrupted marks a download whose body stopped partway;
+// it may be resumed.
+var errStaticDownloadInterrupted = errors.New("static GTFS download interrupted")
+
+// staticFeed is a static GTFS zip as read from a file or downloaded.
+type staticFeed struct {
+	data       []byte
+	validators gtfsdb.FeedValidators // empty for files and servers sending none
+	// notModified is set when the server confirmed the known validators still
+	// match; data is then empty.
+	notModified bool
+}
+
+// downloadStaticGTFS downloads the static feed at source within the configured
+// download timeout. When known holds the validators of the feed already
+// imported, the request is conditional and an unchanged feed is not sent
+// again. A connection that drops partway is resumed with a range request for
+// the remaining bytes, provided the server identifies the same feed.
+func downloadStaticGTFS(ctx context.Context, source string, config Config, known gtfsdb.FeedValidators) (*staticFeed, error) {
+	logger := slog.Default().With(slog.String("component", "gtfs_downloader"))
+
+	ctx, cancel := context.WithTimeout(ctx, config.staticDownloadTimeout())
+	defer cancel()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		}}
+
+	var body bytes.Buffer
+	var validators gtfsdb.FeedValidators
+	var err error
+	for attempt := 1; attempt <= maxStaticDownloadAttempts; attempt++ {
+		var download *staticFeed
+		download, err = requestStaticGTFS(ctx, client, source, config, known, validators, &body)
+		if err == nil {
+			return download, nil
+		}
+		if !errors.Is(err, errStaticDownloadInterrupted) || ctx.Err() != nil {
+			return nil, err
+		}
+		if download != nil {
+			validators = download.validators
+		}
+		if attempt == maxStaticDownloadAttempts {
+			break
+		}
+		logging.LogError(logger, "Static GTFS download interrupted, resuming", err,
+			slog.String("source", source),
+			slog.Int("attempt", attempt),
+			slog.Int("bytes_received", body.Len()))
+	}
+	return nil, err
+}
+
+// requestStaticGTFS makes one request for the static feed, appending to body
+// the bytes received. With bytes already in body, it asks only for the rest,
+// and only if the feed still has the validators those bytes were served with;
+// otherwise the server sends the whole feed and body starts over.
+//
+// An interrupted body is reported as errStaticDownloadInterrupted together
+// with the validators of the response, so that the next request can resume it.
+func requestStaticGTFS(ctx context.Context, client *http.Client, source string, config Config,
+	known, partial gtfsdb.FeedValidators, body *bytes.Buffer) (*staticFeed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating GTFS request: %w", err)
+	}
+
+	// Add auth header if provided
+	if config.StaticAuthHeaderKey != "" && config.StaticAuthHeaderValue != "" {
+		req.Header.Set(config.StaticAuthHeaderKey, config.StaticAuthHeaderValue)
+	}
+
+	resuming := body.Len() > 0 && !partial.IsZero()
+	switch {
+	case resuming:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", body.Len()))
+		// A strong ETag identifies the exact bytes; otherwise fall back to the
+		// modification date.
+		if partial.ETag != "" && !strings.HasPrefix(partial.ETag, "W/") {
+			req.Header.Set("If-Range", partial.ETag)
+		} else if partial.LastModified != "" {
+			req.Header.Set("If-Range", partial.LastModified)
+		} else {
+			resuming = false
+			req.Header.Del("Range")
+		}
+	case body.Len() == 0:
+		if known.ETag != "" {
+			req.Header.Set("If-None-Match", known.ETag)
+		}
+		if known.LastModified != "" {
+			req.Header.Set("If-Modified-Since", known.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: %w", errStaticDownloadInterrupted, err)
+		}
+		return nil, fmt.Errorf("error downloading GTFS data: %w", err)
+	}
+	defer logging.SafeCloseWithLogging(resp.Body,
+		slog.Default().With(slog.String("component", "gtfs_downloader")),
+		"http_response_body")
+
+	validators := gtfsdb.FeedValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if body.Len() > 0 {
+			return nil, fmt.Errorf("failed to download GTFS data: unexpected HTTP status %s while resuming", resp.Status)
+		}
+		// A 304 need not repeat every validator; those it leaves out still hold.
+		if validators.ETag == "" {
+			validators.ETag = known.ETag
+		}
+		if validators.LastModified == "" {
+			validators.LastModified = known.LastModified
+		}
+		return &staticFeed{validators: validators, notModified: true}, nil
+	case http.StatusOK:
+		// The whole feed, either because the server ignores ranges or because
+		// the feed changed since the interrupted request.
+		body.Reset()
+	case http.StatusPartialContent:
+		if !resuming {
+			return nil, fmt.Errorf("failed to download GTFS data: unexpected HTTP status %s", resp.Status)
+		}
+		start, ok := contentRangeStart(resp.Header.Get("Content-Range"))
+		if !ok || start != int64(body.Len()) {
+			return nil, fmt.Errorf("failed to download GTFS data: range %q does not resume at byte %d",
+				resp.Header.Get("Content-Range"), body.Len())
+		}
+		validators = partial
+	default:
+		return nil, fmt.Errorf("failed to download GTFS data: received HTTP status %s", resp.Status)
+	}
+
+	remaining := int64(maxStaticSize + 1 - body.Len())
+	if _, err := io.Copy(body, io.LimitReader(resp.Body, remaining)); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("error reading GTFS data: %w", err)
+		}
+		return &staticFeed{validators: validators},
+			fmt.Errorf("%w: %w", errStaticDownloadInterrupted, err)
+	}
+	if int64(body.Len()) > maxStaticSize {
+		return nil, fmt.Errorf("static GTFS response exceeds size limit of %d bytes", maxStaticSize)
+	}
+
+	return &staticFeed{data: body.Bytes(), validators: validators}, nil
+}
+
+// contentRangeStart parses the first byte position of a Content-Range header
+// such as "bytes 100-199/200".
+func contentRangeStart(header string) (int64, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	return start, err == nil
+}
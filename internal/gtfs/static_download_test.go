@@ -0,0 +1,163 @@
+package gtfs
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+// interruptingFeedServer serves feed under etag, dropping the connection
+// halfway through every full response it sends until interruptions run out.
+// Range requests are answered by http.ServeContent, which honours If-Range.
+func interruptingFeedServer(t *testing.T, feed *[]byte, etag *string, interruptions int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", *etag)
+		modified := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+		if r.Header.Get("Range") == "" && interruptions > 0 {
+			interruptions--
+			w.Header().Set("Content-Length", strconv.Itoa(len(*feed)))
+			_, _ = w.Write((*feed)[:len(*feed)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "gtfs.zip", modified, bytes.NewReader(*feed))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDownloadStaticGTFS_Conditional(t *testing.T) {
+	feed := bytes.Repeat([]byte("gtfs"), 1000)
+	etag := `"v1"`
+	server, requests := interruptingFeedServer(t, &feed, &etag, 0)
+
+	download, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, gtfsdb.FeedValidators{})
+	require.NoError(t, err)
+	assert.False(t, download.notModified)
+	assert.Equal(t, feed, download.data)
+	assert.Equal(t, `"v1"`, download.validators.ETag)
+	assert.Equal(t, "Sun, 01 Jun 2025 00:00:00 GMT", download.validators.LastModified)
+
+	unchanged, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, download.validators)
+	require.NoError(t, err)
+	assert.True(t, unchanged.notModified)
+	assert.Empty(t, unchanged.data)
+	assert.Equal(t, download.validators, unchanged.validators)
+
+	etag = `"v2"`
+	changed, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, download.validators)
+	require.NoError(t, err)
+	assert.False(t, changed.notModified, "a feed with a new ETag is downloaded")
+	assert.Equal(t, feed, changed.data)
+	assert.Equal(t, `"v2"`, changed.validators.ETag)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestDownloadStaticGTFS_ResumesInterruptedDownload(t *testing.T) {
+	feed := bytes.Repeat([]byte("0123456789"), 10_000)
+	etag := `"v1"`
+	server, requests := interruptingFeedServer(t, &feed, &etag, 1)
+
+	download, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, gtfsdb.FeedValidators{})
+	require.NoError(t, err)
+	assert.Equal(t, feed, download.data)
+	assert.Equal(t, `"v1"`, download.validators.ETag)
+	assert.Equal(t, int32(2), requests.Load(), "the second request only fetches the missing half")
+}
+
+func TestDownloadStaticGTFS_RestartsWhenFeedChangesWhileResuming(t *testing.T) {
+	feed := bytes.Repeat([]byte("0123456789"), 10_000)
+	etag := `"v1"`
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Length", strconv.Itoa(len(feed)))
+			_, _ = w.Write(feed[:len(feed)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		// The feed was replaced between the two requests, so If-Range fails
+		// and the whole new feed is sent.
+		assert.Equal(t, `"v1"`, r.Header.Get("If-Range"))
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "gtfs.zip", time.Time{}, bytes.NewReader(bytes.ToUpper(feed)))
+	}))
+	defer server.Close()
+
+	download, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, gtfsdb.FeedValidators{})
+	require.NoError(t, err)
+	assert.Equal(t, bytes.ToUpper(feed), download.data)
+	assert.Equal(t, `"v2"`, download.validators.ETag)
+}
+
+func TestDownloadStaticGTFS_GivesUpAfterRepeatedInterruptions(t *testing.T) {
+	feed := bytes.Repeat([]byte("0123456789"), 10_000)
+	// Without validators the partial body cannot be resumed, so every attempt
+	// starts over and is interrupted again.
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Length", strconv.Itoa(len(feed)))
+		_, _ = w.Write(feed[:len(feed)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	_, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, gtfsdb.FeedValidators{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errStaticDownloadInterrupted)
+	assert.Equal(t, int32(maxStaticDownloadAttempts), requests.Load())
+}
+
+func TestDownloadStaticGTFS_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := downloadStaticGTFS(context.Background(), server.URL, Config{StaticDownloadTimeout: 100 * time.Millisecond}, gtfsdb.FeedValidators{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestDownloadStaticGTFS_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := downloadStaticGTFS(context.Background(), server.URL, Config{}, gtfsdb.FeedValidators{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.NotErrorIs(t, err, errStaticDownloadInterrupted)
+}
+
+func TestContentRangeStart(t *testing.T) {
+	start, ok := contentRangeStart("bytes 100-199/200")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), start)
+
+	for _, header := range []string{"", "bytes */200", "items 1-2/3"} {
+		_, ok := contentRangeStart(header)
+		assert.False(t, ok, header)
+	}
+}
@@ -0,0 +1,126 @@
+package gtfs
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// RankedStop is a stop found by GetRankedStopsForLocation, with its distance in
+// meters from the search point and, for a query search, its relevance score.
+type RankedStop struct {
+	Stop     gtfsdb.Stop
+	Distance float64
+	// Score runs from 0 to 1, higher being a better match. It is only set
+	// when the search has a query.
+	Score float64
+}
+
+// The weights of the parts of a stop's score. How well the name or code
+// matches counts most, so that a close but poorly matching stop does not
+// outrank the one the rider typed.
+const (
+	nameMatchWeight = 0.6
+	proximityWeight = 0.25
+	routesWeight    = 0.15
+
+	// routeCountSaturation is the number of serving routes from which a stop
+	// gets the full routes part of its score.
+	routeCountSaturation = 5
+)
+
+// nameMatchScore rates how well a stop's code or name matches query, from 1
+// for its exact code or name to 0 for no match. Matching ignores case.
+func nameMatchScore(stop gtfsdb.Stop, query string) float64 {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0
+	}
+	if stop.Code.Valid && strings.ToLower(stop.Code.String) == query {
+		return 1
+	}
+	name := strings.ToLower(stop.Name.String)
+	switch {
+	case name == query:
+		return 1
+	case strings.HasPrefix(name, query):
+		return 0.8
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '&' || r == '/' || r == '-' || r == ',' || r == '(' || r == ')'
+	})
+	for _, word := range words {
+		if strings.HasPrefix(word, query) {
+			return 0.6
+		}
+	}
+	for _, term := range strings.Fields(query) {
+		if !strings.Contains(name, term) {
+			return 0
+		}
+	}
+	return 0.4
+}
+
+// searchRadius is the distance at which a stop's proximity score reaches zero:
+// the search radius, or half the diagonal of a span search.
+func searchRadius(lat, lon, radius, latSpan, lonSpan float64) float64 {
+	if latSpan > 0 && lonSpan > 0 {
+		return utils.Distance(lat, lon, lat+latSpan/2, lon+lonSpan/2)
+	}
+	return radius
+}
+
+// rankStops scores the stops matching query and orders them best first,
+// nearer stops breaking ties. Stops whose code and name do not match query
+// are dropped.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) rankStops(ctx context.Context, stops []RankedStop, query string, radius float64) []RankedStop {
+	ranked := make([]RankedStop, 0, len(stops))
+	nameScores := make(map[string]float64, len(stops))
+	stopIDs := make([]string, 0, len(stops))
+	for _, candidate := range stops {
+		score := nameMatchScore(candidate.Stop, query)
+		if score == 0 {
+			continue
+		}
+		nameScores[candidate.Stop.ID] = score
+		stopIDs = append(stopIDs, candidate.Stop.ID)
+		ranked = append(ranked, candidate)
+	}
+	if len(ranked) == 0 {
+		return ranked
+	}
+
+	// A failed lookup only costs the routes part of the score.
+	routeCounts := make(map[string]int)
+	if rows, err := manager.GtfsDB.Queries.GetRouteIDsForStops(ctx, stopIDs); err == nil {
+		for _, row := range rows {
+			routeCounts[row.StopID]++
+		}
+	}
+
+	for i := range ranked {
+		stop := &ranked[i]
+		proximity := 0.0
+		if radius > 0 {
+			proximity = math.Max(0, 1-stop.Distance/radius)
+		}
+		routes := math.Min(float64(routeCounts[stop.Stop.ID]), routeCountSaturation) / routeCountSaturation
+		score := nameMatchWeight*nameScores[stop.Stop.ID] + proximityWeight*proximity + routesWeight*routes
+		stop.Score = math.Round(score*1000) / 1000
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Distance < ranked[j].Distance
+	})
+	return ranked
+}
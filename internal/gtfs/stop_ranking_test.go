@@ -0,0 +1,76 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestNameMatchScore(t *testing.T) {
+	stop := gtfsdb.Stop{
+		Code: sql.NullString{String: "2042", Valid: true},
+		Name: sql.NullString{String: "Buenaventura Blvd at Eureka Way", Valid: true},
+	}
+
+	tests := []struct {
+		query string
+		want  float64
+	}{
+		{"2042", 1},
+		{"buenaventura blvd at eureka way", 1},
+		{"Buenaventura", 0.8},
+		{"eureka", 0.6},
+		{"way blvd", 0.4},
+		{"204", 0},
+		{"shasta", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, nameMatchScore(stop, tt.query))
+		})
+	}
+}
+
+func TestGetRankedStopsForLocation(t *testing.T) {
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath: ":memory:",
+		Env:          appconf.Test,
+	})
+	require.NoError(t, err)
+	defer manager.Shutdown()
+
+	manager.RLock()
+	defer manager.RUnlock()
+
+	// Two stops are named for Buenaventura Blvd at Eureka Way, right by the
+	// search point, and a third for a farther corner of Buenaventura Blvd.
+	stops := manager.GetRankedStopsForLocation(context.Background(), 40.583321, -122.426966, 0, 0, 0, "buenaventura", 10, false, nil, false, time.Time{})
+	require.Len(t, stops, 3)
+	assert.Equal(t, "9039", stops[2].Stop.ID, "the farthest match ranks last")
+	for i := 1; i < len(stops); i++ {
+		assert.GreaterOrEqual(t, stops[i-1].Score, stops[i].Score)
+	}
+	assert.Greater(t, stops[0].Score, nameMatchWeight*0.8)
+
+	// An exact code outranks name matches.
+	stops = manager.GetRankedStopsForLocation(context.Background(), 40.583321, -122.426966, 0, 0, 0, "2026", 10, false, nil, false, time.Time{})
+	require.NotEmpty(t, stops)
+	assert.Equal(t, "2026", stops[0].Stop.ID)
+
+	// Without a query stops are ordered by distance and carry no score.
+	stops = manager.GetRankedStopsForLocation(context.Background(), 40.583321, -122.426966, 500, 0, 0, "", 10, false, nil, false, time.Time{})
+	require.NotEmpty(t, stops)
+	for i := 1; i < len(stops); i++ {
+		assert.LessOrEqual(t, stops[i-1].Distance, stops[i].Distance)
+		assert.Zero(t, stops[i].Score)
+	}
+}
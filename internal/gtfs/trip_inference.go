@@ -0,0 +1,403 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// tripInferenceSlack is how long before its first departure and after its last
+// arrival a trip remains a candidate for a vehicle, covering vehicles that pull
+// out early or run late.
+const tripInferenceSlack = 15 * time.Minute
+
+// inferenceServiceDay is a service date on which trips may be running at the
+// time of a poll.
+type inferenceServiceDay struct {
+	serviceIDs []string
+	now        utils.ServiceTime // The poll time on this service day
+}
+
+// tripCandidate is a scheduled trip a vehicle may be running.
+type tripCandidate struct {
+	trip      gtfsdb.Trip
+	now       utils.ServiceTime
+	stopTimes []gtfsdb.StopTime
+}
+
+func (c tripCandidate) span() (utils.ServiceTime, utils.ServiceTime) {
+	first := c.stopTimes[0]
+	last := c.stopTimes[len(c.stopTimes)-1]
+	return utils.NewServiceTime(first.DepartureTime), utils.NewServiceTime(last.ArrivalTime)
+}
+
+// tripInference holds the schedule lookups shared by the vehicles of one poll.
+type tripInference struct {
+	manager    *Manager
+	logger     *slog.Logger
+	days       []inferenceServiceDay
+	candidates map[string][]tripCandidate // By route ID
+	stops      map[string]gtfsdb.Stop
+	claimed    map[string]bool // Trips a vehicle reports or was already given
+}
+
+// inferVehicleTrips gives a trip to each vehicle of a poll that reports none.
+// A vehicle keeps to its block: one under a dispatcher's block assignment, or
+// given a trip of a block on an earlier poll, gets the block's trip scheduled
+// now. Otherwise, if the vehicle reports its route, it gets the route's trip
+// whose scheduled position at the time of the poll lies nearest the vehicle's,
+// or, without a position, the trip whose start time it reports. Trips other
+// vehicles report are passed over. previous holds the trips given on the last
+// poll by vehicle ID. The vehicles are updated in place, and the trips given
+// are returned by vehicle ID.
+func (manager *Manager) inferVehicleTrips(ctx context.Context, vehicles []gtfs.Vehicle, previous map[string]string, now time.Time) map[string]string {
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+
+	if manager.GtfsDB == nil {
+		return nil
+	}
+
+	claimed := make(map[string]bool)
+	var pending []int
+	for i, v := range vehicles {
+		if v.Trip != nil && v.Trip.ID.ID != "" {
+			claimed[v.Trip.ID.ID] = true
+			continue
+		}
+		if v.ID != nil && v.ID.ID != "" {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	inference := &tripInference{
+		manager:    manager,
+		logger:     slog.Default().With(slog.String("component", "trip_inference")),
+		candidates: make(map[string][]tripCandidate),
+		stops:      make(map[string]gtfsdb.Stop),
+		claimed:    claimed,
+	}
+	if !inference.loadServiceDays(ctx, now) {
+		return nil
+	}
+
+	inferred := make(map[string]string)
+	for _, i := range pending {
+		vehicle := &vehicles[i]
+		trip, ok := inference.fromBlock(ctx, *vehicle, previous[vehicle.ID.ID], now)
+		if !ok {
+			trip, ok = inference.fromRoute(ctx, *vehicle)
+		}
+		if !ok {
+			continue
+		}
+
+		claimed[trip.ID] = true
+		inferred[vehicle.ID.ID] = trip.ID
+
+		var assigned gtfs.Trip
+		if vehicle.Trip != nil {
+			assigned = *vehicle.Trip
+		}
+		assigned.ID.ID = trip.ID
+		assigned.ID.RouteID = trip.RouteID
+		if trip.DirectionID.Valid {
+			assigned.ID.DirectionID = realtimeDirectionID(trip.DirectionID.Int64)
+		}
+		vehicle.Trip = &assigned
+	}
+	return inferred
+}
+
+// realtimeDirectionID converts a static direction_id to its GTFS-RT value.
+func realtimeDirectionID(directionID int64) gtfs.DirectionID {
+	if directionID == 1 {
+		return gtfs.DirectionID_True
+	}
+	return gtfs.DirectionID_False
+}
+
+// loadServiceDays looks up the services of every service day with trips
+// possibly running at now, reporting whether any has service.
+func (inference *tripInference) loadServiceDays(ctx context.Context, now time.Time) bool {
+	manager := inference.manager
+	local := now.In(manager.agencyLocation())
+	for _, date := range utils.ServiceDatesAt(local, manager.maxServiceTime) {
+		serviceIDs, err := manager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, date.Format("20060102"))
+		if err != nil {
+			logging.LogError(inference.logger, "could not get active service IDs", err,
+				slog.String("date", date.Format("20060102")))
+			continue
+		}
+		if len(serviceIDs) > 0 {
+			inference.days = append(inference.days, inferenceServiceDay{
+				serviceIDs: serviceIDs,
+				now:        utils.ServiceTimeAt(date, local),
+			})
+		}
+	}
+	return len(inference.days) > 0
+}
+
+// fromBlock returns the trip scheduled now on the vehicle's block: the block
+// of its dispatcher's block assignment, or else that of the trip it was given
+// on the last poll. A trip of a route other than the one the vehicle reports
+// does not count, since the vehicle has evidently left the block.
+func (inference *tripInference) fromBlock(ctx context.Context, vehicle gtfs.Vehicle, previousTripID string, now time.Time) (gtfsdb.Trip, bool) {
+	queries := inference.manager.GtfsDB.Queries
+
+	blockID := inference.manager.assignedBlock(vehicle.ID.ID, now)
+	if blockID == "" && previousTripID != "" {
+		previousBlock, err := queries.GetBlockIDByTripID(ctx, previousTripID)
+		if err != nil || !previousBlock.Valid {
+			return gtfsdb.Trip{}, false
+		}
+		blockID = previousBlock.String
+	}
+	if blockID == "" {
+		return gtfsdb.Trip{}, false
+	}
+
+	for _, day := range inference.days {
+		tripID, err := queries.GetActiveTripInBlockAtTime(ctx, gtfsdb.GetActiveTripInBlockAtTimeParams{
+			BlockID:     sql.NullString{String: blockID, Valid: true},
+			ServiceIds:  day.serviceIDs,
+			CurrentTime: day.now.Seconds(),
+		})
+		if err != nil || inference.claimed[tripID] {
+			continue
+		}
+		trip, err := queries.GetTrip(ctx, tripID)
+		if err != nil {
+			continue
+		}
+		if routeID := vehicle.GetTrip().ID.RouteID; routeID != "" && trip.RouteID != routeID {
+			continue
+		}
+		return trip, true
+	}
+	return gtfsdb.Trip{}, false
+}
+
+// fromRoute returns the trip of the vehicle's route that it is most likely
+// running: the one scheduled nearest its position, or, for a vehicle without
+// a position, the one starting nearest the start time it reports. A vehicle
+// reporting neither gets a trip only if a single one is running.
+func (inference *tripInference) fromRoute(ctx context.Context, vehicle gtfs.Vehicle) (gtfsdb.Trip, bool) {
+	tripID := vehicle.GetTrip().ID
+	if tripID.RouteID == "" {
+		return gtfsdb.Trip{}, false
+	}
+
+	var running []tripCandidate
+	for _, c := range inference.routeCandidates(ctx, tripID.RouteID) {
+		if inference.claimed[c.trip.ID] {
+			continue
+		}
+		if tripID.DirectionID != gtfs.DirectionID_Unspecified && c.trip.DirectionID.Valid &&
+			realtimeDirectionID(c.trip.DirectionID.Int64) != tripID.DirectionID {
+			continue
+		}
+		first, last := c.span()
+		slack := utils.ServiceTime(tripInferenceSlack)
+		if c.now < first-slack || c.now > last+slack {
+			continue
+		}
+		running = append(running, c)
+	}
+
+	var score func(tripCandidate) float64
+	switch {
+	case vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil:
+		lat, lon := float64(*vehicle.Position.Latitude), float64(*vehicle.Position.Longitude)
+		score = func(c tripCandidate) float64 {
+			scheduledLat, scheduledLon, ok := inference.scheduledPosition(c)
+			if !ok {
+				return math.Inf(1)
+			}
+			return utils.Distance(lat, lon, scheduledLat, scheduledLon)
+		}
+	case tripID.HasStartTime:
+		start := utils.ServiceTime(tripID.StartTime)
+		score = func(c tripCandidate) float64 {
+			first, _ := c.span()
+			return math.Abs(float64(first - start))
+		}
+	default:
+		if len(running) != 1 {
+			return gtfsdb.Trip{}, false
+		}
+		return running[0].trip, true
+	}
+
+	best, bestScore := -1, math.Inf(1)
+	for i, c := range running {
+		if s := score(c); s < bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if best < 0 {
+		return gtfsdb.Trip{}, false
+	}
+	return running[best].trip, true
+}
+
+// routeCandidates returns the trips of a route on each service day with their
+// stop times, ordered by trip ID.
+func (inference *tripInference) routeCandidates(ctx context.Context, routeID string) []tripCandidate {
+	if candidates, ok := inference.candidates[routeID]; ok {
+		return candidates
+	}
+
+	queries := inference.manager.GtfsDB.Queries
+	var candidates []tripCandidate
+	for _, day := range inference.days {
+		trips, err := queries.GetTripsForRouteInActiveServiceIDs(ctx, gtfsdb.GetTripsForRouteInActiveServiceIDsParams{
+			RouteID:    routeID,
+			ServiceIds: day.serviceIDs,
+		})
+		if err != nil {
+			logging.LogError(inference.logger, "could not get trips for route", err,
+				slog.String("route_id", routeID))
+			continue
+		}
+		if len(trips) == 0 {
+			continue
+		}
+
+		tripIDs := make([]string, len(trips))
+		for i, trip := range trips {
+			tripIDs[i] = trip.ID
+		}
+		stopTimes, err := queries.GetStopTimesForTripIDs(ctx, tripIDs)
+		if err != nil {
+			logging.LogError(inference.logger, "could not get stop times for route", err,
+				slog.String("route_id", routeID))
+			continue
+		}
+		byTrip := make(map[string][]gtfsdb.StopTime, len(trips))
+		for _, st := range stopTimes {
+			byTrip[st.TripID] = append(byTrip[st.TripID], st)
+		}
+
+		for _, trip := range trips {
+			if len(byTrip[trip.ID]) == 0 {
+				continue
+			}
+			candidates = append(candidates, tripCandidate{trip: trip, now: day.now, stopTimes: byTrip[trip.ID]})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].trip.ID < candidates[j].trip.ID
+	})
+
+	inference.loadStops(ctx, candidates)
+	inference.candidates[routeID] = candidates
+	return candidates
+}
+
+func (inference *tripInference) loadStops(ctx context.Context, candidates []tripCandidate) {
+	var stopIDs []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		for _, st := range c.stopTimes {
+			if _, loaded := inference.stops[st.StopID]; !loaded && !seen[st.StopID] {
+				seen[st.StopID] = true
+				stopIDs = append(stopIDs, st.StopID)
+			}
+		}
+	}
+	if len(stopIDs) == 0 {
+		return
+	}
+
+	stops, err := inference.manager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		logging.LogError(inference.logger, "could not get stops", err)
+		return
+	}
+	for _, stop := range stops {
+		inference.stops[stop.ID] = stop
+	}
+}
+
+// scheduledPosition interpolates where the schedule places a trip at the time
+// of the poll, between the stops it departs and arrives at around then. Before
+// its first departure the trip is at its first stop, and after its last
+// arrival at its last.
+func (inference *tripInference) scheduledPosition(c tripCandidate) (float64, float64, bool) {
+	now := c.now.Seconds()
+	stopAt := func(i int) (gtfsdb.Stop, bool) {
+		stop, ok := inference.stops[c.stopTimes[i].StopID]
+		return stop, ok
+	}
+
+	last := len(c.stopTimes) - 1
+	for i := 0; i < last; i++ {
+		from, to := c.stopTimes[i], c.stopTimes[i+1]
+		if now > to.ArrivalTime {
+			continue
+		}
+		fromStop, ok := stopAt(i)
+		if !ok {
+			return 0, 0, false
+		}
+		if now <= from.DepartureTime {
+			return fromStop.Lat, fromStop.Lon, true
+		}
+		toStop, ok := stopAt(i + 1)
+		if !ok {
+			return 0, 0, false
+		}
+		fraction := float64(now-from.DepartureTime) / float64(to.ArrivalTime-from.DepartureTime)
+		return fromStop.Lat + fraction*(toStop.Lat-fromStop.Lat),
+			fromStop.Lon + fraction*(toStop.Lon-fromStop.Lon), true
+	}
+	stop, ok := stopAt(last)
+	return stop.Lat, stop.Lon, ok
+}
+
+// keptInferredTrips returns the inferred trips of a feed's vehicles after a
+// poll: those given on the poll, and those of vehicles retained from earlier
+// polls while they are not stale.
+func keptInferredTrips(vehicles []gtfs.Vehicle, current map[string]struct{}, inferred, previous map[string]string) map[string]string {
+	kept := make(map[string]string, len(inferred))
+	for _, v := range vehicles {
+		vehicleID := v.ID.ID
+		if tripID, ok := inferred[vehicleID]; ok {
+			kept[vehicleID] = tripID
+			continue
+		}
+		if _, reported := current[vehicleID]; reported {
+			continue
+		}
+		if tripID, ok := previous[vehicleID]; ok && v.Trip != nil && v.Trip.ID.ID == tripID {
+			kept[vehicleID] = tripID
+		}
+	}
+	return kept
+}
+
+// TripAssignmentInferred reports whether the trip a vehicle is running was
+// inferred from its block or route rather than reported by its feed.
+func (manager *Manager) TripAssignmentInferred(vehicle *gtfs.Vehicle) bool {
+	if vehicle == nil || vehicle.ID == nil || vehicle.Trip == nil {
+		return false
+	}
+	manager.realTimeMutex.RLock()
+	defer manager.realTimeMutex.RUnlock()
+
+	tripID, ok := manager.realTimeInferredTrips[vehicle.ID.ID]
+	return ok && tripID == vehicle.Trip.ID.ID
+}
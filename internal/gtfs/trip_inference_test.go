@@ -0,0 +1,163 @@
+package gtfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// inferenceFixture is a RABA trip in progress: the moment it departs a stop
+// halfway along and the stop's position.
+type inferenceFixture struct {
+	trip     gtfsdb.Trip
+	now      time.Time
+	lat, lon float32
+}
+
+func newInferenceFixture(t *testing.T) (*Manager, inferenceFixture) {
+	t.Helper()
+	manager, err := InitGTFSManager(Config{
+		GtfsURL:      models.GetFixturePath(t, "raba.zip"),
+		GTFSDataPath: ":memory:",
+		Env:          appconf.Test,
+	})
+	require.NoError(t, err)
+	t.Cleanup(manager.Shutdown)
+
+	ctx := context.Background()
+	queries := manager.GtfsDB.Queries
+	serviceIDs, err := queries.GetActiveServiceIDsForDate(ctx, "20250610")
+	require.NoError(t, err)
+	trips, err := queries.GetTripsForRouteInActiveServiceIDs(ctx, gtfsdb.GetTripsForRouteInActiveServiceIDsParams{
+		RouteID:    "151",
+		ServiceIds: serviceIDs,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, trips)
+
+	trip := trips[len(trips)/2]
+	require.True(t, trip.BlockID.Valid)
+	stopTimes, err := queries.GetStopTimesForTrip(ctx, trip.ID)
+	require.NoError(t, err)
+	require.Greater(t, len(stopTimes), 2)
+	stopTime := stopTimes[len(stopTimes)/2]
+	stop, err := queries.GetStop(ctx, stopTime.StopID)
+	require.NoError(t, err)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	midnight := time.Date(2025, 6, 10, 0, 0, 0, 0, loc)
+	return manager, inferenceFixture{
+		trip: trip,
+		now:  midnight.Add(time.Duration(stopTime.DepartureTime) * time.Second),
+		lat:  float32(stop.Lat),
+		lon:  float32(stop.Lon),
+	}
+}
+
+func TestInferVehicleTrips(t *testing.T) {
+	manager, fixture := newInferenceFixture(t)
+	ctx := context.Background()
+
+	positioned := func(vehicleID, routeID string) gtfs.Vehicle {
+		lat, lon := fixture.lat, fixture.lon
+		return gtfs.Vehicle{
+			ID:       &gtfs.VehicleID{ID: vehicleID},
+			Trip:     &gtfs.Trip{ID: gtfs.TripID{RouteID: routeID}},
+			Position: &gtfs.Position{Latitude: &lat, Longitude: &lon},
+		}
+	}
+
+	t.Run("nearest scheduled trip of the route", func(t *testing.T) {
+		vehicles := []gtfs.Vehicle{positioned("bus1", fixture.trip.RouteID)}
+		inferred := manager.inferVehicleTrips(ctx, vehicles, nil, fixture.now)
+
+		assert.Equal(t, map[string]string{"bus1": fixture.trip.ID}, inferred)
+		assert.Equal(t, fixture.trip.ID, vehicles[0].Trip.ID.ID)
+		assert.Equal(t, fixture.trip.RouteID, vehicles[0].Trip.ID.RouteID)
+	})
+
+	t.Run("trip reported by another vehicle is passed over", func(t *testing.T) {
+		vehicles := []gtfs.Vehicle{
+			{ID: &gtfs.VehicleID{ID: "bus2"}, Trip: &gtfs.Trip{ID: gtfs.TripID{ID: fixture.trip.ID}}},
+			positioned("bus1", fixture.trip.RouteID),
+		}
+		inferred := manager.inferVehicleTrips(ctx, vehicles, nil, fixture.now)
+
+		assert.NotEqual(t, fixture.trip.ID, inferred["bus1"])
+		assert.NotContains(t, inferred, "bus2")
+	})
+
+	t.Run("vehicle without route or block is left alone", func(t *testing.T) {
+		vehicles := []gtfs.Vehicle{positioned("bus1", "")}
+		inferred := manager.inferVehicleTrips(ctx, vehicles, nil, fixture.now)
+
+		assert.Empty(t, inferred)
+		assert.Empty(t, vehicles[0].Trip.ID.ID)
+	})
+
+	t.Run("block of the previous poll", func(t *testing.T) {
+		vehicles := []gtfs.Vehicle{{ID: &gtfs.VehicleID{ID: "bus1"}}}
+		previous := map[string]string{"bus1": fixture.trip.ID}
+		inferred := manager.inferVehicleTrips(ctx, vehicles, previous, fixture.now)
+
+		assert.Equal(t, fixture.trip.ID, inferred["bus1"])
+	})
+
+	t.Run("block assignment", func(t *testing.T) {
+		manager.AssignVehicle(VehicleAssignment{
+			VehicleID: "bus3",
+			BlockID:   fixture.trip.BlockID.String,
+			ExpiresAt: fixture.now.Add(time.Hour),
+		})
+		t.Cleanup(func() { manager.RemoveVehicleAssignment("bus3", fixture.now) })
+
+		vehicles := []gtfs.Vehicle{{ID: &gtfs.VehicleID{ID: "bus3"}}}
+		inferred := manager.inferVehicleTrips(ctx, vehicles, nil, fixture.now)
+
+		assert.Equal(t, fixture.trip.ID, inferred["bus3"])
+	})
+}
+
+func TestTripAssignmentInferred(t *testing.T) {
+	manager := newTestManager()
+	inferredVehicle := gtfs.Vehicle{ID: &gtfs.VehicleID{ID: "bus1"}, Trip: &gtfs.Trip{ID: gtfs.TripID{ID: "trip1"}}}
+	reportedVehicle := gtfs.Vehicle{ID: &gtfs.VehicleID{ID: "bus2"}, Trip: &gtfs.Trip{ID: gtfs.TripID{ID: "trip2"}}}
+
+	manager.realTimeMutex.Lock()
+	manager.feedVehicles["feed-0"] = []gtfs.Vehicle{inferredVehicle, reportedVehicle}
+	manager.feedInferredTrips = map[string]map[string]string{"feed-0": {"bus1": "trip1"}}
+	manager.rebuildMergedRealtimeLocked()
+	manager.realTimeMutex.Unlock()
+
+	assert.True(t, manager.TripAssignmentInferred(&inferredVehicle))
+	assert.False(t, manager.TripAssignmentInferred(&reportedVehicle))
+
+	// A vehicle since reported on another trip is no longer inferred.
+	moved := gtfs.Vehicle{ID: &gtfs.VehicleID{ID: "bus1"}, Trip: &gtfs.Trip{ID: gtfs.TripID{ID: "trip3"}}}
+	assert.False(t, manager.TripAssignmentInferred(&moved))
+}
+
+func TestKeptInferredTrips(t *testing.T) {
+	vehicle := func(vehicleID, tripID string) gtfs.Vehicle {
+		return gtfs.Vehicle{ID: &gtfs.VehicleID{ID: vehicleID}, Trip: &gtfs.Trip{ID: gtfs.TripID{ID: tripID}}}
+	}
+	vehicles := []gtfs.Vehicle{
+		vehicle("new", "trip1"),
+		vehicle("reported", "trip2"),
+		vehicle("retained", "trip3"),
+	}
+	current := map[string]struct{}{"new": {}, "reported": {}}
+	inferred := map[string]string{"new": "trip1"}
+	previous := map[string]string{"reported": "trip2", "retained": "trip3", "gone": "trip4"}
+
+	kept := keptInferredTrips(vehicles, current, inferred, previous)
+	assert.Equal(t, map[string]string{"new": "trip1", "retained": "trip3"}, kept)
+}
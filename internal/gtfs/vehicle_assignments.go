@@ -0,0 +1,163 @@
+package gtfs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// VehicleAssignment is a dispatcher's statement that a vehicle is running a
+// trip, or every trip of a block. It takes precedence over the trip the
+// vehicle's GTFS-RT feed reports and also covers vehicles that report no
+// position at all. Assignments are held in memory only and lapse at ExpiresAt.
+type VehicleAssignment struct {
+	// AgencyID is the agency the assignment was made for; the other IDs are
+	// feed IDs without an agency prefix.
+	AgencyID  string
+	VehicleID string
+	// Exactly one of TripID and BlockID is set.
+	TripID     string
+	BlockID    string
+	AssignedAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (a VehicleAssignment) expired(now time.Time) bool {
+	return !now.Before(a.ExpiresAt)
+}
+
+// covers reports whether the assignment places its vehicle on the trip.
+func (a VehicleAssignment) covers(tripID, blockID string) bool {
+	if a.TripID != "" {
+		return a.TripID == tripID
+	}
+	return blockID != "" && a.BlockID == blockID
+}
+
+// vehicleAssignmentStore holds the current assignment of each vehicle. The zero
+// value is ready to use and safe for concurrent use.
+type vehicleAssignmentStore struct {
+	mu        sync.RWMutex
+	byVehicle map[string]VehicleAssignment
+}
+
+// AssignVehicle records an assignment, replacing any earlier one of the same
+// vehicle.
+func (manager *Manager) AssignVehicle(assignment VehicleAssignment) {
+	store := &manager.vehicleAssignments
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.byVehicle == nil {
+		store.byVehicle = make(map[string]VehicleAssignment)
+	}
+	store.byVehicle[assignment.VehicleID] = assignment
+	manager.dataGeneration.Add(1)
+}
+
+// RemoveVehicleAssignment drops the assignment of a vehicle, reporting whether
+// it had one that had not expired.
+func (manager *Manager) RemoveVehicleAssignment(vehicleID string, now time.Time) bool {
+	store := &manager.vehicleAssignments
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	assignment, ok := store.byVehicle[vehicleID]
+	delete(store.byVehicle, vehicleID)
+	manager.dataGeneration.Add(1)
+	return ok && !assignment.expired(now)
+}
+
+// VehicleAssignments lists the assignments in force at now, ordered by vehicle
+// ID. Expired assignments are dropped along the way.
+func (manager *Manager) VehicleAssignments(now time.Time) []VehicleAssignment {
+	store := &manager.vehicleAssignments
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	assignments := make([]VehicleAssignment, 0, len(store.byVehicle))
+	for vehicleID, a := range store.byVehicle {
+		if a.expired(now) {
+			delete(store.byVehicle, vehicleID)
+			continue
+		}
+		assignments = append(assignments, a)
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].VehicleID < assignments[j].VehicleID
+	})
+	return assignments
+}
+
+// assignmentForTrip returns the assignment in force that covers the trip. A
+// trip assignment wins over a block assignment, and a later one over an
+// earlier one.
+func (manager *Manager) assignmentForTrip(tripID, blockID string, now time.Time) (VehicleAssignment, bool) {
+	store := &manager.vehicleAssignments
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var best VehicleAssignment
+	found := false
+	for _, a := range store.byVehicle {
+		if a.expired(now) || !a.covers(tripID, blockID) {
+			continue
+		}
+		if !found || a.supersedes(best) {
+			best, found = a, true
+		}
+	}
+	return best, found
+}
+
+func (a VehicleAssignment) supersedes(other VehicleAssignment) bool {
+	if (a.TripID != "") != (other.TripID != "") {
+		return a.TripID != ""
+	}
+	return a.AssignedAt.After(other.AssignedAt)
+}
+
+// assignedElsewhere reports whether a vehicle is assigned to something other
+// than the trip, so its feed's claim on the trip no longer holds.
+func (manager *Manager) assignedElsewhere(vehicleID, tripID, blockID string, now time.Time) bool {
+	store := &manager.vehicleAssignments
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	a, ok := store.byVehicle[vehicleID]
+	return ok && !a.expired(now) && !a.covers(tripID, blockID)
+}
+
+// assignedVehicle returns the vehicle an assignment places on the trip: its
+// latest GTFS-RT report when it has one, with the trip replaced unless the
+// vehicle already reports a trip of the block, or else a vehicle carrying only
+// its ID and the trip. Caller must hold realTimeMutex.
+func (manager *Manager) assignedVehicle(assignment VehicleAssignment, tripID string, blockTripIDs map[string]bool) *gtfs.Vehicle {
+	var vehicle gtfs.Vehicle
+	if index, ok := manager.realTimeVehicleLookupByVehicle[assignment.VehicleID]; ok {
+		vehicle = manager.realTimeVehicles[index]
+	} else {
+		vehicle.ID = &gtfs.VehicleID{ID: assignment.VehicleID}
+	}
+
+	if vehicle.Trip == nil || !blockTripIDs[vehicle.Trip.ID.ID] || assignment.TripID != "" {
+		vehicle.Trip = &gtfs.Trip{ID: gtfs.TripID{ID: tripID}}
+	}
+	return &vehicle
+}
+
+// assignedBlock returns the block a vehicle is assigned to, or "" if it has
+// no block assignment in force.
+func (manager *Manager) assignedBlock(vehicleID string, now time.Time) string {
+	store := &manager.vehicleAssignments
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	a, ok := store.byVehicle[vehicleID]
+	if !ok || a.expired(now) {
+		return ""
+	}
+	return a.BlockID
+}
@@ -0,0 +1,83 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVehicleAssignments_Precedence(t *testing.T) {
+	manager := newTestManager()
+	now := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-1", BlockID: "block-1", AssignedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)})
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-2", BlockID: "block-1", AssignedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)})
+
+	a, ok := manager.assignmentForTrip("trip-a", "block-1", now)
+	require.True(t, ok)
+	assert.Equal(t, "bus-2", a.VehicleID, "the later block assignment wins")
+
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-3", TripID: "trip-a", AssignedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour)})
+	a, ok = manager.assignmentForTrip("trip-a", "block-1", now)
+	require.True(t, ok)
+	assert.Equal(t, "bus-3", a.VehicleID, "a trip assignment wins over a block assignment")
+
+	a, ok = manager.assignmentForTrip("trip-b", "block-1", now)
+	require.True(t, ok)
+	assert.Equal(t, "bus-2", a.VehicleID)
+
+	_, ok = manager.assignmentForTrip("trip-c", "", now)
+	assert.False(t, ok, "a trip without a block is covered by trip assignments only")
+
+	assert.True(t, manager.assignedElsewhere("bus-3", "trip-b", "block-1", now))
+	assert.False(t, manager.assignedElsewhere("bus-2", "trip-b", "block-1", now))
+	assert.False(t, manager.assignedElsewhere("bus-9", "trip-b", "block-1", now))
+}
+
+func TestVehicleAssignments_Expiry(t *testing.T) {
+	manager := newTestManager()
+	now := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-1", TripID: "trip-a", AssignedAt: now, ExpiresAt: now.Add(time.Hour)})
+	manager.AssignVehicle(VehicleAssignment{VehicleID: "bus-0", TripID: "trip-b", AssignedAt: now, ExpiresAt: now.Add(2 * time.Hour)})
+
+	assignments := manager.VehicleAssignments(now)
+	require.Len(t, assignments, 2)
+	assert.Equal(t, "bus-0", assignments[0].VehicleID)
+
+	later := now.Add(90 * time.Minute)
+	_, ok := manager.assignmentForTrip("trip-a", "", later)
+	assert.False(t, ok)
+	assert.Len(t, manager.VehicleAssignments(later), 1)
+	assert.False(t, manager.RemoveVehicleAssignment("bus-1", later))
+	assert.True(t, manager.RemoveVehicleAssignment("bus-0", later))
+	assert.Empty(t, manager.VehicleAssignments(later))
+}
+
+func TestAssignedVehicle(t *testing.T) {
+	manager := newTestManager()
+	lat := float32(47.6)
+	manager.realTimeVehicles = []gtfs.Vehicle{{
+		ID:       &gtfs.VehicleID{ID: "bus-1"},
+		Trip:     &gtfs.Trip{ID: gtfs.TripID{ID: "trip-a"}},
+		Position: &gtfs.Position{Latitude: &lat},
+	}}
+	manager.realTimeVehicleLookupByVehicle["bus-1"] = 0
+	block := map[string]bool{"trip-a": true, "trip-b": true}
+
+	vehicle := manager.assignedVehicle(VehicleAssignment{VehicleID: "bus-1", BlockID: "block-1"}, "trip-b", block)
+	assert.Equal(t, "trip-a", vehicle.Trip.ID.ID, "a block assignment keeps the trip of the block the vehicle reports")
+	assert.Equal(t, float32(47.6), *vehicle.Position.Latitude)
+
+	vehicle = manager.assignedVehicle(VehicleAssignment{VehicleID: "bus-1", TripID: "trip-b"}, "trip-b", block)
+	assert.Equal(t, "trip-b", vehicle.Trip.ID.ID)
+	assert.Equal(t, "trip-a", manager.realTimeVehicles[0].Trip.ID.ID, "the feed's vehicle is not modified")
+
+	vehicle = manager.assignedVehicle(VehicleAssignment{VehicleID: "bus-7", TripID: "trip-b"}, "trip-b", block)
+	assert.Equal(t, "bus-7", vehicle.ID.ID)
+	assert.Equal(t, "trip-b", vehicle.Trip.ID.ID)
+	assert.Nil(t, vehicle.Position, "a vehicle the feeds do not report has no position")
+}
@@ -0,0 +1,86 @@
+package gtfs
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadVehicleCapacities builds the passenger capacity of each vehicle from the
+// CSV file and the capacities in the configuration, which take precedence.
+// Vehicles are keyed by the IDs GTFS-RT feeds publish, after any rewrites.
+func loadVehicleCapacities(config Config) (map[string]int, error) {
+	capacities := make(map[string]int)
+	if config.VehicleCapacityFile != "" {
+		f, err := os.Open(config.VehicleCapacityFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() //nolint:errcheck
+		if err := readVehicleCapacities(f, capacities); err != nil {
+			return nil, fmt.Errorf("%s: %w", config.VehicleCapacityFile, err)
+		}
+	}
+	for vehicleID, capacity := range config.VehicleCapacities {
+		if capacity <= 0 {
+			return nil, fmt.Errorf("capacity of vehicle %s must be positive, got %d", vehicleID, capacity)
+		}
+		capacities[vehicleID] = capacity
+	}
+	return capacities, nil
+}
+
+// readVehicleCapacities reads a CSV file with vehicle_id and capacity columns,
+// in any order, into capacities.
+func readVehicleCapacities(r io.Reader, capacities map[string]int) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	idColumn, capacityColumn := -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) {
+		case "vehicle_id":
+			idColumn = i
+		case "capacity":
+			capacityColumn = i
+		}
+	}
+	if idColumn < 0 || capacityColumn < 0 {
+		return errors.New("header must name vehicle_id and capacity columns")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		vehicleID := strings.TrimSpace(record[idColumn])
+		if vehicleID == "" {
+			return fmt.Errorf("line %d: missing vehicle_id", line)
+		}
+		capacity, err := strconv.Atoi(strings.TrimSpace(record[capacityColumn]))
+		if err != nil || capacity <= 0 {
+			return fmt.Errorf("line %d: capacity of vehicle %s must be a positive integer, got %q", line, vehicleID, record[capacityColumn])
+		}
+		capacities[vehicleID] = capacity
+	}
+}
+
+// VehicleCapacity returns how many passengers the vehicle holds, or false
+// when its capacity is not configured.
+func (manager *Manager) VehicleCapacity(vehicleID string) (int, bool) {
+	capacity, ok := manager.vehicleCapacities[vehicleID]
+	return capacity, ok
+}
@@ -0,0 +1,52 @@
+package gtfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadVehicleCapacities(t *testing.T) {
+	capacities := make(map[string]int)
+	err := readVehicleCapacities(strings.NewReader("\ufeffcapacity,vehicle_id,model\n80, 1201,New Flyer\n40,1202,\n"), capacities)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"1201": 80, "1202": 40}, capacities)
+
+	invalid := map[string]string{
+		"no capacity column": "vehicle_id\n1201\n",
+		"not a number":       "vehicle_id,capacity\n1201,many\n",
+		"not positive":       "vehicle_id,capacity\n1201,0\n",
+		"no vehicle ID":      "vehicle_id,capacity\n,80\n",
+		"empty":              "",
+	}
+	for name, contents := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, readVehicleCapacities(strings.NewReader(contents), make(map[string]int)))
+		})
+	}
+}
+
+func TestLoadVehicleCapacities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capacities.csv")
+	require.NoError(t, os.WriteFile(path, []byte("vehicle_id,capacity\n1201,80\n1202,40\n"), 0o600))
+
+	capacities, err := loadVehicleCapacities(Config{
+		VehicleCapacityFile: path,
+		VehicleCapacities:   map[string]int{"1202": 60, "1301": 120},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"1201": 80, "1202": 60, "1301": 120}, capacities)
+
+	capacities, err = loadVehicleCapacities(Config{})
+	require.NoError(t, err)
+	assert.Empty(t, capacities)
+
+	_, err = loadVehicleCapacities(Config{VehicleCapacityFile: filepath.Join(t.TempDir(), "missing.csv")})
+	assert.Error(t, err)
+	_, err = loadVehicleCapacities(Config{VehicleCapacities: map[string]int{"1201": -5}})
+	assert.Error(t, err)
+}
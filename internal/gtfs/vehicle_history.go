@@ -0,0 +1,257 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+// scheduleDeviationRetention is how long schedule deviation samples are kept for
+// on-time performance. Samples are one row per trip, stop and service day, so
+// they are kept far longer than the raw position history.
+const scheduleDeviationRetention = 90 * 24 * time.Hour
+
+// defaultDeviationSmoothingSamples is the number of recent observations averaged
+// when smoothing schedule deviation and no explicit value is configured.
+const defaultDeviationSmoothingSamples = 5
+
+// historyEnabled reports whether vehicle position history recording is configured.
+func (config Config) historyEnabled() bool {
+	return config.VehicleHistoryRetention > 0
+}
+
+// deviationSmoothingSamples returns the configured smoothing window, falling back
+// to the default when unset.
+func (config Config) deviationSmoothingSamples() int {
+	if config.DeviationSmoothingSamples > 0 {
+		return config.DeviationSmoothingSamples
+	}
+	return defaultDeviationSmoothingSamples
+}
+
+// tripUpdateDeviations maps trip IDs to the schedule deviation (in seconds) reported
+// by their trip update, using the trip-level delay when present and otherwise the
+// first stop-level delay.
+func tripUpdateDeviations(trips []gtfs.Trip) map[string]int64 {
+	deviations := make(map[string]int64, len(trips))
+	for _, trip := range trips {
+		if trip.ID.ID == "" {
+			continue
+		}
+		if trip.Delay != nil {
+			deviations[trip.ID.ID] = int64(trip.Delay.Seconds())
+			continue
+		}
+		for _, stu := range trip.StopTimeUpdates {
+			if stu.Arrival != nil && stu.Arrival.Delay != nil {
+				deviations[trip.ID.ID] = int64(stu.Arrival.Delay.Seconds())
+				break
+			}
+			if stu.Departure != nil && stu.Departure.Delay != nil {
+				deviations[trip.ID.ID] = int64(stu.Departure.Delay.Seconds())
+				break
+			}
+		}
+	}
+	return deviations
+}
+
+// recordVehiclePositions appends a feed's vehicle positions to the position history
+// table, tagging each with the deviation of the matching trip update, and prunes
+// observations that have aged out of the retention window. Deviations at a known
+// stop are also kept as schedule deviation samples for on-time performance. It is
+// a no-op unless history recording is enabled.
+//
+// History lives in the GTFS database, so a static hot-swap starts a fresh
+// position history; the schedule deviation samples are carried over.
+func (manager *Manager) recordVehiclePositions(ctx context.Context, feedID string, vehicles []gtfs.Vehicle, trips []gtfs.Trip, now time.Time) {
+	if !manager.config.historyEnabled() {
+		return
+	}
+
+	manager.staticMutex.RLock()
+	defer manager.staticMutex.RUnlock()
+
+	if manager.GtfsDB == nil {
+		return
+	}
+
+	logger := slog.Default().With(slog.String("component", "vehicle_position_history"))
+	deviations := tripUpdateDeviations(trips)
+	loc := manager.agencyLocation()
+
+	tx, err := manager.GtfsDB.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logging.LogError(logger, "Failed to begin vehicle history transaction", err, slog.String("feed", feedID))
+		return
+	}
+	defer logging.SafeRollbackWithLogging(tx, logger, "record_vehicle_positions")
+
+	qtx := manager.GtfsDB.Queries.WithTx(tx)
+	recorded := 0
+	for _, v := range vehicles {
+		if v.ID == nil || v.ID.ID == "" || v.Position == nil {
+			continue
+		}
+
+		observedAt := now
+		if v.Timestamp != nil {
+			observedAt = *v.Timestamp
+		}
+
+		params := gtfsdb.CreateVehiclePositionHistoryParams{
+			FeedID:     feedID,
+			VehicleID:  v.ID.ID,
+			ObservedAt: observedAt.UnixMilli(),
+		}
+		if v.Trip != nil && v.Trip.ID.ID != "" {
+			params.TripID = sql.NullString{String: v.Trip.ID.ID, Valid: true}
+			if v.Trip.ID.RouteID != "" {
+				params.RouteID = sql.NullString{String: v.Trip.ID.RouteID, Valid: true}
+			}
+			if deviation, ok := deviations[v.Trip.ID.ID]; ok {
+				params.ScheduleDeviation = sql.NullInt64{Int64: deviation, Valid: true}
+			}
+		}
+		if v.Position.Latitude != nil && v.Position.Longitude != nil {
+			params.Lat = sql.NullFloat64{Float64: float64(*v.Position.Latitude), Valid: true}
+			params.Lon = sql.NullFloat64{Float64: float64(*v.Position.Longitude), Valid: true}
+		}
+		if v.Position.Bearing != nil {
+			params.Bearing = sql.NullFloat64{Float64: float64(*v.Position.Bearing), Valid: true}
+		}
+		if v.Position.Speed != nil {
+			params.Speed = sql.NullFloat64{Float64: float64(*v.Position.Speed), Valid: true}
+		}
+		if v.StopID != nil && *v.StopID != "" {
+			params.StopID = sql.NullString{String: *v.StopID, Valid: true}
+		}
+		if v.OccupancyStatus != nil && isOccupancyLevel(*v.OccupancyStatus) {
+			params.OccupancyStatus = sql.NullInt64{Int64: int64(*v.OccupancyStatus), Valid: true}
+		}
+
+		inserted, err := qtx.CreateVehiclePositionHistory(ctx, params)
+		if err != nil {
+			logging.LogError(logger, "Failed to record vehicle position", err,
+				slog.String("feed", feedID),
+				slog.String("vehicle_id", v.ID.ID))
+			return
+		}
+		if inserted == 0 {
+			// Same report as the previous poll; don't count its occupancy twice.
+			continue
+		}
+		recorded++
+
+		if !params.TripID.Valid || !params.StopID.Valid {
+			continue
+		}
+		serviceDate := occupancyServiceDate(v, observedAt, loc)
+
+		if params.ScheduleDeviation.Valid {
+			err := qtx.UpsertScheduleDeviationSample(ctx, gtfsdb.UpsertScheduleDeviationSampleParams{
+				TripID:      params.TripID.String,
+				StopID:      params.StopID.String,
+				ServiceDate: serviceDate.Format("20060102"),
+				Deviation:   params.ScheduleDeviation.Int64,
+				ObservedAt:  params.ObservedAt,
+			})
+			if err != nil {
+				logging.LogError(logger, "Failed to record schedule deviation sample", err,
+					slog.String("feed", feedID),
+					slog.String("vehicle_id", v.ID.ID))
+				return
+			}
+		}
+
+		if params.OccupancyStatus.Valid {
+			err := qtx.IncrementHistoricalOccupancy(ctx, gtfsdb.IncrementHistoricalOccupancyParams{
+				TripID:          params.TripID.String,
+				StopID:          params.StopID.String,
+				DayOfWeek:       int64(serviceDate.Weekday()),
+				OccupancyStatus: params.OccupancyStatus.Int64,
+			})
+			if err != nil {
+				logging.LogError(logger, "Failed to aggregate historical occupancy", err,
+					slog.String("feed", feedID),
+					slog.String("vehicle_id", v.ID.ID))
+				return
+			}
+		}
+	}
+
+	cutoff := now.Add(-manager.config.VehicleHistoryRetention).UnixMilli()
+	pruned, err := qtx.DeleteVehiclePositionsHistoryBefore(ctx, cutoff)
+	if err != nil {
+		logging.LogError(logger, "Failed to prune vehicle position history", err, slog.String("feed", feedID))
+		return
+	}
+	samplesCutoff := now.Add(-scheduleDeviationRetention).UnixMilli()
+	if _, err := qtx.DeleteScheduleDeviationSamplesBefore(ctx, samplesCutoff); err != nil {
+		logging.LogError(logger, "Failed to prune schedule deviation samples", err, slog.String("feed", feedID))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.LogError(logger, "Failed to commit vehicle position history", err, slog.String("feed", feedID))
+		return
+	}
+
+	logger.Debug("recorded vehicle positions",
+		slog.String("feed", feedID),
+		slog.Int("recorded", recorded),
+		slog.Int64("pruned", pruned))
+}
+
+// GetSmoothedScheduleDeviation returns the mean schedule deviation (in seconds) over
+// the most recent recorded observations of a trip. Observations older than
+// staleVehicleTimeout are ignored so that a trip ID reused on a later service day
+// does not inherit old deviations. Returns false when history recording is
+// disabled or no recent deviation has been recorded.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (manager *Manager) GetSmoothedScheduleDeviation(ctx context.Context, tripID string, now time.Time) (int, bool) {
+	if !manager.config.historyEnabled() || manager.GtfsDB == nil {
+		return 0, false
+	}
+
+	observations, err := manager.GtfsDB.Queries.GetRecentVehiclePositionsForTrip(ctx, gtfsdb.GetRecentVehiclePositionsForTripParams{
+		TripID: sql.NullString{String: tripID, Valid: true},
+		Limit:  int64(manager.config.deviationSmoothingSamples()),
+	})
+	if err != nil {
+		slog.Warn("failed to load vehicle position history",
+			slog.String("trip_id", tripID),
+			slog.Any("error", err))
+		return 0, false
+	}
+
+	return smoothScheduleDeviation(observations, now.Add(-staleVehicleTimeout))
+}
+
+// smoothScheduleDeviation averages the deviations of observations made at or after
+// notBefore, rounding to the nearest second.
+func smoothScheduleDeviation(observations []gtfsdb.VehiclePositionsHistory, notBefore time.Time) (int, bool) {
+	var sum, count int64
+	cutoff := notBefore.UnixMilli()
+	for _, o := range observations {
+		if !o.ScheduleDeviation.Valid || o.ObservedAt < cutoff {
+			continue
+		}
+		sum += o.ScheduleDeviation.Int64
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+
+	mean := float64(sum) / float64(count)
+	if mean < 0 {
+		return int(mean - 0.5), true
+	}
+	return int(mean + 0.5), true
+}
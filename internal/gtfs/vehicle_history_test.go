@@ -0,0 +1,198 @@
+package gtfs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
+)
+
+func newHistoryTestManager(t *testing.T, retention time.Duration, samples int) *Manager {
+	t.Helper()
+	client, err := gtfsdb.NewClient(gtfsdb.Config{DBPath: ":memory:", Env: appconf.Test})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	manager := newTestManager()
+	manager.GtfsDB = client
+	manager.config = Config{
+		VehicleHistoryRetention:   retention,
+		DeviationSmoothingSamples: samples,
+	}
+	return manager
+}
+
+func historyVehicle(vehicleID, tripID string, observedAt time.Time) gtfs.Vehicle {
+	lat, lon := float32(47.6), float32(-122.3)
+	return gtfs.Vehicle{
+		ID:        &gtfs.VehicleID{ID: vehicleID},
+		Trip:      &gtfs.Trip{ID: gtfs.TripID{ID: tripID, RouteID: "route1"}},
+		Position:  &gtfs.Position{Latitude: &lat, Longitude: &lon},
+		Timestamp: &observedAt,
+	}
+}
+
+func delayedTrip(tripID string, delay time.Duration) gtfs.Trip {
+	return gtfs.Trip{ID: gtfs.TripID{ID: tripID}, Delay: &delay}
+}
+
+func TestRecordVehiclePositions_SmoothsDeviation(t *testing.T) {
+	manager := newHistoryTestManager(t, time.Hour, 3)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	delays := []time.Duration{60 * time.Second, 120 * time.Second, 90 * time.Second, 150 * time.Second}
+	for i, delay := range delays {
+		observedAt := now.Add(time.Duration(i-len(delays)) * 30 * time.Second)
+		manager.recordVehiclePositions(ctx, "feed-0",
+			[]gtfs.Vehicle{historyVehicle("bus1", "trip1", observedAt)},
+			[]gtfs.Trip{delayedTrip("trip1", delay)},
+			observedAt)
+	}
+
+	// Only the three most recent observations (120, 90, 150) are averaged.
+	deviation, ok := manager.GetSmoothedScheduleDeviation(ctx, "trip1", now)
+	require.True(t, ok)
+	assert.Equal(t, 120, deviation)
+
+	_, ok = manager.GetSmoothedScheduleDeviation(ctx, "unknown-trip", now)
+	assert.False(t, ok)
+}
+
+func TestRecordVehiclePositions_PrunesExpiredRows(t *testing.T) {
+	manager := newHistoryTestManager(t, 10*time.Minute, 5)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	old := now.Add(-30 * time.Minute)
+	manager.recordVehiclePositions(ctx, "feed-0",
+		[]gtfs.Vehicle{historyVehicle("bus1", "trip1", old)},
+		[]gtfs.Trip{delayedTrip("trip1", time.Minute)},
+		old)
+	manager.recordVehiclePositions(ctx, "feed-0",
+		[]gtfs.Vehicle{historyVehicle("bus1", "trip1", now)},
+		[]gtfs.Trip{delayedTrip("trip1", 2*time.Minute)},
+		now)
+
+	rows, err := manager.GtfsDB.Queries.GetRecentVehiclePositionsForTrip(ctx, gtfsdb.GetRecentVehiclePositionsForTripParams{
+		TripID: sql.NullString{String: "trip1", Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, now.UnixMilli(), rows[0].ObservedAt)
+	assert.Equal(t, int64(120), rows[0].ScheduleDeviation.Int64)
+	assert.Equal(t, "route1", rows[0].RouteID.String)
+}
+
+func TestRecordVehiclePositions_KeepsLatestDeviationPerStop(t *testing.T) {
+	manager := newHistoryTestManager(t, time.Hour, 0)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	atStop := func(stopID string, observedAt time.Time) gtfs.Vehicle {
+		v := historyVehicle("bus1", "trip1", observedAt)
+		if stopID != "" {
+			v.StopID = &stopID
+		}
+		return v
+	}
+	observations := []struct {
+		stopID string
+		delay  time.Duration
+	}{
+		{"stopA", time.Minute},
+		{"stopA", 3 * time.Minute},
+		{"stopB", -2 * time.Minute},
+		{"", 10 * time.Minute},
+	}
+	for i, o := range observations {
+		observedAt := now.Add(time.Duration(i) * 30 * time.Second)
+		manager.recordVehiclePositions(ctx, "feed-0",
+			[]gtfs.Vehicle{atStop(o.stopID, observedAt)},
+			[]gtfs.Trip{delayedTrip("trip1", o.delay)},
+			observedAt)
+	}
+
+	samples := func() map[string]int64 {
+		rows, err := manager.GtfsDB.DB.QueryContext(ctx, `SELECT stop_id, service_date, deviation FROM schedule_deviation_samples WHERE trip_id = 'trip1'`)
+		require.NoError(t, err)
+		defer rows.Close() //nolint:errcheck
+		deviations := make(map[string]int64)
+		for rows.Next() {
+			var stopID, serviceDate string
+			var deviation int64
+			require.NoError(t, rows.Scan(&stopID, &serviceDate, &deviation))
+			assert.Equal(t, "20260302", serviceDate)
+			deviations[stopID] = deviation
+		}
+		require.NoError(t, rows.Err())
+		return deviations
+	}
+	// The report without a stop is left out, and stopA keeps its later deviation.
+	assert.Equal(t, map[string]int64{"stopA": 180, "stopB": -120}, samples())
+
+	// Samples outlive the raw history but not their own retention.
+	manager.recordVehiclePositions(ctx, "feed-0", nil, nil, now.Add(2*time.Hour))
+	assert.Len(t, samples(), 2)
+	later := now.Add(scheduleDeviationRetention + time.Hour)
+	manager.recordVehiclePositions(ctx, "feed-0", nil, nil, later)
+	assert.Empty(t, samples())
+}
+
+func TestRecordVehiclePositions_DisabledByDefault(t *testing.T) {
+	manager := newHistoryTestManager(t, 0, 0)
+	ctx := context.Background()
+	now := time.Now()
+
+	manager.recordVehiclePositions(ctx, "feed-0",
+		[]gtfs.Vehicle{historyVehicle("bus1", "trip1", now)},
+		[]gtfs.Trip{delayedTrip("trip1", time.Minute)},
+		now)
+
+	rows, err := manager.GtfsDB.Queries.GetRecentVehiclePositionsForTrip(ctx, gtfsdb.GetRecentVehiclePositionsForTripParams{
+		TripID: sql.NullString{String: "trip1", Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	_, ok := manager.GetSmoothedScheduleDeviation(ctx, "trip1", now)
+	assert.False(t, ok)
+}
+
+func TestSmoothScheduleDeviation(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	observation := func(deviation int64, valid bool, age time.Duration) gtfsdb.VehiclePositionsHistory {
+		return gtfsdb.VehiclePositionsHistory{
+			ScheduleDeviation: sql.NullInt64{Int64: deviation, Valid: valid},
+			ObservedAt:        now.Add(-age).UnixMilli(),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		observations []gtfsdb.VehiclePositionsHistory
+		want         int
+		wantOK       bool
+	}{
+		{"no observations", nil, 0, false},
+		{"skips missing deviation", []gtfsdb.VehiclePositionsHistory{observation(0, false, 0), observation(30, true, 0)}, 30, true},
+		{"ignores stale observations", []gtfsdb.VehiclePositionsHistory{observation(60, true, 0), observation(600, true, time.Hour)}, 60, true},
+		{"rounds negative means", []gtfsdb.VehiclePositionsHistory{observation(-10, true, 0), observation(-11, true, 0)}, -11, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := smoothScheduleDeviation(tt.observations, now.Add(-staleVehicleTimeout))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package gtfs
+
+import (
+	"regexp"
+
+	"github.com/OneBusAway/go-gtfs"
+)
+
+// VehicleIDRewrite normalizes the vehicle IDs a GTFS-RT feed publishes, for
+// feeds whose IDs are prefixed or formatted differently from the static feed's
+// conventions. Matches of Pattern are replaced as by regexp.ReplaceAllString,
+// so Replacement may refer to capture groups.
+type VehicleIDRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// normalizeVehicleID applies the feed's rewrites to a vehicle ID in order,
+// each to the result of the previous one.
+func (feed RTFeedConfig) normalizeVehicleID(id string) string {
+	for _, rewrite := range feed.VehicleIDRewrites {
+		id = rewrite.Pattern.ReplaceAllString(id, rewrite.Replacement)
+	}
+	return id
+}
+
+// normalizeVehicleIDs rewrites the vehicle IDs of freshly fetched data, both
+// of the vehicle positions and of the vehicles named by trip updates, so that
+// everything downstream (matching vehicles to trips, history, responses) sees
+// only normalized IDs. Parsed vehicles share their ID pointers, so rewritten
+// IDs are copies rather than updates in place.
+func (feed RTFeedConfig) normalizeVehicleIDs(fetch *feedFetch) {
+	if len(feed.VehicleIDRewrites) == 0 {
+		return
+	}
+	rewrite := func(id *gtfs.VehicleID) *gtfs.VehicleID {
+		if id == nil {
+			return nil
+		}
+		normalized := *id
+		normalized.ID = feed.normalizeVehicleID(id.ID)
+		return &normalized
+	}
+
+	for _, data := range fetch.data {
+		if data == nil {
+			continue
+		}
+		for i := range data.Vehicles {
+			data.Vehicles[i].ID = rewrite(data.Vehicles[i].ID)
+		}
+		for i := range data.Trips {
+			if data.Trips[i].Vehicle == nil {
+				continue
+			}
+			vehicle := *data.Trips[i].Vehicle
+			vehicle.ID = rewrite(vehicle.ID)
+			data.Trips[i].Vehicle = &vehicle
+		}
+	}
+}
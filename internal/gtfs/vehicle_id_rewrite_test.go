@@ -0,0 +1,91 @@
+package gtfs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeVehicleID(t *testing.T) {
+	feed := RTFeedConfig{VehicleIDRewrites: []VehicleIDRewrite{
+		{Pattern: regexp.MustCompile(`^KCM_`), Replacement: ""},
+		{Pattern: regexp.MustCompile(`^0*(\d+)$`), Replacement: "bus-$1"},
+	}}
+
+	assert.Equal(t, "bus-42", feed.normalizeVehicleID("KCM_0042"))
+	assert.Equal(t, "bus-7", feed.normalizeVehicleID("7"))
+	assert.Equal(t, "tram A", feed.normalizeVehicleID("tram A"))
+	assert.Equal(t, "KCM_1", RTFeedConfig{}.normalizeVehicleID("KCM_1"))
+}
+
+func TestNormalizeVehicleIDsRewritesSharedIDsOnce(t *testing.T) {
+	feed := RTFeedConfig{VehicleIDRewrites: []VehicleIDRewrite{
+		{Pattern: regexp.MustCompile(`^`), Replacement: "1_"},
+	}}
+
+	// The parser links a trip update and a vehicle position reporting the same
+	// vehicle through one VehicleID.
+	id := &gtfs.VehicleID{ID: "42", Label: "Bus 42"}
+	vehicle := &gtfs.Vehicle{ID: id}
+	fetch := &feedFetch{}
+	fetch.data[sourceTripUpdates] = &gtfs.Realtime{Trips: []gtfs.Trip{{ID: gtfs.TripID{ID: "t1"}, Vehicle: vehicle}}}
+	fetch.data[sourceVehiclePositions] = &gtfs.Realtime{Vehicles: []gtfs.Vehicle{{ID: id}, {}}}
+
+	feed.normalizeVehicleIDs(fetch)
+
+	assert.Equal(t, "1_42", fetch.data[sourceTripUpdates].Trips[0].Vehicle.ID.ID)
+	assert.Equal(t, "1_42", fetch.data[sourceVehiclePositions].Vehicles[0].ID.ID)
+	assert.Equal(t, "Bus 42", fetch.data[sourceVehiclePositions].Vehicles[0].ID.Label)
+	assert.Nil(t, fetch.data[sourceVehiclePositions].Vehicles[1].ID)
+}
+
+func TestVehicleIDRewritesAppliedToFeed(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, name := range []string{"trip-updates", "vehicle-positions"} {
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			data, err := os.ReadFile(filepath.Join("../../testdata", "raba-"+name+".pb"))
+			require.NoError(t, err)
+			_, _ = w.Write(data)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	manager := newTestManager()
+	manager.updateFeedRealtime(context.Background(), RTFeedConfig{
+		ID:                  "raba",
+		TripUpdatesURL:      server.URL + "/trip-updates",
+		VehiclePositionsURL: server.URL + "/vehicle-positions",
+		Enabled:             true,
+		VehicleIDRewrites: []VehicleIDRewrite{
+			{Pattern: regexp.MustCompile(`^(.+)$`), Replacement: "raba-$1"},
+		},
+	})
+
+	vehicles := manager.GetRealTimeVehicles()
+	require.NotEmpty(t, vehicles)
+	for _, v := range vehicles {
+		require.NotNil(t, v.ID)
+		assert.True(t, strings.HasPrefix(v.ID.ID, "raba-"), v.ID.ID)
+		assert.False(t, strings.HasPrefix(v.ID.ID, "raba-raba-"), v.ID.ID)
+
+		found, err := manager.GetVehicleByID(v.ID.ID)
+		require.NoError(t, err, "vehicle %s is looked up by its normalized ID", v.ID.ID)
+		assert.Equal(t, v.ID.ID, found.ID.ID)
+	}
+
+	for _, trip := range manager.GetRealTimeTrips() {
+		if trip.Vehicle != nil && trip.Vehicle.ID != nil {
+			assert.True(t, strings.HasPrefix(trip.Vehicle.ID.ID, "raba-"), trip.Vehicle.ID.ID)
+		}
+	}
+}
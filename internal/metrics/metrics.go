@@ -20,6 +20,9 @@ type Metrics struct {
 	// HTTP metrics
 	HTTPRequestsTotal   *prometheus.CounterVec
 	HTTPRequestDuration *prometheus.HistogramVec
+	// RateLimitRejectionsTotal counts requests answered with 429, labelled by
+	// the limit that rejected them: "key" or "ip".
+	RateLimitRejectionsTotal *prometheus.CounterVec
 
 	// Database metrics
 	DBConnectionsOpen  prometheus.Gauge
@@ -66,6 +69,14 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 		[]string{"method", "path"},
 	)
 
+	rateLimitRejectionsTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "maglev_rate_limit_rejections_total",
+			Help: "Total number of requests rejected by a rate limit",
+		},
+		[]string{"limit"},
+	)
+
 	dbConnectionsOpen := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "maglev_db_connections_open",
 		Help: "Number of open database connections",
@@ -90,6 +101,7 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 	registry.MustRegister(
 		httpRequestsTotal,
 		httpRequestDuration,
+		rateLimitRejectionsTotal,
 		dbConnectionsOpen,
 		dbConnectionsInUse,
 		dbConnectionsIdle,
@@ -105,6 +117,8 @@ func NewWithLogger(logger *slog.Logger) *Metrics {
 		DBConnectionsIdle:   dbConnectionsIdle,
 		DBWaitSecondsTotal:  dbWaitSecondsTotal,
 		logger:              logger,
+
+		RateLimitRejectionsTotal: rateLimitRejectionsTotal,
 	}
 }
 
@@ -166,6 +180,49 @@ func (m *Metrics) StartDBStatsCollector(db *sql.DB, interval time.Duration) {
 	}()
 }
 
+// CacheStats counts the lookups a cache answered and the ones it passed on.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// RegisterEntityCacheStats exports the GTFS database's entity cache counts as
+// maglev_entity_cache_hits_total and maglev_entity_cache_misses_total, labelled
+// by entity. source is read on every scrape; the counts restart when the
+// database is reopened after a static reload.
+func (m *Metrics) RegisterEntityCacheStats(source func() map[string]CacheStats) {
+	m.Registry.MustRegister(&entityCacheCollector{source: source})
+}
+
+var (
+	entityCacheHitsDesc = prometheus.NewDesc(
+		"maglev_entity_cache_hits_total",
+		"Trip, route and stop lookups answered from the in-memory cache",
+		[]string{"entity"}, nil,
+	)
+	entityCacheMissesDesc = prometheus.NewDesc(
+		"maglev_entity_cache_misses_total",
+		"Trip, route and stop lookups that went to the database",
+		[]string{"entity"}, nil,
+	)
+)
+
+type entityCacheCollector struct {
+	source func() map[string]CacheStats
+}
+
+func (c *entityCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- entityCacheHitsDesc
+	ch <- entityCacheMissesDesc
+}
+
+func (c *entityCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for entity, stats := range c.source() {
+		ch <- prometheus.MustNewConstMetric(entityCacheHitsDesc, prometheus.CounterValue, float64(stats.Hits), entity)
+		ch <- prometheus.MustNewConstMetric(entityCacheMissesDesc, prometheus.CounterValue, float64(stats.Misses), entity)
+	}
+}
+
 // Shutdown stops the DB stats collector goroutine and waits for it to exit.
 // This method is safe to call multiple times.
 func (m *Metrics) Shutdown() {
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, m.Registry)
 	assert.NotNil(t, m.HTTPRequestsTotal)
 	assert.NotNil(t, m.HTTPRequestDuration)
+	assert.NotNil(t, m.RateLimitRejectionsTotal)
 	assert.NotNil(t, m.DBConnectionsOpen)
 	assert.NotNil(t, m.DBConnectionsInUse)
 	assert.NotNil(t, m.DBConnectionsIdle)
@@ -129,3 +131,21 @@ func TestHTTPMetrics_RecordRequest(t *testing.T) {
 	assert.NotNil(t, m.HTTPRequestsTotal)
 	assert.NotNil(t, m.HTTPRequestDuration)
 }
+
+func TestRegisterEntityCacheStats(t *testing.T) {
+	m := New()
+	m.RegisterEntityCacheStats(func() map[string]CacheStats {
+		return map[string]CacheStats{"trip": {Hits: 7, Misses: 3}}
+	})
+
+	expected := `
+# HELP maglev_entity_cache_hits_total Trip, route and stop lookups answered from the in-memory cache
+# TYPE maglev_entity_cache_hits_total counter
+maglev_entity_cache_hits_total{entity="trip"} 7
+# HELP maglev_entity_cache_misses_total Trip, route and stop lookups that went to the database
+# TYPE maglev_entity_cache_misses_total counter
+maglev_entity_cache_misses_total{entity="trip"} 3
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry, strings.NewReader(expected),
+		"maglev_entity_cache_hits_total", "maglev_entity_cache_misses_total"))
+}
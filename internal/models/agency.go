@@ -20,6 +20,23 @@ func NewAgencyCoverage(agencyID string, lat, latSpan, lon, lonSpan float64) Agen
 	}
 }
 
+// AgencyServiceCoverage describes where and when an agency runs service: the
+// bounding box and centroid of the stops its trips serve and the range of dates
+// its services cover. Dates use the GTFS YYYYMMDD format and are empty when the
+// agency's services list no dates.
+type AgencyServiceCoverage struct {
+	AgencyID         string  `json:"agencyId"`
+	MinLat           float64 `json:"minLat"`
+	MinLon           float64 `json:"minLon"`
+	MaxLat           float64 `json:"maxLat"`
+	MaxLon           float64 `json:"maxLon"`
+	CentroidLat      float64 `json:"centroidLat"`
+	CentroidLon      float64 `json:"centroidLon"`
+	StopCount        int     `json:"stopCount"`
+	ServiceStartDate string  `json:"serviceStartDate,omitempty"`
+	ServiceEndDate   string  `json:"serviceEndDate,omitempty"`
+}
+
 type AgencyReference struct {
 	Disclaimer     string `json:"disclaimer"`
 	Email          string `json:"email"`
@@ -0,0 +1,16 @@
+package models
+
+// APIKey describes a stored API key for the admin endpoints. Times are in
+// milliseconds since the epoch.
+type APIKey struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// RateLimit is the key's requests-per-second limit; absent means the server default.
+	RateLimit *int `json:"rateLimit,omitempty"`
+	// ExpiresAt is when the key stops being accepted; absent means never.
+	ExpiresAt    *int64 `json:"expiresAt,omitempty"`
+	Expired      bool   `json:"expired"`
+	CreatedAt    int64  `json:"createdAt"`
+	RequestCount int64  `json:"requestCount"`
+	LastUsedAt   *int64 `json:"lastUsedAt,omitempty"`
+}
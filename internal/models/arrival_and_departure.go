@@ -16,6 +16,7 @@ type ArrivalAndDeparture struct {
 	PredictedArrivalTime       int64                     `json:"predictedArrivalTime"`
 	PredictedDepartureInterval interface{}               `json:"predictedDepartureInterval"`
 	PredictedDepartureTime     int64                     `json:"predictedDepartureTime"`
+	PredictedFrom              string                    `json:"predictedFrom,omitempty"`
 	PredictedOccupancy         string                    `json:"predictedOccupancy"`
 	RouteID                    string                    `json:"routeId"`
 	RouteLongName              string                    `json:"routeLongName"`
@@ -0,0 +1,34 @@
+package models
+
+// ArrivalSubscription is a client's request to be notified at CallbackURL
+// when a vehicle is due at a stop within ThresholdMinutes. Secret, which
+// signs the notifications, is only returned when the subscription is created.
+// Times are in milliseconds since the epoch.
+type ArrivalSubscription struct {
+	ID               string `json:"id"`
+	CallbackURL      string `json:"callbackUrl"`
+	StopID           string `json:"stopId"`
+	RouteID          string `json:"routeId,omitempty"`
+	ThresholdMinutes int    `json:"thresholdMinutes"`
+	Secret           string `json:"secret,omitempty"`
+	CreatedAt        int64  `json:"createdAt"`
+	ExpiresAt        int64  `json:"expiresAt"`
+}
+
+// ArrivalNotification is the body POSTed to a subscription's callback URL
+// when an arrival comes within its threshold. PredictedArrivalTime is 0 when
+// the arrival is not predicted and ScheduledArrivalTime is the best estimate.
+type ArrivalNotification struct {
+	SubscriptionID       string `json:"subscriptionId"`
+	StopID               string `json:"stopId"`
+	RouteID              string `json:"routeId"`
+	RouteShortName       string `json:"routeShortName"`
+	TripID               string `json:"tripId"`
+	TripHeadsign         string `json:"tripHeadsign"`
+	VehicleID            string `json:"vehicleId,omitempty"`
+	ServiceDate          int64  `json:"serviceDate"`
+	ScheduledArrivalTime int64  `json:"scheduledArrivalTime"`
+	PredictedArrivalTime int64  `json:"predictedArrivalTime"`
+	Predicted            bool   `json:"predicted"`
+	CurrentTime          int64  `json:"currentTime"`
+}
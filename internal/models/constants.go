@@ -8,6 +8,10 @@ const (
 	Accessible = "ACCESSIBLE"
 	// NotAccessible indicates wheelchair boarding is not possible (GTFS wheelchair_boarding = 2)
 	NotAccessible = "NOT_ACCESSIBLE"
+	// BikesAllowed indicates bicycles may be taken on a trip (GTFS bikes_allowed = 1)
+	BikesAllowed = "ALLOWED"
+	// BikesNotAllowed indicates bicycles may not be taken on a trip (GTFS bikes_allowed = 2)
+	BikesNotAllowed = "NOT_ALLOWED"
 )
 
 const (
@@ -23,6 +27,7 @@ const (
 )
 
 const (
+	DefaultMaxCountForBlocks = 50
 	DefaultMaxCountForRoutes = 50
 	DefaultMaxCountForStops  = 100
 	MaxAllowedCount          = 250
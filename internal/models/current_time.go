@@ -6,6 +6,27 @@ import "time"
 type CurrentTimeModel struct {
 	ReadableTime string `json:"readableTime"`
 	Time         int64  `json:"time"`
+	// Ready is true once the static GTFS import is complete and the data is
+	// valid, so that clients can tell an instance still importing from one
+	// that can answer.
+	Ready bool `json:"ready"`
+	// StaticDataUpdatedAt is when the static GTFS data in use was loaded, in
+	// epoch milliseconds; omitted before the first import finishes.
+	StaticDataUpdatedAt int64                `json:"staticDataUpdatedAt,omitempty"`
+	RealtimeFeeds       []RealtimeFeedStatus `json:"realtimeFeeds,omitempty"`
+	// ClockSkew is the server time minus the clientTime the request sent, in
+	// milliseconds; omitted when the request sent none.
+	ClockSkew *int64 `json:"clockSkew,omitempty"`
+}
+
+// RealtimeFeedStatus is the state of one GTFS-realtime feed as reported by the
+// current-time endpoint.
+type RealtimeFeedStatus struct {
+	FeedID string `json:"feedId"`
+	// LastSuccess is when the feed was last fetched successfully, in epoch
+	// milliseconds, or 0 if it never has been.
+	LastSuccess int64 `json:"lastSuccess"`
+	Stale       bool  `json:"stale"`
 }
 
 // CurrentTimeData Combined data structure for current time endpoint
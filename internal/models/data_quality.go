@@ -0,0 +1,47 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// DataQuality summarizes how well the realtime feeds cover an agency's routes,
+// as last computed at ComputedAt, in Unix milliseconds. ComputedAt is 0 and
+// Routes empty until the first computation, and without realtime feeds.
+type DataQuality struct {
+	AgencyID   string             `json:"agencyId"`
+	ComputedAt int64              `json:"computedAt"`
+	Routes     []RouteDataQuality `json:"routes"`
+}
+
+// RouteDataQuality measures the realtime coverage of one route's trips that
+// are under way by the schedule. The percentages are of ScheduledTripCount;
+// MedianPositionAge is in seconds, -1 when no vehicle reports the time of its
+// position.
+type RouteDataQuality struct {
+	RouteID                  string  `json:"routeId"`
+	ScheduledTripCount       int     `json:"scheduledTripCount"`
+	TripsWithVehicleCount    int     `json:"tripsWithVehicleCount"`
+	TripsWithVehiclePercent  float64 `json:"tripsWithVehiclePercent"`
+	PredictedTripCount       int     `json:"predictedTripCount"`
+	PredictedArrivalsPercent float64 `json:"predictedArrivalsPercent"`
+	VehicleCount             int     `json:"vehicleCount"`
+	MedianPositionAge        float64 `json:"medianPositionAge"`
+}
+
+func NewRouteDataQuality(routeID string, scheduled, withVehicle, predicted, vehicles int, medianPositionAge time.Duration) RouteDataQuality {
+	age := -1.0
+	if medianPositionAge >= 0 {
+		age = math.Round(medianPositionAge.Seconds()*10) / 10
+	}
+	return RouteDataQuality{
+		RouteID:                  routeID,
+		ScheduledTripCount:       scheduled,
+		TripsWithVehicleCount:    withVehicle,
+		TripsWithVehiclePercent:  percentOf(withVehicle, scheduled),
+		PredictedTripCount:       predicted,
+		PredictedArrivalsPercent: percentOf(predicted, scheduled),
+		VehicleCount:             vehicles,
+		MedianPositionAge:        age,
+	}
+}
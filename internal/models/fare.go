@@ -0,0 +1,31 @@
+package models
+
+// Payment methods of a fare, from the payment_method field of fare_attributes.txt.
+const (
+	FarePaymentOnBoard        = "onBoard"
+	FarePaymentBeforeBoarding = "beforeBoarding"
+)
+
+// Fare is a GTFS fare: the price of a ride and the transfers it includes.
+// Transfers is the number of transfers allowed, or nil when they are
+// unlimited, and TransferDuration is how long, in seconds, a transfer stays
+// valid. Rules are the zones the fare is limited to; a fare without rules
+// applies to every ride on the route.
+type Fare struct {
+	ID               string     `json:"id"`
+	AgencyID         string     `json:"agencyId"`
+	Price            float64    `json:"price"`
+	CurrencyType     string     `json:"currencyType"`
+	PaymentMethod    string     `json:"paymentMethod"`
+	Transfers        *int64     `json:"transfers"`
+	TransferDuration *int64     `json:"transferDuration,omitempty"`
+	Rules            []FareRule `json:"rules"`
+}
+
+// FareRule limits a fare to rides that board in OriginZoneID, alight in
+// DestinationZoneID or pass through ContainsZoneID. Empty fields match any zone.
+type FareRule struct {
+	OriginZoneID      string `json:"originZoneId,omitempty"`
+	DestinationZoneID string `json:"destinationZoneId,omitempty"`
+	ContainsZoneID    string `json:"containsZoneId,omitempty"`
+}
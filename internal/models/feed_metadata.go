@@ -0,0 +1,30 @@
+package models
+
+// FeedMetadata describes the static GTFS dataset the server is running, as
+// recorded by the importer.
+type FeedMetadata struct {
+	// FileHash is the SHA-256 of the imported GTFS zip, hex encoded.
+	FileHash string `json:"fileHash"`
+	// ImportTime is when the dataset was imported, in milliseconds since the epoch.
+	ImportTime int64 `json:"importTime"`
+	// Source is the URL or path the dataset was loaded from, with credentials removed.
+	Source string `json:"source"`
+	// TableCounts maps each GTFS table to its row count.
+	TableCounts map[string]int `json:"tableCounts"`
+	// FeedInfo holds the contents of feed_info.txt, when the feed has one.
+	FeedInfo *FeedInfo `json:"feedInfo,omitempty"`
+}
+
+// FeedInfo mirrors the fields of GTFS feed_info.txt. Dates use the GTFS
+// YYYYMMDD format and are empty when the feed leaves them unset.
+type FeedInfo struct {
+	PublisherName string `json:"publisherName"`
+	PublisherURL  string `json:"publisherUrl"`
+	Lang          string `json:"lang"`
+	DefaultLang   string `json:"defaultLang,omitempty"`
+	StartDate     string `json:"startDate,omitempty"`
+	EndDate       string `json:"endDate,omitempty"`
+	Version       string `json:"version,omitempty"`
+	ContactEmail  string `json:"contactEmail,omitempty"`
+	ContactURL    string `json:"contactUrl,omitempty"`
+}
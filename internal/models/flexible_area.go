@@ -0,0 +1,60 @@
+package models
+
+import "encoding/json"
+
+// Kinds of FlexibleArea.
+const (
+	FlexibleAreaZone          = "zone"
+	FlexibleAreaLocationGroup = "locationGroup"
+)
+
+// FlexibleArea is a GTFS-Flex service area: either a zone from
+// locations.geojson, which carries its GeoJSON geometry, or a location group,
+// which lists its stops.
+type FlexibleArea struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Geometry    json.RawMessage `json:"geometry,omitempty"`
+	StopIDs     []string        `json:"stopIds,omitempty"`
+}
+
+// TripFlexibleArea describes where and when a demand-responsive trip picks up
+// and drops off riders. Area is set for rows serving a zone or location group
+// and StopID for rows at a regular stop. Windows are in seconds since the
+// start of the service day.
+type TripFlexibleArea struct {
+	StopSequence             int           `json:"stopSequence"`
+	Area                     *FlexibleArea `json:"area,omitempty"`
+	StopID                   string        `json:"stopId,omitempty"`
+	StartPickupDropOffWindow *int          `json:"startPickupDropOffWindow,omitempty"`
+	EndPickupDropOffWindow   *int          `json:"endPickupDropOffWindow,omitempty"`
+	PickupType               int           `json:"pickupType"`
+	DropOffType              int           `json:"dropOffType"`
+	ContinuousPickup         int           `json:"continuousPickup"`
+	ContinuousDropOff        int           `json:"continuousDropOff"`
+	PickupBookingRule        *BookingRule  `json:"pickupBookingRule,omitempty"`
+	DropOffBookingRule       *BookingRule  `json:"dropOffBookingRule,omitempty"`
+}
+
+// BookingRule is a booking_rules.txt entry: how and how far in advance a
+// rider must book a demand-responsive trip. Times of day are in seconds since
+// midnight.
+type BookingRule struct {
+	ID                     string `json:"id"`
+	BookingType            int    `json:"bookingType"`
+	PriorNoticeDurationMin *int   `json:"priorNoticeDurationMin,omitempty"`
+	PriorNoticeDurationMax *int   `json:"priorNoticeDurationMax,omitempty"`
+	PriorNoticeLastDay     *int   `json:"priorNoticeLastDay,omitempty"`
+	PriorNoticeLastTime    *int   `json:"priorNoticeLastTime,omitempty"`
+	PriorNoticeStartDay    *int   `json:"priorNoticeStartDay,omitempty"`
+	PriorNoticeStartTime   *int   `json:"priorNoticeStartTime,omitempty"`
+	PriorNoticeServiceID   string `json:"priorNoticeServiceId,omitempty"`
+	Message                string `json:"message,omitempty"`
+	PickupMessage          string `json:"pickupMessage,omitempty"`
+	DropOffMessage         string `json:"dropOffMessage,omitempty"`
+	PhoneNumber            string `json:"phoneNumber,omitempty"`
+	InfoURL                string `json:"infoUrl,omitempty"`
+	BookingURL             string `json:"bookingUrl,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// ImportWarning is a problem found while parsing a row of the static GTFS
+// feed, for the admin endpoints.
+type ImportWarning struct {
+	File string `json:"file"`
+	// RowNumber counts the file's data rows; blank lines are skipped, so it
+	// is not always the line number.
+	RowNumber  int    `json:"rowNumber"`
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	RowContent string `json:"rowContent"`
+}
+
+// ImportWarningCount is how many warnings of one kind were stored for a file.
+type ImportWarningCount struct {
+	File  string `json:"file"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
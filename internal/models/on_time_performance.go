@@ -0,0 +1,54 @@
+package models
+
+import "math"
+
+// OnTimePerformance summarizes how closely an agency's routes kept to schedule
+// between FromTime and ToTime, in Unix milliseconds. A stop observation is early
+// when more than EarlyThreshold seconds ahead of schedule and late when more
+// than LateThreshold seconds behind it.
+type OnTimePerformance struct {
+	AgencyID       string                   `json:"agencyId"`
+	FromTime       int64                    `json:"fromTime"`
+	ToTime         int64                    `json:"toTime"`
+	EarlyThreshold int                      `json:"earlyThreshold"`
+	LateThreshold  int                      `json:"lateThreshold"`
+	Routes         []RouteOnTimePerformance `json:"routes"`
+}
+
+// RouteOnTimePerformance counts the stop observations of one route. The
+// percentages are of SampleCount, and MeanDeviation is in seconds, positive when
+// late.
+type RouteOnTimePerformance struct {
+	RouteID       string  `json:"routeId"`
+	SampleCount   int     `json:"sampleCount"`
+	EarlyCount    int     `json:"earlyCount"`
+	OnTimeCount   int     `json:"onTimeCount"`
+	LateCount     int     `json:"lateCount"`
+	EarlyPercent  float64 `json:"earlyPercent"`
+	OnTimePercent float64 `json:"onTimePercent"`
+	LatePercent   float64 `json:"latePercent"`
+	MeanDeviation float64 `json:"meanDeviation"`
+}
+
+func NewRouteOnTimePerformance(routeID string, samples, early, late int, meanDeviation float64) RouteOnTimePerformance {
+	onTime := samples - early - late
+	return RouteOnTimePerformance{
+		RouteID:       routeID,
+		SampleCount:   samples,
+		EarlyCount:    early,
+		OnTimeCount:   onTime,
+		LateCount:     late,
+		EarlyPercent:  percentOf(early, samples),
+		OnTimePercent: percentOf(onTime, samples),
+		LatePercent:   percentOf(late, samples),
+		MeanDeviation: math.Round(meanDeviation*10) / 10,
+	}
+}
+
+// percentOf returns part as a percentage of total, to one decimal place.
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
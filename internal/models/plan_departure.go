@@ -0,0 +1,28 @@
+package models
+
+// PlannedDeparture is a trip a rider can board at FromStopID and stay on until
+// it later calls at ToStopID. Times are Unix milliseconds; the predicted ones
+// are 0 unless real-time data covers the trip.
+type PlannedDeparture struct {
+	TripID                 string `json:"tripId"`
+	RouteID                string `json:"routeId"`
+	ServiceDate            int64  `json:"serviceDate"`
+	TripHeadsign           string `json:"tripHeadsign"`
+	FromStopID             string `json:"fromStopId"`
+	FromStopSequence       int    `json:"fromStopSequence"`
+	ToStopID               string `json:"toStopId"`
+	ToStopSequence         int    `json:"toStopSequence"`
+	ScheduledDepartureTime int64  `json:"scheduledDepartureTime"`
+	PredictedDepartureTime int64  `json:"predictedDepartureTime"`
+	ScheduledArrivalTime   int64  `json:"scheduledArrivalTime"`
+	PredictedArrivalTime   int64  `json:"predictedArrivalTime"`
+	Predicted              bool   `json:"predicted"`
+}
+
+// DepartureTime is when the trip leaves the origin stop, predicted if known.
+func (p PlannedDeparture) DepartureTime() int64 {
+	if p.PredictedDepartureTime != 0 {
+		return p.PredictedDepartureTime
+	}
+	return p.ScheduledDepartureTime
+}
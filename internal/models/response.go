@@ -4,11 +4,13 @@ import (
 	"maglev.onebusaway.org/internal/clock"
 )
 
-// ResponseModel Base response structure that can be reused
+// ResponseModel Base response structure that can be reused. Error responses
+// also carry a machine-readable ErrorCode.
 type ResponseModel struct {
 	Code        int         `json:"code"`
 	CurrentTime int64       `json:"currentTime"`
 	Data        interface{} `json:"data,omitempty"`
+	ErrorCode   string      `json:"errorCode,omitempty"`
 	Text        string      `json:"text"`
 	Version     int         `json:"version"`
 }
@@ -74,3 +76,25 @@ func NewResponse(code int, data interface{}, text string, c clock.Clock) Respons
 func ResponseCurrentTime(c clock.Clock) int64 {
 	return c.NowUnixMilli()
 }
+
+// Page describes how a list response relates to the full result set.
+// NextCursor, when set, is passed back as the cursor parameter to fetch the
+// following page.
+type Page struct {
+	LimitExceeded bool
+	NextCursor    string
+}
+
+// WithPage records page on a list or arrivals response: limitExceeded is set
+// from it, and nextCursor is added when there are more results.
+func WithPage(response ResponseModel, page Page) ResponseModel {
+	data, ok := response.Data.(map[string]interface{})
+	if !ok {
+		return response
+	}
+	data["limitExceeded"] = page.LimitExceeded
+	if page.NextCursor != "" {
+		data["nextCursor"] = page.NextCursor
+	}
+	return response
+}
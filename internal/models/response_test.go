@@ -232,3 +232,17 @@ func TestResponseModelJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestWithPage(t *testing.T) {
+	c := clock.NewMockClock(time.Unix(0, 0))
+
+	response := WithPage(NewListResponse([]string{"a"}, NewEmptyReferences(), false, c), Page{LimitExceeded: true, NextCursor: "abc"})
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, true, data["limitExceeded"])
+	assert.Equal(t, "abc", data["nextCursor"])
+
+	response = WithPage(NewArrivalsAndDepartureResponse(nil, NewEmptyReferences(), nil, nil, "1_2", c), Page{})
+	data = response.Data.(map[string]interface{})
+	assert.Equal(t, false, data["limitExceeded"])
+	assert.NotContains(t, data, "nextCursor", "the last page carries no cursor")
+}
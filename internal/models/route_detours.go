@@ -0,0 +1,20 @@
+package models
+
+// RouteDetours summarizes the trips of a route whose vehicles have been away
+// from the scheduled shape for several consecutive position updates.
+type RouteDetours struct {
+	RouteID           string       `json:"routeId"`
+	DeviatedTripCount int          `json:"deviatedTripCount"`
+	Trips             []TripDetour `json:"trips"`
+}
+
+// TripDetour is one deviated trip. DistanceFromShape is in meters and Since is
+// when the vehicle was first seen off the shape, in Unix milliseconds.
+type TripDetour struct {
+	TripID             string   `json:"tripId"`
+	VehicleID          string   `json:"vehicleId"`
+	DistanceFromShape  float64  `json:"distanceFromShape"`
+	ConsecutiveUpdates int      `json:"consecutiveUpdates"`
+	Since              int64    `json:"since"`
+	Position           Location `json:"position"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "cmp"
+
 type RouteType int
 
 type Route struct {
@@ -10,6 +12,7 @@ type Route struct {
 	LongName          string    `json:"longName"`
 	NullSafeShortName string    `json:"nullSafeShortName"`
 	ShortName         string    `json:"shortName"`
+	SortOrder         *int32    `json:"sortOrder,omitempty"` // route_sort_order; nil when the feed gives none
 	TextColor         string    `json:"textColor"`
 	Type              RouteType `json:"type"`
 	URL               string    `json:"url"`
@@ -35,6 +38,26 @@ func NewRoute(id, agencyID, shortName, longName, description string, routeType R
 	}
 }
 
+// WithSortOrder returns the route with the feed's route_sort_order, which may be nil.
+func (r Route) WithSortOrder(sortOrder *int32) Route {
+	r.SortOrder = sortOrder
+	return r
+}
+
+// CompareSortOrder orders two route_sort_order values the way routes are
+// listed: ascending, with routes that have none after those that do.
+func CompareSortOrder(a, b *int32) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return cmp.Compare(*a, *b)
+}
+
 type RouteResponse struct {
 	Code        int       `json:"code"`
 	CurrentTime int64     `json:"currentTime"`
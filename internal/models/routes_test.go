@@ -180,3 +180,22 @@ func TestRouteNullSafeShortNameFallback(t *testing.T) {
 	route2 := NewRoute("2", "agency-1", "DX", "Downtown Express", "", 3, "", "", "")
 	assert.Equal(t, "DX", route2.NullSafeShortName)
 }
+
+func TestRouteSortOrder(t *testing.T) {
+	zero, ten := int32(0), int32(10)
+
+	route := NewRoute("1", "agency-1", "1", "", "", 3, "", "", "")
+	data, err := json.Marshal(route)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "sortOrder")
+
+	data, err = json.Marshal(route.WithSortOrder(&zero))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"sortOrder":0`)
+
+	assert.Negative(t, CompareSortOrder(&zero, &ten))
+	assert.Positive(t, CompareSortOrder(&ten, &zero))
+	assert.Negative(t, CompareSortOrder(&ten, nil), "routes without a sort order come last")
+	assert.Positive(t, CompareSortOrder(nil, &zero))
+	assert.Zero(t, CompareSortOrder(nil, nil))
+}
@@ -1,14 +1,18 @@
 package models
 
 type RouteStopTime struct {
-	ArrivalEnabled   bool   `json:"arrivalEnabled"`
-	ArrivalTime      int    `json:"arrivalTime"`
-	DepartureEnabled bool   `json:"departureEnabled"`
-	DepartureTime    int    `json:"departureTime"`
-	ServiceID        string `json:"serviceId"`
-	StopHeadsign     string `json:"stopHeadsign"`
-	StopID           string `json:"stopId"`
-	TripID           string `json:"tripId"`
+	ArrivalEnabled   bool `json:"arrivalEnabled"`
+	ArrivalTime      int  `json:"arrivalTime"`
+	DepartureEnabled bool `json:"departureEnabled"`
+	DepartureTime    int  `json:"departureTime"`
+	// DistanceAlongTrip is the stop's position along the trip's shape in meters,
+	// or 0 when the trip has no shape.
+	DistanceAlongTrip float64 `json:"distanceAlongTrip"`
+	ServiceID         string  `json:"serviceId"`
+	StopHeadsign      string  `json:"stopHeadsign"`
+	StopID            string  `json:"stopId"`
+	StopSequence      int     `json:"stopSequence"`
+	TripID            string  `json:"tripId"`
 }
 
 type TripStopTimes struct {
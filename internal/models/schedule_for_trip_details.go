@@ -6,6 +6,28 @@ type Schedule struct {
 	PreviousTripID string     `json:"previousTripId"`
 	StopTimes      []StopTime `json:"stopTimes"`
 	TimeZone       string     `json:"timeZone"`
+	// PreviousLayover and NextLayover are the waits between this trip and the
+	// previous and next trips of its block, when it has them.
+	PreviousLayover *Layover `json:"previousLayover,omitempty"`
+	NextLayover     *Layover `json:"nextLayover,omitempty"`
+	// Truncated is set when the trip had more stop times than the configured
+	// limit and only the first ones are listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Layover is the time a vehicle waits between two consecutive trips of a
+// block: from the earlier trip's arrival at its last stop to the later trip's
+// departure from its first stop. Times are seconds since the service date's
+// midnight. StopID is the earlier trip's last stop, where the layover begins.
+type Layover struct {
+	StopID    string `json:"stopId"`
+	StartTime int    `json:"startTime"`
+	EndTime   int    `json:"endTime"`
+}
+
+// Duration is the length of the layover in seconds.
+func (l Layover) Duration() int {
+	return l.EndTime - l.StartTime
 }
 
 func NewSchedule(frequency int64, nextTripID, previousTripID string, stopTimes []StopTime, timeZone string) *Schedule {
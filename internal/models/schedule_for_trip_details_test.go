@@ -76,3 +76,17 @@ func TestScheduleWithMultipleStopTimes(t *testing.T) {
 	assert.Equal(t, "stop_2", schedule.StopTimes[1].StopID)
 	assert.Equal(t, "stop_3", schedule.StopTimes[2].StopID)
 }
+
+func TestScheduleLayoverJSON(t *testing.T) {
+	schedule := NewSchedule(0, "trip_next", "", []StopTime{}, "America/Los_Angeles")
+
+	jsonData, err := json.Marshal(schedule)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(jsonData), "Layover", "missing layovers are omitted")
+
+	schedule.NextLayover = &Layover{StopID: "1_stop_9", StartTime: 29400, EndTime: 30000}
+	jsonData, err = json.Marshal(schedule)
+	assert.NoError(t, err)
+	assert.Contains(t, string(jsonData), `"nextLayover":{"stopId":"1_stop_9","startTime":29400,"endTime":30000}`)
+	assert.Equal(t, 600, schedule.NextLayover.Duration())
+}
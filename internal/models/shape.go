@@ -14,4 +14,7 @@ type ShapeEntry struct {
 	Points string `json:"points"`
 	Length int    `json:"length"`
 	Levels string `json:"levels"`
+	// Truncated is set when the shape had more points than the configured
+	// limit and was thinned out to fit it.
+	Truncated bool `json:"truncated,omitempty"`
 }
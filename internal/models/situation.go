@@ -29,7 +29,15 @@ type AffectedEntity struct {
 	TripID        string `json:"tripId"`
 }
 
+// TranslatedString holds the text selected for the requested language in
+// Value/Lang, plus every available translation when the feed provides more than one.
 type TranslatedString struct {
-	Value string `json:"value,omitempty"`
+	Value        string        `json:"value,omitempty"`
+	Lang         string        `json:"lang,omitempty"`
+	Translations []Translation `json:"translations,omitempty"`
+}
+
+type Translation struct {
+	Value string `json:"value"`
 	Lang  string `json:"lang,omitempty"`
 }
@@ -4,15 +4,20 @@ type StopTimes struct {
 	StopTimes []StopTime `json:"stop_times"`
 }
 
+// StopTime is a scheduled stop on a trip. Times are seconds since the start of
+// the service day; the predicted times are set when a realtime trip update
+// covers the stop, directly or through a delay carried from an earlier stop.
 type StopTime struct {
-	ArrivalTime         int     `json:"arrivalTime"`
-	DepartureTime       int     `json:"departureTime"`
-	DropOffType         int     `json:"dropOffType"`
-	PickupType          int     `json:"pickupType"`
-	StopID              string  `json:"stopId"`
-	StopHeadsign        string  `json:"stopHeadsign"`
-	DistanceAlongTrip   float64 `json:"distanceAlongTrip"`
-	HistoricalOccupancy string  `json:"historicalOccupancy"`
+	ArrivalTime            int     `json:"arrivalTime"`
+	DepartureTime          int     `json:"departureTime"`
+	PredictedArrivalTime   *int    `json:"predictedArrivalTime,omitempty"`
+	PredictedDepartureTime *int    `json:"predictedDepartureTime,omitempty"`
+	DropOffType            int     `json:"dropOffType"`
+	PickupType             int     `json:"pickupType"`
+	StopID                 string  `json:"stopId"`
+	StopHeadsign           string  `json:"stopHeadsign"`
+	DistanceAlongTrip      float64 `json:"distanceAlongTrip"`
+	HistoricalOccupancy    string  `json:"historicalOccupancy"`
 }
 
 func NewStopTime(arrivalTime, departureTime int, stopID, stopHeadsign string, distanceAlongTrip float64, historicalOccupancy string) StopTime {
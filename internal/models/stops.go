@@ -12,6 +12,31 @@ type Stop struct {
 	RouteIDs           []string `json:"routeIds"`
 	StaticRouteIDs     []string `json:"staticRouteIds"`
 	WheelchairBoarding string   `json:"wheelchairBoarding"`
+
+	// Transfers and FlexibleAreas are only filled in on the stop/{id} entry. They
+	// take a query per stop, so stops listed as references, in arrivals,
+	// stops-for-route or trip details, leave them out.
+	Transfers     []StopTransfer `json:"transfers,omitempty"`
+	FlexibleAreas []FlexibleArea `json:"flexibleAreas,omitempty"`
+
+	// Score is how well the stop matched a stops-for-location query, from 0
+	// to 1, best matches being listed first.
+	Score float64 `json:"score,omitempty"`
+
+	// Distance is how far in meters the stop is from the location given to a
+	// stop-by-code query, which tells apart stops sharing a code.
+	Distance float64 `json:"distance,omitempty"`
+}
+
+// StopTransfer describes a transfers.txt rule originating at a stop.
+type StopTransfer struct {
+	ToStopID        string `json:"toStopId"`
+	TransferType    int    `json:"transferType"`
+	MinTransferTime *int   `json:"minTransferTime,omitempty"`
+	FromRouteID     string `json:"fromRouteId,omitempty"`
+	ToRouteID       string `json:"toRouteId,omitempty"`
+	FromTripID      string `json:"fromTripId,omitempty"`
+	ToTripID        string `json:"toTripId,omitempty"`
 }
 
 func NewStop(code, direction, id, name, parent, wheelchairBoarding string, lat, lon float64, locationType int, routeIDs, staticRouteIDs []string) Stop {
@@ -12,6 +12,14 @@ type Trip struct {
 	RouteShortName string `json:"routeShortName"`
 	PeakOffPeak    int64  `json:"peakOffPeak"`
 	TimeZone       string `json:"timeZone"`
+	// WheelchairAccessible is ACCESSIBLE, NOT_ACCESSIBLE or UNKNOWN, from the
+	// trip's GTFS wheelchair_accessible.
+	WheelchairAccessible string `json:"wheelchairAccessible"`
+	// BikesAllowed is ALLOWED, NOT_ALLOWED or UNKNOWN, from the trip's GTFS
+	// bikes_allowed.
+	BikesAllowed string `json:"bikesAllowed"`
+
+	FlexibleAreas []TripFlexibleArea `json:"flexibleAreas,omitempty"`
 }
 
 type TripResponse struct {
@@ -37,6 +45,9 @@ func NewTripReference(id, routeID, serviceID, headSign, shortName string, direct
 		TimeZone:       "",
 		TripHeadsign:   headSign,
 		TripShortName:  shortName,
+
+		WheelchairAccessible: UnknownValue,
+		BikesAllowed:         UnknownValue,
 	}
 }
 
@@ -1,5 +1,7 @@
 package models
 
+import "math"
+
 type TripDetails struct {
 	Frequency    *Frequency                `json:"frequency"`
 	Schedule     *Schedule                 `json:"schedule"`
@@ -46,20 +48,59 @@ type TripStatusForTripDetails struct {
 	LastUpdateTime             int64      `json:"lastUpdateTime"`
 	NextStop                   string     `json:"nextStop"`
 	NextStopTimeOffset         int        `json:"nextStopTimeOffset"`
-	OccupancyCapacity          int        `json:"occupancyCapacity"`
-	OccupancyCount             int        `json:"occupancyCount"`
+	OccupancyCapacity          int        `json:"occupancyCapacity"`   // passengers the vehicle holds; -1 when unknown
+	OccupancyCount             int        `json:"occupancyCount"`      // passengers aboard; -1 when unknown
+	OccupancyPercentage        int        `json:"occupancyPercentage"` // load as a percentage of capacity, over 100 when crush loaded; -1 when unknown
 	OccupancyStatus            string     `json:"occupancyStatus"`
 	Orientation                float64    `json:"orientation"`
 	Phase                      string     `json:"phase"`
 	Position                   Location   `json:"position"`
-	Predicted                  bool       `json:"predicted"`
-	ScheduleDeviation          int        `json:"scheduleDeviation"`
-	ScheduledDistanceAlongTrip float64    `json:"scheduledDistanceAlongTrip"`
-	ServiceDate                int64      `json:"serviceDate"`
-	SituationIDs               []string   `json:"situationIds"`
-	Status                     string     `json:"status"`
-	TotalDistanceAlongTrip     float64    `json:"totalDistanceAlongTrip"`
-	VehicleFeatures            []string   `json:"vehicleFeatures,omitempty"`
-	VehicleID                  string     `json:"vehicleId"`
-	Scheduled                  bool       `json:"scheduled"`
+	// PositionIsExtrapolated is set when Position and DistanceAlongTrip were
+	// projected forward from an older fix rather than reported by the vehicle.
+	PositionIsExtrapolated     bool     `json:"positionIsExtrapolated,omitempty"`
+	Predicted                  bool     `json:"predicted"`
+	ScheduleDeviation          int      `json:"scheduleDeviation"`
+	ScheduledDistanceAlongTrip float64  `json:"scheduledDistanceAlongTrip"`
+	ServiceDate                int64    `json:"serviceDate"`
+	SituationIDs               []string `json:"situationIds"`
+	// Stale is set when the assigned vehicle's last report is older than the
+	// configured staleness threshold, so its position is not used.
+	Stale                  bool     `json:"stale,omitempty"`
+	Deviated               bool     `json:"deviated,omitempty"` // set while the vehicle is off the trip's shape, as on a detour
+	Status                 string   `json:"status"`
+	TotalDistanceAlongTrip float64  `json:"totalDistanceAlongTrip"`
+	TripAssignmentInferred bool     `json:"tripAssignmentInferred,omitempty"` // set when the feed named no trip and it was inferred from the block or route
+	VehicleFeatures        []string `json:"vehicleFeatures,omitempty"`
+	VehicleID              string   `json:"vehicleId"`
+	Scheduled              bool     `json:"scheduled"`
+}
+
+// SetOccupancy records the load of the vehicle serving the trip. capacity is
+// the configured passenger capacity of the vehicle, zero when unknown, and
+// percentage the GTFS-RT occupancy_percentage it reports, nil when it reports
+// none. Passengers aboard are only known when both are. Callers start from -1
+// in all three fields.
+func (status *TripStatusForTripDetails) SetOccupancy(capacity int, percentage *uint32) {
+	if capacity > 0 {
+		status.OccupancyCapacity = capacity
+	}
+	if percentage == nil {
+		return
+	}
+	if capacity <= 0 {
+		status.OccupancyPercentage = int(*percentage)
+		return
+	}
+	status.OccupancyCount = int(math.Round(float64(*percentage) * float64(capacity) / 100))
+	// Report the load of the whole passengers counted, so the three fields agree
+	status.OccupancyPercentage = status.LoadPercentage()
+}
+
+// LoadPercentage returns OccupancyCount as a percentage of OccupancyCapacity,
+// or -1 when either is unknown.
+func (status *TripStatusForTripDetails) LoadPercentage() int {
+	if status.OccupancyCount < 0 || status.OccupancyCapacity <= 0 {
+		return -1
+	}
+	return int(math.Round(float64(status.OccupancyCount) * 100 / float64(status.OccupancyCapacity)))
 }
@@ -178,3 +178,35 @@ func TestTripStatusForTripDetailsJSON(t *testing.T) {
 	assert.Equal(t, tripStatus.Position.Lat, unmarshaledStatus.Position.Lat)
 	assert.Equal(t, tripStatus.Position.Lon, unmarshaledStatus.Position.Lon)
 }
+
+func TestTripStatusSetOccupancy(t *testing.T) {
+	percentage := func(p uint32) *uint32 { return &p }
+	unknown := func() *TripStatusForTripDetails {
+		return &TripStatusForTripDetails{OccupancyCapacity: -1, OccupancyCount: -1, OccupancyPercentage: -1}
+	}
+
+	status := unknown()
+	status.SetOccupancy(60, percentage(50))
+	assert.Equal(t, 60, status.OccupancyCapacity)
+	assert.Equal(t, 30, status.OccupancyCount)
+	assert.Equal(t, 50, status.OccupancyPercentage)
+
+	// The count is rounded to whole passengers, and the percentage follows it
+	status = unknown()
+	status.SetOccupancy(7, percentage(50))
+	assert.Equal(t, 4, status.OccupancyCount)
+	assert.Equal(t, 57, status.OccupancyPercentage)
+
+	status = unknown()
+	status.SetOccupancy(0, percentage(130))
+	assert.Equal(t, -1, status.OccupancyCapacity)
+	assert.Equal(t, -1, status.OccupancyCount)
+	assert.Equal(t, 130, status.OccupancyPercentage, "crush loads exceed 100")
+
+	status = unknown()
+	status.SetOccupancy(40, nil)
+	assert.Equal(t, 40, status.OccupancyCapacity)
+	assert.Equal(t, -1, status.OccupancyCount)
+	assert.Equal(t, -1, status.OccupancyPercentage)
+	assert.Equal(t, -1, status.LoadPercentage())
+}
@@ -0,0 +1,28 @@
+package models
+
+// TripGeometry is a trip's shape together with where each of its stops lies
+// on it.
+type TripGeometry struct {
+	TripID  string `json:"tripId"`
+	ShapeID string `json:"shapeId"`
+	// Points is the shape as an encoded polyline of Length points.
+	Points string `json:"points"`
+	Length int    `json:"length"`
+	// Truncated is set when the polyline was thinned out to the configured
+	// limit on shape points; the stops are still placed on the full shape.
+	Truncated bool `json:"truncated,omitempty"`
+	// TotalDistance is the length of the shape in meters.
+	TotalDistance float64            `json:"totalDistance"`
+	Stops         []TripGeometryStop `json:"stops"`
+}
+
+// TripGeometryStop is a stop time of a trip placed on the trip's shape.
+type TripGeometryStop struct {
+	StopID            string  `json:"stopId"`
+	StopSequence      int     `json:"stopSequence"`
+	DistanceAlongTrip float64 `json:"distanceAlongTrip"`
+	// ProjectedLat and ProjectedLon are the point of the shape the stop is
+	// placed at; they are the stop's own position when the trip has no shape.
+	ProjectedLat float64 `json:"projectedLat"`
+	ProjectedLon float64 `json:"projectedLon"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// VehicleAssignment is a dispatcher's assignment of a vehicle to a trip or to
+// every trip of a block, as returned by the admin endpoints. Exactly one of
+// TripID and BlockID is set. Times are in milliseconds since the epoch.
+type VehicleAssignment struct {
+	VehicleID  string `json:"vehicleId"`
+	TripID     string `json:"tripId,omitempty"`
+	BlockID    string `json:"blockId,omitempty"`
+	AssignedAt int64  `json:"assignedAt"`
+	ExpiresAt  int64  `json:"expiresAt"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// StreamVehicle is the latest reported state of one vehicle on the realtime
+// vehicle stream. IDs are combined agency IDs.
+type StreamVehicle struct {
+	VehicleID string    `json:"vehicleId"`
+	TripID    string    `json:"tripId"`
+	RouteID   string    `json:"routeId"`
+	Location  *Location `json:"location,omitempty"`
+	// Bearing is in degrees clockwise from north, as reported by the feed.
+	Bearing *float64 `json:"bearing,omitempty"`
+	StopID  string   `json:"stopId,omitempty"`
+	// Timestamp is when the position was recorded, in milliseconds since the epoch.
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// StreamTripUpdate summarizes a GTFS-RT trip update on the realtime vehicle stream.
+type StreamTripUpdate struct {
+	TripID    string `json:"tripId"`
+	RouteID   string `json:"routeId"`
+	VehicleID string `json:"vehicleId,omitempty"`
+	// ScheduleDeviation is the reported delay in seconds; positive means late.
+	ScheduleDeviation *int64 `json:"scheduleDeviation,omitempty"`
+}
+
+// StreamSnapshot is the first event sent to a stream client: everything that
+// currently matches its subscription.
+type StreamSnapshot struct {
+	Vehicles    []StreamVehicle    `json:"vehicles"`
+	TripUpdates []StreamTripUpdate `json:"tripUpdates"`
+	Situations  []Situation        `json:"situations"`
+}
+
+// StreamVehicleDelta lists the vehicles that changed since the previous event.
+// Removed holds the IDs of vehicles that no longer match the subscription.
+type StreamVehicleDelta struct {
+	Updated []StreamVehicle `json:"updated"`
+	Removed []string        `json:"removed"`
+}
+
+// StreamTripUpdateDelta lists the trip updates that changed since the previous
+// event. Removed holds the IDs of trips whose updates were withdrawn.
+type StreamTripUpdateDelta struct {
+	Updated []StreamTripUpdate `json:"updated"`
+	Removed []string           `json:"removed"`
+}
+
+// StreamAlerts carries the full set of matching service alerts; it is sent
+// whenever that set changes.
+type StreamAlerts struct {
+	Situations []Situation `json:"situations"`
+}
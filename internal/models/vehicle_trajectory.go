@@ -0,0 +1,13 @@
+package models
+
+// VehicleTrajectory is the recent path of a vehicle as recorded by the vehicle
+// position history. Points is an encoded polyline of Length positions, oldest
+// first, and Timestamps holds the time each was observed, in Unix milliseconds.
+type VehicleTrajectory struct {
+	VehicleID  string  `json:"vehicleId"`
+	Points     string  `json:"points"`
+	Length     int     `json:"length"`
+	Timestamps []int64 `json:"timestamps"`
+	FromTime   int64   `json:"fromTime"`
+	ToTime     int64   `json:"toTime"`
+}
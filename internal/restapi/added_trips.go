@@ -0,0 +1,182 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// collectAddedTripStopTimes returns the visits to stopCode between windowStart
+// and windowEnd of the trips the realtime feeds add to the schedule. ADDED trips
+// have no static stop times, so each visit is synthesized from a StopTimeUpdate
+// for the stop that gives an absolute arrival or departure time; those times
+// serve as both the scheduled and the predicted times. Service dates are read in
+// the timezone of the agency running the trip's route, or in loc when the route
+// is unknown.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) collectAddedTripStopTimes(ctx context.Context, stopCode string, windowStart, windowEnd time.Time, loc *time.Location) []activeStopTime {
+	memo := tripDataMemoFromContext(ctx)
+	var visits []activeStopTime
+	for _, update := range api.GtfsManager.GetAllTripUpdates() {
+		if update.ID.ScheduleRelationship != gtfsrt.TripDescriptor_ADDED || update.ID.ID == "" {
+			continue
+		}
+		tripLoc := loc
+		if route, err := memo.route(ctx, api.GtfsManager.GtfsDB, update.ID.RouteID); err == nil {
+			tripLoc = api.agencyLocation(ctx, route.AgencyID)
+		}
+		for i := range update.StopTimeUpdates {
+			stu := &update.StopTimeUpdates[i]
+			if stu.StopID == nil || *stu.StopID != stopCode || stu.ScheduleRelationship == gtfsrt.TripUpdate_StopTimeUpdate_SKIPPED {
+				continue
+			}
+			arrival, departure, ok := addedStopTimes(stu)
+			if !ok || arrival.After(windowEnd) || departure.Before(windowStart) {
+				continue
+			}
+
+			serviceMidnight := addedTripServiceDate(&update, arrival, tripLoc)
+			visits = append(visits, activeStopTime{
+				GetStopTimesForStopInWindowRow: gtfsdb.GetStopTimesForStopInWindowRow{
+					TripID:        update.ID.ID,
+					RouteID:       update.ID.RouteID,
+					StopID:        stopCode,
+					StopSequence:  addedStopSequence(stu, i),
+					ArrivalTime:   utils.StopTimeSeconds(arrival.Sub(serviceMidnight)),
+					DepartureTime: utils.StopTimeSeconds(departure.Sub(serviceMidnight)),
+				},
+				ServiceDate: serviceMidnight,
+				Added:       &update,
+			})
+		}
+	}
+	return visits
+}
+
+// addedStopTimes returns the arrival and departure an ADDED trip's update
+// gives for a stop. Only absolute times are usable since the trip has no
+// schedule to apply a delay to; a missing arrival or departure takes the
+// other's time.
+func addedStopTimes(stu *gtfs.StopTimeUpdate) (arrival, departure time.Time, ok bool) {
+	hasArrival := stu.Arrival != nil && stu.Arrival.Time != nil
+	hasDeparture := stu.Departure != nil && stu.Departure.Time != nil
+	switch {
+	case hasArrival && hasDeparture:
+		return *stu.Arrival.Time, *stu.Departure.Time, true
+	case hasArrival:
+		return *stu.Arrival.Time, *stu.Arrival.Time, true
+	case hasDeparture:
+		return *stu.Departure.Time, *stu.Departure.Time, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// addedStopSequence is the stop_sequence of an update, or its one-based
+// position in the trip update when the feed gives none.
+func addedStopSequence(stu *gtfs.StopTimeUpdate, index int) int64 {
+	if stu.StopSequence != nil {
+		return int64(*stu.StopSequence)
+	}
+	return int64(index + 1)
+}
+
+// addedTripServiceDate is the midnight of the service date an ADDED trip runs
+// on: its start date when the feed gives one, or else the local date of its
+// visit at the stop.
+func addedTripServiceDate(update *gtfs.Trip, visit time.Time, loc *time.Location) time.Time {
+	if update.ID.HasStartDate {
+		return utils.MidnightIn(update.ID.StartDate, loc)
+	}
+	return utils.ServiceDateAt(visit, loc)
+}
+
+// buildAddedTripArrival builds the arrival for a visit of an ADDED trip. The
+// stop sequence, stop count and stops away are positions within the trip
+// update, the only description of the trip there is.
+func (api *RestAPI) buildAddedTripArrival(ctx context.Context, ast activeStopTime, route gtfsdb.Route, stopID string, currentTime time.Time) *models.ArrivalAndDeparture {
+	st := ast.GetStopTimesForStopInWindowRow
+	update := ast.Added
+
+	arrivalTime := ast.ServiceDate.Add(utils.StopTimeDuration(st.ArrivalTime)).UnixMilli()
+	departureTime := ast.ServiceDate.Add(utils.StopTimeDuration(st.DepartureTime)).UnixMilli()
+
+	// Stops of the trip still to be served before this one.
+	position, stopsAway := 0, 0
+	for i := range update.StopTimeUpdates {
+		stu := &update.StopTimeUpdates[i]
+		if addedStopSequence(stu, i) == st.StopSequence {
+			position = i
+			break
+		}
+		if _, departure, ok := addedStopTimes(stu); ok && departure.After(currentTime) &&
+			stu.ScheduleRelationship != gtfsrt.TripUpdate_StopTimeUpdate_SKIPPED {
+			stopsAway++
+		}
+	}
+
+	var vehicleID string
+	var lastUpdateTime int64
+	if update.Vehicle != nil && update.Vehicle.ID != nil {
+		vehicleID = update.Vehicle.ID.ID
+		if vehicle, err := api.GtfsManager.GetVehicleByID(vehicleID); err == nil {
+			lastUpdateTime = api.GtfsManager.GetVehicleLastUpdateTime(vehicle)
+		}
+	}
+
+	situationIDs := api.GetSituationIDsForTrip(ctx, st.TripID)
+	status := scheduleRelationshipStatus(update.ID.ScheduleRelationship)
+
+	return models.NewArrivalAndDeparture(
+		utils.FormCombinedID(route.AgencyID, route.ID),  // routeID
+		route.ShortName.String,                          // routeShortName
+		route.LongName.String,                           // routeLongName
+		utils.FormCombinedID(route.AgencyID, st.TripID), // tripID
+		"",                          // tripHeadsign
+		stopID,                      // stopID
+		vehicleID,                   // vehicleID
+		ast.ServiceDate.UnixMilli(), // serviceDate
+		arrivalTime,                 // scheduledArrivalTime
+		departureTime,               // scheduledDepartureTime
+		arrivalTime,                 // predictedArrivalTime
+		departureTime,               // predictedDepartureTime
+		lastUpdateTime,              // lastUpdateTime
+		true,                        // predicted
+		true,                        // arrivalEnabled
+		true,                        // departureEnabled
+		position,                    // stopSequence (Zero-based index)
+		len(update.StopTimeUpdates), // totalStopsInTrip
+		stopsAway,                   // numberOfStopsAway
+		0,                           // blockTripSequence
+		0,                           // distanceFromStop
+		status,                      // status
+		"",                          // occupancyStatus
+		"",                          // predictedOccupancy
+		"",                          // historicalOccupancy
+		nil,                         // tripStatus
+		situationIDs,                // situationIDs
+	)
+}
+
+// addedTripReference is the trip reference of an ADDED trip, which has only
+// the route and direction the feed gives for it.
+func addedTripReference(update *gtfs.Trip, route gtfsdb.Route) *models.Trip {
+	var directionID int64
+	if update.ID.DirectionID == gtfs.DirectionID_True {
+		directionID = 1
+	}
+	return models.NewTripReference(
+		utils.FormCombinedID(route.AgencyID, update.ID.ID),
+		utils.FormCombinedID(route.AgencyID, route.ID),
+		"",
+		"",
+		"",
+		directionID,
+		"",
+		"",
+	)
+}
@@ -0,0 +1,215 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const canceledTestTripID = "bf005cf3-4cab-4ac6-985f-ce6385b645cf"
+
+// arrivalsForStop requests the arrivals at a RABA stop around now and returns
+// the entry's arrivals and the trip references.
+func arrivalsForStop(t *testing.T, api *RestAPI, stopID string, now time.Time) ([]interface{}, []interface{}) {
+	t.Helper()
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		fmt.Sprintf("/api/where/arrivals-and-departures-for-stop/%s.json?key=TEST&time=%d&minutesBefore=5&minutesAfter=30",
+			stopID, now.UnixMilli()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+	arrivals, ok := entry["arrivalsAndDepartures"].([]interface{})
+	require.True(t, ok)
+	references, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	trips, _ := references["trips"].([]interface{})
+	return arrivals, trips
+}
+
+func findArrival(arrivals []interface{}, tripID string) map[string]interface{} {
+	for _, a := range arrivals {
+		arrival := a.(map[string]interface{})
+		if arrival["tripId"] == tripID {
+			return arrival
+		}
+	}
+	return nil
+}
+
+func TestArrivalsForStop_CanceledTrip(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 13, 9, 55, 0, 0, loc)
+
+	t.Run("matching start date", func(t *testing.T) {
+		api := createTestApi(t)
+		defer api.Shutdown()
+		t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+		api.GtfsManager.MockAddRealtimeTrip(gtfs.Trip{ID: gtfs.TripID{
+			ID:                   canceledTestTripID,
+			StartDate:            time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC),
+			HasStartDate:         true,
+			ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED,
+		}})
+
+		arrivals, _ := arrivalsForStop(t, api, "25_2000", now)
+		arrival := findArrival(arrivals, "25_"+canceledTestTripID)
+		require.NotNil(t, arrival, "a canceled trip is flagged rather than dropped")
+		assert.Equal(t, "CANCELED", arrival["status"])
+		assert.Equal(t, false, arrival["predicted"])
+		assert.Equal(t, 0.0, arrival["predictedArrivalTime"])
+		assert.Equal(t, float64(time.Date(2025, 6, 13, 10, 0, 0, 0, loc).UnixMilli()), arrival["scheduledArrivalTime"])
+	})
+
+	t.Run("other start date", func(t *testing.T) {
+		api := createTestApi(t)
+		defer api.Shutdown()
+		t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+		api.GtfsManager.MockAddRealtimeTrip(gtfs.Trip{ID: gtfs.TripID{
+			ID:                   canceledTestTripID,
+			StartDate:            time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC),
+			HasStartDate:         true,
+			ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED,
+		}})
+
+		arrivals, _ := arrivalsForStop(t, api, "25_2000", now)
+		arrival := findArrival(arrivals, "25_"+canceledTestTripID)
+		require.NotNil(t, arrival)
+		assert.Equal(t, "default", arrival["status"], "the cancellation is for another day's run")
+	})
+}
+
+func TestArrivalAndDepartureForStop_CanceledTrip(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	api.GtfsManager.MockAddRealtimeTrip(gtfs.Trip{ID: gtfs.TripID{
+		ID:                   canceledTestTripID,
+		ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED,
+	}})
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+	now := time.Date(2025, 6, 13, 10, 40, 0, 0, loc)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrival-and-departure-for-stop/25_327.json?key=TEST&tripId=25_"+canceledTestTripID+
+			fmt.Sprintf("&serviceDate=%d&time=%d", serviceDate.UnixMilli(), now.UnixMilli()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+
+	assert.Equal(t, "CANCELED", entry["status"])
+	assert.Equal(t, false, entry["predicted"])
+	assert.Equal(t, 0.0, entry["predictedArrivalTime"])
+}
+
+func TestArrivalsForStop_AddedTrip(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	atStop2000 := time.Date(2025, 6, 13, 10, 5, 0, 0, loc)
+	atStop9902 := time.Date(2025, 6, 13, 10, 20, 0, 0, loc)
+	stop2000, stop9902 := "2000", "9902"
+
+	api.GtfsManager.MockAddRealtimeTrip(gtfs.Trip{
+		ID: gtfs.TripID{
+			ID:                   "extra-run",
+			RouteID:              "151",
+			ScheduleRelationship: gtfsrt.TripDescriptor_ADDED,
+		},
+		StopTimeUpdates: []gtfs.StopTimeUpdate{
+			{StopID: &stop2000, Departure: &gtfs.StopTimeEvent{Time: &atStop2000}},
+			{StopID: &stop9902, Arrival: &gtfs.StopTimeEvent{Time: &atStop9902}},
+		},
+	})
+
+	now := time.Date(2025, 6, 13, 9, 55, 0, 0, loc)
+	arrivals, trips := arrivalsForStop(t, api, "25_9902", now)
+
+	arrival := findArrival(arrivals, "25_extra-run")
+	require.NotNil(t, arrival, "the added trip's visit is synthesized from its update")
+	assert.Equal(t, "ADDED", arrival["status"])
+	assert.Equal(t, "25_151", arrival["routeId"])
+	assert.Equal(t, true, arrival["predicted"])
+	assert.Equal(t, float64(atStop9902.UnixMilli()), arrival["predictedArrivalTime"])
+	assert.Equal(t, float64(atStop9902.UnixMilli()), arrival["scheduledArrivalTime"])
+	assert.Equal(t, 1.0, arrival["stopSequence"])
+	assert.Equal(t, 2.0, arrival["totalStopsInTrip"])
+	assert.Equal(t, 1.0, arrival["numberOfStopsAway"])
+
+	var found bool
+	for _, tr := range trips {
+		if tr.(map[string]interface{})["id"] == "25_extra-run" {
+			found = true
+		}
+	}
+	assert.True(t, found, "the added trip is in the references")
+}
+
+func TestAddedStopTimes(t *testing.T) {
+	arrival := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	departure := arrival.Add(time.Minute)
+	delay := time.Minute
+
+	tests := []struct {
+		name               string
+		stu                gtfs.StopTimeUpdate
+		arrival, departure time.Time
+		ok                 bool
+	}{
+		{
+			name:      "both times",
+			stu:       gtfs.StopTimeUpdate{Arrival: &gtfs.StopTimeEvent{Time: &arrival}, Departure: &gtfs.StopTimeEvent{Time: &departure}},
+			arrival:   arrival,
+			departure: departure,
+			ok:        true,
+		},
+		{
+			name:      "arrival only",
+			stu:       gtfs.StopTimeUpdate{Arrival: &gtfs.StopTimeEvent{Time: &arrival}},
+			arrival:   arrival,
+			departure: arrival,
+			ok:        true,
+		},
+		{
+			name:      "departure only",
+			stu:       gtfs.StopTimeUpdate{Departure: &gtfs.StopTimeEvent{Time: &departure}},
+			arrival:   departure,
+			departure: departure,
+			ok:        true,
+		},
+		{
+			name: "delay only",
+			stu:  gtfs.StopTimeUpdate{Arrival: &gtfs.StopTimeEvent{Delay: &delay}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArrival, gotDeparture, ok := addedStopTimes(&tt.stu)
+			assert.Equal(t, tt.ok, ok)
+			assert.True(t, tt.arrival.Equal(gotArrival))
+			assert.True(t, tt.departure.Equal(gotDeparture))
+		})
+	}
+}
@@ -26,8 +26,13 @@ func (api *RestAPI) agenciesWithCoverageHandler(w http.ResponseWriter, r *http.R
 	}
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	offset, limit, pageErrors := parseListPagination(r)
+	if len(pageErrors) > 0 {
+		api.validationErrorResponse(w, r, pageErrors)
+		return
+	}
 	agencies, limitExceeded := utils.PaginateSlice(agencies, offset, limit)
+	page := newPage(r, offset, len(agencies), limitExceeded)
 
 	lat, lon, latSpan, lonSpan := api.GtfsManager.GetRegionBounds()
 	agenciesWithCoverage := make([]models.AgencyCoverage, 0)
@@ -66,6 +71,6 @@ func (api *RestAPI) agenciesWithCoverageHandler(w http.ResponseWriter, r *http.R
 		Trips:      []interface{}{},
 	}
 
-	response := models.NewListResponse(agenciesWithCoverage, references, limitExceeded, api.Clock)
+	response := models.WithPage(models.NewListResponse(agenciesWithCoverage, references, limitExceeded, api.Clock), page)
 	api.sendResponse(w, r, response)
 }
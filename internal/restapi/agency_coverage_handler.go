@@ -0,0 +1,59 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// agencyCoverageHandler lists, per agency, the bounding box and centroid of the
+// stops it serves and the dates its service covers, so clients can zoom their
+// maps to a region. The coverage is computed when the static data is loaded.
+func (api *RestAPI) agencyCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agencies, err := api.GtfsManager.GtfsDB.Queries.ListAgencies(ctx)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	references := models.NewEmptyReferences()
+	for _, a := range agencies {
+		references.Agencies = append(references.Agencies, models.NewAgencyReference(
+			a.ID, a.Name, a.Url, a.Timezone, a.Lang.String,
+			a.Phone.String, a.Email.String, a.FareUrl.String, "", false,
+		))
+	}
+
+	coverage := api.GtfsManager.GetAgencyCoverage()
+	entries := make([]models.AgencyServiceCoverage, 0, len(coverage))
+	for _, c := range coverage {
+		entries = append(entries, models.AgencyServiceCoverage{
+			AgencyID:         c.AgencyID,
+			MinLat:           c.MinLat,
+			MinLon:           c.MinLon,
+			MaxLat:           c.MaxLat,
+			MaxLon:           c.MaxLon,
+			CentroidLat:      c.CentroidLat,
+			CentroidLon:      c.CentroidLon,
+			StopCount:        c.StopCount,
+			ServiceStartDate: formatGTFSDate(c.ServiceStart),
+			ServiceEndDate:   formatGTFSDate(c.ServiceEnd),
+		})
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(entries, references, false, api.Clock))
+}
+
+// formatGTFSDate formats a date as GTFS YYYYMMDD, or empty for the zero time.
+func formatGTFSDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("20060102")
+}
@@ -0,0 +1,46 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgencyCoverageHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/agency-coverage.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	list, ok := data["list"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, list, 1)
+
+	entry, ok := list[0].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "25", entry["agencyId"])
+	assert.Greater(t, entry["stopCount"], 0.0)
+
+	minLat, maxLat := entry["minLat"].(float64), entry["maxLat"].(float64)
+	minLon, maxLon := entry["minLon"].(float64), entry["maxLon"].(float64)
+	assert.Less(t, minLat, maxLat)
+	assert.Less(t, minLon, maxLon)
+	assert.GreaterOrEqual(t, entry["centroidLat"], minLat)
+	assert.LessOrEqual(t, entry["centroidLat"], maxLat)
+	assert.GreaterOrEqual(t, entry["centroidLon"], minLon)
+	assert.LessOrEqual(t, entry["centroidLon"], maxLon)
+
+	assert.Equal(t, "20240101", entry["serviceStartDate"])
+	assert.Equal(t, "20251231", entry["serviceEndDate"])
+
+	references, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	agencies, ok := references["agencies"].([]interface{})
+	require.True(t, ok)
+	assert.Len(t, agencies, 1)
+}
@@ -17,7 +17,7 @@ func (api *RestAPI) agencyHandler(w http.ResponseWriter, r *http.Request) {
 	agency := api.GtfsManager.FindAgency(id)
 
 	if agency == nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
 		return
 	}
 
@@ -0,0 +1,199 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"maglev.onebusaway.org/internal/apikeys"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// maxAdminRequestBody bounds the JSON body accepted by the admin endpoints.
+const maxAdminRequestBody = 16 << 10
+
+// withAdminKey only lets requests whose key is one of the configured admin keys
+// reach handler.
+func withAdminKey(api *RestAPI, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !api.IsAdminAPIKey(r.URL.Query().Get("key")) {
+			api.invalidAPIKeyResponse(w, r)
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// withAPIKeyStore makes the key store endpoints answer 404 while the store is
+// disabled.
+func withAPIKeyStore(api *RestAPI, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.APIKeys == nil {
+			api.sendNotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (api *RestAPI) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	now := api.Clock.Now()
+	stored := api.APIKeys.List()
+	keys := make([]models.APIKey, 0, len(stored))
+	for _, k := range stored {
+		keys = append(keys, newAPIKeyModel(k, now))
+	}
+	api.sendResponse(w, r, models.NewListResponse(keys, models.NewEmptyReferences(), false, api.Clock))
+}
+
+func (api *RestAPI) getAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	k, err := api.APIKeys.Get(r.PathValue("key"))
+	if err != nil {
+		api.sendAPIKeyError(w, r, err)
+		return
+	}
+	api.sendResponse(w, r, models.NewEntryResponse(newAPIKeyModel(k, api.Clock.Now()), models.NewEmptyReferences(), api.Clock))
+}
+
+// createAPIKeyHandler stores a new key from a JSON body with the optional fields
+// key, name, rateLimit and expiresAt (milliseconds since the epoch). A random
+// key is generated when none is given.
+func (api *RestAPI) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Key       string `json:"key"`
+		Name      string `json:"name"`
+		RateLimit *int   `json:"rateLimit"`
+		ExpiresAt *int64 `json:"expiresAt"`
+	}
+	// An empty body creates a key with a generated value and no limits.
+	if err := decodeAdminRequest(r, &body); err != nil && !errors.Is(err, io.EOF) {
+		api.validationErrorResponse(w, r, map[string][]string{"body": {err.Error()}})
+		return
+	}
+	if api.IsAdminAPIKey(body.Key) || slices.Contains(api.Config.ApiKeys, body.Key) {
+		api.sendAPIKeyError(w, r, apikeys.ErrKeyExists)
+		return
+	}
+
+	k, err := api.APIKeys.Create(r.Context(), apikeys.NewKey{
+		Key:       body.Key,
+		Name:      body.Name,
+		RateLimit: body.RateLimit,
+		ExpiresAt: timeFromMillis(body.ExpiresAt),
+	})
+	if err != nil {
+		api.sendAPIKeyError(w, r, err)
+		return
+	}
+	api.sendResponse(w, r, models.NewEntryResponse(newAPIKeyModel(k, api.Clock.Now()), models.NewEmptyReferences(), api.Clock))
+}
+
+// updateAPIKeyHandler changes the fields present in a JSON body. Setting
+// rateLimit or expiresAt to null removes the per-key limit or the expiry.
+func (api *RestAPI) updateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var body map[string]json.RawMessage
+	if err := decodeAdminRequest(r, &body); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"body": {err.Error()}})
+		return
+	}
+
+	var update apikeys.KeyUpdate
+	fieldErrors := make(map[string][]string)
+	for field, raw := range body {
+		isNull := string(raw) == "null"
+		var err error
+		switch field {
+		case "name":
+			err = json.Unmarshal(raw, &update.Name)
+		case "rateLimit":
+			update.ClearRateLimit = isNull
+			err = json.Unmarshal(raw, &update.RateLimit)
+		case "expiresAt":
+			update.ClearExpiresAt = isNull
+			var millis *int64
+			err = json.Unmarshal(raw, &millis)
+			update.ExpiresAt = timeFromMillis(millis)
+		default:
+			fieldErrors[field] = append(fieldErrors[field], "unknown field")
+			continue
+		}
+		if err != nil {
+			fieldErrors[field] = append(fieldErrors[field], "invalid value")
+		}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	key := r.PathValue("key")
+	k, err := api.APIKeys.Update(r.Context(), key, update)
+	if err != nil {
+		api.sendAPIKeyError(w, r, err)
+		return
+	}
+	api.rateLimiter.Forget(key)
+	api.sendResponse(w, r, models.NewEntryResponse(newAPIKeyModel(k, api.Clock.Now()), models.NewEmptyReferences(), api.Clock))
+}
+
+func (api *RestAPI) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if err := api.APIKeys.Delete(r.Context(), key); err != nil {
+		api.sendAPIKeyError(w, r, err)
+		return
+	}
+	api.rateLimiter.Forget(key)
+	api.sendResponse(w, r, models.NewOKResponse(nil, api.Clock))
+}
+
+// sendAPIKeyError maps key store errors onto responses.
+func (api *RestAPI) sendAPIKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *apikeys.ValidationError
+	switch {
+	case errors.Is(err, apikeys.ErrKeyNotFound):
+		api.sendNotFound(w, r)
+	case errors.Is(err, apikeys.ErrKeyExists):
+		api.sendError(w, r, http.StatusConflict, err.Error())
+	case errors.As(err, &validationErr):
+		api.validationErrorResponse(w, r, map[string][]string{validationErr.Field: {validationErr.Message}})
+	default:
+		api.serverErrorResponse(w, r, err)
+	}
+}
+
+func decodeAdminRequest(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxAdminRequestBody))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func newAPIKeyModel(k apikeys.Key, now time.Time) models.APIKey {
+	m := models.APIKey{
+		Key:          k.Key,
+		Name:         k.Name,
+		RateLimit:    k.RateLimit,
+		Expired:      k.Expired(now),
+		CreatedAt:    k.CreatedAt.UnixMilli(),
+		RequestCount: k.RequestCount,
+	}
+	if k.ExpiresAt != nil {
+		expiresAt := k.ExpiresAt.UnixMilli()
+		m.ExpiresAt = &expiresAt
+	}
+	if k.LastUsedAt != nil {
+		lastUsed := k.LastUsedAt.UnixMilli()
+		m.LastUsedAt = &lastUsed
+	}
+	return m
+}
+
+func timeFromMillis(millis *int64) *time.Time {
+	if millis == nil {
+		return nil
+	}
+	t := time.UnixMilli(*millis)
+	return &t
+}
@@ -0,0 +1,131 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/apikeys"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func createTestApiWithKeyStore(t *testing.T) (*RestAPI, *httptest.Server) {
+	t.Helper()
+	api := createTestApi(t)
+	t.Cleanup(api.Shutdown)
+
+	store, err := apikeys.Open(":memory:", api.Clock)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	api.APIKeys = store
+	api.Config.AdminApiKeys = []string{"ADMIN"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func doAdminRequest(t *testing.T, server *httptest.Server, method, path, body string) (*http.Response, models.ResponseModel) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var model models.ResponseModel
+	require.NoError(t, json.Unmarshal(data, &model), string(data))
+	return resp, model
+}
+
+func TestAPIKeyAdminRequiresAdminKey(t *testing.T) {
+	_, server := createTestApiWithKeyStore(t)
+
+	resp, _ := doAdminRequest(t, server, http.MethodGet, "/api/admin/api-keys?key=TEST", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "regular keys cannot administer keys")
+
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/admin/api-keys", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAPIKeyAdminNotFoundWithoutStore(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"ADMIN"}
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/admin/api-keys?key=ADMIN")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIKeyAdminLifecycle(t *testing.T) {
+	_, server := createTestApiWithKeyStore(t)
+
+	resp, model := doAdminRequest(t, server, http.MethodPost, "/api/admin/api-keys?key=ADMIN",
+		`{"key":"partner-key","name":"Partner","rateLimit":1}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "partner-key", entry["key"])
+	assert.Equal(t, float64(1), entry["rateLimit"])
+
+	resp, _ = doAdminRequest(t, server, http.MethodPost, "/api/admin/api-keys?key=ADMIN", `{"key":"partner-key"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodPost, "/api/admin/api-keys?key=ADMIN", `{"key":"TEST"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "configured keys cannot be shadowed by stored ones")
+	resp, _ = doAdminRequest(t, server, http.MethodPost, "/api/admin/api-keys?key=ADMIN", `{"rateLimit":-1}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// The stored key works on the regular API and its per-key limit applies.
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/where/current-time.json?key=partner-key", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/where/current-time.json?key=partner-key", "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Limit"))
+
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/admin/api-keys/partner-key?key=ADMIN", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(2), entry["requestCount"])
+	assert.NotNil(t, entry["lastUsedAt"])
+
+	// Raising the limit takes effect immediately.
+	resp, model = doAdminRequest(t, server, http.MethodPatch, "/api/admin/api-keys/partner-key?key=ADMIN",
+		`{"name":"Renamed","rateLimit":null}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "Renamed", entry["name"])
+	assert.NotContains(t, entry, "rateLimit")
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/where/current-time.json?key=partner-key", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/admin/api-keys?key=ADMIN", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	list := model.Data.(map[string]interface{})["list"].([]interface{})
+	assert.Len(t, list, 1)
+
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/admin/api-keys/partner-key?key=ADMIN", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/where/current-time.json?key=partner-key", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "deleted keys are rejected")
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/admin/api-keys/partner-key?key=ADMIN", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIKeyAdminRejectsUnknownUpdateFields(t *testing.T) {
+	api, server := createTestApiWithKeyStore(t)
+	_, err := api.APIKeys.Create(context.Background(), apikeys.NewKey{Key: "k1"})
+	require.NoError(t, err)
+
+	resp, model := doAdminRequest(t, server, http.MethodPatch, "/api/admin/api-keys/k1?key=ADMIN", `{"owner":"someone"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, model.Text, "unknown field")
+}
@@ -3,7 +3,6 @@ package restapi
 import (
 	"context"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
@@ -26,77 +25,17 @@ type ArrivalAndDepartureParams struct {
 // parseArrivalAndDepartureParams parses and validates request parameters.
 // Returns parameters and a map of validation errors if any.
 func (api *RestAPI) parseArrivalAndDepartureParams(r *http.Request) (ArrivalAndDepartureParams, map[string][]string) {
+	q := newQueryParams(r)
 	params := ArrivalAndDepartureParams{
-		MinutesAfter:  30, // Default 30 minutes after
-		MinutesBefore: 5,  // Default 5 minutes before
-	}
-
-	// Initialize errors map
-	fieldErrors := make(map[string][]string)
-
-	// Validate minutesAfter
-	if minutesAfterStr := r.URL.Query().Get("minutesAfter"); minutesAfterStr != "" {
-		if minutesAfter, err := strconv.Atoi(minutesAfterStr); err == nil {
-			params.MinutesAfter = minutesAfter
-		} else {
-			fieldErrors["minutesAfter"] = []string{"must be a valid integer"}
-		}
-	}
-
-	// Validate minutesBefore
-	if minutesBeforeStr := r.URL.Query().Get("minutesBefore"); minutesBeforeStr != "" {
-		if minutesBefore, err := strconv.Atoi(minutesBeforeStr); err == nil {
-			params.MinutesBefore = minutesBefore
-		} else {
-			fieldErrors["minutesBefore"] = []string{"must be a valid integer"}
-		}
-	}
-
-	// Validate time
-	if timeStr := r.URL.Query().Get("time"); timeStr != "" {
-		if timeMs, err := strconv.ParseInt(timeStr, 10, 64); err == nil {
-			timeParam := time.Unix(timeMs/1000, 0)
-			params.Time = &timeParam
-		} else {
-			fieldErrors["time"] = []string{"must be a valid Unix timestamp in milliseconds"}
-		}
-	}
-
-	// Check TripID (Assignment only, required check is in handler)
-	if tripIDStr := r.URL.Query().Get("tripId"); tripIDStr != "" {
-		params.TripID = tripIDStr
-	}
-
-	// Validate serviceDate
-	if serviceDateStr := r.URL.Query().Get("serviceDate"); serviceDateStr != "" {
-		if serviceDateMs, err := strconv.ParseInt(serviceDateStr, 10, 64); err == nil {
-			serviceDate := time.Unix(serviceDateMs/1000, 0)
-			params.ServiceDate = &serviceDate
-		} else {
-			fieldErrors["serviceDate"] = []string{"must be a valid Unix timestamp in milliseconds"}
-		}
-	}
-
-	// Optional vehicleId parameter
-	if vehicleIDStr := r.URL.Query().Get("vehicleId"); vehicleIDStr != "" {
-		params.VehicleID = vehicleIDStr
-	}
-
-	// Validate stopSequence
-	if stopSequenceStr := r.URL.Query().Get("stopSequence"); stopSequenceStr != "" {
-		if stopSequence, err := strconv.Atoi(stopSequenceStr); err == nil {
-			params.StopSequence = &stopSequence
-		} else {
-			fieldErrors["stopSequence"] = []string{"must be a valid integer"}
-		}
-	}
-
-	// Return errors if any existed
-	if len(fieldErrors) > 0 {
-		return params, fieldErrors
-	}
-
-	return params, nil
+		MinutesAfter:  q.intParam("minutesAfter", 30),
+		MinutesBefore: q.intParam("minutesBefore", 5),
+		Time:          q.optionalTimeParam("time"),
+		TripID:        q.stringParam("tripId", ""), // Required check is in handler
+		ServiceDate:   q.optionalTimeParam("serviceDate"),
+		VehicleID:     q.stringParam("vehicleId", ""),
+		StopSequence:  q.optionalIntParam("stopSequence"),
+	}
+	return params, q.errors()
 }
 
 func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *http.Request) {
@@ -105,7 +44,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	stopCode := parsed.CodeID
 	stopID := parsed.CombinedID
 
-	ctx := r.Context()
+	ctx := withTripDataMemo(r.Context())
 
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
@@ -142,9 +81,9 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode)
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopCode)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
 		return
 	}
 
@@ -154,13 +93,13 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
 		return
 	}
 
-	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, trip.RouteID)
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, trip.RouteID)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -172,64 +111,29 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	var targetStopTime *struct {
-		ArrivalTime   int64
-		DepartureTime int64
-		StopSequence  int64
-		StopHeadsign  string
-	}
-
-	for _, st := range stopTimes {
-		if st.StopID == stopCode {
-			if params.StopSequence != nil && int64(*params.StopSequence) != st.StopSequence {
-				continue
-			}
-			targetStopTime = &struct {
-				ArrivalTime   int64
-				DepartureTime int64
-				StopSequence  int64
-				StopHeadsign  string
-			}{
-				ArrivalTime:   st.ArrivalTime,
-				DepartureTime: st.DepartureTime,
-				StopSequence:  st.StopSequence,
-				StopHeadsign:  st.StopHeadsign.String,
-			}
-			break
-		}
-	}
-
-	if targetStopTime == nil {
-		api.sendNotFound(w, r)
-		return
-	}
-
-	// Set current time
+	// Set current time. The trip's service date is a calendar date in the
+	// timezone of the agency operating it, not necessarily the stop's agency.
 	var currentTime time.Time
-	loc := utils.LoadLocationWithUTCFallBack(stopAgency.Timezone, stopAgency.ID)
+	loc := api.agencyLocation(ctx, route.AgencyID)
 	if params.Time != nil {
 		currentTime = params.Time.In(loc)
 	} else {
 		currentTime = api.Clock.Now().In(loc)
 	}
 
-	// Use the provided service date
-	serviceDate := *params.ServiceDate
-	serviceDateMillis := serviceDate.Unix() * 1000
-
-	// Service date is a "date" only, so get midnight in agency's TZ
-	serviceMidnight := time.Date(
-		serviceDate.Year(),
-		serviceDate.Month(),
-		serviceDate.Day(),
-		0, 0, 0, 0,
-		loc,
-	)
+	// Use the provided service date, read as a calendar date in the agency's timezone
+	serviceDateMillis := params.ServiceDate.Unix() * 1000
+	serviceMidnight := utils.ServiceDateAt(*params.ServiceDate, loc)
+
+	targetStopTime := selectStopTimeForArrival(stopTimes, stopCode, params.StopSequence, serviceMidnight, currentTime)
+	if targetStopTime == nil {
+		api.sendNotFound(w, r)
+		return
+	}
 
-	// Arrival time is stored in nanoseconds since midnight → convert to duration
-	// arrival and departure time is stored in nanoseconds (sqlite)
-	arrivalOffset := time.Duration(targetStopTime.ArrivalTime)
-	departureOffset := time.Duration(targetStopTime.DepartureTime)
+	// Arrival and departure times are stored as seconds since midnight → convert to durations
+	arrivalOffset := utils.StopTimeDuration(targetStopTime.ArrivalTime)
+	departureOffset := utils.StopTimeDuration(targetStopTime.DepartureTime)
 
 	// Add offsets to midnight
 	scheduledArrivalTime := serviceMidnight.Add(arrivalOffset)
@@ -246,7 +150,6 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		vehicleID                                    string
 		tripStatus                                   *models.TripStatusForTripDetails
 		distanceFromStop                             float64
-		numberOfStopsAway                            int
 	)
 
 	// If vehicleId is provided, validate it matches the trip
@@ -271,17 +174,17 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 
 	if vehicle != nil && vehicle.Trip != nil {
 		vehicleID = vehicle.ID.ID
-		predicted = true
+		predicted = !api.beyondPredictionHorizon(scheduledArrivalTime)
 	}
 
-	status, _ := api.BuildTripStatus(ctx, route.AgencyID, tripID, serviceDate, currentTime)
+	status, _ := api.BuildTripStatus(ctx, route.AgencyID, tripID, serviceMidnight, currentTime)
 	if status != nil {
 		tripStatus = status
 
 		predictedArrivalTime = scheduledArrivalTimeMs
 		predictedDepartureTime = scheduledDepartureTimeMs
 
-		predictedArrival, predictedDeparture := api.getPredictedTimes(tripID, stopCode, targetStopTime.StopSequence, scheduledArrivalTime, scheduledDepartureTime)
+		predictedArrival, predictedDeparture := api.getPredictedTimes(ctx, tripID, stopCode, targetStopTime.StopSequence, scheduledArrivalTime, scheduledDepartureTime)
 
 		if predictedArrival != 0 && predictedDeparture != 0 {
 			predictedArrivalTime = predictedArrival
@@ -292,31 +195,40 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		}
 
 		if vehicle != nil && vehicle.Position != nil {
-			distanceFromStop = api.getBlockDistanceToStop(ctx, tripID, stopCode, vehicle, serviceDate)
-
-			numberOfStopsAwayPtr := api.getNumberOfStopsAway(ctx, tripID, int(targetStopTime.StopSequence), vehicle, serviceDate)
-			if numberOfStopsAwayPtr != nil {
-				numberOfStopsAway = *numberOfStopsAwayPtr
-			} else {
-				numberOfStopsAway = -1
-			}
+			distanceFromStop = api.getBlockDistanceToStop(ctx, tripID, targetStopTime.StopSequence, vehicle, serviceMidnight)
 		}
 	}
 
+	arrivalStatus := "default"
+	if api.tripCanceledOn(tripID, serviceMidnight) {
+		// A canceled run keeps its scheduled times but is not predicted.
+		arrivalStatus = "CANCELED"
+		predicted = false
+		predictedArrivalTime = 0
+		predictedDepartureTime = 0
+	}
+
+	numberOfStopsAway, stopsAwaySource := api.numberOfStopsAwayForArrival(ctx, tripID, targetStopTime.StopSequence, vehicle, serviceMidnight, currentTime)
+
 	totalStopsInTrip := len(stopTimes)
 
-	blockTripSequence := api.calculateBlockTripSequence(ctx, tripID, serviceDate)
+	blockTripSequence := api.calculateBlockTripSequence(ctx, tripID, serviceMidnight)
 
 	lastUpdateTime := api.GtfsManager.GetVehicleLastUpdateTime(vehicle)
 
-	situationIDs := api.GetSituationIDsForTrip(r.Context(), tripID)
+	situationIDs := api.GetSituationIDsForTrip(ctx, tripID)
+	occupancyHistory := api.GtfsManager.GetOccupancyHistoryForTrip(ctx, tripID, serviceMidnight)
+	historicalOccupancy := occupancyHistory.StatusAt(stopCode)
+	predictedOccupancy := predictedOccupancyAtStop(occupancyHistory, vehicle, tripStatus, stopCode, numberOfStopsAway)
+
+	arrivalHeadsign := stopTimeHeadsign(targetStopTime.StopHeadsign, trip.TripHeadsign)
 
 	arrival := models.NewArrivalAndDeparture(
 		utils.FormCombinedID(route.AgencyID, route.ID), // routeID
 		route.ShortName.String,                         // routeShortName
 		route.LongName.String,                          // routeLongName
 		utils.FormCombinedID(route.AgencyID, tripID),   // tripID
-		trip.TripHeadsign.String,                       // tripHeadsign
+		arrivalHeadsign,                                // tripHeadsign
 		stopID,                                         // stopID
 		vehicleID,                                      // vehicleID
 		serviceDateMillis,                              // serviceDate
@@ -326,20 +238,21 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		predictedDepartureTime,                         // predictedDepartureTime
 		lastUpdateTime,                                 // lastUpdateTime
 		predicted,                                      // predicted
-		true,                                           // arrivalEnabled
-		true,                                           // departureEnabled
+		arrivalEnabled(targetStopTime.DropOffType),     // arrivalEnabled
+		departureEnabled(targetStopTime.PickupType),    // departureEnabled
 		int(targetStopTime.StopSequence)-1,             // stopSequence (Zero-based index)
 		totalStopsInTrip,                               // totalStopsInTrip
 		numberOfStopsAway,                              // numberOfStopsAway
 		blockTripSequence,                              // blockTripSequence
 		distanceFromStop,                               // distanceFromStop
-		"default",                                      // status
+		arrivalStatus,                                  // status
 		"",                                             // occupancyStatus
-		"",                                             // predictedOccupancy
-		"",                                             // historicalOccupancy
+		predictedOccupancy,                             // predictedOccupancy
+		historicalOccupancy,                            // historicalOccupancy
 		tripStatus,                                     // tripStatus
 		situationIDs,                                   // situationIds
 	)
+	arrival.PredictedFrom = stopsAwaySource
 
 	references := models.NewEmptyReferences()
 
@@ -388,15 +301,17 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		utils.FormCombinedID(route.AgencyID, trip.BlockID.String),
 		utils.FormCombinedID(route.AgencyID, trip.ShapeID.String),
 	)
+	tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+	tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
 	references.Trips = append(references.Trips, tripRef)
 
 	// Include active trip if it's different from the parameter trip and trip status is not null
 	if tripStatus != nil && tripStatus.ActiveTripID != "" {
 		_, activeTripID, err := utils.ExtractAgencyIDAndCodeID(tripStatus.ActiveTripID)
 		if err == nil && activeTripID != tripID {
-			activeTrip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, activeTripID)
+			activeTrip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, activeTripID)
 			if err == nil {
-				activeRoute, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, activeTrip.RouteID)
+				activeRoute, err := api.GtfsManager.GtfsDB.GetRoute(ctx, activeTrip.RouteID)
 				if err != nil {
 					api.Logger.Warn("failed to fetch route for active trip reference", "tripID", activeTripID, "error", err)
 				} else {
@@ -410,6 +325,8 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 						utils.FormCombinedID(activeRoute.AgencyID, activeTrip.BlockID.String),
 						utils.FormCombinedID(activeRoute.AgencyID, activeTrip.ShapeID.String),
 					)
+					activeTripRef.WheelchairAccessible = utils.MapWheelchairAccessible(activeTrip.WheelchairAccessible)
+					activeTripRef.BikesAllowed = utils.MapBikesAllowed(activeTrip.BikesAllowed)
 					references.Trips = append(references.Trips, activeTripRef)
 				}
 			}
@@ -445,49 +362,12 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 		}
 	}
 	calc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
-	for stopID := range stopIDSet {
-		stopData, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
-		if err != nil {
-			continue
-		}
-
-		routesForThisStop, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStops(ctx, []string{stopID})
-		if err != nil {
-			api.Logger.Warn("failed to fetch routes for stop", "stopID", stopID, "error", err)
-			continue
-		}
-
-		combinedRouteIDs := make([]string, len(routesForThisStop))
-		for i, route := range routesForThisStop {
-			combinedRouteIDs[i] = utils.FormCombinedID(route.AgencyID, route.ID)
-			routeCopy := gtfsdb.Route{
-				ID:        route.ID,
-				AgencyID:  route.AgencyID,
-				ShortName: route.ShortName,
-				LongName:  route.LongName,
-				Desc:      route.Desc,
-				Type:      route.Type,
-				Url:       route.Url,
-				Color:     route.Color,
-				TextColor: route.TextColor,
-			}
-			routeIDSet[route.ID] = &routeCopy
-		}
-
-		stopRef := models.Stop{
-			ID:                 utils.FormCombinedID(stopAgencyID, stopData.ID),
-			Name:               stopData.Name.String,
-			Lat:                stopData.Lat,
-			Lon:                stopData.Lon,
-			Code:               stopData.Code.String,
-			Direction:          calc.CalculateStopDirection(r.Context(), stopData.ID, stopData.Direction),
-			LocationType:       int(stopData.LocationType.Int64),
-			WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stopData.WheelchairBoarding)),
-			RouteIDs:           combinedRouteIDs,
-			StaticRouteIDs:     combinedRouteIDs,
-		}
-		references.Stops = append(references.Stops, stopRef)
+	stopRefs, err := buildArrivalStopReferences(api, ctx, stopAgencyID, stopIDSet, routeIDSet, calc)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
 	}
+	references.Stops = append(references.Stops, stopRefs...)
 
 	// Build routes references
 	for _, route := range routeIDSet {
@@ -500,7 +380,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 			models.RouteType(route.Type),
 			route.Url.String,
 			route.Color.String,
-			route.TextColor.String)
+			route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 		references.Routes = append(references.Routes, routeRef)
 	}
@@ -508,7 +388,7 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	if len(situationIDs) > 0 {
 		alerts := api.GtfsManager.GetAlertsForTrip(r.Context(), tripID)
 		if len(alerts) > 0 {
-			situations := api.BuildSituationReferences(alerts, route.AgencyID)
+			situations := api.BuildSituationReferences(alerts, route.AgencyID, r.URL.Query().Get("lang"))
 			for _, situation := range situations {
 				references.Situations = append(references.Situations, situation)
 			}
@@ -519,7 +399,13 @@ func (api *RestAPI) arrivalAndDepartureForStopHandler(w http.ResponseWriter, r *
 	api.sendResponse(w, r, response)
 }
 
+// getPredictedTimes returns the predicted arrival and departure (Unix ms) of a
+// trip at one of its stops, with GTFS-RT delays propagated from earlier stops.
+// It returns 0, 0 when there is no prediction for the stop, or when the
+// predicted arrival lies beyond the prediction horizon.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (api *RestAPI) getPredictedTimes(
+	ctx context.Context,
 	tripID string,
 	stopCode string,
 	targetStopSequence int64,
@@ -527,83 +413,50 @@ func (api *RestAPI) getPredictedTimes(
 ) (predictedArrivalTime, predictedDepartureTime int64) {
 
 	realTimeTrip, _ := api.GtfsManager.GetTripUpdateByID(tripID)
-	if realTimeTrip == nil || len(realTimeTrip.StopTimeUpdates) == 0 {
+	if realTimeTrip == nil || (len(realTimeTrip.StopTimeUpdates) == 0 && realTimeTrip.Delay == nil) {
 		return 0, 0
 	}
 
-	var arrivalOffset, departureOffset *int64
-	var propagatedDelay int64 = 0
-	var closestPriorSequence int64 = -1
-	var foundTarget bool
-
-	for _, stu := range realTimeTrip.StopTimeUpdates {
-		seq := int64(-1)
-		if stu.StopSequence != nil {
-			seq = int64(*stu.StopSequence)
-		}
-
-		if (stu.StopID != nil && *stu.StopID == stopCode) || (seq != -1 && seq == targetStopSequence) {
-			foundTarget = true
-			if stu.Arrival != nil {
-				if stu.Arrival.Time != nil {
-					offset := stu.Arrival.Time.Sub(scheduledArrivalTime).Nanoseconds()
-					arrivalOffset = &offset
-				} else if stu.Arrival.Delay != nil {
-					offset := int64(*stu.Arrival.Delay)
-					arrivalOffset = &offset
-				}
-			}
-			if stu.Departure != nil {
-				if stu.Departure.Time != nil {
-					offset := stu.Departure.Time.Sub(scheduledDepartureTime).Nanoseconds()
-					departureOffset = &offset
-				} else if stu.Departure.Delay != nil {
-					offset := int64(*stu.Departure.Delay)
-					departureOffset = &offset
-				}
-			}
+	target := scheduledStop{
+		StopID:       stopCode,
+		StopSequence: targetStopSequence,
+		Arrival:      scheduledArrivalTime,
+		Departure:    scheduledDepartureTime,
+	}
+
+	// Place the target among the trip's other stops so that updates for earlier
+	// stops reach it. The trip's service midnight follows from the target's
+	// scheduled arrival.
+	stops := []scheduledStop{target}
+	targetIndex := 0
+	stopTimes, _ := tripDataMemoFromContext(ctx).stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, tripID)
+	for i, st := range stopTimes {
+		if st.StopSequence == targetStopSequence {
+			serviceMidnight := scheduledArrivalTime.Add(-utils.StopTimeDuration(st.ArrivalTime))
+			stops = newScheduledStops(stopTimes, serviceMidnight)
+			stops[i] = target
+			targetIndex = i
 			break
 		}
-
-		if seq != -1 && seq < targetStopSequence && seq > closestPriorSequence {
-			closestPriorSequence = seq
-			if stu.Departure != nil && stu.Departure.Delay != nil {
-				propagatedDelay = int64(*stu.Departure.Delay)
-			} else if stu.Arrival != nil && stu.Arrival.Delay != nil {
-				propagatedDelay = int64(*stu.Arrival.Delay)
-			}
-		}
 	}
 
-	if !foundTarget && closestPriorSequence == -1 {
+	prediction := propagateTripUpdate(realTimeTrip, stops)[targetIndex]
+	if prediction == nil {
 		return 0, 0
 	}
-
-	if arrivalOffset == nil {
-		arrivalOffset = &propagatedDelay
-	}
-	if departureOffset == nil {
-		departureOffset = &propagatedDelay
-	}
-
-	// Rule 1: arrival == departure (Simplified Logic)
-	if scheduledArrivalTime.Equal(scheduledDepartureTime) {
-		offset := *arrivalOffset
-
-		if *departureOffset != propagatedDelay {
-			offset = *departureOffset
-		}
-
-		predictedArrival := scheduledArrivalTime.Add(time.Duration(offset))
-		predictedDeparture := scheduledDepartureTime.Add(time.Duration(offset))
-		return predictedArrival.UnixMilli(), predictedDeparture.UnixMilli()
+	predictedArrival := scheduledArrivalTime.Add(prediction.ArrivalDelay)
+	if api.beyondPredictionHorizon(predictedArrival) {
+		return 0, 0
 	}
+	return predictedArrival.UnixMilli(),
+		scheduledDepartureTime.Add(prediction.DepartureDelay).UnixMilli()
+}
 
-	// Rule 2: arrival < departure
-	predictedArrival := scheduledArrivalTime.Add(time.Duration(*arrivalOffset))
-	predictedDeparture := scheduledDepartureTime.Add(time.Duration(*departureOffset))
-
-	return predictedArrival.UnixMilli(), predictedDeparture.UnixMilli()
+// beyondPredictionHorizon reports whether an arrival at t is further ahead
+// than the configured prediction horizon, and so reported as scheduled only.
+func (api *RestAPI) beyondPredictionHorizon(t time.Time) bool {
+	horizon := api.Config.PredictionHorizon
+	return horizon > 0 && t.After(api.Clock.Now().Add(horizon))
 }
 
 func (api *RestAPI) getNumberOfStopsAway(ctx context.Context, targetTripID string, targetStopSequence int, vehicle *gtfs.Vehicle, serviceDate time.Time) *int {
@@ -623,3 +476,33 @@ func (api *RestAPI) getNumberOfStopsAway(ctx context.Context, targetTripID strin
 	numberOfStopsAway := targetGlobalSeq - vehicleGlobalSeq - 1
 	return &numberOfStopsAway
 }
+
+// selectStopTimeForArrival finds the trip's visit to stopID. An explicit
+// stopSequence must match exactly. Otherwise the only visit is used, and for
+// trips that serve the stop more than once (loops) the visit scheduled closest to
+// now is chosen.
+func selectStopTimeForArrival(stopTimes []gtfsdb.StopTime, stopID string, stopSequence *int, serviceMidnight, now time.Time) *gtfsdb.StopTime {
+	var best *gtfsdb.StopTime
+	var bestDelta time.Duration
+	for i := range stopTimes {
+		st := &stopTimes[i]
+		if st.StopID != stopID {
+			continue
+		}
+		if stopSequence != nil {
+			if int64(*stopSequence) == st.StopSequence {
+				return st
+			}
+			continue
+		}
+
+		delta := serviceMidnight.Add(utils.StopTimeDuration(st.ArrivalTime)).Sub(now)
+		if delta < 0 {
+			delta = -delta
+		}
+		if best == nil || delta < bestDelta {
+			best, bestDelta = st, delta
+		}
+	}
+	return best
+}
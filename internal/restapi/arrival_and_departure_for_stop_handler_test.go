@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/clock"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -530,7 +531,7 @@ func TestGetPredictedTimes_NoRealTimeData(t *testing.T) {
 	scheduledDeparture := scheduledArrival.Add(2 * time.Minute)
 
 	// When there's no real-time data, should return 0, 0
-	predArrival, predDeparture := api.getPredictedTimes("nonexistent_trip", "nonexistent_stop", 1, scheduledArrival, scheduledDeparture)
+	predArrival, predDeparture := api.getPredictedTimes(context.Background(), "nonexistent_trip", "nonexistent_stop", 1, scheduledArrival, scheduledDeparture)
 
 	assert.Equal(t, int64(0), predArrival)
 	assert.Equal(t, int64(0), predDeparture)
@@ -545,7 +546,7 @@ func TestGetPredictedTimes_EqualArrivalDeparture(t *testing.T) {
 
 	// Even without real-time data, test the logic path
 	// This tests that the function handles the case correctly
-	predArrival, predDeparture := api.getPredictedTimes("test_trip", "test_stop", 1, scheduledTime, scheduledTime)
+	predArrival, predDeparture := api.getPredictedTimes(context.Background(), "test_trip", "test_stop", 1, scheduledTime, scheduledTime)
 
 	// Without real-time data, returns 0,0
 	assert.Equal(t, int64(0), predArrival)
@@ -557,7 +558,7 @@ func TestGetBlockDistanceToStop_NilVehicle(t *testing.T) {
 	defer api.Shutdown()
 	ctx := context.Background()
 
-	result := api.getBlockDistanceToStop(ctx, "test_trip", "test_stop", nil, time.Now())
+	result := api.getBlockDistanceToStop(ctx, "test_trip", 1, nil, time.Now())
 
 	assert.Equal(t, 0.0, result)
 }
@@ -571,7 +572,7 @@ func TestGetBlockDistanceToStop_NoPosition(t *testing.T) {
 		Position: nil,
 	}
 
-	result := api.getBlockDistanceToStop(ctx, "test_trip", "test_stop", vehicle, time.Now())
+	result := api.getBlockDistanceToStop(ctx, "test_trip", 1, vehicle, time.Now())
 
 	assert.Equal(t, 0.0, result)
 }
@@ -587,6 +588,37 @@ func TestGetNumberOfStopsAway_NilCurrentSequence(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func TestSelectStopTimeForArrival_LoopTrip(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceMidnight := time.Date(2026, 3, 2, 0, 0, 0, 0, loc)
+
+	// A loop trip that starts and ends at stop A.
+	stopTimes := []gtfsdb.StopTime{
+		{TripID: "loop", StopID: "A", StopSequence: 1, ArrivalTime: utils.StopTimeSeconds(8 * time.Hour)},
+		{TripID: "loop", StopID: "B", StopSequence: 2, ArrivalTime: utils.StopTimeSeconds(8*time.Hour + 20*time.Minute)},
+		{TripID: "loop", StopID: "A", StopSequence: 3, ArrivalTime: utils.StopTimeSeconds(8*time.Hour + 40*time.Minute)},
+	}
+
+	first := selectStopTimeForArrival(stopTimes, "A", nil, serviceMidnight, serviceMidnight.Add(7*time.Hour+55*time.Minute))
+	require.NotNil(t, first)
+	assert.Equal(t, int64(1), first.StopSequence)
+
+	last := selectStopTimeForArrival(stopTimes, "A", nil, serviceMidnight, serviceMidnight.Add(8*time.Hour+35*time.Minute))
+	require.NotNil(t, last)
+	assert.Equal(t, int64(3), last.StopSequence)
+
+	// An explicit stopSequence wins regardless of time.
+	sequence := 1
+	explicit := selectStopTimeForArrival(stopTimes, "A", &sequence, serviceMidnight, serviceMidnight.Add(8*time.Hour+35*time.Minute))
+	require.NotNil(t, explicit)
+	assert.Equal(t, int64(1), explicit.StopSequence)
+
+	sequence = 2
+	assert.Nil(t, selectStopTimeForArrival(stopTimes, "A", &sequence, serviceMidnight, serviceMidnight))
+	assert.Nil(t, selectStopTimeForArrival(stopTimes, "C", nil, serviceMidnight, serviceMidnight))
+}
+
 func TestParseArrivalAndDepartureParams_AllParameters(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -743,8 +775,8 @@ func TestArrivalAndDepartureForStopHandler_MultiAgency_Regression(t *testing.T)
 		TripID:        tripB_ID,
 		StopID:        stopID,
 		StopSequence:  1,
-		ArrivalTime:   28800 * 1e9, // 08:00:00 converted to nanoseconds
-		DepartureTime: 29100 * 1e9, // 08:05:00 converted to nanoseconds
+		ArrivalTime:   28800, // 08:00:00
+		DepartureTime: 29100, // 08:05:00
 	})
 	require.NoError(t, err)
 
@@ -831,10 +863,41 @@ func TestGetPredictedTimes_DelayPropagationLogic(t *testing.T) {
 	api.GtfsManager.SetRealTimeTripsForTest([]gtfs.Trip{mockTrip})
 
 	scheduledTime := time.Now()
-	predArrival, predDeparture := api.getPredictedTimes(tripID, "test_stop", targetStopSequence, scheduledTime, scheduledTime)
+	predArrival, predDeparture := api.getPredictedTimes(context.Background(), tripID, "test_stop", targetStopSequence, scheduledTime, scheduledTime)
 
 	expectedTime := scheduledTime.Add(delayDuration).UnixMilli()
 
 	assert.Equal(t, expectedTime, predArrival, "Arrival time should include 120s delay")
 	assert.Equal(t, expectedTime, predDeparture, "Departure time should include 120s delay")
 }
+
+func TestGetPredictedTimes_PredictionHorizon(t *testing.T) {
+	now := time.Date(2025, 6, 12, 9, 0, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	delay := 2 * time.Minute
+	early := -10 * time.Minute
+	api.GtfsManager.MockAddTripUpdate("late_trip", &delay, nil)
+	api.GtfsManager.MockAddTripUpdate("early_trip", &early, nil)
+	api.Config.PredictionHorizon = 30 * time.Minute
+	ctx := context.Background()
+
+	soon := now.Add(10 * time.Minute)
+	predArrival, _ := api.getPredictedTimes(ctx, "late_trip", "test_stop", 1, soon, soon)
+	assert.Equal(t, soon.Add(delay).UnixMilli(), predArrival)
+
+	later := now.Add(40 * time.Minute)
+	predArrival, predDeparture := api.getPredictedTimes(ctx, "late_trip", "test_stop", 1, later, later)
+	assert.Zero(t, predArrival, "beyond the horizon")
+	assert.Zero(t, predDeparture)
+
+	// The horizon applies to the predicted arrival, not the scheduled one.
+	predArrival, _ = api.getPredictedTimes(ctx, "early_trip", "test_stop", 1, later, later)
+	assert.Equal(t, later.Add(early).UnixMilli(), predArrival)
+
+	api.Config.PredictionHorizon = 0
+	predArrival, _ = api.getPredictedTimes(ctx, "late_trip", "test_stop", 1, later, later)
+	assert.Equal(t, later.Add(delay).UnixMilli(), predArrival, "no horizon predicts every arrival")
+}
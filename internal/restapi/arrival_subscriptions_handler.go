@@ -0,0 +1,189 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+	"maglev.onebusaway.org/internal/webhooks"
+)
+
+const (
+	maxArrivalSubscriptionsPerKey = 100
+	maxArrivalThresholdMinutes    = 120
+	// defaultArrivalSubscriptionTTL is how long a subscription lasts when the
+	// request gives no expiresAt.
+	defaultArrivalSubscriptionTTL = 24 * time.Hour
+	maxArrivalSubscriptionTTL     = 7 * 24 * time.Hour
+)
+
+// withArrivalWebhooks makes the subscription endpoints answer 404 while
+// arrival webhooks are disabled.
+func withArrivalWebhooks(api *RestAPI, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.arrivalWebhooks == nil {
+			api.sendNotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// listArrivalSubscriptionsHandler lists the subscriptions made with the
+// request's API key.
+func (api *RestAPI) listArrivalSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	list := make([]models.ArrivalSubscription, 0)
+	for _, sub := range api.arrivalWebhooks.store.List(api.Clock.Now()) {
+		if sub.APIKey == key {
+			list = append(list, newArrivalSubscriptionModel(sub, false))
+		}
+	}
+	api.sendResponse(w, r, models.NewListResponse(list, models.NewEmptyReferences(), false, api.Clock))
+}
+
+// createArrivalSubscriptionHandler subscribes the request's API key to the
+// arrivals at a stop from a JSON body with callbackUrl, an agency-prefixed
+// stopId and optional routeId, thresholdMinutes (1 to 120) and an optional
+// expiresAt (milliseconds since the epoch, at most a week ahead). The response
+// carries the secret the notifications are signed with.
+func (api *RestAPI) createArrivalSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		CallbackURL      string `json:"callbackUrl"`
+		StopID           string `json:"stopId"`
+		RouteID          string `json:"routeId"`
+		ThresholdMinutes int    `json:"thresholdMinutes"`
+		ExpiresAt        *int64 `json:"expiresAt"`
+	}
+	if err := decodeAdminRequest(r, &body); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"body": {err.Error()}})
+		return
+	}
+
+	now := api.Clock.Now()
+	sub := webhooks.Subscription{
+		APIKey:    r.URL.Query().Get("key"),
+		Threshold: time.Duration(body.ThresholdMinutes) * time.Minute,
+		CreatedAt: now,
+		ExpiresAt: now.Add(defaultArrivalSubscriptionTTL),
+	}
+	fieldErrors := make(map[string][]string)
+
+	callback, err := api.arrivalWebhooks.policy.CheckURL(body.CallbackURL)
+	switch {
+	case errors.Is(err, webhooks.ErrForbiddenAddress):
+		fieldErrors["callbackUrl"] = []string{"must not be a loopback, private or link-local address"}
+	case err != nil:
+		fieldErrors["callbackUrl"] = []string{err.Error()}
+	default:
+		sub.CallbackURL = callback.String()
+	}
+
+	if sub.AgencyID, sub.StopID, err = utils.ExtractAgencyIDAndCodeID(body.StopID); err != nil {
+		fieldErrors["stopId"] = []string{"must be an agency-prefixed stop ID"}
+	}
+	if body.RouteID != "" {
+		if sub.RouteAgencyID, sub.RouteID, err = utils.ExtractAgencyIDAndCodeID(body.RouteID); err != nil {
+			fieldErrors["routeId"] = []string{"must be an agency-prefixed route ID"}
+		}
+	}
+	if body.ThresholdMinutes < 1 || body.ThresholdMinutes > maxArrivalThresholdMinutes {
+		fieldErrors["thresholdMinutes"] = []string{fmt.Sprintf("must be between 1 and %d", maxArrivalThresholdMinutes)}
+	}
+	if body.ExpiresAt != nil {
+		sub.ExpiresAt = time.UnixMilli(*body.ExpiresAt)
+		if !sub.ExpiresAt.After(now) || sub.ExpiresAt.Sub(now) > maxArrivalSubscriptionTTL {
+			fieldErrors["expiresAt"] = []string{"must be in the next 7 days"}
+		}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+	api.GtfsManager.RLock()
+	_, stopErr := api.GtfsManager.GtfsDB.GetStop(ctx, sub.StopID)
+	var routeErr error
+	if sub.RouteID != "" {
+		_, routeErr = api.GtfsManager.GtfsDB.GetRoute(ctx, sub.RouteID)
+	}
+	api.GtfsManager.RUnlock()
+	if stopErr != nil {
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
+		return
+	}
+	if routeErr != nil {
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
+		return
+	}
+
+	created, err := api.arrivalWebhooks.store.Add(sub, now)
+	if errors.Is(err, webhooks.ErrLimitReached) {
+		api.sendError(w, r, http.StatusConflict,
+			fmt.Sprintf("an API key may hold at most %d arrival subscriptions", maxArrivalSubscriptionsPerKey))
+		return
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	api.Logger.Info("arrival subscription created",
+		"subscriptionId", created.ID,
+		"stopId", body.StopID,
+		"routeId", body.RouteID,
+		"thresholdMinutes", body.ThresholdMinutes)
+
+	api.sendResponse(w, r, models.NewEntryResponse(newArrivalSubscriptionModel(created, true), models.NewEmptyReferences(), api.Clock))
+}
+
+func (api *RestAPI) getArrivalSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	sub, ok := api.ownArrivalSubscription(r)
+	if !ok {
+		api.sendNotFound(w, r)
+		return
+	}
+	api.sendResponse(w, r, models.NewEntryResponse(newArrivalSubscriptionModel(sub, false), models.NewEmptyReferences(), api.Clock))
+}
+
+func (api *RestAPI) deleteArrivalSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	sub, ok := api.ownArrivalSubscription(r)
+	if !ok || !api.arrivalWebhooks.store.Remove(sub.ID) {
+		api.sendNotFound(w, r)
+		return
+	}
+	api.sendResponse(w, r, models.NewOKResponse(nil, api.Clock))
+}
+
+// ownArrivalSubscription returns the subscription named in the path if it was
+// made with the request's API key; other keys' subscriptions are not found.
+func (api *RestAPI) ownArrivalSubscription(r *http.Request) (webhooks.Subscription, bool) {
+	sub, ok := api.arrivalWebhooks.store.Get(r.PathValue("id"), api.Clock.Now())
+	if !ok || sub.APIKey != r.URL.Query().Get("key") {
+		return webhooks.Subscription{}, false
+	}
+	return sub, true
+}
+
+// newArrivalSubscriptionModel describes a subscription, with its secret only
+// when withSecret is set.
+func newArrivalSubscriptionModel(sub webhooks.Subscription, withSecret bool) models.ArrivalSubscription {
+	m := models.ArrivalSubscription{
+		ID:               sub.ID,
+		CallbackURL:      sub.CallbackURL,
+		StopID:           utils.FormCombinedID(sub.AgencyID, sub.StopID),
+		ThresholdMinutes: int(sub.Threshold / time.Minute),
+		CreatedAt:        sub.CreatedAt.UnixMilli(),
+		ExpiresAt:        sub.ExpiresAt.UnixMilli(),
+	}
+	if sub.RouteID != "" {
+		m.RouteID = utils.FormCombinedID(sub.RouteAgencyID, sub.RouteID)
+	}
+	if withSecret {
+		m.Secret = sub.Secret
+	}
+	return m
+}
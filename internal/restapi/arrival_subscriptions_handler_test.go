@@ -0,0 +1,115 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/webhooks"
+)
+
+// subscriberKey is exempt from rate limiting, so tests can make many requests.
+const subscriberKey = "org.onebusaway.iphone"
+
+func createTestApiWithArrivalWebhooks(t *testing.T, c clock.Clock, policy webhooks.CallbackPolicy) (*RestAPI, *httptest.Server) {
+	t.Helper()
+	api := createTestApiWithClock(t, c)
+	t.Cleanup(api.Shutdown)
+	// Subscriptions are checked by hand rather than by the background loop.
+	api.arrivalWebhooks = newArrivalWebhooks(c, policy)
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func TestArrivalSubscriptionsNotFoundWhenDisabled(t *testing.T) {
+	api := createTestApi(t)
+	t.Cleanup(api.Shutdown)
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	resp, _ := doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestArrivalSubscriptions(t *testing.T) {
+	_, server := createTestApiWithArrivalWebhooks(t, clock.RealClock{}, webhooks.CallbackPolicy{})
+
+	resp, _ := doAdminRequest(t, server, http.MethodPost, "/api/subscriptions/arrivals", `{}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "subscribing needs an API key")
+
+	resp, model := doAdminRequest(t, server, http.MethodPost, "/api/subscriptions/arrivals?key="+subscriberKey,
+		`{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","routeId":"25_151","thresholdMinutes":5}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	id := entry["id"].(string)
+	assert.Equal(t, "https://rider.example/hook", entry["callbackUrl"])
+	assert.Equal(t, "25_1505", entry["stopId"])
+	assert.Equal(t, "25_151", entry["routeId"])
+	assert.Equal(t, float64(5), entry["thresholdMinutes"])
+	assert.NotEmpty(t, entry["secret"], "the secret is returned on creation")
+	createdAt := int64(entry["createdAt"].(float64))
+	assert.Equal(t, createdAt+defaultArrivalSubscriptionTTL.Milliseconds(), int64(entry["expiresAt"].(float64)))
+
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.NotContains(t, entry, "secret", "the secret is not shown again")
+
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals?key="+subscriberKey, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, model.Data.(map[string]interface{})["list"], 1)
+
+	// Other keys do not see the subscription.
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals?key=test", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, model.Data.(map[string]interface{})["list"])
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/subscriptions/arrivals/"+id+"?key=test", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCreateArrivalSubscriptionValidation(t *testing.T) {
+	_, server := createTestApiWithArrivalWebhooks(t, clock.RealClock{}, webhooks.CallbackPolicy{})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string
+	}{
+		{"callback not http", `{"callbackUrl":"ftp://rider.example/hook","stopId":"25_1505","thresholdMinutes":5}`, http.StatusBadRequest, "callbackUrl"},
+		{"callback loopback", `{"callbackUrl":"http://127.0.0.1/hook","stopId":"25_1505","thresholdMinutes":5}`, http.StatusBadRequest, "callbackUrl"},
+		{"callback metadata service", `{"callbackUrl":"http://169.254.169.254/latest/meta-data/","stopId":"25_1505","thresholdMinutes":5}`, http.StatusBadRequest, "callbackUrl"},
+		{"callback relative", `{"callbackUrl":"/hook","stopId":"25_1505","thresholdMinutes":5}`, http.StatusBadRequest, "callbackUrl"},
+		{"stop without agency", `{"callbackUrl":"https://rider.example/hook","stopId":"1505","thresholdMinutes":5}`, http.StatusBadRequest, "stopId"},
+		{"threshold missing", `{"callbackUrl":"https://rider.example/hook","stopId":"25_1505"}`, http.StatusBadRequest, "thresholdMinutes"},
+		{"threshold too large", `{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","thresholdMinutes":500}`, http.StatusBadRequest, "thresholdMinutes"},
+		{"expiry in the past", `{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","thresholdMinutes":5,"expiresAt":1}`, http.StatusBadRequest, "expiresAt"},
+		{"unknown field", `{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","thresholdMinutes":5,"minutes":5}`, http.StatusBadRequest, "body"},
+		{"unknown stop", `{"callbackUrl":"https://rider.example/hook","stopId":"25_nowhere","thresholdMinutes":5}`, http.StatusNotFound, ""},
+		{"unknown route", `{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","routeId":"25_nowhere","thresholdMinutes":5}`, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, model := doAdminRequest(t, server, http.MethodPost, "/api/subscriptions/arrivals?key="+subscriberKey, tt.body)
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.field != "" {
+				fieldErrors := model.Data.(map[string]interface{})["fieldErrors"].(map[string]interface{})
+				assert.Contains(t, fieldErrors, tt.field)
+			}
+		})
+	}
+}
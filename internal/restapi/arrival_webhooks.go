@@ -0,0 +1,221 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+	"maglev.onebusaway.org/internal/webhooks"
+)
+
+const (
+	// arrivalWebhookInterval is how often subscriptions are checked when no
+	// realtime update prompts it sooner; arrivals without predictions only
+	// come within reach as time passes.
+	arrivalWebhookInterval = 30 * time.Second
+	// arrivalWebhookLookback is how long after its scheduled time a late trip
+	// is still looked for, and how long a notified arrival is remembered.
+	arrivalWebhookLookback = 30 * time.Minute
+)
+
+// arrivalWebhooks holds the arrival subscriptions and delivers their
+// notifications.
+type arrivalWebhooks struct {
+	store      *webhooks.Store
+	dispatcher *webhooks.Dispatcher
+	policy     webhooks.CallbackPolicy
+
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func newArrivalWebhooks(c clock.Clock, policy webhooks.CallbackPolicy) *arrivalWebhooks {
+	return &arrivalWebhooks{
+		store:      webhooks.NewStore(maxArrivalSubscriptionsPerKey),
+		dispatcher: webhooks.NewDispatcher(c, policy),
+		policy:     policy,
+		stop:       make(chan struct{}),
+	}
+}
+
+// close stops checking subscriptions and drops undelivered notifications.
+func (h *arrivalWebhooks) close() {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+	h.wg.Wait()
+	h.dispatcher.Close()
+}
+
+// startArrivalWebhooks checks the arrival subscriptions whenever the realtime
+// data changes, and every arrivalWebhookInterval, until the API shuts down.
+func (api *RestAPI) startArrivalWebhooks() {
+	hooks := newArrivalWebhooks(api.Clock, webhooks.CallbackPolicy{AllowedHosts: api.Config.ArrivalWebhookAllowedHosts})
+	api.arrivalWebhooks = hooks
+	logger := slog.Default().With(slog.String("component", "arrival_webhooks"))
+
+	changes, unsubscribe := api.GtfsManager.SubscribeRealtime()
+	hooks.wg.Add(1)
+	go func() {
+		defer hooks.wg.Done()
+		defer unsubscribe()
+
+		ticker := time.NewTicker(arrivalWebhookInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hooks.stop:
+				return
+			case _, ok := <-changes:
+				if !ok {
+					return
+				}
+			case <-ticker.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), arrivalWebhookInterval)
+			if err := api.checkArrivalSubscriptions(ctx, api.Clock.Now()); err != nil {
+				logging.LogError(logger, "could not check arrival subscriptions", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// upcomingArrival is a visit to a stop expected between now and the end of
+// the window checked.
+type upcomingArrival struct {
+	activeStopTime
+	scheduled time.Time
+	// predicted is the predicted arrival in milliseconds since the epoch, or 0.
+	predicted int64
+	// at is the predicted arrival, or the scheduled one without a prediction.
+	at time.Time
+}
+
+// key identifies the arrival among those of its stop; frequency-based runs of
+// one trip differ in their scheduled time.
+func (a upcomingArrival) key() string {
+	return a.TripID + "|" + a.ServiceDate.Format("20060102") + "|" + a.scheduled.Format(time.RFC3339)
+}
+
+// checkArrivalSubscriptions notifies each subscription in force of the
+// arrivals at its stop that are now within its threshold and have not been
+// notified before.
+func (api *RestAPI) checkArrivalSubscriptions(ctx context.Context, now time.Time) error {
+	hooks := api.arrivalWebhooks
+	byStop := make(map[string][]webhooks.Subscription)
+	for _, sub := range hooks.store.List(now) {
+		byStop[sub.StopID] = append(byStop[sub.StopID], sub)
+	}
+	if len(byStop) == 0 {
+		return nil
+	}
+
+	ctx = withTripDataMemo(ctx)
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	for stopCode, subs := range byStop {
+		var horizon time.Duration
+		for _, sub := range subs {
+			if sub.Threshold > horizon {
+				horizon = sub.Threshold
+			}
+		}
+		arrivals, err := api.upcomingArrivals(ctx, stopCode, now, now.Add(horizon))
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			for _, arrival := range arrivals {
+				if sub.RouteID != "" && arrival.RouteID != sub.RouteID {
+					continue
+				}
+				if arrival.at.After(now.Add(sub.Threshold)) {
+					continue
+				}
+				if !hooks.store.MarkNotified(sub.ID, arrival.key(), arrival.at.Add(arrivalWebhookLookback), now) {
+					continue
+				}
+				api.sendArrivalNotification(ctx, sub, arrival, now)
+			}
+		}
+	}
+	return nil
+}
+
+// upcomingArrivals returns the scheduled visits to stopCode that are
+// expected between now and windowEnd, by prediction where there is one.
+// Canceled runs are left out.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) upcomingArrivals(ctx context.Context, stopCode string, now, windowEnd time.Time) ([]upcomingArrival, error) {
+	stopTimes, err := api.collectActiveStopTimes(ctx, stopCode, now.Add(-arrivalWebhookLookback), windowEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var arrivals []upcomingArrival
+	for _, ast := range stopTimes {
+		if api.tripCanceledOn(ast.TripID, ast.ServiceDate) {
+			continue
+		}
+		arrival := upcomingArrival{
+			activeStopTime: ast,
+			scheduled:      ast.ServiceDate.Add(utils.StopTimeDuration(ast.ArrivalTime)),
+		}
+		arrival.predicted, _ = api.getPredictedTimes(ctx, ast.TripID, stopCode, ast.StopSequence,
+			arrival.scheduled, ast.ServiceDate.Add(utils.StopTimeDuration(ast.DepartureTime)))
+		arrival.at = arrival.scheduled
+		if arrival.predicted != 0 {
+			arrival.at = time.UnixMilli(arrival.predicted)
+		}
+		if arrival.at.Before(now) || arrival.at.After(windowEnd) {
+			continue
+		}
+		arrivals = append(arrivals, arrival)
+	}
+	return arrivals, nil
+}
+
+// sendArrivalNotification queues the notification of an arrival for delivery
+// to the subscription's callback URL.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) sendArrivalNotification(ctx context.Context, sub webhooks.Subscription, arrival upcomingArrival, now time.Time) {
+	route, err := tripDataMemoFromContext(ctx).route(ctx, api.GtfsManager.GtfsDB, arrival.RouteID)
+	if err != nil {
+		api.Logger.Warn("skipping arrival notification: route not found",
+			"subscriptionId", sub.ID, "routeID", arrival.RouteID, "error", err)
+		return
+	}
+
+	notification := models.ArrivalNotification{
+		SubscriptionID:       sub.ID,
+		StopID:               utils.FormCombinedID(sub.AgencyID, sub.StopID),
+		RouteID:              utils.FormCombinedID(route.AgencyID, route.ID),
+		RouteShortName:       route.ShortName.String,
+		TripID:               utils.FormCombinedID(route.AgencyID, arrival.TripID),
+		TripHeadsign:         stopTimeHeadsign(arrival.StopHeadsign, arrival.TripHeadsign),
+		ServiceDate:          arrival.ServiceDate.UnixMilli(),
+		ScheduledArrivalTime: arrival.scheduled.UnixMilli(),
+		PredictedArrivalTime: arrival.predicted,
+		Predicted:            arrival.predicted != 0,
+		CurrentTime:          now.UnixMilli(),
+	}
+	if vehicle := api.GtfsManager.GetVehicleForTrip(ctx, arrival.TripID); vehicle != nil && vehicle.ID != nil {
+		notification.VehicleID = utils.FormCombinedID(route.AgencyID, vehicle.ID.ID)
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		api.Logger.Warn("could not encode arrival notification", "subscriptionId", sub.ID, "error", err)
+		return
+	}
+	api.arrivalWebhooks.dispatcher.Send(sub, body)
+}
@@ -0,0 +1,115 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/webhooks"
+)
+
+func TestCheckArrivalSubscriptions(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 4, 16, 0, 0, 0, loc)
+	// The receiver listens on loopback.
+	api, server := createTestApiWithArrivalWebhooks(t, clock.NewMockClock(now), webhooks.CallbackPolicy{AllowPrivateAddresses: true})
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	type received struct {
+		notification models.ArrivalNotification
+		signed       bool
+	}
+	deliveries := make(chan received, 100)
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		var notification models.ArrivalNotification
+		assert.NoError(t, json.Unmarshal(body, &notification))
+		deliveries <- received{
+			notification: notification,
+			signed:       r.Header.Get(webhooks.SignatureHeader) == webhooks.Sign(secret, timestamp, body),
+		}
+	}))
+	t.Cleanup(receiver.Close)
+
+	resp, model := doAdminRequest(t, server, http.MethodPost, "/api/subscriptions/arrivals?key="+subscriberKey,
+		`{"callbackUrl":"`+receiver.URL+`","stopId":"25_1505","thresholdMinutes":20}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	secret = entry["secret"].(string)
+
+	api.GtfsManager.RLock()
+	expected, err := api.upcomingArrivals(withTripDataMemo(context.Background()), "1505", now, now.Add(20*time.Minute))
+	api.GtfsManager.RUnlock()
+	require.NoError(t, err)
+	require.NotEmpty(t, expected, "the stop has arrivals in the next 20 minutes")
+
+	require.NoError(t, api.checkArrivalSubscriptions(context.Background(), now))
+	for range expected {
+		select {
+		case got := <-deliveries:
+			assert.True(t, got.signed, "notifications carry a valid signature")
+			assert.Equal(t, entry["id"], got.notification.SubscriptionID)
+			assert.Equal(t, "25_1505", got.notification.StopID)
+			assert.False(t, got.notification.Predicted, "there is no realtime data")
+			assert.GreaterOrEqual(t, got.notification.ScheduledArrivalTime, now.UnixMilli())
+			assert.LessOrEqual(t, got.notification.ScheduledArrivalTime, now.Add(20*time.Minute).UnixMilli())
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification was not delivered")
+		}
+	}
+
+	// Each arrival is notified once, however often the subscriptions are checked.
+	require.NoError(t, api.checkArrivalSubscriptions(context.Background(), now.Add(time.Minute)))
+	select {
+	case got := <-deliveries:
+		assert.Greater(t, got.notification.ScheduledArrivalTime, now.Add(20*time.Minute).UnixMilli(),
+			"only arrivals that newly came within the threshold are notified")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestUpcomingArrivalsUsePredictions(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 4, 16, 0, 0, 0, loc)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	t.Cleanup(api.Shutdown)
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := withTripDataMemo(context.Background())
+
+	api.GtfsManager.RLock()
+	scheduled, err := api.upcomingArrivals(ctx, "1505", now, now.Add(20*time.Minute))
+	api.GtfsManager.RUnlock()
+	require.NoError(t, err)
+	require.NotEmpty(t, scheduled)
+	first := scheduled[0]
+
+	// Running late pushes the first arrival back by the delay.
+	delay := 3 * time.Minute
+	api.GtfsManager.MockAddTripUpdate(first.TripID, &delay, nil)
+	api.GtfsManager.RLock()
+	predicted, err := api.upcomingArrivals(withTripDataMemo(context.Background()), "1505", now, now.Add(30*time.Minute))
+	api.GtfsManager.RUnlock()
+	require.NoError(t, err)
+
+	for _, arrival := range predicted {
+		if arrival.key() == first.key() {
+			assert.Equal(t, first.scheduled.Add(delay).UnixMilli(), arrival.predicted)
+			assert.True(t, first.scheduled.Add(delay).Equal(arrival.at), arrival.at)
+			return
+		}
+	}
+	t.Fatal("the delayed arrival is missing")
+}
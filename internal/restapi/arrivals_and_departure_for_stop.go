@@ -4,9 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"sort"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
 	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
@@ -18,6 +19,21 @@ type ArrivalsStopParams struct {
 	MinutesAfter  int
 	MinutesBefore int
 	Time          time.Time
+	// MaxCount limits the arrivals returned; -1 returns all of them.
+	MaxCount int
+	// Offset is the index of the first arrival returned, in order of scheduled arrival.
+	Offset int
+	// RouteTypes limits the arrivals to routes of these GTFS route types.
+	RouteTypes []int
+	// WheelchairAccessible and BikesAllowed limit the arrivals to trips marked
+	// wheelchair accessible or as allowing bikes.
+	WheelchairAccessible bool
+	BikesAllowed         bool
+	// NearbyStopsRadius, NearbyStopsMaxCount and NearbyStopsRouteTypes control
+	// which stops are listed as nearbyStopIds; a max count of zero lists none.
+	NearbyStopsRadius     float64
+	NearbyStopsMaxCount   int
+	NearbyStopsRouteTypes []int
 }
 
 // parseArrivalsAndDeparturesParams parses and validates parameters.
@@ -25,60 +41,28 @@ func (api *RestAPI) parseArrivalsAndDeparturesParams(r *http.Request) (ArrivalsS
 	const maxMinutesBefore = 60
 	const maxMinutesAfter = 240
 
+	q := newQueryParams(r)
 	params := ArrivalsStopParams{
-		MinutesAfter:  35,              // Default
-		MinutesBefore: 5,               // Default
-		Time:          api.Clock.Now(), // Default to current time
-	}
-
-	var fieldErrors map[string][]string
-
-	addError := func(field, msg string) {
-		if fieldErrors == nil {
-			fieldErrors = make(map[string][]string)
-		}
-		fieldErrors[field] = append(fieldErrors[field], msg)
-	}
-
-	query := r.URL.Query()
+		MinutesAfter:  q.intParam("minutesAfter", 35, nonNegative[int](), clampedTo(maxMinutesAfter)),
+		MinutesBefore: q.intParam("minutesBefore", 5, nonNegative[int](), clampedTo(maxMinutesBefore)),
+		Time:          q.timeParam("time", api.Clock.Now()),
+		MaxCount:      -1,
 
-	if val := query.Get("minutesAfter"); val != "" {
-		if minutes, err := strconv.Atoi(val); err == nil {
-			if minutes > maxMinutesAfter {
-				params.MinutesAfter = maxMinutesAfter
-			} else if minutes >= 0 {
-				params.MinutesAfter = minutes
-			} else {
-				addError("minutesAfter", "must be a non-negative integer")
-			}
-		} else {
-			addError("minutesAfter", "must be a valid integer")
-		}
-	}
+		NearbyStopsRadius:   q.floatParam("nearbyStopsRadius", api.nearbyStopsRadius(), positive[float64](), between[float64](0, maxNearbyStopsRadius)),
+		NearbyStopsMaxCount: q.intParam("nearbyStopsMaxCount", api.nearbyStopsMaxCount(), between(0, models.MaxAllowedCount)),
 
-	if val := query.Get("minutesBefore"); val != "" {
-		if minutes, err := strconv.Atoi(val); err == nil {
-			if minutes > maxMinutesBefore {
-				params.MinutesBefore = maxMinutesBefore
-			} else if minutes >= 0 {
-				params.MinutesBefore = minutes
-			} else {
-				addError("minutesBefore", "must be a non-negative integer")
-			}
-		} else {
-			addError("minutesBefore", "must be a valid integer")
-		}
+		WheelchairAccessible: q.boolParam("wheelchairAccessible", false),
+		BikesAllowed:         q.boolParam("bikesAllowed", false),
 	}
 
-	if val := query.Get("time"); val != "" {
-		if timeMs, err := strconv.ParseInt(val, 10, 64); err == nil {
-			params.Time = time.Unix(timeMs/1000, (timeMs%1000)*1000000)
-		} else {
-			addError("time", "must be a valid Unix timestamp in milliseconds")
-		}
+	if q.has("maxCount") {
+		params.MaxCount, q.fieldErrors = utils.ParseMaxCount(q.values, -1, q.fieldErrors)
 	}
+	params.Offset, q.fieldErrors = parsePageOffset(r, q.fieldErrors)
+	params.RouteTypes, q.fieldErrors = utils.ParseRouteTypes(q.values, "routeTypes", q.fieldErrors)
+	params.NearbyStopsRouteTypes, q.fieldErrors = utils.ParseRouteTypes(q.values, "nearbyStopsRouteType", q.fieldErrors)
 
-	return params, fieldErrors
+	return params, q.errors()
 }
 
 func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r *http.Request) {
@@ -87,7 +71,8 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 	stopCode := parsed.CodeID
 	stopID := parsed.CombinedID
 
-	ctx := r.Context()
+	// Statuses are built per arrival, and arrivals often share trips and blocks.
+	ctx := withTripDataMemo(r.Context())
 
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
@@ -99,9 +84,9 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		return
 	}
 
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopCode)
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopCode)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
 		return
 	}
 
@@ -119,6 +104,14 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 	arrivals := make([]models.ArrivalAndDeparture, 0)
 	references := models.NewEmptyReferences()
 
+	// Alerts on the stop itself are listed with the entry rather than with
+	// each arrival, which carries its trip's alerts.
+	stopAlerts := api.alertsForStop(ctx, stopCode)
+	stopSituationIDs := situationIDsForAlerts(stopAlerts, stopAgencyID)
+	for _, situation := range api.BuildSituationReferences(stopAlerts, stopAgencyID, r.URL.Query().Get("lang")) {
+		references.Situations = append(references.Situations, situation)
+	}
+
 	// Add the stop's agency to references immediately
 	references.Agencies = append(references.Agencies, models.NewAgencyReference(
 		agency.ID, agency.Name, agency.Url, agency.Timezone, agency.Lang.String,
@@ -129,69 +122,51 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 	addedAgencyIDs := make(map[string]bool)
 	addedAgencyIDs[agency.ID] = true
 
-	type activeStopTime struct {
-		gtfsdb.GetStopTimesForStopInWindowRow
-		ServiceDate time.Time
-	}
-	var allActiveStopTimes []activeStopTime
-
-	for dayOffset := -1; dayOffset <= 1; dayOffset++ {
+	allActiveStopTimes, err := api.collectActiveStopTimes(ctx, stopCode, windowStart, windowEnd)
+	if err != nil {
 		if ctx.Err() != nil {
 			return
 		}
+		api.serverErrorResponse(w, r, err)
+		return
+	}
 
-		targetDate := params.Time.AddDate(0, 0, dayOffset)
-		serviceMidnight := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, loc)
-		serviceDateStr := targetDate.Format("20060102")
+	// Trips the realtime feeds add to the schedule are merged in by time.
+	if added := api.collectAddedTripStopTimes(ctx, stopCode, windowStart, windowEnd, loc); len(added) > 0 {
+		allActiveStopTimes = append(allActiveStopTimes, added...)
+		sortActiveStopTimes(allActiveStopTimes)
+	}
 
-		activeServiceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, serviceDateStr)
+	allActiveStopTimes, err = api.filterStopTimesByRouteTypes(ctx, stopCode, allActiveStopTimes, params.RouteTypes)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if params.WheelchairAccessible {
+		allActiveStopTimes, err = api.filterStopTimesByTrip(ctx, allActiveStopTimes, tripWheelchairAccessible)
 		if err != nil {
-			api.Logger.Warn("failed to query active service IDs",
-				slog.String("date", serviceDateStr),
-				slog.Any("error", err))
-			continue
-		}
-		if len(activeServiceIDs) == 0 {
-			continue
-		}
-
-		activeServiceIDSet := make(map[string]bool, len(activeServiceIDs))
-		for _, sid := range activeServiceIDs {
-			activeServiceIDSet[sid] = true
-		}
-
-		startNanos := windowStart.Sub(serviceMidnight).Nanoseconds()
-		endNanos := windowEnd.Sub(serviceMidnight).Nanoseconds()
-
-		if endNanos < 0 {
-			continue
+			api.serverErrorResponse(w, r, err)
+			return
 		}
-
-		stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForStopInWindow(ctx, gtfsdb.GetStopTimesForStopInWindowParams{
-			StopID:           stopCode,
-			WindowStartNanos: startNanos,
-			WindowEndNanos:   endNanos,
-		})
+	}
+	if params.BikesAllowed {
+		allActiveStopTimes, err = api.filterStopTimesByTrip(ctx, allActiveStopTimes, tripAllowsBikes)
 		if err != nil {
-			api.Logger.Warn("failed to query stop times in window",
-				slog.String("stopID", stopCode),
-				slog.Any("error", err))
-			continue
-		}
-
-		for _, st := range stopTimes {
-			if activeServiceIDSet[st.ServiceID] {
-				allActiveStopTimes = append(allActiveStopTimes, activeStopTime{
-					GetStopTimesForStopInWindowRow: st,
-					ServiceDate:                    serviceMidnight,
-				})
-			}
+			api.serverErrorResponse(w, r, err)
+			return
 		}
 	}
 
+	// Paging happens before the per-arrival realtime work so that only the
+	// requested page is built.
+	start, end, more := pageWindow(len(allActiveStopTimes), params.Offset, params.MaxCount)
+	allActiveStopTimes = allActiveStopTimes[start:end]
+	page := newPage(r, params.Offset, len(allActiveStopTimes), more)
+
 	if len(allActiveStopTimes) == 0 {
-		response := models.NewArrivalsAndDepartureResponse(arrivals, references, []string{}, []string{}, stopID, api.Clock)
-		api.sendResponse(w, r, response)
+		nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, stopAgencyID, params)
+		response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, stopSituationIDs, stopID, api.Clock)
+		api.sendResponse(w, r, models.WithPage(response, page))
 		return
 	}
 
@@ -203,6 +178,8 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 	// Add the current stop
 	stopIDSet[stop.ID] = true
 
+	addedTripRefs := make(map[string]*models.Trip)
+
 	batchRouteIDs := make(map[string]bool)
 	batchTripIDs := make(map[string]bool)
 
@@ -211,7 +188,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		if st.RouteID != "" {
 			batchRouteIDs[st.RouteID] = true
 		}
-		if st.TripID != "" {
+		if st.TripID != "" && ast.Added == nil {
 			batchTripIDs[st.TripID] = true
 		}
 	}
@@ -265,6 +242,14 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			continue
 		}
 
+		if ast.Added != nil {
+			rCopy := route
+			routeIDSet[route.ID] = &rCopy
+			addedTripRefs[st.TripID] = addedTripReference(ast.Added, route)
+			arrivals = append(arrivals, *api.buildAddedTripArrival(ctx, ast, route, stopID, params.Time))
+			continue
+		}
+
 		trip, tripExists := tripsLookup[st.TripID]
 		if !tripExists {
 			api.Logger.Debug("skipping stop time: trip not found in batch fetch",
@@ -278,8 +263,8 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		tCopy := trip
 		tripIDSet[trip.ID] = &tCopy
 
-		scheduledArrivalTime := serviceMidnight.Add(time.Duration(st.ArrivalTime)).UnixMilli()
-		scheduledDepartureTime := serviceMidnight.Add(time.Duration(st.DepartureTime)).UnixMilli()
+		scheduledArrivalTime := serviceMidnight.Add(utils.StopTimeDuration(st.ArrivalTime)).UnixMilli()
+		scheduledDepartureTime := serviceMidnight.Add(utils.StopTimeDuration(st.DepartureTime)).UnixMilli()
 
 		var (
 			predictedArrivalTime   = scheduledArrivalTime
@@ -288,48 +273,24 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			vehicleID              string
 			tripStatus             *models.TripStatusForTripDetails
 			distanceFromStop       = 0.0
-			numberOfStopsAway      = 0
 		)
 
-		// Get real-time updates from GTFS-RT
+		// Get real-time updates from GTFS-RT. Trip update delays propagate from
+		// the trip's earlier stops to this one.
+		scheduledArrival := serviceMidnight.Add(utils.StopTimeDuration(st.ArrivalTime))
+		predictedArrival, predictedDeparture := api.getPredictedTimes(ctx, st.TripID, stopCode, st.StopSequence,
+			scheduledArrival, serviceMidnight.Add(utils.StopTimeDuration(st.DepartureTime)))
+		if predictedArrival != 0 && predictedDeparture != 0 {
+			predicted = true
+			predictedArrivalTime = predictedArrival
+			predictedDepartureTime = predictedDeparture
+		}
+
 		vehicle := api.GtfsManager.GetVehicleForTrip(ctx, st.TripID)
 		if vehicle != nil && vehicle.Trip != nil {
 			vehicleID = vehicle.ID.ID
-
-			// Fetch the Trip Update separately
-			tripUpdate, _ := api.GtfsManager.GetTripUpdateByID(st.TripID)
-
-			// Use the tripUpdate for predictions
-			if tripUpdate != nil && len(tripUpdate.StopTimeUpdates) > 0 {
-				// Look for StopTimeUpdate that matches this stop
-				for _, stopTimeUpdate := range tripUpdate.StopTimeUpdates {
-					// Match by stop sequence or stop ID
-					if (stopTimeUpdate.StopSequence != nil && int64(*stopTimeUpdate.StopSequence) == st.StopSequence) ||
-						(stopTimeUpdate.StopID != nil && *stopTimeUpdate.StopID == stopCode) {
-
-						predicted = true
-
-						// Update predicted times from GTFS-RT
-						if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Time != nil {
-							predictedArrivalTime = stopTimeUpdate.Arrival.Time.Unix() * 1000
-						} else if stopTimeUpdate.Arrival != nil && stopTimeUpdate.Arrival.Delay != nil {
-							predictedArrivalTime = scheduledArrivalTime + (stopTimeUpdate.Arrival.Delay.Nanoseconds() / 1e6)
-						}
-
-						if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Time != nil {
-							predictedDepartureTime = stopTimeUpdate.Departure.Time.Unix() * 1000
-						} else if stopTimeUpdate.Departure != nil && stopTimeUpdate.Departure.Delay != nil {
-							predictedDepartureTime = scheduledDepartureTime + (stopTimeUpdate.Departure.Delay.Nanoseconds() / 1e6)
-						}
-						break
-					}
-				}
-			}
-
-			if !predicted && vehicle.Position != nil {
+			if !predicted && vehicle.Position != nil && !api.beyondPredictionHorizon(scheduledArrival) {
 				predicted = true
-				predictedArrivalTime = scheduledArrivalTime
-				predictedDepartureTime = scheduledDepartureTime
 			}
 		}
 
@@ -353,14 +314,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 				}
 
 				if vehicle.Position != nil {
-					distanceFromStop = api.getBlockDistanceToStop(ctx, st.TripID, stopCode, vehicle, params.Time)
-
-					numberOfStopsAwayPtr := api.getNumberOfStopsAway(ctx, st.TripID, int(st.StopSequence), vehicle, params.Time)
-					if numberOfStopsAwayPtr != nil {
-						numberOfStopsAway = *numberOfStopsAwayPtr
-					} else {
-						numberOfStopsAway = -1
-					}
+					distanceFromStop = api.getBlockDistanceToStop(ctx, st.TripID, st.StopSequence, vehicle, serviceMidnight)
 				}
 
 				// If there's an active trip that's different from the current trip, add it to references
@@ -369,7 +323,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 					if err == nil && activeTripID != st.TripID {
 						// Check cache for active trip
 						if _, exists := tripIDSet[activeTripID]; !exists {
-							activeTrip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, activeTripID)
+							activeTrip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, activeTripID)
 							if err != nil {
 								api.Logger.Debug("skipping active trip reference: trip not found",
 									slog.String("activeTripID", activeTripID),
@@ -377,7 +331,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 									slog.Any("error", err))
 							} else {
 								tripIDSet[activeTrip.ID] = &activeTrip
-								activeRoute, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, activeTrip.RouteID)
+								activeRoute, err := api.GtfsManager.GtfsDB.GetRoute(ctx, activeTrip.RouteID)
 								if err == nil {
 									routeIDSet[activeRoute.ID] = &activeRoute
 								} else {
@@ -391,12 +345,19 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			}
 		}
 
+		arrivalStatus := "default"
+		if api.tripCanceledOn(st.TripID, serviceMidnight) {
+			// A canceled run keeps its scheduled times but is not predicted.
+			arrivalStatus = "CANCELED"
+			predicted = false
+		}
+
 		if !predicted {
 			predictedArrivalTime = 0
 			predictedDepartureTime = 0
 		}
 
-		tripStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, st.TripID)
+		tripStopTimes, err := tripDataMemoFromContext(ctx).stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, st.TripID)
 		var totalStopsInTrip int
 		if err != nil {
 			api.Logger.Debug("failed to get stop times for trip",
@@ -407,17 +368,24 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			totalStopsInTrip = len(tripStopTimes)
 		}
 
+		numberOfStopsAway, stopsAwaySource := api.numberOfStopsAwayForArrival(ctx, st.TripID, st.StopSequence, vehicle, serviceMidnight, params.Time)
+
 		blockTripSequence := api.calculateBlockTripSequence(ctx, st.TripID, serviceMidnight)
 
 		lastUpdateTime := api.GtfsManager.GetVehicleLastUpdateTime(vehicle)
-		situationIDs := api.GetSituationIDsForTrip(r.Context(), st.TripID)
+		situationIDs := api.GetSituationIDsForTrip(ctx, st.TripID)
+		occupancyHistory := api.GtfsManager.GetOccupancyHistoryForTrip(ctx, st.TripID, serviceMidnight)
+		historicalOccupancy := occupancyHistory.StatusAt(stopCode)
+		predictedOccupancy := predictedOccupancyAtStop(occupancyHistory, vehicle, tripStatus, stopCode, numberOfStopsAway)
+
+		arrivalHeadsign := stopTimeHeadsign(st.StopHeadsign, st.TripHeadsign)
 
 		arrival := models.NewArrivalAndDeparture(
 			utils.FormCombinedID(route.AgencyID, route.ID),  // routeID
 			route.ShortName.String,                          // routeShortName
 			route.LongName.String,                           // routeLongName
 			utils.FormCombinedID(route.AgencyID, st.TripID), // tripID
-			st.TripHeadsign.String,                          // tripHeadsign
+			arrivalHeadsign,                                 // tripHeadsign
 			stopID,                                          // stopID
 			vehicleID,                                       // vehicleID
 			serviceDateMillis,                               // serviceDate
@@ -427,20 +395,27 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			predictedDepartureTime,                          // predictedDepartureTime
 			lastUpdateTime,                                  // lastUpdateTime
 			predicted,                                       // predicted
-			true,                                            // arrivalEnabled
-			true,                                            // departureEnabled
+			arrivalEnabled(st.DropOffType),                  // arrivalEnabled
+			departureEnabled(st.PickupType),                 // departureEnabled
 			int(st.StopSequence)-1,                          // stopSequence (Zero-based index)
 			totalStopsInTrip,                                // totalStopsInTrip
 			numberOfStopsAway,                               // numberOfStopsAway
 			blockTripSequence,                               // blockTripSequence
 			distanceFromStop,                                // distanceFromStop
-			"default",                                       // status
+			arrivalStatus,                                   // status
 			"",                                              // occupancyStatus
-			"",                                              // predictedOccupancy
-			"",                                              // historicalOccupancy
+			predictedOccupancy,                              // predictedOccupancy
+			historicalOccupancy,                             // historicalOccupancy
 			tripStatus,                                      // tripStatus
 			situationIDs,                                    // situationIDs
 		)
+		arrival.PredictedFrom = stopsAwaySource
+
+		// Only headway-based (non exact_times) service is reported as a frequency;
+		// exact_times trips behave like ordinary scheduled trips for riders.
+		if ast.Frequency != nil && ast.Frequency.ExactTimes == 0 {
+			arrival.Frequency = newFrequencyModel(*ast.Frequency, serviceMidnight)
+		}
 
 		arrivals = append(arrivals, *arrival)
 	}
@@ -454,7 +429,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			route = r
 			routeAgencyID = route.AgencyID
 		} else {
-			fetchedRoute, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, trip.RouteID)
+			fetchedRoute, err := api.GtfsManager.GtfsDB.GetRoute(ctx, trip.RouteID)
 			if err == nil {
 				route = &fetchedRoute
 				routeAgencyID = route.AgencyID
@@ -475,66 +450,22 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			utils.FormCombinedID(routeAgencyID, trip.BlockID.String), // Use route agency for block ID
 			utils.FormCombinedID(routeAgencyID, trip.ShapeID.String), // Use route agency for shape ID
 		)
+		tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+		tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
+		references.Trips = append(references.Trips, tripRef)
+	}
+	for _, tripRef := range addedTripRefs {
 		references.Trips = append(references.Trips, tripRef)
 	}
 
 	calc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
 
-	for stopID := range stopIDSet {
-		if ctx.Err() != nil {
-			return
-		}
-
-		stopData, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
-		if err != nil {
-			api.Logger.Debug("skipping stop reference: stop not found",
-				slog.String("stopID", stopID),
-				slog.Any("error", err))
-			continue
-		}
-
-		routesForThisStop, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStops(ctx, []string{stopID})
-		if err != nil {
-			api.Logger.Debug("failed to get routes for stop",
-				slog.String("stopID", stopID),
-				slog.Any("error", err))
-			continue
-		}
-		combinedRouteIDs := make([]string, len(routesForThisStop))
-		for i, route := range routesForThisStop {
-			// Use route.AgencyID instead of stopAgencyID
-			combinedRouteIDs[i] = utils.FormCombinedID(route.AgencyID, route.ID)
-
-			if _, exists := routeIDSet[route.ID]; !exists {
-				routeCopy := gtfsdb.Route{
-					ID:        route.ID,
-					AgencyID:  route.AgencyID,
-					ShortName: route.ShortName,
-					LongName:  route.LongName,
-					Desc:      route.Desc,
-					Type:      route.Type,
-					Url:       route.Url,
-					Color:     route.Color,
-					TextColor: route.TextColor,
-				}
-				routeIDSet[route.ID] = &routeCopy
-			}
-		}
-
-		stopRef := models.Stop{
-			ID:                 utils.FormCombinedID(stopAgencyID, stopData.ID),
-			Name:               stopData.Name.String,
-			Lat:                stopData.Lat,
-			Lon:                stopData.Lon,
-			Code:               stopData.Code.String,
-			Direction:          calc.CalculateStopDirection(ctx, stopData.ID, stopData.Direction),
-			LocationType:       int(stopData.LocationType.Int64),
-			WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stopData.WheelchairBoarding)),
-			RouteIDs:           combinedRouteIDs,
-			StaticRouteIDs:     combinedRouteIDs,
-		}
-		references.Stops = append(references.Stops, stopRef)
+	stopRefs, err := buildArrivalStopReferences(api, ctx, stopAgencyID, stopIDSet, routeIDSet, calc)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
 	}
+	references.Stops = append(references.Stops, stopRefs...)
 
 	for _, route := range routeIDSet {
 		routeRef := models.NewRoute(
@@ -546,7 +477,7 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 			models.RouteType(route.Type),
 			route.Url.String,
 			route.Color.String,
-			route.TextColor.String)
+			route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 		references.Routes = append(references.Routes, routeRef)
 
@@ -565,18 +496,235 @@ func (api *RestAPI) arrivalsAndDeparturesForStopHandler(w http.ResponseWriter, r
 		}
 	}
 
-	nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, stopAgencyID)
-	response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, []string{}, stopID, api.Clock)
-	api.sendResponse(w, r, response)
+	nearbyStopIDs := getNearbyStopIDs(api, ctx, stop.Lat, stop.Lon, stopCode, stopAgencyID, params)
+	response := models.NewArrivalsAndDepartureResponse(arrivals, references, nearbyStopIDs, stopSituationIDs, stopID, api.Clock)
+	api.sendResponse(w, r, models.WithPage(response, page))
+}
+
+// buildArrivalStopReferences builds the stop references for the stops in
+// stopIDSet with two batched queries, one for the stops and one for their
+// routes, and adds those routes to routeIDSet. Stops missing from the
+// database are left out.
+func buildArrivalStopReferences(api *RestAPI, ctx context.Context, stopAgencyID string, stopIDSet map[string]bool, routeIDSet map[string]*gtfsdb.Route, calc *GTFS.AdvancedDirectionCalculator) ([]models.Stop, error) {
+	stopIDs := make([]string, 0, len(stopIDSet))
+	for stopID := range stopIDSet {
+		stopIDs = append(stopIDs, stopID)
+	}
+	if len(stopIDs) == 0 {
+		return nil, nil
+	}
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	routeRows, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStops(ctx, stopIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	routeIDsByStop := make(map[string][]string)
+	for _, route := range routeRows {
+		routeIDsByStop[route.StopID] = append(routeIDsByStop[route.StopID], utils.FormCombinedID(route.AgencyID, route.ID))
+
+		if _, exists := routeIDSet[route.ID]; !exists {
+			routeIDSet[route.ID] = &gtfsdb.Route{
+				ID:        route.ID,
+				AgencyID:  route.AgencyID,
+				ShortName: route.ShortName,
+				LongName:  route.LongName,
+				Desc:      route.Desc,
+				Type:      route.Type,
+				Url:       route.Url,
+				Color:     route.Color,
+				TextColor: route.TextColor,
+			}
+		}
+	}
+
+	stopRefs := make([]models.Stop, 0, len(stops))
+	for _, stopData := range stops {
+		combinedRouteIDs := routeIDsByStop[stopData.ID]
+		if combinedRouteIDs == nil {
+			combinedRouteIDs = []string{}
+		}
+		stopRefs = append(stopRefs, models.Stop{
+			ID:                 utils.FormCombinedID(stopAgencyID, stopData.ID),
+			Name:               stopData.Name.String,
+			Lat:                stopData.Lat,
+			Lon:                stopData.Lon,
+			Code:               stopData.Code.String,
+			Direction:          calc.CalculateStopDirection(ctx, stopData.ID, stopData.Direction),
+			LocationType:       int(stopData.LocationType.Int64),
+			WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stopData.WheelchairBoarding)),
+			RouteIDs:           combinedRouteIDs,
+			StaticRouteIDs:     combinedRouteIDs,
+		})
+	}
+	return stopRefs, nil
 }
 
-func getNearbyStopIDs(api *RestAPI, ctx context.Context, lat, lon float64, stopID, agencyID string) []string {
-	nearbyStops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, 10000, 100, 100, "", 5, false, []int{}, api.Clock.Now())
-	var nearbyStopIDs []string
+// getNearbyStopIDs lists the stops closest to lat, lon other than stopID,
+// nearest first, within the radius, count and route types of params.
+func getNearbyStopIDs(api *RestAPI, ctx context.Context, lat, lon float64, stopID, agencyID string, params ArrivalsStopParams) []string {
+	nearbyStopIDs := []string{}
+	if params.NearbyStopsMaxCount == 0 {
+		return nearbyStopIDs
+	}
+	// One extra stop is fetched because the stop itself is usually the nearest.
+	nearbyStops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, params.NearbyStopsRadius, 0, 0, "", params.NearbyStopsMaxCount+1, false, params.NearbyStopsRouteTypes, api.Clock.Now())
 	for _, s := range nearbyStops {
-		if s.ID != stopID {
-			nearbyStopIDs = append(nearbyStopIDs, utils.FormCombinedID(agencyID, s.ID))
+		if s.ID == stopID {
+			continue
+		}
+		if len(nearbyStopIDs) == params.NearbyStopsMaxCount {
+			break
 		}
+		nearbyStopIDs = append(nearbyStopIDs, utils.FormCombinedID(agencyID, s.ID))
 	}
 	return nearbyStopIDs
 }
+
+// activeStopTime is a scheduled visit to a stop on a specific service date. For
+// frequency-based trips Frequency is set and the times are those of one expanded run.
+type activeStopTime struct {
+	gtfsdb.GetStopTimesForStopInWindowRow
+	ServiceDate time.Time
+	Frequency   *gtfsdb.Frequency
+	// Added is the realtime update of an ADDED trip the visit was synthesized
+	// from; the trip is not in the static schedule.
+	Added *gtfs.Trip
+}
+
+// collectActiveStopTimes returns the visits to stopCode that are scheduled between
+// windowStart and windowEnd, ordered by scheduled arrival. Every service day the
+// feed's trips can reach the window from is searched, so trips still running
+// past midnight on the previous day's service are found. Service dates are
+// calendar dates in the timezone of the agency running each trip; when the
+// feed's agencies are in several zones, each zone's dates are searched for the
+// trips of its agencies.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) collectActiveStopTimes(ctx context.Context, stopCode string, windowStart, windowEnd time.Time) ([]activeStopTime, error) {
+	var allActiveStopTimes []activeStopTime
+
+	// Frequency-based trips only store a template schedule, so they are expanded
+	// separately below instead of being matched against the window directly.
+	frequencyStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetFrequencyStopTimesForStop(ctx, stopCode)
+	if err != nil {
+		return nil, err
+	}
+	frequencyTripIDs := make(map[string]bool)
+	for _, fst := range frequencyStopTimes {
+		frequencyTripIDs[fst.TripID] = true
+	}
+	var tripStarts map[string]int64
+	if len(frequencyTripIDs) > 0 {
+		ids := make([]string, 0, len(frequencyTripIDs))
+		for id := range frequencyTripIDs {
+			ids = append(ids, id)
+		}
+		tripStarts, err = api.tripStartTimes(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	locations, err := api.feedLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, loc := range locations {
+		inZone := func(routeID string) bool {
+			return len(locations) == 1 || api.routeLocation(ctx, routeID).String() == loc.String()
+		}
+		for _, serviceMidnight := range utils.ServiceDatesBetween(windowStart.In(loc), windowEnd, api.GtfsManager.MaxServiceTime()) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			serviceDateStr := serviceMidnight.Format("20060102")
+
+			activeServiceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, serviceDateStr)
+			if err != nil {
+				api.Logger.Warn("failed to query active service IDs",
+					slog.String("date", serviceDateStr),
+					slog.Any("error", err))
+				continue
+			}
+			if len(activeServiceIDs) == 0 {
+				continue
+			}
+
+			activeServiceIDSet := make(map[string]bool, len(activeServiceIDs))
+			for _, sid := range activeServiceIDs {
+				activeServiceIDSet[sid] = true
+			}
+
+			startSeconds := utils.ServiceTimeAt(serviceMidnight, windowStart).Seconds()
+			endSeconds := utils.ServiceTimeAt(serviceMidnight, windowEnd).Seconds()
+
+			if endSeconds < 0 {
+				continue
+			}
+
+			stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForStopInWindow(ctx, gtfsdb.GetStopTimesForStopInWindowParams{
+				StopID:      stopCode,
+				WindowStart: startSeconds,
+				WindowEnd:   endSeconds,
+			})
+			if err != nil {
+				api.Logger.Warn("failed to query stop times in window",
+					slog.String("stopID", stopCode),
+					slog.Any("error", err))
+				continue
+			}
+
+			for _, st := range stopTimes {
+				if activeServiceIDSet[st.ServiceID] && !frequencyTripIDs[st.TripID] && inZone(st.RouteID) {
+					allActiveStopTimes = append(allActiveStopTimes, activeStopTime{
+						GetStopTimesForStopInWindowRow: st,
+						ServiceDate:                    serviceMidnight,
+					})
+				}
+			}
+
+			for _, fst := range frequencyStopTimes {
+				if !activeServiceIDSet[fst.ServiceID] || !inZone(fst.RouteID) {
+					continue
+				}
+				tripStart, ok := tripStarts[fst.TripID]
+				if !ok {
+					continue
+				}
+				frequency := &gtfsdb.Frequency{
+					TripID:      fst.TripID,
+					StartTime:   fst.StartTime,
+					EndTime:     fst.EndTime,
+					HeadwaySecs: fst.HeadwaySecs,
+					ExactTimes:  fst.ExactTimes,
+				}
+				for _, st := range expandFrequencyStopTime(fst, tripStart, startSeconds, endSeconds) {
+					allActiveStopTimes = append(allActiveStopTimes, activeStopTime{
+						GetStopTimesForStopInWindowRow: st,
+						ServiceDate:                    serviceMidnight,
+						Frequency:                      frequency,
+					})
+				}
+			}
+		}
+	}
+
+	sortActiveStopTimes(allActiveStopTimes)
+	return allActiveStopTimes, nil
+}
+
+// sortActiveStopTimes orders visits by arrival time, keeping the order of
+// visits that arrive together.
+func sortActiveStopTimes(stopTimes []activeStopTime) {
+	sort.SliceStable(stopTimes, func(i, j int) bool {
+		ti := stopTimes[i].ServiceDate.Add(utils.StopTimeDuration(stopTimes[i].ArrivalTime))
+		tj := stopTimes[j].ServiceDate.Add(utils.StopTimeDuration(stopTimes[j].ArrivalTime))
+		return ti.Before(tj)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/clock"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
 
@@ -528,8 +531,8 @@ func TestArrivalsAndDeparturesForStopHandler_MultiAgency_Regression(t *testing.T
 		TripID:        tripB_ID,
 		StopID:        stopID,
 		StopSequence:  1,
-		ArrivalTime:   28800 * 1e9, // 08:00:00 converted to nanoseconds
-		DepartureTime: 29100 * 1e9, // 08:05:00 converted to nanoseconds
+		ArrivalTime:   28800, // 08:00:00
+		DepartureTime: 29100, // 08:05:00
 	})
 	require.NoError(t, err)
 
@@ -629,3 +632,425 @@ func TestArrivalsAndDeparturesReturnsResultsNearMidnight(t *testing.T) {
 
 	assert.True(t, foundResults, "Should find at least one stop with early morning arrivals near midnight boundary")
 }
+
+func TestParseArrivalsAndDeparturesParams_NearbyStops(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	params, errs := api.parseArrivalsAndDeparturesParams(httptest.NewRequest("GET", "/test", nil))
+	assert.Nil(t, errs)
+	assert.Equal(t, float64(defaultNearbyStopsRadius), params.NearbyStopsRadius)
+	assert.Equal(t, defaultNearbyStopsMaxCount, params.NearbyStopsMaxCount)
+	assert.Nil(t, params.NearbyStopsRouteTypes)
+
+	api.Config.NearbyStopsRadius = 800
+	api.Config.NearbyStopsMaxCount = 3
+	params, errs = api.parseArrivalsAndDeparturesParams(httptest.NewRequest("GET", "/test", nil))
+	assert.Nil(t, errs)
+	assert.Equal(t, 800.0, params.NearbyStopsRadius)
+	assert.Equal(t, 3, params.NearbyStopsMaxCount)
+
+	req := httptest.NewRequest("GET", "/test?nearbyStopsRadius=250&nearbyStopsMaxCount=0&nearbyStopsRouteType=3,0", nil)
+	params, errs = api.parseArrivalsAndDeparturesParams(req)
+	assert.Nil(t, errs)
+	assert.Equal(t, 250.0, params.NearbyStopsRadius)
+	assert.Equal(t, 0, params.NearbyStopsMaxCount)
+	assert.Equal(t, []int{3, 0}, params.NearbyStopsRouteTypes)
+
+	for _, query := range []string{
+		"nearbyStopsRadius=abc",
+		"nearbyStopsRadius=0",
+		"nearbyStopsRadius=20000",
+		"nearbyStopsMaxCount=-1",
+		"nearbyStopsMaxCount=251",
+		"nearbyStopsRouteType=boat",
+	} {
+		_, errs := api.parseArrivalsAndDeparturesParams(httptest.NewRequest("GET", "/test?"+query, nil))
+		assert.Len(t, errs, 1, query)
+	}
+}
+
+func TestArrivalsAndDeparturesForStopHandlerNearbyStops(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 13, 11, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+	stops := api.GtfsManager.GetStops()
+	require.NotEmpty(t, stops)
+	stopID := utils.FormCombinedID(agency.Id, stops[0].Id)
+
+	nearbyStopIDs := func(query string) []interface{} {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/arrivals-and-departures-for-stop/"+stopID+".json?key=TEST"+query)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		ids, ok := entry["nearbyStopIds"].([]interface{})
+		require.True(t, ok)
+		return ids
+	}
+
+	ids := nearbyStopIDs("")
+	assert.Len(t, ids, defaultNearbyStopsMaxCount, "the stop itself does not use up one of the nearby stops")
+	assert.NotContains(t, ids, stopID)
+
+	assert.Len(t, nearbyStopIDs("&nearbyStopsMaxCount=2"), 2)
+	assert.Empty(t, nearbyStopIDs("&nearbyStopsMaxCount=0"))
+	assert.Empty(t, nearbyStopIDs("&nearbyStopsRouteType=4"), "no RABA route is a ferry")
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/"+stopID+".json?key=TEST&nearbyStopsRadius=-5")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestBuildArrivalStopReferences(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	queries := api.GtfsManager.GtfsDB.Queries
+	agency := api.GtfsManager.GetAgencies()[0]
+	stops := api.GtfsManager.GetStops()
+	require.GreaterOrEqual(t, len(stops), 2)
+
+	stopIDSet := map[string]bool{stops[0].Id: true, stops[1].Id: true, "no-such-stop": true}
+	routeIDSet := make(map[string]*gtfsdb.Route)
+	calc := GTFS.NewAdvancedDirectionCalculator(queries)
+
+	refs, err := buildArrivalStopReferences(api, ctx, agency.Id, stopIDSet, routeIDSet, calc)
+	require.NoError(t, err)
+	require.Len(t, refs, 2, "unknown stops are left out")
+
+	for _, ref := range refs {
+		_, stopID, err := utils.ExtractAgencyIDAndCodeID(ref.ID)
+		require.NoError(t, err)
+
+		routes, err := queries.GetRoutesForStops(ctx, []string{stopID})
+		require.NoError(t, err)
+		expected := make([]string, len(routes))
+		for i, route := range routes {
+			expected[i] = utils.FormCombinedID(route.AgencyID, route.ID)
+			assert.Contains(t, routeIDSet, route.ID)
+		}
+		assert.ElementsMatch(t, expected, ref.RouteIDs, "stop %s", stopID)
+	}
+}
+
+func TestArrivalsAndDeparturesForStopHandlerRouteTypes(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 13, 11, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+
+	agency := api.GtfsManager.GetAgencies()[0]
+
+	arrivalCount := func(stopID, filter string) int {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/arrivals-and-departures-for-stop/"+stopID+".json?key=TEST&minutesBefore=15&minutesAfter=240"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		arrivals, ok := entry["arrivalsAndDepartures"].([]interface{})
+		require.True(t, ok)
+		return len(arrivals)
+	}
+
+	var stopID string
+	var all int
+	for _, stop := range api.GtfsManager.GetStops() {
+		stopID = utils.FormCombinedID(agency.Id, stop.Id)
+		if all = arrivalCount(stopID, ""); all > 0 {
+			break
+		}
+	}
+	require.NotZero(t, all, "expected a stop with arrivals")
+
+	assert.Equal(t, all, arrivalCount(stopID, "&routeTypes=bus"))
+	assert.Zero(t, arrivalCount(stopID, "&routeTypes=ferry"))
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/"+stopID+".json?key=TEST&routeTypes=boat")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// createPickupDropOffTestTrip adds a trip, running every day of 2025, that
+// riders can only board at its first stop (2000, at 10:00) and only leave at
+// its last (1030, at 10:20).
+func createPickupDropOffTestTrip(t *testing.T, api *RestAPI) {
+	t.Helper()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+
+	_, err := client.Queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:        "PICKUP_TEST",
+		RouteID:   "24",
+		ServiceID: "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM stop_times WHERE trip_id = 'PICKUP_TEST'")
+		assert.NoError(t, err)
+		_, err = client.DB.ExecContext(context.Background(), "DELETE FROM trips WHERE id = 'PICKUP_TEST'")
+		assert.NoError(t, err)
+	})
+
+	for i, st := range []struct {
+		stopID      string
+		at          time.Duration
+		pickupType  int64
+		dropOffType int64
+	}{{"2000", 10 * time.Hour, 0, 1}, {"1030", 10*time.Hour + 20*time.Minute, 1, 0}} {
+		_, err = client.Queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+			TripID:        "PICKUP_TEST",
+			StopID:        st.stopID,
+			StopSequence:  int64(i + 1),
+			ArrivalTime:   utils.StopTimeSeconds(st.at),
+			DepartureTime: utils.StopTimeSeconds(st.at),
+			PickupType:    sql.NullInt64{Int64: st.pickupType, Valid: true},
+			DropOffType:   sql.NullInt64{Int64: st.dropOffType, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestArrivalsAndDeparturesForStopHandler_PickupAndDropOffTypes(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	createPickupDropOffTestTrip(t, api)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 9, 55, 0, 0, loc).UnixMilli()
+
+	tests := []struct {
+		stopID           string
+		arrivalEnabled   bool
+		departureEnabled bool
+	}{
+		{stopID: "25_2000", arrivalEnabled: false, departureEnabled: true},
+		{stopID: "25_1030", arrivalEnabled: true, departureEnabled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stopID, func(t *testing.T) {
+			resp, model := serveApiAndRetrieveEndpoint(t, api,
+				"/api/where/arrivals-and-departures-for-stop/"+tt.stopID+".json?key=TEST&minutesAfter=60&time="+strconv.FormatInt(at, 10))
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+			var found map[string]interface{}
+			for _, ad := range entry["arrivalsAndDepartures"].([]interface{}) {
+				if arrival := ad.(map[string]interface{}); arrival["tripId"] == "25_PICKUP_TEST" {
+					found = arrival
+				}
+			}
+			require.NotNil(t, found, "the test trip calls at the stop")
+			assert.Equal(t, tt.arrivalEnabled, found["arrivalEnabled"])
+			assert.Equal(t, tt.departureEnabled, found["departureEnabled"])
+		})
+	}
+}
+
+// createNewYorkTestTrip adds an agency in New York, a route of it and a trip,
+// running every day of 2025, that calls at RABA's stop 2000 at 01:00 New York
+// time. RABA's own agency is in Los Angeles.
+func createNewYorkTestTrip(t *testing.T, api *RestAPI) {
+	t.Helper()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+
+	_, err := client.Queries.CreateAgency(ctx, gtfsdb.CreateAgencyParams{
+		ID: "NYC", Name: "New York Test Agency", Url: "https://example.com", Timezone: "America/New_York",
+	})
+	require.NoError(t, err)
+	_, err = client.Queries.CreateRoute(ctx, gtfsdb.CreateRouteParams{ID: "NYC_ROUTE", AgencyID: "NYC", Type: 3})
+	require.NoError(t, err)
+	_, err = client.Queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:        "NYC_TEST",
+		RouteID:   "NYC_ROUTE",
+		ServiceID: "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+	})
+	require.NoError(t, err)
+	_, err = client.Queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+		TripID:        "NYC_TEST",
+		StopID:        "2000",
+		StopSequence:  1,
+		ArrivalTime:   utils.StopTimeSeconds(time.Hour),
+		DepartureTime: utils.StopTimeSeconds(time.Hour),
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for _, stmt := range []string{
+			"DELETE FROM stop_times WHERE trip_id = 'NYC_TEST'",
+			"DELETE FROM trips WHERE id = 'NYC_TEST'",
+			"DELETE FROM routes WHERE id = 'NYC_ROUTE'",
+			"DELETE FROM agencies WHERE id = 'NYC'",
+		} {
+			_, err := client.DB.ExecContext(context.Background(), stmt)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestArrivalsAndDeparturesForStopHandler_TripAgencyTimezone(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	createNewYorkTestTrip(t, api)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, newYork)
+	arrival := time.Date(2025, 6, 13, 1, 0, 0, 0, newYork)
+
+	// 00:50 in New York is still the evening of June 12 in Los Angeles, the
+	// timezone of the stop's agency.
+	at := arrival.Add(-10 * time.Minute).UnixMilli()
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/25_2000.json?key=TEST&minutesAfter=30&time="+strconv.FormatInt(at, 10))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	var found map[string]interface{}
+	for _, ad := range entry["arrivalsAndDepartures"].([]interface{}) {
+		if a := ad.(map[string]interface{}); a["tripId"] == "NYC_NYC_TEST" {
+			found = a
+		}
+	}
+	require.NotNil(t, found, "the trip is found on its own agency's service date")
+	assert.Equal(t, float64(serviceDate.UnixMilli()), found["serviceDate"])
+	assert.Equal(t, float64(arrival.UnixMilli()), found["scheduledArrivalTime"])
+}
+
+func TestArrivalsAndDeparturesForStopHandlerWheelchairAccessible(t *testing.T) {
+	// Wednesday 17:00 in Redding; route 15 has accessible trips at stop 1505
+	// just after 16:00 and others at 17:25.
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 4, 17, 0, 0, 0, loc)))
+	defer api.Shutdown()
+
+	arrivals := func(filter string) ([]interface{}, map[string]string) {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST&minutesBefore=60&minutesAfter=30"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		data := model.Data.(map[string]interface{})
+		accessibility := map[string]string{}
+		for _, trip := range data["references"].(map[string]interface{})["trips"].([]interface{}) {
+			trip := trip.(map[string]interface{})
+			accessibility[trip["id"].(string)] = trip["wheelchairAccessible"].(string)
+		}
+		return data["entry"].(map[string]interface{})["arrivalsAndDepartures"].([]interface{}), accessibility
+	}
+
+	all, _ := arrivals("")
+	accessible, accessibility := arrivals("&wheelchairAccessible=true")
+	require.NotEmpty(t, accessible)
+	assert.Less(t, len(accessible), len(all))
+	for _, arrival := range accessible {
+		tripID := arrival.(map[string]interface{})["tripId"].(string)
+		assert.Equal(t, models.Accessible, accessibility[tripID], tripID)
+	}
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST&wheelchairAccessible=maybe")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestArrivalsAndDeparturesForStopHandlerBikesAllowed(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 4, 17, 0, 0, 0, loc)))
+	defer api.Shutdown()
+
+	arrivals := func(filter string) []interface{} {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST&minutesBefore=60&minutesAfter=30"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		return entry["arrivalsAndDepartures"].([]interface{})
+	}
+
+	require.Greater(t, len(arrivals("")), 1)
+	assert.Empty(t, arrivals("&bikesAllowed=true"))
+
+	allowBikesOnTrip(t, api, "Route15-Southbound-MonSat-4")
+	withBikes := arrivals("&bikesAllowed=true")
+	require.Len(t, withBikes, 1)
+	assert.Equal(t, "25_Route15-Southbound-MonSat-4", withBikes[0].(map[string]interface{})["tripId"])
+}
+
+func TestArrivalsAndDeparturesForStopHandlerScenario(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 4, 16, 0, 0, 0, loc)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	require.NoError(t, GTFS.NewScenario().At(now).
+		WithTrip("Route15-Southbound-MonSat-4").Delayed(2*time.Minute).
+		WithVehicleAtStop("bus-1", "1502").
+		Apply(context.Background(), api.GtfsManager))
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST&minutesBefore=5&minutesAfter=30")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+
+	var arrival map[string]interface{}
+	for _, a := range entry["arrivalsAndDepartures"].([]interface{}) {
+		if a.(map[string]interface{})["tripId"] == "25_Route15-Southbound-MonSat-4" {
+			arrival = a.(map[string]interface{})
+		}
+	}
+	require.NotNil(t, arrival)
+	assert.Equal(t, true, arrival["predicted"])
+	assert.Equal(t, "bus-1", arrival["vehicleId"])
+	scheduled := time.Date(2025, 6, 4, 16, 7, 0, 0, loc).UnixMilli()
+	assert.Equal(t, float64(scheduled), arrival["scheduledArrivalTime"])
+	assert.Equal(t, float64(scheduled+(2*time.Minute).Milliseconds()), arrival["predictedArrivalTime"])
+}
+
+func TestArrivalsAndDeparturesForStopHandlerPredictionHorizon(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 4, 16, 0, 0, 0, loc)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	const endpoint = "/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST&minutesBefore=0&minutesAfter=120"
+	arrivals := func() []interface{} {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return model.Data.(map[string]interface{})["entry"].(map[string]interface{})["arrivalsAndDepartures"].([]interface{})
+	}
+
+	// Every trip runs a minute late.
+	delay := time.Minute
+	for _, arrival := range arrivals() {
+		tripID := arrival.(map[string]interface{})["tripId"].(string)
+		api.GtfsManager.MockAddTripUpdate(strings.TrimPrefix(tripID, "25_"), &delay, nil)
+	}
+	api.Config.PredictionHorizon = 45 * time.Minute
+
+	horizon := now.Add(45 * time.Minute).UnixMilli()
+	var within, beyond int
+	for _, arrival := range arrivals() {
+		arrival := arrival.(map[string]interface{})
+		scheduled := int64(arrival["scheduledArrivalTime"].(float64))
+		if scheduled+delay.Milliseconds() <= horizon {
+			within++
+			assert.True(t, arrival["predicted"].(bool), arrival["tripId"])
+			assert.Equal(t, float64(scheduled+delay.Milliseconds()), arrival["predictedArrivalTime"])
+		} else {
+			beyond++
+			assert.False(t, arrival["predicted"].(bool), arrival["tripId"])
+			assert.Equal(t, float64(0), arrival["predictedArrivalTime"], "arrivals past the horizon keep their scheduled times only")
+		}
+	}
+	assert.Positive(t, within)
+	assert.Positive(t, beyond)
+}
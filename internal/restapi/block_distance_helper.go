@@ -2,98 +2,166 @@ package restapi
 
 import (
 	"context"
-	"math"
-	"sort"
+	"database/sql"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
 )
 
-// IMPORTANT: Caller must hold manager.RLock() before calling this method.
-func (api *RestAPI) getBlockDistanceToStop(ctx context.Context, targetTripID, targetStopID string, vehicle *gtfs.Vehicle, serviceDate time.Time) float64 {
-	if vehicle == nil || vehicle.Position == nil || vehicle.Trip == nil {
-		return 0
-	}
+// blockTripStops is one trip of a block with its stop times and the distances
+// block_trip_entry holds for it.
+type blockTripStops struct {
+	tripID        string
+	serviceID     string
+	stopTimes     []gtfsdb.StopTime
+	shapeLength   sql.NullFloat64
+	blockDistance sql.NullFloat64
+}
 
-	blockID, err := api.GtfsManager.GtfsDB.Queries.GetBlockIDByTripID(ctx, targetTripID)
-	if err != nil || !blockID.Valid || blockID.String == "" {
-		// Fallback to single trip logic if no block
-		if vehicle.Trip.ID.ID == targetTripID {
-			targetDist := api.getStopDistanceAlongShape(ctx, targetTripID, targetStopID)
-			vehicleDist := api.getVehicleDistanceAlongShapeContextual(ctx, targetTripID, vehicle)
-			return targetDist - vehicleDist
+// groupBlockStopTimes splits the rows of GetBlockStopTimesWithShapeLengths,
+// which come trip by trip, into the block's trips.
+func groupBlockStopTimes(rows []gtfsdb.GetBlockStopTimesWithShapeLengthsRow) []blockTripStops {
+	var trips []blockTripStops
+	for _, row := range rows {
+		if len(trips) == 0 || trips[len(trips)-1].tripID != row.TripID {
+			trips = append(trips, blockTripStops{
+				tripID:        row.TripID,
+				serviceID:     row.ServiceID,
+				shapeLength:   row.ShapeLength,
+				blockDistance: row.BlockDistance,
+			})
 		}
-		return 0
+		trip := &trips[len(trips)-1]
+		trip.stopTimes = append(trip.stopTimes, gtfsdb.StopTime{
+			TripID:             row.TripID,
+			ArrivalTime:        row.ArrivalTime,
+			DepartureTime:      row.DepartureTime,
+			StopID:             row.StopID,
+			StopSequence:       row.StopSequence,
+			StopHeadsign:       row.StopHeadsign,
+			PickupType:         row.PickupType,
+			DropOffType:        row.DropOffType,
+			ShapeDistTraveled:  row.ShapeDistTraveled,
+			Timepoint:          row.Timepoint,
+			DistanceAlongShape: row.DistanceAlongShape,
+		})
 	}
+	return trips
+}
 
-	blockTrips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockID(ctx, blockID)
-	if err != nil {
-		return 0
+// distanceBetweenBlockTrips returns the total shape length of the trips after
+// earlier and before later, read from the distances precomputed at import.
+// It returns false when they are missing, as they are in a database migrated
+// from before they existed that has not been re-imported since.
+func distanceBetweenBlockTrips(trips []blockTripStops, earlier, later int) (float64, bool) {
+	first, last := trips[earlier], trips[later]
+	sameService := true
+	for _, trip := range trips[earlier : later+1] {
+		if trip.serviceID != first.serviceID {
+			sameService = false
+			break
+		}
 	}
-
-	type TripInfo struct {
-		TripID        string
-		TotalDistance float64
-		StartTime     int
+	// Block distances accumulate per service ID, so they can only be
+	// subtracted when every trip in between shares one.
+	if sameService && first.blockDistance.Valid && last.blockDistance.Valid && first.shapeLength.Valid {
+		return last.blockDistance.Float64 - first.blockDistance.Float64 - first.shapeLength.Float64, true
 	}
 
-	activeTrips := []TripInfo{}
-	for _, blockTrip := range blockTrips {
-		isActive, err := api.GtfsManager.IsServiceActiveOnDate(ctx, blockTrip.ServiceID, serviceDate)
-		if err != nil || isActive == 0 {
-			continue
-		}
-
-		stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, blockTrip.ID)
-		if err != nil || len(stopTimes) == 0 {
-			continue
-		}
-
-		startTime := math.MaxInt
-		for _, st := range stopTimes {
-			if st.DepartureTime > 0 && int(st.DepartureTime) < startTime {
-				startTime = int(st.DepartureTime)
-			}
+	distance := 0.0
+	for _, trip := range trips[earlier+1 : later] {
+		if !trip.shapeLength.Valid {
+			return 0, false
 		}
+		distance += trip.shapeLength.Float64
+	}
+	return distance, true
+}
 
-		shapeRows, _ := api.GtfsManager.GtfsDB.Queries.GetShapePointsByTripID(ctx, blockTrip.ID)
-		totalDist := 0.0
-		if len(shapeRows) > 1 {
-			shapePoints := shapeRowsToPoints(shapeRows)
-			totalDist = preCalculateCumulativeDistances(shapePoints)[len(shapePoints)-1]
-		}
+// getBlockDistanceToStop returns how far, in meters along the trips' shapes,
+// the vehicle is from the stop time of the target trip with the given stop
+// sequence: positive while the stop lies ahead of the vehicle and negative once
+// the vehicle has passed it. When the vehicle is serving another trip of the
+// target trip's block, the shapes of every trip between the two, in block order
+// on the service date, count in full; their lengths are read with the block's
+// stop times in one query. Returns 0 when either end cannot be placed.
+//
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) getBlockDistanceToStop(ctx context.Context, targetTripID string, targetStopSequence int64, vehicle *gtfs.Vehicle, serviceDate time.Time) float64 {
+	vehicleTripID := GetVehicleActiveTripID(vehicle)
+	if vehicleTripID == "" || vehicle.Position == nil {
+		return 0
+	}
 
-		activeTrips = append(activeTrips, TripInfo{
-			TripID:        blockTrip.ID,
-			TotalDistance: totalDist,
-			StartTime:     startTime,
-		})
+	target := api.tripShapePlacement(ctx, targetTripID)
+	if target == nil {
+		return 0
+	}
+	targetDist, ok := target.stopDistance(targetStopSequence)
+	if !ok {
+		return 0
 	}
 
-	sort.Slice(activeTrips, func(i, j int) bool {
-		return activeTrips[i].StartTime < activeTrips[j].StartTime
-	})
+	if vehicleTripID == targetTripID {
+		return targetDist - target.vehicleDistance(vehicle)
+	}
 
-	cumulativeDist := 0.0
-	vehicleBlockDist := -1.0
-	targetBlockDist := -1.0
+	queries := api.GtfsManager.GtfsDB.Queries
+	memo := tripDataMemoFromContext(ctx)
+	trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, targetTripID)
+	if err != nil || !trip.BlockID.Valid || trip.BlockID.String == "" {
+		return 0
+	}
+	serviceIDs, err := memo.activeServiceIDs(ctx, queries, serviceDate)
+	if err != nil || len(serviceIDs) == 0 {
+		return 0
+	}
+	blockTrips, err := memo.blockStopTimes(ctx, queries, trip.BlockID, serviceIDs)
+	if err != nil {
+		return 0
+	}
 
-	for _, trip := range activeTrips {
-		if trip.TripID == vehicle.Trip.ID.ID {
-			vehicleDist := api.getVehicleDistanceAlongShapeContextual(ctx, trip.TripID, vehicle)
-			vehicleBlockDist = cumulativeDist + vehicleDist
-		}
-		if trip.TripID == targetTripID {
-			targetDist := api.getStopDistanceAlongShape(ctx, trip.TripID, targetStopID)
-			targetBlockDist = cumulativeDist + targetDist
+	targetIndex, vehicleIndex := -1, -1
+	for i, blockTrip := range blockTrips {
+		switch blockTrip.tripID {
+		case targetTripID:
+			targetIndex = i
+		case vehicleTripID:
+			vehicleIndex = i
 		}
-
-		cumulativeDist += trip.TotalDistance
+	}
+	if targetIndex < 0 || vehicleIndex < 0 {
+		return 0
 	}
 
-	if vehicleBlockDist < 0 || targetBlockDist < 0 {
+	current := api.tripShapePlacement(ctx, vehicleTripID)
+	if current == nil {
 		return 0
 	}
+	vehicleDist := current.vehicleDistance(vehicle)
+
+	// Measure from the earlier of the two points to the later one.
+	earlier, later := vehicleIndex, targetIndex
+	distance := current.geometry.Length() - vehicleDist + targetDist
+	if targetIndex < vehicleIndex {
+		earlier, later = targetIndex, vehicleIndex
+		distance = target.geometry.Length() - targetDist + vehicleDist
+	}
+	if between, ok := distanceBetweenBlockTrips(blockTrips, earlier, later); ok {
+		distance += between
+	} else {
+		for i := earlier + 1; i < later; i++ {
+			between := api.tripShapePlacement(ctx, blockTrips[i].tripID)
+			if between == nil {
+				return 0
+			}
+			distance += between.geometry.Length()
+		}
+	}
 
-	return targetBlockDist - vehicleBlockDist
+	if targetIndex < vehicleIndex {
+		return -distance
+	}
+	return distance
 }
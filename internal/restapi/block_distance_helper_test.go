@@ -0,0 +1,200 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"maglev.onebusaway.org/gtfsdb"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// blockDistanceFixture seeds a trip data memo with a block of synthetic trips
+// so getBlockDistanceToStop can run without matching rows in the database.
+type blockDistanceFixture struct {
+	memo        *tripDataMemo
+	serviceDate time.Time
+	lengths     map[string]float64
+}
+
+func newBlockDistanceFixture(blockID string) *blockDistanceFixture {
+	f := &blockDistanceFixture{
+		memo:        newTripDataMemo(),
+		serviceDate: time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC),
+		lengths:     make(map[string]float64),
+	}
+	f.memo.serviceIDs[f.serviceDate.Format("20060102")] = memoEntry[[]string]{value: []string{"weekday"}}
+	f.memo.blockStops[blockTripsKey{blockID: blockID, serviceIDs: "weekday"}] = memoEntry[[]blockTripStops]{}
+	return f
+}
+
+// addTrip appends a trip to the block, placing its stops, one per stop
+// sequence from 1, along the shape. The block's distances are left
+// unset, as in a database that has not been re-imported since they were added.
+func (f *blockDistanceFixture) addTrip(blockID, tripID string, shape []gtfs.ShapePoint, stops ...models.Location) {
+	f.memo.trips[tripID] = memoEntry[gtfsdb.Trip]{value: gtfsdb.Trip{
+		ID:      tripID,
+		BlockID: sql.NullString{String: blockID, Valid: true},
+	}}
+
+	key := blockTripsKey{blockID: blockID, serviceIDs: "weekday"}
+	entry := f.memo.blockStops[key]
+	entry.value = append(entry.value, blockTripStops{tripID: tripID, serviceID: "weekday"})
+	f.memo.blockStops[key] = entry
+
+	cumulative := preCalculateCumulativeDistances(shape)
+	stopTimes := make([]gtfsdb.StopTime, len(stops))
+	for i := range stops {
+		stopTimes[i] = gtfsdb.StopTime{TripID: tripID, StopSequence: int64(i + 1)}
+	}
+	f.memo.placements[tripID] = memoEntry[*tripShapePlacement]{value: &tripShapePlacement{
+		geometry:      &internalgtfs.ShapeGeometry{Points: shape, CumulativeDistances: cumulative},
+		stopTimes:     stopTimes,
+		stopDistances: placeStopsAlongShape(shape, cumulative, stops),
+	}}
+	f.lengths[tripID] = cumulative[len(cumulative)-1]
+}
+
+// precomputeDistances fills in the block's distances the way an import
+// computes them.
+func (f *blockDistanceFixture) precomputeDistances(blockID string) {
+	key := blockTripsKey{blockID: blockID, serviceIDs: "weekday"}
+	accumulated := 0.0
+	for i := range f.memo.blockStops[key].value {
+		trip := &f.memo.blockStops[key].value[i]
+		trip.shapeLength = sql.NullFloat64{Float64: f.lengths[trip.tripID], Valid: true}
+		trip.blockDistance = sql.NullFloat64{Float64: accumulated, Valid: true}
+		accumulated += f.lengths[trip.tripID]
+	}
+}
+
+func (f *blockDistanceFixture) context() context.Context {
+	return context.WithValue(context.Background(), tripDataMemoKey{}, f.memo)
+}
+
+func blockTestVehicle(tripID string, lat, lon float32, currentStopSequence uint32) *gtfs.Vehicle {
+	return &gtfs.Vehicle{
+		ID:                  &gtfs.VehicleID{ID: "bus-1"},
+		Trip:                &gtfs.Trip{ID: gtfs.TripID{ID: tripID}},
+		Position:            &gtfs.Position{Latitude: &lat, Longitude: &lon},
+		CurrentStopSequence: &currentStopSequence,
+	}
+}
+
+func TestGetBlockDistanceToStop_Loop(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	f := newBlockDistanceFixture("loop-block")
+	terminal := models.Location{Lat: 40.00, Lon: -75.00}
+	f.addTrip("loop-block", "loop", loopShape, terminal, models.Location{Lat: 40.01, Lon: -74.99}, terminal)
+	ctx := f.context()
+	length := f.lengths["loop"]
+
+	// Coming back down the last side of the loop, a quarter of a side short
+	// of the terminal.
+	vehicle := blockTestVehicle("loop", 40.00, -74.9975, 3)
+	lastSide := loopShape[3:]
+	remaining := preCalculateCumulativeDistances(lastSide)[1] / 4
+
+	assert.InDelta(t, remaining, api.getBlockDistanceToStop(ctx, "loop", 3, vehicle, f.serviceDate), 2,
+		"the terminal is just ahead, at the end of the loop")
+	assert.InDelta(t, remaining-length, api.getBlockDistanceToStop(ctx, "loop", 1, vehicle, f.serviceDate), 2,
+		"the terminal was passed at the start of the loop")
+}
+
+func TestGetBlockDistanceToStop_AcrossBlockTrips(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	outbound := []gtfs.ShapePoint{{Latitude: 40.00, Longitude: -75.00}, {Latitude: 40.02, Longitude: -75.00}}
+	inbound := []gtfs.ShapePoint{{Latitude: 40.02, Longitude: -75.00}, {Latitude: 40.00, Longitude: -75.00}}
+	north := models.Location{Lat: 40.02, Lon: -75.00}
+	south := models.Location{Lat: 40.00, Lon: -75.00}
+	middle := models.Location{Lat: 40.01, Lon: -75.00}
+
+	f := newBlockDistanceFixture("shuttle")
+	f.addTrip("shuttle", "out-1", outbound, south, middle, north)
+	f.addTrip("shuttle", "in-1", inbound, north, middle, south)
+	f.addTrip("shuttle", "out-2", outbound, south, middle, north)
+	ctx := f.context()
+	half := f.lengths["out-1"] / 2
+
+	// Halfway along the first trip, past its middle stop.
+	vehicle := blockTestVehicle("out-1", 40.01, -75.00, 3)
+
+	assert.InDelta(t, half, api.getBlockDistanceToStop(ctx, "out-1", 3, vehicle, f.serviceDate), 1)
+	assert.InDelta(t, half+f.lengths["in-1"]/2, api.getBlockDistanceToStop(ctx, "in-1", 2, vehicle, f.serviceDate), 1,
+		"the rest of this trip plus the way to the next trip's stop")
+	assert.InDelta(t, half+f.lengths["in-1"], api.getBlockDistanceToStop(ctx, "out-2", 1, vehicle, f.serviceDate), 1,
+		"the trips in between count in full")
+
+	// Once on the return trip, stops of the first trip are behind.
+	vehicle = blockTestVehicle("in-1", 40.015, -75.00, 2)
+	assert.InDelta(t, -(f.lengths["out-1"]/2 + f.lengths["in-1"]/4), api.getBlockDistanceToStop(ctx, "out-1", 2, vehicle, f.serviceDate), 1)
+
+	// A vehicle on a trip of another block is not measured.
+	vehicle = blockTestVehicle("elsewhere", 40.01, -75.00, 2)
+	assert.Equal(t, 0.0, api.getBlockDistanceToStop(ctx, "out-1", 3, vehicle, f.serviceDate))
+}
+
+func TestGetBlockDistanceToStop_PrecomputedDistances(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	outbound := []gtfs.ShapePoint{{Latitude: 40.00, Longitude: -75.00}, {Latitude: 40.02, Longitude: -75.00}}
+	inbound := []gtfs.ShapePoint{{Latitude: 40.02, Longitude: -75.00}, {Latitude: 40.00, Longitude: -75.00}}
+	north := models.Location{Lat: 40.02, Lon: -75.00}
+	south := models.Location{Lat: 40.00, Lon: -75.00}
+
+	f := newBlockDistanceFixture("shuttle")
+	f.addTrip("shuttle", "out-1", outbound, south, north)
+	f.addTrip("shuttle", "in-1", inbound, north, south)
+	f.addTrip("shuttle", "out-2", outbound, south, north)
+	f.addTrip("shuttle", "in-2", inbound, north, south)
+	f.precomputeDistances("shuttle")
+	// The trips in between are measured from the block's distances alone.
+	delete(f.memo.placements, "in-1")
+	delete(f.memo.placements, "out-2")
+	ctx := f.context()
+	half := f.lengths["out-1"] / 2
+
+	vehicle := blockTestVehicle("out-1", 40.01, -75.00, 2)
+	assert.InDelta(t, half+f.lengths["in-1"]+f.lengths["out-2"], api.getBlockDistanceToStop(ctx, "in-2", 1, vehicle, f.serviceDate), 1)
+
+	vehicle = blockTestVehicle("in-2", 40.01, -75.00, 2)
+	assert.InDelta(t, -(f.lengths["out-1"] + f.lengths["in-1"] + f.lengths["out-2"] + f.lengths["in-2"]/2),
+		api.getBlockDistanceToStop(ctx, "out-1", 1, vehicle, f.serviceDate), 1)
+}
+
+func TestDistanceBetweenBlockTrips(t *testing.T) {
+	known := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	trips := []blockTripStops{
+		{tripID: "a", serviceID: "weekday", shapeLength: known(100), blockDistance: known(0)},
+		{tripID: "b", serviceID: "weekday", shapeLength: known(200), blockDistance: known(100)},
+		{tripID: "c", serviceID: "weekday", shapeLength: known(300), blockDistance: known(300)},
+		{tripID: "d", serviceID: "weekday", shapeLength: known(400), blockDistance: known(600)},
+	}
+	distance, ok := distanceBetweenBlockTrips(trips, 0, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 500.0, distance)
+
+	// Block distances of different service IDs are accumulated separately,
+	// so the shape lengths in between are summed instead.
+	trips[2].serviceID, trips[2].blockDistance = "extra", known(0)
+	distance, ok = distanceBetweenBlockTrips(trips, 0, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 500.0, distance)
+
+	trips[1].shapeLength = sql.NullFloat64{}
+	_, ok = distanceBetweenBlockTrips(trips, 0, 3)
+	assert.False(t, ok, "a trip in between has no known length")
+
+	distance, ok = distanceBetweenBlockTrips(trips, 2, 3)
+	assert.True(t, ok, "adjacent trips have nothing in between")
+	assert.Zero(t, distance)
+}
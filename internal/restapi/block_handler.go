@@ -39,13 +39,13 @@ func (api *RestAPI) blockHandler(w http.ResponseWriter, r *http.Request) {
 			api.serverErrorResponse(w, r, ctx.Err())
 			return
 		}
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeBlockNotFound)
 		return
 	}
 
 	//  Return JSON 404 response if no block data is found
 	if len(block) == 0 {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeBlockNotFound)
 		return
 	}
 
@@ -67,6 +67,12 @@ func (api *RestAPI) blockHandler(w http.ResponseWriter, r *http.Request) {
 	api.sendResponse(w, r, response)
 }
 
+// transformBlockToEntry builds the OBA block entry from the stop-level rows of a
+// block. Each service ID yields one configuration whose trips are ordered by their
+// first departure, the order in which a vehicle operates them. Distances and slack
+// times accumulate across the whole configuration: layover between consecutive trips
+// counts as slack, and each trip reports the distance and slack accumulated before it
+// starts.
 func transformBlockToEntry(block []gtfsdb.GetBlockDetailsRow, blockID, agencyID string) models.BlockEntry {
 	serviceGroups := make(map[string][]gtfsdb.GetBlockDetailsRow)
 
@@ -82,82 +88,63 @@ func transformBlockToEntry(block []gtfsdb.GetBlockDetailsRow, blockID, agencyID
 
 	configurations := make([]models.BlockConfiguration, 0, len(serviceGroups))
 
-	var blockDistance float64
-
 	for _, serviceID := range serviceIDs {
-		serviceStops := serviceGroups[serviceID]
-
-		config := &models.BlockConfiguration{
+		config := models.BlockConfiguration{
 			ActiveServiceIds:   []string{utils.FormCombinedID(agencyID, serviceID)},
 			InactiveServiceIds: []string{},
 			Trips:              make([]models.TripBlock, 0),
 		}
 
-		tripStops := make(map[string][]gtfsdb.GetBlockDetailsRow)
-		for _, stop := range serviceStops {
-			tripStops[stop.TripID] = append(tripStops[stop.TripID], stop)
-		}
-
-		tripIDs := make([]string, 0, len(tripStops))
-		for tripID := range tripStops {
-			tripIDs = append(tripIDs, tripID)
-		}
-		sort.Strings(tripIDs)
-
-		for _, tripID := range tripIDs {
-			stops := tripStops[tripID]
-
-			sort.Slice(stops, func(i, j int) bool {
-				return stops[i].StopSequence < stops[j].StopSequence
-			})
+		var blockDistance float64
+		var accumulatedSlack int
+		blockSequence := 0
+		previousDeparture := -1
 
-			var blockStopTimes []models.BlockStopTime
+		for _, stops := range orderBlockTrips(serviceGroups[serviceID]) {
 			tripStartDistance := blockDistance
+			tripStartSlack := accumulatedSlack
+			blockStopTimes := make([]models.BlockStopTime, 0, len(stops))
 
 			for i, stop := range stops {
-				var distanceFromPrevious float64
+				arrival := int(stop.ArrivalTime)
+				departure := int(stop.DepartureTime)
+
 				if i > 0 {
 					prevStop := stops[i-1]
-					distanceFromPrevious = utils.Distance(
-						prevStop.Lat, prevStop.Lon,
-						stop.Lat, stop.Lon,
-					)
-					blockDistance += distanceFromPrevious
+					blockDistance += utils.Distance(prevStop.Lat, prevStop.Lon, stop.Lat, stop.Lon)
+				} else if previousDeparture >= 0 && arrival > previousDeparture {
+					// Layover between the previous trip's last stop and this trip's first stop.
+					accumulatedSlack += arrival - previousDeparture
+					tripStartSlack = accumulatedSlack
 				}
 
-				blockStopTime := models.BlockStopTime{
-					BlockSequence:      int(stop.StopSequence - 1),
-					DistanceAlongBlock: blockDistance,
+				blockStopTimes = append(blockStopTimes, models.BlockStopTime{
+					AccumulatedSlackTime: float64(accumulatedSlack),
+					BlockSequence:        blockSequence,
+					DistanceAlongBlock:   blockDistance,
 					StopTime: models.StopTime{
-						ArrivalTime:   int(utils.NanosToSeconds(stop.ArrivalTime)),
-						DepartureTime: int(utils.NanosToSeconds(stop.DepartureTime)),
+						ArrivalTime:   arrival,
+						DepartureTime: departure,
 						DropOffType:   int(stop.DropOffType.Int64),
 						PickupType:    int(stop.PickupType.Int64),
 						StopID:        utils.FormCombinedID(agencyID, stop.StopID),
 					},
-				}
-				blockStopTimes = append(blockStopTimes, blockStopTime)
-			}
-
-			blockStopTimes = calculateBlockSlackTimes(blockStopTimes)
+				})
 
-			var tripAccumulatedSlack float64
-			if len(blockStopTimes) > 0 {
-				tripAccumulatedSlack = blockStopTimes[len(blockStopTimes)-1].AccumulatedSlackTime
+				accumulatedSlack += departure - arrival
+				blockSequence++
+				previousDeparture = departure
 			}
 
-			tripDistance := blockDistance - tripStartDistance
-
-			trip := models.TripBlock{
-				AccumulatedSlackTime: int(tripAccumulatedSlack),
+			config.Trips = append(config.Trips, models.TripBlock{
+				AccumulatedSlackTime: tripStartSlack,
 				BlockStopTimes:       blockStopTimes,
-				DistanceAlongBlock:   tripDistance,
-				TripId:               utils.FormCombinedID(agencyID, tripID),
-			}
-			config.Trips = append(config.Trips, trip)
+				DistanceAlongBlock:   tripStartDistance,
+				TripId:               utils.FormCombinedID(agencyID, stops[0].TripID),
+			})
 		}
 
-		configurations = append(configurations, *config)
+		configurations = append(configurations, config)
 	}
 
 	return models.BlockEntry{
@@ -166,6 +153,31 @@ func transformBlockToEntry(block []gtfsdb.GetBlockDetailsRow, blockID, agencyID
 	}
 }
 
+// orderBlockTrips groups block rows by trip, sorts each trip's stops by sequence and
+// orders the trips by first departure time, breaking ties by trip ID.
+func orderBlockTrips(rows []gtfsdb.GetBlockDetailsRow) [][]gtfsdb.GetBlockDetailsRow {
+	tripStops := make(map[string][]gtfsdb.GetBlockDetailsRow)
+	for _, row := range rows {
+		tripStops[row.TripID] = append(tripStops[row.TripID], row)
+	}
+
+	trips := make([][]gtfsdb.GetBlockDetailsRow, 0, len(tripStops))
+	for _, stops := range tripStops {
+		sort.Slice(stops, func(i, j int) bool {
+			return stops[i].StopSequence < stops[j].StopSequence
+		})
+		trips = append(trips, stops)
+	}
+
+	sort.Slice(trips, func(i, j int) bool {
+		if trips[i][0].DepartureTime != trips[j][0].DepartureTime {
+			return trips[i][0].DepartureTime < trips[j][0].DepartureTime
+		}
+		return trips[i][0].TripID < trips[j][0].TripID
+	})
+	return trips
+}
+
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
 func (api *RestAPI) getReferences(ctx context.Context, agencyID string, calc *GTFS.AdvancedDirectionCalculator, block []gtfsdb.GetBlockDetailsRow) (models.ReferencesModel, error) {
 	routeIDs := make(map[string]struct{})
@@ -217,7 +229,7 @@ func (api *RestAPI) getReferences(ctx context.Context, agencyID string, calc *GT
 			return models.ReferencesModel{}, ctx.Err()
 		}
 
-		stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
+		stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopID)
 		if err != nil {
 			return models.ReferencesModel{}, err
 		}
@@ -237,7 +249,7 @@ func (api *RestAPI) getReferences(ctx context.Context, agencyID string, calc *GT
 			return models.ReferencesModel{}, ctx.Err()
 		}
 
-		trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+		trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
 		if err != nil {
 			return models.ReferencesModel{}, err
 		}
@@ -249,6 +261,9 @@ func (api *RestAPI) getReferences(ctx context.Context, agencyID string, calc *GT
 			BlockID:      utils.FormCombinedID(agencyID, trip.BlockID.String),
 			ShapeID:      utils.FormCombinedID(agencyID, trip.ShapeID.String),
 			TripHeadsign: trip.TripHeadsign.String,
+
+			WheelchairAccessible: utils.MapWheelchairAccessible(trip.WheelchairAccessible),
+			BikesAllowed:         utils.MapBikesAllowed(trip.BikesAllowed),
 		})
 	}
 
@@ -270,15 +285,3 @@ func (api *RestAPI) getReferences(ctx context.Context, agencyID string, calc *GT
 		Situations: []interface{}{},
 	}, nil
 }
-
-func calculateBlockSlackTimes(blockStopTimes []models.BlockStopTime) []models.BlockStopTime {
-	var accumulatedBlockSlackTime int
-
-	for i := range blockStopTimes {
-		blockStopTimes[i].AccumulatedSlackTime = float64(accumulatedBlockSlackTime)
-		dwellTime := blockStopTimes[i].StopTime.DepartureTime - blockStopTimes[i].StopTime.ArrivalTime
-		accumulatedBlockSlackTime += dwellTime
-	}
-
-	return blockStopTimes
-}
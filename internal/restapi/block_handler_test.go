@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 )
 
 func TestBlockHandlerEndToEnd(t *testing.T) {
@@ -552,7 +553,54 @@ func TestBlockHandlerContextCancellation(t *testing.T) {
 		mux.ServeHTTP(w, req)
 
 		assert.True(t,
-			w.Code == http.StatusInternalServerError || (w.Code == http.StatusOK && w.Body.Len() > 0),
+			w.Code == http.StatusServiceUnavailable || (w.Code == http.StatusOK && w.Body.Len() > 0),
 			"Expected explicit error or valid response, but got silent failure (200 with empty body) or unexpected code: %d", w.Code)
 	})
 }
+
+func TestTransformBlockToEntryOrdersTripsAndAccumulatesLayover(t *testing.T) {
+	hour, minute := int64(3600), int64(60)
+	row := func(tripID, stopID string, seq int64, arrival, departure int64, lat float64) gtfsdb.GetBlockDetailsRow {
+		return gtfsdb.GetBlockDetailsRow{
+			ServiceID:     "WKDY",
+			TripID:        tripID,
+			StopID:        stopID,
+			StopSequence:  seq,
+			ArrivalTime:   arrival,
+			DepartureTime: departure,
+			Lat:           lat,
+			Lon:           -122.0,
+		}
+	}
+
+	// "a_late" sorts first by ID but runs second; 10 minutes of layover separate the trips
+	// and the first trip dwells 60 seconds at its middle stop.
+	rows := []gtfsdb.GetBlockDetailsRow{
+		row("a_late", "S3", 1, 9*hour+10*minute, 9*hour+10*minute, 47.02),
+		row("a_late", "S1", 2, 9*hour+30*minute, 9*hour+30*minute, 47.00),
+		row("z_early", "S1", 1, 8*hour, 8*hour, 47.00),
+		row("z_early", "S2", 2, 8*hour+30*minute, 8*hour+31*minute, 47.01),
+		row("z_early", "S3", 3, 9*hour, 9*hour, 47.02),
+	}
+
+	entry := transformBlockToEntry(rows, "25_B1", "25")
+	require.Len(t, entry.Configurations, 1)
+	trips := entry.Configurations[0].Trips
+	require.Len(t, trips, 2)
+
+	assert.Equal(t, "25_z_early", trips[0].TripId)
+	assert.Equal(t, "25_a_late", trips[1].TripId)
+
+	assert.Equal(t, 0.0, trips[0].DistanceAlongBlock)
+	assert.Equal(t, 0, trips[0].AccumulatedSlackTime)
+	firstTripEnd := trips[0].BlockStopTimes[2].DistanceAlongBlock
+	assert.Greater(t, firstTripEnd, 0.0)
+	assert.Equal(t, firstTripEnd, trips[1].DistanceAlongBlock)
+
+	// 60s dwell in the first trip plus 600s of layover.
+	assert.Equal(t, 660, trips[1].AccumulatedSlackTime)
+	assert.Equal(t, 660.0, trips[1].BlockStopTimes[0].AccumulatedSlackTime)
+
+	assert.Equal(t, 3, trips[1].BlockStopTimes[0].BlockSequence)
+	assert.Equal(t, 4, trips[1].BlockStopTimes[1].BlockSequence)
+}
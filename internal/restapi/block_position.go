@@ -0,0 +1,241 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Phases of a vehicle relative to its block, named after the OneBusAway
+// EVehiclePhase values. A vehicle deadheads to the start of its block and lays
+// over there, lays over during the breaks between the following trips, and
+// deadheads away once the block is completed.
+const (
+	phaseDeadheadBefore = "deadhead_before"
+	phaseLayoverBefore  = "layover_before"
+	phaseInProgress     = "in_progress"
+	phaseLayoverDuring  = "layover_during"
+	phaseDeadheadAfter  = "deadhead_after"
+	phaseCompleted      = "completed"
+)
+
+// layoverDistanceMeters is how close to the start of a trip a vehicle must be
+// to count as waiting there, and how close to the end of its trip to count as
+// having finished it.
+const layoverDistanceMeters = 100.0
+
+// deadheadDistanceMeters is how far from its trip's shape a vehicle must be to
+// count as driving off the route, the default detour threshold.
+const deadheadDistanceMeters = 150.0
+
+// blockState is where the vehicle serving a block stands relative to one trip
+// of that block.
+type blockState int
+
+const (
+	// blockStateUnknown means the vehicle could not be placed in the block.
+	blockStateUnknown blockState = iota
+	// blockStateEarlierTrip means the vehicle is still running an earlier trip.
+	blockStateEarlierTrip
+	// blockStateDeadheadBefore means the vehicle is driving to the start of
+	// the block's first trip.
+	blockStateDeadheadBefore
+	// blockStateLayover means the vehicle is waiting to start the trip.
+	blockStateLayover
+	// blockStateInProgress means the vehicle is running the trip.
+	blockStateInProgress
+	// blockStateTripEnded means the vehicle has reached the end of the trip
+	// and has later trips of the block to run.
+	blockStateTripEnded
+	// blockStateBlockEnded means the vehicle has reached the end of the
+	// block's last trip.
+	blockStateBlockEnded
+	// blockStateDeadheadAfter means the vehicle has left the route after the
+	// block's last trip.
+	blockStateDeadheadAfter
+	// blockStateFinished means the vehicle has moved on to a later trip.
+	blockStateFinished
+)
+
+// blockPosition describes a block's vehicle relative to one trip of the block.
+// The trip indexes count from the first trip the block runs on the service date.
+type blockPosition struct {
+	tripIndex    int // the trip the status is built for
+	vehicleIndex int // the trip the vehicle reports, -1 when not in the block
+	lastIndex    int // the block's last trip
+	now          utils.ServiceTime
+	tripStart    utils.ServiceTime // first departure of the trip
+	vehicleEnd   utils.ServiceTime // last arrival of the vehicle's trip
+
+	// hasDistance tells whether the vehicle reported a position, in which case
+	// vehicleDistance is how far along its own trip of vehicleTripLength meters it
+	// is, and vehicleOffShape how far in meters it is from that trip's shape.
+	hasDistance       bool
+	vehicleDistance   float64
+	vehicleTripLength float64
+	vehicleOffShape   float64
+}
+
+// state runs the block position through the states a vehicle passes for a
+// trip:
+//
+//	earlier trip ------+
+//	                   v
+//	deadhead before -> layover -> in progress -> trip ended -> finished
+//	                                          -> block ended -> deadhead after
+//
+// A vehicle running an earlier trip of the block lays over for this one once
+// it reaches the end of the previous trip, even while the schedule says that
+// trip still runs. Before its scheduled departure, a vehicle reporting the
+// trip lays over when it is at the start, or has no position, deadheads when
+// it is off the route of the block's first trip, and otherwise runs early.
+// Once running, reaching the end of the trip ends it, or the block if it is
+// the last trip; a vehicle off the route after the trip's scheduled end has
+// left it, deadheading away after the last trip. Reporting a later trip
+// finishes this one. Without a position only the schedule places the vehicle
+// between trips, and a vehicle is never taken to have ended a trip it reports.
+func (p blockPosition) state() blockState {
+	switch {
+	case p.tripIndex < 0 || p.vehicleIndex < 0:
+		return blockStateUnknown
+	case p.vehicleIndex > p.tripIndex:
+		return blockStateFinished
+	case p.vehicleIndex == p.tripIndex:
+		return p.reportedTripState()
+	case p.vehicleIndex == p.tripIndex-1 && p.finishedVehicleTrip():
+		return blockStateLayover
+	default:
+		return blockStateEarlierTrip
+	}
+}
+
+// reportedTripState places a vehicle reporting the trip the status is for.
+func (p blockPosition) reportedTripState() blockState {
+	offRoute := p.hasDistance && p.vehicleOffShape > deadheadDistanceMeters
+	if p.now < p.tripStart {
+		switch {
+		case offRoute && p.tripIndex == 0:
+			return blockStateDeadheadBefore
+		case !p.hasDistance || p.vehicleDistance <= layoverDistanceMeters:
+			return blockStateLayover
+		}
+		return blockStateInProgress
+	}
+
+	ended := offRoute && p.now > p.vehicleEnd
+	if !ended && (!p.hasDistance || p.vehicleTripLength <= 0 || p.vehicleDistance < p.vehicleTripLength-layoverDistanceMeters) {
+		return blockStateInProgress
+	}
+	switch {
+	case p.tripIndex < p.lastIndex:
+		return blockStateTripEnded
+	case offRoute:
+		return blockStateDeadheadAfter
+	default:
+		return blockStateBlockEnded
+	}
+}
+
+// finishedVehicleTrip reports whether the vehicle has reached the end of the
+// trip it reports. Without a position the schedule decides.
+func (p blockPosition) finishedVehicleTrip() bool {
+	if p.hasDistance && p.vehicleTripLength > 0 {
+		return p.vehicleDistance >= p.vehicleTripLength-layoverDistanceMeters
+	}
+	return p.now > p.vehicleEnd
+}
+
+// layoverPhase is the phase of a vehicle laying over before the trip.
+func (p blockPosition) layoverPhase() string {
+	if p.tripIndex == 0 {
+		return phaseLayoverBefore
+	}
+	return phaseLayoverDuring
+}
+
+// phase is the phase of the vehicle in the given state of the block position.
+func (p blockPosition) phase(state blockState) string {
+	switch state {
+	case blockStateDeadheadBefore:
+		return phaseDeadheadBefore
+	case blockStateLayover:
+		return p.layoverPhase()
+	case blockStateTripEnded:
+		return phaseLayoverDuring
+	case blockStateDeadheadAfter:
+		return phaseDeadheadAfter
+	case blockStateBlockEnded, blockStateFinished:
+		return phaseCompleted
+	default:
+		return phaseInProgress
+	}
+}
+
+// blockPositionForTrip places the vehicle serving tripID's block relative to
+// that trip on serviceDate.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) blockPositionForTrip(ctx context.Context, tripID string, vehicle *gtfs.Vehicle, serviceDate, currentTime time.Time) blockPosition {
+	position := blockPosition{tripIndex: -1, vehicleIndex: -1}
+	vehicleTripID := GetVehicleActiveTripID(vehicle)
+	if vehicleTripID == "" {
+		return position
+	}
+
+	memo := tripDataMemoFromContext(ctx)
+	queries := api.GtfsManager.GtfsDB.Queries
+	trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, tripID)
+	if err != nil || !trip.BlockID.Valid {
+		return position
+	}
+	serviceIDs, err := memo.activeServiceIDs(ctx, queries, serviceDate)
+	if err != nil || len(serviceIDs) == 0 {
+		return position
+	}
+	blockTrips, err := memo.orderedBlockTrips(ctx, queries, trip.BlockID, serviceIDs)
+	if err != nil {
+		return position
+	}
+
+	var vehicleTrip gtfsdb.GetTripsByBlockIDOrderedRow
+	for i, blockTrip := range blockTrips {
+		if blockTrip.ID == tripID {
+			position.tripIndex = i
+			position.tripStart = utils.NewServiceTime(blockTripServiceTime(blockTrip.FirstDepartureTime))
+		}
+		if blockTrip.ID == vehicleTripID {
+			position.vehicleIndex = i
+			vehicleTrip = blockTrip
+		}
+	}
+	if position.tripIndex < 0 || position.vehicleIndex < 0 {
+		return position
+	}
+	position.lastIndex = len(blockTrips) - 1
+	position.now = utils.ServiceTimeAt(serviceDate, currentTime)
+	position.vehicleEnd = utils.NewServiceTime(blockTripServiceTime(vehicleTrip.LastArrivalTime))
+
+	if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+		if geometry, err := api.GtfsManager.GetShapeGeometryForTrip(ctx, vehicleTripID); err == nil && geometry != nil {
+			position.hasDistance = true
+			position.vehicleDistance = api.getVehicleDistanceAlongShapeContextual(ctx, vehicleTripID, vehicle)
+			position.vehicleTripLength = geometry.Length()
+			position.vehicleOffShape = geometry.DistanceFromShape(float64(*vehicle.Position.Latitude), float64(*vehicle.Position.Longitude))
+		}
+	}
+	return position
+}
+
+// blockTripServiceTime reads the MIN/MAX stop time aggregates of
+// GetTripsByBlockIDOrdered, which sqlc leaves untyped.
+func blockTripServiceTime(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
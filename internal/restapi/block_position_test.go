@@ -0,0 +1,249 @@
+package restapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestBlockPositionState(t *testing.T) {
+	hour := func(h float64) utils.ServiceTime { return utils.NewServiceTime(int64(h * 3600)) }
+
+	tests := []struct {
+		name     string
+		position blockPosition
+		expected blockState
+		phase    string
+	}{
+		{
+			name:     "vehicle not in the block",
+			position: blockPosition{tripIndex: 1, vehicleIndex: -1},
+			expected: blockStateUnknown,
+			phase:    phaseInProgress,
+		},
+		{
+			name:     "vehicle moved on to a later trip",
+			position: blockPosition{tripIndex: 0, vehicleIndex: 1, now: hour(12)},
+			expected: blockStateFinished,
+			phase:    phaseCompleted,
+		},
+		{
+			name:     "waiting at the start of the first trip",
+			position: blockPosition{tripIndex: 0, vehicleIndex: 0, now: hour(9.9), tripStart: hour(10), hasDistance: true, vehicleDistance: 20},
+			expected: blockStateLayover,
+			phase:    phaseLayoverBefore,
+		},
+		{
+			name:     "waiting at the start of a later trip",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, now: hour(11.9), tripStart: hour(12)},
+			expected: blockStateLayover,
+			phase:    phaseLayoverDuring,
+		},
+		{
+			name:     "running early is in progress",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, now: hour(11.9), tripStart: hour(12), hasDistance: true, vehicleDistance: 2000},
+			expected: blockStateInProgress,
+			phase:    phaseInProgress,
+		},
+		{
+			name:     "running the trip",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, now: hour(12.5), tripStart: hour(12)},
+			expected: blockStateInProgress,
+			phase:    phaseInProgress,
+		},
+		{
+			name: "driving to the start of the first trip",
+			position: blockPosition{tripIndex: 0, vehicleIndex: 0, now: hour(9.5), tripStart: hour(10),
+				hasDistance: true, vehicleDistance: 20, vehicleOffShape: 800},
+			expected: blockStateDeadheadBefore,
+			phase:    phaseDeadheadBefore,
+		},
+		{
+			name: "off the route before a later trip lays over",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, now: hour(11.9), tripStart: hour(12),
+				hasDistance: true, vehicleDistance: 20, vehicleOffShape: 800},
+			expected: blockStateLayover,
+			phase:    phaseLayoverDuring,
+		},
+		{
+			name: "off the route during the trip is a detour",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, lastIndex: 1, now: hour(12.5), tripStart: hour(12), vehicleEnd: hour(13),
+				hasDistance: true, vehicleDistance: 5000, vehicleTripLength: 10000, vehicleOffShape: 800},
+			expected: blockStateInProgress,
+			phase:    phaseInProgress,
+		},
+		{
+			name: "reached the end of a trip with more to run",
+			position: blockPosition{tripIndex: 0, vehicleIndex: 0, lastIndex: 1, now: hour(11.7), tripStart: hour(10), vehicleEnd: hour(11.75),
+				hasDistance: true, vehicleDistance: 9950, vehicleTripLength: 10000},
+			expected: blockStateTripEnded,
+			phase:    phaseLayoverDuring,
+		},
+		{
+			name: "reached the end of the block",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, lastIndex: 1, now: hour(13.6), tripStart: hour(12), vehicleEnd: hour(13.5),
+				hasDistance: true, vehicleDistance: 9950, vehicleTripLength: 10000},
+			expected: blockStateBlockEnded,
+			phase:    phaseCompleted,
+		},
+		{
+			name: "left the route after the block",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, lastIndex: 1, now: hour(13.75), tripStart: hour(12), vehicleEnd: hour(13.5),
+				hasDistance: true, vehicleDistance: 9800, vehicleTripLength: 10000, vehicleOffShape: 600},
+			expected: blockStateDeadheadAfter,
+			phase:    phaseDeadheadAfter,
+		},
+		{
+			name:     "late without a position is still in progress",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 1, lastIndex: 1, now: hour(13.75), tripStart: hour(12), vehicleEnd: hour(13.5)},
+			expected: blockStateInProgress,
+			phase:    phaseInProgress,
+		},
+		{
+			name: "at the end of the previous trip",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 0, now: hour(11.5), vehicleEnd: hour(11.75),
+				hasDistance: true, vehicleDistance: 9950, vehicleTripLength: 10000},
+			expected: blockStateLayover,
+			phase:    phaseLayoverDuring,
+		},
+		{
+			name: "still running the previous trip",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 0, now: hour(11.9), vehicleEnd: hour(11.75),
+				hasDistance: true, vehicleDistance: 5000, vehicleTripLength: 10000},
+			expected: blockStateEarlierTrip,
+			phase:    phaseInProgress,
+		},
+		{
+			name:     "previous trip over by the schedule without a position",
+			position: blockPosition{tripIndex: 1, vehicleIndex: 0, now: hour(11.9), vehicleEnd: hour(11.75)},
+			expected: blockStateLayover,
+			phase:    phaseLayoverDuring,
+		},
+		{
+			name:     "running a trip two trips earlier",
+			position: blockPosition{tripIndex: 2, vehicleIndex: 0, now: hour(12), vehicleEnd: hour(11.75)},
+			expected: blockStateEarlierTrip,
+			phase:    phaseInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.position.state()
+			assert.Equal(t, tt.expected, state)
+			assert.Equal(t, tt.phase, tt.position.phase(state))
+		})
+	}
+}
+
+func TestBuildTripStatus_BlockLayover(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := context.Background()
+
+	// Block 99 runs these two trips of route 24 every day: 2000 -> 327 from
+	// 10:00 to 11:45, then 327 -> 2000 from 11:55 to 13:40.
+	const (
+		firstTrip  = "bf005cf3-4cab-4ac6-985f-ce6385b645cf"
+		secondTrip = "139014b6-1f81-4307-a272-c7ee817d4d49"
+	)
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, []string{"2000", "327"})
+	require.NoError(t, err)
+	require.Len(t, stops, 2)
+	stopPositions := make(map[string]*gtfs.Position)
+	for _, stop := range stops {
+		lat, lon := float32(stop.Lat), float32(stop.Lon)
+		stopPositions[stop.ID] = &gtfs.Position{Latitude: &lat, Longitude: &lon}
+	}
+	// A depot a few kilometers off the route.
+	depotLat, depotLon := *stopPositions["2000"].Latitude+0.03, *stopPositions["2000"].Longitude+0.03
+	stopPositions["depot"] = &gtfs.Position{Latitude: &depotLat, Longitude: &depotLon}
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name          string
+		vehicleTrip   string
+		atStop        string
+		at            time.Time
+		statusTrip    string
+		phase         string
+		finishedTrip  bool
+		notYetStarted bool
+	}{
+		{
+			name:        "waiting at the start of the block",
+			vehicleTrip: firstTrip, atStop: "2000", at: time.Date(2025, 6, 13, 9, 50, 0, 0, loc),
+			statusTrip: firstTrip, phase: phaseLayoverBefore, notYetStarted: true,
+		},
+		{
+			name:        "arrived at the end of the previous trip",
+			vehicleTrip: firstTrip, atStop: "327", at: time.Date(2025, 6, 13, 11, 50, 0, 0, loc),
+			statusTrip: secondTrip, phase: phaseLayoverDuring, notYetStarted: true,
+		},
+		{
+			name:        "assigned to the next trip before it departs",
+			vehicleTrip: secondTrip, atStop: "327", at: time.Date(2025, 6, 13, 11, 50, 0, 0, loc),
+			statusTrip: secondTrip, phase: phaseLayoverDuring, notYetStarted: true,
+		},
+		{
+			name:        "still running the previous trip",
+			vehicleTrip: firstTrip, atStop: "2000", at: time.Date(2025, 6, 13, 10, 5, 0, 0, loc),
+			statusTrip: secondTrip, phase: phaseInProgress, notYetStarted: true,
+		},
+		{
+			name:        "moved on to the next trip",
+			vehicleTrip: secondTrip, atStop: "2000", at: time.Date(2025, 6, 13, 13, 35, 0, 0, loc),
+			statusTrip: firstTrip, phase: phaseCompleted, finishedTrip: true,
+		},
+		{
+			name:        "arrived at the end of a trip with another to run",
+			vehicleTrip: firstTrip, atStop: "327", at: time.Date(2025, 6, 13, 11, 44, 0, 0, loc),
+			statusTrip: firstTrip, phase: phaseLayoverDuring, finishedTrip: true,
+		},
+		{
+			name:        "arrived at the end of the block",
+			vehicleTrip: secondTrip, atStop: "2000", at: time.Date(2025, 6, 13, 13, 41, 0, 0, loc),
+			statusTrip: secondTrip, phase: phaseCompleted, finishedTrip: true,
+		},
+		{
+			name:        "driving to the start of the block",
+			vehicleTrip: firstTrip, atStop: "depot", at: time.Date(2025, 6, 13, 9, 30, 0, 0, loc),
+			statusTrip: firstTrip, phase: phaseDeadheadBefore, notYetStarted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.GtfsManager.MockResetRealTimeData()
+			timestamp := tt.at
+			api.GtfsManager.MockAddVehicleWithOptions("BLOCK_99_BUS", tt.vehicleTrip, "24", internalgtfs.MockVehicleOptions{
+				Position:  stopPositions[tt.atStop],
+				Timestamp: &timestamp,
+			})
+
+			status, err := api.BuildTripStatus(ctx, "25", tt.statusTrip, serviceDate, tt.at)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.phase, status.Phase)
+			assert.Equal(t, utils.FormCombinedID("25", tt.vehicleTrip), status.ActiveTripID)
+			require.Greater(t, status.TotalDistanceAlongTrip, 0.0)
+			if tt.notYetStarted {
+				assert.Zero(t, status.DistanceAlongTrip)
+			}
+			if tt.finishedTrip {
+				assert.Equal(t, status.TotalDistanceAlongTrip, status.DistanceAlongTrip)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/gtfsdb"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func (api *RestAPI) blocksForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	maxCount, fieldErrors := utils.ParseMaxCount(r.URL.Query(), models.DefaultMaxCountForBlocks, nil)
+	offset, fieldErrors := parsePageOffset(r, fieldErrors)
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	// Check if context is already cancelled
+	if ctx.Err() != nil {
+		api.serverErrorResponse(w, r, ctx.Err())
+		return
+	}
+
+	id, _ := utils.GetIDFromContext(r.Context())
+
+	// Validate agency exists
+	agency := api.GtfsManager.FindAgency(id)
+	if agency == nil {
+		api.sendNull(w, r)
+		return
+	}
+
+	blockIDs, err := api.GtfsManager.GtfsDB.Queries.GetBlockIDsForAgency(ctx, id)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	start, end, limitExceeded := pageWindow(len(blockIDs), offset, maxCount)
+	blockIDs = blockIDs[start:end]
+	page := newPage(r, offset, len(blockIDs), limitExceeded)
+
+	// The stop times of every block on the page are read at once and grouped
+	// by block.
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetBlockDetailsForBlocks(ctx, blockIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	rowsByBlock := make(map[string][]gtfsdb.GetBlockDetailsRow, len(blockIDs))
+	allRows := make([]gtfsdb.GetBlockDetailsRow, 0, len(rows))
+	for _, row := range rows {
+		detail := gtfsdb.GetBlockDetailsRow{
+			ServiceID:     row.ServiceID,
+			TripID:        row.TripID,
+			RouteID:       row.RouteID,
+			ArrivalTime:   row.ArrivalTime,
+			DepartureTime: row.DepartureTime,
+			StopID:        row.StopID,
+			StopSequence:  row.StopSequence,
+			PickupType:    row.PickupType,
+			DropOffType:   row.DropOffType,
+			Lat:           row.Lat,
+			Lon:           row.Lon,
+		}
+		rowsByBlock[row.BlockID.String] = append(rowsByBlock[row.BlockID.String], detail)
+		allRows = append(allRows, detail)
+	}
+
+	blocks := make([]models.BlockEntry, 0, len(blockIDs))
+	for _, blockID := range blockIDs {
+		blockRows := rowsByBlock[blockID.String]
+		if len(blockRows) == 0 {
+			continue
+		}
+		blocks = append(blocks, transformBlockToEntry(blockRows, utils.FormCombinedID(id, blockID.String), id))
+	}
+
+	calc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
+	references, err := api.getReferences(ctx, id, calc, allRows)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := models.WithPage(models.NewListResponse(blocks, references, limitExceeded, api.Clock), page)
+	api.sendResponse(w, r, response)
+}
@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocksForAgencyRequiresValidApiKey(t *testing.T) {
+	_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/blocks-for-agency/25.json?key=invalid")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, model.Code)
+}
+
+func TestBlocksForAgencyEndToEnd(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/blocks-for-agency/25.json?key=TEST&maxCount=3")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, data["limitExceeded"])
+
+	list, ok := data["list"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, list, 3)
+
+	for _, item := range list {
+		block, ok := item.(map[string]interface{})
+		require.True(t, ok)
+		assert.True(t, strings.HasPrefix(block["id"].(string), "25_"))
+
+		configurations, ok := block["configurations"].([]interface{})
+		require.True(t, ok)
+		require.NotEmpty(t, configurations)
+
+		trips := configurations[0].(map[string]interface{})["trips"].([]interface{})
+		require.NotEmpty(t, trips)
+		assert.Equal(t, 0.0, trips[0].(map[string]interface{})["distanceAlongBlock"])
+	}
+
+	references, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	assert.NotEmpty(t, references["trips"])
+	assert.NotEmpty(t, references["stops"])
+}
+
+func TestBlocksForAgencyUnknownAgency(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/blocks-for-agency/unknown.json?key=TEST")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, model.Data)
+}
+
+func TestBlocksForAgencyInvalidMaxCount(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/blocks-for-agency/25.json?key=TEST&maxCount=0")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
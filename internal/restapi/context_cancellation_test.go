@@ -75,15 +75,17 @@ func TestContextCancellationHandling(t *testing.T) {
 			// If cancelled, we expect a timeout or cancellation error response
 			statusCode := w.Code
 
-			// Valid responses: 200 (completed), 401 (API validation), 500 (error), or timeout-related
+			// Valid responses: 200 (completed), 401 (API validation), 500 (error), or timeout-related;
+			// an expired deadline is reported as 503 timeout
 			assert.True(t, statusCode == http.StatusOK ||
 				statusCode == http.StatusUnauthorized || // API key validation happens first
 				statusCode == http.StatusBadRequest ||
 				statusCode == http.StatusInternalServerError ||
 				statusCode == http.StatusRequestTimeout ||
 				statusCode == http.StatusGatewayTimeout ||
+				statusCode == http.StatusServiceUnavailable ||
 				statusCode == http.StatusNotFound,
-				"Expected status 200, 401, 404, 500, 408, or 504, got %d", statusCode)
+				"Expected status 200, 401, 404, 500, 408, 503 or 504, got %d", statusCode)
 		})
 	}
 }
@@ -2,20 +2,67 @@ package restapi
 
 import (
 	"net/http"
+	"strconv"
 
 	"maglev.onebusaway.org/internal/models"
 )
 
 // Declare a handler which writes a JSON response with information about the
 // current time.
+//
+// The entry also reports whether the server is ready to answer: an instance
+// still importing GTFS data responds 503 with ready set to false, so that load
+// balancers and clients can tell it apart from one that is down. A clientTime
+// parameter, in epoch milliseconds, adds the clock skew between the two.
 func (api *RestAPI) currentTimeHandler(w http.ResponseWriter, r *http.Request) {
+	now := api.Clock.Now()
+	timeData := models.NewCurrentTimeData(now)
+
+	if raw := r.URL.Query().Get("clientTime"); raw != "" {
+		clientTime, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || clientTime < 0 {
+			api.validationErrorResponse(w, r, map[string][]string{
+				"clientTime": {"clientTime must be a time in epoch milliseconds"},
+			})
+			return
+		}
+		skew := now.UnixMilli() - clientTime
+		timeData.Entry.ClockSkew = &skew
+	}
+
+	if !api.GtfsManager.IsReady() {
+		response := models.ResponseModel{
+			Code:        http.StatusServiceUnavailable,
+			CurrentTime: models.ResponseCurrentTime(api.Clock),
+			Data:        timeData,
+			ErrorCode:   string(errCodeServiceUnavailable),
+			Text:        "GTFS data is still being imported",
+			Version:     2,
+		}
+		if err := api.writeResponse(w, r, http.StatusServiceUnavailable, response); err != nil {
+			api.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	// Health Check: fail if GTFS data is invalid
 	if !api.GtfsManager.IsHealthy() {
 		http.Error(w, "Service Unavailable: GTFS data invalid", http.StatusServiceUnavailable)
 		return
 	}
 
-	timeData := models.NewCurrentTimeData(api.Clock.Now())
+	timeData.Entry.Ready = true
+	if updated := api.GtfsManager.LastStaticUpdate(); !updated.IsZero() {
+		timeData.Entry.StaticDataUpdatedAt = updated.UnixMilli()
+	}
+	for _, feed := range api.GtfsManager.FeedHealthStatus() {
+		status := models.RealtimeFeedStatus{FeedID: feed.FeedID, Stale: feed.Stale}
+		if !feed.LastSuccess.IsZero() {
+			status.LastSuccess = feed.LastSuccess.UnixMilli()
+		}
+		timeData.Entry.RealtimeFeeds = append(timeData.Entry.RealtimeFeeds, status)
+	}
+
 	response := models.NewOKResponse(timeData, api.Clock)
 
 	api.sendResponse(w, r, response)
@@ -1,12 +1,19 @@
 package restapi
 
 import (
+	"database/sql"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/gtfs"
 )
 
 func TestCurrentTimeHandlerRequiresValidApiKey(t *testing.T) {
@@ -88,3 +95,52 @@ func TestCurrentTimeHandler_DeterministicTime(t *testing.T) {
 	expectedReadable := fixedTime.Format(time.RFC3339)
 	assert.Equal(t, expectedReadable, entry["readableTime"], "Readable time should match mock clock")
 }
+
+func TestCurrentTimeHandlerReportsReadiness(t *testing.T) {
+	api := createTestApi(t)
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, true, entry["ready"])
+	assert.Equal(t, float64(api.GtfsManager.LastStaticUpdate().UnixMilli()), entry["staticDataUpdatedAt"])
+	assert.NotContains(t, entry, "clockSkew", "only reported when the client sends its time")
+}
+
+func TestCurrentTimeHandlerClockSkew(t *testing.T) {
+	fixedTime := time.Date(2024, 6, 15, 14, 30, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(fixedTime))
+
+	clientTime := fixedTime.Add(-1500 * time.Millisecond).UnixMilli()
+	_, model := serveApiAndRetrieveEndpoint(t, api, fmt.Sprintf("/api/where/current-time.json?key=TEST&clientTime=%d", clientTime))
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(1500), entry["clockSkew"], "server is ahead of the client")
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST&clientTime=soon")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusBadRequest, model.Code)
+}
+
+func TestCurrentTimeHandlerWhileImporting(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	// The manager is never marked ready, as during the initial import.
+	api := NewRestAPI(&app.Application{
+		GtfsManager: &gtfs.Manager{GtfsDB: &gtfsdb.Client{DB: db}},
+		Config:      appconf.Config{RateLimit: 100, ApiKeys: []string{"TEST"}},
+		Clock:       clock.RealClock{},
+	})
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/current-time.json?key=TEST")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, model.Code)
+	assert.Equal(t, "SERVICE_UNAVAILABLE", model.ErrorCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, false, entry["ready"])
+	assert.NotContains(t, entry, "staticDataUpdatedAt")
+	assert.NotZero(t, entry["time"], "the time is still reported")
+}
@@ -0,0 +1,58 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// dataQualityHandler reports, for each route of an agency with trips under way
+// or vehicles reporting, how well the realtime feeds cover it: the share of
+// scheduled trips served by a vehicle and with predicted arrivals, and the
+// median age of its vehicles' positions. The GTFS manager recomputes these
+// every minute while realtime feeds are configured.
+func (api *RestAPI) dataQualityHandler(w http.ResponseWriter, r *http.Request) {
+	agencyID, _ := utils.GetIDFromContext(r.Context())
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agency := api.GtfsManager.FindAgency(agencyID)
+	if agency == nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return
+	}
+
+	references := models.NewEmptyReferences()
+	references.Agencies = append(references.Agencies, models.NewAgencyReference(
+		agency.Id, agency.Name, agency.Url, agency.Timezone,
+		agency.Language, agency.Phone, agency.Email,
+		agency.FareUrl, "", false,
+	))
+
+	entry := models.DataQuality{
+		AgencyID: agencyID,
+		Routes:   []models.RouteDataQuality{},
+	}
+	if quality, ok := api.GtfsManager.GetDataQuality(); ok {
+		entry.ComputedAt = quality.ComputedAt.UnixMilli()
+		for _, routeQuality := range quality.Routes {
+			route := api.GtfsManager.FindRoute(routeQuality.RouteID)
+			if route == nil || route.Agency == nil || route.Agency.Id != agencyID {
+				continue
+			}
+			routeID := utils.FormCombinedID(agencyID, route.Id)
+			entry.Routes = append(entry.Routes, models.NewRouteDataQuality(
+				routeID, routeQuality.ScheduledTrips, routeQuality.TripsWithVehicles,
+				routeQuality.PredictedTrips, routeQuality.Vehicles, routeQuality.MedianPositionAge,
+			))
+			references.Routes = append(references.Routes, models.NewRoute(
+				routeID, agencyID, route.ShortName, route.LongName,
+				route.Description, models.RouteType(route.Type),
+				route.Url, route.Color, route.TextColor).WithSortOrder(route.SortOrder))
+		}
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+}
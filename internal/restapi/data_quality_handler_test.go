@@ -0,0 +1,80 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+)
+
+func TestDataQualityHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/data-quality/25.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "25", entry["agencyId"])
+	assert.Equal(t, float64(0), entry["computedAt"], "not computed without realtime feeds")
+	assert.Empty(t, entry["routes"])
+
+	// A trip of route 151 a minute after it departs.
+	ctx := context.Background()
+	queries := api.GtfsManager.GtfsDB.Queries
+	serviceIDs, err := queries.GetActiveServiceIDsForDate(ctx, "20250610")
+	require.NoError(t, err)
+	trips, err := queries.GetTripsForRouteInActiveServiceIDs(ctx, gtfsdb.GetTripsForRouteInActiveServiceIDsParams{
+		RouteID:    "151",
+		ServiceIds: serviceIDs,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, trips)
+	trip := trips[0]
+	span, err := queries.GetTripServiceSpan(ctx, trip.ID)
+	require.NoError(t, err)
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 10, 0, 0, 0, 0, loc).Add(time.Duration(span.FirstDepartureTime)*time.Second + time.Minute)
+
+	observed := now.Add(-20 * time.Second)
+	stopSequence := uint32(1)
+	api.GtfsManager.MockAddVehicleWithOptions("v1", trip.ID, "151", internalgtfs.MockVehicleOptions{Timestamp: &observed})
+	api.GtfsManager.MockAddTripUpdate(trip.ID, nil, []gtfs.StopTimeUpdate{{StopSequence: &stopSequence}})
+	require.NoError(t, api.GtfsManager.MockUpdateDataQuality(now))
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/data-quality/25.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	entry = data["entry"].(map[string]interface{})
+	assert.Equal(t, float64(now.UnixMilli()), entry["computedAt"])
+
+	var route map[string]interface{}
+	for _, r := range entry["routes"].([]interface{}) {
+		if r.(map[string]interface{})["routeId"] == "25_151" {
+			route = r.(map[string]interface{})
+		}
+	}
+	require.NotNil(t, route, "route 151 has a trip under way")
+	scheduled := route["scheduledTripCount"].(float64)
+	require.Positive(t, scheduled)
+	assert.Equal(t, float64(1), route["tripsWithVehicleCount"])
+	assert.Equal(t, float64(1), route["predictedTripCount"])
+	assert.InDelta(t, 100/scheduled, route["tripsWithVehiclePercent"], 0.05)
+	assert.InDelta(t, 100/scheduled, route["predictedArrivalsPercent"], 0.05)
+	assert.Equal(t, float64(1), route["vehicleCount"])
+	assert.Equal(t, 20.0, route["medianPositionAge"])
+
+	refs := data["references"].(map[string]interface{})
+	assert.Len(t, refs["agencies"], 1)
+	assert.Len(t, refs["routes"], len(entry["routes"].([]interface{})))
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/data-quality/no-such-agency.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,105 @@
+package restapi
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// deadReckoningMinAge is how old a vehicle's position fix must be before the
+// vehicle is moved forward from it rather than reported where it was.
+const deadReckoningMinAge = 5 * time.Second
+
+// deadReckoningMaxAge bounds how far past its last fix a vehicle is moved.
+// Further out an extrapolated position is no better than the schedule's.
+const deadReckoningMaxAge = 2 * time.Minute
+
+// extrapolateVehiclePosition moves a vehicle whose last fix is older than
+// deadReckoningMinAge forward along the trip's shape to where it should be at
+// currentTime, updating the status's distance along trip and position.
+// status.DistanceAlongTrip must already hold the distance at the last fix.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) extrapolateVehiclePosition(
+	ctx context.Context,
+	status *models.TripStatusForTripDetails,
+	vehicle *gtfs.Vehicle,
+	geometry *GTFS.ShapeGeometry,
+	stopTimes []gtfsdb.StopTime,
+	scheduleDeviation int,
+	serviceDate, currentTime time.Time,
+) {
+	if vehicle.Timestamp == nil || status.Stale || currentTime.Sub(*vehicle.Timestamp) < deadReckoningMinAge {
+		return
+	}
+
+	var speed *float32
+	if vehicle.Position != nil {
+		speed = vehicle.Position.Speed
+	}
+
+	var scheduledDistanceAt func(time.Time) (float64, bool)
+	if len(stopTimes) > 0 {
+		var stopDistances []float64
+		scheduledDistanceAt = func(t time.Time) (float64, bool) {
+			if stopDistances == nil {
+				stopDistances = api.stopDistancesAlongShape(ctx, stopTimes, geometry.Points, geometry.CumulativeDistances)
+				if stopDistances == nil {
+					return 0, false
+				}
+			}
+			scheduledTime := utils.ServiceTimeAt(serviceDate, t).Seconds() - int64(scheduleDeviation)
+			return interpolateDistanceAtScheduledTime(scheduledTime, stopTimes, stopDistances), true
+		}
+	}
+
+	distance, ok := extrapolateDistance(status.DistanceAlongTrip, *vehicle.Timestamp, currentTime, speed, scheduledDistanceAt, geometry.Length())
+	if !ok {
+		return
+	}
+	status.DistanceAlongTrip = distance
+	lat, lon := geometry.PointAtDistance(distance)
+	status.Position = models.Location{Lat: lat, Lon: lon}
+	status.PositionIsExtrapolated = true
+}
+
+// extrapolateDistance returns how far along a trip of tripLength meters a
+// vehicle last seen lastDistance meters along at fixTime has got by
+// currentTime. The vehicle keeps its last reported speed when it sent one;
+// otherwise it advances as far as the schedule does over the same period.
+// ok is false when the fix is too recent or the vehicle would not move.
+func extrapolateDistance(
+	lastDistance float64,
+	fixTime, currentTime time.Time,
+	speed *float32,
+	scheduledDistanceAt func(time.Time) (float64, bool),
+	tripLength float64,
+) (float64, bool) {
+	elapsed := currentTime.Sub(fixTime)
+	if elapsed < deadReckoningMinAge {
+		return 0, false
+	}
+	elapsed = min(elapsed, deadReckoningMaxAge)
+
+	var advance float64
+	switch {
+	case speed != nil && *speed > 0:
+		advance = float64(*speed) * elapsed.Seconds()
+	case scheduledDistanceAt != nil:
+		from, okFrom := scheduledDistanceAt(fixTime)
+		to, okTo := scheduledDistanceAt(fixTime.Add(elapsed))
+		if !okFrom || !okTo {
+			return 0, false
+		}
+		advance = to - from
+	}
+	if advance <= 0 {
+		return 0, false
+	}
+	return math.Min(lastDistance+advance, tripLength), true
+}
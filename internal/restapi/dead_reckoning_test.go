@@ -0,0 +1,94 @@
+package restapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestExtrapolateDistance(t *testing.T) {
+	fix := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	speed := func(v float32) *float32 { return &v }
+	// The schedule advances 5 m/s.
+	schedule := func(at time.Time) (float64, bool) {
+		return at.Sub(fix).Seconds() * 5, true
+	}
+	noSchedule := func(time.Time) (float64, bool) { return 0, false }
+
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		speed     *float32
+		schedule  func(time.Time) (float64, bool)
+		want      float64
+		wantMoved bool
+	}{
+		{name: "fix too recent", elapsed: 2 * time.Second, speed: speed(10), schedule: schedule},
+		{name: "reported speed", elapsed: 30 * time.Second, speed: speed(10), schedule: schedule, want: 1300, wantMoved: true},
+		{name: "schedule without speed", elapsed: 30 * time.Second, schedule: schedule, want: 1150, wantMoved: true},
+		{name: "stopped vehicle follows schedule", elapsed: 30 * time.Second, speed: speed(0), schedule: schedule, want: 1150, wantMoved: true},
+		{name: "capped at max age", elapsed: 10 * time.Minute, speed: speed(10), want: 1000 + 10*deadReckoningMaxAge.Seconds(), wantMoved: true},
+		{name: "clamped to trip length", elapsed: time.Minute, speed: speed(100), want: 5000, wantMoved: true},
+		{name: "no speed or schedule", elapsed: 30 * time.Second},
+		{name: "schedule unavailable", elapsed: 30 * time.Second, schedule: noSchedule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, moved := extrapolateDistance(1000, fix, fix.Add(tt.elapsed), tt.speed, tt.schedule, 5000)
+			assert.Equal(t, tt.wantMoved, moved)
+			if tt.wantMoved {
+				assert.InDelta(t, tt.want, got, 1e-6)
+			}
+		})
+	}
+}
+
+func TestBuildTripStatusExtrapolatesOldFix(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := context.Background()
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	var tripID, routeID string
+	var stopTimes []gtfsdb.StopTime
+	for _, trip := range api.GtfsManager.GetTrips() {
+		st, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, trip.ID)
+		if err == nil && len(st) >= 3 {
+			tripID, routeID, stopTimes = trip.ID, trip.Route.Id, st
+			break
+		}
+	}
+	require.NotEmpty(t, tripID)
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, []string{stopTimes[0].StopID})
+	require.NoError(t, err)
+	require.NotEmpty(t, stops)
+	lat, lon := float32(stops[0].Lat), float32(stops[0].Lon)
+
+	serviceDate := time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC)
+	fixTime := serviceDate.Add(utils.StopTimeDuration(stopTimes[0].DepartureTime))
+	currentTime := fixTime.Add(30 * time.Second)
+	speed := float32(10)
+
+	api.GtfsManager.MockAddVehicleWithOptions("DEAD_RECKONING_TEST", tripID, routeID, internalgtfs.MockVehicleOptions{
+		Position:  &gtfs.Position{Latitude: &lat, Longitude: &lon, Speed: &speed},
+		Timestamp: &fixTime,
+	})
+
+	status, err := api.BuildTripStatus(ctx, agencyID, tripID, serviceDate, currentTime)
+	require.NoError(t, err)
+	require.NotNil(t, status)
+
+	assert.True(t, status.PositionIsExtrapolated)
+	assert.InDelta(t, status.LastKnownDistanceAlongTrip+300, status.DistanceAlongTrip, 1e-6)
+	assert.Equal(t, float64(lat), float64(status.LastKnownLocation.Lat), "last known location stays the reported fix")
+	assert.NotEqual(t, status.LastKnownLocation, status.Position)
+}
@@ -0,0 +1,87 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// detoursForRouteHandler lists the trips of a route whose vehicles are off the
+// scheduled shape, as found by detour detection on the vehicle position feeds.
+func (api *RestAPI) detoursForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	agencyID := parsed.AgencyID
+	routeID := parsed.CodeID
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, routeID)
+	if err != nil || route.ID == "" {
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
+		return
+	}
+
+	references := models.NewEmptyReferences()
+	references.Routes = append(references.Routes, models.NewRoute(
+		utils.FormCombinedID(agencyID, route.ID),
+		agencyID,
+		route.ShortName.String,
+		route.LongName.String,
+		route.Desc.String,
+		models.RouteType(route.Type),
+		route.Url.String,
+		route.Color.String,
+		route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder)))
+
+	if agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID); err == nil {
+		references.Agencies = append(references.Agencies, models.NewAgencyReference(
+			agency.ID, agency.Name, agency.Url, agency.Timezone, agency.Lang.String,
+			agency.Phone.String, agency.Email.String, agency.FareUrl.String, "", false,
+		))
+	}
+
+	entry := models.RouteDetours{
+		RouteID: parsed.CombinedID,
+		Trips:   []models.TripDetour{},
+	}
+	deviatedTrips := make(map[string]bool)
+	for _, detour := range api.GtfsManager.GetDetoursForRoute(routeID) {
+		entry.Trips = append(entry.Trips, models.TripDetour{
+			TripID:             utils.FormCombinedID(agencyID, detour.TripID),
+			VehicleID:          utils.FormCombinedID(agencyID, detour.VehicleID),
+			DistanceFromShape:  detour.DistanceFromShape,
+			ConsecutiveUpdates: detour.ConsecutiveUpdates,
+			Since:              detour.Since.UnixMilli(),
+			Position:           models.Location{Lat: detour.Lat, Lon: detour.Lon},
+		})
+		if deviatedTrips[detour.TripID] {
+			continue
+		}
+		deviatedTrips[detour.TripID] = true
+
+		trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, detour.TripID)
+		if err != nil {
+			continue
+		}
+		tripRef := models.NewTripReference(
+			utils.FormCombinedID(agencyID, trip.ID),
+			utils.FormCombinedID(agencyID, trip.RouteID),
+			utils.FormCombinedID(agencyID, trip.ServiceID),
+			trip.TripHeadsign.String,
+			trip.TripShortName.String,
+			trip.DirectionID.Int64,
+			utils.FormCombinedID(agencyID, trip.BlockID.String),
+			utils.FormCombinedID(agencyID, trip.ShapeID.String),
+		)
+		tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+		tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
+		references.Trips = append(references.Trips, tripRef)
+	}
+	entry.DeviatedTripCount = len(deviatedTrips)
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+}
@@ -0,0 +1,119 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+)
+
+func TestDetoursForRouteHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(context.Background(), canceledTestTripID)
+	require.NoError(t, err)
+
+	since := time.Date(2025, 6, 13, 10, 0, 0, 0, time.UTC)
+	api.GtfsManager.MockAddDetour(internalgtfs.Detour{
+		VehicleID:          "bus-7",
+		TripID:             trip.ID,
+		RouteID:            trip.RouteID,
+		DistanceFromShape:  420,
+		ConsecutiveUpdates: 4,
+		Since:              since,
+		Lat:                40.58,
+		Lon:                -122.39,
+	})
+	// Not yet off the shape for long enough to count.
+	api.GtfsManager.MockAddDetour(internalgtfs.Detour{
+		VehicleID:          "bus-8",
+		TripID:             "other-trip",
+		RouteID:            trip.RouteID,
+		DistanceFromShape:  300,
+		ConsecutiveUpdates: 1,
+		Since:              since,
+	})
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/detours-for-route/25_"+trip.RouteID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+
+	assert.Equal(t, "25_"+trip.RouteID, entry["routeId"])
+	assert.Equal(t, 1.0, entry["deviatedTripCount"])
+	trips, ok := entry["trips"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, trips, 1)
+
+	detour := trips[0].(map[string]interface{})
+	assert.Equal(t, "25_"+trip.ID, detour["tripId"])
+	assert.Equal(t, "25_bus-7", detour["vehicleId"])
+	assert.Equal(t, 420.0, detour["distanceFromShape"])
+	assert.Equal(t, 4.0, detour["consecutiveUpdates"])
+	assert.Equal(t, float64(since.UnixMilli()), detour["since"])
+
+	references, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	refTrips, ok := references["trips"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, refTrips, 1)
+	assert.Equal(t, "25_"+trip.ID, refTrips[0].(map[string]interface{})["id"])
+}
+
+func TestDetoursForRouteHandler_NoDetours(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/detours-for-route/25_151.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, 0.0, entry["deviatedTripCount"])
+	assert.Equal(t, []interface{}{}, entry["trips"])
+}
+
+func TestDetoursForRouteHandler_UnknownRoute(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/detours-for-route/25_no-such-route.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestBuildTripStatus_Deviated(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := context.Background()
+
+	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, canceledTestTripID)
+	require.NoError(t, err)
+	api.GtfsManager.MockAddVehicle("bus-7", trip.ID, trip.RouteID)
+
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	currentTime := serviceDate.Add(10 * time.Hour)
+
+	status, err := api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime)
+	require.NoError(t, err)
+	assert.False(t, status.Deviated)
+
+	api.GtfsManager.MockAddDetour(internalgtfs.Detour{
+		VehicleID:          "bus-7",
+		TripID:             trip.ID,
+		RouteID:            trip.RouteID,
+		ConsecutiveUpdates: 3,
+	})
+
+	status, err = api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime)
+	require.NoError(t, err)
+	assert.True(t, status.Deviated)
+}
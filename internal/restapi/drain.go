@@ -0,0 +1,110 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout bounds how long Shutdown waits for in-flight requests.
+const defaultShutdownTimeout = 30 * time.Second
+
+// inFlightRequests counts the requests being served so that shutdown can wait
+// for them to finish.
+type inFlightRequests struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{} // Closed when count drops back to zero
+}
+
+func (t *inFlightRequests) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+}
+
+func (t *inFlightRequests) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count--
+	if t.count == 0 {
+		close(t.idle)
+	}
+}
+
+// wait blocks until no request is in flight or ctx is done.
+func (t *inFlightRequests) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.count == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrackInFlight counts the requests it serves so that ShutdownContext can wait
+// for them, and cancels their contexts when ShutdownContext stops waiting, so
+// that their queries abort and roll back instead of being cut off when the
+// process exits.
+func (api *RestAPI) TrackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.inFlight.start()
+		defer api.inFlight.done()
+
+		if api.abortCtx != nil {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(api.abortCtx, cancel)
+			defer stop()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ShutdownContext gracefully stops the RestAPI: it ends open event streams,
+// waits for requests in flight until ctx is done, cancelling the ones still
+// running then, and stops the rate limiters and arrival webhooks. Call it after the HTTP server has
+// stopped accepting connections.
+func (api *RestAPI) ShutdownContext(ctx context.Context) error {
+	api.CloseStreams()
+
+	var err error
+	if waitErr := api.inFlight.wait(ctx); waitErr != nil {
+		err = fmt.Errorf("in-flight requests still running at shutdown: %w", waitErr)
+	}
+	// Cancel whatever is still running; with nothing left this only releases
+	// the context.
+	if api.abortRequests != nil {
+		api.abortRequests()
+	}
+	for _, regionAPI := range api.regionAPIs {
+		if regionErr := regionAPI.ShutdownContext(ctx); regionErr != nil && err == nil {
+			err = regionErr
+		}
+	}
+
+	if api.rateLimiter != nil {
+		api.rateLimiter.Stop()
+	}
+	if api.ipRateLimiter != nil {
+		api.ipRateLimiter.Stop()
+	}
+	if api.arrivalWebhooks != nil {
+		api.arrivalWebhooks.close()
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveInBackground starts a request through TrackInFlight and returns once
+// the handler is running.
+func serveInBackground(t *testing.T, api *RestAPI, handler http.HandlerFunc) <-chan struct{} {
+	t.Helper()
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	tracked := api.TrackInFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		handler(w, r)
+	}))
+	go func() {
+		defer close(finished)
+		tracked.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("request did not start")
+	}
+	return finished
+}
+
+func TestShutdownContextWaitsForInFlightRequests(t *testing.T) {
+	api := createTestApi(t)
+
+	release := make(chan struct{})
+	finished := serveInBackground(t, api, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- api.ShutdownContext(context.Background())
+	}()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("shutdown returned while a request was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-shutdownDone:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not return after the request finished")
+	}
+	<-finished
+}
+
+func TestShutdownContextCancelsRequestsAtDeadline(t *testing.T) {
+	api := createTestApi(t)
+
+	requestErr := make(chan error, 1)
+	finished := serveInBackground(t, api, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		requestErr <- r.Context().Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := api.ShutdownContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case err := <-requestErr:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request was not canceled")
+	}
+	<-finished
+}
+
+func TestShutdownContextWithoutRequests(t *testing.T) {
+	api := createTestApi(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, api.ShutdownContext(ctx))
+}
@@ -0,0 +1,67 @@
+package restapi
+
+import (
+	"net/http"
+)
+
+// errorCode is the machine-readable errorCode of an error response. Clients
+// branch on it rather than on the human-readable text, which may change.
+type errorCode string
+
+const (
+	// errCodeInvalidParameter reports a missing or malformed request parameter.
+	errCodeInvalidParameter errorCode = "INVALID_PARAMETER"
+	// errCodeDateOutOfRange reports a date outside the loaded feed's validity window.
+	errCodeDateOutOfRange errorCode = "DATE_OUT_OF_RANGE"
+	// errCodeInvalidAPIKey reports a missing or unknown API key.
+	errCodeInvalidAPIKey errorCode = "INVALID_API_KEY"
+	// errCodeNotFound reports a resource that does not exist, when no more
+	// specific code below applies.
+	errCodeNotFound           errorCode = "NOT_FOUND"
+	errCodeAgencyNotFound     errorCode = "AGENCY_NOT_FOUND"
+	errCodeRouteNotFound      errorCode = "ROUTE_NOT_FOUND"
+	errCodeStopNotFound       errorCode = "STOP_NOT_FOUND"
+	errCodeTripNotFound       errorCode = "TRIP_NOT_FOUND"
+	errCodeBlockNotFound      errorCode = "BLOCK_NOT_FOUND"
+	errCodeShapeNotFound      errorCode = "SHAPE_NOT_FOUND"
+	errCodeSituationNotFound  errorCode = "SITUATION_NOT_FOUND"
+	errCodeVehicleNotFound    errorCode = "VEHICLE_NOT_FOUND"
+	errCodeConflict           errorCode = "CONFLICT"
+	errCodeRateLimited        errorCode = "RATE_LIMITED"
+	errCodeInternal           errorCode = "INTERNAL_ERROR"
+	errCodeTimeout            errorCode = "TIMEOUT"
+	errCodeServiceUnavailable errorCode = "SERVICE_UNAVAILABLE"
+	// errCodeFeedStale reports realtime data that is missing because the GTFS-RT
+	// feeds carrying it are stale, rather than because it does not exist.
+	errCodeFeedStale errorCode = "FEED_STALE"
+)
+
+// errorCodeForStatus is the code of an error response that has no more
+// specific one.
+func errorCodeForStatus(status int) errorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return errCodeInvalidParameter
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errCodeInvalidAPIKey
+	case http.StatusNotFound:
+		return errCodeNotFound
+	case http.StatusConflict:
+		return errCodeConflict
+	case http.StatusTooManyRequests:
+		return errCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return errCodeServiceUnavailable
+	default:
+		return errCodeInternal
+	}
+}
+
+// vehicleNotFoundCode is the code for a vehicle missing from the realtime data.
+// While feeds are stale the vehicle may exist but not have been heard from.
+func (api *RestAPI) vehicleNotFoundCode() errorCode {
+	if api.GtfsManager != nil && api.GtfsManager.IsRealtimeDegraded() {
+		return errCodeFeedStale
+	}
+	return errCodeVehicleNotFound
+}
@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected errorCode
+	}{
+		{http.StatusBadRequest, errCodeInvalidParameter},
+		{http.StatusUnauthorized, errCodeInvalidAPIKey},
+		{http.StatusNotFound, errCodeNotFound},
+		{http.StatusConflict, errCodeConflict},
+		{http.StatusTooManyRequests, errCodeRateLimited},
+		{http.StatusServiceUnavailable, errCodeServiceUnavailable},
+		{http.StatusInternalServerError, errCodeInternal},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, errorCodeForStatus(tt.status), http.StatusText(tt.status))
+	}
+}
+
+func TestErrorResponsesCarryErrorCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected errorCode
+	}{
+		{
+			name:     "unknown trip",
+			path:     "/api/where/trip/25_no-such-trip.json?key=TEST",
+			status:   http.StatusNotFound,
+			expected: errCodeTripNotFound,
+		},
+		{
+			name:     "unknown stop",
+			path:     "/api/where/stop/25_no-such-stop.json?key=TEST",
+			status:   http.StatusNotFound,
+			expected: errCodeStopNotFound,
+		},
+		{
+			name:     "unknown agency",
+			path:     "/api/where/agency/no-such-agency.json?key=TEST",
+			status:   http.StatusNotFound,
+			expected: errCodeAgencyNotFound,
+		},
+		{
+			name:     "unknown vehicle",
+			path:     "/api/where/trip-for-vehicle/25_no-such-vehicle.json?key=TEST",
+			status:   http.StatusNotFound,
+			expected: errCodeVehicleNotFound,
+		},
+		{
+			name:     "malformed parameter",
+			path:     "/api/where/stops-for-location.json?key=TEST&lat=invalid&lon=-122.3",
+			status:   http.StatusBadRequest,
+			expected: errCodeInvalidParameter,
+		},
+		{
+			name:     "date outside the feed",
+			path:     "/api/where/schedule-for-stop/25_2000.json?key=TEST&date=2030-01-01",
+			status:   http.StatusBadRequest,
+			expected: errCodeDateOutOfRange,
+		},
+		{
+			name:     "invalid API key",
+			path:     "/api/where/stop/25_2000.json?key=nope",
+			status:   http.StatusUnauthorized,
+			expected: errCodeInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A fresh API per case keeps the requests under the per-key rate limit.
+			api := createTestApi(t)
+			defer api.Shutdown()
+
+			resp, model := serveApiAndRetrieveEndpoint(t, api, tt.path)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, string(tt.expected), model.ErrorCode)
+		})
+	}
+}
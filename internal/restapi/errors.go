@@ -1,7 +1,8 @@
 package restapi
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 
 	"maglev.onebusaway.org/internal/models"
@@ -14,41 +15,45 @@ func (api *RestAPI) invalidAPIKeyResponse(w http.ResponseWriter, r *http.Request
 	response := struct {
 		Code        int    `json:"code"`
 		CurrentTime int64  `json:"currentTime"`
+		ErrorCode   string `json:"errorCode"`
 		Text        string `json:"text"`
 		Version     int    `json:"version"`
 	}{
 		Code:        http.StatusUnauthorized,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(errCodeInvalidAPIKey),
 		Text:        "permission denied",
 		Version:     1, // Note: This is version 1, not 2 as in a successful response. Probably a mistake, but back-compat.
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusUnauthorized)
-	err := json.NewEncoder(w).Encode(response)
+	err := api.writeResponse(w, r, http.StatusUnauthorized, response)
 	if err != nil {
 		api.Logger.Error("failed to encode invalid API key response", "error", err)
 	}
 }
 
 func (api *RestAPI) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		api.timeoutResponse(w, r)
+		return
+	}
 	api.Logger.Error("internal server error", "error", err, "path", r.URL.Path)
 	// Send a 500 Internal Server Error response
 	response := struct {
 		Code        int    `json:"code"`
 		CurrentTime int64  `json:"currentTime"`
+		ErrorCode   string `json:"errorCode"`
 		Text        string `json:"text"`
 		Version     int    `json:"version"`
 	}{
 		Code:        http.StatusInternalServerError,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(errCodeInternal),
 		Text:        "internal server error",
 		Version:     1,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
-	encoderErr := json.NewEncoder(w).Encode(response)
+	encoderErr := api.writeResponse(w, r, http.StatusInternalServerError, response)
 	if encoderErr != nil {
 		api.Logger.Error("failed to encode server error response", "error", encoderErr)
 	}
@@ -56,6 +61,12 @@ func (api *RestAPI) serverErrorResponse(w http.ResponseWriter, r *http.Request,
 
 // validationErrorResponse sends a 400 Bad Request response with field-specific validation errors
 func (api *RestAPI) validationErrorResponse(w http.ResponseWriter, r *http.Request, fieldErrors map[string][]string) {
+	api.validationErrorResponseWithCode(w, r, errCodeInvalidParameter, fieldErrors)
+}
+
+// validationErrorResponseWithCode is validationErrorResponse for errors that
+// have a more specific code than INVALID_PARAMETER.
+func (api *RestAPI) validationErrorResponseWithCode(w http.ResponseWriter, r *http.Request, code errorCode, fieldErrors map[string][]string) {
 	errorText := "validation error"
 	for _, errs := range fieldErrors {
 		if len(errs) > 0 {
@@ -67,12 +78,14 @@ func (api *RestAPI) validationErrorResponse(w http.ResponseWriter, r *http.Reque
 	response := struct {
 		Code        int         `json:"code"`
 		CurrentTime int64       `json:"currentTime"`
+		ErrorCode   string      `json:"errorCode"`
 		Text        string      `json:"text"`
 		Version     int         `json:"version"`
 		Data        interface{} `json:"data"`
 	}{
 		Code:        http.StatusBadRequest,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(code),
 		Text:        errorText,
 		Version:     2,
 		Data: struct {
@@ -82,9 +95,7 @@ func (api *RestAPI) validationErrorResponse(w http.ResponseWriter, r *http.Reque
 		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	err := json.NewEncoder(w).Encode(response)
+	err := api.writeResponse(w, r, http.StatusBadRequest, response)
 	if err != nil {
 		api.Logger.Error("failed to encode validation error response", "error", err)
 	}
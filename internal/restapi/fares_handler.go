@@ -0,0 +1,242 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// faresForRouteHandler lists the fares that can apply to rides on a route,
+// cheapest first.
+func (api *RestAPI) faresForRouteHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, parsed.CodeID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
+		return
+	}
+
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetFaresForRoute(ctx, sql.NullString{String: route.ID, Valid: true})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	fares := make([]models.Fare, 0)
+	for _, group := range groupFareRows(rows) {
+		fares = append(fares, newFare(group, parsed.AgencyID))
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(fares, api.fareReferences(ctx, parsed.AgencyID), false, api.Clock))
+}
+
+// fareForTripHandler returns the cheapest fare for a ride on a trip. The ride
+// runs from the fromStop to the toStop parameters, which default to the first
+// and last stops of the trip.
+func (api *RestAPI) fareForTripHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	ctx := r.Context()
+	queryParams := r.URL.Query()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, parsed.CodeID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
+		return
+	}
+
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, trip.ID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(stopTimes) == 0 {
+		api.sendNotFound(w, r)
+		return
+	}
+
+	board, alight := 0, len(stopTimes)-1
+	fieldErrors := make(map[string][]string)
+	if fromStop := queryParams.Get("fromStop"); fromStop != "" {
+		board = stopTimeIndex(stopTimes, fromStop, 0)
+		if board < 0 {
+			fieldErrors["fromStop"] = []string{"stop is not served by the trip"}
+		}
+	}
+	if toStop := queryParams.Get("toStop"); toStop != "" && board >= 0 {
+		alight = stopTimeIndex(stopTimes, toStop, board+1)
+		if alight < 0 {
+			fieldErrors["toStop"] = []string{"stop is not served by the trip after fromStop"}
+		}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ride := stopTimes[board : alight+1]
+	stopIDs := make([]string, len(ride))
+	for i, st := range ride {
+		stopIDs[i] = st.StopID
+	}
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	stopZones := make(map[string]string, len(stops))
+	zones := make(map[string]bool)
+	for _, stop := range stops {
+		if stop.ZoneID.String != "" {
+			stopZones[stop.ID] = stop.ZoneID.String
+			zones[stop.ZoneID.String] = true
+		}
+	}
+	origin := stopZones[ride[0].StopID]
+	destination := stopZones[ride[len(ride)-1].StopID]
+
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetFaresForRoute(ctx, sql.NullString{String: trip.RouteID, Valid: true})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// The groups are ordered by price, so the first fare that applies is the cheapest.
+	for _, group := range groupFareRows(rows) {
+		if fareApplies(group, origin, destination, zones) {
+			fare := newFare(group, parsed.AgencyID)
+			api.sendResponse(w, r, models.NewEntryResponse(fare, api.fareReferences(ctx, parsed.AgencyID), api.Clock))
+			return
+		}
+	}
+	api.sendNotFound(w, r)
+}
+
+// stopTimeIndex returns the index of the first stop time at or after start
+// that serves the stop with the given combined ID, or -1.
+func stopTimeIndex(stopTimes []gtfsdb.StopTime, combinedStopID string, start int) int {
+	_, stopID, err := utils.ExtractAgencyIDAndCodeID(combinedStopID)
+	if err != nil {
+		return -1
+	}
+	for i := start; i < len(stopTimes); i++ {
+		if stopTimes[i].StopID == stopID {
+			return i
+		}
+	}
+	return -1
+}
+
+// groupFareRows splits the rows of GetFaresForRoute into one group per fare,
+// keeping their order.
+func groupFareRows(rows []gtfsdb.GetFaresForRouteRow) [][]gtfsdb.GetFaresForRouteRow {
+	var groups [][]gtfsdb.GetFaresForRouteRow
+	for _, row := range rows {
+		if n := len(groups); n > 0 && groups[n-1][0].FareID == row.FareID {
+			groups[n-1] = append(groups[n-1], row)
+			continue
+		}
+		groups = append(groups, []gtfsdb.GetFaresForRouteRow{row})
+	}
+	return groups
+}
+
+// unrestrictedFareRow reports whether a row places no zone limits on its fare.
+func unrestrictedFareRow(row gtfsdb.GetFaresForRouteRow) bool {
+	return !row.OriginID.Valid && !row.DestinationID.Valid && !row.ContainsID.Valid
+}
+
+// fareApplies reports whether the rules of a fare cover a ride boarding in the
+// origin zone, alighting in the destination zone and passing through zones.
+// Following GTFS, a fare limited by contains_id applies only when its rules
+// list exactly the zones the ride passes through.
+func fareApplies(rows []gtfsdb.GetFaresForRouteRow, origin, destination string, zones map[string]bool) bool {
+	contained := make(map[string]bool)
+	for _, row := range rows {
+		if row.OriginID.Valid && row.OriginID.String != origin {
+			continue
+		}
+		if row.DestinationID.Valid && row.DestinationID.String != destination {
+			continue
+		}
+		if !row.ContainsID.Valid {
+			return true
+		}
+		contained[row.ContainsID.String] = true
+	}
+
+	if len(contained) == 0 || len(contained) != len(zones) {
+		return false
+	}
+	for zone := range zones {
+		if !contained[zone] {
+			return false
+		}
+	}
+	return true
+}
+
+// newFare builds the fare model of one group of GetFaresForRoute rows. Fares
+// without an agency_id belong to the agency of the route.
+func newFare(rows []gtfsdb.GetFaresForRouteRow, routeAgencyID string) models.Fare {
+	first := rows[0]
+	agencyID := routeAgencyID
+	if first.AgencyID.String != "" {
+		agencyID = first.AgencyID.String
+	}
+
+	fare := models.Fare{
+		ID:            utils.FormCombinedID(agencyID, first.FareID),
+		AgencyID:      agencyID,
+		Price:         first.Price,
+		CurrencyType:  first.CurrencyType,
+		PaymentMethod: models.FarePaymentOnBoard,
+		Rules:         []models.FareRule{},
+	}
+	if first.PaymentMethod == 1 {
+		fare.PaymentMethod = models.FarePaymentBeforeBoarding
+	}
+	if first.Transfers.Valid {
+		fare.Transfers = &first.Transfers.Int64
+	}
+	if first.TransferDuration.Valid {
+		fare.TransferDuration = &first.TransferDuration.Int64
+	}
+
+	for _, row := range rows {
+		if unrestrictedFareRow(row) {
+			// The fare applies to every ride on the route, whatever its other rules say.
+			fare.Rules = []models.FareRule{}
+			break
+		}
+		fare.Rules = append(fare.Rules, models.FareRule{
+			OriginZoneID:      row.OriginID.String,
+			DestinationZoneID: row.DestinationID.String,
+			ContainsZoneID:    row.ContainsID.String,
+		})
+	}
+	return fare
+}
+
+// fareReferences references the agency the fares were requested for.
+func (api *RestAPI) fareReferences(ctx context.Context, agencyID string) models.ReferencesModel {
+	references := models.NewEmptyReferences()
+	if agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID); err == nil {
+		references.Agencies = append(references.Agencies, models.NewAgencyReference(
+			agency.ID, agency.Name, agency.Url, agency.Timezone, agency.Lang.String,
+			agency.Phone.String, agency.Email.String, agency.FareUrl.String, "", false,
+		))
+	}
+	return references
+}
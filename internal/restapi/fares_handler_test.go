@@ -0,0 +1,106 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+func TestFaresForRouteHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/fares-for-route/25_161.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	list, ok := data["list"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, list, 1)
+
+	fare := list[0].(map[string]interface{})
+	assert.Equal(t, "25_64", fare["id"])
+	assert.Equal(t, "25", fare["agencyId"])
+	assert.Equal(t, 4.0, fare["price"])
+	assert.Equal(t, "USD", fare["currencyType"])
+	assert.Equal(t, "onBoard", fare["paymentMethod"])
+	assert.Nil(t, fare["transfers"], "RABA fares allow unlimited transfers")
+	assert.Empty(t, fare["rules"])
+
+	refs := data["references"].(map[string]interface{})
+	assert.Len(t, refs["agencies"], 1)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/fares-for-route/25_no-such-route.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFareForTripHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	const tripID = "84f4520e-88b6-4ee6-8975-856799bc1359" // route 151
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(context.Background(), tripID)
+	require.NoError(t, err)
+	require.Greater(t, len(stopTimes), 2)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/fare-for-trip/25_"+tripID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "25_63", entry["id"])
+	assert.Equal(t, 2.0, entry["price"])
+
+	ride := "&fromStop=25_" + stopTimes[1].StopID + "&toStop=25_" + stopTimes[2].StopID
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/fare-for-trip/25_"+tripID+".json?key=TEST"+ride)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	backwards := "&fromStop=25_" + stopTimes[2].StopID + "&toStop=25_" + stopTimes[1].StopID
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/fare-for-trip/25_"+tripID+".json?key=TEST"+backwards)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/fare-for-trip/25_"+tripID+".json?key=TEST&fromStop=25_no-such-stop")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/fare-for-trip/25_no-such-trip.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFareApplies(t *testing.T) {
+	zone := func(id string) sql.NullString { return sql.NullString{String: id, Valid: id != ""} }
+	rule := func(origin, destination, contains string) gtfsdb.GetFaresForRouteRow {
+		return gtfsdb.GetFaresForRouteRow{OriginID: zone(origin), DestinationID: zone(destination), ContainsID: zone(contains)}
+	}
+	zones := func(ids ...string) map[string]bool {
+		set := make(map[string]bool)
+		for _, id := range ids {
+			set[id] = true
+		}
+		return set
+	}
+
+	tests := []struct {
+		name     string
+		rules    []gtfsdb.GetFaresForRouteRow
+		origin   string
+		dest     string
+		zones    map[string]bool
+		expected bool
+	}{
+		{"no zone limits", []gtfsdb.GetFaresForRouteRow{rule("", "", "")}, "A", "C", zones("A", "B", "C"), true},
+		{"matching origin and destination", []gtfsdb.GetFaresForRouteRow{rule("A", "C", "")}, "A", "C", zones("A", "C"), true},
+		{"other destination", []gtfsdb.GetFaresForRouteRow{rule("A", "B", "")}, "A", "C", zones("A", "C"), false},
+		{"contains every zone", []gtfsdb.GetFaresForRouteRow{rule("", "", "A"), rule("", "", "B")}, "A", "B", zones("A", "B"), true},
+		{"contains too few zones", []gtfsdb.GetFaresForRouteRow{rule("", "", "A")}, "A", "B", zones("A", "B"), false},
+		{"contains extra zones", []gtfsdb.GetFaresForRouteRow{rule("", "", "A"), rule("", "", "B"), rule("", "", "C")}, "A", "B", zones("A", "B"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fareApplies(tt.rules, tt.origin, tt.dest, tt.zones))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package restapi
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// feedInfoHandler reports the import metadata and feed_info.txt contents of the
+// loaded static GTFS dataset so operators can verify which feed a server is running.
+func (api *RestAPI) feedInfoHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	metadata, err := api.GtfsManager.GtfsDB.Queries.GetImportMetadata(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		api.sendNotFound(w, r)
+		return
+	}
+	if err != nil {
+		api.serverErrorResponse(w, r, fmt.Errorf("failed to load import metadata: %w", err))
+		return
+	}
+
+	counts, err := api.GtfsManager.GtfsDB.TableCounts()
+	if err != nil {
+		api.serverErrorResponse(w, r, fmt.Errorf("failed to count GTFS tables: %w", err))
+		return
+	}
+
+	entry := models.FeedMetadata{
+		FileHash:    metadata.FileHash,
+		ImportTime:  metadata.ImportTime * 1000,
+		Source:      redactFeedSource(metadata.FileSource),
+		TableCounts: counts,
+	}
+
+	feedInfo, err := api.GtfsManager.GtfsDB.Queries.GetFeedInfo(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		api.serverErrorResponse(w, r, fmt.Errorf("failed to load feed info: %w", err))
+		return
+	}
+	if err == nil {
+		entry.FeedInfo = &models.FeedInfo{
+			PublisherName: feedInfo.FeedPublisherName,
+			PublisherURL:  feedInfo.FeedPublisherUrl,
+			Lang:          feedInfo.FeedLang,
+			DefaultLang:   feedInfo.DefaultLang.String,
+			StartDate:     feedInfo.FeedStartDate.String,
+			EndDate:       feedInfo.FeedEndDate.String,
+			Version:       feedInfo.FeedVersion.String,
+			ContactEmail:  feedInfo.FeedContactEmail.String,
+			ContactURL:    feedInfo.FeedContactUrl.String,
+		}
+	}
+
+	response := models.NewEntryResponse(entry, models.NewEmptyReferences(), api.Clock)
+	api.sendResponse(w, r, response)
+}
+
+// redactFeedSource strips credentials and query parameters, which commonly carry
+// API keys, from a feed URL. Local paths are returned unchanged.
+func redactFeedSource(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return source
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,41 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// feedValidityMessage explains why targetDate (YYYYMMDD) cannot be served, or
+// returns "" when it lies within the feed_start_date/feed_end_date window from
+// feed_info.txt. Feeds without feed_info.txt, or without those dates, cover
+// every date.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) feedValidityMessage(ctx context.Context, targetDate string) (string, error) {
+	feedInfo, err := api.GtfsManager.GtfsDB.Queries.GetFeedInfo(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load feed info: %w", err)
+	}
+
+	start, end := feedInfo.FeedStartDate, feedInfo.FeedEndDate
+	if (start.Valid && targetDate < start.String) || (end.Valid && targetDate > end.String) {
+		return fmt.Sprintf("date %s is outside the feed validity window (%s to %s)",
+			displayFeedDate(targetDate), displayFeedDate(start.String), displayFeedDate(end.String)), nil
+	}
+	return "", nil
+}
+
+// displayFeedDate renders a GTFS YYYYMMDD date as YYYY-MM-DD, the format the
+// API accepts, or "open" for a missing bound.
+func displayFeedDate(date string) string {
+	t, err := time.Parse("20060102", date)
+	if err != nil {
+		return "open"
+	}
+	return t.Format("2006-01-02")
+}
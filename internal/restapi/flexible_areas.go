@@ -0,0 +1,197 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// buildStopFlexibleAreas returns the GTFS-Flex areas a stop belongs to: the
+// location groups listing it and the zones whose geometry contains it.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) buildStopFlexibleAreas(ctx context.Context, agencyID, stopID string, lat, lon float64) ([]models.FlexibleArea, error) {
+	queries := api.GtfsManager.GtfsDB.Queries
+
+	groups, err := queries.GetLocationGroupsForStop(ctx, stopID)
+	if err != nil {
+		return nil, err
+	}
+	var areas []models.FlexibleArea
+	for _, group := range groups {
+		area, err := api.locationGroupArea(ctx, agencyID, group)
+		if err != nil {
+			return nil, err
+		}
+		areas = append(areas, area)
+	}
+
+	zones, err := queries.GetLocationsContainingPoint(ctx, gtfsdb.GetLocationsContainingPointParams{
+		Lat: lat,
+		Lon: lon,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range zones {
+		contains, err := utils.GeoJSONContains([]byte(zone.Geometry), lat, lon)
+		if err != nil {
+			api.Logger.Warn("skipping zone with unreadable geometry", "location_id", zone.ID, "error", err)
+			continue
+		}
+		if contains {
+			areas = append(areas, zoneArea(agencyID, zone))
+		}
+	}
+	return areas, nil
+}
+
+// buildTripFlexibleAreas returns the GTFS-Flex stop times of a trip, with
+// their areas and booking rules resolved. Trips without flexible service
+// return nil.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) buildTripFlexibleAreas(ctx context.Context, agencyID, tripID string) ([]models.TripFlexibleArea, error) {
+	queries := api.GtfsManager.GtfsDB.Queries
+
+	rows, err := queries.GetFlexStopTimesForTrip(ctx, tripID)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	bookingRules := make(map[string]*models.BookingRule)
+	bookingRule := func(id sql.NullString) (*models.BookingRule, error) {
+		if !id.Valid {
+			return nil, nil
+		}
+		if rule, ok := bookingRules[id.String]; ok {
+			return rule, nil
+		}
+		row, err := queries.GetBookingRule(ctx, id.String)
+		if errors.Is(err, sql.ErrNoRows) {
+			bookingRules[id.String] = nil
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rule := newBookingRuleModel(agencyID, row)
+		bookingRules[id.String] = rule
+		return rule, nil
+	}
+
+	areas := make([]models.TripFlexibleArea, 0, len(rows))
+	for _, row := range rows {
+		entry := models.TripFlexibleArea{
+			StopSequence:      int(row.StopSequence),
+			PickupType:        int(row.PickupType),
+			DropOffType:       int(row.DropOffType),
+			ContinuousPickup:  int(row.ContinuousPickup),
+			ContinuousDropOff: int(row.ContinuousDropOff),
+		}
+		if row.StartPickupDropOffWindow.Valid {
+			start := int(row.StartPickupDropOffWindow.Int64)
+			entry.StartPickupDropOffWindow = &start
+		}
+		if row.EndPickupDropOffWindow.Valid {
+			end := int(row.EndPickupDropOffWindow.Int64)
+			entry.EndPickupDropOffWindow = &end
+		}
+
+		switch {
+		case row.LocationID.Valid:
+			zone, err := queries.GetLocation(ctx, row.LocationID.String)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			if err == nil {
+				area := zoneArea(agencyID, zone)
+				entry.Area = &area
+			}
+		case row.LocationGroupID.Valid:
+			group, err := queries.GetLocationGroup(ctx, row.LocationGroupID.String)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			if err == nil {
+				area, err := api.locationGroupArea(ctx, agencyID, group)
+				if err != nil {
+					return nil, err
+				}
+				entry.Area = &area
+			}
+		case row.StopID.Valid:
+			entry.StopID = utils.FormCombinedID(agencyID, row.StopID.String)
+		}
+
+		if entry.PickupBookingRule, err = bookingRule(row.PickupBookingRuleID); err != nil {
+			return nil, err
+		}
+		if entry.DropOffBookingRule, err = bookingRule(row.DropOffBookingRuleID); err != nil {
+			return nil, err
+		}
+		areas = append(areas, entry)
+	}
+	return areas, nil
+}
+
+func (api *RestAPI) locationGroupArea(ctx context.Context, agencyID string, group gtfsdb.LocationGroup) (models.FlexibleArea, error) {
+	stopIDs, err := api.GtfsManager.GtfsDB.Queries.GetLocationGroupStopIDs(ctx, group.ID)
+	if err != nil {
+		return models.FlexibleArea{}, err
+	}
+	combined := make([]string, len(stopIDs))
+	for i, id := range stopIDs {
+		combined[i] = utils.FormCombinedID(agencyID, id)
+	}
+	return models.FlexibleArea{
+		ID:      utils.FormCombinedID(agencyID, group.ID),
+		Kind:    models.FlexibleAreaLocationGroup,
+		Name:    group.LocationGroupName.String,
+		StopIDs: combined,
+	}, nil
+}
+
+func zoneArea(agencyID string, zone gtfsdb.Location) models.FlexibleArea {
+	return models.FlexibleArea{
+		ID:          utils.FormCombinedID(agencyID, zone.ID),
+		Kind:        models.FlexibleAreaZone,
+		Name:        zone.StopName.String,
+		Description: zone.StopDesc.String,
+		Geometry:    json.RawMessage(zone.Geometry),
+	}
+}
+
+func newBookingRuleModel(agencyID string, row gtfsdb.BookingRule) *models.BookingRule {
+	rule := &models.BookingRule{
+		ID:                     utils.FormCombinedID(agencyID, row.ID),
+		BookingType:            int(row.BookingType),
+		PriorNoticeDurationMin: nullIntPtr(row.PriorNoticeDurationMin),
+		PriorNoticeDurationMax: nullIntPtr(row.PriorNoticeDurationMax),
+		PriorNoticeLastDay:     nullIntPtr(row.PriorNoticeLastDay),
+		PriorNoticeLastTime:    nullIntPtr(row.PriorNoticeLastTime),
+		PriorNoticeStartDay:    nullIntPtr(row.PriorNoticeStartDay),
+		PriorNoticeStartTime:   nullIntPtr(row.PriorNoticeStartTime),
+		Message:                row.Message.String,
+		PickupMessage:          row.PickupMessage.String,
+		DropOffMessage:         row.DropOffMessage.String,
+		PhoneNumber:            row.PhoneNumber.String,
+		InfoURL:                row.InfoUrl.String,
+		BookingURL:             row.BookingUrl.String,
+	}
+	if row.PriorNoticeServiceID.Valid {
+		rule.PriorNoticeServiceID = utils.FormCombinedID(agencyID, row.PriorNoticeServiceID.String)
+	}
+	return rule
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
@@ -0,0 +1,141 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// insertTestFlexData adds a zone around stop 2000, a location group holding
+// it, a booking rule and two flexible stop times on tripID to the shared test
+// database, and removes them again when the test ends.
+func insertTestFlexData(t *testing.T, api *RestAPI, tripID string) {
+	t.Helper()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	queries := client.Queries
+
+	stop, err := queries.GetStop(ctx, "2000")
+	require.NoError(t, err)
+
+	geometry := fmt.Sprintf(`{"type":"Polygon","coordinates":[[[%[1]f,%[3]f],[%[2]f,%[3]f],[%[2]f,%[4]f],[%[1]f,%[4]f],[%[1]f,%[3]f]]]}`,
+		stop.Lon-0.01, stop.Lon+0.01, stop.Lat-0.01, stop.Lat+0.01)
+	require.NoError(t, queries.CreateLocation(ctx, gtfsdb.CreateLocationParams{
+		ID:       "TEST_ZONE",
+		StopName: sql.NullString{String: "Test zone", Valid: true},
+		Geometry: geometry,
+		MinLat:   stop.Lat - 0.01,
+		MinLon:   stop.Lon - 0.01,
+		MaxLat:   stop.Lat + 0.01,
+		MaxLon:   stop.Lon + 0.01,
+	}))
+	require.NoError(t, queries.CreateLocationGroup(ctx, gtfsdb.CreateLocationGroupParams{
+		ID:                "TEST_GROUP",
+		LocationGroupName: sql.NullString{String: "Test group", Valid: true},
+	}))
+	require.NoError(t, queries.CreateLocationGroupStop(ctx, gtfsdb.CreateLocationGroupStopParams{
+		LocationGroupID: "TEST_GROUP",
+		StopID:          "2000",
+	}))
+	require.NoError(t, queries.CreateBookingRule(ctx, gtfsdb.CreateBookingRuleParams{
+		ID:                     "TEST_RULE",
+		BookingType:            1,
+		PriorNoticeDurationMin: sql.NullInt64{Int64: 30, Valid: true},
+		PhoneNumber:            sql.NullString{String: "555-0100", Valid: true},
+	}))
+	require.NoError(t, queries.CreateFlexStopTime(ctx, gtfsdb.CreateFlexStopTimeParams{
+		TripID:                   tripID,
+		StopSequence:             1001,
+		LocationGroupID:          sql.NullString{String: "TEST_GROUP", Valid: true},
+		StartPickupDropOffWindow: sql.NullInt64{Int64: 8 * 3600, Valid: true},
+		EndPickupDropOffWindow:   sql.NullInt64{Int64: 18 * 3600, Valid: true},
+		PickupType:               2,
+		ContinuousPickup:         1,
+		ContinuousDropOff:        1,
+		PickupBookingRuleID:      sql.NullString{String: "TEST_RULE", Valid: true},
+	}))
+	require.NoError(t, queries.CreateFlexStopTime(ctx, gtfsdb.CreateFlexStopTimeParams{
+		TripID:            tripID,
+		StopSequence:      1002,
+		LocationID:        sql.NullString{String: "TEST_ZONE", Valid: true},
+		DropOffType:       2,
+		ContinuousPickup:  1,
+		ContinuousDropOff: 1,
+	}))
+
+	t.Cleanup(func() {
+		for _, stmt := range []string{
+			"DELETE FROM flex_stop_times WHERE stop_sequence IN (1001, 1002)",
+			"DELETE FROM booking_rules WHERE id = 'TEST_RULE'",
+			"DELETE FROM location_group_stops WHERE location_group_id = 'TEST_GROUP'",
+			"DELETE FROM location_groups WHERE id = 'TEST_GROUP'",
+			"DELETE FROM locations WHERE id = 'TEST_ZONE'",
+		} {
+			_, err := client.DB.ExecContext(context.Background(), stmt)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestStopAndTripIncludeFlexibleAreas(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	tripID := api.GtfsManager.GetTrips()[0].ID
+	insertTestFlexData(t, api, tripID)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/25_2000.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	areas, ok := entry["flexibleAreas"].([]interface{})
+	require.True(t, ok, "stop should list its flexible areas")
+	require.Len(t, areas, 2)
+
+	group := areas[0].(map[string]interface{})
+	assert.Equal(t, "25_TEST_GROUP", group["id"])
+	assert.Equal(t, "locationGroup", group["kind"])
+	assert.Equal(t, []interface{}{"25_2000"}, group["stopIds"])
+	zone := areas[1].(map[string]interface{})
+	assert.Equal(t, "25_TEST_ZONE", zone["id"])
+	assert.Equal(t, "zone", zone["kind"])
+	assert.Equal(t, "Polygon", zone["geometry"].(map[string]interface{})["type"])
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/trip/"+utils.FormCombinedID("25", tripID)+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	tripAreas, ok := entry["flexibleAreas"].([]interface{})
+	require.True(t, ok, "trip should list its flexible stop times")
+	require.Len(t, tripAreas, 2)
+
+	first := tripAreas[0].(map[string]interface{})
+	assert.Equal(t, float64(1001), first["stopSequence"])
+	assert.Equal(t, float64(8*3600), first["startPickupDropOffWindow"])
+	assert.Equal(t, float64(18*3600), first["endPickupDropOffWindow"])
+	assert.Equal(t, "25_TEST_GROUP", first["area"].(map[string]interface{})["id"])
+	rule := first["pickupBookingRule"].(map[string]interface{})
+	assert.Equal(t, "25_TEST_RULE", rule["id"])
+	assert.Equal(t, float64(30), rule["priorNoticeDurationMin"])
+	assert.Equal(t, "555-0100", rule["phoneNumber"])
+	assert.NotContains(t, first, "dropOffBookingRule")
+
+	second := tripAreas[1].(map[string]interface{})
+	assert.Equal(t, "25_TEST_ZONE", second["area"].(map[string]interface{})["id"])
+	assert.NotContains(t, second, "startPickupDropOffWindow")
+}
+
+func TestStopWithoutFlexibleAreasOmitsField(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/25_2000.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.NotContains(t, entry, "flexibleAreas")
+}
@@ -0,0 +1,114 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// newFrequencyModel converts a frequencies.txt row into the API representation,
+// anchoring the window to the given service date.
+func newFrequencyModel(f gtfsdb.Frequency, serviceDate time.Time) *models.Frequency {
+	return &models.Frequency{
+		StartTime: serviceDate.Add(utils.StopTimeDuration(f.StartTime)).UnixMilli(),
+		EndTime:   serviceDate.Add(utils.StopTimeDuration(f.EndTime)).UnixMilli(),
+		Headway:   int(f.HeadwaySecs),
+	}
+}
+
+// getTripFrequency returns the frequency window of a headway-based trip that is in
+// effect at currentTime, falling back to the next upcoming window and finally the
+// last one of the day. Returns nil for trips that are not headway-based; exact_times
+// trips are exposed as ordinary scheduled trips.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) getTripFrequency(ctx context.Context, tripID string, serviceDate, currentTime time.Time) *models.Frequency {
+	frequencies, err := api.GtfsManager.GtfsDB.Queries.GetFrequenciesForTrip(ctx, tripID)
+	if err != nil || len(frequencies) == 0 || frequencies[0].ExactTimes != 0 {
+		return nil
+	}
+
+	sinceMidnight := utils.ServiceTimeAt(serviceDate, currentTime).Seconds()
+	for _, f := range frequencies {
+		if sinceMidnight < f.EndTime {
+			return newFrequencyModel(f, serviceDate)
+		}
+	}
+	return newFrequencyModel(frequencies[len(frequencies)-1], serviceDate)
+}
+
+// tripStartTimes returns the first-stop departure time of each trip, in seconds
+// since service-day midnight. Frequency-based trips define their stop_times
+// relative to this value.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) tripStartTimes(ctx context.Context, tripIDs []string) (map[string]int64, error) {
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := make(map[string]int64, len(tripIDs))
+	for _, st := range stopTimes {
+		// Rows are ordered by trip_id, stop_sequence so the first row per trip wins.
+		if _, ok := starts[st.TripID]; !ok {
+			starts[st.TripID] = st.DepartureTime
+		}
+	}
+	return starts, nil
+}
+
+// frequencyTripStarts enumerates the trip start times generated by a frequency
+// window [startTime, endTime) with the given headway, keeping only the trips that
+// reach a stop `offset` seconds after departure within [windowStart, windowEnd].
+// All values are seconds since service-day midnight.
+func frequencyTripStarts(startTime, endTime, headway, offset, windowStart, windowEnd int64) []int64 {
+	if headway <= 0 || endTime <= startTime {
+		return nil
+	}
+
+	first := startTime
+	if earliest := windowStart - offset; earliest > startTime {
+		steps := (earliest - startTime + headway - 1) / headway
+		first = startTime + steps*headway
+	}
+
+	var starts []int64
+	for s := first; s < endTime && s+offset <= windowEnd; s += headway {
+		starts = append(starts, s)
+	}
+	return starts
+}
+
+// expandFrequencyStopTime materializes the individual trips of a frequency-based
+// template stop time whose arrival or departure falls within the window. The
+// returned rows carry the shifted times so they can be processed exactly like
+// regular scheduled stop times.
+func expandFrequencyStopTime(row gtfsdb.GetFrequencyStopTimesForStopRow, tripStart, windowStart, windowEnd int64) []gtfsdb.GetStopTimesForStopInWindowRow {
+	arrivalOffset := row.ArrivalTime - tripStart
+	departureOffset := row.DepartureTime - tripStart
+
+	starts := frequencyTripStarts(row.StartTime, row.EndTime, row.HeadwaySecs, arrivalOffset, windowStart-(departureOffset-arrivalOffset), windowEnd)
+
+	expanded := make([]gtfsdb.GetStopTimesForStopInWindowRow, 0, len(starts))
+	for _, s := range starts {
+		expanded = append(expanded, gtfsdb.GetStopTimesForStopInWindowRow{
+			TripID:            row.TripID,
+			ArrivalTime:       s + arrivalOffset,
+			DepartureTime:     s + departureOffset,
+			StopID:            row.StopID,
+			StopSequence:      row.StopSequence,
+			StopHeadsign:      row.StopHeadsign,
+			PickupType:        row.PickupType,
+			DropOffType:       row.DropOffType,
+			ShapeDistTraveled: row.ShapeDistTraveled,
+			Timepoint:         row.Timepoint,
+			RouteID:           row.RouteID,
+			ServiceID:         row.ServiceID,
+			TripHeadsign:      row.TripHeadsign,
+			BlockID:           row.BlockID,
+		})
+	}
+	return expanded
+}
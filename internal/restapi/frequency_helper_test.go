@@ -0,0 +1,108 @@
+package restapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestFrequencyTripStarts(t *testing.T) {
+	h := func(hours, minutes int) int64 {
+		return int64(hours*3600 + minutes*60)
+	}
+
+	tests := []struct {
+		name        string
+		start, end  int64
+		headway     int64
+		offset      int64
+		windowStart int64
+		windowEnd   int64
+		expected    []int64
+	}{
+		{
+			name:  "window inside frequency block",
+			start: h(6, 0), end: h(9, 0), headway: 600,
+			windowStart: h(7, 0), windowEnd: h(7, 30),
+			expected: []int64{h(7, 0), h(7, 10), h(7, 20), h(7, 30)},
+		},
+		{
+			name:  "offset shifts the trips that reach the stop",
+			start: h(6, 0), end: h(9, 0), headway: 600, offset: h(0, 15),
+			windowStart: h(7, 0), windowEnd: h(7, 20),
+			expected: []int64{h(6, 50), h(7, 0)},
+		},
+		{
+			name:  "end time is exclusive",
+			start: h(6, 0), end: h(7, 0), headway: 1800,
+			windowStart: h(5, 0), windowEnd: h(8, 0),
+			expected: []int64{h(6, 0), h(6, 30)},
+		},
+		{
+			name:  "window before service",
+			start: h(6, 0), end: h(9, 0), headway: 600,
+			windowStart: h(4, 0), windowEnd: h(5, 0),
+			expected: nil,
+		},
+		{
+			name:  "invalid headway",
+			start: h(6, 0), end: h(9, 0), headway: 0,
+			windowStart: h(6, 0), windowEnd: h(9, 0),
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := frequencyTripStarts(tt.start, tt.end, tt.headway, tt.offset, tt.windowStart, tt.windowEnd)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExpandFrequencyStopTime(t *testing.T) {
+	row := gtfsdb.GetFrequencyStopTimesForStopRow{
+		TripID:        "TRIP1",
+		ArrivalTime:   utils.StopTimeSeconds(8*time.Hour + 10*time.Minute),
+		DepartureTime: utils.StopTimeSeconds(8*time.Hour + 11*time.Minute),
+		StopID:        "STOP2",
+		StopSequence:  2,
+		RouteID:       "ROUTE1",
+		ServiceID:     "WEEKDAY",
+		StartTime:     utils.StopTimeSeconds(6 * time.Hour),
+		EndTime:       utils.StopTimeSeconds(7 * time.Hour),
+		HeadwaySecs:   1200,
+	}
+	tripStart := utils.StopTimeSeconds(8 * time.Hour)
+
+	expanded := expandFrequencyStopTime(row, tripStart, utils.StopTimeSeconds(6*time.Hour), utils.StopTimeSeconds(6*time.Hour+40*time.Minute))
+
+	assert.Len(t, expanded, 2)
+	assert.Equal(t, utils.StopTimeSeconds(6*time.Hour+10*time.Minute), expanded[0].ArrivalTime)
+	assert.Equal(t, utils.StopTimeSeconds(6*time.Hour+11*time.Minute), expanded[0].DepartureTime)
+	assert.Equal(t, utils.StopTimeSeconds(6*time.Hour+30*time.Minute), expanded[1].ArrivalTime)
+	for _, st := range expanded {
+		assert.Equal(t, "TRIP1", st.TripID)
+		assert.Equal(t, "ROUTE1", st.RouteID)
+		assert.Equal(t, int64(2), st.StopSequence)
+	}
+}
+
+func TestNewFrequencyModel(t *testing.T) {
+	serviceDate := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	f := gtfsdb.Frequency{
+		TripID:      "TRIP1",
+		StartTime:   utils.StopTimeSeconds(6 * time.Hour),
+		EndTime:     utils.StopTimeSeconds(9 * time.Hour),
+		HeadwaySecs: 600,
+	}
+
+	model := newFrequencyModel(f, serviceDate)
+
+	assert.Equal(t, serviceDate.Add(6*time.Hour).UnixMilli(), model.StartTime)
+	assert.Equal(t, serviceDate.Add(9*time.Hour).UnixMilli(), model.EndTime)
+	assert.Equal(t, 600, model.Headway)
+}
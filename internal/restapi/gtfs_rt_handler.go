@@ -0,0 +1,44 @@
+package restapi
+
+import (
+	"net/http"
+
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/internal/rtfeed"
+)
+
+// gtfsRtVehiclePositionsHandler republishes the merged vehicle positions of all
+// realtime feeds as a GTFS-realtime VehiclePositions feed.
+func (api *RestAPI) gtfsRtVehiclePositionsHandler(w http.ResponseWriter, r *http.Request) {
+	vehicles := api.GtfsManager.GetRealTimeVehicles()
+	api.sendGtfsRt(w, r, rtfeed.VehiclePositions(vehicles, api.Clock.Now()))
+}
+
+// gtfsRtTripUpdatesHandler republishes the merged trip updates of all realtime
+// feeds as a GTFS-realtime TripUpdates feed.
+func (api *RestAPI) gtfsRtTripUpdatesHandler(w http.ResponseWriter, r *http.Request) {
+	trips := api.GtfsManager.GetRealTimeTrips()
+	api.sendGtfsRt(w, r, rtfeed.TripUpdates(trips, api.Clock.Now()))
+}
+
+// gtfsRtAlertsHandler republishes the merged service alerts of all realtime
+// feeds as a GTFS-realtime Alerts feed.
+func (api *RestAPI) gtfsRtAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts := api.GtfsManager.GetRealTimeAlerts()
+	api.sendGtfsRt(w, r, rtfeed.Alerts(alerts, api.Clock.Now()))
+}
+
+func (api *RestAPI) sendGtfsRt(w http.ResponseWriter, r *http.Request, feed *gtfsrt.FeedMessage) {
+	body, err := rtfeed.Encode(feed)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", rtfeed.ContentType)
+	if api.GtfsManager.IsRealtimeDegraded() {
+		w.Header().Set(realtimeStaleHeader, "true")
+	}
+	if _, err := w.Write(body); err != nil {
+		api.Logger.Warn("failed to write GTFS-realtime feed", "path", r.URL.Path, "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/rtfeed"
+)
+
+func getGtfsRtFeed(t *testing.T, api *RestAPI, endpoint string) *gtfs.Realtime {
+	t.Helper()
+	resp, body := serveSiriEndpoint(t, api, endpoint)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Equal(t, rtfeed.ContentType, resp.Header.Get("Content-Type"))
+
+	realtime, err := gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+	require.NoError(t, err)
+	return realtime
+}
+
+func TestGtfsRtVehiclePositions(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager.MockResetRealTimeData()
+	defer api.GtfsManager.MockResetRealTimeData()
+
+	lat, lon := float32(40.58), float32(-122.39)
+	api.GtfsManager.MockAddVehicleWithOptions("bus1", "trip1", "151", internalgtfs.MockVehicleOptions{
+		Position: &gtfs.Position{Latitude: &lat, Longitude: &lon},
+	})
+
+	realtime := getGtfsRtFeed(t, api, "/gtfs-rt/vehicle-positions?key=TEST")
+	require.Len(t, realtime.Vehicles, 1)
+	vehicle := realtime.Vehicles[0]
+	assert.Equal(t, "bus1", vehicle.ID.ID, "IDs are the raw GTFS IDs")
+	assert.Equal(t, "trip1", vehicle.Trip.ID.ID)
+	assert.InDelta(t, lon, *vehicle.Position.Longitude, 1e-6)
+}
+
+func TestGtfsRtTripUpdates(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager.MockResetRealTimeData()
+	defer api.GtfsManager.MockResetRealTimeData()
+
+	delay := 90 * time.Second
+	api.GtfsManager.MockAddTripUpdate("trip1", &delay, nil)
+
+	realtime := getGtfsRtFeed(t, api, "/gtfs-rt/trip-updates?key=TEST")
+	require.Len(t, realtime.Trips, 1)
+	assert.Equal(t, "trip1", realtime.Trips[0].ID.ID)
+	assert.Equal(t, delay, *realtime.Trips[0].Delay)
+}
+
+func TestGtfsRtAlerts(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager.MockResetRealTimeData()
+	defer api.GtfsManager.MockResetRealTimeData()
+
+	stopID := "2000"
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "closure",
+		InformedEntities: []gtfs.AlertInformedEntity{{StopID: &stopID, RouteType: gtfs.RouteType_Unknown}},
+		Header:           []gtfs.AlertText{{Text: "Stop closed"}},
+	})
+
+	realtime := getGtfsRtFeed(t, api, "/gtfs-rt/alerts?key=TEST")
+	require.Len(t, realtime.Alerts, 1)
+	assert.Equal(t, "closure", realtime.Alerts[0].ID)
+	assert.Equal(t, stopID, *realtime.Alerts[0].InformedEntities[0].StopID)
+}
+
+func TestGtfsRtRequiresAPIKey(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveSiriEndpoint(t, api, "/gtfs-rt/vehicle-positions")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,16 @@
+package restapi
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// stopTimeHeadsign returns the headsign riders see at a stop time. A
+// stop_headsign overrides the trip headsign for the stop times that set it,
+// as on loop routes or trips whose destination changes along the way.
+func stopTimeHeadsign(stopHeadsign, tripHeadsign sql.NullString) string {
+	if stopHeadsign.Valid && strings.TrimSpace(stopHeadsign.String) != "" {
+		return stopHeadsign.String
+	}
+	return tripHeadsign.String
+}
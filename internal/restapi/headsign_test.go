@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestStopTimeHeadsign(t *testing.T) {
+	trip := sql.NullString{String: "Downtown", Valid: true}
+
+	assert.Equal(t, "Mall", stopTimeHeadsign(sql.NullString{String: "Mall", Valid: true}, trip))
+	assert.Equal(t, "Downtown", stopTimeHeadsign(sql.NullString{}, trip))
+	assert.Equal(t, "Downtown", stopTimeHeadsign(sql.NullString{String: " ", Valid: true}, trip))
+	assert.Equal(t, "", stopTimeHeadsign(sql.NullString{}, sql.NullString{}))
+}
+
+// createStopHeadsignTestTrip adds a trip headed "Downtown" that calls at stop
+// 2000 at 10:00, where its stop_headsign reads "Mall via Downtown", and at
+// stop 1030 at 10:20, where it has none.
+func createStopHeadsignTestTrip(t *testing.T, api *RestAPI) {
+	t.Helper()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+
+	_, err := client.Queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:           "HEADSIGN_TEST",
+		RouteID:      "24",
+		ServiceID:    "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+		TripHeadsign: sql.NullString{String: "Downtown", Valid: true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM stop_times WHERE trip_id = 'HEADSIGN_TEST'")
+		assert.NoError(t, err)
+		_, err = client.DB.ExecContext(context.Background(), "DELETE FROM trips WHERE id = 'HEADSIGN_TEST'")
+		assert.NoError(t, err)
+	})
+
+	for i, st := range []struct {
+		stopID       string
+		at           time.Duration
+		stopHeadsign sql.NullString
+	}{
+		{"2000", 10 * time.Hour, sql.NullString{String: "Mall via Downtown", Valid: true}},
+		{"1030", 10*time.Hour + 20*time.Minute, sql.NullString{}},
+	} {
+		_, err = client.Queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+			TripID:        "HEADSIGN_TEST",
+			StopID:        st.stopID,
+			StopSequence:  int64(i + 1),
+			ArrivalTime:   utils.StopTimeSeconds(st.at),
+			DepartureTime: utils.StopTimeSeconds(st.at),
+			StopHeadsign:  st.stopHeadsign,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestArrivalsUseStopHeadsign(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	createStopHeadsignTestTrip(t, api)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc).UnixMilli()
+	at := strconv.FormatInt(time.Date(2025, 6, 13, 9, 55, 0, 0, loc).UnixMilli(), 10)
+
+	tests := []struct {
+		stopID   string
+		headsign string
+	}{
+		{stopID: "25_2000", headsign: "Mall via Downtown"},
+		{stopID: "25_1030", headsign: "Downtown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stopID, func(t *testing.T) {
+			resp, model := serveApiAndRetrieveEndpoint(t, api,
+				"/api/where/arrivals-and-departures-for-stop/"+tt.stopID+".json?key=TEST&minutesAfter=60&time="+at)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+			var found map[string]interface{}
+			for _, ad := range entry["arrivalsAndDepartures"].([]interface{}) {
+				if arrival := ad.(map[string]interface{}); arrival["tripId"] == "25_HEADSIGN_TEST" {
+					found = arrival
+				}
+			}
+			require.NotNil(t, found, "the test trip calls at the stop")
+			assert.Equal(t, tt.headsign, found["tripHeadsign"])
+
+			resp, model = serveApiAndRetrieveEndpoint(t, api,
+				"/api/where/arrival-and-departure-for-stop/"+tt.stopID+".json?key=TEST&tripId=25_HEADSIGN_TEST&serviceDate="+
+					strconv.FormatInt(serviceDate, 10)+"&time="+at)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			arrival := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+			assert.Equal(t, tt.headsign, arrival["tripHeadsign"])
+
+			// The trip reference keeps the trip-level headsign
+			for _, ref := range model.Data.(map[string]interface{})["references"].(map[string]interface{})["trips"].([]interface{}) {
+				if trip := ref.(map[string]interface{}); trip["id"] == "25_HEADSIGN_TEST" {
+					assert.Equal(t, "Downtown", trip["tripHeadsign"])
+				}
+			}
+		})
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/logging"
 )
 
 // HealthResponse represents the JSON response from the health endpoint.
 type HealthResponse struct {
-	Status string `json:"status"`
-	Detail string `json:"detail,omitempty"`
+	Status string            `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Feeds  []gtfs.FeedHealth `json:"feeds,omitempty"`
 }
 
 // healthHandler verifies database connectivity and readiness.
@@ -50,9 +52,23 @@ func (api *RestAPI) healthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 4. Realtime Check: stale GTFS-RT feeds degrade predictions but the instance
+	// can still serve schedule data, so report it without failing the probe.
+	feeds := api.GtfsManager.FeedHealthStatus()
+	if api.GtfsManager.IsRealtimeDegraded() {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(HealthResponse{
+			Status: "degraded",
+			Detail: "one or more realtime feeds are stale; serving scheduled data for them",
+			Feeds:  feeds,
+		})
+		return
+	}
+
 	// All checks passed
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(HealthResponse{
 		Status: "ok",
+		Feeds:  feeds,
 	})
 }
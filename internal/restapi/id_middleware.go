@@ -25,7 +25,8 @@ func (api *RestAPI) ValidateIDMiddleware(next http.HandlerFunc) http.HandlerFunc
 	}
 }
 
-// ValidateCombinedIDMiddleware enforces that the ID is in "agency_code" format.
+// ValidateCombinedIDMiddleware enforces that the ID is a combined ID, "agency_code"
+// unless another ID format is configured.
 // It injects both the raw ID and the ParsedID struct into the context.
 func (api *RestAPI) ValidateCombinedIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,75 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// importWarningsHandler lists the warnings raised while parsing the current
+// static feed in feed order. The file and kind parameters narrow the list,
+// and it is paged like the other list endpoints.
+func (api *RestAPI) importWarningsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	offset, limit, pageErrors := parseListPagination(r)
+	if len(pageErrors) > 0 {
+		api.validationErrorResponse(w, r, pageErrors)
+		return
+	}
+	// Fetch one extra row to tell whether another page follows.
+	pageLimit := int64(-1)
+	if limit > 0 {
+		pageLimit = int64(limit) + 1
+	}
+
+	query := r.URL.Query()
+	api.GtfsManager.RLock()
+	rows, err := api.GtfsManager.GtfsDB.Queries.ListImportWarnings(ctx, gtfsdb.ListImportWarningsParams{
+		File:       gtfsdb.ToNullString(query.Get("file")),
+		Kind:       gtfsdb.ToNullString(query.Get("kind")),
+		PageLimit:  pageLimit,
+		PageOffset: int64(offset),
+	})
+	api.GtfsManager.RUnlock()
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	more := limit > 0 && len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	list := make([]models.ImportWarning, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, models.ImportWarning{
+			File:       row.File,
+			RowNumber:  int(row.RowNum),
+			Kind:       row.Kind,
+			Message:    row.Message,
+			RowContent: row.RowContent,
+		})
+	}
+
+	page := newPage(r, offset, len(list), more)
+	api.sendResponse(w, r, models.WithPage(models.NewListResponse(list, models.NewEmptyReferences(), more, api.Clock), page))
+}
+
+// importWarningsSummaryHandler counts the stored warnings of each kind per file.
+func (api *RestAPI) importWarningsSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	api.GtfsManager.RLock()
+	rows, err := api.GtfsManager.GtfsDB.Queries.CountImportWarnings(r.Context())
+	api.GtfsManager.RUnlock()
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	list := make([]models.ImportWarningCount, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, models.ImportWarningCount{File: row.File, Kind: row.Kind, Count: int(row.Count)})
+	}
+	api.sendResponse(w, r, models.NewListResponse(list, models.NewEmptyReferences(), false, api.Clock))
+}
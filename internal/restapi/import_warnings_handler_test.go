@@ -0,0 +1,73 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+func TestImportWarningsHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.AdminApiKeys = []string{"ADMIN"}
+
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, client.Queries.CreateImportWarning(ctx, gtfsdb.CreateImportWarningParams{
+			File:       "stops.txt",
+			RowNum:     int64(i),
+			Kind:       "TestWarning",
+			Message:    fmt.Sprintf("problem %d", i),
+			RowContent: fmt.Sprintf("STOP%d,,", i),
+		}))
+	}
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM import_warnings WHERE kind = 'TestWarning'")
+		assert.NoError(t, err)
+	})
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/admin/import-warnings?key=TEST")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "regular keys cannot read import warnings")
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/admin/import-warnings?key=ADMIN&kind=TestWarning&maxCount=2")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	list := data["list"].([]interface{})
+	require.Len(t, list, 2)
+	first := list[0].(map[string]interface{})
+	assert.Equal(t, "stops.txt", first["file"])
+	assert.Equal(t, float64(1), first["rowNumber"])
+	assert.Equal(t, "problem 1", first["message"])
+	assert.Equal(t, "STOP1,,", first["rowContent"])
+	assert.Equal(t, true, data["limitExceeded"])
+	cursor, ok := data["nextCursor"].(string)
+	require.True(t, ok)
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/admin/import-warnings?key=ADMIN&kind=TestWarning&maxCount=2&cursor="+cursor)
+	data = model.Data.(map[string]interface{})
+	list = data["list"].([]interface{})
+	require.Len(t, list, 1)
+	assert.Equal(t, "problem 3", list[0].(map[string]interface{})["message"])
+	assert.Equal(t, false, data["limitExceeded"])
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/admin/import-warnings?key=ADMIN&kind=TestWarning&file=agency.txt")
+	assert.Empty(t, model.Data.(map[string]interface{})["list"])
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/admin/import-warnings/summary?key=ADMIN")
+	var found bool
+	for _, item := range model.Data.(map[string]interface{})["list"].([]interface{}) {
+		count := item.(map[string]interface{})
+		if count["kind"] == "TestWarning" {
+			found = true
+			assert.Equal(t, "stops.txt", count["file"])
+			assert.Equal(t, float64(3), count["count"])
+		}
+	}
+	assert.True(t, found)
+}
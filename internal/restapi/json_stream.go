@@ -0,0 +1,234 @@
+package restapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+const (
+	// streamingMinElements is the length of a list from which a JSON response
+	// is written while it is encoded instead of being built in memory first.
+	streamingMinElements = 500
+	// streamingBufferSize is how much of a streamed response is held before it
+	// is written out.
+	streamingBufferSize = 32 << 10
+)
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// largeJSONPayload reports whether v holds a list of at least
+// streamingMinElements elements, looking through the response envelope and
+// the maps and lists the response helpers build. Lists inside structs are not
+// looked at; they are encoded whole either way.
+func largeJSONPayload(v any) bool {
+	if response, ok := v.(models.ResponseModel); ok {
+		v = response.Data
+	}
+	return largeValue(reflect.ValueOf(v))
+}
+
+func largeValue(v reflect.Value) bool {
+	v = indirectInterface(v)
+	if !streamable(v) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() >= streamingMinElements {
+			return true
+		}
+		for i := range v.Len() {
+			if largeValue(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if largeValue(iter.Value()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeJSONStream writes v exactly as json.Encoder would, newline included,
+// but encodes the entries of maps and lists one at a time into a small buffer,
+// so that a long list is never held in memory as a whole.
+func writeJSONStream(w io.Writer, v any) error {
+	bw := bufio.NewWriterSize(w, streamingBufferSize)
+	if err := streamJSONValue(bw, v); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('\n'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func streamJSONValue(w *bufio.Writer, v any) error {
+	if response, ok := v.(models.ResponseModel); ok {
+		return streamResponseModel(w, response)
+	}
+	return streamReflectValue(w, reflect.ValueOf(v))
+}
+
+// streamResponseModel writes the envelope's fields in the order, and with the
+// omitempty rules, of its struct tags.
+func streamResponseModel(w *bufio.Writer, response models.ResponseModel) error {
+	if _, err := w.WriteString(`{"code":`); err != nil {
+		return err
+	}
+	if err := writeMarshaled(w, response.Code); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(`,"currentTime":`); err != nil {
+		return err
+	}
+	if err := writeMarshaled(w, response.CurrentTime); err != nil {
+		return err
+	}
+	if response.Data != nil {
+		if _, err := w.WriteString(`,"data":`); err != nil {
+			return err
+		}
+		if err := streamReflectValue(w, reflect.ValueOf(response.Data)); err != nil {
+			return err
+		}
+	}
+	if response.ErrorCode != "" {
+		if _, err := w.WriteString(`,"errorCode":`); err != nil {
+			return err
+		}
+		if err := writeMarshaled(w, response.ErrorCode); err != nil {
+			return err
+		}
+	}
+	if _, err := w.WriteString(`,"text":`); err != nil {
+		return err
+	}
+	if err := writeMarshaled(w, response.Text); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(`,"version":`); err != nil {
+		return err
+	}
+	if err := writeMarshaled(w, response.Version); err != nil {
+		return err
+	}
+	return w.WriteByte('}')
+}
+
+func streamReflectValue(w *bufio.Writer, v reflect.Value) error {
+	v = indirectInterface(v)
+	if !streamable(v) {
+		return writeMarshaled(w, valueInterface(v))
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			_, err := w.WriteString("null")
+			return err
+		}
+		if err := w.WriteByte('['); err != nil {
+			return err
+		}
+		for i := range v.Len() {
+			if i > 0 {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := streamReflectValue(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+
+	default: // reflect.Map
+		if v.IsNil() {
+			_, err := w.WriteString("null")
+			return err
+		}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		slices.Sort(keys)
+		if err := w.WriteByte('{'); err != nil {
+			return err
+		}
+		for i, key := range keys {
+			if i > 0 {
+				if err := w.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			if err := writeMarshaled(w, key); err != nil {
+				return err
+			}
+			if err := w.WriteByte(':'); err != nil {
+				return err
+			}
+			if err := streamReflectValue(w, v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte('}')
+	}
+}
+
+// streamable reports whether v is a map or list the stream encoder can walk
+// itself. Everything else, including types with their own MarshalJSON and
+// []byte (encoded as base64), is handed to json.Marshal.
+func streamable(v reflect.Value) bool {
+	if !v.IsValid() || v.Type().Implements(jsonMarshalerType) {
+		return false
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	case reflect.Map:
+		return v.Type().Key().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+func indirectInterface(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func valueInterface(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func writeMarshaled(w *bufio.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,55 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestWriteJSONStreamMatchesEncoder(t *testing.T) {
+	c := clock.NewMockClock(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	stopTimes := make([]models.StopTime, 600)
+	for i := range stopTimes {
+		stopTimes[i] = models.StopTime{StopID: "25_2000", ArrivalTime: i * 60, DepartureTime: i*60 + 30}
+	}
+
+	tests := []struct {
+		name     string
+		response any
+	}{
+		{"list", models.NewListResponse(stopTimes, models.NewEmptyReferences(), true, c)},
+		{"entry", models.NewEntryResponse(models.Schedule{StopTimes: stopTimes, TimeZone: "America/Los_Angeles"}, models.NewEmptyReferences(), c)},
+		{"nil list", models.NewListResponse([]models.StopTime(nil), models.NewEmptyReferences(), false, c)},
+		{"no data", models.NewResponse(404, nil, "resource not found", c)},
+		{"nested maps", map[string]any{"b": []any{1, "two", nil, map[string]int{"z": 1, "a": 2}}, "a": []byte("raw")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want bytes.Buffer
+			require.NoError(t, json.NewEncoder(&want).Encode(tt.response))
+
+			var got bytes.Buffer
+			require.NoError(t, writeJSONStream(&got, tt.response))
+			assert.Equal(t, want.String(), got.String())
+		})
+	}
+}
+
+func TestLargeJSONPayload(t *testing.T) {
+	c := clock.NewMockClock(time.Now())
+	small := make([]models.StopTime, streamingMinElements-1)
+	large := make([]models.StopTime, streamingMinElements)
+
+	assert.False(t, largeJSONPayload(models.NewListResponse(small, models.NewEmptyReferences(), false, c)))
+	assert.True(t, largeJSONPayload(models.NewListResponse(large, models.NewEmptyReferences(), false, c)))
+	assert.False(t, largeJSONPayload(models.NewResponse(404, nil, "resource not found", c)))
+	// Lists inside structs are not looked into.
+	assert.False(t, largeJSONPayload(models.NewEntryResponse(models.Schedule{StopTimes: large}, models.NewEmptyReferences(), c)))
+}
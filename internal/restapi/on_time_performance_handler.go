@@ -0,0 +1,100 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// A stop observation counts as on time from one minute early to five
+	// minutes late, the usual transit industry window.
+	onTimeEarlyThresholdSeconds = 60
+	onTimeLateThresholdSeconds  = 300
+
+	defaultOnTimePerformanceWindow = 24 * time.Hour
+)
+
+// parseOnTimePerformanceWindow reads the startTime and endTime of a request, in
+// Unix milliseconds. The window defaults to the day before endTime, which
+// defaults to now.
+func (api *RestAPI) parseOnTimePerformanceWindow(r *http.Request) (time.Time, time.Time, map[string][]string) {
+	q := newQueryParams(r)
+	to := q.timeParam("endTime", api.Clock.Now())
+	from := q.timeParam("startTime", to.Add(-defaultOnTimePerformanceWindow))
+	if q.errors() == nil && !from.Before(to) {
+		q.addError("startTime", "must be before endTime")
+	}
+	return from, to, q.errors()
+}
+
+// onTimePerformanceHandler reports, for each route of an agency, the share of
+// stop observations that were early, on time or late over a time range. The
+// observations are schedule deviation samples kept by the vehicle position
+// history, so every route is left out unless history recording is enabled.
+func (api *RestAPI) onTimePerformanceHandler(w http.ResponseWriter, r *http.Request) {
+	agencyID, _ := utils.GetIDFromContext(r.Context())
+
+	from, to, fieldErrors := api.parseOnTimePerformanceWindow(r)
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agency := api.GtfsManager.FindAgency(agencyID)
+	if agency == nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return
+	}
+
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetOnTimePerformanceByRoute(ctx, gtfsdb.GetOnTimePerformanceByRouteParams{
+		EarlyThreshold: onTimeEarlyThresholdSeconds,
+		LateThreshold:  onTimeLateThresholdSeconds,
+		AgencyID:       agencyID,
+		FromTime:       from.UnixMilli(),
+		ToTime:         to.UnixMilli(),
+	})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	references := models.NewEmptyReferences()
+	references.Agencies = append(references.Agencies, models.NewAgencyReference(
+		agency.Id, agency.Name, agency.Url, agency.Timezone,
+		agency.Language, agency.Phone, agency.Email,
+		agency.FareUrl, "", false,
+	))
+
+	entry := models.OnTimePerformance{
+		AgencyID:       agencyID,
+		FromTime:       from.UnixMilli(),
+		ToTime:         to.UnixMilli(),
+		EarlyThreshold: onTimeEarlyThresholdSeconds,
+		LateThreshold:  onTimeLateThresholdSeconds,
+		Routes:         make([]models.RouteOnTimePerformance, 0, len(rows)),
+	}
+	for _, row := range rows {
+		entry.Routes = append(entry.Routes, models.NewRouteOnTimePerformance(
+			utils.FormCombinedID(agencyID, row.RouteID),
+			int(row.SampleCount), int(row.EarlyCount), int(row.LateCount), row.MeanDeviation,
+		))
+
+		if route := api.GtfsManager.FindRoute(row.RouteID); route != nil {
+			references.Routes = append(references.Routes, models.NewRoute(
+				utils.FormCombinedID(agencyID, route.Id), agencyID, route.ShortName, route.LongName,
+				route.Description, models.RouteType(route.Type),
+				route.Url, route.Color, route.TextColor).WithSortOrder(route.SortOrder))
+		}
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+}
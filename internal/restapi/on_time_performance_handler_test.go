@@ -0,0 +1,89 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestOnTimePerformanceHandler(t *testing.T) {
+	now := time.Date(2025, 6, 13, 18, 0, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	var tripID string
+	require.NoError(t, client.DB.QueryRowContext(ctx, `SELECT id FROM trips WHERE route_id = '151' LIMIT 1`).Scan(&tripID))
+
+	// Two early, three on time and one late, plus one sample from two days ago.
+	deviations := []int64{-120, -61, -60, 0, 300, 301}
+	for i, deviation := range deviations {
+		require.NoError(t, client.Queries.UpsertScheduleDeviationSample(ctx, gtfsdb.UpsertScheduleDeviationSampleParams{
+			TripID:      tripID,
+			StopID:      "stop-" + strconv.Itoa(i),
+			ServiceDate: "20250613",
+			Deviation:   deviation,
+			ObservedAt:  now.Add(-time.Duration(i+1) * time.Minute).UnixMilli(),
+		}))
+	}
+	twoDaysAgo := now.Add(-48 * time.Hour)
+	require.NoError(t, client.Queries.UpsertScheduleDeviationSample(ctx, gtfsdb.UpsertScheduleDeviationSampleParams{
+		TripID:      tripID,
+		StopID:      "stop-0",
+		ServiceDate: "20250611",
+		Deviation:   600,
+		ObservedAt:  twoDaysAgo.UnixMilli(),
+	}))
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM schedule_deviation_samples")
+		assert.NoError(t, err)
+	})
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/on-time-performance/25.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	entry := data["entry"].(map[string]interface{})
+	assert.Equal(t, float64(now.Add(-24*time.Hour).UnixMilli()), entry["fromTime"])
+	assert.Equal(t, float64(60), entry["earlyThreshold"])
+	assert.Equal(t, float64(300), entry["lateThreshold"])
+
+	routes := entry["routes"].([]interface{})
+	require.Len(t, routes, 1)
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, "25_151", route["routeId"])
+	assert.Equal(t, float64(6), route["sampleCount"])
+	assert.Equal(t, float64(2), route["earlyCount"])
+	assert.Equal(t, float64(3), route["onTimeCount"])
+	assert.Equal(t, float64(1), route["lateCount"])
+	assert.Equal(t, 33.3, route["earlyPercent"])
+	assert.Equal(t, 50.0, route["onTimePercent"])
+	assert.Equal(t, 16.7, route["latePercent"])
+	assert.Equal(t, 60.0, route["meanDeviation"])
+
+	refs := data["references"].(map[string]interface{})
+	assert.Len(t, refs["routes"], 1)
+	assert.Len(t, refs["agencies"], 1)
+
+	// Widening the range takes in the older sample.
+	startTime := strconv.FormatInt(now.Add(-72*time.Hour).UnixMilli(), 10)
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/on-time-performance/25.json?key=TEST&startTime="+startTime)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	route = entry["routes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(7), route["sampleCount"])
+	assert.Equal(t, float64(2), route["lateCount"])
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/on-time-performance/no-such-agency.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	endTime := strconv.FormatInt(now.Add(-96*time.Hour).UnixMilli(), 10)
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/on-time-performance/25.json?key=TEST&startTime="+startTime+"&endTime="+endTime)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
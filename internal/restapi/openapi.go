@@ -0,0 +1,374 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// openAPIVersion is the version of the OpenAPI specification the document follows.
+const openAPIVersion = "3.0.3"
+
+// schemaBuilder derives JSON schemas from Go types the way encoding/json
+// marshals them. Named struct types become shared component schemas.
+type schemaBuilder struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]any), names: make(map[reflect.Type]string)}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of the values of type t.
+func (b *schemaBuilder) schemaOf(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		schema := b.schemaOf(t.Elem())
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.componentName(t)}
+	default:
+		// Interfaces can hold anything
+		return map[string]any{}
+	}
+}
+
+// componentName returns the component under which the named struct type t is
+// described, adding it on first use. Types of the same name in different
+// packages are told apart by their package name.
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := b.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.names[t] = name
+	// Reserve the name before describing the fields, as they may refer back to t
+	b.components[name] = nil
+	b.components[name] = b.structSchema(t)
+	return name
+}
+
+// structSchema describes the fields of a struct as encoding/json writes them.
+// Fields without omitempty are always written, so they are required.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	b.addFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				b.addFields(fieldType, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := b.schemaOf(fieldType)
+		if hasJSONOption(options, "string") {
+			schema = map[string]any{"type": "string"}
+		}
+		properties[name] = schema
+		if !hasJSONOption(options, "omitempty") && !hasJSONOption(options, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func hasJSONOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaOfValue returns the schema of the type of v.
+func (b *schemaBuilder) schemaOfValue(v any) map[string]any {
+	return b.schemaOf(reflect.TypeOf(v))
+}
+
+// envelopeSchema describes the standard response wrapping data.
+func envelopeSchema(data map[string]any) map[string]any {
+	properties := map[string]any{
+		"code":        map[string]any{"type": "integer", "format": "int32"},
+		"currentTime": map[string]any{"type": "integer", "format": "int64"},
+		"text":        map[string]any{"type": "string"},
+		"version":     map[string]any{"type": "integer", "format": "int32"},
+	}
+	if data != nil {
+		properties["data"] = data
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{"code", "currentTime", "text", "version"},
+	}
+}
+
+// dataSchema describes the data of a response of the given kind.
+func (b *schemaBuilder) dataSchema(response responseDoc) map[string]any {
+	references := b.schemaOf(reflect.TypeOf(models.ReferencesModel{}))
+	switch response.Kind {
+	case entryResponse:
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"entry":      b.schemaOfValue(response.Model),
+				"references": references,
+			},
+			"required": []string{"entry", "references"},
+		}
+	case listResponse:
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"list":          map[string]any{"type": "array", "items": b.schemaOfValue(response.Model)},
+				"limitExceeded": map[string]any{"type": "boolean"},
+				"outOfRange":    map[string]any{"type": "boolean"},
+				"nextCursor":    map[string]any{"type": "string"},
+				"references":    references,
+			},
+			"required": []string{"limitExceeded", "list", "references"},
+		}
+	case arrivalsResponse:
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"entry": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"arrivalsAndDepartures": map[string]any{"type": "array", "items": b.schemaOfValue(response.Model)},
+						"nearbyStopIds":         map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+						"situationIds":          map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+						"stopId":                map[string]any{"type": "string"},
+					},
+					"required": []string{"arrivalsAndDepartures", "nearbyStopIds", "situationIds", "stopId"},
+				},
+				"limitExceeded": map[string]any{"type": "boolean"},
+				"nextCursor":    map[string]any{"type": "string"},
+				"references":    references,
+			},
+			"required": []string{"entry", "references"},
+		}
+	case dataResponse:
+		return b.schemaOfValue(response.Model)
+	default:
+		return nil
+	}
+}
+
+// operationID derives a unique operation name from a route, such as
+// getStopsForLocation or deleteApiKeys.
+func operationID(route registeredRoute) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(route.Method))
+	path := strings.TrimPrefix(route.Path, "/api/where")
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		if strings.HasPrefix(word, "{") {
+			word = "by" + strings.Trim(word, "{}")
+		}
+		id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return id.String()
+}
+
+// buildOpenAPIDocument describes the routes in the OpenAPI format.
+func buildOpenAPIDocument(routes []registeredRoute) map[string]any {
+	b := newSchemaBuilder()
+	paths := make(map[string]any)
+
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ErrorResponse"}},
+		},
+	}
+
+	for _, route := range routes {
+		doc, ok := routeDocs[route.pattern()]
+		if !ok {
+			continue
+		}
+
+		var parameters []any
+		for _, name := range route.pathParams() {
+			description := "ID of the " + strings.TrimSuffix(name, "Id")
+			if name == "id" && len(route.Formats) == 0 && strings.HasPrefix(route.Path, "/api/where/") {
+				description = "ID, followed by .json or .xml to choose the response format"
+			}
+			parameters = append(parameters, map[string]any{
+				"name": name, "in": "path", "required": true,
+				"description": description,
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+		for _, p := range doc.Params {
+			parameters = append(parameters, map[string]any{
+				"name": p.Name, "in": "query", "required": p.Required,
+				"description": p.Description,
+				"schema":      map[string]any{"type": p.Type},
+			})
+		}
+
+		var okContent map[string]any
+		if doc.Response.Kind == rawResponse {
+			media := map[string]any{}
+			if doc.Response.Model != nil {
+				media["schema"] = b.schemaOfValue(doc.Response.Model)
+			}
+			okContent = map[string]any{doc.Response.ContentType: media}
+		} else {
+			okContent = map[string]any{
+				"application/json": map[string]any{"schema": envelopeSchema(b.dataSchema(doc.Response))},
+			}
+		}
+
+		operation := map[string]any{
+			"operationId": operationID(route),
+			"summary":     doc.Summary,
+			"responses": map[string]any{
+				"200":     map[string]any{"description": "OK", "content": okContent},
+				"default": errorResponse,
+			},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if doc.Body != nil {
+			operation["requestBody"] = map[string]any{
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": b.schemaOfValue(doc.Body)},
+				},
+			}
+		}
+		switch doc.Auth {
+		case authAPIKey:
+			operation["security"] = []any{map[string]any{"apiKey": []string{}}}
+		case authAdminKey:
+			operation["security"] = []any{map[string]any{"adminKey": []string{}}}
+		case authNone:
+			operation["security"] = []any{}
+		}
+
+		addOperation := func(path string) {
+			item, _ := paths[path].(map[string]any)
+			if item == nil {
+				item = make(map[string]any)
+				paths[path] = item
+			}
+			item[strings.ToLower(route.Method)] = operation
+		}
+		if len(route.Formats) == 0 {
+			addOperation(route.Path)
+		}
+		for _, format := range route.Formats {
+			addOperation(route.Path + format)
+		}
+	}
+
+	errorSchema := envelopeSchema(nil)
+	errorSchema["properties"].(map[string]any)["errorCode"] = map[string]any{"type": "string"}
+	b.components["ErrorResponse"] = errorSchema
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "OneBusAway REST API",
+			"description": "Transit data served by Maglev. Endpoints under /api/where also answer in XML when the path ends in .xml.",
+			"version":     "2",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.components,
+			"securitySchemes": map[string]any{
+				"apiKey":   map[string]any{"type": "apiKey", "in": "query", "name": "key"},
+				"adminKey": map[string]any{"type": "apiKey", "in": "query", "name": "key", "description": "One of the configured admin keys"},
+			},
+		},
+	}
+}
+
+// openAPIHandler serves the OpenAPI document of the routes in the registry,
+// built on the first request, once every route has been registered.
+func (api *RestAPI) openAPIHandler(routes *routeRegistry) http.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			body, err = json.Marshal(buildOpenAPIDocument(routes.routes))
+		})
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
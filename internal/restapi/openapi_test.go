@@ -0,0 +1,242 @@
+package restapi
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registeredRoutes(t *testing.T, api *RestAPI) []registeredRoute {
+	t.Helper()
+	routes := newRouteRegistry(http.NewServeMux())
+	api.registerRoutes(routes)
+	return routes.routes
+}
+
+func TestRouteDocsMatchRegisteredRoutes(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	registered := make(map[string]bool)
+	for _, route := range registeredRoutes(t, api) {
+		pattern := route.pattern()
+		assert.False(t, registered[pattern], "%s registered twice", pattern)
+		registered[pattern] = true
+
+		doc, ok := routeDocs[pattern]
+		if !assert.True(t, ok, "%s has no entry in routeDocs", pattern) {
+			continue
+		}
+		assert.NotEmpty(t, doc.Summary, pattern)
+
+		seen := make(map[string]bool)
+		for _, name := range route.pathParams() {
+			seen[name] = true
+		}
+		for _, p := range doc.Params {
+			assert.False(t, seen[p.Name], "%s documents %s twice", pattern, p.Name)
+			seen[p.Name] = true
+			assert.Contains(t, []string{"string", "integer", "number", "boolean"}, p.Type, "%s %s", pattern, p.Name)
+		}
+	}
+	for pattern := range routeDocs {
+		assert.True(t, registered[pattern], "routeDocs documents %s, which is not registered", pattern)
+	}
+}
+
+func TestRegisteredRoutePathParams(t *testing.T) {
+	assert.Equal(t, []string{"id"}, registeredRoute{Method: "GET", Path: "/api/where/stop/{id}"}.pathParams())
+	assert.Equal(t, []string{"vehicleId"}, registeredRoute{Method: "DELETE", Path: "/api/admin/vehicle-assignments/{vehicleId}"}.pathParams())
+	assert.Nil(t, registeredRoute{Method: "GET", Path: "/healthz"}.pathParams())
+}
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "getStopsForLocation", operationID(registeredRoute{Method: "GET", Path: "/api/where/stops-for-location"}))
+	assert.Equal(t, "getStopByid", operationID(registeredRoute{Method: "GET", Path: "/api/where/stop/{id}"}))
+	assert.Equal(t, "deleteApiAdminApiKeysBykey", operationID(registeredRoute{Method: "DELETE", Path: "/api/admin/api-keys/{key}"}))
+}
+
+// fetchOpenAPIDocument serves the routes of api and returns the decoded
+// OpenAPI document.
+func fetchOpenAPIDocument(t *testing.T, server *httptest.Server) map[string]any {
+	t.Helper()
+	resp, err := http.Get(server.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+	return doc
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	doc := fetchOpenAPIDocument(t, server)
+	assert.Equal(t, openAPIVersion, doc["openapi"])
+
+	paths := doc["paths"].(map[string]any)
+	for _, route := range registeredRoutes(t, api) {
+		served := []string{route.Path}
+		if len(route.Formats) > 0 {
+			served = nil
+			for _, format := range route.Formats {
+				served = append(served, route.Path+format)
+			}
+		}
+		for _, path := range served {
+			item, ok := paths[path].(map[string]any)
+			if assert.True(t, ok, "%s is missing from the document", path) {
+				assert.Contains(t, item, strings.ToLower(route.Method), path)
+			}
+		}
+	}
+
+	stop := paths["/api/where/stop/{id}"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, "getStopByid", stop["operationId"])
+	assert.Equal(t, []any{map[string]any{"apiKey": []any{}}}, stop["security"])
+
+	health := paths["/healthz"].(map[string]any)["get"].(map[string]any)
+	assert.Equal(t, []any{}, health["security"])
+
+	// Every reference resolves
+	components := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	var checkRefs func(v any)
+	checkRefs = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				assert.Contains(t, components, strings.TrimPrefix(ref, "#/components/schemas/"))
+			}
+			for _, child := range v {
+				checkRefs(child)
+			}
+		case []any:
+			for _, child := range v {
+				checkRefs(child)
+			}
+		}
+	}
+	checkRefs(doc)
+}
+
+// assertMatchesSchema checks that value, decoded from JSON, has only the
+// properties its schema documents, of the documented types, and all the
+// required ones.
+func assertMatchesSchema(t *testing.T, components map[string]any, schema map[string]any, value any, path string) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = components[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+	}
+	if len(schema) == 0 || value == nil {
+		// An empty schema allows anything; nil slices, maps and pointers are written as null
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		if !assert.Equal(t, "object", schema["type"], path) {
+			return
+		}
+		if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+			for key, child := range value {
+				assertMatchesSchema(t, components, additional, child, path+"."+key)
+			}
+			return
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for key, child := range value {
+			propSchema, ok := properties[key].(map[string]any)
+			if assert.True(t, ok, "%s.%s is not documented", path, key) {
+				assertMatchesSchema(t, components, propSchema, child, path+"."+key)
+			}
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			assert.Contains(t, value, name, "%s is missing required %s", path, name)
+		}
+	case []any:
+		if !assert.Equal(t, "array", schema["type"], path) {
+			return
+		}
+		for i, child := range value {
+			if i >= 5 {
+				break
+			}
+			assertMatchesSchema(t, components, schema["items"].(map[string]any), child, path+"[]")
+		}
+	case string:
+		assert.Equal(t, "string", schema["type"], path)
+	case bool:
+		assert.Equal(t, "boolean", schema["type"], path)
+	case float64:
+		if schema["type"] == "integer" {
+			assert.Equal(t, math.Trunc(value), value, "%s is documented as an integer", path)
+		} else {
+			assert.Equal(t, "number", schema["type"], path)
+		}
+	}
+}
+
+func TestOpenAPIDocumentDescribesResponses(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	doc := fetchOpenAPIDocument(t, server)
+	paths := doc["paths"].(map[string]any)
+	components := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	endpoints := []struct {
+		path  string // As documented
+		id    string // Replaces {id}
+		query string
+	}{
+		{path: "/api/where/agencies-with-coverage.json"},
+		{path: "/api/where/current-time.json"},
+		{path: "/api/where/config.json"},
+		{path: "/api/where/search/route.json", query: "&input=shasta"},
+		{path: "/api/where/stops-for-location.json", query: "&lat=40.583321&lon=-122.426966"},
+		{path: "/api/where/routes-for-location.json", query: "&lat=40.583321&lon=-122.426966"},
+		{path: "/api/where/agency/{id}", id: "25.json"},
+		{path: "/api/where/routes-for-agency/{id}", id: "25.json"},
+		{path: "/api/where/route-ids-for-agency/{id}", id: "25.json"},
+		{path: "/api/where/stop/{id}", id: "25_2000.json"},
+		{path: "/api/where/block/{id}", id: "25_1.json"},
+		{path: "/api/where/schedule-for-stop/{id}", id: "25_2000.json", query: "&date=2025-06-13"},
+		{path: "/api/where/arrivals-and-departures-for-stop/{id}", id: "25_2000.json"},
+	}
+	for _, endpoint := range endpoints {
+		t.Run(endpoint.path, func(t *testing.T) {
+			// The exempt key, so that the requests are not rate limited
+			url := server.URL + strings.Replace(endpoint.path, "{id}", endpoint.id, 1) + "?key=org.onebusaway.iphone" + endpoint.query
+			resp, err := http.Get(url)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body any
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+			operation := paths[endpoint.path].(map[string]any)["get"].(map[string]any)
+			schema := operation["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+			assertMatchesSchema(t, components, schema, body, "response")
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package restapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Paginated endpoints accept either an explicit offset or the opaque cursor
+// returned as nextCursor by the previous page. A cursor carries the offset of
+// the next result and a fingerprint of the request's filter parameters, so it
+// is rejected when replayed against a different query. Page size (maxCount or
+// limit) may change between pages.
+const (
+	cursorParam = "cursor"
+	offsetParam = "offset"
+)
+
+// pageParams are left out of the fingerprint because they change from one page
+// to the next.
+var pageParams = []string{"key", cursorParam, offsetParam, "maxCount", "limit"}
+
+// parsePageOffset returns the offset of the first requested result from the
+// cursor or offset parameter, recording invalid values in fieldErrors.
+func parsePageOffset(r *http.Request, fieldErrors map[string][]string) (int, map[string][]string) {
+	if fieldErrors == nil {
+		fieldErrors = make(map[string][]string)
+	}
+	query := r.URL.Query()
+	cursor := query.Get(cursorParam)
+	offsetStr := query.Get(offsetParam)
+
+	if cursor != "" {
+		if offsetStr != "" {
+			fieldErrors[cursorParam] = append(fieldErrors[cursorParam], "cannot be combined with offset")
+			return 0, fieldErrors
+		}
+		offset, err := decodePageCursor(cursor, requestFingerprint(r))
+		if err != nil {
+			fieldErrors[cursorParam] = append(fieldErrors[cursorParam], err.Error())
+			return 0, fieldErrors
+		}
+		return offset, fieldErrors
+	}
+
+	if offsetStr == "" {
+		return 0, fieldErrors
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		fieldErrors[offsetParam] = append(fieldErrors[offsetParam], "must be a non-negative integer")
+		return 0, fieldErrors
+	}
+	return offset, fieldErrors
+}
+
+// newPage describes a page of returned results starting at offset. When more
+// results follow, it carries a cursor for the next page.
+func newPage(r *http.Request, offset, returned int, more bool) models.Page {
+	page := models.Page{LimitExceeded: more}
+	if more {
+		page.NextCursor = encodePageCursor(offset+returned, requestFingerprint(r))
+	}
+	return page
+}
+
+// pageWindow returns the bounds of the page [offset, offset+limit) within n
+// results and whether more results follow. A negative limit means no limit.
+func pageWindow(n, offset, limit int) (start, end int, more bool) {
+	start = min(offset, n)
+	if limit < 0 {
+		return start, n, false
+	}
+	end = min(start+limit, n)
+	return start, end, end < n
+}
+
+func encodePageCursor(offset int, fingerprint string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset) + "." + fingerprint))
+}
+
+func decodePageCursor(cursor, fingerprint string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offsetStr, cursorFingerprint, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	if cursorFingerprint != fingerprint {
+		return 0, fmt.Errorf("cursor does not match this request")
+	}
+	return offset, nil
+}
+
+// requestFingerprint hashes the request path and its query parameters other
+// than the paging ones.
+func requestFingerprint(r *http.Request) string {
+	query := r.URL.Query()
+	for _, name := range pageParams {
+		query.Del(name)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.URL.Path))
+	_, _ = h.Write([]byte{'?'})
+	_, _ = h.Write([]byte(query.Encode()))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// parseListPagination reads paging parameters for endpoints paged with
+// utils.ParsePaginationParams. Their lenient offset and limit handling is kept;
+// a cursor, when present, supplies the offset instead.
+func parseListPagination(r *http.Request) (offset, limit int, fieldErrors map[string][]string) {
+	offset, limit = utils.ParsePaginationParams(r)
+	if r.URL.Query().Get(cursorParam) == "" {
+		return offset, limit, nil
+	}
+	offset, fieldErrors = parsePageOffset(r, nil)
+	return offset, limit, fieldErrors
+}
@@ -0,0 +1,182 @@
+package restapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/where/stops-for-location.json?key=A&lat=1&lon=2&maxCount=5", nil)
+	page := newPage(r, 10, 5, true)
+	require.NotEmpty(t, page.NextCursor)
+	assert.True(t, page.LimitExceeded)
+
+	// Paging parameters and the API key may differ on the follow-up request.
+	next := httptest.NewRequest(http.MethodGet, "/api/where/stops-for-location.json?key=B&lon=2&lat=1&maxCount=20&cursor="+page.NextCursor, nil)
+	offset, fieldErrors := parsePageOffset(next, nil)
+	assert.Empty(t, fieldErrors)
+	assert.Equal(t, 15, offset)
+
+	assert.Empty(t, newPage(r, 10, 5, false).NextCursor, "the last page has no cursor")
+}
+
+func TestParsePageOffsetRejectsInvalidInput(t *testing.T) {
+	cursor := newPage(httptest.NewRequest(http.MethodGet, "/list?lat=1", nil), 0, 5, true).NextCursor
+
+	tests := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{"cursor for another query", "lat=2&cursor=" + cursor, "cursor"},
+		{"garbled cursor", "lat=1&cursor=%21%21", "cursor"},
+		{"cursor and offset", "lat=1&offset=3&cursor=" + cursor, "cursor"},
+		{"negative offset", "lat=1&offset=-1", "offset"},
+		{"non-numeric offset", "lat=1&offset=abc", "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/list?"+tt.query, nil)
+			_, fieldErrors := parsePageOffset(r, nil)
+			assert.Contains(t, fieldErrors, tt.field)
+		})
+	}
+}
+
+func TestPageWindow(t *testing.T) {
+	start, end, more := pageWindow(10, 4, 3)
+	assert.Equal(t, []int{4, 7}, []int{start, end})
+	assert.True(t, more)
+
+	start, end, more = pageWindow(10, 8, 3)
+	assert.Equal(t, []int{8, 10}, []int{start, end})
+	assert.False(t, more)
+
+	start, end, more = pageWindow(10, 12, 3)
+	assert.Equal(t, []int{10, 10}, []int{start, end})
+	assert.False(t, more)
+
+	start, end, more = pageWindow(10, 2, -1)
+	assert.Equal(t, []int{2, 10}, []int{start, end})
+	assert.False(t, more)
+}
+
+// collectPages follows nextCursor from endpoint and returns the items of each
+// page, extracted by items.
+func collectPages(t *testing.T, api *RestAPI, endpoint string, items func(data map[string]interface{}) []interface{}) [][]interface{} {
+	t.Helper()
+	var pages [][]interface{}
+	next := endpoint
+	for range 100 {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, next)
+		require.Equal(t, http.StatusOK, resp.StatusCode, next)
+		data := model.Data.(map[string]interface{})
+		pages = append(pages, items(data))
+
+		cursor, hasCursor := data["nextCursor"].(string)
+		assert.Equal(t, hasCursor, data["limitExceeded"], "limitExceeded is set exactly when another page follows")
+		if !hasCursor {
+			return pages
+		}
+		next = endpoint + "&cursor=" + url.QueryEscape(cursor)
+	}
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func flatten(pages [][]interface{}) []interface{} {
+	var all []interface{}
+	for _, page := range pages {
+		all = append(all, page...)
+	}
+	return all
+}
+
+func TestStopsForLocationCursorPagination(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 12, 12, 0, 0, 0, time.UTC)))
+	defer api.Shutdown()
+
+	base := "/api/where/stops-for-location.json?key=org.onebusaway.iphone&lat=40.583321&lon=-122.362535&radius=2000"
+	list := func(data map[string]interface{}) []interface{} {
+		ids := []interface{}{}
+		for _, s := range data["list"].([]interface{}) {
+			ids = append(ids, s.(map[string]interface{})["id"])
+		}
+		return ids
+	}
+
+	pages := collectPages(t, api, base+"&maxCount=3", list)
+	require.Greater(t, len(pages), 1, "the test area should hold more than one page of stops")
+	for _, page := range pages[:len(pages)-1] {
+		assert.LessOrEqual(t, len(page), 3)
+	}
+
+	all := flatten(pages)
+	seen := make(map[interface{}]bool)
+	for _, id := range all {
+		assert.False(t, seen[id], "stop %v appears on two pages", id)
+		seen[id] = true
+	}
+
+	// Paging in larger steps covers the same stops.
+	assert.ElementsMatch(t, all, flatten(collectPages(t, api, base+"&maxCount=7", list)))
+}
+
+func TestArrivalsAndDeparturesCursorPagination(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	at := time.Date(2025, 6, 12, 9, 0, 0, 0, time.FixedZone("PDT", -7*3600))
+	base := "/api/where/arrivals-and-departures-for-stop/25_2000.json?key=org.onebusaway.iphone&minutesAfter=240&time=" + strconv.FormatInt(at.UnixMilli(), 10)
+	arrivals := func(data map[string]interface{}) []interface{} {
+		entry := data["entry"].(map[string]interface{})
+		keys := []interface{}{}
+		for _, a := range entry["arrivalsAndDepartures"].([]interface{}) {
+			arrival := a.(map[string]interface{})
+			keys = append(keys, arrival["tripId"].(string)+"@"+strconv.FormatFloat(arrival["scheduledArrivalTime"].(float64), 'f', 0, 64))
+		}
+		return keys
+	}
+
+	unpaged := collectPages(t, api, base, arrivals)
+	require.Len(t, unpaged, 1, "without maxCount all arrivals are returned at once")
+	require.Greater(t, len(unpaged[0]), 2)
+
+	pages := collectPages(t, api, base+"&maxCount=2", arrivals)
+	assert.Len(t, pages, (len(unpaged[0])+1)/2)
+	assert.Equal(t, unpaged[0], flatten(pages), "pages follow the order of the full list")
+}
+
+func TestTripsForRouteCursorPagination(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	at := time.Date(2025, 6, 12, 9, 0, 0, 0, time.FixedZone("PDT", -7*3600))
+	base := "/api/where/trips-for-route/25_151.json?key=org.onebusaway.iphone&includeSchedule=false&includeStatus=false&time=" + strconv.FormatInt(at.UnixMilli(), 10)
+	trips := func(data map[string]interface{}) []interface{} {
+		ids := []interface{}{}
+		for _, e := range data["list"].([]interface{}) {
+			ids = append(ids, e.(map[string]interface{})["tripId"])
+		}
+		return ids
+	}
+
+	unpaged := collectPages(t, api, base, trips)
+	require.Len(t, unpaged, 1)
+	require.Greater(t, len(unpaged[0]), 1, "route 151 should have several active trips at this time")
+
+	pages := collectPages(t, api, base+"&maxCount=1", trips)
+	assert.Len(t, pages, len(unpaged[0]))
+	assert.Equal(t, unpaged[0], flatten(pages))
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, base+"&cursor=bogus")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
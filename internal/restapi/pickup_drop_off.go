@@ -0,0 +1,18 @@
+package restapi
+
+import "database/sql"
+
+// noPickupOrDropOff is the GTFS pickup_type and drop_off_type meaning riders
+// cannot board or alight at a stop time. The other values, which ask riders to
+// phone the agency or tell the driver, still serve the stop.
+const noPickupOrDropOff = 1
+
+// arrivalEnabled reports whether riders can get off at a stop time.
+func arrivalEnabled(dropOffType sql.NullInt64) bool {
+	return !dropOffType.Valid || dropOffType.Int64 != noPickupOrDropOff
+}
+
+// departureEnabled reports whether riders can board at a stop time.
+func departureEnabled(pickupType sql.NullInt64) bool {
+	return !pickupType.Valid || pickupType.Int64 != noPickupOrDropOff
+}
@@ -0,0 +1,325 @@
+package restapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	defaultPlanDepartureMinutesAfter = 120
+	maxPlanDepartureMinutesAfter     = 720
+	defaultPlanDepartureMaxCount     = 10
+)
+
+type planDepartureParams struct {
+	ToAgencyID   string
+	ToStopCode   string
+	Time         time.Time
+	MinutesAfter int
+	MaxCount     int
+}
+
+// parsePlanDepartureParams reads the destination stop, the earliest departure
+// time and the size of the departure window from the query string.
+func (api *RestAPI) parsePlanDepartureParams(r *http.Request) (planDepartureParams, map[string][]string) {
+	q := newQueryParams(r)
+	params := planDepartureParams{
+		Time:         q.timeParam("time", api.Clock.Now()),
+		MinutesAfter: q.intParam("minutesAfter", defaultPlanDepartureMinutesAfter, positive[int](), clampedTo(maxPlanDepartureMinutesAfter)),
+	}
+
+	if toStopID := q.requiredParam("toStopId"); toStopID != "" {
+		if agencyID, code, err := utils.ExtractAgencyIDAndCodeID(toStopID); err != nil || agencyID == "" || code == "" {
+			q.addError("toStopId", "must be an agency-prefixed stop ID")
+		} else {
+			params.ToAgencyID, params.ToStopCode = agencyID, code
+		}
+	}
+
+	params.MaxCount, q.fieldErrors = utils.ParseMaxCount(q.values, defaultPlanDepartureMaxCount, q.fieldErrors)
+	return params, q.errors()
+}
+
+// planDepartureHandler answers "when is the next bus from A to B": the trips
+// that leave the origin stop within minutesAfter of time and later call at
+// toStopId, soonest first. Only single-seat rides are found; there is no
+// routing across transfers.
+func (api *RestAPI) planDepartureHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	fromAgencyID, fromStopCode := parsed.AgencyID, parsed.CodeID
+
+	params, fieldErrors := api.parsePlanDepartureParams(r)
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := withTripDataMemo(r.Context())
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	db := api.GtfsManager.GtfsDB
+	if _, err := db.GetStop(ctx, fromStopCode); err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
+		return
+	}
+	if _, err := db.GetStop(ctx, params.ToStopCode); err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
+		return
+	}
+
+	windowEnd := params.Time.Add(time.Duration(params.MinutesAfter) * time.Minute)
+	departures, err := api.collectPlannedDepartures(ctx, fromStopCode, params.ToStopCode, params.Time, windowEnd)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	nowMs := params.Time.UnixMilli()
+	list := make([]models.PlannedDeparture, 0, len(departures))
+	for _, d := range departures {
+		// Leave out trips that real-time data shows have already left.
+		if d.departure.DepartureTime() < nowMs {
+			continue
+		}
+		agencyID := d.route.AgencyID
+		d.departure.TripID = utils.FormCombinedID(agencyID, d.departure.TripID)
+		d.departure.RouteID = utils.FormCombinedID(agencyID, d.route.ID)
+		d.departure.FromStopID = utils.FormCombinedID(fromAgencyID, fromStopCode)
+		d.departure.ToStopID = utils.FormCombinedID(params.ToAgencyID, params.ToStopCode)
+		list = append(list, d.departure)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].DepartureTime() < list[j].DepartureTime()
+	})
+	if len(list) > params.MaxCount {
+		list = list[:params.MaxCount]
+	}
+
+	stopCodes := map[string]map[string]bool{fromAgencyID: {fromStopCode: true}}
+	if stopCodes[params.ToAgencyID] == nil {
+		stopCodes[params.ToAgencyID] = make(map[string]bool)
+	}
+	stopCodes[params.ToAgencyID][params.ToStopCode] = true
+
+	references, err := api.buildPlanDepartureReferences(ctx, list, stopCodes)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(list, references, false, api.Clock))
+}
+
+type plannedDeparture struct {
+	route     gtfsdb.Route
+	departure models.PlannedDeparture
+}
+
+// collectPlannedDepartures finds the trips that run on their service date and
+// leave originStop between windowStart and windowEnd before calling at
+// destinationStop. A trip that passes either stop more than once is given its
+// earliest boarding. Canceled trips are left out.
+//
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) collectPlannedDepartures(ctx context.Context, originStop, destinationStop string, windowStart, windowEnd time.Time) ([]plannedDeparture, error) {
+	queries := api.GtfsManager.GtfsDB.Queries
+	memo := tripDataMemoFromContext(ctx)
+
+	locations, err := api.feedLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var departures []plannedDeparture
+	seen := make(map[string]bool)
+	for _, loc := range locations {
+		for _, serviceMidnight := range utils.ServiceDatesBetween(windowStart.In(loc), windowEnd, api.GtfsManager.MaxServiceTime()) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			endSeconds := utils.ServiceTimeAt(serviceMidnight, windowEnd).Seconds()
+			if endSeconds < 0 {
+				continue
+			}
+			serviceIDs, err := memo.activeServiceIDs(ctx, queries, serviceMidnight)
+			if err != nil {
+				api.Logger.Warn("failed to query active service IDs",
+					slog.String("date", serviceMidnight.Format("20060102")),
+					slog.Any("error", err))
+				continue
+			}
+			if len(serviceIDs) == 0 {
+				continue
+			}
+			activeServiceIDs := make(map[string]bool, len(serviceIDs))
+			for _, id := range serviceIDs {
+				activeServiceIDs[id] = true
+			}
+
+			rows, err := queries.GetTripsServingStopsInOrder(ctx, gtfsdb.GetTripsServingStopsInOrderParams{
+				OriginStopID:      originStop,
+				DestinationStopID: destinationStop,
+				WindowStart:       utils.ServiceTimeAt(serviceMidnight, windowStart).Seconds(),
+				WindowEnd:         endSeconds,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			serviceDate := serviceMidnight.Format("20060102")
+			for _, row := range rows {
+				key := serviceDate + "|" + row.TripID
+				if seen[key] || !activeServiceIDs[row.ServiceID] {
+					continue
+				}
+				route, err := memo.route(ctx, api.GtfsManager.GtfsDB, row.RouteID)
+				if err != nil {
+					continue
+				}
+				// With agencies in several zones, each service date is only
+				// read for the trips whose agency keeps time in that zone.
+				if len(locations) > 1 && api.agencyLocation(ctx, route.AgencyID).String() != loc.String() {
+					continue
+				}
+				seen[key] = true
+				if api.tripCanceledOn(row.TripID, serviceMidnight) {
+					continue
+				}
+
+				departures = append(departures, plannedDeparture{
+					route:     route,
+					departure: api.newPlannedDeparture(ctx, row, originStop, destinationStop, serviceMidnight),
+				})
+			}
+		}
+	}
+	return departures, nil
+}
+
+// newPlannedDeparture fills in the scheduled and, where real-time data has
+// them, predicted times of a trip between the two stops. IDs are left
+// unprefixed.
+func (api *RestAPI) newPlannedDeparture(ctx context.Context, row gtfsdb.GetTripsServingStopsInOrderRow, originStop, destinationStop string, serviceMidnight time.Time) models.PlannedDeparture {
+	at := func(seconds int64) time.Time {
+		return serviceMidnight.Add(utils.StopTimeDuration(seconds))
+	}
+
+	departure := models.PlannedDeparture{
+		TripID:                 row.TripID,
+		RouteID:                row.RouteID,
+		ServiceDate:            serviceMidnight.UnixMilli(),
+		TripHeadsign:           row.TripHeadsign.String,
+		FromStopSequence:       int(row.OriginStopSequence),
+		ToStopSequence:         int(row.DestinationStopSequence),
+		ScheduledDepartureTime: at(row.OriginDepartureTime).UnixMilli(),
+		ScheduledArrivalTime:   at(row.DestinationArrivalTime).UnixMilli(),
+	}
+
+	_, predictedDeparture := api.getPredictedTimes(ctx, row.TripID, originStop, row.OriginStopSequence,
+		at(row.OriginArrivalTime), at(row.OriginDepartureTime))
+	predictedArrival, _ := api.getPredictedTimes(ctx, row.TripID, destinationStop, row.DestinationStopSequence,
+		at(row.DestinationArrivalTime), at(row.DestinationDepartureTime))
+	if predictedDeparture != 0 || predictedArrival != 0 {
+		departure.Predicted = true
+		departure.PredictedDepartureTime = predictedDeparture
+		departure.PredictedArrivalTime = predictedArrival
+	}
+	return departure
+}
+
+// buildPlanDepartureReferences references the trips and routes of the listed
+// departures, their agencies, and the two stops, given by agency and stop code.
+//
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) buildPlanDepartureReferences(ctx context.Context, list []models.PlannedDeparture, stopCodes map[string]map[string]bool) (models.ReferencesModel, error) {
+	queries := api.GtfsManager.GtfsDB.Queries
+	memo := tripDataMemoFromContext(ctx)
+	references := models.NewEmptyReferences()
+
+	routeIDSet := make(map[string]*gtfsdb.Route)
+	seenTrips := make(map[string]bool)
+	for _, departure := range list {
+		if seenTrips[departure.TripID] {
+			continue
+		}
+		seenTrips[departure.TripID] = true
+
+		_, tripID, _ := utils.ExtractAgencyIDAndCodeID(departure.TripID)
+		trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, tripID)
+		if err != nil {
+			continue
+		}
+		route, err := memo.route(ctx, api.GtfsManager.GtfsDB, trip.RouteID)
+		if err != nil {
+			continue
+		}
+		routeIDSet[route.ID] = &route
+
+		agencyID := route.AgencyID
+		tripRef := models.NewTripReference(
+			utils.FormCombinedID(agencyID, trip.ID),
+			utils.FormCombinedID(agencyID, trip.RouteID),
+			utils.FormCombinedID(agencyID, trip.ServiceID),
+			trip.TripHeadsign.String,
+			trip.TripShortName.String,
+			trip.DirectionID.Int64,
+			utils.FormCombinedID(agencyID, trip.BlockID.String),
+			utils.FormCombinedID(agencyID, trip.ShapeID.String),
+		)
+		tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+		tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
+		references.Trips = append(references.Trips, tripRef)
+	}
+
+	calc := GTFS.NewAdvancedDirectionCalculator(queries)
+	for stopAgencyID, codes := range stopCodes {
+		stopRefs, err := buildArrivalStopReferences(api, ctx, stopAgencyID, codes, routeIDSet, calc)
+		if err != nil {
+			return references, err
+		}
+		references.Stops = append(references.Stops, stopRefs...)
+	}
+
+	addedAgencies := make(map[string]bool)
+	for _, route := range routeIDSet {
+		references.Routes = append(references.Routes, models.NewRoute(
+			utils.FormCombinedID(route.AgencyID, route.ID),
+			route.AgencyID,
+			route.ShortName.String,
+			route.LongName.String,
+			route.Desc.String,
+			models.RouteType(route.Type),
+			route.Url.String,
+			route.Color.String,
+			route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder)))
+
+		if addedAgencies[route.AgencyID] {
+			continue
+		}
+		addedAgencies[route.AgencyID] = true
+		agency, err := queries.GetAgency(ctx, route.AgencyID)
+		if err != nil {
+			api.Logger.Warn("failed to fetch route agency for reference", "agencyID", route.AgencyID, "error", err)
+			continue
+		}
+		references.Agencies = append(references.Agencies, models.NewAgencyReference(
+			agency.ID, agency.Name, agency.Url, agency.Timezone, agency.Lang.String,
+			agency.Phone.String, agency.Email.String, agency.FareUrl.String, "", false,
+		))
+	}
+	return references, nil
+}
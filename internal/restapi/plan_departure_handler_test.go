@@ -0,0 +1,150 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// createPlanDepartureTestTrips adds two trips on route 24 between stops 2000
+// and 1030: PLAN_FORWARD leaves 2000 at 10:00 and reaches 1030 at 10:20, and
+// PLAN_REVERSE runs the other way at 10:05.
+func createPlanDepartureTestTrips(t *testing.T, api *RestAPI) {
+	t.Helper()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+
+	trips := []struct {
+		id    string
+		stops []string
+		start time.Duration
+	}{
+		{"PLAN_FORWARD", []string{"2000", "1030"}, 10 * time.Hour},
+		{"PLAN_REVERSE", []string{"1030", "2000"}, 10*time.Hour + 5*time.Minute},
+	}
+	for _, trip := range trips {
+		_, err := client.Queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+			ID:        trip.id,
+			RouteID:   "24",
+			ServiceID: "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+		})
+		require.NoError(t, err)
+		tripID := trip.id
+		t.Cleanup(func() {
+			_, err := client.DB.ExecContext(context.Background(), "DELETE FROM stop_times WHERE trip_id = ?", tripID)
+			assert.NoError(t, err)
+			_, err = client.DB.ExecContext(context.Background(), "DELETE FROM trips WHERE id = ?", tripID)
+			assert.NoError(t, err)
+		})
+
+		for i, stopID := range trip.stops {
+			at := utils.StopTimeSeconds(trip.start + time.Duration(i)*20*time.Minute)
+			_, err = client.Queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+				TripID:        trip.id,
+				StopID:        stopID,
+				StopSequence:  int64(i + 1),
+				ArrivalTime:   at,
+				DepartureTime: at,
+			})
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestPlanDepartureHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	createPlanDepartureTestTrips(t, api)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 9, 55, 0, 0, loc)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/plan-departure/25_2000.json?key=TEST&toStopId=25_1030&minutesAfter=30&time="+strconv.FormatInt(at.UnixMilli(), 10))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := model.Data.(map[string]interface{})
+	list := data["list"].([]interface{})
+
+	var forward map[string]interface{}
+	var lastDeparture float64
+	for _, item := range list {
+		departure := item.(map[string]interface{})
+		assert.NotEqual(t, "25_PLAN_REVERSE", departure["tripId"], "a trip serving the stops in the wrong order is not a departure")
+		assert.GreaterOrEqual(t, departure["scheduledDepartureTime"].(float64), lastDeparture, "departures are soonest first")
+		lastDeparture = departure["scheduledDepartureTime"].(float64)
+		if departure["tripId"] == "25_PLAN_FORWARD" {
+			forward = departure
+		}
+	}
+	require.NotNil(t, forward, "PLAN_FORWARD serves 2000 then 1030")
+
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+	assert.Equal(t, "25_24", forward["routeId"])
+	assert.Equal(t, "25_2000", forward["fromStopId"])
+	assert.Equal(t, "25_1030", forward["toStopId"])
+	assert.Equal(t, float64(1), forward["fromStopSequence"])
+	assert.Equal(t, float64(2), forward["toStopSequence"])
+	assert.Equal(t, float64(serviceDate.UnixMilli()), forward["serviceDate"])
+	assert.Equal(t, float64(serviceDate.Add(10*time.Hour).UnixMilli()), forward["scheduledDepartureTime"])
+	assert.Equal(t, float64(serviceDate.Add(10*time.Hour+20*time.Minute).UnixMilli()), forward["scheduledArrivalTime"])
+	assert.Equal(t, false, forward["predicted"])
+
+	references := data["references"].(map[string]interface{})
+	stopIDs := map[string]bool{}
+	for _, stop := range references["stops"].([]interface{}) {
+		stopIDs[stop.(map[string]interface{})["id"].(string)] = true
+	}
+	assert.True(t, stopIDs["25_2000"])
+	assert.True(t, stopIDs["25_1030"])
+	tripIDs := map[string]bool{}
+	for _, trip := range references["trips"].([]interface{}) {
+		tripIDs[trip.(map[string]interface{})["id"].(string)] = true
+	}
+	assert.True(t, tripIDs["25_PLAN_FORWARD"])
+}
+
+func TestPlanDepartureHandlerMaxCount(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 6, 0, 0, 0, loc).UnixMilli()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/plan-departure/25_2000.json?key=TEST&toStopId=25_1030&minutesAfter=720&maxCount=1&time="+strconv.FormatInt(at, 10))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.LessOrEqual(t, len(model.Data.(map[string]interface{})["list"].([]interface{})), 1)
+}
+
+func TestPlanDepartureHandlerValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"missing toStopId", "/api/where/plan-departure/25_2000.json?key=TEST", http.StatusBadRequest},
+		{"unprefixed toStopId", "/api/where/plan-departure/25_2000.json?key=TEST&toStopId=1030", http.StatusBadRequest},
+		{"invalid time", "/api/where/plan-departure/25_2000.json?key=TEST&toStopId=25_1030&time=soon", http.StatusBadRequest},
+		{"zero minutesAfter", "/api/where/plan-departure/25_2000.json?key=TEST&toStopId=25_1030&minutesAfter=0", http.StatusBadRequest},
+		{"unknown origin", "/api/where/plan-departure/25_nope.json?key=TEST&toStopId=25_1030", http.StatusNotFound},
+		{"unknown destination", "/api/where/plan-departure/25_2000.json?key=TEST&toStopId=25_nope", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := createTestApi(t)
+			defer api.Shutdown()
+			resp, _ := serveApiAndRetrieveEndpoint(t, api, tt.url)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
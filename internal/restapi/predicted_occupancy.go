@@ -0,0 +1,22 @@
+package restapi
+
+import (
+	"github.com/OneBusAway/go-gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+)
+
+// predictedOccupancyAtStop forecasts how full the vehicle serving a trip will
+// be at stopID, stopsAway stops ahead of it. The vehicle's current occupancy
+// is only blended in while it is fresh and has a position, since otherwise how
+// many stops away it is is unknown; the forecast then falls back to history.
+func predictedOccupancyAtStop(history GTFS.OccupancyHistory, vehicle *gtfs.Vehicle, status *models.TripStatusForTripDetails, stopID string, stopsAway int) string {
+	if vehicle == nil || vehicle.Position == nil || status == nil || status.Stale {
+		return history.ForecastOccupancy(nil, "", stopID, -1)
+	}
+	var currentStopID string
+	if vehicle.StopID != nil {
+		currentStopID = *vehicle.StopID
+	}
+	return history.ForecastOccupancy(vehicle.OccupancyStatus, currentStopID, stopID, stopsAway)
+}
@@ -0,0 +1,39 @@
+package restapi
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestPredictedOccupancyAtStop(t *testing.T) {
+	history := GTFS.OccupancyHistory{
+		"A": gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE,
+		"B": gtfsrt.VehiclePosition_MANY_SEATS_AVAILABLE,
+	}
+	lat, lon := float32(38.5), float32(-121.7)
+	occupancy := gtfsrt.VehiclePosition_STANDING_ROOM_ONLY
+	currentStop := "A"
+	vehicle := &gtfs.Vehicle{
+		Position:        &gtfs.Position{Latitude: &lat, Longitude: &lon},
+		StopID:          &currentStop,
+		OccupancyStatus: &occupancy,
+	}
+
+	fresh := &models.TripStatusForTripDetails{}
+	assert.Equal(t, "STANDING_ROOM_ONLY", predictedOccupancyAtStop(history, vehicle, fresh, "B", 0))
+
+	stale := &models.TripStatusForTripDetails{Stale: true}
+	assert.Equal(t, "MANY_SEATS_AVAILABLE", predictedOccupancyAtStop(history, vehicle, stale, "B", 0),
+		"a stale vehicle's occupancy is ignored")
+
+	withoutPosition := *vehicle
+	withoutPosition.Position = nil
+	assert.Equal(t, "MANY_SEATS_AVAILABLE", predictedOccupancyAtStop(history, &withoutPosition, fresh, "B", 0))
+
+	assert.Empty(t, predictedOccupancyAtStop(nil, nil, nil, "B", 0))
+}
@@ -0,0 +1,203 @@
+package restapi
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// queryParams parses the query string of a request into typed values. Each
+// parse method returns its default when the parameter is absent, and records
+// an error for the field and returns the default when the value is malformed
+// or breaks one of its rules. Handlers declare their parameters one per line
+// and report every problem at once, with the same messages across endpoints.
+type queryParams struct {
+	values url.Values
+	// fieldErrors collects the errors by field. The utils parsers that take and
+	// return such a map are called with it directly.
+	fieldErrors map[string][]string
+}
+
+func newQueryParams(r *http.Request) *queryParams {
+	return &queryParams{values: r.URL.Query()}
+}
+
+// addError records a validation error for a field.
+func (q *queryParams) addError(field, msg string) {
+	if q.fieldErrors == nil {
+		q.fieldErrors = make(map[string][]string)
+	}
+	q.fieldErrors[field] = append(q.fieldErrors[field], msg)
+}
+
+// errors returns the validation errors found so far, or nil when there are none.
+func (q *queryParams) errors() map[string][]string {
+	if len(q.fieldErrors) == 0 {
+		return nil
+	}
+	return q.fieldErrors
+}
+
+// has reports whether the parameter is present with a value.
+func (q *queryParams) has(name string) bool {
+	return q.values.Get(name) != ""
+}
+
+// paramRule checks a parsed value. It returns the value to use, which a rule
+// may clamp, or a non-empty message when the value is rejected.
+type paramRule[T any] func(value T) (T, string)
+
+// nonNegative rejects values below zero.
+func nonNegative[T int | float64]() paramRule[T] {
+	return func(value T) (T, string) {
+		if value < 0 {
+			return value, "must not be negative"
+		}
+		return value, ""
+	}
+}
+
+// positive rejects zero and values below it.
+func positive[T int | float64]() paramRule[T] {
+	return func(value T) (T, string) {
+		if value <= 0 {
+			return value, "must be greater than zero"
+		}
+		return value, ""
+	}
+}
+
+// between rejects values outside [lo, hi].
+func between[T int | float64](lo, hi T) paramRule[T] {
+	return func(value T) (T, string) {
+		if value < lo || value > hi {
+			return value, fmt.Sprintf("must be between %v and %v", lo, hi)
+		}
+		return value, ""
+	}
+}
+
+// clampedTo lowers values above hi to hi instead of rejecting them.
+func clampedTo[T cmp.Ordered](hi T) paramRule[T] {
+	return func(value T) (T, string) {
+		return min(value, hi), ""
+	}
+}
+
+// applyRules runs the rules in order, stopping at the first that rejects the
+// value.
+func applyRules[T any](q *queryParams, name string, value, fallback T, rules []paramRule[T]) T {
+	for _, rule := range rules {
+		var msg string
+		if value, msg = rule(value); msg != "" {
+			q.addError(name, msg)
+			return fallback
+		}
+	}
+	return value
+}
+
+// intParam parses an integer parameter.
+func (q *queryParams) intParam(name string, fallback int, rules ...paramRule[int]) int {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		q.addError(name, "must be a valid integer")
+		return fallback
+	}
+	return applyRules(q, name, value, fallback, rules)
+}
+
+// optionalIntParam parses an integer parameter that has no default, returning
+// nil when it is absent or invalid.
+func (q *queryParams) optionalIntParam(name string, rules ...paramRule[int]) *int {
+	if !q.has(name) {
+		return nil
+	}
+	before := len(q.fieldErrors[name])
+	value := q.intParam(name, 0, rules...)
+	if len(q.fieldErrors[name]) > before {
+		return nil
+	}
+	return &value
+}
+
+// floatParam parses a number parameter.
+func (q *queryParams) floatParam(name string, fallback float64, rules ...paramRule[float64]) float64 {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		q.addError(name, "must be a valid number")
+		return fallback
+	}
+	return applyRules(q, name, value, fallback, rules)
+}
+
+// boolParam parses a true/false parameter.
+func (q *queryParams) boolParam(name string, fallback bool) bool {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		q.addError(name, "must be a boolean value (true/false)")
+		return fallback
+	}
+	return value
+}
+
+// timeParam parses a time given in Unix milliseconds.
+func (q *queryParams) timeParam(name string, fallback time.Time) time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return fallback
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		q.addError(name, "must be a valid Unix timestamp in milliseconds")
+		return fallback
+	}
+	return time.UnixMilli(ms)
+}
+
+// optionalTimeParam parses a time in Unix milliseconds that has no default,
+// returning nil when it is absent or invalid.
+func (q *queryParams) optionalTimeParam(name string) *time.Time {
+	if !q.has(name) {
+		return nil
+	}
+	before := len(q.fieldErrors[name])
+	value := q.timeParam(name, time.Time{})
+	if len(q.fieldErrors[name]) > before {
+		return nil
+	}
+	return &value
+}
+
+// stringParam returns a text parameter.
+func (q *queryParams) stringParam(name, fallback string, rules ...paramRule[string]) string {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return fallback
+	}
+	return applyRules(q, name, raw, fallback, rules)
+}
+
+// requiredParam returns a text parameter that must be present.
+func (q *queryParams) requiredParam(name string, rules ...paramRule[string]) string {
+	if !q.has(name) {
+		q.addError(name, "is required")
+		return ""
+	}
+	return q.stringParam(name, "", rules...)
+}
@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQueryParams(query string) *queryParams {
+	return newQueryParams(httptest.NewRequest("GET", "/test?"+query, nil))
+}
+
+func TestQueryParams_Defaults(t *testing.T) {
+	q := newTestQueryParams("")
+	fallback := time.UnixMilli(1700000000000)
+
+	assert.Equal(t, 35, q.intParam("minutesAfter", 35, nonNegative[int]()))
+	assert.Equal(t, 2.5, q.floatParam("radius", 2.5))
+	assert.True(t, q.boolParam("includeTrip", true))
+	assert.Equal(t, fallback, q.timeParam("time", fallback))
+	assert.Nil(t, q.optionalTimeParam("serviceDate"))
+	assert.Nil(t, q.optionalIntParam("stopSequence"))
+	assert.Equal(t, "x", q.stringParam("vehicleId", "x"))
+	assert.Nil(t, q.errors())
+}
+
+func TestQueryParams_ParsesValues(t *testing.T) {
+	q := newTestQueryParams("n=7&f=1.5&b=false&t=1700000000123&s=abc")
+
+	assert.Equal(t, 7, q.intParam("n", 0))
+	assert.Equal(t, 1.5, q.floatParam("f", 0))
+	assert.False(t, q.boolParam("b", true))
+	assert.Equal(t, time.UnixMilli(1700000000123), q.timeParam("t", time.Time{}))
+	require.NotNil(t, q.optionalIntParam("n"))
+	assert.Equal(t, 7, *q.optionalIntParam("n"))
+	require.NotNil(t, q.optionalTimeParam("t"))
+	assert.Equal(t, "abc", q.requiredParam("s"))
+	assert.Nil(t, q.errors())
+}
+
+func TestQueryParams_MalformedValues(t *testing.T) {
+	q := newTestQueryParams("n=x&f=y&b=maybe&t=noon&o=1.5")
+
+	assert.Equal(t, 3, q.intParam("n", 3))
+	assert.Equal(t, 4.0, q.floatParam("f", 4))
+	assert.True(t, q.boolParam("b", true))
+	assert.Nil(t, q.optionalTimeParam("t"))
+	assert.Nil(t, q.optionalIntParam("o"))
+	q.requiredParam("missing")
+
+	assert.Equal(t, map[string][]string{
+		"n":       {"must be a valid integer"},
+		"f":       {"must be a valid number"},
+		"b":       {"must be a boolean value (true/false)"},
+		"t":       {"must be a valid Unix timestamp in milliseconds"},
+		"o":       {"must be a valid integer"},
+		"missing": {"is required"},
+	}, q.errors())
+}
+
+func TestQueryParams_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		rules   []paramRule[int]
+		want    int
+		wantErr string
+	}{
+		{name: "non-negative accepts zero", value: "0", rules: []paramRule[int]{nonNegative[int]()}, want: 0},
+		{name: "non-negative rejects", value: "-1", rules: []paramRule[int]{nonNegative[int]()}, want: 10, wantErr: "must not be negative"},
+		{name: "positive rejects zero", value: "0", rules: []paramRule[int]{positive[int]()}, want: 10, wantErr: "must be greater than zero"},
+		{name: "between accepts bounds", value: "5", rules: []paramRule[int]{between(1, 5)}, want: 5},
+		{name: "between rejects", value: "6", rules: []paramRule[int]{between(1, 5)}, want: 10, wantErr: "must be between 1 and 5"},
+		{name: "clamped", value: "500", rules: []paramRule[int]{clampedTo(240)}, want: 240},
+		{name: "first rejection wins", value: "-500", rules: []paramRule[int]{nonNegative[int](), clampedTo(240)}, want: 10, wantErr: "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueryParams("v=" + tt.value)
+			assert.Equal(t, tt.want, q.intParam("v", 10, tt.rules...))
+			if tt.wantErr == "" {
+				assert.Nil(t, q.errors())
+			} else {
+				assert.Equal(t, map[string][]string{"v": {tt.wantErr}}, q.errors())
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package restapi
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"maglev.onebusaway.org/internal/models"
@@ -130,6 +131,24 @@ func TestRateLimitingExemption(t *testing.T) {
 	}
 }
 
+func TestIPRateLimitingCoversExemptKeys(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.ipRateLimiter = NewIPRateLimitMiddleware(3, 0, time.Minute, false, api.Clock)
+
+	endpoint := "/api/where/current-time.json?key=org.onebusaway.iphone"
+	statuses := make([]int, 0, 4)
+	for i := 0; i < 4; i++ {
+		response, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
+		statuses = append(statuses, response.StatusCode)
+		if response.StatusCode == http.StatusTooManyRequests {
+			assert.Equal(t, "RATE_LIMITED", model.ErrorCode)
+			assert.NotEmpty(t, response.Header.Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, statuses)
+}
+
 func TestRateLimitingHeaders(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
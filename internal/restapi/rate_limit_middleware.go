@@ -3,6 +3,8 @@ package restapi
 import (
 	"encoding/json"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,6 +14,7 @@ import (
 
 	"golang.org/x/time/rate"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/metrics"
 )
 
 // rateLimitClient tracks the limiter and its last usage time.
@@ -21,7 +24,8 @@ type rateLimitClient struct {
 	lastSeen atomic.Int64 // Unix nanoseconds (time.Time.UnixNano())
 }
 
-// RateLimitMiddleware provides per-API-key rate limiting
+// RateLimitMiddleware provides per-API-key rate limiting, or per-client-IP
+// rate limiting when built by NewIPRateLimitMiddleware.
 type RateLimitMiddleware struct {
 	limiters    map[string]*rateLimitClient
 	mu          sync.RWMutex
@@ -32,22 +36,23 @@ type RateLimitMiddleware struct {
 	stopChan    chan struct{}
 	stopOnce    sync.Once
 	clock       clock.Clock
+	interval    time.Duration
+	// keyLimit, when set, returns a per-key requests-per-interval limit that
+	// overrides the default for that key.
+	keyLimit func(apiKey string) (int, bool)
+	// clientKey names the client a request is counted against.
+	clientKey func(r *http.Request) string
+	// limitName labels the rejections of this limit in metrics: "key" or "ip".
+	limitName string
+	// metrics, when set, counts rejected requests.
+	metrics *metrics.Metrics
 }
 
 // NewRateLimitMiddleware creates a new rate limiting middleware
 // ratePerSecond: number of requests allowed per second per API key
 // burstSize: number of requests allowed in a burst per API key
 func NewRateLimitMiddleware(ratePerSecond int, interval time.Duration, exemptKeys []string, clock clock.Clock) *RateLimitMiddleware {
-	// Handle zero rate limit case
-	var rateLimit rate.Limit
-	if ratePerSecond <= 0 {
-		rateLimit = rate.Inf // Infinite rate limit (no limiting)
-		if ratePerSecond == 0 {
-			rateLimit = 0 // No requests allowed
-		}
-	} else {
-		rateLimit = rate.Every(interval / time.Duration(ratePerSecond))
-	}
+	rateLimit := limitFor(ratePerSecond, interval)
 
 	exemptMap := make(map[string]bool)
 	for _, key := range exemptKeys {
@@ -65,6 +70,9 @@ func NewRateLimitMiddleware(ratePerSecond int, interval time.Duration, exemptKey
 		exemptKeys:  exemptMap,
 		stopChan:    make(chan struct{}),
 		clock:       clock,
+		interval:    interval,
+		clientKey:   apiKeyOf,
+		limitName:   "key",
 	}
 
 	// Start cleanup goroutine
@@ -73,6 +81,99 @@ func NewRateLimitMiddleware(ratePerSecond int, interval time.Duration, exemptKey
 	return middleware
 }
 
+// NewIPRateLimitMiddleware creates a middleware that limits each client IP
+// address to ratePerInterval requests per interval with bursts of burstSize,
+// whichever API key it uses. No key is exempt: an exempt key shared by every
+// install of an app is what per-IP limiting protects. With trustForwardedFor
+// the address is the last X-Forwarded-For entry, as appended by a reverse
+// proxy, instead of the connection's remote address.
+func NewIPRateLimitMiddleware(ratePerInterval, burstSize int, interval time.Duration, trustForwardedFor bool, clock clock.Clock) *RateLimitMiddleware {
+	if burstSize <= 0 {
+		burstSize = ratePerInterval
+	}
+	middleware := &RateLimitMiddleware{
+		limiters:    make(map[string]*rateLimitClient),
+		rateLimit:   limitFor(ratePerInterval, interval),
+		burstSize:   burstSize,
+		cleanupTick: time.NewTicker(5 * time.Minute),
+		exemptKeys:  map[string]bool{},
+		stopChan:    make(chan struct{}),
+		clock:       clock,
+		interval:    interval,
+		clientKey: func(r *http.Request) string {
+			return clientIP(r, trustForwardedFor)
+		},
+		limitName: "ip",
+	}
+
+	go middleware.cleanup()
+
+	return middleware
+}
+
+// apiKeyOf returns the request's API key, with a shared placeholder for
+// requests without one.
+func apiKeyOf(r *http.Request) string {
+	if apiKey := r.URL.Query().Get("key"); apiKey != "" {
+		return apiKey
+	}
+	return "__no_key__"
+}
+
+// clientIP returns the address a request came from. With trustForwardedFor it
+// is the last entry of X-Forwarded-For, the one the nearest proxy added;
+// earlier entries are set by the client and cannot be trusted.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(entries[len(entries)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// SetMetrics counts the requests this middleware rejects in m.
+func (rl *RateLimitMiddleware) SetMetrics(m *metrics.Metrics) {
+	rl.metrics = m
+}
+
+// limitFor converts a count of requests per interval into a rate.
+func limitFor(ratePerInterval int, interval time.Duration) rate.Limit {
+	// Handle zero rate limit case
+	if ratePerInterval <= 0 {
+		if ratePerInterval == 0 {
+			return 0 // No requests allowed
+		}
+		return rate.Inf // Infinite rate limit (no limiting)
+	}
+	return rate.Every(interval / time.Duration(ratePerInterval))
+}
+
+// SetKeyLimits installs a lookup for per-key rate limits. Keys for which it
+// reports no limit use the default. Call Forget after a key's limit changes.
+func (rl *RateLimitMiddleware) SetKeyLimits(keyLimit func(apiKey string) (int, bool)) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.keyLimit = keyLimit
+	clear(rl.limiters)
+}
+
+// Forget drops the limiter of an API key so that the next request builds a new
+// one from the key's current limit.
+func (rl *RateLimitMiddleware) Forget(apiKey string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	delete(rl.limiters, apiKey)
+}
+
 // Handler returns the HTTP middleware handler function
 func (rl *RateLimitMiddleware) Handler() func(http.Handler) http.Handler {
 	return rl.rateLimitHandler
@@ -101,7 +202,13 @@ func (rl *RateLimitMiddleware) getLimiter(apiKey string) *rate.Limiter {
 	}
 
 	// Create new limiter and wrap it in our client struct
-	limiter := rate.NewLimiter(rl.rateLimit, rl.burstSize)
+	limit, burst := rl.rateLimit, rl.burstSize
+	if rl.keyLimit != nil {
+		if perKey, ok := rl.keyLimit(apiKey); ok {
+			limit, burst = limitFor(perKey, rl.interval), perKey
+		}
+	}
+	limiter := rate.NewLimiter(limit, burst)
 	newClient := &rateLimitClient{
 		limiter: limiter,
 	}
@@ -114,26 +221,21 @@ func (rl *RateLimitMiddleware) getLimiter(apiKey string) *rate.Limiter {
 // rateLimitHandler is the HTTP middleware function
 func (rl *RateLimitMiddleware) rateLimitHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract API key from query parameters
-		apiKey := r.URL.Query().Get("key")
+		// Identify the client: its API key, or its address for per-IP limits
+		client := rl.clientKey(r)
 
-		// Use a default key for requests without an API key
-		if apiKey == "" {
-			apiKey = "__no_key__"
-		}
-
-		// Check if this API key is exempted from rate limiting
-		if rl.exemptKeys[apiKey] {
+		// Check if this client is exempted from rate limiting
+		if rl.exemptKeys[client] {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Get the rate limiter for this API key
-		limiter := rl.getLimiter(apiKey)
+		// Get the rate limiter for this client
+		limiter := rl.getLimiter(client)
 
 		// Check if request is allowed
 		if !limiter.Allow() {
-			rl.sendRateLimitExceeded(w, r)
+			rl.sendRateLimitExceeded(w, r, limiter)
 			return
 		}
 
@@ -143,29 +245,23 @@ func (rl *RateLimitMiddleware) rateLimitHandler(next http.Handler) http.Handler
 }
 
 // sendRateLimitExceeded sends a 429 Too Many Requests response
-func (rl *RateLimitMiddleware) sendRateLimitExceeded(w http.ResponseWriter, r *http.Request) {
-	// Calculate retry-after based on rate limit
-	var retryAfter time.Duration
-	switch rl.rateLimit {
-	case 0:
-		retryAfter = time.Hour // For zero rate limit, suggest retrying much later
-	case rate.Inf:
-		retryAfter = time.Second // Should not happen, but fallback
-	default:
-		retryAfter = time.Duration(1) / time.Duration(rl.rateLimit)
+func (rl *RateLimitMiddleware) sendRateLimitExceeded(w http.ResponseWriter, r *http.Request, limiter *rate.Limiter) {
+	if rl.metrics != nil {
+		rl.metrics.RateLimitRejectionsTotal.WithLabelValues(rl.limitName).Inc()
 	}
 
 	// Set headers
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
-	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.burstSize))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(limiter)))
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Burst()))
 	w.Header().Set("X-RateLimit-Remaining", "0")
 	w.WriteHeader(http.StatusTooManyRequests)
 
 	// Send JSON error response consistent with OneBusAway API format
 	errorResponse := map[string]interface{}{
-		"code": http.StatusTooManyRequests,
-		"text": "Rate limit exceeded. Please try again later.",
+		"code":      http.StatusTooManyRequests,
+		"errorCode": errCodeRateLimited,
+		"text":      "Rate limit exceeded. Please try again later.",
 		"data": map[string]interface{}{
 			"entry": nil,
 			"references": map[string]interface{}{
@@ -185,6 +281,19 @@ func (rl *RateLimitMiddleware) sendRateLimitExceeded(w http.ResponseWriter, r *h
 	}
 }
 
+// retryAfterSeconds returns how many whole seconds pass before the limiter
+// has a token again, at least one.
+func retryAfterSeconds(limiter *rate.Limiter) int {
+	switch limiter.Limit() {
+	case 0:
+		return int(time.Hour / time.Second) // For zero rate limit, suggest retrying much later
+	case rate.Inf:
+		return 1 // Should not happen, but fallback
+	}
+	wait := (1 - limiter.Tokens()) / float64(limiter.Limit())
+	return max(1, int(math.Ceil(wait)))
+}
+
 // cleanupOnce performs a single iteration of removing old, unused limiters.
 // It is separated from the background loop so tests can trigger it synchronously.
 func (rl *RateLimitMiddleware) cleanupOnce() {
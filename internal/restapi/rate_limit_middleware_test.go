@@ -9,8 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/metrics"
 )
 
 // initRateLimitMiddleware initializes a rate limit middleware with clock.RealClock for testing
@@ -435,3 +437,98 @@ func TestRateLimitMiddleware_EdgeCases(t *testing.T) {
 			"Empty API key should be handled gracefully")
 	})
 }
+
+func TestIPRateLimitMiddleware_LimitsEachAddressAcrossKeys(t *testing.T) {
+	middleware := NewIPRateLimitMiddleware(2, 0, time.Minute, false, clock.RealClock{})
+	defer middleware.Stop()
+	m := metrics.New()
+	middleware.SetMetrics(m)
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test?key="+key, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Different keys, including an exempt one, share the address's budget
+	assert.Equal(t, http.StatusOK, serve("203.0.113.7:5000", "key-a").Code)
+	assert.Equal(t, http.StatusOK, serve("203.0.113.7:5001", "org.onebusaway.iphone").Code)
+	limited := serve("203.0.113.7:5002", "key-b")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "30", limited.Header().Get("Retry-After"), "one request per 30 seconds refills in 30 seconds")
+	assert.Equal(t, "2", limited.Header().Get("X-RateLimit-Limit"))
+
+	// Another address has its own budget
+	assert.Equal(t, http.StatusOK, serve("198.51.100.1:5000", "key-b").Code)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RateLimitRejectionsTotal.WithLabelValues("ip")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.RateLimitRejectionsTotal.WithLabelValues("key")))
+}
+
+func TestIPRateLimitMiddleware_Burst(t *testing.T) {
+	middleware := NewIPRateLimitMiddleware(1, 3, time.Minute, false, clock.RealClock{})
+	defer middleware.Stop()
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, req)
+		if w.Code == http.StatusOK {
+			allowed++
+		}
+	}
+	assert.Equal(t, 3, allowed)
+}
+
+func TestRateLimitMiddleware_CountsKeyRejections(t *testing.T) {
+	middleware := initRateLimitMiddleware(1, time.Minute)
+	defer middleware.Stop()
+	m := metrics.New()
+	middleware.SetMetrics(m)
+
+	limitedHandler := middleware.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i := 0; i < 3; i++ {
+		limitedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test?key=k", nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RateLimitRejectionsTotal.WithLabelValues("key")))
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name              string
+		remoteAddr        string
+		forwardedFor      []string
+		trustForwardedFor bool
+		want              string
+	}{
+		{name: "remote address", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "IPv6 remote address", remoteAddr: "[2001:db8::1]:5000", want: "2001:db8::1"},
+		{name: "remote address without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+		{name: "forwarded for ignored", remoteAddr: "10.0.0.1:5000", forwardedFor: []string{"203.0.113.7"}, want: "10.0.0.1"},
+		{name: "last forwarded entry", remoteAddr: "10.0.0.1:5000", forwardedFor: []string{"198.51.100.1, 203.0.113.7"}, trustForwardedFor: true, want: "203.0.113.7"},
+		{name: "last forwarded header", remoteAddr: "10.0.0.1:5000", forwardedFor: []string{"198.51.100.1", "203.0.113.7"}, trustForwardedFor: true, want: "203.0.113.7"},
+		{name: "no forwarded header", remoteAddr: "10.0.0.1:5000", trustForwardedFor: true, want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, tt.want, clientIP(req, tt.trustForwardedFor))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package restapi
 
 import (
 	"context"
+	"strings"
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/internal/models"
@@ -79,12 +80,20 @@ func (api *RestAPI) BuildRouteReferencesAsInterface(ctx context.Context, agencyI
 	return routeRefs, nil
 }
 
-func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID string) []models.Situation {
+// BuildSituationReferences converts alerts into situation references. Text fields
+// are localized to lang (see selectTranslation); an empty lang keeps the feed's
+// default language. Situation IDs are agency-prefixed to match GetSituationIDsForTrip.
+func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID string, lang string) []models.Situation {
 	situations := make([]models.Situation, 0, len(alerts))
 
 	for _, alert := range alerts {
+		situationID := alert.ID
+		if agencyID != "" {
+			situationID = utils.FormCombinedID(agencyID, alert.ID)
+		}
+
 		situation := models.Situation{
-			ID:                 alert.ID,
+			ID:                 situationID,
 			CreationTime:       0,
 			ActiveWindows:      make([]models.ActiveWindow, 0, len(alert.ActivePeriods)),
 			AllAffects:         make([]models.AffectedEntity, 0, len(alert.InformedEntities)),
@@ -123,31 +132,69 @@ func (api *RestAPI) BuildSituationReferences(alerts []gtfs.Alert, agencyID strin
 			situation.AllAffects = append(situation.AllAffects, affectedEntity)
 		}
 
-		if len(alert.Header) > 0 && alert.Header[0].Text != "" {
-			situation.Summary = &models.TranslatedString{
-				Value: alert.Header[0].Text,
-				Lang:  alert.Header[0].Language,
-			}
-		}
+		situation.Summary = selectTranslation(alert.Header, lang)
+		situation.Description = selectTranslation(alert.Description, lang)
+		situation.URL = selectTranslation(alert.URL, lang)
 
-		if len(alert.Description) > 0 && alert.Description[0].Text != "" {
-			situation.Description = &models.TranslatedString{
-				Value: alert.Description[0].Text,
-				Lang:  alert.Description[0].Language,
-			}
+		situations = append(situations, situation)
+	}
+
+	return situations
+}
+
+// selectTranslation picks the text best matching lang from a GTFS-RT translated
+// string: an exact language match first, then one sharing the primary language
+// subtag ("en" and "en-US"), then the untagged default, then the first entry.
+// All non-empty translations are kept alongside the selection when there are
+// several. Returns nil if no entry has text.
+func selectTranslation(texts []gtfs.AlertText, lang string) *models.TranslatedString {
+	translations := make([]models.Translation, 0, len(texts))
+	for _, text := range texts {
+		if text.Text != "" {
+			translations = append(translations, models.Translation{Value: text.Text, Lang: text.Language})
 		}
+	}
+	if len(translations) == 0 {
+		return nil
+	}
 
-		if len(alert.URL) > 0 && alert.URL[0].Text != "" {
-			situation.URL = &models.TranslatedString{
-				Value: alert.URL[0].Text,
-				Lang:  alert.URL[0].Language,
-			}
+	best := 0
+	bestScore := -1
+	for i, t := range translations {
+		score := translationScore(t.Lang, lang)
+		if score > bestScore {
+			best, bestScore = i, score
 		}
+	}
 
-		situations = append(situations, situation)
+	result := &models.TranslatedString{
+		Value: translations[best].Value,
+		Lang:  translations[best].Lang,
+	}
+	if len(translations) > 1 {
+		result.Translations = translations
+	}
+	return result
+}
+
+func translationScore(available, requested string) int {
+	switch {
+	case requested != "" && strings.EqualFold(available, requested):
+		return 3
+	case requested != "" && available != "" && strings.EqualFold(primaryLanguage(available), primaryLanguage(requested)):
+		return 2
+	case available == "":
+		return 1
+	default:
+		return 0
 	}
+}
 
-	return situations
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
 }
 
 func getStringValue(ptr *string) string {
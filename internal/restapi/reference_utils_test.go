@@ -0,0 +1,89 @@
+package restapi
+
+import (
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestSelectTranslation(t *testing.T) {
+	texts := []gtfs.AlertText{
+		{Text: "Detour in effect", Language: "en"},
+		{Text: "Desvío en efecto", Language: "es-MX"},
+		{Text: "", Language: "fr"},
+	}
+
+	tests := []struct {
+		name      string
+		lang      string
+		wantValue string
+		wantLang  string
+	}{
+		{"exact match", "en", "Detour in effect", "en"},
+		{"case-insensitive match", "ES-mx", "Desvío en efecto", "es-MX"},
+		{"primary subtag match", "es", "Desvío en efecto", "es-MX"},
+		{"region falls back to primary language", "en_GB", "Detour in effect", "en"},
+		{"empty translations are ignored", "fr", "Detour in effect", "en"},
+		{"no preference uses the first entry", "", "Detour in effect", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectTranslation(texts, tt.lang)
+			require.NotNil(t, got)
+			assert.Equal(t, tt.wantValue, got.Value)
+			assert.Equal(t, tt.wantLang, got.Lang)
+			assert.Equal(t, []models.Translation{
+				{Value: "Detour in effect", Lang: "en"},
+				{Value: "Desvío en efecto", Lang: "es-MX"},
+			}, got.Translations)
+		})
+	}
+}
+
+func TestSelectTranslation_PrefersUntaggedDefault(t *testing.T) {
+	texts := []gtfs.AlertText{
+		{Text: "Servicio reducido", Language: "es"},
+		{Text: "Reduced service", Language: ""},
+	}
+
+	got := selectTranslation(texts, "de")
+	require.NotNil(t, got)
+	assert.Equal(t, "Reduced service", got.Value)
+	assert.Empty(t, got.Lang)
+}
+
+func TestSelectTranslation_SingleAndEmpty(t *testing.T) {
+	got := selectTranslation([]gtfs.AlertText{{Text: "Stop closed", Language: "en"}}, "es")
+	require.NotNil(t, got)
+	assert.Equal(t, "Stop closed", got.Value)
+	assert.Nil(t, got.Translations, "a single translation is not repeated")
+
+	assert.Nil(t, selectTranslation(nil, "en"))
+	assert.Nil(t, selectTranslation([]gtfs.AlertText{{Text: ""}}, "en"))
+}
+
+func TestBuildSituationReferences_Localized(t *testing.T) {
+	api := &RestAPI{}
+	alerts := []gtfs.Alert{{
+		ID: "alert-1",
+		Header: []gtfs.AlertText{
+			{Text: "Detour", Language: "en"},
+			{Text: "Desvío", Language: "es"},
+		},
+		Description: []gtfs.AlertText{{Text: "Use Main St", Language: "en"}},
+	}}
+
+	situations := api.BuildSituationReferences(alerts, "25", "es")
+	require.Len(t, situations, 1)
+	assert.Equal(t, "25_alert-1", situations[0].ID)
+	require.NotNil(t, situations[0].Summary)
+	assert.Equal(t, "Desvío", situations[0].Summary.Value)
+	assert.Len(t, situations[0].Summary.Translations, 2)
+	require.NotNil(t, situations[0].Description)
+	assert.Equal(t, "Use Main St", situations[0].Description.Value)
+	assert.Nil(t, situations[0].URL)
+}
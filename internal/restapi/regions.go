@@ -0,0 +1,62 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/app"
+)
+
+// regionPathPrefix is the path under which each region's routes are served,
+// followed by the region ID.
+const regionPathPrefix = "/regions/"
+
+// SetRegionRoutes serves every route of each of the application's regions
+// under /regions/{id}/, answered from the region's own dataset. Region APIs
+// share api's rate limiters, so that a key's limit covers all regions, and
+// are shut down along with api.
+//
+// With arrival webhooks enabled, each region keeps its own subscriptions,
+// since they name the region's stops, and checks them against the region's
+// realtime data.
+func (api *RestAPI) SetRegionRoutes(mux *http.ServeMux) {
+	if len(api.Regions) == 0 {
+		return
+	}
+	// Regions that are not configured are not found, rather than falling
+	// through to the web UI.
+	mux.HandleFunc("GET "+regionPathPrefix, api.sendNotFound)
+
+	for _, region := range api.Regions {
+		regionAPI := api.newRegionAPI(region.Application)
+		api.regionAPIs = append(api.regionAPIs, regionAPI)
+
+		regionMux := http.NewServeMux()
+		regionAPI.SetRoutes(regionMux)
+		// Mounting the region with methods keeps it from conflicting with the
+		// web UI's "GET /". Only arrival subscriptions take POST and DELETE.
+		prefix := regionPathPrefix + region.ID
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+			mux.Handle(method+" "+prefix+"/", http.StripPrefix(prefix, regionMux))
+		}
+	}
+}
+
+// newRegionAPI creates the RestAPI of a region's application. Its caches are
+// its own, as they hold responses built from the region's dataset. Its
+// requests are tracked by api.TrackInFlight like any other.
+func (api *RestAPI) newRegionAPI(regionApp *app.Application) *RestAPI {
+	regionAPI := &RestAPI{
+		Application:     regionApp,
+		rateLimiter:     api.rateLimiter,
+		ipRateLimiter:   api.ipRateLimiter,
+		staleDetector:   newStaleDetectorFromConfig(regionApp.Config),
+		streamsDone:     make(chan struct{}),
+		responseCache:   newResponseCache(responseCacheMaxEntries, responseCacheMaxBytes),
+		tripStatusCache: newTripStatusCache(),
+		abortCtx:        api.abortCtx,
+	}
+	if regionApp.Config.EnableArrivalWebhooks && regionApp.GtfsManager != nil {
+		regionAPI.startArrivalWebhooks()
+	}
+	return regionAPI
+}
@@ -0,0 +1,100 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestRegionRoutes(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// The region serves the same dataset under a manager of its own.
+	regionApp := *api.Application
+	api.Regions = []app.Region{{ID: "north", Application: &regionApp}}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	api.SetRegionRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	require.Len(t, api.regionAPIs, 1)
+	assert.Same(t, &regionApp, api.regionAPIs[0].Application)
+	assert.NotSame(t, api.responseCache, api.regionAPIs[0].responseCache)
+
+	get := func(path string) (*http.Response, models.ResponseModel) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var model models.ResponseModel
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&model))
+		}
+		return resp, model
+	}
+
+	resp, model := get("/regions/north/api/where/agency/25.json?key=test")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "25", entry["id"])
+
+	resp, _ = get("/regions/south/api/where/agency/25.json?key=test")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "unknown regions are not served")
+
+	// A key's rate limit covers every region.
+	statuses := make([]int, 0, 6)
+	for i := 0; i < 3; i++ {
+		resp, _ = get("/api/where/current-time.json?key=TEST")
+		statuses = append(statuses, resp.StatusCode)
+		resp, _ = get("/regions/north/api/where/current-time.json?key=TEST")
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Contains(t, statuses, http.StatusTooManyRequests)
+
+	// Shutting the API down ends the region's streams too.
+	api.CloseStreams()
+	select {
+	case <-api.regionAPIs[0].streamsDone:
+	default:
+		t.Error("region streams are still open")
+	}
+}
+
+func TestRegionArrivalSubscriptions(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	regionApp := *api.Application
+	regionApp.Config.EnableArrivalWebhooks = true
+	api.Regions = []app.Region{{ID: "north", Application: &regionApp}}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	api.SetRegionRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	require.Len(t, api.regionAPIs, 1)
+	require.NotNil(t, api.regionAPIs[0].arrivalWebhooks, "the region checks its subscriptions")
+
+	resp, model := doAdminRequest(t, server, http.MethodPost, "/regions/north/api/subscriptions/arrivals?key="+subscriberKey,
+		`{"callbackUrl":"https://rider.example/hook","stopId":"25_1505","thresholdMinutes":5}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	id := model.Data.(map[string]interface{})["entry"].(map[string]interface{})["id"].(string)
+
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/regions/north/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "subscriptions belong to their region")
+
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/regions/north/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodGet, "/regions/north/api/subscriptions/arrivals/"+id+"?key="+subscriberKey, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -23,7 +23,7 @@ func (api *RestAPI) reportProblemWithStopHandler(w http.ResponseWriter, r *http.
 	// Safety check: Ensure DB is initialized
 	if api.GtfsManager == nil || api.GtfsManager.GtfsDB == nil || api.GtfsManager.GtfsDB.Queries == nil {
 		logger.Error("report problem with stop failed: GTFS DB not initialized")
-		http.Error(w, `{"code":500, "text":"internal server error"}`, http.StatusInternalServerError)
+		http.Error(w, `{"code":500, "errorCode":"INTERNAL_ERROR", "text":"internal server error"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -62,7 +62,7 @@ func (api *RestAPI) reportProblemWithStopHandler(w http.ResponseWriter, r *http.
 	if err != nil {
 		logging.LogError(logger, "failed to store problem report", err,
 			slog.String("stop_id", stopID))
-		http.Error(w, `{"code":500, "text":"failed to store problem report"}`, http.StatusInternalServerError)
+		http.Error(w, `{"code":500, "errorCode":"INTERNAL_ERROR", "text":"failed to store problem report"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -23,7 +23,7 @@ func (api *RestAPI) reportProblemWithTripHandler(w http.ResponseWriter, r *http.
 	// Safety check: Ensure DB is initialized
 	if api.GtfsManager == nil || api.GtfsManager.GtfsDB == nil || api.GtfsManager.GtfsDB.Queries == nil {
 		logger.Error("report problem with trip failed: GTFS DB not initialized")
-		http.Error(w, `{"code":500, "text":"internal server error"}`, http.StatusInternalServerError)
+		http.Error(w, `{"code":500, "errorCode":"INTERNAL_ERROR", "text":"internal server error"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -78,7 +78,7 @@ func (api *RestAPI) reportProblemWithTripHandler(w http.ResponseWriter, r *http.
 	if err != nil {
 		logging.LogError(logger, "failed to store problem report", err,
 			slog.String("trip_id", tripID))
-		http.Error(w, `{"code":500, "text":"failed to store problem report"}`, http.StatusInternalServerError)
+		http.Error(w, `{"code":500, "errorCode":"INTERNAL_ERROR", "text":"failed to store problem report"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -1,10 +1,13 @@
 package restapi
 
 import (
+	"context"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"time"
 
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/logging"
 )
 
@@ -19,14 +22,61 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// NewRequestLoggingMiddleware creates middleware that logs HTTP requests
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RequestLogConfig controls which requests the access log records.
+type RequestLogConfig struct {
+	// SampleRate is the fraction of requests logged, between 0 and 1. Requests
+	// that fail with a server error are logged regardless.
+	SampleRate float64
+	// SlowDBThreshold, when positive, logs a warning for every request that
+	// spends at least this long in the database, whether sampled or not.
+	SlowDBThreshold time.Duration
+}
+
+// requestLogEntry collects what inner handlers learn about a request for the
+// access log written once it completes.
+type requestLogEntry struct {
+	route   string
+	dbTimer gtfsdb.QueryTimer
+}
+
+type requestLogEntryKey struct{}
+
+// WithRoutePattern records the mux pattern that matched a request so the
+// access log can report it. The mux sets the pattern on the request it is
+// given, which middleware between it and the logger have replaced, so it must
+// wrap the mux directly.
+func WithRoutePattern(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if entry, ok := r.Context().Value(requestLogEntryKey{}).(*requestLogEntry); ok {
+			entry.route = r.Pattern
+		}
+	})
+}
+
+// NewRequestLoggingMiddleware creates middleware that logs every HTTP request
 func NewRequestLoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return NewRequestLoggingMiddlewareWithConfig(logger, RequestLogConfig{SampleRate: 1})
+}
+
+// NewRequestLoggingMiddlewareWithConfig creates middleware that logs a sample
+// of HTTP requests, with their route, API key and database time, and warns
+// about requests whose queries are slow.
+func NewRequestLoggingMiddlewareWithConfig(logger *slog.Logger, cfg RequestLogConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			// Add logger to context for downstream handlers
+			// Add logger, log entry and database timer to context for downstream handlers
+			entry := &requestLogEntry{}
 			ctx := logging.WithLogger(r.Context(), logger)
+			ctx = context.WithValue(ctx, requestLogEntryKey{}, entry)
+			ctx = gtfsdb.WithQueryTimer(ctx, &entry.dbTimer)
 			r = r.WithContext(ctx)
 
 			// Wrap response writer to capture status code
@@ -38,19 +88,61 @@ func NewRequestLoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Ha
 			// Call next handler
 			next.ServeHTTP(wrapped, r)
 
-			// Log the request
 			duration := time.Since(start)
+			dbTime := entry.dbTimer.Total()
+			slowDB := cfg.SlowDBThreshold > 0 && dbTime >= cfg.SlowDBThreshold
+			sampled := wrapped.statusCode >= http.StatusInternalServerError ||
+				cfg.SampleRate >= 1 || rand.Float64() < cfg.SampleRate
+			if !sampled && !slowDB {
+				return
+			}
 
 			reqID, _ := r.Context().Value(RequestIDKey).(string)
-
-			logging.LogHTTPRequest(logger,
-				r.Method,
-				r.URL.Path,
-				wrapped.statusCode,
-				float64(duration.Nanoseconds())/1e6,
+			attrs := []slog.Attr{
+				slog.String("route", entry.route),
+				slog.String("key", redactAPIKey(r.URL.Query().Get("key"))),
+				slog.Float64("db_ms", float64(dbTime.Nanoseconds())/1e6),
+				slog.Int64("db_queries", entry.dbTimer.Queries()),
 				slog.String("request_id", reqID),
 				slog.String("user_agent", r.Header.Get("User-Agent")),
-				slog.String("component", "http_server"))
+				slog.String("component", "http_server"),
+			}
+
+			// Log the request
+			if sampled {
+				logging.LogHTTPRequest(logger,
+					r.Method,
+					r.URL.Path,
+					wrapped.statusCode,
+					float64(duration.Nanoseconds())/1e6,
+					attrs...)
+			}
+			if slowDB {
+				args := make([]any, 0, len(attrs)+4)
+				args = append(args,
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", wrapped.statusCode),
+					slog.Float64("duration_ms", float64(duration.Nanoseconds())/1e6))
+				for _, attr := range attrs {
+					args = append(args, attr)
+				}
+				logger.Warn("slow_db_request", args...)
+			}
 		})
 	}
 }
+
+// redactAPIKey keeps enough of an API key to tell keys apart in the logs
+// without writing out the whole key. Keys too short to hide at least as much
+// as is shown are masked entirely.
+func redactAPIKey(key string) string {
+	const visible = 4
+	if key == "" {
+		return ""
+	}
+	if len(key) < 2*visible {
+		return "***"
+	}
+	return key[:visible] + "***"
+}
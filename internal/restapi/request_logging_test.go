@@ -11,6 +11,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/logging"
 )
 
@@ -253,6 +255,89 @@ func TestRequestLoggingWithContext(t *testing.T) {
 	})
 }
 
+func TestRequestLoggingMiddlewareWithConfig(t *testing.T) {
+	t.Run("records the route pattern and a redacted key", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.NewStructuredLogger(&buf, slog.LevelInfo)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/where/stop/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		// Middleware between the logger and the mux hands the mux a copy of the request.
+		inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WithRoutePattern(mux).ServeHTTP(w, r.WithContext(r.Context()))
+		})
+		handler := NewRequestLoggingMiddlewareWithConfig(logger, RequestLogConfig{SampleRate: 1})(inner)
+
+		req := httptest.NewRequest("GET", "/api/where/stop/1_75403.json?key=secret-key", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		output := buf.String()
+		assert.Contains(t, output, `"route":"GET /api/where/stop/{id}"`)
+		assert.Contains(t, output, `"key":"secr***"`)
+		assert.NotContains(t, output, "secret-key")
+		assert.Contains(t, output, `"db_queries":0`)
+	})
+
+	t.Run("sampling skips successful requests but not server errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.NewStructuredLogger(&buf, slog.LevelInfo)
+
+		status := http.StatusOK
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+		handler := NewRequestLoggingMiddlewareWithConfig(logger, RequestLogConfig{SampleRate: 0})(testHandler)
+
+		for i := 0; i < 10; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+		}
+		assert.Empty(t, buf.String())
+
+		status = http.StatusInternalServerError
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/broken", nil))
+		assert.Contains(t, buf.String(), `"path":"/broken"`)
+		assert.Contains(t, buf.String(), `"status":500`)
+	})
+
+	t.Run("warns about slow database time even when not sampled", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.NewStructuredLogger(&buf, slog.LevelInfo)
+
+		client, err := gtfsdb.NewClient(gtfsdb.Config{DBPath: ":memory:", Env: appconf.Test})
+		require.NoError(t, err)
+		defer func() { _ = client.Close() }()
+
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := client.Queries.ListAgencies(r.Context())
+			require.NoError(t, err)
+			w.WriteHeader(http.StatusOK)
+		})
+		handler := NewRequestLoggingMiddlewareWithConfig(logger, RequestLogConfig{
+			SampleRate:      0,
+			SlowDBThreshold: time.Nanosecond,
+		})(testHandler)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/where/agencies-with-coverage.json", nil))
+
+		output := buf.String()
+		assert.Contains(t, output, `"level":"WARN"`)
+		assert.Contains(t, output, `"msg":"slow_db_request"`)
+		assert.Contains(t, output, `"db_queries":1`)
+		assert.NotContains(t, output, `"msg":"http_request"`)
+	})
+}
+
+func TestRedactAPIKey(t *testing.T) {
+	assert.Equal(t, "", redactAPIKey(""))
+	assert.Equal(t, "***", redactAPIKey("T"))
+	assert.Equal(t, "***", redactAPIKey("TEST"))
+	assert.Equal(t, "***", redactAPIKey("TESTKEY"))
+	assert.Equal(t, "TEST***", redactAPIKey("TESTKEY1"))
+	assert.Equal(t, "org.***", redactAPIKey("org.onebusaway.iphone"))
+}
+
 // createHandlerWithRequestLogging creates an API handler with request logging enabled for testing
 func createHandlerWithRequestLogging(api *RestAPI, logger *slog.Logger) http.Handler {
 	// Create a simple test handler
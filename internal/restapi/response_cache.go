@@ -0,0 +1,208 @@
+package restapi
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	// responseCacheMaxEntries and responseCacheMaxBytes bound the in-memory
+	// response cache; the least recently used entries are evicted first.
+	responseCacheMaxEntries = 4096
+	responseCacheMaxBytes   = 64 << 20
+	// responseCacheMaxEntryBytes keeps very large responses (e.g. a busy stop's
+	// full-day schedule) from displacing many smaller ones.
+	responseCacheMaxEntryBytes = 2 << 20
+
+	// responseCacheHeader reports whether a response was served from the cache.
+	responseCacheHeader = "X-Cache"
+)
+
+// currentTimePrefix is how every encoded OK response starts, given the field
+// order of models.ResponseModel. Cached bodies keep the offset of the
+// timestamp that follows so it can be replaced on each hit.
+var currentTimePrefix = []byte(`{"code":200,"currentTime":`)
+
+// responseCache holds encoded responses of endpoints whose output depends only
+// on the request and the static GTFS data. Entries are tagged with the system
+// ETag they were built under, and the whole cache is dropped as soon as a
+// different ETag is seen, so a static data reload invalidates everything.
+type responseCache struct {
+	mu         sync.Mutex
+	etag       string
+	entries    map[string]*list.Element
+	lru        *list.List // Front is most recently used; values are *cachedResponse
+	size       int
+	maxEntries int
+	maxBytes   int
+}
+
+type cachedResponse struct {
+	key  string
+	body []byte
+	// timeStart and timeEnd delimit the currentTime digits in body.
+	timeStart, timeEnd int
+}
+
+func newResponseCache(maxEntries, maxBytes int) *responseCache {
+	return &responseCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (c *responseCache) get(etag, key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStaleLocked(etag)
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cachedResponse), true
+}
+
+// put stores body under key. Bodies that are too large, or that do not look
+// like an OK response, are not cached.
+func (c *responseCache) put(etag, key string, body []byte) {
+	if len(body) > responseCacheMaxEntryBytes || len(body) > c.maxBytes || !bytes.HasPrefix(body, currentTimePrefix) {
+		return
+	}
+	start := len(currentTimePrefix)
+	end := start
+	for end < len(body) && body[end] >= '0' && body[end] <= '9' {
+		end++
+	}
+	if end == start {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStaleLocked(etag)
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+	entry := &cachedResponse{key: key, body: body, timeStart: start, timeEnd: end}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += len(body)
+	for c.lru.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+func (c *responseCache) resetIfStaleLocked(etag string) {
+	if c.etag == etag {
+		return
+	}
+	c.etag = etag
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
+
+func (c *responseCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cachedResponse)
+	delete(c.entries, entry.key)
+	c.size -= len(entry.body)
+}
+
+// writeTo writes the cached body with currentTime replaced by now (Unix ms).
+func (e *cachedResponse) writeTo(w http.ResponseWriter, now int64) error {
+	if _, err := w.Write(e.body[:e.timeStart]); err != nil {
+		return err
+	}
+	if _, err := w.Write(strconv.AppendInt(nil, now, 10)); err != nil {
+		return err
+	}
+	_, err := w.Write(e.body[e.timeEnd:])
+	return err
+}
+
+// responseCacheKey identifies a request by path and query, leaving out the API
+// key so that all clients share entries. url.Values.Encode sorts parameters, so
+// their order in the request does not matter.
+func responseCacheKey(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del("key")
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// recordingResponseWriter passes a response through while keeping a copy of
+// its status and body.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// hasQueryParam reports whether the request sets the named query parameter,
+// for endpoints that are only static when a parameter pins them down.
+func hasQueryParam(name string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.URL.Query().Get(name) != ""
+	}
+}
+
+// cachedStatic serves successful responses of handler from the response cache.
+// cacheable, when non-nil, limits caching to matching requests. Misses run the
+// handler and store its output; only 200 responses are kept.
+func cachedStatic(api *RestAPI, cacheable func(*http.Request) bool, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.responseCache == nil || api.GtfsManager == nil || (cacheable != nil && !cacheable(r)) {
+			handler(w, r)
+			return
+		}
+		etag := api.GtfsManager.GetSystemETag()
+		if etag == "" {
+			handler(w, r)
+			return
+		}
+
+		key := responseCacheKey(r)
+		if entry, ok := api.responseCache.get(etag, key); ok {
+			setJSONResponseType(&w)
+			api.setRealtimeStaleHeader(w)
+			w.Header().Set(responseCacheHeader, "HIT")
+			if err := entry.writeTo(w, api.Clock.NowUnixMilli()); err != nil {
+				api.Logger.Warn("failed to write cached response", "path", r.URL.Path, "error", err)
+			}
+			return
+		}
+
+		w.Header().Set(responseCacheHeader, "MISS")
+		recorder := &recordingResponseWriter{ResponseWriter: w}
+		handler(recorder, r)
+		// Skip responses built while the static data was being swapped.
+		if recorder.status == http.StatusOK && api.GtfsManager.GetSystemETag() == etag {
+			api.responseCache.put(etag, key, bytes.Clone(recorder.body.Bytes()))
+		}
+	}
+}
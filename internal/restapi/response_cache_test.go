@@ -0,0 +1,131 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func okBody(currentTime int64, data string) []byte {
+	return fmt.Appendf(nil, `{"code":200,"currentTime":%d,"data":%q,"text":"OK","version":2}`+"\n", currentTime, data)
+}
+
+func TestResponseCacheReplacesCurrentTime(t *testing.T) {
+	cache := newResponseCache(10, 1<<20)
+	cache.put(`"v1"`, "/stop?", okBody(1000, "stop"))
+
+	entry, ok := cache.get(`"v1"`, "/stop?")
+	require.True(t, ok)
+	rr := httptest.NewRecorder()
+	require.NoError(t, entry.writeTo(rr, 1234567890123))
+	assert.Equal(t, string(okBody(1234567890123, "stop")), rr.Body.String())
+}
+
+func TestResponseCacheSkipsNonOKBodies(t *testing.T) {
+	cache := newResponseCache(10, 1<<20)
+	cache.put(`"v1"`, "/missing?", []byte(`{"code":404,"currentTime":1,"text":"resource not found","version":2}`))
+
+	_, ok := cache.get(`"v1"`, "/missing?")
+	assert.False(t, ok)
+}
+
+func TestResponseCacheDropsEntriesWhenETagChanges(t *testing.T) {
+	cache := newResponseCache(10, 1<<20)
+	cache.put(`"v1"`, "/a?", okBody(1, "a"))
+
+	_, ok := cache.get(`"v2"`, "/a?")
+	assert.False(t, ok)
+	_, ok = cache.get(`"v1"`, "/a?")
+	assert.False(t, ok, "entries from before a reload must not come back")
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache(2, 1<<20)
+	cache.put(`"v1"`, "/a?", okBody(1, "a"))
+	cache.put(`"v1"`, "/b?", okBody(1, "b"))
+	_, _ = cache.get(`"v1"`, "/a?")
+	cache.put(`"v1"`, "/c?", okBody(1, "c"))
+
+	_, ok := cache.get(`"v1"`, "/b?")
+	assert.False(t, ok)
+	_, ok = cache.get(`"v1"`, "/a?")
+	assert.True(t, ok)
+	_, ok = cache.get(`"v1"`, "/c?")
+	assert.True(t, ok)
+
+	small := newResponseCache(10, len(okBody(1, "a"))+1)
+	small.put(`"v1"`, "/a?", okBody(1, "a"))
+	small.put(`"v1"`, "/b?", okBody(1, "b"))
+	assert.Len(t, small.entries, 1, "the byte limit applies as well as the entry limit")
+}
+
+func TestResponseCacheKeyIgnoresAPIKeyAndParameterOrder(t *testing.T) {
+	a := httptest.NewRequest(http.MethodGet, "/api/where/stop/1_2.json?key=A&lang=fr&version=2", nil)
+	b := httptest.NewRequest(http.MethodGet, "/api/where/stop/1_2.json?version=2&lang=fr&key=B", nil)
+	c := httptest.NewRequest(http.MethodGet, "/api/where/stop/1_2.json?key=A&lang=de&version=2", nil)
+
+	assert.Equal(t, responseCacheKey(a), responseCacheKey(b))
+	assert.NotEqual(t, responseCacheKey(a), responseCacheKey(c))
+}
+
+func TestStopEndpointServedFromResponseCache(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+	require.NotEmpty(t, api.GtfsManager.GetSystemETag(), "test data should have import metadata")
+
+	stopID := utils.FormCombinedID(api.GtfsManager.GetAgencies()[0].Id, api.GtfsManager.GetStops()[0].Id)
+	endpoint := "/api/where/stop/" + stopID + ".json?key=TEST"
+
+	resp, first := serveApiAndRetrieveEndpoint(t, api, endpoint)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MISS", resp.Header.Get(responseCacheHeader))
+
+	mockClock.Advance(time.Minute)
+	resp, second := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=test")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HIT", resp.Header.Get(responseCacheHeader))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, api.GtfsManager.GetSystemETag(), resp.Header.Get("ETag"))
+	assert.Equal(t, first.Data, second.Data)
+	assert.Equal(t, mockClock.Now().UnixMilli(), second.CurrentTime, "cached responses report the current time")
+}
+
+func TestScheduleForStopCachedOnlyWithDate(t *testing.T) {
+	mockClock := clock.NewMockClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, mockClock)
+	defer api.Shutdown()
+
+	stopID := utils.FormCombinedID(api.GtfsManager.GetAgencies()[0].Id, api.GtfsManager.GetStops()[0].Id)
+
+	undated := "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST"
+	for range 2 {
+		resp, _ := serveApiAndRetrieveEndpoint(t, api, undated)
+		assert.Empty(t, resp.Header.Get(responseCacheHeader), "the default date follows the clock")
+	}
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, undated+"&date=2025-06-02")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MISS", resp.Header.Get(responseCacheHeader))
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, undated+"&date=2025-06-02")
+	assert.Equal(t, "HIT", resp.Header.Get(responseCacheHeader))
+}
+
+func TestResponseCacheDoesNotStoreErrors(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	endpoint := "/api/where/stop/" + utils.FormCombinedID(api.GtfsManager.GetAgencies()[0].Id, "does-not-exist") + ".json?key=TEST"
+	for range 2 {
+		resp, _ := serveApiAndRetrieveEndpoint(t, api, endpoint)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "MISS", resp.Header.Get(responseCacheHeader))
+	}
+}
@@ -0,0 +1,197 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonpCallbackPattern accepts dotted JavaScript identifiers such as
+// "handleStops" or "OBA.callbacks.cb12", which is all that JSONP clients send
+// and keeps callers from injecting script into the response.
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+// jsonpCallbackMaxLength bounds the callback name echoed back to the client.
+const jsonpCallbackMaxLength = 128
+
+// xmlNamePattern matches the JSON field names that can be used unchanged as
+// XML element names.
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][0-9A-Za-z_.-]*$`)
+
+// isXMLRequest reports whether the request was made to the .xml variant of an
+// endpoint, e.g. /api/where/stop/1_75403.xml.
+func isXMLRequest(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, ".xml")
+}
+
+// jsonpEnabled reports whether responses may be wrapped in a JSONP callback.
+func (api *RestAPI) jsonpEnabled() bool {
+	return api.Application != nil && api.Config.EnableJSONP
+}
+
+// validJSONPCallback reports whether callback is safe to echo back as the
+// name of the function wrapping a JSONP response.
+func validJSONPCallback(callback string) bool {
+	return len(callback) <= jsonpCallbackMaxLength && jsonpCallbackPattern.MatchString(callback)
+}
+
+// jsonpCallback returns the function a JSON response should be wrapped in, or
+// "" when JSONP is disabled or the request does not ask for a valid one.
+func (api *RestAPI) jsonpCallback(r *http.Request) string {
+	if !api.jsonpEnabled() {
+		return ""
+	}
+	callback := r.URL.Query().Get("callback")
+	if !validJSONPCallback(callback) {
+		return ""
+	}
+	return callback
+}
+
+// writeResponse writes response with the given status code in the format the
+// request asks for: XML for .xml endpoints, JSONP when enabled and a callback
+// is given, and JSON otherwise. JSONP responses always use status 200, as a
+// script tag cannot read the status; the code field of the body carries it.
+func (api *RestAPI) writeResponse(w http.ResponseWriter, r *http.Request, code int, response any) error {
+	if isXMLRequest(r) {
+		body, err := encodeXMLResponse(response)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.WriteHeader(code)
+		_, err = w.Write(body)
+		return err
+	}
+
+	if callback := api.jsonpCallback(r); callback != "" {
+		body, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		// The leading comment stops the body from being sniffed as anything
+		// other than script when the callback name is short.
+		_, err = io.WriteString(w, "/**/"+callback+"(")
+		if err == nil {
+			_, err = w.Write(body)
+		}
+		if err == nil {
+			_, err = io.WriteString(w, ");\n")
+		}
+		return err
+	}
+
+	setJSONResponseType(&w)
+	w.WriteHeader(code)
+	if largeJSONPayload(response) {
+		// The status is already sent, so a failure part way through can only
+		// be logged; the client sees a truncated body.
+		if err := writeJSONStream(w, response); err != nil {
+			api.Logger.Warn("failed to stream JSON response", "path", r.URL.Path, "error", err)
+		}
+		return nil
+	}
+	return json.NewEncoder(w).Encode(response)
+}
+
+// encodeXMLResponse renders response in the layout of the OneBusAway XML API:
+// the body sits under a <response> root, every JSON field becomes an element of
+// the same name, and array items are named after the singular of their
+// array's field, so "stops" holds <stop> elements.
+func encodeXMLResponse(response any) ([]byte, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if err := encodeXMLValue(dec, enc, xml.StartElement{Name: xml.Name{Local: "response"}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// encodeXMLValue reads the next JSON value from dec and writes it as the
+// element start. Nulls become empty elements.
+func encodeXMLValue(dec *json.Decoder, enc *xml.Encoder, start xml.StartElement) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		item := xml.StartElement{Name: xml.Name{Local: singularXMLName(start.Name.Local)}}
+		for dec.More() {
+			if v == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				item = xmlFieldElement(key.(string))
+			}
+			if err := encodeXMLValue(dec, enc, item); err != nil {
+				return err
+			}
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case string:
+		err = enc.EncodeToken(xml.CharData(v))
+	case json.Number:
+		err = enc.EncodeToken(xml.CharData(v.String()))
+	case bool:
+		err = enc.EncodeToken(xml.CharData(strconv.FormatBool(v)))
+	}
+	if err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlFieldElement returns the element for a JSON object field. Keys that are
+// not valid XML names, such as map keys holding IDs, are written as
+// <entry key="...">.
+func xmlFieldElement(key string) xml.StartElement {
+	if xmlNamePattern.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
+
+// singularXMLName names the items of an array field: "agencies" holds
+// <agency> elements and "stopIds" <stopId>. Fields that are not plural hold
+// <element> items.
+func singularXMLName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	default:
+		return "element"
+	}
+}
@@ -0,0 +1,132 @@
+package restapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getRaw requests endpoint from api and returns the response with its body.
+func getRaw(t *testing.T, api *RestAPI, endpoint string) (*http.Response, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + endpoint)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestXMLEndpoints(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, body := getRaw(t, api, "/api/where/current-time.xml?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/xml; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	var currentTime struct {
+		XMLName xml.Name `xml:"response"`
+		Code    int      `xml:"code"`
+		Version int      `xml:"version"`
+		Time    int64    `xml:"data>entry>time"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(body), &currentTime), body)
+	assert.Equal(t, 200, currentTime.Code)
+	assert.Equal(t, 2, currentTime.Version)
+	assert.NotZero(t, currentTime.Time)
+
+	resp, body = getRaw(t, api, "/api/where/stop/25_2000.xml?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stop struct {
+		ID       string   `xml:"data>entry>id"`
+		RouteIDs []string `xml:"data>entry>routeIds>routeId"`
+		Routes   []string `xml:"data>references>routes>route>id"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(body), &stop), body)
+	assert.Equal(t, "25_2000", stop.ID)
+	assert.NotEmpty(t, stop.RouteIDs)
+	assert.ElementsMatch(t, stop.RouteIDs, stop.Routes)
+
+	resp, body = getRaw(t, api, "/api/where/stop/25_nonexistent.xml?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, body, "<code>404</code>")
+
+	resp, body = getRaw(t, api, "/api/where/current-time.xml?key=invalid")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, body, "<text>permission denied</text>")
+}
+
+func TestJSONPCallback(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	t.Run("disabled by default", func(t *testing.T) {
+		resp, body := getRaw(t, api, "/api/where/current-time.json?key=TEST&callback=handle")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.True(t, json.Valid([]byte(body)))
+	})
+
+	api.Config.EnableJSONP = true
+
+	t.Run("wraps response", func(t *testing.T) {
+		resp, body := getRaw(t, api, "/api/where/current-time.json?key=TEST&callback=OBA.handle_1")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/javascript; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.True(t, strings.HasPrefix(body, "/**/OBA.handle_1("), body)
+		require.True(t, strings.HasSuffix(body, ");\n"), body)
+		payload := strings.TrimSuffix(strings.TrimPrefix(body, "/**/OBA.handle_1("), ");\n")
+		var response map[string]any
+		require.NoError(t, json.Unmarshal([]byte(payload), &response))
+		assert.Equal(t, float64(200), response["code"])
+	})
+
+	t.Run("errors keep status 200", func(t *testing.T) {
+		resp, body := getRaw(t, api, "/api/where/stop/25_nonexistent.json?key=TEST&callback=handle")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, `"code":404`)
+	})
+
+	t.Run("rejects invalid callback", func(t *testing.T) {
+		resp, body := getRaw(t, api, "/api/where/current-time.json?key=TEST&callback=alert(1)")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.NotContains(t, body, "alert(1)(")
+	})
+}
+
+func TestEncodeXMLResponse(t *testing.T) {
+	body, err := encodeXMLResponse(map[string]any{
+		"agencies":    []string{"25"},
+		"fieldErrors": map[string][]string{"1_2": {"bad <id>"}},
+		"entry":       nil,
+		"wheelchair":  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, xml.Header+`<response><agencies><agency>25</agency></agencies>`+
+		`<entry></entry>`+
+		`<fieldErrors><entry key="1_2"><element>bad &lt;id&gt;</element></entry></fieldErrors>`+
+		`<wheelchair>true</wheelchair></response>`+"\n", string(body))
+}
+
+func TestValidJSONPCallback(t *testing.T) {
+	for _, callback := range []string{"cb", "jQuery123_456", "$", "OBA.api.callbacks.cb3"} {
+		assert.True(t, validJSONPCallback(callback), callback)
+	}
+	for _, callback := range []string{"", "1cb", "cb()", "a..b", "a.", "cb;alert(1)", "<script>", strings.Repeat("a", jsonpCallbackMaxLength+1)} {
+		assert.False(t, validJSONPCallback(callback), callback)
+	}
+}
@@ -0,0 +1,44 @@
+package restapi
+
+const (
+	// defaultMaxShapePoints is the most points a shape response carries when
+	// the config does not set a limit.
+	defaultMaxShapePoints = 10000
+	// defaultMaxTripStops is the most stop times a trip schedule carries when
+	// the config does not set a limit.
+	defaultMaxTripStops = 1000
+)
+
+// maxShapePoints returns the configured cap on the points of a shape.
+func (api *RestAPI) maxShapePoints() int {
+	if api.Application == nil || api.Config.MaxShapePoints == 0 {
+		return defaultMaxShapePoints
+	}
+	return api.Config.MaxShapePoints
+}
+
+// maxTripStops returns the configured cap on the stop times of a trip schedule.
+func (api *RestAPI) maxTripStops() int {
+	if api.Application == nil || api.Config.MaxTripStops == 0 {
+		return defaultMaxTripStops
+	}
+	return api.Config.MaxTripStops
+}
+
+// thinPoints keeps at most limit of points, spread evenly along them and always
+// including the first and last, so that a thinned line still spans the whole
+// shape. It reports whether any point was dropped.
+func thinPoints[T any](points []T, limit int) ([]T, bool) {
+	if limit <= 0 || len(points) <= limit {
+		return points, false
+	}
+	if limit == 1 {
+		return points[:1], true
+	}
+	thinned := make([]T, limit)
+	last := len(points) - 1
+	for i := range limit {
+		thinned[i] = points[i*last/(limit-1)]
+	}
+	return thinned, true
+}
@@ -0,0 +1,57 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThinPoints(t *testing.T) {
+	points := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	thinned, truncated := thinPoints(points, 4)
+	assert.True(t, truncated)
+	assert.Equal(t, []int{0, 3, 6, 9}, thinned, "keeps both ends and spreads the rest evenly")
+
+	thinned, truncated = thinPoints(points, 10)
+	assert.False(t, truncated)
+	assert.Equal(t, points, thinned)
+
+	thinned, truncated = thinPoints(points, 1)
+	assert.True(t, truncated)
+	assert.Equal(t, []int{0}, thinned)
+}
+
+func TestShapesHandlerTruncatesLongShapes(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.MaxShapePoints = 3
+
+	points := []struct {
+		lat      float64
+		lon      float64
+		sequence int64
+	}{
+		{0.0, 0.0, 1},
+		{1.0, 1.0, 2},
+		{2.0, 2.0, 3},
+		{3.0, 3.0, 4},
+		{4.0, 4.0, 5},
+	}
+
+	agencyID := setupShapeTest(t, api, "long_shape", points)
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/shape/"+agencyID+"_long_shape.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, true, entry["truncated"])
+	assert.Equal(t, float64(3), entry["length"])
+
+	decoded := decodePolylinePoints(t, entry["points"].(string))
+	require.Len(t, decoded, 3)
+	assert.InDelta(t, 0.0, decoded[0][0], 1e-5)
+	assert.InDelta(t, 2.0, decoded[1][0], 1e-5)
+	assert.InDelta(t, 4.0, decoded[2][0], 1e-5)
+}
@@ -1,21 +1,32 @@
 package restapi
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"maglev.onebusaway.org/internal/models"
 )
 
+// realtimeStaleHeader is set on responses served while at least one GTFS-RT feed is
+// stale, letting clients tell scheduled-only answers apart from live ones.
+const realtimeStaleHeader = "X-Realtime-Stale"
+
 func (api *RestAPI) sendResponse(w http.ResponseWriter, r *http.Request, response models.ResponseModel) {
-	setJSONResponseType(&w)
-	err := json.NewEncoder(w).Encode(response)
+	api.setRealtimeStaleHeader(w)
+	api.translateNames(r, &response)
+	err := api.writeResponse(w, r, http.StatusOK, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
 }
 
+// setRealtimeStaleHeader flags the response while realtime data is degraded.
+func (api *RestAPI) setRealtimeStaleHeader(w http.ResponseWriter) {
+	if api.Application != nil && api.GtfsManager != nil && api.GtfsManager.IsRealtimeDegraded() {
+		w.Header().Set(realtimeStaleHeader, "true")
+	}
+}
+
 func (api *RestAPI) sendNull(w http.ResponseWriter, r *http.Request) { // nolint:unused
 	setJSONResponseType(&w)
 	_, err := w.Write([]byte("null"))
@@ -26,17 +37,21 @@ func (api *RestAPI) sendNull(w http.ResponseWriter, r *http.Request) { // nolint
 }
 
 func (api *RestAPI) sendNotFound(w http.ResponseWriter, r *http.Request) {
-	setJSONResponseType(&w)
-	w.WriteHeader(http.StatusNotFound)
+	api.sendNotFoundWithCode(w, r, errCodeNotFound)
+}
 
+// sendNotFoundWithCode sends a 404 response naming the kind of resource that
+// was not found in its errorCode.
+func (api *RestAPI) sendNotFoundWithCode(w http.ResponseWriter, r *http.Request, code errorCode) {
 	response := models.ResponseModel{
 		Code:        http.StatusNotFound,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(code),
 		Text:        "resource not found",
 		Version:     2,
 	}
 
-	err := json.NewEncoder(w).Encode(response)
+	err := api.writeResponse(w, r, http.StatusNotFound, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -44,17 +59,16 @@ func (api *RestAPI) sendNotFound(w http.ResponseWriter, r *http.Request) {
 }
 
 func (api *RestAPI) sendUnauthorized(w http.ResponseWriter, r *http.Request) { // nolint:unused
-	setJSONResponseType(&w)
-	w.WriteHeader(http.StatusUnauthorized)
 
 	response := models.ResponseModel{
 		Code:        http.StatusUnauthorized,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(errCodeInvalidAPIKey),
 		Text:        "permission denied",
 		Version:     1,
 	}
 
-	err := json.NewEncoder(w).Encode(response)
+	err := api.writeResponse(w, r, http.StatusUnauthorized, response)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -66,17 +80,20 @@ func setJSONResponseType(w *http.ResponseWriter) {
 }
 
 func (api *RestAPI) sendError(w http.ResponseWriter, r *http.Request, code int, message string) {
-	setJSONResponseType(&w)
-	w.WriteHeader(code)
+	api.sendErrorWithCode(w, r, code, errorCodeForStatus(code), message)
+}
 
+// sendErrorWithCode sends an error response with the given HTTP status and errorCode.
+func (api *RestAPI) sendErrorWithCode(w http.ResponseWriter, r *http.Request, status int, code errorCode, message string) {
 	response := models.ResponseModel{
-		Code:        code,
+		Code:        status,
 		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(code),
 		Text:        message,
 		Version:     2,
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := api.writeResponse(w, r, status, response); err != nil {
 		api.serverErrorResponse(w, r, err)
 	}
 }
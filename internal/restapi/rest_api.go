@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"maglev.onebusaway.org/internal/app"
@@ -8,20 +10,73 @@ import (
 
 type RestAPI struct {
 	*app.Application
-	rateLimiter *RateLimitMiddleware
+	rateLimiter     *RateLimitMiddleware
+	ipRateLimiter   *RateLimitMiddleware // Per-client-IP limit; nil when disabled
+	staleDetector   *StaleDetector
+	responseCache   *responseCache   // Encoded responses of static endpoints; nil disables caching
+	tripStatusCache *tripStatusCache // Recently built trip statuses; nil disables caching
+	streamsDone     chan struct{}    // Closed by CloseStreams to end long-lived stream responses
+	closeStreams    sync.Once
+	inFlight        inFlightRequests   // Requests served through TrackInFlight
+	abortCtx        context.Context    // Canceled when shutdown stops waiting for in-flight requests
+	abortRequests   context.CancelFunc // Cancels abortCtx
+	regionAPIs      []*RestAPI         // APIs of the regions served under /regions/{id}/
+	arrivalWebhooks *arrivalWebhooks   // Nil when arrival webhooks are disabled
 }
 
 // NewRestAPI creates a new RestAPI instance with initialized rate limiter
 func NewRestAPI(app *app.Application) *RestAPI {
-	return &RestAPI{
-		Application: app,
-		rateLimiter: NewRateLimitMiddleware(app.Config.RateLimit, time.Second, app.Config.ExemptApiKeys, app.Clock),
+	api := &RestAPI{
+		Application:     app,
+		rateLimiter:     NewRateLimitMiddleware(app.Config.RateLimit, time.Second, app.Config.ExemptApiKeys, app.Clock),
+		staleDetector:   newStaleDetectorFromConfig(app.Config),
+		streamsDone:     make(chan struct{}),
+		responseCache:   newResponseCache(responseCacheMaxEntries, responseCacheMaxBytes),
+		tripStatusCache: newTripStatusCache(),
+	}
+	api.abortCtx, api.abortRequests = context.WithCancel(context.Background())
+	api.rateLimiter.SetKeyLimits(api.storedKeyRateLimit)
+	api.rateLimiter.SetMetrics(app.Metrics)
+	if app.Config.IPRateLimit > 0 {
+		api.ipRateLimiter = NewIPRateLimitMiddleware(app.Config.IPRateLimit, app.Config.IPRateLimitBurst, time.Second,
+			app.Config.IPRateLimitTrustForwardedFor, app.Clock)
+		api.ipRateLimiter.SetMetrics(app.Metrics)
+	}
+	if app.Config.EnableArrivalWebhooks && app.GtfsManager != nil {
+		api.startArrivalWebhooks()
+	}
+	return api
+}
+
+// storedKeyRateLimit returns the rate limit assigned to a key in the API key
+// store, if the store is enabled and the key has one.
+func (api *RestAPI) storedKeyRateLimit(key string) (int, bool) {
+	if api.APIKeys == nil {
+		return 0, false
+	}
+	return api.APIKeys.RateLimit(key)
+}
+
+// CloseStreams ends all open streaming responses. It is registered with the
+// HTTP server's shutdown hooks, since http.Server.Shutdown otherwise waits for
+// streams that never finish on their own.
+func (api *RestAPI) CloseStreams() {
+	api.closeStreams.Do(func() {
+		if api.streamsDone != nil {
+			close(api.streamsDone)
+		}
+	})
+	for _, regionAPI := range api.regionAPIs {
+		regionAPI.CloseStreams()
 	}
 }
 
-// Shutdown gracefully stops the RestAPI resources
+// Shutdown gracefully stops the RestAPI resources, waiting up to
+// defaultShutdownTimeout for in-flight requests.
 func (api *RestAPI) Shutdown() {
-	if api.rateLimiter != nil {
-		api.rateLimiter.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	if err := api.ShutdownContext(ctx); err != nil && api.Application != nil && api.Logger != nil {
+		api.Logger.Warn("restapi shutdown", "error", err)
 	}
 }
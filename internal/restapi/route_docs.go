@@ -0,0 +1,589 @@
+package restapi
+
+import "maglev.onebusaway.org/internal/models"
+
+// routeAuth is the key a route requires.
+type routeAuth int
+
+const (
+	authAPIKey   routeAuth = iota // Any valid API key in the key parameter
+	authAdminKey                  // One of the configured admin keys
+	authNone                      // No key
+)
+
+// responseKind is the shape of the data a route responds with.
+type responseKind int
+
+const (
+	// entryResponse wraps a single model in data.entry, with references.
+	entryResponse responseKind = iota
+	// listResponse wraps models in data.list, with references and paging fields.
+	listResponse
+	// arrivalsResponse is the arrivals-and-departures-for-stop entry, which
+	// lists the arrivals of a stop.
+	arrivalsResponse
+	// dataResponse puts the model itself in data.
+	dataResponse
+	// emptyResponse is the envelope alone.
+	emptyResponse
+	// rawResponse is not the JSON envelope; the body has ContentType.
+	rawResponse
+)
+
+// responseDoc describes the successful response of a route.
+type responseDoc struct {
+	Kind responseKind
+	// Model is a value of the type described: the entry, the list element,
+	// the data or the raw JSON body.
+	Model       any
+	ContentType string // For rawResponse only
+}
+
+func entryOf(model any) responseDoc { return responseDoc{Kind: entryResponse, Model: model} }
+func listOf(model any) responseDoc  { return responseDoc{Kind: listResponse, Model: model} }
+
+// paramDoc describes a query parameter.
+type paramDoc struct {
+	Name        string
+	Type        string // OpenAPI type: string, integer, number or boolean
+	Required    bool
+	Description string
+}
+
+// routeDoc describes a route registered by SetRoutes. Every registered route
+// has one, which the route registry tests enforce.
+type routeDoc struct {
+	Summary  string
+	Auth     routeAuth
+	Params   []paramDoc
+	Body     any // Sample of the JSON request body, if the route takes one
+	Response responseDoc
+}
+
+// Query parameters shared by several routes.
+var (
+	langParam = paramDoc{Name: "lang", Type: "string", Description: "Preferred language of situation text and stop and route names, as a BCP-47 code"}
+	timeParam = paramDoc{Name: "time", Type: "integer", Description: "Time to answer for, in epoch milliseconds; defaults to now"}
+
+	locationParams = []paramDoc{
+		{Name: "lat", Type: "number", Required: true, Description: "Latitude of the search center"},
+		{Name: "lon", Type: "number", Required: true, Description: "Longitude of the search center"},
+		{Name: "radius", Type: "number", Description: "Search radius in meters"},
+		{Name: "latSpan", Type: "number", Description: "Height of the search box in degrees, instead of radius"},
+		{Name: "lonSpan", Type: "number", Description: "Width of the search box in degrees, instead of radius"},
+	}
+
+	pagingParams = []paramDoc{
+		{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+		{Name: "offset", Type: "integer", Description: "Number of results to skip"},
+		{Name: "cursor", Type: "string", Description: "nextCursor of the previous page, instead of offset"},
+	}
+
+	activeTripParams = append([]paramDoc{
+		timeParam,
+		{Name: "includeSchedule", Type: "boolean", Description: "Include the stop times of each trip; defaults to true"},
+		{Name: "includeStatus", Type: "boolean", Description: "Include the realtime status of each trip; defaults to true"},
+		{Name: "bikesAllowed", Type: "boolean", Description: "Only trips that do (true) or do not (false) allow bikes"},
+	}, pagingParams...)
+
+	problemReportParams = []paramDoc{
+		{Name: "code", Type: "string", Description: "Kind of problem"},
+		{Name: "userComment", Type: "string", Description: "Free-form description of the problem"},
+		{Name: "userLat", Type: "number", Description: "Latitude of the reporter"},
+		{Name: "userLon", Type: "number", Description: "Longitude of the reporter"},
+		{Name: "userLocationAccuracy", Type: "number", Description: "Accuracy of the reporter location in meters"},
+	}
+)
+
+// joinParams joins parameter lists.
+func joinParams(lists ...[]paramDoc) []paramDoc {
+	var all []paramDoc
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// routeDocs documents the routes registered by SetRoutes, keyed by pattern.
+var routeDocs = map[string]routeDoc{
+	"GET /healthz": {
+		Summary:  "Report whether the server is up and its GTFS data is ready",
+		Auth:     authNone,
+		Response: responseDoc{Kind: rawResponse, Model: HealthResponse{}, ContentType: "application/json"},
+	},
+	"GET /openapi.json": {
+		Summary:  "This OpenAPI document",
+		Auth:     authNone,
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/json"},
+	},
+
+	"GET /api/where/agencies-with-coverage": {
+		Summary:  "List the agencies and the area their stops cover",
+		Params:   pagingParams,
+		Response: listOf(models.AgencyCoverage{}),
+	},
+	"GET /api/where/agency-coverage": {
+		Summary:  "List the dates each agency has service",
+		Response: listOf(models.AgencyServiceCoverage{}),
+	},
+	"GET /api/where/search/stop": {
+		Summary: "Search stops by name or code",
+		Params: []paramDoc{
+			{Name: "input", Type: "string", Required: true, Description: "Text to search for"},
+			{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+			{Name: "lat", Type: "number", Description: "Latitude to rank nearby stops first"},
+			{Name: "lon", Type: "number", Description: "Longitude to rank nearby stops first"},
+			langParam,
+		},
+		Response: listOf(models.Stop{}),
+	},
+	"GET /api/where/search/route": {
+		Summary: "Search routes by name",
+		Params: []paramDoc{
+			{Name: "input", Type: "string", Required: true, Description: "Text to search for"},
+			{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+			langParam,
+		},
+		Response: listOf(models.Route{}),
+	},
+	"GET /api/where/current-time": {
+		Summary: "Return the server time and whether its data is ready",
+		Params: []paramDoc{
+			{Name: "clientTime", Type: "integer", Description: "Client time in epoch milliseconds, to report the clock skew"},
+		},
+		Response: responseDoc{Kind: dataResponse, Model: models.CurrentTimeData{}},
+	},
+	"GET /api/where/stops-for-location": {
+		Summary: "List the stops near a location",
+		Params: joinParams(locationParams, pagingParams, []paramDoc{
+			timeParam,
+			{Name: "query", Type: "string", Description: "Only stops whose code matches"},
+			{Name: "routeTypes", Type: "string", Description: "Comma separated GTFS route types or names, e.g. 3,ferry"},
+			{Name: "wheelchairAccessible", Type: "boolean", Description: "Only wheelchair accessible stops"},
+			langParam,
+		}),
+		Response: listOf(models.Stop{}),
+	},
+	"GET /api/where/routes-for-location": {
+		Summary: "List the routes serving stops near a location",
+		Params: joinParams(locationParams, []paramDoc{
+			{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+			{Name: "query", Type: "string", Description: "Only routes whose short name matches"},
+			{Name: "routeTypes", Type: "string", Description: "Comma separated GTFS route types or names, e.g. 3,ferry"},
+			langParam,
+		}),
+		Response: listOf(models.Route{}),
+	},
+	"GET /api/where/trips-for-location": {
+		Summary: "List the active trips with vehicles near a location",
+		Params: []paramDoc{
+			locationParams[0], locationParams[1], locationParams[3], locationParams[4],
+			timeParam,
+			{Name: "includeTrip", Type: "boolean", Description: "Include the trips in the references"},
+			{Name: "includeSchedule", Type: "boolean", Description: "Include the schedule of each trip"},
+		},
+		Response: listOf(models.TripsForLocationListEntry{}),
+	},
+	"GET /api/where/config": {
+		Summary:  "Describe the server build",
+		Response: entryOf(models.ConfigModel{}),
+	},
+	"GET /api/where/feed-info": {
+		Summary:  "Describe the static GTFS feed in use",
+		Response: entryOf(models.FeedMetadata{}),
+	},
+
+	"GET /siri/vehicle-monitoring": {
+		Summary: "SIRI VehicleMonitoring delivery of the vehicles in service",
+		Params: []paramDoc{
+			{Name: "LineRef", Type: "string", Description: "Only vehicles on this route"},
+			{Name: "VehicleRef", Type: "string", Description: "Only this vehicle"},
+			{Name: "type", Type: "string", Description: "xml (default) or json"},
+		},
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/xml"},
+	},
+	"GET /siri/stop-monitoring": {
+		Summary: "SIRI StopMonitoring delivery of the upcoming visits to a stop",
+		Params: []paramDoc{
+			{Name: "MonitoringRef", Type: "string", Required: true, Description: "Stop to monitor"},
+			{Name: "LineRef", Type: "string", Description: "Only visits on this route"},
+			{Name: "MaximumStopVisits", Type: "integer", Description: "Maximum number of visits to return"},
+			{Name: "type", Type: "string", Description: "xml (default) or json"},
+		},
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/xml"},
+	},
+	"GET /gtfs-rt/vehicle-positions": {
+		Summary:  "GTFS-realtime VehiclePositions feed of the merged realtime state",
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/x-protobuf"},
+	},
+	"GET /gtfs-rt/trip-updates": {
+		Summary:  "GTFS-realtime TripUpdates feed of the merged realtime state",
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/x-protobuf"},
+	},
+	"GET /gtfs-rt/alerts": {
+		Summary:  "GTFS-realtime Alerts feed of the merged realtime state",
+		Response: responseDoc{Kind: rawResponse, ContentType: "application/x-protobuf"},
+	},
+	"GET /api/stream/vehicles": {
+		Summary: "Server-Sent Events stream of vehicle changes",
+		Params: []paramDoc{
+			{Name: "routeId", Type: "string", Description: "Only vehicles on this route"},
+			{Name: "tripId", Type: "string", Description: "Only the vehicle on this trip"},
+			{Name: "bounds", Type: "string", Description: "Only vehicles inside minLat,minLon,maxLat,maxLon"},
+		},
+		Response: responseDoc{Kind: rawResponse, ContentType: "text/event-stream"},
+	},
+
+	"GET /api/subscriptions/arrivals": {
+		Summary:  "List the arrival subscriptions made with the API key",
+		Response: listOf(models.ArrivalSubscription{}),
+	},
+	"POST /api/subscriptions/arrivals": {
+		Summary: "Subscribe a callback URL to arrivals at a stop; the response carries the secret notifications are signed with",
+		Body: struct {
+			CallbackURL      string `json:"callbackUrl"`
+			StopID           string `json:"stopId"`
+			RouteID          string `json:"routeId,omitempty"`
+			ThresholdMinutes int    `json:"thresholdMinutes"`
+			ExpiresAt        *int64 `json:"expiresAt,omitempty"`
+		}{},
+		Response: entryOf(models.ArrivalSubscription{}),
+	},
+	"GET /api/subscriptions/arrivals/{id}": {
+		Summary:  "Look up an arrival subscription",
+		Response: entryOf(models.ArrivalSubscription{}),
+	},
+	"DELETE /api/subscriptions/arrivals/{id}": {
+		Summary:  "Cancel an arrival subscription",
+		Response: responseDoc{Kind: emptyResponse},
+	},
+
+	"GET /api/admin/api-keys": {
+		Summary:  "List the stored API keys",
+		Auth:     authAdminKey,
+		Response: listOf(models.APIKey{}),
+	},
+	"POST /api/admin/api-keys": {
+		Summary: "Create an API key",
+		Auth:    authAdminKey,
+		Body: struct {
+			Key       string `json:"key,omitempty"`
+			Name      string `json:"name,omitempty"`
+			RateLimit *int   `json:"rateLimit,omitempty"`
+			ExpiresAt *int64 `json:"expiresAt,omitempty"`
+		}{},
+		Response: entryOf(models.APIKey{}),
+	},
+	"GET /api/admin/api-keys/{key}": {
+		Summary:  "Look up a stored API key",
+		Auth:     authAdminKey,
+		Response: entryOf(models.APIKey{}),
+	},
+	"PATCH /api/admin/api-keys/{key}": {
+		Summary: "Update the name, rate limit or expiry of an API key; null clears a limit",
+		Auth:    authAdminKey,
+		Body: struct {
+			Name      string `json:"name,omitempty"`
+			RateLimit *int   `json:"rateLimit,omitempty"`
+			ExpiresAt *int64 `json:"expiresAt,omitempty"`
+		}{},
+		Response: entryOf(models.APIKey{}),
+	},
+	"DELETE /api/admin/api-keys/{key}": {
+		Summary:  "Delete an API key",
+		Auth:     authAdminKey,
+		Response: responseDoc{Kind: emptyResponse},
+	},
+	"GET /api/admin/import-warnings": {
+		Summary: "List the problems found while importing the static GTFS feed",
+		Auth:    authAdminKey,
+		Params: joinParams([]paramDoc{
+			{Name: "file", Type: "string", Description: "Only warnings about this GTFS file"},
+			{Name: "kind", Type: "string", Description: "Only warnings of this kind"},
+		}, pagingParams),
+		Response: listOf(models.ImportWarning{}),
+	},
+	"GET /api/admin/import-warnings/summary": {
+		Summary:  "Count the import warnings by file and kind",
+		Auth:     authAdminKey,
+		Response: listOf(models.ImportWarningCount{}),
+	},
+	"GET /api/admin/vehicle-assignments": {
+		Summary:  "List the dispatcher vehicle assignments",
+		Auth:     authAdminKey,
+		Response: listOf(models.VehicleAssignment{}),
+	},
+	"POST /api/admin/vehicle-assignments": {
+		Summary: "Assign a vehicle to a trip or block",
+		Auth:    authAdminKey,
+		Body: struct {
+			VehicleID string `json:"vehicleId"`
+			TripID    string `json:"tripId,omitempty"`
+			BlockID   string `json:"blockId,omitempty"`
+			ExpiresAt *int64 `json:"expiresAt,omitempty"`
+		}{},
+		Response: entryOf(models.VehicleAssignment{}),
+	},
+	"DELETE /api/admin/vehicle-assignments/{vehicleId}": {
+		Summary:  "Remove the assignment of a vehicle",
+		Auth:     authAdminKey,
+		Response: responseDoc{Kind: emptyResponse},
+	},
+
+	"GET /api/where/agency/{id}": {
+		Summary:  "Look up an agency",
+		Response: entryOf(models.AgencyReference{}),
+	},
+	"GET /api/where/routes-for-agency/{id}": {
+		Summary:  "List the routes of an agency",
+		Params:   joinParams(pagingParams, []paramDoc{langParam}),
+		Response: listOf(models.Route{}),
+	},
+	"GET /api/where/stop-ids-for-agency/{id}": {
+		Summary:  "List the stop IDs of an agency",
+		Response: listOf(""),
+	},
+	"GET /api/where/stop-by-code/{id}": {
+		Summary: "List the stops with a stop code, as printed on signage",
+		Params: []paramDoc{
+			{Name: "agencyId", Type: "string", Description: "Only list stops served by this agency"},
+			{Name: "lat", Type: "number", Description: "Latitude to measure each stop's distance from and list the nearest first"},
+			{Name: "lon", Type: "number", Description: "Longitude to measure each stop's distance from and list the nearest first"},
+		},
+		Response: listOf(models.Stop{}),
+	},
+	"GET /api/where/stops-for-agency/{id}": {
+		Summary:  "List the stops of an agency",
+		Params:   []paramDoc{langParam},
+		Response: listOf(models.Stop{}),
+	},
+	"GET /api/where/route-ids-for-agency/{id}": {
+		Summary:  "List the route IDs of an agency",
+		Response: listOf(""),
+	},
+	"GET /api/where/blocks-for-agency/{id}": {
+		Summary: "List the blocks of an agency",
+		Params: []paramDoc{
+			{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+			{Name: "offset", Type: "integer", Description: "Number of results to skip"},
+			{Name: "cursor", Type: "string", Description: "nextCursor of the previous page, instead of offset"},
+		},
+		Response: listOf(models.BlockEntry{}),
+	},
+	"GET /api/where/vehicles-for-agency/{id}": {
+		Summary:  "List the vehicles of an agency with their trip status",
+		Params:   pagingParams,
+		Response: listOf(models.VehicleStatus{}),
+	},
+	"GET /api/where/trips-for-agency/{id}": {
+		Summary:  "List the active trips of an agency",
+		Params:   activeTripParams,
+		Response: listOf(models.TripsForRouteListEntry{}),
+	},
+	"GET /api/where/situations-for-agency/{id}": {
+		Summary:  "List the active service alerts of an agency",
+		Params:   []paramDoc{langParam},
+		Response: listOf(models.Situation{}),
+	},
+	"GET /api/where/on-time-performance/{id}": {
+		Summary: "Summarize how punctual the trips of an agency were",
+		Params: []paramDoc{
+			{Name: "startTime", Type: "integer", Description: "Start of the window in epoch milliseconds"},
+			{Name: "endTime", Type: "integer", Description: "End of the window in epoch milliseconds"},
+		},
+		Response: entryOf(models.OnTimePerformance{}),
+	},
+	"GET /api/where/data-quality/{id}": {
+		Summary:  "Summarize how well the realtime feeds cover the routes of an agency",
+		Response: entryOf(models.DataQuality{}),
+	},
+
+	"GET /api/where/trip/{id}": {
+		Summary:  "Look up a trip",
+		Response: entryOf(models.TripResponse{}),
+	},
+	"GET /api/where/route/{id}": {
+		Summary:  "Look up a route",
+		Params:   []paramDoc{langParam},
+		Response: entryOf(models.Route{}),
+	},
+	"GET /api/where/stop/{id}": {
+		Summary:  "Look up a stop",
+		Params:   []paramDoc{langParam},
+		Response: entryOf(models.Stop{}),
+	},
+	"GET /api/where/shape/{id}": {
+		Summary:  "Look up a shape as an encoded polyline",
+		Response: entryOf(models.ShapeEntry{}),
+	},
+	"GET /api/where/trip-geometry/{id}": {
+		Summary:  "Return the path of a trip and where its stops are along it",
+		Response: entryOf(models.TripGeometry{}),
+	},
+	"GET /api/where/stops-for-route/{id}": {
+		Summary: "List the stops of a route, grouped by direction",
+		Params: []paramDoc{
+			{Name: "includePolylines", Type: "boolean", Description: "Include the route shapes; defaults to true"},
+			{Name: "time", Type: "string", Description: "Service date as YYYY-MM-DD or epoch milliseconds"},
+			langParam,
+		},
+		Response: entryOf(models.RouteEntry{}),
+	},
+	"GET /api/where/schedule-for-stop/{id}": {
+		Summary: "Return the schedule of a stop for a service date",
+		Params: []paramDoc{
+			{Name: "date", Type: "string", Description: "Service date as YYYY-MM-DD; defaults to today"},
+		},
+		Response: entryOf(models.ScheduleForStopEntry{}),
+	},
+	"GET /api/where/schedule-for-route/{id}": {
+		Summary: "Return the schedule of a route for a service date",
+		Params: []paramDoc{
+			{Name: "date", Type: "string", Description: "Service date as YYYY-MM-DD; defaults to today"},
+		},
+		Response: entryOf(models.ScheduleForRouteEntry{}),
+	},
+	"GET /api/export/route-timetable/{id}": {
+		Summary: "Export the timetable of a route for a service date",
+		Params: []paramDoc{
+			{Name: "date", Type: "string", Description: "Service date as YYYY-MM-DD; defaults to today"},
+			{Name: "format", Type: "string", Description: "csv (default) or html"},
+		},
+		Response: responseDoc{Kind: rawResponse, ContentType: "text/csv"},
+	},
+	"GET /api/where/block/{id}": {
+		Summary:  "Look up a block and its trips",
+		Response: entryOf(models.BlockResponse{}),
+	},
+	"GET /api/where/fares-for-route/{id}": {
+		Summary: "List the fares of a route",
+		Params: []paramDoc{
+			{Name: "fromStop", Type: "string", Description: "Only fares from this stop"},
+			{Name: "toStop", Type: "string", Description: "Only fares to this stop"},
+		},
+		Response: listOf(models.Fare{}),
+	},
+	"GET /api/where/fare-for-trip/{id}": {
+		Summary: "Return the fare of a trip between two stops",
+		Params: []paramDoc{
+			{Name: "fromStop", Type: "string", Description: "Boarding stop; defaults to the first stop of the trip"},
+			{Name: "toStop", Type: "string", Description: "Alighting stop; defaults to the last stop of the trip"},
+		},
+		Response: entryOf(models.Fare{}),
+	},
+
+	"GET /api/where/report-problem-with-trip/{id}": {
+		Summary: "Report a problem with a trip",
+		Params: joinParams(problemReportParams, []paramDoc{
+			{Name: "serviceDate", Type: "integer", Description: "Service date of the trip in epoch milliseconds"},
+			{Name: "stopId", Type: "string", Description: "Stop the problem was seen at"},
+			{Name: "vehicleId", Type: "string", Description: "Vehicle serving the trip"},
+			{Name: "userOnVehicle", Type: "boolean", Description: "Whether the reporter is on the vehicle"},
+			{Name: "userVehicleNumber", Type: "string", Description: "Vehicle number the reporter saw"},
+		}),
+		Response: responseDoc{Kind: emptyResponse},
+	},
+	"GET /api/where/report-problem-with-stop/{id}": {
+		Summary:  "Report a problem with a stop",
+		Params:   problemReportParams,
+		Response: responseDoc{Kind: emptyResponse},
+	},
+	"GET /api/where/problem-reports-for-trip/{id}": {
+		Summary:  "List the problems reported with a trip",
+		Response: listOf(models.ProblemReportTrip{}),
+	},
+	"GET /api/where/problem-reports-for-stop/{id}": {
+		Summary:  "List the problems reported with a stop",
+		Response: listOf(models.ProblemReportStop{}),
+	},
+	"GET /api/where/trip-details/{id}": {
+		Summary: "Return a trip with its schedule and realtime status",
+		Params: []paramDoc{
+			timeParam,
+			{Name: "serviceDate", Type: "integer", Description: "Service date of the trip in epoch milliseconds"},
+			{Name: "includeTrip", Type: "boolean", Description: "Include the trip in the references; defaults to true"},
+			{Name: "includeSchedule", Type: "boolean", Description: "Include the schedule; defaults to true"},
+			{Name: "includeStatus", Type: "boolean", Description: "Include the realtime status; defaults to true"},
+			langParam,
+		},
+		Response: entryOf(models.TripDetails{}),
+	},
+	"GET /api/where/trip-for-vehicle/{id}": {
+		Summary: "Return the trip a vehicle is serving",
+		Params: []paramDoc{
+			timeParam,
+			{Name: "serviceDate", Type: "integer", Description: "Service date of the trip in epoch milliseconds"},
+			{Name: "includeTrip", Type: "boolean", Description: "Include the trip in the references; defaults to true"},
+			{Name: "includeSchedule", Type: "boolean", Description: "Include the schedule; defaults to false"},
+			{Name: "includeStatus", Type: "boolean", Description: "Include the realtime status; defaults to true"},
+		},
+		Response: entryOf(models.TripDetails{}),
+	},
+	"GET /api/where/situations-for-stop/{id}": {
+		Summary:  "List the active service alerts affecting a stop",
+		Params:   []paramDoc{langParam},
+		Response: listOf(models.Situation{}),
+	},
+	"GET /api/where/situation/{id}": {
+		Summary:  "Look up a service alert",
+		Params:   []paramDoc{langParam},
+		Response: entryOf(models.Situation{}),
+	},
+	"GET /api/where/vehicle-trajectory/{id}": {
+		Summary: "Return the recorded positions of a vehicle",
+		Params: []paramDoc{
+			timeParam,
+			{Name: "minutes", Type: "integer", Description: "Length of the window before time, in minutes"},
+		},
+		Response: entryOf(models.VehicleTrajectory{}),
+	},
+	"GET /api/where/arrival-and-departure-for-stop/{id}": {
+		Summary: "Return one arrival of a trip at a stop",
+		Params: []paramDoc{
+			{Name: "tripId", Type: "string", Required: true, Description: "Trip arriving at the stop"},
+			{Name: "serviceDate", Type: "integer", Required: true, Description: "Service date of the trip in epoch milliseconds"},
+			{Name: "stopSequence", Type: "integer", Description: "Which visit to the stop, for trips visiting it more than once"},
+			{Name: "vehicleId", Type: "string", Description: "Vehicle serving the trip"},
+			timeParam,
+			{Name: "minutesBefore", Type: "integer", Description: "Minutes before time to search"},
+			{Name: "minutesAfter", Type: "integer", Description: "Minutes after time to search"},
+			langParam,
+		},
+		Response: entryOf(models.ArrivalAndDeparture{}),
+	},
+	"GET /api/where/trips-for-route/{id}": {
+		Summary:  "List the active trips of a route",
+		Params:   activeTripParams,
+		Response: listOf(models.TripsForRouteListEntry{}),
+	},
+	"GET /api/where/detours-for-route/{id}": {
+		Summary:  "List the detours of a route",
+		Response: entryOf(models.RouteDetours{}),
+	},
+	"GET /api/where/arrivals-and-departures-for-stop/{id}": {
+		Summary: "List the upcoming arrivals and departures at a stop",
+		Params: joinParams([]paramDoc{
+			timeParam,
+			{Name: "minutesBefore", Type: "integer", Description: "Minutes before time to include"},
+			{Name: "minutesAfter", Type: "integer", Description: "Minutes after time to include"},
+			{Name: "routeTypes", Type: "string", Description: "Comma separated GTFS route types or names, e.g. 3,ferry"},
+			{Name: "wheelchairAccessible", Type: "boolean", Description: "Only wheelchair accessible trips"},
+			{Name: "bikesAllowed", Type: "boolean", Description: "Only trips that allow bikes"},
+			{Name: "nearbyStopsRadius", Type: "number", Description: "Radius in meters to list nearby stops in"},
+			{Name: "nearbyStopsMaxCount", Type: "integer", Description: "Maximum number of nearby stops"},
+			{Name: "nearbyStopsRouteType", Type: "string", Description: "Only nearby stops served by these route types"},
+			langParam,
+		}, pagingParams),
+		Response: responseDoc{Kind: arrivalsResponse, Model: models.ArrivalAndDeparture{}},
+	},
+	"GET /api/where/plan-departure/{id}": {
+		Summary: "List the departures from a stop that reach another stop",
+		Params: []paramDoc{
+			{Name: "toStopId", Type: "string", Required: true, Description: "Destination stop"},
+			timeParam,
+			{Name: "minutesAfter", Type: "integer", Description: "Minutes after time to search"},
+			{Name: "maxCount", Type: "integer", Description: "Maximum number of results to return"},
+		},
+		Response: listOf(models.PlannedDeparture{}),
+	},
+}
@@ -17,9 +17,9 @@ func (api *RestAPI) routeHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, routeID)
 	if err != nil || route.ID == "" {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
 		return
 	}
 
@@ -32,7 +32,7 @@ func (api *RestAPI) routeHandler(w http.ResponseWriter, r *http.Request) {
 		models.RouteType(route.Type),
 		route.Url.String,
 		route.Color.String,
-		route.TextColor.String)
+		route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 	references := models.NewEmptyReferences()
 
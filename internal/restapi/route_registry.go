@@ -0,0 +1,70 @@
+package restapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// registeredRoute is one pattern registered by SetRoutes.
+type registeredRoute struct {
+	Method string
+	// Path is the pattern path. Endpoints served under several format
+	// suffixes are recorded once, without the suffix.
+	Path string
+	// Formats lists the suffixes the path is registered under, e.g. ".json"
+	// and ".xml"; empty when the path is registered as is.
+	Formats []string
+}
+
+// pattern returns the route as written in SetRoutes and in routeDocs.
+func (r registeredRoute) pattern() string {
+	return r.Method + " " + r.Path
+}
+
+// pathParams returns the names of the wildcards in the path, in order.
+func (r registeredRoute) pathParams() []string {
+	var params []string
+	for _, segment := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, strings.TrimSuffix(strings.Trim(segment, "{}"), "..."))
+		}
+	}
+	return params
+}
+
+// routeRegistry registers handlers with a mux and records every route, so
+// that the OpenAPI document describes exactly the routes being served.
+type routeRegistry struct {
+	mux    *http.ServeMux
+	routes []registeredRoute
+}
+
+func newRouteRegistry(mux *http.ServeMux) *routeRegistry {
+	return &routeRegistry{mux: mux}
+}
+
+// handle registers a handler for a "METHOD /path" pattern.
+func (rr *routeRegistry) handle(pattern string, handler http.Handler) {
+	rr.mux.Handle(pattern, handler)
+	rr.record(pattern, nil)
+}
+
+// handleFunc registers a handler function for a "METHOD /path" pattern.
+func (rr *routeRegistry) handleFunc(pattern string, handler handlerFunc) {
+	rr.handle(pattern, http.HandlerFunc(handler))
+}
+
+// handleJSONAndXML registers an endpoint under both its .json and .xml paths.
+// Endpoints taking an {id} serve both already, as the suffix is part of the ID.
+func (rr *routeRegistry) handleJSONAndXML(pattern string, handler http.Handler) {
+	formats := []string{".json", ".xml"}
+	for _, format := range formats {
+		rr.mux.Handle(pattern+format, handler)
+	}
+	rr.record(pattern, formats)
+}
+
+func (rr *routeRegistry) record(pattern string, formats []string) {
+	method, path, _ := strings.Cut(pattern, " ")
+	rr.routes = append(rr.routes, registeredRoute{Method: method, Path: path, Formats: formats})
+}
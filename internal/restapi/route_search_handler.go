@@ -9,9 +9,9 @@ import (
 )
 
 func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
+	q := newQueryParams(r)
 
-	input := queryParams.Get("input")
+	input := q.values.Get("input")
 	sanitizedInput, err := utils.ValidateAndSanitizeQuery(input)
 	if err != nil {
 		fieldErrors := map[string][]string{
@@ -32,23 +32,8 @@ func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	// maxCount defaults to 20
-	maxCount := 20
-	var fieldErrors map[string][]string
-	if maxCountStr := queryParams.Get("maxCount"); maxCountStr != "" {
-		parsedMaxCount, fe := utils.ParseFloatParam(queryParams, "maxCount", fieldErrors)
-		fieldErrors = fe
-		if parsedMaxCount <= 0 {
-			fieldErrors["maxCount"] = append(fieldErrors["maxCount"], "must be greater than zero")
-		} else {
-			maxCount = int(parsedMaxCount)
-			if maxCount > 100 {
-				fieldErrors["maxCount"] = append(fieldErrors["maxCount"], "must not exceed 100")
-			}
-		}
-	}
-
-	if len(fieldErrors) > 0 {
+	maxCount := q.intParam("maxCount", 20, between(1, 100))
+	if fieldErrors := q.errors(); fieldErrors != nil {
 		api.validationErrorResponse(w, r, fieldErrors)
 		return
 	}
@@ -108,7 +93,7 @@ func (api *RestAPI) routeSearchHandler(w http.ResponseWriter, r *http.Request) {
 			models.RouteType(routeRow.Type),
 			url,
 			color,
-			textColor))
+			textColor).WithSortOrder(utils.NullInt32Ptr(routeRow.SortOrder)))
 	}
 
 	agencies := utils.FilterAgencies(api.GtfsManager.GetAgencies(), agencyIDs)
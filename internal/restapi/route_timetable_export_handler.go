@@ -0,0 +1,131 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/export"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// routeTimetableExportHandler renders a route's schedule for a day (`date`,
+// default today in the agency's timezone) as a CSV download or a printable
+// HTML page, chosen by `format`. The trips and stop times are those of
+// schedule-for-route.
+func (api *RestAPI) routeTimetableExportHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	agencyID := parsed.AgencyID
+	routeID := parsed.CodeID
+
+	query := r.URL.Query()
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"format": {err.Error()}})
+		return
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	queries := api.GtfsManager.GtfsDB.Queries
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, routeID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
+		return
+	}
+	agency, err := queries.GetAgency(ctx, agencyID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return
+	}
+	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
+
+	serviceDate, fieldErrors := api.parseScheduleDate(query.Get("date"), loc)
+	if fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+	targetDate := serviceDate.Format("20060102")
+
+	validityMsg, err := api.feedValidityMessage(ctx, targetDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if validityMsg != "" {
+		api.validationErrorResponseWithCode(w, r, errCodeDateOutOfRange, map[string][]string{"date": {validityMsg}})
+		return
+	}
+
+	timetable := export.Timetable{
+		AgencyName:     agency.Name,
+		RouteShortName: route.ShortName.String,
+		RouteLongName:  route.LongName.String,
+		ServiceDate:    serviceDate,
+	}
+
+	serviceIDs, err := queries.GetActiveServiceIDsForDate(ctx, targetDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	var trips []gtfsdb.Trip
+	if len(serviceIDs) > 0 {
+		trips, err = queries.GetTripsForRouteInActiveServiceIDs(ctx, gtfsdb.GetTripsForRouteInActiveServiceIDsParams{
+			RouteID:    routeID,
+			ServiceIds: serviceIDs,
+		})
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	if len(trips) > 0 {
+		groupings, stopIDSet, err := api.buildRouteStopTripGroupings(ctx, agencyID, trips)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+
+		stopIDs := make([]string, 0, len(stopIDSet))
+		for stopID := range stopIDSet {
+			stopIDs = append(stopIDs, stopID)
+		}
+		stops, err := queries.GetStopsByIDs(ctx, stopIDs)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		stopNames := make(map[string]string, len(stops))
+		for _, stop := range stops {
+			stopNames[utils.FormCombinedID(agencyID, stop.ID)] = stop.Name.String
+		}
+		tripHeadsigns := make(map[string]string, len(trips))
+		for _, trip := range trips {
+			tripHeadsigns[utils.FormCombinedID(agencyID, trip.ID)] = trip.TripHeadsign.String
+		}
+
+		for _, grouping := range groupings {
+			timetable.Directions = append(timetable.Directions, export.NewDirectionTimetable(grouping, stopNames, tripHeadsigns))
+		}
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	if format == export.FormatCSV {
+		name := route.ShortName.String
+		if name == "" {
+			name = route.ID
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename(name, serviceDate, format)))
+	}
+	if err := export.Write(w, timetable, format); err != nil {
+		api.Logger.Warn("failed to write route timetable", "routeID", routeID, "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+package restapi
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getRouteTimetableExport(t *testing.T, api *RestAPI, endpoint string) (*http.Response, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + endpoint)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestRouteTimetableExportCSV(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, body := getRouteTimetableExport(t, api, "/api/export/route-timetable/25_151?key=TEST&date=2025-06-12")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="route-1-2025-06-12.csv"`, resp.Header.Get("Content-Disposition"))
+
+	reader := csv.NewReader(strings.NewReader(body))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+	require.Greater(t, len(records), 1)
+	assert.Equal(t, []string{"direction_id", "trip_id", "trip_headsign"}, records[0][:3])
+	assert.Greater(t, len(records[0]), 3, "the header names the stops")
+
+	for _, record := range records[1:] {
+		if record[0] == "direction_id" {
+			continue
+		}
+		assert.Len(t, record, len(records[0]), "trip rows have a cell per stop")
+		assert.True(t, strings.HasPrefix(record[1], "25_"), "trip IDs are agency-prefixed")
+		break
+	}
+}
+
+func TestRouteTimetableExportHTML(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, body := getRouteTimetableExport(t, api, "/api/export/route-timetable/25_151?key=TEST&date=2025-06-12&format=html")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Empty(t, resp.Header.Get("Content-Disposition"))
+	assert.Contains(t, body, "<table>")
+	assert.Contains(t, body, "Thursday, June 12, 2025")
+}
+
+func TestRouteTimetableExportErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"unknown format", "/api/export/route-timetable/25_151?key=TEST&format=pdf", http.StatusBadRequest},
+		{"invalid date", "/api/export/route-timetable/25_151?key=TEST&date=tomorrow", http.StatusBadRequest},
+		{"unknown route", "/api/export/route-timetable/25_nope?key=TEST&date=2025-06-12", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := createTestApi(t)
+			defer api.Shutdown()
+			resp, _ := getRouteTimetableExport(t, api, tt.url)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package restapi
+
+import (
+	"context"
+
+	"maglev.onebusaway.org/gtfsdb"
+)
+
+// routesForStopsOfTypes returns the routes serving stopIDs, limited to the
+// given GTFS route types when there are any.
+func (api *RestAPI) routesForStopsOfTypes(ctx context.Context, stopIDs []string, routeTypes []int) ([]gtfsdb.GetRoutesForStopsRow, error) {
+	if len(routeTypes) == 0 {
+		return api.GtfsManager.GtfsDB.Queries.GetRoutesForStops(ctx, stopIDs)
+	}
+
+	types := make([]int64, len(routeTypes))
+	for i, rt := range routeTypes {
+		types[i] = int64(rt)
+	}
+
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStopsWithRouteTypes(ctx, gtfsdb.GetRoutesForStopsWithRouteTypesParams{
+		StopIds:    stopIDs,
+		RouteTypes: types,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]gtfsdb.GetRoutesForStopsRow, len(rows))
+	for i, row := range rows {
+		routes[i] = gtfsdb.GetRoutesForStopsRow(row)
+	}
+	return routes, nil
+}
+
+// filterStopTimesByRouteTypes keeps the stop times at stopID whose route is of
+// one of the given GTFS route types. No route types keeps them all.
+func (api *RestAPI) filterStopTimesByRouteTypes(ctx context.Context, stopID string, stopTimes []activeStopTime, routeTypes []int) ([]activeStopTime, error) {
+	if len(routeTypes) == 0 || len(stopTimes) == 0 {
+		return stopTimes, nil
+	}
+
+	routes, err := api.routesForStopsOfTypes(ctx, []string{stopID}, routeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingRoutes := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		matchingRoutes[route.ID] = true
+	}
+
+	filtered := make([]activeStopTime, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		if matchingRoutes[st.RouteID] {
+			filtered = append(filtered, st)
+		}
+	}
+	return filtered, nil
+}
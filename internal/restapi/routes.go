@@ -11,7 +11,7 @@ type handlerFunc func(w http.ResponseWriter, r *http.Request)
 
 // rateLimitAndValidateAPIKey combines rate limiting, API key validation, and compression
 func rateLimitAndValidateAPIKey(api *RestAPI, finalHandler handlerFunc) http.Handler {
-	// Create the handler chain: API key validation -> rate limiting -> compression -> final handler
+	// Create the handler chain: per-IP rate limiting -> API key validation -> rate limiting -> compression -> final handler
 	finalHandlerHttp := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		finalHandler(w, r)
 	})
@@ -28,15 +28,31 @@ func rateLimitAndValidateAPIKey(api *RestAPI, finalHandler handlerFunc) http.Han
 		rateLimitedHandler = compressedHandler
 	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	validatedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if callback := r.URL.Query().Get("callback"); callback != "" && api.jsonpEnabled() && !validJSONPCallback(callback) {
+			api.validationErrorResponse(w, r, map[string][]string{
+				"callback": {"callback must be a JavaScript function name"},
+			})
+			return
+		}
 		// First validate API key
 		if api.RequestHasInvalidAPIKey(r) {
 			api.invalidAPIKeyResponse(w, r)
 			return
 		}
+		if api.APIKeys != nil {
+			api.APIKeys.RecordUse(r.URL.Query().Get("key"))
+		}
 		// Then apply rate limiting and compression
 		rateLimitedHandler.ServeHTTP(w, r)
 	})
+
+	// Per-IP limiting comes first, so that it also covers requests with
+	// invalid keys and requests made with exempt keys
+	if api.ipRateLimiter != nil {
+		return api.ipRateLimiter.Handler()(validatedHandler)
+	}
+	return validatedHandler
 }
 
 // etagStatic applies ETag middleware at the innermost handler level.
@@ -83,51 +99,112 @@ func registerPprofHandlers(mux *http.ServeMux) { // nolint:unused
 
 // SetRoutes registers all API endpoints with compression applied per route
 func (api *RestAPI) SetRoutes(mux *http.ServeMux) {
+	api.registerRoutes(newRouteRegistry(mux))
+}
+
+// registerRoutes registers every endpoint through routes, which records them
+// for the OpenAPI document. Each route needs an entry in routeDocs.
+func (api *RestAPI) registerRoutes(routes *routeRegistry) {
 	// Health check endpoint - no authentication required
-	mux.HandleFunc("GET /healthz", api.healthHandler)
+	routes.handleFunc("GET /healthz", api.healthHandler)
+
+	// OpenAPI description of the routes registered here - no authentication required
+	routes.handle("GET /openapi.json", api.openAPIHandler(routes))
 
 	// --- Routes without ID validation ---
-	mux.Handle("GET /api/where/agencies-with-coverage.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.agenciesWithCoverageHandler))))
-	mux.Handle("GET /api/where/search/stop.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.searchStopsHandler))))
-	mux.Handle("GET /api/where/search/route.json", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.routeSearchHandler))))
+	routes.handleJSONAndXML("GET /api/where/agencies-with-coverage", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.agenciesWithCoverageHandler))))
+	routes.handleJSONAndXML("GET /api/where/agency-coverage", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.agencyCoverageHandler))))
+	routes.handleJSONAndXML("GET /api/where/search/stop", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.searchStopsHandler))))
+	routes.handleJSONAndXML("GET /api/where/search/route", CacheControlMiddleware(models.CacheDurationLong, rateLimitAndValidateAPIKey(api, etagStatic(api, api.routeSearchHandler))))
 
 	// Non-static endpoints (no ETag)
-	mux.Handle("GET /api/where/current-time.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.currentTimeHandler)))
-	mux.Handle("GET /api/where/stops-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stopsForLocationHandler)))
-	mux.Handle("GET /api/where/routes-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.routesForLocationHandler)))
-	mux.Handle("GET /api/where/trips-for-location.json", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripsForLocationHandler)))
-	mux.Handle("GET /api/where/config.json", rateLimitAndValidateAPIKey(api, api.configHandler))
+	routes.handleJSONAndXML("GET /api/where/current-time", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.currentTimeHandler)))
+	routes.handleJSONAndXML("GET /api/where/stops-for-location", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.stopsForLocationHandler)))
+	routes.handleJSONAndXML("GET /api/where/routes-for-location", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.routesForLocationHandler)))
+	routes.handleJSONAndXML("GET /api/where/trips-for-location", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.tripsForLocationHandler)))
+	routes.handleJSONAndXML("GET /api/where/config", rateLimitAndValidateAPIKey(api, api.configHandler))
+	routes.handleJSONAndXML("GET /api/where/feed-info", rateLimitAndValidateAPIKey(api, etagStatic(api, api.feedInfoHandler)))
+
+	// SIRI VehicleMonitoring and StopMonitoring (XML by default, JSON with type=json)
+	routes.handle("GET /siri/vehicle-monitoring", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriVehicleMonitoringHandler)))
+	routes.handle("GET /siri/stop-monitoring", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.siriStopMonitoringHandler)))
+
+	// GTFS-realtime feeds of the merged realtime state
+	routes.handle("GET /gtfs-rt/vehicle-positions", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.gtfsRtVehiclePositionsHandler)))
+	routes.handle("GET /gtfs-rt/trip-updates", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.gtfsRtTripUpdatesHandler)))
+	routes.handle("GET /gtfs-rt/alerts", CacheControlMiddleware(models.CacheDurationShort, rateLimitAndValidateAPIKey(api, api.gtfsRtAlertsHandler)))
+
+	// Server-Sent Events stream of realtime changes; sets its own Cache-Control
+	routes.handle("GET /api/stream/vehicles", rateLimitAndValidateAPIKey(api, api.vehicleStreamHandler))
+
+	// Webhook subscriptions to arrivals at a stop; each key sees only its own
+	routes.handle("GET /api/subscriptions/arrivals", rateLimitAndValidateAPIKey(api, withArrivalWebhooks(api, api.listArrivalSubscriptionsHandler)))
+	routes.handle("POST /api/subscriptions/arrivals", rateLimitAndValidateAPIKey(api, withArrivalWebhooks(api, api.createArrivalSubscriptionHandler)))
+	routes.handle("GET /api/subscriptions/arrivals/{id}", rateLimitAndValidateAPIKey(api, withArrivalWebhooks(api, api.getArrivalSubscriptionHandler)))
+	routes.handle("DELETE /api/subscriptions/arrivals/{id}", rateLimitAndValidateAPIKey(api, withArrivalWebhooks(api, api.deleteArrivalSubscriptionHandler)))
+
+	// API key administration; requires one of the configured admin keys
+	routes.handle("GET /api/admin/api-keys", withAdminKey(api, withAPIKeyStore(api, api.listAPIKeysHandler)))
+	routes.handle("POST /api/admin/api-keys", withAdminKey(api, withAPIKeyStore(api, api.createAPIKeyHandler)))
+	routes.handle("GET /api/admin/api-keys/{key}", withAdminKey(api, withAPIKeyStore(api, api.getAPIKeyHandler)))
+	routes.handle("PATCH /api/admin/api-keys/{key}", withAdminKey(api, withAPIKeyStore(api, api.updateAPIKeyHandler)))
+	routes.handle("DELETE /api/admin/api-keys/{key}", withAdminKey(api, withAPIKeyStore(api, api.deleteAPIKeyHandler)))
+
+	// Problems found while parsing the static GTFS feed; requires an admin key
+	routes.handle("GET /api/admin/import-warnings", withAdminKey(api, api.importWarningsHandler))
+	routes.handle("GET /api/admin/import-warnings/summary", withAdminKey(api, api.importWarningsSummaryHandler))
+
+	// Dispatcher overrides of which vehicle runs a trip or block; requires an admin key
+	routes.handle("GET /api/admin/vehicle-assignments", withAdminKey(api, api.listVehicleAssignmentsHandler))
+	routes.handle("POST /api/admin/vehicle-assignments", withAdminKey(api, api.createVehicleAssignmentHandler))
+	routes.handle("DELETE /api/admin/vehicle-assignments/{vehicleId}", withAdminKey(api, api.deleteVehicleAssignmentHandler))
 
 	// --- Routes with simple ID validation (agency IDs) ---
-	mux.Handle("GET /api/where/agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.agencyHandler))))
-	mux.Handle("GET /api/where/routes-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.routesForAgencyHandler))))
-	mux.Handle("GET /api/where/stop-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.stopIDsForAgencyHandler))))
-	mux.Handle("GET /api/where/stops-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.stopsForAgencyHandler))))
-	mux.Handle("GET /api/where/route-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.routeIDsForAgencyHandler))))
+	routes.handle("GET /api/where/agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, cachedStatic(api, nil, api.agencyHandler)))))
+	routes.handle("GET /api/where/routes-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.routesForAgencyHandler))))
+	routes.handle("GET /api/where/stop-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.stopIDsForAgencyHandler))))
+	routes.handle("GET /api/where/stops-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.stopsForAgencyHandler))))
+	// Stop codes are validated like IDs; the path value is the code
+	routes.handle("GET /api/where/stop-by-code/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.stopByCodeHandler))))
+	routes.handle("GET /api/where/route-ids-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.routeIDsForAgencyHandler))))
+	routes.handle("GET /api/where/blocks-for-agency/{id}", CacheControlMiddleware(models.CacheDurationLong, withID(api, etagStatic(api, api.blocksForAgencyHandler))))
 
 	// Real-time simple ID endpoints (no ETag)
-	mux.Handle("GET /api/where/vehicles-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.vehiclesForAgencyHandler)))
+	routes.handle("GET /api/where/vehicles-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.vehiclesForAgencyHandler)))
+	routes.handle("GET /api/where/trips-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.tripsForAgencyHandler)))
+	routes.handle("GET /api/where/situations-for-agency/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.situationsForAgencyHandler)))
+	routes.handle("GET /api/where/on-time-performance/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.onTimePerformanceHandler)))
+	routes.handle("GET /api/where/data-quality/{id}", CacheControlMiddleware(models.CacheDurationShort, withID(api, api.dataQualityHandler)))
 
 	// --- Routes with combined ID validation (agency_id_code format) ---
-	mux.Handle("GET /api/where/trip/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.tripHandler))))
-	mux.Handle("GET /api/where/route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.routeHandler))))
-	mux.Handle("GET /api/where/stop/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.stopHandler))))
-	mux.Handle("GET /api/where/shape/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.shapesHandler))))
-	mux.Handle("GET /api/where/stops-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.stopsForRouteHandler))))
-	mux.Handle("GET /api/where/schedule-for-stop/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.scheduleForStopHandler))))
-	mux.Handle("GET /api/where/schedule-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.scheduleForRouteHandler))))
-	mux.Handle("GET /api/where/block/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.blockHandler))))
+	routes.handle("GET /api/where/trip/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.tripHandler))))
+	routes.handle("GET /api/where/route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, cachedStatic(api, nil, api.routeHandler)))))
+	routes.handle("GET /api/where/stop/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, cachedStatic(api, nil, api.stopHandler)))))
+	routes.handle("GET /api/where/shape/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.shapesHandler))))
+	routes.handle("GET /api/where/trip-geometry/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.tripGeometryHandler))))
+	routes.handle("GET /api/where/stops-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.stopsForRouteHandler))))
+	routes.handle("GET /api/where/schedule-for-stop/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, cachedStatic(api, hasQueryParam("date"), api.scheduleForStopHandler)))))
+	routes.handle("GET /api/where/schedule-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.scheduleForRouteHandler))))
+	routes.handle("GET /api/export/route-timetable/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.routeTimetableExportHandler))))
+	routes.handle("GET /api/where/block/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.blockHandler))))
+	routes.handle("GET /api/where/fares-for-route/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.faresForRouteHandler))))
+	routes.handle("GET /api/where/fare-for-trip/{id}", CacheControlMiddleware(models.CacheDurationLong, withCombinedID(api, etagStatic(api, api.fareForTripHandler))))
 
 	// Real-time or transactional combined ID endpoints (no ETag)
-	mux.Handle("GET /api/where/report-problem-with-trip/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.reportProblemWithTripHandler)))
-	mux.Handle("GET /api/where/report-problem-with-stop/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.reportProblemWithStopHandler)))
-	mux.Handle("GET /api/where/problem-reports-for-trip/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.problemReportsForTripHandler)))
-	mux.Handle("GET /api/where/problem-reports-for-stop/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.problemReportsForStopHandler)))
-	mux.Handle("GET /api/where/trip-details/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripDetailsHandler)))
-	mux.Handle("GET /api/where/trip-for-vehicle/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripForVehicleHandler)))
-	mux.Handle("GET /api/where/arrival-and-departure-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.arrivalAndDepartureForStopHandler)))
-	mux.Handle("GET /api/where/trips-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripsForRouteHandler)))
-	mux.Handle("GET /api/where/arrivals-and-departures-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.arrivalsAndDeparturesForStopHandler)))
+	routes.handle("GET /api/where/report-problem-with-trip/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.reportProblemWithTripHandler)))
+	routes.handle("GET /api/where/report-problem-with-stop/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.reportProblemWithStopHandler)))
+	routes.handle("GET /api/where/problem-reports-for-trip/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.problemReportsForTripHandler)))
+	routes.handle("GET /api/where/problem-reports-for-stop/{id}", CacheControlMiddleware(models.CacheDurationNone, withCombinedID(api, api.problemReportsForStopHandler)))
+	routes.handle("GET /api/where/trip-details/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripDetailsHandler)))
+	routes.handle("GET /api/where/trip-for-vehicle/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripForVehicleHandler)))
+	routes.handle("GET /api/where/situations-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.situationsForStopHandler)))
+	routes.handle("GET /api/where/situation/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.situationHandler)))
+	routes.handle("GET /api/where/vehicle-trajectory/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.vehicleTrajectoryHandler)))
+	routes.handle("GET /api/where/arrival-and-departure-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.arrivalAndDepartureForStopHandler)))
+	routes.handle("GET /api/where/trips-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.tripsForRouteHandler)))
+	routes.handle("GET /api/where/detours-for-route/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.detoursForRouteHandler)))
+	routes.handle("GET /api/where/arrivals-and-departures-for-stop/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.arrivalsAndDeparturesForStopHandler)))
+	routes.handle("GET /api/where/plan-departure/{id}", CacheControlMiddleware(models.CacheDurationShort, withCombinedID(api, api.planDepartureHandler)))
 }
 
 // SetupAPIRoutes creates and configures the API router with all middleware applied globally
@@ -138,7 +215,7 @@ func (api *RestAPI) SetupAPIRoutes() http.Handler {
 	// Register all API routes
 	api.SetRoutes(mux)
 
-	// Apply global middleware chain: compression -> base routes
+	// Apply global middleware chain: compression -> request timeout -> base routes
 	// This ensures all responses are compressed
-	return CompressionMiddleware(mux)
+	return CompressionMiddleware(api.RequestTimeoutMiddleware(mux))
 }
@@ -23,8 +23,13 @@ func (api *RestAPI) routesForAgencyHandler(w http.ResponseWriter, r *http.Reques
 	routesForAgency := api.GtfsManager.RoutesForAgencyID(id)
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	offset, limit, pageErrors := parseListPagination(r)
+	if len(pageErrors) > 0 {
+		api.validationErrorResponse(w, r, pageErrors)
+		return
+	}
 	routesForAgency, limitExceeded := utils.PaginateSlice(routesForAgency, offset, limit)
+	page := newPage(r, offset, len(routesForAgency), limitExceeded)
 	// Safe allocation logic
 	routesList := make([]models.Route, 0, len(routesForAgency))
 
@@ -32,7 +37,7 @@ func (api *RestAPI) routesForAgencyHandler(w http.ResponseWriter, r *http.Reques
 		routesList = append(routesList, models.NewRoute(
 			utils.FormCombinedID(route.Agency.Id, route.Id), route.Agency.Id, route.ShortName, route.LongName,
 			route.Description, models.RouteType(route.Type),
-			route.Url, route.Color, route.TextColor))
+			route.Url, route.Color, route.TextColor).WithSortOrder(route.SortOrder))
 	}
 
 	references := models.ReferencesModel{
@@ -50,6 +55,6 @@ func (api *RestAPI) routesForAgencyHandler(w http.ResponseWriter, r *http.Reques
 		Trips:      []interface{}{},
 	}
 
-	response := models.NewListResponse(routesList, references, limitExceeded, api.Clock)
+	response := models.WithPage(models.NewListResponse(routesList, references, limitExceeded, api.Clock), page)
 	api.sendResponse(w, r, response)
 }
@@ -153,3 +153,34 @@ func TestRoutesForAgencyHandlerPagination(t *testing.T) {
 	assert.Len(t, list3, 13)
 	assert.False(t, data3["limitExceeded"].(bool), "limitExceeded should be false when all items returned")
 }
+
+func TestRoutesForAgencyHandlerListsRoutesInSortOrder(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/routes-for-agency/25.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	list := model.Data.(map[string]interface{})["list"].([]interface{})
+	require.NotEmpty(t, list)
+
+	// RABA's route_sort_order starts from 0 for route 1; three routes have none
+	first := list[0].(map[string]interface{})
+	assert.Equal(t, "25_151", first["id"])
+	assert.Equal(t, 0.0, first["sortOrder"])
+
+	previous := -1.0
+	var unordered []string
+	for _, item := range list {
+		route := item.(map[string]interface{})
+		sortOrder, ok := route["sortOrder"].(float64)
+		if !ok {
+			unordered = append(unordered, route["id"].(string))
+			continue
+		}
+		assert.Empty(t, unordered, "routes without a sort order come last")
+		assert.GreaterOrEqual(t, sortOrder, previous)
+		previous = sortOrder
+	}
+	assert.Equal(t, []string{"25_15", "25_24", "25_44X"}, unordered)
+}
@@ -1,7 +1,9 @@
 package restapi
 
 import (
+	"cmp"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,17 +12,20 @@ import (
 )
 
 func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
-
-	lat, fieldErrors := utils.ParseFloatParam(queryParams, "lat", nil)
-	lon, _ := utils.ParseFloatParam(queryParams, "lon", fieldErrors)
-	radius, _ := utils.ParseFloatParam(queryParams, "radius", fieldErrors)
-	latSpan, _ := utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
-	lonSpan, _ := utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
-	maxCount, _ := utils.ParseMaxCount(queryParams, models.DefaultMaxCountForRoutes, fieldErrors)
-	query := queryParams.Get("query")
-
-	if len(fieldErrors) > 0 {
+	q := newQueryParams(r)
+	lat := q.floatParam("lat", 0)
+	lon := q.floatParam("lon", 0)
+	radius := q.floatParam("radius", 0)
+	latSpan := q.floatParam("latSpan", 0)
+	lonSpan := q.floatParam("lonSpan", 0)
+	query := q.values.Get("query")
+
+	var maxCount int
+	var routeTypes []int
+	maxCount, q.fieldErrors = utils.ParseMaxCount(q.values, models.DefaultMaxCountForRoutes, q.fieldErrors)
+	routeTypes, q.fieldErrors = utils.ParseRouteTypes(q.values, "routeTypes", q.fieldErrors)
+
+	if fieldErrors := q.errors(); fieldErrors != nil {
 		api.validationErrorResponse(w, r, fieldErrors)
 		return
 	}
@@ -60,7 +65,7 @@ func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Requ
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	stops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, radius, latSpan, lonSpan, query, maxCount, true, nil, time.Time{})
+	stops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, radius, latSpan, lonSpan, query, maxCount, true, routeTypes, time.Time{})
 
 	var results = []models.Route{}
 	routeIDs := map[string]bool{}
@@ -89,7 +94,7 @@ func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Batch query to get all routes for all stops
-	routesForStops, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStops(ctx, stopIDs)
+	routesForStops, err := api.routesForStopsOfTypes(ctx, stopIDs, routeTypes)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -120,7 +125,7 @@ func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Requ
 				models.RouteType(routeRow.Type),
 				routeRow.Url.String,
 				routeRow.Color.String,
-				routeRow.TextColor.String))
+				routeRow.TextColor.String).WithSortOrder(utils.NullInt32Ptr(routeRow.SortOrder)))
 		}
 		routeIDs[combinedRouteID] = true
 		if len(results) >= maxCount {
@@ -133,6 +138,10 @@ func (api *RestAPI) routesForLocationHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	slices.SortStableFunc(results, func(a, b models.Route) int {
+		return cmp.Or(models.CompareSortOrder(a.SortOrder, b.SortOrder), strings.Compare(a.ID, b.ID))
+	})
+
 	agencies := utils.FilterAgencies(api.GtfsManager.GetAgencies(), agencyIDs)
 
 	references := models.ReferencesModel{
@@ -239,3 +239,22 @@ func TestRoutesForLocationHandlerInRangeWithNoResults(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, 0, len(list))
 }
+
+func TestRoutesForLocationRouteTypes(t *testing.T) {
+	routeCount := func(filter string) int {
+		t.Helper()
+		_, resp, model := serveAndRetrieveEndpoint(t, "/api/where/routes-for-location.json?key=TEST&lat=40.583321&lon=-122.426966"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		list, ok := model.Data.(map[string]interface{})["list"].([]interface{})
+		require.True(t, ok)
+		return len(list)
+	}
+
+	all := routeCount("")
+	require.NotZero(t, all)
+	assert.Equal(t, all, routeCount("&routeTypes=bus"))
+	assert.Zero(t, routeCount("&routeTypes=rail,ferry"))
+
+	_, resp, _ := serveAndRetrieveEndpoint(t, "/api/where/routes-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&routeTypes=boat")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -1,11 +1,15 @@
 package restapi
 
 import (
+	"context"
+	"math"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
-	"maglev.onebusaway.org/internal/gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -28,38 +32,35 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, routeID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
 		return
 	}
 
 	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
 		return
 	}
 	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
 
-	var targetDate string
-	var scheduleDate int64
-	if dateParam != "" {
-		parsedDate, err := time.ParseInLocation("2006-01-02", dateParam, loc)
-		if err != nil {
-			fieldErrors := map[string][]string{
-				"date": {"Invalid date format. Use YYYY-MM-DD"},
-			}
-			api.validationErrorResponse(w, r, fieldErrors)
-			return
-		}
-		targetDate = parsedDate.Format("20060102")
-		scheduleDate = parsedDate.UnixMilli()
-	} else {
-		now := api.Clock.Now().In(loc)
-		y, m, d := now.Date()
-		startOfDay := time.Date(y, m, d, 0, 0, 0, 0, loc)
-		targetDate = startOfDay.Format("20060102")
-		scheduleDate = startOfDay.UnixMilli()
+	serviceDate, fieldErrors := api.parseScheduleDate(dateParam, loc)
+	if fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+	targetDate := serviceDate.Format("20060102")
+	scheduleDate := serviceDate.UnixMilli()
+
+	validityMsg, err := api.feedValidityMessage(ctx, targetDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if validityMsg != "" {
+		api.validationErrorResponseWithCode(w, r, errCodeDateOutOfRange, map[string][]string{"date": {validityMsg}})
+		return
 	}
 
 	serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, targetDate)
@@ -120,87 +121,27 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 		models.RouteType(route.Type),
 		route.Url.String,
 		route.Color.String,
-		route.TextColor.String)
+		route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 	routeRefs[utils.FormCombinedID(agencyID, route.ID)] = routeModel
 
-	groupings := make(map[string][]gtfsdb.Trip)
 	for _, trip := range trips {
 		tripIDsSet[trip.ID] = true
-		// The go-gtfs library encodes direction_id as a 3-value enum:
-		//   0 = Unspecified, 1 = True (GTFS direction_id=1), 2 = False (GTFS direction_id=0)
-		dirID := "0"
-		if trip.DirectionID.Int64 == 1 {
-			dirID = "1"
-		}
-		groupings[dirID] = append(groupings[dirID], trip)
 	}
-	var stopTripGroupings []models.StopTripGrouping
-	globalStopIDSet := make(map[string]struct{})
-	var stopTimesRefs []interface{}
-	for dirID, groupedTrips := range groupings {
+
+	stopTripGroupings, globalStopIDSet, err := api.buildRouteStopTripGroupings(ctx, agencyID, trips)
+	if err != nil {
 		if ctx.Err() != nil {
 			return
 		}
-
-		stopIDSet := make(map[string]struct{})
-		headsignSet := make(map[string]struct{})
-		tripIDs := make([]string, 0, len(groupedTrips))
-		tripsWithStopTimes := make([]models.TripStopTimes, 0, len(groupedTrips))
-		for _, trip := range groupedTrips {
-			combinedTripID := utils.FormCombinedID(agencyID, trip.ID)
-			tripIDs = append(tripIDs, combinedTripID)
-			if trip.TripHeadsign.String != "" {
-				headsignSet[trip.TripHeadsign.String] = struct{}{}
-			}
-			stopIDs, err := api.GtfsManager.GtfsDB.Queries.GetOrderedStopIDsForTrip(ctx, trip.ID)
-			if err != nil {
-				continue
-			}
-			for _, stopID := range stopIDs {
-				stopIDSet[stopID] = struct{}{}
-				globalStopIDSet[stopID] = struct{}{}
-			}
-			stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, trip.ID)
-			if err != nil {
-				continue
-			}
-			stopTimesList := make([]models.RouteStopTime, 0, len(stopTimes))
-			for _, st := range stopTimes {
-				arrivalSec := int(utils.NanosToSeconds(st.ArrivalTime))
-				departureSec := int(utils.NanosToSeconds(st.DepartureTime))
-				stopTimesList = append(stopTimesList, models.RouteStopTime{
-					ArrivalEnabled:   true,
-					ArrivalTime:      arrivalSec,
-					DepartureEnabled: true,
-					DepartureTime:    departureSec,
-					ServiceID:        utils.FormCombinedID(agencyID, trip.ServiceID),
-					StopHeadsign:     st.StopHeadsign.String,
-					StopID:           utils.FormCombinedID(agencyID, st.StopID),
-					TripID:           utils.FormCombinedID(agencyID, trip.ID),
-				})
-			}
-			tripsWithStopTimes = append(tripsWithStopTimes, models.TripStopTimes{
-				TripID:    utils.FormCombinedID(agencyID, trip.ID),
-				StopTimes: stopTimesList,
-			})
-			stopTimesRefs = append(stopTimesRefs, stopTimesList)
-		}
-		stopIDsOrdered := make([]string, 0, len(stopIDSet))
-		for stopID := range stopIDSet {
-			stopIDsOrdered = append(stopIDsOrdered, utils.FormCombinedID(agencyID, stopID))
-		}
-		headsigns := make([]string, 0, len(headsignSet))
-		for h := range headsignSet {
-			headsigns = append(headsigns, h)
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	var stopTimesRefs []interface{}
+	for _, grouping := range stopTripGroupings {
+		for _, tripStopTimes := range grouping.TripsWithStopTimes {
+			stopTimesRefs = append(stopTimesRefs, tripStopTimes.StopTimes)
 		}
-		stopTripGroupings = append(stopTripGroupings, models.StopTripGrouping{
-			DirectionID:        dirID,
-			TripHeadsigns:      headsigns,
-			StopIDs:            stopIDsOrdered,
-			TripIDs:            tripIDs,
-			TripsWithStopTimes: tripsWithStopTimes,
-		})
 	}
 
 	references := models.NewEmptyReferences()
@@ -241,13 +182,15 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 					utils.FormCombinedID(agencyID, t.BlockID.String),
 					utils.FormCombinedID(agencyID, t.ShapeID.String),
 				)
+				tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(t.WheelchairAccessible)
+				tripRef.BikesAllowed = utils.MapBikesAllowed(t.BikesAllowed)
 				references.Trips = append(references.Trips, tripRef)
 			}
 		}
 	}
 
 	// Create a local calculator to ensure thread safety
-	calc := gtfs.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
+	calc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
 
 	uniqueStopIDs := make([]string, 0, len(globalStopIDSet))
 	for sid := range globalStopIDSet {
@@ -286,3 +229,159 @@ func (api *RestAPI) scheduleForRouteHandler(w http.ResponseWriter, r *http.Reque
 	}
 	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
 }
+
+// parseScheduleDate returns the midnight, in loc, of the YYYY-MM-DD date
+// parameter, or of today there when the parameter is empty.
+func (api *RestAPI) parseScheduleDate(dateParam string, loc *time.Location) (time.Time, map[string][]string) {
+	if dateParam == "" {
+		y, m, d := api.Clock.Now().In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateParam, loc)
+	if err != nil {
+		return time.Time{}, map[string][]string{
+			"date": {"Invalid date format. Use YYYY-MM-DD"},
+		}
+	}
+	return date, nil
+}
+
+// buildRouteStopTripGroupings groups a route's trips by direction and attaches
+// their stop times, with each stop's distance along its trip's shape. Directions
+// are ordered by ID and trips by their first departure. A grouping's stopIds
+// follow the order in which its trips visit them. It also returns the set of
+// raw stop IDs visited by any trip.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) buildRouteStopTripGroupings(ctx context.Context, agencyID string, trips []gtfsdb.Trip) ([]models.StopTripGrouping, map[string]struct{}, error) {
+	tripIDs := make([]string, 0, len(trips))
+	for _, trip := range trips {
+		tripIDs = append(tripIDs, trip.ID)
+	}
+	stopTimeRows, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTripIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	stopTimesByTrip := make(map[string][]gtfsdb.StopTime, len(trips))
+	stopIDSet := make(map[string]struct{})
+	for _, st := range stopTimeRows {
+		stopTimesByTrip[st.TripID] = append(stopTimesByTrip[st.TripID], st)
+		stopIDSet[st.StopID] = struct{}{}
+	}
+
+	stopIDs := make([]string, 0, len(stopIDSet))
+	for stopID := range stopIDSet {
+		stopIDs = append(stopIDs, stopID)
+	}
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	stopCoords := make(map[string]struct{ lat, lon float64 }, len(stops))
+	for _, stop := range stops {
+		stopCoords[stop.ID] = struct{ lat, lon float64 }{lat: stop.Lat, lon: stop.Lon}
+	}
+
+	// The go-gtfs library encodes direction_id as a 3-value enum:
+	//   0 = Unspecified, 1 = True (GTFS direction_id=1), 2 = False (GTFS direction_id=0)
+	byDirection := make(map[string][]gtfsdb.Trip)
+	for _, trip := range trips {
+		dirID := "0"
+		if trip.DirectionID.Int64 == 1 {
+			dirID = "1"
+		}
+		byDirection[dirID] = append(byDirection[dirID], trip)
+	}
+	directionIDs := make([]string, 0, len(byDirection))
+	for dirID := range byDirection {
+		directionIDs = append(directionIDs, dirID)
+	}
+	sort.Strings(directionIDs)
+
+	firstDeparture := func(tripID string) int64 {
+		if stopTimes := stopTimesByTrip[tripID]; len(stopTimes) > 0 {
+			return stopTimes[0].DepartureTime
+		}
+		return math.MaxInt64
+	}
+
+	groupings := make([]models.StopTripGrouping, 0, len(directionIDs))
+	for _, dirID := range directionIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		groupedTrips := byDirection[dirID]
+		sort.SliceStable(groupedTrips, func(i, j int) bool {
+			di, dj := firstDeparture(groupedTrips[i].ID), firstDeparture(groupedTrips[j].ID)
+			if di != dj {
+				return di < dj
+			}
+			return groupedTrips[i].ID < groupedTrips[j].ID
+		})
+
+		seenStops := make(map[string]struct{})
+		stopIDsOrdered := []string{}
+		headsignSet := make(map[string]struct{})
+		combinedTripIDs := make([]string, 0, len(groupedTrips))
+		tripsWithStopTimes := make([]models.TripStopTimes, 0, len(groupedTrips))
+		for _, trip := range groupedTrips {
+			combinedTripID := utils.FormCombinedID(agencyID, trip.ID)
+			combinedTripIDs = append(combinedTripIDs, combinedTripID)
+			if trip.TripHeadsign.String != "" {
+				headsignSet[trip.TripHeadsign.String] = struct{}{}
+			}
+
+			var shapePoints []gtfs.ShapePoint
+			if trip.ShapeID.Valid {
+				geometry, err := api.GtfsManager.GetShapeGeometry(ctx, trip.ShapeID.String)
+				if err != nil {
+					return nil, nil, err
+				}
+				if geometry != nil {
+					shapePoints = geometry.Points
+				}
+			}
+
+			stopTimes := stopTimesByTrip[trip.ID]
+			withDistances := api.calculateBatchStopDistances(stopTimes, shapePoints, stopCoords, agencyID)
+			stopTimesList := make([]models.RouteStopTime, 0, len(stopTimes))
+			for i, st := range stopTimes {
+				if _, seen := seenStops[st.StopID]; !seen {
+					seenStops[st.StopID] = struct{}{}
+					stopIDsOrdered = append(stopIDsOrdered, utils.FormCombinedID(agencyID, st.StopID))
+				}
+				stopTimesList = append(stopTimesList, models.RouteStopTime{
+					ArrivalEnabled:    arrivalEnabled(st.DropOffType),
+					ArrivalTime:       int(st.ArrivalTime),
+					DepartureEnabled:  departureEnabled(st.PickupType),
+					DepartureTime:     int(st.DepartureTime),
+					DistanceAlongTrip: withDistances[i].DistanceAlongTrip,
+					ServiceID:         utils.FormCombinedID(agencyID, trip.ServiceID),
+					StopHeadsign:      st.StopHeadsign.String,
+					StopID:            utils.FormCombinedID(agencyID, st.StopID),
+					StopSequence:      int(st.StopSequence),
+					TripID:            combinedTripID,
+				})
+			}
+			tripsWithStopTimes = append(tripsWithStopTimes, models.TripStopTimes{
+				TripID:    combinedTripID,
+				StopTimes: stopTimesList,
+			})
+		}
+
+		headsigns := make([]string, 0, len(headsignSet))
+		for h := range headsignSet {
+			headsigns = append(headsigns, h)
+		}
+		sort.Strings(headsigns)
+
+		groupings = append(groupings, models.StopTripGrouping{
+			DirectionID:        dirID,
+			TripHeadsigns:      headsigns,
+			StopIDs:            stopIDsOrdered,
+			TripIDs:            combinedTripIDs,
+			TripsWithStopTimes: tripsWithStopTimes,
+		})
+	}
+	return groupings, stopIDSet, nil
+}
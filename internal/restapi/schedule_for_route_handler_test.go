@@ -176,3 +176,71 @@ func TestScheduleForRouteHandlerWithMalformedID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Status code should be 400 Bad Request")
 }
+
+func TestScheduleForRouteHandlerRejectsDateOutsideFeedValidity(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// The RABA feed_info.txt covers 2025-01-01 through 2025-12-31.
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-route/25_151.json?key=TEST&date=2026-01-05")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	fieldErrors, ok := data["fieldErrors"].(map[string]interface{})
+	require.True(t, ok)
+	dateErrors, ok := fieldErrors["date"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, dateErrors, 1)
+	assert.Equal(t, "date 2026-01-05 is outside the feed validity window (2025-01-01 to 2025-12-31)", dateErrors[0])
+}
+
+func TestScheduleForRouteHandlerOrdersTripsAndReportsDistances(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-route/25_151.json?key=TEST&date=2025-06-12")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	groupings := entry["stopTripGroupings"].([]interface{})
+	require.NotEmpty(t, groupings)
+
+	var previousDirection string
+	for _, g := range groupings {
+		grouping := g.(map[string]interface{})
+		direction := grouping["directionId"].(string)
+		assert.Greater(t, direction, previousDirection, "directions are listed once, in order")
+		previousDirection = direction
+
+		stopIDs := grouping["stopIds"].([]interface{})
+		trips := grouping["tripsWithStopTimes"].([]interface{})
+		require.NotEmpty(t, trips)
+		require.Len(t, grouping["tripIds"], len(trips))
+
+		// The first trip's stops lead the grouping's stop list.
+		firstStopTimes := trips[0].(map[string]interface{})["stopTimes"].([]interface{})
+		require.NotEmpty(t, firstStopTimes)
+		assert.Equal(t, firstStopTimes[0].(map[string]interface{})["stopId"], stopIDs[0])
+
+		previousDeparture := -1.0
+		for _, tr := range trips {
+			stopTimes := tr.(map[string]interface{})["stopTimes"].([]interface{})
+			require.NotEmpty(t, stopTimes)
+			departure := stopTimes[0].(map[string]interface{})["departureTime"].(float64)
+			assert.GreaterOrEqual(t, departure, previousDeparture, "trips are ordered by first departure")
+			previousDeparture = departure
+
+			previousDistance, previousSequence := -1.0, -1.0
+			for _, s := range stopTimes {
+				st := s.(map[string]interface{})
+				distance := st["distanceAlongTrip"].(float64)
+				sequence := st["stopSequence"].(float64)
+				assert.GreaterOrEqual(t, distance, previousDistance)
+				assert.Greater(t, sequence, previousSequence)
+				previousDistance, previousSequence = distance, sequence
+			}
+			assert.Greater(t, previousDistance, 0.0, "the last stop lies beyond the start of the shape")
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package restapi
 
 import (
+	"context"
+	"math"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -35,14 +38,13 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 	agency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
 
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
 		return
 	}
 
 	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
 	var date int64
 	var targetDate string
-	var weekday string
 
 	if dateParam != "" {
 		parsedDate, err := time.ParseInLocation("2006-01-02", dateParam, loc)
@@ -55,20 +57,28 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		}
 		date = parsedDate.UnixMilli()
 		targetDate = parsedDate.Format("20060102")
-		weekday = strings.ToLower(parsedDate.Weekday().String())
 	} else {
 		now := api.Clock.Now().In(loc)
 		y, m, d := now.Date()
 		startOfDay := time.Date(y, m, d, 0, 0, 0, 0, loc)
 		date = startOfDay.UnixMilli()
 		targetDate = startOfDay.Format("20060102")
-		weekday = strings.ToLower(startOfDay.Weekday().String())
+	}
+
+	validityMsg, err := api.feedValidityMessage(ctx, targetDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if validityMsg != "" {
+		api.validationErrorResponseWithCode(w, r, errCodeDateOutOfRange, map[string][]string{"date": {validityMsg}})
+		return
 	}
 
 	// Verify stop exists
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
 		return
 	}
 
@@ -92,14 +102,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	params := gtfsdb.GetScheduleForStopOnDateParams{
-		StopID:     stopID,
-		TargetDate: targetDate,
-		Weekday:    weekday,
-		RouteIds:   routeIDs,
-	}
-	scheduleRows, err := api.GtfsManager.GtfsDB.Queries.GetScheduleForStopOnDate(ctx, params)
-
+	scheduleRows, err := api.scheduleRowsForStop(ctx, stopID, routeIDs, time.UnixMilli(date).In(loc))
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -159,7 +162,7 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 				models.RouteType(route.Type),
 				route.Url.String,
 				route.Color.String,
-				route.TextColor.String)
+				route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 		}
 	}
 
@@ -189,6 +192,14 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 	// Track headsign counts to pick the most common one
 	routeHeadsignCounts := make(map[string]map[string]int)
 
+	// Each route's stop times count from the midnight of their service date in
+	// the timezone of the agency operating it.
+	type dayStartKey struct {
+		agencyID    string
+		serviceDate string
+	}
+	dayStarts := make(map[dayStartKey]time.Time)
+
 	for _, row := range scheduleRows {
 		if ctx.Err() != nil {
 			return
@@ -199,11 +210,15 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 
 		tripIDsSet[row.TripID] = true
 
-		// Convert GTFS time (nanoseconds since midnight) to Unix timestamp in the agency's timezone in milliseconds
-		// GTFS times are stored as time.Duration values (nanoseconds), need to add to the target date
-		startOfDay := time.UnixMilli(date).In(loc)
-		arrivalDuration := time.Duration(row.ArrivalTime)
-		departureDuration := time.Duration(row.DepartureTime)
+		// Convert GTFS time (seconds since midnight) to Unix timestamp in the agency's timezone in milliseconds
+		key := dayStartKey{row.AgencyID, row.serviceDate.Format("20060102")}
+		startOfDay, ok := dayStarts[key]
+		if !ok {
+			startOfDay = utils.MidnightIn(row.serviceDate, api.agencyLocation(ctx, row.AgencyID))
+			dayStarts[key] = startOfDay
+		}
+		arrivalDuration := utils.StopTimeDuration(row.ArrivalTime)
+		departureDuration := utils.StopTimeDuration(row.DepartureTime)
 		arrivalTimeMs := startOfDay.Add(arrivalDuration).UnixMilli()
 		departureTimeMs := startOfDay.Add(departureDuration).UnixMilli()
 
@@ -242,6 +257,11 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 	// Build the route schedules
 	var routeSchedules []models.StopRouteSchedule
 	for routeID, stopTimes := range routeScheduleMap {
+		// Stop times of earlier service days interleave with the date's own.
+		sort.SliceStable(stopTimes, func(i, j int) bool {
+			return stopTimes[i].ArrivalTime < stopTimes[j].ArrivalTime
+		})
+
 		// Select the most common headsign for this route
 		tripHeadsign := ""
 		maxCount := 0
@@ -284,6 +304,8 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 			utils.FormCombinedID(agencyID, trip.BlockID.String),
 			utils.FormCombinedID(agencyID, trip.ShapeID.String),
 		)
+		tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+		tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
 		references.Trips = append(references.Trips, tripRef)
 	}
 
@@ -311,3 +333,46 @@ func (api *RestAPI) scheduleForStopHandler(w http.ResponseWriter, r *http.Reques
 	response := models.NewEntryResponse(entry, references, api.Clock)
 	api.sendResponse(w, r, response)
 }
+
+// scheduledStopRow is a row of a stop's schedule with the service date its
+// trip runs on.
+type scheduledStopRow struct {
+	gtfsdb.GetScheduleForStopOnDateRow
+	serviceDate time.Time
+}
+
+// scheduleRowsForStop returns the stop times at stopID, on the given routes,
+// that fall on the calendar date starting at day. Besides the trips of the
+// date's own service, these include those of earlier service days still
+// running past midnight (stop times of 24:00:00 and later), searching as many
+// days back as the feed's latest stop time reaches, like the arrivals window.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) scheduleRowsForStop(ctx context.Context, stopID string, routeIDs []string, day time.Time) ([]scheduledStopRow, error) {
+	nextDay := day.AddDate(0, 0, 1)
+	var rows []scheduledStopRow
+	for _, serviceDate := range utils.ServiceDatesBetween(day, nextDay.Add(-time.Nanosecond), api.GtfsManager.MaxServiceTime()) {
+		// The date's own service is listed whole, as it always was; earlier
+		// days only contribute the stop times that fall on the date.
+		windowStart, windowEnd := int64(0), int64(math.MaxInt64)
+		if !serviceDate.Equal(day) {
+			windowStart = utils.ServiceTimeAt(serviceDate, day).Seconds()
+			windowEnd = utils.ServiceTimeAt(serviceDate, nextDay).Seconds()
+		}
+
+		serviceRows, err := api.GtfsManager.GtfsDB.Queries.GetScheduleForStopOnDate(ctx, gtfsdb.GetScheduleForStopOnDateParams{
+			StopID:      stopID,
+			TargetDate:  serviceDate.Format("20060102"),
+			Weekday:     strings.ToLower(serviceDate.Weekday().String()),
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+			RouteIds:    routeIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range serviceRows {
+			rows = append(rows, scheduledStopRow{row, serviceDate})
+		}
+	}
+	return rows, nil
+}
@@ -1,11 +1,14 @@
 package restapi
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 	"maglev.onebusaway.org/internal/clock"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -129,6 +132,30 @@ func TestScheduleForStopHandlerAgencyTimeZone(t *testing.T) {
 	assert.Equal(t, float64(expected), entry["date"])
 }
 
+func TestScheduleForStopHandlerTripAgencyTimeZone(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	createNewYorkTestTrip(t, api)
+
+	_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/25_2000.json?key=TEST&date=2025-06-13")
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+
+	var arrivalTime interface{}
+	for _, rs := range entry["stopRouteSchedules"].([]interface{}) {
+		for _, ds := range rs.(map[string]interface{})["stopRouteDirectionSchedules"].([]interface{}) {
+			for _, st := range ds.(map[string]interface{})["scheduleStopTimes"].([]interface{}) {
+				if st := st.(map[string]interface{}); st["tripId"] == "25_NYC_TEST" {
+					arrivalTime = st["arrivalTime"]
+				}
+			}
+		}
+	}
+	// The trip's 01:00 counts from midnight in New York, not in Los Angeles
+	// where the stop's agency is.
+	newYork, _ := time.LoadLocation("America/New_York")
+	assert.Equal(t, float64(time.Date(2025, 6, 13, 1, 0, 0, 0, newYork).UnixMilli()), arrivalTime)
+}
+
 func TestScheduleForStopHandlerWithDateFiltering(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -351,7 +378,7 @@ func TestScheduleForStopQueryValidation(t *testing.T) {
 
 	t.Run("Query returns valid data structure", func(t *testing.T) {
 		stopID := utils.FormCombinedID(agencies[0].Id, stops[0].Id)
-		endpoint := "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST&date=2024-05-15"
+		endpoint := "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST&date=2025-05-14"
 		resp, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
 
 		require.Equal(http.StatusOK, resp.StatusCode)
@@ -438,8 +465,8 @@ func TestScheduleForStopQueryValidation(t *testing.T) {
 			date    string
 			weekday string
 		}{
-			{"2024-05-13", "Monday"},
-			{"2024-05-17", "Friday"},
+			{"2025-05-12", "Monday"},
+			{"2025-05-16", "Friday"},
 		}
 
 		for _, tt := range weekdayTests {
@@ -464,7 +491,7 @@ func TestScheduleForStopQueryValidation(t *testing.T) {
 
 	t.Run("Query properly formats timestamps", func(t *testing.T) {
 		stopID := utils.FormCombinedID(agencies[0].Id, stops[0].Id)
-		endpoint := "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST&date=2024-05-15"
+		endpoint := "/api/where/schedule-for-stop/" + stopID + ".json?key=TEST&date=2025-05-14"
 		resp, model := serveApiAndRetrieveEndpoint(t, api, endpoint)
 
 		require.Equal(http.StatusOK, resp.StatusCode)
@@ -516,3 +543,81 @@ func TestScheduleForStopHandlerWithMalformedID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Status code should be 400 Bad Request")
 }
+
+func TestScheduleForStopHandlerRejectsDateOutsideFeedValidity(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/25_1030.json?key=TEST&date=2024-12-31")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/25_1030.json?key=TEST&date=2025-01-01")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestScheduleForStopHandlerIncludesPreviousServiceDay(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+
+	routes, err := client.Queries.GetRoutesForStop(ctx, "2000")
+	require.NoError(t, err)
+	require.NotEmpty(t, routes)
+
+	// A daily trip reaching stop 2000 at 25:30, i.e. 01:30 on the next
+	// calendar day.
+	_, err = client.Queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:        "OVERNIGHT_TEST",
+		RouteID:   routes[0].ID,
+		ServiceID: "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+	})
+	require.NoError(t, err)
+	overnight := utils.StopTimeSeconds(25*time.Hour + 30*time.Minute)
+	_, err = client.Queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+		TripID:        "OVERNIGHT_TEST",
+		StopID:        "2000",
+		StopSequence:  1,
+		ArrivalTime:   overnight,
+		DepartureTime: overnight,
+	})
+	require.NoError(t, err)
+	previous := api.GtfsManager.MockSetMaxServiceTime(utils.NewServiceTime(overnight))
+	t.Cleanup(func() {
+		api.GtfsManager.MockSetMaxServiceTime(previous)
+		for _, stmt := range []string{
+			"DELETE FROM stop_times WHERE trip_id = 'OVERNIGHT_TEST'",
+			"DELETE FROM trips WHERE id = 'OVERNIGHT_TEST'",
+		} {
+			_, err := client.DB.ExecContext(context.Background(), stmt)
+			assert.NoError(t, err)
+		}
+	})
+
+	_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/schedule-for-stop/25_2000.json?key=TEST&date=2025-06-13")
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+
+	var arrivals []float64
+	for _, rs := range entry["stopRouteSchedules"].([]interface{}) {
+		for _, ds := range rs.(map[string]interface{})["stopRouteDirectionSchedules"].([]interface{}) {
+			stopTimes := ds.(map[string]interface{})["scheduleStopTimes"].([]interface{})
+			for i, st := range stopTimes {
+				st := st.(map[string]interface{})
+				if i > 0 {
+					assert.LessOrEqual(t, stopTimes[i-1].(map[string]interface{})["arrivalTime"], st["arrivalTime"])
+				}
+				if st["tripId"] == "25_OVERNIGHT_TEST" {
+					arrivals = append(arrivals, st["arrivalTime"].(float64))
+				}
+			}
+		}
+	}
+
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	assert.Equal(t, []float64{
+		// June 12's trip, still running after midnight.
+		float64(time.Date(2025, 6, 13, 1, 30, 0, 0, losAngeles).UnixMilli()),
+		// June 13's own trip, which reaches the stop early on June 14.
+		float64(time.Date(2025, 6, 14, 1, 30, 0, 0, losAngeles).UnixMilli()),
+	}, arrivals)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -35,6 +36,55 @@ func sanitizeFTS5Query(input string) string {
 	return sanitized
 }
 
+const (
+	// stopSearchBiasPoolFactor is how many candidates per requested result are
+	// fetched when results are re-ranked by distance.
+	stopSearchBiasPoolFactor = 4
+	// stopSearchBiasDistance is the distance in meters at which a match's text
+	// relevance is halved when ranking by proximity.
+	stopSearchBiasDistance = 1000.0
+)
+
+type stopSearchBias struct {
+	lat, lon float64
+}
+
+// parseStopSearchBias reads the optional lat/lon pair used to prefer nearby
+// stops, recording any problem with it in q. Both must be given together;
+// neither returns a nil bias.
+func parseStopSearchBias(q *queryParams) *stopSearchBias {
+	if !q.has("lat") && !q.has("lon") {
+		return nil
+	}
+
+	before := len(q.fieldErrors)
+	for _, key := range []string{"lat", "lon"} {
+		if !q.has(key) {
+			q.addError(key, "lat and lon must be provided together")
+		}
+	}
+	lat := q.floatParam("lat", 0, between(-90.0, 90.0))
+	lon := q.floatParam("lon", 0, between(-180.0, 180.0))
+	if len(q.fieldErrors) > before {
+		return nil
+	}
+	return &stopSearchBias{lat: lat, lon: lon}
+}
+
+// rankStopsByProximity reorders matches by text relevance scaled down with
+// distance from (lat, lon). bm25 ranks are negative with lower being better, so
+// shrinking a far stop's rank toward zero moves it down the list.
+func rankStopsByProximity(stops []gtfsdb.SearchStopsByNameRow, lat, lon float64) {
+	scores := make(map[string]float64, len(stops))
+	for _, s := range stops {
+		distance := utils.Distance(lat, lon, s.Lat, s.Lon)
+		scores[s.ID] = s.Rank / (1 + distance/stopSearchBiasDistance)
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		return scores[stops[i].ID] < scores[stops[j].ID]
+	})
+}
+
 func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -55,6 +105,13 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	q := newQueryParams(r)
+	bias := parseStopSearchBias(q)
+	if fieldErrors := q.errors(); fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
 	// 2. Sanitize and construct FTS5 query
 	sanitizedQuery := sanitizeFTS5Query(query)
 
@@ -85,9 +142,16 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 
 	searchQuery := `"` + sanitizedQuery + `*"`
 
+	// With a location bias, over-fetch so that nearby stops ranked just below the
+	// limit by text relevance alone can still make the cut after re-ranking.
+	fetchLimit := limit
+	if bias != nil {
+		fetchLimit = limit * stopSearchBiasPoolFactor
+	}
+
 	searchParams := gtfsdb.SearchStopsByNameParams{
 		SearchQuery: searchQuery,
-		Limit:       int64(limit),
+		Limit:       int64(fetchLimit),
 	}
 
 	// 3. Perform Full Text Search (with logged fallback)
@@ -126,6 +190,15 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	limitExceeded := len(stops) >= limit
+	if bias != nil {
+		rankStopsByProximity(stops, bias.lat, bias.lon)
+		limitExceeded = len(stops) > limit
+		if limitExceeded {
+			stops = stops[:limit]
+		}
+	}
+
 	// 4. Batch Fetch Related Data
 	stopIDs := make([]string, len(stops))
 	for i, s := range stops {
@@ -198,7 +271,7 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 				models.RouteType(row.Type),
 				url,
 				color,
-				textColor)
+				textColor).WithSortOrder(utils.NullInt32Ptr(row.SortOrder))
 
 		}
 	}
@@ -304,7 +377,7 @@ func (api *RestAPI) searchStopsHandler(w http.ResponseWriter, r *http.Request) {
 		OutOfRange    bool                   `json:"outOfRange"`
 		References    models.ReferencesModel `json:"references"`
 	}{
-		LimitExceeded: len(stops) >= limit,
+		LimitExceeded: limitExceeded,
 		List:          stopModels,
 		OutOfRange:    false,
 		References:    references,
@@ -382,3 +382,57 @@ func TestSanitizeFTS5Query(t *testing.T) {
 		})
 	}
 }
+
+func searchStopIDs(t *testing.T, model models.ResponseModel) []string {
+	t.Helper()
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	list, ok := data["list"].([]interface{})
+	require.True(t, ok)
+
+	ids := make([]string, 0, len(list))
+	for _, item := range list {
+		stop, ok := item.(map[string]interface{})
+		require.True(t, ok)
+		ids = append(ids, stop["id"].(string))
+	}
+	return ids
+}
+
+func TestSearchStopsHandlerMatchesStopCode(t *testing.T) {
+	api := createTestApi(t)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/search/stop.json?key=TEST&input=1030")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ids := searchStopIDs(t, model)
+	require.NotEmpty(t, ids)
+	assert.Equal(t, "25_1030", ids[0])
+}
+
+func TestSearchStopsHandlerLocationBias(t *testing.T) {
+	api := createTestApi(t)
+
+	// Commerce St at the south end of Hilltop Dr; every "Hilltop" stop matches
+	// the text equally well, so proximity decides the order.
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/search/stop.json?key=TEST&input=Hilltop&maxCount=2&lat=40.572833&lon=-122.357884")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ids := searchStopIDs(t, model)
+	require.Len(t, ids, 2)
+	assert.ElementsMatch(t, []string{"25_1112", "25_1414"}, ids)
+
+	data := model.Data.(map[string]interface{})
+	assert.Equal(t, true, data["limitExceeded"])
+}
+
+func TestSearchStopsHandlerLocationBiasValidation(t *testing.T) {
+	api := createTestApi(t)
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/search/stop.json?key=TEST&input=Hilltop&lat=40.57")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/search/stop.json?key=TEST&input=Hilltop&lat=140&lon=-122.35")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
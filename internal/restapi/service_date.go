@@ -0,0 +1,56 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// A trip's stop times count from the midnight of its service date in the
+// timezone of the agency operating it. A feed's agencies can be in different
+// zones, so service dates are resolved from the trip's route rather than from
+// the stop or agency a request names.
+
+// agencyLocation returns the agency's timezone, or UTC if the agency is unknown.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) agencyLocation(ctx context.Context, agencyID string) *time.Location {
+	loc, err := tripDataMemoFromContext(ctx).agencyLocation(ctx, api.GtfsManager.GtfsDB.Queries, agencyID)
+	if err != nil || loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// routeLocation returns the timezone of the agency operating the route.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) routeLocation(ctx context.Context, routeID string) *time.Location {
+	route, err := tripDataMemoFromContext(ctx).route(ctx, api.GtfsManager.GtfsDB, routeID)
+	if err != nil {
+		return time.UTC
+	}
+	return api.agencyLocation(ctx, route.AgencyID)
+}
+
+// feedLocations returns the distinct timezones of the feed's agencies.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) feedLocations(ctx context.Context) ([]*time.Location, error) {
+	agencies, err := api.GtfsManager.GtfsDB.Queries.ListAgencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []*time.Location
+	seen := make(map[string]bool)
+	for _, agency := range agencies {
+		loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
+		if !seen[loc.String()] {
+			seen[loc.String()] = true
+			locations = append(locations, loc)
+		}
+	}
+	if len(locations) == 0 {
+		locations = append(locations, time.UTC)
+	}
+	return locations, nil
+}
@@ -5,8 +5,25 @@ import (
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/geo"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
 )
 
+// defaultStopDistanceEarlyExitMeters is how far the batch stop placement keeps
+// searching past a stop's best match when the config does not set it.
+const defaultStopDistanceEarlyExitMeters = 100.0
+
+// stopDistanceEarlyExit returns the configured early-exit threshold of
+// calculateBatchStopDistances.
+func (api *RestAPI) stopDistanceEarlyExit() float64 {
+	if api.Application == nil || api.Config.StopDistanceEarlyExitMeters == 0 {
+		return defaultStopDistanceEarlyExitMeters
+	}
+	return api.Config.StopDistanceEarlyExitMeters
+}
+
 // shapeRowsToPoints converts database shape rows to gtfs.ShapePoint slice.
 // ShapeDistTraveled is intentionally dropped; cumulative distances are recomputed
 // from scratch via preCalculateCumulativeDistances to ensure consistency.
@@ -18,79 +35,155 @@ func shapeRowsToPoints(rows []gtfsdb.Shape) []gtfs.ShapePoint {
 	return pts
 }
 
-// IMPORTANT: Caller must hold manager.RLock() before calling this method.
-func (api *RestAPI) getStopDistanceAlongShape(ctx context.Context, tripID, stopID string) float64 {
-	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
-	if err == nil {
-		for _, st := range stopTimes {
-			if st.StopID == stopID && st.ShapeDistTraveled.Valid {
-				return st.ShapeDistTraveled.Float64
-			}
+// tripShapePlacement places the stop times of a trip on its shape geometry,
+// in meters. shape_dist_traveled is not used directly: its units are up to the
+// feed. The import converts it to meters when it stores each stop time's
+// distance_along_shape.
+type tripShapePlacement struct {
+	geometry  *GTFS.ShapeGeometry
+	stopTimes []gtfsdb.StopTime
+	// stopDistances[i] is how far along the shape stopTimes[i] lies.
+	stopDistances []float64
+}
+
+// stopDistance returns how far along the shape the stop time with the given
+// sequence lies.
+func (p *tripShapePlacement) stopDistance(stopSequence int64) (float64, bool) {
+	for i, st := range p.stopTimes {
+		if st.StopSequence == stopSequence {
+			return p.stopDistances[i], true
 		}
 	}
+	return 0, false
+}
 
-	shapeRows, err := api.GtfsManager.GtfsDB.Queries.GetShapePointsByTripID(ctx, tripID)
-	if err != nil || len(shapeRows) < 2 {
+// vehicleDistance map-matches the vehicle's position onto the shape and returns
+// how far along it the vehicle is. When the vehicle reports its current stop,
+// the match is confined to the stretch leading up to that stop so that a
+// vehicle on a loop or out-and-back shape is not matched to the wrong pass.
+func (p *tripShapePlacement) vehicleDistance(vehicle *gtfs.Vehicle) float64 {
+	if vehicle == nil || vehicle.Position == nil || vehicle.Position.Latitude == nil || vehicle.Position.Longitude == nil {
 		return 0
 	}
 
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
-	if err != nil {
-		return 0
+	lat := float64(*vehicle.Position.Latitude)
+	lon := float64(*vehicle.Position.Longitude)
+	points, cumulative := p.geometry.Points, p.geometry.CumulativeDistances
+
+	if vehicle.CurrentStopSequence != nil {
+		currentSeq := int64(*vehicle.CurrentStopSequence)
+		for i, st := range p.stopTimes {
+			if st.StopSequence < currentSeq {
+				continue
+			}
+			var prevStopDist float64
+			if i > 0 {
+				prevStopDist = p.stopDistances[i-1]
+			}
+			return distanceAlongShapeInRange(lat, lon, points, cumulative, prevStopDist, p.stopDistances[i])
+		}
 	}
 
-	shapePoints := shapeRowsToPoints(shapeRows)
+	return distanceAlongShape(lat, lon, points, cumulative)
+}
 
-	return getDistanceAlongShape(stop.Lat, stop.Lon, shapePoints)
+// placeStopsAlongShape returns how far along the shape, in meters, each stop
+// lies, given the stops in stop sequence order; see geo.PlaceStops.
+func placeStopsAlongShape(shape []gtfs.ShapePoint, cumulativeDistances []float64, stops []models.Location) []float64 {
+	points := make([]geo.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = geo.Point{Lat: stop.Lat, Lon: stop.Lon}
+	}
+	return geo.PlaceStops(shape, cumulativeDistances, points, utils.GeodesicProjection())
 }
 
-// IMPORTANT: Caller must hold manager.RLock() before calling this method.
-func (api *RestAPI) getVehicleDistanceAlongShapeContextual(ctx context.Context, tripID string, vehicle *gtfs.Vehicle) float64 {
-	if vehicle == nil || vehicle.Position == nil || vehicle.Position.Latitude == nil || vehicle.Position.Longitude == nil {
-		return 0
+// storedStopDistances returns the distances along their trip's shape that the
+// import stored for the stop times. It returns false when one is missing, as
+// in a database imported before they were stored, and when the geodesic
+// projection is enabled, as the import matched stops with the planar one.
+func storedStopDistances(stopTimes []gtfsdb.StopTime) ([]float64, bool) {
+	if len(stopTimes) == 0 || utils.GeodesicProjection() {
+		return nil, false
+	}
+	distances := make([]float64, len(stopTimes))
+	for i, st := range stopTimes {
+		if !st.DistanceAlongShape.Valid {
+			return nil, false
+		}
+		distances[i] = st.DistanceAlongShape.Float64
 	}
+	return distances, true
+}
 
-	shapeRows, err := api.GtfsManager.GtfsDB.Queries.GetShapePointsByTripID(ctx, tripID)
-	if err != nil || len(shapeRows) < 2 {
-		return 0
+// tripShapePlacement returns the trip's stop times placed on its shape, or nil
+// when the trip has no shape. Stop times whose stop cannot be found leave the
+// placement without stop times, so only whole-shape matching is available.
+//
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) tripShapePlacement(ctx context.Context, tripID string) *tripShapePlacement {
+	memo := tripDataMemoFromContext(ctx)
+	placement, err := memo.shapePlacement(tripID, func() (*tripShapePlacement, error) {
+		return api.placeTripOnShape(ctx, memo, tripID)
+	})
+	if err != nil {
+		return nil
 	}
+	return placement
+}
 
-	shapePoints := shapeRowsToPoints(shapeRows)
+func (api *RestAPI) placeTripOnShape(ctx context.Context, memo *tripDataMemo, tripID string) (*tripShapePlacement, error) {
+	geometry, err := api.GtfsManager.GetShapeGeometryForTrip(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if geometry == nil || len(geometry.Points) < 2 {
+		return nil, nil
+	}
+	placement := &tripShapePlacement{geometry: geometry}
 
-	lat := float64(*vehicle.Position.Latitude)
-	lon := float64(*vehicle.Position.Longitude)
+	queries := api.GtfsManager.GtfsDB.Queries
+	stopTimes, err := memo.stopTimesForTrip(ctx, queries, tripID)
+	if err != nil || len(stopTimes) == 0 {
+		return placement, err
+	}
+	if distances, ok := storedStopDistances(stopTimes); ok {
+		placement.stopTimes = stopTimes
+		placement.stopDistances = distances
+		return placement, nil
+	}
 
-	if vehicle.CurrentStopSequence != nil {
-		stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
-		if err == nil && len(stopTimes) > 0 {
-			currentSeq := int64(*vehicle.CurrentStopSequence)
-			var prevStopDist, nextStopDist float64
-			foundNext := false
-
-			for i, st := range stopTimes {
-				if st.StopSequence >= currentSeq {
-					if st.ShapeDistTraveled.Valid {
-						nextStopDist = st.ShapeDistTraveled.Float64
-					} else {
-						nextStopDist = api.getStopDistanceAlongShape(ctx, tripID, st.StopID)
-					}
-					if i > 0 {
-						if stopTimes[i-1].ShapeDistTraveled.Valid {
-							prevStopDist = stopTimes[i-1].ShapeDistTraveled.Float64
-						} else {
-							prevStopDist = api.getStopDistanceAlongShape(ctx, tripID, stopTimes[i-1].StopID)
-						}
-					}
-					foundNext = true
-					break
-				}
-			}
+	stopIDs := make([]string, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		stopIDs = append(stopIDs, st.StopID)
+	}
+	stops, err := queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		return nil, err
+	}
+	locations := make(map[string]models.Location, len(stops))
+	for _, stop := range stops {
+		locations[stop.ID] = models.Location{Lat: stop.Lat, Lon: stop.Lon}
+	}
 
-			if foundNext {
-				return getDistanceAlongShapeInRange(lat, lon, shapePoints, prevStopDist, nextStopDist)
-			}
+	stopLocations := make([]models.Location, len(stopTimes))
+	for i, st := range stopTimes {
+		location, ok := locations[st.StopID]
+		if !ok {
+			return placement, nil
 		}
+		stopLocations[i] = location
 	}
 
-	return getDistanceAlongShape(lat, lon, shapePoints)
+	placement.stopTimes = stopTimes
+	placement.stopDistances = placeStopsAlongShape(geometry.Points, geometry.CumulativeDistances, stopLocations)
+	return placement, nil
+}
+
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) getVehicleDistanceAlongShapeContextual(ctx context.Context, tripID string, vehicle *gtfs.Vehicle) float64 {
+	placement := api.tripShapePlacement(ctx, tripID)
+	if placement == nil {
+		return 0
+	}
+	return placement.vehicleDistance(vehicle)
 }
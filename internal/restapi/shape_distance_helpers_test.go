@@ -0,0 +1,119 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/geo"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// loopShape runs counter-clockwise around a block of roughly 1.1 km by 0.85 km
+// and ends where it started.
+var loopShape = []gtfs.ShapePoint{
+	{Latitude: 40.00, Longitude: -75.00},
+	{Latitude: 40.01, Longitude: -75.00},
+	{Latitude: 40.01, Longitude: -74.99},
+	{Latitude: 40.00, Longitude: -74.99},
+	{Latitude: 40.00, Longitude: -75.00},
+}
+
+func TestPlaceStopsAlongShape_Loop(t *testing.T) {
+	cumulative := preCalculateCumulativeDistances(loopShape)
+	length := cumulative[len(cumulative)-1]
+
+	terminal := models.Location{Lat: 40.00, Lon: -75.00}
+	farCorner := models.Location{Lat: 40.01, Lon: -74.99}
+	distances := placeStopsAlongShape(loopShape, cumulative, []models.Location{terminal, farCorner, terminal})
+
+	require.Len(t, distances, 3)
+	assert.InDelta(t, 0, distances[0], 1, "the first call at the terminal starts the loop")
+	assert.InDelta(t, cumulative[2], distances[1], 1)
+	assert.InDelta(t, length, distances[2], 1, "the second call at the terminal ends the loop")
+}
+
+func TestPlaceStopsAlongShape_OutAndBack(t *testing.T) {
+	shape := []gtfs.ShapePoint{
+		{Latitude: 40.00, Longitude: -75.00},
+		{Latitude: 40.01, Longitude: -75.00},
+		{Latitude: 40.02, Longitude: -75.00},
+		{Latitude: 40.01, Longitude: -75.00},
+		{Latitude: 40.00, Longitude: -75.00},
+	}
+	cumulative := preCalculateCumulativeDistances(shape)
+
+	// The middle stop sits on both directions of the shape, a little off to
+	// the side of the road.
+	middle := models.Location{Lat: 40.01, Lon: -75.0001}
+	stops := []models.Location{{Lat: 40.00, Lon: -75.00}, middle, {Lat: 40.02, Lon: -75.00}, middle}
+	distances := placeStopsAlongShape(shape, cumulative, stops)
+
+	require.Len(t, distances, 4)
+	assert.InDelta(t, cumulative[1], distances[1], 1, "outbound call")
+	assert.InDelta(t, cumulative[2], distances[2], 1)
+	assert.InDelta(t, cumulative[3], distances[3], 1, "inbound call")
+}
+
+func TestPlaceStopsAlongShape_NeverGoesBackwards(t *testing.T) {
+	cumulative := preCalculateCumulativeDistances(loopShape)
+
+	// The second stop lies behind the first along the shape, as with
+	// stop times listed out of order.
+	stops := []models.Location{{Lat: 40.01, Lon: -74.995}, {Lat: 40.005, Lon: -75.00}}
+	distances := placeStopsAlongShape(loopShape, cumulative, stops)
+
+	require.Len(t, distances, 2)
+	assert.GreaterOrEqual(t, distances[1], distances[0])
+}
+
+func TestStoredStopDistancesAgreeWithPlacement(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	ctx := context.Background()
+
+	const tripID = "Route15-Southbound-MonSat-4"
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
+	require.NoError(t, err)
+	stored, ok := storedStopDistances(stopTimes)
+	require.True(t, ok, "the import stores a distance for every stop time of a trip with a shape")
+
+	geometry, err := api.GtfsManager.GetShapeGeometryForTrip(ctx, tripID)
+	require.NoError(t, err)
+	require.NotNil(t, geometry)
+	locations := make([]models.Location, len(stopTimes))
+	for i, st := range stopTimes {
+		stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, st.StopID)
+		require.NoError(t, err)
+		locations[i] = models.Location{Lat: stop.Lat, Lon: stop.Lon}
+	}
+	// RABA's stop times carry shape_dist_traveled, which the import follows
+	// rather than the closest approach of the shape to the stop.
+	placed := placeStopsAlongShape(geometry.Points, geometry.CumulativeDistances, locations)
+	for i := range stored {
+		assert.InDelta(t, placed[i], stored[i], geo.StopMatchToleranceMeters, "stop sequence %d", stopTimes[i].StopSequence)
+	}
+
+	// The import matched stops with the planar projection.
+	utils.SetGeodesicProjection(true)
+	t.Cleanup(func() { utils.SetGeodesicProjection(false) })
+	_, ok = storedStopDistances(stopTimes)
+	assert.False(t, ok)
+}
+
+func TestStoredStopDistancesMissing(t *testing.T) {
+	_, ok := storedStopDistances(nil)
+	assert.False(t, ok)
+
+	stopTimes := []gtfsdb.StopTime{
+		{StopSequence: 1, DistanceAlongShape: sql.NullFloat64{Float64: 0, Valid: true}},
+		{StopSequence: 2},
+	}
+	_, ok = storedStopDistances(stopTimes)
+	assert.False(t, ok, "a database imported before distances were stored has none")
+}
@@ -3,7 +3,6 @@ package restapi
 import (
 	"net/http"
 
-	"github.com/twpayne/go-polyline"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -21,18 +20,18 @@ func (api *RestAPI) shapesHandler(w http.ResponseWriter, r *http.Request) {
 	_, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
 
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
 		return
 	}
 
-	shapes, err := api.GtfsManager.GtfsDB.Queries.GetShapeByID(ctx, shapeID)
+	shapes, err := api.GtfsManager.GtfsDB.Queries.GetShapePoints(ctx, shapeID)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
 
 	if len(shapes) == 0 {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeShapeNotFound)
 		return
 	}
 
@@ -46,13 +45,16 @@ func (api *RestAPI) shapesHandler(w http.ResponseWriter, r *http.Request) {
 		lineCoords = append(lineCoords, []float64{point.Lat, point.Lon})
 	}
 
+	lineCoords, truncated := thinPoints(lineCoords, api.maxShapePoints())
+
 	// Encode as a single continuous polyline to ensure valid delta offsets
-	encodedPoints := string(polyline.EncodeCoords(lineCoords))
+	encodedPoints := utils.EncodePolyline(lineCoords)
 
 	shapeEntry := models.ShapeEntry{
-		Length: len(lineCoords),
-		Levels: "",
-		Points: encodedPoints,
+		Length:    len(lineCoords),
+		Levels:    "",
+		Points:    encodedPoints,
+		Truncated: truncated,
 	}
 
 	api.sendResponse(w, r, models.NewEntryResponse(shapeEntry, models.NewEmptyReferences(), api.Clock))
@@ -8,8 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/twpayne/go-polyline"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
 )
 
 // setupShapeTest creates a test agency and inserts shape points into the database.
@@ -49,7 +49,7 @@ func setupShapeTest(t *testing.T, api *RestAPI, shapeID string, points []struct
 // Only used in shape handler tests.
 func decodePolylinePoints(t *testing.T, encoded string) [][]float64 {
 	t.Helper()
-	coords, _, err := polyline.DecodeCoords([]byte(encoded))
+	coords, err := utils.DecodePolyline(encoded)
 	require.NoError(t, err)
 	return coords
 }
@@ -0,0 +1,352 @@
+package restapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/siri"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// siriStopMonitoringWindow is how far ahead stop-monitoring looks for visits.
+	siriStopMonitoringWindow = 60 * time.Minute
+	// siriStopMonitoringLookback includes visits scheduled this long ago, which may
+	// still be on their way if running late.
+	siriStopMonitoringLookback = 30 * time.Minute
+	// siriDefaultMaximumStopVisits caps the visits returned when the request sets no limit.
+	siriDefaultMaximumStopVisits = 50
+)
+
+// siriVehicleMonitoringHandler serves a SIRI VehicleMonitoring delivery for the
+// vehicles currently reported by the realtime feeds. Results can be narrowed with
+// VehicleRef and LineRef, both given as combined agency IDs.
+func (api *RestAPI) siriVehicleMonitoringHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	format, err := siri.ParseFormat(query.Get("type"))
+	if err != nil {
+		api.sendSiriVehicleMonitoringError(w, r, format, err.Error())
+		return
+	}
+	vehicleRef := query.Get("VehicleRef")
+	lineRef := query.Get("LineRef")
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	now := api.Clock.Now()
+	activities := make([]siri.VehicleActivity, 0)
+	locations := make(map[string]*time.Location)
+
+	for _, vehicle := range api.GtfsManager.GetRealTimeVehicles() {
+		if ctx.Err() != nil {
+			return
+		}
+		if vehicle.ID == nil || vehicle.Trip == nil {
+			continue
+		}
+
+		trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, vehicle.Trip.ID.ID)
+		if err != nil {
+			continue
+		}
+		route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, trip.RouteID)
+		if err != nil {
+			continue
+		}
+
+		vehicleID := utils.FormCombinedID(route.AgencyID, vehicle.ID.ID)
+		if vehicleRef != "" && vehicleRef != vehicleID {
+			continue
+		}
+		if lineRef != "" && lineRef != utils.FormCombinedID(route.AgencyID, route.ID) {
+			continue
+		}
+
+		loc, ok := locations[route.AgencyID]
+		if !ok {
+			loc = api.agencyLocation(ctx, route.AgencyID)
+			locations[route.AgencyID] = loc
+		}
+		serviceDate := now.In(loc)
+		if vehicle.Trip.ID.HasStartDate {
+			serviceDate = vehicle.Trip.ID.StartDate
+		}
+
+		journey := newSiriVehicleJourney(route, trip, serviceDate)
+		journey.Monitored = true
+		journey.VehicleRef = vehicleID
+		if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+			journey.VehicleLocation = &siri.VehicleLocation{
+				Latitude:  float64(*vehicle.Position.Latitude),
+				Longitude: float64(*vehicle.Position.Longitude),
+			}
+			if vehicle.Position.Bearing != nil {
+				bearing := float64(*vehicle.Position.Bearing)
+				journey.Bearing = &bearing
+			}
+		}
+		if tripUpdate, _ := api.GtfsManager.GetTripUpdateByID(trip.ID); tripUpdate != nil {
+			if delay := tripUpdateDelay(tripUpdate); delay != nil {
+				journey.Delay = siri.FormatDelay(*delay)
+			}
+		}
+		if vehicle.StopID != nil {
+			call := &siri.MonitoredCall{StopPointRef: utils.FormCombinedID(route.AgencyID, *vehicle.StopID)}
+			if stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, *vehicle.StopID); err == nil {
+				call.StopPointName = stop.Name.String
+			}
+			journey.MonitoredCall = call
+		}
+
+		recordedAt := now
+		if vehicle.Timestamp != nil {
+			recordedAt = *vehicle.Timestamp
+		}
+		activities = append(activities, siri.VehicleActivity{
+			RecordedAtTime:          recordedAt,
+			MonitoredVehicleJourney: journey,
+		})
+	}
+
+	api.sendSiri(w, r, http.StatusOK, format, siri.ServiceDelivery{
+		ResponseTimestamp: now,
+		VehicleMonitoringDelivery: []siri.VehicleMonitoringDelivery{{
+			Version:           siri.Version,
+			ResponseTimestamp: now,
+			VehicleActivity:   activities,
+		}},
+	})
+}
+
+// siriStopMonitoringHandler serves a SIRI StopMonitoring delivery listing the
+// upcoming visits to the stop named by MonitoringRef, with predicted times where
+// realtime data is available.
+func (api *RestAPI) siriStopMonitoringHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	format, err := siri.ParseFormat(query.Get("type"))
+	if err != nil {
+		api.sendSiriStopMonitoringError(w, r, format, err.Error())
+		return
+	}
+
+	monitoringRef := query.Get("MonitoringRef")
+	if monitoringRef == "" {
+		api.sendSiriStopMonitoringError(w, r, format, "MonitoringRef is required")
+		return
+	}
+	agencyID, stopCode, err := utils.ExtractAgencyIDAndCodeID(monitoringRef)
+	if err != nil {
+		api.sendSiriStopMonitoringError(w, r, format, "MonitoringRef must be a combined agency and stop ID")
+		return
+	}
+
+	maximumStopVisits := siriDefaultMaximumStopVisits
+	if val := query.Get("MaximumStopVisits"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			api.sendSiriStopMonitoringError(w, r, format, "MaximumStopVisits must be a positive integer")
+			return
+		}
+		maximumStopVisits = n
+	}
+	lineRef := query.Get("LineRef")
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopCode)
+	if err != nil {
+		api.sendSiriStopMonitoringError(w, r, format, "no such stop: "+monitoringRef)
+		return
+	}
+
+	now := api.Clock.Now()
+	loc := api.agencyLocation(ctx, agencyID)
+	now = now.In(loc)
+
+	windowStart := now.Add(-siriStopMonitoringLookback)
+	stopTimes, err := api.collectActiveStopTimes(ctx, stopCode, windowStart, now.Add(siriStopMonitoringWindow))
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	visits := make([]siri.MonitoredStopVisit, 0)
+	for _, ast := range stopTimes {
+		if len(visits) >= maximumStopVisits {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		st := ast.GetStopTimesForStopInWindowRow
+		// Stop monitoring lists departures; a call where nobody can board,
+		// such as a trip's last stop, is not one.
+		if !departureEnabled(st.PickupType) {
+			continue
+		}
+
+		trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, st.TripID)
+		if err != nil {
+			continue
+		}
+		route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, st.RouteID)
+		if err != nil {
+			continue
+		}
+		if lineRef != "" && lineRef != utils.FormCombinedID(route.AgencyID, route.ID) {
+			continue
+		}
+
+		aimedArrival := ast.ServiceDate.Add(utils.StopTimeDuration(st.ArrivalTime))
+		aimedDeparture := ast.ServiceDate.Add(utils.StopTimeDuration(st.DepartureTime))
+		expectedArrival, expectedDeparture := aimedArrival, aimedDeparture
+		predictedArrival, predictedDeparture := api.getPredictedTimes(ctx, st.TripID, stopCode, st.StopSequence, aimedArrival, aimedDeparture)
+		monitored := predictedArrival != 0 || predictedDeparture != 0
+		if monitored {
+			expectedArrival = time.UnixMilli(predictedArrival).In(loc)
+			expectedDeparture = time.UnixMilli(predictedDeparture).In(loc)
+		}
+		if expectedDeparture.Before(now) {
+			continue
+		}
+
+		journey := newSiriVehicleJourney(route, trip, ast.ServiceDate)
+		journey.DestinationName = stopTimeHeadsign(st.StopHeadsign, trip.TripHeadsign)
+		journey.Monitored = monitored
+		if monitored {
+			journey.Delay = siri.FormatDelay(expectedDeparture.Sub(aimedDeparture))
+		}
+		if vehicle := api.GtfsManager.GetVehicleForTrip(ctx, st.TripID); vehicle != nil && vehicle.ID != nil {
+			journey.VehicleRef = utils.FormCombinedID(route.AgencyID, vehicle.ID.ID)
+			if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+				journey.VehicleLocation = &siri.VehicleLocation{
+					Latitude:  float64(*vehicle.Position.Latitude),
+					Longitude: float64(*vehicle.Position.Longitude),
+				}
+			}
+		}
+
+		call := &siri.MonitoredCall{
+			StopPointRef:       monitoringRef,
+			StopPointName:      stop.Name.String,
+			AimedArrivalTime:   &aimedArrival,
+			AimedDepartureTime: &aimedDeparture,
+		}
+		if monitored {
+			call.ExpectedArrivalTime = &expectedArrival
+			call.ExpectedDepartureTime = &expectedDeparture
+		}
+		journey.MonitoredCall = call
+
+		visits = append(visits, siri.MonitoredStopVisit{
+			RecordedAtTime:          now,
+			MonitoringRef:           monitoringRef,
+			MonitoredVehicleJourney: journey,
+		})
+	}
+
+	api.sendSiri(w, r, http.StatusOK, format, siri.ServiceDelivery{
+		ResponseTimestamp: now,
+		StopMonitoringDelivery: []siri.StopMonitoringDelivery{{
+			Version:            siri.Version,
+			ResponseTimestamp:  now,
+			MonitoredStopVisit: visits,
+		}},
+	})
+}
+
+// newSiriVehicleJourney fills the schedule-derived parts of a journey. Realtime
+// fields are left for the caller.
+func newSiriVehicleJourney(route gtfsdb.Route, trip gtfsdb.Trip, serviceDate time.Time) siri.MonitoredVehicleJourney {
+	journey := siri.MonitoredVehicleJourney{
+		LineRef: utils.FormCombinedID(route.AgencyID, route.ID),
+		FramedVehicleJourneyRef: siri.FramedVehicleJourneyRef{
+			DataFrameRef:           siri.FormatServiceDate(serviceDate),
+			DatedVehicleJourneyRef: utils.FormCombinedID(route.AgencyID, trip.ID),
+		},
+		PublishedLineName: route.ShortName.String,
+		OperatorRef:       route.AgencyID,
+		DestinationName:   trip.TripHeadsign.String,
+	}
+	if journey.PublishedLineName == "" {
+		journey.PublishedLineName = route.LongName.String
+	}
+	if trip.DirectionID.Valid {
+		journey.DirectionRef = strconv.FormatInt(trip.DirectionID.Int64, 10)
+	}
+	if trip.ShapeID.Valid {
+		journey.JourneyPatternRef = utils.FormCombinedID(route.AgencyID, trip.ShapeID.String)
+	}
+	if trip.BlockID.Valid {
+		journey.BlockRef = utils.FormCombinedID(route.AgencyID, trip.BlockID.String)
+	}
+	return journey
+}
+
+// tripUpdateDelay returns the trip-level delay of a trip update, falling back to
+// the first stop time update that carries one.
+func tripUpdateDelay(update *gtfs.Trip) *time.Duration {
+	if update.Delay != nil {
+		return update.Delay
+	}
+	for _, stu := range update.StopTimeUpdates {
+		if stu.Arrival != nil && stu.Arrival.Delay != nil {
+			return stu.Arrival.Delay
+		}
+		if stu.Departure != nil && stu.Departure.Delay != nil {
+			return stu.Departure.Delay
+		}
+	}
+	return nil
+}
+
+func (api *RestAPI) sendSiriVehicleMonitoringError(w http.ResponseWriter, r *http.Request, format siri.Format, description string) {
+	now := api.Clock.Now()
+	status := false
+	api.sendSiri(w, r, http.StatusBadRequest, format, siri.ServiceDelivery{
+		ResponseTimestamp: now,
+		VehicleMonitoringDelivery: []siri.VehicleMonitoringDelivery{{
+			Version:           siri.Version,
+			ResponseTimestamp: now,
+			Status:            &status,
+			ErrorCondition:    &siri.ErrorCondition{Description: description},
+		}},
+	})
+}
+
+func (api *RestAPI) sendSiriStopMonitoringError(w http.ResponseWriter, r *http.Request, format siri.Format, description string) {
+	now := api.Clock.Now()
+	status := false
+	api.sendSiri(w, r, http.StatusBadRequest, format, siri.ServiceDelivery{
+		ResponseTimestamp: now,
+		StopMonitoringDelivery: []siri.StopMonitoringDelivery{{
+			Version:           siri.Version,
+			ResponseTimestamp: now,
+			Status:            &status,
+			ErrorCondition:    &siri.ErrorCondition{Description: description},
+		}},
+	})
+}
+
+func (api *RestAPI) sendSiri(w http.ResponseWriter, r *http.Request, code int, format siri.Format, delivery siri.ServiceDelivery) {
+	w.Header().Set("Content-Type", format.ContentType())
+	if api.Application != nil && api.GtfsManager != nil && api.GtfsManager.IsRealtimeDegraded() {
+		w.Header().Set(realtimeStaleHeader, "true")
+	}
+	w.WriteHeader(code)
+	if err := siri.Encode(w, format, siri.NewSiri(delivery)); err != nil {
+		api.Logger.Error("failed to encode SIRI response", "error", err, "path", r.URL.Path)
+	}
+}
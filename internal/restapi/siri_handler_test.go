@@ -0,0 +1,123 @@
+package restapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/siri"
+)
+
+func serveSiriEndpoint(t *testing.T, api *RestAPI, endpoint string) (*http.Response, []byte) {
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + endpoint)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+func TestSiriStopMonitoringJSON(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 13, 8, 0, 0, 0, loc)))
+	defer api.Shutdown()
+
+	resp, body := serveSiriEndpoint(t, api, "/siri/stop-monitoring?key=TEST&type=json&MonitoringRef=25_1030&MaximumStopVisits=3")
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var decoded struct {
+		Siri siri.Siri `json:"Siri"`
+	}
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	deliveries := decoded.Siri.ServiceDelivery.StopMonitoringDelivery
+	require.Len(t, deliveries, 1)
+
+	visits := deliveries[0].MonitoredStopVisit
+	require.NotEmpty(t, visits)
+	assert.LessOrEqual(t, len(visits), 3)
+	for _, visit := range visits {
+		assert.Equal(t, "25_1030", visit.MonitoringRef)
+		journey := visit.MonitoredVehicleJourney
+		assert.Contains(t, journey.LineRef, "25_")
+		assert.Equal(t, "2025-06-13", journey.FramedVehicleJourneyRef.DataFrameRef)
+		require.NotNil(t, journey.MonitoredCall)
+		require.NotNil(t, journey.MonitoredCall.AimedDepartureTime)
+		assert.False(t, journey.MonitoredCall.AimedDepartureTime.Before(time.Date(2025, 6, 13, 7, 30, 0, 0, loc)))
+	}
+}
+
+func TestSiriStopMonitoringSkipsCallsWithoutPickup(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 6, 13, 9, 55, 0, 0, loc)))
+	defer api.Shutdown()
+	createPickupDropOffTestTrip(t, api)
+
+	journeys := func(stopID string) []string {
+		resp, body := serveSiriEndpoint(t, api, "/siri/stop-monitoring?key=TEST&type=json&MaximumStopVisits=100&MonitoringRef="+stopID)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		var decoded struct {
+			Siri siri.Siri `json:"Siri"`
+		}
+		require.NoError(t, json.Unmarshal(body, &decoded))
+		var refs []string
+		for _, visit := range decoded.Siri.ServiceDelivery.StopMonitoringDelivery[0].MonitoredStopVisit {
+			refs = append(refs, visit.MonitoredVehicleJourney.FramedVehicleJourneyRef.DatedVehicleJourneyRef)
+		}
+		return refs
+	}
+
+	assert.Contains(t, journeys("25_2000"), "25_PICKUP_TEST")
+	assert.NotContains(t, journeys("25_1030"), "25_PICKUP_TEST", "riders cannot board at the trip's last stop")
+}
+
+func TestSiriStopMonitoringRequiresMonitoringRef(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, body := serveSiriEndpoint(t, api, "/siri/stop-monitoring?key=TEST")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+
+	var decoded siri.Siri
+	require.NoError(t, xml.Unmarshal(body, &decoded))
+	require.Len(t, decoded.ServiceDelivery.StopMonitoringDelivery, 1)
+	delivery := decoded.ServiceDelivery.StopMonitoringDelivery[0]
+	require.NotNil(t, delivery.ErrorCondition)
+	assert.Contains(t, delivery.ErrorCondition.Description, "MonitoringRef")
+}
+
+func TestSiriVehicleMonitoringXML(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, body := serveSiriEndpoint(t, api, "/siri/vehicle-monitoring?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var decoded siri.Siri
+	require.NoError(t, xml.Unmarshal(body, &decoded))
+	assert.Equal(t, siri.Version, decoded.Version)
+	require.Len(t, decoded.ServiceDelivery.VehicleMonitoringDelivery, 1)
+}
+
+func TestSiriRequiresAPIKey(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveSiriEndpoint(t, api, "/siri/vehicle-monitoring")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// situationsForAgencyHandler lists the service alerts affecting an agency,
+// localized to the lang parameter.
+func (api *RestAPI) situationsForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	agencyID, _ := utils.GetIDFromContext(r.Context())
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	if api.GtfsManager.FindAgency(agencyID) == nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return
+	}
+
+	alerts := api.GtfsManager.GetAlertsForAgency(ctx, agencyID)
+	situations := api.BuildSituationReferences(alerts, agencyID, r.URL.Query().Get("lang"))
+
+	api.sendResponse(w, r, models.NewListResponse(situations, models.NewEmptyReferences(), false, api.Clock))
+}
+
+// situationHandler returns a single service alert. Situation IDs are the
+// alert's feed entity ID prefixed with an agency ID, as listed by other endpoints.
+func (api *RestAPI) situationHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+
+	alert, ok := api.GtfsManager.GetAlertByID(parsed.CodeID)
+	if !ok {
+		api.sendNotFoundWithCode(w, r, errCodeSituationNotFound)
+		return
+	}
+
+	situations := api.BuildSituationReferences([]gtfs.Alert{alert}, parsed.AgencyID, r.URL.Query().Get("lang"))
+	api.sendResponse(w, r, models.NewEntryResponse(situations[0], models.NewEmptyReferences(), api.Clock))
+}
+
+// situationsForStopHandler lists the service alerts affecting a stop,
+// localized to the lang parameter.
+func (api *RestAPI) situationsForStopHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	if _, err := api.GtfsManager.GtfsDB.GetStop(ctx, parsed.CodeID); err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
+		return
+	}
+
+	alerts := api.alertsForStop(ctx, parsed.CodeID)
+	situations := api.BuildSituationReferences(alerts, parsed.AgencyID, r.URL.Query().Get("lang"))
+
+	api.sendResponse(w, r, models.NewListResponse(situations, models.NewEmptyReferences(), false, api.Clock))
+}
+
+// alertsForStop returns the alerts naming the stop or the station it is part
+// of, each once. A failed station lookup leaves the station's alerts out.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) alertsForStop(ctx context.Context, stopID string) []gtfs.Alert {
+	alerts := api.GtfsManager.GetAlertsForStop(stopID)
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, []string{stopID})
+	if err != nil {
+		api.Logger.Warn("Failed to fetch stop for alerts; leaving out its station's alerts",
+			slog.String("stop_id", stopID),
+			slog.Any("error", err),
+		)
+		return alerts
+	}
+	if len(stops) == 0 || !stops[0].ParentStation.Valid || stops[0].ParentStation.String == "" {
+		return alerts
+	}
+
+	seen := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		seen[alert.ID] = true
+	}
+	for _, alert := range api.GtfsManager.GetAlertsForStop(stops[0].ParentStation.String) {
+		if alert.ID != "" && seen[alert.ID] {
+			continue
+		}
+		seen[alert.ID] = true
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// situationIDsForAlerts returns the agency-prefixed situation IDs of the
+// alerts, as BuildSituationReferences forms them. Alerts without an ID are
+// left out since they cannot be looked up.
+func situationIDsForAlerts(alerts []gtfs.Alert, agencyID string) []string {
+	situationIDs := []string{}
+	for _, alert := range alerts {
+		if alert.ID == "" {
+			continue
+		}
+		situationIDs = append(situationIDs, utils.FormCombinedID(agencyID, alert.ID))
+	}
+	return situationIDs
+}
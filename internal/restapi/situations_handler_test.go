@@ -0,0 +1,116 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSituationsHandlers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	routeID := api.GtfsManager.RoutesForAgencyID(agencyID)[0].Id
+	var tripID, stopID string
+	row := api.GtfsManager.GtfsDB.DB.QueryRowContext(context.Background(), "SELECT trip_id, stop_id FROM stop_times LIMIT 1")
+	require.NoError(t, row.Scan(&tripID, &stopID))
+	otherAgency := "not-this-agency"
+
+	start := time.UnixMilli(1700000000000)
+	api.GtfsManager.MockAddAlert(gtfs.Alert{
+		ID:               "agency-wide",
+		Effect:           gtfs.NoService,
+		ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &start}},
+		InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID}},
+		Header: []gtfs.AlertText{
+			{Text: "Service suspended", Language: "en"},
+			{Text: "Servicio suspendido", Language: "es"},
+		},
+	})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "route", InformedEntities: []gtfs.AlertInformedEntity{{RouteID: &routeID}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "trip", InformedEntities: []gtfs.AlertInformedEntity{{TripID: &gtfs.TripID{ID: tripID}}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "stop", InformedEntities: []gtfs.AlertInformedEntity{{StopID: &stopID}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "elsewhere", InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &otherAgency}}})
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/situations-for-agency/"+agencyID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	list := model.Data.(map[string]interface{})["list"].([]interface{})
+	var ids []string
+	for _, item := range list {
+		ids = append(ids, item.(map[string]interface{})["id"].(string))
+	}
+	assert.ElementsMatch(t, []string{agencyID + "_agency-wide", agencyID + "_route", agencyID + "_trip", agencyID + "_stop"}, ids)
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/situation/"+agencyID+"_agency-wide.json?key=TEST&lang=es")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, agencyID+"_agency-wide", entry["id"])
+	assert.Equal(t, "severe", entry["severity"])
+	assert.Equal(t, "Servicio suspendido", entry["summary"].(map[string]interface{})["value"])
+	windows := entry["activeWindows"].([]interface{})
+	require.Len(t, windows, 1)
+	assert.Equal(t, float64(start.UnixMilli()), windows[0].(map[string]interface{})["from"])
+	affects := entry["allAffects"].([]interface{})
+	require.Len(t, affects, 1)
+	assert.Equal(t, agencyID, affects[0].(map[string]interface{})["agencyId"])
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/situation/"+agencyID+"_missing.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/situations-for-agency/no-such-agency.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSituationsForStopHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	// Put stop 1505 in a station for the length of the test.
+	ctx := context.Background()
+	_, err := api.GtfsManager.GtfsDB.DB.ExecContext(ctx, "UPDATE stops SET parent_station = 'station-1505' WHERE id = '1505'")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := api.GtfsManager.GtfsDB.DB.ExecContext(ctx, "UPDATE stops SET parent_station = NULL WHERE id = '1505'")
+		require.NoError(t, err)
+	})
+
+	stopID, stationID, otherStopID := "1505", "station-1505", "1502"
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "stop", InformedEntities: []gtfs.AlertInformedEntity{{StopID: &stopID}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "station", InformedEntities: []gtfs.AlertInformedEntity{{StopID: &stationID}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "both", InformedEntities: []gtfs.AlertInformedEntity{{StopID: &stopID}, {StopID: &stationID}}})
+	api.GtfsManager.MockAddAlert(gtfs.Alert{ID: "elsewhere", InformedEntities: []gtfs.AlertInformedEntity{{StopID: &otherStopID}}})
+	want := []string{"25_stop", "25_station", "25_both"}
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/situations-for-stop/25_1505.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var ids []string
+	for _, item := range model.Data.(map[string]interface{})["list"].([]interface{}) {
+		ids = append(ids, item.(map[string]interface{})["id"].(string))
+	}
+	assert.ElementsMatch(t, want, ids)
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/arrivals-and-departures-for-stop/25_1505.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	assert.ElementsMatch(t, want, data["entry"].(map[string]interface{})["situationIds"])
+	var referenced []string
+	for _, item := range data["references"].(map[string]interface{})["situations"].([]interface{}) {
+		referenced = append(referenced, item.(map[string]interface{})["id"].(string))
+	}
+	assert.ElementsMatch(t, want, referenced)
+
+	resp, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/arrivals-and-departures-for-stop/25_1502.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []interface{}{"25_elsewhere"}, model.Data.(map[string]interface{})["entry"].(map[string]interface{})["situationIds"])
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/situations-for-stop/25_no-such-stop.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,156 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// stopByCodeHandler looks up stops by the code riders read off signage. Codes
+// are not unique: opposite sides of a street may share one, and so may stops of
+// agencies that number them separately. Every stop with the code is listed,
+// only those served by agencyId when it is given. With lat and lon the stops
+// carry their distance from there and are listed nearest first; otherwise they
+// are ordered by ID.
+func (api *RestAPI) stopByCodeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	code, _ := utils.GetIDFromContext(ctx)
+	q := newQueryParams(r)
+	agencyID := q.values.Get("agencyId")
+
+	bias := parseStopSearchBias(q)
+	if fieldErrors := q.errors(); fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	if agencyID != "" && api.GtfsManager.FindAgency(agencyID) == nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return
+	}
+
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByCode(ctx, sql.NullString{String: code, Valid: true})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	stopIDs := make([]string, len(stops))
+	for i, stop := range stops {
+		stopIDs[i] = stop.ID
+	}
+
+	routeIDRows, err := api.GtfsManager.GtfsDB.Queries.GetRouteIDsForStops(ctx, stopIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	agencyRows, err := api.GtfsManager.GtfsDB.Queries.GetAgenciesForStops(ctx, stopIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	routesByStop := make(map[string][]string)
+	for _, row := range routeIDRows {
+		if routeID, ok := row.RouteID.(string); ok {
+			routesByStop[row.StopID] = append(routesByStop[row.StopID], routeID)
+		}
+	}
+	agenciesByStop := make(map[string][]string)
+	for _, row := range agencyRows {
+		agenciesByStop[row.StopID] = append(agenciesByStop[row.StopID], row.ID)
+	}
+	// A stop no route serves belongs to the feed's only agency, if it has one.
+	var soleAgencyID string
+	if agencies := api.GtfsManager.GetAgencies(); len(agencies) == 1 {
+		soleAgencyID = agencies[0].Id
+	}
+
+	list := make([]models.Stop, 0, len(stops))
+	presentAgencies := make(map[string]bool)
+	presentRoutes := make(map[string]bool)
+	for _, stop := range stops {
+		stopAgencyID := stopCodeAgency(agenciesByStop[stop.ID], agencyID, soleAgencyID)
+		if stopAgencyID == "" {
+			continue
+		}
+
+		routeIDs := routesByStop[stop.ID]
+		if routeIDs == nil {
+			routeIDs = []string{}
+		}
+		sort.Strings(routeIDs)
+		for _, routeID := range routeIDs {
+			presentRoutes[routeID] = true
+		}
+		presentAgencies[stopAgencyID] = true
+
+		model := models.Stop{
+			Code:               stop.Code.String,
+			Direction:          utils.NullStringOrEmpty(stop.Direction),
+			ID:                 utils.FormCombinedID(stopAgencyID, stop.ID),
+			Lat:                stop.Lat,
+			LocationType:       int(stop.LocationType.Int64),
+			Lon:                stop.Lon,
+			Name:               utils.NullStringOrEmpty(stop.Name),
+			Parent:             utils.NullStringOrEmpty(stop.ParentStation),
+			RouteIDs:           routeIDs,
+			StaticRouteIDs:     routeIDs,
+			WheelchairBoarding: utils.MapWheelchairBoarding(utils.NullWheelchairBoardingOrUnknown(stop.WheelchairBoarding)),
+		}
+		if bias != nil {
+			model.Distance = utils.Distance(bias.lat, bias.lon, stop.Lat, stop.Lon)
+		}
+		list = append(list, model)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if bias != nil && list[i].Distance != list[j].Distance {
+			return list[i].Distance < list[j].Distance
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	references := models.NewEmptyReferences()
+	if len(presentAgencies) > 0 {
+		references.Agencies = utils.FilterAgencies(api.GtfsManager.GetAgencies(), presentAgencies)
+	}
+	if len(presentRoutes) > 0 {
+		references.Routes = utils.FilterRoutes(api.GtfsManager.GtfsDB.Queries, ctx, presentRoutes)
+	}
+
+	api.sendResponse(w, r, models.NewListResponse(list, references, false, api.Clock))
+}
+
+// stopCodeAgency picks the agency a stop served by agencies is listed under:
+// scope when it is one of them, and otherwise the first in ID order. A stop
+// outside scope, or without an agency, gets "".
+func stopCodeAgency(agencies []string, scope, soleAgencyID string) string {
+	if len(agencies) == 0 {
+		if scope == "" || scope == soleAgencyID {
+			return soleAgencyID
+		}
+		return ""
+	}
+	if scope != "" {
+		for _, id := range agencies {
+			if id == scope {
+				return id
+			}
+		}
+		return ""
+	}
+	first := agencies[0]
+	for _, id := range agencies[1:] {
+		if id < first {
+			first = id
+		}
+	}
+	return first
+}
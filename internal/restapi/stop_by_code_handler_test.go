@@ -0,0 +1,91 @@
+package restapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stopByCodeList(t *testing.T, api *RestAPI, path string) []map[string]interface{} {
+	t.Helper()
+	resp, model := serveApiAndRetrieveEndpoint(t, api, path)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	var stops []map[string]interface{}
+	for _, item := range data["list"].([]interface{}) {
+		stops = append(stops, item.(map[string]interface{}))
+	}
+	return stops
+}
+
+func TestStopByCode(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop-by-code/1505.json?key=org.onebusaway.iphone")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data := model.Data.(map[string]interface{})
+	list := data["list"].([]interface{})
+	require.Len(t, list, 1)
+	stop := list[0].(map[string]interface{})
+	assert.Equal(t, "25_1505", stop["id"])
+	assert.Equal(t, "1505", stop["code"])
+	assert.Equal(t, "Redding Regional Airport", stop["name"])
+	assert.NotEmpty(t, stop["routeIds"])
+	assert.NotContains(t, stop, "distance", "no location was given")
+
+	references := data["references"].(map[string]interface{})
+	agencies := references["agencies"].([]interface{})
+	require.Len(t, agencies, 1)
+	assert.Equal(t, "25", agencies[0].(map[string]interface{})["id"])
+	assert.Len(t, references["routes"], len(stop["routeIds"].([]interface{})))
+
+	assert.Empty(t, stopByCodeList(t, api, "/api/where/stop-by-code/99999.json?key=org.onebusaway.iphone"))
+}
+
+func TestStopByCodeCollisions(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// Both sides of Shasta View Dr at Tarmac Rd carry code 8009.
+	stops := stopByCodeList(t, api, "/api/where/stop-by-code/8009.json?key=org.onebusaway.iphone")
+	require.Len(t, stops, 2)
+	assert.Equal(t, "25_8009", stops[0]["id"])
+	assert.Equal(t, "25_8009-west", stops[1]["id"])
+
+	// From the westbound stop, it is listed first.
+	stops = stopByCodeList(t, api, "/api/where/stop-by-code/8009.json?key=org.onebusaway.iphone&lat=40.576183&lon=-122.324742")
+	require.Len(t, stops, 2)
+	assert.Equal(t, "25_8009-west", stops[0]["id"])
+	assert.NotContains(t, stops[0], "distance", "the stop is where the rider is")
+	assert.InDelta(t, 90, stops[1]["distance"], 10)
+
+	stops = stopByCodeList(t, api, "/api/where/stop-by-code/8009.json?key=org.onebusaway.iphone&agencyId=25")
+	assert.Len(t, stops, 2)
+}
+
+func TestStopByCodeValidation(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop-by-code/8009.json?key=org.onebusaway.iphone&agencyId=nope")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, http.StatusNotFound, model.Code)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/stop-by-code/8009.json?key=org.onebusaway.iphone&lat=40.5")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "lat needs lon")
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/stop-by-code/80%2009.json?key=org.onebusaway.iphone")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStopCodeAgency(t *testing.T) {
+	assert.Equal(t, "1", stopCodeAgency([]string{"3", "1"}, "", ""))
+	assert.Equal(t, "3", stopCodeAgency([]string{"3", "1"}, "3", ""))
+	assert.Equal(t, "", stopCodeAgency([]string{"3", "1"}, "2", ""))
+	assert.Equal(t, "25", stopCodeAgency(nil, "", "25"), "a stop no route serves belongs to the only agency")
+	assert.Equal(t, "", stopCodeAgency(nil, "", ""))
+	assert.Equal(t, "", stopCodeAgency(nil, "2", "25"))
+}
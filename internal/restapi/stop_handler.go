@@ -1,6 +1,7 @@
 package restapi
 
 import (
+	"context"
 	"net/http"
 
 	"maglev.onebusaway.org/internal/models"
@@ -20,9 +21,9 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopID)
 	if err != nil || stop.ID == "" {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeStopNotFound)
 		return
 	}
 
@@ -52,6 +53,20 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 		StaticRouteIDs:     combinedRouteIDs,
 	}
 
+	transfers, err := api.buildStopTransfers(ctx, agencyID, stopID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	stopData.Transfers = transfers
+
+	flexibleAreas, err := api.buildStopFlexibleAreas(ctx, agencyID, stop.ID, stop.Lat, stop.Lon)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	stopData.FlexibleAreas = flexibleAreas
+
 	references := models.NewEmptyReferences()
 	uniqueAgencyIDs := make(map[string]bool)
 
@@ -66,7 +81,7 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 			models.RouteType(route.Type),
 			route.Url.String,
 			route.Color.String,
-			route.TextColor.String)
+			route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 		references.Routes = append(references.Routes, routeModel)
 		uniqueAgencyIDs[route.AgencyID] = true
@@ -95,3 +110,55 @@ func (api *RestAPI) stopHandler(w http.ResponseWriter, r *http.Request) {
 	response := models.NewEntryResponse(stopData, references, api.Clock)
 	api.sendResponse(w, r, response)
 }
+
+// buildStopTransfers returns the transfers.txt rules departing from a stop. Route
+// and trip IDs are qualified with the agency of the referenced route.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) buildStopTransfers(ctx context.Context, agencyID, stopID string) ([]models.StopTransfer, error) {
+	rows, err := api.GtfsManager.GtfsDB.Queries.GetTransfersFromStop(ctx, stopID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	routeAgencies := make(map[string]string)
+	agencyForRoute := func(routeID string) string {
+		if aid, ok := routeAgencies[routeID]; ok {
+			return aid
+		}
+		aid := agencyID
+		if route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, routeID); err == nil {
+			aid = route.AgencyID
+		}
+		routeAgencies[routeID] = aid
+		return aid
+	}
+
+	transfers := make([]models.StopTransfer, 0, len(rows))
+	for _, row := range rows {
+		transfer := models.StopTransfer{
+			ToStopID:     utils.FormCombinedID(agencyID, row.ToStopID),
+			TransferType: int(row.TransferType),
+		}
+		if row.MinTransferTime.Valid {
+			minTransferTime := int(row.MinTransferTime.Int64)
+			transfer.MinTransferTime = &minTransferTime
+		}
+		if row.FromRouteID.Valid {
+			transfer.FromRouteID = utils.FormCombinedID(agencyForRoute(row.FromRouteID.String), row.FromRouteID.String)
+		}
+		if row.ToRouteID.Valid {
+			transfer.ToRouteID = utils.FormCombinedID(agencyForRoute(row.ToRouteID.String), row.ToRouteID.String)
+		}
+		if row.FromTripID.Valid {
+			transfer.FromTripID = utils.FormCombinedID(agencyID, row.FromTripID.String)
+		}
+		if row.ToTripID.Valid {
+			transfer.ToTripID = utils.FormCombinedID(agencyID, row.ToTripID.String)
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
@@ -294,3 +294,95 @@ func TestStopHandlerMultiAgencyScenario(t *testing.T) {
 	assert.True(t, foundA, "Agency A should be in references")
 	assert.True(t, foundB, "Agency B should be in references")
 }
+
+func TestStopHandlerIncludesTransfers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// RABA's transfers.txt declares timed (type 1) same-stop transfers, e.g. at stop 1000.
+	stopID := utils.FormCombinedID("25", "1000")
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+
+	transfers, ok := entry["transfers"].([]interface{})
+	require.True(t, ok, "stop should expose its transfers")
+	require.NotEmpty(t, transfers)
+
+	transfer, ok := transfers[0].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, stopID, transfer["toStopId"])
+	assert.Equal(t, float64(1), transfer["transferType"])
+	assert.NotContains(t, transfer, "minTransferTime")
+}
+
+func TestStopReferencesOmitTransfers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	// Stop 2000 has transfers on its stop/{id} entry; references to it do not.
+	stopID := utils.FormCombinedID("25", "2000")
+	routes, err := api.GtfsManager.GtfsDB.Queries.GetRoutesForStop(context.Background(), "2000")
+	require.NoError(t, err)
+	require.NotEmpty(t, routes)
+	routeID := utils.FormCombinedID(routes[0].AgencyID, routes[0].ID)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stops-for-route/"+routeID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	refs, ok := data["references"].(map[string]interface{})
+	require.True(t, ok)
+	stops, ok := refs["stops"].([]interface{})
+	require.True(t, ok)
+
+	var found bool
+	for _, s := range stops {
+		stop, ok := s.(map[string]interface{})
+		require.True(t, ok)
+		if stop["id"] == stopID {
+			found = true
+			assert.NotContains(t, stop, "transfers")
+		}
+	}
+	assert.True(t, found, "stop %s should be referenced by route %s", stopID, routeID)
+}
+
+func TestStopHandlerWithConfiguredIDFormat(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(func() { require.NoError(t, utils.SetIDCodec(utils.IDCodec{})) })
+
+	formats := []struct {
+		codec   utils.IDCodec
+		stopID  string
+		routeID string
+	}{
+		{utils.IDCodec{Separator: ":"}, "25:2000", "25:151"},
+		{utils.IDCodec{Raw: true, RawAgencyID: "25"}, "2000", "151"},
+	}
+	for _, format := range formats {
+		require.NoError(t, utils.SetIDCodec(format.codec))
+
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+format.stopID+".json?key=TEST")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		data := model.Data.(map[string]interface{})
+		entry := data["entry"].(map[string]interface{})
+		assert.Equal(t, format.stopID, entry["id"])
+		assert.Contains(t, entry["routeIds"], format.routeID)
+
+		references := data["references"].(map[string]interface{})
+		agencies := references["agencies"].([]interface{})
+		require.Len(t, agencies, 1)
+		assert.Equal(t, "25", agencies[0].(map[string]interface{})["id"], "agency IDs are never prefixed")
+	}
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/25_2000.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "raw IDs are looked up as given")
+}
@@ -0,0 +1,49 @@
+package restapi
+
+import "maglev.onebusaway.org/internal/models"
+
+// Defaults for the nearbyStopIds of arrivals-and-departures-for-stop when
+// neither the request nor the config sets them.
+const (
+	defaultNearbyStopsRadius   = 10000
+	defaultNearbyStopsMaxCount = 5
+)
+
+// maxNearbyStopsRadius is the largest nearbyStopsRadius a request may set,
+// the largest radius utils.ValidateRadius accepts.
+const maxNearbyStopsRadius = 10000
+
+// stopSearchRadius is the radius stops-for-location searches when the request
+// gives neither a radius, a span nor a query. Zero leaves the choice to the
+// GTFS manager.
+func (api *RestAPI) stopSearchRadius() float64 {
+	if api.Application == nil {
+		return 0
+	}
+	return api.Config.StopSearchRadius
+}
+
+// stopSearchMaxCount is the default maxCount of stops-for-location.
+func (api *RestAPI) stopSearchMaxCount() int {
+	if api.Application == nil || api.Config.StopSearchMaxCount == 0 {
+		return models.DefaultMaxCountForStops
+	}
+	return api.Config.StopSearchMaxCount
+}
+
+// nearbyStopsRadius is the default radius, in meters, searched for the stops
+// listed as nearbyStopIds.
+func (api *RestAPI) nearbyStopsRadius() float64 {
+	if api.Application == nil || api.Config.NearbyStopsRadius == 0 {
+		return defaultNearbyStopsRadius
+	}
+	return api.Config.NearbyStopsRadius
+}
+
+// nearbyStopsMaxCount is the default number of stops listed as nearbyStopIds.
+func (api *RestAPI) nearbyStopsMaxCount() int {
+	if api.Application == nil || api.Config.NearbyStopsMaxCount == 0 {
+		return defaultNearbyStopsMaxCount
+	}
+	return api.Config.NearbyStopsMaxCount
+}
@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// Sources of an arrival's numberOfStopsAway, reported as its predictedFrom.
+const (
+	predictedFromRealtime = "realtime"
+	predictedFromSchedule = "schedule"
+)
+
+// numberOfStopsAwayForArrival counts the stops between the vehicle serving
+// tripID and the trip's visit to targetStopSequence. A vehicle that reports its
+// position and stop decides the count. Otherwise it is estimated from the
+// schedule shifted by the trip's realtime schedule deviation, so that
+// schedule-only trips still get a count. The second value is where the count
+// came from, empty when neither could place the trip.
+func (api *RestAPI) numberOfStopsAwayForArrival(ctx context.Context, tripID string, targetStopSequence int64, vehicle *gtfs.Vehicle, serviceMidnight, currentTime time.Time) (int, string) {
+	if vehicle != nil && vehicle.Position != nil {
+		if stopsAway := api.getNumberOfStopsAway(ctx, tripID, int(targetStopSequence), vehicle, serviceMidnight); stopsAway != nil {
+			return *stopsAway, predictedFromRealtime
+		}
+	}
+
+	stopTimes, err := tripDataMemoFromContext(ctx).stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, tripID)
+	if err != nil {
+		return -1, ""
+	}
+	deviation, _ := api.GetScheduleDeviation(tripID)
+	stopsAway, ok := scheduleNumberOfStopsAway(stopTimes, targetStopSequence, serviceMidnight, currentTime, time.Duration(deviation)*time.Second)
+	if !ok {
+		return -1, ""
+	}
+	return stopsAway, predictedFromSchedule
+}
+
+// scheduleNumberOfStopsAway places a trip's vehicle at the last stop whose
+// scheduled arrival, shifted by deviation, is not after now, and counts the
+// stops between it and the stop at targetStopSequence. Before the trip starts
+// every stop ahead of the target counts, and once the vehicle is past the target
+// the count goes negative, matching the counts derived from vehicle positions.
+// stopTimes must be ordered by stop sequence.
+func scheduleNumberOfStopsAway(stopTimes []gtfsdb.StopTime, targetStopSequence int64, serviceMidnight, now time.Time, deviation time.Duration) (int, bool) {
+	target, reached := -1, -1
+	for i, st := range stopTimes {
+		if st.StopSequence == targetStopSequence {
+			target = i
+		}
+		if !serviceMidnight.Add(utils.StopTimeDuration(st.ArrivalTime) + deviation).After(now) {
+			reached = i
+		}
+	}
+	if target < 0 {
+		return 0, false
+	}
+	return target - reached - 1, true
+}
@@ -0,0 +1,81 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestScheduleNumberOfStopsAway(t *testing.T) {
+	serviceMidnight := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time {
+		return serviceMidnight.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+	}
+
+	// Stop sequences need not be contiguous.
+	stopTimes := []gtfsdb.StopTime{
+		{StopID: "A", StopSequence: 1, ArrivalTime: utils.StopTimeSeconds(8 * time.Hour)},
+		{StopID: "B", StopSequence: 5, ArrivalTime: utils.StopTimeSeconds(8*time.Hour + 10*time.Minute)},
+		{StopID: "C", StopSequence: 7, ArrivalTime: utils.StopTimeSeconds(8*time.Hour + 20*time.Minute)},
+		{StopID: "D", StopSequence: 9, ArrivalTime: utils.StopTimeSeconds(8*time.Hour + 30*time.Minute)},
+	}
+
+	tests := []struct {
+		name      string
+		target    int64
+		now       time.Time
+		deviation time.Duration
+		expected  int
+	}{
+		{name: "before the trip starts", target: 9, now: at(7, 50), expected: 3},
+		{name: "first stop before the trip starts", target: 1, now: at(7, 50), expected: 0},
+		{name: "between stops", target: 9, now: at(8, 15), expected: 1},
+		{name: "at the previous stop", target: 9, now: at(8, 20), expected: 0},
+		{name: "late by the deviation", target: 9, now: at(8, 15), deviation: 10 * time.Minute, expected: 2},
+		{name: "early by the deviation", target: 9, now: at(8, 15), deviation: -5 * time.Minute, expected: 0},
+		{name: "past the stop", target: 5, now: at(8, 25), expected: -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stopsAway, ok := scheduleNumberOfStopsAway(stopTimes, tt.target, serviceMidnight, tt.now, tt.deviation)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, stopsAway)
+		})
+	}
+
+	_, ok := scheduleNumberOfStopsAway(stopTimes, 2, serviceMidnight, at(8, 0), 0)
+	assert.False(t, ok, "a stop sequence the trip does not have cannot be placed")
+}
+
+func TestArrivalAndDepartureForStopHandler_ScheduleStopsAway(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+	// The trip reaches stop 9902 at 10:35, leaving stop 9901 between it and
+	// the final stop 327.
+	now := time.Date(2025, 6, 13, 10, 40, 0, 0, loc)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/arrival-and-departure-for-stop/25_327.json?key=TEST&tripId=25_bf005cf3-4cab-4ac6-985f-ce6385b645cf"+
+			fmt.Sprintf("&serviceDate=%d&time=%d", serviceDate.UnixMilli(), now.UnixMilli()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	entry, ok := data["entry"].(map[string]interface{})
+	require.True(t, ok)
+
+	assert.Equal(t, 1.0, entry["numberOfStopsAway"])
+	assert.Equal(t, predictedFromSchedule, entry["predictedFrom"])
+	assert.Equal(t, false, entry["predicted"])
+}
@@ -1,10 +1,8 @@
 package restapi
 
 import (
-	"fmt"
 	"net/http"
 	"sort"
-	"strings"
 	"time"
 
 	"maglev.onebusaway.org/gtfsdb"
@@ -14,63 +12,35 @@ import (
 )
 
 func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
+	q := newQueryParams(r)
+	lat := q.floatParam("lat", 0)
+	lon := q.floatParam("lon", 0)
+	radius := q.floatParam("radius", 0)
+	latSpan := q.floatParam("latSpan", 0)
+	lonSpan := q.floatParam("lonSpan", 0)
+	wheelchairAccessible := q.boolParam("wheelchairAccessible", false)
+	query := q.values.Get("query")
 
-	lat, fieldErrors := utils.ParseFloatParam(queryParams, "lat", nil)
-	lon, _ := utils.ParseFloatParam(queryParams, "lon", fieldErrors)
-	radius, _ := utils.ParseFloatParam(queryParams, "radius", fieldErrors)
-	latSpan, _ := utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
-	lonSpan, _ := utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
-	maxCount, _ := utils.ParseMaxCount(queryParams, models.DefaultMaxCountForStops, fieldErrors)
-	query := queryParams.Get("query")
-
-	var routeTypes []int
-	if routeTypeStr := queryParams.Get("routeType"); routeTypeStr != "" {
-		routeTypeStrs := strings.Split(routeTypeStr, ",")
-
-		const maxRouteTypeTokens = 100
-
-		if len(routeTypeStrs) > maxRouteTypeTokens {
-			if fieldErrors == nil {
-				fieldErrors = make(map[string][]string)
-			}
-			fieldErrors["routeType"] = []string{
-				fmt.Sprintf("too many route types (maximum %d allowed)", maxRouteTypeTokens),
-			}
-		} else {
-			for _, rtStr := range routeTypeStrs {
-				rtStr = strings.TrimSpace(rtStr)
-				if rtStr != "" {
-					var rt int
-					if _, err := fmt.Sscanf(rtStr, "%d", &rt); err != nil {
-						if fieldErrors == nil {
-							fieldErrors = make(map[string][]string)
-						}
-						if _, exists := fieldErrors["routeType"]; !exists {
-							fieldErrors["routeType"] = []string{
-								`Invalid field value for field "routeType".`,
-							}
-						}
-					} else {
-						routeTypes = append(routeTypes, rt)
-					}
-				}
-			}
-		}
+	queryTime := api.Clock.Now()
+	if t := q.optionalTimeParam("time"); t != nil {
+		// Bin to 15 minutes
+		timeMs := t.UnixMilli()
+		queryTime = time.UnixMilli(timeMs - (timeMs % 900000))
 	}
 
-	queryTime := api.Clock.Now()
+	var maxCount, offset int
+	maxCount, q.fieldErrors = utils.ParseMaxCount(q.values, api.stopSearchMaxCount(), q.fieldErrors)
+	offset, q.fieldErrors = parsePageOffset(r, q.fieldErrors)
 
-	if timeStr := queryParams.Get("time"); timeStr != "" {
-		var timeMs int64
-		if _, err := fmt.Sscanf(timeStr, "%d", &timeMs); err == nil {
-			// Bin to 15 minutes
-			binnedMs := timeMs - (timeMs % 900000)
-			queryTime = time.UnixMilli(binnedMs)
-		}
+	// routeType is the older name of the routeTypes filter.
+	routeTypesKey := "routeTypes"
+	if !q.values.Has(routeTypesKey) {
+		routeTypesKey = "routeType"
 	}
+	var routeTypes []int
+	routeTypes, q.fieldErrors = utils.ParseRouteTypes(q.values, routeTypesKey, q.fieldErrors)
 
-	if len(fieldErrors) > 0 {
+	if fieldErrors := q.errors(); fieldErrors != nil {
 		api.validationErrorResponse(w, r, fieldErrors)
 		return
 	}
@@ -92,6 +62,10 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 	}
 	query = sanitizedQuery
 
+	if radius == 0 && query == "" && (latSpan <= 0 || lonSpan <= 0) {
+		radius = api.stopSearchRadius()
+	}
+
 	ctx := r.Context()
 
 	// Check if context is already cancelled
@@ -103,22 +77,31 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
 
-	stops := api.GtfsManager.GetStopsForLocation(ctx, lat, lon, radius, latSpan, lonSpan, query, maxCount, false, routeTypes, queryTime)
+	// Pages are cut from the stops ordered by distance, or by score for a
+	// query, so each page holds the next-best stops; one extra stop is fetched
+	// to tell whether more follow.
+	stops := api.GtfsManager.GetRankedStopsForLocation(ctx, lat, lon, radius, latSpan, lonSpan, query, offset+maxCount+1, false, routeTypes, wheelchairAccessible, queryTime)
+	start, end, more := pageWindow(len(stops), offset, maxCount)
+	stops = stops[start:end]
+	page := newPage(r, offset, len(stops), more)
 
 	// Referenced Java code: "here we sort by distance for possible truncation, but later it will be re-sorted by stopId"
-	sort.SliceStable(stops, func(i, j int) bool {
-		return stops[i].ID < stops[j].ID
-	})
+	// Query results keep their ranking, which is what the client asked for.
+	if query == "" {
+		sort.SliceStable(stops, func(i, j int) bool {
+			return stops[i].Stop.ID < stops[j].Stop.ID
+		})
+	}
 
 	var results []models.Stop
 	routeIDs := map[string]bool{}
 	agencyIDs := map[string]bool{}
 
 	stopIDs := make([]string, 0, len(stops))
-	stopMap := make(map[string]gtfsdb.Stop)
+	stopMap := make(map[string]gtfs.RankedStop)
 	for _, stop := range stops {
-		stopIDs = append(stopIDs, stop.ID)
-		stopMap[stop.ID] = stop
+		stopIDs = append(stopIDs, stop.Stop.ID)
+		stopMap[stop.Stop.ID] = stop
 	}
 
 	if len(stopIDs) == 0 {
@@ -134,7 +117,7 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 			Trips:      []interface{}{},
 		}
 		response := models.NewListResponseWithRange(results, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, radius), api.Clock, false)
-		api.sendResponse(w, r, response)
+		api.sendResponse(w, r, models.WithPage(response, page))
 		return
 	}
 
@@ -198,8 +181,6 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	isLimitExceeded := false
-
 	calc := gtfs.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
 
 	// Build results using the pre-fetched data
@@ -208,7 +189,8 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		stop := stopMap[stopID]
+		ranked := stopMap[stopID]
+		stop := ranked.Stop
 		rids := stopRouteIDs[stopID]
 		agency := stopAgency[stopID]
 
@@ -218,7 +200,7 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 
 		direction := calc.CalculateStopDirection(ctx, stop.ID, stop.Direction)
 
-		results = append(results, models.NewStop(
+		result := models.NewStop(
 			utils.NullStringOrEmpty(stop.Code),
 			direction,
 			utils.FormCombinedID(agency.ID, stop.ID),
@@ -230,11 +212,9 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 			0,
 			rids,
 			rids,
-		))
-		if len(results) >= maxCount {
-			isLimitExceeded = true
-			break
-		}
+		)
+		result.Score = ranked.Score
+		results = append(results, result)
 	}
 
 	if ctx.Err() != nil {
@@ -253,6 +233,6 @@ func (api *RestAPI) stopsForLocationHandler(w http.ResponseWriter, r *http.Reque
 		Trips:      []interface{}{},
 	}
 
-	response := models.NewListResponseWithRange(results, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, radius), api.Clock, isLimitExceeded)
-	api.sendResponse(w, r, response)
+	response := models.NewListResponseWithRange(results, references, checkIfOutOfBounds(api, lat, lon, latSpan, lonSpan, radius), api.Clock, page.LimitExceeded)
+	api.sendResponse(w, r, models.WithPage(response, page))
 }
@@ -123,6 +123,27 @@ func TestStopsForLocationQuery(t *testing.T) {
 	assert.Equal(t, "Buenaventura Blvd at Eureka Way", stop["name"])
 }
 
+func TestStopsForLocationQueryRankedByScore(t *testing.T) {
+	clock := clock.NewMockClock(time.Date(2025, 6, 13, 14, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, clock)
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&query=Eureka")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	list, ok := model.Data.(map[string]interface{})["list"].([]interface{})
+	require.True(t, ok)
+	require.NotEmpty(t, list)
+
+	previous := 1.0
+	for _, item := range list {
+		stop := item.(map[string]interface{})
+		score, ok := stop["score"].(float64)
+		require.True(t, ok, "every query result has a score")
+		assert.Contains(t, stop["name"], "Eureka")
+		assert.LessOrEqual(t, score, previous, "results are listed best match first")
+		previous = score
+	}
+}
+
 func TestStopsForLocationLatSpanAndLonSpan(t *testing.T) {
 	clock := clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC))
 	api := createTestApiWithClock(t, clock)
@@ -150,6 +171,28 @@ func TestStopsForLocationRadius(t *testing.T) {
 	assert.NotEmpty(t, list)
 }
 
+func TestStopsForLocationConfigDefaults(t *testing.T) {
+	clock := clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC))
+	api := createTestApiWithClock(t, clock)
+
+	list := func(query string) []interface{} {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966"+query)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		list, ok := model.Data.(map[string]interface{})["list"].([]interface{})
+		require.True(t, ok)
+		return list
+	}
+
+	api.Config.StopSearchRadius = 5000
+	wide := list("")
+	assert.Equal(t, len(list("&radius=5000")), len(wide))
+
+	api.Config.StopSearchMaxCount = 2
+	assert.Len(t, list(""), 2)
+	assert.Len(t, list("&maxCount=3"), 3, "the request overrides the configured maxCount")
+}
+
 func TestStopsForLocationLatAndLan(t *testing.T) {
 	clock := clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC))
 	api := createTestApiWithClock(t, clock)
@@ -332,3 +375,47 @@ func TestStopsForLocationHandlerRouteTypeValidMultiple(t *testing.T) {
 	assert.NotNil(t, refs["agencies"])
 	assert.NotNil(t, refs["routes"])
 }
+
+func TestStopsForLocationHandlerRouteTypes(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC)))
+
+	stopCount := func(filter string) int {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/stops-for-location.json?key=TEST&lat=40.583321&lon=-122.426966&radius=2500"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		list, _ := model.Data.(map[string]interface{})["list"].([]interface{})
+		return len(list)
+	}
+
+	all := stopCount("")
+	require.NotZero(t, all)
+	assert.Equal(t, all, stopCount("&routeTypes=bus"), "every RABA route is a bus")
+	assert.Equal(t, all, stopCount("&routeTypes=3,4"))
+	assert.Zero(t, stopCount("&routeTypes=ferry"))
+	assert.Zero(t, stopCount("&routeType=4"), "the singular routeType is still recognized")
+}
+
+func TestStopsForLocationHandlerWheelchairAccessible(t *testing.T) {
+	api := createTestApiWithClock(t, clock.NewMockClock(time.Date(2025, 12, 26, 14, 0, 0, 0, time.UTC)))
+	stops := func(filter string) []interface{} {
+		t.Helper()
+		resp, model := serveApiAndRetrieveEndpoint(t, api,
+			"/api/where/stops-for-location.json?key=TEST&lat=40.583170&lon=-122.392586&radius=1000"+filter)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return model.Data.(map[string]interface{})["list"].([]interface{})
+	}
+
+	all := stops("")
+	require.Greater(t, len(all), 1)
+
+	accessible := stops("&wheelchairAccessible=true")
+	require.Len(t, accessible, 1, "only the downtown terminal is marked accessible nearby")
+	stop := accessible[0].(map[string]interface{})
+	assert.Equal(t, "25_2000", stop["id"])
+	assert.Equal(t, "ACCESSIBLE", stop["wheelchairBoarding"])
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/stops-for-location.json?key=TEST&lat=40.583170&lon=-122.392586&wheelchairAccessible=2")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
-	"github.com/twpayne/go-polyline"
 	"maglev.onebusaway.org/gtfsdb"
 	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
@@ -59,7 +60,7 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 
 	currentAgency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
 		return
 	}
 
@@ -80,9 +81,9 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+	_, err = api.GtfsManager.GtfsDB.GetRoute(ctx, routeID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeRouteNotFound)
 		return
 	}
 
@@ -92,66 +93,10 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// This prevents nil pointer panics and ensures thread-safety.
+	// Stop directions are precomputed at import, so the calculator only reads
+	// the stops' direction column.
 	adc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
 
-	// Get Stop IDs for the route to drive the bulk-loading caches
-	stopIDs, err := api.GtfsManager.GtfsDB.Queries.GetStopIDsForRoute(ctx, routeID)
-	if err == nil && len(stopIDs) > 0 {
-
-		contextRows, err := api.GtfsManager.GtfsDB.Queries.GetStopsWithShapeContextByIDs(ctx, stopIDs)
-		if err != nil {
-			// Log error when bulk context load fails
-			slog.Warn("bulk context cache load failed, falling back to per-stop queries",
-				slog.String("routeID", routeID),
-				slog.String("error", err.Error()))
-		} else {
-			contextCache := make(map[string][]gtfsdb.GetStopsWithShapeContextRow)
-			shapeIDMap := make(map[string]bool)
-			var uniqueShapeIDs []string
-
-			for _, row := range contextRows {
-				calcRow := gtfsdb.GetStopsWithShapeContextRow{
-					ID:                row.StopID,
-					ShapeID:           row.ShapeID,
-					Lat:               row.Lat,
-					Lon:               row.Lon,
-					ShapeDistTraveled: row.ShapeDistTraveled,
-				}
-				contextCache[row.StopID] = append(contextCache[row.StopID], calcRow)
-
-				if row.ShapeID.Valid && row.ShapeID.String != "" && !shapeIDMap[row.ShapeID.String] {
-					shapeIDMap[row.ShapeID.String] = true
-					uniqueShapeIDs = append(uniqueShapeIDs, row.ShapeID.String)
-				}
-			}
-
-			// Fetch Shape Points in bulk to populate the local cache
-			if len(uniqueShapeIDs) > 0 {
-				shapePoints, err := api.GtfsManager.GtfsDB.Queries.GetShapePointsByIDs(ctx, uniqueShapeIDs)
-				if err != nil {
-					// Log error when bulk shape load fails
-					slog.Warn("bulk shape cache load failed, falling back to per-stop queries",
-						slog.String("routeID", routeID),
-						slog.String("error", err.Error()))
-				} else {
-					shapeCache := make(map[string][]gtfsdb.GetShapePointsWithDistanceRow)
-					for _, p := range shapePoints {
-						shapeCache[p.ShapeID] = append(shapeCache[p.ShapeID], gtfsdb.GetShapePointsWithDistanceRow{
-							Lat:               p.Lat,
-							Lon:               p.Lon,
-							ShapeDistTraveled: p.ShapeDistTraveled,
-						})
-					}
-
-					// Inject caches into the LOCAL instance.
-					adc.SetShapeCache(shapeCache)
-					adc.SetContextCache(contextCache)
-				}
-			}
-		}
-	}
-
 	result, stopsList, err := api.processRouteStops(ctx, agencyID, routeID, serviceIDs, params.IncludePolylines, adc)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
@@ -163,6 +108,7 @@ func (api *RestAPI) stopsForRouteHandler(w http.ResponseWriter, r *http.Request)
 
 func (api *RestAPI) processRouteStops(ctx context.Context, agencyID string, routeID string, serviceIDs []string, includePolylines bool, adc *GTFS.AdvancedDirectionCalculator) (models.RouteEntry, []models.Stop, error) {
 	allStops := make(map[string]bool)
+	var allStopIDs []string
 	allPolylines := make([]models.Polyline, 0, 100)
 	var stopGroupings []models.StopGrouping
 
@@ -182,17 +128,17 @@ func (api *RestAPI) processRouteStops(ctx context.Context, agencyID string, rout
 		if err != nil {
 			return models.RouteEntry{}, nil, err
 		}
-		processTripGroups(ctx, api, agencyID, routeID, allTrips, &stopGroupings, allStops, &allPolylines)
+		processTripGroups(ctx, api, agencyID, routeID, allTrips, &stopGroupings, allStops, &allStopIDs, &allPolylines)
 	} else {
 		// Process trips for the current service date
-		processTripGroups(ctx, api, agencyID, routeID, trips, &stopGroupings, allStops, &allPolylines)
+		processTripGroups(ctx, api, agencyID, routeID, trips, &stopGroupings, allStops, &allStopIDs, &allPolylines)
 	}
 
 	if !includePolylines {
 		allPolylines = []models.Polyline{}
 	}
 
-	allStopsIds := formatStopIDs(agencyID, allStops)
+	allStopsIds := formatStopIDs(agencyID, allStopIDs)
 	stopsList, err := buildStopsList(ctx, api, adc, agencyID, allStops)
 	if err != nil {
 		return models.RouteEntry{}, nil, err
@@ -304,6 +250,7 @@ func processTripGroups(
 	trips []gtfsdb.Trip,
 	stopGroupings *[]models.StopGrouping,
 	allStops map[string]bool,
+	allStopIDs *[]string,
 	allPolylines *[]models.Polyline,
 ) {
 	type directionHeadsignKey struct {
@@ -345,15 +292,22 @@ func processTripGroups(
 		})
 
 		representativeTrip := tripsInGroup[0]
-		stopsList, err := api.GtfsManager.GtfsDB.Queries.GetOrderedStopIDsForTrip(ctx, representativeTrip.ID)
+
+		tripIDs := make([]string, len(tripsInGroup))
+		for i, trip := range tripsInGroup {
+			tripIDs[i] = trip.ID
+		}
+		stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTripIDs(ctx, tripIDs)
 		if err != nil {
 			continue
 		}
 
-		stopIDs := make(map[string]bool)
+		stopsList := mergeStopPatterns(stopTimes)
 		for _, stopID := range stopsList {
-			stopIDs[stopID] = true
-			allStops[stopID] = true
+			if !allStops[stopID] {
+				allStops[stopID] = true
+				*allStopIDs = append(*allStopIDs, stopID)
+			}
 		}
 
 		shape, err := api.GtfsManager.GtfsDB.Queries.GetShapesGroupedByTripHeadSign(ctx,
@@ -368,7 +322,7 @@ func processTripGroups(
 		polylines := generatePolylines(shape)
 		*allPolylines = append(*allPolylines, polylines...)
 
-		formattedStopIDs := formatStopIDs(agencyID, stopIDs)
+		formattedStopIDs := formatStopIDs(agencyID, stopsList)
 
 		groupID := fmt.Sprintf("%d", key.DirectionID-1)
 
@@ -402,20 +356,75 @@ func generatePolylines(shapes []gtfsdb.GetShapesGroupedByTripHeadSignRow) []mode
 	for _, shape := range shapes {
 		coords = append(coords, []float64{shape.Lat, shape.Lon})
 	}
-	encodedPoints := polyline.EncodeCoords(coords)
+	encodedPoints := utils.EncodePolyline(coords)
 	polylines = append(polylines, models.Polyline{
 		Length: len(shapes),
 		Levels: "",
-		Points: string(encodedPoints),
+		Points: encodedPoints,
 	})
 	return polylines
 }
 
-func formatStopIDs(agencyID string, stops map[string]bool) []string {
-	var stopIDs []string
-	for key := range stops {
-		stopID := utils.FormCombinedID(agencyID, key)
-		stopIDs = append(stopIDs, stopID)
+func formatStopIDs(agencyID string, stops []string) []string {
+	stopIDs := make([]string, 0, len(stops))
+	for _, stop := range stops {
+		stopIDs = append(stopIDs, utils.FormCombinedID(agencyID, stop))
 	}
 	return stopIDs
 }
+
+// mergeStopPatterns combines the stop sequences of the given trips into a single
+// ordered list. The most common pattern (longest on ties) forms the base, and
+// stops served only by other patterns are inserted after the nearest preceding
+// stop they share with it. stopTimes must be ordered by trip and stop_sequence.
+func mergeStopPatterns(stopTimes []gtfsdb.StopTime) []string {
+	type pattern struct {
+		stops []string
+		count int
+		first int
+	}
+
+	var patterns []*pattern
+	byKey := make(map[string]*pattern)
+	for start := 0; start < len(stopTimes); {
+		end := start
+		var stops []string
+		for end < len(stopTimes) && stopTimes[end].TripID == stopTimes[start].TripID {
+			stops = append(stops, stopTimes[end].StopID)
+			end++
+		}
+
+		key := strings.Join(stops, "\x00")
+		if p, ok := byKey[key]; ok {
+			p.count++
+		} else {
+			p = &pattern{stops: stops, count: 1, first: len(patterns)}
+			byKey[key] = p
+			patterns = append(patterns, p)
+		}
+		start = end
+	}
+
+	sort.SliceStable(patterns, func(i, j int) bool {
+		if patterns[i].count != patterns[j].count {
+			return patterns[i].count > patterns[j].count
+		}
+		return len(patterns[i].stops) > len(patterns[j].stops)
+	})
+
+	var merged []string
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		insertAt := 0
+		for _, stopID := range p.stops {
+			if seen[stopID] {
+				insertAt = slices.Index(merged, stopID) + 1
+				continue
+			}
+			merged = slices.Insert(merged, insertAt, stopID)
+			seen[stopID] = true
+			insertAt++
+		}
+	}
+	return merged
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
 )
 
 func TestStopsForRouteHandlerEndToEnd(t *testing.T) {
@@ -77,6 +78,8 @@ func TestStopsForRouteHandlerEndToEnd(t *testing.T) {
 
 	// With deterministic sorting, checks should be consistent
 	assert.Equal(t, 21, len(inboundStopIds))
+	assert.Equal(t, "25_2000", inboundStopIds[0])
+	assert.Equal(t, "25_1030", inboundStopIds[len(inboundStopIds)-1])
 
 	inboundPolylines, ok := inboundGroup["polylines"].([]interface{})
 	require.True(t, ok)
@@ -96,6 +99,11 @@ func TestStopsForRouteHandlerEndToEnd(t *testing.T) {
 	require.True(t, ok)
 	// With deterministic sorting, checks should be consistent
 	assert.Equal(t, 22, len(outboundStopIds))
+	assert.Equal(t, []interface{}{"25_1030", "25_1031", "25_1032"}, outboundStopIds[:3])
+	assert.Equal(t, "25_2000", outboundStopIds[len(outboundStopIds)-1])
+
+	// Route-level stop IDs follow the group order.
+	assert.Equal(t, outboundStopIds, stopIds[:len(outboundStopIds)])
 
 	// Verify references
 	refs, ok := data["references"].(map[string]interface{})
@@ -183,3 +191,32 @@ func TestStopsForRouteHandlerWithMalformedID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Status code should be 400 Bad Request")
 }
+
+func TestMergeStopPatterns(t *testing.T) {
+	stopTimes := func(tripID string, stops ...string) []gtfsdb.StopTime {
+		rows := make([]gtfsdb.StopTime, len(stops))
+		for i, stop := range stops {
+			rows[i] = gtfsdb.StopTime{TripID: tripID, StopID: stop, StopSequence: int64(i)}
+		}
+		return rows
+	}
+
+	t.Run("single pattern keeps trip order", func(t *testing.T) {
+		rows := stopTimes("t1", "C", "A", "B")
+		assert.Equal(t, []string{"C", "A", "B"}, mergeStopPatterns(rows))
+	})
+
+	t.Run("short turns and branches are merged", func(t *testing.T) {
+		var rows []gtfsdb.StopTime
+		rows = append(rows, stopTimes("t1", "A", "B", "C", "D")...)
+		rows = append(rows, stopTimes("t2", "A", "B", "C", "D")...)
+		rows = append(rows, stopTimes("t3", "B", "X", "D", "E")...)
+		rows = append(rows, stopTimes("t4", "Z", "A", "B")...)
+
+		assert.Equal(t, []string{"Z", "A", "B", "X", "C", "D", "E"}, mergeStopPatterns(rows))
+	})
+
+	t.Run("no stop times", func(t *testing.T) {
+		assert.Empty(t, mergeStopPatterns(nil))
+	})
+}
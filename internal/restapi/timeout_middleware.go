@@ -0,0 +1,105 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// defaultRequestTimeout bounds requests when no timeout is configured. It is
+// shorter than the server's 10 second write timeout, so the 503 still reaches
+// the client.
+const defaultRequestTimeout = 8 * time.Second
+
+// requestTimeout is how long a request may run before it is answered with a 503.
+func (api *RestAPI) requestTimeout() time.Duration {
+	if api.Application == nil || api.Config.RequestTimeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return api.Config.RequestTimeout
+}
+
+// RequestTimeoutMiddleware gives every request a context that expires after the
+// configured request timeout. Handlers pass that context to their queries, which
+// abort once it expires; a handler that then gives up without writing a
+// response is answered with a 503 timeout error. Event streams are long-lived
+// by design and are left without a deadline, including those of regions.
+func (api *RestAPI) RequestTimeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isEventStreamPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), api.requestTimeout())
+		defer cancel()
+
+		dw := &deadlineWriter{ResponseWriter: w}
+		next.ServeHTTP(dw, r.WithContext(ctx))
+
+		if !dw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			api.timeoutResponse(w, r)
+		}
+	})
+}
+
+// isEventStreamPath reports whether path is an event stream route, served
+// either at the top level or under /regions/{id}/.
+func isEventStreamPath(path string) bool {
+	if rest, ok := strings.CutPrefix(path, regionPathPrefix); ok {
+		_, path, ok = strings.Cut(rest, "/")
+		if !ok {
+			return false
+		}
+		path = "/" + path
+	}
+	return strings.HasPrefix(path, "/api/stream/")
+}
+
+// deadlineWriter records whether the handler started a response.
+type deadlineWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// timeoutResponse sends a 503 Service Unavailable response for a request that
+// ran past its deadline.
+func (api *RestAPI) timeoutResponse(w http.ResponseWriter, r *http.Request) {
+	api.Logger.Warn("request timed out", "path", r.URL.Path, "timeout", api.requestTimeout())
+	response := struct {
+		Code        int    `json:"code"`
+		CurrentTime int64  `json:"currentTime"`
+		ErrorCode   string `json:"errorCode"`
+		Text        string `json:"text"`
+		Version     int    `json:"version"`
+	}{
+		Code:        http.StatusServiceUnavailable,
+		CurrentTime: models.ResponseCurrentTime(api.Clock),
+		ErrorCode:   string(errCodeTimeout),
+		Text:        "timeout",
+		Version:     1,
+	}
+
+	if err := api.writeResponse(w, r, http.StatusServiceUnavailable, response); err != nil {
+		api.Logger.Error("failed to encode timeout response", "error", err)
+	}
+}
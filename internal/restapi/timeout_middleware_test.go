@@ -0,0 +1,115 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeoutMiddleware(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.RequestTimeout = 20 * time.Millisecond
+
+	t.Run("handler that gives up gets a 503", func(t *testing.T) {
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/where/stop/1_1.json", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var body struct {
+			Code int    `json:"code"`
+			Text string `json:"text"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusServiceUnavailable, body.Code)
+		assert.Equal(t, "timeout", body.Text)
+	})
+
+	t.Run("query errors from the deadline become a 503", func(t *testing.T) {
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := api.GtfsManager.GtfsDB.DB.ExecContext(r.Context(),
+				"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT count(*) FROM n")
+			api.serverErrorResponse(w, r, err)
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/where/stop/1_1.json", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("fast handlers are unaffected", func(t *testing.T) {
+		var deadline time.Time
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, _ = r.Context().Deadline()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/where/stop/1_1.json", nil))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, 20*time.Millisecond)
+	})
+
+	t.Run("event streams have no deadline", func(t *testing.T) {
+		var hasDeadline bool
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/stream/vehicles", nil))
+
+		assert.False(t, hasDeadline)
+	})
+
+	t.Run("region event streams stay open past the timeout", func(t *testing.T) {
+		var ctxErr error
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * api.Config.RequestTimeout):
+			}
+			ctxErr = r.Context().Err()
+			w.WriteHeader(http.StatusOK)
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/regions/west/api/stream/vehicles", nil))
+
+		assert.NoError(t, ctxErr)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("other region routes keep their deadline", func(t *testing.T) {
+		var hasDeadline bool
+		handler := api.RequestTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/regions/west/api/where/stop/1_1.json", nil))
+
+		assert.True(t, hasDeadline)
+	})
+}
+
+func TestServerErrorResponseReportsTimeouts(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	rec := httptest.NewRecorder()
+	api.serverErrorResponse(rec, httptest.NewRequest("GET", "/api/where/stop/1_1.json", nil), ctx.Err())
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	api.serverErrorResponse(rec, httptest.NewRequest("GET", "/api/where/stop/1_1.json", nil), context.Canceled)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -0,0 +1,190 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// responseNames gathers copies of the stops and routes of a response so their
+// names can be translated without touching models shared with caches.
+type responseNames struct {
+	stops  []*models.Stop
+	routes []*models.Route
+	apply  []func() // Store the translated copies back into the response
+}
+
+// translateNames translates the names of the stops and routes of a response,
+// as listed or as references, from translations.txt. The language is the lang
+// parameter, or else the feed's default_lang; names stay in the feed language
+// when neither is given, when it is the feed language, or when the feed has no
+// translation.
+func (api *RestAPI) translateNames(r *http.Request, response *models.ResponseModel) {
+	data, ok := response.Data.(map[string]interface{})
+	if !ok || !hasNames(data) || api.GtfsManager == nil || api.GtfsManager.GtfsDB == nil {
+		return
+	}
+
+	ctx := r.Context()
+	language := api.responseLanguage(ctx, r)
+	if language == "" {
+		return
+	}
+
+	var names responseNames
+	for key, value := range data {
+		names.collect(data, key, value)
+	}
+
+	stopIDs := make([]string, 0, len(names.stops))
+	for _, stop := range names.stops {
+		stopIDs = append(stopIDs, rawID(stop.ID))
+	}
+	routeIDs := make([]string, 0, len(names.routes))
+	for _, route := range names.routes {
+		routeIDs = append(routeIDs, rawID(route.ID))
+	}
+
+	db := api.GtfsManager.GtfsDB
+	stopTranslator, err := db.LoadTranslator(ctx, "stops", language, stopIDs)
+	if err != nil {
+		api.Logger.Warn("failed to load stop translations", "language", language, "error", err)
+	}
+	routeTranslator, err := db.LoadTranslator(ctx, "routes", language, routeIDs)
+	if err != nil {
+		api.Logger.Warn("failed to load route translations", "language", language, "error", err)
+	}
+	if stopTranslator == nil && routeTranslator == nil {
+		return
+	}
+
+	for i, stop := range names.stops {
+		stop.Name = stopTranslator.Translate("stop_name", stopIDs[i], stop.Name)
+	}
+	for i, route := range names.routes {
+		route.ShortName = routeTranslator.Translate("route_short_name", routeIDs[i], route.ShortName)
+		route.LongName = routeTranslator.Translate("route_long_name", routeIDs[i], route.LongName)
+		route.Description = routeTranslator.Translate("route_desc", routeIDs[i], route.Description)
+		route.NullSafeShortName = route.ShortName
+		if route.NullSafeShortName == "" {
+			route.NullSafeShortName = route.LongName
+		}
+	}
+	for _, apply := range names.apply {
+		apply()
+	}
+}
+
+// responseLanguage returns the language names should be given in, or "" to
+// leave them in the feed language.
+func (api *RestAPI) responseLanguage(ctx context.Context, r *http.Request) string {
+	language := r.URL.Query().Get("lang")
+	feedInfo, err := api.GtfsManager.GtfsDB.Queries.GetFeedInfo(ctx)
+	if err != nil {
+		// Without feed_info.txt there is no default language to fall back to.
+		return language
+	}
+	if language == "" {
+		language = feedInfo.DefaultLang.String
+	}
+	if strings.EqualFold(language, feedInfo.FeedLang) {
+		return ""
+	}
+	return language
+}
+
+// hasNames reports whether response data holds any stop or route.
+func hasNames(data map[string]interface{}) bool {
+	for _, value := range data {
+		switch v := value.(type) {
+		case models.ReferencesModel:
+			if len(v.Stops) > 0 || len(v.Routes) > 0 {
+				return true
+			}
+		case models.Stop, models.Route:
+			return true
+		case *models.Stop:
+			return v != nil
+		case *models.Route:
+			return v != nil
+		case []models.Stop:
+			if len(v) > 0 {
+				return true
+			}
+		case []models.Route:
+			if len(v) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collect copies the stops and routes of one value of the response data.
+func (n *responseNames) collect(data map[string]interface{}, key string, value interface{}) {
+	switch v := value.(type) {
+	case models.ReferencesModel:
+		stops := make([]models.Stop, len(v.Stops))
+		copy(stops, v.Stops)
+		for i := range stops {
+			n.stops = append(n.stops, &stops[i])
+		}
+		routes := make([]interface{}, len(v.Routes))
+		copy(routes, v.Routes)
+		for i, ref := range routes {
+			if route, ok := ref.(models.Route); ok {
+				n.addRoute(route, func(translated models.Route) { routes[i] = translated })
+			}
+		}
+		v.Stops, v.Routes = stops, routes
+		data[key] = v
+	case models.Stop:
+		n.stops = append(n.stops, &v)
+		n.apply = append(n.apply, func() { data[key] = v })
+	case models.Route:
+		n.addRoute(v, func(translated models.Route) { data[key] = translated })
+	case *models.Stop:
+		if v != nil {
+			stop := *v
+			n.stops = append(n.stops, &stop)
+			data[key] = &stop
+		}
+	case *models.Route:
+		if v != nil {
+			route := *v
+			n.routes = append(n.routes, &route)
+			data[key] = &route
+		}
+	case []models.Stop:
+		stops := make([]models.Stop, len(v))
+		copy(stops, v)
+		for i := range stops {
+			n.stops = append(n.stops, &stops[i])
+		}
+		data[key] = stops
+	case []models.Route:
+		routes := make([]models.Route, len(v))
+		copy(routes, v)
+		for i := range routes {
+			n.routes = append(n.routes, &routes[i])
+		}
+		data[key] = routes
+	}
+}
+
+func (n *responseNames) addRoute(route models.Route, store func(models.Route)) {
+	copied := route
+	n.routes = append(n.routes, &copied)
+	n.apply = append(n.apply, func() { store(copied) })
+}
+
+// rawID returns the feed ID of a combined agency and entity ID.
+func rawID(combinedID string) string {
+	if id, err := utils.ExtractCodeID(combinedID); err == nil {
+		return id
+	}
+	return combinedID
+}
@@ -0,0 +1,80 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestStopHandlerTranslatesNames(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	queries := api.GtfsManager.GtfsDB.Queries
+	t.Cleanup(func() { _ = queries.ClearTranslations(ctx) })
+
+	agencies := api.GtfsManager.GetAgencies()
+	stops := api.GtfsManager.GetStops()
+	require.NotEmpty(t, stops)
+	stopID := utils.FormCombinedID(agencies[0].Id, stops[0].Id)
+
+	_, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=TEST")
+	data := model.Data.(map[string]interface{})
+	routeIDs := data["entry"].(map[string]interface{})["routeIds"].([]interface{})
+	require.NotEmpty(t, routeIDs)
+	routeID, err := utils.ExtractCodeID(routeIDs[0].(string))
+	require.NoError(t, err)
+
+	require.NoError(t, queries.CreateTranslation(ctx, gtfsdb.CreateTranslationParams{
+		TableName:   "stops",
+		FieldName:   "stop_name",
+		Language:    "es",
+		Translation: "Parada traducida",
+		RecordID:    sql.NullString{String: stops[0].Id, Valid: true},
+	}))
+	require.NoError(t, queries.CreateTranslation(ctx, gtfsdb.CreateTranslationParams{
+		TableName:   "routes",
+		FieldName:   "route_long_name",
+		Language:    "es",
+		Translation: "Ruta traducida",
+		RecordID:    sql.NullString{String: routeID, Valid: true},
+	}))
+
+	routeLongName := func(data map[string]interface{}) string {
+		references := data["references"].(map[string]interface{})
+		for _, ref := range references["routes"].([]interface{}) {
+			route := ref.(map[string]interface{})
+			if route["id"] == routeIDs[0] {
+				return route["longName"].(string)
+			}
+		}
+		return ""
+	}
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=TEST&lang=es")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	data = model.Data.(map[string]interface{})
+	assert.Equal(t, "Parada traducida", data["entry"].(map[string]interface{})["name"])
+	assert.Equal(t, "Ruta traducida", routeLongName(data))
+
+	// Regional variants fall back to the primary language.
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=TEST&lang=es-MX")
+	data = model.Data.(map[string]interface{})
+	assert.Equal(t, "Parada traducida", data["entry"].(map[string]interface{})["name"])
+
+	// Without lang, in the feed language or in a language without translations,
+	// names are untranslated, even after a translated response was served.
+	for _, query := range []string{"", "&lang=en", "&lang=de"} {
+		_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/stop/"+stopID+".json?key=test"+query)
+		data = model.Data.(map[string]interface{})
+		assert.Equal(t, stops[0].Name, data["entry"].(map[string]interface{})["name"], query)
+		assert.NotEqual(t, "Ruta traducida", routeLongName(data), query)
+	}
+}
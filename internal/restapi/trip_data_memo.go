@@ -0,0 +1,200 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// tripDataMemo caches the static GTFS rows read while building trip statuses so
+// that a request which builds many of them (arrivals-and-departures builds one per
+// arrival) queries each trip, route, agency, service date and block only once. It
+// also keeps each trip's stop times placed along its shape, which several
+// distances of a trip status are measured from.
+//
+// A memo is scoped to a single request: it is attached to the request context with
+// withTripDataMemo and read back with tripDataMemoFromContext. Every method is safe
+// on a nil memo and then simply queries the database. Returned slices are shared
+// between callers and must not be modified.
+type tripDataMemo struct {
+	mu         sync.Mutex
+	trips      map[string]memoEntry[gtfsdb.Trip]
+	routes     map[string]memoEntry[gtfsdb.Route]
+	locations  map[string]memoEntry[*time.Location]
+	stopTimes  map[string]memoEntry[[]gtfsdb.StopTime]
+	serviceIDs map[string]memoEntry[[]string]
+	blockTrips map[blockTripsKey]memoEntry[[]gtfsdb.GetTripsByBlockIDOrderedRow]
+	blockStops map[blockTripsKey]memoEntry[[]blockTripStops]
+	placements map[string]memoEntry[*tripShapePlacement]
+}
+
+type memoEntry[T any] struct {
+	value T
+	err   error
+}
+
+// blockTripsKey identifies an ordered block lookup. serviceIDs is the
+// NUL-joined list of service IDs the lookup was filtered by.
+type blockTripsKey struct {
+	blockID    string
+	serviceIDs string
+}
+
+type tripDataMemoKey struct{}
+
+func newTripDataMemo() *tripDataMemo {
+	return &tripDataMemo{
+		trips:      make(map[string]memoEntry[gtfsdb.Trip]),
+		routes:     make(map[string]memoEntry[gtfsdb.Route]),
+		locations:  make(map[string]memoEntry[*time.Location]),
+		stopTimes:  make(map[string]memoEntry[[]gtfsdb.StopTime]),
+		serviceIDs: make(map[string]memoEntry[[]string]),
+		blockTrips: make(map[blockTripsKey]memoEntry[[]gtfsdb.GetTripsByBlockIDOrderedRow]),
+		blockStops: make(map[blockTripsKey]memoEntry[[]blockTripStops]),
+		placements: make(map[string]memoEntry[*tripShapePlacement]),
+	}
+}
+
+// withTripDataMemo returns a context carrying a trip data memo, reusing the one
+// already attached to ctx if there is one.
+func withTripDataMemo(ctx context.Context) context.Context {
+	if tripDataMemoFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tripDataMemoKey{}, newTripDataMemo())
+}
+
+// tripDataMemoFromContext returns the memo attached to ctx, or nil.
+func tripDataMemoFromContext(ctx context.Context) *tripDataMemo {
+	memo, _ := ctx.Value(tripDataMemoKey{}).(*tripDataMemo)
+	return memo
+}
+
+// memoize returns the cached result for key, calling fetch on a miss. Results are
+// cached when the query succeeded or found no rows; other errors (including a
+// cancelled context) are returned without being remembered.
+func memoize[K comparable, T any](mu *sync.Mutex, cache map[K]memoEntry[T], key K, fetch func() (T, error)) (T, error) {
+	mu.Lock()
+	entry, ok := cache[key]
+	mu.Unlock()
+	if ok {
+		return entry.value, entry.err
+	}
+
+	value, err := fetch()
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		mu.Lock()
+		cache[key] = memoEntry[T]{value: value, err: err}
+		mu.Unlock()
+	}
+	return value, err
+}
+
+func (m *tripDataMemo) trip(ctx context.Context, db *gtfsdb.Client, tripID string) (gtfsdb.Trip, error) {
+	fetch := func() (gtfsdb.Trip, error) { return db.GetTrip(ctx, tripID) }
+	if m == nil {
+		return fetch()
+	}
+	return memoize(&m.mu, m.trips, tripID, fetch)
+}
+
+func (m *tripDataMemo) route(ctx context.Context, db *gtfsdb.Client, routeID string) (gtfsdb.Route, error) {
+	fetch := func() (gtfsdb.Route, error) { return db.GetRoute(ctx, routeID) }
+	if m == nil {
+		return fetch()
+	}
+	return memoize(&m.mu, m.routes, routeID, fetch)
+}
+
+// agencyLocation returns the timezone of the agency.
+func (m *tripDataMemo) agencyLocation(ctx context.Context, q *gtfsdb.Queries, agencyID string) (*time.Location, error) {
+	fetch := func() (*time.Location, error) {
+		agency, err := q.GetAgency(ctx, agencyID)
+		if err != nil {
+			return nil, err
+		}
+		return utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID), nil
+	}
+	if m == nil {
+		return fetch()
+	}
+	return memoize(&m.mu, m.locations, agencyID, fetch)
+}
+
+func (m *tripDataMemo) stopTimesForTrip(ctx context.Context, q *gtfsdb.Queries, tripID string) ([]gtfsdb.StopTime, error) {
+	fetch := func() ([]gtfsdb.StopTime, error) { return q.GetStopTimesForTrip(ctx, tripID) }
+	if m == nil {
+		return fetch()
+	}
+	return memoize(&m.mu, m.stopTimes, tripID, fetch)
+}
+
+func (m *tripDataMemo) activeServiceIDs(ctx context.Context, q *gtfsdb.Queries, serviceDate time.Time) ([]string, error) {
+	formattedDate := serviceDate.Format("20060102")
+	fetch := func() ([]string, error) { return q.GetActiveServiceIDsForDate(ctx, formattedDate) }
+	if m == nil {
+		return fetch()
+	}
+	return memoize(&m.mu, m.serviceIDs, formattedDate, fetch)
+}
+
+func (m *tripDataMemo) orderedBlockTrips(ctx context.Context, q *gtfsdb.Queries, blockID sql.NullString, serviceIDs []string) ([]gtfsdb.GetTripsByBlockIDOrderedRow, error) {
+	fetch := func() ([]gtfsdb.GetTripsByBlockIDOrderedRow, error) {
+		return q.GetTripsByBlockIDOrdered(ctx, gtfsdb.GetTripsByBlockIDOrderedParams{
+			BlockID:    blockID,
+			ServiceIds: serviceIDs,
+		})
+	}
+	if m == nil {
+		return fetch()
+	}
+	key := blockTripsKey{blockID: blockID.String, serviceIDs: strings.Join(serviceIDs, "\x00")}
+	return memoize(&m.mu, m.blockTrips, key, fetch)
+}
+
+// blockStopTimes returns the block's trips on the service IDs in block order,
+// each with its stop times and precomputed distances. The stop times are also
+// remembered for their trips, so placing those along their shapes does not
+// query them again.
+func (m *tripDataMemo) blockStopTimes(ctx context.Context, q *gtfsdb.Queries, blockID sql.NullString, serviceIDs []string) ([]blockTripStops, error) {
+	fetch := func() ([]blockTripStops, error) {
+		rows, err := q.GetBlockStopTimesWithShapeLengths(ctx, gtfsdb.GetBlockStopTimesWithShapeLengthsParams{
+			BlockID:    blockID,
+			ServiceIds: serviceIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		trips := groupBlockStopTimes(rows)
+		if m != nil {
+			m.mu.Lock()
+			for _, trip := range trips {
+				if _, ok := m.stopTimes[trip.tripID]; !ok {
+					m.stopTimes[trip.tripID] = memoEntry[[]gtfsdb.StopTime]{value: trip.stopTimes}
+				}
+			}
+			m.mu.Unlock()
+		}
+		return trips, nil
+	}
+	if m == nil {
+		return fetch()
+	}
+	key := blockTripsKey{blockID: blockID.String, serviceIDs: strings.Join(serviceIDs, "\x00")}
+	return memoize(&m.mu, m.blockStops, key, fetch)
+}
+
+// shapePlacement returns the trip's stop times placed along its shape, calling
+// place on a miss.
+func (m *tripDataMemo) shapePlacement(tripID string, place func() (*tripShapePlacement, error)) (*tripShapePlacement, error) {
+	if m == nil {
+		return place()
+	}
+	return memoize(&m.mu, m.placements, tripID, place)
+}
@@ -0,0 +1,89 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTripDataMemoReusesAttachedMemo(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, tripDataMemoFromContext(ctx))
+
+	ctx = withTripDataMemo(ctx)
+	memo := tripDataMemoFromContext(ctx)
+	require.NotNil(t, memo)
+	assert.Same(t, memo, tripDataMemoFromContext(withTripDataMemo(ctx)))
+}
+
+func TestTripDataMemoCachesLookups(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	queries := api.GtfsManager.GtfsDB.Queries
+
+	ctx := withTripDataMemo(context.Background())
+	memo := tripDataMemoFromContext(ctx)
+
+	trips := api.GtfsManager.GetTrips()
+	require.NotEmpty(t, trips)
+	tripID := trips[0].ID
+
+	first, err := memo.stopTimesForTrip(ctx, queries, tripID)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	second, err := memo.stopTimesForTrip(ctx, queries, tripID)
+	require.NoError(t, err)
+	assert.Same(t, &first[0], &second[0], "second lookup should be served from the memo")
+
+	trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, tripID)
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	serviceIDs, err := memo.activeServiceIDs(ctx, queries, serviceDate)
+	require.NoError(t, err)
+	require.NotEmpty(t, serviceIDs)
+
+	if trip.BlockID.Valid {
+		blockTrips, err := memo.orderedBlockTrips(ctx, queries, trip.BlockID, serviceIDs)
+		require.NoError(t, err)
+		again, err := memo.orderedBlockTrips(ctx, queries, trip.BlockID, serviceIDs)
+		require.NoError(t, err)
+		assert.Equal(t, blockTrips, again)
+		assert.Len(t, memo.blockTrips, 1)
+	}
+
+	// Missing rows are remembered so repeated misses do not query again.
+	_, err = memo.trip(ctx, api.GtfsManager.GtfsDB, "no-such-trip")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Contains(t, memo.trips, "no-such-trip")
+}
+
+func TestTripDataMemoDoesNotCacheCancellation(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	queries := api.GtfsManager.GtfsDB.Queries
+
+	memo := newTripDataMemo()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := memo.stopTimesForTrip(cancelled, queries, "any-trip")
+	require.Error(t, err)
+	assert.Empty(t, memo.stopTimes)
+}
+
+func TestTripDataMemoNilFallsBackToQueries(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	trips := api.GtfsManager.GetTrips()
+	require.NotEmpty(t, trips)
+
+	var memo *tripDataMemo
+	trip, err := memo.trip(context.Background(), api.GtfsManager.GtfsDB, trips[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, trips[0].ID, trip.ID)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
 
 	"maglev.onebusaway.org/gtfsdb"
@@ -27,63 +26,15 @@ type TripParams struct {
 // includeScheduleDefault controls the default value of IncludeSchedule when the
 // parameter is not present in the request (true for trip-details, false for trip-for-vehicle).
 func (api *RestAPI) parseTripParams(r *http.Request, includeScheduleDefault bool) (TripParams, map[string][]string) {
+	q := newQueryParams(r)
 	params := TripParams{
-		IncludeTrip:     true,
-		IncludeSchedule: includeScheduleDefault,
-		IncludeStatus:   true,
+		ServiceDate:     q.optionalTimeParam("serviceDate"),
+		IncludeTrip:     q.boolParam("includeTrip", true),
+		IncludeSchedule: q.boolParam("includeSchedule", includeScheduleDefault),
+		IncludeStatus:   q.boolParam("includeStatus", true),
+		Time:            q.optionalTimeParam("time"),
 	}
-
-	fieldErrors := make(map[string][]string)
-
-	// Validate serviceDate
-	if serviceDateStr := r.URL.Query().Get("serviceDate"); serviceDateStr != "" {
-		if serviceDateMs, err := strconv.ParseInt(serviceDateStr, 10, 64); err == nil {
-			serviceDate := time.Unix(serviceDateMs/1000, 0)
-			params.ServiceDate = &serviceDate
-		} else {
-			fieldErrors["serviceDate"] = []string{"must be a valid Unix timestamp in milliseconds"}
-		}
-	}
-
-	if includeTripStr := r.URL.Query().Get("includeTrip"); includeTripStr != "" {
-		if val, err := strconv.ParseBool(includeTripStr); err == nil {
-			params.IncludeTrip = val
-		} else {
-			fieldErrors["includeTrip"] = []string{"must be a boolean value (true/false)"}
-		}
-	}
-
-	if includeScheduleStr := r.URL.Query().Get("includeSchedule"); includeScheduleStr != "" {
-		if val, err := strconv.ParseBool(includeScheduleStr); err == nil {
-			params.IncludeSchedule = val
-		} else {
-			fieldErrors["includeSchedule"] = []string{"must be a boolean value (true/false)"}
-		}
-	}
-
-	if includeStatusStr := r.URL.Query().Get("includeStatus"); includeStatusStr != "" {
-		if val, err := strconv.ParseBool(includeStatusStr); err == nil {
-			params.IncludeStatus = val
-		} else {
-			fieldErrors["includeStatus"] = []string{"must be a boolean value (true/false)"}
-		}
-	}
-
-	// Validate time
-	if timeStr := r.URL.Query().Get("time"); timeStr != "" {
-		if timeMs, err := strconv.ParseInt(timeStr, 10, 64); err == nil {
-			timeParam := time.Unix(timeMs/1000, 0)
-			params.Time = &timeParam
-		} else {
-			fieldErrors["time"] = []string{"must be a valid Unix timestamp in milliseconds"}
-		}
-	}
-
-	if len(fieldErrors) > 0 {
-		return params, fieldErrors
-	}
-
-	return params, nil
+	return params, q.errors()
 }
 
 func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
@@ -103,13 +54,13 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
 		return
 	}
 
-	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, trip.RouteID)
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, trip.RouteID)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -130,6 +81,10 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		currentTime = api.Clock.Now().In(loc)
 	}
 
+	if params.ServiceDate == nil {
+		serviceDate := api.serviceDateForTrip(ctx, trip, currentTime)
+		params.ServiceDate = &serviceDate
+	}
 	serviceDate, serviceDateMillis := utils.ServiceDateMillis(params.ServiceDate, currentTime)
 
 	var schedule *models.Schedule
@@ -167,11 +122,12 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		TripID:       utils.FormCombinedID(agencyID, trip.ID),
 		ServiceDate:  serviceDateMillis,
 		Schedule:     schedule,
-		Frequency:    nil,
+		Frequency:    api.getTripFrequency(ctx, trip.ID, serviceDate, currentTime),
 		SituationIDs: situationsIDs,
 	}
 
 	if status != nil {
+		status.Frequency = tripDetails.Frequency
 		tripDetails.Status = status
 	}
 
@@ -225,7 +181,7 @@ func (api *RestAPI) tripDetailsHandler(w http.ResponseWriter, r *http.Request) {
 	if len(situationsIDs) > 0 {
 		alerts := api.GtfsManager.GetAlertsForTrip(r.Context(), tripID)
 		if len(alerts) > 0 {
-			situations := api.BuildSituationReferences(alerts, agencyID)
+			situations := api.BuildSituationReferences(alerts, agencyID, r.URL.Query().Get("lang"))
 			for _, situation := range situations {
 				references.Situations = append(references.Situations, situation)
 			}
@@ -275,12 +231,12 @@ func (api *RestAPI) buildReferencedTrips(ctx context.Context, agencyID string, t
 			continue
 		}
 
-		refTrip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, refTripID)
+		refTrip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, refTripID)
 		if err != nil {
 			continue
 		}
 
-		refRoute, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, refTrip.RouteID)
+		refRoute, err := api.GtfsManager.GtfsDB.GetRoute(ctx, refTrip.RouteID)
 		if err != nil {
 			continue
 		}
@@ -302,6 +258,9 @@ func (api *RestAPI) buildReferencedTrips(ctx context.Context, agencyID string, t
 			RouteShortName: refRoute.ShortName.String,
 			TimeZone:       "",
 			PeakOffPeak:    0,
+
+			WheelchairAccessible: utils.MapWheelchairAccessible(refTrip.WheelchairAccessible),
+			BikesAllowed:         utils.MapBikesAllowed(refTrip.BikesAllowed),
 		}
 
 		referencedTrips = append(referencedTrips, refTripModel)
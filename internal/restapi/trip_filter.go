@@ -0,0 +1,85 @@
+package restapi
+
+import (
+	"context"
+
+	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// tripWheelchairAccessible reports whether the trip's GTFS wheelchair_accessible
+// says a wheelchair can board.
+func tripWheelchairAccessible(trip gtfsdb.Trip) bool {
+	return utils.NullWheelchairBoardingOrUnknown(trip.WheelchairAccessible) == gtfs.WheelchairBoarding_Possible
+}
+
+// tripAllowsBikes reports whether the trip's GTFS bikes_allowed says bicycles
+// may be taken on board.
+func tripAllowsBikes(trip gtfsdb.Trip) bool {
+	return trip.BikesAllowed.Valid && gtfs.BikesAllowed(trip.BikesAllowed.Int64) == gtfs.BikesAllowed_Allowed
+}
+
+// filterStopTimesByTrip keeps the stop times whose static trip satisfies keep.
+// Trips added by the realtime feeds have no static record to judge and are
+// dropped.
+func (api *RestAPI) filterStopTimesByTrip(ctx context.Context, stopTimes []activeStopTime, keep func(gtfsdb.Trip) bool) ([]activeStopTime, error) {
+	if len(stopTimes) == 0 {
+		return stopTimes, nil
+	}
+
+	tripIDs := make([]string, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		if st.Added == nil {
+			tripIDs = append(tripIDs, st.TripID)
+		}
+	}
+	kept, err := api.tripsMatching(ctx, tripIDs, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]activeStopTime, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		if st.Added == nil && kept[st.TripID] {
+			filtered = append(filtered, st)
+		}
+	}
+	return filtered, nil
+}
+
+// filterTripIDs keeps the IDs of the trips that satisfy keep, in order.
+func (api *RestAPI) filterTripIDs(ctx context.Context, tripIDs []string, keep func(gtfsdb.Trip) bool) ([]string, error) {
+	kept, err := api.tripsMatching(ctx, tripIDs, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]string, 0, len(kept))
+	for _, tripID := range tripIDs {
+		if kept[tripID] {
+			filtered = append(filtered, tripID)
+		}
+	}
+	return filtered, nil
+}
+
+// tripsMatching looks up the trips and returns the set of IDs of those that
+// satisfy keep. Unknown trips are left out.
+func (api *RestAPI) tripsMatching(ctx context.Context, tripIDs []string, keep func(gtfsdb.Trip) bool) (map[string]bool, error) {
+	kept := make(map[string]bool)
+	if len(tripIDs) == 0 {
+		return kept, nil
+	}
+
+	trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByIDs(ctx, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, trip := range trips {
+		if keep(trip) {
+			kept[trip.ID] = true
+		}
+	}
+	return kept, nil
+}
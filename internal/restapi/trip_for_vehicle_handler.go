@@ -24,7 +24,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 	vehicle, err := api.GtfsManager.GetVehicleByID(vehicleID)
 
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, api.vehicleNotFoundCode())
 		return
 	}
 
@@ -54,7 +54,26 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	loc := utils.LoadLocationWithUTCFallBack(agency.Timezone, agency.ID)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
+	if err != nil {
+		// If the trip doesn't exist in our DB (sql.ErrNoRows), return 404 instead of 500
+		if errors.Is(err, sql.ErrNoRows) {
+			api.Logger.Warn("vehicle references non-existent trip",
+				"vehicleID", vehicleID, "tripID", tripID, "agencyID", agencyID)
+			api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
+			return
+		}
+		api.Logger.Error("database error fetching trip",
+			"error", err,
+			"tripID", tripID,
+			"agencyID", agencyID)
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// The vehicle's agency need not be the one operating the trip, whose
+	// timezone its service dates are in.
+	loc := api.routeLocation(ctx, trip.RouteID)
 
 	var currentTime time.Time
 	if params.Time != nil {
@@ -63,6 +82,10 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 		currentTime = api.Clock.Now().In(loc)
 	}
 
+	if params.ServiceDate == nil {
+		serviceDate := api.serviceDateForTrip(ctx, trip, currentTime)
+		params.ServiceDate = &serviceDate
+	}
 	serviceDate, serviceDateMillis := utils.ServiceDateMillis(params.ServiceDate, currentTime)
 
 	var status *models.TripStatusForTripDetails
@@ -78,23 +101,6 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
-	if err != nil {
-		// If the trip doesn't exist in our DB (sql.ErrNoRows), return 404 instead of 500
-		if errors.Is(err, sql.ErrNoRows) {
-			api.Logger.Warn("vehicle references non-existent trip",
-				"vehicleID", vehicleID, "tripID", tripID, "agencyID", agencyID)
-			api.sendNotFound(w, r)
-			return
-		}
-		api.Logger.Error("database error fetching trip",
-			"error", err,
-			"tripID", tripID,
-			"agencyID", agencyID)
-		api.serverErrorResponse(w, r, err)
-		return
-	}
-
 	var schedule *models.Schedule
 	if params.IncludeSchedule {
 		var scheduleErr error
@@ -178,7 +184,7 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 			models.RouteType(route.Type),
 			route.Url.String,
 			route.Color.String,
-			route.TextColor.String)
+			route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 		references.Routes = append(references.Routes, routeModel)
 	}
@@ -196,6 +202,8 @@ func (api *RestAPI) tripForVehicleHandler(w http.ResponseWriter, r *http.Request
 			utils.FormCombinedID(agencyID, trip.BlockID.String),
 			utils.FormCombinedID(agencyID, trip.ShapeID.String),
 		)
+		tripRef.WheelchairAccessible = utils.MapWheelchairAccessible(trip.WheelchairAccessible)
+		tripRef.BikesAllowed = utils.MapBikesAllowed(trip.BikesAllowed)
 		references.Trips = append(references.Trips, tripRef)
 	}
 
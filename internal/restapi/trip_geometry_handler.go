@@ -0,0 +1,124 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/OneBusAway/go-gtfs"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// tripGeometryHandler returns a trip's shape as an encoded polyline with each
+// stop time placed on it, as calculateBatchStopDistances places them for trip
+// schedules, so that clients can draw a trip's progress without projecting the
+// stops themselves.
+func (api *RestAPI) tripGeometryHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	agencyID := parsed.AgencyID
+	tripID := parsed.CodeID
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
+		return
+	}
+
+	geometry, err := api.GtfsManager.GetShapeGeometryForTrip(ctx, trip.ID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, trip.ID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(stopTimes) > api.maxTripStops() {
+		stopTimes = stopTimes[:api.maxTripStops()]
+	}
+
+	stopIDs := make([]string, len(stopTimes))
+	for i, st := range stopTimes {
+		stopIDs[i] = st.StopID
+	}
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	stopCoords := make(map[string]struct{ lat, lon float64 })
+	for _, stop := range stops {
+		stopCoords[stop.ID] = struct{ lat, lon float64 }{lat: stop.Lat, lon: stop.Lon}
+	}
+
+	var shapePoints []gtfs.ShapePoint
+	if geometry != nil {
+		shapePoints = geometry.Points
+	}
+	placed := api.calculateBatchStopDistances(stopTimes, shapePoints, stopCoords, agencyID)
+
+	geometryStops := make([]models.TripGeometryStop, len(stopTimes))
+	for i, st := range stopTimes {
+		geometryStop := models.TripGeometryStop{
+			StopID:            placed[i].StopID,
+			StopSequence:      int(st.StopSequence),
+			DistanceAlongTrip: placed[i].DistanceAlongTrip,
+		}
+		if coords, ok := stopCoords[st.StopID]; ok {
+			geometryStop.ProjectedLat, geometryStop.ProjectedLon = coords.lat, coords.lon
+			if geometry != nil {
+				geometryStop.ProjectedLat, geometryStop.ProjectedLon = geometry.PointAtDistance(placed[i].DistanceAlongTrip)
+			}
+		}
+		geometryStops[i] = geometryStop
+	}
+
+	lineCoords := make([][]float64, 0, len(shapePoints))
+	for i, point := range shapePoints {
+		if i > 0 && point == shapePoints[i-1] {
+			continue
+		}
+		lineCoords = append(lineCoords, []float64{point.Latitude, point.Longitude})
+	}
+	lineCoords, truncated := thinPoints(lineCoords, api.maxShapePoints())
+
+	var shapeID string
+	if trip.ShapeID.Valid {
+		shapeID = utils.FormCombinedID(agencyID, trip.ShapeID.String)
+	}
+	entry := models.TripGeometry{
+		TripID:        utils.FormCombinedID(agencyID, trip.ID),
+		ShapeID:       shapeID,
+		Points:        utils.EncodePolyline(lineCoords),
+		Length:        len(lineCoords),
+		Truncated:     truncated,
+		TotalDistance: geometry.Length(),
+		Stops:         geometryStops,
+	}
+
+	references := models.NewEmptyReferences()
+	referencedTrips, err := api.buildReferencedTrips(ctx, agencyID, []string{entry.TripID}, trip)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	for _, referencedTrip := range referencedTrips {
+		references.Trips = append(references.Trips, referencedTrip)
+	}
+
+	calc := GTFS.NewAdvancedDirectionCalculator(api.GtfsManager.GtfsDB.Queries)
+	references.Stops, err = api.buildStopReferences(ctx, calc, agencyID, placed)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(entry, references, api.Clock))
+}
@@ -0,0 +1,68 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestTripGeometryHandler(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	tripID := "Route15-Southbound-MonSat-4"
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(context.Background(), tripID)
+	require.NoError(t, err)
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/trip-geometry/25_"+tripID+".json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := model.Data.(map[string]interface{})
+	entry := data["entry"].(map[string]interface{})
+	assert.Equal(t, "25_"+tripID, entry["tripId"])
+	assert.NotEmpty(t, entry["shapeId"])
+
+	points := decodePolylinePoints(t, entry["points"].(string))
+	assert.Len(t, points, int(entry["length"].(float64)))
+	totalDistance := entry["totalDistance"].(float64)
+	assert.Greater(t, totalDistance, 0.0)
+
+	stops := entry["stops"].([]interface{})
+	require.Len(t, stops, len(stopTimes))
+	previous := 0.0
+	for i, raw := range stops {
+		stop := raw.(map[string]interface{})
+		assert.Equal(t, utils.FormCombinedID("25", stopTimes[i].StopID), stop["stopId"])
+		assert.Equal(t, float64(stopTimes[i].StopSequence), stop["stopSequence"])
+
+		distance := stop["distanceAlongTrip"].(float64)
+		assert.GreaterOrEqual(t, distance, previous, "stop %d goes backwards along the trip", i)
+		assert.LessOrEqual(t, distance, totalDistance)
+		previous = distance
+
+		gtfsStop, err := api.GtfsManager.GtfsDB.GetStop(context.Background(), stopTimes[i].StopID)
+		require.NoError(t, err)
+		projectedLat, projectedLon := stop["projectedLat"].(float64), stop["projectedLon"].(float64)
+		assert.Less(t, utils.Distance(gtfsStop.Lat, gtfsStop.Lon, projectedLat, projectedLon), 100.0,
+			"stop %s is projected onto the shape beside it", stopTimes[i].StopID)
+	}
+
+	references := data["references"].(map[string]interface{})
+	trips := references["trips"].([]interface{})
+	require.Len(t, trips, 1)
+	assert.Equal(t, "25_"+tripID, trips[0].(map[string]interface{})["id"])
+	assert.NotEmpty(t, references["stops"])
+}
+
+func TestTripGeometryHandlerUnknownTrip(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/trip-geometry/25_nonexistent.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Nil(t, model.Data)
+}
@@ -17,13 +17,13 @@ func (api *RestAPI) tripHandler(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, id)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, id)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
 		return
 	}
 
-	route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, trip.RouteID)
+	route, err := api.GtfsManager.GtfsDB.GetRoute(ctx, trip.RouteID)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
@@ -58,7 +58,17 @@ func (api *RestAPI) tripHandler(w http.ResponseWriter, r *http.Request) {
 		TripHeadsign:   trip.TripHeadsign.String,
 		TripShortName:  trip.TripShortName.String,
 		RouteShortName: route.ShortName.String,
+
+		WheelchairAccessible: utils.MapWheelchairAccessible(trip.WheelchairAccessible),
+		BikesAllowed:         utils.MapBikesAllowed(trip.BikesAllowed),
 	}
+	flexibleAreas, err := api.buildTripFlexibleAreas(ctx, agencyID, trip.ID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+	tripModel.FlexibleAreas = flexibleAreas
+
 	tripResponse := models.NewTripResponse(
 		tripModel,
 		"",
@@ -76,7 +86,7 @@ func (api *RestAPI) tripHandler(w http.ResponseWriter, r *http.Request) {
 		models.RouteType(route.Type),
 		route.Url.String,
 		route.Color.String,
-		route.TextColor.String))
+		route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder)))
 
 	references.Agencies = append(references.Agencies, models.NewAgencyReference(
 		agency.ID,
@@ -93,3 +93,19 @@ func TestTripHandlerWithMalformedID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Status code should be 400 Bad Request")
 }
+
+func TestTripHandlerWheelchairAccessible(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	tests := map[string]string{
+		"25_Route15-Northbound-MonFri":            "ACCESSIBLE",
+		"25_84f4520e-88b6-4ee6-8975-856799bc1359": "UNKNOWN",
+	}
+	for tripID, expected := range tests {
+		resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/trip/"+tripID+".json?key=TEST")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+		assert.Equal(t, expected, entry["wheelchairAccessible"], tripID)
+	}
+}
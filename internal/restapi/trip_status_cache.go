@@ -0,0 +1,115 @@
+package restapi
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/models"
+)
+
+// tripStatusCacheMaxEntries bounds the trip status cache; it is emptied when
+// full, which at the scale of one minute's worth of statuses is cheap.
+const tripStatusCacheMaxEntries = 10000
+
+// tripStatusKey identifies a trip status: the trip on one service date, as
+// seen during one minute.
+type tripStatusKey struct {
+	agencyID    string
+	tripID      string
+	serviceDate int64
+	minute      int64
+}
+
+// tripStatusCache holds the statuses BuildTripStatus built recently, so that a
+// busy stop listing the same trips on every request, or the many rows of one
+// trip in a schedule, do not redo the work. Entries are tagged with the GTFS
+// manager's data generation and the whole cache is dropped as soon as a
+// different one is seen, so new realtime data is never answered from stale
+// statuses. Within a generation a status is reused for the rest of its minute.
+type tripStatusCache struct {
+	mu         sync.Mutex
+	generation uint64
+	entries    map[tripStatusKey]*models.TripStatusForTripDetails
+}
+
+func newTripStatusCache() *tripStatusCache {
+	return &tripStatusCache{entries: make(map[tripStatusKey]*models.TripStatusForTripDetails)}
+}
+
+func (c *tripStatusCache) get(generation uint64, key tripStatusKey) (*models.TripStatusForTripDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStaleLocked(generation)
+	status, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return cloneTripStatus(status), true
+}
+
+func (c *tripStatusCache) put(generation uint64, key tripStatusKey, status *models.TripStatusForTripDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetIfStaleLocked(generation)
+	if len(c.entries) >= tripStatusCacheMaxEntries {
+		clear(c.entries)
+	}
+	c.entries[key] = cloneTripStatus(status)
+}
+
+func (c *tripStatusCache) resetIfStaleLocked(generation uint64) {
+	if c.generation == generation {
+		return
+	}
+	c.generation = generation
+	clear(c.entries)
+}
+
+// cloneTripStatus copies a status so that callers, which fill in fields of
+// their own, never change the cached one.
+func cloneTripStatus(status *models.TripStatusForTripDetails) *models.TripStatusForTripDetails {
+	clone := *status
+	clone.SituationIDs = slices.Clone(status.SituationIDs)
+	clone.VehicleFeatures = slices.Clone(status.VehicleFeatures)
+	if status.Frequency != nil {
+		frequency := *status.Frequency
+		clone.Frequency = &frequency
+	}
+	return &clone
+}
+
+// BuildTripStatus builds the status of a trip on serviceDate as of
+// currentTime, reusing one built earlier in the same minute while the GTFS data
+// has not changed.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) BuildTripStatus(
+	ctx context.Context,
+	agencyID, tripID string,
+	serviceDate time.Time,
+	currentTime time.Time,
+) (*models.TripStatusForTripDetails, error) {
+	if api.tripStatusCache == nil {
+		return api.buildTripStatus(ctx, agencyID, tripID, serviceDate, currentTime)
+	}
+
+	generation := api.GtfsManager.DataGeneration()
+	key := tripStatusKey{
+		agencyID:    agencyID,
+		tripID:      tripID,
+		serviceDate: serviceDate.Unix(),
+		minute:      currentTime.Unix() / 60,
+	}
+	if status, ok := api.tripStatusCache.get(generation, key); ok {
+		return status, nil
+	}
+
+	status, err := api.buildTripStatus(ctx, agencyID, tripID, serviceDate, currentTime)
+	if err == nil {
+		api.tripStatusCache.put(generation, key, status)
+	}
+	return status, err
+}
@@ -0,0 +1,61 @@
+package restapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/models"
+)
+
+func TestTripStatusCacheReusesStatusWithinMinute(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := context.Background()
+
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, canceledTestTripID)
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	currentTime := serviceDate.Add(10 * time.Hour)
+
+	first, err := api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime)
+	require.NoError(t, err)
+	first.Frequency = &models.Frequency{Headway: 600} // callers may fill in fields of their own
+
+	second, err := api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, second.Frequency, "the cached status is not shared with callers")
+	assert.Equal(t, first.ActiveTripID, second.ActiveTripID)
+	assert.Len(t, api.tripStatusCache.entries, 1)
+
+	_, err = api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, api.tripStatusCache.entries, 2, "each minute gets its own status")
+
+	// New realtime data drops every cached status.
+	api.GtfsManager.MockAddVehicle("bus-7", trip.ID, trip.RouteID)
+	third, err := api.BuildTripStatus(ctx, "25", trip.ID, serviceDate, currentTime)
+	require.NoError(t, err)
+	assert.Equal(t, "25_bus-7", third.VehicleID)
+	assert.Len(t, api.tripStatusCache.entries, 1)
+}
+
+func TestTripStatusCacheFull(t *testing.T) {
+	cache := newTripStatusCache()
+	for i := range tripStatusCacheMaxEntries {
+		cache.put(1, tripStatusKey{tripID: "trip", minute: int64(i)}, &models.TripStatusForTripDetails{})
+	}
+	require.Len(t, cache.entries, tripStatusCacheMaxEntries)
+
+	cache.put(1, tripStatusKey{tripID: "another"}, &models.TripStatusForTripDetails{ActiveTripID: "another"})
+	assert.Len(t, cache.entries, 1, "a full cache starts over")
+	status, ok := cache.get(1, tripStatusKey{tripID: "another"})
+	require.True(t, ok)
+	assert.Equal(t, "another", status.ActiveTripID)
+
+	_, ok = cache.get(2, tripStatusKey{tripID: "another"})
+	assert.False(t, ok, "a new data generation drops the cache")
+}
@@ -1,5 +1,15 @@
 package restapi
 
+import (
+	"sort"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/utils"
+)
+
 type StopDelayInfo struct {
 	ArrivalDelay   int64
 	DepartureDelay int64
@@ -62,3 +72,231 @@ func (api *RestAPI) GetStopDelaysFromTripUpdates(tripID string) map[string]StopD
 
 	return delays
 }
+
+// scheduledStop is one stop time of a trip with its scheduled times on a
+// particular service date.
+type scheduledStop struct {
+	StopID       string
+	StopSequence int64
+	Arrival      time.Time
+	Departure    time.Time
+}
+
+// stopPrediction is the real-time prediction for a scheduledStop, as offsets
+// from its scheduled arrival and departure.
+type stopPrediction struct {
+	ArrivalDelay   time.Duration
+	DepartureDelay time.Duration
+	// Propagated is set when the delay was carried forward from an earlier
+	// stop (or the trip-level delay) rather than given for this stop.
+	Propagated bool
+}
+
+func newScheduledStops(stopTimes []gtfsdb.StopTime, serviceMidnight time.Time) []scheduledStop {
+	stops := make([]scheduledStop, len(stopTimes))
+	for i, st := range stopTimes {
+		stops[i] = scheduledStop{
+			StopID:       st.StopID,
+			StopSequence: st.StopSequence,
+			Arrival:      serviceMidnight.Add(utils.StopTimeDuration(st.ArrivalTime)),
+			Departure:    serviceMidnight.Add(utils.StopTimeDuration(st.DepartureTime)),
+		}
+	}
+	return stops
+}
+
+// propagateTripUpdate applies the StopTimeUpdates of a GTFS-RT trip update to
+// the stops of a trip, which must be in stop_sequence order, and returns one
+// prediction per stop (nil where there is none).
+//
+// Each update applies to the stop with its stop_sequence or, failing that, the
+// next stop with its stop_id. A stop without an update of its own gets the
+// departure delay of the closest earlier update, and stops before the first
+// update get the trip-level delay if the feed sets one. Skipped stops have no
+// prediction but pass the delay on; NO_DATA stops stop the propagation until
+// the next update. Updates for stop sequences missing from the schedule still
+// pass their delay on.
+func propagateTripUpdate(update *gtfs.Trip, stops []scheduledStop) []*stopPrediction {
+	predictions := make([]*stopPrediction, len(stops))
+	if update == nil {
+		return predictions
+	}
+
+	var carried time.Duration
+	hasCarried := update.Delay != nil
+	if hasCarried {
+		carried = *update.Delay
+	}
+
+	bySequence := make(map[int64]*gtfs.StopTimeUpdate)
+	byStopID := make(map[string][]*gtfs.StopTimeUpdate)
+	var sequences []int64
+	for i := range update.StopTimeUpdates {
+		stu := &update.StopTimeUpdates[i]
+		switch {
+		case stu.StopSequence != nil:
+			seq := int64(*stu.StopSequence)
+			bySequence[seq] = stu
+			sequences = append(sequences, seq)
+		case stu.StopID != nil:
+			byStopID[*stu.StopID] = append(byStopID[*stu.StopID], stu)
+		}
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	stopSequences := make(map[int64]bool, len(stops))
+	for _, stop := range stops {
+		stopSequences[stop.StopSequence] = true
+	}
+
+	next := 0 // index into sequences of the first update not yet passed
+	for i, stop := range stops {
+		// Updates for sequences the schedule does not have still carry their
+		// delay to the stops after them. Without a scheduled time, only an
+		// explicit delay can be used.
+		for next < len(sequences) && sequences[next] < stop.StopSequence {
+			stu := bySequence[sequences[next]]
+			if !stopSequences[sequences[next]] {
+				if delay, ok := stopTimeUpdateDelay(stu); ok {
+					carried, hasCarried = delay, true
+				}
+			}
+			next++
+		}
+
+		stu := bySequence[stop.StopSequence]
+		if stu == nil {
+			if queue := byStopID[stop.StopID]; len(queue) > 0 {
+				stu = queue[0]
+				byStopID[stop.StopID] = queue[1:]
+			}
+		}
+
+		if stu != nil {
+			switch stu.ScheduleRelationship {
+			case gtfsrt.TripUpdate_StopTimeUpdate_SKIPPED:
+				continue
+			case gtfsrt.TripUpdate_StopTimeUpdate_NO_DATA:
+				hasCarried = false
+				continue
+			}
+			arrival, hasArrival := stopTimeEventDelay(stu.Arrival, stop.Arrival)
+			departure, hasDeparture := stopTimeEventDelay(stu.Departure, stop.Departure)
+			if hasArrival || hasDeparture {
+				if !hasArrival {
+					arrival = departure
+				}
+				if !hasDeparture {
+					departure = arrival
+				}
+				// A vehicle cannot leave before it arrives.
+				if predictedArrival := stop.Arrival.Add(arrival); stop.Departure.Add(departure).Before(predictedArrival) {
+					departure = predictedArrival.Sub(stop.Departure)
+				}
+				predictions[i] = &stopPrediction{ArrivalDelay: arrival, DepartureDelay: departure}
+				carried, hasCarried = departure, true
+				continue
+			}
+		}
+
+		if hasCarried {
+			predictions[i] = &stopPrediction{ArrivalDelay: carried, DepartureDelay: carried, Propagated: true}
+		}
+	}
+	return predictions
+}
+
+// stopTimeEventDelay returns the offset of a GTFS-RT event from the scheduled
+// time. An absolute time wins over a delay when the feed gives both.
+func stopTimeEventDelay(event *gtfs.StopTimeEvent, scheduled time.Time) (time.Duration, bool) {
+	switch {
+	case event == nil:
+		return 0, false
+	case event.Time != nil:
+		return event.Time.Sub(scheduled), true
+	case event.Delay != nil:
+		return *event.Delay, true
+	}
+	return 0, false
+}
+
+// stopTimeUpdateDelay returns the delay an update passes on to later stops
+// when its scheduled times are unknown: the departure delay, or else the
+// arrival delay.
+func stopTimeUpdateDelay(stu *gtfs.StopTimeUpdate) (time.Duration, bool) {
+	if stu.Departure != nil && stu.Departure.Delay != nil {
+		return *stu.Departure.Delay, true
+	}
+	if stu.Arrival != nil && stu.Arrival.Delay != nil {
+		return *stu.Arrival.Delay, true
+	}
+	return 0, false
+}
+
+// predictTripStopTimes returns the propagated predictions for a trip's stop
+// times on the service day starting at serviceMidnight, or nil when the trip
+// has no real-time trip update.
+func (api *RestAPI) predictTripStopTimes(tripID string, stopTimes []gtfsdb.StopTime, serviceMidnight time.Time) []*stopPrediction {
+	update, _ := api.GtfsManager.GetTripUpdateByID(tripID)
+	if update == nil {
+		return nil
+	}
+	return propagateTripUpdate(update, newScheduledStops(stopTimes, serviceMidnight))
+}
+
+// currentScheduleDeviation returns the predicted delay, in seconds, at the
+// first stop the trip has not yet departed from at currentTime, or at the
+// last stop once it has passed them all.
+func currentScheduleDeviation(stopTimes []gtfsdb.StopTime, predictions []*stopPrediction, serviceMidnight, currentTime time.Time) (int, bool) {
+	var last *stopPrediction
+	for i, prediction := range predictions {
+		if prediction == nil {
+			continue
+		}
+		last = prediction
+		predictedDeparture := serviceMidnight.Add(utils.StopTimeDuration(stopTimes[i].DepartureTime) + prediction.DepartureDelay)
+		if !predictedDeparture.Before(currentTime) {
+			break
+		}
+	}
+	if last == nil {
+		return 0, false
+	}
+	return int(last.DepartureDelay.Seconds()), true
+}
+
+// stopDelaysFromPredictions keys propagated predictions by stop ID in the form
+// GetStopDelaysFromTripUpdates returns. For a stop visited twice the later
+// visit wins.
+func stopDelaysFromPredictions(stopTimes []gtfsdb.StopTime, predictions []*stopPrediction) map[string]StopDelayInfo {
+	delays := make(map[string]StopDelayInfo)
+	for i, prediction := range predictions {
+		if prediction == nil {
+			continue
+		}
+		delays[stopTimes[i].StopID] = StopDelayInfo{
+			ArrivalDelay:   int64(prediction.ArrivalDelay.Seconds()),
+			DepartureDelay: int64(prediction.DepartureDelay.Seconds()),
+		}
+	}
+	return delays
+}
+
+// tripUpdateAppliesOn reports whether a GTFS-RT trip update is for the run of
+// its trip on the service date starting at serviceMidnight. An update without
+// a start date applies to whichever run is in progress.
+func tripUpdateAppliesOn(update *gtfs.Trip, serviceMidnight time.Time) bool {
+	if !update.ID.HasStartDate {
+		return true
+	}
+	return update.ID.StartDate.Format("20060102") == serviceMidnight.Format("20060102")
+}
+
+// tripCanceledOn reports whether the realtime feed cancels the trip's run on
+// the service date starting at serviceMidnight.
+func (api *RestAPI) tripCanceledOn(tripID string, serviceMidnight time.Time) bool {
+	update, _ := api.GtfsManager.GetTripUpdateByID(tripID)
+	return update != nil &&
+		update.ID.ScheduleRelationship == gtfsrt.TripDescriptor_CANCELED &&
+		tripUpdateAppliesOn(update, serviceMidnight)
+}
@@ -1,11 +1,16 @@
 package restapi
 
 import (
+	"context"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/utils"
 )
 
 func TestGetScheduleDeviation_NoUpdates(t *testing.T) {
@@ -244,3 +249,153 @@ func TestGetStopDelaysFromTripUpdates_MultipleStops(t *testing.T) {
 	assert.Equal(t, int64(0), delays["stop-C"].ArrivalDelay)
 	assert.Equal(t, int64(0), delays["stop-C"].DepartureDelay)
 }
+
+// propagationTestStops returns five stops ten minutes apart, each dwelling
+// for one minute, with stop sequences 1 to 5.
+func propagationTestStops(midnight time.Time) []scheduledStop {
+	stops := make([]scheduledStop, 5)
+	for i := range stops {
+		arrival := midnight.Add(8*time.Hour + time.Duration(i)*10*time.Minute)
+		stops[i] = scheduledStop{
+			StopID:       []string{"A", "B", "C", "D", "E"}[i],
+			StopSequence: int64(i + 1),
+			Arrival:      arrival,
+			Departure:    arrival.Add(time.Minute),
+		}
+	}
+	return stops
+}
+
+func durationPtr(d time.Duration) *time.Duration { return &d }
+
+func uint32Ptr(v uint32) *uint32 { return &v }
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func TestPropagateTripUpdate(t *testing.T) {
+	midnight := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	stops := propagationTestStops(midnight)
+	stopC := "C"
+
+	type delays struct{ arrival, departure time.Duration }
+	tests := []struct {
+		name     string
+		update   *gtfs.Trip
+		expected []*delays // nil entries have no prediction
+	}{
+		{
+			name: "delay carried to later stops only",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(2), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(2 * time.Minute)}},
+			}},
+			expected: []*delays{nil, {2 * time.Minute, 2 * time.Minute}, {2 * time.Minute, 2 * time.Minute}, {2 * time.Minute, 2 * time.Minute}, {2 * time.Minute, 2 * time.Minute}},
+		},
+		{
+			name: "each update applies to its own stop",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Departure: &gtfs.StopTimeEvent{Delay: durationPtr(time.Minute)}},
+				{StopID: &stopC, Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(3 * time.Minute)}},
+			}},
+			expected: []*delays{{time.Minute, time.Minute}, {time.Minute, time.Minute}, {3 * time.Minute, 3 * time.Minute}, {3 * time.Minute, 3 * time.Minute}, {3 * time.Minute, 3 * time.Minute}},
+		},
+		{
+			name: "trip-level delay seeds stops before the first update",
+			update: &gtfs.Trip{Delay: durationPtr(30 * time.Second), StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(4), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(-time.Minute)}},
+			}},
+			expected: []*delays{{30 * time.Second, 30 * time.Second}, {30 * time.Second, 30 * time.Second}, {30 * time.Second, 30 * time.Second}, {-time.Minute, -time.Minute}, {-time.Minute, -time.Minute}},
+		},
+		{
+			name: "absolute times and dwell clamping",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				// Arrives five minutes late but claims to leave on time.
+				{StopSequence: uint32Ptr(2),
+					Arrival:   &gtfs.StopTimeEvent{Time: timePtr(stops[1].Arrival.Add(5 * time.Minute))},
+					Departure: &gtfs.StopTimeEvent{Time: timePtr(stops[1].Departure)}},
+			}},
+			expected: []*delays{nil, {5 * time.Minute, 4 * time.Minute}, {4 * time.Minute, 4 * time.Minute}, {4 * time.Minute, 4 * time.Minute}, {4 * time.Minute, 4 * time.Minute}},
+		},
+		{
+			name: "skipped stop passes the delay on",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(time.Minute)}},
+				{StopSequence: uint32Ptr(2), ScheduleRelationship: gtfsrt.TripUpdate_StopTimeUpdate_SKIPPED},
+			}},
+			expected: []*delays{{time.Minute, time.Minute}, nil, {time.Minute, time.Minute}, {time.Minute, time.Minute}, {time.Minute, time.Minute}},
+		},
+		{
+			name: "no data stops propagation until the next update",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(time.Minute)}},
+				{StopSequence: uint32Ptr(2), ScheduleRelationship: gtfsrt.TripUpdate_StopTimeUpdate_NO_DATA},
+				{StopSequence: uint32Ptr(4), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(2 * time.Minute)}},
+			}},
+			expected: []*delays{{time.Minute, time.Minute}, nil, nil, {2 * time.Minute, 2 * time.Minute}, {2 * time.Minute, 2 * time.Minute}},
+		},
+		{
+			name: "update for an unscheduled sequence still carries its delay",
+			update: &gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+				{StopSequence: uint32Ptr(0), Departure: &gtfs.StopTimeEvent{Delay: durationPtr(90 * time.Second)}},
+			}},
+			expected: []*delays{{90 * time.Second, 90 * time.Second}, {90 * time.Second, 90 * time.Second}, {90 * time.Second, 90 * time.Second}, {90 * time.Second, 90 * time.Second}, {90 * time.Second, 90 * time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predictions := propagateTripUpdate(tt.update, stops)
+			require.Len(t, predictions, len(stops))
+			for i, want := range tt.expected {
+				if want == nil {
+					assert.Nil(t, predictions[i], "stop %d", i+1)
+					continue
+				}
+				require.NotNil(t, predictions[i], "stop %d", i+1)
+				assert.Equal(t, want.arrival, predictions[i].ArrivalDelay, "arrival at stop %d", i+1)
+				assert.Equal(t, want.departure, predictions[i].DepartureDelay, "departure at stop %d", i+1)
+			}
+		})
+	}
+}
+
+func TestPropagateTripUpdateMarksCarriedPredictions(t *testing.T) {
+	stops := propagationTestStops(time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC))
+	predictions := propagateTripUpdate(&gtfs.Trip{StopTimeUpdates: []gtfs.StopTimeUpdate{
+		{StopSequence: uint32Ptr(3), Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(time.Minute)}},
+	}}, stops)
+
+	assert.False(t, predictions[2].Propagated)
+	assert.True(t, predictions[3].Propagated)
+	assert.True(t, predictions[4].Propagated)
+}
+
+func TestTripDetailsScheduleIncludesPropagatedPredictions(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	trip := api.GtfsManager.GetTrips()[0]
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(context.Background(), trip.ID)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(stopTimes), 3)
+
+	second := uint32(stopTimes[1].StopSequence)
+	api.GtfsManager.MockAddTripUpdate(trip.ID, nil, []gtfs.StopTimeUpdate{
+		{StopSequence: &second, Arrival: &gtfs.StopTimeEvent{Delay: durationPtr(2 * time.Minute)}},
+	})
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api,
+		"/api/where/trip-details/"+utils.FormCombinedID("25", trip.ID)+".json?key=TEST&includeStatus=false")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	schedule := entry["schedule"].(map[string]interface{})
+	scheduled := schedule["stopTimes"].([]interface{})
+	require.Len(t, scheduled, len(stopTimes))
+
+	first := scheduled[0].(map[string]interface{})
+	assert.NotContains(t, first, "predictedArrivalTime", "stops before the update are not predicted")
+	for _, raw := range scheduled[1:] {
+		st := raw.(map[string]interface{})
+		assert.Equal(t, st["arrivalTime"].(float64)+120, st["predictedArrivalTime"])
+		assert.Equal(t, st["departureTime"].(float64)+120, st["predictedDepartureTime"])
+	}
+}
@@ -0,0 +1,50 @@
+package restapi
+
+import (
+	"net/http"
+
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// tripsForAgencyHandler lists the trips of every route of an agency that are
+// active at the requested time (now by default), in the same shape as
+// trips-for-route.
+func (api *RestAPI) tripsForAgencyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := withTripDataMemo(r.Context())
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	agencyID, _ := utils.GetIDFromContext(r.Context())
+
+	opts, formattedDate, ok := api.parseActiveTripsRequest(w, r, agencyID)
+	if !ok {
+		return
+	}
+
+	serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, formattedDate)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	routeIDs, err := api.GtfsManager.GtfsDB.Queries.GetRouteIDsForAgency(ctx, agencyID)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	vehiclesByTripID := api.vehiclesByTripID()
+	activeTripIDs := make(map[string]bool)
+	for _, routeID := range routeIDs {
+		if err := api.collectActiveTripsForRoute(ctx, routeID, serviceIDs, opts.currentTime, vehiclesByTripID, activeTripIDs); err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+
+	api.sendActiveTrips(w, r, ctx, activeTripIDs, opts)
+}
@@ -0,0 +1,99 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTripsForAgencyHandler(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 8, 0, 0, 0, loc)
+
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	url := fmt.Sprintf("/api/where/trips-for-agency/25.json?key=TEST&time=%d", at.UnixMilli())
+	resp, model := serveApiAndRetrieveEndpoint(t, api, url)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := model.Data.(map[string]interface{})
+	list := data["list"].([]interface{})
+	require.NotEmpty(t, list, "agency 25 should have trips running at 08:00")
+	assert.False(t, data["limitExceeded"].(bool))
+
+	routeURL := fmt.Sprintf("/api/where/trips-for-route/25_151.json?key=TEST&includeSchedule=false&includeStatus=false&time=%d", at.UnixMilli())
+	_, routeModel := serveApiAndRetrieveEndpoint(t, api, routeURL)
+	routeList := routeModel.Data.(map[string]interface{})["list"].([]interface{})
+
+	tripIDs := make(map[string]bool)
+	previous := ""
+	for _, item := range list {
+		entry := item.(map[string]interface{})
+		tripID := entry["tripId"].(string)
+		assert.True(t, strings.HasPrefix(tripID, "25_"), tripID)
+		assert.Greater(t, tripID, previous, "trips are listed once, in trip ID order")
+		previous = tripID
+		tripIDs[tripID] = true
+
+		assert.NotNil(t, entry["schedule"], "schedule is included by default")
+		assert.NotNil(t, entry["status"], "status is included by default")
+	}
+	for _, item := range routeList {
+		tripID := item.(map[string]interface{})["tripId"].(string)
+		assert.True(t, tripIDs[tripID], "trip %s active on route 151 is missing from its agency", tripID)
+	}
+
+	references := data["references"].(map[string]interface{})
+	assert.NotEmpty(t, references["trips"])
+	assert.NotEmpty(t, references["routes"])
+}
+
+func TestTripsForAgencyHandlerPagination(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 8, 0, 0, 0, loc)
+
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	base := fmt.Sprintf("/api/where/trips-for-agency/25.json?key=TEST&includeSchedule=false&includeStatus=false&time=%d", at.UnixMilli())
+	_, model := serveApiAndRetrieveEndpoint(t, api, base)
+	all := model.Data.(map[string]interface{})["list"].([]interface{})
+	require.Greater(t, len(all), 2)
+
+	for _, item := range all {
+		entry := item.(map[string]interface{})
+		assert.Nil(t, entry["schedule"])
+		assert.Nil(t, entry["status"])
+	}
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, base+"&maxCount=2")
+	data := model.Data.(map[string]interface{})
+	page := data["list"].([]interface{})
+	require.Len(t, page, 2)
+	assert.True(t, data["limitExceeded"].(bool))
+	assert.Equal(t, all[0].(map[string]interface{})["tripId"], page[0].(map[string]interface{})["tripId"])
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, base+"&maxCount=2&offset=2")
+	page = model.Data.(map[string]interface{})["list"].([]interface{})
+	require.NotEmpty(t, page)
+	assert.Equal(t, all[2].(map[string]interface{})["tripId"], page[0].(map[string]interface{})["tripId"])
+}
+
+func TestTripsForAgencyHandlerErrors(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/trips-for-agency/NOPE.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/trips-for-agency/25.json?key=TEST&maxCount=abc")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -121,14 +121,13 @@ func (api *RestAPI) parseAndValidateRequest(r *http.Request) (
 	fieldErrors map[string][]string,
 	err error,
 ) {
-	queryParams := r.URL.Query()
-
-	lat, fieldErrors = utils.ParseFloatParam(queryParams, "lat", nil)
-	lon, _ = utils.ParseFloatParam(queryParams, "lon", fieldErrors)
-	latSpan, _ = utils.ParseFloatParam(queryParams, "latSpan", fieldErrors)
-	lonSpan, _ = utils.ParseFloatParam(queryParams, "lonSpan", fieldErrors)
-	includeTrip = queryParams.Get("includeTrip") == "true"
-	includeSchedule = queryParams.Get("includeSchedule") == "true"
+	q := newQueryParams(r)
+	lat = q.floatParam("lat", 0)
+	lon = q.floatParam("lon", 0)
+	latSpan = q.floatParam("latSpan", 0)
+	lonSpan = q.floatParam("lonSpan", 0)
+	includeTrip = q.boolParam("includeTrip", false)
+	includeSchedule = q.boolParam("includeSchedule", false)
 
 	agencies := api.GtfsManager.GetAgencies()
 	if len(agencies) == 0 {
@@ -138,35 +137,30 @@ func (api *RestAPI) parseAndValidateRequest(r *http.Request) (
 	currentAgency := agencies[0]
 	currentLocation, _ = time.LoadLocation(currentAgency.Timezone)
 
-	timeParam := queryParams.Get("time")
 	currentTime := api.Clock.Now().In(currentLocation)
 	todayMidnight = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentLocation)
 
-	_, serviceDate, fieldErrors, success := utils.ParseTimeParameter(timeParam, currentLocation)
+	_, serviceDate, timeErrors, _ := utils.ParseTimeParameter(q.values.Get("time"), currentLocation)
 
 	ctx := r.Context()
 	if ctx.Err() != nil {
 		return 0, 0, 0, 0, false, false, nil, time.Time{}, time.Time{}, nil, ctx.Err()
 	}
 
-	if !success {
-		if fieldErrors == nil {
-			fieldErrors = make(map[string][]string)
+	for field, msgs := range timeErrors {
+		for _, msg := range msgs {
+			q.addError(field, msg)
 		}
 	}
-
-	locationErrors := utils.ValidateLocationParams(lat, lon, 0, latSpan, lonSpan)
-	if len(locationErrors) > 0 {
-		if fieldErrors == nil {
-			fieldErrors = locationErrors
-		} else {
-			for k, v := range locationErrors {
-				fieldErrors[k] = append(fieldErrors[k], v...)
+	if q.errors() == nil {
+		for field, msgs := range utils.ValidateLocationParams(lat, lon, 0, latSpan, lonSpan) {
+			for _, msg := range msgs {
+				q.addError(field, msg)
 			}
 		}
 	}
 
-	if len(fieldErrors) > 0 {
+	if fieldErrors := q.errors(); fieldErrors != nil {
 		return 0, 0, 0, 0, false, false, nil, time.Time{}, time.Time{}, fieldErrors, nil
 	}
 
@@ -383,7 +377,7 @@ func (api *RestAPI) buildScheduleForTrip(
 		shapePoints = shapeRowsToPoints(shapeRows)
 	}
 
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
 	if err != nil {
 		api.serverErrorResponse(w, r, err)
 		return nil
@@ -586,6 +580,9 @@ func (rb *referenceBuilder) createTrip(trip gtfsdb.Trip) models.Trip {
 		ShapeID:       trip.ShapeID.String,
 		PeakOffPeak:   0,
 		TimeZone:      "",
+
+		WheelchairAccessible: utils.MapWheelchairAccessible(trip.WheelchairAccessible),
+		BikesAllowed:         utils.MapBikesAllowed(trip.BikesAllowed),
 	}
 }
 
@@ -625,7 +622,7 @@ func (rb *referenceBuilder) createRoute(route gtfsdb.Route) models.Route {
 		models.RouteType(route.Type),
 		route.Url.String,
 		route.Color.String,
-		route.TextColor.String)
+		route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 }
 
@@ -658,7 +655,7 @@ func (rb *referenceBuilder) buildTripReferences() error {
 			return rb.ctx.Err()
 		}
 
-		tripDetails, err := rb.api.GtfsManager.GtfsDB.Queries.GetTrip(rb.ctx, trip.ID)
+		tripDetails, err := rb.api.GtfsManager.GtfsDB.GetTrip(rb.ctx, trip.ID)
 		if err != nil {
 			continue
 		}
@@ -681,6 +678,9 @@ func (rb *referenceBuilder) createTripReference(tripDetails gtfsdb.Trip, current
 		ShapeID:       utils.FormCombinedID(currentAgency, tripDetails.ShapeID.String),
 		PeakOffPeak:   0,
 		TimeZone:      "",
+
+		WheelchairAccessible: utils.MapWheelchairAccessible(tripDetails.WheelchairAccessible),
+		BikesAllowed:         utils.MapBikesAllowed(tripDetails.BikesAllowed),
 	}
 }
 
@@ -157,3 +157,17 @@ func TestTripsForLocationHandler_ScheduleInclusion(t *testing.T) {
 		})
 	}
 }
+
+func TestTripsForLocationHandler_ReportsEveryInvalidParameter(t *testing.T) {
+	_, resp, model := serveAndRetrieveEndpoint(t,
+		"/api/where/trips-for-location.json?key=TEST&lat=abc&lon=-122.3917&latSpan=0.1&lonSpan=0.1&includeSchedule=maybe&time=bad")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	data, ok := model.Data.(map[string]interface{})
+	require.True(t, ok)
+	fieldErrors, ok := data["fieldErrors"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, fieldErrors, "lat")
+	assert.Contains(t, fieldErrors, "includeSchedule")
+	assert.Contains(t, fieldErrors, "time")
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
@@ -14,7 +16,7 @@ import (
 )
 
 func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withTripDataMemo(r.Context())
 
 	api.GtfsManager.RLock()
 	defer api.GtfsManager.RUnlock()
@@ -23,65 +25,130 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 	agencyID := parsed.AgencyID
 	routeID := parsed.CodeID
 
-	includeSchedule := r.URL.Query().Get("includeSchedule") != "false"
-	includeStatus := r.URL.Query().Get("includeStatus") != "false"
+	opts, formattedDate, ok := api.parseActiveTripsRequest(w, r, agencyID)
+	if !ok {
+		return
+	}
 
-	currentAgency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(ctx, agencyID)
+	serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, formattedDate)
 	if err != nil {
-		api.sendNotFound(w, r)
+		api.serverErrorResponse(w, r, err)
 		return
 	}
 
-	currentLocation, err := time.LoadLocation(currentAgency.Timezone)
-	if err != nil {
+	vehiclesByTripID := api.vehiclesByTripID()
+	activeTripIDs := make(map[string]bool)
+	if err := api.collectActiveTripsForRoute(ctx, routeID, serviceIDs, opts.currentTime, vehiclesByTripID, activeTripIDs); err != nil {
 		api.serverErrorResponse(w, r, err)
 		return
 	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	api.sendActiveTrips(w, r, ctx, activeTripIDs, opts)
+}
+
+// parseActiveTripsRequest reads the time, includeSchedule, includeStatus,
+// bikesAllowed and paging parameters of a request listing the active trips of agencyID, and
+// returns them with the GTFS date of the requested time. It writes the error
+// response and returns false when the agency is unknown or a parameter is
+// invalid.
+func (api *RestAPI) parseActiveTripsRequest(w http.ResponseWriter, r *http.Request, agencyID string) (activeTripsOptions, string, bool) {
+	opts := activeTripsOptions{
+		includeSchedule: r.URL.Query().Get("includeSchedule") != "false",
+		includeStatus:   r.URL.Query().Get("includeStatus") != "false",
+	}
+
+	currentAgency, err := api.GtfsManager.GtfsDB.Queries.GetAgency(r.Context(), agencyID)
+	if err != nil {
+		api.sendNotFoundWithCode(w, r, errCodeAgencyNotFound)
+		return opts, "", false
+	}
+
+	opts.location, err = time.LoadLocation(currentAgency.Timezone)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return opts, "", false
+	}
 
 	timeParam := r.URL.Query().Get("time")
-	formattedDate, currentTime, fieldErrors, success := utils.ParseTimeParameter(timeParam, currentLocation)
+	if timeParam == "" {
+		timeParam = strconv.FormatInt(api.Clock.Now().UnixMilli(), 10)
+	}
+	formattedDate, currentTime, fieldErrors, success := utils.ParseTimeParameter(timeParam, opts.location)
 	if !success {
 		api.validationErrorResponse(w, r, fieldErrors)
-		return
+		return opts, "", false
 	}
+	opts.currentTime = currentTime
 
-	serviceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, formattedDate)
-	if err != nil {
-		api.serverErrorResponse(w, r, err)
-		return
+	// Trips are paged in trip ID order; by default all active trips are returned.
+	opts.maxCount = -1
+	var pageErrors map[string][]string
+	if r.URL.Query().Get("maxCount") != "" {
+		opts.maxCount, pageErrors = utils.ParseMaxCount(r.URL.Query(), -1, nil)
 	}
+	opts.offset, pageErrors = parsePageOffset(r, pageErrors)
+	opts.bikesAllowed, pageErrors = utils.ParseBoolParam(r.URL.Query(), "bikesAllowed", pageErrors)
+	if len(pageErrors) > 0 {
+		api.validationErrorResponse(w, r, pageErrors)
+		return opts, "", false
+	}
+	return opts, formattedDate, true
+}
 
-	// Calculate nanoseconds since midnight of the service day
+// vehiclesByTripID indexes the realtime vehicles that report both a position
+// and a trip by the ID of that trip.
+func (api *RestAPI) vehiclesByTripID() map[string]gtfs.Vehicle {
+	vehiclesByTripID := make(map[string]gtfs.Vehicle)
+	for _, vehicle := range api.GtfsManager.GetRealTimeVehicles() {
+		if vehicle.Position == nil || vehicle.Trip == nil {
+			continue
+		}
+		vehiclesByTripID[vehicle.Trip.ID.ID] = vehicle
+	}
+	return vehiclesByTripID
+}
+
+// collectActiveTripsForRoute adds to activeTripIDs the trips of routeID that
+// are active at currentTime. Each block serving the route around that time
+// contributes the trips its vehicles are serving, or its scheduled active trip
+// when no vehicle is reporting, so a trip is listed only once even when several
+// vehicles report against the same block.
+func (api *RestAPI) collectActiveTripsForRoute(
+	ctx context.Context,
+	routeID string,
+	serviceIDs []string,
+	currentTime time.Time,
+	vehiclesByTripID map[string]gtfs.Vehicle,
+	activeTripIDs map[string]bool,
+) error {
+	// Calculate seconds since midnight of the service day
 	serviceDayMidnight := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentTime.Location())
-	nanosSinceMidnight := currentTime.Sub(serviceDayMidnight).Nanoseconds()
-	if nanosSinceMidnight < 0 {
-		nanosSinceMidnight = 0
+	secondsSinceMidnight := utils.StopTimeSeconds(currentTime.Sub(serviceDayMidnight))
+	if secondsSinceMidnight < 0 {
+		secondsSinceMidnight = 0
 	}
-	currentNanosSinceMidnight := nanosSinceMidnight
 
 	indexIDs, err := api.GtfsManager.GtfsDB.Queries.GetBlockTripIndexIDsForRoute(ctx, gtfsdb.GetBlockTripIndexIDsForRouteParams{
 		RouteID:    routeID,
 		ServiceIds: serviceIDs,
 	})
 	if err != nil {
-		api.serverErrorResponse(w, r, err)
-		return
+		return err
 	}
 
 	layoverIndices := api.GtfsManager.GetBlockLayoverIndicesForRoute(routeID)
 
-	timeRangeStart := currentNanosSinceMidnight - (10 * 60 * 1_000_000_000)
-	timeRangeEnd := currentNanosSinceMidnight + (30 * 60 * 1_000_000_000)
+	// Layover indices are built from the parsed static feed and hold durations
+	// since midnight rather than the seconds stored in stop_times.
+	sinceMidnight := utils.StopTimeDuration(secondsSinceMidnight)
+	timeRangeStart := int64(sinceMidnight - 10*time.Minute)
+	timeRangeEnd := int64(sinceMidnight + 30*time.Minute)
 
 	layoverBlocks := gtfsInternal.GetBlocksInTimeRange(layoverIndices, timeRangeStart, timeRangeEnd)
 
-	if len(indexIDs) == 0 && len(layoverBlocks) == 0 {
-		references := buildTripReferences(api, w, r, ctx, includeSchedule, []models.TripsForRouteListEntry{}, []gtfsdb.Stop{})
-		response := models.NewListResponseWithRange([]models.TripsForRouteListEntry{}, references, false, api.Clock, false)
-		api.sendResponse(w, r, response)
-		return
-	}
-
 	allLinkedBlocks := make(map[string]bool)
 
 	if len(indexIDs) > 0 {
@@ -90,8 +157,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 			ServiceIds: serviceIDs,
 		})
 		if err != nil {
-			api.serverErrorResponse(w, r, err)
-			return
+			return err
 		}
 
 		for _, b := range blocksFromIndices {
@@ -105,26 +171,9 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		allLinkedBlocks[blockID] = true
 	}
 
-	realTimeVehicles := api.GtfsManager.GetRealTimeVehicles()
-	vehiclesByTripID := make(map[string]gtfs.Vehicle)
-
-	for _, vehicle := range realTimeVehicles {
-		if vehicle.Position == nil || vehicle.Trip == nil {
-			continue
-		}
-		vehicleTripID := vehicle.Trip.ID.ID
-		vehiclesByTripID[vehicleTripID] = vehicle
-	}
-
-	type ActiveTripEntry struct {
-		TripID     string
-		HasVehicle bool
-	}
-	var activeTrips []ActiveTripEntry
-
 	for blockID := range allLinkedBlocks {
 		if ctx.Err() != nil {
-			return
+			return nil
 		}
 
 		blockIDNullStr := sql.NullString{String: blockID, Valid: true}
@@ -138,48 +187,67 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 			continue
 		}
 
-		activeTrip, err := api.GtfsManager.GtfsDB.Queries.GetActiveTripInBlockAtTime(ctx, gtfsdb.GetActiveTripInBlockAtTimeParams{
-			BlockID:     blockIDNullStr,
-			ServiceIds:  serviceIDs,
-			CurrentTime: currentNanosSinceMidnight,
-		})
-		if err != nil {
-			continue
-		}
-
 		vehiclesInBlock := 0
 		for _, tripInBlock := range tripsInBlock {
 			if _, hasVehicle := vehiclesByTripID[tripInBlock]; hasVehicle {
+				activeTripIDs[tripInBlock] = true
 				vehiclesInBlock++
 			}
 		}
-
 		if vehiclesInBlock > 0 {
-			for i := 0; i < vehiclesInBlock; i++ {
-				activeTrips = append(activeTrips, ActiveTripEntry{
-					TripID:     activeTrip,
-					HasVehicle: true,
-				})
-			}
+			continue
+		}
 
-		} else {
-			activeTrips = append(activeTrips, ActiveTripEntry{
-				TripID:     activeTrip,
-				HasVehicle: false,
-			})
+		activeTrip, err := api.GtfsManager.GtfsDB.Queries.GetActiveTripInBlockAtTime(ctx, gtfsdb.GetActiveTripInBlockAtTimeParams{
+			BlockID:     blockIDNullStr,
+			ServiceIds:  serviceIDs,
+			CurrentTime: secondsSinceMidnight,
+		})
+		if err != nil {
+			continue
 		}
+		activeTripIDs[activeTrip] = true
 	}
+	return nil
+}
 
-	tripIDsSet := make(map[string]bool)
-	for _, entry := range activeTrips {
-		tripIDsSet[entry.TripID] = true
-	}
-	var tripIDs []string
-	for id := range tripIDsSet {
+// activeTripsOptions are the request parameters shared by the endpoints that
+// list active trips.
+type activeTripsOptions struct {
+	offset          int
+	maxCount        int
+	includeSchedule bool
+	includeStatus   bool
+	bikesAllowed    bool // list only the trips that allow bikes on board
+	currentTime     time.Time
+	location        *time.Location
+}
+
+// sendActiveTrips writes the page of activeTripIDs selected by opts, in trip ID
+// order, with each trip's schedule and status as requested.
+func (api *RestAPI) sendActiveTrips(w http.ResponseWriter, r *http.Request, ctx context.Context, activeTripIDs map[string]bool, opts activeTripsOptions) {
+	currentTime := opts.currentTime
+	includeSchedule := opts.includeSchedule
+
+	tripIDs := make([]string, 0, len(activeTripIDs))
+	for id := range activeTripIDs {
 		tripIDs = append(tripIDs, id)
 	}
+	sort.Strings(tripIDs)
+	if opts.bikesAllowed {
+		var err error
+		tripIDs, err = api.filterTripIDs(ctx, tripIDs, tripAllowsBikes)
+		if err != nil {
+			api.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	start, end, more := pageWindow(len(tripIDs), opts.offset, opts.maxCount)
+	tripIDs = tripIDs[start:end]
+	page := newPage(r, opts.offset, len(tripIDs), more)
 
 	var fetchedTrips []gtfsdb.Trip
+	var err error
 	if len(tripIDs) > 0 {
 		fetchedTrips, err = api.GtfsManager.GtfsDB.Queries.GetTripsByIDs(ctx, tripIDs)
 		if err != nil {
@@ -219,17 +287,15 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	todayMidnight := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, currentLocation)
+	todayMidnight := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, opts.location)
 	stopIDsMap := make(map[string]bool)
 
 	var result []models.TripsForRouteListEntry
-	for _, activeEntry := range activeTrips {
+	for _, tripID := range tripIDs {
 		if ctx.Err() != nil {
 			return
 		}
 
-		tripID := activeEntry.TripID
-
 		agencyID, ok := tripAgencyMap[tripID]
 		if !ok {
 			continue
@@ -239,7 +305,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 		var status *models.TripStatusForTripDetails
 
 		if includeSchedule {
-			schedule = api.buildScheduleForTrip(ctx, tripID, agencyID, currentTime, currentLocation, w, r)
+			schedule = api.buildScheduleForTrip(ctx, tripID, agencyID, currentTime, opts.location, w, r)
 			if schedule == nil {
 				continue
 			}
@@ -255,9 +321,8 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 			}
 		}
 
-		// Build status if we have a vehicle (either on this trip or we know block has vehicles)
-		if includeStatus {
-			status, _ = api.BuildTripStatus(ctx, agencyID, tripID, currentTime, currentTime)
+		if opts.includeStatus {
+			status, _ = api.BuildTripStatus(ctx, agencyID, tripID, todayMidnight, currentTime)
 		}
 
 		entry := models.TripsForRouteListEntry{
@@ -265,7 +330,7 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 			Schedule:     schedule,
 			Status:       status,
 			ServiceDate:  todayMidnight.UnixMilli(),
-			SituationIds: api.GetSituationIDsForTrip(r.Context(), tripID),
+			SituationIds: api.GetSituationIDsForTrip(ctx, tripID),
 			TripId:       utils.FormCombinedID(agencyID, tripID),
 		}
 		result = append(result, entry)
@@ -291,8 +356,8 @@ func (api *RestAPI) tripsForRouteHandler(w http.ResponseWriter, r *http.Request)
 
 	// Pass only the result list; references function will fetch what it needs
 	references := buildTripReferences(api, w, r, ctx, includeSchedule, result, stops)
-	response := models.NewListResponseWithRange(result, references, false, api.Clock, false)
-	api.sendResponse(w, r, response)
+	response := models.NewListResponseWithRange(result, references, false, api.Clock, page.LimitExceeded)
+	api.sendResponse(w, r, models.WithPage(response, page))
 }
 
 // buildTripReferences has been updated to perform efficient batch fetching
@@ -365,6 +430,9 @@ func buildTripReferences[T interface{ GetTripId() string }](
 				ShapeID:       trip.ShapeID.String,
 				PeakOffPeak:   0,
 				TimeZone:      "",
+
+				WheelchairAccessible: utils.MapWheelchairAccessible(trip.WheelchairAccessible),
+				BikesAllowed:         utils.MapBikesAllowed(trip.BikesAllowed),
 			}
 			presentRoutes[trip.RouteID] = models.Route{}
 		}
@@ -393,7 +461,7 @@ func buildTripReferences[T interface{ GetTripId() string }](
 				models.RouteType(route.Type),
 				route.Url.String,
 				route.Color.String,
-				route.TextColor.String)
+				route.TextColor.String).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 			// Identify Agency IDs needed
 			if _, exists := presentAgencies[route.AgencyID]; !exists {
@@ -466,6 +534,9 @@ func buildTripReferences[T interface{ GetTripId() string }](
 					ShapeID:       utils.FormCombinedID(currentAgency, trip.ShapeID),
 					PeakOffPeak:   0,
 					TimeZone:      "",
+
+					WheelchairAccessible: trip.WheelchairAccessible,
+					BikesAllowed:         trip.BikesAllowed,
 				})
 			}
 		}
@@ -1,12 +1,15 @@
 package restapi
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/utils"
 )
 
 func TestTripsForRouteHandler_DifferentRoutes(t *testing.T) {
@@ -232,3 +235,86 @@ func TestTripsForRouteHandlerWithMalformedID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Status code should be 400 Bad Request")
 }
+
+func TestTripsForRouteHandler_StatusUsesServiceDate(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 8, 0, 0, 0, loc)
+	url := fmt.Sprintf("/api/where/trips-for-route/25_151.json?key=TEST&includeStatus=true&includeSchedule=false&time=%d", at.UnixMilli())
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, url)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := model.Data.(map[string]interface{})
+	list := data["list"].([]interface{})
+	require.NotEmpty(t, list, "route 151 should have trips running at 08:00")
+
+	serviceDate := time.Date(2025, 6, 13, 0, 0, 0, 0, loc).UnixMilli()
+	seen := make(map[string]bool)
+	for _, item := range list {
+		entry := item.(map[string]interface{})
+		tripID := entry["tripId"].(string)
+		assert.False(t, seen[tripID], "trip %s listed more than once", tripID)
+		seen[tripID] = true
+
+		assert.Equal(t, float64(serviceDate), entry["serviceDate"])
+		status, ok := entry["status"].(map[string]interface{})
+		require.True(t, ok, "status requested for %s", tripID)
+		assert.Equal(t, float64(serviceDate), status["serviceDate"], "status should carry the service date, not the request time")
+	}
+}
+
+// allowBikesOnTrip marks a RABA trip, which sets no bikes_allowed, as allowing
+// bikes for the rest of the test.
+func allowBikesOnTrip(t *testing.T, api *RestAPI, tripID string) {
+	t.Helper()
+	db := api.GtfsManager.GtfsDB.DB
+	_, err := db.ExecContext(context.Background(), "UPDATE trips SET bikes_allowed = 1 WHERE id = ?", tripID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.ExecContext(context.Background(), "UPDATE trips SET bikes_allowed = NULL WHERE id = ?", tripID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTripsForRouteHandlerBikesAllowed(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := time.Date(2025, 6, 13, 8, 0, 0, 0, loc)
+	tripsForRoute := func(filter string) map[string]interface{} {
+		t.Helper()
+		url := fmt.Sprintf("/api/where/trips-for-route/25_151.json?key=TEST&time=%d%s", at.UnixMilli(), filter)
+		resp, model := serveApiAndRetrieveEndpoint(t, api, url)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return model.Data.(map[string]interface{})
+	}
+
+	all := tripsForRoute("")["list"].([]interface{})
+	require.Greater(t, len(all), 1, "route 151 should have several trips running at 08:00")
+	assert.Empty(t, tripsForRoute("&bikesAllowed=true")["list"], "no RABA trip says it allows bikes")
+
+	tripID := all[0].(map[string]interface{})["tripId"].(string)
+	_, codeID, err := utils.ExtractAgencyIDAndCodeID(tripID)
+	require.NoError(t, err)
+	allowBikesOnTrip(t, api, codeID)
+
+	data := tripsForRoute("&bikesAllowed=true")
+	list := data["list"].([]interface{})
+	require.Len(t, list, 1)
+	assert.Equal(t, tripID, list[0].(map[string]interface{})["tripId"])
+	bikesAllowed := map[string]interface{}{}
+	for _, trip := range data["references"].(map[string]interface{})["trips"].([]interface{}) {
+		trip := trip.(map[string]interface{})
+		bikesAllowed[trip["id"].(string)] = trip["bikesAllowed"]
+	}
+	assert.Equal(t, "ALLOWED", bikesAllowed[tripID])
+
+	resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/where/trips-for-route/25_151.json?key=TEST&bikesAllowed=sometimes")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -6,68 +6,127 @@ import (
 	"errors"
 	"log/slog"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/OneBusAway/go-gtfs"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/geo"
+	GTFS "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
 
+// buildTripStatus does the work of BuildTripStatus, bypassing its cache.
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
-func (api *RestAPI) BuildTripStatus(
+func (api *RestAPI) buildTripStatus(
 	ctx context.Context,
 	agencyID, tripID string,
 	serviceDate time.Time,
 	currentTime time.Time,
 ) (*models.TripStatusForTripDetails, error) {
 	status := &models.TripStatusForTripDetails{
-		ActiveTripID:      utils.FormCombinedID(agencyID, tripID),
-		ServiceDate:       serviceDate.Unix() * 1000,
-		SituationIDs:      api.GetSituationIDsForTrip(ctx, tripID),
-		OccupancyCapacity: -1,
-		OccupancyCount:    -1,
-	}
+		ActiveTripID:        utils.FormCombinedID(agencyID, tripID),
+		ServiceDate:         serviceDate.Unix() * 1000,
+		OccupancyCapacity:   -1,
+		OccupancyCount:      -1,
+		OccupancyPercentage: -1,
+	}
+
+	ctx = withTripDataMemo(ctx)
+	memo := tripDataMemoFromContext(ctx)
+
+	_, activeTripRawID, idErr := utils.ExtractAgencyIDAndCodeID(status.ActiveTripID)
+	if idErr != nil {
+		activeTripRawID = tripID
+	}
+
+	// The static lookups do not depend on the realtime state, so issue them
+	// concurrently while the vehicle status is assembled below.
+	var (
+		wg                sync.WaitGroup
+		stopTimes         []gtfsdb.StopTime
+		stopTimesErr      error
+		geometry          *GTFS.ShapeGeometry
+		shapeErr          error
+		situationIDs      []string
+		blockTripSequence int
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		stopTimes, stopTimesErr = memo.stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, activeTripRawID)
+	}()
+	go func() {
+		defer wg.Done()
+		geometry, shapeErr = api.GtfsManager.GetShapeGeometryForTrip(ctx, activeTripRawID)
+	}()
+	go func() {
+		defer wg.Done()
+		// Both lookups start from the trip row; running them in one goroutine
+		// lets the second one hit the memo.
+		situationIDs = api.GetSituationIDsForTrip(ctx, tripID)
+		blockTripSequence = api.calculateBlockTripSequence(ctx, tripID, serviceDate)
+	}()
 
 	vehicle := api.GtfsManager.GetVehicleForTrip(ctx, tripID)
 
 	if vehicle != nil {
+		var capacity int
 		if vehicle.ID != nil {
 			status.VehicleID = utils.FormCombinedID(agencyID, vehicle.ID.ID)
+			capacity, _ = api.GtfsManager.VehicleCapacity(vehicle.ID.ID)
 		}
 		if vehicle.OccupancyStatus != nil {
 			status.OccupancyStatus = vehicle.OccupancyStatus.String()
 		}
-		// NOTE: GTFS-RT OccupancyPercentage (0-100%) has no direct equivalent in the
-		// OBA TripStatus schema. The Java OBA server populates occupancyCapacity from
-		// agency-provided vehicle capacity data, not from GTFS-RT percentages.
-		// We intentionally leave OccupancyCapacity at its default (-1) here.
-		// See: TripStatusBeanServiceImpl.java in onebusaway-transit-data-federation.
+		// Like the Java OBA server, occupancyCapacity comes from agency-provided
+		// vehicle capacities; GTFS-RT only gives the load as a percentage.
+		status.SetOccupancy(capacity, vehicle.OccupancyPercentage)
+		status.TripAssignmentInferred = api.GtfsManager.TripAssignmentInferred(vehicle)
 	}
 	api.BuildVehicleStatus(ctx, vehicle, tripID, agencyID, status, currentTime)
 
-	_, activeTripRawID, err := utils.ExtractAgencyIDAndCodeID(status.ActiveTripID)
-	if err != nil {
-		return status, err
+	wg.Wait()
+	status.SituationIDs = situationIDs
+	if idErr != nil {
+		return status, idErr
 	}
 
 	scheduleDeviation, hasRealtimeTripUpdate := api.GetScheduleDeviation(activeTripRawID)
 
+	// With the schedule at hand, report the delay propagated to the stop the
+	// trip is heading for rather than the first stop the update mentions.
+	var predictions []*stopPrediction
+	if stopTimesErr == nil && len(stopTimes) > 0 {
+		predictions = api.predictTripStopTimes(activeTripRawID, stopTimes, serviceDate)
+		if deviation, ok := currentScheduleDeviation(stopTimes, predictions, serviceDate, currentTime); ok {
+			scheduleDeviation, hasRealtimeTripUpdate = deviation, true
+		}
+	}
+
 	if hasRealtimeTripUpdate {
+		// Prefer the deviation averaged over recent observations when history is recorded,
+		// which damps single-update jitter in the reported value.
+		if smoothed, ok := api.GtfsManager.GetSmoothedScheduleDeviation(ctx, activeTripRawID, currentTime); ok {
+			scheduleDeviation = smoothed
+		}
 		status.ScheduleDeviation = scheduleDeviation
 	}
 
-	hasVehicleRealtimeData := vehicle != nil && !defaultStaleDetector.Check(vehicle, currentTime)
+	hasVehicleRealtimeData := vehicle != nil && !status.Stale
+	if hasVehicleRealtimeData {
+		_, status.Deviated = api.GtfsManager.GetTripDetour(activeTripRawID)
+	}
 	status.Predicted = hasVehicleRealtimeData || hasRealtimeTripUpdate
 	status.Scheduled = !status.Predicted
 
-	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, activeTripRawID)
-	if err != nil {
+	if stopTimesErr != nil {
 		slog.Warn("BuildTripStatus: failed to get stop times",
 			slog.String("trip_id", activeTripRawID),
-			slog.String("error", err.Error()))
+			slog.String("error", stopTimesErr.Error()))
 	}
-	if err == nil && len(stopTimes) > 0 {
+	if stopTimesErr == nil && len(stopTimes) > 0 {
 		stopTimesPtrs := make([]*gtfsdb.StopTime, len(stopTimes))
 		for i := range stopTimes {
 			stopTimesPtrs[i] = &stopTimes[i]
@@ -79,13 +138,16 @@ func (api *RestAPI) BuildTripStatus(
 		if vehicle != nil && vehicle.Position != nil {
 			if vehicle.StopID != nil && *vehicle.StopID != "" {
 				closestStopID = *vehicle.StopID
-				closestOffset = api.calculateOffsetForStop(closestStopID, stopTimesPtrs, currentTime, serviceDate, scheduleDeviation)
-				isStoppedAt := vehicle.CurrentStatus != nil && *vehicle.CurrentStatus == gtfs.CurrentStatus(1)
-				if isStoppedAt {
-					nextStopID, nextOffset = api.findNextStopAfter(closestStopID, stopTimesPtrs, currentTime, serviceDate, scheduleDeviation)
-				} else {
-					nextStopID = closestStopID
-					nextOffset = closestOffset
+				// A loop trip visits the reported stop more than once.
+				if stopSequence, ok := stopVisitSequence(stopTimesPtrs, closestStopID, vehicle.CurrentStopSequence, currentTime, serviceDate, scheduleDeviation); ok {
+					closestOffset = api.calculateOffsetForStop(stopSequence, stopTimesPtrs, currentTime, serviceDate, scheduleDeviation)
+					isStoppedAt := vehicle.CurrentStatus != nil && *vehicle.CurrentStatus == gtfs.CurrentStatus(1)
+					if isStoppedAt {
+						nextStopID, nextOffset = api.findNextStopAfter(stopSequence, stopTimesPtrs, currentTime, serviceDate, scheduleDeviation)
+					} else {
+						nextStopID = closestStopID
+						nextOffset = closestOffset
+					}
 				}
 			} else if vehicle.CurrentStopSequence != nil {
 				closestStopID, closestOffset = api.findClosestStopBySequence(
@@ -100,7 +162,7 @@ func (api *RestAPI) BuildTripStatus(
 				)
 			}
 		} else {
-			stopDelays := api.GetStopDelaysFromTripUpdates(activeTripRawID)
+			stopDelays := stopDelaysFromPredictions(stopTimes, predictions)
 			closestStopID, closestOffset = findClosestStopByTimeWithDelays(currentTime, serviceDate, stopTimesPtrs, stopDelays)
 			nextStopID, nextOffset = findNextStopByTimeWithDelays(currentTime, serviceDate, stopTimesPtrs, stopDelays)
 		}
@@ -119,18 +181,35 @@ func (api *RestAPI) BuildTripStatus(
 		api.fillStopsFromSchedule(ctx, status, activeTripRawID, currentTime, serviceDate, agencyID)
 	}
 
-	shapeRows, shapeErr := api.GtfsManager.GtfsDB.Queries.GetShapePointsByTripID(ctx, activeTripRawID)
+	// Place a vehicle that is between the trips of its block, so that it is
+	// neither reported as running this trip nor projected onto its shape.
+	state := blockStateUnknown
+	if hasVehicleRealtimeData && status.Phase == phaseInProgress {
+		position := api.blockPositionForTrip(ctx, tripID, vehicle, serviceDate, currentTime)
+		state = position.state()
+		status.Phase = position.phase(state)
+	}
+
 	if shapeErr != nil {
 		slog.Warn("BuildTripStatus: failed to get shape points",
 			slog.String("trip_id", activeTripRawID),
 			slog.String("error", shapeErr.Error()))
 	}
-	if shapeErr == nil && len(shapeRows) > 1 {
-		shapePoints := shapeRowsToPoints(shapeRows)
-		cumulativeDistances := preCalculateCumulativeDistances(shapePoints)
-		status.TotalDistanceAlongTrip = cumulativeDistances[len(cumulativeDistances)-1]
-
-		if vehicle != nil && vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+	if geometry != nil {
+		shapePoints := geometry.Points
+		cumulativeDistances := geometry.CumulativeDistances
+		status.TotalDistanceAlongTrip = geometry.Length()
+
+		switch {
+		case state == blockStateEarlierTrip || state == blockStateDeadheadBefore || state == blockStateLayover:
+			// The vehicle has yet to start the trip.
+			status.DistanceAlongTrip = 0
+			status.LastKnownDistanceAlongTrip = 0
+		case state == blockStateTripEnded || state == blockStateBlockEnded ||
+			state == blockStateDeadheadAfter || state == blockStateFinished:
+			status.DistanceAlongTrip = status.TotalDistanceAlongTrip
+			status.LastKnownDistanceAlongTrip = status.TotalDistanceAlongTrip
+		case vehicle != nil && vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil:
 			// Refine the raw GPS position (set by BuildVehicleStatus) by projecting
 			// it onto the route shape. Reuses the already-fetched shapePoints.
 			actualPosition := status.LastKnownLocation
@@ -140,6 +219,8 @@ func (api *RestAPI) BuildTripStatus(
 
 			actualDistance := api.getVehicleDistanceAlongShapeContextual(ctx, activeTripRawID, vehicle)
 			status.DistanceAlongTrip = actualDistance
+			status.LastKnownDistanceAlongTrip = actualDistance
+			api.extrapolateVehiclePosition(ctx, status, vehicle, geometry, stopTimes, scheduleDeviation, serviceDate, currentTime)
 
 			if scheduleDeviation != 0 && len(stopTimes) > 0 {
 				scheduledDistance := api.calculateEffectiveDistanceAlongTrip(
@@ -151,7 +232,6 @@ func (api *RestAPI) BuildTripStatus(
 		}
 	}
 
-	blockTripSequence := api.calculateBlockTripSequence(ctx, tripID, serviceDate)
 	if blockTripSequence > 0 {
 		status.BlockTripSequence = blockTripSequence
 	}
@@ -165,6 +245,10 @@ func (api *RestAPI) BuildTripSchedule(ctx context.Context, agencyID string, serv
 	if err != nil {
 		return nil, err
 	}
+	truncated := len(stopTimes) > api.maxTripStops()
+	if truncated {
+		stopTimes = stopTimes[:api.maxTripStops()]
+	}
 
 	shapeRows, err := api.GtfsManager.GtfsDB.Queries.GetShapePointsByTripID(ctx, trip.ID)
 	var shapePoints []gtfs.ShapePoint
@@ -172,11 +256,26 @@ func (api *RestAPI) BuildTripSchedule(ctx context.Context, agencyID string, serv
 		shapePoints = shapeRowsToPoints(shapeRows)
 	}
 
-	var nextTripID, previousTripID string
-	nextTripID, previousTripID, _, err = api.GetNextAndPreviousTripIDs(ctx, trip, agencyID, serviceDate)
+	previousTrip, nextTrip, err := api.adjacentBlockTrips(ctx, trip)
 	if err != nil {
 		return nil, err
 	}
+	var nextTripID, previousTripID string
+	var previousLayover, nextLayover *models.Layover
+	if previousTrip != "" {
+		previousTripID = utils.FormCombinedID(agencyID, previousTrip)
+		previousLayover, err = api.blockLayover(ctx, agencyID, previousTrip, trip.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if nextTrip != "" {
+		nextTripID = utils.FormCombinedID(agencyID, nextTrip)
+		nextLayover, err = api.blockLayover(ctx, agencyID, trip.ID, nextTrip)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Batch-fetch all stop coordinates at once
 	stopIDs := make([]string, len(stopTimes))
@@ -195,51 +294,152 @@ func (api *RestAPI) BuildTripSchedule(ctx context.Context, agencyID string, serv
 	}
 
 	stopTimesVals := api.calculateBatchStopDistances(stopTimes, shapePoints, stopCoords, agencyID)
+	if occupancy := api.GtfsManager.GetHistoricalOccupancyForTrip(ctx, trip.ID, serviceDate); len(occupancy) > 0 {
+		for i, st := range stopTimes {
+			stopTimesVals[i].HistoricalOccupancy = occupancy[st.StopID]
+		}
+	}
+	for i, prediction := range api.predictTripStopTimes(trip.ID, stopTimes, serviceDate) {
+		if prediction == nil {
+			continue
+		}
+		arrival := stopTimesVals[i].ArrivalTime + int(prediction.ArrivalDelay.Seconds())
+		departure := stopTimesVals[i].DepartureTime + int(prediction.DepartureDelay.Seconds())
+		stopTimesVals[i].PredictedArrivalTime = &arrival
+		stopTimesVals[i].PredictedDepartureTime = &departure
+	}
+
+	// Headway-based trips report the headway (in seconds) of their first window.
+	var frequency int64
+	frequencies, err := api.GtfsManager.GtfsDB.Queries.GetFrequenciesForTrip(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(frequencies) > 0 && frequencies[0].ExactTimes == 0 {
+		frequency = frequencies[0].HeadwaySecs
+	}
 
 	return &models.Schedule{
-		StopTimes:      stopTimesVals,
-		TimeZone:       loc.String(),
-		Frequency:      0,
-		NextTripID:     nextTripID,
-		PreviousTripID: previousTripID,
+		StopTimes:       stopTimesVals,
+		TimeZone:        loc.String(),
+		Frequency:       frequency,
+		NextTripID:      nextTripID,
+		PreviousTripID:  previousTripID,
+		PreviousLayover: previousLayover,
+		NextLayover:     nextLayover,
+		Truncated:       truncated,
 	}, nil
 }
 
+// blockLayover returns the layover between two consecutive trips of a block,
+// from the earlier trip's last arrival to the later trip's first departure, or
+// nil when either trip has no stop times.
 // IMPORTANT: Caller must hold manager.RLock() before calling this method.
-func (api *RestAPI) GetNextAndPreviousTripIDs(ctx context.Context, trip *gtfsdb.Trip, agencyID string, serviceDate time.Time) (nextTripID string, previousTripID string, stopTimes []gtfsdb.StopTime, err error) {
-	if !trip.BlockID.Valid {
-		return "", "", nil, nil
+func (api *RestAPI) blockLayover(ctx context.Context, agencyID, earlierTripID, laterTripID string) (*models.Layover, error) {
+	memo := tripDataMemoFromContext(ctx)
+	earlier, err := memo.stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, earlierTripID)
+	if err != nil {
+		return nil, err
 	}
+	later, err := memo.stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, laterTripID)
+	if err != nil {
+		return nil, err
+	}
+	if len(earlier) == 0 || len(later) == 0 {
+		return nil, nil
+	}
+
+	last := earlier[len(earlier)-1]
+	first := later[0]
+	return &models.Layover{
+		StopID:    utils.FormCombinedID(agencyID, last.StopID),
+		StartTime: int(utils.EffectiveStopTimeSeconds(last.ArrivalTime, last.DepartureTime)),
+		EndTime:   int(first.DepartureTime),
+	}, nil
+}
+
+// serviceDateForTrip returns the service date of the trip instance a request
+// at currentTime refers to. In the early hours a trip that runs past midnight
+// may still be on the previous day's service, so each service date the trip
+// can reach currentTime from is checked against calendar and calendar_dates.
+// The date the trip is running on wins, then the date of its next departure.
+// When neither exists, the calendar date of currentTime is used. Dates are
+// those of the timezone of the agency operating the trip.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) serviceDateForTrip(ctx context.Context, trip gtfsdb.Trip, currentTime time.Time) time.Time {
+	currentTime = currentTime.In(api.routeLocation(ctx, trip.RouteID))
+	calendarDate := utils.CalculateServiceDate(currentTime)
 
-	orderedTrips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockIDOrdered(ctx, gtfsdb.GetTripsByBlockIDOrderedParams{
-		BlockID:    trip.BlockID,
-		ServiceIds: []string{trip.ServiceID},
-	})
+	span, err := api.GtfsManager.GtfsDB.Queries.GetTripServiceSpan(ctx, trip.ID)
 	if err != nil {
-		return "", "", nil, err
+		return calendarDate
 	}
-	if len(orderedTrips) == 0 {
-		return "", "", nil, nil
+	firstDeparture := utils.NewServiceTime(span.FirstDepartureTime)
+	lastArrival := utils.NewServiceTime(span.LastArrivalTime)
+
+	var upcoming *time.Time
+	// Latest first, so the last upcoming date seen is the earliest departure.
+	for _, serviceDate := range utils.ServiceDatesAt(currentTime, lastArrival) {
+		active, err := api.GtfsManager.IsServiceActiveOnDate(ctx, trip.ServiceID, serviceDate)
+		if err != nil || active == 0 {
+			continue
+		}
+		now := utils.ServiceTimeAt(serviceDate, currentTime)
+		if now < firstDeparture {
+			upcoming = &serviceDate
+		} else if now <= lastArrival {
+			return serviceDate
+		}
+	}
+	if upcoming != nil {
+		return *upcoming
 	}
+	return calendarDate
+}
 
-	currentIndex := -1
+// adjacentBlockTrips returns the raw IDs of the trips before and after trip in
+// its block on the same service, or empty strings where there are none.
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) adjacentBlockTrips(ctx context.Context, trip *gtfsdb.Trip) (previous, next string, err error) {
+	if !trip.BlockID.Valid {
+		return "", "", nil
+	}
+
+	memo := tripDataMemoFromContext(ctx)
+	orderedTrips, err := memo.orderedBlockTrips(ctx, api.GtfsManager.GtfsDB.Queries, trip.BlockID, []string{trip.ServiceID})
+	if err != nil {
+		return "", "", err
+	}
 	for i, t := range orderedTrips {
-		if t.ID == trip.ID {
-			currentIndex = i
-			break
+		if t.ID != trip.ID {
+			continue
 		}
+		if i > 0 {
+			previous = orderedTrips[i-1].ID
+		}
+		if i < len(orderedTrips)-1 {
+			next = orderedTrips[i+1].ID
+		}
+		break
 	}
+	return previous, next, nil
+}
 
-	if currentIndex == -1 {
+// IMPORTANT: Caller must hold manager.RLock() before calling this method.
+func (api *RestAPI) GetNextAndPreviousTripIDs(ctx context.Context, trip *gtfsdb.Trip, agencyID string, serviceDate time.Time) (nextTripID string, previousTripID string, stopTimes []gtfsdb.StopTime, err error) {
+	if !trip.BlockID.Valid {
 		return "", "", nil, nil
 	}
 
-	if currentIndex > 0 {
-		previousTripID = utils.FormCombinedID(agencyID, orderedTrips[currentIndex-1].ID)
+	previous, next, err := api.adjacentBlockTrips(ctx, trip)
+	if err != nil {
+		return "", "", nil, err
 	}
-
-	if currentIndex < len(orderedTrips)-1 {
-		nextTripID = utils.FormCombinedID(agencyID, orderedTrips[currentIndex+1].ID)
+	if previous != "" {
+		previousTripID = utils.FormCombinedID(agencyID, previous)
+	}
+	if next != "" {
+		nextTripID = utils.FormCombinedID(agencyID, next)
 	}
 
 	stopTimes, err = api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, trip.ID)
@@ -251,7 +451,7 @@ func (api *RestAPI) GetNextAndPreviousTripIDs(ctx context.Context, trip *gtfsdb.
 }
 
 func (api *RestAPI) fillStopsFromSchedule(ctx context.Context, status *models.TripStatusForTripDetails, tripID string, currentTime time.Time, serviceDate time.Time, agencyID string) {
-	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
+	stopTimes, err := tripDataMemoFromContext(ctx).stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, tripID)
 	if err != nil {
 		slog.Warn("fillStopsFromSchedule: failed to get stop times",
 			slog.String("trip_id", tripID),
@@ -302,9 +502,9 @@ func findClosestStopByTimeWithDelays(currentTime time.Time, serviceDate time.Tim
 		// more relevant metric for predicting when the vehicle leaves a stop.
 		var stopTimeSeconds int64
 		if st.DepartureTime > 0 {
-			stopTimeSeconds = utils.NanosToSeconds(st.DepartureTime)
+			stopTimeSeconds = st.DepartureTime
 		} else if st.ArrivalTime > 0 {
-			stopTimeSeconds = utils.NanosToSeconds(st.ArrivalTime)
+			stopTimeSeconds = st.ArrivalTime
 		} else {
 			continue
 		}
@@ -345,9 +545,9 @@ func findNextStopByTimeWithDelays(currentTime time.Time, serviceDate time.Time,
 		// findClosestStopByTimeWithDelays for rationale.
 		var stopTimeSeconds int64
 		if st.DepartureTime > 0 {
-			stopTimeSeconds = utils.NanosToSeconds(st.DepartureTime)
+			stopTimeSeconds = st.DepartureTime
 		} else if st.ArrivalTime > 0 {
-			stopTimeSeconds = utils.NanosToSeconds(st.ArrivalTime)
+			stopTimeSeconds = st.ArrivalTime
 		} else {
 			continue
 		}
@@ -383,8 +583,15 @@ func getDistanceAlongShape(lat, lon float64, shape []gtfs.ShapePoint) float64 {
 	if len(shape) < 2 {
 		return 0
 	}
+	return distanceAlongShape(lat, lon, shape, preCalculateCumulativeDistances(shape))
+}
 
-	cumulativeDistances := preCalculateCumulativeDistances(shape)
+// distanceAlongShape is getDistanceAlongShape with the shape's cumulative distances
+// supplied by the caller, typically from the manager's shape geometry cache.
+func distanceAlongShape(lat, lon float64, shape []gtfs.ShapePoint, cumulativeDistances []float64) float64 {
+	if len(shape) < 2 {
+		return 0
+	}
 
 	var minDistance = math.Inf(1)
 	var closestSegmentIndex int
@@ -419,8 +626,16 @@ func getDistanceAlongShapeInRange(lat, lon float64, shape []gtfs.ShapePoint, min
 	if len(shape) < 2 {
 		return 0
 	}
+	return distanceAlongShapeInRange(lat, lon, shape, preCalculateCumulativeDistances(shape), minDistTraveled, maxDistTraveled)
+}
+
+// distanceAlongShapeInRange is getDistanceAlongShapeInRange with precomputed
+// cumulative distances.
+func distanceAlongShapeInRange(lat, lon float64, shape []gtfs.ShapePoint, cumulativeDistances []float64, minDistTraveled, maxDistTraveled float64) float64 {
+	if len(shape) < 2 {
+		return 0
+	}
 
-	cumulativeDistances := preCalculateCumulativeDistances(shape)
 	useRange := maxDistTraveled > minDistTraveled
 
 	var minDistance = math.Inf(1)
@@ -455,7 +670,7 @@ func getDistanceAlongShapeInRange(lat, lon float64, shape []gtfs.ShapePoint, min
 
 	// Fallback to full shape search if nothing found in range (GPS drift edge case)
 	if useRange && !foundInRange {
-		return getDistanceAlongShape(lat, lon, shape)
+		return distanceAlongShape(lat, lon, shape, cumulativeDistances)
 	}
 
 	var segmentLength float64
@@ -473,7 +688,8 @@ func getDistanceAlongShapeInRange(lat, lon float64, shape []gtfs.ShapePoint, min
 // for trips that are active on the given service date.
 // Uses GetTripsByBlockIDOrdered to perform a single SQL JOIN instead of N+1 queries.
 func (api *RestAPI) calculateBlockTripSequence(ctx context.Context, tripID string, serviceDate time.Time) int {
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+	memo := tripDataMemoFromContext(ctx)
+	trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, tripID)
 	if err != nil {
 		slog.Warn("calculateBlockTripSequence: failed to get trip",
 			slog.String("trip_id", tripID),
@@ -486,7 +702,7 @@ func (api *RestAPI) calculateBlockTripSequence(ctx context.Context, tripID strin
 	}
 
 	formattedDate := serviceDate.Format("20060102")
-	activeServiceIDs, err := api.GtfsManager.GtfsDB.Queries.GetActiveServiceIDsForDate(ctx, formattedDate)
+	activeServiceIDs, err := memo.activeServiceIDs(ctx, api.GtfsManager.GtfsDB.Queries, serviceDate)
 	if err != nil {
 		slog.Warn("calculateBlockTripSequence: failed to get active service IDs",
 			slog.String("trip_id", tripID),
@@ -498,10 +714,7 @@ func (api *RestAPI) calculateBlockTripSequence(ctx context.Context, tripID strin
 		return 0
 	}
 
-	orderedTrips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockIDOrdered(ctx, gtfsdb.GetTripsByBlockIDOrderedParams{
-		BlockID:    trip.BlockID,
-		ServiceIds: activeServiceIDs,
-	})
+	orderedTrips, err := memo.orderedBlockTrips(ctx, api.GtfsManager.GtfsDB.Queries, trip.BlockID, activeServiceIDs)
 	if err != nil {
 		slog.Warn("calculateBlockTripSequence: failed to get ordered block trips",
 			slog.String("trip_id", tripID),
@@ -573,7 +786,7 @@ func (api *RestAPI) calculatePreciseDistanceAlongTrip(ctx context.Context, stopI
 	}
 
 	// Get stop coordinates
-	stop, err := api.GtfsManager.GtfsDB.Queries.GetStop(ctx, stopID)
+	stop, err := api.GtfsManager.GtfsDB.GetStop(ctx, stopID)
 	if err != nil {
 		return 0.0
 	}
@@ -605,38 +818,10 @@ func preCalculateCumulativeDistances(shapePoints []gtfs.ShapePoint) []float64 {
 	return cumulativeDistances
 }
 
-// projectOntoSegment is the shared implementation for projecting a point onto a line segment.
-// Returns the distance from point to the closest point on the segment, the projection ratio t ∈ [0,1],
-func projectOntoSegment(px, py, x1, y1, x2, y2 float64) (distance, ratio float64, projLat, projLon float64) {
-	dx := x2 - x1
-	dy := y2 - y1
-
-	if dx == 0 && dy == 0 {
-		// Line segment is a point
-		return utils.Distance(px, py, x1, y1), 0, x1, y1
-	}
-
-	// Calculate the parameter t for the projection of point onto the line
-	t := ((px-x1)*dx + (py-y1)*dy) / (dx*dx + dy*dy)
-
-	// Clamp t to [0, 1] to stay within the line segment
-	if t < 0 {
-		t = 0
-	} else if t > 1 {
-		t = 1
-	}
-
-	// Find the closest point on the line segment
-	closestX := x1 + t*dx
-	closestY := y1 + t*dy
-
-	return utils.Distance(px, py, closestX, closestY), t, closestX, closestY
-}
-
 // distanceToLineSegment returns the distance from a point to the closest point on a line segment
 // and the projection ratio t ∈ [0,1].
 func distanceToLineSegment(px, py, x1, y1, x2, y2 float64) (distance, ratio float64) {
-	d, r, _, _ := projectOntoSegment(px, py, x1, y1, x2, y2)
+	d, r, _, _ := utils.ProjectOntoSegment(px, py, x1, y1, x2, y2)
 	return d, r
 }
 
@@ -646,10 +831,11 @@ func (api *RestAPI) GetSituationIDsForTrip(ctx context.Context, tripID string) [
 	var agencyID string
 
 	if api.GtfsManager.GtfsDB != nil {
-		trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, tripID)
+		memo := tripDataMemoFromContext(ctx)
+		trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, tripID)
 		if err == nil {
 			routeID = trip.RouteID
-			route, err := api.GtfsManager.GtfsDB.Queries.GetRoute(ctx, routeID)
+			route, err := memo.route(ctx, api.GtfsManager.GtfsDB, routeID)
 			if err == nil {
 				agencyID = route.AgencyID
 			} else if !errors.Is(err, sql.ErrNoRows) {
@@ -684,8 +870,46 @@ func (api *RestAPI) GetSituationIDsForTrip(ctx context.Context, tripID string) [
 	return situationIDs
 }
 
-func (api *RestAPI) calculateOffsetForStop(
+// stopVisitSequence returns the stop sequence of the visit to stopID a vehicle
+// reports. A trip that loops back visits the stop more than once: the visit
+// with the vehicle's reported current_stop_sequence wins, and otherwise the one
+// whose time, shifted by the schedule deviation, is nearest to now.
+func stopVisitSequence(
+	stopTimes []*gtfsdb.StopTime,
 	stopID string,
+	reportedSequence *uint32,
+	currentTime time.Time,
+	serviceDate time.Time,
+	scheduleDeviation int,
+) (int64, bool) {
+	currentTimeSeconds := utils.CalculateSecondsSinceServiceDate(currentTime, serviceDate)
+
+	var best *gtfsdb.StopTime
+	var bestGap int64
+	for _, st := range stopTimes {
+		if st.StopID != stopID {
+			continue
+		}
+		if reportedSequence != nil && uint32(st.StopSequence) == *reportedSequence {
+			return st.StopSequence, true
+		}
+		predicted := utils.EffectiveStopTimeSeconds(st.ArrivalTime, st.DepartureTime) + int64(scheduleDeviation)
+		gap := predicted - currentTimeSeconds
+		if gap < 0 {
+			gap = -gap
+		}
+		if best == nil || gap < bestGap {
+			best, bestGap = st, gap
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.StopSequence, true
+}
+
+func (api *RestAPI) calculateOffsetForStop(
+	stopSequence int64,
 	stopTimes []*gtfsdb.StopTime,
 	currentTime time.Time,
 	serviceDate time.Time,
@@ -694,7 +918,7 @@ func (api *RestAPI) calculateOffsetForStop(
 	currentTimeSeconds := utils.CalculateSecondsSinceServiceDate(currentTime, serviceDate)
 
 	for _, st := range stopTimes {
-		if st.StopID == stopID {
+		if st.StopSequence == stopSequence {
 			stopTimeSeconds := utils.EffectiveStopTimeSeconds(st.ArrivalTime, st.DepartureTime)
 			predictedArrival := stopTimeSeconds + int64(scheduleDeviation)
 			return int(predictedArrival - currentTimeSeconds)
@@ -705,7 +929,7 @@ func (api *RestAPI) calculateOffsetForStop(
 }
 
 func (api *RestAPI) findNextStopAfter(
-	currentStopID string,
+	currentStopSequence int64,
 	stopTimes []*gtfsdb.StopTime,
 	currentTime time.Time,
 	serviceDate time.Time,
@@ -718,7 +942,7 @@ func (api *RestAPI) findNextStopAfter(
 	currentTimeSeconds := utils.CalculateSecondsSinceServiceDate(currentTime, serviceDate)
 
 	for i, st := range stopTimes {
-		if st.StopID == currentStopID {
+		if st.StopSequence == currentStopSequence {
 			if i+1 < len(stopTimes) {
 				nextSt := stopTimes[i+1]
 				stopTimeSeconds := utils.EffectiveStopTimeSeconds(nextSt.ArrivalTime, nextSt.DepartureTime)
@@ -745,8 +969,8 @@ func (api *RestAPI) calculateBatchStopDistances(
 		for _, stopTime := range timeStops {
 			stopTimesList = append(stopTimesList, models.StopTime{
 				StopID:              utils.FormCombinedID(agencyID, stopTime.StopID),
-				ArrivalTime:         int(utils.NanosToSeconds(stopTime.ArrivalTime)),
-				DepartureTime:       int(utils.NanosToSeconds(stopTime.DepartureTime)),
+				ArrivalTime:         int(stopTime.ArrivalTime),
+				DepartureTime:       int(stopTime.DepartureTime),
 				StopHeadsign:        utils.NullStringOrEmpty(stopTime.StopHeadsign),
 				DistanceAlongTrip:   0.0,
 				HistoricalOccupancy: "",
@@ -755,14 +979,28 @@ func (api *RestAPI) calculateBatchStopDistances(
 		return stopTimesList
 	}
 
+	if distances, ok := storedStopDistances(timeStops); ok {
+		for i, stopTime := range timeStops {
+			stopTimesList = append(stopTimesList, models.StopTime{
+				StopID:              utils.FormCombinedID(agencyID, stopTime.StopID),
+				ArrivalTime:         int(stopTime.ArrivalTime),
+				DepartureTime:       int(stopTime.DepartureTime),
+				StopHeadsign:        utils.NullStringOrEmpty(stopTime.StopHeadsign),
+				DistanceAlongTrip:   distances[i],
+				HistoricalOccupancy: "",
+			})
+		}
+		return stopTimesList
+	}
+
 	// Pre-calculate cumulative distances
 	cumulativeDistances := preCalculateCumulativeDistances(shapePoints)
 	if len(cumulativeDistances) != len(shapePoints) {
 		for _, stopTime := range timeStops {
 			stopTimesList = append(stopTimesList, models.StopTime{
 				StopID:              utils.FormCombinedID(agencyID, stopTime.StopID),
-				ArrivalTime:         int(utils.NanosToSeconds(stopTime.ArrivalTime)),
-				DepartureTime:       int(utils.NanosToSeconds(stopTime.DepartureTime)),
+				ArrivalTime:         int(stopTime.ArrivalTime),
+				DepartureTime:       int(stopTime.DepartureTime),
 				StopHeadsign:        utils.NullStringOrEmpty(stopTime.StopHeadsign),
 				DistanceAlongTrip:   0.0,
 				HistoricalOccupancy: "",
@@ -771,6 +1009,7 @@ func (api *RestAPI) calculateBatchStopDistances(
 		return stopTimesList
 	}
 
+	earlyExitThreshold := api.stopDistanceEarlyExit()
 	lastMatchedIndex := 0
 
 	for _, stopTime := range timeStops {
@@ -785,14 +1024,19 @@ func (api *RestAPI) calculateBatchStopDistances(
 			if lastMatchedIndex >= len(shapePoints)-1 {
 				lastMatchedIndex = len(shapePoints) - 2
 			}
+			previousMatchedIndex := lastMatchedIndex
 
 			var minDistance = math.Inf(1)
 			var closestSegmentIndex = lastMatchedIndex
 			var projectionRatio float64
 
-			// Early exit threshold to speed up search
-			//This may be too conservative for some cases but helps performance significantly
-			const earlyExitThresholdMeters = 100.0
+			// The search stops once the shape has moved earlyExitThreshold
+			// farther from the stop than its closest segment so far. It
+			// regresses when the shape moves away from the stop and then comes
+			// back closer within that window, as it does where a route crosses
+			// or loops back on itself; the greedy pick may then be the wrong pass.
+			var peakSinceMin float64
+			regressed := false
 
 			// Start from lastMatchedIndex
 			for i := lastMatchedIndex; i < len(shapePoints)-1; i++ {
@@ -803,16 +1047,31 @@ func (api *RestAPI) calculateBatchStopDistances(
 				)
 
 				if distance < minDistance {
+					if peakSinceMin > minDistance+geo.StopMatchToleranceMeters {
+						regressed = true
+					}
 					minDistance = distance
+					peakSinceMin = distance
 					closestSegmentIndex = i
 					projectionRatio = ratio
 					lastMatchedIndex = i
-				} else if distance > minDistance+earlyExitThresholdMeters {
+				} else if distance > minDistance+earlyExitThreshold {
 					// Early exit:
 					break
+				} else if distance > peakSinceMin {
+					peakSinceMin = distance
 				}
 			}
 
+			// Verify the greedy pick. A regression, or a stop left farther from
+			// the shape than the threshold, falls back to a full scan of the
+			// rest of the shape for this stop.
+			if regressed || minDistance > earlyExitThreshold {
+				stop := geo.Point{Lat: stopLat, Lon: stopLon}
+				closestSegmentIndex, _, projectionRatio = geo.MatchStopToPass(shapePoints, stop, previousMatchedIndex, 0, utils.GeodesicProjection())
+				lastMatchedIndex = closestSegmentIndex
+			}
+
 			// Calculate distance along trip
 			var segmentLength float64
 			if closestSegmentIndex < len(shapePoints)-1 {
@@ -826,8 +1085,8 @@ func (api *RestAPI) calculateBatchStopDistances(
 
 		stopTimesList = append(stopTimesList, models.StopTime{
 			StopID:              utils.FormCombinedID(agencyID, stopTime.StopID),
-			ArrivalTime:         int(utils.NanosToSeconds(stopTime.ArrivalTime)),
-			DepartureTime:       int(utils.NanosToSeconds(stopTime.DepartureTime)),
+			ArrivalTime:         int(stopTime.ArrivalTime),
+			DepartureTime:       int(stopTime.DepartureTime),
 			StopHeadsign:        utils.NullStringOrEmpty(stopTime.StopHeadsign),
 			DistanceAlongTrip:   distanceAlongTrip,
 			HistoricalOccupancy: "",
@@ -951,7 +1210,8 @@ func (api *RestAPI) findNextStopBySequence(
 }
 
 func (api *RestAPI) getFirstStopOfNextTripInBlock(ctx context.Context, currentTripID string, serviceDate time.Time) *gtfsdb.StopTime {
-	trip, err := api.GtfsManager.GtfsDB.Queries.GetTrip(ctx, currentTripID)
+	memo := tripDataMemoFromContext(ctx)
+	trip, err := memo.trip(ctx, api.GtfsManager.GtfsDB, currentTripID)
 	if err != nil {
 		slog.Warn("getFirstStopOfNextTripInBlock: failed to get trip",
 			slog.String("trip_id", currentTripID),
@@ -962,10 +1222,7 @@ func (api *RestAPI) getFirstStopOfNextTripInBlock(ctx context.Context, currentTr
 		return nil
 	}
 
-	orderedTrips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockIDOrdered(ctx, gtfsdb.GetTripsByBlockIDOrderedParams{
-		BlockID:    trip.BlockID,
-		ServiceIds: []string{trip.ServiceID},
-	})
+	orderedTrips, err := memo.orderedBlockTrips(ctx, api.GtfsManager.GtfsDB.Queries, trip.BlockID, []string{trip.ServiceID})
 	if err != nil {
 		slog.Warn("getFirstStopOfNextTripInBlock: failed to get ordered block trips",
 			slog.String("trip_id", currentTripID),
@@ -984,7 +1241,7 @@ func (api *RestAPI) getFirstStopOfNextTripInBlock(ctx context.Context, currentTr
 
 	if currentIndex >= 0 && currentIndex+1 < len(orderedTrips) {
 		nextTripID := orderedTrips[currentIndex+1].ID
-		nextTripStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, nextTripID)
+		nextTripStopTimes, err := memo.stopTimesForTrip(ctx, api.GtfsManager.GtfsDB.Queries, nextTripID)
 		if err != nil {
 			slog.Warn("getFirstStopOfNextTripInBlock: failed to get stop times for next trip",
 				slog.String("next_trip_id", nextTripID),
@@ -992,7 +1249,8 @@ func (api *RestAPI) getFirstStopOfNextTripInBlock(ctx context.Context, currentTr
 			return nil
 		}
 		if len(nextTripStopTimes) > 0 {
-			return &nextTripStopTimes[0]
+			first := nextTripStopTimes[0]
+			return &first
 		}
 	}
 
@@ -1013,13 +1271,36 @@ func (api *RestAPI) calculateEffectiveDistanceAlongTrip(
 		return actualDistance
 	}
 
+	stopDistances := api.stopDistancesAlongShape(ctx, stopTimes, shapePoints, cumulativeDistances)
+	if stopDistances == nil {
+		return actualDistance
+	}
+
+	currentTimeSeconds := utils.CalculateSecondsSinceServiceDate(currentTime, serviceDate)
+	effectiveScheduleTime := currentTimeSeconds - int64(scheduleDeviation)
+
+	return interpolateDistanceAtScheduledTime(effectiveScheduleTime, stopTimes, stopDistances)
+}
+
+// stopDistancesAlongShape returns the distance along the shape of each stop
+// time's stop, or nil if any of the stops cannot be found.
+func (api *RestAPI) stopDistancesAlongShape(
+	ctx context.Context,
+	stopTimes []gtfsdb.StopTime,
+	shapePoints []gtfs.ShapePoint,
+	cumulativeDistances []float64,
+) []float64 {
+	if distances, ok := storedStopDistances(stopTimes); ok {
+		return distances
+	}
+
 	stopIDs := make([]string, len(stopTimes))
 	for i, st := range stopTimes {
 		stopIDs[i] = st.StopID
 	}
 	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, stopIDs)
 	if err != nil {
-		return actualDistance
+		return nil
 	}
 	stopByID := make(map[string]gtfsdb.Stop, len(stops))
 	for _, s := range stops {
@@ -1030,17 +1311,13 @@ func (api *RestAPI) calculateEffectiveDistanceAlongTrip(
 	for i, st := range stopTimes {
 		stop, ok := stopByID[st.StopID]
 		if !ok {
-			return actualDistance
+			return nil
 		}
 		stopDistances[i] = api.calculatePreciseDistanceAlongTripWithCoords(
 			stop.Lat, stop.Lon, shapePoints, cumulativeDistances,
 		)
 	}
-
-	currentTimeSeconds := utils.CalculateSecondsSinceServiceDate(currentTime, serviceDate)
-	effectiveScheduleTime := currentTimeSeconds - int64(scheduleDeviation)
-
-	return interpolateDistanceAtScheduledTime(effectiveScheduleTime, stopTimes, stopDistances)
+	return stopDistances
 }
 
 func interpolateDistanceAtScheduledTime(
@@ -1056,8 +1333,13 @@ func interpolateDistanceAtScheduledTime(
 		fromStop := stopTimes[i]
 		toStop := stopTimes[i+1]
 
-		fromTime := utils.NanosToSeconds(fromStop.DepartureTime)
-		toTime := utils.NanosToSeconds(toStop.ArrivalTime)
+		fromTime := fromStop.DepartureTime
+		toTime := toStop.ArrivalTime
+
+		// Dwelling at the stop.
+		if scheduledTime >= fromStop.ArrivalTime && scheduledTime < fromTime {
+			return cumulativeDistances[i]
+		}
 
 		if scheduledTime >= fromTime && scheduledTime <= toTime {
 			if toTime == fromTime {
@@ -1070,7 +1352,7 @@ func interpolateDistanceAtScheduledTime(
 		}
 	}
 
-	if scheduledTime < utils.NanosToSeconds(stopTimes[0].ArrivalTime) {
+	if scheduledTime < stopTimes[0].ArrivalTime {
 		return 0
 	}
 
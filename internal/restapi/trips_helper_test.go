@@ -2,7 +2,10 @@ package restapi
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"math"
+	"net/http"
 	"testing"
 	"time"
 
@@ -10,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/app"
+	"maglev.onebusaway.org/internal/appconf"
 	internalgtfs "maglev.onebusaway.org/internal/gtfs"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
@@ -362,6 +367,64 @@ func TestCalculateBatchStopDistances(t *testing.T) {
 	assert.NotZero(t, results[0].DistanceAlongTrip, "Distance should not be zero")
 }
 
+// loopingCrossingShape runs east through (0, 0.002), loops north and back
+// west within 100 m of that point, and crosses it again heading south. Points
+// are about 11 m apart.
+func loopingCrossingShape() []gtfs.ShapePoint {
+	var shape []gtfs.ShapePoint
+	leg := func(lat1, lon1, lat2, lon2 float64) {
+		steps := int(math.Round(math.Max(math.Abs(lat2-lat1), math.Abs(lon2-lon1)) / 0.0001))
+		for i := 0; i < steps; i++ {
+			f := float64(i) / float64(steps)
+			shape = append(shape, gtfs.ShapePoint{Latitude: lat1 + f*(lat2-lat1), Longitude: lon1 + f*(lon2-lon1)})
+		}
+	}
+	leg(0, 0, 0, 0.0026)
+	leg(0, 0.0026, 0.0006, 0.0026)
+	leg(0.0006, 0.0026, 0.0006, 0.002)
+	leg(0.0006, 0.002, -0.003, 0.002)
+	return append(shape, gtfs.ShapePoint{Latitude: -0.003, Longitude: 0.002})
+}
+
+func TestCalculateBatchStopDistances_SelfIntersectingShape(t *testing.T) {
+	shape := loopingCrossingShape()
+	stopCoords := map[string]struct{ lat, lon float64 }{
+		"start":    {lat: 0, lon: 0.0005},
+		"crossing": {lat: 0.00003, lon: 0.002}, // 3 m off the first pass, on the second
+		"loop":     {lat: 0.0006, lon: 0.0023},
+		"end":      {lat: -0.003, lon: 0.002},
+	}
+	stops := []gtfsdb.StopTime{
+		{StopID: "start", ArrivalTime: 100},
+		{StopID: "crossing", ArrivalTime: 200},
+		{StopID: "loop", ArrivalTime: 300},
+		{StopID: "end", ArrivalTime: 400},
+	}
+	metersPerDegree := utils.Distance(0, 0, 0, 1)
+
+	for _, threshold := range []float64{0, 10} {
+		t.Run(fmt.Sprintf("threshold %g", threshold), func(t *testing.T) {
+			api := &RestAPI{Application: &app.Application{Config: appconf.Config{StopDistanceEarlyExitMeters: threshold}}}
+			results := api.calculateBatchStopDistances(stops, shape, stopCoords, "agency_1")
+			require.Len(t, results, 4)
+
+			// The default threshold reaches the second pass through the crossing
+			// before giving up; the regression sends the stop to a full scan,
+			// which takes the first pass.
+			assert.InDelta(t, 0.002*metersPerDegree, results[1].DistanceAlongTrip, 2, "crossing is served on the first pass")
+			assert.InDelta(t, (0.0026+0.0006+0.0003)*metersPerDegree, results[2].DistanceAlongTrip, 2, "loop is served on the loop")
+			assert.Greater(t, results[3].DistanceAlongTrip, results[2].DistanceAlongTrip)
+		})
+	}
+}
+
+func TestStopDistanceEarlyExit(t *testing.T) {
+	assert.Equal(t, defaultStopDistanceEarlyExitMeters, (&RestAPI{}).stopDistanceEarlyExit())
+
+	api := &RestAPI{Application: &app.Application{Config: appconf.Config{StopDistanceEarlyExitMeters: 40}}}
+	assert.Equal(t, 40.0, api.stopDistanceEarlyExit())
+}
+
 // TestCalculatePreciseDistanceAlongTripWithCoords_Validation tests input validation
 func TestCalculatePreciseDistanceAlongTripWithCoords_Validation(t *testing.T) {
 	api := createTestApi(t)
@@ -714,6 +777,67 @@ func TestBuildTripStatus_VehicleIDFormat(t *testing.T) {
 	assert.Equal(t, utils.FormCombinedID(agencyID, vehicleID), model.VehicleID)
 }
 
+func TestBuildTripStatus_VehicleOccupancy(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	trips := api.GtfsManager.GetTrips()
+	api.GtfsManager.MockSetVehicleCapacity("CAPACITY_VEHICLE", 80)
+
+	tests := []struct {
+		name       string
+		vehicleID  string
+		percentage *uint32
+		capacity   int
+		count      int
+		load       int
+	}{
+		{"capacity and percentage", "CAPACITY_VEHICLE", uint32Ptr(45), 80, 36, 45},
+		{"percentage only", "UNKNOWN_VEHICLE", uint32Ptr(120), -1, -1, 120},
+		{"no occupancy", "SILENT_VEHICLE", nil, -1, -1, -1},
+	}
+	trip := trips[0]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A vehicle serving another trip of the block would stand in for a missing one
+			api.GtfsManager.MockResetRealTimeData()
+			api.GtfsManager.MockAddVehicleWithOptions(tt.vehicleID, trip.ID, trip.Route.Id, internalgtfs.MockVehicleOptions{
+				OccupancyPercentage: tt.percentage,
+			})
+
+			currentTime := time.Now()
+			status, err := api.BuildTripStatus(context.Background(), agencyID, trip.ID, currentTime, currentTime)
+			require.NoError(t, err)
+			assert.Equal(t, tt.capacity, status.OccupancyCapacity)
+			assert.Equal(t, tt.count, status.OccupancyCount)
+			assert.Equal(t, tt.load, status.OccupancyPercentage)
+		})
+	}
+}
+
+func TestBuildTripStatus_TripAssignmentInferred(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	agencyID := api.GtfsManager.GetAgencies()[0].Id
+	trip := api.GtfsManager.GetTrips()[0]
+
+	for _, inferred := range []bool{true, false} {
+		api.GtfsManager.MockResetRealTimeData()
+		api.GtfsManager.MockAddVehicleWithOptions("INFERRED_VEHICLE", trip.ID, trip.Route.Id, internalgtfs.MockVehicleOptions{
+			TripAssignmentInferred: inferred,
+		})
+
+		currentTime := time.Now()
+		status, err := api.BuildTripStatus(context.Background(), agencyID, trip.ID, currentTime, currentTime)
+		require.NoError(t, err)
+		assert.Equal(t, inferred, status.TripAssignmentInferred)
+	}
+}
+
 func makeStopTimePtrs(stops []gtfsdb.StopTime) []*gtfsdb.StopTime {
 	ptrs := make([]*gtfsdb.StopTime, len(stops))
 	for i := range stops {
@@ -722,17 +846,15 @@ func makeStopTimePtrs(stops []gtfsdb.StopTime) []*gtfsdb.StopTime {
 	return ptrs
 }
 
-func secondsToNanos(s int64) int64 { return s * int64(time.Second) }
-
 func TestFindClosestStopByTimeWithDelays_NoDelays(t *testing.T) {
 	// serviceDate at midnight UTC; currentTime = 08:00:00 UTC
 	serviceDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := []gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600)}, // 07:00
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600)}, // 08:00 — exact match
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600)}, // 09:00
+		{StopID: "s1", ArrivalTime: 7 * 3600}, // 07:00
+		{StopID: "s2", ArrivalTime: 8 * 3600}, // 08:00 — exact match
+		{StopID: "s3", ArrivalTime: 9 * 3600}, // 09:00
 	}
 
 	stopID, _ := findClosestStopByTimeWithDelays(currentTime, serviceDate, makeStopTimePtrs(stops), nil)
@@ -744,8 +866,8 @@ func TestFindClosestStopByTimeWithDelays_WithDelay(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := []gtfsdb.StopTime{
-		{StopID: "s1", DepartureTime: secondsToNanos(7 * 3600)}, // scheduled 07:00
-		{StopID: "s2", DepartureTime: secondsToNanos(9 * 3600)}, // scheduled 09:00
+		{StopID: "s1", DepartureTime: 7 * 3600}, // scheduled 07:00
+		{StopID: "s2", DepartureTime: 9 * 3600}, // scheduled 09:00
 	}
 	// delay of +60 minutes pushes s1 to 08:00 — closest to currentTime
 	delays := map[string]StopDelayInfo{
@@ -770,9 +892,9 @@ func TestFindNextStopByTimeWithDelays_NoDelays(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := []gtfsdb.StopTime{
-		{StopID: "s1", DepartureTime: secondsToNanos(7 * 3600)},  // past
-		{StopID: "s2", DepartureTime: secondsToNanos(9 * 3600)},  // first future stop
-		{StopID: "s3", DepartureTime: secondsToNanos(10 * 3600)}, // later future
+		{StopID: "s1", DepartureTime: 7 * 3600},  // past
+		{StopID: "s2", DepartureTime: 9 * 3600},  // first future stop
+		{StopID: "s3", DepartureTime: 10 * 3600}, // later future
 	}
 
 	stopID, offset := findNextStopByTimeWithDelays(currentTime, serviceDate, makeStopTimePtrs(stops), nil)
@@ -785,8 +907,8 @@ func TestFindNextStopByTimeWithDelays_AllStopsPast(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
 
 	stops := []gtfsdb.StopTime{
-		{StopID: "s1", DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", DepartureTime: 7 * 3600},
+		{StopID: "s2", DepartureTime: 9 * 3600},
 	}
 
 	stopID, _ := findNextStopByTimeWithDelays(currentTime, serviceDate, makeStopTimePtrs(stops), nil)
@@ -798,7 +920,7 @@ func TestFindNextStopByTimeWithDelays_WithDelay(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)
 
 	stops := []gtfsdb.StopTime{
-		{StopID: "s1", DepartureTime: secondsToNanos(8 * 3600)}, // scheduled 08:00
+		{StopID: "s1", DepartureTime: 8 * 3600}, // scheduled 08:00
 	}
 	// +90 minute delay pushes it to 09:30, making it the next stop
 	delays := map[string]StopDelayInfo{
@@ -890,12 +1012,12 @@ func TestCalculateOffsetForStop_MatchingStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", StopSequence: 3, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
-	offset := api.calculateOffsetForStop("s2", stops, currentTime, serviceDate, 0)
+	offset := api.calculateOffsetForStop(2, stops, currentTime, serviceDate, 0)
 	// predicted arrival = 28800 + 0 = 28800; current = 28800; offset = 0
 	assert.Equal(t, 0, offset, "on-time vehicle at exact stop time should have offset 0")
 }
@@ -907,10 +1029,10 @@ func TestCalculateOffsetForStop_NonMatchingStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
 	})
 
-	offset := api.calculateOffsetForStop("nonexistent", stops, currentTime, serviceDate, 0)
+	offset := api.calculateOffsetForStop(99, stops, currentTime, serviceDate, 0)
 	assert.Equal(t, 0, offset, "non-matching stop should return 0")
 }
 
@@ -921,11 +1043,11 @@ func TestCalculateOffsetForStop_WithDeviation(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
 	})
 
 	// 5-minute late deviation
-	offset := api.calculateOffsetForStop("s1", stops, currentTime, serviceDate, 300)
+	offset := api.calculateOffsetForStop(1, stops, currentTime, serviceDate, 300)
 	// predicted arrival = 28800 + 300 = 29100; current = 28800; offset = 300
 	assert.Equal(t, 300, offset, "offset should reflect 5-minute delay")
 }
@@ -936,7 +1058,7 @@ func TestCalculateOffsetForStop_EmptyStops(t *testing.T) {
 	serviceDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
-	offset := api.calculateOffsetForStop("s1", nil, currentTime, serviceDate, 0)
+	offset := api.calculateOffsetForStop(1, nil, currentTime, serviceDate, 0)
 	assert.Equal(t, 0, offset, "empty stop times should return 0")
 }
 
@@ -947,12 +1069,12 @@ func TestFindNextStopAfter_MidTrip(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", StopSequence: 3, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
-	nextStopID, nextOffset := api.findNextStopAfter("s2", stops, currentTime, serviceDate, 0)
+	nextStopID, nextOffset := api.findNextStopAfter(2, stops, currentTime, serviceDate, 0)
 	assert.Equal(t, "s3", nextStopID, "next stop after s2 should be s3")
 	// predicted arrival for s3 = 32400 + 0 = 32400; current = 28800; offset = 3600
 	assert.Equal(t, 3600, nextOffset, "offset should be 1 hour")
@@ -965,11 +1087,11 @@ func TestFindNextStopAfter_LastStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
-	nextStopID, nextOffset := api.findNextStopAfter("s2", stops, currentTime, serviceDate, 0)
+	nextStopID, nextOffset := api.findNextStopAfter(2, stops, currentTime, serviceDate, 0)
 	assert.Empty(t, nextStopID, "no next stop after last stop")
 	assert.Equal(t, 0, nextOffset, "offset should be 0 when no next stop")
 }
@@ -981,12 +1103,12 @@ func TestFindNextStopAfter_WithDeviation(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
 	})
 
 	// 5-minute late deviation
-	nextStopID, nextOffset := api.findNextStopAfter("s1", stops, currentTime, serviceDate, 300)
+	nextStopID, nextOffset := api.findNextStopAfter(1, stops, currentTime, serviceDate, 300)
 	assert.Equal(t, "s2", nextStopID)
 	// predicted arrival for s2 = 28800 + 300 = 29100; current = 28800; offset = 300
 	assert.Equal(t, 300, nextOffset, "offset should include schedule deviation")
@@ -999,10 +1121,10 @@ func TestFindNextStopAfter_NonMatchingStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
 	})
 
-	nextStopID, nextOffset := api.findNextStopAfter("nonexistent", stops, currentTime, serviceDate, 0)
+	nextStopID, nextOffset := api.findNextStopAfter(99, stops, currentTime, serviceDate, 0)
 	assert.Empty(t, nextStopID, "non-matching stop should return empty")
 	assert.Equal(t, 0, nextOffset)
 }
@@ -1013,11 +1135,143 @@ func TestFindNextStopAfter_EmptyStops(t *testing.T) {
 	serviceDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
-	nextStopID, nextOffset := api.findNextStopAfter("s1", nil, currentTime, serviceDate, 0)
+	nextStopID, nextOffset := api.findNextStopAfter(1, nil, currentTime, serviceDate, 0)
 	assert.Empty(t, nextStopID, "empty stops should return empty")
 	assert.Equal(t, 0, nextOffset)
 }
 
+func TestStopVisitSequence_LoopTrip(t *testing.T) {
+	serviceDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return time.Date(2024, 1, 1, h, m, 0, 0, time.UTC) }
+
+	// The trip leaves s1 and loops back to it.
+	stops := makeStopTimePtrs([]gtfsdb.StopTime{
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8*3600 + 1200, DepartureTime: 8*3600 + 1200},
+		{StopID: "s1", StopSequence: 3, ArrivalTime: 8*3600 + 2400, DepartureTime: 8*3600 + 2400},
+	})
+	third := uint32(3)
+	first := uint32(1)
+
+	tests := []struct {
+		name      string
+		stopID    string
+		reported  *uint32
+		now       time.Time
+		deviation int
+		expected  int64
+		found     bool
+	}{
+		{name: "nearest visit at the start", stopID: "s1", now: at(7, 58), expected: 1, found: true},
+		{name: "nearest visit at the end", stopID: "s1", now: at(8, 39), expected: 3, found: true},
+		{name: "late vehicle still nearing the first visit", stopID: "s1", now: at(8, 25), deviation: 1800, expected: 1, found: true},
+		{name: "reported sequence wins over time", stopID: "s1", reported: &third, now: at(8, 1), expected: 3, found: true},
+		{name: "reported sequence of another stop is ignored", stopID: "s2", reported: &first, now: at(8, 20), expected: 2, found: true},
+		{name: "stop not on the trip", stopID: "s9", now: at(8, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sequence, ok := stopVisitSequence(stops, tt.stopID, tt.reported, tt.now, serviceDate, tt.deviation)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, sequence)
+		})
+	}
+}
+
+func TestFindNextStopAfter_LoopTrip(t *testing.T) {
+	api := &RestAPI{}
+
+	serviceDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
+
+	stops := makeStopTimePtrs([]gtfsdb.StopTime{
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
+		{StopID: "s1", StopSequence: 3, ArrivalTime: 10 * 3600, DepartureTime: 10 * 3600},
+		{StopID: "s3", StopSequence: 4, ArrivalTime: 11 * 3600, DepartureTime: 11 * 3600},
+	})
+
+	nextStopID, nextOffset := api.findNextStopAfter(1, stops, currentTime, serviceDate, 0)
+	assert.Equal(t, "s2", nextStopID, "next stop after the first visit")
+	assert.Equal(t, 3600, nextOffset)
+
+	nextStopID, nextOffset = api.findNextStopAfter(3, stops, currentTime, serviceDate, 0)
+	assert.Equal(t, "s3", nextStopID, "next stop after the second visit")
+	assert.Equal(t, 3*3600, nextOffset)
+
+	assert.Equal(t, 2*3600, api.calculateOffsetForStop(3, stops, currentTime, serviceDate, 0), "offset of the second visit")
+}
+
+func TestBuildTripStatus_LoopTripVehicleAtRepeatedStop(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+	ctx := context.Background()
+
+	// This route 159 trip leaves stop 4000 at 16:20 (sequence 0), loops and
+	// returns to it at 17:15 (sequence 28).
+	const loopTrip = "t_74134_b_18260_tn_11"
+	stops, err := api.GtfsManager.GtfsDB.Queries.GetStopsByIDs(ctx, []string{"4000"})
+	require.NoError(t, err)
+	require.Len(t, stops, 1)
+	lat, lon := float32(stops[0].Lat), float32(stops[0].Lon)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	serviceDate := time.Date(2025, 6, 10, 0, 0, 0, 0, loc)
+	stoppedAt := gtfs.CurrentStatus(1)
+	inTransit := gtfs.CurrentStatus(2)
+	lastVisit := uint32(28)
+
+	tests := []struct {
+		name          string
+		at            time.Time
+		status        *gtfs.CurrentStatus
+		sequence      *uint32
+		closestOffset int
+		nextStop      string
+	}{
+		{
+			name: "approaching the first visit", at: time.Date(2025, 6, 10, 16, 19, 0, 0, loc),
+			status: &inTransit, closestOffset: 60, nextStop: "4000",
+		},
+		{
+			name: "stopped at the first visit", at: time.Date(2025, 6, 10, 16, 20, 0, 0, loc),
+			status: &stoppedAt, closestOffset: 0, nextStop: "1422",
+		},
+		{
+			name: "back at the stop for the last visit", at: time.Date(2025, 6, 10, 17, 14, 0, 0, loc),
+			status: &inTransit, closestOffset: 60, nextStop: "4000",
+		},
+		{
+			name: "reported sequence of the last visit", at: time.Date(2025, 6, 10, 16, 25, 0, 0, loc),
+			status: &inTransit, sequence: &lastVisit, closestOffset: 50 * 60, nextStop: "4000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.GtfsManager.MockResetRealTimeData()
+			stopID := "4000"
+			timestamp := tt.at
+			api.GtfsManager.MockAddVehicleWithOptions("LOOP_BUS", loopTrip, "159", internalgtfs.MockVehicleOptions{
+				Position:            &gtfs.Position{Latitude: &lat, Longitude: &lon},
+				StopID:              &stopID,
+				CurrentStatus:       tt.status,
+				CurrentStopSequence: tt.sequence,
+				Timestamp:           &timestamp,
+			})
+
+			status, err := api.BuildTripStatus(ctx, "25", loopTrip, serviceDate, tt.at)
+			require.NoError(t, err)
+
+			assert.Equal(t, utils.FormCombinedID("25", "4000"), status.ClosestStop)
+			assert.Equal(t, tt.closestOffset, status.ClosestStopTimeOffset)
+			assert.Equal(t, utils.FormCombinedID("25", tt.nextStop), status.NextStop)
+		})
+	}
+}
+
 func TestBuildTripStatus_VehicleWithStopID_FindsStops(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -1338,9 +1592,9 @@ func TestFindClosestStopBySequence_Match(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s into service day
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", StopSequence: 2, ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", StopSequence: 3, ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", StopSequence: 3, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	stopID, offset := api.findClosestStopBySequence(stops, 2, currentTime, serviceDate, 0)
@@ -1356,7 +1610,7 @@ func TestFindClosestStopBySequence_WithDeviation(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
 	})
 
 	// Vehicle is 5 minutes late
@@ -1373,7 +1627,7 @@ func TestFindClosestStopBySequence_NoMatch(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600},
 	})
 
 	stopID, offset := api.findClosestStopBySequence(stops, 99, currentTime, serviceDate, 0)
@@ -1389,9 +1643,9 @@ func TestFindNextStopBySequence_InTransit(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", StopSequence: 2, ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", StopSequence: 3, ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", StopSequence: 3, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	// Vehicle is IN_TRANSIT_TO (CurrentStatus 2 = the default for nil)
@@ -1412,9 +1666,9 @@ func TestFindNextStopBySequence_StoppedAt(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", StopSequence: 2, ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", StopSequence: 3, ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", StopSequence: 3, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	// Vehicle is STOPPED_AT (CurrentStatus 1) at stop sequence 2
@@ -1437,8 +1691,8 @@ func TestFindNextStopBySequence_StoppedAtLastStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s2", StopSequence: 2, ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s2", StopSequence: 2, ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	stoppedAt := gtfs.CurrentStatus(1)
@@ -1458,7 +1712,7 @@ func TestFindNextStopBySequence_NoMatch(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", StopSequence: 1, ArrivalTime: secondsToNanos(8 * 3600)},
+		{StopID: "s1", StopSequence: 1, ArrivalTime: 8 * 3600},
 	})
 
 	stopID, offset := api.findNextStopBySequence(ctx, stops, 99, currentTime, serviceDate, 0, nil, "trip1")
@@ -1473,9 +1727,9 @@ func TestFindStopsByScheduleDeviation_OnTime(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC) // 28800s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	closestStopID, closestOffset, nextStopID, nextOffset := api.findStopsByScheduleDeviation(stops, currentTime, serviceDate, 0)
@@ -1497,9 +1751,9 @@ func TestFindStopsByScheduleDeviation_Late(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 5, 0, 0, time.UTC) // 28800 + 300 = 29100s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	// Vehicle is 5 minutes late (300s deviation)
@@ -1534,8 +1788,8 @@ func TestFindStopsByScheduleDeviation_LastStop(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC) // 32400s
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s2", ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	closestStopID, _, nextStopID, _ := api.findStopsByScheduleDeviation(stops, currentTime, serviceDate, 0)
@@ -1545,8 +1799,8 @@ func TestFindStopsByScheduleDeviation_LastStop(t *testing.T) {
 
 func TestInterpolateDistanceAtScheduledTime_BetweenStops(t *testing.T) {
 	stopTimes := []gtfsdb.StopTime{
-		{DepartureTime: secondsToNanos(100), ArrivalTime: secondsToNanos(100)},
-		{DepartureTime: secondsToNanos(200), ArrivalTime: secondsToNanos(200)},
+		{DepartureTime: 100, ArrivalTime: 100},
+		{DepartureTime: 200, ArrivalTime: 200},
 	}
 	distances := []float64{0.0, 1000.0}
 
@@ -1557,9 +1811,9 @@ func TestInterpolateDistanceAtScheduledTime_BetweenStops(t *testing.T) {
 
 func TestInterpolateDistanceAtScheduledTime_AtStopBoundaries(t *testing.T) {
 	stopTimes := []gtfsdb.StopTime{
-		{DepartureTime: secondsToNanos(100), ArrivalTime: secondsToNanos(100)},
-		{DepartureTime: secondsToNanos(200), ArrivalTime: secondsToNanos(200)},
-		{DepartureTime: secondsToNanos(300), ArrivalTime: secondsToNanos(300)},
+		{DepartureTime: 100, ArrivalTime: 100},
+		{DepartureTime: 200, ArrivalTime: 200},
+		{DepartureTime: 300, ArrivalTime: 300},
 	}
 	distances := []float64{0.0, 500.0, 1500.0}
 
@@ -1574,8 +1828,8 @@ func TestInterpolateDistanceAtScheduledTime_AtStopBoundaries(t *testing.T) {
 
 func TestInterpolateDistanceAtScheduledTime_BeforeFirstStop(t *testing.T) {
 	stopTimes := []gtfsdb.StopTime{
-		{DepartureTime: secondsToNanos(100), ArrivalTime: secondsToNanos(100)},
-		{DepartureTime: secondsToNanos(200), ArrivalTime: secondsToNanos(200)},
+		{DepartureTime: 100, ArrivalTime: 100},
+		{DepartureTime: 200, ArrivalTime: 200},
 	}
 	distances := []float64{0.0, 1000.0}
 
@@ -1585,8 +1839,8 @@ func TestInterpolateDistanceAtScheduledTime_BeforeFirstStop(t *testing.T) {
 
 func TestInterpolateDistanceAtScheduledTime_AfterLastStop(t *testing.T) {
 	stopTimes := []gtfsdb.StopTime{
-		{DepartureTime: secondsToNanos(100), ArrivalTime: secondsToNanos(100)},
-		{DepartureTime: secondsToNanos(200), ArrivalTime: secondsToNanos(200)},
+		{DepartureTime: 100, ArrivalTime: 100},
+		{DepartureTime: 200, ArrivalTime: 200},
 	}
 	distances := []float64{0.0, 1000.0}
 
@@ -1597,16 +1851,16 @@ func TestInterpolateDistanceAtScheduledTime_AfterLastStop(t *testing.T) {
 func TestInterpolateDistanceAtScheduledTime_EmptyInput(t *testing.T) {
 	assert.Equal(t, 0.0, interpolateDistanceAtScheduledTime(100, nil, nil))
 	assert.Equal(t, 0.0, interpolateDistanceAtScheduledTime(100,
-		[]gtfsdb.StopTime{{DepartureTime: secondsToNanos(100)}},
+		[]gtfsdb.StopTime{{DepartureTime: 100}},
 		[]float64{0.0, 1.0}), // mismatched lengths
 	)
 }
 
 func TestInterpolateDistanceAtScheduledTime_MultipleSegments(t *testing.T) {
 	stopTimes := []gtfsdb.StopTime{
-		{DepartureTime: secondsToNanos(0), ArrivalTime: secondsToNanos(0)},
-		{DepartureTime: secondsToNanos(100), ArrivalTime: secondsToNanos(100)},
-		{DepartureTime: secondsToNanos(300), ArrivalTime: secondsToNanos(300)},
+		{DepartureTime: 0, ArrivalTime: 0},
+		{DepartureTime: 100, ArrivalTime: 100},
+		{DepartureTime: 300, ArrivalTime: 300},
 	}
 	distances := []float64{0.0, 500.0, 1500.0}
 
@@ -1619,6 +1873,18 @@ func TestInterpolateDistanceAtScheduledTime_MultipleSegments(t *testing.T) {
 	assert.InDelta(t, 1000.0, d, 0.01)
 }
 
+func TestInterpolateDistanceAtScheduledTime_DwellingAtStop(t *testing.T) {
+	stopTimes := []gtfsdb.StopTime{
+		{ArrivalTime: 0, DepartureTime: 0},
+		{ArrivalTime: 100, DepartureTime: 160},
+		{ArrivalTime: 260, DepartureTime: 260},
+	}
+	distances := []float64{0.0, 500.0, 1500.0}
+
+	d := interpolateDistanceAtScheduledTime(130, stopTimes, distances)
+	assert.InDelta(t, 500.0, d, 0.01, "vehicle should wait at the stop until its departure")
+}
+
 func TestGetDistanceAlongShape_Projection(t *testing.T) {
 	shape := []gtfs.ShapePoint{
 		{Latitude: 0.0, Longitude: 0.0},
@@ -1667,9 +1933,9 @@ func TestFindStopsByScheduleDeviation_Early(t *testing.T) {
 	currentTime := time.Date(2024, 1, 1, 8, 5, 0, 0, time.UTC)
 
 	stops := makeStopTimePtrs([]gtfsdb.StopTime{
-		{StopID: "s1", ArrivalTime: secondsToNanos(7 * 3600), DepartureTime: secondsToNanos(7 * 3600)},
-		{StopID: "s2", ArrivalTime: secondsToNanos(8 * 3600), DepartureTime: secondsToNanos(8 * 3600)},
-		{StopID: "s3", ArrivalTime: secondsToNanos(9 * 3600), DepartureTime: secondsToNanos(9 * 3600)},
+		{StopID: "s1", ArrivalTime: 7 * 3600, DepartureTime: 7 * 3600},
+		{StopID: "s2", ArrivalTime: 8 * 3600, DepartureTime: 8 * 3600},
+		{StopID: "s3", ArrivalTime: 9 * 3600, DepartureTime: 9 * 3600},
 	})
 
 	// Vehicle is 5 minutes early (deviation = -300s).
@@ -1725,3 +1991,209 @@ func TestGetFirstStopOfNextTripInBlock_WithBlockContinuation(t *testing.T) {
 	assert.NotNil(t, result, "should find the first stop of the next block trip")
 	assert.NotEmpty(t, result.StopID, "returned stop should have a non-empty StopID")
 }
+
+func TestServiceDateForTripAfterMidnight(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	queries := client.Queries
+
+	// An overnight trip on a service running every day of 2025.
+	trip, err := queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:        "OVERNIGHT_TEST",
+		RouteID:   "24",
+		ServiceID: "c_2713_b_80332_d_49 (MoTuWeThFrSaSu)",
+	})
+	require.NoError(t, err)
+	for i, st := range []struct {
+		stopID string
+		at     time.Duration
+	}{{"2000", 23*time.Hour + 50*time.Minute}, {"1030", 24*time.Hour + 40*time.Minute}} {
+		_, err = queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+			TripID:        trip.ID,
+			StopID:        st.stopID,
+			StopSequence:  int64(i + 1),
+			ArrivalTime:   utils.StopTimeSeconds(st.at),
+			DepartureTime: utils.StopTimeSeconds(st.at),
+		})
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM stop_times WHERE trip_id = 'OVERNIGHT_TEST'")
+		assert.NoError(t, err)
+		_, err = client.DB.ExecContext(context.Background(), "DELETE FROM trips WHERE id = 'OVERNIGHT_TEST'")
+		assert.NoError(t, err)
+	})
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	june12 := time.Date(2025, 6, 12, 0, 0, 0, 0, loc)
+	june13 := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected time.Time
+	}{
+		{"before midnight", time.Date(2025, 6, 12, 23, 55, 0, 0, loc), june12},
+		{"after midnight on the previous day's service", time.Date(2025, 6, 13, 0, 20, 0, 0, loc), june12},
+		{"after the trip has finished", time.Date(2025, 6, 13, 1, 0, 0, 0, loc), june13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected.Unix(), api.serviceDateForTrip(ctx, trip, tt.at).Unix())
+		})
+	}
+
+	at := time.Date(2025, 6, 13, 0, 20, 0, 0, loc)
+	resp, model := serveApiAndRetrieveEndpoint(t, api, fmt.Sprintf(
+		"/api/where/trip-details/25_OVERNIGHT_TEST.json?key=TEST&includeSchedule=false&time=%d", at.UnixMilli()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(june12.UnixMilli()), entry["serviceDate"])
+}
+
+func TestServiceDateForTripChecksCalendar(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	queries := client.Queries
+
+	// A trip that only departs after midnight, on a Monday to Friday service.
+	trip, err := queries.CreateTrip(ctx, gtfsdb.CreateTripParams{
+		ID:        "AFTER_MIDNIGHT_TEST",
+		RouteID:   "24",
+		ServiceID: "c_1658_b_18260_d_31",
+	})
+	require.NoError(t, err)
+	for i, st := range []struct {
+		stopID string
+		at     time.Duration
+	}{{"2000", 24*time.Hour + 30*time.Minute}, {"1030", 25 * time.Hour}} {
+		_, err = queries.CreateStopTime(ctx, gtfsdb.CreateStopTimeParams{
+			TripID:        trip.ID,
+			StopID:        st.stopID,
+			StopSequence:  int64(i + 1),
+			ArrivalTime:   utils.StopTimeSeconds(st.at),
+			DepartureTime: utils.StopTimeSeconds(st.at),
+		})
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM stop_times WHERE trip_id = 'AFTER_MIDNIGHT_TEST'")
+		assert.NoError(t, err)
+		_, err = client.DB.ExecContext(context.Background(), "DELETE FROM trips WHERE id = 'AFTER_MIDNIGHT_TEST'")
+		assert.NoError(t, err)
+	})
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	thursday := time.Date(2025, 6, 12, 0, 0, 0, 0, loc)
+	friday := time.Date(2025, 6, 13, 0, 0, 0, 0, loc)
+	sunday := time.Date(2025, 6, 15, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected time.Time
+	}{
+		{"early hours run on the previous day's service", time.Date(2025, 6, 13, 0, 10, 0, 0, loc), thursday},
+		{"during the day the next departure is tonight", time.Date(2025, 6, 13, 12, 0, 0, 0, loc), friday},
+		{"early Saturday still runs on Friday's service", time.Date(2025, 6, 14, 0, 10, 0, 0, loc), friday},
+		{"no service falls back to the calendar date", time.Date(2025, 6, 15, 12, 0, 0, 0, loc), sunday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected.Unix(), api.serviceDateForTrip(ctx, trip, tt.at).Unix())
+		})
+	}
+
+	at := time.Date(2025, 6, 14, 0, 10, 0, 0, loc)
+	resp, model := serveApiAndRetrieveEndpoint(t, api, fmt.Sprintf(
+		"/api/where/trip-details/25_AFTER_MIDNIGHT_TEST.json?key=TEST&includeSchedule=false&time=%d", at.UnixMilli()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, float64(friday.UnixMilli()), entry["serviceDate"])
+	status, ok := entry["status"].(map[string]interface{})
+	require.True(t, ok, "trip details should include the trip status")
+	assert.Equal(t, float64(friday.UnixMilli()), status["serviceDate"])
+}
+
+func TestBuildTripScheduleBlockLayovers(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	ctx := context.Background()
+
+	// A trip in the middle of a block has trips on either side of it.
+	var blockID, serviceID string
+	err := api.GtfsManager.GtfsDB.DB.QueryRowContext(ctx, `
+		SELECT block_id, service_id FROM trips
+		WHERE block_id IS NOT NULL AND block_id != ''
+		GROUP BY block_id, service_id HAVING COUNT(*) >= 3 LIMIT 1`).Scan(&blockID, &serviceID)
+	require.NoError(t, err)
+	ordered, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockIDOrdered(ctx, gtfsdb.GetTripsByBlockIDOrderedParams{
+		BlockID:    sql.NullString{String: blockID, Valid: true},
+		ServiceIds: []string{serviceID},
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(ordered), 3)
+
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, ordered[1].ID)
+	require.NoError(t, err)
+	loc := time.UTC
+	schedule, err := api.BuildTripSchedule(ctx, "25", time.Date(2025, 6, 12, 0, 0, 0, 0, loc), &trip, loc)
+	require.NoError(t, err)
+
+	assert.Equal(t, utils.FormCombinedID("25", ordered[0].ID), schedule.PreviousTripID)
+	assert.Equal(t, utils.FormCombinedID("25", ordered[2].ID), schedule.NextTripID)
+
+	previousStopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, ordered[0].ID)
+	require.NoError(t, err)
+	lastOfPrevious := previousStopTimes[len(previousStopTimes)-1]
+	require.NotNil(t, schedule.PreviousLayover)
+	assert.Equal(t, utils.FormCombinedID("25", lastOfPrevious.StopID), schedule.PreviousLayover.StopID)
+	assert.Equal(t, int(lastOfPrevious.ArrivalTime), schedule.PreviousLayover.StartTime)
+	assert.Equal(t, schedule.StopTimes[0].DepartureTime, schedule.PreviousLayover.EndTime)
+	assert.GreaterOrEqual(t, schedule.PreviousLayover.Duration(), 0)
+
+	require.NotNil(t, schedule.NextLayover)
+	last := schedule.StopTimes[len(schedule.StopTimes)-1]
+	assert.Equal(t, last.StopID, schedule.NextLayover.StopID)
+	assert.Equal(t, last.ArrivalTime, schedule.NextLayover.StartTime)
+	assert.GreaterOrEqual(t, schedule.NextLayover.Duration(), 0)
+
+	// The first trip of the block has nothing to lay over from.
+	first, err := api.GtfsManager.GtfsDB.GetTrip(ctx, ordered[0].ID)
+	require.NoError(t, err)
+	schedule, err = api.BuildTripSchedule(ctx, "25", time.Date(2025, 6, 12, 0, 0, 0, 0, loc), &first, loc)
+	require.NoError(t, err)
+	assert.Nil(t, schedule.PreviousLayover)
+	assert.NotNil(t, schedule.NextLayover)
+}
+
+func TestBuildTripScheduleTruncatesLongTrips(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.Config.MaxTripStops = 2
+	ctx := context.Background()
+
+	var tripID string
+	err := api.GtfsManager.GtfsDB.DB.QueryRowContext(ctx, `
+		SELECT trip_id FROM stop_times GROUP BY trip_id HAVING COUNT(*) > 2 LIMIT 1`).Scan(&tripID)
+	require.NoError(t, err)
+	trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, tripID)
+	require.NoError(t, err)
+
+	loc := time.UTC
+	schedule, err := api.BuildTripSchedule(ctx, "25", time.Date(2025, 6, 12, 0, 0, 0, 0, loc), &trip, loc)
+	require.NoError(t, err)
+	assert.True(t, schedule.Truncated)
+	require.Len(t, schedule.StopTimes, 2)
+	stopTimes, err := api.GtfsManager.GtfsDB.Queries.GetStopTimesForTrip(ctx, tripID)
+	require.NoError(t, err)
+	assert.Equal(t, utils.FormCombinedID("25", stopTimes[1].StopID), schedule.StopTimes[1].StopID, "the first stops are the ones kept")
+}
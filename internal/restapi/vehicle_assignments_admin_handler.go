@@ -0,0 +1,133 @@
+package restapi
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	// defaultVehicleAssignmentTTL is how long an assignment lasts when the
+	// request gives no expiresAt, roughly one run of a block.
+	defaultVehicleAssignmentTTL = 4 * time.Hour
+	maxVehicleAssignmentTTL     = 24 * time.Hour
+)
+
+func (api *RestAPI) listVehicleAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	assignments := api.GtfsManager.VehicleAssignments(api.Clock.Now())
+	list := make([]models.VehicleAssignment, 0, len(assignments))
+	for _, a := range assignments {
+		list = append(list, newVehicleAssignmentModel(a))
+	}
+	api.sendResponse(w, r, models.NewListResponse(list, models.NewEmptyReferences(), false, api.Clock))
+}
+
+// createVehicleAssignmentHandler assigns a vehicle from a JSON body with
+// vehicleId and exactly one of tripId and blockId, all agency-prefixed, and an
+// optional expiresAt (milliseconds since the epoch, at most a day ahead). The
+// assignment replaces any earlier one of the vehicle.
+func (api *RestAPI) createVehicleAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		VehicleID string `json:"vehicleId"`
+		TripID    string `json:"tripId"`
+		BlockID   string `json:"blockId"`
+		ExpiresAt *int64 `json:"expiresAt"`
+	}
+	if err := decodeAdminRequest(r, &body); err != nil {
+		api.validationErrorResponse(w, r, map[string][]string{"body": {err.Error()}})
+		return
+	}
+
+	now := api.Clock.Now()
+	assignment := gtfs.VehicleAssignment{
+		AssignedAt: now,
+		ExpiresAt:  now.Add(defaultVehicleAssignmentTTL),
+	}
+	fieldErrors := make(map[string][]string)
+
+	vehicleID, err := utils.ExtractCodeID(body.VehicleID)
+	if err != nil {
+		fieldErrors["vehicleId"] = []string{"must be an agency-prefixed vehicle ID"}
+	}
+	assignment.VehicleID = vehicleID
+
+	switch {
+	case (body.TripID == "") == (body.BlockID == ""):
+		fieldErrors["tripId"] = []string{"exactly one of tripId and blockId is required"}
+	case body.TripID != "":
+		if assignment.AgencyID, assignment.TripID, err = utils.ExtractAgencyIDAndCodeID(body.TripID); err != nil {
+			fieldErrors["tripId"] = []string{"must be an agency-prefixed trip ID"}
+		}
+	default:
+		if assignment.AgencyID, assignment.BlockID, err = utils.ExtractAgencyIDAndCodeID(body.BlockID); err != nil {
+			fieldErrors["blockId"] = []string{"must be an agency-prefixed block ID"}
+		}
+	}
+
+	if body.ExpiresAt != nil {
+		assignment.ExpiresAt = time.UnixMilli(*body.ExpiresAt)
+		if !assignment.ExpiresAt.After(now) || assignment.ExpiresAt.Sub(now) > maxVehicleAssignmentTTL {
+			fieldErrors["expiresAt"] = []string{"must be in the next 24 hours"}
+		}
+	}
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+	api.GtfsManager.RLock()
+	found := false
+	if assignment.TripID != "" {
+		trip, err := api.GtfsManager.GtfsDB.GetTrip(ctx, assignment.TripID)
+		found = err == nil && trip.ID != ""
+	} else {
+		trips, err := api.GtfsManager.GtfsDB.Queries.GetTripsByBlockID(ctx, sql.NullString{String: assignment.BlockID, Valid: true})
+		found = err == nil && len(trips) > 0
+	}
+	api.GtfsManager.RUnlock()
+	if !found {
+		if assignment.TripID != "" {
+			api.sendNotFoundWithCode(w, r, errCodeTripNotFound)
+		} else {
+			api.sendNotFoundWithCode(w, r, errCodeBlockNotFound)
+		}
+		return
+	}
+
+	api.GtfsManager.AssignVehicle(assignment)
+	api.Logger.Info("vehicle assigned",
+		"vehicleId", body.VehicleID,
+		"tripId", body.TripID,
+		"blockId", body.BlockID,
+		"expiresAt", assignment.ExpiresAt)
+
+	api.sendResponse(w, r, models.NewEntryResponse(newVehicleAssignmentModel(assignment), models.NewEmptyReferences(), api.Clock))
+}
+
+func (api *RestAPI) deleteVehicleAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	_, vehicleID, err := utils.ExtractAgencyIDAndCodeID(r.PathValue("vehicleId"))
+	if err != nil || !api.GtfsManager.RemoveVehicleAssignment(vehicleID, api.Clock.Now()) {
+		api.sendNotFoundWithCode(w, r, errCodeVehicleNotFound)
+		return
+	}
+	api.sendResponse(w, r, models.NewOKResponse(nil, api.Clock))
+}
+
+func newVehicleAssignmentModel(a gtfs.VehicleAssignment) models.VehicleAssignment {
+	m := models.VehicleAssignment{
+		VehicleID:  utils.FormCombinedID(a.AgencyID, a.VehicleID),
+		AssignedAt: a.AssignedAt.UnixMilli(),
+		ExpiresAt:  a.ExpiresAt.UnixMilli(),
+	}
+	if a.TripID != "" {
+		m.TripID = utils.FormCombinedID(a.AgencyID, a.TripID)
+	} else {
+		m.BlockID = utils.FormCombinedID(a.AgencyID, a.BlockID)
+	}
+	return m
+}
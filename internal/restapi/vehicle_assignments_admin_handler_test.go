@@ -0,0 +1,114 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestApiWithAdminKey(t *testing.T) (*RestAPI, *httptest.Server) {
+	t.Helper()
+	api := createTestApi(t)
+	t.Cleanup(api.Shutdown)
+	api.Config.AdminApiKeys = []string{"ADMIN"}
+
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func TestVehicleAssignmentsAdmin(t *testing.T) {
+	api, server := createTestApiWithAdminKey(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		for _, a := range api.GtfsManager.VehicleAssignments(time.Now()) {
+			api.GtfsManager.RemoveVehicleAssignment(a.VehicleID, time.Now())
+		}
+	})
+
+	var tripID, blockID, siblingID string
+	require.NoError(t, api.GtfsManager.GtfsDB.DB.QueryRowContext(ctx, `
+		SELECT a.id, a.block_id, b.id FROM trips a JOIN trips b ON a.block_id = b.block_id AND a.id < b.id
+		LIMIT 1`).Scan(&tripID, &blockID, &siblingID))
+
+	resp, model := doAdminRequest(t, server, http.MethodPost, "/api/admin/vehicle-assignments?key=ADMIN",
+		`{"vehicleId":"25_dispatch-bus","tripId":"25_`+tripID+`"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "25_dispatch-bus", entry["vehicleId"])
+	assert.Equal(t, "25_"+tripID, entry["tripId"])
+	assert.NotContains(t, entry, "blockId")
+	assignedAt := int64(entry["assignedAt"].(float64))
+	assert.Equal(t, assignedAt+defaultVehicleAssignmentTTL.Milliseconds(), int64(entry["expiresAt"].(float64)))
+
+	// The trip status shows the assigned vehicle, which no feed reports.
+	api.GtfsManager.RLock()
+	status, err := api.BuildTripStatus(ctx, "25", tripID, time.Now(), time.Now())
+	api.GtfsManager.RUnlock()
+	require.NoError(t, err)
+	assert.Equal(t, "25_dispatch-bus", status.VehicleID)
+
+	// A block assignment covers the block's other trips too.
+	resp, _ = doAdminRequest(t, server, http.MethodPost, "/api/admin/vehicle-assignments?key=ADMIN",
+		`{"vehicleId":"25_block-bus","blockId":"25_`+blockID+`"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	api.GtfsManager.RLock()
+	vehicle := api.GtfsManager.GetVehicleForTrip(ctx, siblingID)
+	tripVehicle := api.GtfsManager.GetVehicleForTrip(ctx, tripID)
+	api.GtfsManager.RUnlock()
+	require.NotNil(t, vehicle)
+	assert.Equal(t, "block-bus", vehicle.ID.ID)
+	assert.Equal(t, "dispatch-bus", tripVehicle.ID.ID, "the trip assignment still wins on its trip")
+
+	resp, model = doAdminRequest(t, server, http.MethodGet, "/api/admin/vehicle-assignments?key=ADMIN", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	list := model.Data.(map[string]interface{})["list"].([]interface{})
+	require.Len(t, list, 2)
+	assert.Equal(t, "25_block-bus", list[0].(map[string]interface{})["vehicleId"])
+	assert.Equal(t, "25_"+blockID, list[0].(map[string]interface{})["blockId"])
+
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/admin/vehicle-assignments/25_dispatch-bus?key=ADMIN", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doAdminRequest(t, server, http.MethodDelete, "/api/admin/vehicle-assignments/25_dispatch-bus?key=ADMIN", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	api.GtfsManager.RLock()
+	tripVehicle = api.GtfsManager.GetVehicleForTrip(ctx, tripID)
+	api.GtfsManager.RUnlock()
+	require.NotNil(t, tripVehicle)
+	assert.Equal(t, "block-bus", tripVehicle.ID.ID)
+}
+
+func TestVehicleAssignmentsAdminValidation(t *testing.T) {
+	_, server := createTestApiWithAdminKey(t)
+
+	resp, _ := doAdminRequest(t, server, http.MethodGet, "/api/admin/vehicle-assignments?key=TEST", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"trip and block", `{"vehicleId":"25_bus","tripId":"25_t","blockId":"25_b"}`, http.StatusBadRequest},
+		{"neither trip nor block", `{"vehicleId":"25_bus"}`, http.StatusBadRequest},
+		{"unprefixed vehicle", `{"vehicleId":"bus","blockId":"25_b"}`, http.StatusBadRequest},
+		{"expiry in the past", `{"vehicleId":"25_bus","tripId":"25_t","expiresAt":1}`, http.StatusBadRequest},
+		{"unknown field", `{"vehicleId":"25_bus","tripId":"25_t","route":"x"}`, http.StatusBadRequest},
+		{"unknown trip", `{"vehicleId":"25_bus","tripId":"25_no-such-trip"}`, http.StatusNotFound},
+		{"unknown block", `{"vehicleId":"25_bus","blockId":"25_no-such-block"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := doAdminRequest(t, server, http.MethodPost, "/api/admin/vehicle-assignments?key=ADMIN", tt.body)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
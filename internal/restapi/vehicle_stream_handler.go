@@ -0,0 +1,430 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/klauspost/compress/gzhttp"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/logging"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+// vehicleStreamHeartbeat is how often an idle stream sends a comment line, so
+// that proxies and clients do not drop the connection.
+const vehicleStreamHeartbeat = 15 * time.Second
+
+// vehicleStreamFilter is a client's subscription. Route and trip IDs are
+// combined agency IDs; a vehicle matches when it serves one of the listed routes
+// or trips (or when neither list is given) and lies within bounds, if set.
+type vehicleStreamFilter struct {
+	routeIDs map[string]bool
+	tripIDs  map[string]bool
+	bounds   *boundingBoxStruct
+}
+
+func (f vehicleStreamFilter) matchesIDs(routeID, tripID string) bool {
+	if len(f.routeIDs) == 0 && len(f.tripIDs) == 0 {
+		return true
+	}
+	return f.routeIDs[routeID] || f.tripIDs[tripID]
+}
+
+func (f vehicleStreamFilter) matchesVehicle(v models.StreamVehicle) bool {
+	if !f.matchesIDs(v.RouteID, v.TripID) {
+		return false
+	}
+	if f.bounds == nil {
+		return true
+	}
+	if v.Location == nil {
+		return false
+	}
+	b := f.bounds
+	return v.Location.Lat >= b.minLat && v.Location.Lat <= b.maxLat &&
+		v.Location.Lon >= b.minLon && v.Location.Lon <= b.maxLon
+}
+
+// matchesAlert reports whether an alert concerns the subscription. Without route
+// or trip filters every alert matches; otherwise an alert matches when it names
+// one of the routes or trips, or applies to an agency as a whole.
+func (f vehicleStreamFilter) matchesAlert(alert gtfs.Alert) bool {
+	if len(f.routeIDs) == 0 && len(f.tripIDs) == 0 {
+		return true
+	}
+	for _, entity := range alert.InformedEntities {
+		agencyID := ""
+		if entity.AgencyID != nil {
+			agencyID = *entity.AgencyID
+		}
+		if entity.RouteID != nil && f.routeIDs[utils.FormCombinedID(agencyID, *entity.RouteID)] {
+			return true
+		}
+		if entity.TripID != nil && f.tripIDs[utils.FormCombinedID(agencyID, entity.TripID.ID)] {
+			return true
+		}
+		if agencyID != "" && entity.RouteID == nil && entity.TripID == nil && entity.StopID == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVehicleStreamFilter reads the routeId, tripId and bounds parameters.
+// routeId and tripId take comma-separated combined IDs; bounds is
+// "minLat,minLon,maxLat,maxLon".
+func parseVehicleStreamFilter(query url.Values) (vehicleStreamFilter, map[string][]string) {
+	fieldErrors := make(map[string][]string)
+	filter := vehicleStreamFilter{
+		routeIDs: parseStreamIDList(query.Get("routeId"), "routeId", fieldErrors),
+		tripIDs:  parseStreamIDList(query.Get("tripId"), "tripId", fieldErrors),
+	}
+
+	if raw := query.Get("bounds"); raw != "" {
+		bounds, err := parseStreamBounds(raw)
+		if err != nil {
+			fieldErrors["bounds"] = []string{err.Error()}
+		} else {
+			filter.bounds = &bounds
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return filter, fieldErrors
+	}
+	return filter, nil
+}
+
+func parseStreamIDList(raw, field string, fieldErrors map[string][]string) map[string]bool {
+	if raw == "" {
+		return nil
+	}
+	ids := make(map[string]bool)
+	for _, id := range strings.Split(raw, ",") {
+		id = strings.TrimSpace(id)
+		if _, _, err := utils.ExtractAgencyIDAndCodeID(id); err != nil {
+			fieldErrors[field] = append(fieldErrors[field], fmt.Sprintf("invalid %s %q: expected agencyId_code", field, id))
+			continue
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+func parseStreamBounds(raw string) (boundingBoxStruct, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return boundingBoxStruct{}, fmt.Errorf("bounds must be minLat,minLon,maxLat,maxLon")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return boundingBoxStruct{}, fmt.Errorf("bounds must be minLat,minLon,maxLat,maxLon")
+		}
+		values[i] = v
+	}
+	b := boundingBoxStruct{minLat: values[0], minLon: values[1], maxLat: values[2], maxLon: values[3]}
+	if b.minLat < -90 || b.maxLat > 90 || b.minLon < -180 || b.maxLon > 180 {
+		return boundingBoxStruct{}, fmt.Errorf("bounds are outside valid latitude and longitude ranges")
+	}
+	if b.minLat > b.maxLat || b.minLon > b.maxLon {
+		return boundingBoxStruct{}, fmt.Errorf("bounds minimums must not exceed maximums")
+	}
+	return b, nil
+}
+
+// vehicleStreamState is what a stream client has been sent, keyed by combined ID.
+type vehicleStreamState struct {
+	vehicles    map[string]models.StreamVehicle
+	tripUpdates map[string]models.StreamTripUpdate
+	situations  []models.Situation
+}
+
+// vehicleStreamHandler serves GET /api/stream/vehicles as Server-Sent Events.
+// The client first receives a "snapshot" event with the vehicles, trip updates
+// and alerts matching its subscription. Each time the realtime feeds are
+// refreshed it then receives "vehicles" and "tripUpdates" events listing what
+// changed, and an "alerts" event with the full alert set when that changed.
+func (api *RestAPI) vehicleStreamHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, fieldErrors := parseVehicleStreamFilter(r.URL.Query())
+	if fieldErrors != nil {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	// Subscribe before taking the first snapshot so no refresh is missed in between.
+	refreshed, unsubscribe := api.GtfsManager.SubscribeRealtime()
+	defer unsubscribe()
+
+	state, err := api.vehicleStreamState(ctx, filter)
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The server's write timeout is meant for ordinary responses; a stream stays
+	// open until the client leaves. Writers that cannot clear it are left alone.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	header.Set(gzhttp.HeaderNoCompression, "1")
+	w.WriteHeader(http.StatusOK)
+
+	snapshot := models.StreamSnapshot{
+		Vehicles:    sortedStreamValues(state.vehicles),
+		TripUpdates: sortedStreamValues(state.tripUpdates),
+		Situations:  state.situations,
+	}
+	if writeStreamEvent(w, rc, "snapshot", snapshot) != nil {
+		return
+	}
+
+	logger := logging.FromContext(ctx).With(slog.String("component", "vehicle_stream"))
+	heartbeat := time.NewTicker(vehicleStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-api.streamsDone:
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if rc.Flush() != nil {
+				return
+			}
+		case _, ok := <-refreshed:
+			if !ok {
+				return
+			}
+			next, err := api.vehicleStreamState(ctx, filter)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.LogError(logger, "failed to build vehicle stream update", err)
+				}
+				continue
+			}
+			if api.sendVehicleStreamChanges(w, rc, state, next) != nil {
+				return
+			}
+			state = next
+		}
+	}
+}
+
+// sendVehicleStreamChanges writes an event for each kind of data that differs
+// between prev and next.
+func (api *RestAPI) sendVehicleStreamChanges(w io.Writer, rc *http.ResponseController, prev, next vehicleStreamState) error {
+	vehicleDelta := models.StreamVehicleDelta{
+		Updated: changedStreamValues(prev.vehicles, next.vehicles),
+		Removed: removedStreamKeys(prev.vehicles, next.vehicles),
+	}
+	if len(vehicleDelta.Updated) > 0 || len(vehicleDelta.Removed) > 0 {
+		if err := writeStreamEvent(w, rc, "vehicles", vehicleDelta); err != nil {
+			return err
+		}
+	}
+
+	tripDelta := models.StreamTripUpdateDelta{
+		Updated: changedStreamValues(prev.tripUpdates, next.tripUpdates),
+		Removed: removedStreamKeys(prev.tripUpdates, next.tripUpdates),
+	}
+	if len(tripDelta.Updated) > 0 || len(tripDelta.Removed) > 0 {
+		if err := writeStreamEvent(w, rc, "tripUpdates", tripDelta); err != nil {
+			return err
+		}
+	}
+
+	if !reflect.DeepEqual(prev.situations, next.situations) {
+		if err := writeStreamEvent(w, rc, "alerts", models.StreamAlerts{Situations: next.situations}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vehicleStreamState collects the realtime data matching filter. Vehicles and
+// trip updates whose trip is not in the static feed are left out, as their
+// agency cannot be determined. With a bounding box, trip updates are only
+// included when the vehicle serving the trip is inside it.
+func (api *RestAPI) vehicleStreamState(ctx context.Context, filter vehicleStreamFilter) (vehicleStreamState, error) {
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	db := api.GtfsManager.GtfsDB
+	memo := newTripDataMemo()
+	resolve := func(tripID string) (gtfsdb.Trip, gtfsdb.Route, bool) {
+		trip, err := memo.trip(ctx, db, tripID)
+		if err != nil {
+			return gtfsdb.Trip{}, gtfsdb.Route{}, false
+		}
+		route, err := memo.route(ctx, db, trip.RouteID)
+		if err != nil {
+			return gtfsdb.Trip{}, gtfsdb.Route{}, false
+		}
+		return trip, route, true
+	}
+
+	state := vehicleStreamState{
+		vehicles:    make(map[string]models.StreamVehicle),
+		tripUpdates: make(map[string]models.StreamTripUpdate),
+		situations:  []models.Situation{},
+	}
+	vehicleByTrip := make(map[string]string)
+
+	for _, vehicle := range api.GtfsManager.GetRealTimeVehicles() {
+		if vehicle.ID == nil || vehicle.Trip == nil {
+			continue
+		}
+		trip, route, ok := resolve(vehicle.Trip.ID.ID)
+		if !ok {
+			continue
+		}
+
+		sv := models.StreamVehicle{
+			VehicleID: utils.FormCombinedID(route.AgencyID, vehicle.ID.ID),
+			TripID:    utils.FormCombinedID(route.AgencyID, trip.ID),
+			RouteID:   utils.FormCombinedID(route.AgencyID, route.ID),
+		}
+		if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+			sv.Location = &models.Location{
+				Lat: float64(*vehicle.Position.Latitude),
+				Lon: float64(*vehicle.Position.Longitude),
+			}
+			if vehicle.Position.Bearing != nil {
+				bearing := float64(*vehicle.Position.Bearing)
+				sv.Bearing = &bearing
+			}
+		}
+		if vehicle.StopID != nil {
+			sv.StopID = utils.FormCombinedID(route.AgencyID, *vehicle.StopID)
+		}
+		if vehicle.Timestamp != nil {
+			sv.Timestamp = vehicle.Timestamp.UnixMilli()
+		}
+
+		if !filter.matchesVehicle(sv) {
+			continue
+		}
+		state.vehicles[sv.VehicleID] = sv
+		vehicleByTrip[sv.TripID] = sv.VehicleID
+	}
+
+	for _, update := range api.GtfsManager.GetRealTimeTrips() {
+		trip, route, ok := resolve(update.ID.ID)
+		if !ok {
+			continue
+		}
+
+		su := models.StreamTripUpdate{
+			TripID:  utils.FormCombinedID(route.AgencyID, trip.ID),
+			RouteID: utils.FormCombinedID(route.AgencyID, route.ID),
+		}
+		if !filter.matchesIDs(su.RouteID, su.TripID) {
+			continue
+		}
+		if vehicleID, ok := vehicleByTrip[su.TripID]; ok {
+			su.VehicleID = vehicleID
+		} else if filter.bounds != nil {
+			continue
+		} else if update.Vehicle != nil && update.Vehicle.ID != nil {
+			su.VehicleID = utils.FormCombinedID(route.AgencyID, update.Vehicle.ID.ID)
+		}
+		if delay := tripUpdateDelay(&update); delay != nil {
+			seconds := int64(*delay / time.Second)
+			su.ScheduleDeviation = &seconds
+		}
+		state.tripUpdates[su.TripID] = su
+	}
+
+	for _, alert := range api.GtfsManager.GetRealTimeAlerts() {
+		if !filter.matchesAlert(alert) {
+			continue
+		}
+		state.situations = append(state.situations, api.BuildSituationReferences([]gtfs.Alert{alert}, streamAlertAgencyID(alert), "")...)
+	}
+
+	return state, ctx.Err()
+}
+
+// streamAlertAgencyID returns the first agency named by the alert's informed
+// entities, used to prefix its situation ID.
+func streamAlertAgencyID(alert gtfs.Alert) string {
+	for _, entity := range alert.InformedEntities {
+		if entity.AgencyID != nil && *entity.AgencyID != "" {
+			return *entity.AgencyID
+		}
+	}
+	return ""
+}
+
+// writeStreamEvent writes one Server-Sent Event with a JSON payload and flushes it.
+func writeStreamEvent(w io.Writer, rc *http.ResponseController, event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func sortedStreamValues[T any](m map[string]T) []T {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]T, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+	return values
+}
+
+// changedStreamValues returns the entries of next that are new or differ from
+// prev, ordered by key.
+func changedStreamValues[T any](prev, next map[string]T) []T {
+	changed := make(map[string]T)
+	for k, v := range next {
+		if old, ok := prev[k]; !ok || !reflect.DeepEqual(old, v) {
+			changed[k] = v
+		}
+	}
+	return sortedStreamValues(changed)
+}
+
+// removedStreamKeys returns the keys of prev that are absent from next, sorted.
+func removedStreamKeys[T any](prev, next map[string]T) []string {
+	removed := []string{}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
@@ -0,0 +1,183 @@
+package restapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	internalgtfs "maglev.onebusaway.org/internal/gtfs"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSEEvent reads the next event from an event stream, skipping comments.
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event.name != "" {
+				return event
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openVehicleStream(t *testing.T, api *RestAPI, query string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	mux := http.NewServeMux()
+	api.SetRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/stream/vehicles?key=TEST"+query, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestVehicleStreamSendsSnapshotThenDeltas(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.GtfsManager.MockResetRealTimeData()
+	t.Cleanup(api.GtfsManager.MockResetRealTimeData)
+
+	trips := api.GtfsManager.GetTrips()
+	require.NotEmpty(t, trips)
+	trip := trips[0]
+	routeID := utils.FormCombinedID("25", trip.Route.Id)
+
+	resp, reader := openVehicleStream(t, api, "&routeId="+routeID)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	event := readSSEEvent(t, reader)
+	require.Equal(t, "snapshot", event.name)
+	var snapshot models.StreamSnapshot
+	require.NoError(t, json.Unmarshal([]byte(event.data), &snapshot))
+	assert.Empty(t, snapshot.Vehicles)
+
+	lat, lon, bearing := float32(40.58), float32(-122.39), float32(90)
+	api.GtfsManager.MockAddVehicleWithOptions("bus1", trip.ID, trip.Route.Id, internalgtfs.MockVehicleOptions{
+		Position: &gtfs.Position{Latitude: &lat, Longitude: &lon, Bearing: &bearing},
+	})
+
+	event = readSSEEvent(t, reader)
+	require.Equal(t, "vehicles", event.name)
+	var delta models.StreamVehicleDelta
+	require.NoError(t, json.Unmarshal([]byte(event.data), &delta))
+	require.Len(t, delta.Updated, 1)
+	vehicle := delta.Updated[0]
+	assert.Equal(t, "25_bus1", vehicle.VehicleID)
+	assert.Equal(t, utils.FormCombinedID("25", trip.ID), vehicle.TripID)
+	assert.Equal(t, routeID, vehicle.RouteID)
+	require.NotNil(t, vehicle.Location)
+	assert.InDelta(t, 40.58, vehicle.Location.Lat, 1e-4)
+	require.NotNil(t, vehicle.Bearing)
+	assert.InDelta(t, 90, *vehicle.Bearing, 1e-6)
+	assert.Empty(t, delta.Removed)
+
+	api.GtfsManager.MockResetRealTimeData()
+
+	event = readSSEEvent(t, reader)
+	require.Equal(t, "vehicles", event.name)
+	require.NoError(t, json.Unmarshal([]byte(event.data), &delta))
+	assert.Empty(t, delta.Updated)
+	assert.Equal(t, []string{"25_bus1"}, delta.Removed)
+}
+
+func TestVehicleStreamEndsWhenStreamsClose(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	resp, reader := openVehicleStream(t, api, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "snapshot", readSSEEvent(t, reader).name)
+
+	api.CloseStreams()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after CloseStreams")
+	}
+}
+
+func TestVehicleStreamValidation(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+
+	for _, query := range []string{
+		"&bounds=40.5,-122.5,40.6",
+		"&bounds=40.7,-122.5,40.6,-122.3",
+		"&bounds=95,-122.5,96,-122.3",
+		"&routeId=151",
+	} {
+		resp, _ := serveApiAndRetrieveEndpoint(t, api, "/api/stream/vehicles?key=TEST"+query)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestVehicleStreamFilterMatching(t *testing.T) {
+	filter, fieldErrors := parseVehicleStreamFilter(map[string][]string{
+		"routeId": {"25_151,25_152"},
+		"bounds":  {"40.5,-122.5,40.7,-122.3"},
+	})
+	require.Nil(t, fieldErrors)
+
+	inside := models.StreamVehicle{RouteID: "25_151", TripID: "25_t1", Location: &models.Location{Lat: 40.6, Lon: -122.4}}
+	assert.True(t, filter.matchesVehicle(inside))
+
+	otherRoute := inside
+	otherRoute.RouteID = "25_999"
+	assert.False(t, filter.matchesVehicle(otherRoute))
+
+	outside := inside
+	outside.Location = &models.Location{Lat: 41, Lon: -122.4}
+	assert.False(t, filter.matchesVehicle(outside))
+
+	unlocated := inside
+	unlocated.Location = nil
+	assert.False(t, filter.matchesVehicle(unlocated))
+
+	agencyID, routeID, otherRouteID := "25", "151", "999"
+	assert.True(t, filter.matchesAlert(gtfs.Alert{InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID, RouteID: &routeID}}}))
+	assert.False(t, filter.matchesAlert(gtfs.Alert{InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID, RouteID: &otherRouteID}}}))
+	assert.True(t, filter.matchesAlert(gtfs.Alert{InformedEntities: []gtfs.AlertInformedEntity{{AgencyID: &agencyID}}}), "agency-wide alerts match")
+}
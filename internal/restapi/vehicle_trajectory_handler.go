@@ -0,0 +1,76 @@
+package restapi
+
+import (
+	"net/http"
+	"time"
+
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/models"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+const (
+	defaultTrajectoryMinutes = 30
+	maxTrajectoryMinutes     = 24 * 60
+)
+
+// parseTrajectoryWindow reads the window of a trajectory request: it ends at
+// time (Unix milliseconds, default now) and spans the preceding minutes.
+func (api *RestAPI) parseTrajectoryWindow(r *http.Request) (time.Time, time.Time, map[string][]string) {
+	q := newQueryParams(r)
+	to := q.timeParam("time", api.Clock.Now())
+	minutes := q.intParam("minutes", defaultTrajectoryMinutes, between(1, maxTrajectoryMinutes))
+	return to.Add(-time.Duration(minutes) * time.Minute), to, q.errors()
+}
+
+// vehicleTrajectoryHandler returns the breadcrumb path a vehicle has reported
+// over a recent window. Positions come from the vehicle position history, so the
+// endpoint returns 404 for every vehicle unless history recording is enabled.
+func (api *RestAPI) vehicleTrajectoryHandler(w http.ResponseWriter, r *http.Request) {
+	parsed, _ := utils.GetParsedIDFromContext(r.Context())
+	vehicleID := parsed.CodeID
+
+	from, to, fieldErrors := api.parseTrajectoryWindow(r)
+	if len(fieldErrors) > 0 {
+		api.validationErrorResponse(w, r, fieldErrors)
+		return
+	}
+
+	ctx := r.Context()
+
+	api.GtfsManager.RLock()
+	defer api.GtfsManager.RUnlock()
+
+	positions, err := api.GtfsManager.GtfsDB.Queries.GetVehiclePositionsInWindow(ctx, gtfsdb.GetVehiclePositionsInWindowParams{
+		VehicleID: vehicleID,
+		FromTime:  from.UnixMilli(),
+		ToTime:    to.UnixMilli(),
+	})
+	if err != nil {
+		api.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if len(positions) == 0 {
+		api.sendNotFoundWithCode(w, r, api.vehicleNotFoundCode())
+		return
+	}
+
+	coords := make([][]float64, 0, len(positions))
+	timestamps := make([]int64, 0, len(positions))
+	for _, p := range positions {
+		coords = append(coords, []float64{p.Lat.Float64, p.Lon.Float64})
+		timestamps = append(timestamps, p.ObservedAt)
+	}
+
+	trajectory := models.VehicleTrajectory{
+		VehicleID:  parsed.CombinedID,
+		Points:     utils.EncodePolyline(coords),
+		Length:     len(coords),
+		Timestamps: timestamps,
+		FromTime:   from.UnixMilli(),
+		ToTime:     to.UnixMilli(),
+	}
+
+	api.sendResponse(w, r, models.NewEntryResponse(trajectory, models.NewEmptyReferences(), api.Clock))
+}
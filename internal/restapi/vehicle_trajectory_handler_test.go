@@ -0,0 +1,77 @@
+package restapi
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/gtfsdb"
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/utils"
+)
+
+func TestVehicleTrajectoryHandler(t *testing.T) {
+	now := time.Date(2025, 6, 13, 18, 0, 0, 0, time.UTC)
+	api := createTestApiWithClock(t, clock.NewMockClock(now))
+	defer api.Shutdown()
+
+	ctx := context.Background()
+	client := api.GtfsManager.GtfsDB
+	coords := [][]float64{{40.58, -122.39}, {40.581, -122.391}, {40.583, -122.392}}
+	observed := []time.Time{now.Add(-45 * time.Minute), now.Add(-10 * time.Minute), now.Add(-5 * time.Minute)}
+	for i, c := range coords {
+		_, err := client.Queries.CreateVehiclePositionHistory(ctx, gtfsdb.CreateVehiclePositionHistoryParams{
+			FeedID:     "test",
+			VehicleID:  "trajectory-bus",
+			Lat:        sql.NullFloat64{Float64: c[0], Valid: true},
+			Lon:        sql.NullFloat64{Float64: c[1], Valid: true},
+			ObservedAt: observed[i].UnixMilli(),
+		})
+		require.NoError(t, err)
+	}
+	// A report without a position is not part of the path.
+	_, err := client.Queries.CreateVehiclePositionHistory(ctx, gtfsdb.CreateVehiclePositionHistoryParams{
+		FeedID:     "test",
+		VehicleID:  "trajectory-bus",
+		ObservedAt: now.Add(-7 * time.Minute).UnixMilli(),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := client.DB.ExecContext(context.Background(), "DELETE FROM vehicle_positions_history WHERE vehicle_id = 'trajectory-bus'")
+		assert.NoError(t, err)
+	})
+
+	resp, model := serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicle-trajectory/25_trajectory-bus.json?key=TEST")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	entry := model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, "25_trajectory-bus", entry["vehicleId"])
+	assert.Equal(t, float64(2), entry["length"], "the default window is the last 30 minutes")
+	assert.Equal(t, []interface{}{float64(observed[1].UnixMilli()), float64(observed[2].UnixMilli())}, entry["timestamps"])
+	assert.Equal(t, float64(now.Add(-30*time.Minute).UnixMilli()), entry["fromTime"])
+	assert.Equal(t, float64(now.UnixMilli()), entry["toTime"])
+
+	points, err := utils.DecodePolyline(entry["points"].(string))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.InDelta(t, coords[1][0], points[0][0], 1e-5)
+	assert.InDelta(t, coords[2][1], points[1][1], 1e-5)
+
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicle-trajectory/25_trajectory-bus.json?key=TEST&minutes=60")
+	assert.Equal(t, float64(3), model.Data.(map[string]interface{})["entry"].(map[string]interface{})["length"])
+
+	reviewTime := strconv.FormatInt(now.Add(-40*time.Minute).UnixMilli(), 10)
+	_, model = serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicle-trajectory/25_trajectory-bus.json?key=TEST&minutes=10&time="+reviewTime)
+	entry = model.Data.(map[string]interface{})["entry"].(map[string]interface{})
+	assert.Equal(t, []interface{}{float64(observed[0].UnixMilli())}, entry["timestamps"])
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicle-trajectory/25_no-such-bus.json?key=TEST")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = serveApiAndRetrieveEndpoint(t, api, "/api/where/vehicle-trajectory/25_trajectory-bus.json?key=TEST&minutes=0")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
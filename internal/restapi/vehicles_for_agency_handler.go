@@ -27,8 +27,13 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 	vehiclesForAgency := api.GtfsManager.VehiclesForAgencyID(id)
 
 	// Apply pagination
-	offset, limit := utils.ParsePaginationParams(r)
+	offset, limit, pageErrors := parseListPagination(r)
+	if len(pageErrors) > 0 {
+		api.validationErrorResponse(w, r, pageErrors)
+		return
+	}
 	vehiclesForAgency, limitExceeded := utils.PaginateSlice(vehiclesForAgency, offset, limit)
+	page := newPage(r, offset, len(vehiclesForAgency), limitExceeded)
 	vehiclesList := make([]models.VehicleStatus, 0, len(vehiclesForAgency))
 
 	// Collect unique route IDs and batch-fetch routes
@@ -153,7 +158,7 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 				routeRefs[route.ID] = models.NewRoute(
 					route.ID, route.AgencyID, shortName, longName,
 					desc, models.RouteType(route.Type),
-					url, color, textColor)
+					url, color, textColor).WithSortOrder(utils.NullInt32Ptr(route.SortOrder))
 
 			}
 		}
@@ -193,6 +198,6 @@ func (api *RestAPI) vehiclesForAgencyHandler(w http.ResponseWriter, r *http.Requ
 		Trips:      tripRefList,
 	}
 
-	response := models.NewListResponse(vehiclesList, references, limitExceeded, api.Clock)
+	response := models.WithPage(models.NewListResponse(vehiclesList, references, limitExceeded, api.Clock), page)
 	api.sendResponse(w, r, response)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/OneBusAway/go-gtfs"
 	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 	"maglev.onebusaway.org/internal/utils"
 )
@@ -19,30 +20,75 @@ import (
 // The Java implementation removes vehicle records from the active map when their
 // last update time is more than 15 minutes in the past. We mirror that threshold
 // here so stale vehicles are treated as absent rather than as live vehicles.
+// Deployments can change the threshold globally and per agency; see appconf.Config.
 type StaleDetector struct {
-	threshold time.Duration
+	threshold        time.Duration
+	agencyThresholds map[string]time.Duration
 }
 
+const defaultStaleVehicleThreshold = 15 * time.Minute
+
 func NewStaleDetector() *StaleDetector {
-	return &StaleDetector{threshold: 15 * time.Minute}
+	return &StaleDetector{threshold: defaultStaleVehicleThreshold}
 }
 
 func (d *StaleDetector) WithThreshold(threshold time.Duration) *StaleDetector {
-	return &StaleDetector{threshold: threshold}
+	return &StaleDetector{threshold: threshold, agencyThresholds: d.agencyThresholds}
+}
+
+// WithAgencyThresholds returns a detector that applies the given thresholds to
+// vehicles of those agencies and the default threshold to everyone else.
+func (d *StaleDetector) WithAgencyThresholds(thresholds map[string]time.Duration) *StaleDetector {
+	return &StaleDetector{threshold: d.threshold, agencyThresholds: thresholds}
+}
+
+// Threshold returns the staleness window that applies to agencyID.
+func (d *StaleDetector) Threshold(agencyID string) time.Duration {
+	if threshold, ok := d.agencyThresholds[agencyID]; ok {
+		return threshold
+	}
+	return d.threshold
 }
 
 func (d *StaleDetector) Check(vehicle *gtfs.Vehicle, currentTime time.Time) bool {
+	return d.CheckForAgency(vehicle, "", currentTime)
+}
+
+// CheckForAgency is Check using the threshold configured for agencyID.
+func (d *StaleDetector) CheckForAgency(vehicle *gtfs.Vehicle, agencyID string, currentTime time.Time) bool {
 	if vehicle == nil {
 		return true
 	}
 	if vehicle.Timestamp == nil {
 		return vehicle.Position == nil
 	}
-	return currentTime.Sub(*vehicle.Timestamp) > d.threshold
+	return currentTime.Sub(*vehicle.Timestamp) > d.Threshold(agencyID)
 }
 
 var defaultStaleDetector = NewStaleDetector()
 
+// newStaleDetectorFromConfig builds the detector described by the application
+// config, falling back to the default threshold when none is set.
+func newStaleDetectorFromConfig(cfg appconf.Config) *StaleDetector {
+	detector := NewStaleDetector()
+	if cfg.StaleVehicleThreshold > 0 {
+		detector = detector.WithThreshold(cfg.StaleVehicleThreshold)
+	}
+	if len(cfg.AgencyStaleVehicleThresholds) > 0 {
+		detector = detector.WithAgencyThresholds(cfg.AgencyStaleVehicleThresholds)
+	}
+	return detector
+}
+
+// vehicleStaleDetector returns the API's configured detector. RestAPI values built
+// without NewRestAPI, as some tests do, use the default.
+func (api *RestAPI) vehicleStaleDetector() *StaleDetector {
+	if api.staleDetector != nil {
+		return api.staleDetector
+	}
+	return defaultStaleDetector
+}
+
 // scheduleRelationshipStatus converts a GTFS-RT TripDescriptor_ScheduleRelationship to
 // the OBA status string.
 //
@@ -99,7 +145,7 @@ func GetVehicleStatusAndPhase(vehicle *gtfs.Vehicle) (status string, phase strin
 	// For CANCELED trips phase is intentionally left as "" (empty string), matching
 	// the Java OBA null-phase behavior for canceled trips.
 	if sr != gtfsrt.TripDescriptor_CANCELED {
-		phase = "in_progress"
+		phase = phaseInProgress
 	}
 
 	return status, phase
@@ -113,7 +159,7 @@ func (api *RestAPI) BuildVehicleStatus(
 	status *models.TripStatusForTripDetails,
 	currentTime time.Time,
 ) {
-	if vehicle == nil || defaultStaleDetector.Check(vehicle, currentTime) {
+	if vehicle == nil {
 		status.Status, status.Phase = GetVehicleStatusAndPhase(nil)
 		return
 	}
@@ -124,6 +170,22 @@ func (api *RestAPI) BuildVehicleStatus(
 		status.LastUpdateTime = lastUpdateTime
 	}
 
+	// A stale vehicle no longer drives the trip's status, but its last report is
+	// still returned so clients can see how old it is: lastUpdateTime is when the
+	// vehicle last reported at all, lastLocationUpdateTime when it last sent a fix.
+	if api.vehicleStaleDetector().CheckForAgency(vehicle, agencyID, currentTime) {
+		status.Stale = true
+		if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
+			status.LastKnownLocation = models.Location{
+				Lat: float64(*vehicle.Position.Latitude),
+				Lon: float64(*vehicle.Position.Longitude),
+			}
+			status.LastLocationUpdateTime = lastUpdateTime
+		}
+		status.Status, status.Phase = GetVehicleStatusAndPhase(nil)
+		return
+	}
+
 	if vehicle.Position != nil && vehicle.Position.Latitude != nil && vehicle.Position.Longitude != nil {
 		actualPosition := models.Location{
 			Lat: float64(*vehicle.Position.Latitude),
@@ -132,8 +194,8 @@ func (api *RestAPI) BuildVehicleStatus(
 		status.LastKnownLocation = actualPosition
 		// Position is initially set to the raw GPS position.
 		// BuildTripStatus will refine this by projecting it onto the route shape
-		// after fetching shape data. Note: getVehicleDistanceAlongShapeContextual
-		// makes its own GetShapePointsByTripID call; these two fetches are separate.
+		// after fetching shape data. Both it and getVehicleDistanceAlongShapeContextual
+		// read the shape through the manager's shape geometry cache.
 		status.Position = actualPosition
 
 		if vehicle.Timestamp != nil {
@@ -198,7 +260,7 @@ func projectPositionWithShapePoints(shapePoints []gtfs.ShapePoint, actualPos mod
 }
 
 func projectPointToSegment(px, py, x1, y1, x2, y2 float64) (float64, models.Location) {
-	dist, _, projLat, projLon := projectOntoSegment(px, py, x1, y1, x2, y2)
+	dist, _, projLat, projLon := utils.ProjectOntoSegment(px, py, x1, y1, x2, y2)
 	return dist, models.Location{Lat: projLat, Lon: projLon}
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/OneBusAway/go-gtfs"
 	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
 	"github.com/stretchr/testify/assert"
+	"maglev.onebusaway.org/internal/appconf"
 	"maglev.onebusaway.org/internal/models"
 )
 
@@ -135,6 +136,31 @@ func TestStaleDetector_WithCustomThreshold(t *testing.T) {
 	assert.True(t, d.Check(staleVehicle, now), "6-minute old vehicle should be stale with 5-minute threshold")
 }
 
+func TestStaleDetector_AgencyOverride(t *testing.T) {
+	d := NewStaleDetector().WithAgencyThresholds(map[string]time.Duration{"fast": 2 * time.Minute})
+	now := time.Now()
+	ts := now.Add(-5 * time.Minute)
+	vehicle := &gtfs.Vehicle{Timestamp: &ts}
+
+	assert.Equal(t, 2*time.Minute, d.Threshold("fast"))
+	assert.Equal(t, 15*time.Minute, d.Threshold("other"))
+	assert.True(t, d.CheckForAgency(vehicle, "fast", now), "override should apply to its agency")
+	assert.False(t, d.CheckForAgency(vehicle, "other", now), "other agencies keep the global threshold")
+	assert.False(t, d.Check(vehicle, now))
+}
+
+func TestNewStaleDetectorFromConfig(t *testing.T) {
+	d := newStaleDetectorFromConfig(appconf.Config{})
+	assert.Equal(t, 15*time.Minute, d.Threshold("any"), "unset threshold keeps the default")
+
+	d = newStaleDetectorFromConfig(appconf.Config{
+		StaleVehicleThreshold:        10 * time.Minute,
+		AgencyStaleVehicleThresholds: map[string]time.Duration{"1": time.Minute},
+	})
+	assert.Equal(t, 10*time.Minute, d.Threshold("40"))
+	assert.Equal(t, time.Minute, d.Threshold("1"))
+}
+
 func TestBuildVehicleStatus_NilVehicleSetsDefaultStatus(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
@@ -168,6 +194,39 @@ func TestBuildVehicleStatus_StaleVehicleSetsDefaultStatus(t *testing.T) {
 	assert.Equal(t, "scheduled", status.Phase)
 }
 
+func TestBuildVehicleStatus_StaleVehicleReportsLastUpdate(t *testing.T) {
+	api := createTestApi(t)
+	defer api.Shutdown()
+	api.staleDetector = NewStaleDetector().WithAgencyThresholds(map[string]time.Duration{"strict": time.Minute})
+	ctx := context.Background()
+
+	now := time.Now()
+	old := now.Add(-5 * time.Minute)
+	lat := float32(37.7749)
+	lon := float32(-122.4194)
+	vehicle := &gtfs.Vehicle{
+		ID:        &gtfs.VehicleID{ID: "v1"},
+		Timestamp: &old,
+		Position:  &gtfs.Position{Latitude: &lat, Longitude: &lon},
+	}
+
+	status := &models.TripStatusForTripDetails{}
+	api.BuildVehicleStatus(ctx, vehicle, "any-trip", "strict", status, now)
+
+	assert.True(t, status.Stale)
+	assert.Equal(t, "default", status.Status)
+	assert.Equal(t, "scheduled", status.Phase)
+	assert.Equal(t, old.UnixMilli(), status.LastUpdateTime)
+	assert.Equal(t, old.UnixMilli(), status.LastLocationUpdateTime)
+	assert.InDelta(t, 37.7749, status.LastKnownLocation.Lat, 1e-4)
+	assert.Zero(t, status.Position.Lat, "a stale fix must not be used as the current position")
+
+	status = &models.TripStatusForTripDetails{}
+	api.BuildVehicleStatus(ctx, vehicle, "any-trip", "lenient", status, now)
+	assert.False(t, status.Stale, "agencies without an override use the global threshold")
+	assert.Equal(t, "in_progress", status.Phase)
+}
+
 func TestBuildVehicleStatus_FreshVehicleWithPosition_SetsLocationAndPhase(t *testing.T) {
 	api := createTestApi(t)
 	defer api.Shutdown()
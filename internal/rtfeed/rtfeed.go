@@ -0,0 +1,275 @@
+// Package rtfeed encodes maglev's merged realtime state back into GTFS-realtime
+// feeds, so that consumers can read the cleaned-up vehicle positions, trip
+// updates and alerts of every configured source as one standard feed of each
+// kind. IDs are the raw GTFS IDs of the static feed, not agency-prefixed ones.
+package rtfeed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the GTFS-realtime version the feeds declare.
+const Version = "2.0"
+
+// ContentType is the HTTP Content-Type of an encoded feed.
+const ContentType = "application/x-protobuf"
+
+// VehiclePositions builds a full-dataset feed of the vehicles. Vehicles
+// without an ID are left out.
+func VehiclePositions(vehicles []gtfs.Vehicle, at time.Time) *gtfsrt.FeedMessage {
+	feed := newFeed(at)
+	ids := newEntityIDs()
+	for _, vehicle := range vehicles {
+		if vehicle.ID == nil {
+			continue
+		}
+		feed.Entity = append(feed.Entity, &gtfsrt.FeedEntity{
+			Id:      ids.next(vehicle.ID.ID),
+			Vehicle: vehiclePosition(vehicle),
+		})
+	}
+	return feed
+}
+
+// TripUpdates builds a full-dataset feed of the trip updates. Trips only known
+// from a vehicle position, which carry no update of their own, are left out.
+func TripUpdates(trips []gtfs.Trip, at time.Time) *gtfsrt.FeedMessage {
+	feed := newFeed(at)
+	ids := newEntityIDs()
+	for _, trip := range trips {
+		if !trip.IsEntityInMessage && len(trip.StopTimeUpdates) == 0 && trip.Delay == nil &&
+			trip.ID.ScheduleRelationship == gtfsrt.TripDescriptor_SCHEDULED {
+			continue
+		}
+		feed.Entity = append(feed.Entity, &gtfsrt.FeedEntity{
+			Id:         ids.next(trip.ID.ID),
+			TripUpdate: tripUpdate(trip),
+		})
+	}
+	return feed
+}
+
+// Alerts builds a full-dataset feed of the service alerts.
+func Alerts(alerts []gtfs.Alert, at time.Time) *gtfsrt.FeedMessage {
+	feed := newFeed(at)
+	ids := newEntityIDs()
+	for _, alert := range alerts {
+		feed.Entity = append(feed.Entity, &gtfsrt.FeedEntity{
+			Id:    ids.next(alert.ID),
+			Alert: serviceAlert(alert),
+		})
+	}
+	return feed
+}
+
+// Encode serializes a feed to the GTFS-realtime wire format.
+func Encode(feed *gtfsrt.FeedMessage) ([]byte, error) {
+	return proto.Marshal(feed)
+}
+
+func newFeed(at time.Time) *gtfsrt.FeedMessage {
+	return &gtfsrt.FeedMessage{
+		Header: &gtfsrt.FeedHeader{
+			GtfsRealtimeVersion: proto.String(Version),
+			Incrementality:      gtfsrt.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           unixSeconds(at),
+		},
+	}
+}
+
+// entityIDs hands out the feed entity IDs, which must be unique within a feed.
+// An entity is named after what it describes; a missing or repeated name falls
+// back to one numbered by position.
+type entityIDs struct {
+	seen map[string]bool
+	n    int
+}
+
+func newEntityIDs() *entityIDs {
+	return &entityIDs{seen: make(map[string]bool)}
+}
+
+func (ids *entityIDs) next(name string) *string {
+	ids.n++
+	id := name
+	for suffix := ids.n; id == "" || ids.seen[id]; suffix++ {
+		id = fmt.Sprintf("%s#%d", name, suffix)
+	}
+	ids.seen[id] = true
+	return proto.String(id)
+}
+
+func vehiclePosition(vehicle gtfs.Vehicle) *gtfsrt.VehiclePosition {
+	position := &gtfsrt.VehiclePosition{
+		Vehicle:             vehicleDescriptor(vehicle.ID),
+		CurrentStopSequence: vehicle.CurrentStopSequence,
+		StopId:              vehicle.StopID,
+		CurrentStatus:       vehicle.CurrentStatus,
+		OccupancyStatus:     vehicle.OccupancyStatus,
+		OccupancyPercentage: vehicle.OccupancyPercentage,
+	}
+	if vehicle.Trip != nil {
+		position.Trip = tripDescriptor(vehicle.Trip.ID)
+	}
+	if p := vehicle.Position; p != nil && p.Latitude != nil && p.Longitude != nil {
+		position.Position = &gtfsrt.Position{
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Bearing:   p.Bearing,
+			Odometer:  p.Odometer,
+			Speed:     p.Speed,
+		}
+	}
+	if vehicle.Timestamp != nil {
+		position.Timestamp = unixSeconds(*vehicle.Timestamp)
+	}
+	if vehicle.CongestionLevel != gtfsrt.VehiclePosition_UNKNOWN_CONGESTION_LEVEL {
+		position.CongestionLevel = vehicle.CongestionLevel.Enum()
+	}
+	return position
+}
+
+func tripUpdate(trip gtfs.Trip) *gtfsrt.TripUpdate {
+	update := &gtfsrt.TripUpdate{
+		Trip: tripDescriptor(trip.ID),
+	}
+	if trip.Vehicle != nil {
+		update.Vehicle = vehicleDescriptor(trip.Vehicle.ID)
+	}
+	if trip.Delay != nil {
+		update.Delay = proto.Int32(int32(trip.Delay.Seconds()))
+	}
+	for _, stu := range trip.StopTimeUpdates {
+		update.StopTimeUpdate = append(update.StopTimeUpdate, &gtfsrt.TripUpdate_StopTimeUpdate{
+			StopSequence:         stu.StopSequence,
+			StopId:               stu.StopID,
+			Arrival:              stopTimeEvent(stu.Arrival),
+			Departure:            stopTimeEvent(stu.Departure),
+			ScheduleRelationship: stu.ScheduleRelationship.Enum(),
+		})
+	}
+	return update
+}
+
+func stopTimeEvent(event *gtfs.StopTimeEvent) *gtfsrt.TripUpdate_StopTimeEvent {
+	if event == nil {
+		return nil
+	}
+	result := &gtfsrt.TripUpdate_StopTimeEvent{Uncertainty: event.Uncertainty}
+	if event.Time != nil {
+		result.Time = proto.Int64(event.Time.Unix())
+	}
+	if event.Delay != nil {
+		result.Delay = proto.Int32(int32(event.Delay.Seconds()))
+	}
+	return result
+}
+
+func tripDescriptor(id gtfs.TripID) *gtfsrt.TripDescriptor {
+	descriptor := &gtfsrt.TripDescriptor{
+		TripId:               optionalString(id.ID),
+		RouteId:              optionalString(id.RouteID),
+		DirectionId:          directionID(id.DirectionID),
+		ScheduleRelationship: id.ScheduleRelationship.Enum(),
+	}
+	if id.HasStartTime {
+		seconds := int64(id.StartTime / time.Second)
+		descriptor.StartTime = proto.String(fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60))
+	}
+	if id.HasStartDate {
+		descriptor.StartDate = proto.String(id.StartDate.Format("20060102"))
+	}
+	return descriptor
+}
+
+func vehicleDescriptor(id *gtfs.VehicleID) *gtfsrt.VehicleDescriptor {
+	if id == nil {
+		return nil
+	}
+	return &gtfsrt.VehicleDescriptor{
+		Id:           optionalString(id.ID),
+		Label:        optionalString(id.Label),
+		LicensePlate: optionalString(id.LicensePlate),
+	}
+}
+
+func serviceAlert(alert gtfs.Alert) *gtfsrt.Alert {
+	result := &gtfsrt.Alert{
+		Cause:           alert.Cause.Enum(),
+		Effect:          alert.Effect.Enum(),
+		Url:             translatedString(alert.URL),
+		HeaderText:      translatedString(alert.Header),
+		DescriptionText: translatedString(alert.Description),
+	}
+	for _, period := range alert.ActivePeriods {
+		timeRange := &gtfsrt.TimeRange{}
+		if period.StartsAt != nil {
+			timeRange.Start = unixSeconds(*period.StartsAt)
+		}
+		if period.EndsAt != nil {
+			timeRange.End = unixSeconds(*period.EndsAt)
+		}
+		result.ActivePeriod = append(result.ActivePeriod, timeRange)
+	}
+	for _, entity := range alert.InformedEntities {
+		selector := &gtfsrt.EntitySelector{
+			AgencyId:    entity.AgencyID,
+			RouteId:     entity.RouteID,
+			DirectionId: directionID(entity.DirectionID),
+			StopId:      entity.StopID,
+		}
+		if entity.RouteType != gtfs.RouteType_Unknown {
+			selector.RouteType = proto.Int32(int32(entity.RouteType))
+		}
+		if entity.TripID != nil {
+			selector.Trip = tripDescriptor(*entity.TripID)
+		}
+		result.InformedEntity = append(result.InformedEntity, selector)
+	}
+	return result
+}
+
+func translatedString(texts []gtfs.AlertText) *gtfsrt.TranslatedString {
+	if len(texts) == 0 {
+		return nil
+	}
+	result := &gtfsrt.TranslatedString{}
+	for _, text := range texts {
+		result.Translation = append(result.Translation, &gtfsrt.TranslatedString_Translation{
+			Text:     proto.String(text.Text),
+			Language: optionalString(text.Language),
+		})
+	}
+	return result
+}
+
+// directionID converts go-gtfs's three-valued direction back to GTFS's 0 or 1.
+func directionID(direction gtfs.DirectionID) *uint32 {
+	switch direction {
+	case gtfs.DirectionID_False:
+		return proto.Uint32(0)
+	case gtfs.DirectionID_True:
+		return proto.Uint32(1)
+	default:
+		return nil
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return proto.String(s)
+}
+
+func unixSeconds(t time.Time) *uint64 {
+	if t.IsZero() || t.Unix() < 0 {
+		return nil
+	}
+	return proto.Uint64(uint64(t.Unix()))
+}
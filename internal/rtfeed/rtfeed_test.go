@@ -0,0 +1,142 @@
+package rtfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneBusAway/go-gtfs"
+	gtfsrt "github.com/OneBusAway/go-gtfs/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var feedTime = time.Date(2025, 6, 13, 11, 0, 0, 0, time.UTC)
+
+func roundTrip(t *testing.T, feed *gtfsrt.FeedMessage) *gtfs.Realtime {
+	t.Helper()
+	body, err := Encode(feed)
+	require.NoError(t, err)
+	realtime, err := gtfs.ParseRealtime(body, &gtfs.ParseRealtimeOptions{})
+	require.NoError(t, err)
+	return realtime
+}
+
+func TestVehiclePositions(t *testing.T) {
+	lat, lon := float32(40.58), float32(-122.39)
+	seq := uint32(7)
+	reported := feedTime.Add(-30 * time.Second)
+	vehicles := []gtfs.Vehicle{
+		{
+			ID:                  &gtfs.VehicleID{ID: "bus1", Label: "101"},
+			Trip:                &gtfs.Trip{ID: gtfs.TripID{ID: "trip1", RouteID: "151", DirectionID: gtfs.DirectionID_False}},
+			Position:            &gtfs.Position{Latitude: &lat, Longitude: &lon},
+			CurrentStopSequence: &seq,
+			Timestamp:           &reported,
+		},
+		{ID: &gtfs.VehicleID{ID: "bus2"}, Position: &gtfs.Position{Latitude: &lat}},
+		{Trip: &gtfs.Trip{ID: gtfs.TripID{ID: "trip3"}}},
+	}
+
+	feed := VehiclePositions(vehicles, feedTime)
+	assert.Equal(t, Version, feed.GetHeader().GetGtfsRealtimeVersion())
+	assert.Equal(t, gtfsrt.FeedHeader_FULL_DATASET, feed.GetHeader().GetIncrementality())
+	assert.Equal(t, uint64(feedTime.Unix()), feed.GetHeader().GetTimestamp())
+	require.Len(t, feed.Entity, 2, "a vehicle without an ID is left out")
+	assert.Nil(t, feed.Entity[1].GetVehicle().GetPosition(), "a position without coordinates is left out")
+
+	realtime := roundTrip(t, feed)
+	require.Len(t, realtime.Vehicles, 2)
+	var bus1 gtfs.Vehicle
+	for _, vehicle := range realtime.Vehicles {
+		if vehicle.ID != nil && vehicle.ID.ID == "bus1" {
+			bus1 = vehicle
+		}
+	}
+	require.NotNil(t, bus1.ID, "the parsed feed does not keep entity order")
+	assert.Equal(t, "bus1", bus1.ID.ID)
+	assert.Equal(t, "101", bus1.ID.Label)
+	assert.Equal(t, "trip1", bus1.Trip.ID.ID)
+	assert.Equal(t, "151", bus1.Trip.ID.RouteID)
+	assert.Equal(t, gtfs.DirectionID_False, bus1.Trip.ID.DirectionID)
+	assert.InDelta(t, lat, *bus1.Position.Latitude, 1e-6)
+	assert.Equal(t, seq, *bus1.CurrentStopSequence)
+	assert.Equal(t, reported.Unix(), bus1.Timestamp.Unix())
+}
+
+func TestTripUpdates(t *testing.T) {
+	delay := 2 * time.Minute
+	arrival := feedTime.Add(5 * time.Minute)
+	seq := uint32(3)
+	stopID := "2000"
+	trips := []gtfs.Trip{
+		{
+			ID: gtfs.TripID{
+				ID: "trip1", RouteID: "151",
+				HasStartTime: true, StartTime: 7*time.Hour + 5*time.Minute,
+				HasStartDate: true, StartDate: time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC),
+			},
+			StopTimeUpdates: []gtfs.StopTimeUpdate{{
+				StopSequence: &seq,
+				StopID:       &stopID,
+				Arrival:      &gtfs.StopTimeEvent{Time: &arrival, Delay: &delay},
+			}},
+		},
+		{ID: gtfs.TripID{ID: "trip2"}, Delay: &delay},
+		{ID: gtfs.TripID{ID: "trip3", ScheduleRelationship: gtfsrt.TripDescriptor_CANCELED}},
+		{ID: gtfs.TripID{ID: "trip4"}},
+	}
+
+	feed := TripUpdates(trips, feedTime)
+	require.Len(t, feed.Entity, 3, "a trip with nothing to report is left out")
+	assert.Equal(t, "07:05:00", feed.Entity[0].GetTripUpdate().GetTrip().GetStartTime())
+	assert.Equal(t, "20250613", feed.Entity[0].GetTripUpdate().GetTrip().GetStartDate())
+
+	realtime := roundTrip(t, feed)
+	require.Len(t, realtime.Trips, 3)
+	trip1 := realtime.Trips[0]
+	assert.Equal(t, "trip1", trip1.ID.ID)
+	require.Len(t, trip1.StopTimeUpdates, 1)
+	assert.Equal(t, stopID, *trip1.StopTimeUpdates[0].StopID)
+	assert.Equal(t, arrival.Unix(), trip1.StopTimeUpdates[0].Arrival.Time.Unix())
+	assert.Equal(t, delay, *trip1.StopTimeUpdates[0].Arrival.Delay)
+	assert.Equal(t, delay, *realtime.Trips[1].Delay)
+	assert.Equal(t, gtfsrt.TripDescriptor_CANCELED, realtime.Trips[2].ID.ScheduleRelationship)
+}
+
+func TestAlerts(t *testing.T) {
+	start := feedTime.Add(-time.Hour)
+	routeID := "151"
+	alerts := []gtfs.Alert{{
+		ID:               "detour",
+		Cause:            gtfsrt.Alert_CONSTRUCTION,
+		Effect:           gtfsrt.Alert_DETOUR,
+		ActivePeriods:    []gtfs.AlertActivePeriod{{StartsAt: &start}},
+		InformedEntities: []gtfs.AlertInformedEntity{{RouteID: &routeID, RouteType: gtfs.RouteType_Unknown}},
+		Header:           []gtfs.AlertText{{Text: "Detour on Main", Language: "en"}},
+	}}
+
+	feed := Alerts(alerts, feedTime)
+	require.Len(t, feed.Entity, 1)
+	assert.Nil(t, feed.Entity[0].GetAlert().GetInformedEntity()[0].RouteType, "an unknown route type is left out")
+
+	realtime := roundTrip(t, feed)
+	require.Len(t, realtime.Alerts, 1)
+	alert := realtime.Alerts[0]
+	assert.Equal(t, "detour", alert.ID)
+	assert.Equal(t, gtfsrt.Alert_CONSTRUCTION, alert.Cause)
+	assert.Equal(t, gtfsrt.Alert_DETOUR, alert.Effect)
+	require.Len(t, alert.ActivePeriods, 1)
+	assert.Equal(t, start.Unix(), alert.ActivePeriods[0].StartsAt.Unix())
+	assert.Nil(t, alert.ActivePeriods[0].EndsAt)
+	require.Len(t, alert.InformedEntities, 1)
+	assert.Equal(t, routeID, *alert.InformedEntities[0].RouteID)
+	assert.Equal(t, []gtfs.AlertText{{Text: "Detour on Main", Language: "en"}}, alert.Header)
+}
+
+func TestEntityIDsAreUnique(t *testing.T) {
+	ids := newEntityIDs()
+	assert.Equal(t, "a", *ids.next("a"))
+	assert.Equal(t, "a#2", *ids.next("a"))
+	assert.Equal(t, "#3", *ids.next(""))
+	assert.Equal(t, "b", *ids.next("b"))
+}
@@ -0,0 +1,165 @@
+// Package siri defines the subset of the SIRI 2.0 data model served by maglev's
+// VehicleMonitoring and StopMonitoring endpoints, and encodes it as XML or JSON.
+package siri
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// Namespace is the SIRI XML namespace.
+	Namespace = "http://www.siri.org.uk/siri"
+	// Version is the SIRI schema version reported in responses.
+	Version = "2.0"
+)
+
+// Format selects the response encoding.
+type Format int
+
+const (
+	FormatXML Format = iota
+	FormatJSON
+)
+
+// ParseFormat maps the "type" request parameter to a Format. XML is the default,
+// matching the SIRI specification; "json" selects the JSON rendering.
+func ParseFormat(value string) (Format, error) {
+	switch value {
+	case "", "xml":
+		return FormatXML, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatXML, fmt.Errorf("unsupported type %q: must be xml or json", value)
+	}
+}
+
+// ContentType returns the HTTP Content-Type for the format.
+func (f Format) ContentType() string {
+	if f == FormatJSON {
+		return "application/json"
+	}
+	return "application/xml"
+}
+
+// Siri is the root element of every response.
+type Siri struct {
+	XMLName         xml.Name        `xml:"Siri" json:"-"`
+	Xmlns           string          `xml:"xmlns,attr" json:"-"`
+	Version         string          `xml:"version,attr" json:"-"`
+	ServiceDelivery ServiceDelivery `xml:"ServiceDelivery" json:"ServiceDelivery"`
+}
+
+type ServiceDelivery struct {
+	ResponseTimestamp         time.Time                   `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	ProducerRef               string                      `xml:"ProducerRef,omitempty" json:"ProducerRef,omitempty"`
+	VehicleMonitoringDelivery []VehicleMonitoringDelivery `xml:"VehicleMonitoringDelivery,omitempty" json:"VehicleMonitoringDelivery,omitempty"`
+	StopMonitoringDelivery    []StopMonitoringDelivery    `xml:"StopMonitoringDelivery,omitempty" json:"StopMonitoringDelivery,omitempty"`
+}
+
+// ErrorCondition reports why a delivery could not be produced.
+type ErrorCondition struct {
+	Description string `xml:"Description" json:"Description"`
+}
+
+type VehicleMonitoringDelivery struct {
+	Version           string            `xml:"version,attr" json:"-"`
+	ResponseTimestamp time.Time         `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	Status            *bool             `xml:"Status,omitempty" json:"Status,omitempty"`
+	ErrorCondition    *ErrorCondition   `xml:"ErrorCondition,omitempty" json:"ErrorCondition,omitempty"`
+	VehicleActivity   []VehicleActivity `xml:"VehicleActivity" json:"VehicleActivity"`
+}
+
+type VehicleActivity struct {
+	RecordedAtTime          time.Time               `xml:"RecordedAtTime" json:"RecordedAtTime"`
+	MonitoredVehicleJourney MonitoredVehicleJourney `xml:"MonitoredVehicleJourney" json:"MonitoredVehicleJourney"`
+}
+
+type StopMonitoringDelivery struct {
+	Version            string               `xml:"version,attr" json:"-"`
+	ResponseTimestamp  time.Time            `xml:"ResponseTimestamp" json:"ResponseTimestamp"`
+	Status             *bool                `xml:"Status,omitempty" json:"Status,omitempty"`
+	ErrorCondition     *ErrorCondition      `xml:"ErrorCondition,omitempty" json:"ErrorCondition,omitempty"`
+	MonitoredStopVisit []MonitoredStopVisit `xml:"MonitoredStopVisit" json:"MonitoredStopVisit"`
+}
+
+type MonitoredStopVisit struct {
+	RecordedAtTime          time.Time               `xml:"RecordedAtTime" json:"RecordedAtTime"`
+	MonitoringRef           string                  `xml:"MonitoringRef" json:"MonitoringRef"`
+	MonitoredVehicleJourney MonitoredVehicleJourney `xml:"MonitoredVehicleJourney" json:"MonitoredVehicleJourney"`
+}
+
+type MonitoredVehicleJourney struct {
+	LineRef                 string                  `xml:"LineRef" json:"LineRef"`
+	DirectionRef            string                  `xml:"DirectionRef,omitempty" json:"DirectionRef,omitempty"`
+	FramedVehicleJourneyRef FramedVehicleJourneyRef `xml:"FramedVehicleJourneyRef" json:"FramedVehicleJourneyRef"`
+	JourneyPatternRef       string                  `xml:"JourneyPatternRef,omitempty" json:"JourneyPatternRef,omitempty"`
+	PublishedLineName       string                  `xml:"PublishedLineName,omitempty" json:"PublishedLineName,omitempty"`
+	OperatorRef             string                  `xml:"OperatorRef,omitempty" json:"OperatorRef,omitempty"`
+	DestinationName         string                  `xml:"DestinationName,omitempty" json:"DestinationName,omitempty"`
+	Monitored               bool                    `xml:"Monitored" json:"Monitored"`
+	VehicleLocation         *VehicleLocation        `xml:"VehicleLocation,omitempty" json:"VehicleLocation,omitempty"`
+	Bearing                 *float64                `xml:"Bearing,omitempty" json:"Bearing,omitempty"`
+	Delay                   string                  `xml:"Delay,omitempty" json:"Delay,omitempty"`
+	BlockRef                string                  `xml:"BlockRef,omitempty" json:"BlockRef,omitempty"`
+	VehicleRef              string                  `xml:"VehicleRef,omitempty" json:"VehicleRef,omitempty"`
+	MonitoredCall           *MonitoredCall          `xml:"MonitoredCall,omitempty" json:"MonitoredCall,omitempty"`
+}
+
+type FramedVehicleJourneyRef struct {
+	// DataFrameRef is the service date, formatted YYYY-MM-DD.
+	DataFrameRef           string `xml:"DataFrameRef" json:"DataFrameRef"`
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef" json:"DatedVehicleJourneyRef"`
+}
+
+type VehicleLocation struct {
+	Longitude float64 `xml:"Longitude" json:"Longitude"`
+	Latitude  float64 `xml:"Latitude" json:"Latitude"`
+}
+
+type MonitoredCall struct {
+	StopPointRef          string     `xml:"StopPointRef" json:"StopPointRef"`
+	StopPointName         string     `xml:"StopPointName,omitempty" json:"StopPointName,omitempty"`
+	AimedArrivalTime      *time.Time `xml:"AimedArrivalTime,omitempty" json:"AimedArrivalTime,omitempty"`
+	ExpectedArrivalTime   *time.Time `xml:"ExpectedArrivalTime,omitempty" json:"ExpectedArrivalTime,omitempty"`
+	AimedDepartureTime    *time.Time `xml:"AimedDepartureTime,omitempty" json:"AimedDepartureTime,omitempty"`
+	ExpectedDepartureTime *time.Time `xml:"ExpectedDepartureTime,omitempty" json:"ExpectedDepartureTime,omitempty"`
+	DistanceFromStop      *float64   `xml:"DistanceFromStop,omitempty" json:"DistanceFromStop,omitempty"`
+}
+
+// NewSiri wraps a service delivery in the root element.
+func NewSiri(delivery ServiceDelivery) Siri {
+	return Siri{Xmlns: Namespace, Version: Version, ServiceDelivery: delivery}
+}
+
+// Encode writes s to w in the requested format. The JSON rendering nests the
+// delivery under a top-level "Siri" key, as other SIRI JSON producers do.
+func Encode(w io.Writer, format Format, s Siri) error {
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(map[string]Siri{"Siri": s})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(s)
+}
+
+// FormatDelay renders a schedule deviation as an xs:duration, e.g. "PT90S" or "-PT30S".
+func FormatDelay(d time.Duration) string {
+	seconds := int64(math.Round(d.Seconds()))
+	if seconds < 0 {
+		return fmt.Sprintf("-PT%dS", -seconds)
+	}
+	return fmt.Sprintf("PT%dS", seconds)
+}
+
+// FormatServiceDate renders a service date as a DataFrameRef.
+func FormatServiceDate(serviceDate time.Time) string {
+	return serviceDate.Format("2006-01-02")
+}
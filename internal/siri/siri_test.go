@@ -0,0 +1,94 @@
+package siri
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatXML, f)
+
+	f, err = ParseFormat("json")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, f)
+	assert.Equal(t, "application/json", f.ContentType())
+
+	_, err = ParseFormat("csv")
+	assert.Error(t, err)
+}
+
+func TestFormatDelay(t *testing.T) {
+	assert.Equal(t, "PT0S", FormatDelay(0))
+	assert.Equal(t, "PT90S", FormatDelay(90*time.Second))
+	assert.Equal(t, "-PT30S", FormatDelay(-30*time.Second))
+	assert.Equal(t, "PT2S", FormatDelay(1600*time.Millisecond))
+}
+
+func testDelivery() ServiceDelivery {
+	ts := time.Date(2025, 6, 13, 11, 0, 0, 0, time.UTC)
+	return ServiceDelivery{
+		ResponseTimestamp: ts,
+		VehicleMonitoringDelivery: []VehicleMonitoringDelivery{{
+			Version:           Version,
+			ResponseTimestamp: ts,
+			VehicleActivity: []VehicleActivity{{
+				RecordedAtTime: ts,
+				MonitoredVehicleJourney: MonitoredVehicleJourney{
+					LineRef: "25_151",
+					FramedVehicleJourneyRef: FramedVehicleJourneyRef{
+						DataFrameRef:           FormatServiceDate(ts),
+						DatedVehicleJourneyRef: "25_trip1",
+					},
+					Monitored:       true,
+					VehicleLocation: &VehicleLocation{Latitude: 40.58, Longitude: -122.39},
+					Delay:           FormatDelay(2 * time.Minute),
+					VehicleRef:      "25_bus1",
+				},
+			}},
+		}},
+	}
+}
+
+func TestEncodeXML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, FormatXML, NewSiri(testDelivery())))
+
+	body := buf.String()
+	assert.True(t, strings.HasPrefix(body, xml.Header))
+	assert.Contains(t, body, `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0">`)
+	assert.Contains(t, body, "<DataFrameRef>2025-06-13</DataFrameRef>")
+	assert.Contains(t, body, "<Delay>PT120S</Delay>")
+	assert.NotContains(t, body, "StopMonitoringDelivery", "empty deliveries are omitted")
+	assert.NotContains(t, body, "MonitoredCall", "absent calls are omitted")
+
+	var decoded Siri
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.ServiceDelivery.VehicleMonitoringDelivery, 1)
+	assert.Equal(t, "25_bus1", decoded.ServiceDelivery.VehicleMonitoringDelivery[0].VehicleActivity[0].MonitoredVehicleJourney.VehicleRef)
+}
+
+func TestEncodeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, FormatJSON, NewSiri(testDelivery())))
+
+	var decoded struct {
+		Siri Siri `json:"Siri"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	deliveries := decoded.Siri.ServiceDelivery.VehicleMonitoringDelivery
+	require.Len(t, deliveries, 1)
+	journey := deliveries[0].VehicleActivity[0].MonitoredVehicleJourney
+	assert.Equal(t, "25_151", journey.LineRef)
+	assert.Equal(t, "25_trip1", journey.FramedVehicleJourneyRef.DatedVehicleJourneyRef)
+	require.NotNil(t, journey.VehicleLocation)
+	assert.InDelta(t, -122.39, journey.VehicleLocation.Longitude, 1e-9)
+}
@@ -30,59 +30,52 @@ func ServiceDateMillis(explicitServiceDate *time.Time, currentTime time.Time) (t
 }
 
 func CalculateSecondsSinceServiceDate(currentTime time.Time, serviceDate time.Time) int64 {
-	duration := currentTime.Sub(serviceDate)
-	return int64(duration.Seconds())
+	return ServiceTimeAt(serviceDate, currentTime).Seconds()
 }
 
-// Converts a GTFS stop-time value (stored as nanoseconds in db since midnight)
-// to seconds since midnight.
-func NanosToSeconds(nanos int64) int64 {
-	return nanos / 1e9
+// StopTimeDuration converts a stop-time value, stored in the database as
+// seconds since service-day midnight, to a duration to add to that midnight.
+func StopTimeDuration(seconds int64) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
+
+// StopTimeSeconds converts a duration since service-day midnight to the
+// seconds the database stores for stop times, dropping any fraction.
+func StopTimeSeconds(d time.Duration) int64 {
+	return int64(d / time.Second)
 }
 
 // EffectiveStopTimeSeconds returns the effective stop time in seconds since midnight,
-// using arrivalTimeNanos with a fallback to departureTimeNanos when arrival is zero.
-// Both inputs are nanoseconds since midnight (the GTFS database storage format).
-func EffectiveStopTimeSeconds(arrivalTimeNanos, departureTimeNanos int64) int64 {
-	if arrivalTimeNanos > 0 {
-		return arrivalTimeNanos / 1e9
+// using arrivalTime with a fallback to departureTime when arrival is zero.
+func EffectiveStopTimeSeconds(arrivalTime, departureTime int64) int64 {
+	if arrivalTime > 0 {
+		return arrivalTime
 	}
-	return departureTimeNanos / 1e9
+	return departureTime
 }
 
-// ExtractCodeID extracts the `code_id` from a string in the format `{agency_id}_{code_id}`.
+// ExtractCodeID extracts the `code_id` from a combined ID, by default in the
+// format `{agency_id}_{code_id}`; see SetIDCodec.
 func ExtractCodeID(combinedID string) (string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid format: %s", combinedID)
-	}
-	return parts[1], nil
+	_, codeID, err := CurrentIDCodec().Parse(combinedID)
+	return codeID, err
 }
 
-// ExtractAgencyID extracts the `agency_id` from a string in the format `{agency_id}_{code_id}`.
+// ExtractAgencyID extracts the `agency_id` from a combined ID, by default in
+// the format `{agency_id}_{code_id}`; see SetIDCodec.
 func ExtractAgencyID(combinedID string) (string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid format: %s", combinedID)
-	}
-	return parts[0], nil
+	agencyID, _, err := CurrentIDCodec().Parse(combinedID)
+	return agencyID, err
 }
 
-// ExtractAgencyIDAndCodeID Extract AgencyIDAndCodeID extracts both `agency_id` and `code_id` from a string in the format `{agency_id}_{code_id}`.
+// ExtractAgencyIDAndCodeID Extract AgencyIDAndCodeID extracts both `agency_id` and `code_id` from a combined ID, by default in the format `{agency_id}_{code_id}`; see SetIDCodec.
 func ExtractAgencyIDAndCodeID(combinedID string) (string, string, error) {
-	parts := strings.SplitN(combinedID, "_", 2)
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid format: %s", combinedID)
-	}
-	return parts[0], parts[1], nil
+	return CurrentIDCodec().Parse(combinedID)
 }
 
-// FormCombinedID forms a combined ID in the format `{agency_id}_{code_id}` using the given `agencyID` and `codeID`.
+// FormCombinedID forms a combined ID, by default in the format `{agency_id}_{code_id}`, using the given `agencyID` and `codeID`; see SetIDCodec.
 func FormCombinedID(agencyID, codeID string) string {
-	if codeID == "" || agencyID == "" {
-		return ""
-	}
-	return fmt.Sprintf("%s_%s", agencyID, codeID)
+	return CurrentIDCodec().Form(agencyID, codeID)
 }
 
 // MapWheelchairBoarding converts GTFS wheelchair boarding values to our API format
@@ -122,6 +115,25 @@ func ParseFloatParam(params url.Values, key string, fieldErrors map[string][]str
 	return f, fieldErrors
 }
 
+// ParseBoolParam parses an optional true/false query parameter, which is
+// false when absent.
+func ParseBoolParam(params url.Values, key string, fieldErrors map[string][]string) (bool, map[string][]string) {
+	if fieldErrors == nil {
+		fieldErrors = make(map[string][]string)
+	}
+
+	val := params.Get(key)
+	if val == "" {
+		return false, fieldErrors
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		fieldErrors[key] = append(fieldErrors[key], fmt.Sprintf("Invalid field value for field %q.", key))
+	}
+	return b, fieldErrors
+}
+
 func ParseTimeParameter(timeParam string, currentLocation *time.Location) (string, time.Time, map[string][]string, bool) {
 	if timeParam == "" {
 		// No time parameter, use current date
@@ -196,6 +208,63 @@ func ParseMaxCount(queryParams url.Values, defaultCount int, fieldErrors map[str
 	return maxCount, fieldErrors
 }
 
+// maxRouteTypeTokens bounds the route types a single parameter may list.
+const maxRouteTypeTokens = 100
+
+// routeTypeNames maps the names accepted in route type parameters to their
+// GTFS route_type values.
+var routeTypeNames = map[string]int{
+	"tram":        0,
+	"subway":      1,
+	"rail":        2,
+	"bus":         3,
+	"ferry":       4,
+	"cable_tram":  5,
+	"aerial_lift": 6,
+	"funicular":   7,
+	"trolleybus":  11,
+	"monorail":    12,
+}
+
+// ParseRouteTypes parses the comma separated GTFS route types in the key
+// parameter, given as numbers or names, e.g. routeTypes=3,ferry. An absent
+// parameter returns no route types, which callers treat as no filter.
+func ParseRouteTypes(queryParams url.Values, key string, fieldErrors map[string][]string) ([]int, map[string][]string) {
+	if fieldErrors == nil {
+		fieldErrors = make(map[string][]string)
+	}
+
+	value := queryParams.Get(key)
+	if value == "" {
+		return nil, fieldErrors
+	}
+
+	tokens := strings.Split(value, ",")
+	if len(tokens) > maxRouteTypeTokens {
+		fieldErrors[key] = []string{fmt.Sprintf("too many route types (maximum %d allowed)", maxRouteTypeTokens)}
+		return nil, fieldErrors
+	}
+
+	var routeTypes []int
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if rt, ok := routeTypeNames[strings.ToLower(token)]; ok {
+			routeTypes = append(routeTypes, rt)
+			continue
+		}
+		rt, err := strconv.Atoi(token)
+		if err != nil {
+			fieldErrors[key] = []string{fmt.Sprintf("Invalid field value for field %q.", key)}
+			return nil, fieldErrors
+		}
+		routeTypes = append(routeTypes, rt)
+	}
+	return routeTypes, fieldErrors
+}
+
 // a custom type for context keys to avoid collisions
 type contextKey string
 
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -230,6 +231,16 @@ func TestFormCombinedID(t *testing.T) {
 	}
 }
 
+func TestStopTimeConversions(t *testing.T) {
+	overnight := 25*time.Hour + 10*time.Minute + 5*time.Second
+	assert.Equal(t, int64(90605), StopTimeSeconds(overnight))
+	assert.Equal(t, overnight, StopTimeDuration(90605))
+	assert.Equal(t, int64(90605), StopTimeSeconds(overnight+999*time.Millisecond), "fractions of a second are dropped")
+
+	assert.Equal(t, int64(100), EffectiveStopTimeSeconds(100, 160))
+	assert.Equal(t, int64(160), EffectiveStopTimeSeconds(0, 160))
+}
+
 func TestMapWheelchairBoarding(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -266,6 +277,32 @@ func TestMapWheelchairBoarding(t *testing.T) {
 	}
 }
 
+func TestMapWheelchairAccessible(t *testing.T) {
+	assert.Equal(t, models.Accessible, MapWheelchairAccessible(sql.NullInt64{Int64: 1, Valid: true}))
+	assert.Equal(t, models.NotAccessible, MapWheelchairAccessible(sql.NullInt64{Int64: 2, Valid: true}))
+	assert.Equal(t, models.UnknownValue, MapWheelchairAccessible(sql.NullInt64{}))
+}
+
+func TestMapBikesAllowed(t *testing.T) {
+	assert.Equal(t, models.BikesAllowed, MapBikesAllowed(sql.NullInt64{Int64: 1, Valid: true}))
+	assert.Equal(t, models.BikesNotAllowed, MapBikesAllowed(sql.NullInt64{Int64: 2, Valid: true}))
+	assert.Equal(t, models.UnknownValue, MapBikesAllowed(sql.NullInt64{Int64: 0, Valid: true}))
+	assert.Equal(t, models.UnknownValue, MapBikesAllowed(sql.NullInt64{}))
+}
+
+func TestParseBoolParam(t *testing.T) {
+	value, fieldErrors := ParseBoolParam(url.Values{"wheelchairAccessible": {"true"}}, "wheelchairAccessible", nil)
+	assert.True(t, value)
+	assert.Empty(t, fieldErrors)
+
+	value, fieldErrors = ParseBoolParam(url.Values{}, "wheelchairAccessible", nil)
+	assert.False(t, value)
+	assert.Empty(t, fieldErrors)
+
+	_, fieldErrors = ParseBoolParam(url.Values{"wheelchairAccessible": {"yes please"}}, "wheelchairAccessible", nil)
+	assert.Contains(t, fieldErrors, "wheelchairAccessible")
+}
+
 func TestParseFloatParam(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -642,6 +679,39 @@ func TestParseMaxCount(t *testing.T) {
 	}
 }
 
+func TestParseRouteTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    []int
+		expectError bool
+	}{
+		{name: "absent", value: "", expected: nil},
+		{name: "single", value: "3", expected: []int{3}},
+		{name: "list with spaces and empty tokens", value: "0, 3,,", expected: []int{0, 3}},
+		{name: "names", value: "Bus,ferry,2", expected: []int{3, 4, 2}},
+		{name: "unknown name", value: "3,boat", expectError: true},
+		{name: "too many", value: strings.Repeat("3,", 101), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := url.Values{}
+			if tt.value != "" {
+				params.Set("nearbyStopsRouteType", tt.value)
+			}
+			routeTypes, fieldErrors := ParseRouteTypes(params, "nearbyStopsRouteType", nil)
+			if tt.expectError {
+				assert.Contains(t, fieldErrors, "nearbyStopsRouteType")
+				assert.Nil(t, routeTypes)
+				return
+			}
+			assert.Empty(t, fieldErrors)
+			assert.Equal(t, tt.expected, routeTypes)
+		})
+	}
+}
+
 func TestParsePaginationParams(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/OneBusAway/go-gtfs"
+	"maglev.onebusaway.org/internal/models"
 )
 
 // NullStringOrEmpty returns the string value if valid, otherwise returns an empty string
@@ -22,6 +23,15 @@ func NullInt64OrDefault(ni sql.NullInt64, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// NullInt32Ptr returns a pointer to the value if valid, otherwise nil
+func NullInt32Ptr(ni sql.NullInt64) *int32 {
+	if !ni.Valid {
+		return nil
+	}
+	v := int32(ni.Int64)
+	return &v
+}
+
 // NullWheelchairBoardingOrUnknown returns the wheelchair boarding value if valid, otherwise returns NotSpecified
 func NullWheelchairBoardingOrUnknown(ni sql.NullInt64) gtfs.WheelchairBoarding {
 	if ni.Valid {
@@ -29,3 +39,24 @@ func NullWheelchairBoardingOrUnknown(ni sql.NullInt64) gtfs.WheelchairBoarding {
 	}
 	return gtfs.WheelchairBoarding_NotSpecified
 }
+
+// MapWheelchairAccessible converts a trip's GTFS wheelchair_accessible value,
+// which shares the codes of wheelchair_boarding, to our API format.
+func MapWheelchairAccessible(wheelchairAccessible sql.NullInt64) string {
+	return MapWheelchairBoarding(NullWheelchairBoardingOrUnknown(wheelchairAccessible))
+}
+
+// MapBikesAllowed converts a trip's GTFS bikes_allowed value to our API format.
+func MapBikesAllowed(bikesAllowed sql.NullInt64) string {
+	if !bikesAllowed.Valid {
+		return models.UnknownValue
+	}
+	switch gtfs.BikesAllowed(bikesAllowed.Int64) {
+	case gtfs.BikesAllowed_Allowed:
+		return models.BikesAllowed
+	case gtfs.BikesAllowed_NotAllowed:
+		return models.BikesNotAllowed
+	default:
+		return models.UnknownValue
+	}
+}
@@ -35,7 +35,7 @@ func FilterRoutes(q *gtfsdb.Queries, ctx context.Context, present map[string]boo
 			refs = append(refs, models.NewRoute(
 				routeIDStr, r.AgencyID, r.ShortName.String, r.LongName.String,
 				r.Desc.String, models.RouteType(r.Type), r.Url.String,
-				r.Color.String, r.TextColor.String))
+				r.Color.String, r.TextColor.String).WithSortOrder(NullInt32Ptr(r.SortOrder)))
 		}
 	}
 	return refs
@@ -51,7 +51,7 @@ func GetAllRoutesRefs(q *gtfsdb.Queries, ctx context.Context) []interface{} {
 		refs = append(refs, models.NewRoute(
 			FormCombinedID(r.AgencyID, r.ID), r.AgencyID, r.ShortName.String, r.LongName.String,
 			r.Desc.String, models.RouteType(r.Type), r.Url.String,
-			r.Color.String, r.TextColor.String))
+			r.Color.String, r.TextColor.String).WithSortOrder(NullInt32Ptr(r.SortOrder)))
 	}
 	return refs
 }
@@ -0,0 +1,52 @@
+package utils
+
+import "encoding/json"
+
+// GeoJSONContains reports whether the point lies inside a GeoJSON Polygon or
+// MultiPolygon geometry. Holes are honoured, and a point exactly on an edge may
+// fall either way. Other geometry types never contain a point.
+func GeoJSONContains(geometry []byte, lat, lon float64) (bool, error) {
+	var g struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(geometry, &g); err != nil {
+		return false, err
+	}
+
+	var polygons [][][][2]float64
+	switch g.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return false, err
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+
+	for _, polygon := range polygons {
+		// Even-odd ray casting over every ring, so points in a hole cross
+		// the outer ring and the hole's ring and end up outside.
+		inside := false
+		for _, ring := range polygon {
+			for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+				// GeoJSON positions are [longitude, latitude].
+				xi, yi := ring[i][0], ring[i][1]
+				xj, yj := ring[j][0], ring[j][1]
+				if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+					inside = !inside
+				}
+			}
+		}
+		if inside {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoJSONContains(t *testing.T) {
+	// A 1x1 degree square with a 0.2 degree hole in the middle.
+	polygonWithHole := []byte(`{"type":"Polygon","coordinates":[
+		[[-122,47],[-121,47],[-121,48],[-122,48],[-122,47]],
+		[[-121.6,47.4],[-121.4,47.4],[-121.4,47.6],[-121.6,47.6],[-121.6,47.4]]
+	]}`)
+	multiPolygon := []byte(`{"type":"MultiPolygon","coordinates":[
+		[[[0,0],[1,0],[1,1],[0,1],[0,0]]],
+		[[[10,10],[11,10],[11,11],[10,11],[10,10]]]
+	]}`)
+
+	tests := []struct {
+		name     string
+		geometry []byte
+		lat, lon float64
+		expected bool
+	}{
+		{"inside polygon", polygonWithHole, 47.2, -121.8, true},
+		{"in hole", polygonWithHole, 47.5, -121.5, false},
+		{"outside polygon", polygonWithHole, 46.5, -121.5, false},
+		{"first of multipolygon", multiPolygon, 0.5, 0.5, true},
+		{"second of multipolygon", multiPolygon, 10.5, 10.5, true},
+		{"between multipolygon parts", multiPolygon, 5, 5, false},
+		{"point geometry", []byte(`{"type":"Point","coordinates":[0.5,0.5]}`), 0.5, 0.5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contains, err := GeoJSONContains(tt.geometry, tt.lat, tt.lon)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, contains)
+		})
+	}
+
+	_, err := GeoJSONContains([]byte(`{"type":"Polygon","coordinates":"nope"}`), 0, 0)
+	assert.Error(t, err)
+}
@@ -1,12 +1,15 @@
 package utils
 
-import "math"
+import (
+	"math"
+	"sync/atomic"
 
-const (
-	// RadiusOfEarthInMeters is RADIUS_OF_EARTH_IN_KM * 1000
-	RadiusOfEarthInMeters = 6371010.0
+	"maglev.onebusaway.org/internal/geo"
 )
 
+// RadiusOfEarthInMeters is RADIUS_OF_EARTH_IN_KM * 1000
+const RadiusOfEarthInMeters = geo.RadiusOfEarthInMeters
+
 // CoordinateBounds represents a bounding box with min/max latitude and longitude
 type CoordinateBounds struct {
 	MinLat float64
@@ -15,37 +18,38 @@ type CoordinateBounds struct {
 	MaxLon float64
 }
 
-// Distance calculates the distance between two points on the Earth.
-// For short distances (under ~22km), it uses a highly optimized Equirectangular
-// approximation to save CPU cycles. For longer distances, it falls back to the exact formula.
+// Distance calculates the distance in meters between two points on the
+// Earth; see geo.Distance.
 func Distance(lat1, lon1, lat2, lon2 float64) float64 {
-	// Fast-path for short distances: coordinate differences less than 0.2 degrees (~22km)
-	// Bypasses expensive Atan2, Pow, and multiple Sin/Cos calls for 99% of transit queries.
-	if math.Abs(lat2-lat1) < 0.2 && math.Abs(lon2-lon1) < 0.2 {
-		lat1Rad := lat1 * (math.Pi / 180)
-		lat2Rad := lat2 * (math.Pi / 180)
-		dLatRad := (lat2 - lat1) * (math.Pi / 180)
-		dLonRad := (lon2 - lon1) * (math.Pi / 180)
-
-		// Equirectangular approximation
-		x := dLonRad * math.Cos((lat1Rad+lat2Rad)/2)
-		y := dLatRad
-		return RadiusOfEarthInMeters * math.Sqrt(x*x+y*y)
-	}
-
-	// Exact calculation fallback for longer distances
-	lat1Rad := lat1 * (math.Pi / 180)
-	lon1Rad := lon1 * (math.Pi / 180)
-	lat2Rad := lat2 * (math.Pi / 180)
-	lon2Rad := lon2 * (math.Pi / 180)
+	return geo.Distance(lat1, lon1, lat2, lon2)
+}
 
-	deltaLon := lon2Rad - lon1Rad
+// geodesicProjection selects how ProjectOntoSegment finds the closest point
+// of a segment; see SetGeodesicProjection.
+var geodesicProjection atomic.Bool
+
+// SetGeodesicProjection chooses how ProjectOntoSegment treats coordinates.
+// Disabled, the default, latitude and longitude are projected as if they were
+// planar, which stretches east-west distances by 1/cos(latitude) and skews the
+// ratio along segments that do not run north-south, badly so at high latitudes.
+// Enabled, each segment is projected on a local equirectangular plane scaled
+// at its mean latitude.
+func SetGeodesicProjection(enabled bool) {
+	geodesicProjection.Store(enabled)
+}
 
-	y := math.Sqrt(math.Pow(math.Cos(lat2Rad)*math.Sin(deltaLon), 2) +
-		math.Pow(math.Cos(lat1Rad)*math.Sin(lat2Rad)-math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLon), 2))
-	x := math.Sin(lat1Rad)*math.Sin(lat2Rad) + math.Cos(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLon)
+// GeodesicProjection reports whether ProjectOntoSegment uses the geodesic
+// projection.
+func GeodesicProjection() bool {
+	return geodesicProjection.Load()
+}
 
-	return RadiusOfEarthInMeters * math.Atan2(y, x)
+// ProjectOntoSegment finds the point of the segment from (lat1, lon1) to
+// (lat2, lon2) closest to (lat, lon). It returns the distance in meters to
+// that point, how far along the segment it lies as a ratio in [0, 1], and its
+// coordinates. A segment of zero length projects everything onto its start.
+func ProjectOntoSegment(lat, lon, lat1, lon1, lat2, lon2 float64) (distance, ratio, closestLat, closestLon float64) {
+	return geo.ProjectOntoSegment(lat, lon, lat1, lon1, lat2, lon2, geodesicProjection.Load())
 }
 
 func CalculateBounds(lat, lon, distance float64) CoordinateBounds {
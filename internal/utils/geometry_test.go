@@ -459,3 +459,101 @@ func BenchmarkDistance_LongRange(b *testing.B) {
 		Distance(40.7128, -74.0060, 34.0522, -118.2437)
 	}
 }
+
+// referenceProjection finds the closest point of a segment by sampling it
+// densely and measuring every sample, for judging ProjectOntoSegment.
+func referenceProjection(lat, lon, lat1, lon1, lat2, lon2 float64) (distance, ratio float64) {
+	const samples = 100000
+	distance = math.Inf(1)
+	for i := 0; i <= samples; i++ {
+		t := float64(i) / samples
+		if d := Distance(lat, lon, lat1+t*(lat2-lat1), lon1+t*(lon2-lon1)); d < distance {
+			distance, ratio = d, t
+		}
+	}
+	return distance, ratio
+}
+
+// projectionCases are segments of about a kilometer running diagonally, with
+// a point off to one side, at increasing latitudes.
+var projectionCases = []struct {
+	name                             string
+	lat, lon, lat1, lon1, lat2, lon2 float64
+}{
+	{"equator", 0.003, 0.002, 0, 0, 0.006, 0.009},
+	{"redding", 40.593, -122.388, 40.590, -122.390, 40.596, -122.381},
+	{"anchorage", 61.223, -149.898, 61.220, -149.900, 61.226, -149.891},
+	{"tromso", 69.652, 18.958, 69.649, 18.956, 69.655, 18.965},
+}
+
+func TestProjectOntoSegment_Geodesic(t *testing.T) {
+	t.Cleanup(func() { SetGeodesicProjection(false) })
+
+	for _, tc := range projectionCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wantDistance, wantRatio := referenceProjection(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+
+			SetGeodesicProjection(false)
+			planarDistance, planarRatio, _, _ := ProjectOntoSegment(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			SetGeodesicProjection(true)
+			distance, ratio, closestLat, closestLon := ProjectOntoSegment(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+
+			assert.InDelta(t, wantRatio, ratio, 0.001)
+			assert.InDelta(t, wantDistance, distance, 0.5, "meters")
+			assert.InDelta(t, tc.lat1+ratio*(tc.lat2-tc.lat1), closestLat, 1e-12)
+			assert.InDelta(t, tc.lon1+ratio*(tc.lon2-tc.lon1), closestLon, 1e-12)
+			assert.LessOrEqual(t, math.Abs(ratio-wantRatio), math.Abs(planarRatio-wantRatio)+1e-9,
+				"the geodesic ratio is never worse than the planar one")
+			assert.LessOrEqual(t, distance, planarDistance+1e-9,
+				"the planar projection can only land further from the point")
+		})
+	}
+}
+
+func TestProjectOntoSegment_PlanarByDefault(t *testing.T) {
+	// Degrees are treated as planar: the point projects onto the middle of the
+	// diagonal although at 60 degrees north a degree of longitude is half as long.
+	distance, ratio, closestLat, closestLon := ProjectOntoSegment(60.5, 10.5, 60, 10, 61, 11)
+	assert.InDelta(t, 0.5, ratio, 1e-12)
+	assert.Equal(t, 60.5, closestLat)
+	assert.Equal(t, 10.5, closestLon)
+	assert.Zero(t, distance)
+
+	distance, ratio, closestLat, closestLon = ProjectOntoSegment(1, 1, 0, 0, 0, 0)
+	assert.Zero(t, ratio, "a zero-length segment projects onto its start")
+	assert.Equal(t, 0.0, closestLat)
+	assert.Equal(t, 0.0, closestLon)
+	assert.InDelta(t, Distance(1, 1, 0, 0), distance, 1e-9)
+}
+
+// BenchmarkProjectOntoSegment compares the speed of both projections and
+// reports the worst ratio error and distance error, in meters, across the
+// projectionCases.
+func BenchmarkProjectOntoSegment(b *testing.B) {
+	for _, geodesic := range []bool{false, true} {
+		name := "planar"
+		if geodesic {
+			name = "geodesic"
+		}
+		b.Run(name, func(b *testing.B) {
+			SetGeodesicProjection(geodesic)
+			defer SetGeodesicProjection(false)
+
+			var maxRatioError, maxDistanceError float64
+			for _, tc := range projectionCases {
+				wantDistance, wantRatio := referenceProjection(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+				distance, ratio, _, _ := ProjectOntoSegment(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+				maxRatioError = math.Max(maxRatioError, math.Abs(ratio-wantRatio))
+				maxDistanceError = math.Max(maxDistanceError, math.Abs(distance-wantDistance))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tc := projectionCases[i%len(projectionCases)]
+				_, _, _, _ = ProjectOntoSegment(tc.lat, tc.lon, tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			}
+			b.ReportMetric(maxRatioError, "max-ratio-error")
+			b.ReportMetric(maxDistanceError, "max-distance-error-m")
+		})
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"strings"
 )
 
-// ExtractIDFromParams retrieves a parameter value from the request context and removes file extensions like ".json".
+// ExtractIDFromParams retrieves a parameter value from the request context and removes file extensions like ".json" and ".xml".
 func ExtractIDFromParams(r *http.Request) string {
 	id := r.PathValue("id")
-	return strings.Split(id, ".json")[0]
+	return strings.TrimSuffix(strings.Split(id, ".json")[0], ".xml")
 }
@@ -30,6 +30,11 @@ func TestExtractIDFromParams(t *testing.T) {
 			id:   "789.data.json",
 			want: "789.data",
 		},
+		{
+			name: "ID with XML extension",
+			id:   "1_75403.xml",
+			want: "1_75403",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultIDSeparator joins the agency ID and the GTFS ID in the combined IDs
+// of the OneBusAway API, as in "25_1234".
+const DefaultIDSeparator = "_"
+
+// separatorPattern keeps separators to the punctuation ValidateID accepts, so
+// that every combined ID the API hands out can be requested back.
+var separatorPattern = regexp.MustCompile(`^[_.:-]+$`)
+
+// IDCodec converts between an agency ID and GTFS ID pair and the combined ID
+// the API exposes for it.
+type IDCodec struct {
+	// Separator joins the agency ID and the GTFS ID; empty uses DefaultIDSeparator.
+	Separator string
+	// Raw drops the agency prefix, for installs serving a single agency: IDs
+	// are the plain GTFS IDs and every ID parses as belonging to RawAgencyID.
+	Raw         bool
+	RawAgencyID string
+}
+
+var idCodec atomic.Pointer[IDCodec]
+
+// SetIDCodec makes codec the one FormCombinedID and the Extract functions use.
+// It is meant to be called once at startup, before requests are served.
+func SetIDCodec(codec IDCodec) error {
+	if err := codec.Validate(); err != nil {
+		return err
+	}
+	idCodec.Store(&codec)
+	return nil
+}
+
+// CurrentIDCodec returns the codec set with SetIDCodec, or the default
+// `{agency_id}_{code_id}` one.
+func CurrentIDCodec() IDCodec {
+	if codec := idCodec.Load(); codec != nil {
+		return *codec
+	}
+	return IDCodec{}
+}
+
+// Validate reports whether the codec can form IDs that parse back.
+func (c IDCodec) Validate() error {
+	if c.Raw {
+		if c.RawAgencyID == "" {
+			return errors.New("raw IDs need the agency ID they belong to")
+		}
+		return nil
+	}
+	if c.Separator != "" && !separatorPattern.MatchString(c.Separator) {
+		return fmt.Errorf("ID separator %q must be made of the characters _ . : -", c.Separator)
+	}
+	return nil
+}
+
+func (c IDCodec) separator() string {
+	if c.Separator == "" {
+		return DefaultIDSeparator
+	}
+	return c.Separator
+}
+
+// Form returns the combined ID of codeID within agencyID, or "" if either is
+// empty.
+func (c IDCodec) Form(agencyID, codeID string) string {
+	if codeID == "" || agencyID == "" {
+		return ""
+	}
+	if c.Raw {
+		return codeID
+	}
+	return agencyID + c.separator() + codeID
+}
+
+// Parse splits a combined ID into its agency ID and GTFS ID. The first
+// separator ends the agency ID, so GTFS IDs may contain the separator.
+func (c IDCodec) Parse(combinedID string) (agencyID, codeID string, err error) {
+	if c.Raw {
+		if combinedID == "" {
+			return "", "", fmt.Errorf("invalid format: %s", combinedID)
+		}
+		return c.RawAgencyID, combinedID, nil
+	}
+	agencyID, codeID, ok := strings.Cut(combinedID, c.separator())
+	if !ok {
+		return "", "", fmt.Errorf("invalid format: %s", combinedID)
+	}
+	return agencyID, codeID, nil
+}
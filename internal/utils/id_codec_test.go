@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDCodecRoundTrip(t *testing.T) {
+	codecs := map[string]IDCodec{
+		"default":   {},
+		"colon":     {Separator: ":"},
+		"two chars": {Separator: "::"},
+		"raw":       {Raw: true, RawAgencyID: "25"},
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, codec.Validate())
+			for _, codeID := range []string{"1234", "a_b", "x:y:z", "route.1-2"} {
+				combinedID := codec.Form("25", codeID)
+				require.NoError(t, ValidateID(combinedID), "formed IDs must be accepted back")
+				agencyID, parsedCode, err := codec.Parse(combinedID)
+				require.NoError(t, err)
+				assert.Equal(t, "25", agencyID)
+				assert.Equal(t, codeID, parsedCode, "IDs containing the separator survive the round trip")
+			}
+		})
+	}
+}
+
+func TestIDCodecFormats(t *testing.T) {
+	assert.Equal(t, "25_1234", IDCodec{}.Form("25", "1234"))
+	assert.Equal(t, "25:1234", IDCodec{Separator: ":"}.Form("25", "1234"))
+	assert.Equal(t, "1234", IDCodec{Raw: true, RawAgencyID: "25"}.Form("25", "1234"))
+	assert.Empty(t, IDCodec{Raw: true, RawAgencyID: "25"}.Form("", "1234"))
+
+	_, _, err := IDCodec{Separator: ":"}.Parse("25_1234")
+	assert.Error(t, err, "the default separator is not accepted once another is set")
+	_, _, err = IDCodec{Raw: true, RawAgencyID: "25"}.Parse("")
+	assert.Error(t, err)
+}
+
+func TestIDCodecValidate(t *testing.T) {
+	assert.Error(t, IDCodec{Separator: "/"}.Validate())
+	assert.Error(t, IDCodec{Separator: "x"}.Validate())
+	assert.Error(t, IDCodec{Raw: true}.Validate(), "raw IDs need an agency")
+}
+
+func TestSetIDCodec(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetIDCodec(IDCodec{})) })
+
+	require.NoError(t, SetIDCodec(IDCodec{Separator: "-"}))
+	assert.Equal(t, "25-1234", FormCombinedID("25", "1234"))
+	agencyID, codeID, err := ExtractAgencyIDAndCodeID("25-12-34")
+	require.NoError(t, err)
+	assert.Equal(t, "25", agencyID)
+	assert.Equal(t, "12-34", codeID)
+
+	assert.Error(t, SetIDCodec(IDCodec{Separator: "/"}))
+	assert.Equal(t, "-", CurrentIDCodec().Separator, "an invalid codec leaves the current one in place")
+}
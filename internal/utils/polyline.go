@@ -0,0 +1,22 @@
+package utils
+
+import "github.com/twpayne/go-polyline"
+
+// EncodePolyline encodes a sequence of [lat, lon] pairs using Google's encoded
+// polyline algorithm (5 decimal places of precision), as used by the OBA API
+// for shape and route geometry.
+func EncodePolyline(coords [][]float64) string {
+	if len(coords) == 0 {
+		return ""
+	}
+	return string(polyline.EncodeCoords(coords))
+}
+
+// DecodePolyline decodes a Google encoded polyline into [lat, lon] pairs.
+func DecodePolyline(encoded string) ([][]float64, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	coords, _, err := polyline.DecodeCoords([]byte(encoded))
+	return coords, err
+}
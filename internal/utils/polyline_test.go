@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	// Example from Google's polyline algorithm documentation.
+	coords := [][]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	assert.Equal(t, "_p~iF~ps|U_ulLnnqC_mqNvxq`@", EncodePolyline(coords))
+	assert.Equal(t, "", EncodePolyline(nil))
+}
+
+func TestDecodePolyline(t *testing.T) {
+	coords, err := DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	require.NoError(t, err)
+	require.Len(t, coords, 3)
+	assert.InDelta(t, 43.252, coords[2][0], 1e-9)
+	assert.InDelta(t, -126.453, coords[2][1], 1e-9)
+
+	coords, err = DecodePolyline("")
+	require.NoError(t, err)
+	assert.Empty(t, coords)
+
+	_, err = DecodePolyline("_p~iF~ps|U_")
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ServiceTime is a time of day on a GTFS service day: the time elapsed since
+// the service date's midnight. Trips that run past midnight have service
+// times beyond 24:00:00 on the day they started rather than wrapping around,
+// so a moment in time can fall on more than one service day.
+type ServiceTime time.Duration
+
+// NewServiceTime returns the service time of a stop_times arrival or
+// departure, which the database stores as seconds.
+func NewServiceTime(seconds int64) ServiceTime {
+	return ServiceTime(StopTimeDuration(seconds))
+}
+
+// ServiceTimeAt returns the service time of t on the service day starting at
+// serviceDate. It is negative before the service date and exceeds 24 hours
+// after it.
+func ServiceTimeAt(serviceDate, t time.Time) ServiceTime {
+	return ServiceTime(t.Sub(serviceDate))
+}
+
+// On returns the moment the service time falls on the service day starting at
+// serviceDate.
+func (s ServiceTime) On(serviceDate time.Time) time.Time {
+	return serviceDate.Add(time.Duration(s))
+}
+
+// Seconds returns the service time in whole seconds, as stop_times stores it.
+func (s ServiceTime) Seconds() int64 {
+	return int64(time.Duration(s) / time.Second)
+}
+
+// String formats the service time as GTFS does, e.g. "25:10:00".
+func (s ServiceTime) String() string {
+	sign := ""
+	seconds := s.Seconds()
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+}
+
+// ServiceDatesBetween returns the midnight of every service date whose service
+// day may have trips running between start and end, earliest first, in
+// start's location. maxServiceTime is the latest service time in the feed: a
+// feed whose trips end by 24:00:00 only needs the calendar dates of start and
+// end, while one running until 26:00:00 also needs the date before.
+func ServiceDatesBetween(start, end time.Time, maxServiceTime ServiceTime) []time.Time {
+	dates := serviceDatesLatestFirst(start, end, maxServiceTime)
+	slices.Reverse(dates)
+	return dates
+}
+
+// ServiceDatesAt returns the midnight of every service date whose service day
+// may have trips running at t, latest first.
+func ServiceDatesAt(t time.Time, maxServiceTime ServiceTime) []time.Time {
+	return serviceDatesLatestFirst(t, t, maxServiceTime)
+}
+
+func serviceDatesLatestFirst(start, end time.Time, maxServiceTime ServiceTime) []time.Time {
+	firstCalendarDate := CalculateServiceDate(start)
+	var dates []time.Time
+	for date := CalculateServiceDate(end.In(start.Location())); ; date = date.AddDate(0, 0, -1) {
+		if date.Before(firstCalendarDate) && maxServiceTime.On(date).Before(start) {
+			return dates
+		}
+		dates = append(dates, date)
+	}
+}
+
+// ServiceDateAt returns the midnight, in loc, of the calendar date t falls on
+// in loc. A trip's service dates are calendar dates in the timezone of the
+// agency operating it, which can differ from the zone of the stop or agency a
+// request is about.
+func ServiceDateAt(t time.Time, loc *time.Location) time.Time {
+	return CalculateServiceDate(t.In(loc))
+}
+
+// MidnightIn returns the midnight, in loc, of the calendar date date falls on
+// in its own location. It carries a date already chosen in one timezone, such
+// as a date a request names, over to another.
+func MidnightIn(date time.Time, loc *time.Location) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceTime(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	serviceDate := time.Date(2025, 6, 12, 0, 0, 0, 0, loc)
+
+	overnight := NewServiceTime(25*3600 + 10*60)
+	assert.Equal(t, "25:10:00", overnight.String())
+	assert.Equal(t, int64(25*3600+600), overnight.Seconds())
+	assert.Equal(t, time.Date(2025, 6, 13, 1, 10, 0, 0, loc), overnight.On(serviceDate))
+	assert.Equal(t, overnight, ServiceTimeAt(serviceDate, time.Date(2025, 6, 13, 1, 10, 0, 0, loc)))
+
+	before := ServiceTimeAt(serviceDate, time.Date(2025, 6, 11, 23, 30, 0, 0, loc))
+	assert.Equal(t, "-00:30:00", before.String())
+}
+
+func TestServiceDates(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, loc) }
+	at := func(d, h, m int) time.Time { return time.Date(2025, 6, d, h, m, 0, 0, loc) }
+
+	tests := []struct {
+		name           string
+		start, end     time.Time
+		maxServiceTime time.Duration
+		expected       []time.Time
+	}{
+		{"daytime feed after midnight", at(13, 0, 30), at(13, 1, 0), 22 * time.Hour, []time.Time{day(13)}},
+		{"overnight feed after midnight", at(13, 0, 30), at(13, 1, 0), 26 * time.Hour, []time.Time{day(12), day(13)}},
+		{"overnight feed past its last trip", at(13, 3, 0), at(13, 4, 0), 26 * time.Hour, []time.Time{day(13)}},
+		{"window crossing midnight", at(12, 23, 30), at(13, 0, 30), 22 * time.Hour, []time.Time{day(12), day(13)}},
+		{"two-day feed", at(13, 0, 30), at(13, 0, 30), 49 * time.Hour, []time.Time{day(11), day(12), day(13)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := ServiceDatesBetween(tt.start, tt.end, ServiceTime(tt.maxServiceTime))
+			assert.Equal(t, tt.expected, dates)
+		})
+	}
+
+	assert.Equal(t, []time.Time{day(13), day(12)}, ServiceDatesAt(at(13, 0, 30), ServiceTime(26*time.Hour)))
+}
+
+func TestServiceDateAcrossTimezones(t *testing.T) {
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	// 22:30 in Los Angeles is already the next day in New York.
+	late := time.Date(2025, 6, 12, 22, 30, 0, 0, losAngeles)
+	assert.Equal(t, time.Date(2025, 6, 12, 0, 0, 0, 0, losAngeles), ServiceDateAt(late, losAngeles))
+	assert.Equal(t, time.Date(2025, 6, 13, 0, 0, 0, 0, newYork), ServiceDateAt(late, newYork))
+
+	// A date named in one zone keeps its calendar date in another.
+	named := time.Date(2025, 6, 12, 0, 0, 0, 0, newYork)
+	assert.Equal(t, time.Date(2025, 6, 12, 0, 0, 0, 0, losAngeles), MidnightIn(named, losAngeles))
+}
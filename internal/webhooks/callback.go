@@ -0,0 +1,84 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for callbacks that resolve to an address
+// inside the server's own networks.
+var ErrForbiddenAddress = errors.New("callback address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP does not
+// count as private but which is no more reachable from outside.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CallbackPolicy decides which callback URLs subscriptions may name and which
+// addresses notifications may be delivered to. Loopback, private, link-local
+// (including cloud metadata services at 169.254.169.254) and other
+// non-public addresses are refused, so that API key holders cannot use the
+// server to reach its own network.
+type CallbackPolicy struct {
+	// AllowedHosts, when not empty, are the only hosts callbacks may name,
+	// compared without port and case.
+	AllowedHosts []string
+	// AllowPrivateAddresses lets callbacks reach non-public addresses. Tests
+	// set it to deliver to local servers.
+	AllowPrivateAddresses bool
+}
+
+// CheckURL parses a callback URL when subscribing. It must be an absolute
+// http or https URL whose host is allowed and, if it is an IP address, public.
+// Host names are checked again for each delivery, once resolved.
+func (p CallbackPolicy) CheckURL(raw string) (*url.URL, error) {
+	callback, err := url.Parse(raw)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		return nil, errors.New("must be an absolute http or https URL")
+	}
+	host := callback.Hostname()
+	if len(p.AllowedHosts) > 0 && !p.hostAllowed(host) {
+		return nil, errors.New("host is not among the allowed callback hosts")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !p.addressAllowed(addr) {
+		return nil, ErrForbiddenAddress
+	}
+	return callback, nil
+}
+
+func (p CallbackPolicy) hostAllowed(host string) bool {
+	for _, allowed := range p.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CallbackPolicy) addressAllowed(addr netip.Addr) bool {
+	return p.AllowPrivateAddresses || isPublicAddress(addr)
+}
+
+// control is the net.Dialer.Control hook of deliveries. It runs on the
+// address actually connected to, after name resolution, so a host name that
+// resolves to an internal address is refused however often it changes.
+func (p CallbackPolicy) control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q: %w", address, err)
+	}
+	if !p.addressAllowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// isPublicAddress reports whether addr is a global unicast address, which
+// excludes loopback and link-local ones, outside the private and shared ranges.
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestCallbackPolicyCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://rider.example/hook", true},
+		{"http://93.184.216.34:8080/hook", true},
+		{"http://[2606:4700::1111]/hook", true},
+		{"ftp://rider.example/hook", false},
+		{"/hook", false},
+		{"http://127.0.0.1/hook", false},
+		{"http://127.0.0.1:4000/hook", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://172.16.3.4/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+	}
+	for _, tt := range tests {
+		_, err := CallbackPolicy{}.CheckURL(tt.url)
+		assert.Equal(t, tt.allowed, err == nil, tt.url)
+	}
+
+	_, err := CallbackPolicy{AllowPrivateAddresses: true}.CheckURL("http://127.0.0.1/hook")
+	assert.NoError(t, err)
+}
+
+func TestCallbackPolicyAllowedHosts(t *testing.T) {
+	policy := CallbackPolicy{AllowedHosts: []string{"hooks.rider.example"}}
+	_, err := policy.CheckURL("https://Hooks.Rider.Example:8443/arrivals")
+	assert.NoError(t, err)
+	_, err = policy.CheckURL("https://elsewhere.example/arrivals")
+	assert.Error(t, err)
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	defer receiver.Close()
+
+	// The default policy refuses the connection itself, whatever name led to it.
+	d := NewDispatcher(clock.RealClock{}, CallbackPolicy{})
+	d.retryDelay = time.Millisecond
+	retry, err := d.post(delivery{sub: Subscription{ID: "sub-1", CallbackURL: receiver.URL}, body: []byte("{}")})
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, retry, "refused addresses are not retried")
+	d.Close()
+	assert.Zero(t, attempts.Load())
+}
@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"maglev.onebusaway.org/internal/clock"
+	"maglev.onebusaway.org/internal/logging"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256, keyed with the
+	// subscription's secret, of the timestamp header, a period and the body.
+	SignatureHeader = "X-Maglev-Signature"
+	// TimestampHeader is when the notification was signed, in seconds since
+	// the epoch. Receivers can reject old timestamps to refuse replays.
+	TimestampHeader = "X-Maglev-Timestamp"
+
+	// maxAttempts bounds how often one notification is tried.
+	maxAttempts = 4
+	// defaultRetryDelay is the wait before the first retry; it doubles after each.
+	defaultRetryDelay = 2 * time.Second
+	// deliveryTimeout bounds each attempt, so that a slow receiver cannot hold
+	// up the others for long.
+	deliveryTimeout = 10 * time.Second
+	// queueSize is how many notifications may wait for delivery; more are dropped.
+	queueSize = 256
+	// deliveryWorkers is how many notifications are delivered at once.
+	deliveryWorkers = 4
+)
+
+// Sign returns the value of SignatureHeader for a body signed at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type delivery struct {
+	sub  Subscription
+	body []byte
+}
+
+// Dispatcher delivers notifications in the background. Send queues them and
+// returns at once; a pool of workers POSTs them and retries failures.
+type Dispatcher struct {
+	client     *http.Client
+	logger     *slog.Logger
+	clock      clock.Clock
+	retryDelay time.Duration
+
+	queue     chan delivery
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewDispatcher starts a dispatcher that timestamps notifications with c and
+// only connects to the addresses policy allows.
+func NewDispatcher(c clock.Clock, policy CallbackPolicy) *Dispatcher {
+	dialer := &net.Dialer{Timeout: deliveryTimeout, Control: policy.control}
+	d := &Dispatcher{
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+			// No proxy: the policy must see the receiver's address, not a proxy's.
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: deliveryTimeout,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// A redirect is treated as the receiver's answer rather than
+			// followed, so that notifications only go where they were sent.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger:     slog.Default().With(slog.String("component", "webhooks")),
+		clock:      c,
+		retryDelay: defaultRetryDelay,
+		queue:      make(chan delivery, queueSize),
+		stop:       make(chan struct{}),
+	}
+	d.wg.Add(deliveryWorkers)
+	for range deliveryWorkers {
+		go d.work()
+	}
+	return d
+}
+
+// Send queues body for delivery to the subscription's callback URL. It
+// reports false when the queue is full or the dispatcher is closed, in which
+// case the notification is dropped.
+func (d *Dispatcher) Send(sub Subscription, body []byte) bool {
+	select {
+	case <-d.stop:
+		return false
+	default:
+	}
+	select {
+	case d.queue <- delivery{sub: sub, body: body}:
+		return true
+	default:
+		d.logger.Warn("webhook queue full, dropping notification", "subscriptionId", sub.ID)
+		return false
+	}
+}
+
+// Close stops the dispatcher. Attempts in progress finish; queued
+// notifications and pending retries are dropped.
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case next := <-d.queue:
+			d.deliver(next)
+		}
+	}
+}
+
+// deliver POSTs a notification until the receiver accepts it, refuses it with
+// a client error other than 429, or maxAttempts is reached.
+func (d *Dispatcher) deliver(next delivery) {
+	delay := d.retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(next)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			d.logger.Warn("webhook delivery failed",
+				"subscriptionId", next.sub.ID,
+				"attempts", attempt,
+				"error", err)
+			return
+		}
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post makes one attempt at a delivery. It reports whether a failure is worth
+// retrying.
+func (d *Dispatcher) post(next delivery) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, next.sub.CallbackURL, bytes.NewReader(next.body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := d.clock.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(next.sub.Secret, timestamp, next.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrForbiddenAddress), err
+	}
+	defer logging.SafeCloseWithLogging(resp.Body, d.logger, "webhook_response_body")
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maglev.onebusaway.org/internal/clock"
+)
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	c := clock.NewMockClock(time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC))
+	sub := Subscription{ID: "sub-1", Secret: "s3cret"}
+	body := []byte(`{"stopId":"25_2042"}`)
+
+	var attempts atomic.Int32
+	delivered := make(chan *http.Request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		assert.Equal(t, body, got)
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered <- r
+	}))
+	defer receiver.Close()
+	sub.CallbackURL = receiver.URL
+
+	d := NewDispatcher(c, CallbackPolicy{AllowPrivateAddresses: true})
+	defer d.Close()
+	d.retryDelay = time.Millisecond
+
+	require.True(t, d.Send(sub, body))
+	select {
+	case r := <-delivered:
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, c.Now().Unix(), timestamp)
+		assert.Equal(t, Sign("s3cret", timestamp, body), r.Header.Get(SignatureHeader))
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestDispatcherDoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	done := make(chan struct{}, maxAttempts)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusGone)
+		done <- struct{}{}
+	}))
+	defer receiver.Close()
+
+	d := NewDispatcher(clock.RealClock{}, CallbackPolicy{AllowPrivateAddresses: true})
+	d.retryDelay = time.Millisecond
+	require.True(t, d.Send(Subscription{ID: "sub-1", CallbackURL: receiver.URL}, []byte("{}")))
+
+	<-done
+	// Give a retry, were there one, the time to arrive.
+	time.Sleep(50 * time.Millisecond)
+	d.Close()
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestDispatcherSendAfterClose(t *testing.T) {
+	d := NewDispatcher(clock.RealClock{}, CallbackPolicy{AllowPrivateAddresses: true})
+	d.Close()
+	assert.False(t, d.Send(Subscription{ID: "sub-1", CallbackURL: "http://127.0.0.1:1"}, []byte("{}")))
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" keyed with "key".
+	assert.Equal(t,
+		"sha256=9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae",
+		Sign("key", 1700000000, []byte("{}")))
+}
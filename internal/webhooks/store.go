@@ -0,0 +1,182 @@
+// Package webhooks keeps the arrival subscriptions clients register and
+// delivers their notifications. Each notification is POSTed as JSON to the
+// subscription's callback URL, signed with an HMAC of the subscription's
+// secret, and retried with backoff while the receiver fails.
+//
+// Subscriptions are held in memory only: they are lost on restart and lapse at
+// their expiry time, so clients renew them as they would any other lease.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLimitReached is returned when an API key already holds the most
+// subscriptions the store allows it.
+var ErrLimitReached = errors.New("subscription limit reached")
+
+// Subscription asks for a notification whenever a vehicle is due at a stop
+// within Threshold.
+type Subscription struct {
+	ID string
+	// APIKey is the key the subscription was created with; only requests made
+	// with it see the subscription.
+	APIKey      string
+	CallbackURL string
+	// AgencyID and RouteAgencyID are the agencies of the stop and the route;
+	// StopID and RouteID are feed IDs without an agency prefix. An empty
+	// RouteID matches every route.
+	AgencyID      string
+	StopID        string
+	RouteAgencyID string
+	RouteID       string
+	// Threshold is how long before an arrival its notification is sent.
+	Threshold time.Duration
+	// Secret is the HMAC key the notifications are signed with.
+	Secret    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (s Subscription) expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+type storedSubscription struct {
+	Subscription
+	// notified holds the arrivals already notified, each until it can no
+	// longer come up again.
+	notified map[string]time.Time
+}
+
+// Store holds the subscriptions in force. It is safe for concurrent use.
+type Store struct {
+	maxPerKey int
+
+	mu   sync.Mutex
+	subs map[string]*storedSubscription
+}
+
+// NewStore returns an empty store that allows each API key up to maxPerKey
+// subscriptions.
+func NewStore(maxPerKey int) *Store {
+	return &Store{
+		maxPerKey: maxPerKey,
+		subs:      make(map[string]*storedSubscription),
+	}
+}
+
+// Add stores a subscription under a new random ID and secret and returns it.
+func (s *Store) Add(sub Subscription, now time.Time) (Subscription, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return Subscription{}, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.ID = id
+	sub.Secret = secret
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropExpired(now)
+	count := 0
+	for _, stored := range s.subs {
+		if stored.APIKey == sub.APIKey {
+			count++
+		}
+	}
+	if count >= s.maxPerKey {
+		return Subscription{}, ErrLimitReached
+	}
+	s.subs[sub.ID] = &storedSubscription{Subscription: sub, notified: make(map[string]time.Time)}
+	return sub, nil
+}
+
+// Get returns the subscription with the ID if it is in force at now.
+func (s *Store) Get(id string, now time.Time) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.subs[id]
+	if !ok || stored.expired(now) {
+		return Subscription{}, false
+	}
+	return stored.Subscription, true
+}
+
+// Remove drops a subscription, reporting whether it was stored.
+func (s *Store) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.subs[id]
+	delete(s.subs, id)
+	return ok
+}
+
+// List returns the subscriptions in force at now, ordered by ID. Expired ones
+// are dropped along the way.
+func (s *Store) List(now time.Time) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropExpired(now)
+	subs := make([]Subscription, 0, len(s.subs))
+	for _, stored := range s.subs {
+		subs = append(subs, stored.Subscription)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// MarkNotified records that the subscription was notified of the arrival
+// identified by key, remembering it until keepUntil. It reports whether the
+// arrival is new, so that each arrival is notified once however often it is
+// checked.
+func (s *Store) MarkNotified(id, key string, keepUntil, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.subs[id]
+	if !ok {
+		return false
+	}
+	for k, until := range stored.notified {
+		if !now.Before(until) {
+			delete(stored.notified, k)
+		}
+	}
+	if _, seen := stored.notified[key]; seen {
+		return false
+	}
+	stored.notified[key] = keepUntil
+	return true
+}
+
+// dropExpired removes the subscriptions that have expired. Caller must hold mu.
+func (s *Store) dropExpired(now time.Time) {
+	for id, stored := range s.subs {
+		if stored.expired(now) {
+			delete(s.subs, id)
+		}
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,75 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreAddGetRemove(t *testing.T) {
+	now := time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC)
+	store := NewStore(2)
+
+	sub, err := store.Add(Subscription{
+		APIKey:    "rider",
+		StopID:    "2042",
+		Threshold: 5 * time.Minute,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, now)
+	require.NoError(t, err)
+	assert.Len(t, sub.ID, 32)
+	assert.Len(t, sub.Secret, 64)
+
+	found, ok := store.Get(sub.ID, now)
+	require.True(t, ok)
+	assert.Equal(t, sub, found)
+
+	_, ok = store.Get(sub.ID, now.Add(time.Hour))
+	assert.False(t, ok, "expired subscriptions are not returned")
+
+	assert.True(t, store.Remove(sub.ID))
+	assert.False(t, store.Remove(sub.ID))
+	assert.Empty(t, store.List(now))
+}
+
+func TestStoreLimitPerKey(t *testing.T) {
+	now := time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC)
+	store := NewStore(1)
+	sub := Subscription{APIKey: "rider", ExpiresAt: now.Add(time.Hour)}
+
+	_, err := store.Add(sub, now)
+	require.NoError(t, err)
+	_, err = store.Add(sub, now)
+	assert.ErrorIs(t, err, ErrLimitReached)
+
+	other := sub
+	other.APIKey = "other"
+	_, err = store.Add(other, now)
+	assert.NoError(t, err, "the limit is per API key")
+
+	// Once the first subscription lapses its key may subscribe again.
+	later := now.Add(time.Hour)
+	sub.ExpiresAt = later.Add(time.Hour)
+	_, err = store.Add(sub, later)
+	assert.NoError(t, err)
+}
+
+func TestStoreMarkNotified(t *testing.T) {
+	now := time.Date(2025, 6, 13, 12, 0, 0, 0, time.UTC)
+	store := NewStore(1)
+	sub, err := store.Add(Subscription{APIKey: "rider", ExpiresAt: now.Add(time.Hour)}, now)
+	require.NoError(t, err)
+
+	keepUntil := now.Add(10 * time.Minute)
+	assert.True(t, store.MarkNotified(sub.ID, "trip-1", keepUntil, now))
+	assert.False(t, store.MarkNotified(sub.ID, "trip-1", keepUntil, now.Add(time.Minute)))
+	assert.True(t, store.MarkNotified(sub.ID, "trip-2", keepUntil, now))
+
+	// An arrival is forgotten once its keepUntil has passed.
+	assert.True(t, store.MarkNotified(sub.ID, "trip-1", keepUntil.Add(time.Hour), keepUntil))
+
+	assert.False(t, store.MarkNotified("missing", "trip-1", keepUntil, now))
+}
@@ -0,0 +1,12 @@
+-- Service-day times were stored as nanoseconds since midnight.
+UPDATE stop_times
+SET arrival_time = arrival_time / 1000000000,
+    departure_time = departure_time / 1000000000;
+
+UPDATE frequencies
+SET start_time = start_time / 1000000000,
+    end_time = end_time / 1000000000;
+
+UPDATE flex_stop_times
+SET start_pickup_drop_off_window = start_pickup_drop_off_window / 1000000000,
+    end_pickup_drop_off_window = end_pickup_drop_off_window / 1000000000;
@@ -0,0 +1,2 @@
+ALTER TABLE block_trip_entry DROP COLUMN block_distance;
+ALTER TABLE block_trip_entry DROP COLUMN shape_length;
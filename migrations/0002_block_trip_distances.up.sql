@@ -0,0 +1,7 @@
+-- Shape lengths and accumulated block distances are filled in by the next
+-- static import; until then they are NULL and are computed per request.
+ALTER TABLE block_trip_entry ADD COLUMN shape_length REAL;
+ALTER TABLE block_trip_entry ADD COLUMN block_distance REAL;
+
+-- An unchanged feed is only imported again once its hash is forgotten.
+UPDATE import_metadata SET file_hash = '';
@@ -0,0 +1,2 @@
+ALTER TABLE stop_times DROP COLUMN distance_along_shape;
+ALTER TABLE shapes DROP COLUMN distance_along_shape;
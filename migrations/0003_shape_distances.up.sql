@@ -0,0 +1,7 @@
+-- Distances along shapes are filled in by the next static import, which
+-- clearing the feed's hash brings forward to the next start; until then they
+-- are NULL and are computed per request.
+ALTER TABLE shapes ADD COLUMN distance_along_shape REAL;
+ALTER TABLE stop_times ADD COLUMN distance_along_shape REAL;
+
+UPDATE import_metadata SET file_hash = '';
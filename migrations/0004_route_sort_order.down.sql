@@ -0,0 +1,2 @@
+ALTER TABLE routes DROP COLUMN network_id;
+ALTER TABLE routes DROP COLUMN sort_order;
@@ -0,0 +1,6 @@
+-- Sort orders and networks are filled in when the feed is imported again on
+-- the next start, as its hash is cleared; until then routes are listed by ID.
+ALTER TABLE routes ADD COLUMN sort_order INTEGER;
+ALTER TABLE routes ADD COLUMN network_id TEXT;
+
+UPDATE import_metadata SET file_hash = '';
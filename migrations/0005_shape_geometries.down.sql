@@ -0,0 +1,16 @@
+-- Give every shape sharing another's geometry its own copy of the points.
+INSERT INTO shapes (shape_id, lat, lon, shape_pt_sequence, shape_dist_traveled, distance_along_shape)
+SELECT
+    g.shape_id,
+    s.lat,
+    s.lon,
+    s.shape_pt_sequence,
+    s.shape_dist_traveled,
+    s.distance_along_shape
+FROM
+    shape_geometries g
+    JOIN shapes s ON s.shape_id = g.geometry_id
+WHERE
+    g.shape_id != g.geometry_id;
+
+DELETE FROM shape_geometries;
@@ -0,0 +1,9 @@
+-- Every shape becomes its own geometry; the next static import shares the
+-- geometries of shapes with the same points.
+INSERT
+OR IGNORE INTO shape_geometries (shape_id, geometry_id)
+SELECT DISTINCT
+    shape_id,
+    shape_id
+FROM
+    shapes;
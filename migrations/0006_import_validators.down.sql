@@ -0,0 +1,2 @@
+ALTER TABLE import_metadata DROP COLUMN last_modified;
+ALTER TABLE import_metadata DROP COLUMN etag;
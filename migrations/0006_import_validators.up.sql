@@ -0,0 +1,4 @@
+-- Feeds imported before validators were stored are downloaded unconditionally
+-- once more.
+ALTER TABLE import_metadata ADD COLUMN etag TEXT;
+ALTER TABLE import_metadata ADD COLUMN last_modified TEXT;
@@ -0,0 +1,34 @@
+DROP TRIGGER IF EXISTS stops_fts_insert_trigger;
+DROP TRIGGER IF EXISTS stops_fts_update_trigger;
+DROP TRIGGER IF EXISTS stops_fts_delete_trigger;
+DROP TABLE IF EXISTS stops_fts;
+
+CREATE VIRTUAL TABLE stops_fts USING fts5(
+    id UNINDEXED,
+    stop_name,
+    tokenize = 'porter'
+);
+
+CREATE TRIGGER stops_fts_insert_trigger
+AFTER INSERT ON stops
+BEGIN
+    INSERT INTO stops_fts (rowid, id, stop_name)
+    VALUES (new.rowid, new.id, new.name);
+END;
+
+CREATE TRIGGER stops_fts_update_trigger
+AFTER UPDATE ON stops
+BEGIN
+    DELETE FROM stops_fts WHERE rowid = old.rowid;
+    INSERT INTO stops_fts (rowid, id, stop_name)
+    VALUES (new.rowid, new.id, new.name);
+END;
+
+CREATE TRIGGER stops_fts_delete_trigger
+AFTER DELETE ON stops
+BEGIN
+    DELETE FROM stops_fts WHERE rowid = old.rowid;
+END;
+
+INSERT INTO stops_fts (rowid, id, stop_name)
+SELECT rowid, id, name FROM stops;
@@ -0,0 +1,41 @@
+-- Stop search indexed names only, in a standalone table. The index now reads
+-- names and codes from the stops table, so it and its triggers are recreated
+-- and rebuilt from it.
+DROP TRIGGER IF EXISTS stops_fts_insert_trigger;
+DROP TRIGGER IF EXISTS stops_fts_update_trigger;
+DROP TRIGGER IF EXISTS stops_fts_delete_trigger;
+DROP TABLE IF EXISTS stops_fts;
+
+CREATE VIRTUAL TABLE stops_fts USING fts5(
+    id UNINDEXED,
+    name,
+    code,
+    content = 'stops',
+    content_rowid = 'rowid',
+    tokenize = 'porter'
+);
+
+CREATE TRIGGER stops_fts_insert_trigger
+AFTER INSERT ON stops
+BEGIN
+    INSERT INTO stops_fts (rowid, id, name, code)
+    VALUES (new.rowid, new.id, coalesce(new.name, ''), coalesce(new.code, ''));
+END;
+
+CREATE TRIGGER stops_fts_update_trigger
+AFTER UPDATE ON stops
+BEGIN
+    INSERT INTO stops_fts (stops_fts, rowid, id, name, code)
+    VALUES ('delete', old.rowid, old.id, coalesce(old.name, ''), coalesce(old.code, ''));
+    INSERT INTO stops_fts (rowid, id, name, code)
+    VALUES (new.rowid, new.id, coalesce(new.name, ''), coalesce(new.code, ''));
+END;
+
+CREATE TRIGGER stops_fts_delete_trigger
+AFTER DELETE ON stops
+BEGIN
+    INSERT INTO stops_fts (stops_fts, rowid, id, name, code)
+    VALUES ('delete', old.rowid, old.id, coalesce(old.name, ''), coalesce(old.code, ''));
+END;
+
+INSERT INTO stops_fts(stops_fts) VALUES ('rebuild');
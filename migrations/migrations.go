@@ -0,0 +1,14 @@
+// Package migrations holds the versioned changes that bring GTFS databases
+// written by earlier releases up to the schema in gtfsdb/schema.sql.
+//
+// Each migration is a pair of files named NNNN_description.up.sql and
+// NNNN_description.down.sql, where NNNN is its version. Versions are applied in
+// order and must never be renumbered or edited once released.
+package migrations
+
+import "embed"
+
+// Files contains every migration file.
+//
+//go:embed *.sql
+var Files embed.FS